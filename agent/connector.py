@@ -0,0 +1,216 @@
+#!/usr/bin/env python3
+"""
+Chat platform connectors of an agent.
+
+Runs as the connectors sidecar of agents with spec.connectors. Each connector is
+configured by CONNECTORS_DIR/<name>/config.json, next to its credentials, and bridges a
+Slack workspace over Socket Mode or a Discord bot over the gateway to the agent at
+AGENT_URL: the messages that trigger the agent are sent to its /chat endpoint, with the
+channel or thread as the conversation, and the replies are posted in the thread of the
+message.
+
+A connector rejected by its platform, for example for an expired token, is retried with
+backoff and never stops the sidecar. The state of the connectors is served on
+CONNECTOR_STATUS_PORT at /status, which the operator reads for the ConnectorsReady
+condition.
+"""
+
+import asyncio
+import glob
+import json
+import logging
+import os
+import re
+from typing import Any, Dict, Optional
+
+import httpx
+from aiohttp import web
+
+CONNECTORS_DIR = os.getenv("CONNECTORS_DIR", "/etc/kubeagentic/connectors")
+AGENT_URL = os.getenv("AGENT_URL", "http://127.0.0.1:8080")
+ENDPOINT_TOKEN = os.getenv("AGENT_ENDPOINT_TOKEN", "")
+STATUS_PORT = int(os.getenv("CONNECTOR_STATUS_PORT", "8090"))
+MIN_BACKOFF_SECONDS = 5
+MAX_BACKOFF_SECONDS = 300
+# Discord rejects messages longer than this
+DISCORD_MAX_MESSAGE = 2000
+
+logging.basicConfig(level=os.getenv("LOG_LEVEL", "INFO").upper())
+logger = logging.getLogger("connector")
+
+# State of each connector, by name, as served on /status
+states: Dict[str, Dict[str, str]] = {}
+
+
+def set_state(config: Dict[str, Any], state: str, error: str = ""):
+    states[config["name"]] = {"name": config["name"], "type": config["type"], "state": state}
+    if error:
+        states[config["name"]]["error"] = error
+
+
+def read_token(path: str) -> str:
+    try:
+        with open(path) as f:
+            token = f.read().strip()
+    except FileNotFoundError:
+        raise RuntimeError(f"credentials {os.path.basename(path)} not found in the Secret")
+    if not token:
+        raise RuntimeError(f"credentials {os.path.basename(path)} are empty")
+    return token
+
+
+def triggered(config: Dict[str, Any], text: str, mentioned: bool, direct: bool) -> Optional[str]:
+    """Returns the message to send to the agent, or None when the message does not trigger it."""
+    if direct:
+        return text if config["directMessages"] else None
+    if mentioned and config["mention"]:
+        return text
+    prefix = config.get("prefix")
+    if prefix and text.startswith(prefix):
+        return text[len(prefix):].strip()
+    return None
+
+
+async def ask_agent(agent: httpx.AsyncClient, message: str, conversation_id: str) -> str:
+    headers = {"Authorization": f"Bearer {ENDPOINT_TOKEN}"} if ENDPOINT_TOKEN else {}
+    response = await agent.post(
+        f"{AGENT_URL}/chat",
+        json={"message": message, "conversation_id": conversation_id},
+        headers=headers,
+    )
+    response.raise_for_status()
+    return response.json()["response"]
+
+
+async def run_slack(config: Dict[str, Any], agent: httpx.AsyncClient):
+    from slack_bolt.adapter.socket_mode.async_handler import AsyncSocketModeHandler
+    from slack_bolt.async_app import AsyncApp
+
+    app = AsyncApp(token=read_token(config["botTokenFile"]))
+    # Fails with invalid_auth for a revoked or expired bot token
+    auth = await app.client.auth_test()
+    mention = f"<@{auth['user_id']}>"
+    channels = set(config["channels"])
+
+    @app.event("message")
+    async def on_message(event, say):
+        if event.get("bot_id") or event.get("subtype"):
+            return
+        channel = event["channel"]
+        direct = event.get("channel_type") == "im"
+        if channels and not direct and channel not in channels:
+            return
+        text = event.get("text", "")
+        message = triggered(config, text.replace(mention, "").strip(), mention in text, direct)
+        if not message:
+            return
+        thread = event.get("thread_ts") or event["ts"]
+        try:
+            answer = await ask_agent(agent, message, f"slack-{channel}-{thread}")
+        except Exception as e:
+            logger.error(f"Connector {config['name']}: the agent failed to answer: {e}")
+            return
+        await say(text=answer, thread_ts=thread)
+
+    handler = AsyncSocketModeHandler(app, read_token(config["appTokenFile"]))
+    await handler.connect_async()
+    set_state(config, "connected")
+    # The Socket Mode client reconnects on its own from now on
+    await asyncio.Event().wait()
+
+
+async def run_discord(config: Dict[str, Any], agent: httpx.AsyncClient):
+    import discord
+
+    intents = discord.Intents.default()
+    intents.message_content = True
+    client = discord.Client(intents=intents)
+    channels = set(config["channels"])
+
+    @client.event
+    async def on_ready():
+        set_state(config, "connected")
+
+    @client.event
+    async def on_message(message):
+        if message.author.bot:
+            return
+        direct = isinstance(message.channel, discord.DMChannel)
+        # Threads are filtered by the channel they belong to
+        channel = getattr(message.channel, "parent_id", None) or message.channel.id
+        if channels and not direct and str(channel) not in channels:
+            return
+        text = re.sub(rf"<@!?{client.user.id}>", "", message.content).strip()
+        prompt = triggered(config, text, client.user in message.mentions, direct)
+        if not prompt:
+            return
+        try:
+            async with message.channel.typing():
+                answer = await ask_agent(agent, prompt, f"discord-{message.channel.id}")
+        except Exception as e:
+            logger.error(f"Connector {config['name']}: the agent failed to answer: {e}")
+            return
+        await message.reply(answer[:DISCORD_MAX_MESSAGE])
+
+    try:
+        # Fails with LoginFailure for an invalid bot token
+        await client.start(read_token(config["botTokenFile"]))
+    finally:
+        await client.close()
+
+
+RUNNERS = {"slack": run_slack, "discord": run_discord}
+
+
+async def run_connector(config: Dict[str, Any], agent: httpx.AsyncClient):
+    """Runs the connector forever, retrying with backoff when its platform rejects it."""
+    backoff = MIN_BACKOFF_SECONDS
+    while True:
+        set_state(config, "connecting")
+        try:
+            await RUNNERS[config["type"]](config, agent)
+            error = "the connection was closed"
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            error = str(e) or type(e).__name__
+        if states[config["name"]]["state"] == "connected":
+            backoff = MIN_BACKOFF_SECONDS
+        set_state(config, "failed", error)
+        logger.warning(f"Connector {config['name']} failed, retrying in {backoff}s: {error}")
+        await asyncio.sleep(backoff)
+        backoff = min(backoff * 2, MAX_BACKOFF_SECONDS)
+
+
+def load_configs():
+    configs = []
+    for path in sorted(glob.glob(os.path.join(CONNECTORS_DIR, "*", "config.json"))):
+        with open(path) as f:
+            configs.append(json.load(f))
+    return configs
+
+
+async def serve_status(configs):
+    async def status(request):
+        return web.json_response({"connectors": [states[config["name"]] for config in configs]})
+
+    app = web.Application()
+    app.router.add_get("/status", status)
+    runner = web.AppRunner(app)
+    await runner.setup()
+    await web.TCPSite(runner, port=STATUS_PORT).start()
+
+
+async def main():
+    configs = load_configs()
+    for config in configs:
+        set_state(config, "connecting")
+    await serve_status(configs)
+    logger.info(f"Starting {len(configs)} connectors for agent {os.getenv('AGENT_NAME', '')}")
+    # The agent runs in the same pod, never behind the proxy of the platforms
+    async with httpx.AsyncClient(timeout=300, trust_env=False) as agent:
+        await asyncio.gather(*(run_connector(config, agent) for config in configs))
+
+
+if __name__ == "__main__":
+    asyncio.run(main())
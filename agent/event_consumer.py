@@ -0,0 +1,173 @@
+"""
+Event source consumer of an agent.
+
+Agents with spec.eventSource run in consumer mode (AGENT_MODE=consumer): next to their
+probes and metrics, they consume messages from the Kafka topic or SQS queue configured by
+the operator through the environment, and process AGENT_EVENT_CONCURRENCY of them at once.
+
+A message is either plain text, sent to the agent as is, or a JSON object with a
+"message", an optional "conversation_id" and an optional "reply_to" topic or queue URL
+receiving the answer. A message failing AGENT_EVENT_MAX_ATTEMPTS times is sent to
+AGENT_EVENT_DEAD_LETTER_TARGET, or dropped and logged without one.
+"""
+
+import asyncio
+import json
+import logging
+import os
+import ssl
+from typing import Awaitable, Callable, Dict, Optional
+
+logger = logging.getLogger("event_consumer")
+
+EVENT_SOURCE = os.getenv("AGENT_EVENT_SOURCE", "")
+CONCURRENCY = int(os.getenv("AGENT_EVENT_CONCURRENCY", "1"))
+MAX_ATTEMPTS = int(os.getenv("AGENT_EVENT_MAX_ATTEMPTS", "3"))
+DEAD_LETTER_TARGET = os.getenv("AGENT_EVENT_DEAD_LETTER_TARGET", "")
+MAX_BACKOFF_SECONDS = 30
+
+# Sends a message of a conversation to the agent and returns its answer
+Handler = Callable[[str, Optional[str]], Awaitable[str]]
+
+
+def parse(body: str) -> Dict[str, Optional[str]]:
+    """Returns the message, conversation and reply target of a message body."""
+    try:
+        data = json.loads(body)
+    except ValueError:
+        data = None
+    if isinstance(data, dict) and isinstance(data.get("message"), str):
+        return {"message": data["message"], "conversation_id": data.get("conversation_id"), "reply_to": data.get("reply_to")}
+    return {"message": body, "conversation_id": None, "reply_to": None}
+
+
+async def process(handle: Handler, body: str, attempts: int = MAX_ATTEMPTS) -> Optional[Dict[str, str]]:
+    """Processes the message, retrying with backoff, and returns the reply to send, if any.
+
+    Raises the last error once the attempts are exhausted."""
+    request = parse(body)
+    backoff = 1
+    for attempt in range(1, attempts + 1):
+        try:
+            answer = await handle(request["message"], request["conversation_id"])
+            break
+        except Exception as e:
+            if attempt == attempts:
+                raise
+            logger.warning(f"Processing a message failed (attempt {attempt}/{attempts}), retrying in {backoff}s: {getattr(e, 'detail', e)}")
+            await asyncio.sleep(backoff)
+            backoff = min(backoff * 2, MAX_BACKOFF_SECONDS)
+    if not request["reply_to"]:
+        return None
+    return {"target": request["reply_to"], "body": json.dumps({"conversation_id": request["conversation_id"], "response": answer})}
+
+
+async def run_kafka(handle: Handler):
+    from aiokafka import AIOKafkaConsumer, AIOKafkaProducer
+
+    options = {"bootstrap_servers": os.environ["KAFKA_BOOTSTRAP_SERVERS"].split(",")}
+    tls = os.getenv("KAFKA_TLS", "false") == "true"
+    mechanism = os.getenv("KAFKA_SASL_MECHANISM", "")
+    if mechanism:
+        options.update(
+            security_protocol="SASL_SSL" if tls else "SASL_PLAINTEXT",
+            sasl_mechanism=mechanism,
+            sasl_plain_username=os.environ["KAFKA_SASL_USERNAME"],
+            sasl_plain_password=os.environ["KAFKA_SASL_PASSWORD"],
+        )
+    elif tls:
+        options["security_protocol"] = "SSL"
+    if tls:
+        options["ssl_context"] = ssl.create_default_context()
+
+    consumer = AIOKafkaConsumer(
+        os.environ["KAFKA_TOPIC"],
+        group_id=os.environ["KAFKA_CONSUMER_GROUP"],
+        enable_auto_commit=False,
+        auto_offset_reset="earliest",
+        **options,
+    )
+    producer = AIOKafkaProducer(**options)
+    await consumer.start()
+    await producer.start()
+    logger.info(f"Consuming Kafka topic {os.environ['KAFKA_TOPIC']} as group {os.environ['KAFKA_CONSUMER_GROUP']}")
+
+    async def consume(record):
+        body = record.value.decode(errors="replace")
+        try:
+            reply = await process(handle, body)
+        except Exception as e:
+            logger.error(f"Message {record.topic}/{record.partition}/{record.offset} failed {MAX_ATTEMPTS} times: {getattr(e, 'detail', e)}")
+            if DEAD_LETTER_TARGET:
+                await producer.send_and_wait(DEAD_LETTER_TARGET, record.value, key=record.key)
+            return
+        if reply:
+            await producer.send_and_wait(reply["target"], reply["body"].encode())
+
+    try:
+        while True:
+            batches = await consumer.getmany(timeout_ms=1000, max_records=CONCURRENCY)
+            records = [record for batch in batches.values() for record in batch]
+            if records:
+                await asyncio.gather(*(consume(record) for record in records))
+                # Offsets are committed once every message of the batch was handled
+                await consumer.commit()
+    finally:
+        await consumer.stop()
+        await producer.stop()
+
+
+async def run_sqs(handle: Handler):
+    import boto3
+
+    sqs = boto3.client("sqs", region_name=os.environ["AWS_REGION"])
+    queue_url = os.environ["SQS_QUEUE_URL"]
+    logger.info(f"Consuming SQS queue {queue_url}")
+
+    async def consume(message):
+        receives = int(message.get("Attributes", {}).get("ApproximateReceiveCount", "1"))
+        try:
+            # The queue redelivers failed messages, each delivery counting as one attempt
+            reply = await process(handle, message["Body"], attempts=1)
+        except Exception as e:
+            if receives < MAX_ATTEMPTS:
+                logger.warning(f"Message {message['MessageId']} failed (attempt {receives}/{MAX_ATTEMPTS}): {getattr(e, 'detail', e)}")
+                backoff = min(2 ** receives, MAX_BACKOFF_SECONDS)
+                await asyncio.to_thread(sqs.change_message_visibility, QueueUrl=queue_url, ReceiptHandle=message["ReceiptHandle"], VisibilityTimeout=backoff)
+                return
+            logger.error(f"Message {message['MessageId']} failed {MAX_ATTEMPTS} times: {getattr(e, 'detail', e)}")
+            if DEAD_LETTER_TARGET:
+                await asyncio.to_thread(sqs.send_message, QueueUrl=DEAD_LETTER_TARGET, MessageBody=message["Body"])
+            reply = None
+        if reply:
+            await asyncio.to_thread(sqs.send_message, QueueUrl=reply["target"], MessageBody=reply["body"])
+        await asyncio.to_thread(sqs.delete_message, QueueUrl=queue_url, ReceiptHandle=message["ReceiptHandle"])
+
+    while True:
+        response = await asyncio.to_thread(
+            sqs.receive_message,
+            QueueUrl=queue_url,
+            MaxNumberOfMessages=min(CONCURRENCY, 10),
+            WaitTimeSeconds=20,
+            AttributeNames=["ApproximateReceiveCount"],
+        )
+        messages = response.get("Messages", [])
+        if messages:
+            await asyncio.gather(*(consume(message) for message in messages))
+
+
+CONSUMERS = {"kafka": run_kafka, "sqs": run_sqs}
+
+
+async def run(handle: Handler):
+    """Consumes the event source forever, reconnecting with backoff when it fails."""
+    backoff = 1
+    while True:
+        try:
+            await CONSUMERS[EVENT_SOURCE](handle)
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            logger.error(f"Consuming the {EVENT_SOURCE} event source failed, reconnecting in {backoff}s: {e}")
+            await asyncio.sleep(backoff)
+            backoff = min(backoff * 2, MAX_BACKOFF_SECONDS)
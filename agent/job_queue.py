@@ -0,0 +1,108 @@
+"""
+Job queue of an agent in worker mode.
+
+Agents with spec.workerMode keep long jobs off the HTTP path: the agent pods enqueue them
+in the Redis list AGENT_QUEUE_NAME, and the worker pods (AGENT_MODE=worker) process them
+one at a time, each within AGENT_MAX_JOB_DURATION seconds. A job is a Redis hash holding
+its request, status and result, kept for a day once finished.
+"""
+
+import asyncio
+import json
+import logging
+import os
+import time
+import uuid
+from typing import Any, Awaitable, Callable, Dict, Optional
+
+import redis.asyncio as redis
+
+logger = logging.getLogger("job_queue")
+
+QUEUE_NAME = os.getenv("AGENT_QUEUE_NAME", "")
+MAX_JOB_DURATION = int(os.getenv("AGENT_MAX_JOB_DURATION", "1800"))
+RESULT_TTL_SECONDS = 24 * 3600
+MAX_BACKOFF_SECONDS = 30
+
+# Sends a message of a conversation to the agent and returns its answer
+Handler = Callable[[str, Optional[str]], Awaitable[str]]
+
+
+def enabled() -> bool:
+    return bool(os.getenv("AGENT_QUEUE_ADDRESS"))
+
+
+def connect() -> redis.Redis:
+    host, _, port = os.environ["AGENT_QUEUE_ADDRESS"].rpartition(":")
+    return redis.Redis(
+        host=host,
+        port=int(port),
+        db=int(os.getenv("AGENT_QUEUE_DB", "0")),
+        password=os.getenv("AGENT_QUEUE_PASSWORD") or None,
+        decode_responses=True,
+    )
+
+
+def job_key(job_id: str) -> str:
+    return f"{QUEUE_NAME}:{job_id}"
+
+
+async def enqueue(queue: redis.Redis, message: str, conversation_id: Optional[str]) -> str:
+    """Stores the job and queues it, returning its id."""
+    job_id = uuid.uuid4().hex
+    await queue.hset(job_key(job_id), mapping={
+        "status": "queued",
+        "request": json.dumps({"message": message, "conversation_id": conversation_id}),
+        "created": str(time.time()),
+    })
+    await queue.lpush(QUEUE_NAME, job_id)
+    return job_id
+
+
+async def get(queue: redis.Redis, job_id: str) -> Optional[Dict[str, Any]]:
+    job = await queue.hgetall(job_key(job_id))
+    if not job:
+        return None
+    result = {"id": job_id, "status": job["status"]}
+    for field in ("response", "error"):
+        if field in job:
+            result[field] = job[field]
+    return result
+
+
+async def process(queue: redis.Redis, handle: Handler, job_id: str):
+    key = job_key(job_id)
+    request = json.loads(await queue.hget(key, "request") or "{}")
+    await queue.hset(key, "status", "running")
+    try:
+        answer = await asyncio.wait_for(handle(request["message"], request.get("conversation_id")), MAX_JOB_DURATION)
+        await queue.hset(key, mapping={"status": "succeeded", "response": answer})
+    except asyncio.TimeoutError:
+        logger.error(f"Job {job_id} exceeded {MAX_JOB_DURATION}s")
+        await queue.hset(key, mapping={"status": "failed", "error": f"the job exceeded {MAX_JOB_DURATION}s"})
+    except Exception as e:
+        logger.error(f"Job {job_id} failed: {getattr(e, 'detail', e)}")
+        await queue.hset(key, mapping={"status": "failed", "error": str(getattr(e, "detail", e))})
+    await queue.expire(key, RESULT_TTL_SECONDS)
+
+
+async def run_worker(handle: Handler):
+    """Processes the queued jobs forever, reconnecting with backoff when Redis fails."""
+    backoff = 1
+    logger.info(f"Processing the jobs of {QUEUE_NAME}")
+    while True:
+        queue = connect()
+        try:
+            while True:
+                item = await queue.brpop(QUEUE_NAME, timeout=5)
+                backoff = 1
+                if item:
+                    await process(queue, handle, item[1])
+        except asyncio.CancelledError:
+            raise
+        except Exception as e:
+            logger.error(f"Reading the job queue failed, reconnecting in {backoff}s: {e}")
+            await asyncio.sleep(backoff)
+            backoff = min(backoff * 2, MAX_BACKOFF_SECONDS)
+        finally:
+            await queue.aclose()
@@ -8,25 +8,94 @@ an API endpoint for LLM interactions.
 """
 
 import os
+import asyncio
+import hmac
 import json
 import logging
+import random
+import re
+import tempfile
+import threading
+import time
+from collections import deque
+from contextvars import ContextVar
+from concurrent.futures import ThreadPoolExecutor
 from typing import Dict, List, Optional, Any
-from fastapi import FastAPI, HTTPException
+from fastapi import FastAPI, HTTPException, Request
+from fastapi.responses import JSONResponse, Response, StreamingResponse
 from pydantic import BaseModel
 import uvicorn
-from datetime import datetime
+from datetime import datetime, timedelta, timezone
+from zoneinfo import ZoneInfo
 import backoff
 import httpx
+from prometheus_client import CONTENT_TYPE_LATEST, Counter, Gauge, Histogram, generate_latest
 
 # Import LLM providers
 import openai
 from anthropic import Anthropic
 import google.generativeai as genai
 
-# Configure structured logging
-logging.basicConfig(level=logging.INFO, format='%(asctime)s - %(name)s - %(levelname)s - %(message)s')
+import tool_executor
+
+# Identity of this pod, set by the operator through the Downward API
+AGENT_NAME = os.getenv("AGENT_NAME", "agent")
+AGENT_NAMESPACE = os.getenv("AGENT_NAMESPACE", "default")
+AGENT_UID = os.getenv("AGENT_UID", "")
+POD_NAME = os.getenv("POD_NAME", "")
+POD_IP = os.getenv("POD_IP", "")
+NODE_NAME = os.getenv("NODE_NAME", "")
+
+# Scratch volume bounded by spec.scratch, which holds the temporary files of the runtime and of
+# the libraries it calls, as the root filesystem may be read-only
+AGENT_TMP_DIR = os.getenv("AGENT_TMP_DIR")
+if AGENT_TMP_DIR:
+    os.environ["TMPDIR"] = AGENT_TMP_DIR
+    tempfile.tempdir = AGENT_TMP_DIR
+
+# Log verbosity set by the operator from spec.logLevel or the kubeagentic.ai/log-level annotation
+LOG_LEVELS = {"debug": logging.DEBUG, "info": logging.INFO, "warn": logging.WARNING, "error": logging.ERROR}
+# Debug mode also logs the bodies of chat requests and responses, redacted
+AGENT_DEBUG = os.getenv("AGENT_DEBUG", "false").lower() == "true"
+LOG_LEVEL = logging.DEBUG if AGENT_DEBUG else LOG_LEVELS.get(os.getenv("AGENT_LOG_LEVEL", "info"), logging.INFO)
+
+# Configure structured logging, tagged with the agent and pod
+logging.basicConfig(level=LOG_LEVEL, format=f'%(asctime)s - {AGENT_NAMESPACE}/{AGENT_NAME} {POD_NAME} - %(name)s - %(levelname)s - %(message)s')
 logger = logging.getLogger(__name__)
 
+# Secrets and personal data removed from the bodies logged in debug mode
+REDACTIONS = [
+    (re.compile(r"\b(sk|pk|rk)-[A-Za-z0-9_-]{8,}"), "[REDACTED_KEY]"),
+    (re.compile(r"(?i)bearer\s+[A-Za-z0-9._~+/=-]+"), "Bearer [REDACTED]"),
+    (re.compile(r"[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}"), "[REDACTED_EMAIL]"),
+]
+
+# Rules of spec.redaction rendered by the operator, applied to every log record when set
+AGENT_REDACTION = json.loads(os.getenv("AGENT_REDACTION") or "{}")
+LOG_REDACTIONS = [(re.compile(rule["pattern"]), rule["replacement"]) for rule in AGENT_REDACTION.get("rules") or []]
+REDACTIONS += LOG_REDACTIONS
+
+def redact(text: str) -> str:
+    """Removes API keys, bearer tokens, e-mail addresses and the personal data of spec.redaction from text before it is logged."""
+    for pattern, replacement in REDACTIONS:
+        # Replacements are literal, as in the operator
+        text = pattern.sub(lambda _, replacement=replacement: replacement, text)
+    return text
+
+class RedactionFilter(logging.Filter):
+    """Redacts the personal data of spec.redaction from the log records."""
+
+    def filter(self, record: logging.LogRecord) -> bool:
+        message = record.getMessage()
+        for pattern, replacement in LOG_REDACTIONS:
+            message = pattern.sub(lambda _, replacement=replacement: replacement, message)
+        record.msg, record.args = message, None
+        return True
+
+if LOG_REDACTIONS:
+    for handler in logging.getLogger().handlers:
+        handler.addFilter(RedactionFilter())
+
 # Import LangGraph components (optional)
 try:
     from langgraph.graph import StateGraph, END
@@ -34,7 +103,7 @@ try:
     from langchain_openai import ChatOpenAI
     from langchain_anthropic import ChatAnthropic
     from langchain_google_genai import ChatGoogleGenerativeAI
-    from langchain.schema import HumanMessage, SystemMessage
+    from langchain.schema import AIMessage, HumanMessage, SystemMessage
     from langchain.tools import BaseTool
     from typing import Type
     LANGGRAPH_AVAILABLE = True
@@ -46,6 +115,49 @@ except ImportError as e:
 
 app = FastAPI(title="KubeAgentic Agent", version="1.0.0")
 
+# --- Metrics ---
+
+# Experiment variant served by this pod, "a" outside of experiments
+AGENT_VARIANT = os.getenv("AGENT_VARIANT", "a")
+
+# Labels of every metric, which the operator's dashboards select and break down by
+IDENTITY_LABELS = {"agent": AGENT_NAME, "namespace": AGENT_NAMESPACE, "pod": POD_NAME}
+
+# Scraped by Prometheus and by the operator to gate canary rollouts and compare experiment variants
+REQUESTS_TOTAL = Counter("kubeagentic_requests_total", "Chat requests handled by the agent.", [*IDENTITY_LABELS, "variant"])
+ERRORS_TOTAL = Counter("kubeagentic_errors_total", "Chat requests that failed.", [*IDENTITY_LABELS, "variant"])
+# With the bucket of the latency target of spec.slo, which the SLO rules of the operator select
+LATENCY_BUCKET_SECONDS = float(os.getenv("AGENT_LATENCY_BUCKET_SECONDS") or "inf")
+RESPONSE_DURATION = Histogram(
+    "kubeagentic_response_duration_seconds",
+    "Chat request duration in seconds.",
+    [*IDENTITY_LABELS, "variant"],
+    buckets=sorted({*Histogram.DEFAULT_BUCKETS, LATENCY_BUCKET_SECONDS}),
+)
+TOKENS_TOTAL = Counter("kubeagentic_tokens_total", "LLM tokens consumed by chat requests.", [*IDENTITY_LABELS, "variant", "type"])
+# Scraped by the operator into status.usage of agents with spec.caching
+CACHE_REQUESTS_TOTAL = Counter("kubeagentic_cache_requests_total", "Single-turn prompts looked up in the response cache.", [*IDENTITY_LABELS, "result"])
+CACHE_SAVED_TOKENS_TOTAL = Counter("kubeagentic_cache_saved_tokens_total", "LLM tokens not consumed thanks to response cache hits.", [*IDENTITY_LABELS, "type"])
+
+# Tokens consumed by the chat request being handled, stored with its answer in the response cache
+REQUEST_TOKENS: ContextVar[Optional[Dict[str, int]]] = ContextVar("request_tokens", default=None)
+
+# Sent with the requests to OpenAI-compatible and Anthropic providers, so that their usage
+# can be attributed to the agent
+PROVIDER_HEADERS = {"X-KubeAgentic-Agent": f"{AGENT_NAMESPACE}/{AGENT_NAME}", "X-KubeAgentic-Agent-UID": AGENT_UID, "X-KubeAgentic-Pod": POD_NAME}
+
+def record_tokens(prompt_tokens: Optional[int], completion_tokens: Optional[int]):
+    """Counts the tokens reported by the LLM provider for a chat request."""
+    if prompt_tokens:
+        TOKENS_TOTAL.labels(**IDENTITY_LABELS, variant=AGENT_VARIANT, type="prompt").inc(prompt_tokens)
+    if completion_tokens:
+        TOKENS_TOTAL.labels(**IDENTITY_LABELS, variant=AGENT_VARIANT, type="completion").inc(completion_tokens)
+    count_quota_tokens((prompt_tokens or 0) + (completion_tokens or 0))
+    request_tokens = REQUEST_TOKENS.get()
+    if request_tokens is not None:
+        request_tokens["prompt"] += prompt_tokens or 0
+        request_tokens["completion"] += completion_tokens or 0
+
 # --- Pydantic Models for API Requests and Responses ---
 
 class ChatRequest(BaseModel):
@@ -54,6 +166,13 @@ class ChatRequest(BaseModel):
     conversation_id: Optional[str] = None
     context: Optional[Dict[str, Any]] = None
 
+class ChatCompletionRequest(BaseModel):
+    """Request model for the OpenAI-compatible /v1/chat/completions endpoint."""
+    model: Optional[str] = None
+    messages: List[Dict[str, Any]]
+    stream: bool = False
+    user: Optional[str] = None
+
 class ChatResponse(BaseModel):
     """Response model for the /chat endpoint."""
     response: str
@@ -61,12 +180,14 @@ class ChatResponse(BaseModel):
     timestamp: datetime
     provider: str
     model: str
+    variant: str
 
 class HealthResponse(BaseModel):
     """Response model for the /health and /ready endpoints."""
     status: str
     provider: str
     model: str
+    variant: str
     timestamp: datetime
 
 # --- Agent Configuration ---
@@ -79,8 +200,24 @@ class AgentConfig:
         self.system_prompt = os.getenv("AGENT_SYSTEM_PROMPT", "You are a helpful AI assistant.")
         self.api_key = os.getenv("AGENT_API_KEY")
         self.endpoint = os.getenv("AGENT_ENDPOINT")
+        # Endpoints of a provider served by several model servers, sorted by priority
+        self.endpoints = json.loads(os.getenv("AGENT_ENDPOINTS") or "[]")
         self.framework = os.getenv("AGENT_FRAMEWORK", "direct")
         self.tools_count = int(os.getenv("AGENT_TOOLS_COUNT", "0"))
+        self.temperature = float(os.getenv("AGENT_TEMPERATURE", "0.7"))
+        self.max_tokens = int(os.getenv("AGENT_MAX_TOKENS", "2000"))
+        # Few-shot examples sent between the system prompt and the message, rendered by the operator
+        self.examples = []
+        examples_file = os.getenv("AGENT_EXAMPLES_FILE")
+        if examples_file:
+            with open(examples_file) as f:
+                self.examples = json.load(f)
+            logger.info(f"Loaded {len(self.examples)} few-shot examples")
+        # Model of the schedule in effect, rewritten by the operator at the window boundaries
+        self.base_model, self.base_temperature, self.base_max_tokens = self.model, self.temperature, self.max_tokens
+        self.model_file = os.getenv("AGENT_MODEL_FILE")
+        self.model_file_mtime = None
+        self.reload_model()
         
         # Load LangGraph configuration if framework is langgraph
         self.langgraph_config = None
@@ -97,8 +234,13 @@ class AgentConfig:
                 logger.warning("Framework set to 'langgraph' but no AGENT_LANGGRAPH_CONFIG provided")
         
         if not self.api_key:
-            logger.error("AGENT_API_KEY environment variable is not set.")
-            raise ValueError("AGENT_API_KEY environment variable is required")
+            if self.endpoint and self.provider in ["openai", "vllm", "ollama"]:
+                # Self-hosted OpenAI-compatible servers accept any key
+                logger.info("No AGENT_API_KEY set, calling the self-hosted endpoint without credentials")
+                self.api_key = "EMPTY"
+            else:
+                logger.error("AGENT_API_KEY environment variable is not set.")
+                raise ValueError("AGENT_API_KEY environment variable is required")
         
         # Validate framework
         if self.framework not in ["direct", "langgraph"]:
@@ -112,26 +254,182 @@ class AgentConfig:
         
         logger.info(f"Agent configured with provider: {self.provider}, model: {self.model}, framework: {self.framework}")
 
+    def reload_model(self) -> bool:
+        """Applies the model of AGENT_MODEL_FILE when the file changed, and reports whether the model did."""
+        if not self.model_file:
+            return False
+        try:
+            mtime = os.stat(self.model_file).st_mtime
+            if mtime == self.model_file_mtime:
+                return False
+            with open(self.model_file) as f:
+                scheduled = json.load(f)
+        except (OSError, json.JSONDecodeError) as e:
+            logger.warning(f"Failed to read the scheduled model: {e}")
+            return False
+        self.model_file_mtime = mtime
+        previous = self.model
+        self.model = scheduled.get("model") or self.base_model
+        self.temperature = float(scheduled["temperature"]) if scheduled.get("temperature") else self.base_temperature
+        self.max_tokens = int(scheduled.get("maxTokens") or self.base_max_tokens)
+        if self.model == previous:
+            return False
+        logger.info(f"Switched the model from {previous} to {self.model}")
+        return True
+
 # --- LLM Provider Logic ---
 
+def example_messages(examples: List[Dict[str, str]]) -> List[Dict[str, str]]:
+    """Returns the few-shot examples as alternating user and assistant chat messages."""
+    messages = []
+    for example in examples:
+        messages.append({"role": "user", "content": example["user"]})
+        messages.append({"role": "assistant", "content": example["assistant"]})
+    return messages
+
+# How long an endpoint failing a request is skipped while others answer
+ENDPOINT_COOLDOWN_SECONDS = float(os.getenv("AGENT_ENDPOINT_COOLDOWN_SECONDS", "30"))
+
+class EndpointPool:
+    """
+    Fails over between the endpoints of AGENT_ENDPOINTS. Requests go to the endpoints of the
+    lowest priority, picked by weight, and reach the next priority only when those fail. An
+    endpoint failing a request is tried last for ENDPOINT_COOLDOWN_SECONDS.
+    """
+    def __init__(self, endpoints: List[Dict[str, Any]], api_key: str):
+        self.endpoints = endpoints
+        self.clients = {
+            endpoint["url"]: openai.OpenAI(api_key=api_key, base_url=endpoint["url"], default_headers=PROVIDER_HEADERS)
+            for endpoint in endpoints
+        }
+        self.active = endpoints[0]["url"]
+        self.failed_until: Dict[str, float] = {}
+        self.last_check: List[Dict[str, Any]] = []
+
+    def order(self) -> List[str]:
+        """Returns the URLs in the order a request tries them."""
+        now = time.monotonic()
+        available = [e for e in self.endpoints if self.failed_until.get(e["url"], 0) <= now]
+        cooling = [e for e in self.endpoints if self.failed_until.get(e["url"], 0) > now]
+        ordered = []
+        for group in (available, cooling):
+            for priority in sorted({e["priority"] for e in group}):
+                tier = [e for e in group if e["priority"] == priority]
+                while tier:
+                    pick = random.choices(tier, weights=[e["weight"] for e in tier])[0]
+                    tier.remove(pick)
+                    ordered.append(pick["url"])
+        return ordered
+
+    def call(self, request):
+        """Sends request(client) to the endpoints in order until one answers."""
+        error = None
+        for url in self.order():
+            try:
+                result = request(self.clients[url])
+            except (openai.APIConnectionError, openai.InternalServerError) as e:
+                logger.warning(f"Endpoint {url} failed, failing over: {e}")
+                self.failed_until[url] = time.monotonic() + ENDPOINT_COOLDOWN_SECONDS
+                error = e
+                continue
+            self.failed_until.pop(url, None)
+            if url != self.active:
+                logger.info(f"Sending requests to endpoint {url}")
+                self.active = url
+            return result
+        raise error
+
+    def check(self, timeout: float) -> str:
+        """Lists the models of every endpoint concurrently, and fails when none answers."""
+        def check_endpoint(url: str) -> Dict[str, Any]:
+            try:
+                self.clients[url].with_options(timeout=timeout, max_retries=0).models.list()
+                return {"url": url, "status": "healthy", "active": url == self.active}
+            except Exception as e:
+                return {"url": url, "status": "unhealthy", "active": url == self.active, "message": redact(str(e))}
+
+        with ThreadPoolExecutor(max_workers=len(self.endpoints)) as executor:
+            results = list(executor.map(check_endpoint, [endpoint["url"] for endpoint in self.endpoints]))
+        self.last_check = results
+        healthy = sum(1 for result in results if result["status"] == "healthy")
+        if healthy == 0:
+            raise RuntimeError(f"none of the {len(results)} endpoints is reachable")
+        return f"{healthy} of {len(results)} endpoints reachable, active {self.active}"
+
+class MicroBatcher:
+    """
+    Groups the requests to a vLLM server into micro-batches, so that the server schedules
+    them in the same steps: a request waits up to AGENT_VLLM_MAX_WAIT_MS for others, and
+    AGENT_VLLM_MAX_BATCH_SIZE waiting requests are sent together. At most
+    AGENT_VLLM_MAX_CONCURRENT_SEQUENCES requests of the pod are in flight.
+    """
+    def __init__(self, max_batch_size: int, max_wait_ms: int, max_concurrent_sequences: int):
+        self.max_batch_size = max_batch_size
+        self.max_wait = max_wait_ms / 1000
+        self.sequences = asyncio.Semaphore(max_concurrent_sequences) if max_concurrent_sequences > 0 else None
+        self.pending: List[asyncio.Future] = []
+        self.flush_handle = None
+
+    @classmethod
+    def from_env(cls) -> Optional["MicroBatcher"]:
+        names = ["AGENT_VLLM_MAX_BATCH_SIZE", "AGENT_VLLM_MAX_WAIT_MS", "AGENT_VLLM_MAX_CONCURRENT_SEQUENCES"]
+        if not any(os.getenv(name) for name in names):
+            return None
+        return cls(
+            int(os.getenv("AGENT_VLLM_MAX_BATCH_SIZE", "1")),
+            int(os.getenv("AGENT_VLLM_MAX_WAIT_MS", "0")),
+            int(os.getenv("AGENT_VLLM_MAX_CONCURRENT_SEQUENCES", "0")),
+        )
+
+    async def submit(self, request):
+        """Runs the blocking request(), once its batch is released, in a worker thread."""
+        loop = asyncio.get_running_loop()
+        released = loop.create_future()
+        self.pending.append(released)
+        if len(self.pending) >= self.max_batch_size:
+            self._flush()
+        elif self.flush_handle is None:
+            self.flush_handle = loop.call_later(self.max_wait, self._flush)
+        await released
+        if self.sequences is None:
+            return await asyncio.to_thread(request)
+        async with self.sequences:
+            return await asyncio.to_thread(request)
+
+    def _flush(self):
+        if self.flush_handle is not None:
+            self.flush_handle.cancel()
+            self.flush_handle = None
+        batch, self.pending = self.pending, []
+        for released in batch:
+            if not released.done():
+                released.set_result(None)
+
 class LLMProvider:
     """Handles the interaction with the underlying LLM provider."""
     def __init__(self, config: AgentConfig):
         self.config = config
         self.client = None
+        self.pool = None
+        self.batcher = MicroBatcher.from_env() if config.provider == "vllm" else None
         self._initialize_client()
     
     def _initialize_client(self):
         """Initializes the appropriate LLM client based on the configured provider."""
         try:
-            if self.config.provider == "openai":
+            if self.config.endpoints and self.config.provider in ["openai", "vllm"]:
+                self.pool = EndpointPool(self.config.endpoints, self.config.api_key)
+                self.client = self.pool.clients[self.pool.active]
+
+            elif self.config.provider == "openai":
                 self.client = openai.OpenAI(
                     api_key=self.config.api_key,
-                    base_url=self.config.endpoint
-                ) if self.config.endpoint else openai.OpenAI(api_key=self.config.api_key)
+                    base_url=self.config.endpoint,
+                    default_headers=PROVIDER_HEADERS
+                ) if self.config.endpoint else openai.OpenAI(api_key=self.config.api_key, default_headers=PROVIDER_HEADERS)
             
             elif self.config.provider == "claude":
-                self.client = Anthropic(api_key=self.config.api_key)
+                self.client = Anthropic(api_key=self.config.api_key, default_headers=PROVIDER_HEADERS)
             
             elif self.config.provider == "gemini":
                 genai.configure(api_key=self.config.api_key)
@@ -142,7 +440,8 @@ class LLMProvider:
                     raise ValueError("Endpoint is required for the vLLM provider")
                 self.client = openai.OpenAI(
                     api_key=self.config.api_key,
-                    base_url=self.config.endpoint
+                    base_url=self.config.endpoint,
+                    default_headers=PROVIDER_HEADERS
                 )
             
             else:
@@ -160,30 +459,43 @@ class LLMProvider:
         Sends a chat message to the LLM and returns the response.
         Includes retry logic for transient network errors and rate limiting.
         """
+        # Gemini binds the model to its client, which is recreated when the schedule switches it
+        if self.config.reload_model() and self.config.provider == "gemini":
+            self.client = genai.GenerativeModel(self.config.model)
         try:
             if self.config.provider in ["openai", "vllm"]:
-                response = self.client.chat.completions.create(
-                    model=self.config.model,
-                    messages=[
-                        {"role": "system", "content": self.config.system_prompt},
-                        {"role": "user", "content": message}
-                    ],
-                    temperature=0.7,
-                    max_tokens=2000
-                )
+                def complete(client):
+                    return client.chat.completions.create(
+                        model=self.config.model,
+                        messages=[
+                            {"role": "system", "content": self.config.system_prompt},
+                            *example_messages(self.config.examples),
+                            {"role": "user", "content": message}
+                        ],
+                        temperature=self.config.temperature,
+                        max_tokens=self.config.max_tokens
+                    )
+                def send():
+                    return self.pool.call(complete) if self.pool else complete(self.client)
+                response = await self.batcher.submit(send) if self.batcher else send()
+                if response.usage:
+                    record_tokens(response.usage.prompt_tokens, response.usage.completion_tokens)
                 return response.choices[0].message.content
             
             elif self.config.provider == "claude":
                 response = self.client.messages.create(
                     model=self.config.model,
-                    max_tokens=2000,
+                    max_tokens=self.config.max_tokens,
+                    temperature=self.config.temperature,
                     system=self.config.system_prompt,
-                    messages=[{"role": "user", "content": message}]
+                    messages=[*example_messages(self.config.examples), {"role": "user", "content": message}]
                 )
+                record_tokens(response.usage.input_tokens, response.usage.output_tokens)
                 return response.content[0].text
             
             elif self.config.provider == "gemini":
-                full_prompt = f"System: {self.config.system_prompt}\n\nUser: {message}"
+                shots = "".join(f"User: {e['user']}\n\nAssistant: {e['assistant']}\n\n" for e in self.config.examples)
+                full_prompt = f"System: {self.config.system_prompt}\n\n{shots}User: {message}"
                 response = self.client.generate_content(full_prompt)
                 return response.text
                 
@@ -212,7 +524,14 @@ class LangGraphProvider:
     
     def _initialize_llm(self):
         """Initialize the appropriate LangChain LLM."""
-        if self.config.provider == "openai":
+        if self.config.provider == "openai" and self.config.endpoints:
+            # Fall back through the endpoints by priority
+            llms = [
+                ChatOpenAI(model=self.config.model, openai_api_key=self.config.api_key, base_url=endpoint["url"])
+                for endpoint in self.config.endpoints
+            ]
+            self.llm = llms[0].with_fallbacks(llms[1:])
+        elif self.config.provider == "openai":
             self.llm = ChatOpenAI(
                 model=self.config.model,
                 openai_api_key=self.config.api_key,
@@ -274,6 +593,8 @@ class LangGraphProvider:
             
             messages = [
                 SystemMessage(content=self.config.system_prompt),
+                *[message for e in self.config.examples
+                  for message in (HumanMessage(content=e["user"]), AIMessage(content=e["assistant"]))],
                 HumanMessage(content=formatted_prompt)
             ]
             
@@ -294,8 +615,16 @@ class LangGraphProvider:
             tool_name = node_config.get("tool", "unknown")
             inputs = {key: state.get(key) for key in node_config.get("inputs", [])}
             
-            # Mock tool execution - in production, integrate with actual tools
-            result = f"Tool {tool_name} executed with inputs: {inputs}"
+            if tool_executor.executable(tool_name):
+                # Run the command of the tool in the executor sidecar, with the inputs as arguments
+                args = [str(value) for value in inputs.values() if value is not None]
+                executed = tool_executor.execute(tool_name, args)
+                result = executed["stdout"]
+                if executed["exitCode"] != 0:
+                    result = f"Tool {tool_name} failed with exit code {executed['exitCode']}: {executed['stderr']}"
+            else:
+                # Mock tool execution - in production, integrate with actual tools
+                result = f"Tool {tool_name} executed with inputs: {inputs}"
             
             # Update state with outputs
             outputs = node_config.get("outputs", ["tool_result"])
@@ -362,6 +691,298 @@ except Exception as e:
     logger.critical(f"Failed to initialize agent: {e}", exc_info=True)
     raise
 
+# Bearer token expected on inbound requests when the operator generated an endpoint key
+ENDPOINT_TOKEN = os.getenv("AGENT_ENDPOINT_TOKEN")
+
+# Paths serving chat requests, limited by the budget and the token quota
+CHAT_PATHS = {"/chat", "/v1/chat/completions", "/jobs"}
+
+# Kubernetes probes cannot send credentials, so the probe endpoints stay open
+UNAUTHENTICATED_PATHS = {"/health", "/ready"}
+
+# --- Concurrency ---
+
+# Chat requests handled at once and waiting for a slot, set by the operator from spec.concurrency.
+# Requests beyond both are rejected, so that a spike is shed instead of exhausting the memory of the pod.
+MAX_CONCURRENT_REQUESTS = int(os.getenv("AGENT_MAX_CONCURRENT_REQUESTS", "0"))
+REQUEST_QUEUE_LENGTH = int(os.getenv("AGENT_REQUEST_QUEUE_LENGTH", "0"))
+REJECT_STATUS_CODE = int(os.getenv("AGENT_REJECT_STATUS_CODE", "503"))
+
+# Scraped by Prometheus, and by the HorizontalPodAutoscaler of agents with spec.concurrency.targetUtilization
+INFLIGHT_REQUESTS = Gauge("kubeagentic_inflight_requests", "Chat requests being handled by the pod.", [*IDENTITY_LABELS])
+
+concurrency_slots = asyncio.Semaphore(MAX_CONCURRENT_REQUESTS) if MAX_CONCURRENT_REQUESTS > 0 else None
+concurrency_state = {"inflight": 0, "queued": 0}
+
+def saturated() -> bool:
+    """Reports whether all the request slots of the pod are taken."""
+    return MAX_CONCURRENT_REQUESTS > 0 and concurrency_state["inflight"] >= MAX_CONCURRENT_REQUESTS
+
+# Defined before the other middlewares, which run first, so that requests they reject never take a slot
+@app.middleware("http")
+async def limit_concurrency(request: Request, call_next):
+    """Bounds the chat requests handled at once, queueing then rejecting the others.
+
+    Streamed responses release their slot once their headers are sent.
+    """
+    if request.url.path not in CHAT_PATHS:
+        return await call_next(request)
+    if concurrency_slots is not None:
+        if concurrency_slots.locked():
+            if concurrency_state["queued"] >= REQUEST_QUEUE_LENGTH:
+                return JSONResponse(status_code=REJECT_STATUS_CODE, content={"detail": "Too many concurrent requests"}, headers={"Retry-After": "1"})
+            concurrency_state["queued"] += 1
+            try:
+                await concurrency_slots.acquire()
+            finally:
+                concurrency_state["queued"] -= 1
+        else:
+            await concurrency_slots.acquire()
+    concurrency_state["inflight"] += 1
+    INFLIGHT_REQUESTS.labels(**IDENTITY_LABELS).inc()
+    try:
+        return await call_next(request)
+    finally:
+        concurrency_state["inflight"] -= 1
+        INFLIGHT_REQUESTS.labels(**IDENTITY_LABELS).dec()
+        if concurrency_slots is not None:
+            concurrency_slots.release()
+
+@app.middleware("http")
+async def require_endpoint_token(request: Request, call_next):
+    """Rejects requests without the expected bearer token when endpoint auth is enabled."""
+    if ENDPOINT_TOKEN and request.url.path not in UNAUTHENTICATED_PATHS:
+        auth = request.headers.get("authorization", "")
+        scheme, _, token = auth.partition(" ")
+        if scheme.lower() != "bearer" or not hmac.compare_digest(token.strip(), ENDPOINT_TOKEN):
+            return JSONResponse(status_code=401, content={"detail": "Unauthorized"}, headers={"WWW-Authenticate": "Bearer"})
+    return await call_next(request)
+
+# Chat requests accepted per minute, set by the operator while the agent budget is exhausted
+MAX_REQUESTS_PER_MINUTE = int(os.getenv("AGENT_MAX_REQUESTS_PER_MINUTE", "0"))
+recent_chat_requests = deque()
+
+@app.middleware("http")
+async def limit_chat_requests(request: Request, call_next):
+    """Rejects chat requests beyond the per-minute limit of this pod."""
+    if MAX_REQUESTS_PER_MINUTE > 0 and request.url.path in CHAT_PATHS:
+        now = time.monotonic()
+        while recent_chat_requests and now - recent_chat_requests[0] >= 60:
+            recent_chat_requests.popleft()
+        if len(recent_chat_requests) >= MAX_REQUESTS_PER_MINUTE:
+            retry_after = int(60 - (now - recent_chat_requests[0])) + 1
+            return JSONResponse(status_code=429, content={"detail": "Request limit reached, the agent budget is exhausted"}, headers={"Retry-After": str(retry_after)})
+        recent_chat_requests.append(now)
+    return await call_next(request)
+
+# --- Token quota ---
+
+# Daily token quota set by the operator. Each pod counts its own tokens; the operator sets
+# AGENT_TOKEN_QUOTA_EXHAUSTED_UNTIL once the agent as a whole has used up the quota.
+TOKEN_QUOTA_DAILY_LIMIT = int(os.getenv("AGENT_TOKEN_QUOTA_DAILY_LIMIT", "0"))
+TOKEN_QUOTA_ACTION = os.getenv("AGENT_TOKEN_QUOTA_ACTION", "reject")
+TOKEN_QUOTA_THROTTLE_PER_MINUTE = int(os.getenv("AGENT_TOKEN_QUOTA_THROTTLE_PER_MINUTE", "10"))
+try:
+    TOKEN_QUOTA_TIMEZONE = ZoneInfo(os.getenv("AGENT_TOKEN_QUOTA_TIMEZONE", "UTC"))
+except Exception as e:
+    logger.warning(f"Unknown token quota time zone, resetting at midnight UTC: {e}")
+    TOKEN_QUOTA_TIMEZONE = timezone.utc
+try:
+    TOKEN_QUOTA_EXHAUSTED_UNTIL = datetime.fromisoformat(os.getenv("AGENT_TOKEN_QUOTA_EXHAUSTED_UNTIL", "").replace("Z", "+00:00"))
+except ValueError:
+    TOKEN_QUOTA_EXHAUSTED_UNTIL = None
+
+quota_usage = {"day": None, "used": 0}
+quota_throttled_requests = deque()
+
+def count_quota_tokens(tokens: int):
+    """Adds tokens to the consumption of this pod in the current quota day."""
+    today = datetime.now(TOKEN_QUOTA_TIMEZONE).date()
+    if quota_usage["day"] != today:
+        quota_usage["day"], quota_usage["used"] = today, 0
+    quota_usage["used"] += tokens
+
+def next_quota_reset() -> datetime:
+    """Returns the next midnight in the quota time zone."""
+    now = datetime.now(TOKEN_QUOTA_TIMEZONE)
+    return datetime.combine(now.date() + timedelta(days=1), datetime.min.time(), tzinfo=TOKEN_QUOTA_TIMEZONE)
+
+def token_quota_exhausted() -> bool:
+    """Reports whether this pod or, according to the operator, the whole agent used up the quota."""
+    if TOKEN_QUOTA_DAILY_LIMIT <= 0:
+        return False
+    if TOKEN_QUOTA_EXHAUSTED_UNTIL and datetime.now(timezone.utc) < TOKEN_QUOTA_EXHAUSTED_UNTIL:
+        return True
+    count_quota_tokens(0)
+    return quota_usage["used"] >= TOKEN_QUOTA_DAILY_LIMIT
+
+@app.middleware("http")
+async def enforce_token_quota(request: Request, call_next):
+    """Rejects or throttles chat requests once the daily token quota is exhausted."""
+    if request.url.path in CHAT_PATHS and token_quota_exhausted():
+        if TOKEN_QUOTA_ACTION != "throttle":
+            retry_after = int((next_quota_reset() - datetime.now(TOKEN_QUOTA_TIMEZONE)).total_seconds()) + 1
+            return JSONResponse(status_code=429, content={"detail": "Daily token quota exhausted"}, headers={"Retry-After": str(retry_after)})
+        now = time.monotonic()
+        while quota_throttled_requests and now - quota_throttled_requests[0] >= 60:
+            quota_throttled_requests.popleft()
+        if len(quota_throttled_requests) >= TOKEN_QUOTA_THROTTLE_PER_MINUTE:
+            retry_after = int(60 - (now - quota_throttled_requests[0])) + 1
+            return JSONResponse(status_code=429, content={"detail": "Request limit reached, the daily token quota is exhausted"}, headers={"Retry-After": str(retry_after)})
+        quota_throttled_requests.append(now)
+    return await call_next(request)
+
+# --- Warm-up ---
+
+# Chat requests sent after startup, before the pod reports ready, so the first user requests
+# do not pay for opening connections to the LLM provider
+WARMUP_COUNT = int(os.getenv("AGENT_WARMUP_COUNT", "0"))
+WARMUP_TIMEOUT_SECONDS = int(os.getenv("AGENT_WARMUP_TIMEOUT_SECONDS", "120"))
+WARMUP_PROMPTS = json.loads(os.getenv("AGENT_WARMUP_PROMPTS", "[]")) or ["Reply with OK."]
+
+# Read by the operator on /warmup; a failed warm-up is reported but does not keep the pod unready
+warmup_state = {"state": "warming" if WARMUP_COUNT > 0 else "disabled", "completed": 0, "error": None}
+warmup_deadline = time.monotonic() + WARMUP_TIMEOUT_SECONDS
+
+async def run_warmup():
+    """Sends the warm-up requests, which are not counted in the request metrics."""
+    for i in range(WARMUP_COUNT):
+        prompt = WARMUP_PROMPTS[i % len(WARMUP_PROMPTS)]
+        try:
+            if agent_config.framework == "direct":
+                await llm_provider.chat(message=prompt)
+            else:
+                await langgraph_provider.chat(message=prompt, conversation_id="warmup")
+        except Exception as e:
+            logger.warning(f"Warm-up request failed: {e}")
+            if warmup_state["state"] == "warming":
+                warmup_state.update(state="failed", error=f"warm-up request {i + 1} failed: {getattr(e, 'detail', e)}")
+            return
+        finally:
+            if langgraph_provider is not None:
+                langgraph_provider.sessions.pop("warmup", None)
+        if warmup_state["state"] != "warming":
+            return
+        warmup_state["completed"] = i + 1
+    warmup_state["state"] = "warm"
+    logger.info(f"Warm-up completed with {WARMUP_COUNT} requests")
+
+def current_warmup_state():
+    """Returns the warm-up state, failing a warm-up that outlived its timeout."""
+    if warmup_state["state"] == "warming" and time.monotonic() > warmup_deadline:
+        warmup_state.update(state="failed", error=f"timed out after {WARMUP_TIMEOUT_SECONDS}s")
+        logger.warning(f"Warm-up timed out after {WARMUP_TIMEOUT_SECONDS}s")
+    return warmup_state
+
+@app.on_event("startup")
+async def start_warmup():
+    """Runs the warm-up in the background; LLM clients block, so it gets its own thread."""
+    if WARMUP_COUNT > 0:
+        threading.Thread(target=lambda: asyncio.run(run_warmup()), daemon=True).start()
+
+# Agents with an event source consume their work from Kafka or SQS, and the workers of
+# agents in worker mode process the queued jobs; set by the operator
+AGENT_MODE = os.getenv("AGENT_MODE", "server")
+
+@app.on_event("startup")
+async def start_consumer():
+    """Consumes the event source in the background, next to the probes and metrics."""
+    if AGENT_MODE == "consumer":
+        import event_consumer
+
+        async def handle(message: str, conversation_id: Optional[str]) -> str:
+            return (await chat(ChatRequest(message=message, conversation_id=conversation_id))).response
+
+        asyncio.create_task(event_consumer.run(handle))
+
+@app.on_event("startup")
+async def start_worker():
+    """Processes the queued jobs in the background, next to the probes and metrics."""
+    if AGENT_MODE == "worker":
+        import job_queue
+
+        async def handle(message: str, conversation_id: Optional[str]) -> str:
+            return (await chat(ChatRequest(message=message, conversation_id=conversation_id))).response
+
+        asyncio.create_task(job_queue.run_worker(handle))
+
+@app.on_event("startup")
+async def start_transcript_sink():
+    """Posts the transcripts to spec.transcriptSink in the background."""
+    import transcript_sink
+
+    if transcript_sink.enabled():
+        asyncio.create_task(transcript_sink.run())
+
+# Job queue shared with the workers, in worker mode
+job_queue_client = None
+
+def job_queue_connection():
+    global job_queue_client
+    import job_queue
+
+    if not job_queue.enabled():
+        raise HTTPException(status_code=404, detail="Worker mode is not enabled for this agent")
+    if job_queue_client is None:
+        job_queue_client = job_queue.connect()
+    return job_queue_client
+
+@app.post("/jobs", status_code=202)
+async def create_job(request: ChatRequest):
+    """Queues a long chat request for the workers and returns the id of its job."""
+    import job_queue
+
+    job_id = await job_queue.enqueue(job_queue_connection(), request.message, request.conversation_id)
+    return {"id": job_id, "status": "queued"}
+
+@app.get("/jobs/{job_id}")
+async def get_job(job_id: str):
+    """Status of a queued job, with its response or error once finished."""
+    import job_queue
+
+    job = await job_queue.get(job_queue_connection(), job_id)
+    if job is None:
+        raise HTTPException(status_code=404, detail="Job not found")
+    return job
+
+response_cache = None
+
+def embed_prompt_client() -> openai.OpenAI:
+    """Returns the client of the OpenAI-compatible embeddings API of the semantic response cache."""
+    return openai.OpenAI(
+        # Ollama and vLLM accept any key
+        api_key=os.getenv("AGENT_CACHE_EMBEDDINGS_API_KEY") or "unused",
+        base_url=os.getenv("AGENT_CACHE_EMBEDDINGS_ENDPOINT") or None,
+        default_headers=PROVIDER_HEADERS,
+    )
+
+@app.on_event("startup")
+async def start_response_cache():
+    """Creates the response cache of agents with spec.caching."""
+    global response_cache
+    import response_cache as cache
+
+    if not cache.enabled():
+        return
+    embed = None
+    if cache.KEY_STRATEGY == "semantic":
+        client = embed_prompt_client()
+        model = os.getenv("AGENT_CACHE_EMBEDDINGS_MODEL", "")
+
+        async def embed(prompt: str) -> List[float]:
+            response = await asyncio.to_thread(client.embeddings.create, model=model, input=prompt)
+            if response.usage and response.usage.total_tokens:
+                # Embedding prompts costs tokens too, which count toward the budget and quotas
+                TOKENS_TOTAL.labels(**IDENTITY_LABELS, variant=AGENT_VARIANT, type="embedding").inc(response.usage.total_tokens)
+                count_quota_tokens(response.usage.total_tokens)
+            return response.data[0].embedding
+    response_cache = cache.ResponseCache(embed)
+
+@app.get("/warmup")
+async def warmup():
+    """Warm-up state, read by the operator to report pods warming up and failed warm-ups."""
+    return current_warmup_state()
+
 @app.get("/health", response_model=HealthResponse)
 async def health_check():
     """Health check endpoint for Kubernetes liveness probe."""
@@ -373,11 +994,21 @@ async def health_check():
     )
 
 @app.get("/ready", response_model=HealthResponse)
-async def readiness_check():
-    """Readiness check endpoint for Kubernetes readiness probe."""
+async def readiness_check(backpressure: bool = False):
+    """Readiness check endpoint for Kubernetes readiness probe.
+
+    With backpressure, set by the operator for spec.concurrency.backpressureReadiness, the pod
+    is also unready while all its request slots are taken, until it drains.
+    """
     if llm_provider.client is None:
         raise HTTPException(status_code=503, detail="LLM client not initialized")
     
+    if current_warmup_state()["state"] == "warming":
+        raise HTTPException(status_code=503, detail="Warming up")
+
+    if backpressure and saturated():
+        raise HTTPException(status_code=503, detail="Saturated")
+    
     return HealthResponse(
         status="ready",
         provider=agent_config.provider,
@@ -385,10 +1016,127 @@ async def readiness_check():
         timestamp=datetime.now()
     )
 
+# Bound on the check of each component of /health/detailed
+HEALTH_CHECK_TIMEOUT_SECONDS = float(os.getenv("AGENT_HEALTH_CHECK_TIMEOUT_SECONDS", "3"))
+
+def check_provider() -> str:
+    """Lists the models of the LLM provider, proving that it is reachable with the configured key."""
+    if llm_provider and llm_provider.pool:
+        return llm_provider.pool.check(HEALTH_CHECK_TIMEOUT_SECONDS)
+    if agent_config.provider in ["openai", "vllm"]:
+        client = llm_provider.client if llm_provider else openai.OpenAI(
+            api_key=agent_config.api_key, base_url=agent_config.endpoint, default_headers=PROVIDER_HEADERS
+        )
+        client.models.list()
+    elif agent_config.provider == "claude":
+        Anthropic(api_key=agent_config.api_key, default_headers=PROVIDER_HEADERS).models.list(limit=1)
+    elif agent_config.provider == "gemini":
+        genai.configure(api_key=agent_config.api_key)
+        next(iter(genai.list_models()), None)
+    return f"{agent_config.provider} is reachable"
+
+async def check_memory() -> str:
+    """Pings the Redis memory backend; the in-memory backend is always available."""
+    backend = os.getenv("AGENT_MEMORY_BACKEND", "inmemory")
+    if backend != "redis":
+        return f"{backend} backend"
+    import redis.asyncio as redis
+
+    client = redis.from_url(os.environ["AGENT_MEMORY_URL"])
+    try:
+        await client.ping()
+    finally:
+        await client.aclose()
+    return "redis is reachable"
+
+def check_tools() -> str:
+    if langgraph_provider is not None and agent_config.langgraph_config and langgraph_provider.workflow is None:
+        raise RuntimeError("the LangGraph workflow failed to build")
+    if tool_executor.enabled():
+        return f"{agent_config.tools_count} tools configured, {tool_executor.check()}"
+    return f"{agent_config.tools_count} tools configured"
+
+@app.get("/health/detailed")
+async def detailed_health():
+    """
+    Health of the components the agent depends on, read by the operator to set the
+    AgentHealthy condition. Answers 503 with the report when a component is unhealthy.
+    """
+    checks = {
+        "provider": asyncio.to_thread(check_provider),
+        "memory": check_memory(),
+        "tools": asyncio.to_thread(check_tools),
+    }
+    results = await asyncio.gather(
+        *(asyncio.wait_for(check, HEALTH_CHECK_TIMEOUT_SECONDS) for check in checks.values()),
+        return_exceptions=True,
+    )
+    components = {}
+    for name, result in zip(checks, results):
+        if isinstance(result, asyncio.TimeoutError):
+            components[name] = {"status": "unhealthy", "message": f"no answer within {HEALTH_CHECK_TIMEOUT_SECONDS:g}s"}
+        elif isinstance(result, Exception):
+            components[name] = {"status": "unhealthy", "message": redact(str(result))}
+        else:
+            components[name] = {"status": "healthy", "message": result}
+    healthy = all(component["status"] == "healthy" for component in components.values())
+    report = {"status": "healthy" if healthy else "unhealthy", "components": components}
+    if llm_provider and llm_provider.pool:
+        report["endpoints"] = llm_provider.pool.last_check
+    return JSONResponse(status_code=200 if healthy else 503, content=report)
+
+@app.get("/metrics")
+async def metrics():
+    """Prometheus metrics endpoint."""
+    return Response(content=generate_latest(), media_type=CONTENT_TYPE_LATEST)
+
+def record_transcript(request: ChatRequest, answer: str, tokens: Dict[str, int]) -> None:
+    """Queues the exchange for spec.transcriptSink, with its metadata when includeMetadata is set."""
+    import transcript_sink
+
+    if not transcript_sink.enabled():
+        return
+    metadata = {
+        "provider": agent_config.provider,
+        "model": agent_config.model,
+        "pod": POD_NAME,
+        "variant": AGENT_VARIANT,
+        "promptTokens": tokens["prompt"],
+        "completionTokens": tokens["completion"],
+    }
+    transcript_sink.record(request.conversation_id or "single-turn", request.message, answer, metadata)
+
 @app.post("/chat", response_model=ChatResponse)
 async def chat(request: ChatRequest):
     """Main chat endpoint for interacting with the agent."""
+    REQUESTS_TOTAL.labels(**IDENTITY_LABELS, variant=AGENT_VARIANT).inc()
+    start = time.monotonic()
     try:
+        if AGENT_DEBUG:
+            logger.debug(f"Chat request (conversation {request.conversation_id}): {redact(request.message)}")
+
+        # Only single-turn prompts are cached, as the answers within a conversation depend on its history
+        cached, cache_key, embedding = None, None, None
+        if response_cache and not request.conversation_id:
+            try:
+                cached, cache_key, embedding = await response_cache.lookup(request.message)
+            except Exception as e:
+                logger.warning(f"Response cache lookup failed: {e}")
+            CACHE_REQUESTS_TOTAL.labels(**IDENTITY_LABELS, result="hit" if cached else "miss").inc()
+        if cached:
+            CACHE_SAVED_TOKENS_TOTAL.labels(**IDENTITY_LABELS, type="prompt").inc(cached.prompt_tokens)
+            CACHE_SAVED_TOKENS_TOTAL.labels(**IDENTITY_LABELS, type="completion").inc(cached.completion_tokens)
+            return ChatResponse(
+                response=cached.answer,
+                conversation_id="single-turn",
+                timestamp=datetime.now(),
+                provider=agent_config.provider,
+                model=agent_config.model,
+                variant=AGENT_VARIANT
+            )
+        request_tokens = {"prompt": 0, "completion": 0}
+        REQUEST_TOKENS.set(request_tokens)
+
         if agent_config.framework == "direct":
             response_text = await llm_provider.chat(
                 message=request.message,
@@ -401,21 +1149,70 @@ async def chat(request: ChatRequest):
             )
         else:
             raise HTTPException(status_code=500, detail=f"Unknown framework: {agent_config.framework}")
-        
+
+        if AGENT_DEBUG:
+            logger.debug(f"Chat response (conversation {request.conversation_id}): {redact(response_text)}")
+
+        if cache_key:
+            try:
+                await response_cache.store_answer(cache_key, response_text, request_tokens["prompt"], request_tokens["completion"], embedding)
+            except Exception as e:
+                logger.warning(f"Failed to cache the response: {e}")
+
+        record_transcript(request, response_text, request_tokens)
+
         return ChatResponse(
             response=response_text,
             conversation_id=request.conversation_id or "single-turn",
             timestamp=datetime.now(),
             provider=agent_config.provider,
-            model=agent_config.model
+            model=agent_config.model,
+            variant=AGENT_VARIANT
         )
     
     except HTTPException:
         # Re-raise HTTPException to let FastAPI handle it
+        ERRORS_TOTAL.labels(**IDENTITY_LABELS, variant=AGENT_VARIANT).inc()
         raise
     except Exception as e:
+        ERRORS_TOTAL.labels(**IDENTITY_LABELS, variant=AGENT_VARIANT).inc()
         logger.error(f"Chat request failed: {e}", exc_info=True)
         raise HTTPException(status_code=500, detail="An internal error occurred during the chat request.")
+    finally:
+        RESPONSE_DURATION.labels(**IDENTITY_LABELS, variant=AGENT_VARIANT).observe(time.monotonic() - start)
+
+@app.post("/v1/chat/completions")
+async def chat_completions(request: ChatCompletionRequest):
+    """OpenAI-compatible chat completions, served to clients of the KubeAgentic gateway.
+
+    The agent keeps the conversation history itself, so only the last user message is sent
+    to it, and the OpenAI `user` field selects the conversation. Streaming requests receive
+    the whole answer in a single chunk, as the agent does not stream from its provider.
+    """
+    message = next((m.get("content") for m in reversed(request.messages) if m.get("role") == "user"), None)
+    if not isinstance(message, str) or not message:
+        raise HTTPException(status_code=400, detail="messages must contain a user message with text content")
+    answer = await chat(ChatRequest(message=message, conversation_id=request.user))
+    completion_id = f"chatcmpl-{int(time.time() * 1000)}"
+    created = int(time.time())
+    model = request.model or AGENT_NAME
+
+    if request.stream:
+        def events():
+            for delta, finish_reason in (({"role": "assistant", "content": answer.response}, None), ({}, "stop")):
+                chunk = {"id": completion_id, "object": "chat.completion.chunk", "created": created, "model": model,
+                         "choices": [{"index": 0, "delta": delta, "finish_reason": finish_reason}]}
+                yield f"data: {json.dumps(chunk)}\n\n"
+            yield "data: [DONE]\n\n"
+        return StreamingResponse(events(), media_type="text/event-stream")
+
+    return {
+        "id": completion_id,
+        "object": "chat.completion",
+        "created": created,
+        "model": model,
+        "choices": [{"index": 0, "message": {"role": "assistant", "content": answer.response}, "finish_reason": "stop"}],
+    }
 
 @app.get("/config")
 async def get_config():
@@ -439,12 +1236,55 @@ async def root():
         "model": agent_config.model
     }
 
+# --- Admin port ---
+
+# Port of the operational endpoints when the operator sets spec.adminPort, apart from the chat API
+ADMIN_PORT = int(os.getenv("AGENT_ADMIN_PORT", "0"))
+# Bearer token of the admin port, generated by the operator independently of the endpoint token
+ADMIN_TOKEN = os.getenv("AGENT_ADMIN_TOKEN")
+
+# Operational endpoints, only served on the admin port when there is one
+ADMIN_PATHS = {"/health/detailed", "/warmup", "/config"}
+
+admin_app = FastAPI(title="KubeAgentic Agent Admin", version="1.0.0")
+for route in app.routes:
+    if getattr(route, "path", None) in ADMIN_PATHS | UNAUTHENTICATED_PATHS:
+        admin_app.router.routes.append(route)
+
+@admin_app.middleware("http")
+async def require_admin_token(request: Request, call_next):
+    """Rejects requests to the admin port without the admin token, except the probes."""
+    if ADMIN_TOKEN and request.url.path not in UNAUTHENTICATED_PATHS:
+        auth = request.headers.get("authorization", "")
+        scheme, _, token = auth.partition(" ")
+        if scheme.lower() != "bearer" or not hmac.compare_digest(token.strip(), ADMIN_TOKEN):
+            return JSONResponse(status_code=401, content={"detail": "Unauthorized"}, headers={"WWW-Authenticate": "Bearer"})
+    return await call_next(request)
+
+@app.middleware("http")
+async def hide_admin_paths(request: Request, call_next):
+    """Keeps the operational endpoints off the chat port when the agent has an admin port."""
+    if ADMIN_PORT and request.url.path in ADMIN_PATHS:
+        return JSONResponse(status_code=404, content={"detail": "Not Found"})
+    return await call_next(request)
+
+async def serve(port: int):
+    """Serves the chat API and the admin endpoints on their ports in the same process."""
+    servers = [
+        uvicorn.Server(uvicorn.Config(app, host="0.0.0.0", port=port, log_level="info")),
+        uvicorn.Server(uvicorn.Config(admin_app, host="0.0.0.0", port=ADMIN_PORT, log_level="info")),
+    ]
+    await asyncio.gather(*(server.serve() for server in servers))
+
 if __name__ == "__main__":
     port = int(os.getenv("PORT", "8080"))
-    uvicorn.run(
-        app,
-        host="0.0.0.0",
-        port=port,
-        log_level="info"
-    )
+    if ADMIN_PORT:
+        asyncio.run(serve(port))
+    else:
+        uvicorn.run(
+            app,
+            host="0.0.0.0",
+            port=port,
+            log_level="info"
+        )
 
@@ -0,0 +1,152 @@
+"""
+Response cache of an agent with spec.caching.
+
+Single-turn prompts, sent without a conversation, are answered from the cache when the same
+prompt was answered within AGENT_CACHE_TTL_SECONDS. The exact key strategy matches prompts
+after normalizing case and whitespace; the semantic strategy embeds them with
+AGENT_CACHE_EMBEDDINGS_MODEL and matches the most similar cached prompt above
+AGENT_CACHE_SIMILARITY_THRESHOLD. The memory backend keeps AGENT_CACHE_MAX_ENTRIES answers per
+pod, the redis backend shares them between the replicas through AGENT_CACHE_URL.
+"""
+
+import hashlib
+import json
+import logging
+import math
+import os
+import time
+from collections import OrderedDict
+from typing import Dict, List, Optional
+
+logger = logging.getLogger("response_cache")
+
+TTL_SECONDS = int(os.getenv("AGENT_CACHE_TTL_SECONDS", "3600"))
+MAX_ENTRIES = int(os.getenv("AGENT_CACHE_MAX_ENTRIES", "1000"))
+KEY_STRATEGY = os.getenv("AGENT_CACHE_KEY_STRATEGY", "exact")
+SIMILARITY_THRESHOLD = float(os.getenv("AGENT_CACHE_SIMILARITY_THRESHOLD", "0.95"))
+
+
+def enabled() -> bool:
+    return os.getenv("AGENT_CACHE_ENABLED", "false").lower() == "true"
+
+
+def normalize(prompt: str) -> str:
+    return " ".join(prompt.lower().split())
+
+
+def similarity(a: List[float], b: List[float]) -> float:
+    dot = sum(x * y for x, y in zip(a, b))
+    norm = math.sqrt(sum(x * x for x in a)) * math.sqrt(sum(y * y for y in b))
+    return dot / norm if norm else 0.0
+
+
+class Entry:
+    """A cached answer with the tokens it cost."""
+    def __init__(self, answer: str, prompt_tokens: int, completion_tokens: int, embedding: Optional[List[float]] = None, expires: float = 0):
+        self.answer = answer
+        self.prompt_tokens = prompt_tokens
+        self.completion_tokens = completion_tokens
+        self.embedding = embedding
+        self.expires = expires or time.time() + TTL_SECONDS
+
+    def to_json(self) -> str:
+        return json.dumps(self.__dict__)
+
+    @classmethod
+    def from_json(cls, data: str) -> "Entry":
+        return cls(**json.loads(data))
+
+
+class MemoryStore:
+    """Keeps the entries of the pod, evicting the oldest beyond MAX_ENTRIES."""
+    def __init__(self):
+        self.entries: "OrderedDict[str, Entry]" = OrderedDict()
+
+    async def get(self, key: str) -> Optional[Entry]:
+        entry = self.entries.get(key)
+        if entry is None or entry.expires < time.time():
+            self.entries.pop(key, None)
+            return None
+        return entry
+
+    async def put(self, key: str, entry: Entry):
+        self.entries[key] = entry
+        self.entries.move_to_end(key)
+        while len(self.entries) > MAX_ENTRIES:
+            self.entries.popitem(last=False)
+
+    async def all(self) -> Dict[str, Entry]:
+        now = time.time()
+        return {key: entry for key, entry in self.entries.items() if entry.expires >= now}
+
+
+class RedisStore:
+    """Shares the entries between the replicas in a Redis hash, indexed by insertion time."""
+    def __init__(self, url: str):
+        import redis.asyncio as redis
+
+        self.client = redis.from_url(url)
+        prefix = f"kubeagentic:{os.getenv('AGENT_NAMESPACE', 'default')}:{os.getenv('AGENT_NAME', 'agent')}:cache"
+        self.entries_key = f"{prefix}:entries"
+        self.index_key = f"{prefix}:index"
+
+    async def get(self, key: str) -> Optional[Entry]:
+        data = await self.client.hget(self.entries_key, key)
+        if data is None:
+            return None
+        entry = Entry.from_json(data)
+        return entry if entry.expires >= time.time() else None
+
+    async def put(self, key: str, entry: Entry):
+        async with self.client.pipeline(transaction=True) as pipe:
+            pipe.hset(self.entries_key, key, entry.to_json())
+            pipe.zadd(self.index_key, {key: time.time()})
+            await pipe.execute()
+        # Evict the expired and oldest entries beyond MAX_ENTRIES
+        evicted = await self.client.zrangebyscore(self.index_key, 0, time.time() - TTL_SECONDS)
+        overflow = await self.client.zcard(self.index_key) - len(evicted) - MAX_ENTRIES
+        if overflow > 0:
+            evicted += await self.client.zrange(self.index_key, len(evicted), len(evicted) + overflow - 1)
+        if evicted:
+            await self.client.hdel(self.entries_key, *evicted)
+            await self.client.zrem(self.index_key, *evicted)
+
+    async def all(self) -> Dict[str, Entry]:
+        now = time.time()
+        entries = {}
+        for key, data in (await self.client.hgetall(self.entries_key)).items():
+            entry = Entry.from_json(data)
+            if entry.expires >= now:
+                entries[key.decode() if isinstance(key, bytes) else key] = entry
+        return entries
+
+
+class ResponseCache:
+    """Looks prompts up in the store with the configured key strategy."""
+    def __init__(self, embed=None):
+        url = os.getenv("AGENT_CACHE_URL")
+        backend = os.getenv("AGENT_CACHE_BACKEND", "memory")
+        self.store = RedisStore(url) if backend == "redis" and url else MemoryStore()
+        # Returns the embedding of a prompt, for the semantic strategy
+        self.embed = embed
+        logger.info(f"Response cache enabled with the {backend} backend and the {KEY_STRATEGY} key strategy")
+
+    async def lookup(self, prompt: str) -> tuple:
+        """Returns the cached entry of the prompt, or None, and the key and embedding to store its answer with."""
+        key = hashlib.sha256(normalize(prompt).encode()).hexdigest()
+        entry = await self.store.get(key)
+        if entry is not None or KEY_STRATEGY != "semantic" or self.embed is None:
+            return entry, key, None
+
+        embedding = await self.embed(prompt)
+        best, best_similarity = None, SIMILARITY_THRESHOLD
+        for candidate in (await self.store.all()).values():
+            if candidate.embedding is None:
+                continue
+            score = similarity(embedding, candidate.embedding)
+            if score >= best_similarity:
+                best, best_similarity = candidate, score
+        return best, key, embedding
+
+    async def store_answer(self, key: str, answer: str, prompt_tokens: int, completion_tokens: int, embedding: Optional[List[float]]):
+        await self.store.put(key, Entry(answer, prompt_tokens, completion_tokens, embedding))
@@ -0,0 +1,49 @@
+"""
+Client of the tool executor sidecar of an agent with spec.toolExecutor.
+
+The sidecar runs the commands of the tools, such as git or kubectl, that the agent runtime
+image does not ship. It serves its execution API on AGENT_TOOL_EXECUTOR_URL, a loopback
+address of the pod, and only runs the commands listed in AGENT_TOOL_EXECUTOR_COMMANDS. Both
+containers share AGENT_WORKSPACE, where commands run and exchange files with the agent.
+"""
+
+import logging
+import os
+from typing import Dict, List, Optional
+
+import httpx
+
+logger = logging.getLogger("tool_executor")
+
+EXECUTOR_URL = os.getenv("AGENT_TOOL_EXECUTOR_URL", "")
+COMMANDS = [c for c in os.getenv("AGENT_TOOL_EXECUTOR_COMMANDS", "").split(",") if c]
+WORKSPACE = os.getenv("AGENT_WORKSPACE", "/workspace")
+
+
+def enabled() -> bool:
+    return bool(EXECUTOR_URL)
+
+
+def executable(command: str) -> bool:
+    """Reports whether the sidecar runs command. The sidecar enforces the allowlist as well."""
+    return enabled() and command in COMMANDS
+
+
+def execute(command: str, args: Optional[List[str]] = None, dir: str = "", stdin: str = "") -> Dict:
+    """Runs command in the sidecar and returns its exitCode, stdout and stderr."""
+    response = httpx.post(
+        f"{EXECUTOR_URL}/execute",
+        json={"command": command, "args": args or [], "dir": dir, "stdin": stdin},
+        # The sidecar bounds the commands with the timeout of its configuration
+        timeout=None,
+    )
+    if response.status_code == 403:
+        raise PermissionError(f"command {command} is not allowed by the tool executor")
+    response.raise_for_status()
+    return response.json()
+
+
+def check() -> str:
+    response = httpx.get(f"{EXECUTOR_URL}/healthz", timeout=5)
+    response.raise_for_status()
+    return f"tool executor serves {len(COMMANDS)} commands"
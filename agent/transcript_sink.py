@@ -0,0 +1,141 @@
+"""
+Transcript sink of an agent with spec.transcriptSink.
+
+Every completed exchange, a prompt and its answer in a conversation, is queued as a
+transcript and posted in batches to the HTTPS URL of AGENT_TRANSCRIPT_SINK: once the batch
+holds batchSize transcripts, or once its oldest transcript waited maxWaitSeconds. Each
+request is signed with AGENT_TRANSCRIPT_SINK_SECRET in the X-KubeAgentic-Signature header,
+t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>">, and the rules of
+AGENT_TRANSCRIPT_REDACTION are applied to the transcripts before they are queued.
+
+A batch that fails is retried with the next one; the queue keeps at most MAX_BUFFERED
+transcripts and drops the oldest beyond. The operator reads the consecutive failures from
+kubeagentic_transcript_sink_consecutive_failures.
+"""
+
+import asyncio
+import hashlib
+import hmac
+import json
+import logging
+import os
+import re
+import time
+from collections import deque
+from datetime import datetime, timezone
+from typing import Any, Dict, Optional
+
+import httpx
+from prometheus_client import Counter, Gauge
+
+logger = logging.getLogger("transcript_sink")
+
+CONFIG = json.loads(os.getenv("AGENT_TRANSCRIPT_SINK") or "{}")
+SECRET = os.getenv("AGENT_TRANSCRIPT_SINK_SECRET", "").encode()
+REDACTION = json.loads(os.getenv("AGENT_TRANSCRIPT_REDACTION") or "{}")
+REDACTIONS = [(re.compile(rule["pattern"]), rule["replacement"]) for rule in REDACTION.get("rules") or []]
+
+BATCH_SIZE = int(CONFIG.get("batchSize") or 20)
+MAX_WAIT_SECONDS = float(CONFIG.get("maxWaitSeconds") or 10)
+INCLUDE_METADATA = bool(CONFIG.get("includeMetadata"))
+MAX_BUFFERED = 50 * BATCH_SIZE
+TIMEOUT_SECONDS = 10
+
+LABELS = ["agent", "namespace", "pod"]
+DELIVERED_TOTAL = Counter("kubeagentic_transcripts_delivered_total", "Transcripts accepted by the transcript sink.", LABELS)
+FAILED_TOTAL = Counter("kubeagentic_transcripts_failed_total", "Batches of transcripts the transcript sink did not accept.", LABELS)
+DROPPED_TOTAL = Counter("kubeagentic_transcripts_dropped_total", "Transcripts dropped while the transcript sink was failing.", LABELS)
+CONSECUTIVE_FAILURES = Gauge("kubeagentic_transcript_sink_consecutive_failures", "Batches of transcripts the transcript sink did not accept in a row.", LABELS)
+
+# Labels of the metrics, as in the rest of the runtime
+identity = {"agent": os.getenv("AGENT_NAME", "agent"), "namespace": os.getenv("AGENT_NAMESPACE", "default"), "pod": os.getenv("POD_NAME", "")}
+
+queue: deque = deque()
+consecutive_failures = 0
+
+
+def enabled() -> bool:
+    return bool(CONFIG.get("url"))
+
+
+def sign(timestamp: int, body: bytes) -> str:
+    """Returns the X-KubeAgentic-Signature header of body sent at timestamp."""
+    signature = hmac.new(SECRET, f"{timestamp}.".encode() + body, hashlib.sha256).hexdigest()
+    return f"t={timestamp},v1={signature}"
+
+
+def redact(text: str) -> str:
+    for pattern, replacement in REDACTIONS:
+        # Replacements are literal, as in the operator
+        text = pattern.sub(lambda _, replacement=replacement: replacement, text)
+    return text
+
+
+def record(conversation_id: str, prompt: str, answer: str, metadata: Optional[Dict[str, Any]] = None) -> None:
+    """Queues the transcript of a completed exchange."""
+    transcript: Dict[str, Any] = {
+        "conversation_id": conversation_id,
+        "prompt": redact(prompt),
+        "answer": redact(answer),
+        "timestamp": datetime.now(timezone.utc).isoformat(),
+    }
+    if INCLUDE_METADATA and metadata:
+        transcript["metadata"] = metadata
+    if len(queue) >= MAX_BUFFERED:
+        queue.popleft()
+        DROPPED_TOTAL.labels(**identity).inc()
+    queue.append((time.monotonic(), transcript))
+
+
+async def post(client: httpx.AsyncClient, transcripts) -> None:
+    timestamp = int(time.time())
+    body = json.dumps({
+        "type": "transcripts",
+        "agent": identity["agent"],
+        "namespace": identity["namespace"],
+        "sentAt": datetime.fromtimestamp(timestamp, timezone.utc).isoformat().replace("+00:00", "Z"),
+        "transcripts": transcripts,
+    }).encode()
+    response = await client.post(CONFIG["url"], content=body, headers={
+        "Content-Type": "application/json",
+        "X-KubeAgentic-Event": "transcripts",
+        "X-KubeAgentic-Signature": sign(timestamp, body),
+    })
+    response.raise_for_status()
+
+
+async def flush(client: httpx.AsyncClient) -> None:
+    """Posts the next batch, keeping it queued when the sink does not accept it."""
+    global consecutive_failures
+    # Taken off the queue while posted, so that the transcripts dropped meanwhile are not from the batch
+    entries = [queue.popleft() for _ in range(min(BATCH_SIZE, len(queue)))]
+    batch = [transcript for _, transcript in entries]
+    try:
+        await post(client, batch)
+    except Exception as e:
+        consecutive_failures += 1
+        FAILED_TOTAL.labels(**identity).inc()
+        CONSECUTIVE_FAILURES.labels(**identity).set(consecutive_failures)
+        logger.warning(f"Failed to deliver {len(batch)} transcripts to the sink ({consecutive_failures} in a row): {e}")
+        queue.extendleft(reversed(entries))
+        while len(queue) > MAX_BUFFERED:
+            queue.popleft()
+            DROPPED_TOTAL.labels(**identity).inc()
+        return
+    consecutive_failures = 0
+    DELIVERED_TOTAL.labels(**identity).inc(len(batch))
+    CONSECUTIVE_FAILURES.labels(**identity).set(0)
+
+
+async def run() -> None:
+    """Posts the queued transcripts until cancelled."""
+    CONSECUTIVE_FAILURES.labels(**identity).set(0)
+    async with httpx.AsyncClient(timeout=TIMEOUT_SECONDS) as client:
+        while True:
+            if queue and (len(queue) >= BATCH_SIZE or time.monotonic() - queue[0][0] >= MAX_WAIT_SECONDS):
+                await flush(client)
+                # Back off while the sink fails, so that the batches are not retried in a loop
+                if consecutive_failures:
+                    await asyncio.sleep(min(2 ** consecutive_failures, 60))
+                continue
+            await asyncio.sleep(0.5)
@@ -0,0 +1,133 @@
+#!/usr/bin/env python3
+"""
+Wait for the model endpoint of an agent to answer.
+
+Runs as the wait-for-endpoint init container of agents served by self-hosted model
+servers, which load their weights for minutes after starting. It polls the models route
+of AGENT_ENDPOINT with exponential backoff until the server answers or
+AGENT_ENDPOINT_WAIT_TIMEOUT_SECONDS pass. Agents failing over between the endpoints of
+AGENT_ENDPOINTS wait until one of them answers. Proxies are taken from HTTP_PROXY, HTTPS_PROXY
+and NO_PROXY, and SSL_CERT_FILE or REQUESTS_CA_BUNDLE add trusted certificates.
+
+With --preload it runs as the preload-model init container instead, and sends a small
+chat completion request for AGENT_MODEL, so the server loads the weights and captures its
+CUDA graphs before the agent serves users. Failed requests are retried with backoff
+AGENT_PRELOAD_RETRIES times within AGENT_PRELOAD_TIMEOUT_SECONDS, each through the endpoints
+in order until one generates.
+"""
+
+import json
+import os
+import ssl
+import sys
+import time
+import urllib.error
+import urllib.request
+
+TERMINATION_LOG = "/dev/termination-log"
+MAX_BACKOFF_SECONDS = 30
+
+
+def probe(url: str, context: ssl.SSLContext) -> str:
+    """Returns an empty string once the server answers, or why it did not."""
+    try:
+        with urllib.request.urlopen(url, timeout=10, context=context):
+            return ""
+    except urllib.error.HTTPError as e:
+        # Any answer but a server error means the server is up, even one requiring a key
+        return "" if e.code < 500 else f"HTTP {e.code}"
+    except Exception as e:
+        return str(getattr(e, "reason", e))
+
+
+def generate(url: str, context: ssl.SSLContext, timeout: float) -> str:
+    """Returns an empty string once the server generated an answer, or why it did not."""
+    body = json.dumps({
+        "model": os.environ["AGENT_MODEL"],
+        "messages": [{"role": "user", "content": os.getenv("AGENT_PRELOAD_PROMPT") or "Say hello."}],
+        "max_tokens": int(os.getenv("AGENT_PRELOAD_MAX_TOKENS", "16")),
+    }).encode()
+    headers = {"Content-Type": "application/json"}
+    if os.getenv("AGENT_API_KEY"):
+        headers["Authorization"] = f"Bearer {os.environ['AGENT_API_KEY']}"
+    request = urllib.request.Request(url, data=body, headers=headers, method="POST")
+    try:
+        with urllib.request.urlopen(request, timeout=timeout, context=context):
+            return ""
+    except urllib.error.HTTPError as e:
+        return f"HTTP {e.code}: {e.read(200).decode(errors='replace')}"
+    except Exception as e:
+        return str(getattr(e, "reason", e))
+
+
+def fail(message: str) -> int:
+    print(message, file=sys.stderr, flush=True)
+    try:
+        with open(TERMINATION_LOG, "w") as f:
+            f.write(message)
+    except OSError:
+        pass
+    return 1
+
+
+def endpoints() -> list:
+    """Returns the endpoint URLs by priority, from AGENT_ENDPOINTS or AGENT_ENDPOINT."""
+    listed = json.loads(os.getenv("AGENT_ENDPOINTS") or "[]")
+    if listed:
+        return [endpoint["url"].rstrip("/") for endpoint in listed]
+    return [os.environ["AGENT_ENDPOINT"].rstrip("/")]
+
+
+def preload(urls: list, context: ssl.SSLContext) -> int:
+    retries = int(os.getenv("AGENT_PRELOAD_RETRIES", "3"))
+    deadline = time.monotonic() + int(os.getenv("AGENT_PRELOAD_TIMEOUT_SECONDS", "600"))
+    backoff = 1
+    for attempt in range(retries + 1):
+        remaining = deadline - time.monotonic()
+        if remaining <= 0:
+            return fail(f"timed out after {attempt} attempts to preload through {', '.join(urls)}")
+        for endpoint in urls:
+            url = f"{endpoint}/chat/completions"
+            print(f"Preloading model {os.environ['AGENT_MODEL']} through {url}", flush=True)
+            error = generate(url, context, max(0, deadline - time.monotonic()))
+            if not error:
+                print("Model preloaded", flush=True)
+                return 0
+        if attempt < retries:
+            print(f"Preload request failed: {error}", flush=True)
+            time.sleep(max(0, min(backoff, deadline - time.monotonic())))
+            backoff = min(backoff * 2, MAX_BACKOFF_SECONDS)
+    return fail(f"last error from {url}: {error}")
+
+
+def main() -> int:
+    urls = endpoints()
+    timeout = int(os.getenv("AGENT_ENDPOINT_WAIT_TIMEOUT_SECONDS", "600"))
+
+    context = ssl.create_default_context()
+    ca_file = os.getenv("SSL_CERT_FILE") or os.getenv("REQUESTS_CA_BUNDLE")
+    if ca_file:
+        context.load_verify_locations(cafile=ca_file)
+
+    if "--preload" in sys.argv[1:]:
+        return preload(urls, context)
+
+    deadline = time.monotonic() + timeout
+    backoff = 1
+    while True:
+        for endpoint in urls:
+            url = f"{endpoint}/models"
+            error = probe(url, context)
+            if not error:
+                print(f"Model endpoint {url} is ready", flush=True)
+                return 0
+        remaining = deadline - time.monotonic()
+        if remaining <= 0:
+            return fail(f"last error from {url}: {error}")
+        print(f"Waiting for the model endpoint {url}: {error}", flush=True)
+        time.sleep(min(backoff, remaining))
+        backoff = min(backoff * 2, MAX_BACKOFF_SECONDS)
+
+
+if __name__ == "__main__":
+    sys.exit(main())
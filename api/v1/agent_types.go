@@ -2,6 +2,8 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -16,21 +18,62 @@ type AgentSpec struct {
 
 	// Model specifies the specific model to use from the selected provider.
 	// For example, "gpt-4" for OpenAI or "claude-2" for Anthropic.
+	// The defaulting webhook sets the provider's default model when it is empty and
+	// records it in the kubeagentic.ai/defaulted-model annotation.
 	Model string `json:"model"`
 
+	// ModelSchedule switches the model of the agent during recurring time windows, such as
+	// to a cheaper model at night and on weekends. Outside the windows the agent serves with
+	// spec.model. It cannot be combined with spec.rollout or spec.experiment.
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	ModelSchedule []ModelScheduleEntry `json:"modelSchedule,omitempty"`
+
 	// SystemPrompt defines the agent's persona, behavior, and instructions.
 	// It's a crucial part of the agent's configuration that guides its responses.
 	SystemPrompt string `json:"systemPrompt"`
 
+	// Examples are example exchanges sent to the model as few-shot context, between the
+	// system prompt and the conversation.
+	// +kubebuilder:validation:MaxItems=50
+	// +optional
+	Examples []Example `json:"examples,omitempty"`
+
+	// ExamplesRef selects a key of a ConfigMap in the namespace of the agent holding a JSON
+	// or YAML list of examples, for sets too large for spec.examples. It cannot be combined
+	// with spec.examples.
+	// +optional
+	ExamplesRef *corev1.ConfigMapKeySelector `json:"examplesRef,omitempty"`
+
 	// ApiSecretRef references a Kubernetes Secret that holds the API credentials for the provider.
-	// The secret must contain a key with the API key.
-	ApiSecretRef corev1.SecretKeySelector `json:"apiSecretRef"`
+	// The secret must contain a key with the API key. It may be omitted for vllm, ollama and
+	// OpenAI-compatible servers reached through Endpoint, which often need no credentials.
+	// +optional
+	ApiSecretRef *SecretKeyReference `json:"apiSecretRef,omitempty"`
+
+	// NameOverrides sets the names of the resources created for the agent, for example to
+	// keep the name of a Service existing clients use. Unset names are derived from the
+	// agent name.
+	// +optional
+	NameOverrides *NameOverrides `json:"nameOverrides,omitempty"`
 
 	// Endpoint is an optional field to specify a custom endpoint URL.
 	// This is particularly useful for self-hosted models like vLLM.
 	// +optional
 	Endpoint string `json:"endpoint,omitempty"`
 
+	// Endpoints are the endpoint URLs of a provider served by several model servers, used
+	// instead of Endpoint. The runtime sends requests to the healthy endpoints of the lowest
+	// priority, and fails over to the next priority when they all fail. The health check
+	// reports which endpoint is active in status.health.endpoints.
+	// +optional
+	Endpoints []ProviderEndpoint `json:"endpoints,omitempty"`
+
+	// VLLM tunes the micro-batching of the requests the runtime sends to a vLLM server.
+	// Only valid for the vllm provider.
+	// +optional
+	VLLM *VLLMConfig `json:"vllm,omitempty"`
+
 	// Framework specifies which framework to use for agent execution.
 	// "direct" uses simple API calls, "langgraph" enables complex workflows.
 	// +kubebuilder:validation:Enum=direct;langgraph
@@ -45,6 +88,7 @@ type AgentSpec struct {
 
 	// Tools is a list of tools that the agent can use to perform actions.
 	// Each tool has a name, description, and an optional input schema.
+	// +kubebuilder:validation:MaxItems=50
 	// +optional
 	Tools []Tool `json:"tools,omitempty"`
 
@@ -53,10 +97,22 @@ type AgentSpec struct {
 	// +optional
 	Image string `json:"image,omitempty"`
 
+	// ImagePullSecrets are Secrets in the namespace of the agent used to pull the images of
+	// the agent pods from private registries.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// ImagePullPolicy is the pull policy of the agent image. Defaults to Always for images
+	// tagged latest or untagged, IfNotPresent otherwise.
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	// +optional
+	ImagePullPolicy corev1.PullPolicy `json:"imagePullPolicy,omitempty"`
+
 	// Replicas is the number of agent pod replicas to run.
-	// Defaults to 1 if not specified.
+	// Defaults to 1 if not specified. The operator configuration and the AgentPolicies of
+	// the namespace set the maximum, 10 by default; the schema only rejects values above 1000.
 	// +kubebuilder:validation:Minimum=1
-	// +kubebuilder:validation:Maximum=10
+	// +kubebuilder:validation:Maximum=1000
 	// +kubebuilder:default=1
 	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
@@ -66,174 +122,2950 @@ type AgentSpec struct {
 	// +optional
 	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
 
+	// Size selects a preset of resource requests and limits for the agent container,
+	// configured by the operator. Agents of self-hosted providers (vllm, ollama) get
+	// larger presets than agents of hosted providers. The preset is copied into
+	// Resources, which must not be set otherwise.
+	// +kubebuilder:validation:Enum=small;medium;large;xlarge
+	// +optional
+	Size string `json:"size,omitempty"`
+
+	// Scratch mounts a bounded emptyDir into the agent container for the temporary files of
+	// the runtime, such as the documents downloaded for RAG, at the path of AGENT_TMP_DIR.
+	// +optional
+	Scratch *ScratchConfig `json:"scratch,omitempty"`
+
 	// ServiceType specifies the type of Kubernetes service to create for the agent endpoint.
 	// It can be ClusterIP, NodePort, or LoadBalancer. Defaults to ClusterIP.
 	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
 	// +kubebuilder:default=ClusterIP
 	// +optional
 	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
-}
 
-// Tool defines a tool that is available to the agent.
-// Tools allow agents to interact with external systems and perform actions.
-type Tool struct {
-	// Name is the unique identifier for the tool.
-	Name string `json:"name"`
+	// Ingress sets the host and path of the Ingress exposing the agent, which is created
+	// whatever the serviceType when it is set. LoadBalancer agents without it are served at
+	// <name>.<namespace>.local. No two agents may claim the same host with overlapping paths.
+	// +optional
+	Ingress *IngressConfig `json:"ingress,omitempty"`
 
-	// Description is a human-readable explanation of what the tool does.
-	// This is used by the agent to decide when to use the tool.
-	Description string `json:"description"`
+	// RAG configures retrieval-augmented generation for the agent.
+	// When enabled, the agent is connected to the configured vector store.
+	// +optional
+	RAG *RAGConfig `json:"rag,omitempty"`
 
-	// InputSchema is a JSON schema that describes the input parameters for the tool.
-	// This helps the agent to correctly format the input for the tool.
+	// ModelCache mounts the operator's shared model weight cache into the agent pods,
+	// so that replicas do not re-download model weights on startup.
 	// +optional
-	InputSchema *runtime.RawExtension `json:"inputSchema,omitempty"`
-}
+	ModelCache *ModelCacheConfig `json:"modelCache,omitempty"`
 
-// LanggraphConfig defines the configuration for LangGraph workflows
-type LanggraphConfig struct {
-	// GraphType specifies the type of LangGraph workflow
-	// +kubebuilder:validation:Enum=sequential;parallel;conditional;hierarchical
-	GraphType string `json:"graphType"`
+	// Export archives conversation transcripts to S3-compatible object storage on a schedule.
+	// +optional
+	Export *ExportConfig `json:"export,omitempty"`
 
-	// Nodes defines the workflow nodes
-	Nodes []WorkflowNode `json:"nodes"`
+	// Persistence gives the agent a PersistentVolumeClaim of its own for its conversation
+	// state, mounted at /var/lib/kubeagentic, with scheduled VolumeSnapshot backups and
+	// restores from them.
+	// +optional
+	Persistence *PersistenceConfig `json:"persistence,omitempty"`
 
-	// Edges defines the workflow edges
-	Edges []WorkflowEdge `json:"edges"`
+	// Memory selects where the agent runtime keeps conversation history.
+	// +optional
+	Memory *MemoryConfig `json:"memory,omitempty"`
 
-	// State defines the state schema for the workflow
-	State *runtime.RawExtension `json:"state,omitempty"`
+	// Caching answers repeated single-turn prompts from a cache instead of the provider.
+	// +optional
+	Caching *CachingConfig `json:"caching,omitempty"`
 
-	// Entrypoint specifies the entry node for the workflow
-	Entrypoint string `json:"entrypoint"`
+	// Routing configures how requests are spread across the agent replicas.
+	// +optional
+	Routing *RoutingConfig `json:"routing,omitempty"`
 
-	// Endpoints specifies possible end nodes for the workflow
-	Endpoints []string `json:"endpoints,omitempty"`
-}
+	// Concurrency bounds the requests each agent pod handles at once. Requests beyond the
+	// bound wait in a queue, and are rejected once the queue is full, so that a traffic spike
+	// is shed instead of exhausting the memory of the pods.
+	// +optional
+	Concurrency *ConcurrencyConfig `json:"concurrency,omitempty"`
 
-// WorkflowNode defines a node in the LangGraph workflow
-type WorkflowNode struct {
-	// Name is the unique identifier for the node
-	Name string `json:"name"`
+	// InboundRateLimit limits the requests each client sends to the agent. A proxy sidecar
+	// enforces the rules in front of the agent container, and the agent Service targets it.
+	// +optional
+	InboundRateLimit *InboundRateLimitConfig `json:"inboundRateLimit,omitempty"`
 
-	// Type specifies the type of node
-	// +kubebuilder:validation:Enum=llm;tool;action
-	Type string `json:"type"`
+	// Guardrails checks the prompts sent to the agent and its responses against a policy,
+	// such as to block prompt injections and flag toxic output. A proxy sidecar enforces
+	// the policy in front of the agent container, behind the rate limit proxy.
+	// +optional
+	Guardrails *GuardrailsConfig `json:"guardrails,omitempty"`
 
-	// Prompt is the template for LLM nodes
-	Prompt string `json:"prompt,omitempty"`
+	// NetworkPolicy restricts the traffic to and from the agent pods.
+	// +optional
+	NetworkPolicy *NetworkPolicyConfig `json:"networkPolicy,omitempty"`
 
-	// Tool is the tool name for tool nodes
-	Tool string `json:"tool,omitempty"`
+	// EndpointAuth protects the agent endpoint with a bearer token.
+	// +optional
+	EndpointAuth *EndpointAuthConfig `json:"endpointAuth,omitempty"`
 
-	// Action is the action to execute for action nodes
-	Action string `json:"action,omitempty"`
+	// AutomountServiceAccountToken mounts the ServiceAccount token into the agent pods.
+	// Defaults to false, as agents do not talk to the Kubernetes API.
+	// +optional
+	AutomountServiceAccountToken *bool `json:"automountServiceAccountToken,omitempty"`
 
-	// Condition is the conditional logic for conditional nodes
-	Condition string `json:"condition,omitempty"`
+	// Disruption controls how node consolidation by the cluster autoscaler or Karpenter
+	// treats the agent pods.
+	// +optional
+	Disruption *DisruptionConfig `json:"disruption,omitempty"`
 
-	// Inputs are the input fields from state
-	Inputs []string `json:"inputs,omitempty"`
+	// Encryption configures the key the runtime uses to encrypt conversation and checkpoint
+	// records before persisting them.
+	// +optional
+	Encryption *EncryptionConfig `json:"encryption,omitempty"`
 
-	// Outputs are the output fields to state
-	Outputs []string `json:"outputs,omitempty"`
+	// SecurityProfile is the Pod Security Standards level the generated pods comply with.
+	// "restricted" hardens every container; configurations that would violate the level are
+	// rejected. Defaults to the operator's DEFAULT_SECURITY_PROFILE.
+	// +kubebuilder:validation:Enum=baseline;restricted
+	// +optional
+	SecurityProfile string `json:"securityProfile,omitempty"`
+
+	// PodTemplateOverrides is a strategic merge patch applied to the generated pod template
+	// after everything else, for pod fields the Agent does not model. Overrides are
+	// best-effort: they may conflict with fields the operator renders in later versions.
+	// The agent container name, its API key and the labels of the pods cannot be changed.
+	// +kubebuilder:pruning:PreserveUnknownFields
+	// +optional
+	PodTemplateOverrides *runtime.RawExtension `json:"podTemplateOverrides,omitempty"`
+
+	// Rollout controls how changes to the agent pods, such as a new model or system prompt,
+	// are rolled out. Without it, changes replace the pods in a regular rolling update.
+	// +optional
+	Rollout *RolloutConfig `json:"rollout,omitempty"`
+
+	// Experiment runs an A/B experiment comparing the agent configuration, variant A, with
+	// the overrides of variant B.
+	// +optional
+	Experiment *ExperimentConfig `json:"experiment,omitempty"`
+
+	// RevisionHistoryLimit is the number of Agent spec revisions kept as ControllerRevisions.
+	// The kubeagentic.ai/rollback-to annotation restores the spec of a kept revision.
+	// Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// Warmup sends warm-up requests to new agent pods before they are marked ready, so a
+	// rollout does not cause a latency spike.
+	// +optional
+	Warmup *WarmupConfig `json:"warmup,omitempty"`
+
+	// Synthetics sends a prompt to the agent Service on a schedule and checks the answer,
+	// verifying the agent end to end independently of pod readiness.
+	// +optional
+	Synthetics *SyntheticsConfig `json:"synthetics,omitempty"`
+
+	// HealthCheck polls the detailed health of the agent runtime through its Service, and
+	// reports whether the runtime reaches its provider, memory backend and tools in the
+	// AgentHealthy condition.
+	// +optional
+	HealthCheck *HealthCheckConfig `json:"healthCheck,omitempty"`
+
+	// Budget caps the spend of the agent on LLM tokens per period.
+	// +optional
+	Budget *BudgetConfig `json:"budget,omitempty"`
+
+	// TokenQuota caps the tokens the agent consumes per day.
+	// +optional
+	TokenQuota *TokenQuotaConfig `json:"tokenQuota,omitempty"`
+
+	// Metrics configures how Prometheus discovers the metrics of the agent pods.
+	// +optional
+	Metrics *MetricsConfig `json:"metrics,omitempty"`
+
+	// LogLevel is the verbosity of the agent runtime logs. The kubeagentic.ai/log-level
+	// annotation overrides it temporarily. Defaults to info.
+	// +kubebuilder:validation:Enum=debug;info;warn;error
+	// +optional
+	LogLevel string `json:"logLevel,omitempty"`
+
+	// Debug logs debug messages and the bodies of chat requests and responses, with API
+	// keys, bearer tokens and e-mail addresses redacted.
+	// +optional
+	Debug bool `json:"debug,omitempty"`
+
+	// DependsOn lists the Agents and Services that must be ready before the agent pods
+	// start. The Deployment is held at zero replicas until they all are; dependencies that
+	// become unready later are reported but do not stop running pods.
+	// +optional
+	DependsOn []AgentDependency `json:"dependsOn,omitempty"`
+
+	// WaitForEndpoint holds the agent container in an init container until the model
+	// endpoint answers, so that agent pods do not crash-loop while a model server loads its
+	// weights. Defaults to true for vllm and ollama agents with an endpoint.
+	// +optional
+	WaitForEndpoint *bool `json:"waitForEndpoint,omitempty"`
+
+	// WaitForEndpointTimeout bounds the wait for the model endpoint, after which the init
+	// container fails and is restarted with backoff. Defaults to 10m.
+	// +optional
+	WaitForEndpointTimeout *metav1.Duration `json:"waitForEndpointTimeout,omitempty"`
+
+	// Peers are the agents this agent talks to. Their URLs are rendered into peers.json,
+	// mounted with the endpoint tokens of the peers requiring them under
+	// /etc/kubeagentic/peers, and the agent pods roll when a peer moves or rotates its token.
+	// +optional
+	Peers []AgentPeer `json:"peers,omitempty"`
+
+	// Preload sends a generation request to the model endpoint before the agent container
+	// starts, so that the first request of a user does not pay for loading the weights and
+	// capturing the CUDA graphs. Only applies to vllm and ollama agents with an endpoint.
+	// +optional
+	Preload *PreloadConfig `json:"preload,omitempty"`
+
+	// Connectors bridge chat platforms such as Slack and Discord to the agent. They run in
+	// a connector sidecar of the agent pods, which forwards the messages that trigger the
+	// agent to it and posts its replies back.
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	Connectors []AgentConnector `json:"connectors,omitempty"`
+
+	// ToolExecutor runs the tools that need binaries, such as git or kubectl, in a sidecar
+	// of the agent pods built from an image of the user, instead of in the agent runtime.
+	// The sidecar shares a workspace with the agent container and only runs the allowed
+	// commands.
+	// +optional
+	ToolExecutor *ToolExecutorConfig `json:"toolExecutor,omitempty"`
+
+	// EventSource makes the agent consume its work from a Kafka topic or an SQS queue
+	// instead of serving it over HTTP. The agent keeps serving its probes and metrics.
+	// +optional
+	EventSource *EventSourceConfig `json:"eventSource,omitempty"`
+
+	// WorkerMode runs long jobs, such as report generation, in a worker Deployment of the
+	// agent fed by a job queue, so they do not block the HTTP requests. The agent Deployment
+	// keeps serving requests and enqueues the jobs; spec.replicas only applies to it.
+	// +optional
+	WorkerMode *WorkerModeConfig `json:"workerMode,omitempty"`
+
+	// Binding configures the Secret publishing the URLs and the endpoint token of the agent
+	// to the applications calling it, in the layout of the Service Binding specification.
+	// The Secret is created unless disabled.
+	// +optional
+	Binding *BindingConfig `json:"binding,omitempty"`
+
+	// AdminPort serves the operational endpoints of the agent runtime, such as config
+	// reload, memory flush and debug dumps, on a port of their own, apart from the chat API
+	// and protected by their own generated token. The probes and the health checks of the
+	// operator use it instead of the chat port.
+	// +optional
+	AdminPort *AdminPortConfig `json:"adminPort,omitempty"`
+
+	// Redaction removes personal data, such as e-mail addresses, phone numbers and account
+	// IDs, from the logs of the agent, the audit records of its tool executor, its
+	// conversation exports and its transcripts before they are written.
+	// +optional
+	Redaction *RedactionConfig `json:"redaction,omitempty"`
+
+	// TranscriptSink pushes a copy of every completed exchange of the conversations of the
+	// agent, in signed batches, to an HTTPS endpoint such as an analytics platform. The
+	// transcripts are redacted by spec.redaction when it applies to transcripts.
+	// +optional
+	TranscriptSink *TranscriptSinkConfig `json:"transcriptSink,omitempty"`
+
+	// SLO defines the service level objectives of the agent. The operator generates their
+	// recording rules and multiwindow, multi-burn-rate alerts in a PrometheusRule, and reports
+	// the error budget left in the SLOCompliant condition when it is given a Prometheus URL.
+	// +optional
+	SLO *SLOConfig `json:"slo,omitempty"`
+
+	// PodLabels are added to the labels of the agent pods, such as cost-allocation labels.
+	// The labels set by the operator win on conflict and alone select the pods.
+	// +optional
+	PodLabels map[string]string `json:"podLabels,omitempty"`
+
+	// PodAnnotations are added to the annotations of the agent pods, such as the annotations
+	// injecting Istio or Vault agent sidecars. The annotations set by the operator win on
+	// conflict. Changing them rolls the pods.
+	// +optional
+	PodAnnotations map[string]string `json:"podAnnotations,omitempty"`
+
+	// NodeSelector restricts the agent pods to the nodes with these labels, such as GPU nodes.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// Tolerations let the agent pods run on tainted nodes.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+
+	// Affinity constrains the nodes and the pods the agent pods are scheduled by. The terms
+	// of spec.colocateWith and spec.spreadFrom are added to it.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+
+	// TopologySpreadConstraints spread the agent pods across topology domains, such as nodes
+	// or zones. Constraints without a label selector spread the serving pods of the agent.
+	// The defaulting webhook spreads agents of more than one replica across nodes when it is
+	// empty.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+
+	// PriorityClassName is the PriorityClass of the agent pods, such as a high priority
+	// keeping production agents from being preempted or evicted under node pressure.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+
+	// SchedulerName is the scheduler placing the agent pods, such as a custom scheduler
+	// bin-packing GPU nodes. The default scheduler is used when it is empty.
+	// +optional
+	SchedulerName string `json:"schedulerName,omitempty"`
+
+	// ColocateWith schedules the pods of the agent in the same topology domain as the pods
+	// of other agents of the namespace, such as a responder next to its retrieval agent.
+	// +kubebuilder:validation:MaxItems=5
+	// +optional
+	ColocateWith []AgentPlacement `json:"colocateWith,omitempty"`
+
+	// SpreadFrom schedules the pods of the agent away from the pods of other agents of the
+	// namespace.
+	// +kubebuilder:validation:MaxItems=5
+	// +optional
+	SpreadFrom []AgentPlacement `json:"spreadFrom,omitempty"`
+
+	// TTL deletes the agent this long after its creation, such as the preview agents CI
+	// creates for pull requests. The kubeagentic.ai/extend-ttl annotation pushes the deadline
+	// back. Agents labeled kubeagentic.ai/environment=production may not set it.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// ExpireAt deletes the agent at this time. With ttl, the earlier of both applies.
+	// +optional
+	ExpireAt *metav1.Time `json:"expireAt,omitempty"`
 }
 
-// WorkflowEdge defines an edge in the LangGraph workflow
-type WorkflowEdge struct {
-	// From is the source node name
-	From string `json:"from"`
+// AgentPlacement places the pods of an agent relative to the pods of another Agent.
+type AgentPlacement struct {
+	// AgentRef is the name of the other Agent, in the namespace of the agent.
+	// +kubebuilder:validation:MinLength=1
+	AgentRef string `json:"agentRef"`
 
-	// To is the target node name
-	To string `json:"to"`
+	// TopologyKey is the node label whose value defines the domain, such as
+	// topology.kubernetes.io/zone. Defaults to kubernetes.io/hostname, the node.
+	// +optional
+	TopologyKey string `json:"topologyKey,omitempty"`
 
-	// Condition is the conditional logic for the edge
-	Condition string `json:"condition,omitempty"`
+	// Mode is required for a hard scheduling constraint, or preferred for a soft one.
+	// +kubebuilder:validation:Enum=required;preferred
+	// +kubebuilder:default=preferred
+	// +optional
+	Mode PlacementMode `json:"mode,omitempty"`
 }
 
-// AgentConditionType represents the type of an Agent's condition.
-type AgentConditionType string
+// PlacementMode tells whether a placement constrains the scheduler or guides it.
+type PlacementMode string
 
 const (
-	// AgentConditionReady indicates that the agent is ready to serve requests.
-	AgentConditionReady AgentConditionType = "Ready"
-	// AgentConditionProgressing indicates that the agent's deployment is in progress.
-	AgentConditionProgressing AgentConditionType = "Progressing"
-	// AgentConditionDegraded indicates that the agent is in a degraded state.
-	AgentConditionDegraded AgentConditionType = "Degraded"
+	PlacementRequired  PlacementMode = "required"
+	PlacementPreferred PlacementMode = "preferred"
 )
 
-// AgentCondition represents the condition of an Agent.
-// It provides more detailed information about the agent's state.
-type AgentCondition struct {
-	// Type of the condition.
-	Type AgentConditionType `json:"type"`
+// RedactionConfig defines the personal data removed from what an agent writes.
+type RedactionConfig struct {
+	// Builtin enables the redaction rules shipped with the operator.
+	// +optional
+	Builtin RedactionBuiltins `json:"builtin,omitempty"`
 
-	// Status of the condition, one of True, False, Unknown.
-	Status corev1.ConditionStatus `json:"status"`
+	// Rules are custom redaction rules, applied after the builtin ones in order.
+	// +kubebuilder:validation:MaxItems=20
+	// +optional
+	Rules []RedactionRule `json:"rules,omitempty"`
 
-	// Reason is a brief, machine-readable reason for the condition's last transition.
+	// ApplyTo lists what is redacted: logs, the logs of the agent runtime; toolAudit, the
+	// audit records of the tool executor; exports, the conversation exports; transcripts,
+	// the transcripts pushed to spec.transcriptSink. Defaults to all four.
 	// +optional
-	Reason string `json:"reason,omitempty"`
+	ApplyTo []RedactionTarget `json:"applyTo,omitempty"`
+}
 
-	// Message is a human-readable message indicating details about the last transition.
+// RedactionBuiltins toggles the builtin redaction rules.
+type RedactionBuiltins struct {
+	// Email replaces e-mail addresses with [REDACTED_EMAIL].
 	// +optional
-	Message string `json:"message,omitempty"`
+	Email bool `json:"email,omitempty"`
 
-	// LastTransitionTime is the last time the condition transitioned from one status to another.
+	// Phone replaces phone numbers with [REDACTED_PHONE].
 	// +optional
-	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+	Phone bool `json:"phone,omitempty"`
+
+	// SSN replaces US social security numbers with [REDACTED_SSN].
+	// +optional
+	SSN bool `json:"ssn,omitempty"`
 }
 
-// AgentPhase represents the lifecycle phase of an Agent.
-type AgentPhase string
+// RedactionRule replaces the matches of a regular expression.
+type RedactionRule struct {
+	// Name identifies the rule in validation errors.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Pattern is an RE2 regular expression, without Unicode classes, which the agent
+	// runtime does not support. Nested repetitions such as (a+)+ are rejected, as they may
+	// backtrack catastrophically in the runtime.
+	// +kubebuilder:validation:MaxLength=256
+	Pattern string `json:"pattern"`
+
+	// Replacement is inserted literally in place of each match. Defaults to [REDACTED].
+	// +optional
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// RedactionTarget is something an agent writes that can be redacted.
+// +kubebuilder:validation:Enum=logs;toolAudit;exports;transcripts
+type RedactionTarget string
 
 const (
-	// AgentPhasePending means the agent is being created and is not yet ready.
-	AgentPhasePending AgentPhase = "Pending"
-	// AgentPhaseRunning means the agent is running and ready to serve requests.
-	AgentPhaseRunning AgentPhase = "Running"
-	// AgentPhaseFailed means the agent has encountered an error and is not running.
-	AgentPhaseFailed AgentPhase = "Failed"
-	// AgentPhaseSucceeded is not currently used but is reserved for future use.
-	AgentPhaseSucceeded AgentPhase = "Succeeded"
+	// RedactionTargetLogs redacts the logs of the agent runtime.
+	RedactionTargetLogs RedactionTarget = "logs"
+	// RedactionTargetToolAudit redacts the audit records of the tool executor.
+	RedactionTargetToolAudit RedactionTarget = "toolAudit"
+	// RedactionTargetExports redacts the conversation exports.
+	RedactionTargetExports RedactionTarget = "exports"
+	// RedactionTargetTranscripts redacts the transcripts pushed to the transcript sink.
+	RedactionTargetTranscripts RedactionTarget = "transcripts"
 )
 
-// ReplicaStatus represents the status of the agent's replicas.
-type ReplicaStatus struct {
-	// Ready is the number of replicas that are ready to serve requests.
-	Ready int32 `json:"ready"`
+// TranscriptSinkConfig defines the HTTPS endpoint receiving the transcripts of an agent.
+type TranscriptSinkConfig struct {
+	// URL is the HTTPS endpoint the transcripts are posted to.
+	// +kubebuilder:validation:Pattern=`^https://`
+	URL string `json:"url"`
 
-	// Desired is the desired number of replicas.
-	Desired int32 `json:"desired"`
+	// AuthSecretRef selects the key of a Secret in the namespace of the agent holding the
+	// secret shared with the sink. Every request carries an X-KubeAgentic-Signature header,
+	// t=<unix time>,v1=<hex HMAC-SHA256 of "<unix time>.<body>" with the secret>.
+	AuthSecretRef corev1.SecretKeySelector `json:"authSecretRef"`
 
-	// Available is the number of replicas that are available.
-	Available int32 `json:"available"`
+	// Batch bounds the transcripts posted in a request.
+	// +optional
+	Batch *TranscriptBatchConfig `json:"batch,omitempty"`
+
+	// IncludeMetadata adds the provider, the model, the pod, the experiment variant and the
+	// tokens of each exchange to its transcript.
+	// +optional
+	IncludeMetadata bool `json:"includeMetadata,omitempty"`
+
+	// FailureThreshold is the number of consecutive batches a pod fails to deliver before
+	// the TranscriptSinkReachable condition turns False. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
 }
 
-// AgentStatus defines the observed state of an Agent.
-// It provides a summary of the agent's current state.
-type AgentStatus struct {
-	// Phase represents the current lifecycle phase of the agent.
+// TranscriptBatchConfig bounds the batches of transcripts posted to the sink.
+type TranscriptBatchConfig struct {
+	// MaxSize is the number of transcripts posted at most in a request. Defaults to 20.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
 	// +optional
-	Phase AgentPhase `json:"phase,omitempty"`
+	MaxSize *int32 `json:"maxSize,omitempty"`
 
-	// Message is a human-readable message about the agent's current state.
+	// MaxWait is how long a transcript waits for its batch to fill before it is posted.
+	// Defaults to 10s.
 	// +optional
-	Message string `json:"message,omitempty"`
+	MaxWait *metav1.Duration `json:"maxWait,omitempty"`
+}
 
-	// ReplicaStatus shows the current status of the agent's replicas.
+// SLOConfig defines the service level objectives of an agent.
+type SLOConfig struct {
+	// AvailabilityTarget is the percentage of the chat requests that succeed over the window,
+	// such as "99.9".
+	// +kubebuilder:validation:Pattern=`^[0-9]{1,2}(\.[0-9]+)?$`
+	AvailabilityTarget string `json:"availabilityTarget"`
+
+	// LatencyTargetMs adds a latency SLO: the same percentage of the chat requests is
+	// answered within this many milliseconds over the window.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=600000
 	// +optional
-	ReplicaStatus ReplicaStatus `json:"replicaStatus,omitempty"`
+	LatencyTargetMs *int32 `json:"latencyTargetMs,omitempty"`
 
-	// LastUpdated is the timestamp of the last status update.
+	// Window is the rolling window of the objectives, between 7 and 90 days. Defaults to 720h.
 	// +optional
-	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+	Window *metav1.Duration `json:"window,omitempty"`
+}
 
-	// Conditions is a list of the latest available observations of the agent's state.
+// AdminPortConfig defines the admin port of an agent.
+type AdminPortConfig struct {
+	// Port of the admin endpoints in the agent container. It must differ from the chat
+	// port 8080, the metrics port and the ports of the sidecars.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	Port int32 `json:"port"`
+
+	// Expose creates the Service "<name>-admin" for the admin port. Only the pods of the
+	// operator namespace may reach it when the agent has a NetworkPolicy.
 	// +optional
-	Conditions []AgentCondition `json:"conditions,omitempty"`
+	Expose bool `json:"expose,omitempty"`
+}
+
+// BindingConfig defines the binding Secret of an agent.
+type BindingConfig struct {
+	// Enabled creates the Secret "<name>-binding". Defaults to true; set it to false to
+	// keep the URLs and the endpoint token from being gathered in one Secret.
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+}
+
+// SecretKeyReference selects a key of a Secret, optionally in another namespace.
+type SecretKeyReference struct {
+	corev1.SecretKeySelector `json:",inline"`
+
+	// Namespace of the Secret. Defaults to the agent's namespace. Other namespaces must be
+	// allowed by an AgentPolicy or the operator; the operator then keeps a copy of the key
+	// in the agent's namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// BindingStatus reports the binding Secret of an agent.
+type BindingStatus struct {
+	// SecretName is the Secret holding the connection details of the agent: the keys type,
+	// provider, uri, host and port, external-uri when it is exposed, and token when its
+	// endpoint requires one.
+	SecretName string `json:"secretName"`
+}
+
+// EndpointAuthConfig defines inbound authentication of the agent endpoint.
+type EndpointAuthConfig struct {
+	// GenerateKey makes the operator generate a bearer token into the Secret
+	// "<name>-endpoint-auth" and require it on every request except the health probes.
+	// +optional
+	GenerateKey bool `json:"generateKey,omitempty"`
+}
+
+// DisruptionConfig defines how voluntary disruptions treat the agent pods.
+type DisruptionConfig struct {
+	// DoNotDisrupt marks the agent pods as not safe to evict for the cluster autoscaler and
+	// Karpenter, and creates a PodDisruptionBudget allowing no unavailable pods, so that
+	// nodes running the agent are not consolidated in the middle of a streaming response.
+	// +optional
+	DoNotDisrupt bool `json:"doNotDisrupt,omitempty"`
+
+	// ConsolidationPolicy limits the evictions of agent pods when DoNotDisrupt is not set.
+	// "Allow" leaves evictions unrestricted; "OneAtATime" creates a PodDisruptionBudget
+	// allowing a single unavailable pod.
+	// +kubebuilder:validation:Enum=Allow;OneAtATime
+	// +kubebuilder:default=Allow
+	// +optional
+	ConsolidationPolicy string `json:"consolidationPolicy,omitempty"`
+}
+
+// EncryptionConfig defines the encryption at rest of persisted conversation data.
+type EncryptionConfig struct {
+	// KeySecretRef selects the key used to encrypt new records. It must be at least 32 bytes.
+	KeySecretRef corev1.SecretKeySelector `json:"keySecretRef"`
+
+	// PreviousKeySecretRef selects the key being rotated out. Records encrypted with it stay
+	// readable until they have been re-encrypted with the current key.
+	// +optional
+	PreviousKeySecretRef *corev1.SecretKeySelector `json:"previousKeySecretRef,omitempty"`
+}
+
+// RolloutConfig defines the rollout strategy for changes to the agent pods.
+type RolloutConfig struct {
+	// Strategy selects how changes are rolled out: "canary" shifts a growing share of the
+	// traffic to the change, "blueGreen" switches all traffic at once to a fully ready
+	// preview. Defaults to "canary".
+	// +kubebuilder:validation:Enum=canary;blueGreen
+	// +optional
+	Strategy string `json:"strategy,omitempty"`
+
+	// Canary runs changes in a separate canary Deployment that receives a growing share of
+	// the agent's traffic before the change is promoted to all pods.
+	// +optional
+	Canary *CanaryStrategy `json:"canary,omitempty"`
+
+	// BlueGreen configures the blueGreen strategy.
+	// +optional
+	BlueGreen *BlueGreenStrategy `json:"blueGreen,omitempty"`
+
+	// AutoRollback reverts the agent pods to their previous pod template when a change fails
+	// to roll out or raises the error rate of the agent. Without canary steps or the
+	// blueGreen strategy, changes are rolled out in place and monitored.
+	// +optional
+	AutoRollback *AutoRollbackConfig `json:"autoRollback,omitempty"`
+}
+
+// AutoRollbackConfig defines when a change to the agent pods is rolled back.
+type AutoRollbackConfig struct {
+	// Enabled turns the automatic rollback on.
+	Enabled bool `json:"enabled"`
+
+	// ErrorRateThreshold is the percentage of failed chat requests during the window above
+	// which the change is rolled back. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	ErrorRateThreshold *int32 `json:"errorRateThreshold,omitempty"`
+
+	// Window is how long the agent pods are monitored after a change. Defaults to 10m.
+	// +optional
+	Window *metav1.Duration `json:"window,omitempty"`
+
+	// MaxLookback is the number of previous pod templates kept, and so the number of
+	// consecutive failed changes that can be rolled back. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=10
+	// +optional
+	MaxLookback *int32 `json:"maxLookback,omitempty"`
+}
+
+// WarmupConfig defines the warm-up requests sent by new agent pods before they report ready.
+type WarmupConfig struct {
+	// Enabled turns the warm-up on.
+	Enabled bool `json:"enabled"`
+
+	// Prompts are sent in turn as warm-up chat requests. A short synthetic prompt is used
+	// when empty.
+	// +kubebuilder:validation:MaxItems=10
+	// +optional
+	Prompts []string `json:"prompts,omitempty"`
+
+	// Count is the number of warm-up requests. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=20
+	// +optional
+	Count *int32 `json:"count,omitempty"`
+
+	// Timeout bounds the warm-up. A pod whose warm-up fails or times out reports ready
+	// anyway and the failure is reported in the Degraded condition. Defaults to 2m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// PreloadConfig defines the warm-up of the model server, run by an init container of the
+// agent pods after the model endpoint answers.
+type PreloadConfig struct {
+	// Enabled turns the preload on.
+	Enabled bool `json:"enabled"`
+
+	// Prompt is the prompt of the generation request. A short synthetic prompt is used when
+	// empty.
+	// +optional
+	Prompt string `json:"prompt,omitempty"`
+
+	// MaxTokens bounds the tokens generated by the request. Defaults to 16.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1024
+	// +optional
+	MaxTokens *int32 `json:"maxTokens,omitempty"`
+
+	// Retries is the number of failed generation requests retried, with backoff, before the
+	// preload fails. Defaults to 3.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=20
+	// +optional
+	Retries *int32 `json:"retries,omitempty"`
+
+	// Timeout bounds the preload of a pod. A failed preload fails the init container, which
+	// the kubelet restarts with backoff. Defaults to 10m.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// BlueGreenStrategy defines the promotion of a blue/green rollout.
+type BlueGreenStrategy struct {
+	// AutoPromote switches the traffic to the preview as soon as all its pods are ready.
+	// Otherwise the preview waits for the kubeagentic.ai/promote annotation.
+	// +optional
+	AutoPromote bool `json:"autoPromote,omitempty"`
+
+	// ScaleDownDelay is how long the previous revision keeps running after promotion.
+	// Until then, the kubeagentic.ai/abort annotation switches the traffic back to it.
+	// Defaults to 30s.
+	// +optional
+	ScaleDownDelay *metav1.Duration `json:"scaleDownDelay,omitempty"`
+}
+
+// CanaryStrategy defines the steps and analysis gates of a canary rollout.
+type CanaryStrategy struct {
+	// Steps are run in order; the change is promoted once the last step passes.
+	// +kubebuilder:validation:MinItems=1
+	Steps []CanaryStep `json:"steps"`
+
+	// Gates abort the rollout when the canary pods perform worse than the thresholds at the
+	// end of a step.
+	// +optional
+	Gates *CanaryGates `json:"gates,omitempty"`
+}
+
+// CanaryStep defines one step of a canary rollout.
+type CanaryStep struct {
+	// Weight is the percentage of the agent pods running the change. The traffic split
+	// follows the pod counts behind the agent Service, so the effective weight is rounded to
+	// whole pods, with at least one canary pod.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	Weight int32 `json:"weight"`
+
+	// Pause is how long the step runs once the canary pods are ready before the gates are
+	// evaluated. Steps without a pause wait for manual promotion.
+	// +optional
+	Pause *metav1.Duration `json:"pause,omitempty"`
+}
+
+// CanaryGates defines the thresholds the canary pods must meet to advance a step. The gates
+// are evaluated on the requests the canary pods handled during the step.
+type CanaryGates struct {
+	// MaxErrorPercent is the highest percentage of failed requests.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	MaxErrorPercent *int32 `json:"maxErrorPercent,omitempty"`
+
+	// MaxAverageLatency is the highest average response duration.
+	// +optional
+	MaxAverageLatency *metav1.Duration `json:"maxAverageLatency,omitempty"`
+
+	// MinRequests is the number of requests the canary pods must handle during a step before
+	// the gates are evaluated. The step is extended until it is reached.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinRequests int64 `json:"minRequests,omitempty"`
+}
+
+// ExperimentConfig defines an A/B experiment between two agent configurations.
+type ExperimentConfig struct {
+	// VariantB overrides the agent configuration for variant B.
+	VariantB ExperimentVariant `json:"variantB"`
+
+	// TrafficPercent is the percentage of agent pods, and so of the traffic, running
+	// variant B. The split is rounded to whole pods, with at least one pod per variant.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=99
+	// +kubebuilder:default=50
+	// +optional
+	TrafficPercent int32 `json:"trafficPercent,omitempty"`
+
+	// Duration is how long the experiment runs once both variants are ready.
+	Duration metav1.Duration `json:"duration"`
+
+	// SuccessMetric decides the better variant at the end of the experiment: "errorRate",
+	// "latency" or "tokens" per request. Lower is better.
+	// +kubebuilder:validation:Enum=errorRate;latency;tokens
+	// +kubebuilder:default=errorRate
+	// +optional
+	SuccessMetric string `json:"successMetric,omitempty"`
+
+	// WinnerPolicy decides how the experiment ends. "manual" keeps both variants running
+	// until the kubeagentic.ai/experiment-winner annotation names the winner; "auto" picks
+	// the variant with the better success metric.
+	// +kubebuilder:validation:Enum=manual;auto
+	// +kubebuilder:default=manual
+	// +optional
+	WinnerPolicy string `json:"winnerPolicy,omitempty"`
+}
+
+// ExperimentVariant overrides agent settings for an experiment variant. Empty fields keep
+// the agent's settings.
+type ExperimentVariant struct {
+	// Model overrides spec.model.
+	// +optional
+	Model string `json:"model,omitempty"`
+
+	// SystemPrompt overrides spec.systemPrompt.
+	// +optional
+	SystemPrompt string `json:"systemPrompt,omitempty"`
+
+	// Temperature is the sampling temperature, e.g. "0.2".
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	Temperature string `json:"temperature,omitempty"`
+
+	// MaxTokens limits the tokens of each completion.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxTokens *int32 `json:"maxTokens,omitempty"`
+}
+
+// NetworkPolicyConfig defines the NetworkPolicy generated for the agent pods.
+type NetworkPolicyConfig struct {
+	// Enabled makes the operator create a NetworkPolicy for the agent pods.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// ExtraIngressFrom lists additional peers allowed to reach the agent.
+	// +optional
+	ExtraIngressFrom []networkingv1.NetworkPolicyPeer `json:"extraIngressFrom,omitempty"`
+
+	// ExtraEgressTo lists additional peers the agent may connect to.
+	// +optional
+	ExtraEgressTo []networkingv1.NetworkPolicyPeer `json:"extraEgressTo,omitempty"`
+}
+
+// RoutingConfig defines request routing in front of the agent replicas.
+type RoutingConfig struct {
+	// ConversationAffinity routes all requests of a conversation to the same replica.
+	// +optional
+	ConversationAffinity *ConversationAffinityConfig `json:"conversationAffinity,omitempty"`
+}
+
+// ScratchConfig defines the scratch volume of the agent container.
+type ScratchConfig struct {
+	// SizeLimit bounds the files of the scratch volume. The operator caps it, 10Gi by default;
+	// a pod writing more is evicted.
+	SizeLimit resource.Quantity `json:"sizeLimit"`
+
+	// Medium backs the volume with the disk of the node, or with memory, where the files
+	// count against the memory limit of the agent container. Defaults to Disk.
+	// +kubebuilder:validation:Enum=Disk;Memory
+	// +kubebuilder:default=Disk
+	// +optional
+	Medium string `json:"medium,omitempty"`
+}
+
+// ConcurrencyConfig defines how many requests each agent pod handles at once.
+type ConcurrencyConfig struct {
+	// MaxConcurrentRequests is the number of chat requests each agent pod handles at once.
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrentRequests int32 `json:"maxConcurrentRequests"`
+
+	// QueueLength is the number of requests each agent pod keeps waiting for a free slot
+	// once maxConcurrentRequests are in flight. Defaults to 0, rejecting them right away.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	QueueLength int32 `json:"queueLength,omitempty"`
+
+	// RejectStatusCode is the HTTP status of the requests rejected when the queue is full.
+	// Defaults to 503.
+	// +kubebuilder:validation:Enum=429;503
+	// +kubebuilder:default=503
+	// +optional
+	RejectStatusCode int32 `json:"rejectStatusCode,omitempty"`
+
+	// BackpressureReadiness fails the readiness probe of a pod while maxConcurrentRequests
+	// are in flight, so that the Service stops sending it requests until it drains.
+	// +optional
+	BackpressureReadiness bool `json:"backpressureReadiness,omitempty"`
+
+	// TargetUtilization scales the HorizontalPodAutoscaler of the agent on the requests in
+	// flight, kubeagentic_inflight_requests, keeping their average at this percentage of
+	// maxConcurrentRequests. It requires a custom metrics adapter serving the metric, such as
+	// the Prometheus Adapter.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +optional
+	TargetUtilization *int32 `json:"targetUtilization,omitempty"`
+}
+
+// InboundRateLimitConfig defines the limits of the requests clients send to the agent.
+type InboundRateLimitConfig struct {
+	// Rules are the limits enforced on each request. A request is rejected with 429 when any
+	// rule is exceeded for its client.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=10
+	Rules []RateLimitRule `json:"rules"`
+}
+
+// GuardrailsConfig defines the guardrails proxy checking the prompts and responses of the agent.
+type GuardrailsConfig struct {
+	// Enabled puts the guardrails proxy in front of the agent container.
+	Enabled bool `json:"enabled"`
+
+	// Image is the image of the guardrails engine. Defaults to the proxy shipped in the
+	// operator image. Other engines are run with the same arguments: --policy, --addr,
+	// --upstream and --fail-mode.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// PolicyRef selects the key of a ConfigMap in the namespace of the agent holding the
+	// policy, a list of rules matching a pattern against the prompts, the responses or both,
+	// and blocking or flagging them. Required when enabled.
+	// +optional
+	PolicyRef *corev1.ConfigMapKeySelector `json:"policyRef,omitempty"`
+
+	// FailMode is what happens to the requests while the proxy has no valid policy: closed
+	// rejects them and reports the pod unready, open passes them unchecked. Defaults to closed.
+	// +kubebuilder:validation:Enum=closed;open
+	// +kubebuilder:default=closed
+	// +optional
+	FailMode string `json:"failMode,omitempty"`
+
+	// Port is the port of the guardrails proxy in the agent pods. Defaults to 8082.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+}
+
+// RateLimitRule limits the requests of each client to a number of requests per window.
+type RateLimitRule struct {
+	// Name identifies the rule in the rate limit metrics.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Header is the request header identifying the client, such as Authorization or
+	// X-API-Key. Clients are identified by their IP address when empty, and requests
+	// without the header are limited together.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9-]+$`
+	// +optional
+	Header string `json:"header,omitempty"`
+
+	// Requests is the number of requests a client may send per window.
+	// +kubebuilder:validation:Minimum=1
+	Requests int32 `json:"requests"`
+
+	// Window is the period the requests are counted over, such as 1m or 1h.
+	Window metav1.Duration `json:"window"`
+
+	// Burst is the number of requests a client may send at once. Defaults to requests.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Burst *int32 `json:"burst,omitempty"`
+}
+
+// ConversationAffinityConfig configures the consistent-hash router placed in front of the agent pods.
+type ConversationAffinityConfig struct {
+	// Enabled deploys the router and points the agent Service at it.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// Header is the request header identifying the conversation.
+	// +kubebuilder:default=X-Conversation-Id
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9-]+$`
+	// +optional
+	Header string `json:"header,omitempty"`
+
+	// RouterReplicas is the number of router pods.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RouterReplicas *int32 `json:"routerReplicas,omitempty"`
+}
+
+// MemoryConfig defines the conversation memory backend of the agent runtime.
+type MemoryConfig struct {
+	// Backend is the conversation memory implementation.
+	// +kubebuilder:validation:Enum=inmemory;redis;postgres
+	// +kubebuilder:default=inmemory
+	// +optional
+	Backend string `json:"backend,omitempty"`
+
+	// ConnectionSecretRef references a secret key holding the connection URL of an external
+	// backend. Required for postgres; for redis, the operator deploys a Redis instance when unset.
+	// +optional
+	ConnectionSecretRef *corev1.SecretKeySelector `json:"connectionSecretRef,omitempty"`
+
+	// MaxHistoryMessages is the number of messages kept per conversation.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxHistoryMessages int32 `json:"maxHistoryMessages,omitempty"`
+
+	// SummarizationThreshold is the number of messages after which older history is summarized.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	SummarizationThreshold int32 `json:"summarizationThreshold,omitempty"`
+
+	// AllowReplicaLocal acknowledges that with the inmemory backend and several replicas,
+	// each replica only sees the conversations it served.
+	// +optional
+	AllowReplicaLocal bool `json:"allowReplicaLocal,omitempty"`
+}
+
+// ExportConfig defines the scheduled export of conversation transcripts.
+type ExportConfig struct {
+	// Schedule is the cron schedule of the export job.
+	Schedule string `json:"schedule"`
+
+	// Destination is the object storage location receiving the transcripts.
+	Destination ExportDestination `json:"destination"`
+
+	// CredentialsSecretRef names a Secret exposed to the export job as environment
+	// variables, e.g. AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	CredentialsSecretRef corev1.LocalObjectReference `json:"credentialsSecretRef"`
+
+	// Format is the file format of the exported batches.
+	// +kubebuilder:validation:Enum=jsonl;parquet
+	// +kubebuilder:default=jsonl
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// Include lists glob patterns of session IDs to export. All sessions are exported when empty.
+	// +optional
+	Include []string `json:"include,omitempty"`
+
+	// Exclude lists glob patterns of session IDs to skip. Exclusions win over inclusions.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+
+	// Image overrides the container image used for the export job.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// BudgetConfig defines the spend cap of an agent.
+type BudgetConfig struct {
+	// Amount is the spend allowed per period, e.g. "250" or "99.50".
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	Amount string `json:"amount"`
+
+	// Currency of Amount, which must be the currency of the pricing catalog.
+	// +kubebuilder:default=USD
+	// +optional
+	Currency string `json:"currency,omitempty"`
+
+	// Period after which the spend is reset, starting at midnight UTC on the first day of
+	// the month, on Monday, or every day.
+	// +kubebuilder:validation:Enum=monthly;weekly;daily
+	// +kubebuilder:default=monthly
+	// +optional
+	Period string `json:"period,omitempty"`
+
+	// Action taken once the spend reaches Amount: alert records an Event and sets the
+	// Degraded condition, throttle additionally limits the requests per minute of each
+	// pod, and suspend scales the agent to zero until the period resets or the budget is
+	// raised.
+	// +kubebuilder:validation:Enum=alert;throttle;suspend
+	// +kubebuilder:default=alert
+	// +optional
+	Action string `json:"action,omitempty"`
+
+	// ThrottleRequestsPerMinute is the number of chat requests each pod accepts per minute
+	// with the throttle action. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ThrottleRequestsPerMinute *int32 `json:"throttleRequestsPerMinute,omitempty"`
+}
+
+// TokenQuotaConfig defines the daily token quota of an agent.
+type TokenQuotaConfig struct {
+	// DailyLimit is the number of prompt and completion tokens the agent may consume per day.
+	// +kubebuilder:validation:Minimum=1
+	DailyLimit int64 `json:"dailyLimit"`
+
+	// Action taken once the quota is exhausted: reject answers chat requests with 429 until
+	// the reset, and throttle limits the requests per minute of each pod.
+	// +kubebuilder:validation:Enum=reject;throttle
+	// +kubebuilder:default=reject
+	// +optional
+	Action string `json:"action,omitempty"`
+
+	// ResetTimezone is the IANA time zone whose midnight resets the quota. Defaults to UTC.
+	// +optional
+	ResetTimezone string `json:"resetTimezone,omitempty"`
+
+	// ThrottleRequestsPerMinute is the number of chat requests each pod accepts per minute
+	// with the throttle action. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ThrottleRequestsPerMinute *int32 `json:"throttleRequestsPerMinute,omitempty"`
+}
+
+// MetricsConfig defines how Prometheus scrapes the metrics of the agent pods.
+type MetricsConfig struct {
+	// Enabled exposes the agent pods to Prometheus.
+	Enabled bool `json:"enabled"`
+
+	// Port serving the metrics in the agent pods. Defaults to 8080, the port of the agent
+	// runtime.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	Port *int32 `json:"port,omitempty"`
+
+	// Path serving the metrics. Defaults to /metrics.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	Path string `json:"path,omitempty"`
+
+	// ScrapeVia selects how Prometheus discovers the agent pods: serviceMonitor for the
+	// Prometheus Operator, or annotations for the prometheus.io/scrape, prometheus.io/port
+	// and prometheus.io/path pod annotations of annotation-based discovery. With
+	// serviceMonitor, the annotations are set anyway when the cluster has no ServiceMonitor
+	// CRD. Defaults to serviceMonitor.
+	// +kubebuilder:validation:Enum=serviceMonitor;annotations
+	// +optional
+	ScrapeVia string `json:"scrapeVia,omitempty"`
+}
+
+// AgentDependency refers to an Agent or a Service that an agent waits for. Exactly one of
+// Agent and Service is set.
+type AgentDependency struct {
+	// Agent is the name of an Agent that must have the Ready condition.
+	// +optional
+	Agent string `json:"agent,omitempty"`
+
+	// Service is the name of a Service that must have ready endpoints.
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// Namespace of the Agent or Service. Defaults to the namespace of the agent.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+
+	// MinReadyEndpoints is the number of ready endpoints the Service must have. Defaults
+	// to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MinReadyEndpoints *int32 `json:"minReadyEndpoints,omitempty"`
+}
+
+// AgentPeer refers to an Agent that an agent talks to.
+type AgentPeer struct {
+	// AgentRef is the name of the peer Agent, in the namespace of the agent.
+	// +kubebuilder:validation:MinLength=1
+	AgentRef string `json:"agentRef"`
+
+	// Alias names the peer in peers.json and the directory of its token. Defaults to
+	// AgentRef.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +optional
+	Alias string `json:"alias,omitempty"`
+}
+
+// AgentConnector connects the agent to a chat platform.
+type AgentConnector struct {
+	// Name identifies the connector in its configuration and the ConnectorsReady condition.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+
+	// Type is the chat platform.
+	// +kubebuilder:validation:Enum=slack;discord
+	Type string `json:"type"`
+
+	// CredentialsSecretRef names the Secret holding the bot credentials, in the namespace
+	// of the agent: bot-token for both platforms, and app-token for the Socket Mode
+	// connection of Slack.
+	CredentialsSecretRef corev1.LocalObjectReference `json:"credentialsSecretRef"`
+
+	// Channels restricts the connector to these channel IDs. All the channels the bot is a
+	// member of are served when empty.
+	// +optional
+	Channels []string `json:"channels,omitempty"`
+
+	// Trigger selects the messages answered by the agent.
+	// +optional
+	Trigger *ConnectorTrigger `json:"trigger,omitempty"`
+}
+
+// ConnectorTrigger selects the chat messages a connector forwards to the agent.
+type ConnectorTrigger struct {
+	// Mention answers the messages mentioning the bot. Defaults to true.
+	// +optional
+	Mention *bool `json:"mention,omitempty"`
+
+	// DirectMessages answers the direct messages sent to the bot. Defaults to true.
+	// +optional
+	DirectMessages *bool `json:"directMessages,omitempty"`
+
+	// Prefix answers the messages starting with it, such as "!ask", without a mention.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// ToolExecutorConfig defines the executor sidecar running the commands of the tools.
+type ToolExecutorConfig struct {
+	// Image is the image of the sidecar, which provides the allowed commands. The operator
+	// adds its execution API to the image at startup.
+	// +kubebuilder:validation:MinLength=1
+	Image string `json:"image"`
+
+	// Resources are the compute resources of the sidecar.
+	// +optional
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// AllowedCommands are the commands the runtime may execute, such as git or kubectl.
+	// Commands are run by name from the PATH of the image, or by absolute path.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=50
+	AllowedCommands []string `json:"allowedCommands"`
+
+	// Timeout bounds the duration of each command. Defaults to 60s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// SecurityContext overrides the restricted security context of the sidecar, for images
+	// that must run as a given user. Privileged sidecars and privilege escalation are
+	// rejected.
+	// +optional
+	SecurityContext *corev1.SecurityContext `json:"securityContext,omitempty"`
+}
+
+// EventSourceConfig defines the queue the agent consumes its work from.
+type EventSourceConfig struct {
+	// Type is the kind of queue.
+	// +kubebuilder:validation:Enum=kafka;sqs
+	Type string `json:"type"`
+
+	// Kafka configures the topic consumed by kafka event sources.
+	// +optional
+	Kafka *KafkaEventSource `json:"kafka,omitempty"`
+
+	// SQS configures the queue consumed by sqs event sources.
+	// +optional
+	SQS *SQSEventSource `json:"sqs,omitempty"`
+
+	// Concurrency is the number of messages each replica processes at once. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=64
+	// +optional
+	Concurrency *int32 `json:"concurrency,omitempty"`
+
+	// DeadLetter configures what happens to messages the agent keeps failing to process.
+	// +optional
+	DeadLetter *EventDeadLetterConfig `json:"deadLetter,omitempty"`
+
+	// Autoscaling scales the agent on the backlog of the queue with KEDA, which must be
+	// installed in the cluster.
+	// +optional
+	Autoscaling *EventSourceAutoscaling `json:"autoscaling,omitempty"`
+
+	// AllowIngress acknowledges that the agent consumes a queue and is exposed through an
+	// Ingress as well. Both are rejected together otherwise.
+	// +optional
+	AllowIngress bool `json:"allowIngress,omitempty"`
+}
+
+// KafkaEventSource defines the Kafka topic an agent consumes.
+type KafkaEventSource struct {
+	// Brokers are the bootstrap brokers, as host:port.
+	// +kubebuilder:validation:MinItems=1
+	Brokers []string `json:"brokers"`
+
+	// Topic is the topic consumed by the agent.
+	// +kubebuilder:validation:MinLength=1
+	Topic string `json:"topic"`
+
+	// ConsumerGroup is the consumer group of the agent replicas. Defaults to the namespace
+	// and name of the agent.
+	// +optional
+	ConsumerGroup string `json:"consumerGroup,omitempty"`
+
+	// SASLMechanism authenticates to the brokers with the credentials Secret.
+	// +kubebuilder:validation:Enum=PLAIN;SCRAM-SHA-256;SCRAM-SHA-512
+	// +optional
+	SASLMechanism string `json:"saslMechanism,omitempty"`
+
+	// TLS connects to the brokers over TLS.
+	// +optional
+	TLS bool `json:"tls,omitempty"`
+
+	// CredentialsSecretRef names a Secret holding the SASL username and password, in the
+	// namespace of the agent. Required with a SASL mechanism.
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// SQSEventSource defines the SQS queue an agent consumes.
+type SQSEventSource struct {
+	// QueueURL is the URL of the queue.
+	// +kubebuilder:validation:Pattern=`^https?://`
+	QueueURL string `json:"queueURL"`
+
+	// Region is the AWS region of the queue.
+	// +kubebuilder:validation:MinLength=1
+	Region string `json:"region"`
+
+	// CredentialsSecretRef names a Secret holding AWS_ACCESS_KEY_ID and
+	// AWS_SECRET_ACCESS_KEY, in the namespace of the agent. The credentials of the pod,
+	// such as those of IRSA, are used when unset.
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// EventDeadLetterConfig defines the handling of messages the agent fails to process.
+type EventDeadLetterConfig struct {
+	// MaxAttempts is the number of times a message is processed before it is dead-lettered.
+	// Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=20
+	// +optional
+	MaxAttempts *int32 `json:"maxAttempts,omitempty"`
+
+	// Target is the Kafka topic or SQS queue URL receiving the failed messages. They are
+	// dropped, and logged, when empty.
+	// +optional
+	Target string `json:"target,omitempty"`
+}
+
+// EventSourceAutoscaling defines the scaling of an agent on the backlog of its queue.
+type EventSourceAutoscaling struct {
+	// Enabled creates a KEDA ScaledObject for the agent Deployment, in place of the
+	// HorizontalPodAutoscaler of the agent.
+	Enabled bool `json:"enabled"`
+
+	// MinReplicas is the number of replicas without backlog. 0 scales the agent to zero.
+	// Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas bounds the replicas. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
+	// TargetBacklog is the number of pending messages per replica: the consumer lag for
+	// Kafka, the visible messages for SQS. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetBacklog *int32 `json:"targetBacklog,omitempty"`
+}
+
+// WorkerModeConfig defines the workers processing the queued jobs of an agent.
+type WorkerModeConfig struct {
+	// Enabled deploys the workers and the job queue.
+	Enabled bool `json:"enabled"`
+
+	// Queue is the Redis holding the job queue: managed-redis deploys one with the agent,
+	// shared with the redis conversation memory, and external uses QueueSecretRef.
+	// Defaults to managed-redis.
+	// +kubebuilder:validation:Enum=managed-redis;external
+	// +optional
+	Queue string `json:"queue,omitempty"`
+
+	// QueueSecretRef names a Secret holding the address, as host:port, and optionally the
+	// password of an external Redis, in the namespace of the agent. Required with the
+	// external queue.
+	// +optional
+	QueueSecretRef *corev1.LocalObjectReference `json:"queueSecretRef,omitempty"`
+
+	// WorkerReplicas is the number of worker pods. Defaults to 1. Ignored when the workers
+	// are autoscaled.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	WorkerReplicas *int32 `json:"workerReplicas,omitempty"`
+
+	// MaxJobDuration bounds the run time of a job, after which it fails. Defaults to 30m.
+	// +optional
+	MaxJobDuration *metav1.Duration `json:"maxJobDuration,omitempty"`
+
+	// Autoscaling scales the workers on the length of the job queue with KEDA, which must
+	// be installed in the cluster, independently of the agent Deployment.
+	// +optional
+	Autoscaling *WorkerAutoscaling `json:"autoscaling,omitempty"`
+}
+
+// WorkerAutoscaling defines the scaling of the workers on the length of the job queue.
+type WorkerAutoscaling struct {
+	// Enabled creates a KEDA ScaledObject for the worker Deployment.
+	Enabled bool `json:"enabled"`
+
+	// MinReplicas is the number of workers without queued jobs. 0 scales the workers to
+	// zero. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// MaxReplicas bounds the workers. Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
+	// TargetQueueLength is the number of queued jobs per worker. Defaults to 5.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TargetQueueLength *int32 `json:"targetQueueLength,omitempty"`
+}
+
+// SyntheticsConfig defines the synthetic probe of the agent.
+type SyntheticsConfig struct {
+	// Enabled turns the synthetic probe on.
+	Enabled bool `json:"enabled"`
+
+	// Interval between two probes, a whole number of minutes below an hour or a whole number
+	// of hours up to 24h. Defaults to 5m.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Prompt is sent to the agent. A short synthetic prompt is used when empty.
+	// +optional
+	Prompt string `json:"prompt,omitempty"`
+
+	// ExpectedSubstring must appear in the answer for the probe to succeed. Any answer
+	// succeeds when empty.
+	// +optional
+	ExpectedSubstring string `json:"expectedSubstring,omitempty"`
+
+	// Timeout bounds a probe. Defaults to 30s.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+
+	// FailureThreshold is the number of consecutive failed probes that set the Degraded
+	// condition. Defaults to 3.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	FailureThreshold *int32 `json:"failureThreshold,omitempty"`
+
+	// Image overrides the container image used for the probe job.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// VLLMConfig tunes the micro-batching of the requests to a vLLM server, which schedules the
+// sequences arriving together in the same batches.
+type VLLMConfig struct {
+	// MaxBatchSize is the number of requests sent together once they are waiting. Defaults to 1,
+	// sending each request as it arrives.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=256
+	// +optional
+	MaxBatchSize *int32 `json:"maxBatchSize,omitempty"`
+
+	// MaxWaitMs is how long, in milliseconds, a request waits for others to fill its batch.
+	// Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=1000
+	// +optional
+	MaxWaitMs *int32 `json:"maxWaitMs,omitempty"`
+
+	// MaxConcurrentSequences caps the requests of each agent pod in flight to the server.
+	// Unlimited by default.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1024
+	// +optional
+	MaxConcurrentSequences *int32 `json:"maxConcurrentSequences,omitempty"`
+}
+
+// HealthCheckConfig configures the polling of the detailed health of the agent runtime.
+type HealthCheckConfig struct {
+	// Enabled turns the health check on.
+	Enabled bool `json:"enabled"`
+
+	// Interval between two health checks. Defaults to the operator --health-check-interval.
+	// +optional
+	Interval *metav1.Duration `json:"interval,omitempty"`
+
+	// Timeout bounds a health check. Defaults to the operator --health-check-timeout.
+	// +optional
+	Timeout *metav1.Duration `json:"timeout,omitempty"`
+}
+
+// ExportDestination is an S3-compatible bucket location.
+type ExportDestination struct {
+	// Endpoint is the S3-compatible API endpoint. Defaults to AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Bucket is the name of the bucket.
+	Bucket string `json:"bucket"`
+
+	// Prefix is prepended to the keys of the uploaded objects.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+}
+
+// PersistenceConfig defines the volume holding the conversation state of an agent.
+type PersistenceConfig struct {
+	// Size is the requested storage of the claim, such as 5Gi. Defaults to 1Gi.
+	// +optional
+	Size string `json:"size,omitempty"`
+
+	// StorageClassName is the storage class of the claim. The default storage class of
+	// the cluster is used when empty.
+	// +optional
+	StorageClassName string `json:"storageClassName,omitempty"`
+
+	// Backup takes VolumeSnapshots of the claim on a schedule.
+	// +optional
+	Backup *BackupConfig `json:"backup,omitempty"`
+
+	// RestoreFromSnapshot names a VolumeSnapshot of the agent namespace to restore the
+	// conversation state from. The operator scales the agent down, creates a new claim from
+	// the snapshot, swaps it in and scales the agent back up. Each snapshot is restored once;
+	// set another name to restore again.
+	// +optional
+	RestoreFromSnapshot string `json:"restoreFromSnapshot,omitempty"`
+}
+
+// BackupConfig defines the scheduled VolumeSnapshots of the claim of an agent.
+type BackupConfig struct {
+	// Schedule is the cron schedule of the snapshots, in UTC.
+	Schedule string `json:"schedule"`
+
+	// VolumeSnapshotClassName is the class of the snapshots. The default snapshot class of
+	// the CSI driver is used when empty.
+	// +optional
+	VolumeSnapshotClassName string `json:"volumeSnapshotClassName,omitempty"`
+
+	// Retention is the number of snapshots kept; older ones are deleted.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=7
+	// +optional
+	Retention int32 `json:"retention,omitempty"`
+}
+
+// ModelCacheConfig opts an agent into the shared model weight cache.
+type ModelCacheConfig struct {
+	// Enabled mounts the shared model cache into the agent pods.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// PVCName overrides the operator's shared cache PersistentVolumeClaim.
+	// The claim must already exist in the agent's namespace.
+	// +optional
+	PVCName string `json:"pvcName,omitempty"`
+
+	// SubPath is the directory within the cache used by this agent.
+	// Defaults to a path derived from the model name.
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+}
+
+// RAGConfig defines the retrieval-augmented generation settings for an agent.
+type RAGConfig struct {
+	// Enabled turns on retrieval-augmented generation for the agent.
+	// +optional
+	Enabled bool `json:"enabled,omitempty"`
+
+	// VectorStore describes the vector database the agent retrieves documents from.
+	// Required when Enabled is true.
+	// +optional
+	VectorStore *VectorStoreConfig `json:"vectorStore,omitempty"`
+
+	// Ingestion configures a scheduled job that keeps the vector store populated.
+	// +optional
+	Ingestion *RAGIngestionConfig `json:"ingestion,omitempty"`
+
+	// Embeddings configures a dedicated embeddings model, which may use a different
+	// provider than the chat model.
+	// +optional
+	Embeddings *EmbeddingsConfig `json:"embeddings,omitempty"`
+}
+
+// EmbeddingsConfig defines the model used to embed documents and queries.
+type EmbeddingsConfig struct {
+	// Provider specifies the embeddings provider. Defaults to the agent's provider.
+	// +kubebuilder:validation:Enum=openai;gemini;claude;vllm;ollama
+	// +optional
+	Provider string `json:"provider,omitempty"`
+
+	// Model specifies the embeddings model, e.g. "text-embedding-3-small".
+	Model string `json:"model"`
+
+	// ApiSecretRef references the Secret key holding the embeddings provider API key.
+	// Defaults to the agent's apiSecretRef.
+	// +optional
+	ApiSecretRef *SecretKeyReference `json:"apiSecretRef,omitempty"`
+
+	// Endpoint is a custom endpoint URL for the embeddings provider.
+	// Required for self-hosted providers such as vLLM and Ollama.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+}
+
+// VectorStoreConfig defines the connection to a vector database.
+type VectorStoreConfig struct {
+	// Type specifies the vector database implementation.
+	// +kubebuilder:validation:Enum=pgvector;qdrant;weaviate
+	Type string `json:"type"`
+
+	// ConnectionSecretRef references a Secret key holding the connection string (DSN or URL).
+	// Rotating the secret contents rolls the agent pods.
+	ConnectionSecretRef corev1.SecretKeySelector `json:"connectionSecretRef"`
+
+	// Collection is the collection, table or index name holding the embeddings.
+	Collection string `json:"collection"`
+
+	// Dimensions is the dimensionality of the stored embedding vectors.
+	// +kubebuilder:validation:Minimum=1
+	Dimensions int32 `json:"dimensions"`
+
+	// VerifyConnectivity runs a pre-flight Job that checks the vector store is reachable
+	// and reports the result in the VectorStoreReachable condition.
+	// +optional
+	VerifyConnectivity bool `json:"verifyConnectivity,omitempty"`
+}
+
+// RAGIngestionConfig defines the scheduled ingestion of documents into the vector store.
+type RAGIngestionConfig struct {
+	// Schedule is the cron schedule on which ingestion runs, e.g. "0 */6 * * *".
+	Schedule string `json:"schedule"`
+
+	// Sources lists the documents to ingest.
+	// +kubebuilder:validation:MinItems=1
+	Sources []IngestionSource `json:"sources"`
+
+	// ChunkSize is the maximum number of characters per embedded chunk.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1000
+	// +optional
+	ChunkSize int32 `json:"chunkSize,omitempty"`
+
+	// ChunkOverlap is the number of characters shared between consecutive chunks.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	ChunkOverlap int32 `json:"chunkOverlap,omitempty"`
+
+	// Image overrides the container image used by the ingestion job.
+	// +optional
+	Image string `json:"image,omitempty"`
+
+	// HistoryLimit is the number of finished ingestion jobs to keep. Defaults to 3.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	HistoryLimit *int32 `json:"historyLimit,omitempty"`
+}
+
+// IngestionSource defines a single source of documents. Exactly one of URL, ConfigMapRef
+// or Bucket must be set.
+type IngestionSource struct {
+	// URL is an http or https location to fetch documents from.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// ConfigMapRef references a ConfigMap whose data entries are ingested as documents.
+	// +optional
+	ConfigMapRef *corev1.LocalObjectReference `json:"configMapRef,omitempty"`
+
+	// Bucket references an S3-compatible bucket to ingest documents from.
+	// +optional
+	Bucket *BucketSource `json:"bucket,omitempty"`
+}
+
+// BucketSource defines an S3-compatible object storage location.
+type BucketSource struct {
+	// Endpoint is the S3-compatible API endpoint. Empty means AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+
+	// Bucket is the bucket name.
+	Bucket string `json:"bucket"`
+
+	// Prefix restricts ingestion to objects under this key prefix.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+
+	// CredentialsSecretRef references a Secret whose keys (e.g. AWS_ACCESS_KEY_ID,
+	// AWS_SECRET_ACCESS_KEY) are exposed to the ingestion job as environment variables.
+	// +optional
+	CredentialsSecretRef *corev1.LocalObjectReference `json:"credentialsSecretRef,omitempty"`
+}
+
+// MaxTools bounds the tools of an agent, including the default tools injected into it.
+const MaxTools = 50
+
+// Example is an example exchange of the few-shot context of an agent.
+type Example struct {
+	// User is the message of the user.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=8000
+	User string `json:"user"`
+
+	// Assistant is the answer the agent is expected to give.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:MaxLength=8000
+	Assistant string `json:"assistant"`
+}
+
+// ModelScheduleEntry overrides the model of an agent during a recurring time window.
+type ModelScheduleEntry struct {
+	// Window is the recurring time window of the override.
+	Window ScheduleWindow `json:"window"`
+
+	// Model replaces spec.model while the window is open. It must be in the pricing catalog
+	// of the provider, unless the provider is self-hosted.
+	// +kubebuilder:validation:MinLength=1
+	Model string `json:"model"`
+
+	// ModelParameters are the sampling parameters used with the model.
+	// +optional
+	ModelParameters *ModelParameters `json:"modelParameters,omitempty"`
+}
+
+// ScheduleWindow is a time window opened on a cron schedule.
+type ScheduleWindow struct {
+	// Start is the cron schedule opening the window, e.g. "0 20 * * 1-5" for 8pm on weekdays.
+	// +kubebuilder:validation:MinLength=1
+	Start string `json:"start"`
+
+	// Duration is how long the window stays open, between 1m and 168h.
+	Duration metav1.Duration `json:"duration"`
+
+	// Timezone is the IANA time zone of Start, such as "Europe/Paris". Defaults to UTC.
+	// +optional
+	Timezone string `json:"timezone,omitempty"`
+}
+
+// ModelParameters are the sampling parameters of a model.
+type ModelParameters struct {
+	// Temperature is the sampling temperature, e.g. "0.2".
+	// +kubebuilder:validation:Pattern=`^[0-9]+(\.[0-9]+)?$`
+	// +optional
+	Temperature string `json:"temperature,omitempty"`
+
+	// MaxTokens limits the tokens of each completion.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxTokens *int32 `json:"maxTokens,omitempty"`
+}
+
+// Tool defines a tool that is available to the agent.
+// Tools allow agents to interact with external systems and perform actions.
+type Tool struct {
+	// Name is the unique identifier for the tool.
+	Name string `json:"name"`
+
+	// Description is a human-readable explanation of what the tool does.
+	// This is used by the agent to decide when to use the tool.
+	Description string `json:"description"`
+
+	// InputSchema is a JSON schema that describes the input parameters for the tool.
+	// This helps the agent to correctly format the input for the tool.
+	// +optional
+	InputSchema *runtime.RawExtension `json:"inputSchema,omitempty"`
+}
+
+// LanggraphConfig defines the configuration for LangGraph workflows
+type LanggraphConfig struct {
+	// GraphType specifies the type of LangGraph workflow
+	// +kubebuilder:validation:Enum=sequential;parallel;conditional;hierarchical
+	GraphType string `json:"graphType"`
+
+	// Nodes defines the workflow nodes
+	Nodes []WorkflowNode `json:"nodes"`
+
+	// Edges defines the workflow edges
+	Edges []WorkflowEdge `json:"edges"`
+
+	// State defines the state schema for the workflow, a JSON Schema object with a
+	// property for each field
+	State *runtime.RawExtension `json:"state,omitempty"`
+
+	// Entrypoint specifies the entry node for the workflow
+	Entrypoint string `json:"entrypoint"`
+
+	// Endpoints specifies possible end nodes for the workflow
+	Endpoints []string `json:"endpoints,omitempty"`
+}
+
+// WorkflowNode defines a node in the LangGraph workflow
+type WorkflowNode struct {
+	// Name is the unique identifier for the node
+	Name string `json:"name"`
+
+	// Type specifies the type of node
+	// +kubebuilder:validation:Enum=llm;tool;action
+	Type string `json:"type"`
+
+	// Prompt is the template for LLM nodes
+	Prompt string `json:"prompt,omitempty"`
+
+	// Tool is the tool name for tool nodes
+	Tool string `json:"tool,omitempty"`
+
+	// Action is the action to execute for action nodes
+	Action string `json:"action,omitempty"`
+
+	// Condition is the conditional logic for conditional nodes
+	Condition string `json:"condition,omitempty"`
+
+	// Inputs are the input fields from state, declared in State or written by other nodes
+	Inputs []string `json:"inputs,omitempty"`
+
+	// Outputs are the output fields to state
+	Outputs []string `json:"outputs,omitempty"`
+}
+
+// WorkflowEdge defines an edge in the LangGraph workflow
+type WorkflowEdge struct {
+	// From is the source node name
+	From string `json:"from"`
+
+	// To is the target node name
+	To string `json:"to"`
+
+	// Condition is the conditional logic for the edge
+	Condition string `json:"condition,omitempty"`
+}
+
+// AgentConditionType represents the type of an Agent's condition.
+type AgentConditionType string
+
+const (
+	// AgentConditionReady indicates that the agent is ready to serve requests. It is True when
+	// SecretValid, ConfigValid, DeploymentReady and ServiceReady are all True.
+	AgentConditionReady AgentConditionType = "Ready"
+	// AgentConditionSecretValid indicates whether the API key secret exists and holds the key.
+	AgentConditionSecretValid AgentConditionType = "SecretValid"
+	// AgentConditionConfigValid indicates whether the agent configuration passed validation.
+	AgentConditionConfigValid AgentConditionType = "ConfigValid"
+	// AgentConditionDeploymentReady indicates whether all replicas of the agent Deployment are ready.
+	AgentConditionDeploymentReady AgentConditionType = "DeploymentReady"
+	// AgentConditionServiceReady indicates whether the Service and the routing to the agent are in place.
+	AgentConditionServiceReady AgentConditionType = "ServiceReady"
+	// AgentConditionProgressing indicates that the agent's deployment is in progress.
+	AgentConditionProgressing AgentConditionType = "Progressing"
+	// AgentConditionDegraded indicates that the agent is in a degraded state.
+	AgentConditionDegraded AgentConditionType = "Degraded"
+	// AgentConditionVectorStoreReachable indicates whether the pre-flight check reached the vector store.
+	AgentConditionVectorStoreReachable AgentConditionType = "VectorStoreReachable"
+	// AgentConditionExportSucceeded indicates whether the most recent conversation export succeeded.
+	AgentConditionExportSucceeded AgentConditionType = "ExportSucceeded"
+	// AgentConditionBackupSucceeded indicates whether the most recent VolumeSnapshot backup of
+	// the agent claim was taken. It does not affect the Ready condition of the agent.
+	AgentConditionBackupSucceeded AgentConditionType = "BackupSucceeded"
+	// AgentConditionSnapshotRestored indicates whether spec.persistence.restoreFromSnapshot
+	// has been restored. The agent is scaled to zero while it is False with the reason
+	// RestoreInProgress.
+	AgentConditionSnapshotRestored AgentConditionType = "SnapshotRestored"
+	// AgentConditionSyntheticProbeHealthy indicates whether the most recent synthetic probe succeeded.
+	AgentConditionSyntheticProbeHealthy AgentConditionType = "SyntheticProbeHealthy"
+	// AgentConditionQuotaExhausted indicates whether the agent has used up its daily token quota.
+	AgentConditionQuotaExhausted AgentConditionType = "QuotaExhausted"
+	// AgentConditionUsageExceedsRequests indicates whether the measured usage of the agent pods is far above their requests.
+	AgentConditionUsageExceedsRequests AgentConditionType = "UsageExceedsRequests"
+	// AgentConditionModelDeprecated is set while the model of the agent is deprecated or retired by its provider.
+	AgentConditionModelDeprecated AgentConditionType = "ModelDeprecated"
+	// AgentConditionPrerequisitesReady indicates whether the Secrets and ConfigMaps the agent
+	// references exist and its pods are not held by dependencies that are not ready. When it
+	// is False, its message lists what the agent waits for.
+	AgentConditionPrerequisitesReady AgentConditionType = "PrerequisitesReady"
+	// AgentConditionDependenciesReady indicates whether all dependencies of the agent are ready.
+	AgentConditionDependenciesReady AgentConditionType = "DependenciesReady"
+	// AgentConditionPeersResolved indicates whether all peers of the agent exist.
+	AgentConditionPeersResolved AgentConditionType = "PeersResolved"
+	// AgentConditionPlacementResolved indicates whether the agents of colocateWith and
+	// spreadFrom exist.
+	AgentConditionPlacementResolved AgentConditionType = "PlacementResolved"
+	// AgentConditionConnectorsReady indicates whether the chat connectors of the agent are
+	// connected. It does not affect the Ready condition of the agent.
+	AgentConditionConnectorsReady AgentConditionType = "ConnectorsReady"
+	// AgentConditionEventSourceReachable indicates whether the pre-flight check reached the
+	// event source of the agent.
+	AgentConditionEventSourceReachable AgentConditionType = "EventSourceReachable"
+	// AgentConditionAgentHealthy indicates whether the components of the agent runtime, such
+	// as its provider, are healthy. It does not affect the Ready condition of the agent.
+	AgentConditionAgentHealthy AgentConditionType = "AgentHealthy"
+	// AgentConditionPolicyClamped is set while the operator lowers the maximum replicas of an
+	// autoscaler of the agent to the autoscaling ceiling of its namespace. It does not affect
+	// the Ready condition of the agent.
+	AgentConditionPolicyClamped AgentConditionType = "PolicyClamped"
+	// AgentConditionExpiring is True while the agent has a ttl or expireAt, with its deadline
+	// and the remaining time in the message. It does not affect the Ready condition of the agent.
+	AgentConditionExpiring AgentConditionType = "Expiring"
+	// AgentConditionIngressConflict is set while another agent, created earlier, claims the
+	// host and an overlapping path of the Ingress of the agent, which is then not created. It
+	// does not affect the Ready condition of the agent.
+	AgentConditionIngressConflict AgentConditionType = "IngressConflict"
+	// AgentConditionGuardrailsActive is True while the guardrails proxy of the agent enforces
+	// its policy, with the number of its rules and the fail mode in the message. It does not
+	// affect the Ready condition of the agent.
+	AgentConditionGuardrailsActive AgentConditionType = "GuardrailsActive"
+	// AgentConditionTranscriptSinkReachable indicates whether the transcript sink accepted
+	// the most recent signed test request of the operator, and is False while a pod of the
+	// agent fails to deliver its batches of transcripts. It does not affect the Ready
+	// condition of the agent.
+	AgentConditionTranscriptSinkReachable AgentConditionType = "TranscriptSinkReachable"
+	// AgentConditionSLOCompliant indicates whether the error budgets of the SLOs of the agent
+	// are left over the window, as recorded in the Prometheus of the operator. It is Unknown
+	// while the operator has no Prometheus URL or Prometheus has no data yet, and does not
+	// affect the Ready condition of the agent.
+	AgentConditionSLOCompliant AgentConditionType = "SLOCompliant"
+)
+
+// AgentCondition represents the condition of an Agent.
+// It provides more detailed information about the agent's state.
+type AgentCondition struct {
+	// Type of the condition.
+	Type AgentConditionType `json:"type"`
+
+	// Status of the condition, one of True, False, Unknown.
+	Status corev1.ConditionStatus `json:"status"`
+
+	// Reason is a brief, machine-readable reason for the condition's last transition.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+
+	// Message is a human-readable message indicating details about the last transition.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// LastTransitionTime is the last time the condition transitioned from one status to another.
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// AgentPhase represents the lifecycle phase of an Agent.
+type AgentPhase string
+
+const (
+	// AgentPhasePending means the agent is being created and is not yet ready.
+	AgentPhasePending AgentPhase = "Pending"
+	// AgentPhaseRunning means the agent is running and ready to serve requests.
+	AgentPhaseRunning AgentPhase = "Running"
+	// AgentPhaseFailed means the agent has encountered an error and is not running.
+	AgentPhaseFailed AgentPhase = "Failed"
+	// AgentPhaseSucceeded is not currently used but is reserved for future use.
+	AgentPhaseSucceeded AgentPhase = "Succeeded"
+	// AgentPhaseBudgetExceeded means the agent is scaled to zero because its budget is exhausted.
+	AgentPhaseBudgetExceeded AgentPhase = "BudgetExceeded"
+	// AgentPhaseWaitingForDependencies means the agent pods wait for the dependencies of the agent to be ready.
+	AgentPhaseWaitingForDependencies AgentPhase = "WaitingForDependencies"
+	// AgentPhaseWaiting means the agent waits for Secrets or ConfigMaps it references to be created.
+	AgentPhaseWaiting AgentPhase = "Waiting"
+)
+
+// IngressConfig sets the host and path an agent is served at through its Ingress.
+type IngressConfig struct {
+	// Host is the host of the Ingress rule, a DNS subdomain without wildcard.
+	// +kubebuilder:validation:MaxLength=253
+	Host string `json:"host"`
+
+	// Path is the path prefix the agent is served at on the host. Defaults to /, which claims
+	// the whole host.
+	// +kubebuilder:validation:Pattern=`^/`
+	// +optional
+	Path string `json:"path,omitempty"`
+}
+
+// NameOverrides sets the names of the resources created for an agent. Each name must be a
+// DNS-1123 label not used by the same kind of resource of another agent in the namespace.
+type NameOverrides struct {
+	// Deployment is the name of the Deployment running the agent pods.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Deployment string `json:"deployment,omitempty"`
+
+	// Service is the name of the Service exposing the agent.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// ConfigMap is the name of the ConfigMap holding the tools and configuration.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// Ingress is the name of the Ingress.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	Ingress string `json:"ingress,omitempty"`
+
+	// HPA is the name of the HorizontalPodAutoscaler.
+	// +kubebuilder:validation:MaxLength=63
+	// +optional
+	HPA string `json:"hpa,omitempty"`
+}
+
+// ResourceNames lists the names of the resources created for an agent.
+type ResourceNames struct {
+	// Deployment is the name of the Deployment running the agent pods.
+	// +optional
+	Deployment string `json:"deployment,omitempty"`
+
+	// Service is the name of the Service exposing the agent.
+	// +optional
+	Service string `json:"service,omitempty"`
+
+	// ConfigMap is the name of the ConfigMap holding the tools and configuration.
+	// +optional
+	ConfigMap string `json:"configMap,omitempty"`
+
+	// HorizontalPodAutoscaler is the name of the HorizontalPodAutoscaler, when the agent
+	// is autoscaled.
+	// +optional
+	HorizontalPodAutoscaler string `json:"horizontalPodAutoscaler,omitempty"`
+
+	// Ingress is the name of the Ingress, when the agent is exposed through one.
+	// +optional
+	Ingress string `json:"ingress,omitempty"`
+
+	// IngressHost is the host served by the Ingress.
+	// +optional
+	IngressHost string `json:"ingressHost,omitempty"`
+
+	// MonitoringConfigMap is the name of the ConfigMap holding the scrape configuration.
+	// +optional
+	MonitoringConfigMap string `json:"monitoringConfigMap,omitempty"`
+
+	// GrafanaDashboard is the name of the ConfigMap holding the Grafana dashboard.
+	// +optional
+	GrafanaDashboard string `json:"grafanaDashboard,omitempty"`
+}
+
+// ReplicaStatus represents the status of the agent's replicas.
+type ReplicaStatus struct {
+	// Ready is the number of replicas that are ready to serve requests.
+	Ready int32 `json:"ready"`
+
+	// Desired is the desired number of replicas.
+	Desired int32 `json:"desired"`
+
+	// Available is the number of replicas that are available.
+	Available int32 `json:"available"`
+}
+
+// EndpointStatus counts the endpoints of the Service of the agent.
+type EndpointStatus struct {
+	// Ready is the number of endpoints receiving traffic.
+	Ready int32 `json:"ready"`
+
+	// NotReady is the number of endpoints not receiving traffic yet.
+	NotReady int32 `json:"notReady"`
+}
+
+// AgentStatus defines the observed state of an Agent.
+// It provides a summary of the agent's current state.
+type AgentStatus struct {
+	// Phase represents the current lifecycle phase of the agent.
+	// +optional
+	Phase AgentPhase `json:"phase,omitempty"`
+
+	// ObservedGeneration is the agent generation most recently reconciled successfully.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// Message is a human-readable message about the agent's current state.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// ReplicaStatus shows the current status of the agent's replicas.
+	// +optional
+	ReplicaStatus ReplicaStatus `json:"replicaStatus,omitempty"`
+
+	// Endpoints counts the endpoints of the Service of the agent. The agent is only Running
+	// once the Service has a ready endpoint.
+	// +optional
+	Endpoints EndpointStatus `json:"endpoints,omitempty"`
+
+	// LastUpdated is the timestamp of the last status update.
+	// +optional
+	LastUpdated *metav1.Time `json:"lastUpdated,omitempty"`
+
+	// Conditions is a list of the latest available observations of the agent's state.
+	// +optional
+	Conditions []AgentCondition `json:"conditions,omitempty"`
+
+	// RecentErrors are the latest errors of the agent, oldest first. Consecutive identical
+	// errors are counted in a single entry, and entries expire after a while.
+	// +optional
+	RecentErrors []AgentError `json:"recentErrors,omitempty"`
+
+	// Ingestion reports the result of the most recent RAG ingestion run.
+	// +optional
+	Ingestion *IngestionStatus `json:"ingestion,omitempty"`
+
+	// Usage reports token consumption of the agent.
+	// +optional
+	Usage *UsageStatus `json:"usage,omitempty"`
+
+	// Export reports the result of the conversation export.
+	// +optional
+	Export *ExportStatus `json:"export,omitempty"`
+
+	// Persistence reports the claim of the agent and its backups.
+	// +optional
+	Persistence *PersistenceStatus `json:"persistence,omitempty"`
+
+	// ResolvedImage is the digest-pinned agent image used when digest resolution is enabled.
+	// +optional
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+
+	// ResolvedFrom is the image reference ResolvedImage was resolved from. The digest is
+	// resolved again only when the configured image changes.
+	// +optional
+	ResolvedFrom string `json:"resolvedFrom,omitempty"`
+
+	// EndpointAuthSecretName is the Secret holding the bearer token under the key "token".
+	// +optional
+	EndpointAuthSecretName string `json:"endpointAuthSecretName,omitempty"`
+
+	// Binding reports the binding Secret of the agent.
+	// +optional
+	Binding *BindingStatus `json:"binding,omitempty"`
+
+	// AdminAuthSecretName is the Secret holding the bearer token of the admin port under
+	// the key "token".
+	// +optional
+	AdminAuthSecretName string `json:"adminAuthSecretName,omitempty"`
+
+	// ResourceNames lists the names of the main resources created for the agent. Names that
+	// would exceed 63 characters are shortened with a hash of the full name.
+	// +optional
+	ResourceNames *ResourceNames `json:"resourceNames,omitempty"`
+
+	// Rollout reports the progress of the most recent canary or blue/green rollout.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+
+	// AutoRollback reports the monitoring of the most recent change to the agent pods and
+	// the most recent automatic rollback.
+	// +optional
+	AutoRollback *AutoRollbackStatus `json:"autoRollback,omitempty"`
+
+	// Revisions lists the kept Agent spec revisions, newest first.
+	// +optional
+	Revisions []AgentRevision `json:"revisions,omitempty"`
+
+	// FleetRollout reports whether the agent runs the operator default image of the
+	// current fleet rollout. It is unset for agents with spec.image.
+	// +optional
+	FleetRollout *FleetRolloutStatus `json:"fleetRollout,omitempty"`
+
+	// Experiment reports the progress and results of the A/B experiment.
+	// +optional
+	Experiment *ExperimentStatus `json:"experiment,omitempty"`
+
+	// Warmup reports the warm-up of the agent pods.
+	// +optional
+	Warmup *WarmupStatus `json:"warmup,omitempty"`
+
+	// Preload reports the preload of the model by the agent pods.
+	// +optional
+	Preload *PreloadStatus `json:"preload,omitempty"`
+
+	// Synthetics reports the results of the synthetic probe.
+	// +optional
+	Synthetics *SyntheticsStatus `json:"synthetics,omitempty"`
+
+	// Health reports the detailed health of the agent runtime.
+	// +optional
+	Health *HealthStatus `json:"health,omitempty"`
+
+	// Budget reports the spend of the agent in the current budget period.
+	// +optional
+	Budget *BudgetStatus `json:"budget,omitempty"`
+
+	// Activity reports the chat requests handled by the agent, from the request counters
+	// of its pods.
+	// +optional
+	Activity *ActivityStatus `json:"activity,omitempty"`
+
+	// Promotion reports the promotion of the agent to another namespace requested with the
+	// kubeagentic.ai/promote-to annotation.
+	// +optional
+	Promotion *PromotionStatus `json:"promotion,omitempty"`
+
+	// TokenQuota reports the consumption of the agent against its daily token quota.
+	// +optional
+	TokenQuota *TokenQuotaStatus `json:"tokenQuota,omitempty"`
+
+	// Recommendations are the requests and limits advised for the agent container from its
+	// measured usage. They are applied only with the kubeagentic.ai/apply-recommendations
+	// annotation.
+	// +optional
+	Recommendations *ResourceRecommendations `json:"recommendations,omitempty"`
+
+	// Dependencies reports the readiness of each entry of spec.dependsOn.
+	// +optional
+	Dependencies []DependencyStatus `json:"dependencies,omitempty"`
+
+	// WorkerMode reports the replicas of the agent and worker Deployments in worker mode.
+	// +optional
+	WorkerMode *WorkerModeStatus `json:"workerMode,omitempty"`
+
+	// TranscriptSink reports the most recent test of the transcript sink.
+	// +optional
+	TranscriptSink *TranscriptSinkStatus `json:"transcriptSink,omitempty"`
+
+	// SLO reports the error budgets of the SLOs of the agent.
+	// +optional
+	SLO *SLOStatus `json:"slo,omitempty"`
+
+	// ModelSchedule reports the model selected by spec.modelSchedule.
+	// +optional
+	ModelSchedule *ModelScheduleStatus `json:"modelSchedule,omitempty"`
+}
+
+// WorkerModeStatus reports the replicas of an agent running in worker mode.
+type WorkerModeStatus struct {
+	// APIReplicas are the replicas of the agent Deployment, serving requests and enqueueing
+	// the jobs.
+	APIReplicas ReplicaStatus `json:"apiReplicas"`
+
+	// WorkerReplicas are the replicas of the worker Deployment, processing the jobs.
+	WorkerReplicas ReplicaStatus `json:"workerReplicas"`
+}
+
+// DependencyStatus is the readiness of a dependency of the agent.
+type DependencyStatus struct {
+	// Kind of the dependency, Agent or Service.
+	Kind string `json:"kind"`
+
+	// Name of the Agent or Service.
+	Name string `json:"name"`
+
+	// Namespace of the Agent or Service.
+	Namespace string `json:"namespace"`
+
+	// Ready reports whether the dependency is ready.
+	Ready bool `json:"ready"`
+
+	// Message explains why the dependency is not ready.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ExperimentPhase is the state of an A/B experiment.
+// +kubebuilder:validation:Enum=Pending;Running;Completed;Concluded
+type ExperimentPhase string
+
+const (
+	// ExperimentPending means the experiment waits for the pods of both variants.
+	ExperimentPending ExperimentPhase = "Pending"
+	// ExperimentRunning means both variants receive traffic.
+	ExperimentRunning ExperimentPhase = "Running"
+	// ExperimentCompleted means the duration has passed and the winner must be chosen.
+	ExperimentCompleted ExperimentPhase = "Completed"
+	// ExperimentConcluded means the winning variant serves all traffic.
+	ExperimentConcluded ExperimentPhase = "Concluded"
+)
+
+// ExperimentStatus reports the progress and results of an A/B experiment.
+type ExperimentStatus struct {
+	// Phase is the state of the experiment.
+	// +optional
+	Phase ExperimentPhase `json:"phase,omitempty"`
+
+	// ConfigHash identifies the experiment configuration. Changing spec.experiment starts a
+	// new experiment.
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+
+	// StartTime is when both variants became ready.
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// EndTime is when the experiment duration passed.
+	// +optional
+	EndTime *metav1.Time `json:"endTime,omitempty"`
+
+	// Variants are the metrics measured for each variant since StartTime.
+	// +optional
+	Variants []VariantStatus `json:"variants,omitempty"`
+
+	// Winner is the variant, "a" or "b", that serves all traffic after the experiment.
+	// +optional
+	Winner string `json:"winner,omitempty"`
+
+	// Summary compares the variants on the success metric.
+	// +optional
+	Summary string `json:"summary,omitempty"`
+}
+
+// VariantStatus reports the metrics of an experiment variant.
+type VariantStatus struct {
+	// Name of the variant, "a" or "b".
+	Name string `json:"name"`
+
+	// Weight is the effective percentage of agent pods running the variant.
+	// +optional
+	Weight int32 `json:"weight"`
+
+	// Requests is the number of requests the variant handled.
+	// +optional
+	Requests int64 `json:"requests"`
+
+	// ErrorPercent is the percentage of failed requests.
+	// +optional
+	ErrorPercent string `json:"errorPercent,omitempty"`
+
+	// AverageLatency is the average response duration.
+	// +optional
+	AverageLatency string `json:"averageLatency,omitempty"`
+
+	// TokensPerRequest is the average number of tokens consumed per request.
+	// +optional
+	TokensPerRequest string `json:"tokensPerRequest,omitempty"`
+
+	// StartCounters are the request counters of the variant's pods at StartTime.
+	// +optional
+	StartCounters *RequestCounters `json:"startCounters,omitempty"`
+}
+
+// RolloutPhase is the state of a canary or blue/green rollout.
+// +kubebuilder:validation:Enum=Progressing;Paused;Promoted;Aborted
+type RolloutPhase string
+
+const (
+	// RolloutProgressing means the canary is running a step or the preview pods are starting.
+	RolloutProgressing RolloutPhase = "Progressing"
+	// RolloutPaused means the canary or the preview waits for manual promotion.
+	RolloutPaused RolloutPhase = "Paused"
+	// RolloutPromoted means the change receives all traffic.
+	RolloutPromoted RolloutPhase = "Promoted"
+	// RolloutAborted means the change was removed and the stable pods serve all traffic.
+	RolloutAborted RolloutPhase = "Aborted"
+)
+
+// RolloutStatus reports the progress of a canary or blue/green rollout.
+type RolloutStatus struct {
+	// Phase is the state of the rollout.
+	// +optional
+	Phase RolloutPhase `json:"phase,omitempty"`
+
+	// StableRevision is the pod template hash of the stable Deployment.
+	// +optional
+	StableRevision string `json:"stableRevision,omitempty"`
+
+	// CanaryRevision is the pod template hash of the change being rolled out.
+	// +optional
+	CanaryRevision string `json:"canaryRevision,omitempty"`
+
+	// ActiveRevision is the pod template hash of the blue/green pods receiving the traffic.
+	// +optional
+	ActiveRevision string `json:"activeRevision,omitempty"`
+
+	// PreviewRevision is the pod template hash of the blue/green preview pods.
+	// +optional
+	PreviewRevision string `json:"previewRevision,omitempty"`
+
+	// PromotionTime is when the traffic was switched to the preview revision.
+	// +optional
+	PromotionTime *metav1.Time `json:"promotionTime,omitempty"`
+
+	// Step is the index of the current step in spec.rollout.canary.steps.
+	// +optional
+	Step int32 `json:"step"`
+
+	// CanaryWeight is the effective percentage of agent pods running the canary revision.
+	// +optional
+	CanaryWeight int32 `json:"canaryWeight"`
+
+	// StableWeight is the effective percentage of agent pods running the stable revision.
+	// +optional
+	StableWeight int32 `json:"stableWeight"`
+
+	// StepStartTime is when the canary pods of the current step became ready.
+	// +optional
+	StepStartTime *metav1.Time `json:"stepStartTime,omitempty"`
+
+	// StepStartCounters are the request counters of the canary pods at StepStartTime.
+	// +optional
+	StepStartCounters *RequestCounters `json:"stepStartCounters,omitempty"`
+
+	// Gates are the results of the most recent gate evaluation.
+	// +optional
+	Gates []GateResult `json:"gates,omitempty"`
+
+	// Message explains the current phase.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// AutoRollbackStatus reports the monitoring and rollback of changes to the agent pods.
+type AutoRollbackStatus struct {
+	// Revision is the pod template hash of the agent Deployment being monitored, or
+	// restored by the most recent rollback.
+	// +optional
+	Revision string `json:"revision,omitempty"`
+
+	// WatchStartTime is when the monitoring of Revision started. It is removed once the
+	// window passed without a breach.
+	// +optional
+	WatchStartTime *metav1.Time `json:"watchStartTime,omitempty"`
+
+	// StartCounters are the request counters of the agent pods at WatchStartTime.
+	// +optional
+	StartCounters *RequestCounters `json:"startCounters,omitempty"`
+
+	// RolledBackRevision is the pod template hash of the desired revision that was rolled
+	// back. The agent pods keep the restored template until the spec changes.
+	// +optional
+	RolledBackRevision string `json:"rolledBackRevision,omitempty"`
+
+	// RollbackTime is when the most recent rollback happened.
+	// +optional
+	RollbackTime *metav1.Time `json:"rollbackTime,omitempty"`
+
+	// Message explains the current state.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// FleetRolloutState is the state of an agent in a fleet rollout.
+type FleetRolloutState string
+
+const (
+	// FleetRolloutPending means the agent keeps the previous operator default image until
+	// its batch of the fleet rollout is released.
+	FleetRolloutPending FleetRolloutState = "Pending"
+	// FleetRolloutUpdated means the agent runs the current operator default image.
+	FleetRolloutUpdated FleetRolloutState = "Updated"
+)
+
+// FleetRolloutStatus reports the operator default image an agent runs.
+type FleetRolloutStatus struct {
+	// State is Pending while the agent waits for its batch of a fleet rollout.
+	State FleetRolloutState `json:"state"`
+
+	// Image is the operator default image the agent runs.
+	Image string `json:"image"`
+}
+
+// AgentRevision describes a kept Agent spec revision.
+type AgentRevision struct {
+	// Revision is the revision number, which the kubeagentic.ai/rollback-to annotation takes.
+	Revision int64 `json:"revision"`
+
+	// Hash identifies the spec of the revision.
+	Hash string `json:"hash"`
+
+	// Time is when the spec was first reconciled, or last restored.
+	Time metav1.Time `json:"time"`
+
+	// ChangeSummary names the spec fields changed from the preceding revision.
+	// +optional
+	ChangeSummary string `json:"changeSummary,omitempty"`
+}
+
+// RequestCounters are cumulative request counters scraped from the agent pods.
+type RequestCounters struct {
+	// Requests is the number of chat requests handled.
+	Requests int64 `json:"requests"`
+
+	// Errors is the number of chat requests that failed.
+	Errors int64 `json:"errors"`
+
+	// LatencyMilliseconds is the total duration of the chat requests.
+	LatencyMilliseconds int64 `json:"latencyMilliseconds"`
+
+	// Tokens is the number of prompt and completion tokens consumed.
+	// +optional
+	Tokens int64 `json:"tokens,omitempty"`
+}
+
+// GateResult is the evaluation of a canary gate.
+type GateResult struct {
+	// Name of the gate, "errorRate" or "averageLatency".
+	Name string `json:"name"`
+
+	// Value is the value measured on the canary pods.
+	Value string `json:"value"`
+
+	// Threshold is the configured limit.
+	Threshold string `json:"threshold"`
+
+	// Passed reports whether Value is within Threshold.
+	Passed bool `json:"passed"`
+}
+
+// ExportStatus reports the conversation export progress.
+type ExportStatus struct {
+	// LastExportTime is when the most recent successful export finished.
+	// +optional
+	LastExportTime *metav1.Time `json:"lastExportTime,omitempty"`
+
+	// ObjectCount is the number of objects uploaded by the most recent successful export.
+	// +optional
+	ObjectCount int64 `json:"objectCount,omitempty"`
+}
+
+// PersistenceStatus reports the claim holding the conversation state of an agent.
+type PersistenceStatus struct {
+	// ClaimName is the PersistentVolumeClaim mounted into the agent pods.
+	// +optional
+	ClaimName string `json:"claimName,omitempty"`
+
+	// RestoredFrom is the VolumeSnapshot the claim was restored from.
+	// +optional
+	RestoredFrom string `json:"restoredFrom,omitempty"`
+
+	// LastBackupTime is when the operator last created a snapshot of the claim.
+	// +optional
+	LastBackupTime *metav1.Time `json:"lastBackupTime,omitempty"`
+
+	// Snapshots lists the kept VolumeSnapshots of the claim, newest first.
+	// +optional
+	Snapshots []string `json:"snapshots,omitempty"`
+}
+
+// UsageStatus reports token consumption, with embeddings tracked separately from chat.
+type UsageStatus struct {
+	// PromptTokens is the number of chat prompt tokens consumed.
+	// +optional
+	PromptTokens int64 `json:"promptTokens,omitempty"`
+
+	// CompletionTokens is the number of chat completion tokens produced.
+	// +optional
+	CompletionTokens int64 `json:"completionTokens,omitempty"`
+
+	// EmbeddingTokens is the number of tokens sent to the embeddings model.
+	// +optional
+	EmbeddingTokens int64 `json:"embeddingTokens,omitempty"`
+
+	// EstimatedCost is the cost of the tokens, priced with the operator pricing catalog.
+	// It is empty when a model has no known price.
+	// +optional
+	EstimatedCost string `json:"estimatedCost,omitempty"`
+
+	// Currency is the currency of EstimatedCost.
+	// +optional
+	Currency string `json:"currency,omitempty"`
+
+	// CacheHits is the number of prompts answered from the response cache.
+	// +optional
+	CacheHits int64 `json:"cacheHits,omitempty"`
+
+	// CacheMisses is the number of prompts the response cache sent to the provider.
+	// +optional
+	CacheMisses int64 `json:"cacheMisses,omitempty"`
+
+	// CacheSavedTokens is the number of tokens the cache hits did not consume.
+	// +optional
+	CacheSavedTokens int64 `json:"cacheSavedTokens,omitempty"`
+
+	// EstimatedSavings is the cost of CacheSavedTokens, priced with the operator pricing
+	// catalog in Currency. It is empty when the model has no known price.
+	// +optional
+	EstimatedSavings string `json:"estimatedSavings,omitempty"`
+
+	// CachePodCounters are the cache counters of the pods seen at the last update, to count
+	// the increase of each.
+	// +optional
+	CachePodCounters []PodCacheCounters `json:"cachePodCounters,omitempty"`
+}
+
+// IngestionStatus summarizes the most recent RAG ingestion run.
+type IngestionStatus struct {
+	// LastRunTime is when the most recent ingestion job finished.
+	// +optional
+	LastRunTime *metav1.Time `json:"lastRunTime,omitempty"`
+
+	// LastRunSucceeded reports whether the most recent ingestion job succeeded.
+	// +optional
+	LastRunSucceeded bool `json:"lastRunSucceeded,omitempty"`
+
+	// DocumentsProcessed is the number of documents upserted by the most recent run.
+	// +optional
+	DocumentsProcessed int64 `json:"documentsProcessed,omitempty"`
+
+	// Failures is the number of documents that could not be ingested in the most recent run.
+	// +optional
+	Failures int64 `json:"failures,omitempty"`
+}
+
+// BudgetStatus reports the spend of an agent in the current budget period. The spend is
+// accumulated from the token counters of the agent pods, kept per pod so that operator
+// restarts do not count tokens twice.
+type BudgetStatus struct {
+	// PeriodStart is when the current budget period started.
+	PeriodStart metav1.Time `json:"periodStart"`
+
+	// ResetTime is when the spend is reset.
+	ResetTime metav1.Time `json:"resetTime"`
+
+	// TimeUntilReset is the time left in the current period when the status was updated.
+	// +optional
+	TimeUntilReset string `json:"timeUntilReset,omitempty"`
+
+	// Spend is the cost of the tokens consumed in the current period.
+	Spend string `json:"spend"`
+
+	// ProjectedSpend extrapolates Spend to the end of the period at the current rate.
+	// +optional
+	ProjectedSpend string `json:"projectedSpend,omitempty"`
+
+	// Currency of Spend and ProjectedSpend.
+	// +optional
+	Currency string `json:"currency,omitempty"`
+
+	// PromptTokens is the number of prompt tokens consumed in the current period.
+	// +optional
+	PromptTokens int64 `json:"promptTokens,omitempty"`
+
+	// CompletionTokens is the number of completion tokens produced in the current period.
+	// +optional
+	CompletionTokens int64 `json:"completionTokens,omitempty"`
+
+	// ActionTaken is the action in effect because the budget is exhausted: Alerted,
+	// Throttled or Suspended.
+	// +optional
+	ActionTaken string `json:"actionTaken,omitempty"`
+
+	// Message reports tokens left out of Spend because their model has no known price.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// PodCounters are the token counters of the agent pods seen at the last update.
+	// +optional
+	PodCounters []PodTokenCounters `json:"podCounters,omitempty"`
+}
+
+// TokenQuotaStatus reports the tokens consumed during the current quota day.
+type TokenQuotaStatus struct {
+	// DayStart is the midnight, in the reset time zone, starting the current quota day.
+	DayStart metav1.Time `json:"dayStart"`
+
+	// ResetTime is when the quota resets.
+	ResetTime metav1.Time `json:"resetTime"`
+
+	// Used is the number of tokens consumed since DayStart.
+	Used int64 `json:"used"`
+
+	// Remaining is the number of tokens left until the quota is exhausted.
+	Remaining int64 `json:"remaining"`
+
+	// PodCounters are the token counters of the agent pods seen last, from which the
+	// consumption of the next update is computed.
+	// +optional
+	PodCounters []PodTokenCounters `json:"podCounters,omitempty"`
+}
+
+// ResourceRecommendations are the CPU and memory requests and limits advised for the agent
+// container.
+type ResourceRecommendations struct {
+	// Requests cover the 95th percentile of the usage plus 15% headroom.
+	Requests corev1.ResourceList `json:"requests"`
+
+	// Limits cover the peak usage plus 30% headroom.
+	Limits corev1.ResourceList `json:"limits"`
+
+	// Samples is the number of usage samples the recommendations are based on.
+	Samples int32 `json:"samples"`
+
+	// WindowStart is when the oldest of the samples was taken.
+	WindowStart metav1.Time `json:"windowStart"`
+
+	// LastUpdated is when the recommendations last changed.
+	LastUpdated metav1.Time `json:"lastUpdated"`
+}
+
+// AgentError is an error the agent ran into, repeated Count times in a row.
+type AgentError struct {
+	// Time is when the error last occurred.
+	Time metav1.Time `json:"time"`
+
+	// Reason is the reason of the condition the error was reported on.
+	Reason string `json:"reason"`
+
+	// Message describes the error.
+	Message string `json:"message"`
+
+	// Count is the number of consecutive times the error occurred. A recurrence alone does
+	// not update the status, so Count and Time are brought up to date by its next change.
+	Count int32 `json:"count"`
+}
+
+// CachingConfig configures the cache of the answers to single-turn prompts.
+type CachingConfig struct {
+	// Enabled turns the cache on.
+	Enabled bool `json:"enabled"`
+
+	// TTL is how long an answer is served from the cache. Defaults to 1h.
+	// +optional
+	TTL *metav1.Duration `json:"ttl,omitempty"`
+
+	// MaxEntries bounds the cached answers, evicting the oldest. Defaults to 1000.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxEntries *int32 `json:"maxEntries,omitempty"`
+
+	// Backend keeps the cache in the memory of each pod, or in Redis shared by the
+	// replicas: the Redis of the redis memory backend when set, or a Redis the operator
+	// deploys. Agents with several replicas require redis.
+	// +kubebuilder:validation:Enum=memory;redis
+	// +kubebuilder:default=memory
+	// +optional
+	Backend string `json:"backend,omitempty"`
+
+	// KeyStrategy matches prompts exactly, after normalizing case and whitespace, or
+	// semantically, by the similarity of their embeddings.
+	// +kubebuilder:validation:Enum=exact;semantic
+	// +kubebuilder:default=exact
+	// +optional
+	KeyStrategy string `json:"keyStrategy,omitempty"`
+
+	// SimilarityThreshold is the cosine similarity above which the semantic strategy
+	// serves a cached answer, between 0 and 1. Defaults to "0.95".
+	// +optional
+	SimilarityThreshold string `json:"similarityThreshold,omitempty"`
+
+	// Embeddings is the model embedding the prompts for the semantic strategy. Defaults to
+	// the embeddings of spec.rag.
+	// +optional
+	Embeddings *EmbeddingsConfig `json:"embeddings,omitempty"`
+}
+
+// PodCacheCounters are the cumulative cache counters of an agent pod.
+type PodCacheCounters struct {
+	// Pod is the name of the pod.
+	Pod string `json:"pod"`
+
+	// Hits is the number of prompts answered from the cache.
+	Hits int64 `json:"hits"`
+
+	// Misses is the number of prompts sent to the provider.
+	Misses int64 `json:"misses"`
+
+	// SavedPromptTokens is the number of prompt tokens the hits did not consume.
+	SavedPromptTokens int64 `json:"savedPromptTokens"`
+
+	// SavedCompletionTokens is the number of completion tokens the hits did not consume.
+	SavedCompletionTokens int64 `json:"savedCompletionTokens"`
+}
+
+// ActivityStatus reports whether an agent is used. The requests are summed from the
+// counters of the agent pods, kept per pod so that restarted pods and operator restarts do
+// not count requests twice.
+type ActivityStatus struct {
+	// TotalRequests is the number of chat requests handled since the activity was first
+	// reported.
+	// +optional
+	TotalRequests int64 `json:"totalRequests,omitempty"`
+
+	// RequestsLastHour is the number of chat requests handled in the last hour.
+	// +optional
+	RequestsLastHour int64 `json:"requestsLastHour,omitempty"`
+
+	// LastRequestTime is when the operator last saw the request counters increase, at most a
+	// polling interval after the request.
+	// +optional
+	LastRequestTime *metav1.Time `json:"lastRequestTime,omitempty"`
+
+	// ErrorRatio is the fraction of the requests of the last hour that failed. It is empty
+	// without requests in the last hour.
+	// +optional
+	ErrorRatio string `json:"errorRatio,omitempty"`
+
+	// Buckets count the requests of the last hour in 5-minute intervals, oldest first.
+	// +optional
+	Buckets []RequestBucket `json:"buckets,omitempty"`
+
+	// PodCounters are the request counters of the pods seen at the last update, to count
+	// the increase of each.
+	// +optional
+	PodCounters []PodRequestCounters `json:"podCounters,omitempty"`
+}
+
+// RequestBucket counts the requests of an interval.
+type RequestBucket struct {
+	// Start is when the interval started.
+	Start metav1.Time `json:"start"`
+
+	// Requests is the number of chat requests handled in the interval.
+	Requests int64 `json:"requests"`
+
+	// Errors is the number of chat requests that failed in the interval.
+	Errors int64 `json:"errors"`
+}
+
+// PodRequestCounters are the cumulative request counters of an agent pod.
+type PodRequestCounters struct {
+	// Pod is the name of the pod.
+	Pod string `json:"pod"`
+
+	// Requests is the number of chat requests handled by the pod.
+	Requests int64 `json:"requests"`
+
+	// Errors is the number of chat requests that failed in the pod.
+	Errors int64 `json:"errors"`
+}
+
+// PromotionStatus reports the promotion of an agent to the namespace named by its
+// kubeagentic.ai/promote-to annotation.
+type PromotionStatus struct {
+	// TargetNamespace is the namespace the agent is promoted to.
+	TargetNamespace string `json:"targetNamespace"`
+
+	// Result is Promoted when the Agent of the target namespace holds the spec of the
+	// agent, transformed by the AgentPolicy of that namespace, and Failed otherwise.
+	// +kubebuilder:validation:Enum=Promoted;Failed
+	Result string `json:"result"`
+
+	// Message explains the result.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// PromotedGeneration is the generation of the agent promoted last.
+	// +optional
+	PromotedGeneration int64 `json:"promotedGeneration,omitempty"`
+
+	// LastPromotionTime is when the Agent of the target namespace was last created or updated.
+	// +optional
+	LastPromotionTime *metav1.Time `json:"lastPromotionTime,omitempty"`
+}
+
+// PodTokenCounters are the cumulative token counters of an agent pod.
+type PodTokenCounters struct {
+	// Pod is the name of the pod.
+	Pod string `json:"pod"`
+
+	// PromptTokens is the number of prompt tokens consumed by the pod.
+	PromptTokens int64 `json:"promptTokens"`
+
+	// CompletionTokens is the number of completion tokens produced by the pod.
+	CompletionTokens int64 `json:"completionTokens"`
+}
+
+// SyntheticsStatus reports the results of the synthetic probe.
+type SyntheticsStatus struct {
+	// LastProbeTime is when the most recent probe finished.
+	// +optional
+	LastProbeTime *metav1.Time `json:"lastProbeTime,omitempty"`
+
+	// LastSuccess is when the most recent successful probe finished.
+	// +optional
+	LastSuccess *metav1.Time `json:"lastSuccess,omitempty"`
+
+	// LastLatencyMs is the time the agent took to answer the most recent successful probe.
+	// +optional
+	LastLatencyMs int64 `json:"lastLatencyMs,omitempty"`
+
+	// ConsecutiveFailures is the number of failed probes since the most recent success.
+	// +optional
+	ConsecutiveFailures int32 `json:"consecutiveFailures,omitempty"`
+
+	// Message describes why the most recent probe failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// HealthStatus reports the most recent health check of the agent runtime.
+type HealthStatus struct {
+	// LastCheckTime is when the most recent health check finished.
+	// +optional
+	LastCheckTime *metav1.Time `json:"lastCheckTime,omitempty"`
+
+	// Components are the health of the components of the runtime, sorted by name.
+	// +optional
+	Components []ComponentHealth `json:"components,omitempty"`
+
+	// Endpoints are the health of the endpoints of the provider, in the order of
+	// spec.endpoints, for agents setting several.
+	// +optional
+	Endpoints []ProviderEndpointHealth `json:"endpoints,omitempty"`
+
+	// Message describes why the most recent health check failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// TranscriptSinkStatus reports the tests of the transcript sink.
+type TranscriptSinkStatus struct {
+	// LastTestTime is when the operator last posted a test request to the sink.
+	// +optional
+	LastTestTime *metav1.Time `json:"lastTestTime,omitempty"`
+
+	// Checksum is a hash of the URL and the shared secret of the sink tested last, which is
+	// tested again as soon as either changes.
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+
+	// Message describes why the most recent test failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// SLOStatus reports the error budgets of the SLOs of an agent.
+type SLOStatus struct {
+	// LastEvaluationTime is when the operator last queried the error budgets.
+	// +optional
+	LastEvaluationTime *metav1.Time `json:"lastEvaluationTime,omitempty"`
+
+	// AvailabilityBudgetRemaining is the percentage of the error budget of the availability
+	// SLO left over the window, negative once exhausted.
+	// +optional
+	AvailabilityBudgetRemaining string `json:"availabilityBudgetRemaining,omitempty"`
+
+	// LatencyBudgetRemaining is the percentage of the error budget of the latency SLO left
+	// over the window, negative once exhausted.
+	// +optional
+	LatencyBudgetRemaining string `json:"latencyBudgetRemaining,omitempty"`
+}
+
+// ModelScheduleStatus reports the model an agent with a model schedule serves with.
+type ModelScheduleStatus struct {
+	// ActiveModel is the model the agent serves with.
+	ActiveModel string `json:"activeModel"`
+
+	// ActiveEntry is the index in spec.modelSchedule of the override in effect, unset while
+	// no window is open and the agent serves with spec.model.
+	// +optional
+	ActiveEntry *int32 `json:"activeEntry,omitempty"`
+
+	// NextSwitchTime is when the model changes next, unset when it never does.
+	// +optional
+	NextSwitchTime *metav1.Time `json:"nextSwitchTime,omitempty"`
+}
+
+// ProviderEndpoint is an endpoint URL of the provider of the agent.
+type ProviderEndpoint struct {
+	// URL of the endpoint, such as http://vllm-0.models.svc:8000/v1.
+	URL string `json:"url"`
+
+	// Priority orders the endpoints, the lowest first. Endpoints of the next priority only
+	// receive requests when all endpoints of the lower ones fail. Defaults to 0.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	Priority *int32 `json:"priority,omitempty"`
+
+	// Weight is the share of the requests the endpoint receives among the endpoints of its
+	// priority. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Weight *int32 `json:"weight,omitempty"`
+}
+
+// ProviderEndpointHealth is the health of an endpoint of the provider, as checked by the
+// agent runtime.
+type ProviderEndpointHealth struct {
+	// URL of the endpoint.
+	URL string `json:"url"`
+
+	// Healthy reports whether the endpoint answered its check.
+	Healthy bool `json:"healthy"`
+
+	// Active reports whether the runtime currently sends requests to the endpoint.
+	Active bool `json:"active"`
+
+	// Message describes why the endpoint failed its check.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// ComponentHealth is the health of a component of the agent runtime.
+type ComponentHealth struct {
+	// Name of the component, such as provider, memory or tools.
+	Name string `json:"name"`
+
+	// Healthy reports whether the component passed its check.
+	Healthy bool `json:"healthy"`
+
+	// Message describes the component, or why it failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// WarmupStatus reports the warm-up of the agent pods.
+type WarmupStatus struct {
+	// WarmingPods is the number of running pods still sending warm-up requests.
+	// +optional
+	WarmingPods int32 `json:"warmingPods,omitempty"`
+
+	// FailedPods is the number of running pods whose warm-up failed or timed out.
+	// +optional
+	FailedPods int32 `json:"failedPods,omitempty"`
+
+	// Message reports the most recent warm-up error.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// PreloadPhase is the progress of the model preload.
+// +kubebuilder:validation:Enum=Loading;Warming;Ready;Failed
+type PreloadPhase string
+
+const (
+	// PreloadPhaseLoading means the model endpoint does not answer yet.
+	PreloadPhaseLoading PreloadPhase = "Loading"
+	// PreloadPhaseWarming means the warm-up generation request is running.
+	PreloadPhaseWarming PreloadPhase = "Warming"
+	// PreloadPhaseReady means every agent pod preloaded the model.
+	PreloadPhaseReady PreloadPhase = "Ready"
+	// PreloadPhaseFailed means the preload of a pod failed and is retried by the kubelet.
+	PreloadPhaseFailed PreloadPhase = "Failed"
+)
+
+// PreloadStatus reports the preload of the model by the agent pods. The phase is that of
+// the pod furthest behind, or Failed when the preload of any pod failed.
+type PreloadStatus struct {
+	// Phase is the progress of the preload.
+	Phase PreloadPhase `json:"phase"`
+
+	// Message reports the error of a failed preload.
+	// +optional
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
@@ -243,7 +3075,9 @@ type AgentStatus struct {
 // +kubebuilder:printcolumn:name="Model",type="string",JSONPath=".spec.model"
 // +kubebuilder:printcolumn:name="Phase",type="string",JSONPath=".status.phase"
 // +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.replicaStatus.ready"
+// +kubebuilder:printcolumn:name="Last Active",type="date",JSONPath=".status.activity.lastRequestTime"
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:printcolumn:name="Message",type="string",JSONPath=".status.message",priority=1
 
 // Agent is the Schema for the agents API. It represents a single AI agent.
 type Agent struct {
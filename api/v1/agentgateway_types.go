@@ -0,0 +1,81 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AgentGatewaySpec defines a gateway serving the OpenAI chat completions API for the
+// agents of the gateway's namespace, which clients select by passing the agent name as
+// the model.
+type AgentGatewaySpec struct {
+	// Agents lists the agents the gateway exposes. All running agents of the namespace
+	// are exposed when empty.
+	// +optional
+	Agents []string `json:"agents,omitempty"`
+
+	// Replicas is the number of gateway pods.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// ServiceType is the type of the gateway Service.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	// +optional
+	ServiceType corev1.ServiceType `json:"serviceType,omitempty"`
+
+	// Image overrides the gateway image.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// AgentGatewayStatus defines the observed state of AgentGateway.
+type AgentGatewayStatus struct {
+	// Models lists the agents the gateway routes, by model name.
+	// +optional
+	Models []string `json:"models,omitempty"`
+
+	// URL is the in-cluster URL of the OpenAI API of the gateway.
+	// +optional
+	URL string `json:"url,omitempty"`
+
+	// ReadyReplicas is the number of ready gateway pods.
+	// +optional
+	ReadyReplicas int32 `json:"readyReplicas,omitempty"`
+
+	// ObservedGeneration is the generation of the spec the status reflects.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:shortName=agw
+// +kubebuilder:printcolumn:name="URL",type="string",JSONPath=".status.url"
+// +kubebuilder:printcolumn:name="Ready",type="integer",JSONPath=".status.readyReplicas"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+
+// AgentGateway is the Schema for the agentgateways API. It exposes the Agents of its
+// namespace as models of an OpenAI-compatible API.
+type AgentGateway struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   AgentGatewaySpec   `json:"spec,omitempty"`
+	Status AgentGatewayStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AgentGatewayList contains a list of AgentGateway resources.
+type AgentGatewayList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AgentGateway `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AgentGateway{}, &AgentGatewayList{})
+}
@@ -0,0 +1,104 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// AgentPolicySpec defines constraints applied to the Agents in the policy's namespace.
+// When several policies exist in a namespace, an Agent must satisfy all of them.
+type AgentPolicySpec struct {
+	// AllowedRegistries lists the registry prefixes agent images may be pulled from,
+	// e.g. "registry.example.com:5000" or "ghcr.io/example". All registries allowed
+	// by the operator are accepted when empty.
+	// +optional
+	AllowedRegistries []string `json:"allowedRegistries,omitempty"`
+
+	// ResolveDigests pins agent Deployments to the digest of the configured image tag.
+	// +optional
+	ResolveDigests bool `json:"resolveDigests,omitempty"`
+
+	// SecretConsumerNamespaces lists namespaces whose Agents may reference the Secrets of the
+	// policy's namespace. Unlike the other fields, it grants access to other namespaces
+	// rather than constraining the Agents of the policy's namespace, and a namespace listed
+	// by any policy is allowed.
+	// +optional
+	SecretConsumerNamespaces []string `json:"secretConsumerNamespaces,omitempty"`
+
+	// MaxReplicas caps spec.replicas of the Agents of the policy's namespace below the
+	// maximum configured for the operator. It only lowers that maximum.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
+	// AutoscalingCeiling bounds how far the autoscalers of the Agents of the policy's
+	// namespace may scale them, below the ceiling configured for the operator.
+	// +optional
+	AutoscalingCeiling *AutoscalingCeiling `json:"autoscalingCeiling,omitempty"`
+
+	// MaxTTL caps the lifetime of the Agents of the policy's namespace with a ttl or
+	// expireAt, extensions included, below the maximum configured for the operator.
+	// +optional
+	MaxTTL *metav1.Duration `json:"maxTTL,omitempty"`
+
+	// Promotion accepts Agents promoted into the policy's namespace from other namespaces
+	// with the kubeagentic.ai/promote-to annotation, and transforms their spec. Agents are
+	// only promoted into namespaces with a policy accepting their namespace.
+	// +optional
+	Promotion *PromotionPolicy `json:"promotion,omitempty"`
+}
+
+// PromotionPolicy accepts promoted Agents and transforms their spec for the environment of
+// the policy's namespace. spec.debug, spec.ttl and spec.expireAt are always cleared.
+type PromotionPolicy struct {
+	// AllowedSources lists the namespaces Agents may be promoted from.
+	// +kubebuilder:validation:MinItems=1
+	AllowedSources []string `json:"allowedSources"`
+
+	// SecretNames renames the Secrets referenced by promoted Agents, from their name in the
+	// source namespace to their name in the policy's namespace.
+	// +optional
+	SecretNames map[string]string `json:"secretNames,omitempty"`
+
+	// Replicas overrides spec.replicas of promoted Agents.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+}
+
+// AutoscalingCeiling bounds the maximum replicas of the HPA and KEDA autoscalers of an Agent.
+type AutoscalingCeiling struct {
+	// MaxReplicas caps the maximum replicas of each autoscaler.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
+	// MaxRequests caps the total cpu and memory requested by the pods of an autoscaler at
+	// its maximum replicas.
+	// +optional
+	MaxRequests corev1.ResourceList `json:"maxRequests,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:shortName=agp
+
+// AgentPolicy is the Schema for the agentpolicies API. It constrains the Agents of its namespace.
+type AgentPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec AgentPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// AgentPolicyList contains a list of AgentPolicy resources.
+type AgentPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []AgentPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&AgentPolicy{}, &AgentPolicyList{})
+}
@@ -1,26 +1,100 @@
 package v1
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"net/http"
+	"net"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	metav1validation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/cronschedule"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/defaultmodel"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/defaulttools"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/dependency"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/examples"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/expiry"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/graphstate"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/imagepolicy"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/ingresshost"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/modelschedule"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/promotion"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/provider"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/redaction"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/replicalimit"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/scratch"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/secretref"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/sizing"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/slo"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/transcriptsink"
 )
 
+// Agent is the aiv1.Agent admitted by the webhooks. The defaulting and validation are
+// methods of it, as they cannot be methods of aiv1.Agent, whose package the packages they
+// use import.
+type Agent aiv1.Agent
+
+// AgentWebhook defaults and validates the Agents created and updated through the API
+// server.
+type AgentWebhook struct {
+	// Reader reads the AgentPolicies, Namespaces, Secrets and Agents the validation depends
+	// on. SetupWebhookWithManager sets it to the client of the Manager if unset; without it,
+	// the checks that read the cluster are skipped.
+	Reader client.Reader
+}
+
 // +kubebuilder:webhook:path=/mutate-ai-example-com-v1-agent,mutating=true,failurePolicy=fail,sideEffects=None,groups=ai.example.com,resources=agents,verbs=create;update,versions=v1,name=magent.kb.io,admissionReviewVersions=v1
 
-var _ webhook.Defaulter = &Agent{}
+var _ webhook.CustomDefaulter = &AgentWebhook{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the type
+func (w *AgentWebhook) Default(_ context.Context, obj runtime.Object) error {
+	agent, err := admittedAgent(obj)
+	if err != nil {
+		return err
+	}
+	agent.Default()
+	return nil
+}
+
+// admittedAgent returns obj as the Agent of the webhooks.
+func admittedAgent(obj runtime.Object) (*Agent, error) {
+	agent, ok := obj.(*aiv1.Agent)
+	if !ok {
+		return nil, fmt.Errorf("expected an Agent, got %T", obj)
+	}
+	return (*Agent)(agent), nil
+}
 
-// Default implements webhook.Defaulter so a webhook will be registered for the type
+// Default sets the defaults of the fields the agent leaves empty.
 func (r *Agent) Default() {
 	log := logf.Log.WithName("agent-resource")
 
@@ -31,17 +105,52 @@ func (r *Agent) Default() {
 		r.Spec.Framework = "direct"
 	}
 
+	// Default the model of the provider and record that it was not chosen; the annotation is
+	// dropped once the model is changed
+	if r.Spec.Model == "" {
+		if model := defaultmodel.For(r.Spec.Provider); model != "" {
+			r.Spec.Model = model
+			if r.Annotations == nil {
+				r.Annotations = map[string]string{}
+			}
+			r.Annotations[defaultmodel.Annotation] = model
+		}
+	} else if defaulted, ok := r.Annotations[defaultmodel.Annotation]; ok && defaulted != r.Spec.Model {
+		delete(r.Annotations, defaultmodel.Annotation)
+	}
+
+	// Inject the tools of the organization, unless the agent opts out; tools of the agent
+	// win over default tools of the same name
+	defaulttools.Inject(r, &r.Spec)
+
 	// Set default replicas if not specified
 	if r.Spec.Replicas == nil {
 		defaultReplicas := int32(1)
 		r.Spec.Replicas = &defaultReplicas
 	}
 
+	// Spread the replicas across nodes, so that a node failure does not take out the agent
+	if len(r.Spec.TopologySpreadConstraints) == 0 && *r.Spec.Replicas > 1 {
+		r.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       corev1.LabelHostname,
+			WhenUnsatisfiable: corev1.ScheduleAnyway,
+		}}
+	}
+
 	// Set default service type if not specified
 	if r.Spec.ServiceType == "" {
 		r.Spec.ServiceType = "ClusterIP"
 	}
 
+	// Copy the preset of the size into the resources, so that users see what they got. Resources
+	// of a preset were set from a size before and follow changes of the size and provider.
+	if r.Spec.Size != "" && (r.Spec.Resources == nil || sizing.IsPreset(r.Spec.Resources)) {
+		if preset, ok := sizing.For(r.Spec.Provider, r.Spec.Size); ok {
+			r.Spec.Resources = &preset
+		}
+	}
+
 	// Set default resources if not specified
 	if r.Spec.Resources == nil {
 		r.Spec.Resources = &corev1.ResourceRequirements{
@@ -55,29 +164,103 @@ func (r *Agent) Default() {
 			},
 		}
 	}
+
+	// Default the embeddings model connection to the primary provider and secret
+	if r.Spec.RAG != nil && r.Spec.RAG.Embeddings != nil {
+		embeddings := r.Spec.RAG.Embeddings
+		if embeddings.Provider == "" {
+			embeddings.Provider = r.Spec.Provider
+			if embeddings.Endpoint == "" {
+				embeddings.Endpoint = r.primaryEndpoint()
+			}
+		}
+		if embeddings.ApiSecretRef == nil && r.Spec.ApiSecretRef != nil {
+			embeddings.ApiSecretRef = r.Spec.ApiSecretRef.DeepCopy()
+		}
+	}
 }
 
 // +kubebuilder:webhook:path=/validate-ai-example-com-v1-agent,mutating=false,failurePolicy=fail,sideEffects=None,groups=ai.example.com,resources=agents,verbs=create;update,versions=v1,name=vagent.kb.io,admissionReviewVersions=v1
 
-var _ webhook.Validator = &Agent{}
+var _ webhook.CustomValidator = &AgentWebhook{}
+
+// webhookRejections counts the agents the validating webhook rejects. The webhook metrics of
+// controller-runtime count rejected requests with the allowed ones, as both are answered with
+// a 200.
+var webhookRejections = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubeagentic_webhook_rejections_total",
+	Help: "Agent creations and updates rejected by the validating webhook.",
+}, []string{"operation"})
+
+func init() {
+	metrics.Registry.MustRegister(webhookRejections)
+}
+
+// countRejection counts err as a rejection of the operation when set.
+func countRejection(operation string, err error) error {
+	if err != nil {
+		webhookRejections.WithLabelValues(operation).Inc()
+	}
+	return err
+}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *AgentWebhook) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	agent, err := admittedAgent(obj)
+	if err != nil {
+		return nil, err
+	}
+	return agent.ValidateCreate(ctx, w.Reader)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *AgentWebhook) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	agent, err := admittedAgent(newObj)
+	if err != nil {
+		return nil, err
+	}
+	oldAgent, err := admittedAgent(oldObj)
+	if err != nil {
+		return nil, err
+	}
+	return agent.ValidateUpdate(ctx, w.Reader, oldAgent)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type
+func (w *AgentWebhook) ValidateDelete(_ context.Context, obj runtime.Object) (admission.Warnings, error) {
+	agent, err := admittedAgent(obj)
+	if err != nil {
+		return nil, err
+	}
+	return agent.ValidateDelete()
+}
 
-// ValidateCreate implements webhook.Validator so a webhook will be registered for the type
-func (r *Agent) ValidateCreate() (admission.Warnings, error) {
+// ValidateCreate validates a new agent.
+func (r *Agent) ValidateCreate(ctx context.Context, reader client.Reader) (admission.Warnings, error) {
 	log := logf.Log.WithName("agent-resource")
 	log.Info("validate create", "name", r.Name)
 
-	return nil, r.validateAgent()
+	warnings := r.warnings(ctx, reader)
+	if !secretref.Strict() {
+		for _, err := range r.missingSecrets(ctx, reader) {
+			warnings = append(warnings, err.Error()+"; the agent waits until it is created")
+		}
+	}
+	return warnings, countRejection("create", r.validateAgent(ctx, reader, nil))
 }
 
-// ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
-func (r *Agent) ValidateUpdate(old runtime.Object) (admission.Warnings, error) {
+// ValidateUpdate validates the update of old to the agent.
+func (r *Agent) ValidateUpdate(ctx context.Context, reader client.Reader, old *Agent) (admission.Warnings, error) {
 	log := logf.Log.WithName("agent-resource")
 	log.Info("validate update", "name", r.Name)
 
-	return nil, r.validateAgent()
+	warnings := r.warnings(ctx, reader)
+	warnings = append(warnings, r.frameworkChangeWarnings(old)...)
+	warnings = append(warnings, r.replicaLimitWarnings(ctx, reader, old)...)
+	return warnings, countRejection("update", r.validateAgent(ctx, reader, old))
 }
 
-// ValidateDelete implements webhook.Validator so a webhook will be registered for the type
+// ValidateDelete validates the deletion of the agent.
 func (r *Agent) ValidateDelete() (admission.Warnings, error) {
 	log := logf.Log.WithName("agent-resource")
 	log.Info("validate delete", "name", r.Name)
@@ -86,12 +269,124 @@ func (r *Agent) ValidateDelete() (admission.Warnings, error) {
 	return nil, nil
 }
 
+// maxUnshortenedNameLength is the longest agent name whose derived names, the longest being
+// "<name>-grafana-dashboard", all fit in a DNS label.
+const maxUnshortenedNameLength = naming.MaxLength - len("-grafana-dashboard")
+
+// warnings returns admission warnings for valid but discouraged configurations
+func (r *Agent) warnings(ctx context.Context, reader client.Reader) admission.Warnings {
+	var warnings admission.Warnings
+
+	// doNotDisrupt pins every node running an agent pod, which adds up across a large fleet
+	if r.Spec.Disruption != nil && r.Spec.Disruption.DoNotDisrupt {
+		warnings = append(warnings, "spec.disruption.doNotDisrupt keeps the cluster autoscaler and Karpenter from removing any node running an agent pod and blocks node drains; on large fleets this impedes node scale-down, consider consolidationPolicy OneAtATime instead")
+	}
+
+	// The names of the resources created for the agent append suffixes to its name
+	if len(r.Name) > maxUnshortenedNameLength {
+		warnings = append(warnings, fmt.Sprintf("names longer than %d characters are shortened with a hash in the names of the Service, ConfigMaps and other resources created for the agent; find them in status.resourceNames", maxUnshortenedNameLength))
+	}
+
+	// Debug logs the bodies of chat requests, which production data should not end up in
+	if r.Spec.Debug && r.inProductionNamespace(ctx, reader) {
+		warnings = append(warnings, fmt.Sprintf("spec.debug logs the bodies of chat requests and responses, redacting only API keys, bearer tokens and e-mail addresses, in namespace %s labeled %s=production", r.Namespace, environmentLabel))
+	}
+
+	// Hosted providers keep their models loaded, so there is nothing to preload
+	if r.Spec.Preload != nil && r.Spec.Preload.Enabled && (r.primaryEndpoint() == "" || r.Spec.Provider != "vllm" && r.Spec.Provider != "ollama") {
+		warnings = append(warnings, "spec.preload only applies to vllm and ollama agents with an endpoint and is skipped for this agent")
+	}
+
+	// Batched requests are held and sent by the agent container, which a low CPU limit throttles
+	if config := r.Spec.VLLM; config != nil && (config.MaxBatchSize != nil && *config.MaxBatchSize > 1 || config.MaxConcurrentSequences != nil) &&
+		r.Spec.Resources != nil && !r.Spec.Resources.Limits.Cpu().IsZero() && r.Spec.Resources.Limits.Cpu().Cmp(minBatchingCPU) < 0 {
+		warnings = append(warnings, fmt.Sprintf("spec.vllm batches requests in the agent container, whose CPU limit %s is below %s and may throttle it; raise resources.limits.cpu or choose a larger size", r.Spec.Resources.Limits.Cpu(), minBatchingCPU.String()))
+	}
+
+	// Each request in flight holds its conversation and response in the agent container
+	warnings = append(warnings, r.concurrencyWarnings()...)
+
+	// Files of a memory-backed scratch volume count against the memory limit of the container
+	if r.Spec.Scratch != nil && scratch.Medium(&r.Spec) == scratch.MediumMemory {
+		if limit := r.memoryLimit(); !limit.IsZero() && limit.Cmp(r.Spec.Scratch.SizeLimit) <= 0 {
+			warnings = append(warnings, fmt.Sprintf("spec.scratch.sizeLimit %s of the Memory medium counts against the memory limit %s of the agent container, which a full scratch volume exhausts; raise resources.limits.memory or use the Disk medium", r.Spec.Scratch.SizeLimit.String(), limit.String()))
+		}
+	}
+
+	// Every prompt is embedded to look it up, hits included
+	if r.Spec.Caching != nil && r.Spec.Caching.Enabled && r.Spec.Caching.KeyStrategy == "semantic" {
+		warnings = append(warnings, "spec.caching.keyStrategy semantic embeds every prompt to look it up in the cache, which consumes embedding tokens even for cache hits")
+	}
+
+	// The operator labels select the pods, so they override the labels of the agent
+	warnings = append(warnings, r.podMetadataWarnings()...)
+
+	// Pods exceeding a LimitRange are rejected, leaving the agent Pending
+	warnings = append(warnings, r.limitRangeWarnings(ctx, reader)...)
+
+	// The system prompt and the examples are sent with every request
+	warnings = append(warnings, r.examplesWarnings(ctx, reader)...)
+
+	// Agents stop working once the provider retires their model
+	now := time.Now()
+	if deprecation, ok := pricing.DeprecationFor(r.Spec.Provider, r.Spec.Model, now); ok {
+		warnings = append(warnings, "spec.model: "+deprecation.Message(r.Spec.Provider, r.Spec.Model, now))
+	}
+
+	return warnings
+}
+
+// limitRangeWarnings warns about the limits of spec.resources above the max of a Container
+// LimitRange of the namespace, as listed by the cached client. The operator reports the
+// pods it rejects on the Degraded condition; this only catches them earlier.
+func (r *Agent) limitRangeWarnings(ctx context.Context, reader client.Reader) admission.Warnings {
+	if reader == nil || r.Spec.Resources == nil {
+		return nil
+	}
+	var limitRanges corev1.LimitRangeList
+	if err := reader.List(ctx, &limitRanges, client.InNamespace(r.Namespace)); err != nil {
+		logf.Log.WithName("agent-resource").Error(err, "Failed to list LimitRanges", "namespace", r.Namespace)
+		return nil
+	}
+	var warnings admission.Warnings
+	for _, limitRange := range limitRanges.Items {
+		for _, item := range limitRange.Spec.Limits {
+			if item.Type != corev1.LimitTypeContainer {
+				continue
+			}
+			for name, max := range item.Max {
+				if limit, ok := r.Spec.Resources.Limits[name]; ok && limit.Cmp(max) > 0 {
+					warnings = append(warnings, fmt.Sprintf("spec.resources.limits.%s %s exceeds LimitRange %s max %s; the agent pods will be rejected", name, limit.String(), limitRange.Name, max.String()))
+				}
+			}
+		}
+	}
+	return warnings
+}
+
+// frameworkChangeWarnings warns when the framework changes while spec.image is set. The
+// operator default images are selected per framework, but a user image is kept as is, and
+// may not ship the runtime of the new framework.
+func (r *Agent) frameworkChangeWarnings(old *Agent) admission.Warnings {
+	framework, oldFramework := r.Spec.Framework, old.Spec.Framework
+	if framework == "" {
+		framework = "direct"
+	}
+	if oldFramework == "" {
+		oldFramework = "direct"
+	}
+	if r.Spec.Image == "" || framework == oldFramework {
+		return nil
+	}
+	return admission.Warnings{fmt.Sprintf("spec.framework changes from %s to %s while spec.image %s is kept; make sure the image ships the %s runtime, or remove spec.image to run the operator default image of the framework", oldFramework, framework, r.Spec.Image, framework)}
+}
+
 // validateAgent validates the Agent resource
-func (r *Agent) validateAgent() error {
+func (r *Agent) validateAgent(ctx context.Context, reader client.Reader, old *Agent) error {
 	var allErrs field.ErrorList
 
 	// Validate provider
-	validProviders := []string{"openai", "gemini", "claude", "vllm"}
+	validProviders := []string{"openai", "gemini", "claude", "vllm", "ollama"}
 	valid := false
 	for _, provider := range validProviders {
 		if r.Spec.Provider == provider {
@@ -107,11 +402,11 @@ func (r *Agent) validateAgent() error {
 		))
 	}
 
-	// Validate model
+	// Validate model, which the defaulting webhook set if the provider has a default model
 	if r.Spec.Model == "" {
 		allErrs = append(allErrs, field.Required(
 			field.NewPath("spec").Child("model"),
-			"model is required",
+			fmt.Sprintf("model is required, provider %q has no default model (set %s on the operator)", r.Spec.Provider, defaultmodel.EnvVar(r.Spec.Provider)),
 		))
 	}
 
@@ -123,18 +418,27 @@ func (r *Agent) validateAgent() error {
 		))
 	}
 
-	// Validate API secret reference
-	if r.Spec.ApiSecretRef.Name == "" {
-		allErrs = append(allErrs, field.Required(
-			field.NewPath("spec").Child("apiSecretRef").Child("name"),
-			"apiSecretRef.name is required",
-		))
-	}
-	if r.Spec.ApiSecretRef.Key == "" {
-		allErrs = append(allErrs, field.Required(
-			field.NewPath("spec").Child("apiSecretRef").Child("key"),
-			"apiSecretRef.key is required",
-		))
+	// Validate API secret reference, which self-hosted endpoints may omit
+	if r.Spec.ApiSecretRef == nil {
+		if provider.RequiresAPISecret(r.Spec.Provider, r.primaryEndpoint()) {
+			allErrs = append(allErrs, field.Required(
+				field.NewPath("spec").Child("apiSecretRef"),
+				fmt.Sprintf("apiSecretRef is required for provider %s without a self-hosted endpoint", r.Spec.Provider),
+			))
+		}
+	} else {
+		if r.Spec.ApiSecretRef.Name == "" {
+			allErrs = append(allErrs, field.Required(
+				field.NewPath("spec").Child("apiSecretRef").Child("name"),
+				"apiSecretRef.name is required",
+			))
+		}
+		if r.Spec.ApiSecretRef.Key == "" {
+			allErrs = append(allErrs, field.Required(
+				field.NewPath("spec").Child("apiSecretRef").Child("key"),
+				"apiSecretRef.key is required",
+			))
+		}
 	}
 
 	// Validate framework
@@ -153,18 +457,15 @@ func (r *Agent) validateAgent() error {
 			"langgraphConfig is required when framework is 'langgraph'",
 		))
 	}
+	if r.Spec.Framework == "langgraph" {
+		allErrs = append(allErrs, graphstate.Validate(field.NewPath("spec").Child("langgraphConfig"), r.Spec.LanggraphConfig)...)
+	}
 
 	// Validate replicas
-	if r.Spec.Replicas != nil && (*r.Spec.Replicas < 1 || *r.Spec.Replicas > 10) {
-		allErrs = append(allErrs, field.Invalid(
-			field.NewPath("spec").Child("replicas"),
-			*r.Spec.Replicas,
-			"must be between 1 and 10",
-		))
-	}
+	allErrs = append(allErrs, r.validateReplicas(ctx, reader, old)...)
 
 	// Validate service type
-	validServiceTypes := []string{"ClusterIP", "NodePort", "LoadBalancer"}
+	validServiceTypes := []corev1.ServiceType{corev1.ServiceTypeClusterIP, corev1.ServiceTypeNodePort, corev1.ServiceTypeLoadBalancer}
 	validServiceType := false
 	for _, serviceType := range validServiceTypes {
 		if r.Spec.ServiceType == serviceType {
@@ -180,6 +481,409 @@ func (r *Agent) validateAgent() error {
 		))
 	}
 
+	// Validate the size, whose preset the defaulting webhook copied into the resources
+	if r.Spec.Size != "" {
+		sizePath := field.NewPath("spec").Child("size")
+		if _, ok := sizing.For(r.Spec.Provider, r.Spec.Size); !ok {
+			allErrs = append(allErrs, field.NotSupported(sizePath, r.Spec.Size, sizing.Sizes))
+		} else if sizing.Conflicts(r.Spec.Provider, r.Spec.Size, r.Spec.Resources) {
+			allErrs = append(allErrs, field.Forbidden(
+				field.NewPath("spec").Child("resources"),
+				fmt.Sprintf("must not be set together with size %q, remove either of them", r.Spec.Size),
+			))
+		}
+	}
+
+	// Validate the log level, which the annotation overrides without a spec change
+	validLogLevels := []string{"debug", "info", "warn", "error"}
+	for _, level := range []struct {
+		path  *field.Path
+		value string
+	}{
+		{field.NewPath("spec").Child("logLevel"), r.Spec.LogLevel},
+		{field.NewPath("metadata").Child("annotations").Key(logLevelAnnotation), r.Annotations[logLevelAnnotation]},
+	} {
+		validLogLevel := level.value == ""
+		for _, logLevel := range validLogLevels {
+			if level.value == logLevel {
+				validLogLevel = true
+				break
+			}
+		}
+		if !validLogLevel {
+			allErrs = append(allErrs, field.Invalid(level.path, level.value, fmt.Sprintf("must be one of %v", validLogLevels)))
+		}
+	}
+
+	// Validate RAG configuration; the vector store is only required when RAG is enabled
+	if r.Spec.RAG != nil && r.Spec.RAG.Enabled {
+		vsPath := field.NewPath("spec").Child("rag").Child("vectorStore")
+		vs := r.Spec.RAG.VectorStore
+		if vs == nil {
+			allErrs = append(allErrs, field.Required(vsPath, "rag.vectorStore is required when rag is enabled"))
+		} else {
+			validVectorStores := []string{"pgvector", "qdrant", "weaviate"}
+			validVectorStore := false
+			for _, vectorStore := range validVectorStores {
+				if vs.Type == vectorStore {
+					validVectorStore = true
+					break
+				}
+			}
+			if !validVectorStore {
+				allErrs = append(allErrs, field.Invalid(
+					vsPath.Child("type"),
+					vs.Type,
+					fmt.Sprintf("must be one of %v", validVectorStores),
+				))
+			}
+			if vs.ConnectionSecretRef.Name == "" {
+				allErrs = append(allErrs, field.Required(
+					vsPath.Child("connectionSecretRef").Child("name"),
+					"connectionSecretRef.name is required",
+				))
+			}
+			if vs.ConnectionSecretRef.Key == "" {
+				allErrs = append(allErrs, field.Required(
+					vsPath.Child("connectionSecretRef").Child("key"),
+					"connectionSecretRef.key is required",
+				))
+			}
+			if vs.Collection == "" {
+				allErrs = append(allErrs, field.Required(
+					vsPath.Child("collection"),
+					"collection is required",
+				))
+			}
+			if vs.Dimensions <= 0 {
+				allErrs = append(allErrs, field.Invalid(
+					vsPath.Child("dimensions"),
+					vs.Dimensions,
+					"must be positive",
+				))
+			}
+		}
+
+		// Validate embeddings model with the same provider rules as the primary model
+		if embeddings := r.Spec.RAG.Embeddings; embeddings != nil {
+			embeddingsPath := field.NewPath("spec").Child("rag").Child("embeddings")
+			validEmbeddingsProvider := false
+			for _, provider := range validProviders {
+				if embeddings.Provider == provider {
+					validEmbeddingsProvider = true
+					break
+				}
+			}
+			if embeddings.Provider != "" && !validEmbeddingsProvider {
+				allErrs = append(allErrs, field.Invalid(
+					embeddingsPath.Child("provider"),
+					embeddings.Provider,
+					fmt.Sprintf("must be one of %v", validProviders),
+				))
+			}
+			if embeddings.Model == "" {
+				allErrs = append(allErrs, field.Required(embeddingsPath.Child("model"), "model is required"))
+			}
+			if (embeddings.Provider == "vllm" || embeddings.Provider == "ollama") && embeddings.Endpoint == "" {
+				allErrs = append(allErrs, field.Required(
+					embeddingsPath.Child("endpoint"),
+					fmt.Sprintf("endpoint is required for provider %s", embeddings.Provider),
+				))
+			}
+			if embeddings.ApiSecretRef == nil && r.Spec.ApiSecretRef == nil && provider.RequiresAPISecret(embeddings.Provider, embeddings.Endpoint) {
+				allErrs = append(allErrs, field.Required(
+					embeddingsPath.Child("apiSecretRef"),
+					fmt.Sprintf("apiSecretRef is required for provider %s without a self-hosted endpoint", embeddings.Provider),
+				))
+			}
+			if embeddings.ApiSecretRef != nil && (embeddings.ApiSecretRef.Name == "" || embeddings.ApiSecretRef.Key == "") {
+				allErrs = append(allErrs, field.Required(
+					embeddingsPath.Child("apiSecretRef"),
+					"apiSecretRef requires both name and key",
+				))
+			}
+		}
+
+		// Validate ingestion sources; each must set exactly one of url, configMapRef or bucket
+		if ingestion := r.Spec.RAG.Ingestion; ingestion != nil {
+			ingestionPath := field.NewPath("spec").Child("rag").Child("ingestion")
+			if ingestion.Schedule == "" {
+				allErrs = append(allErrs, field.Required(ingestionPath.Child("schedule"), "schedule is required"))
+			}
+			if len(ingestion.Sources) == 0 {
+				allErrs = append(allErrs, field.Required(ingestionPath.Child("sources"), "at least one source is required"))
+			}
+			for i, source := range ingestion.Sources {
+				sourcePath := ingestionPath.Child("sources").Index(i)
+				set := 0
+				if source.URL != "" {
+					set++
+					if !strings.HasPrefix(source.URL, "http://") && !strings.HasPrefix(source.URL, "https://") {
+						allErrs = append(allErrs, field.Invalid(sourcePath.Child("url"), source.URL, "must be an http or https URL"))
+					}
+				}
+				if source.ConfigMapRef != nil {
+					set++
+				}
+				if source.Bucket != nil {
+					set++
+					if source.Bucket.Bucket == "" {
+						allErrs = append(allErrs, field.Required(sourcePath.Child("bucket").Child("bucket"), "bucket name is required"))
+					}
+				}
+				if set != 1 {
+					allErrs = append(allErrs, field.Invalid(sourcePath, source, "must set exactly one of url, configMapRef or bucket"))
+				}
+			}
+		}
+	}
+
+	// Validate model cache sub path
+	if r.Spec.ModelCache != nil && r.Spec.ModelCache.Enabled {
+		cachePath := field.NewPath("spec").Child("modelCache")
+		if r.Spec.ModelCache.SubPath != "" && (strings.HasPrefix(r.Spec.ModelCache.SubPath, "/") || strings.Contains(r.Spec.ModelCache.SubPath, "..")) {
+			allErrs = append(allErrs, field.Invalid(
+				cachePath.Child("subPath"),
+				r.Spec.ModelCache.SubPath,
+				"must be a relative path without '..'",
+			))
+		}
+	}
+
+	// Validate conversation export
+	if export := r.Spec.Export; export != nil {
+		exportPath := field.NewPath("spec").Child("export")
+		if r.Spec.Memory == nil || (r.Spec.Memory.Backend != "redis" && r.Spec.Memory.Backend != "postgres") {
+			allErrs = append(allErrs, field.Required(field.NewPath("spec").Child("memory").Child("backend"), "export requires a redis or postgres memory backend"))
+		}
+		if export.Schedule == "" {
+			allErrs = append(allErrs, field.Required(exportPath.Child("schedule"), "schedule is required"))
+		}
+		if export.Destination.Bucket == "" {
+			allErrs = append(allErrs, field.Required(exportPath.Child("destination").Child("bucket"), "bucket is required"))
+		}
+		if export.CredentialsSecretRef.Name == "" {
+			allErrs = append(allErrs, field.Required(exportPath.Child("credentialsSecretRef").Child("name"), "credentialsSecretRef.name is required"))
+		}
+		if export.Format != "" && export.Format != "jsonl" && export.Format != "parquet" {
+			allErrs = append(allErrs, field.Invalid(exportPath.Child("format"), export.Format, "must be 'jsonl' or 'parquet'"))
+		}
+	}
+
+	// Validate the agent claim, mounted read-write by a single pod, and its backups
+	if persistence := r.Spec.Persistence; persistence != nil {
+		persistencePath := field.NewPath("spec").Child("persistence")
+		if persistence.Size != "" {
+			if _, err := resource.ParseQuantity(persistence.Size); err != nil {
+				allErrs = append(allErrs, field.Invalid(persistencePath.Child("size"), persistence.Size, "must be a quantity such as 5Gi"))
+			}
+		}
+		if r.Spec.Replicas != nil && *r.Spec.Replicas != 1 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("replicas"), *r.Spec.Replicas, "must be 1 with persistence"))
+		}
+		if r.Spec.WorkerMode != nil && r.Spec.WorkerMode.Enabled {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("workerMode"), "not supported with persistence"))
+		}
+		if r.Spec.EventSource != nil && r.Spec.EventSource.Autoscaling != nil && r.Spec.EventSource.Autoscaling.Enabled {
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("eventSource").Child("autoscaling"), "not supported with persistence"))
+		}
+		if backup := persistence.Backup; backup != nil {
+			if _, err := cronschedule.Parse(backup.Schedule); err != nil {
+				allErrs = append(allErrs, field.Invalid(persistencePath.Child("backup").Child("schedule"), backup.Schedule, err.Error()))
+			}
+		}
+		if snapshot := persistence.RestoreFromSnapshot; snapshot != "" {
+			for _, msg := range validation.IsDNS1123Subdomain(snapshot) {
+				allErrs = append(allErrs, field.Invalid(persistencePath.Child("restoreFromSnapshot"), snapshot, msg))
+			}
+		}
+	}
+
+	// Validate conversation memory; inmemory history is replica-local
+	if memory := r.Spec.Memory; memory != nil {
+		memoryPath := field.NewPath("spec").Child("memory")
+		switch memory.Backend {
+		case "", "inmemory":
+			if r.Spec.Replicas != nil && *r.Spec.Replicas > 1 && !memory.AllowReplicaLocal {
+				allErrs = append(allErrs, field.Forbidden(
+					memoryPath.Child("backend"),
+					"inmemory memory with more than one replica keeps conversations replica-local; use redis or postgres, or set allowReplicaLocal",
+				))
+			}
+			if memory.ConnectionSecretRef != nil {
+				allErrs = append(allErrs, field.Forbidden(memoryPath.Child("connectionSecretRef"), "not supported by the inmemory backend"))
+			}
+		case "redis":
+		case "postgres":
+			if memory.ConnectionSecretRef == nil {
+				allErrs = append(allErrs, field.Required(memoryPath.Child("connectionSecretRef"), "connectionSecretRef is required for the postgres backend"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(memoryPath.Child("backend"), memory.Backend, []string{"inmemory", "redis", "postgres"}))
+		}
+		if memory.MaxHistoryMessages > 0 && memory.SummarizationThreshold > memory.MaxHistoryMessages {
+			allErrs = append(allErrs, field.Invalid(
+				memoryPath.Child("summarizationThreshold"),
+				memory.SummarizationThreshold,
+				"must not exceed maxHistoryMessages",
+			))
+		}
+	}
+
+	// Validate conversation affinity; shared memory backends already serve any conversation on any replica
+	if r.Spec.Routing != nil && r.Spec.Routing.ConversationAffinity != nil && r.Spec.Routing.ConversationAffinity.Enabled {
+		if r.Spec.Memory != nil && (r.Spec.Memory.Backend == "redis" || r.Spec.Memory.Backend == "postgres") {
+			allErrs = append(allErrs, field.Forbidden(
+				field.NewPath("spec").Child("routing").Child("conversationAffinity"),
+				fmt.Sprintf("conversation affinity is unnecessary with the shared %s memory backend", r.Spec.Memory.Backend),
+			))
+		}
+	}
+
+	// Validate that the rollout strategy has its settings
+	if rollout := r.Spec.Rollout; rollout != nil {
+		rolloutPath := field.NewPath("spec").Child("rollout")
+		if rollout.Strategy == "blueGreen" {
+			if rollout.Canary != nil {
+				allErrs = append(allErrs, field.Forbidden(rolloutPath.Child("canary"), "cannot be combined with the blueGreen strategy"))
+			}
+			if rollout.AutoRollback != nil && rollout.AutoRollback.Enabled {
+				allErrs = append(allErrs, field.Forbidden(rolloutPath.Child("autoRollback"), "cannot be combined with the blueGreen strategy"))
+			}
+		} else {
+			if rollout.BlueGreen != nil {
+				allErrs = append(allErrs, field.Forbidden(rolloutPath.Child("blueGreen"), "requires the blueGreen strategy"))
+			}
+			// Without a strategy, automatic rollback monitors changes rolled out in place
+			inPlace := rollout.Strategy == "" && rollout.Canary == nil && rollout.AutoRollback != nil
+			if !inPlace && (rollout.Canary == nil || len(rollout.Canary.Steps) == 0) {
+				allErrs = append(allErrs, field.Required(rolloutPath.Child("canary").Child("steps"), "at least one step is required for the canary strategy"))
+			}
+		}
+	}
+
+	// Validate the A/B experiment; it splits the pods itself and cannot run next to a rollout
+	if experiment := r.Spec.Experiment; experiment != nil {
+		experimentPath := field.NewPath("spec").Child("experiment")
+		if r.Spec.Rollout != nil {
+			allErrs = append(allErrs, field.Forbidden(experimentPath, "cannot be combined with spec.rollout"))
+		}
+		if experiment.Duration.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(experimentPath.Child("duration"), experiment.Duration.Duration.String(), "must be positive"))
+		}
+		variant := experiment.VariantB
+		if variant.Model == "" && variant.SystemPrompt == "" && variant.Temperature == "" && variant.MaxTokens == nil {
+			allErrs = append(allErrs, field.Required(experimentPath.Child("variantB"), "must override at least one setting"))
+		}
+		if variant.Temperature != "" {
+			if _, err := strconv.ParseFloat(variant.Temperature, 64); err != nil {
+				allErrs = append(allErrs, field.Invalid(experimentPath.Child("variantB").Child("temperature"), variant.Temperature, "must be a number"))
+			}
+		}
+	}
+
+	// Validate that the synthetic probe interval maps to a cron schedule
+	if synthetics := r.Spec.Synthetics; synthetics != nil && synthetics.Enabled && synthetics.Interval != nil {
+		interval := synthetics.Interval.Duration
+		minutesBelowHour := interval >= time.Minute && interval < time.Hour && interval%time.Minute == 0
+		hoursUpToDay := interval >= time.Hour && interval <= 24*time.Hour && interval%time.Hour == 0
+		if !minutesBelowHour && !hoursUpToDay {
+			allErrs = append(allErrs, field.Invalid(
+				field.NewPath("spec").Child("synthetics").Child("interval"),
+				interval.String(),
+				"must be a whole number of minutes below 1h or a whole number of hours up to 24h",
+			))
+		}
+	}
+
+	// Validate that the budget uses the currency of the pricing catalog
+	if budget := r.Spec.Budget; budget != nil && budget.Currency != "" && budget.Currency != pricing.Currency {
+		allErrs = append(allErrs, field.NotSupported(field.NewPath("spec").Child("budget").Child("currency"), budget.Currency, []string{pricing.Currency}))
+	}
+
+	// Validate the time zone resetting the token quota
+	if quota := r.Spec.TokenQuota; quota != nil && quota.ResetTimezone != "" {
+		if _, err := time.LoadLocation(quota.ResetTimezone); err != nil {
+			allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("tokenQuota").Child("resetTimezone"), quota.ResetTimezone, "must be an IANA time zone"))
+		}
+	}
+
+	// Validate images against the operator and AgentPolicy registry allowlists
+	allErrs = append(allErrs, r.validateImages(ctx, reader)...)
+	allErrs = append(allErrs, r.validateImagePull()...)
+
+	// Validate cross-namespace secret references against the operator and AgentPolicy grants
+	allErrs = append(allErrs, r.validateSecretNamespaces(ctx, reader)...)
+
+	// Reject new agents referencing missing Secrets when the operator is strict about it
+	if old == nil && secretref.Strict() {
+		allErrs = append(allErrs, r.missingSecrets(ctx, reader)...)
+	}
+
+	// Require encryption of persisted conversations in namespaces marked for compliance
+	allErrs = append(allErrs, r.validateEncryptionRequired(ctx, reader)...)
+
+	// Reject settings that would break the Pod Security Standards level of the agent pods
+	allErrs = append(allErrs, r.validateSecurityProfile()...)
+
+	// Validate the name overrides and their collisions with the resources of other agents
+	allErrs = append(allErrs, r.validateNameOverrides(ctx, reader)...)
+
+	// Validate the Ingress host and path and their overlaps with those of other agents
+	allErrs = append(allErrs, r.validateIngress(ctx, reader, old)...)
+
+	// Validate the dependencies and reject cycles, in which every agent would wait forever
+	allErrs = append(allErrs, r.validateDependencies(ctx, reader)...)
+
+	// Validate the peers, which are told apart by their aliases
+	allErrs = append(allErrs, r.validatePeers()...)
+
+	// Validate the placements relative to other agents, and on nodes
+	allErrs = append(allErrs, r.validatePlacement()...)
+	allErrs = append(allErrs, r.validateNodeScheduling()...)
+	allErrs = append(allErrs, r.validateTopologySpread()...)
+	allErrs = append(allErrs, r.validatePriorityAndScheduler()...)
+	allErrs = append(allErrs, r.validatePodMetadata()...)
+
+	// Validate the inbound rate limits, which the proxy evaluates on every request
+	allErrs = append(allErrs, r.validateInboundRateLimit()...)
+
+	// Validate the guardrails, whose proxy shares the network of the pod with the other sidecars
+	allErrs = append(allErrs, r.validateGuardrails()...)
+	allErrs = append(allErrs, r.validateExamples()...)
+
+	// Validate the model schedule, whose models must be priced like spec.model
+	allErrs = append(allErrs, r.validateModelSchedule()...)
+
+	// Validate the transcript sink, which receives the conversations of the agent
+	allErrs = append(allErrs, r.validateTranscriptSink()...)
+	allErrs = append(allErrs, r.validateSLO()...)
+
+	// Validate the connectors, whose names key their configuration in the sidecar
+	allErrs = append(allErrs, r.validateConnectors()...)
+
+	// Validate the tool executor, which runs commands chosen by the model
+	allErrs = append(allErrs, r.validateToolExecutor()...)
+	allErrs = append(allErrs, r.validateEventSource()...)
+	allErrs = append(allErrs, r.validateWorkerMode()...)
+	allErrs = append(allErrs, r.validateHealthCheck()...)
+	allErrs = append(allErrs, r.validateEndpoints()...)
+	allErrs = append(allErrs, r.validateVLLM()...)
+	allErrs = append(allErrs, r.validateConcurrency()...)
+	allErrs = append(allErrs, scratch.Validate(&r.Spec)...)
+	allErrs = append(allErrs, r.validateCaching()...)
+	allErrs = append(allErrs, r.validatePodTemplateOverrides()...)
+	allErrs = append(allErrs, r.validateAutoscalingCeiling(ctx, reader, old)...)
+
+	// Validate the redaction rules, compiled by the backtracking engine of the runtime
+	allErrs = append(allErrs, redaction.Validate(field.NewPath("spec").Child("redaction"), r.Spec.Redaction)...)
+
+	// Validate the time to live of preview agents
+	allErrs = append(allErrs, r.validateTTL(ctx, reader, old)...)
+
+	// Validate the namespace the agent is promoted to
+	allErrs = append(allErrs, promotion.Validate(r.Namespace, r.Annotations)...)
+
 	if len(allErrs) == 0 {
 		return nil
 	}
@@ -187,9 +891,1351 @@ func (r *Agent) validateAgent() error {
 	return fmt.Errorf("validation failed: %v", allErrs)
 }
 
-// SetupWebhookWithManager sets up the webhook with the Manager
-func (r *Agent) SetupWebhookWithManager(mgr ctrl.Manager) error {
+// replicaLimit returns the maximum of spec.replicas in the agent's namespace and what sets
+// it, the operator or one of the AgentPolicies listed by the cached client.
+func (r *Agent) replicaLimit(ctx context.Context, reader client.Reader) (int32, string, error) {
+	var policies []aiv1.AgentPolicy
+	if reader != nil {
+		var list aiv1.AgentPolicyList
+		if err := reader.List(ctx, &list, client.InNamespace(r.Namespace)); err != nil {
+			return 0, "", fmt.Errorf("failed to list AgentPolicies: %w", err)
+		}
+		policies = list.Items
+	}
+	max, source := replicalimit.Max(r.Namespace, policies)
+	return max, source, nil
+}
+
+// validateReplicas rejects replicas above the maximum of the operator and the AgentPolicies
+// of the namespace. Updates keeping or lowering replicas set before the maximum was lowered
+// are allowed, with a warning from replicaLimitWarnings, so that lowering it does not lock
+// existing agents.
+func (r *Agent) validateReplicas(ctx context.Context, reader client.Reader, old *Agent) field.ErrorList {
+	replicasPath := field.NewPath("spec").Child("replicas")
+	if r.Spec.Replicas == nil {
+		return nil
+	}
+	if *r.Spec.Replicas < 1 {
+		return field.ErrorList{field.Invalid(replicasPath, *r.Spec.Replicas, "must be at least 1")}
+	}
+	max, source, err := r.replicaLimit(ctx, reader)
+	if err != nil {
+		return field.ErrorList{field.InternalError(replicasPath, err)}
+	}
+	if *r.Spec.Replicas <= max {
+		return nil
+	}
+	if old != nil && old.Spec.Replicas != nil && *r.Spec.Replicas <= *old.Spec.Replicas {
+		return nil
+	}
+	return field.ErrorList{field.Invalid(replicasPath, *r.Spec.Replicas, fmt.Sprintf("must be between 1 and %d, the maximum of %s", max, source))}
+}
+
+// replicaLimitWarnings warns about replicas kept above a maximum lowered since they were set.
+func (r *Agent) replicaLimitWarnings(ctx context.Context, reader client.Reader, old *Agent) admission.Warnings {
+	if r.Spec.Replicas == nil || old.Spec.Replicas == nil || *r.Spec.Replicas > *old.Spec.Replicas {
+		return nil
+	}
+	max, source, err := r.replicaLimit(ctx, reader)
+	if err != nil || *r.Spec.Replicas <= max {
+		return nil
+	}
+	return admission.Warnings{fmt.Sprintf("spec.replicas %d exceeds the maximum %d of %s; it is kept as it was set before, but cannot be raised", *r.Spec.Replicas, max, source)}
+}
+
+// validateTTL rejects a ttl or expireAt on agents labeled as production, or in a namespace
+// labeled as production, and lifetimes, extensions included, above the maximum of the
+// operator and the AgentPolicies of the namespace. Updates keeping the deadline are allowed,
+// so that lowering the maximum does not lock existing agents.
+func (r *Agent) validateTTL(ctx context.Context, reader client.Reader, old *Agent) field.ErrorList {
+	allErrs := expiry.Validate(r, &r.Spec)
+	if r.Spec.TTL == nil && r.Spec.ExpireAt == nil || len(allErrs) > 0 {
+		return allErrs
+	}
+	ttlPath := field.NewPath("spec").Child("ttl")
+	if r.Spec.TTL == nil {
+		ttlPath = field.NewPath("spec").Child("expireAt")
+	}
+	if r.Labels[environmentLabel] == "production" {
+		return field.ErrorList{field.Forbidden(ttlPath, fmt.Sprintf("agents labeled %s=production are not deleted on expiry", environmentLabel))}
+	}
+	if r.inProductionNamespace(ctx, reader) {
+		return field.ErrorList{field.Forbidden(ttlPath, fmt.Sprintf("agents in namespace %s labeled %s=production are not deleted on expiry", r.Namespace, environmentLabel))}
+	}
+
+	// The creation time is only set once the agent is created
+	created := r.CreationTimestamp.Time
+	if created.IsZero() {
+		created = time.Now()
+	}
+	deadline, _ := expiry.Deadline(r, &r.Spec, created)
+	if old != nil {
+		if oldDeadline, ok := expiry.Deadline(old, &old.Spec, created); ok && !deadline.After(oldDeadline) {
+			return nil
+		}
+	}
+	var policies []aiv1.AgentPolicy
+	if reader != nil {
+		var list aiv1.AgentPolicyList
+		if err := reader.List(ctx, &list, client.InNamespace(r.Namespace)); err != nil {
+			return field.ErrorList{field.InternalError(ttlPath, fmt.Errorf("failed to list AgentPolicies: %w", err))}
+		}
+		policies = list.Items
+	}
+	max, source := expiry.Max(r.Namespace, policies)
+	if max > 0 && deadline.Sub(created) > max {
+		return field.ErrorList{field.Invalid(ttlPath, deadline.Sub(created).Round(time.Second).String(), fmt.Sprintf("the lifetime of the agent, extensions included, must not exceed %s, the maximum of %s", max, source))}
+	}
+	return nil
+}
+
+// autoscalerBounds returns the minimum and maximum replicas of the autoscalers of the agent,
+// keyed by the field setting their maximum, as the operator renders them: the HPA scales the
+// agent up to three times spec.replicas, and KEDA up to 10 replicas by default.
+func (r *Agent) autoscalerBounds() map[string][2]int32 {
+	bounds := map[string][2]int32{}
+	eventSourceScaled := r.Spec.EventSource != nil && r.Spec.EventSource.Autoscaling != nil && r.Spec.EventSource.Autoscaling.Enabled
+	if eventSourceScaled {
+		scaling := r.Spec.EventSource.Autoscaling
+		minReplicas, maxReplicas := int32(1), int32(10)
+		if scaling.MinReplicas != nil {
+			minReplicas = *scaling.MinReplicas
+		}
+		if scaling.MaxReplicas != nil {
+			maxReplicas = *scaling.MaxReplicas
+		}
+		bounds["spec.eventSource.autoscaling.maxReplicas"] = [2]int32{minReplicas, maxReplicas}
+	} else if r.Spec.Replicas != nil && *r.Spec.Replicas > 1 {
+		bounds["spec.replicas"] = [2]int32{*r.Spec.Replicas, *r.Spec.Replicas * 3}
+	}
+	if workerMode := r.Spec.WorkerMode; workerMode != nil && workerMode.Enabled && workerMode.Autoscaling != nil && workerMode.Autoscaling.Enabled {
+		minReplicas, maxReplicas := int32(0), int32(10)
+		if workerMode.Autoscaling.MinReplicas != nil {
+			minReplicas = *workerMode.Autoscaling.MinReplicas
+		}
+		if workerMode.Autoscaling.MaxReplicas != nil {
+			maxReplicas = *workerMode.Autoscaling.MaxReplicas
+		}
+		bounds["spec.workerMode.autoscaling.maxReplicas"] = [2]int32{minReplicas, maxReplicas}
+	}
+	return bounds
+}
+
+// validateAutoscalingCeiling rejects autoscalers scaling above the autoscaling ceiling of the
+// operator and the AgentPolicies of the namespace, judging the pods by the requests of the
+// agent container. The HPA maximum is not set by the agent and is clamped by the operator,
+// but spec.replicas, its minimum, must fit. Updates keeping or lowering a maximum set before
+// the ceiling was lowered are allowed; the operator clamps it and reports PolicyClamped.
+func (r *Agent) validateAutoscalingCeiling(ctx context.Context, reader client.Reader, old *Agent) field.ErrorList {
+	bounds := r.autoscalerBounds()
+	if len(bounds) == 0 {
+		return nil
+	}
+	var policies []aiv1.AgentPolicy
+	if reader != nil {
+		var list aiv1.AgentPolicyList
+		if err := reader.List(ctx, &list, client.InNamespace(r.Namespace)); err != nil {
+			return field.ErrorList{field.InternalError(field.NewPath("spec"), fmt.Errorf("failed to list AgentPolicies: %w", err))}
+		}
+		policies = list.Items
+	}
+	ceiling := replicalimit.CeilingFor(r.Namespace, policies)
+	requests := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("256Mi")}
+	if r.Spec.Resources != nil {
+		for name, quantity := range r.Spec.Resources.Requests {
+			requests[name] = quantity
+		}
+	}
+
+	var oldBounds map[string][2]int32
+	if old != nil {
+		oldBounds = old.autoscalerBounds()
+	}
+	var allErrs field.ErrorList
+	for _, path := range []string{"spec.replicas", "spec.eventSource.autoscaling.maxReplicas", "spec.workerMode.autoscaling.maxReplicas"} {
+		bound, ok := bounds[path]
+		if !ok {
+			continue
+		}
+		minReplicas, maxReplicas := bound[0], bound[1]
+		if path == "spec.replicas" {
+			// The operator clamps the HPA down to its minimum
+			maxReplicas = minReplicas
+		}
+		if previous, ok := oldBounds[path]; ok && bound[1] <= previous[1] {
+			continue
+		}
+		if _, exceeded := ceiling.Clamp(minReplicas, maxReplicas, requests); len(exceeded) > 0 {
+			allErrs = append(allErrs, field.Invalid(field.NewPath(path), maxReplicas, "the autoscaling ceiling is exceeded: "+strings.Join(exceeded, ", ")))
+		}
+	}
+	return allErrs
+}
+
+// specImage is a user-provided image of an agent and the path of its field.
+type specImage struct {
+	path  string
+	image string
+}
+
+// specImages returns the user-provided images of the agent in the order of their fields.
+func (r *Agent) specImages() []specImage {
+	var images []specImage
+	if r.Spec.Image != "" {
+		images = append(images, specImage{"spec.image", r.Spec.Image})
+	}
+	if r.Spec.RAG != nil && r.Spec.RAG.Ingestion != nil && r.Spec.RAG.Ingestion.Image != "" {
+		images = append(images, specImage{"spec.rag.ingestion.image", r.Spec.RAG.Ingestion.Image})
+	}
+	if r.Spec.Export != nil && r.Spec.Export.Image != "" {
+		images = append(images, specImage{"spec.export.image", r.Spec.Export.Image})
+	}
+	if r.Spec.Synthetics != nil && r.Spec.Synthetics.Image != "" {
+		images = append(images, specImage{"spec.synthetics.image", r.Spec.Synthetics.Image})
+	}
+	if r.Spec.ToolExecutor != nil && r.Spec.ToolExecutor.Image != "" {
+		images = append(images, specImage{"spec.toolExecutor.image", r.Spec.ToolExecutor.Image})
+	}
+	return images
+}
+
+// validateImages checks the user-provided images against the registry allowlists of the
+// operator and of every AgentPolicy in the agent's namespace, in the order of the policy
+// names, so that the errors are the same on every admission.
+func (r *Agent) validateImages(ctx context.Context, reader client.Reader) field.ErrorList {
+	var allErrs field.ErrorList
+
+	images := r.specImages()
+	if len(images) == 0 {
+		return nil
+	}
+
+	allowlists := []struct {
+		source  string
+		allowed []string
+	}{{"operator", imagepolicy.AllowedRegistriesFromEnv()}}
+	if reader != nil {
+		var policies aiv1.AgentPolicyList
+		if err := reader.List(ctx, &policies, client.InNamespace(r.Namespace)); err != nil {
+			return field.ErrorList{field.InternalError(field.NewPath("spec"), fmt.Errorf("failed to list AgentPolicies: %w", err))}
+		}
+		sort.Slice(policies.Items, func(i, j int) bool { return policies.Items[i].Name < policies.Items[j].Name })
+		for _, policy := range policies.Items {
+			allowlists = append(allowlists, struct {
+				source  string
+				allowed []string
+			}{"AgentPolicy " + policy.Name, policy.Spec.AllowedRegistries})
+		}
+	}
+
+	for _, image := range images {
+		path := field.NewPath(image.path)
+		for _, allowlist := range allowlists {
+			ok, err := imagepolicy.RegistryAllowed(image.image, allowlist.allowed)
+			if err != nil {
+				allErrs = append(allErrs, field.Invalid(path, image.image, err.Error()))
+				break
+			}
+			if !ok {
+				allErrs = append(allErrs, field.Forbidden(path, fmt.Sprintf(
+					"image %q is not from a registry allowed by the %s, allowed registries are %s",
+					image.image, allowlist.source, strings.Join(allowlist.allowed, ", "),
+				)))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateImagePull requires a supported pull policy of the agent image and named pull
+// Secrets, each listed once.
+func (r *Agent) validateImagePull() field.ErrorList {
+	specPath := field.NewPath("spec")
+	var allErrs field.ErrorList
+	switch policy := r.Spec.ImagePullPolicy; policy {
+	case "", corev1.PullAlways, corev1.PullIfNotPresent, corev1.PullNever:
+	default:
+		allErrs = append(allErrs, field.NotSupported(specPath.Child("imagePullPolicy"), policy, []string{string(corev1.PullAlways), string(corev1.PullIfNotPresent), string(corev1.PullNever)}))
+	}
+	seen := map[string]bool{}
+	for i, secret := range r.Spec.ImagePullSecrets {
+		secretPath := specPath.Child("imagePullSecrets").Index(i).Child("name")
+		if secret.Name == "" {
+			allErrs = append(allErrs, field.Required(secretPath, "the pull Secret name is required"))
+			continue
+		}
+		for _, msg := range validation.IsDNS1123Subdomain(secret.Name) {
+			allErrs = append(allErrs, field.Invalid(secretPath, secret.Name, msg))
+		}
+		if seen[secret.Name] {
+			allErrs = append(allErrs, field.Duplicate(secretPath, secret.Name))
+		}
+		seen[secret.Name] = true
+	}
+	return allErrs
+}
+
+// validateSecretNamespaces checks that Secrets referenced from other namespaces come from a
+// namespace granting the agent's namespace access, through the operator configuration or an
+// AgentPolicy of the source namespace.
+func (r *Agent) validateSecretNamespaces(ctx context.Context, reader client.Reader) field.ErrorList {
+	var embeddingsRef *aiv1.SecretKeyReference
+	if r.Spec.RAG != nil && r.Spec.RAG.Embeddings != nil {
+		embeddingsRef = r.Spec.RAG.Embeddings.ApiSecretRef
+	}
+
+	var allErrs field.ErrorList
+	for _, reference := range []struct {
+		path *field.Path
+		ref  *aiv1.SecretKeyReference
+	}{
+		{field.NewPath("spec").Child("apiSecretRef").Child("namespace"), r.Spec.ApiSecretRef},
+		{field.NewPath("spec").Child("rag").Child("embeddings").Child("apiSecretRef").Child("namespace"), embeddingsRef},
+	} {
+		ref := reference.ref
+		if ref == nil || ref.Namespace == "" || ref.Namespace == r.Namespace {
+			continue
+		}
+		var policies aiv1.AgentPolicyList
+		if reader != nil {
+			if err := reader.List(ctx, &policies, client.InNamespace(ref.Namespace)); err != nil {
+				return field.ErrorList{field.InternalError(field.NewPath("spec"), fmt.Errorf("failed to list AgentPolicies: %w", err))}
+			}
+		}
+		if !secretref.NamespaceAllowed(r.Namespace, ref.Namespace, policies.Items) {
+			allErrs = append(allErrs, field.Forbidden(reference.path, fmt.Sprintf(
+				"secrets from namespace %s are not allowed for namespace %s; list it in the secretConsumerNamespaces of an AgentPolicy of namespace %s or in ALLOWED_SECRET_NAMESPACES",
+				ref.Namespace, r.Namespace, ref.Namespace,
+			)))
+		}
+	}
+	return allErrs
+}
+
+// missingSecrets returns the Secrets and keys referenced by the agent that do not exist,
+// as read by the cached client. Lookups that fail or time out are skipped, so that a slow
+// API server never blocks admission.
+func (r *Agent) missingSecrets(ctx context.Context, reader client.Reader) field.ErrorList {
+	if reader == nil {
+		return nil
+	}
+	ctx = logf.IntoContext(ctx, logf.Log.WithName("agent-resource"))
+	return secretref.Missing(ctx, reader, secretref.Refs(r.Namespace, &r.Spec))
+}
+
+// validateSecurityProfile rejects settings that would make the generated pods violate the
+// agent's security profile, so that they fail at admission rather than on reconcile.
+func (r *Agent) validateSecurityProfile() field.ErrorList {
+	profile := securityprofile.Resolve(r.Spec.SecurityProfile)
+	if !securityprofile.Valid(profile) {
+		return field.ErrorList{field.NotSupported(field.NewPath("spec").Child("securityProfile"), profile, []string{securityprofile.Baseline, securityprofile.Restricted})}
+	}
+	if profile == "" {
+		return nil
+	}
+
+	var allErrs field.ErrorList
+	// The operator's hostPath model cache is forbidden by both levels; a claim must be used instead
+	if r.Spec.ModelCache != nil && r.Spec.ModelCache.Enabled && r.Spec.ModelCache.PVCName == "" && os.Getenv("MODEL_CACHE_HOST_PATH") != "" {
+		allErrs = append(allErrs, field.Forbidden(
+			field.NewPath("spec").Child("modelCache"),
+			fmt.Sprintf("the operator's hostPath model cache violates the %s security profile; set modelCache.pvcName", profile),
+		))
+	}
+	return allErrs
+}
+
+// validateNameOverrides checks that the overridden resource names are DNS-1123 labels, and
+// that no resource of the agent takes the name of the same kind of resource of another
+// agent in the namespace, found through the resource name index.
+func (r *Agent) validateNameOverrides(ctx context.Context, reader client.Reader) field.ErrorList {
+	var allErrs field.ErrorList
+	overridesPath := field.NewPath("spec").Child("nameOverrides")
+	if overrides := r.Spec.NameOverrides; overrides != nil {
+		for _, override := range []struct {
+			name  string
+			value string
+		}{
+			{"deployment", overrides.Deployment},
+			{"service", overrides.Service},
+			{"configMap", overrides.ConfigMap},
+			{"ingress", overrides.Ingress},
+			{"hpa", overrides.HPA},
+		} {
+			if override.value == "" {
+				continue
+			}
+			for _, msg := range validation.IsDNS1123Label(override.value) {
+				allErrs = append(allErrs, field.Invalid(overridesPath.Child(override.name), override.value, msg))
+			}
+		}
+	}
+	if len(allErrs) > 0 || reader == nil {
+		return allErrs
+	}
+
+	for _, value := range naming.IndexValues(naming.Names(r.Name, r.Spec.NameOverrides)) {
+		var agents aiv1.AgentList
+		if err := reader.List(ctx, &agents, client.InNamespace(r.Namespace), client.MatchingFields{naming.ResourceNameIndex: value}); err != nil {
+			return field.ErrorList{field.InternalError(overridesPath, fmt.Errorf("failed to list Agents: %w", err))}
+		}
+		for _, other := range agents.Items {
+			if other.Name != r.Name {
+				allErrs = append(allErrs, field.Duplicate(overridesPath, fmt.Sprintf("%s is also used by agent %s", value, other.Name)))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateIngress validates spec.ingress, and rejects hosts and paths overlapping those of an
+// agent of any namespace, found through the ingress host index. Updates keeping the host and
+// path are accepted, so that an agent admitted while the webhook was bypassed can still be
+// changed; the controller reports its conflict.
+func (r *Agent) validateIngress(ctx context.Context, reader client.Reader, old *Agent) field.ErrorList {
+	allErrs := ingresshost.Validate(&r.Spec)
+	route, ok := ingresshost.For(r.Name, r.Namespace, &r.Spec)
+	if len(allErrs) > 0 || !ok || reader == nil {
+		return allErrs
+	}
+	if old != nil {
+		if oldRoute, ok := ingresshost.For(old.Name, old.Namespace, &old.Spec); ok && oldRoute == route {
+			return nil
+		}
+	}
+
+	ingressPath := field.NewPath("spec").Child("ingress")
+	var agents aiv1.AgentList
+	if err := reader.List(ctx, &agents, client.MatchingFields{ingresshost.Index: route.Host}); err != nil {
+		return field.ErrorList{field.InternalError(ingressPath, fmt.Errorf("failed to list Agents: %w", err))}
+	}
+	for _, other := range ingresshost.Conflicts(r.Name, r.Namespace, route, agents.Items) {
+		otherRoute, _ := ingresshost.For(other.Name, other.Namespace, &other.Spec)
+		allErrs = append(allErrs, field.Duplicate(ingressPath, fmt.Sprintf("%s overlaps %s of agent %s/%s", route, otherRoute, other.Namespace, other.Name)))
+	}
+	return allErrs
+}
+
+// validateDependencies checks that each dependency refers to either an Agent or a Service,
+// and that the agent does not depend on itself through other agents.
+func (r *Agent) validateDependencies(ctx context.Context, reader client.Reader) field.ErrorList {
+	dependsOnPath := field.NewPath("spec").Child("dependsOn")
+	var allErrs field.ErrorList
+	for i, dep := range r.Spec.DependsOn {
+		if (dep.Agent == "") == (dep.Service == "") {
+			allErrs = append(allErrs, field.Invalid(dependsOnPath.Index(i), dep, "must set exactly one of agent and service"))
+		}
+	}
+
+	agentDependencies := func(agentNamespace string, dependsOn []aiv1.AgentDependency) []dependency.Key {
+		var keys []dependency.Key
+		for _, dep := range dependsOn {
+			if dep.Agent == "" {
+				continue
+			}
+			namespace := dep.Namespace
+			if namespace == "" {
+				namespace = agentNamespace
+			}
+			keys = append(keys, dependency.Key{Namespace: namespace, Name: dep.Agent})
+		}
+		return keys
+	}
+	self := dependency.Key{Namespace: r.Namespace, Name: r.Name}
+	cycle, err := dependency.FindCycle(self, func(key dependency.Key) ([]dependency.Key, error) {
+		if key == self {
+			return agentDependencies(r.Namespace, r.Spec.DependsOn), nil
+		}
+		if reader == nil {
+			return nil, nil
+		}
+		other := &aiv1.Agent{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: key.Name, Namespace: key.Namespace}, other); apierrors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return agentDependencies(other.Namespace, other.Spec.DependsOn), nil
+	})
+	if err != nil {
+		return append(allErrs, field.InternalError(dependsOnPath, fmt.Errorf("failed to get Agents: %w", err)))
+	}
+	if cycle != nil {
+		allErrs = append(allErrs, field.Invalid(dependsOnPath, dependency.Describe(cycle), "circular dependency"))
+	}
+	return allErrs
+}
+
+// validatePeers rejects agents peering with themselves and peers sharing an alias. Peers
+// that do not exist are accepted, as they may be created later.
+func (r *Agent) validatePeers() field.ErrorList {
+	peersPath := field.NewPath("spec").Child("peers")
+	var allErrs field.ErrorList
+	aliases := map[string]bool{}
+	for i, peer := range r.Spec.Peers {
+		if peer.AgentRef == r.Name {
+			allErrs = append(allErrs, field.Invalid(peersPath.Index(i).Child("agentRef"), peer.AgentRef, "an agent cannot be its own peer"))
+		}
+		alias := peer.Alias
+		if alias == "" {
+			alias = peer.AgentRef
+		}
+		if aliases[alias] {
+			allErrs = append(allErrs, field.Duplicate(peersPath.Index(i).Child("alias"), alias))
+		}
+		aliases[alias] = true
+	}
+	return allErrs
+}
+
+// validatePlacement rejects placements relative to the agent itself, agents listed twice,
+// and agents the pods must be both next to and away from.
+func (r *Agent) validatePlacement() field.ErrorList {
+	var allErrs field.ErrorList
+	validate := func(path *field.Path, placements []aiv1.AgentPlacement) map[string]bool {
+		seen := map[string]bool{}
+		for i, placement := range placements {
+			if placement.AgentRef == r.Name {
+				allErrs = append(allErrs, field.Invalid(path.Index(i).Child("agentRef"), placement.AgentRef, "an agent cannot be placed relative to itself"))
+			}
+			if seen[placement.AgentRef] {
+				allErrs = append(allErrs, field.Duplicate(path.Index(i).Child("agentRef"), placement.AgentRef))
+			}
+			seen[placement.AgentRef] = true
+			if placement.TopologyKey != "" {
+				for _, msg := range validation.IsQualifiedName(placement.TopologyKey) {
+					allErrs = append(allErrs, field.Invalid(path.Index(i).Child("topologyKey"), placement.TopologyKey, msg))
+				}
+			}
+		}
+		return seen
+	}
+	colocated := validate(field.NewPath("spec").Child("colocateWith"), r.Spec.ColocateWith)
+	spreadPath := field.NewPath("spec").Child("spreadFrom")
+	validate(spreadPath, r.Spec.SpreadFrom)
+	for i, placement := range r.Spec.SpreadFrom {
+		if colocated[placement.AgentRef] {
+			allErrs = append(allErrs, field.Invalid(spreadPath.Index(i).Child("agentRef"), placement.AgentRef, "the agent is also in colocateWith"))
+		}
+	}
+	return allErrs
+}
+
+// validateNodeScheduling requires node selector labels and tolerations the API server would
+// accept in the pod template, so that a mistake fails the agent rather than its Deployment.
+func (r *Agent) validateNodeScheduling() field.ErrorList {
+	specPath := field.NewPath("spec")
+	allErrs := metav1validation.ValidateLabels(r.Spec.NodeSelector, specPath.Child("nodeSelector"))
+	for i, toleration := range r.Spec.Tolerations {
+		tolerationPath := specPath.Child("tolerations").Index(i)
+		if toleration.Key != "" {
+			for _, msg := range validation.IsQualifiedName(toleration.Key) {
+				allErrs = append(allErrs, field.Invalid(tolerationPath.Child("key"), toleration.Key, msg))
+			}
+		}
+		switch toleration.Operator {
+		case corev1.TolerationOpExists:
+			if toleration.Value != "" {
+				allErrs = append(allErrs, field.Invalid(tolerationPath.Child("value"), toleration.Value, "must be empty when operator is Exists"))
+			}
+		case "", corev1.TolerationOpEqual:
+			if toleration.Key == "" {
+				allErrs = append(allErrs, field.Invalid(tolerationPath.Child("operator"), toleration.Operator, "must be Exists when key is empty"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(tolerationPath.Child("operator"), toleration.Operator, []string{string(corev1.TolerationOpEqual), string(corev1.TolerationOpExists)}))
+		}
+		switch toleration.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			allErrs = append(allErrs, field.NotSupported(tolerationPath.Child("effect"), toleration.Effect, []string{string(corev1.TaintEffectNoSchedule), string(corev1.TaintEffectPreferNoSchedule), string(corev1.TaintEffectNoExecute)}))
+		}
+		if toleration.TolerationSeconds != nil && toleration.Effect != corev1.TaintEffectNoExecute {
+			allErrs = append(allErrs, field.Invalid(tolerationPath.Child("tolerationSeconds"), *toleration.TolerationSeconds, "only applies to the NoExecute effect"))
+		}
+	}
+	return allErrs
+}
+
+// validatePriorityAndScheduler requires a PriorityClass and a scheduler name the API server
+// would accept in the pod template, both DNS subdomains.
+func (r *Agent) validatePriorityAndScheduler() field.ErrorList {
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+	if r.Spec.PriorityClassName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(r.Spec.PriorityClassName) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("priorityClassName"), r.Spec.PriorityClassName, msg))
+		}
+	}
+	if r.Spec.SchedulerName != "" {
+		for _, msg := range validation.IsDNS1123Subdomain(r.Spec.SchedulerName) {
+			allErrs = append(allErrs, field.Invalid(specPath.Child("schedulerName"), r.Spec.SchedulerName, msg))
+		}
+	}
+	return allErrs
+}
+
+// validatePodMetadata requires pod labels and annotations the API server would accept in the
+// pod template.
+func (r *Agent) validatePodMetadata() field.ErrorList {
+	specPath := field.NewPath("spec")
+	allErrs := metav1validation.ValidateLabels(r.Spec.PodLabels, specPath.Child("podLabels"))
+	return append(allErrs, apivalidation.ValidateAnnotations(r.Spec.PodAnnotations, specPath.Child("podAnnotations"))...)
+}
+
+// operatorOwnedPodLabel reports whether the operator sets the pod label key, overriding the
+// value of spec.podLabels.
+func operatorOwnedPodLabel(key string) bool {
+	switch key {
+	case "app.kubernetes.io/name", "app.kubernetes.io/instance", "app.kubernetes.io/component":
+		return true
+	}
+	return strings.HasPrefix(key, "kubeagentic.ai/")
+}
+
+// podMetadataWarnings warns about the keys of spec.podLabels and spec.podAnnotations the
+// operator sets itself, whose values are ignored.
+func (r *Agent) podMetadataWarnings() admission.Warnings {
+	var warnings admission.Warnings
+	for _, key := range sortedKeys(r.Spec.PodLabels) {
+		if operatorOwnedPodLabel(key) {
+			warnings = append(warnings, fmt.Sprintf("spec.podLabels[%s] is set by the operator, which selects the agent pods by it; the value is ignored", key))
+		}
+	}
+	for _, key := range sortedKeys(r.Spec.PodAnnotations) {
+		if strings.HasPrefix(key, "kubeagentic.ai/") {
+			warnings = append(warnings, fmt.Sprintf("spec.podAnnotations[%s] is reserved for the operator; the value is ignored when the operator sets it", key))
+		}
+	}
+	return warnings
+}
+
+// sortedKeys returns the keys of m in order, so that warnings are stable.
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// validateTopologySpread requires a skew of at least 1, a topology key and a supported
+// policy for unsatisfiable constraints, each topology key and policy pair listed once.
+func (r *Agent) validateTopologySpread() field.ErrorList {
+	var allErrs field.ErrorList
+	seen := map[string]bool{}
+	for i, constraint := range r.Spec.TopologySpreadConstraints {
+		constraintPath := field.NewPath("spec").Child("topologySpreadConstraints").Index(i)
+		if constraint.MaxSkew < 1 {
+			allErrs = append(allErrs, field.Invalid(constraintPath.Child("maxSkew"), constraint.MaxSkew, "must be at least 1"))
+		}
+		if constraint.TopologyKey == "" {
+			allErrs = append(allErrs, field.Required(constraintPath.Child("topologyKey"), "the topology key is required"))
+		} else {
+			for _, msg := range validation.IsQualifiedName(constraint.TopologyKey) {
+				allErrs = append(allErrs, field.Invalid(constraintPath.Child("topologyKey"), constraint.TopologyKey, msg))
+			}
+		}
+		switch constraint.WhenUnsatisfiable {
+		case corev1.DoNotSchedule, corev1.ScheduleAnyway:
+		default:
+			allErrs = append(allErrs, field.NotSupported(constraintPath.Child("whenUnsatisfiable"), constraint.WhenUnsatisfiable, []string{string(corev1.DoNotSchedule), string(corev1.ScheduleAnyway)}))
+		}
+		if constraint.MinDomains != nil && *constraint.MinDomains < 1 {
+			allErrs = append(allErrs, field.Invalid(constraintPath.Child("minDomains"), *constraint.MinDomains, "must be at least 1"))
+		}
+		pair := fmt.Sprintf("{%s, %s}", constraint.TopologyKey, constraint.WhenUnsatisfiable)
+		if seen[pair] {
+			allErrs = append(allErrs, field.Duplicate(constraintPath, pair))
+		}
+		seen[pair] = true
+	}
+	return allErrs
+}
+
+// maxRateLimitRules bounds the inbound rate limit rules of an agent.
+const maxRateLimitRules = 10
+
+// validateInboundRateLimit bounds the number of rate limit rules and rejects rules sharing a
+// name or counting over an empty window.
+func (r *Agent) validateInboundRateLimit() field.ErrorList {
+	if r.Spec.InboundRateLimit == nil {
+		return nil
+	}
+	rulesPath := field.NewPath("spec").Child("inboundRateLimit").Child("rules")
+	var allErrs field.ErrorList
+	rules := r.Spec.InboundRateLimit.Rules
+	if len(rules) == 0 {
+		allErrs = append(allErrs, field.Required(rulesPath, "at least one rule is required"))
+	}
+	if len(rules) > maxRateLimitRules {
+		allErrs = append(allErrs, field.TooMany(rulesPath, len(rules), maxRateLimitRules))
+	}
+	names := map[string]bool{}
+	for i, rule := range rules {
+		if names[rule.Name] {
+			allErrs = append(allErrs, field.Duplicate(rulesPath.Index(i).Child("name"), rule.Name))
+		}
+		names[rule.Name] = true
+		if rule.Window.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(rulesPath.Index(i).Child("window"), rule.Window.Duration.String(), "must be positive"))
+		}
+	}
+	return allErrs
+}
+
+// validateGuardrails requires a policy reference when the guardrails are enabled, and a
+// guardrails port left free by the agent container and the other sidecars. The policy itself
+// is checked by the operator, as its ConfigMap may be created after the agent.
+func (r *Agent) validateGuardrails() field.ErrorList {
+	config := r.Spec.Guardrails
+	if config == nil || !config.Enabled {
+		return nil
+	}
+	guardrailsPath := field.NewPath("spec").Child("guardrails")
+	var allErrs field.ErrorList
+	if config.PolicyRef == nil {
+		allErrs = append(allErrs, field.Required(guardrailsPath.Child("policyRef"), "a policy is required when guardrails are enabled"))
+	} else {
+		if config.PolicyRef.Name == "" {
+			allErrs = append(allErrs, field.Required(guardrailsPath.Child("policyRef").Child("name"), "the policy ConfigMap is required"))
+		}
+		if config.PolicyRef.Key == "" {
+			allErrs = append(allErrs, field.Required(guardrailsPath.Child("policyRef").Child("key"), "the key of the policy is required"))
+		}
+		if config.PolicyRef.Optional != nil && *config.PolicyRef.Optional {
+			allErrs = append(allErrs, field.Forbidden(guardrailsPath.Child("policyRef").Child("optional"), "the policy cannot be optional"))
+		}
+	}
+	if mode := config.FailMode; mode != "" && mode != "closed" && mode != "open" {
+		allErrs = append(allErrs, field.NotSupported(guardrailsPath.Child("failMode"), mode, []string{"closed", "open"}))
+	}
+
+	port := int32(8082)
+	if config.Port != nil {
+		port = *config.Port
+	}
+	used := map[int32]string{8080: "the chat port"}
+	if r.Spec.Metrics != nil && r.Spec.Metrics.Port != nil {
+		used[*r.Spec.Metrics.Port] = "the metrics port"
+	}
+	if r.Spec.AdminPort != nil {
+		used[r.Spec.AdminPort.Port] = "the admin port"
+	}
+	if r.Spec.InboundRateLimit != nil {
+		used[8081] = "the rate limit proxy"
+	}
+	if len(r.Spec.Connectors) > 0 {
+		used[8090] = "the connector sidecar"
+	}
+	if r.Spec.ToolExecutor != nil {
+		used[8091] = "the tool executor sidecar"
+	}
+	if owner, ok := used[port]; ok {
+		allErrs = append(allErrs, field.Invalid(guardrailsPath.Child("port"), port, fmt.Sprintf("already used by %s", owner)))
+	}
+	return allErrs
+}
+
+// validateExamples rejects inline examples combined with examplesRef, and inline examples
+// beyond the limits of the examples package. The referenced examples are checked by the
+// operator, as their ConfigMap may be created after the agent.
+func (r *Agent) validateExamples() field.ErrorList {
+	specPath := field.NewPath("spec")
+	var allErrs field.ErrorList
+	if ref := r.Spec.ExamplesRef; ref != nil {
+		if len(r.Spec.Examples) > 0 {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("examplesRef"), "cannot be combined with spec.examples"))
+		}
+		if ref.Name == "" {
+			allErrs = append(allErrs, field.Required(specPath.Child("examplesRef").Child("name"), "the examples ConfigMap is required"))
+		}
+		if ref.Key == "" {
+			allErrs = append(allErrs, field.Required(specPath.Child("examplesRef").Child("key"), "the key of the examples is required"))
+		}
+		if ref.Optional != nil && *ref.Optional {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("examplesRef").Child("optional"), "the examples cannot be optional"))
+		}
+		return allErrs
+	}
+	if err := examples.Validate(r.Spec.Examples, examples.MaxInline); err != nil {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("examples"), len(r.Spec.Examples), err.Error()))
+	}
+	return allErrs
+}
+
+// examplesWarnings warns when the system prompt and the examples, sent with every request,
+// are estimated to take more than examples.WarningShare of the context window of the model
+// in the pricing catalog, leaving little room for the conversation. Referenced examples are
+// read with the cached client, and skipped when their ConfigMap cannot be read.
+func (r *Agent) examplesWarnings(ctx context.Context, reader client.Reader) admission.Warnings {
+	window, ok := pricing.ContextWindowFor(r.Spec.Provider, r.Spec.Model)
+	if !ok {
+		return nil
+	}
+	seeded := r.Spec.Examples
+	if ref := r.Spec.ExamplesRef; ref != nil {
+		if reader == nil {
+			return nil
+		}
+		configMap := &corev1.ConfigMap{}
+		if err := reader.Get(ctx, client.ObjectKey{Name: ref.Name, Namespace: r.Namespace}, configMap); err != nil {
+			return nil
+		}
+		parsed, err := examples.Parse([]byte(configMap.Data[ref.Key]))
+		if err != nil {
+			return nil
+		}
+		seeded = parsed
+	}
+	tokens := examples.EstimateTokens(r.Spec.SystemPrompt, seeded)
+	if float64(tokens) <= examples.WarningShare*float64(window) {
+		return nil
+	}
+	return admission.Warnings{fmt.Sprintf("spec.systemPrompt and the %d examples take about %d tokens of every request, more than %.0f%% of the %d tokens of the context window of %s/%s; long conversations may exceed it, consider fewer examples or a model with a larger context window",
+		len(seeded), tokens, examples.WarningShare*100, window, r.Spec.Provider, r.Spec.Model)}
+}
+
+// validateModelSchedule rejects a model schedule combined with a rollout or an experiment,
+// which run pods with other models themselves, windows the modelschedule package cannot
+// parse and models missing from the pricing catalog of the provider.
+func (r *Agent) validateModelSchedule() field.ErrorList {
+	if len(r.Spec.ModelSchedule) == 0 {
+		return nil
+	}
+	schedulePath := field.NewPath("spec").Child("modelSchedule")
+	var allErrs field.ErrorList
+	if r.Spec.Rollout != nil {
+		allErrs = append(allErrs, field.Forbidden(schedulePath, "cannot be combined with spec.rollout"))
+	}
+	if r.Spec.Experiment != nil {
+		allErrs = append(allErrs, field.Forbidden(schedulePath, "cannot be combined with spec.experiment"))
+	}
+	for i, entry := range r.Spec.ModelSchedule {
+		entryPath := schedulePath.Index(i)
+		if _, err := modelschedule.ParseWindow(entry.Window.Start, entry.Window.Duration.Duration, entry.Window.Timezone); err != nil {
+			allErrs = append(allErrs, field.Invalid(entryPath.Child("window"), entry.Window.Start, err.Error()))
+		}
+		if entry.Model == "" {
+			allErrs = append(allErrs, field.Required(entryPath.Child("model"), "the model of the window is required"))
+		} else if _, err := pricing.PriceFor(r.Spec.Provider, entry.Model); errors.Is(err, pricing.ErrUnknownModel) {
+			allErrs = append(allErrs, field.Invalid(entryPath.Child("model"), entry.Model, fmt.Sprintf("not in the pricing catalog of provider %s", r.Spec.Provider)))
+		}
+		if params := entry.ModelParameters; params != nil && params.Temperature != "" {
+			if _, err := strconv.ParseFloat(params.Temperature, 64); err != nil {
+				allErrs = append(allErrs, field.Invalid(entryPath.Child("modelParameters").Child("temperature"), params.Temperature, "must be a number"))
+			}
+		}
+	}
+	return allErrs
+}
+
+// validateTranscriptSink requires an HTTPS URL without credentials and a shared secret for
+// the transcript sink, and batches within bounds. The secret itself is checked with the
+// other Secret references.
+func (r *Agent) validateTranscriptSink() field.ErrorList {
+	sink := r.Spec.TranscriptSink
+	if sink == nil {
+		return nil
+	}
+	sinkPath := field.NewPath("spec").Child("transcriptSink")
+	var allErrs field.ErrorList
+	if err := transcriptsink.ValidateURL(sink.URL); err != nil {
+		allErrs = append(allErrs, field.Invalid(sinkPath.Child("url"), sink.URL, err.Error()))
+	}
+	if sink.AuthSecretRef.Name == "" {
+		allErrs = append(allErrs, field.Required(sinkPath.Child("authSecretRef").Child("name"), "the Secret of the shared secret is required"))
+	}
+	if sink.AuthSecretRef.Key == "" {
+		allErrs = append(allErrs, field.Required(sinkPath.Child("authSecretRef").Child("key"), "the key of the shared secret is required"))
+	}
+	if batch := sink.Batch; batch != nil {
+		if batch.MaxSize != nil && (*batch.MaxSize < 1 || *batch.MaxSize > transcriptsink.MaxBatchSize) {
+			allErrs = append(allErrs, field.Invalid(sinkPath.Child("batch").Child("maxSize"), *batch.MaxSize, fmt.Sprintf("must be between 1 and %d", transcriptsink.MaxBatchSize)))
+		}
+		if batch.MaxWait != nil && batch.MaxWait.Duration <= 0 {
+			allErrs = append(allErrs, field.Invalid(sinkPath.Child("batch").Child("maxWait"), batch.MaxWait.Duration.String(), "must be positive"))
+		}
+	}
+	return allErrs
+}
+
+// validateSLO requires an availability target below 100% and a window the burn-rate alerts
+// fit in.
+func (r *Agent) validateSLO() field.ErrorList {
+	config := r.Spec.SLO
+	if config == nil {
+		return nil
+	}
+	sloPath := field.NewPath("spec").Child("slo")
+	var allErrs field.ErrorList
+	if _, err := slo.ParseTarget(config.AvailabilityTarget); err != nil {
+		allErrs = append(allErrs, field.Invalid(sloPath.Child("availabilityTarget"), config.AvailabilityTarget, err.Error()))
+	}
+	if config.Window != nil {
+		if err := slo.ValidateWindow(config.Window.Duration); err != nil {
+			allErrs = append(allErrs, field.Invalid(sloPath.Child("window"), config.Window.Duration.String(), err.Error()))
+		}
+	}
+	return allErrs
+}
+
+// validateConnectors rejects connectors sharing a name. Their credentials Secrets are
+// accepted when missing, as they may be created later.
+func (r *Agent) validateConnectors() field.ErrorList {
+	connectorsPath := field.NewPath("spec").Child("connectors")
+	var allErrs field.ErrorList
+	names := map[string]bool{}
+	for i, connector := range r.Spec.Connectors {
+		if names[connector.Name] {
+			allErrs = append(allErrs, field.Duplicate(connectorsPath.Index(i).Child("name"), connector.Name))
+		}
+		names[connector.Name] = true
+		if connector.CredentialsSecretRef.Name == "" {
+			allErrs = append(allErrs, field.Required(connectorsPath.Index(i).Child("credentialsSecretRef").Child("name"), "the credentials Secret is required"))
+		}
+	}
+	return allErrs
+}
+
+// validateToolExecutor requires at least one allowed command and rejects executors that
+// are privileged or may gain privileges.
+func (r *Agent) validateToolExecutor() field.ErrorList {
+	executor := r.Spec.ToolExecutor
+	if executor == nil {
+		return nil
+	}
+	executorPath := field.NewPath("spec").Child("toolExecutor")
+	var allErrs field.ErrorList
+	if executor.Image == "" {
+		allErrs = append(allErrs, field.Required(executorPath.Child("image"), "the executor image is required"))
+	}
+	commandsPath := executorPath.Child("allowedCommands")
+	if len(executor.AllowedCommands) == 0 {
+		allErrs = append(allErrs, field.Required(commandsPath, "at least one allowed command is required"))
+	}
+	commands := map[string]bool{}
+	for i, command := range executor.AllowedCommands {
+		if command == "" || strings.TrimSpace(command) != command || (strings.Contains(command, "/") && !strings.HasPrefix(command, "/")) {
+			allErrs = append(allErrs, field.Invalid(commandsPath.Index(i), command, "must be a command name or an absolute path"))
+		}
+		if commands[command] {
+			allErrs = append(allErrs, field.Duplicate(commandsPath.Index(i), command))
+		}
+		commands[command] = true
+	}
+	if executor.Timeout != nil && executor.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(executorPath.Child("timeout"), executor.Timeout.Duration.String(), "must be positive"))
+	}
+	if sc := executor.SecurityContext; sc != nil {
+		scPath := executorPath.Child("securityContext")
+		if sc.Privileged != nil && *sc.Privileged {
+			allErrs = append(allErrs, field.Forbidden(scPath.Child("privileged"), "the tool executor must not be privileged"))
+		}
+		if sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation {
+			allErrs = append(allErrs, field.Forbidden(scPath.Child("allowPrivilegeEscalation"), "the tool executor must not allow privilege escalation"))
+		}
+		if sc.Capabilities != nil && len(sc.Capabilities.Add) > 0 {
+			allErrs = append(allErrs, field.Forbidden(scPath.Child("capabilities").Child("add"), "the tool executor must not add capabilities"))
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			allErrs = append(allErrs, field.Forbidden(scPath.Child("runAsUser"), "the tool executor must not run as user 0"))
+		}
+	}
+	return allErrs
+}
+
+// validateEventSource requires the connection block of the event source type, and keeps
+// agents consuming an event source off the Ingress unless eventSource.allowIngress is set.
+func (r *Agent) validateEventSource() field.ErrorList {
+	source := r.Spec.EventSource
+	if source == nil {
+		return nil
+	}
+	sourcePath := field.NewPath("spec").Child("eventSource")
+	var allErrs field.ErrorList
+	switch source.Type {
+	case "kafka":
+		if source.Kafka == nil {
+			allErrs = append(allErrs, field.Required(sourcePath.Child("kafka"), "required for kafka event sources"))
+			break
+		}
+		for i, broker := range source.Kafka.Brokers {
+			if _, port, err := net.SplitHostPort(broker); err != nil || port == "" {
+				allErrs = append(allErrs, field.Invalid(sourcePath.Child("kafka").Child("brokers").Index(i), broker, "must be host:port"))
+			}
+		}
+		if source.Kafka.SASLMechanism != "" && source.Kafka.CredentialsSecretRef == nil {
+			allErrs = append(allErrs, field.Required(sourcePath.Child("kafka").Child("credentialsSecretRef"), "required with a SASL mechanism"))
+		}
+	case "sqs":
+		if source.SQS == nil {
+			allErrs = append(allErrs, field.Required(sourcePath.Child("sqs"), "required for sqs event sources"))
+		}
+	}
+	if scaling := source.Autoscaling; scaling != nil && scaling.MinReplicas != nil && scaling.MaxReplicas != nil && *scaling.MinReplicas > *scaling.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(sourcePath.Child("autoscaling").Child("minReplicas"), *scaling.MinReplicas, "must not exceed maxReplicas"))
+	}
+	if ingresshost.Enabled(&r.Spec) && !source.AllowIngress {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("serviceType"), "agents consuming an event source are not exposed through an Ingress unless eventSource.allowIngress is set"))
+	}
+	return allErrs
+}
+
+// validateWorkerMode checks the job queue of the workers, and that the agent Deployment
+// enqueueing the jobs, to which spec.replicas applies, keeps running.
+func (r *Agent) validateWorkerMode() field.ErrorList {
+	workerMode := r.Spec.WorkerMode
+	if workerMode == nil || !workerMode.Enabled {
+		return nil
+	}
+	workerPath := field.NewPath("spec").Child("workerMode")
+	var allErrs field.ErrorList
+	if r.Spec.EventSource != nil {
+		allErrs = append(allErrs, field.Forbidden(workerPath, "cannot be combined with spec.eventSource"))
+	}
+	if r.Spec.Replicas != nil && *r.Spec.Replicas == 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("replicas"), *r.Spec.Replicas, "applies to the agent Deployment enqueueing the jobs and must not be 0 in worker mode"))
+	}
+	if workerMode.Queue == "external" && workerMode.QueueSecretRef == nil {
+		allErrs = append(allErrs, field.Required(workerPath.Child("queueSecretRef"), "required for the external queue"))
+	}
+	if workerMode.Queue != "external" && workerMode.QueueSecretRef != nil {
+		allErrs = append(allErrs, field.Forbidden(workerPath.Child("queueSecretRef"), "only supported by the external queue"))
+	}
+	if workerMode.MaxJobDuration != nil && workerMode.MaxJobDuration.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(workerPath.Child("maxJobDuration"), workerMode.MaxJobDuration.Duration.String(), "must be positive"))
+	}
+	if scaling := workerMode.Autoscaling; scaling != nil && scaling.MinReplicas != nil && scaling.MaxReplicas != nil && *scaling.MinReplicas > *scaling.MaxReplicas {
+		allErrs = append(allErrs, field.Invalid(workerPath.Child("autoscaling").Child("minReplicas"), *scaling.MinReplicas, "must not exceed maxReplicas"))
+	}
+	return allErrs
+}
+
+// validateHealthCheck requires a positive interval and timeout of the health check.
+func (r *Agent) validateHealthCheck() field.ErrorList {
+	healthCheck := r.Spec.HealthCheck
+	if healthCheck == nil || !healthCheck.Enabled {
+		return nil
+	}
+	healthPath := field.NewPath("spec").Child("healthCheck")
+	var allErrs field.ErrorList
+	if healthCheck.Interval != nil && healthCheck.Interval.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(healthPath.Child("interval"), healthCheck.Interval.Duration.String(), "must be positive"))
+	}
+	if healthCheck.Timeout != nil && healthCheck.Timeout.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(healthPath.Child("timeout"), healthCheck.Timeout.Duration.String(), "must be positive"))
+	}
+	return allErrs
+}
+
+// primaryEndpoint returns the endpoint the runtime tries first: spec.endpoint, or the first
+// of spec.endpoints with the lowest priority.
+func (r *Agent) primaryEndpoint() string {
+	if r.Spec.Endpoint != "" || len(r.Spec.Endpoints) == 0 {
+		return r.Spec.Endpoint
+	}
+	priority := func(endpoint aiv1.ProviderEndpoint) int32 {
+		if endpoint.Priority == nil {
+			return 0
+		}
+		return *endpoint.Priority
+	}
+	primary := r.Spec.Endpoints[0]
+	for _, endpoint := range r.Spec.Endpoints[1:] {
+		if priority(endpoint) < priority(primary) {
+			primary = endpoint
+		}
+	}
+	return primary.URL
+}
+
+// validateEndpoints rejects mixing spec.endpoint with spec.endpoints, and requires unique
+// http or https URLs in spec.endpoints.
+func (r *Agent) validateEndpoints() field.ErrorList {
+	if len(r.Spec.Endpoints) == 0 {
+		return nil
+	}
+	endpointsPath := field.NewPath("spec").Child("endpoints")
+	var allErrs field.ErrorList
+	if r.Spec.Endpoint != "" {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec").Child("endpoint"), "cannot be combined with spec.endpoints"))
+	}
+	seen := map[string]bool{}
+	for i, endpoint := range r.Spec.Endpoints {
+		urlPath := endpointsPath.Index(i).Child("url")
+		parsed, err := url.Parse(endpoint.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			allErrs = append(allErrs, field.Invalid(urlPath, endpoint.URL, "must be an absolute http or https URL"))
+			continue
+		}
+		key := strings.TrimSuffix(endpoint.URL, "/")
+		if seen[key] {
+			allErrs = append(allErrs, field.Duplicate(urlPath, endpoint.URL))
+		}
+		seen[key] = true
+	}
+	return allErrs
+}
+
+// validateVLLM only allows the vLLM tuning for the vllm provider, within its bounds.
+func (r *Agent) validateVLLM() field.ErrorList {
+	config := r.Spec.VLLM
+	if config == nil {
+		return nil
+	}
+	vllmPath := field.NewPath("spec").Child("vllm")
+	var allErrs field.ErrorList
+	if r.Spec.Provider != "vllm" {
+		allErrs = append(allErrs, field.Forbidden(vllmPath, fmt.Sprintf("only supported by the vllm provider, not %s", r.Spec.Provider)))
+	}
+	if config.MaxBatchSize != nil && (*config.MaxBatchSize < 1 || *config.MaxBatchSize > 256) {
+		allErrs = append(allErrs, field.Invalid(vllmPath.Child("maxBatchSize"), *config.MaxBatchSize, "must be between 1 and 256"))
+	}
+	if config.MaxWaitMs != nil && (*config.MaxWaitMs < 0 || *config.MaxWaitMs > 1000) {
+		allErrs = append(allErrs, field.Invalid(vllmPath.Child("maxWaitMs"), *config.MaxWaitMs, "must be between 0 and 1000"))
+	}
+	if config.MaxConcurrentSequences != nil && (*config.MaxConcurrentSequences < 1 || *config.MaxConcurrentSequences > 1024) {
+		allErrs = append(allErrs, field.Invalid(vllmPath.Child("maxConcurrentSequences"), *config.MaxConcurrentSequences, "must be between 1 and 1024"))
+	}
+	return allErrs
+}
+
+// validateConcurrency checks that spec.concurrency lets each pod handle at least one request.
+func (r *Agent) validateConcurrency() field.ErrorList {
+	config := r.Spec.Concurrency
+	if config == nil {
+		return nil
+	}
+	concurrencyPath := field.NewPath("spec").Child("concurrency")
+	var allErrs field.ErrorList
+	if config.MaxConcurrentRequests < 1 {
+		allErrs = append(allErrs, field.Invalid(concurrencyPath.Child("maxConcurrentRequests"), config.MaxConcurrentRequests, "must be at least 1"))
+	}
+	if config.QueueLength < 0 {
+		allErrs = append(allErrs, field.Invalid(concurrencyPath.Child("queueLength"), config.QueueLength, "must not be negative"))
+	}
+	if code := config.RejectStatusCode; code != 0 && code != 429 && code != 503 {
+		allErrs = append(allErrs, field.NotSupported(concurrencyPath.Child("rejectStatusCode"), code, []string{"429", "503"}))
+	}
+	if config.TargetUtilization != nil && (*config.TargetUtilization < 1 || *config.TargetUtilization > 100) {
+		allErrs = append(allErrs, field.Invalid(concurrencyPath.Child("targetUtilization"), *config.TargetUtilization, "must be between 1 and 100"))
+	}
+	return allErrs
+}
+
+// concurrencyWarnings warns when the memory limit of the agent container is missing or too
+// low for spec.concurrency.maxConcurrentRequests, as the pod would be OOM-killed before it
+// sheds any request.
+func (r *Agent) concurrencyWarnings() admission.Warnings {
+	config := r.Spec.Concurrency
+	if config == nil || config.MaxConcurrentRequests < 1 {
+		return nil
+	}
+	needed := concurrencyBaseMemory.DeepCopy()
+	for i := int32(0); i < config.MaxConcurrentRequests; i++ {
+		needed.Add(memoryPerConcurrentRequest)
+	}
+	if r.Spec.Resources == nil {
+		// The operator runs the agent container with a 512Mi memory limit by default
+		if needed.Cmp(defaultMemoryLimit) <= 0 {
+			return nil
+		}
+		return admission.Warnings{fmt.Sprintf("spec.concurrency.maxConcurrentRequests %d needs about %s of memory, above the default limit %s; set resources.limits.memory or choose a larger size", config.MaxConcurrentRequests, needed.String(), defaultMemoryLimit.String())}
+	}
+	limit, ok := r.Spec.Resources.Limits[corev1.ResourceMemory]
+	if !ok {
+		return admission.Warnings{fmt.Sprintf("spec.concurrency is set without resources.limits.memory; set it to about %s for %d concurrent requests so that the pods are scheduled for them", needed.String(), config.MaxConcurrentRequests)}
+	}
+	if limit.Cmp(needed) < 0 {
+		return admission.Warnings{fmt.Sprintf("spec.concurrency.maxConcurrentRequests %d needs about %s of memory, above resources.limits.memory %s; the pods may be OOM-killed before they shed requests", config.MaxConcurrentRequests, needed.String(), limit.String())}
+	}
+	return nil
+}
+
+// memoryLimit returns the memory limit of the agent container, the default one without
+// spec.resources, or zero when spec.resources leaves it unlimited.
+func (r *Agent) memoryLimit() resource.Quantity {
+	if r.Spec.Resources == nil {
+		return defaultMemoryLimit.DeepCopy()
+	}
+	return r.Spec.Resources.Limits[corev1.ResourceMemory]
+}
+
+// validateCaching requires the shared redis backend for agents with several replicas, and
+// valid cache bounds.
+func (r *Agent) validateCaching() field.ErrorList {
+	caching := r.Spec.Caching
+	if caching == nil || !caching.Enabled {
+		return nil
+	}
+	cachingPath := field.NewPath("spec").Child("caching")
+	var allErrs field.ErrorList
+	if r.Spec.Replicas != nil && *r.Spec.Replicas > 1 && caching.Backend != "redis" {
+		allErrs = append(allErrs, field.Invalid(cachingPath.Child("backend"), caching.Backend, fmt.Sprintf("must be redis for %d replicas, which would each keep their own cache", *r.Spec.Replicas)))
+	}
+	if caching.TTL != nil && caching.TTL.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(cachingPath.Child("ttl"), caching.TTL.Duration.String(), "must be positive"))
+	}
+	if caching.SimilarityThreshold != "" {
+		if threshold, err := strconv.ParseFloat(caching.SimilarityThreshold, 64); err != nil || threshold <= 0 || threshold > 1 {
+			allErrs = append(allErrs, field.Invalid(cachingPath.Child("similarityThreshold"), caching.SimilarityThreshold, "must be a number between 0 and 1"))
+		}
+	}
+	if caching.KeyStrategy == "semantic" && caching.Embeddings == nil && (r.Spec.RAG == nil || r.Spec.RAG.Embeddings == nil) {
+		allErrs = append(allErrs, field.Required(cachingPath.Child("embeddings"), "required for the semantic key strategy without spec.rag.embeddings"))
+	}
+	return allErrs
+}
+
+// validatePodTemplateOverrides applies the overrides to a pod template holding the fields
+// they must not change: the agent container, its API key and the labels of the agent pods.
+// The operator checks them again on the fully rendered template.
+func (r *Agent) validatePodTemplateOverrides() field.ErrorList {
+	overrides := r.Spec.PodTemplateOverrides
+	if overrides == nil || len(overrides.Raw) == 0 {
+		return nil
+	}
+	overridesPath := field.NewPath("spec").Child("podTemplateOverrides")
+	protected := corev1.PodTemplateSpec{
+		ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+			"app.kubernetes.io/name":     "kubeagentic-agent",
+			"app.kubernetes.io/instance": r.Name,
+			"kubeagentic.ai/agent":       r.Name,
+		}},
+		Spec: corev1.PodSpec{Containers: []corev1.Container{{
+			Name: "agent",
+			Env:  []corev1.EnvVar{{Name: "AGENT_API_KEY", Value: "protected"}},
+		}}},
+	}
+	original, err := json.Marshal(protected)
+	if err != nil {
+		return field.ErrorList{field.InternalError(overridesPath, err)}
+	}
+	data, err := strategicpatch.StrategicMergePatch(original, overrides.Raw, corev1.PodTemplateSpec{})
+	if err != nil {
+		return field.ErrorList{field.Invalid(overridesPath, string(overrides.Raw), fmt.Sprintf("not a valid strategic merge patch: %v", err))}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	patched := &corev1.PodTemplateSpec{}
+	if err := decoder.Decode(patched); err != nil {
+		return field.ErrorList{field.Invalid(overridesPath, string(overrides.Raw), fmt.Sprintf("does not patch a pod template: %v", err))}
+	}
+
+	var allErrs field.ErrorList
+	for key, value := range protected.Labels {
+		if patched.Labels[key] != value {
+			allErrs = append(allErrs, field.Forbidden(overridesPath.Child("metadata", "labels").Key(key), "must not change the labels of the agent pods"))
+		}
+	}
+	if len(patched.Spec.Containers) == 0 || patched.Spec.Containers[0].Name != "agent" {
+		allErrs = append(allErrs, field.Forbidden(overridesPath.Child("spec", "containers"), "must not rename, remove or reorder the agent container"))
+	} else if !keepsAPIKey(patched.Spec.Containers[0].Env, protected.Spec.Containers[0].Env[0]) {
+		allErrs = append(allErrs, field.Forbidden(overridesPath.Child("spec", "containers").Key("agent").Child("env").Key("AGENT_API_KEY"), "must not change the API key of the agent"))
+	}
+	return allErrs
+}
+
+// keepsAPIKey reports whether env still holds the API key variable unchanged.
+func keepsAPIKey(env []corev1.EnvVar, apiKey corev1.EnvVar) bool {
+	for _, e := range env {
+		if e.Name == apiKey.Name {
+			return e.Value == apiKey.Value && e.ValueFrom == nil
+		}
+	}
+	return false
+}
+
+// minBatchingCPU is the CPU limit below which the agent container cannot keep many batched
+// requests in flight.
+var minBatchingCPU = resource.MustParse("500m")
+
+// concurrencyBaseMemory and memoryPerConcurrentRequest estimate the memory the agent container
+// needs to handle spec.concurrency.maxConcurrentRequests at once.
+var (
+	concurrencyBaseMemory      = resource.MustParse("256Mi")
+	memoryPerConcurrentRequest = resource.MustParse("32Mi")
+)
+
+// defaultMemoryLimit is the memory limit of agent containers without spec.resources.
+var defaultMemoryLimit = resource.MustParse("512Mi")
+
+// encryptionRequiredLabel marks namespaces whose agents must encrypt persisted conversations.
+const encryptionRequiredLabel = "kubeagentic.ai/require-encryption"
+
+// validateEncryptionRequired requires spec.encryption for agents that persist conversations,
+// with a redis or postgres memory backend, a claim of their own or an export, in namespaces
+// labeled with encryptionRequiredLabel=true.
+func (r *Agent) validateEncryptionRequired(ctx context.Context, reader client.Reader) field.ErrorList {
+	if r.Spec.Encryption != nil || reader == nil {
+		return nil
+	}
+	persists := r.Spec.Export != nil || r.Spec.Persistence != nil
+	if r.Spec.Memory != nil && (r.Spec.Memory.Backend == "redis" || r.Spec.Memory.Backend == "postgres") {
+		persists = true
+	}
+	if !persists {
+		return nil
+	}
+
+	namespace := &corev1.Namespace{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: r.Namespace}, namespace); err != nil {
+		return field.ErrorList{field.InternalError(field.NewPath("spec"), fmt.Errorf("failed to get namespace %s: %w", r.Namespace, err))}
+	}
+	if namespace.Labels[encryptionRequiredLabel] != "true" {
+		return nil
+	}
+	return field.ErrorList{field.Required(
+		field.NewPath("spec").Child("encryption"),
+		fmt.Sprintf("namespace %s requires encryption of persisted conversations (label %s=true)", r.Namespace, encryptionRequiredLabel),
+	)}
+}
+
+// logLevelAnnotation overrides spec.logLevel without changing the spec.
+const logLevelAnnotation = "kubeagentic.ai/log-level"
+
+// environmentLabel tells the environment of a namespace, such as production.
+const environmentLabel = "kubeagentic.ai/environment"
+
+// inProductionNamespace reports whether the namespace of the agent is labeled as production.
+func (r *Agent) inProductionNamespace(ctx context.Context, reader client.Reader) bool {
+	if reader == nil {
+		return false
+	}
+	namespace := &corev1.Namespace{}
+	if err := reader.Get(ctx, client.ObjectKey{Name: r.Namespace}, namespace); err != nil {
+		return false
+	}
+	return namespace.Labels[environmentLabel] == "production"
+}
+
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// SetupWebhookWithManager registers the defaulting and validating webhooks of the Agents
+// with the webhook server of the Manager
+func (w *AgentWebhook) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	if w.Reader == nil {
+		w.Reader = mgr.GetClient()
+	}
+	// Index the Agents by the names of their resources to detect name collisions
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &aiv1.Agent{}, naming.ResourceNameIndex, func(obj client.Object) []string {
+		agent := obj.(*aiv1.Agent)
+		return naming.IndexValues(naming.Names(agent.Name, agent.Spec.NameOverrides))
+	}); err != nil {
+		return err
+	}
+	// Index the Agents by the host of their Ingress to detect overlapping hosts and paths
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &aiv1.Agent{}, ingresshost.Index, func(obj client.Object) []string {
+		agent := obj.(*aiv1.Agent)
+		return ingresshost.IndexValues(agent.Name, agent.Namespace, &agent.Spec)
+	}); err != nil {
+		return err
+	}
 	return ctrl.NewWebhookManagedBy(mgr).
-		For(r).
+		For(&aiv1.Agent{}).
+		WithDefaulter(w).
+		WithValidator(w).
 		Complete()
 }
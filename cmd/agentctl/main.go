@@ -0,0 +1,137 @@
+// Command agentctl interacts with the agents deployed by KubeAgentic.
+//
+//	agentctl [-n namespace] list [-A]
+//	agentctl [-n namespace] status <agent>
+//	agentctl [-n namespace] chat [--conversation id] <agent> [prompt]
+//
+// The cluster is read from the kubeconfig, as kubectl does: --kubeconfig, then $KUBECONFIG,
+// then ~/.kube/config, or the in-cluster configuration.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentctl"
+)
+
+const usage = `agentctl interacts with the agents deployed by KubeAgentic.
+
+Usage:
+  agentctl [flags] list [-A]                       List the agents with their phase, provider and endpoint
+  agentctl [flags] status <agent>                  Show the conditions and usage of an agent
+  agentctl [flags] chat [--conversation id] <agent> [prompt]
+                                                   Send a prompt, read from stdin when omitted, and stream the answer
+
+Flags:
+`
+
+func main() {
+	global := flag.NewFlagSet("agentctl", flag.ExitOnError)
+	var kubeconfig, kubeContext, namespace string
+	global.StringVar(&kubeconfig, "kubeconfig", "", "The kubeconfig file to use.")
+	global.StringVar(&kubeContext, "context", "", "The kubeconfig context to use.")
+	global.StringVar(&namespace, "n", "", "The namespace of the agents. Defaults to the namespace of the context.")
+	global.StringVar(&namespace, "namespace", "", "The namespace of the agents. Defaults to the namespace of the context.")
+	global.Usage = func() {
+		fmt.Fprint(global.Output(), usage)
+		global.PrintDefaults()
+	}
+	_ = global.Parse(os.Args[1:])
+	if global.NArg() == 0 {
+		global.Usage()
+		os.Exit(2)
+	}
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfig
+	overrides := &clientcmd.ConfigOverrides{CurrentContext: kubeContext}
+	overrides.Context.Namespace = namespace
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, overrides)
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		fatal(fmt.Errorf("failed to load the kubeconfig: %w", err))
+	}
+	namespace, _, err = clientConfig.Namespace()
+	if err != nil {
+		fatal(err)
+	}
+
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		fatal(err)
+	}
+	if err := aiv1.AddToScheme(scheme); err != nil {
+		fatal(err)
+	}
+	c, err := client.New(config, client.Options{Scheme: scheme})
+	if err != nil {
+		fatal(err)
+	}
+	commands := &agentctl.Commands{
+		Client:    c,
+		Namespace: namespace,
+		Out:       os.Stdout,
+		Connector: &agentctl.PortForwarder{Config: config, Client: c, ErrOut: os.Stderr},
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+	if err := run(ctx, commands, global.Arg(0), global.Args()[1:]); err != nil {
+		fatal(err)
+	}
+}
+
+// run runs the command name with its arguments.
+func run(ctx context.Context, commands *agentctl.Commands, name string, args []string) error {
+	flags := flag.NewFlagSet("agentctl "+name, flag.ExitOnError)
+	switch name {
+	case "list":
+		allNamespaces := flags.Bool("A", false, "List the agents of all namespaces.")
+		_ = flags.Parse(args)
+		return commands.List(ctx, *allNamespaces)
+	case "status":
+		_ = flags.Parse(args)
+		if flags.NArg() != 1 {
+			return fmt.Errorf("usage: agentctl status <agent>")
+		}
+		return commands.Status(ctx, flags.Arg(0))
+	case "chat":
+		var opts agentctl.ChatOptions
+		flags.StringVar(&opts.Conversation, "conversation", "", "Continue the conversation of this ID.")
+		_ = flags.Parse(args)
+		if flags.NArg() < 1 {
+			return fmt.Errorf("usage: agentctl chat [--conversation id] <agent> [prompt]")
+		}
+		prompt := strings.Join(flags.Args()[1:], " ")
+		if prompt == "" {
+			data, err := io.ReadAll(os.Stdin)
+			if err != nil {
+				return err
+			}
+			prompt = strings.TrimSpace(string(data))
+		}
+		if prompt == "" {
+			return fmt.Errorf("no prompt given")
+		}
+		return commands.Chat(ctx, flags.Arg(0), prompt, opts)
+	default:
+		return fmt.Errorf("unknown command %q, expected list, status or chat", name)
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	os.Exit(1)
+}
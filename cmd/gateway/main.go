@@ -0,0 +1,64 @@
+// Command gateway serves the OpenAI chat completions API for the agents of a namespace.
+// It is deployed by the operator for each AgentGateway, and reloads the models it routes
+// from the mounted configuration as agents come and go.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/gateway"
+)
+
+func main() {
+	var configFile, addr string
+	var reloadInterval time.Duration
+	flag.StringVar(&configFile, "config", "/etc/kubeagentic/gateway/models.json", "The file listing the models the gateway routes.")
+	flag.StringVar(&addr, "addr", ":8080", "The address the gateway binds to.")
+	flag.DurationVar(&reloadInterval, "reload-interval", 10*time.Second, "How often the configuration file is checked for changes.")
+	flag.Parse()
+
+	gw := &gateway.Gateway{}
+	data, err := load(gw, configFile)
+	if err != nil {
+		log.Fatalf("Failed to load the gateway configuration: %v", err)
+	}
+
+	// Mounted ConfigMaps are updated in place by the kubelet, so the file is polled
+	go func() {
+		for range time.Tick(reloadInterval) {
+			current, err := os.ReadFile(configFile)
+			if err != nil || bytes.Equal(current, data) {
+				continue
+			}
+			if loaded, err := load(gw, configFile); err != nil {
+				log.Printf("Keeping the previous gateway configuration: %v", err)
+			} else {
+				data = loaded
+			}
+		}
+	}()
+
+	log.Printf("Serving the OpenAI API on %s", addr)
+	server := &http.Server{Addr: addr, Handler: gw, ReadHeaderTimeout: 30 * time.Second}
+	log.Fatal(server.ListenAndServe())
+}
+
+// load sets the configuration of the file on the gateway and returns the file content.
+func load(gw *gateway.Gateway, configFile string) ([]byte, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	config, err := gateway.ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	gw.SetConfig(config)
+	log.Printf("Routing %d models", len(config.Models))
+	return data, nil
+}
@@ -0,0 +1,85 @@
+// Command guardrails-proxy checks the prompts sent to an agent and its responses against a
+// guardrails policy. It runs as a sidecar of the agent pods, in front of the agent container,
+// and reloads the policy from the mounted configuration when it changes.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/guardrails"
+)
+
+func main() {
+	var policyFile, addr, upstream, failMode string
+	var reloadInterval time.Duration
+	var maxBodyBytes int64
+	flag.StringVar(&policyFile, "policy", "/etc/kubeagentic/guardrails/guardrails.json", "The file holding the guardrails policy.")
+	flag.StringVar(&addr, "addr", ":8082", "The address the proxy binds to.")
+	flag.StringVar(&upstream, "upstream", "http://127.0.0.1:8080", "The URL of the agent container.")
+	flag.StringVar(&failMode, "fail-mode", guardrails.FailModeClosed, "What happens to the requests without a valid policy: closed rejects them, open passes them unchecked.")
+	flag.Int64Var(&maxBodyBytes, "max-body-bytes", guardrails.DefaultMaxBodyBytes, "The size of the largest prompts and responses checked.")
+	flag.DurationVar(&reloadInterval, "reload-interval", 10*time.Second, "How often the policy file is checked for changes.")
+	flag.Parse()
+
+	if failMode != guardrails.FailModeClosed && failMode != guardrails.FailModeOpen {
+		log.Fatalf("Invalid fail mode %q, must be closed or open", failMode)
+	}
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		log.Fatalf("Invalid upstream URL: %v", err)
+	}
+	proxy := &guardrails.Proxy{Upstream: upstreamURL, FailMode: failMode, MaxBodyBytes: maxBodyBytes, Labels: map[string]string{}}
+	for label, env := range map[string]string{"agent": "AGENT_NAME", "namespace": "AGENT_NAMESPACE", "pod": "POD_NAME"} {
+		if value := os.Getenv(env); value != "" {
+			proxy.Labels[label] = value
+		}
+	}
+	// Without a valid policy the proxy keeps serving, applying its fail mode
+	data, err := load(proxy, policyFile)
+	if err != nil {
+		log.Printf("Failed to load the guardrails policy, failing %s: %v", failMode, err)
+	}
+
+	// Mounted ConfigMaps are updated in place by the kubelet, so the file is polled
+	go func() {
+		for range time.Tick(reloadInterval) {
+			current, err := os.ReadFile(policyFile)
+			if err != nil || bytes.Equal(current, data) {
+				continue
+			}
+			if loaded, err := load(proxy, policyFile); err != nil {
+				log.Printf("Keeping the previous guardrails policy: %v", err)
+			} else {
+				data = loaded
+			}
+		}
+	}()
+
+	log.Printf("Proxying %s on %s", upstream, addr)
+	server := &http.Server{Addr: addr, Handler: proxy, ReadHeaderTimeout: 30 * time.Second}
+	log.Fatal(server.ListenAndServe())
+}
+
+// load sets the policy of the file on the proxy and returns the file content.
+func load(proxy *guardrails.Proxy, policyFile string) ([]byte, error) {
+	data, err := os.ReadFile(policyFile)
+	if err != nil {
+		return nil, err
+	}
+	policy, err := guardrails.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	if err := proxy.Engine.SetPolicy(policy); err != nil {
+		return nil, err
+	}
+	block, flag := policy.Counts()
+	log.Printf("Enforcing %d blocking and %d flagging guardrails rules", block, flag)
+	return data, nil
+}
@@ -0,0 +1,75 @@
+// Command ratelimit-proxy enforces the inbound rate limits of an agent. It runs as a sidecar
+// of the agent pods, in front of the agent container, and reloads the rules from the
+// mounted configuration when they change without dropping connections.
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/ratelimit"
+)
+
+func main() {
+	var configFile, addr, upstream string
+	var reloadInterval time.Duration
+	flag.StringVar(&configFile, "config", "/etc/kubeagentic/ratelimit/ratelimit.json", "The file holding the rate limit rules.")
+	flag.StringVar(&addr, "addr", ":8081", "The address the proxy binds to.")
+	flag.StringVar(&upstream, "upstream", "http://127.0.0.1:8080", "The URL of the agent container.")
+	flag.DurationVar(&reloadInterval, "reload-interval", 10*time.Second, "How often the configuration file is checked for changes.")
+	flag.Parse()
+
+	upstreamURL, err := url.Parse(upstream)
+	if err != nil {
+		log.Fatalf("Invalid upstream URL: %v", err)
+	}
+	proxy := &ratelimit.Proxy{Upstream: upstreamURL, Labels: map[string]string{}}
+	for label, env := range map[string]string{"agent": "AGENT_NAME", "namespace": "AGENT_NAMESPACE", "pod": "POD_NAME"} {
+		if value := os.Getenv(env); value != "" {
+			proxy.Labels[label] = value
+		}
+	}
+	data, err := load(proxy, configFile)
+	if err != nil {
+		log.Fatalf("Failed to load the rate limit configuration: %v", err)
+	}
+
+	// Mounted ConfigMaps are updated in place by the kubelet, so the file is polled
+	go func() {
+		for range time.Tick(reloadInterval) {
+			current, err := os.ReadFile(configFile)
+			if err != nil || bytes.Equal(current, data) {
+				continue
+			}
+			if loaded, err := load(proxy, configFile); err != nil {
+				log.Printf("Keeping the previous rate limit configuration: %v", err)
+			} else {
+				data = loaded
+			}
+		}
+	}()
+
+	log.Printf("Proxying %s on %s", upstream, addr)
+	server := &http.Server{Addr: addr, Handler: proxy, ReadHeaderTimeout: 30 * time.Second}
+	log.Fatal(server.ListenAndServe())
+}
+
+// load sets the rules of the file on the proxy and returns the file content.
+func load(proxy *ratelimit.Proxy, configFile string) ([]byte, error) {
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		return nil, err
+	}
+	config, err := ratelimit.ParseConfig(data)
+	if err != nil {
+		return nil, err
+	}
+	proxy.Limiter.SetConfig(config)
+	log.Printf("Enforcing %d rate limit rules", len(config.Rules))
+	return data, nil
+}
@@ -0,0 +1,85 @@
+// Command tool-executor runs the commands of the agent tools. It runs as a sidecar of the
+// agent pods, built from an image of the user providing the commands, and serves the
+// execution API to the agent container on the loopback interface.
+//
+// The operator image ships the executor, and an init container installs it into a volume
+// shared with the sidecar with --install, as the image has no shell to copy it with.
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/toolexec"
+)
+
+func main() {
+	var configFile, addr, installDir, checkAddr string
+	flag.StringVar(&configFile, "config", "/etc/kubeagentic/tool-executor/tool-executor.json", "The file holding the executor configuration.")
+	flag.StringVar(&addr, "addr", "127.0.0.1:8091", "The address the execution API binds to.")
+	flag.StringVar(&installDir, "install", "", "Copy the executor into this directory and exit.")
+	flag.StringVar(&checkAddr, "check", "", "Check that the execution API serves on this address and exit.")
+	flag.Parse()
+
+	// The readiness probe execs the check, as the kubelet cannot reach the loopback interface
+	if checkAddr != "" {
+		client := &http.Client{Timeout: 5 * time.Second}
+		resp, err := client.Get("http://" + checkAddr + "/healthz")
+		if err != nil {
+			log.Fatalf("The tool executor is not serving: %v", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			log.Fatalf("The tool executor is not healthy: %s", resp.Status)
+		}
+		return
+	}
+
+	if installDir != "" {
+		if err := install(installDir); err != nil {
+			log.Fatalf("Failed to install the tool executor: %v", err)
+		}
+		return
+	}
+
+	data, err := os.ReadFile(configFile)
+	if err != nil {
+		log.Fatalf("Failed to read the tool executor configuration: %v", err)
+	}
+	config, err := toolexec.ParseConfig(data)
+	if err != nil {
+		log.Fatalf("Failed to load the tool executor configuration: %v", err)
+	}
+
+	log.Printf("Executing %d allowed commands in %s on %s", len(config.AllowedCommands), config.Workspace, addr)
+	// The audit records go to the standard output, collected with the logs of the pod
+	server := &http.Server{Addr: addr, Handler: toolexec.NewExecutor(config, os.Stdout), ReadHeaderTimeout: 30 * time.Second}
+	log.Fatal(server.ListenAndServe())
+}
+
+// install copies the running executor into dir.
+func install(dir string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	source, err := os.Open(self)
+	if err != nil {
+		return err
+	}
+	defer source.Close()
+	target, err := os.OpenFile(filepath.Join(dir, "tool-executor"), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0555)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(target, source); err != nil {
+		target.Close()
+		return err
+	}
+	return target.Close()
+}
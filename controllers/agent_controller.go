@@ -4,32 +4,25 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/sizing"
 )
 
-// AgentReconciler reconciles an Agent object.
-// It's the core component of the operator, responsible for managing the lifecycle of Agent resources.
-type AgentReconciler struct {
-	client.Client
-	Scheme *runtime.Scheme
-}
-
 // RBAC annotations setup the necessary permissions for the controller to manage resources.
 // +kubebuilder:rbac:groups=ai.example.com,resources=agents,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ai.example.com,resources=agents/status,verbs=get;update;patch
@@ -38,103 +31,107 @@ type AgentReconciler struct {
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 
-// Reconcile is the main reconciliation loop for the Agent controller.
-// It's triggered by changes to Agent resources or the resources it owns.
-func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := log.FromContext(ctx).WithValues("agent", req.NamespacedName)
-	logger.Info("Starting reconciliation")
-
-	// Fetch the Agent instance
-	var agent aiv1.Agent
-	if err := r.Get(ctx, req.NamespacedName, &agent); err != nil {
-		if errors.IsNotFound(err) {
-			// The Agent resource was not found, likely deleted.
-			// There's nothing to do, so we can return without error.
-			logger.Info("Agent resource not found, assuming it's been deleted")
-			return ctrl.Result{}, nil
-		}
-		// An unexpected error occurred while fetching the Agent resource.
-		logger.Error(err, "Failed to get Agent resource")
-		return ctrl.Result{}, err
-	}
-
-	// Set the initial status of the Agent resource.
-	if agent.Status.Phase == "" {
-		logger.Info("Initializing Agent status")
-		agent.Status.Phase = aiv1.AgentPhasePending
-		agent.Status.Message = "Initializing agent deployment"
-		now := metav1.NewTime(time.Now())
-		agent.Status.LastUpdated = &now
-		if err := r.Status().Update(ctx, &agent); err != nil {
-			logger.Error(err, "Failed to update Agent status to Pending")
-			return ctrl.Result{}, err
-		}
+// reconcileDeployment manages the Deployment resource for the Agent.
+func (r *AgentReconciler) reconcileDeployment(ctx context.Context, agent *aiv1.Agent) error {
+	deployment := r.buildDeployment(agent)
+	if err := controllerutil.SetControllerReference(agent, deployment, r.Scheme); err != nil {
+		return err
 	}
 
-	// Validate the secret reference to ensure the API key is available.
-	if err := r.validateSecretRef(ctx, &agent); err != nil {
-		logger.Error(err, "Secret validation failed")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Secret validation failed: %v", err))
+	// Roll the pods when an object they read at startup changes.
+	if err := r.annotateChecksums(ctx, agent, deployment); err != nil {
+		return err
 	}
 
-	// Reconcile the Deployment for the Agent.
-	if err := r.reconcileDeployment(ctx, &agent); err != nil {
-		logger.Error(err, "Failed to reconcile Deployment")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Failed to reconcile Deployment: %v", err))
+	// Run the workers from the pod template of the agent, before the variants split it.
+	if err := r.reconcileWorkers(ctx, agent, deployment); err != nil {
+		return err
 	}
 
-	// Reconcile the Service for the Agent.
-	if err := r.reconcileService(ctx, &agent); err != nil {
-		logger.Error(err, "Failed to reconcile Service")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Failed to reconcile Service: %v", err))
+	// Split the pods between the experiment variants, or run the winning variant.
+	if err := r.reconcileExperiment(ctx, agent, deployment); err != nil {
+		return err
 	}
 
-	// Update the Agent's status based on the state of its owned resources.
-	if err := r.updateAgentStatus(ctx, &agent); err != nil {
-		logger.Error(err, "Failed to update Agent status")
-		return ctrl.Result{}, err
+	// Scale the agent to zero while its budget is exhausted.
+	if budgetSuspended(agent) {
+		deployment.Spec.Replicas = int32Ptr(0)
 	}
 
-	logger.Info("Reconciliation completed successfully")
-	return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
-}
-
-// validateSecretRef ensures that the secret referenced by the Agent exists and contains the required key.
-func (r *AgentReconciler) validateSecretRef(ctx context.Context, agent *aiv1.Agent) error {
-	secret := &corev1.Secret{}
-	err := r.Get(ctx, types.NamespacedName{
-		Name:      agent.Spec.ApiSecretRef.Name,
-		Namespace: agent.Namespace,
-	}, secret)
-	if err != nil {
-		return fmt.Errorf("failed to get secret %s: %w", agent.Spec.ApiSecretRef.Name, err)
+	// Hold the agent pods until the dependencies of the agent are ready.
+	if waitingForDependencies(agent) {
+		deployment.Spec.Replicas = int32Ptr(0)
 	}
 
-	if _, exists := secret.Data[agent.Spec.ApiSecretRef.Key]; !exists {
-		return fmt.Errorf("key %s not found in secret %s", agent.Spec.ApiSecretRef.Key, agent.Spec.ApiSecretRef.Name)
+	// Stop the agent pods while their claim is restored from a snapshot.
+	if restoringFromSnapshot(agent) {
+		deployment.Spec.Replicas = int32Ptr(0)
 	}
 
-	return nil
-}
-
-// reconcileDeployment manages the Deployment resource for the Agent.
-func (r *AgentReconciler) reconcileDeployment(ctx context.Context, agent *aiv1.Agent) error {
-	deployment := r.buildDeployment(agent)
-	if err := controllerutil.SetControllerReference(agent, deployment, r.Scheme); err != nil {
-		return err
-	}
+	// Record the desired pod template to detect changes to roll out.
+	deployment.Annotations = map[string]string{templateHashAnnotation: podTemplateHash(&deployment.Spec.Template)}
 
 	found := &appsv1.Deployment{}
 	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		log.FromContext(ctx).Info("Creating new Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
+		deployment.Annotations[appliedHashAnnotation] = deploymentAppliedHash(agent, deployment)
 		return r.Create(ctx, deployment)
 	} else if err != nil {
 		return err
 	}
 
+	// Paused agents keep the Deployment as edited by hand.
+	if agentPaused(agent) {
+		log.FromContext(ctx).V(1).Info("Agent paused, leaving Deployment as is", "Deployment.Name", found.Name)
+		return nil
+	}
+
+	// The selector of a Deployment created by an operator build labelling its pods otherwise
+	// cannot be updated; keep it rather than failing every reconcile.
+	if migrateSelector(deployment, found) {
+		log.FromContext(ctx).V(1).Info("Keeping the selector of existing Deployment", "Deployment.Name", found.Name, "selector", found.Spec.Selector.MatchLabels)
+		deployment.Annotations[templateHashAnnotation] = podTemplateHash(&deployment.Spec.Template)
+	}
+	preserveLegacyAutomount(agent, deployment, found)
+	r.recordRestart(agent, deployment, found)
+
+	// The HPA, or KEDA for agents scaled on the backlog of their event source, owns the
+	// replicas of autoscaled agents. The HPA does not scale a Deployment from zero, as left
+	// by a suspension, so its replicas are restored first. Replicas below the ones set here,
+	// as after the minimum of the HPA is raised, are raised right away rather than on the
+	// next sync of the HPA.
+	if !replicasManaged(agent) && found.Spec.Replicas != nil && (*found.Spec.Replicas > 0 || eventSourceAutoscaled(agent)) {
+		if !hpaEnabled(agent) || *found.Spec.Replicas > *deployment.Spec.Replicas {
+			deployment.Spec.Replicas = found.Spec.Replicas
+		}
+	}
+
+	if budgetSuspended(agent) || waitingForDependencies(agent) || restoringFromSnapshot(agent) {
+		// No rollout progresses while suspended; stop the pods of the one in progress.
+		if err := r.deleteCanary(ctx, agent); err != nil {
+			return err
+		}
+		if err := r.deletePreview(ctx, agent); err != nil {
+			return err
+		}
+	} else {
+		if handled, err := r.reconcileAutoRollback(ctx, agent, deployment, found); handled || err != nil {
+			return err
+		}
+
+		if handled, err := r.reconcileRollout(ctx, agent, deployment, found); handled || err != nil {
+			return err
+		}
+	}
+
 	log.FromContext(ctx).Info("Updating existing Deployment", "Deployment.Namespace", found.Namespace, "Deployment.Name", found.Name)
+	hash := deploymentAppliedHash(agent, deployment)
+	r.recordManualChanges(agent, "Deployment", found.Name, found.Annotations[appliedHashAnnotation], hash, deploymentDrift(agent, deployment, found))
+	found.Annotations = setAppliedHash(found.Annotations, hash)
+	found.Annotations[templateHashAnnotation] = deployment.Annotations[templateHashAnnotation]
 	found.Spec = deployment.Spec
+	adoptLabels(found, deployment.Labels)
 	return r.Update(ctx, found)
 }
 
@@ -144,30 +141,46 @@ func (r *AgentReconciler) reconcileService(ctx context.Context, agent *aiv1.Agen
 	if err := controllerutil.SetControllerReference(agent, service, r.Scheme); err != nil {
 		return err
 	}
+	if !conversationAffinityEnabled(agent) {
+		// Select the pods by the labels of the Deployment, updated in lockstep with it
+		selector, err := r.servingSelector(ctx, agent)
+		if err != nil {
+			return err
+		}
+		service.Spec.Selector = selector
+	}
 
 	foundService := &corev1.Service{}
 	err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
 	if err != nil && errors.IsNotFound(err) {
 		log.FromContext(ctx).Info("Creating new Service", "Service.Namespace", service.Namespace, "Service.Name", service.Name)
+		service.Annotations = setAppliedHash(service.Annotations, serviceAppliedHash(service))
 		return r.Create(ctx, service)
 	} else if err != nil {
 		return err
 	}
 
+	// Paused agents keep the Service as edited by hand.
+	if agentPaused(agent) {
+		log.FromContext(ctx).V(1).Info("Agent paused, leaving Service as is", "Service.Name", foundService.Name)
+		return nil
+	}
+
 	log.FromContext(ctx).Info("Updating existing Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
+	hash := serviceAppliedHash(service)
+	r.recordManualChanges(agent, "Service", foundService.Name, foundService.Annotations[appliedHashAnnotation], hash, serviceDrift(service, foundService))
+	foundService.Annotations = setAppliedHash(foundService.Annotations, hash)
 	foundService.Spec.Ports = service.Spec.Ports
 	foundService.Spec.Selector = service.Spec.Selector
 	foundService.Spec.Type = service.Spec.Type
+	adoptLabels(foundService, service.Labels)
 	return r.Update(ctx, foundService)
 }
 
-// buildDeployment creates a new Deployment resource based on the Agent's specification.
-func (r *AgentReconciler) buildDeployment(agent *aiv1.Agent) *appsv1.Deployment {
-	replicas := int32(1)
-	if agent.Spec.Replicas != nil {
-		replicas = *agent.Spec.Replicas
-	}
-
+// agentResources returns the resource requirements of the agent container: the user's, or
+// defaults, with default ephemeral storage unless set, and the recommended requests and
+// limits when the agent opts in to them.
+func agentResources(agent *aiv1.Agent) corev1.ResourceRequirements {
 	// Default resource requirements, can be overridden by the user.
 	resources := corev1.ResourceRequirements{
 		Requests: corev1.ResourceList{
@@ -181,28 +194,69 @@ func (r *AgentReconciler) buildDeployment(agent *aiv1.Agent) *appsv1.Deployment
 	}
 
 	if agent.Spec.Resources != nil {
-		resources = *agent.Spec.Resources
+		resources = *agent.Spec.Resources.DeepCopy()
+	} else if preset, ok := sizing.For(agent.Spec.Provider, agent.Spec.Size); ok {
+		// The defaulting webhook copies the preset into spec.resources; this covers
+		// Agents admitted without it
+		resources = preset
+	}
+
+	// Bound the disk usage of the container even when the user's resources leave it out
+	return applyRecommendations(agent, withEphemeralStorage(agent, resources))
+}
+
+// buildDeployment creates a new Deployment resource based on the Agent's specification.
+func (r *AgentReconciler) buildDeployment(agent *aiv1.Agent) *appsv1.Deployment {
+	replicas := int32(1)
+	if agent.Spec.Replicas != nil {
+		replicas = *agent.Spec.Replicas
 	}
 
+	resources := agentResources(agent)
+
 	// Construct environment variables for the agent container.
 	env := []corev1.EnvVar{
 		{Name: "AGENT_PROVIDER", Value: agent.Spec.Provider},
-		{Name: "AGENT_MODEL", Value: agent.Spec.Model},
+		{Name: "AGENT_MODEL", Value: servedModel(agent)},
 		{Name: "AGENT_SYSTEM_PROMPT", Value: agent.Spec.SystemPrompt},
-		{
+	}
+
+	// Let the runtime tag its logs, metrics and provider requests with its identity
+	env = append(env, identityEnv(agent)...)
+
+	// Self-hosted endpoints may need no API key
+	if agent.Spec.ApiSecretRef != nil {
+		env = append(env, corev1.EnvVar{
 			Name: "AGENT_API_KEY",
 			ValueFrom: &corev1.EnvVarSource{
-				SecretKeyRef: &agent.Spec.ApiSecretRef,
+				SecretKeyRef: localSecretKeySelector(agent, agent.Spec.ApiSecretRef),
 			},
-		},
+		})
 	}
 
-	if agent.Spec.Endpoint != "" {
+	if endpoint := primaryEndpoint(agent); endpoint != "" {
 		env = append(env, corev1.EnvVar{
 			Name:  "AGENT_ENDPOINT",
-			Value: agent.Spec.Endpoint,
+			Value: endpoint,
 		})
 	}
+	// Let the runtime fail over between the endpoints of the provider
+	if endpoints := providerEndpointsEnv(agent); endpoints != "" {
+		env = append(env, corev1.EnvVar{
+			Name:  "AGENT_ENDPOINTS",
+			Value: endpoints,
+		})
+	}
+
+	// Set the verbosity of the runtime logs
+	env = append(env, loggingEnv(agent)...)
+	env = append(env, redactionEnv(agent)...)
+
+	// Batch the requests to the vLLM server
+	env = append(env, vllmEnv(agent)...)
+
+	// Bound the chat requests handled at once
+	env = append(env, concurrencyEnv(agent)...)
 
 	// Add framework configuration
 	framework := "direct" // default
@@ -225,6 +279,86 @@ func (r *AgentReconciler) buildDeployment(agent *aiv1.Agent) *appsv1.Deployment
 		}
 	}
 
+	// Add vector store configuration for RAG agents
+	env = append(env, ragEnv(agent)...)
+
+	// Add conversation memory configuration
+	env = append(env, memoryEnv(agent)...)
+
+	// Answer repeated prompts from the response cache
+	env = append(env, cachingEnv(agent)...)
+
+	// Add the bearer token expected on inbound requests
+	env = append(env, endpointAuthEnv(agent)...)
+
+	// Serve the operational endpoints on the admin port, with their own token
+	env = append(env, adminEnv(agent)...)
+
+	// Add the warm-up requests sent before the pod reports ready
+	env = append(env, warmupEnv(agent)...)
+
+	// Consume the event source of the agent instead of waiting for requests
+	env = append(env, eventSourceEnv(agent)...)
+
+	// Locate the job queue shared with the workers
+	env = append(env, jobQueueEnv(agent)...)
+
+	// Push the completed exchanges to the transcript sink
+	env = append(env, transcriptSinkEnv(agent)...)
+
+	// Add the bucket of the latency target to the duration histogram
+	env = append(env, sloEnv(agent)...)
+
+	// Limit the requests while the budget is exhausted
+	env = append(env, budgetEnv(agent)...)
+	env = append(env, tokenQuotaEnv(agent)...)
+
+	// Mount the shared model weight cache
+	volumes, volumeMounts, cacheEnv := modelCacheVolume(agent)
+	env = append(env, cacheEnv...)
+
+	// Mount the claim holding the conversation state
+	persistenceVolumes, persistenceMounts, persistenceEnv := persistenceVolume(agent)
+	volumes = append(volumes, persistenceVolumes...)
+	volumeMounts = append(volumeMounts, persistenceMounts...)
+	env = append(env, persistenceEnv...)
+
+	// Mount the keys encrypting persisted conversations
+	encryptionVolumes, encryptionMounts, encryptionEnv := encryptionVolume(agent)
+	volumes = append(volumes, encryptionVolumes...)
+	volumeMounts = append(volumeMounts, encryptionMounts...)
+	env = append(env, encryptionEnv...)
+
+	// Mount the URLs and endpoint tokens of the peers
+	peerVolumes, peerMounts, peerEnv := peersVolume(agent)
+	volumes = append(volumes, peerVolumes...)
+	volumeMounts = append(volumeMounts, peerMounts...)
+	env = append(env, peerEnv...)
+
+	// Mount the few-shot examples sent before the conversation
+	exampleVolumes, exampleMounts, exampleEnv := examplesVolume(agent)
+	volumes = append(volumes, exampleVolumes...)
+	volumeMounts = append(volumeMounts, exampleMounts...)
+	env = append(env, exampleEnv...)
+
+	// Apply the model of the schedule in effect, reloaded by the runtime when it can
+	scheduleVolumes, scheduleMounts, scheduleEnv := modelScheduleVolume(agent)
+	volumes = append(volumes, scheduleVolumes...)
+	volumeMounts = append(volumeMounts, scheduleMounts...)
+	env = append(env, scheduleEnv...)
+
+	// Share the workspace of the tool executor and locate its execution API
+	workspaceVolumes, workspaceMounts, workspaceEnv := toolWorkspaceVolume(agent)
+	volumes = append(volumes, workspaceVolumes...)
+	volumeMounts = append(volumeMounts, workspaceMounts...)
+	env = append(env, workspaceEnv...)
+
+	// Mount the bounded scratch volume of the temporary files of the runtime
+	scratchVolumes, scratchMounts, scratchEnv := scratchVolume(agent)
+	volumes = append(volumes, scratchVolumes...)
+	volumeMounts = append(volumeMounts, scratchMounts...)
+	env = append(env, scratchEnv...)
+
 	// A simple way to pass tools to the agent. A more robust implementation might use a ConfigMap.
 	if len(agent.Spec.Tools) > 0 {
 		env = append(env, corev1.EnvVar{
@@ -233,15 +367,31 @@ func (r *AgentReconciler) buildDeployment(agent *aiv1.Agent) *appsv1.Deployment
 		})
 	}
 
-	labels := map[string]string{
-		"app.kubernetes.io/name":     "kubeagentic-agent",
-		"app.kubernetes.io/instance": agent.Name,
-		"kubeagentic.ai/agent":       agent.Name,
+	// Use the digest resolved for this image when digest pinning is enabled
+	image := r.getAgentImage(agent)
+	if agent.Status.ResolvedImage != "" && agent.Status.ResolvedFrom == image {
+		image = agent.Status.ResolvedImage
 	}
 
-	return &appsv1.Deployment{
+	labels := agentLabels(agent)
+
+	// Let annotation-based Prometheus discovery find the pods. Only the keys of the
+	// discovery are set, and they are gone from the template once it is disabled.
+	annotations := podTemplateAnnotations(agent)
+	if r.scrapeViaAnnotations(agent) {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		for key, value := range prometheusAnnotations(agent) {
+			annotations[key] = value
+		}
+	}
+
+	automount := automountServiceAccountToken(agent)
+
+	deployment := &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      agent.Name,
+			Name:      deploymentName(agent),
 			Namespace: agent.Namespace,
 			Labels:    labels,
 		},
@@ -252,23 +402,34 @@ func (r *AgentReconciler) buildDeployment(agent *aiv1.Agent) *appsv1.Deployment
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					Labels:      podLabels(agent, labels),
+					Annotations: annotations,
 				},
 				Spec: corev1.PodSpec{
+					AutomountServiceAccountToken: &automount,
+					NodeSelector:                 agent.Spec.NodeSelector,
+					Tolerations:                  agent.Spec.Tolerations,
+					Affinity:                     placementAffinity(agent),
+					TopologySpreadConstraints:    topologySpreadConstraints(agent),
+					PriorityClassName:            agent.Spec.PriorityClassName,
+					SchedulerName:                agent.Spec.SchedulerName,
+					ImagePullSecrets:             agent.Spec.ImagePullSecrets,
 					Containers: []corev1.Container{
 						{
-							Name:  "agent",
-							Image: r.getAgentImage(agent),
-							Ports: []corev1.ContainerPort{
+							Name:            "agent",
+							Image:           image,
+							ImagePullPolicy: agent.Spec.ImagePullPolicy,
+							Ports: append([]corev1.ContainerPort{
 								{ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
-							},
-							Env:       env,
-							Resources: resources,
+							}, adminContainerPorts(agent)...),
+							Env:          env,
+							Resources:    resources,
+							VolumeMounts: volumeMounts,
 							LivenessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
 										Path: "/health",
-										Port: intstr.FromInt(8080),
+										Port: intstr.FromInt(int(probePort(agent))),
 									},
 								},
 								InitialDelaySeconds: 30,
@@ -277,8 +438,8 @@ func (r *AgentReconciler) buildDeployment(agent *aiv1.Agent) *appsv1.Deployment
 							ReadinessProbe: &corev1.Probe{
 								ProbeHandler: corev1.ProbeHandler{
 									HTTPGet: &corev1.HTTPGetAction{
-										Path: "/ready",
-										Port: intstr.FromInt(8080),
+										Path: readinessPath(agent),
+										Port: intstr.FromInt(int(probePort(agent))),
 									},
 								},
 								InitialDelaySeconds: 5,
@@ -286,10 +447,60 @@ func (r *AgentReconciler) buildDeployment(agent *aiv1.Agent) *appsv1.Deployment
 							},
 						},
 					},
+					Volumes: volumes,
 				},
 			},
 		},
 	}
+
+	// Enforce the inbound rate limits in front of the agent container
+	if proxy, proxyVolumes := rateLimitProxy(agent); proxy != nil {
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, *proxy)
+		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, proxyVolumes...)
+	}
+
+	// Check the prompts and responses against the guardrails policy in front of the agent container
+	if proxy, proxyVolumes := guardrailsProxy(agent); proxy != nil {
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, *proxy)
+		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, proxyVolumes...)
+	}
+
+	// Bridge the chat platforms of the connectors to the agent container
+	if sidecar, sidecarVolumes := connectorSidecar(agent, &deployment.Spec.Template.Spec.Containers[0]); sidecar != nil {
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, *sidecar)
+		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, sidecarVolumes...)
+	}
+
+	// Run the commands of the tools in the executor sidecar, installed by an init container
+	if sidecar, install, sidecarVolumes := toolExecutorSidecar(agent); sidecar != nil {
+		deployment.Spec.Template.Spec.InitContainers = append(deployment.Spec.Template.Spec.InitContainers, *install)
+		deployment.Spec.Template.Spec.Containers = append(deployment.Spec.Template.Spec.Containers, *sidecar)
+		deployment.Spec.Template.Spec.Volumes = append(deployment.Spec.Template.Spec.Volumes, sidecarVolumes...)
+	}
+
+	// Hold the agent container until the model endpoint answers and has preloaded the model
+	for _, initContainer := range []*corev1.Container{
+		endpointWaitContainer(agent, &deployment.Spec.Template.Spec.Containers[0]),
+		preloadContainer(agent, &deployment.Spec.Template.Spec.Containers[0]),
+	} {
+		if initContainer != nil {
+			deployment.Spec.Template.Spec.InitContainers = append(deployment.Spec.Template.Spec.InitContainers, *initContainer)
+		}
+	}
+
+	// The claim of the agent is mounted by a single pod, so the old pod stops before the new
+	// one starts
+	if persistenceEnabled(agent) {
+		deployment.Spec.Strategy = appsv1.DeploymentStrategy{Type: appsv1.RecreateDeploymentStrategyType}
+	}
+
+	securityprofile.Apply(securityProfile(agent), &deployment.Spec.Template.Spec, agentUID)
+
+	// Apply the overrides of the user last; invalid ones fail validation before rendering
+	if template, err := applyPodTemplateOverrides(agent, &deployment.Spec.Template); err == nil {
+		deployment.Spec.Template = *template
+	}
+	return deployment
 }
 
 // buildService creates a new Service resource to expose the Agent's Deployment.
@@ -299,25 +510,29 @@ func (r *AgentReconciler) buildService(agent *aiv1.Agent) *corev1.Service {
 		serviceType = agent.Spec.ServiceType
 	}
 
-	labels := map[string]string{
-		"app.kubernetes.io/name":     "kubeagentic-agent",
-		"app.kubernetes.io/instance": agent.Name,
-		"kubeagentic.ai/agent":       agent.Name,
+	labels := agentLabels(agent)
+
+	// With conversation affinity the router sits between the Service and the agent pods.
+	selector := agentSelector(agent)
+	targetPort := agentServingPort(agent)
+	if conversationAffinityEnabled(agent) {
+		selector = routerLabels(agent)
+		targetPort = 8080
 	}
 
 	return &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      agent.Name + "-service",
+			Name:      serviceName(agent),
 			Namespace: agent.Namespace,
 			Labels:    labels,
 		},
 		Spec: corev1.ServiceSpec{
 			Type:     serviceType,
-			Selector: labels,
+			Selector: selector,
 			Ports: []corev1.ServicePort{
 				{
 					Port:       80,
-					TargetPort: intstr.FromInt(8080),
+					TargetPort: intstr.FromInt(int(targetPort)),
 					Protocol:   corev1.ProtocolTCP,
 				},
 			},
@@ -326,77 +541,214 @@ func (r *AgentReconciler) buildService(agent *aiv1.Agent) *corev1.Service {
 }
 
 // updateAgentStatus updates the status of the Agent resource based on the state of the Deployment.
-func (r *AgentReconciler) updateAgentStatus(ctx context.Context, agent *aiv1.Agent) error {
+// The status is only written when it differs from previous, the status the reconcile started
+// from, so that reconciles changing nothing do not write to the API server.
+func (r *AgentReconciler) updateAgentStatus(ctx context.Context, agent *aiv1.Agent, previous *aiv1.AgentStatus) error {
 	deployment := &appsv1.Deployment{}
-	err := r.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace}, deployment)
+	err := r.Get(ctx, types.NamespacedName{Name: deploymentName(agent), Namespace: agent.Namespace}, deployment)
 	if err != nil {
 		return fmt.Errorf("failed to get deployment for status update: %w", err)
 	}
 
+	// Delete the resources left behind by changed name overrides, and report the names in use
+	previousDeploymentRuns, err := r.sweepRenamedResources(ctx, agent)
+	if err != nil {
+		return fmt.Errorf("failed to delete renamed resources: %w", err)
+	}
+	names := resourceNames(agent)
+	if previousDeploymentRuns {
+		names.Deployment = agent.Status.ResourceNames.Deployment
+	}
+	agent.Status.ResourceNames = names
+
 	// Update replica status from the deployment.
 	agent.Status.ReplicaStatus.Desired = *deployment.Spec.Replicas
 	agent.Status.ReplicaStatus.Ready = deployment.Status.ReadyReplicas
 	agent.Status.ReplicaStatus.Available = deployment.Status.AvailableReplicas
+	workerMode, err := r.workerModeStatus(ctx, agent)
+	if err != nil {
+		return fmt.Errorf("failed to get worker deployment for status update: %w", err)
+	}
+	agent.Status.WorkerMode = workerMode
+	endpoints, err := r.serviceEndpoints(ctx, agent)
+	if err != nil {
+		return fmt.Errorf("failed to get service endpoints for status update: %w", err)
+	}
+	agent.Status.Endpoints = endpoints
 
 	// Determine the phase of the Agent based on the deployment's status.
-	if deployment.Status.ReadyReplicas == *deployment.Spec.Replicas && deployment.Status.ReadyReplicas > 0 {
+	if budgetSuspended(agent) {
+		agent.Status.Phase = aiv1.AgentPhaseBudgetExceeded
+		agent.Status.Message = fmt.Sprintf("Agent suspended until its budget resets at %s", agent.Status.Budget.ResetTime.Format(time.RFC3339))
+	} else if waitingForDependencies(agent) {
+		agent.Status.Phase = aiv1.AgentPhaseWaitingForDependencies
+		agent.Status.Message = findCondition(agent.Status.Conditions, aiv1.AgentConditionDependenciesReady).Message
+		r.setCondition(agent, aiv1.AgentConditionPrerequisitesReady, corev1.ConditionFalse, waitingForDependenciesReason, agent.Status.Message)
+	} else if restoringFromSnapshot(agent) {
+		agent.Status.Phase = aiv1.AgentPhasePending
+		agent.Status.Message = findCondition(agent.Status.Conditions, aiv1.AgentConditionSnapshotRestored).Message
+	} else if replicasReady(deployment) && endpoints.Ready > 0 {
 		agent.Status.Phase = aiv1.AgentPhaseRunning
 		agent.Status.Message = "Agent is running and ready"
+	} else if replicasReady(deployment) {
+		// The replicas are ready, but the Service does not route to them yet, or does not
+		// select them at all
+		agent.Status.Phase = aiv1.AgentPhasePending
+		agent.Status.Message = fmt.Sprintf("Agent replicas are ready, waiting for the endpoints of the Service %s", serviceName(agent))
 	} else if deployment.Status.Replicas == 0 {
 		agent.Status.Phase = aiv1.AgentPhasePending
 		agent.Status.Message = "Agent deployment is scaling up"
+	} else if warming := warmingPods(agent); warming > 0 {
+		agent.Status.Phase = aiv1.AgentPhasePending
+		agent.Status.Message = fmt.Sprintf("Agent pods warming up (%d/%d ready, %d warming up)", deployment.Status.ReadyReplicas, *deployment.Spec.Replicas, warming)
 	} else {
 		agent.Status.Phase = aiv1.AgentPhasePending
 		agent.Status.Message = fmt.Sprintf("Agent deployment in progress (%d/%d ready)", deployment.Status.ReadyReplicas, *deployment.Spec.Replicas)
 	}
 
+	// Report the preload of the model, and explain pods held by the init containers
+	// waiting for the model endpoint and preloading the model
+	pods, err := r.modelEndpointPods(ctx, agent)
+	if err != nil {
+		return fmt.Errorf("failed to check the init containers of the agent pods: %w", err)
+	}
+	agent.Status.Preload = preloadStatus(agent, pods)
+	if agent.Status.Phase == aiv1.AgentPhasePending {
+		if message := endpointWaitMessage(agent, pods); message != "" {
+			agent.Status.Message = message
+		} else if message := preloadMessage(agent); message != "" {
+			agent.Status.Message = message
+		}
+	}
+
+	agent.Status.ObservedGeneration = agent.Generation
+
+	// Every step succeeded: the Service is in place and the outcome of the rollout is reported.
+	r.setServiceReadyCondition(agent, deployment)
+	r.setDeploymentReadyCondition(agent, deployment)
+	r.resolveFailedStep(agent)
+	r.setNoEndpointsDegraded(agent)
+	r.setReadyCondition(agent)
+	agent.Status.RecentErrors = r.ErrorHistory.Prune(agent.Status.RecentErrors, r.clock().Now())
+
+	if previous != nil && !statusChanged(previous, &agent.Status) {
+		agent.Status.LastUpdated = previous.LastUpdated
+		return nil
+	}
 	now := metav1.NewTime(time.Now())
 	agent.Status.LastUpdated = &now
+	return r.Status().Update(ctx, agent)
+}
 
-	// Set the Ready condition based on the Agent's phase.
-	readyCondition := aiv1.AgentCondition{
-		Type:               aiv1.AgentConditionReady,
-		LastTransitionTime: &now,
+// statusChanged reports whether current differs from previous in more than the times that
+// change on every reconcile: the last update, the condition transition times and the time
+// left in the budget period. The recurrence of a recent error, which only bumps its time
+// and count, is not a change either.
+func statusChanged(previous, current *aiv1.AgentStatus) bool {
+	normalize := func(status *aiv1.AgentStatus) *aiv1.AgentStatus {
+		status = status.DeepCopy()
+		status.LastUpdated = nil
+		for i := range status.Conditions {
+			status.Conditions[i].LastTransitionTime = nil
+		}
+		if status.Budget != nil {
+			status.Budget.TimeUntilReset = ""
+		}
+		for i := range status.RecentErrors {
+			status.RecentErrors[i].Time = metav1.Time{}
+			status.RecentErrors[i].Count = 0
+		}
+		return status
 	}
+	return !equality.Semantic.DeepEqual(normalize(previous), normalize(current))
+}
 
-	if agent.Status.Phase == aiv1.AgentPhaseRunning {
-		readyCondition.Status = corev1.ConditionTrue
-		readyCondition.Reason = "DeploymentReady"
-		readyCondition.Message = "All replicas are ready"
-	} else {
-		readyCondition.Status = corev1.ConditionFalse
-		readyCondition.Reason = "DeploymentNotReady"
-		readyCondition.Message = "Deployment is not yet ready"
+// updateStatusFailed is a helper function to update the Agent's status to Failed. It sets
+// the condition of the step that failed, records the error and recomputes Ready.
+func (r *AgentReconciler) updateStatusFailed(ctx context.Context, agent *aiv1.Agent, conditionType aiv1.AgentConditionType, reason, message string) (ctrl.Result, error) {
+	// A step that failed because the namespace started terminating is not a failure of the
+	// agent, which is deleted with the namespace; its status could not be written either.
+	if r.namespaceTerminating(ctx, agent.Namespace) {
+		log.FromContext(ctx).Info("Namespace is terminating, dropping the failure", "reason", reason)
+		return ctrl.Result{}, nil
 	}
 
-	agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, readyCondition)
+	agent.Status.Phase = aiv1.AgentPhaseFailed
+	agent.Status.Message = message
 
-	return r.Status().Update(ctx, agent)
+	r.setFailedCondition(agent, conditionType, reason, message)
+	r.setReadyCondition(agent)
+
+	// An agent failing the same way on every retry already reports the failure
+	stored := &aiv1.Agent{}
+	if err := r.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace}, stored); err == nil && !statusChanged(&stored.Status, &agent.Status) {
+		agent.Status.LastUpdated = stored.Status.LastUpdated
+	} else {
+		now := metav1.NewTime(time.Now())
+		agent.Status.LastUpdated = &now
+		if err := r.Status().Update(ctx, agent); err != nil {
+			// Log the error but return the original error to avoid masking the root cause.
+			log.FromContext(ctx).Error(err, "Failed to update agent status to Failed")
+		}
+	}
+
+	// Requeue with a backoff growing while the agent keeps failing, to allow for manual
+	// intervention without retrying permanently broken agents every few minutes, but still
+	// deleting expired agents on time.
+	return ctrl.Result{RequeueAfter: r.expiryRequeueAfter(agent, r.failureRequeueAfter(ctx, agent))}, nil
 }
 
-// updateStatusFailed is a helper function to update the Agent's status to Failed.
-func (r *AgentReconciler) updateStatusFailed(ctx context.Context, agent *aiv1.Agent, message string) (ctrl.Result, error) {
-	agent.Status.Phase = aiv1.AgentPhaseFailed
-	agent.Status.Message = message
-	now := metav1.NewTime(time.Now())
-	agent.Status.LastUpdated = &now
+// recordEvent records an Event on the agent when an event recorder is configured.
+func (r *AgentReconciler) recordEvent(agent *aiv1.Agent, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(agent, eventType, reason, message)
+	}
+}
 
-	degradedCondition := aiv1.AgentCondition{
-		Type:               aiv1.AgentConditionDegraded,
-		Status:             corev1.ConditionTrue,
-		Reason:             "ReconciliationFailed",
-		Message:            message,
-		LastTransitionTime: &now,
+// resolveDegradedCondition sets the Degraded condition to False when it was set with reason.
+func (r *AgentReconciler) resolveDegradedCondition(agent *aiv1.Agent, reason, resolvedReason, message string) {
+	for _, condition := range agent.Status.Conditions {
+		if condition.Type != aiv1.AgentConditionDegraded || condition.Reason != reason || condition.Status != corev1.ConditionTrue {
+			continue
+		}
+		now := metav1.Now()
+		agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, aiv1.AgentCondition{
+			Type:               aiv1.AgentConditionDegraded,
+			Status:             corev1.ConditionFalse,
+			Reason:             resolvedReason,
+			Message:            message,
+			LastTransitionTime: &now,
+		})
+		return
 	}
-	agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, degradedCondition)
+}
 
-	if err := r.Status().Update(ctx, agent); err != nil {
-		// Log the error but return the original error to avoid masking the root cause.
-		log.FromContext(ctx).Error(err, "Failed to update agent status to Failed")
+// setDeploymentReadyCondition sets the DeploymentReady condition from the rollout state of
+// the agent Deployment.
+func (r *AgentReconciler) setDeploymentReadyCondition(agent *aiv1.Agent, deployment *appsv1.Deployment) {
+	if agent.Status.Phase == aiv1.AgentPhaseBudgetExceeded {
+		r.setCondition(agent, aiv1.AgentConditionDeploymentReady, corev1.ConditionFalse, budgetExceededReason, agent.Status.Message)
+		return
+	}
+	if agent.Status.Phase == aiv1.AgentPhaseWaitingForDependencies {
+		r.setCondition(agent, aiv1.AgentConditionDeploymentReady, corev1.ConditionFalse, waitingForDependenciesReason, agent.Status.Message)
+		return
+	}
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded" {
+			r.setCondition(agent, aiv1.AgentConditionDeploymentReady, corev1.ConditionFalse, "ProgressDeadlineExceeded", condition.Message)
+			return
+		}
 	}
 
-	// Requeue after a short period to allow for manual intervention or for the issue to be resolved.
-	return ctrl.Result{RequeueAfter: time.Minute * 2}, nil
+	switch {
+	case replicasReady(deployment):
+		r.setCondition(agent, aiv1.AgentConditionDeploymentReady, corev1.ConditionTrue, "ReplicasReady", "All replicas are ready")
+	case warmingPods(agent) > 0:
+		r.setCondition(agent, aiv1.AgentConditionDeploymentReady, corev1.ConditionFalse, "WarmingUp", agent.Status.Message)
+	default:
+		r.setCondition(agent, aiv1.AgentConditionDeploymentReady, corev1.ConditionFalse, "ReplicasNotReady", agent.Status.Message)
+	}
 }
 
 // updateCondition is a helper function to update a condition in the Agent's status.
@@ -416,30 +768,18 @@ func (r *AgentReconciler) updateCondition(conditions []aiv1.AgentCondition, newC
 
 // getAgentImage returns the container image to use for the agent.
 // It first checks if the agent spec has an image specified, then falls back
-// to the AGENT_IMAGE environment variable, and finally to a default.
+// to the operator default image of the agent framework, and finally to a default.
 func (r *AgentReconciler) getAgentImage(agent *aiv1.Agent) string {
 	// First priority: Agent-specific image in spec
 	if agent.Spec.Image != "" {
 		return agent.Spec.Image
 	}
-	
-	// Second priority: Environment variable (operator-wide default)
-	if envImage := os.Getenv("AGENT_IMAGE"); envImage != "" {
-		return envImage
+
+	// Second priority: the operator default image of the fleet rollout the agent is in
+	if fleet := agent.Status.FleetRollout; fleet != nil && fleet.Image != "" && followsFleetRollout(agent) {
+		return fleet.Image
 	}
-	
-	// Third priority: Hardcoded fallback
-	return "kubeagentic/agent:latest"
-}
 
-// SetupWithManager sets up the controller with the Manager.
-// This is how the controller is registered with the controller-runtime.
-func (r *AgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
-		For(&aiv1.Agent{}).
-		// Owns specifies the resources that are owned by the Agent resource.
-		// This allows the controller to watch for changes to these resources.
-		Owns(&appsv1.Deployment{}).
-		Owns(&corev1.Service{}).
-		Complete(r)
+	// Third priority: Environment variables (operator-wide defaults per framework), then a hardcoded fallback
+	return frameworkImage(agent.Spec.Framework)
 }
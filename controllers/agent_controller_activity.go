@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/activity"
+)
+
+// reconcileActivity adds the chat requests of the agent pods since the last update to
+// status.activity. Pods that are not running or cannot be scraped, such as pods of older
+// images without the request metrics, keep their last counters and are counted once they
+// can be scraped again.
+func (r *AgentReconciler) reconcileActivity(ctx context.Context, agent *aiv1.Agent) error {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return err
+	}
+	if len(pods.Items) == 0 && agent.Status.Activity == nil {
+		return nil
+	}
+	token, err := r.endpointToken(ctx, agent)
+	if err != nil {
+		return err
+	}
+
+	scraper := r.metricsScraper()
+	var scraped []aiv1.PodRequestCounters
+	var unscraped []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			unscraped = append(unscraped, pod.Name)
+			continue
+		}
+		sample, err := scraper.Scrape(ctx, fmt.Sprintf("http://%s:8080", pod.Status.PodIP), token)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Failed to scrape pod request counters", "Pod.Name", pod.Name, "error", err.Error())
+			unscraped = append(unscraped, pod.Name)
+			continue
+		}
+		scraped = append(scraped, aiv1.PodRequestCounters{
+			Pod:      pod.Name,
+			Requests: int64(sample.Requests),
+			Errors:   int64(sample.Errors),
+		})
+	}
+	agent.Status.Activity = activity.Update(agent.Status.Activity, scraped, unscraped, r.clock().Now())
+	return nil
+}
@@ -0,0 +1,330 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	// adminPortName names the admin port of the agent container.
+	adminPortName = "admin"
+	// adminAuthTokenKey is the Secret key holding the bearer token of the admin port.
+	adminAuthTokenKey = "token"
+	// adminKeyChecksumAnnotation on the pod template rolls the agent pods when the admin
+	// token changes, e.g. when its Secret was deleted and generated again.
+	adminKeyChecksumAnnotation = "kubeagentic.ai/admin-key-checksum"
+	// defaultOperatorNamespace is the namespace of the operator allowed to reach the admin
+	// port when the reconciler does not know its own.
+	defaultOperatorNamespace = "kubeagentic-system"
+)
+
+// adminPortEnabled reports whether the agent runtime serves its operational endpoints on a
+// port of their own.
+func adminPortEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.AdminPort != nil && agent.Spec.AdminPort.Port > 0
+}
+
+// adminServiceEnabled reports whether the admin port is exposed by the Service "<name>-admin".
+func adminServiceEnabled(agent *aiv1.Agent) bool {
+	return adminPortEnabled(agent) && agent.Spec.AdminPort.Expose
+}
+
+// adminAuthSecretName returns the name of the Secret holding the admin token of the agent.
+func adminAuthSecretName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "admin-auth")
+}
+
+// adminServiceName returns the name of the Service exposing the admin port of the agent.
+func adminServiceName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "admin")
+}
+
+// probePort returns the port of the agent container serving the probes and the operational
+// endpoints polled by the operator: the admin port when the agent has one.
+func probePort(agent *aiv1.Agent) int32 {
+	if adminPortEnabled(agent) {
+		return agent.Spec.AdminPort.Port
+	}
+	return 8080
+}
+
+// operatorNamespace returns the namespace of the operator, whose pods reach the admin port.
+func (r *AgentReconciler) operatorNamespace() string {
+	if r.OperatorNamespace != "" {
+		return r.OperatorNamespace
+	}
+	return defaultOperatorNamespace
+}
+
+// validateAdminPort checks that the admin port does not collide with the other ports of the
+// agent pods.
+func (r *AgentReconciler) validateAdminPort(agent *aiv1.Agent) error {
+	if agent.Spec.AdminPort == nil {
+		return nil
+	}
+	port := agent.Spec.AdminPort.Port
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("adminPort.port must be between 1 and 65535, got %d", port)
+	}
+	used := map[int32]string{8080: "the chat port"}
+	if agent.Spec.Metrics != nil && agent.Spec.Metrics.Port != nil {
+		used[*agent.Spec.Metrics.Port] = "the metrics port"
+	}
+	if inboundRateLimitEnabled(agent) {
+		used[rateLimitProxyPort] = "the rate limit proxy"
+	}
+	if guardrailsEnabled(agent) {
+		used[guardrailsPort(agent)] = "the guardrails proxy"
+	}
+	if connectorsEnabled(agent) {
+		used[connectorStatusPort] = "the connector sidecar"
+	}
+	if toolExecutorEnabled(agent) {
+		used[toolExecutorPort] = "the tool executor sidecar"
+	}
+	if owner, ok := used[port]; ok {
+		return fmt.Errorf("adminPort.port %d is already used by %s", port, owner)
+	}
+	return nil
+}
+
+// adminToken returns the bearer token of the admin port, or "" without an admin port.
+func (r *AgentReconciler) adminToken(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if !adminPortEnabled(agent) {
+		return "", nil
+	}
+	return r.secretValue(ctx, agent.Namespace, &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: adminAuthSecretName(agent)},
+		Key:                  adminAuthTokenKey,
+	})
+}
+
+// pollToken returns the bearer token the operator sends to the operational endpoints of the
+// agent: the admin token with an admin port, the endpoint token otherwise.
+func (r *AgentReconciler) pollToken(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if adminPortEnabled(agent) {
+		return r.adminToken(ctx, agent)
+	}
+	return r.endpointToken(ctx, agent)
+}
+
+// adminKeyChecksum returns a hash of the admin token, or an empty string without an admin port.
+func (r *AgentReconciler) adminKeyChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	token, err := r.adminToken(ctx, agent)
+	if err != nil || token == "" {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// reconcileAdminAuth ensures the admin token Secret exists while the agent has an admin
+// port, and deletes it otherwise.
+func (r *AgentReconciler) reconcileAdminAuth(ctx context.Context, agent *aiv1.Agent) error {
+	name := adminAuthSecretName(agent)
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if !adminPortEnabled(agent) {
+		agent.Status.AdminAuthSecretName = ""
+		if err == nil && metav1.IsControlledBy(found, agent) {
+			log.FromContext(ctx).Info("Deleting admin auth Secret", "Secret.Name", found.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, found))
+		}
+		return nil
+	}
+
+	if err == nil {
+		if !metav1.IsControlledBy(found, agent) {
+			return fmt.Errorf("secret %s already exists and is not owned by the agent", name)
+		}
+		if len(found.Data[adminAuthTokenKey]) > 0 {
+			agent.Status.AdminAuthSecretName = name
+			return nil
+		}
+	}
+
+	token, genErr := generateEndpointToken()
+	if genErr != nil {
+		return genErr
+	}
+	if errors.IsNotFound(err) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: agent.Namespace,
+				Labels:    agentLabels(agent),
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{adminAuthTokenKey: []byte(token)},
+		}
+		if err := controllerutil.SetControllerReference(agent, secret, r.Scheme); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Creating admin auth Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+		if err := r.Create(ctx, secret); err != nil {
+			return err
+		}
+	} else {
+		log.FromContext(ctx).Info("Generating missing admin token", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
+		found.Data = map[string][]byte{adminAuthTokenKey: []byte(token)}
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	agent.Status.AdminAuthSecretName = name
+	return nil
+}
+
+// adminEnv returns the environment variables moving the operational endpoints of the
+// runtime to the admin port, with the token they require.
+func adminEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !adminPortEnabled(agent) {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{Name: "AGENT_ADMIN_PORT", Value: strconv.Itoa(int(agent.Spec.AdminPort.Port))},
+		{
+			Name: "AGENT_ADMIN_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: adminAuthSecretName(agent)},
+					Key:                  adminAuthTokenKey,
+				},
+			},
+		},
+	}
+}
+
+// adminContainerPorts returns the admin port of the agent container.
+func adminContainerPorts(agent *aiv1.Agent) []corev1.ContainerPort {
+	if !adminPortEnabled(agent) {
+		return nil
+	}
+	return []corev1.ContainerPort{
+		{Name: adminPortName, ContainerPort: agent.Spec.AdminPort.Port, Protocol: corev1.ProtocolTCP},
+	}
+}
+
+// adminIngressRule returns the NetworkPolicy rule letting the operator pods, and only them,
+// reach the admin port of the agent pods.
+func (r *AgentReconciler) adminIngressRule(agent *aiv1.Agent) []networkingv1.NetworkPolicyIngressRule {
+	if !adminPortEnabled(agent) {
+		return nil
+	}
+	tcp := corev1.ProtocolTCP
+	port := intstr.FromInt(int(agent.Spec.AdminPort.Port))
+	return []networkingv1.NetworkPolicyIngressRule{{
+		From:  []networkingv1.NetworkPolicyPeer{{NamespaceSelector: namespaceSelector(r.operatorNamespace())}},
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &port}},
+	}}
+}
+
+// buildAdminService creates the Service exposing the admin port of the agent pods.
+func (r *AgentReconciler) buildAdminService(agent *aiv1.Agent) *corev1.Service {
+	port := agent.Spec.AdminPort.Port
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      adminServiceName(agent),
+			Namespace: agent.Namespace,
+			Labels:    agentLabels(agent),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     corev1.ServiceTypeClusterIP,
+			Selector: agentSelector(agent),
+			Ports: []corev1.ServicePort{
+				{
+					Name:       adminPortName,
+					Port:       port,
+					TargetPort: intstr.FromInt(int(port)),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// reconcileAdminService creates, updates or deletes the Service exposing the admin port.
+func (r *AgentReconciler) reconcileAdminService(ctx context.Context, agent *aiv1.Agent) error {
+	name := adminServiceName(agent)
+	found := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if !adminServiceEnabled(agent) {
+		if err == nil && metav1.IsControlledBy(found, agent) {
+			log.FromContext(ctx).Info("Deleting admin Service", "Service.Name", found.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, found))
+		}
+		return nil
+	}
+
+	service := r.buildAdminService(agent)
+	if errors.IsNotFound(err) {
+		if err := controllerutil.SetControllerReference(agent, service, r.Scheme); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Creating admin Service", "Service.Namespace", service.Namespace, "Service.Name", service.Name)
+		return r.Create(ctx, service)
+	}
+	if !metav1.IsControlledBy(found, agent) {
+		return fmt.Errorf("service %s already exists and is not owned by the agent", name)
+	}
+
+	// The cluster IP is allocated by the API server and kept.
+	found.Spec.Selector = service.Spec.Selector
+	found.Spec.Ports = service.Spec.Ports
+	log.FromContext(ctx).Info("Updating admin Service", "Service.Namespace", found.Namespace, "Service.Name", found.Name)
+	return r.Update(ctx, found)
+}
+
+// adminURL returns the base URL of the admin endpoints the operator polls: the admin
+// Service when it is exposed, a ready agent pod otherwise, or "" when no pod is ready.
+func (r *AgentReconciler) adminURL(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	port := agent.Spec.AdminPort.Port
+	if adminServiceEnabled(agent) {
+		return fmt.Sprintf("http://%s.%s.svc:%d", adminServiceName(agent), agent.Namespace, port), nil
+	}
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels(agentSelector(agent))); err != nil {
+		return "", err
+	}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" && pod.DeletionTimestamp == nil && podReady(&pod) {
+			return fmt.Sprintf("http://%s:%d", pod.Status.PodIP, port), nil
+		}
+	}
+	return "", nil
+}
+
+// podReady reports whether the pod passes its readiness probe.
+func podReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
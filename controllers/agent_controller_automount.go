@@ -0,0 +1,30 @@
+package controllers
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// automountServiceAccountToken reports whether the agent pods mount the ServiceAccount token.
+// Agents do not talk to the Kubernetes API unless the user opts in.
+func automountServiceAccountToken(agent *aiv1.Agent) bool {
+	if agent.Spec.AutomountServiceAccountToken != nil {
+		return *agent.Spec.AutomountServiceAccountToken
+	}
+	return false
+}
+
+// preserveLegacyAutomount keeps the token mount of a Deployment created before automounting
+// was disabled by default, so that upgrading the operator does not roll every agent. The
+// default applies once the agent spec changes, which bumps its generation past the one
+// last observed.
+func preserveLegacyAutomount(agent *aiv1.Agent, desired, existing *appsv1.Deployment) {
+	if agent.Spec.AutomountServiceAccountToken != nil || existing.Spec.Template.Spec.AutomountServiceAccountToken != nil {
+		return
+	}
+	observed := agent.Status.ObservedGeneration
+	if observed == 0 || observed == agent.Generation {
+		desired.Spec.Template.Spec.AutomountServiceAccountToken = nil
+	}
+}
@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// templateHistoryAnnotation keeps the most recent pod templates of the agent Deployment,
+	// newest first, so that a failed change can be rolled back.
+	templateHistoryAnnotation = "kubeagentic.ai/template-history"
+
+	// autoRolledBackReason is the reason of the Degraded condition and the Event recorded
+	// when a change is rolled back.
+	autoRolledBackReason = "AutoRolledBack"
+
+	defaultRollbackErrorRate = 10
+	defaultRollbackWindow    = 10 * time.Minute
+	defaultRollbackLookback  = 3
+	// autoRollbackMinRequests is the number of requests needed before the error rate of a
+	// change is judged.
+	autoRollbackMinRequests = 10
+	// autoRollbackRequeue is how often a change is checked during the window.
+	autoRollbackRequeue = 30 * time.Second
+	// maxEventDiff bounds the spec diff included in the rollback Event.
+	maxEventDiff = 800
+)
+
+// templateRevision is a pod template kept in the template history.
+type templateRevision struct {
+	Revision string                 `json:"revision"`
+	Template corev1.PodTemplateSpec `json:"template"`
+}
+
+// autoRollbackEnabled reports whether failed changes to the agent pods are rolled back.
+func autoRollbackEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Rollout != nil && agent.Spec.Rollout.AutoRollback != nil && agent.Spec.Rollout.AutoRollback.Enabled
+}
+
+// rollbackErrorRate returns the error percentage above which a change is rolled back.
+func rollbackErrorRate(agent *aiv1.Agent) int32 {
+	if threshold := agent.Spec.Rollout.AutoRollback.ErrorRateThreshold; threshold != nil {
+		return *threshold
+	}
+	return defaultRollbackErrorRate
+}
+
+// rollbackWindow returns how long a change is monitored.
+func rollbackWindow(agent *aiv1.Agent) time.Duration {
+	if window := agent.Spec.Rollout.AutoRollback.Window; window != nil {
+		return window.Duration
+	}
+	return defaultRollbackWindow
+}
+
+// rollbackLookback returns the number of previous pod templates kept.
+func rollbackLookback(agent *aiv1.Agent) int {
+	if lookback := agent.Spec.Rollout.AutoRollback.MaxLookback; lookback != nil {
+		return int(*lookback)
+	}
+	return defaultRollbackLookback
+}
+
+// stableSelector selects the agent pods of the stable Deployment, leaving out canary pods.
+func stableSelector(agent *aiv1.Agent) labels.Selector {
+	requirement, _ := labels.NewRequirement(trackLabel, selection.NotIn, []string{canaryTrack})
+	return labels.SelectorFromSet(labels.Set{"kubeagentic.ai/agent": agent.Name}).Add(*requirement)
+}
+
+// templateHistory returns the pod templates kept on the agent Deployment, newest first.
+func templateHistory(deployment *appsv1.Deployment) []templateRevision {
+	var history []templateRevision
+	if data := deployment.Annotations[templateHistoryAnnotation]; data != "" {
+		if err := json.Unmarshal([]byte(data), &history); err != nil {
+			return nil
+		}
+	}
+	return history
+}
+
+// setTemplateHistory stores the pod template history on the agent Deployment.
+func setTemplateHistory(deployment *appsv1.Deployment, history []templateRevision) error {
+	data, err := json.Marshal(history)
+	if err != nil {
+		return err
+	}
+	if deployment.Annotations == nil {
+		deployment.Annotations = map[string]string{}
+	}
+	deployment.Annotations[templateHistoryAnnotation] = string(data)
+	return nil
+}
+
+// reconcileAutoRollback monitors the agent Deployment after its pod template changed and
+// restores the previous template when the change fails to roll out or the error rate of
+// the agent pods exceeds the threshold. The restored template is kept until the spec
+// changes again, so the Agent spec still shows the intended state. It returns true while
+// the desired Deployment must not be applied.
+func (r *AgentReconciler) reconcileAutoRollback(ctx context.Context, agent *aiv1.Agent, desired, found *appsv1.Deployment) (bool, error) {
+	if !autoRollbackEnabled(agent) {
+		agent.Status.AutoRollback = nil
+		r.clearRollbackCondition(agent, "Automatic rollback is disabled")
+		if _, ok := found.Annotations[templateHistoryAnnotation]; !ok {
+			return false, nil
+		}
+		delete(found.Annotations, templateHistoryAnnotation)
+		return false, r.Update(ctx, found)
+	}
+
+	status := agent.Status.AutoRollback
+	if status == nil {
+		status = &aiv1.AutoRollbackStatus{}
+		agent.Status.AutoRollback = status
+	}
+
+	revision := desired.Annotations[templateHashAnnotation]
+	if status.RolledBackRevision != "" {
+		if status.RolledBackRevision == revision {
+			// Keep the restored template until the spec changes.
+			return true, nil
+		}
+		status.RolledBackRevision = ""
+		r.clearRollbackCondition(agent, fmt.Sprintf("Revision %s replaced the rolled back revision", revision))
+	}
+
+	// Record the template of the Deployment whenever it changed, whichever way the change
+	// was rolled out, and start monitoring it.
+	current := found.Annotations[templateHashAnnotation]
+	history := templateHistory(found)
+	if current != "" && (len(history) == 0 || history[0].Revision != current) {
+		history = append([]templateRevision{{Revision: current, Template: found.Spec.Template}}, history...)
+		if len(history) > rollbackLookback(agent)+1 {
+			history = history[:rollbackLookback(agent)+1]
+		}
+		if err := setTemplateHistory(found, history); err != nil {
+			return true, err
+		}
+		if err := r.Update(ctx, found); err != nil {
+			return true, err
+		}
+		if len(history) > 1 {
+			log.FromContext(ctx).Info("Monitoring change to the agent pods", "revision", current, "previousRevision", history[1].Revision)
+			now := metav1.Now()
+			status.Revision = current
+			status.WatchStartTime = &now
+			status.StartCounters = nil
+			status.Message = fmt.Sprintf("Monitoring revision %s for %s", current, rollbackWindow(agent))
+		}
+	}
+
+	if status.WatchStartTime == nil || status.Revision != current {
+		return false, nil
+	}
+	if time.Since(status.WatchStartTime.Time) > rollbackWindow(agent) {
+		log.FromContext(ctx).Info("Change to the agent pods passed monitoring", "revision", current)
+		status.WatchStartTime, status.StartCounters = nil, nil
+		status.Message = fmt.Sprintf("Revision %s is healthy", current)
+		return false, nil
+	}
+
+	reason, err := r.checkRolloutHealth(ctx, agent, found)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to check the health of the agent pods")
+		status.Message = fmt.Sprintf("Monitoring revision %s: %v", current, err)
+		return false, nil
+	}
+	if reason == "" || len(history) < 2 {
+		return false, nil
+	}
+	return true, r.rollBack(ctx, agent, found, history, reason)
+}
+
+// checkRolloutHealth returns why the monitored change failed, or an empty string while it
+// is healthy. The request counters are compared with those of the ready agent pods.
+func (r *AgentReconciler) checkRolloutHealth(ctx context.Context, agent *aiv1.Agent, found *appsv1.Deployment) (string, error) {
+	for _, condition := range found.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && condition.Status == corev1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded" {
+			return fmt.Sprintf("the Deployment exceeded its progress deadline: %s", condition.Message), nil
+		}
+	}
+	if !deploymentReady(found) {
+		return "", nil
+	}
+
+	status := agent.Status.AutoRollback
+	sample, err := r.scrapeAgentPods(ctx, agent, stableSelector(agent))
+	if err != nil {
+		return "", err
+	}
+	if status.StartCounters == nil {
+		status.StartCounters = requestCounters(sample)
+		return "", nil
+	}
+	delta := sample.Sub(counterSample(status.StartCounters))
+	if delta.Requests < autoRollbackMinRequests {
+		return "", nil
+	}
+	errorPercent := delta.ErrorRate() * 100
+	if errorPercent > float64(rollbackErrorRate(agent)) {
+		return fmt.Sprintf("the error rate %.2f%% exceeded %d%%", errorPercent, rollbackErrorRate(agent)), nil
+	}
+	return "", nil
+}
+
+// rollBack restores the previous pod template of the agent Deployment and marks the
+// agent Degraded.
+func (r *AgentReconciler) rollBack(ctx context.Context, agent *aiv1.Agent, found *appsv1.Deployment, history []templateRevision, reason string) error {
+	failed, previous := history[0], history[1]
+	log.FromContext(ctx).Info("Rolling back change to the agent pods", "revision", failed.Revision, "previousRevision", previous.Revision, "reason", reason)
+
+	found.Annotations[templateHashAnnotation] = previous.Revision
+	found.Spec.Template = previous.Template
+	if err := setTemplateHistory(found, history[1:]); err != nil {
+		return err
+	}
+	if err := r.Update(ctx, found); err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	message := fmt.Sprintf("Revision %s was rolled back to %s because %s", failed.Revision, previous.Revision, reason)
+	agent.Status.AutoRollback = &aiv1.AutoRollbackStatus{
+		Revision:           previous.Revision,
+		RolledBackRevision: failed.Revision,
+		RollbackTime:       &now,
+		Message:            message,
+	}
+	r.setFailedCondition(agent, aiv1.AgentConditionDegraded, autoRolledBackReason, message+"; the agent pods do not run the spec until it changes")
+
+	event := message
+	if diff := templateDiff(&previous.Template, &failed.Template); diff != "" {
+		event += "; changes: " + diff
+	}
+	r.recordEvent(agent, corev1.EventTypeWarning, autoRolledBackReason, event)
+	return nil
+}
+
+// clearRollbackCondition resolves the Degraded condition set by a rollback.
+func (r *AgentReconciler) clearRollbackCondition(agent *aiv1.Agent, message string) {
+	r.resolveDegradedCondition(agent, autoRolledBackReason, "SpecApplied", message)
+}
+
+// templateDiff summarizes how the agent container of the pod template changed, naming the
+// environment variables and image the spec renders to.
+func templateDiff(from, to *corev1.PodTemplateSpec) string {
+	if len(from.Spec.Containers) == 0 || len(to.Spec.Containers) == 0 {
+		return ""
+	}
+	before, after := from.Spec.Containers[0], to.Spec.Containers[0]
+
+	var changes []string
+	if before.Image != after.Image {
+		changes = append(changes, fmt.Sprintf("image: %q -> %q", before.Image, after.Image))
+	}
+	beforeEnv, afterEnv := envValues(before.Env), envValues(after.Env)
+	var names []string
+	for name := range afterEnv {
+		names = append(names, name)
+	}
+	for name := range beforeEnv {
+		if _, ok := afterEnv[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		oldValue, hadOld := beforeEnv[name]
+		newValue, hasNew := afterEnv[name]
+		switch {
+		case !hadOld:
+			changes = append(changes, fmt.Sprintf("%s: added %q", name, newValue))
+		case !hasNew:
+			changes = append(changes, fmt.Sprintf("%s: removed", name))
+		case oldValue != newValue:
+			changes = append(changes, fmt.Sprintf("%s: %q -> %q", name, oldValue, newValue))
+		}
+	}
+	if !before.Resources.Requests.Cpu().Equal(*after.Resources.Requests.Cpu()) || !before.Resources.Requests.Memory().Equal(*after.Resources.Requests.Memory()) ||
+		!before.Resources.Limits.Cpu().Equal(*after.Resources.Limits.Cpu()) || !before.Resources.Limits.Memory().Equal(*after.Resources.Limits.Memory()) {
+		changes = append(changes, "resources changed")
+	}
+
+	diff := strings.Join(changes, ", ")
+	if len(diff) > maxEventDiff {
+		diff = diff[:maxEventDiff] + "..."
+	}
+	return diff
+}
+
+// envValues maps the environment variables of a container to a printable value. Values
+// read from Secrets or ConfigMaps are shown by their reference only.
+func envValues(env []corev1.EnvVar) map[string]string {
+	values := make(map[string]string, len(env))
+	for _, e := range env {
+		value := e.Value
+		if source := e.ValueFrom; source != nil {
+			switch {
+			case source.SecretKeyRef != nil:
+				value = "secret " + source.SecretKeyRef.Name + "/" + source.SecretKeyRef.Key
+			case source.ConfigMapKeyRef != nil:
+				value = "configmap " + source.ConfigMapKeyRef.Name + "/" + source.ConfigMapKeyRef.Key
+			case source.FieldRef != nil:
+				value = "field " + source.FieldRef.FieldPath
+			}
+		}
+		if len(value) > 60 {
+			value = value[:57] + "..."
+		}
+		values[e.Name] = value
+	}
+	return values
+}
+
+// autoRollbackRequeueAfter shortens the periodic requeue while a change is monitored.
+func autoRollbackRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	status := agent.Status.AutoRollback
+	if !autoRollbackEnabled(agent) || status == nil || status.WatchStartTime == nil {
+		return requeue
+	}
+	if requeue > autoRollbackRequeue {
+		return autoRollbackRequeue
+	}
+	return requeue
+}
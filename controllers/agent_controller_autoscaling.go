@@ -0,0 +1,127 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/replicalimit"
+)
+
+// The autoscalers of an agent, as named in the PolicyClamped condition.
+const (
+	hpaAutoscaler         = "HPA"
+	eventSourceAutoscaler = "eventSource.autoscaling"
+	workerAutoscaler      = "workerMode.autoscaling"
+)
+
+// autoscaler holds the bounds an autoscaler of the agent is configured with, and what each
+// of the pods it scales requests.
+type autoscaler struct {
+	name        string
+	minReplicas int32
+	maxReplicas int32
+	podRequests corev1.ResourceList
+}
+
+// hpaScalingBounds returns the minimum and maximum replicas of the HPA, which scales the
+// agent up to three times its replicas.
+func hpaScalingBounds(agent *aiv1.Agent) (int32, int32) {
+	if agent.Spec.Replicas == nil {
+		return 1, 10
+	}
+	return *agent.Spec.Replicas, *agent.Spec.Replicas * 3
+}
+
+// autoscalers returns the autoscalers the agent runs.
+func (r *AgentReconciler) autoscalers(agent *aiv1.Agent) []autoscaler {
+	pod := r.buildDeployment(agent).Spec.Template.Spec
+	podRequests := corev1.ResourceList{}
+	for _, container := range pod.Containers {
+		addResources(podRequests, container.Resources.Requests)
+	}
+
+	var autoscalers []autoscaler
+	if hpaEnabled(agent) {
+		minReplicas, maxReplicas := hpaScalingBounds(agent)
+		autoscalers = append(autoscalers, autoscaler{hpaAutoscaler, minReplicas, maxReplicas, podRequests})
+	}
+	if eventSourceAutoscaled(agent) {
+		minReplicas, maxReplicas := eventScalingBounds(agent)
+		autoscalers = append(autoscalers, autoscaler{eventSourceAutoscaler, minReplicas, maxReplicas, podRequests})
+	}
+	if workersAutoscaled(agent) {
+		// Workers run the agent container and the tool executor alone
+		workerRequests := corev1.ResourceList{}
+		for _, container := range pod.Containers {
+			if container.Name == "agent" || container.Name == toolExecutorContainerName {
+				addResources(workerRequests, container.Resources.Requests)
+			}
+		}
+		minReplicas, maxReplicas := workerScalingBounds(agent)
+		autoscalers = append(autoscalers, autoscaler{workerAutoscaler, minReplicas, maxReplicas, workerRequests})
+	}
+	return autoscalers
+}
+
+// autoscalingCeiling returns the autoscaling ceiling of the operator and the AgentPolicies
+// of the agent's namespace.
+func (r *AgentReconciler) autoscalingCeiling(ctx context.Context, agent *aiv1.Agent) (replicalimit.Ceiling, error) {
+	var policies aiv1.AgentPolicyList
+	if err := r.List(ctx, &policies, client.InNamespace(agent.Namespace)); err != nil {
+		return replicalimit.Ceiling{}, err
+	}
+	return replicalimit.CeilingFor(agent.Namespace, policies.Items), nil
+}
+
+// autoscalingMaxReplicas returns the maximum replicas of the named autoscaler of the agent,
+// clamped to the autoscaling ceiling.
+func (r *AgentReconciler) autoscalingMaxReplicas(ctx context.Context, agent *aiv1.Agent, name string) (int32, error) {
+	ceiling, err := r.autoscalingCeiling(ctx, agent)
+	if err != nil {
+		return 0, err
+	}
+	for _, scaler := range r.autoscalers(agent) {
+		if scaler.name == name {
+			maxReplicas, _ := ceiling.Clamp(scaler.minReplicas, scaler.maxReplicas, scaler.podRequests)
+			return maxReplicas, nil
+		}
+	}
+	return 0, fmt.Errorf("agent has no %s autoscaler", name)
+}
+
+// reconcileAutoscalingCeiling reports the autoscalers whose maximum replicas the operator
+// lowers to the autoscaling ceiling on the PolicyClamped condition, and records an event
+// when the clamping changes. The admission webhook rejects maximums above the ceiling, so
+// this happens when a ceiling is lowered after the agent was created, or for the HPA, whose
+// maximum the agent does not set.
+func (r *AgentReconciler) reconcileAutoscalingCeiling(ctx context.Context, agent *aiv1.Agent) error {
+	ceiling, err := r.autoscalingCeiling(ctx, agent)
+	if err != nil {
+		return err
+	}
+	var clamps []string
+	for _, scaler := range r.autoscalers(agent) {
+		maxReplicas, exceeded := ceiling.Clamp(scaler.minReplicas, scaler.maxReplicas, scaler.podRequests)
+		if len(exceeded) == 0 {
+			continue
+		}
+		clamps = append(clamps, fmt.Sprintf("%s maxReplicas clamped from %d to %d: %s", scaler.name, scaler.maxReplicas, maxReplicas, strings.Join(exceeded, ", ")))
+	}
+
+	current := findCondition(agent.Status.Conditions, aiv1.AgentConditionPolicyClamped)
+	if len(clamps) == 0 {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionPolicyClamped)
+		return nil
+	}
+	message := strings.Join(clamps, "; ")
+	if current == nil || current.Message != message {
+		r.recordEvent(agent, corev1.EventTypeWarning, "AutoscalingClamped", message)
+	}
+	r.setCondition(agent, aiv1.AgentConditionPolicyClamped, corev1.ConditionTrue, "MaxReplicasClamped", message)
+	return nil
+}
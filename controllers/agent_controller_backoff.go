@@ -0,0 +1,53 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+var failureBackoffSeconds = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubeagentic_agent_failure_backoff_seconds",
+	Help: "Delay before the failing agent is reconciled again; unset once a reconcile succeeds.",
+}, []string{"namespace", "agent"})
+
+func init() {
+	metrics.Registry.MustRegister(failureBackoffSeconds)
+}
+
+// failureRequeueAfter records a failed reconcile of the agent and returns the delay before
+// retrying it, which doubles with each consecutive failure of the same generation.
+func (r *AgentReconciler) failureRequeueAfter(ctx context.Context, agent *aiv1.Agent) time.Duration {
+	key := failureKey(types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace})
+	delay := r.failures.Next(key, agent.Generation)
+	failureBackoffSeconds.WithLabelValues(agent.Namespace, agent.Name).Set(delay.Seconds())
+	log.FromContext(ctx).V(1).Info("Backing off failing agent",
+		"failures", r.failures.Failures(key), "requeueAfter", delay)
+	return delay
+}
+
+// resetFailureBackoff forgets the failures of the agent, so that it is retried promptly
+// the next time it fails.
+func (r *AgentReconciler) resetFailureBackoff(agent *aiv1.Agent) {
+	r.forgetFailures(types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace})
+}
+
+// forgetFailures forgets the failures of the agent named name, which may no longer exist,
+// and unsets its backoff gauge.
+func (r *AgentReconciler) forgetFailures(name types.NamespacedName) {
+	r.failures.Reset(failureKey(name))
+	failureBackoffSeconds.DeleteLabelValues(name.Namespace, name.Name)
+}
+
+// failureKey returns the key the failures of the agent named name are tracked under. The
+// name rather than the UID is used, as it is all that is left of an agent deleted without
+// its finalizer.
+func failureKey(name types.NamespacedName) string {
+	return name.String()
+}
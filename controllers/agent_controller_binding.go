@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	// bindingSecretType is the type of the binding Secrets, as recommended by the Service
+	// Binding specification for the Secrets of provisioned services.
+	bindingSecretType corev1.SecretType = "servicebinding.io/agent"
+	// bindingType is the type entry of the binding Secrets.
+	bindingType = "agent"
+	// bindingProvider is the provider entry of the binding Secrets.
+	bindingProvider = "kubeagentic"
+)
+
+// bindingEnabled reports whether the agent publishes a binding Secret. It does unless
+// spec.binding.enabled is false.
+func bindingEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Binding == nil || agent.Spec.Binding.Enabled == nil || *agent.Spec.Binding.Enabled
+}
+
+// bindingSecretName returns the name of the binding Secret of the agent.
+func bindingSecretName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "binding")
+}
+
+// bindingData returns the entries of the binding Secret of the agent: the in-cluster URL of
+// its Service, the URL of its Ingress when it is exposed, and its endpoint token.
+func bindingData(agent *aiv1.Agent, token string) map[string][]byte {
+	host := fmt.Sprintf("%s.%s.svc.cluster.local", serviceName(agent), agent.Namespace)
+	data := map[string][]byte{
+		"type":     []byte(bindingType),
+		"provider": []byte(bindingProvider),
+		"host":     []byte(host),
+		"port":     []byte(strconv.Itoa(80)),
+		"uri":      []byte("http://" + host),
+	}
+	if ingressEnabled(agent) {
+		data["external-uri"] = []byte("http://" + ingressHost(agent) + strings.TrimSuffix(ingressPath(agent), "/"))
+	}
+	if token != "" {
+		data["token"] = []byte(token)
+	}
+	return data
+}
+
+// reconcileBinding keeps the binding Secret of the agent in sync with its Service, Ingress
+// and endpoint token, and deletes it when the binding is disabled. It runs after the
+// endpoint token is rotated, so consumers read the new token from the same reconcile.
+func (r *AgentReconciler) reconcileBinding(ctx context.Context, agent *aiv1.Agent) error {
+	name := bindingSecretName(agent)
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	if !bindingEnabled(agent) {
+		agent.Status.Binding = nil
+		if err == nil && metav1.IsControlledBy(found, agent) {
+			log.FromContext(ctx).Info("Deleting binding Secret", "Secret.Name", found.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, found))
+		}
+		return nil
+	}
+
+	token, tokenErr := r.endpointToken(ctx, agent)
+	if tokenErr != nil {
+		return tokenErr
+	}
+	data := bindingData(agent, token)
+
+	if errors.IsNotFound(err) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: agent.Namespace,
+				Labels:    agentLabels(agent),
+			},
+			Type: bindingSecretType,
+			Data: data,
+		}
+		if err := controllerutil.SetControllerReference(agent, secret, r.Scheme); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Creating binding Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+		if err := r.Create(ctx, secret); err != nil {
+			return err
+		}
+	} else {
+		if !metav1.IsControlledBy(found, agent) {
+			return fmt.Errorf("secret %s already exists and is not owned by the agent", name)
+		}
+		if !bindingDataEqual(found.Data, data) {
+			log.FromContext(ctx).Info("Updating binding Secret", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
+			found.Data = data
+			if err := r.Update(ctx, found); err != nil {
+				return err
+			}
+		}
+	}
+
+	agent.Status.Binding = &aiv1.BindingStatus{SecretName: name}
+	return nil
+}
+
+func bindingDataEqual(a, b map[string][]byte) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || !bytes.Equal(value, other) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,331 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	// revisionLabel carries the pod template hash on blue/green agent pods. The agent Service
+	// selects the active revision through it.
+	revisionLabel = "kubeagentic.ai/revision"
+
+	// defaultScaleDownDelay is how long the previous revision keeps running after promotion.
+	defaultScaleDownDelay = 30 * time.Second
+)
+
+// previewName returns the name of the agent's blue/green preview Deployment and Service.
+func previewName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "preview")
+}
+
+// scaleDownDelay returns how long the previous revision keeps running after promotion.
+func scaleDownDelay(agent *aiv1.Agent) time.Duration {
+	if bg := agent.Spec.Rollout.BlueGreen; bg != nil && bg.ScaleDownDelay != nil {
+		return bg.ScaleDownDelay.Duration
+	}
+	return defaultScaleDownDelay
+}
+
+// agentSelector returns the labels selecting the agent pods that receive traffic. With the
+// blue/green strategy, only the pods of the active revision are selected.
+func agentSelector(agent *aiv1.Agent) map[string]string {
+	selector := agentLabels(agent)
+	if blueGreenEnabled(agent) && agent.Status.Rollout != nil && agent.Status.Rollout.ActiveRevision != "" {
+		selector[revisionLabel] = agent.Status.Rollout.ActiveRevision
+	}
+	return selector
+}
+
+// withRevisionLabel labels the pod template of deployment with its revision. The template
+// labels are copied, as buildDeployment shares them with the selector.
+func withRevisionLabel(deployment *appsv1.Deployment, revision string) {
+	labels := map[string]string{revisionLabel: revision}
+	for k, v := range deployment.Spec.Template.Labels {
+		if k != revisionLabel {
+			labels[k] = v
+		}
+	}
+	deployment.Spec.Template.Labels = labels
+}
+
+// deploymentReady reports whether all replicas of deployment run its current template and
+// are ready.
+func deploymentReady(deployment *appsv1.Deployment) bool {
+	replicas := *deployment.Spec.Replicas
+	return deployment.Status.ObservedGeneration >= deployment.Generation &&
+		deployment.Status.UpdatedReplicas >= replicas && deployment.Status.ReadyReplicas >= replicas
+}
+
+// reconcileBlueGreen runs changes to the pod template in a preview Deployment next to the
+// stable one. Once the preview is ready and promoted, the agent Service is switched to it;
+// after the scale-down delay the stable Deployment takes over the promoted template and
+// the preview is removed.
+func (r *AgentReconciler) reconcileBlueGreen(ctx context.Context, agent *aiv1.Agent, desired, found *appsv1.Deployment) (bool, error) {
+	revision := desired.Annotations[templateHashAnnotation]
+	stableRevision := found.Annotations[templateHashAnnotation]
+	withRevisionLabel(desired, revision)
+
+	rollout := agent.Status.Rollout
+	if rollout == nil {
+		rollout = &aiv1.RolloutStatus{}
+		agent.Status.Rollout = rollout
+	}
+	rollout.StableRevision = stableRevision
+
+	preview := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: previewName(agent), Namespace: agent.Namespace}, preview)
+	if errors.IsNotFound(err) {
+		preview = nil
+	} else if err != nil {
+		return true, err
+	}
+
+	// A promoted preview serves the traffic until the stable Deployment runs its template.
+	if preview != nil && rollout.PreviewRevision != "" && rollout.ActiveRevision == rollout.PreviewRevision {
+		return true, r.finishBlueGreenPromotion(ctx, agent, preview, found)
+	}
+
+	if stableRevision == "" || stableRevision == revision {
+		if preview != nil {
+			// The spec was reverted to the stable revision before promotion.
+			rollout.Phase = aiv1.RolloutAborted
+			rollout.Message = fmt.Sprintf("Preview revision %s was superseded by the stable revision", rollout.PreviewRevision)
+			if err := r.deletePreview(ctx, agent); err != nil {
+				return true, err
+			}
+		}
+		rollout.PreviewRevision = ""
+		if found.Spec.Template.Labels[revisionLabel] == revision && deploymentReady(found) {
+			rollout.ActiveRevision = revision
+		}
+		return false, nil
+	}
+
+	// The Service can only be switched once the stable pods carry their revision. Until
+	// then changes are rolled out in place.
+	if found.Spec.Template.Labels[revisionLabel] != stableRevision || rollout.ActiveRevision != stableRevision {
+		log.FromContext(ctx).Info("Stable pods are not labeled with their revision yet, updating in place", "revision", revision)
+		return false, nil
+	}
+
+	if rollout.PreviewRevision != revision {
+		log.FromContext(ctx).Info("Starting blue/green preview", "revision", revision, "activeRevision", rollout.ActiveRevision)
+		rollout.PreviewRevision = revision
+		rollout.Phase = aiv1.RolloutProgressing
+		rollout.PromotionTime = nil
+	}
+
+	if annotationSet(agent, abortAnnotation) {
+		if err := r.clearAnnotation(ctx, agent, abortAnnotation); err != nil {
+			return true, err
+		}
+		if rollout.Phase != aiv1.RolloutAborted {
+			log.FromContext(ctx).Info("Aborting blue/green preview", "revision", revision)
+			rollout.Phase = aiv1.RolloutAborted
+			rollout.Message = "Aborted manually"
+		}
+	}
+	if rollout.Phase == aiv1.RolloutAborted {
+		if annotationSet(agent, promoteAnnotation) {
+			// Promoting an aborted revision starts its preview again.
+			rollout.Phase = aiv1.RolloutProgressing
+		} else {
+			return true, r.deletePreview(ctx, agent)
+		}
+	}
+
+	preview, err = r.reconcilePreview(ctx, agent, desired)
+	if err != nil {
+		return true, err
+	}
+	if !deploymentReady(preview) {
+		rollout.Phase = aiv1.RolloutProgressing
+		rollout.Message = fmt.Sprintf("Waiting for preview pods of revision %s (%d/%d ready)", revision, preview.Status.ReadyReplicas, *preview.Spec.Replicas)
+		return true, nil
+	}
+
+	promote := annotationSet(agent, promoteAnnotation)
+	if !promote && (agent.Spec.Rollout.BlueGreen == nil || !agent.Spec.Rollout.BlueGreen.AutoPromote) {
+		rollout.Phase = aiv1.RolloutPaused
+		rollout.Message = fmt.Sprintf("Preview revision %s is ready for promotion", revision)
+		return true, nil
+	}
+	if promote {
+		if err := r.clearAnnotation(ctx, agent, promoteAnnotation); err != nil {
+			return true, err
+		}
+	}
+
+	log.FromContext(ctx).Info("Promoting blue/green preview", "revision", revision, "previousRevision", stableRevision)
+	now := metav1.Now()
+	rollout.ActiveRevision = revision
+	rollout.PromotionTime = &now
+	rollout.Phase = aiv1.RolloutPromoted
+	rollout.Message = fmt.Sprintf("Switched traffic to revision %s; revision %s is scaled down in %s", revision, stableRevision, scaleDownDelay(agent))
+	return true, nil
+}
+
+// finishBlueGreenPromotion moves the stable Deployment to the promoted template once the
+// scale-down delay has passed, and removes the preview when the stable pods are ready.
+// Until the stable Deployment is changed, aborting switches the traffic back to it.
+func (r *AgentReconciler) finishBlueGreenPromotion(ctx context.Context, agent *aiv1.Agent, preview, found *appsv1.Deployment) error {
+	rollout := agent.Status.Rollout
+	stableRevision := found.Annotations[templateHashAnnotation]
+
+	if stableRevision != rollout.ActiveRevision {
+		if annotationSet(agent, abortAnnotation) {
+			if err := r.clearAnnotation(ctx, agent, abortAnnotation); err != nil {
+				return err
+			}
+			log.FromContext(ctx).Info("Rolling back blue/green promotion", "revision", rollout.ActiveRevision, "stableRevision", stableRevision)
+			rollout.Phase = aiv1.RolloutAborted
+			rollout.Message = fmt.Sprintf("Rolled back to revision %s", stableRevision)
+			rollout.ActiveRevision = stableRevision
+			rollout.PromotionTime = nil
+			// The Service is switched back before the preview pods go away.
+			if err := r.reconcileService(ctx, agent); err != nil {
+				return err
+			}
+			return r.deletePreview(ctx, agent)
+		}
+
+		if rollout.PromotionTime != nil && time.Since(rollout.PromotionTime.Time) < scaleDownDelay(agent) {
+			return nil
+		}
+
+		log.FromContext(ctx).Info("Scaling down previous blue/green revision", "revision", stableRevision)
+		if found.Annotations == nil {
+			found.Annotations = map[string]string{}
+		}
+		found.Annotations[templateHashAnnotation] = rollout.ActiveRevision
+		found.Spec.Template = preview.Spec.Template
+		found.Spec.Replicas = preview.Spec.Replicas
+		return r.Update(ctx, found)
+	}
+
+	if !deploymentReady(found) {
+		rollout.Message = fmt.Sprintf("Waiting for the stable Deployment to run revision %s (%d/%d ready)", rollout.ActiveRevision, found.Status.ReadyReplicas, *found.Spec.Replicas)
+		return nil
+	}
+	rollout.Message = fmt.Sprintf("Revision %s is active", rollout.ActiveRevision)
+	rollout.PreviewRevision = ""
+	rollout.PromotionTime = nil
+	return r.deletePreview(ctx, agent)
+}
+
+// reconcilePreview creates or updates the preview Deployment running the desired template
+// and the Service exposing it for pre-flight testing, and returns the Deployment.
+func (r *AgentReconciler) reconcilePreview(ctx context.Context, agent *aiv1.Agent, desired *appsv1.Deployment) (*appsv1.Deployment, error) {
+	revision := desired.Annotations[templateHashAnnotation]
+	deployment := desired.DeepCopy()
+	deployment.Name = previewName(agent)
+	deployment.ResourceVersion = ""
+	selector := map[string]string{revisionLabel: revision}
+	for k, v := range desired.Spec.Selector.MatchLabels {
+		selector[k] = v
+	}
+	deployment.Labels = selector
+	deployment.Spec.Selector = &metav1.LabelSelector{MatchLabels: selector}
+	if err := controllerutil.SetControllerReference(agent, deployment, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	service := r.buildService(agent)
+	service.Name = previewName(agent)
+	service.Spec.Type = corev1.ServiceTypeClusterIP
+	service.Spec.Selector = selector
+	if err := controllerutil.SetControllerReference(agent, service, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	foundService := &corev1.Service{}
+	err := r.Get(ctx, types.NamespacedName{Name: service.Name, Namespace: service.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new preview Service", "Service.Namespace", service.Namespace, "Service.Name", service.Name)
+		if err := r.Create(ctx, service); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	} else if foundService.Spec.Selector[revisionLabel] != revision {
+		log.FromContext(ctx).Info("Updating existing preview Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
+		foundService.Spec.Selector = service.Spec.Selector
+		if err := r.Update(ctx, foundService); err != nil {
+			return nil, err
+		}
+	}
+
+	found := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new preview Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
+		return deployment, r.Create(ctx, deployment)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if found.Annotations[templateHashAnnotation] == revision && *found.Spec.Replicas == *deployment.Spec.Replicas {
+		return found, nil
+	}
+	if found.Spec.Selector.MatchLabels[revisionLabel] != revision {
+		// The selector of a Deployment is immutable; a preview of another revision is replaced.
+		log.FromContext(ctx).Info("Replacing preview Deployment", "Deployment.Name", found.Name, "revision", revision)
+		if err := r.Delete(ctx, found); err != nil && !errors.IsNotFound(err) {
+			return nil, err
+		}
+		return deployment, r.Create(ctx, deployment)
+	}
+	log.FromContext(ctx).Info("Updating existing preview Deployment", "Deployment.Namespace", found.Namespace, "Deployment.Name", found.Name)
+	found.Annotations = deployment.Annotations
+	found.Spec = deployment.Spec
+	return found, r.Update(ctx, found)
+}
+
+// deletePreview removes the preview Deployment and Service, if any.
+func (r *AgentReconciler) deletePreview(ctx context.Context, agent *aiv1.Agent) error {
+	for _, obj := range []client.Object{
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: previewName(agent), Namespace: agent.Namespace}},
+		&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: previewName(agent), Namespace: agent.Namespace}},
+	} {
+		err := r.Get(ctx, client.ObjectKeyFromObject(obj), obj)
+		if err == nil {
+			log.FromContext(ctx).Info("Deleting preview resource", "Name", obj.GetName())
+			err = r.Delete(ctx, obj)
+		}
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// blueGreenRequeueAfter shortens the periodic requeue so that the previous revision is
+// scaled down on time after a promotion.
+func blueGreenRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	rollout := agent.Status.Rollout
+	if rollout == nil || rollout.PromotionTime == nil || rollout.PreviewRevision == "" {
+		return requeue
+	}
+	remaining := time.Until(rollout.PromotionTime.Add(scaleDownDelay(agent)))
+	if remaining <= 0 {
+		return canaryReadyRequeue
+	}
+	if remaining < requeue {
+		return remaining
+	}
+	return requeue
+}
@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+)
+
+const (
+	// Actions in effect while the budget of an agent is exhausted.
+	budgetActionAlerted   = "Alerted"
+	budgetActionThrottled = "Throttled"
+	budgetActionSuspended = "Suspended"
+
+	// budgetExceededReason is the reason of the Degraded condition and Events of an exhausted budget.
+	budgetExceededReason = "BudgetExceeded"
+
+	defaultThrottleRequestsPerMinute = 10
+)
+
+// Budget spend, exposed on the operator metrics endpoint.
+var (
+	budgetSpend = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_budget_spend",
+		Help: "Cost of the tokens consumed by the agent in the current budget period.",
+	}, []string{"namespace", "agent", "currency"})
+	budgetProjectedSpend = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_budget_projected_spend",
+		Help: "Spend of the agent extrapolated to the end of the current budget period.",
+	}, []string{"namespace", "agent", "currency"})
+	budgetAmount = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_budget_amount",
+		Help: "Spend allowed per budget period for the agent.",
+	}, []string{"namespace", "agent", "currency"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(budgetSpend, budgetProjectedSpend, budgetAmount)
+}
+
+// budgetPeriodBounds returns the start and end of the budget period containing now.
+func budgetPeriodBounds(period string, now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	switch period {
+	case "daily":
+		return day, day.AddDate(0, 0, 1)
+	case "weekly":
+		// Weeks start on Monday
+		start := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+		return start, start.AddDate(0, 0, 7)
+	default:
+		start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0)
+	}
+}
+
+// budgetAction returns the action in effect while the budget is exhausted.
+func budgetAction(agent *aiv1.Agent) string {
+	switch agent.Spec.Budget.Action {
+	case "throttle":
+		return budgetActionThrottled
+	case "suspend":
+		return budgetActionSuspended
+	default:
+		return budgetActionAlerted
+	}
+}
+
+// budgetActionName returns the spec name of an action in effect, as used in messages.
+func budgetActionName(action string) string {
+	return map[string]string{
+		budgetActionAlerted:   "alert",
+		budgetActionThrottled: "throttle",
+		budgetActionSuspended: "suspend",
+	}[action]
+}
+
+// budgetCurrency returns the currency of the agent budget.
+func budgetCurrency(agent *aiv1.Agent) string {
+	if agent.Spec.Budget.Currency != "" {
+		return agent.Spec.Budget.Currency
+	}
+	return pricing.Currency
+}
+
+// budgetActionTaken reports whether action is in effect for the agent.
+func budgetActionTaken(agent *aiv1.Agent, action string) bool {
+	return agent.Spec.Budget != nil && agent.Status.Budget != nil && agent.Status.Budget.ActionTaken == action
+}
+
+// budgetSuspended reports whether the agent is scaled to zero because its budget is exhausted.
+func budgetSuspended(agent *aiv1.Agent) bool {
+	return budgetActionTaken(agent, budgetActionSuspended)
+}
+
+// budgetEnv returns the environment variable limiting the requests of each pod while the
+// budget of the agent is exhausted with the throttle action.
+func budgetEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !budgetActionTaken(agent, budgetActionThrottled) {
+		return nil
+	}
+	limit := int32(defaultThrottleRequestsPerMinute)
+	if agent.Spec.Budget.ThrottleRequestsPerMinute != nil {
+		limit = *agent.Spec.Budget.ThrottleRequestsPerMinute
+	}
+	return []corev1.EnvVar{{Name: "AGENT_MAX_REQUESTS_PER_MINUTE", Value: fmt.Sprintf("%d", limit)}}
+}
+
+// validateBudgetConfig validates the budget configuration.
+func (r *AgentReconciler) validateBudgetConfig(agent *aiv1.Agent) error {
+	budget := agent.Spec.Budget
+	if budget == nil {
+		return nil
+	}
+	if _, err := strconv.ParseFloat(budget.Amount, 64); err != nil {
+		return fmt.Errorf("budget.amount %q is not a number", budget.Amount)
+	}
+	if currency := budgetCurrency(agent); currency != pricing.Currency {
+		return fmt.Errorf("budget.currency %s does not match the pricing currency %s", currency, pricing.Currency)
+	}
+	return nil
+}
+
+// reconcileBudget adds the cost of the tokens consumed since the last update to the spend
+// of the current period and takes or lifts the budget action. Each pod is charged for the
+// increase of its token counters, so the counters seen last are kept in the status; the
+// first update only records them, counting tokens from when the budget is set.
+func (r *AgentReconciler) reconcileBudget(ctx context.Context, agent *aiv1.Agent) error {
+	previous := agent.Status.Budget
+	if agent.Spec.Budget == nil {
+		if previous != nil && previous.ActionTaken != "" {
+			r.recordEvent(agent, corev1.EventTypeNormal, "BudgetRestored", fmt.Sprintf("Budget removed, the %s action is lifted", budgetActionName(previous.ActionTaken)))
+		}
+		agent.Status.Budget = nil
+		r.resolveDegradedCondition(agent, budgetExceededReason, "BudgetRemoved", "The agent has no budget")
+		deleteBudgetMetrics(agent)
+		return nil
+	}
+
+	now := time.Now()
+	start, end := budgetPeriodBounds(agent.Spec.Budget.Period, now)
+	status := &aiv1.BudgetStatus{PeriodStart: metav1.NewTime(start), Spend: "0"}
+	previousAction := ""
+	if previous != nil {
+		previousAction = previous.ActionTaken
+		status.PodCounters = previous.PodCounters
+		if previous.PeriodStart.Time.Equal(start) {
+			status.Spend = previous.Spend
+			status.PromptTokens, status.CompletionTokens = previous.PromptTokens, previous.CompletionTokens
+			status.Message = previous.Message
+		}
+	}
+
+	spend, _ := strconv.ParseFloat(status.Spend, 64)
+	counters, err := r.scrapeTokenCounters(ctx, agent, status.PodCounters)
+	if err != nil {
+		return err
+	}
+	if previous != nil {
+		seen := map[string]aiv1.PodTokenCounters{}
+		for _, c := range status.PodCounters {
+			seen[c.Pod] = c
+		}
+		var prompt, completion int64
+		for _, c := range counters {
+			last, ok := seen[c.Pod]
+			if !ok || c.PromptTokens < last.PromptTokens || c.CompletionTokens < last.CompletionTokens {
+				// A new or restarted pod is charged for all its tokens
+				last = aiv1.PodTokenCounters{}
+			}
+			prompt += c.PromptTokens - last.PromptTokens
+			completion += c.CompletionTokens - last.CompletionTokens
+		}
+		cost, err := pricing.CostFor(agent.Spec.Provider, agent.Spec.Model, prompt, completion)
+		if errors.Is(err, pricing.ErrUnknownModel) {
+			if prompt+completion > 0 && status.Message == "" {
+				r.recordEvent(agent, corev1.EventTypeWarning, "UnknownPricing", fmt.Sprintf("Tokens of %s/%s are not counted in the budget: %v", agent.Spec.Provider, agent.Spec.Model, err))
+			}
+			if prompt+completion > 0 {
+				status.Message = fmt.Sprintf("Tokens of %s/%s are not counted: %v", agent.Spec.Provider, agent.Spec.Model, err)
+			}
+		} else if err != nil {
+			return err
+		}
+		spend += cost
+		status.PromptTokens += prompt
+		status.CompletionTokens += completion
+	}
+	status.PodCounters = counters
+
+	amount, _ := strconv.ParseFloat(agent.Spec.Budget.Amount, 64)
+	projected := spend
+	if elapsed := now.Sub(start); elapsed >= time.Hour {
+		projected = spend * float64(end.Sub(start)) / float64(elapsed)
+	}
+	currency := budgetCurrency(agent)
+	status.Spend = strconv.FormatFloat(spend, 'f', 4, 64)
+	status.ProjectedSpend = strconv.FormatFloat(projected, 'f', 2, 64)
+	status.Currency = currency
+	status.ResetTime = metav1.NewTime(end)
+	status.TimeUntilReset = end.Sub(now).Round(time.Minute).String()
+
+	action := ""
+	if spend >= amount {
+		action = budgetAction(agent)
+	}
+	if action != previousAction {
+		spent := fmt.Sprintf("Spent %.2f of %s %s in the %s period", spend, agent.Spec.Budget.Amount, currency, budgetPeriod(agent))
+		if action != "" {
+			log.FromContext(ctx).Info("Budget exhausted", "spend", status.Spend, "action", action)
+			r.recordEvent(agent, corev1.EventTypeWarning, budgetExceededReason, fmt.Sprintf("%s, taking the %s action", spent, budgetActionName(action)))
+		} else {
+			log.FromContext(ctx).Info("Budget available again", "spend", status.Spend, "liftedAction", previousAction)
+			r.recordEvent(agent, corev1.EventTypeNormal, "BudgetRestored", fmt.Sprintf("%s, the %s action is lifted", spent, budgetActionName(previousAction)))
+		}
+	}
+	status.ActionTaken = action
+	agent.Status.Budget = status
+
+	if action != "" {
+		r.setFailedCondition(agent, aiv1.AgentConditionDegraded, budgetExceededReason,
+			fmt.Sprintf("Spent %s of %s %s, the %s action applies until %s", status.Spend, agent.Spec.Budget.Amount, currency, budgetActionName(action), end.Format(time.RFC3339)))
+	} else {
+		r.resolveDegradedCondition(agent, budgetExceededReason, "BudgetAvailable", "The spend is below the budget")
+	}
+
+	budgetSpend.WithLabelValues(agent.Namespace, agent.Name, currency).Set(spend)
+	budgetProjectedSpend.WithLabelValues(agent.Namespace, agent.Name, currency).Set(projected)
+	budgetAmount.WithLabelValues(agent.Namespace, agent.Name, currency).Set(amount)
+	return nil
+}
+
+// budgetPeriod returns the budget period of the agent.
+func budgetPeriod(agent *aiv1.Agent) string {
+	if agent.Spec.Budget.Period != "" {
+		return agent.Spec.Budget.Period
+	}
+	return "monthly"
+}
+
+// scrapeTokenCounters returns the token counters of the running agent pods. Pods that
+// cannot be scraped keep their last counters, to be charged at the next update.
+func (r *AgentReconciler) scrapeTokenCounters(ctx context.Context, agent *aiv1.Agent, last []aiv1.PodTokenCounters) ([]aiv1.PodTokenCounters, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return nil, err
+	}
+	token, err := r.endpointToken(ctx, agent)
+	if err != nil {
+		return nil, err
+	}
+
+	lastByPod := map[string]aiv1.PodTokenCounters{}
+	for _, c := range last {
+		lastByPod[c.Pod] = c
+	}
+	scraper := r.metricsScraper()
+	var counters []aiv1.PodTokenCounters
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			if c, ok := lastByPod[pod.Name]; ok {
+				counters = append(counters, c)
+			}
+			continue
+		}
+		sample, err := scraper.Scrape(ctx, fmt.Sprintf("http://%s:8080", pod.Status.PodIP), token)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Failed to scrape pod token counters", "Pod.Name", pod.Name, "error", err.Error())
+			if c, ok := lastByPod[pod.Name]; ok {
+				counters = append(counters, c)
+			}
+			continue
+		}
+		counters = append(counters, aiv1.PodTokenCounters{
+			Pod:              pod.Name,
+			PromptTokens:     int64(sample.PromptTokens),
+			CompletionTokens: int64(sample.CompletionTokens),
+		})
+	}
+	return counters, nil
+}
+
+// setUsageCost prices the token usage of the agent. Embedding tokens are priced with the
+// dedicated embeddings model; without one, they are left out of the cost.
+func setUsageCost(agent *aiv1.Agent) {
+	usage := agent.Status.Usage
+	if usage == nil {
+		return
+	}
+	usage.EstimatedCost, usage.Currency = "", ""
+	cost, err := pricing.CostFor(agent.Spec.Provider, agent.Spec.Model, usage.PromptTokens, usage.CompletionTokens)
+	if err != nil {
+		return
+	}
+	if embeddings := effectiveEmbeddings(agent); embeddings != nil && usage.EmbeddingTokens > 0 {
+		embeddingCost, err := pricing.CostFor(embeddings.Provider, embeddings.Model, usage.EmbeddingTokens, 0)
+		if err != nil {
+			return
+		}
+		cost += embeddingCost
+	}
+	usage.EstimatedCost = strconv.FormatFloat(cost, 'f', 4, 64)
+	usage.Currency = pricing.Currency
+}
+
+// deleteBudgetMetrics removes the budget spend of the agent.
+func deleteBudgetMetrics(agent *aiv1.Agent) {
+	labels := prometheus.Labels{"namespace": agent.Namespace, "agent": agent.Name}
+	budgetSpend.DeletePartialMatch(labels)
+	budgetProjectedSpend.DeletePartialMatch(labels)
+	budgetAmount.DeletePartialMatch(labels)
+}
+
+// budgetRequeueAfter shortens requeue to the end of the budget period, to lift the budget
+// action on time.
+func budgetRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if agent.Spec.Budget == nil || agent.Status.Budget == nil {
+		return requeue
+	}
+	if untilReset := time.Until(agent.Status.Budget.ResetTime.Time); untilReset < requeue {
+		if untilReset < time.Second {
+			return time.Second
+		}
+		return untilReset
+	}
+	return requeue
+}
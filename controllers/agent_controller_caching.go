@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+)
+
+const (
+	defaultCacheTTL                 = time.Hour
+	defaultCacheMaxEntries          = 1000
+	defaultCacheSimilarityThreshold = "0.95"
+)
+
+// providersServingEmbeddings lists the providers whose OpenAI-compatible embeddings API the
+// runtime calls to embed prompts for the semantic cache.
+var providersServingEmbeddings = map[string]bool{"openai": true, "vllm": true, "ollama": true}
+
+// cachingEnabled reports whether the agent answers repeated prompts from its cache.
+func cachingEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Caching != nil && agent.Spec.Caching.Enabled
+}
+
+// cacheBackend returns where the response cache is kept, defaulting to memory.
+func cacheBackend(agent *aiv1.Agent) string {
+	if agent.Spec.Caching.Backend == "" {
+		return "memory"
+	}
+	return agent.Spec.Caching.Backend
+}
+
+// cacheKeyStrategy returns how prompts are matched, defaulting to exact.
+func cacheKeyStrategy(agent *aiv1.Agent) string {
+	if agent.Spec.Caching.KeyStrategy == "" {
+		return "exact"
+	}
+	return agent.Spec.Caching.KeyStrategy
+}
+
+// managedCacheRedis reports whether the response cache of the agent is kept in the Redis
+// the operator deploys, as the memory of the agent is not in a Redis of its own.
+func managedCacheRedis(agent *aiv1.Agent) bool {
+	return cachingEnabled(agent) && cacheBackend(agent) == "redis" &&
+		!(memoryBackend(agent) == "redis" && agent.Spec.Memory.ConnectionSecretRef != nil)
+}
+
+// cacheEmbeddings returns the embeddings model of the semantic cache: the one of the cache,
+// defaulted to the provider and secret of the agent, or the one of RAG.
+func cacheEmbeddings(agent *aiv1.Agent) *aiv1.EmbeddingsConfig {
+	if agent.Spec.Caching.Embeddings == nil {
+		return effectiveEmbeddings(agent)
+	}
+	embeddings := agent.Spec.Caching.Embeddings.DeepCopy()
+	if embeddings.Provider == "" {
+		embeddings.Provider = agent.Spec.Provider
+		if embeddings.Endpoint == "" {
+			embeddings.Endpoint = primaryEndpoint(agent)
+		}
+	}
+	if embeddings.ApiSecretRef == nil && agent.Spec.ApiSecretRef != nil {
+		embeddings.ApiSecretRef = agent.Spec.ApiSecretRef.DeepCopy()
+	}
+	return embeddings
+}
+
+// validateCachingConfig validates the response cache. The replicas of an agent would each
+// keep their own memory cache, so several replicas require the shared redis backend.
+func (r *AgentReconciler) validateCachingConfig(agent *aiv1.Agent) error {
+	if !cachingEnabled(agent) {
+		return nil
+	}
+	caching := agent.Spec.Caching
+	if caching.TTL != nil && caching.TTL.Duration <= 0 {
+		return fmt.Errorf("caching.ttl must be positive")
+	}
+	if caching.MaxEntries != nil && *caching.MaxEntries < 1 {
+		return fmt.Errorf("caching.maxEntries must be at least 1")
+	}
+	if agent.Spec.Replicas != nil && *agent.Spec.Replicas > 1 && cacheBackend(agent) != "redis" {
+		return fmt.Errorf("caching.backend must be redis for agents with %d replicas, which would each keep their own cache", *agent.Spec.Replicas)
+	}
+	if caching.SimilarityThreshold != "" {
+		threshold, err := strconv.ParseFloat(caching.SimilarityThreshold, 64)
+		if err != nil || threshold <= 0 || threshold > 1 {
+			return fmt.Errorf("caching.similarityThreshold %q must be a number between 0 and 1", caching.SimilarityThreshold)
+		}
+	}
+	if cacheKeyStrategy(agent) == "semantic" {
+		embeddings := cacheEmbeddings(agent)
+		if embeddings == nil {
+			return fmt.Errorf("caching.embeddings is required for the semantic key strategy without rag.embeddings")
+		}
+		if !providersServingEmbeddings[embeddings.Provider] {
+			return fmt.Errorf("caching.embeddings.provider %s has no OpenAI-compatible embeddings API, use openai, vllm or ollama", embeddings.Provider)
+		}
+		if providersRequiringEndpoint[embeddings.Provider] && embeddings.Endpoint == "" {
+			return fmt.Errorf("caching.embeddings.endpoint is required for provider %s", embeddings.Provider)
+		}
+	}
+	return nil
+}
+
+// cachingEnv returns the AGENT_CACHE_* environment variables configuring the response cache
+// of the runtime.
+func cachingEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !cachingEnabled(agent) {
+		return nil
+	}
+	caching := agent.Spec.Caching
+	ttl := defaultCacheTTL
+	if caching.TTL != nil {
+		ttl = caching.TTL.Duration
+	}
+	maxEntries := int32(defaultCacheMaxEntries)
+	if caching.MaxEntries != nil {
+		maxEntries = *caching.MaxEntries
+	}
+	env := []corev1.EnvVar{
+		{Name: "AGENT_CACHE_ENABLED", Value: "true"},
+		{Name: "AGENT_CACHE_BACKEND", Value: cacheBackend(agent)},
+		{Name: "AGENT_CACHE_TTL_SECONDS", Value: strconv.Itoa(int(ttl.Seconds()))},
+		{Name: "AGENT_CACHE_MAX_ENTRIES", Value: strconv.Itoa(int(maxEntries))},
+		{Name: "AGENT_CACHE_KEY_STRATEGY", Value: cacheKeyStrategy(agent)},
+	}
+
+	// The cache reuses the Redis of the conversation memory, or the one the operator deploys
+	if cacheBackend(agent) == "redis" {
+		if managedCacheRedis(agent) {
+			env = append(env, corev1.EnvVar{
+				Name:  "AGENT_CACHE_URL",
+				Value: fmt.Sprintf("redis://%s.%s.svc:6379/0", managedRedisName(agent), agent.Namespace),
+			})
+		} else {
+			env = append(env, corev1.EnvVar{
+				Name:      "AGENT_CACHE_URL",
+				ValueFrom: &corev1.EnvVarSource{SecretKeyRef: agent.Spec.Memory.ConnectionSecretRef},
+			})
+		}
+	}
+
+	if cacheKeyStrategy(agent) == "semantic" {
+		threshold := caching.SimilarityThreshold
+		if threshold == "" {
+			threshold = defaultCacheSimilarityThreshold
+		}
+		env = append(env, corev1.EnvVar{Name: "AGENT_CACHE_SIMILARITY_THRESHOLD", Value: threshold})
+		if embeddings := cacheEmbeddings(agent); embeddings != nil {
+			env = append(env,
+				corev1.EnvVar{Name: "AGENT_CACHE_EMBEDDINGS_PROVIDER", Value: embeddings.Provider},
+				corev1.EnvVar{Name: "AGENT_CACHE_EMBEDDINGS_MODEL", Value: embeddings.Model},
+			)
+			if embeddings.ApiSecretRef != nil {
+				env = append(env, corev1.EnvVar{
+					Name: "AGENT_CACHE_EMBEDDINGS_API_KEY",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: localSecretKeySelector(agent, embeddings.ApiSecretRef),
+					},
+				})
+			}
+			if embeddings.Endpoint != "" {
+				env = append(env, corev1.EnvVar{Name: "AGENT_CACHE_EMBEDDINGS_ENDPOINT", Value: embeddings.Endpoint})
+			}
+		}
+	}
+	return env
+}
+
+// reconcileCacheUsage adds the cache hits and misses of the agent pods since the last update
+// to status.usage, with the cost of the tokens the hits saved. Like the budget, each pod is
+// counted for the increase of its counters, which are kept in the status.
+func (r *AgentReconciler) reconcileCacheUsage(ctx context.Context, agent *aiv1.Agent) error {
+	usage := agent.Status.Usage
+	if !cachingEnabled(agent) {
+		if usage != nil {
+			usage.CacheHits, usage.CacheMisses, usage.CacheSavedTokens = 0, 0, 0
+			usage.EstimatedSavings, usage.CachePodCounters = "", nil
+		}
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return err
+	}
+	token, err := r.endpointToken(ctx, agent)
+	if err != nil {
+		return err
+	}
+	if usage == nil {
+		usage = &aiv1.UsageStatus{}
+		agent.Status.Usage = usage
+	}
+
+	last := map[string]aiv1.PodCacheCounters{}
+	for _, c := range usage.CachePodCounters {
+		last[c.Pod] = c
+	}
+	scraper := r.metricsScraper()
+	var counters []aiv1.PodCacheCounters
+	var savedPrompt, savedCompletion int64
+	for _, pod := range pods.Items {
+		previous, seen := last[pod.Name]
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			if seen {
+				counters = append(counters, previous)
+			}
+			continue
+		}
+		sample, err := scraper.Scrape(ctx, fmt.Sprintf("http://%s:8080", pod.Status.PodIP), token)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Failed to scrape pod cache counters", "Pod.Name", pod.Name, "error", err.Error())
+			if seen {
+				counters = append(counters, previous)
+			}
+			continue
+		}
+		current := aiv1.PodCacheCounters{
+			Pod:                   pod.Name,
+			Hits:                  int64(sample.CacheHits),
+			Misses:                int64(sample.CacheMisses),
+			SavedPromptTokens:     int64(sample.CacheSavedPromptTokens),
+			SavedCompletionTokens: int64(sample.CacheSavedCompletionTokens),
+		}
+		if current.Hits < previous.Hits || current.Misses < previous.Misses {
+			// A restarted pod counts from zero again
+			previous = aiv1.PodCacheCounters{}
+		}
+		usage.CacheHits += current.Hits - previous.Hits
+		usage.CacheMisses += current.Misses - previous.Misses
+		savedPrompt += current.SavedPromptTokens - previous.SavedPromptTokens
+		savedCompletion += current.SavedCompletionTokens - previous.SavedCompletionTokens
+		counters = append(counters, current)
+	}
+	usage.CachePodCounters = counters
+	usage.CacheSavedTokens += savedPrompt + savedCompletion
+
+	savings, err := pricing.CostFor(agent.Spec.Provider, agent.Spec.Model, savedPrompt, savedCompletion)
+	if errors.Is(err, pricing.ErrUnknownModel) {
+		usage.EstimatedSavings = ""
+		return nil
+	} else if err != nil {
+		return err
+	}
+	previousSavings, _ := strconv.ParseFloat(usage.EstimatedSavings, 64)
+	usage.EstimatedSavings = strconv.FormatFloat(previousSavings+savings, 'f', 4, 64)
+	usage.Currency = pricing.Currency
+	return nil
+}
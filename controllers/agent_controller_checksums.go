@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// podTemplateChecksum is a pod template annotation holding the checksum of what the agent
+// pods read at startup, so that the pods roll when it changes.
+type podTemplateChecksum struct {
+	// annotation is the pod template annotation the checksum is set as.
+	annotation string
+	// checksum returns the checksum, or an empty string when the agent does not use what
+	// it covers.
+	checksum func(ctx context.Context, agent *aiv1.Agent) (string, error)
+}
+
+// podTemplateChecksums returns the checksums annotating the pod template of the agents.
+func (r *AgentReconciler) podTemplateChecksums() []podTemplateChecksum {
+	return []podTemplateChecksum{
+		// The vector store connection secret is rotated
+		{vectorStoreChecksumAnnotation, r.vectorStoreChecksum},
+		// The endpoint token is rotated
+		{endpointKeyChecksumAnnotation, r.endpointKeyChecksum},
+		// The admin token is generated again
+		{adminKeyChecksumAnnotation, r.adminKeyChecksum},
+		// Either encryption key changes
+		{encryptionKeyChecksumAnnotation, r.encryptionKeyChecksum},
+		// A peer moves or rotates its endpoint token
+		{peersChecksumAnnotation, r.peersChecksum},
+		// A connector changes or its credentials are rotated
+		{connectorsChecksumAnnotation, r.connectorsChecksum},
+		// The allowlist or the timeout of the tool executor changes
+		{toolExecutorChecksumAnnotation, func(_ context.Context, agent *aiv1.Agent) (string, error) {
+			return toolExecutorChecksum(agent)
+		}},
+		// The guardrails policy changes
+		{guardrailsChecksumAnnotation, r.guardrailsChecksum},
+		// The few-shot examples change
+		{examplesChecksumAnnotation, r.examplesChecksum},
+		// The shared secret of the transcript sink is rotated
+		{transcriptSinkChecksumAnnotation, func(ctx context.Context, agent *aiv1.Agent) (string, error) {
+			_, checksum, err := r.transcriptSinkSecret(ctx, agent)
+			return checksum, err
+		}},
+	}
+}
+
+// annotateChecksums sets the podTemplateChecksums of the agent on the pod template of
+// deployment. The checksums the agent does not use are left unset.
+func (r *AgentReconciler) annotateChecksums(ctx context.Context, agent *aiv1.Agent, deployment *appsv1.Deployment) error {
+	for _, annotation := range r.podTemplateChecksums() {
+		checksum, err := annotation.checksum(ctx, agent)
+		if err != nil {
+			return err
+		}
+		if checksum == "" {
+			continue
+		}
+		if deployment.Spec.Template.Annotations == nil {
+			deployment.Spec.Template.Annotations = map[string]string{}
+		}
+		deployment.Spec.Template.Annotations[annotation.annotation] = checksum
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// InFlightRequestsMetric is the gauge of the chat requests each agent pod handles, which the
+// HorizontalPodAutoscaler of the agent scales on with spec.concurrency.targetUtilization.
+const InFlightRequestsMetric = "kubeagentic_inflight_requests"
+
+// validateConcurrencyConfig checks the bounds of spec.concurrency that the CRD schema does not
+// enforce on Agents written before it had them.
+func validateConcurrencyConfig(agent *aiv1.Agent) error {
+	config := agent.Spec.Concurrency
+	if config == nil {
+		return nil
+	}
+	if config.MaxConcurrentRequests < 1 {
+		return fmt.Errorf("concurrency.maxConcurrentRequests must be at least 1")
+	}
+	if config.QueueLength < 0 {
+		return fmt.Errorf("concurrency.queueLength must not be negative")
+	}
+	if code := config.RejectStatusCode; code != 0 && code != 429 && code != 503 {
+		return fmt.Errorf("concurrency.rejectStatusCode must be 429 or 503, not %d", code)
+	}
+	if config.TargetUtilization != nil && (*config.TargetUtilization < 1 || *config.TargetUtilization > 100) {
+		return fmt.Errorf("concurrency.targetUtilization must be between 1 and 100")
+	}
+	return nil
+}
+
+// concurrencyEnv returns the environment variables bounding the chat requests the runtime
+// handles at once.
+func concurrencyEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	config := agent.Spec.Concurrency
+	if config == nil {
+		return nil
+	}
+	rejectStatusCode := config.RejectStatusCode
+	if rejectStatusCode == 0 {
+		rejectStatusCode = 503
+	}
+	return []corev1.EnvVar{
+		{Name: "AGENT_MAX_CONCURRENT_REQUESTS", Value: strconv.Itoa(int(config.MaxConcurrentRequests))},
+		{Name: "AGENT_REQUEST_QUEUE_LENGTH", Value: strconv.Itoa(int(config.QueueLength))},
+		{Name: "AGENT_REJECT_STATUS_CODE", Value: strconv.Itoa(int(rejectStatusCode))},
+	}
+}
+
+// readinessPath returns the path of the readiness probe of the agent container. With
+// backpressure readiness the runtime also reports the pod unready while all its request slots
+// are taken, which removes it from the Service endpoints until it drains.
+func readinessPath(agent *aiv1.Agent) string {
+	if config := agent.Spec.Concurrency; config != nil && config.BackpressureReadiness {
+		return "/ready?backpressure=true"
+	}
+	return "/ready"
+}
+
+// inFlightMetrics returns the HorizontalPodAutoscaler metric keeping the average requests in
+// flight of the agent pods at spec.concurrency.targetUtilization of maxConcurrentRequests.
+func inFlightMetrics(agent *aiv1.Agent) []autoscalingv2.MetricSpec {
+	config := agent.Spec.Concurrency
+	if config == nil || config.TargetUtilization == nil {
+		return nil
+	}
+	target := resource.NewMilliQuantity(int64(config.MaxConcurrentRequests)*int64(*config.TargetUtilization)*10, resource.DecimalSI)
+	return []autoscalingv2.MetricSpec{{
+		Type: autoscalingv2.PodsMetricSourceType,
+		Pods: &autoscalingv2.PodsMetricSource{
+			Metric: autoscalingv2.MetricIdentifier{Name: InFlightRequestsMetric},
+			Target: autoscalingv2.MetricTarget{
+				Type:         autoscalingv2.AverageValueMetricType,
+				AverageValue: target,
+			},
+		},
+	}}
+}
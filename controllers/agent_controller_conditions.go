@@ -0,0 +1,145 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// readinessConditions are the conditions that must all be True for the agent to be Ready,
+// in the order their problems are reported on the Ready condition.
+var readinessConditions = []aiv1.AgentConditionType{
+	aiv1.AgentConditionSecretValid,
+	aiv1.AgentConditionConfigValid,
+	aiv1.AgentConditionPrerequisitesReady,
+	aiv1.AgentConditionDeploymentReady,
+	aiv1.AgentConditionServiceReady,
+}
+
+// failedStepReasons are the reasons of the Degraded condition set when a reconcile step
+// without a condition of its own fails. They are resolved once a reconcile succeeds.
+var failedStepReasons = map[string]bool{
+	"RevisionsFailed":           true,
+	"RedisFailed":               true,
+	"EndpointAuthFailed":        true,
+	"AdminAuthFailed":           true,
+	"SecretSyncFailed":          true,
+	"FleetRolloutFailed":        true,
+	"BudgetFailed":              true,
+	"TokenQuotaFailed":          true,
+	"CacheUsageFailed":          true,
+	"VectorStoreCheckFailed":    true,
+	"IngestionFailed":           true,
+	"ExportFailed":              true,
+	"SyntheticProbeFailed":      true,
+	"WarmupCheckFailed":         true,
+	"DependencyCheckFailed":     true,
+	"PrerequisiteCheckFailed":   true,
+	"PeerResolutionFailed":      true,
+	"PlacementResolutionFailed": true,
+	"ConnectorCheckFailed":      true,
+	"EventSourceCheckFailed":    true,
+	"EventSourceScalingFailed":  true,
+	"HealthCheckFailed":         true,
+	"LimitCheckFailed":          true,
+	"ReplicaLimitCheckFailed":   true,
+	"AutoscalingCeilingFailed":  true,
+	"BindingFailed":             true,
+}
+
+// conditionError is an error carrying the reason reported on the condition it fails.
+type conditionError struct {
+	reason string
+	err    error
+}
+
+func (e *conditionError) Error() string {
+	return e.err.Error()
+}
+
+func (e *conditionError) Unwrap() error {
+	return e.err
+}
+
+// withReason annotates err with the reason of the condition it fails.
+func withReason(reason string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &conditionError{reason: reason, err: err}
+}
+
+// conditionReason returns the reason carried by err, or fallback when it has none.
+func conditionReason(err error, fallback string) string {
+	var conditionErr *conditionError
+	if errors.As(err, &conditionErr) {
+		return conditionErr.reason
+	}
+	return fallback
+}
+
+// findCondition returns the condition of the given type, or nil when it is not set.
+func findCondition(conditions []aiv1.AgentCondition, conditionType aiv1.AgentConditionType) *aiv1.AgentCondition {
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
+
+// setCondition sets a condition of the agent, keeping its transition time when the status
+// does not change.
+func (r *AgentReconciler) setCondition(agent *aiv1.Agent, conditionType aiv1.AgentConditionType, status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, aiv1.AgentCondition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: &now,
+	})
+}
+
+// setFailedCondition sets the condition reporting a problem, False or, for Degraded, True,
+// and records the problem in the recent errors of the agent.
+func (r *AgentReconciler) setFailedCondition(agent *aiv1.Agent, conditionType aiv1.AgentConditionType, reason, message string) {
+	status := corev1.ConditionFalse
+	if conditionType == aiv1.AgentConditionDegraded {
+		status = corev1.ConditionTrue
+	}
+	r.setCondition(agent, conditionType, status, reason, message)
+	agent.Status.RecentErrors = r.ErrorHistory.Record(agent.Status.RecentErrors, r.clock().Now(), reason, message)
+}
+
+// setReadyCondition sets the Ready condition to True when all readiness conditions are True,
+// and otherwise to False with the reason and message of the first one that is not.
+func (r *AgentReconciler) setReadyCondition(agent *aiv1.Agent) {
+	for _, conditionType := range readinessConditions {
+		condition := findCondition(agent.Status.Conditions, conditionType)
+		if condition == nil {
+			r.setCondition(agent, aiv1.AgentConditionReady, corev1.ConditionFalse, fmt.Sprintf("%sUnknown", conditionType),
+				fmt.Sprintf("The %s condition has not been determined yet", conditionType))
+			return
+		}
+		if condition.Status != corev1.ConditionTrue {
+			r.setCondition(agent, aiv1.AgentConditionReady, corev1.ConditionFalse, condition.Reason, condition.Message)
+			return
+		}
+	}
+	r.setCondition(agent, aiv1.AgentConditionReady, corev1.ConditionTrue, "AgentReady", "The secret and configuration are valid, and the Deployment and Service are ready")
+}
+
+// resolveFailedStep sets the Degraded condition to False when it was set by a failed
+// reconcile step.
+func (r *AgentReconciler) resolveFailedStep(agent *aiv1.Agent) {
+	condition := findCondition(agent.Status.Conditions, aiv1.AgentConditionDegraded)
+	if condition == nil || condition.Status != corev1.ConditionTrue || !failedStepReasons[condition.Reason] {
+		return
+	}
+	r.setCondition(agent, aiv1.AgentConditionDegraded, corev1.ConditionFalse, "ReconciliationSucceeded", "All resources of the agent were reconciled")
+}
@@ -0,0 +1,403 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/connectors"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	// connectorsMountPath is where the connector configurations and credentials are mounted
+	// in the connector sidecar.
+	connectorsMountPath = "/etc/kubeagentic/connectors"
+	// connectorsChecksumAnnotation on the pod template rolls the agent pods when a connector
+	// changes or its credentials are rotated.
+	connectorsChecksumAnnotation = "kubeagentic.ai/connectors-checksum"
+	// connectorStatusPort is the port the connector sidecar reports its state on.
+	connectorStatusPort int32 = 8090
+	// connectorBotTokenKey and connectorAppTokenKey are the keys of the credentials Secrets.
+	connectorBotTokenKey = "bot-token"
+	connectorAppTokenKey = "app-token"
+
+	// connectorSecretInvalidReason is the reason of the ConnectorsReady condition set when
+	// the credentials of a connector are missing.
+	connectorSecretInvalidReason = "ConnectorSecretInvalid"
+
+	// connectorsRequeue is how often connectors not connected yet are checked.
+	connectorsRequeue = 30 * time.Second
+)
+
+// connectorsFileMode makes the mounted connector credentials readable by the file owner only.
+var connectorsFileMode int32 = 0400
+
+// connectorsEnabled reports whether the agent pods run the connector sidecar.
+func connectorsEnabled(agent *aiv1.Agent) bool {
+	return len(agent.Spec.Connectors) > 0
+}
+
+// connectorsConfigMapName returns the name of the ConfigMap holding the connector
+// configurations of the agent.
+func connectorsConfigMapName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "connectors")
+}
+
+// connectorSecretKeys returns the keys the credentials Secret of the connector must hold.
+func connectorSecretKeys(connector aiv1.AgentConnector) []string {
+	if connector.Type == "slack" {
+		return []string{connectorBotTokenKey, connectorAppTokenKey}
+	}
+	return []string{connectorBotTokenKey}
+}
+
+// validateConnectors checks the connectors of the agent. Their credentials are checked by
+// reconcileConnectorHealth, so that a revoked token does not fail the agent.
+func (r *AgentReconciler) validateConnectors(agent *aiv1.Agent) error {
+	names := map[string]bool{}
+	for _, connector := range agent.Spec.Connectors {
+		if connector.Name == "" {
+			return fmt.Errorf("connector names must not be empty")
+		}
+		if names[connector.Name] {
+			return fmt.Errorf("duplicate connector %q", connector.Name)
+		}
+		names[connector.Name] = true
+		if connector.Type != "slack" && connector.Type != "discord" {
+			return fmt.Errorf("connector %q: invalid type %q, must be slack or discord", connector.Name, connector.Type)
+		}
+		if connector.CredentialsSecretRef.Name == "" {
+			return fmt.Errorf("connector %q: credentialsSecretRef.name is required", connector.Name)
+		}
+	}
+	return nil
+}
+
+// connectorConfig returns the configuration file of the connector read by the sidecar.
+func connectorConfig(connector aiv1.AgentConnector) connectors.Config {
+	config := connectors.Config{
+		Name:           connector.Name,
+		Type:           connector.Type,
+		Channels:       []string{},
+		Mention:        true,
+		DirectMessages: true,
+		BotTokenFile:   fmt.Sprintf("%s/%s/%s", connectorsMountPath, connector.Name, connectorBotTokenKey),
+	}
+	if connector.Type == "slack" {
+		config.AppTokenFile = fmt.Sprintf("%s/%s/%s", connectorsMountPath, connector.Name, connectorAppTokenKey)
+	}
+	config.Channels = append(config.Channels, connector.Channels...)
+	if trigger := connector.Trigger; trigger != nil {
+		if trigger.Mention != nil {
+			config.Mention = *trigger.Mention
+		}
+		if trigger.DirectMessages != nil {
+			config.DirectMessages = *trigger.DirectMessages
+		}
+		config.Prefix = trigger.Prefix
+	}
+	return config
+}
+
+// connectorConfigs returns the data of the connectors ConfigMap: the configuration of each
+// connector under <name>.json.
+func connectorConfigs(agent *aiv1.Agent) (map[string]string, error) {
+	data := map[string]string{}
+	for _, connector := range agent.Spec.Connectors {
+		config, err := json.Marshal(connectorConfig(connector))
+		if err != nil {
+			return nil, err
+		}
+		data[connector.Name+".json"] = string(config)
+	}
+	return data, nil
+}
+
+// reconcileConnectors keeps the connectors ConfigMap in sync with the connectors of the
+// agent, and deletes it once the agent has none. The configuration of a removed connector
+// goes away with its key.
+func (r *AgentReconciler) reconcileConnectors(ctx context.Context, agent *aiv1.Agent) error {
+	if !connectorsEnabled(agent) {
+		configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: connectorsConfigMapName(agent), Namespace: agent.Namespace}}
+		return client.IgnoreNotFound(r.Delete(ctx, configMap))
+	}
+
+	data, err := connectorConfigs(agent)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      connectorsConfigMapName(agent),
+			Namespace: agent.Namespace,
+			Labels:    agentLabels(agent),
+		},
+		Data: data,
+	}
+	if err := controllerutil.SetControllerReference(agent, configMap, r.Scheme); err != nil {
+		return err
+	}
+	found := &corev1.ConfigMap{}
+	err = r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating connectors ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+		return r.Create(ctx, configMap)
+	} else if err != nil {
+		return err
+	}
+	found.Data = configMap.Data
+	return r.Update(ctx, found)
+}
+
+// connectorCredentialProblems returns why the credentials Secrets of the connectors cannot
+// be used, one entry per connector.
+func (r *AgentReconciler) connectorCredentialProblems(ctx context.Context, agent *aiv1.Agent) ([]string, error) {
+	var problems []string
+	for _, connector := range agent.Spec.Connectors {
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: connector.CredentialsSecretRef.Name, Namespace: agent.Namespace}, secret)
+		if errors.IsNotFound(err) {
+			problems = append(problems, fmt.Sprintf("connector %s: secret %s not found", connector.Name, connector.CredentialsSecretRef.Name))
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		var missing []string
+		for _, key := range connectorSecretKeys(connector) {
+			if len(secret.Data[key]) == 0 {
+				missing = append(missing, key)
+			}
+		}
+		if len(missing) > 0 {
+			problems = append(problems, fmt.Sprintf("connector %s: secret %s has no %s", connector.Name, connector.CredentialsSecretRef.Name, strings.Join(missing, ", ")))
+		}
+	}
+	return problems, nil
+}
+
+// reconcileConnectorHealth reports in the ConnectorsReady condition whether the connectors
+// of the agent have valid credentials and are connected to their platform, as read from
+// the connector sidecar of the running pods. Connector problems never fail the agent,
+// which keeps serving its other clients.
+func (r *AgentReconciler) reconcileConnectorHealth(ctx context.Context, agent *aiv1.Agent) error {
+	if !connectorsEnabled(agent) {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionConnectorsReady)
+		return nil
+	}
+
+	problems, err := r.connectorCredentialProblems(ctx, agent)
+	if err != nil {
+		return err
+	}
+	if len(problems) > 0 {
+		log.FromContext(ctx).Info("Agent has connectors with invalid credentials", "problems", problems)
+		r.setCondition(agent, aiv1.AgentConditionConnectorsReady, corev1.ConditionFalse, connectorSecretInvalidReason, strings.Join(problems, "; "))
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return err
+	}
+	checker := r.ConnectorChecker
+	if checker == nil {
+		checker = &connectors.Checker{}
+	}
+	connected := map[string]bool{}
+	failed := map[string]string{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		status, err := checker.Check(ctx, fmt.Sprintf("http://%s:%d", pod.Status.PodIP, connectorStatusPort))
+		if err != nil {
+			// The sidecar may not serve yet, or the pod may predate the connectors.
+			log.FromContext(ctx).V(1).Info("Failed to check pod connectors", "Pod.Name", pod.Name, "error", err.Error())
+			continue
+		}
+		for _, connector := range status.Connectors {
+			switch connector.State {
+			case connectors.StateConnected:
+				connected[connector.Name] = true
+			case connectors.StateFailed:
+				failed[connector.Name] = connector.Error
+			}
+		}
+	}
+
+	var failures, pending []string
+	for _, connector := range agent.Spec.Connectors {
+		if message, ok := failed[connector.Name]; ok {
+			failures = append(failures, fmt.Sprintf("connector %s: %s", connector.Name, message))
+		} else if !connected[connector.Name] {
+			pending = append(pending, connector.Name)
+		}
+	}
+	switch {
+	case len(failures) > 0:
+		sort.Strings(failures)
+		r.setCondition(agent, aiv1.AgentConditionConnectorsReady, corev1.ConditionFalse, "ConnectorFailed", strings.Join(failures, "; "))
+	case len(pending) > 0:
+		r.setCondition(agent, aiv1.AgentConditionConnectorsReady, corev1.ConditionFalse, "ConnectorsConnecting",
+			fmt.Sprintf("Waiting for connectors to connect: %s", strings.Join(pending, ", ")))
+	default:
+		r.setCondition(agent, aiv1.AgentConditionConnectorsReady, corev1.ConditionTrue, "ConnectorsConnected",
+			fmt.Sprintf("All %d connectors of the agent are connected", len(agent.Spec.Connectors)))
+	}
+	return nil
+}
+
+// connectorsRequeueAfter shortens requeue while connectors are not connected, to report
+// when they are.
+func connectorsRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if !connectorsEnabled(agent) || requeue <= connectorsRequeue {
+		return requeue
+	}
+	if condition := findCondition(agent.Status.Conditions, aiv1.AgentConditionConnectorsReady); condition == nil || condition.Status != corev1.ConditionTrue {
+		return connectorsRequeue
+	}
+	return requeue
+}
+
+// connectorsChecksum returns a hash of the connector configurations and credentials, or
+// an empty string without connectors. Missing credentials are hashed as empty, and change
+// the checksum once they are created.
+func (r *AgentReconciler) connectorsChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if !connectorsEnabled(agent) {
+		return "", nil
+	}
+	hash := sha256.New()
+	for _, connector := range agent.Spec.Connectors {
+		data, err := json.Marshal(connectorConfig(connector))
+		if err != nil {
+			return "", err
+		}
+		hash.Write(data)
+		secret := &corev1.Secret{}
+		err = r.Get(ctx, types.NamespacedName{Name: connector.CredentialsSecretRef.Name, Namespace: agent.Namespace}, secret)
+		if err != nil && !errors.IsNotFound(err) {
+			return "", err
+		}
+		for _, key := range connectorSecretKeys(connector) {
+			hash.Write([]byte{0})
+			hash.Write(secret.Data[key])
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// getConnectorImage returns the image of the connector sidecar: the operator environment,
+// then the agent image, which ships the connector.
+func getConnectorImage(agentImage string) string {
+	if envImage := os.Getenv("CONNECTOR_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return agentImage
+}
+
+// connectorSidecar returns the sidecar bridging the chat platforms to the agent container
+// and the volume of its configurations and credentials, or nil without connectors. The
+// sidecar has no readiness probe: a connector rejected by its platform is reported in the
+// ConnectorsReady condition and keeps the agent pods serving.
+func connectorSidecar(agent *aiv1.Agent, agentContainer *corev1.Container) (*corev1.Container, []corev1.Volume) {
+	if !connectorsEnabled(agent) {
+		return nil, nil
+	}
+
+	optional := true
+	sources := []corev1.VolumeProjection{}
+	for _, connector := range agent.Spec.Connectors {
+		items := []corev1.KeyToPath{}
+		for _, key := range connectorSecretKeys(connector) {
+			items = append(items, corev1.KeyToPath{Key: key, Path: connector.Name + "/" + key})
+		}
+		sources = append(sources,
+			corev1.VolumeProjection{
+				ConfigMap: &corev1.ConfigMapProjection{
+					LocalObjectReference: corev1.LocalObjectReference{Name: connectorsConfigMapName(agent)},
+					Items:                []corev1.KeyToPath{{Key: connector.Name + ".json", Path: connector.Name + "/config.json"}},
+				},
+			},
+			// Missing credentials are reported by the sidecar rather than holding the pod.
+			corev1.VolumeProjection{
+				Secret: &corev1.SecretProjection{
+					LocalObjectReference: connector.CredentialsSecretRef,
+					Items:                items,
+					Optional:             &optional,
+				},
+			},
+		)
+	}
+
+	// The connector reaches the chat platforms through the same proxy as the agent.
+	env := append(identityEnv(agent),
+		corev1.EnvVar{Name: "CONNECTORS_DIR", Value: connectorsMountPath},
+		corev1.EnvVar{Name: "CONNECTOR_STATUS_PORT", Value: fmt.Sprintf("%d", connectorStatusPort)},
+		corev1.EnvVar{Name: "AGENT_URL", Value: "http://127.0.0.1:8080"},
+	)
+	env = append(env, endpointAuthEnv(agent)...)
+	for _, e := range agentContainer.Env {
+		if endpointWaitEnvNames[e.Name] {
+			env = append(env, e)
+		}
+	}
+
+	container := &corev1.Container{
+		Name:    "connectors",
+		Image:   getConnectorImage(agentContainer.Image),
+		Command: []string{"python", "connector.py"},
+		Ports: []corev1.ContainerPort{
+			{Name: "connectors", ContainerPort: connectorStatusPort, Protocol: corev1.ProtocolTCP},
+		},
+		Env: env,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+				corev1.ResourceCPU:    resource.MustParse("25m"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("256Mi"),
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "connectors", MountPath: connectorsMountPath, ReadOnly: true},
+		},
+	}
+	volume := corev1.Volume{
+		Name: "connectors",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources:     sources,
+				DefaultMode: &connectorsFileMode,
+			},
+		},
+	}
+	return container, []corev1.Volume{volume}
+}
+
+// connectorSecretNames returns the names of the credentials Secrets of the connectors.
+func connectorSecretNames(agent *aiv1.Agent) []string {
+	var names []string
+	for _, connector := range agent.Spec.Connectors {
+		names = append(names, connector.CredentialsSecretRef.Name)
+	}
+	return names
+}
@@ -0,0 +1,241 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/dependency"
+)
+
+// waitingForDependenciesReason is the reason of the DependenciesReady condition while the
+// agent pods are held until the dependencies are ready.
+const waitingForDependenciesReason = "WaitingForDependencies"
+
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+
+// dependencyNamespace returns the namespace of a dependency of the agent.
+func dependencyNamespace(agent *aiv1.Agent, dep aiv1.AgentDependency) string {
+	if dep.Namespace != "" {
+		return dep.Namespace
+	}
+	return agent.Namespace
+}
+
+// agentDependencies returns the agents that the agent depends on.
+func agentDependencies(agent *aiv1.Agent) []dependency.Key {
+	var keys []dependency.Key
+	for _, dep := range agent.Spec.DependsOn {
+		if dep.Agent != "" {
+			keys = append(keys, dependency.Key{Namespace: dependencyNamespace(agent, dep), Name: dep.Agent})
+		}
+	}
+	return keys
+}
+
+// validateDependencies checks that each dependency refers to either an Agent or a Service,
+// and that the agent is not part of a cycle of agents depending on each other.
+func (r *AgentReconciler) validateDependencies(ctx context.Context, agent *aiv1.Agent) error {
+	for i, dep := range agent.Spec.DependsOn {
+		if (dep.Agent == "") == (dep.Service == "") {
+			return fmt.Errorf("dependsOn[%d] must set exactly one of agent and service", i)
+		}
+	}
+
+	self := dependency.Key{Namespace: agent.Namespace, Name: agent.Name}
+	cycle, err := dependency.FindCycle(self, func(key dependency.Key) ([]dependency.Key, error) {
+		if key == self {
+			return agentDependencies(agent), nil
+		}
+		other := &aiv1.Agent{}
+		if err := r.Get(ctx, types.NamespacedName{Name: key.Name, Namespace: key.Namespace}, other); errors.IsNotFound(err) {
+			return nil, nil
+		} else if err != nil {
+			return nil, err
+		}
+		return agentDependencies(other), nil
+	})
+	if err != nil {
+		return err
+	}
+	if cycle != nil {
+		return &dependency.CycleError{Cycle: cycle}
+	}
+	return nil
+}
+
+// reconcileDependencies reports the readiness of the dependencies of the agent and sets the
+// DependenciesReady condition. Until the agent pods first start, a dependency that is not
+// ready holds them with the WaitingForDependencies reason; once started, the pods keep
+// running and the condition only reports the dependency.
+func (r *AgentReconciler) reconcileDependencies(ctx context.Context, agent *aiv1.Agent) error {
+	if len(agent.Spec.DependsOn) == 0 {
+		agent.Status.Dependencies = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionDependenciesReady)
+		return nil
+	}
+
+	statuses := make([]aiv1.DependencyStatus, 0, len(agent.Spec.DependsOn))
+	var notReady []string
+	for _, dep := range agent.Spec.DependsOn {
+		status, err := r.dependencyStatus(ctx, agent, dep)
+		if err != nil {
+			return err
+		}
+		statuses = append(statuses, status)
+		if !status.Ready {
+			notReady = append(notReady, fmt.Sprintf("%s %s/%s (%s)", status.Kind, status.Namespace, status.Name, status.Message))
+		}
+	}
+	agent.Status.Dependencies = statuses
+
+	if len(notReady) == 0 {
+		r.setCondition(agent, aiv1.AgentConditionDependenciesReady, corev1.ConditionTrue, "DependenciesReady", "All dependencies are ready")
+		return nil
+	}
+
+	started, err := r.agentPodsStarted(ctx, agent)
+	if err != nil {
+		return err
+	}
+	reason := "DependenciesNotReady"
+	if !started {
+		reason = waitingForDependenciesReason
+	}
+	r.setCondition(agent, aiv1.AgentConditionDependenciesReady, corev1.ConditionFalse, reason, "Dependencies not ready: "+strings.Join(notReady, ", "))
+	return nil
+}
+
+// waitingForDependencies reports whether the agent pods are held until the dependencies of
+// the agent are ready.
+func waitingForDependencies(agent *aiv1.Agent) bool {
+	condition := findCondition(agent.Status.Conditions, aiv1.AgentConditionDependenciesReady)
+	return condition != nil && condition.Status == corev1.ConditionFalse && condition.Reason == waitingForDependenciesReason
+}
+
+// agentPodsStarted reports whether the Deployment of the agent exists and is scaled up.
+func (r *AgentReconciler) agentPodsStarted(ctx context.Context, agent *aiv1.Agent) (bool, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deploymentName(agent), Namespace: agent.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return deployment.Spec.Replicas == nil || *deployment.Spec.Replicas > 0, nil
+}
+
+// dependencyStatus returns the readiness of a dependency: an Agent is ready with the Ready
+// condition, a Service with at least MinReadyEndpoints ready endpoints.
+func (r *AgentReconciler) dependencyStatus(ctx context.Context, agent *aiv1.Agent, dep aiv1.AgentDependency) (aiv1.DependencyStatus, error) {
+	namespace := dependencyNamespace(agent, dep)
+	if dep.Agent != "" {
+		status := aiv1.DependencyStatus{Kind: "Agent", Name: dep.Agent, Namespace: namespace}
+		other := &aiv1.Agent{}
+		if err := r.Get(ctx, types.NamespacedName{Name: dep.Agent, Namespace: namespace}, other); errors.IsNotFound(err) {
+			status.Message = "Agent not found"
+			return status, nil
+		} else if err != nil {
+			return status, err
+		}
+		ready := findCondition(other.Status.Conditions, aiv1.AgentConditionReady)
+		switch {
+		case ready == nil:
+			status.Message = "Agent readiness not reported yet"
+		case ready.Status != corev1.ConditionTrue:
+			status.Message = ready.Message
+		default:
+			status.Ready = true
+		}
+		return status, nil
+	}
+
+	status := aiv1.DependencyStatus{Kind: "Service", Name: dep.Service, Namespace: namespace}
+	service := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: dep.Service, Namespace: namespace}, service, referenced); errors.IsNotFound(err) {
+		status.Message = "Service not found"
+		return status, nil
+	} else if err != nil {
+		return status, err
+	}
+	var slices discoveryv1.EndpointSliceList
+	if err := r.List(ctx, &slices, client.InNamespace(namespace), client.MatchingLabels{discoveryv1.LabelServiceName: dep.Service}); err != nil {
+		return status, err
+	}
+	readyEndpoints := countEndpoints(slices.Items).Ready
+	minReady := int32(1)
+	if dep.MinReadyEndpoints != nil {
+		minReady = *dep.MinReadyEndpoints
+	}
+	status.Ready = readyEndpoints >= minReady
+	if !status.Ready {
+		status.Message = fmt.Sprintf("%d of %d ready endpoints", readyEndpoints, minReady)
+	}
+	return status, nil
+}
+
+// findAgentsForDependencyAgent maps a change of an Agent, including its status, to the agents
+// depending on it.
+func (r *AgentReconciler) findAgentsForDependencyAgent(ctx context.Context, obj client.Object) []reconcile.Request {
+	return r.findDependentAgents(ctx, func(agent *aiv1.Agent, dep aiv1.AgentDependency) bool {
+		return dep.Agent == obj.GetName() && dependencyNamespace(agent, dep) == obj.GetNamespace()
+	})
+}
+
+// findAgentsForEndpointSlice maps a change of the endpoints of a Service to the agent
+// owning the Service, whose readiness follows its endpoints, and to the agents depending on
+// the Service.
+func (r *AgentReconciler) findAgentsForEndpointSlice(ctx context.Context, obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()[discoveryv1.LabelServiceName]
+	if name == "" {
+		return nil
+	}
+	requests := r.findDependentAgents(ctx, func(agent *aiv1.Agent, dep aiv1.AgentDependency) bool {
+		return dep.Service == name && dependencyNamespace(agent, dep) == obj.GetNamespace()
+	})
+
+	service := &corev1.Service{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}, service, cacheOnly); err != nil {
+		if !errors.IsNotFound(err) {
+			log.FromContext(ctx).Error(err, "Failed to get the Service of endpoints", "service", name)
+		}
+		return requests
+	}
+	if agent, ok := agentOfService(service); ok {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: agent, Namespace: obj.GetNamespace()}})
+	}
+	return requests
+}
+
+// findDependentAgents returns the agents with a dependency matching dependsOn.
+func (r *AgentReconciler) findDependentAgents(ctx context.Context, dependsOn func(*aiv1.Agent, aiv1.AgentDependency) bool) []reconcile.Request {
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for dependencies")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range agents.Items {
+		agent := &agents.Items[i]
+		for _, dep := range agent.Spec.DependsOn {
+			if dependsOn(agent, dep) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
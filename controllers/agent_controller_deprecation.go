@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+)
+
+var modelDeprecated = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubeagentic_agent_model_deprecated",
+	Help: "Set to 1 for agents whose model is deprecated, with the retirement date of the model. Count the series for the number of affected agents.",
+}, []string{"namespace", "agent", "provider", "model", "retires"})
+
+func init() {
+	metrics.Registry.MustRegister(modelDeprecated)
+}
+
+// applyModelDeprecation sets the ModelDeprecated condition and metric of the agent from the
+// deprecations of the current model catalog, or clears them when its model is not
+// deprecated. It reports whether the condition changed.
+func applyModelDeprecation(agent *aiv1.Agent, now time.Time) bool {
+	deleteModelDeprecationMetrics(agent)
+	var current *aiv1.AgentCondition
+	if condition := findCondition(agent.Status.Conditions, aiv1.AgentConditionModelDeprecated); condition != nil {
+		current = condition.DeepCopy()
+	}
+
+	deprecation, ok := pricing.DeprecationFor(agent.Spec.Provider, agent.Spec.Model, now)
+	if !ok {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionModelDeprecated)
+		return current != nil
+	}
+	modelDeprecated.WithLabelValues(agent.Namespace, agent.Name, agent.Spec.Provider, agent.Spec.Model, deprecation.Retires).Set(1)
+
+	reason := "ModelDeprecated"
+	if deprecation.Retired(now) {
+		reason = "ModelRetired"
+	}
+	message := deprecation.Message(agent.Spec.Provider, agent.Spec.Model, now)
+	if current != nil && current.Reason == reason && current.Message == message {
+		return false
+	}
+	transition := metav1.NewTime(now)
+	if current != nil && current.LastTransitionTime != nil {
+		transition = *current.LastTransitionTime
+	}
+	agent.Status.Conditions = append(removeCondition(agent.Status.Conditions, aiv1.AgentConditionModelDeprecated), aiv1.AgentCondition{
+		Type:               aiv1.AgentConditionModelDeprecated,
+		Status:             corev1.ConditionTrue,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: &transition,
+	})
+	return true
+}
+
+// deleteModelDeprecationMetrics removes the deprecation metric of the agent.
+func deleteModelDeprecationMetrics(agent *aiv1.Agent) {
+	modelDeprecated.DeletePartialMatch(prometheus.Labels{"namespace": agent.Namespace, "agent": agent.Name})
+}
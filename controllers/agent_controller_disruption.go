@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"context"
+
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	// safeToEvictAnnotation keeps the cluster autoscaler from removing the node of a pod.
+	safeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+	// doNotDisruptAnnotation keeps Karpenter from voluntarily disrupting the node of a pod.
+	doNotDisruptAnnotation = "karpenter.sh/do-not-disrupt"
+
+	// consolidationPolicyOneAtATime allows node consolidation to evict one agent pod at a time.
+	consolidationPolicyOneAtATime = "OneAtATime"
+)
+
+// doNotDisrupt reports whether the agent pods must not be evicted by node consolidation.
+func doNotDisrupt(agent *aiv1.Agent) bool {
+	return agent.Spec.Disruption != nil && agent.Spec.Disruption.DoNotDisrupt
+}
+
+// disruptionAnnotations returns the pod template annotations asking the cluster autoscaler
+// and Karpenter not to consolidate the nodes of the agent pods.
+func disruptionAnnotations(agent *aiv1.Agent) map[string]string {
+	if !doNotDisrupt(agent) {
+		return nil
+	}
+	return map[string]string{
+		safeToEvictAnnotation:  "false",
+		doNotDisruptAnnotation: "true",
+	}
+}
+
+// disruptionMaxUnavailable returns the maxUnavailable of the agent's PodDisruptionBudget,
+// or nil when evictions are not limited.
+func disruptionMaxUnavailable(agent *aiv1.Agent) *intstr.IntOrString {
+	if agent.Spec.Disruption == nil {
+		return nil
+	}
+	if agent.Spec.Disruption.DoNotDisrupt {
+		maxUnavailable := intstr.FromInt(0)
+		return &maxUnavailable
+	}
+	if agent.Spec.Disruption.ConsolidationPolicy == consolidationPolicyOneAtATime {
+		maxUnavailable := intstr.FromInt(1)
+		return &maxUnavailable
+	}
+	return nil
+}
+
+// reconcilePodDisruptionBudget creates, updates or deletes the PodDisruptionBudget of the
+// agent pods according to spec.disruption.
+func (r *AgentReconciler) reconcilePodDisruptionBudget(ctx context.Context, agent *aiv1.Agent) error {
+	name := naming.Child(agent.Name, "pdb")
+	maxUnavailable := disruptionMaxUnavailable(agent)
+	if maxUnavailable == nil {
+		pdb := &policyv1.PodDisruptionBudget{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, pdb, cacheOnly)
+		if err == nil {
+			log.FromContext(ctx).Info("Deleting PodDisruptionBudget", "PodDisruptionBudget.Name", pdb.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, pdb))
+		}
+		return client.IgnoreNotFound(err)
+	}
+
+	labels := agentLabels(agent)
+	pdb := &policyv1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: policyv1.PodDisruptionBudgetSpec{
+			MaxUnavailable: maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+		},
+	}
+	if err := controllerutil.SetControllerReference(agent, pdb, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &policyv1.PodDisruptionBudget{}
+	err := r.Get(ctx, types.NamespacedName{Name: pdb.Name, Namespace: pdb.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new PodDisruptionBudget", "PodDisruptionBudget.Namespace", pdb.Namespace, "PodDisruptionBudget.Name", pdb.Name)
+		return r.Create(ctx, pdb)
+	} else if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Updating existing PodDisruptionBudget", "PodDisruptionBudget.Namespace", found.Namespace, "PodDisruptionBudget.Name", found.Name)
+	found.Spec = pdb.Spec
+	return r.Update(ctx, found)
+}
@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// encryptionMountPath is where the encryption keys are mounted in agent and job pods.
+	encryptionMountPath = "/etc/kubeagentic/encryption"
+	// encryptionKeyChecksumAnnotation rolls the pods when either encryption key changes.
+	encryptionKeyChecksumAnnotation = "kubeagentic.ai/encryption-key-checksum"
+	// minEncryptionKeyLength is the minimum key length, enough for AES-256.
+	minEncryptionKeyLength = 32
+)
+
+// encryptionKeyMode makes the mounted keys readable by the file owner only.
+var encryptionKeyMode int32 = 0400
+
+// encryptionKeyRefs returns the configured encryption keys, the current key first.
+func encryptionKeyRefs(agent *aiv1.Agent) []*corev1.SecretKeySelector {
+	encryption := agent.Spec.Encryption
+	if encryption == nil {
+		return nil
+	}
+	refs := []*corev1.SecretKeySelector{&encryption.KeySecretRef}
+	if encryption.PreviousKeySecretRef != nil {
+		refs = append(refs, encryption.PreviousKeySecretRef)
+	}
+	return refs
+}
+
+// validateEncryptionConfig checks that the encryption keys exist and are long enough.
+func (r *AgentReconciler) validateEncryptionConfig(ctx context.Context, agent *aiv1.Agent) error {
+	for _, ref := range encryptionKeyRefs(agent) {
+		if ref.Name == "" || ref.Key == "" {
+			return fmt.Errorf("encryption key secret references require both name and key")
+		}
+		key, err := r.secretValue(ctx, agent.Namespace, ref)
+		if err != nil {
+			return fmt.Errorf("failed to get encryption key: %w", err)
+		}
+		if len(key) < minEncryptionKeyLength {
+			return fmt.Errorf("encryption key %s/%s must be at least %d bytes", ref.Name, ref.Key, minEncryptionKeyLength)
+		}
+	}
+	if encryption := agent.Spec.Encryption; encryption != nil && encryption.PreviousKeySecretRef != nil &&
+		encryption.PreviousKeySecretRef.Name == encryption.KeySecretRef.Name &&
+		encryption.PreviousKeySecretRef.Key == encryption.KeySecretRef.Key {
+		return fmt.Errorf("encryption.previousKeySecretRef must differ from encryption.keySecretRef")
+	}
+	return nil
+}
+
+// encryptionVolume returns the pod volume, container mount and environment that expose the
+// encryption keys as files. Both keys are projected into a single read-only volume.
+func encryptionVolume(agent *aiv1.Agent) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	encryption := agent.Spec.Encryption
+	if encryption == nil {
+		return nil, nil, nil
+	}
+
+	sources := []corev1.VolumeProjection{
+		{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: encryption.KeySecretRef.LocalObjectReference,
+				Items:                []corev1.KeyToPath{{Key: encryption.KeySecretRef.Key, Path: "key"}},
+			},
+		},
+	}
+	env := []corev1.EnvVar{
+		{Name: "AGENT_ENCRYPTION_KEY_FILE", Value: encryptionMountPath + "/key"},
+	}
+	if ref := encryption.PreviousKeySecretRef; ref != nil {
+		sources = append(sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: ref.LocalObjectReference,
+				Items:                []corev1.KeyToPath{{Key: ref.Key, Path: "previous-key"}},
+			},
+		})
+		env = append(env, corev1.EnvVar{Name: "AGENT_ENCRYPTION_PREVIOUS_KEY_FILE", Value: encryptionMountPath + "/previous-key"})
+	}
+
+	volume := corev1.Volume{
+		Name: "encryption-keys",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources:     sources,
+				DefaultMode: &encryptionKeyMode,
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      "encryption-keys",
+		MountPath: encryptionMountPath,
+		ReadOnly:  true,
+	}
+
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}, env
+}
+
+// encryptionKeyChecksum returns a hash of the current and previous encryption keys, or an
+// empty string when encryption is not configured.
+func (r *AgentReconciler) encryptionKeyChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	refs := encryptionKeyRefs(agent)
+	if len(refs) == 0 {
+		return "", nil
+	}
+
+	hash := sha256.New()
+	for _, ref := range refs {
+		key, err := r.secretValue(ctx, agent.Namespace, ref)
+		if err != nil {
+			return "", err
+		}
+		sum := sha256.Sum256([]byte(key))
+		hash.Write(sum[:])
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	// rotateEndpointKeyAnnotation on an Agent requests a new endpoint token whenever its value changes.
+	rotateEndpointKeyAnnotation = "kubeagentic.ai/rotate-endpoint-key"
+	// endpointKeyRotationAnnotation on the token Secret records the rotation request it was generated for.
+	endpointKeyRotationAnnotation = "kubeagentic.ai/endpoint-key-rotation"
+	// endpointKeyChecksumAnnotation on the pod template rolls the agent pods when the token changes.
+	endpointKeyChecksumAnnotation = "kubeagentic.ai/endpoint-key-checksum"
+	// endpointAuthTokenKey is the Secret key holding the bearer token.
+	endpointAuthTokenKey = "token"
+)
+
+// endpointAuthEnabled reports whether the agent endpoint requires a generated bearer token.
+func endpointAuthEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.EndpointAuth != nil && agent.Spec.EndpointAuth.GenerateKey
+}
+
+// endpointAuthSecretName returns the name of the Secret holding the agent's endpoint token.
+func endpointAuthSecretName(agent *aiv1.Agent) string {
+	return agentEndpointAuthSecretName(agent.Name)
+}
+
+// agentEndpointAuthSecretName returns the name of the Secret holding the endpoint token of
+// the named agent.
+func agentEndpointAuthSecretName(agentName string) string {
+	return naming.Child(agentName, "endpoint-auth")
+}
+
+// endpointToken returns the bearer token the agent pods expect, or "" without endpoint auth.
+func (r *AgentReconciler) endpointToken(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if !endpointAuthEnabled(agent) {
+		return "", nil
+	}
+	return r.secretValue(ctx, agent.Namespace, &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: endpointAuthSecretName(agent)},
+		Key:                  endpointAuthTokenKey,
+	})
+}
+
+// generateEndpointToken returns a random bearer token.
+func generateEndpointToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// reconcileEndpointAuth ensures the endpoint token Secret exists and regenerates the token
+// when the rotation annotation of the agent changes.
+func (r *AgentReconciler) reconcileEndpointAuth(ctx context.Context, agent *aiv1.Agent) error {
+	name := endpointAuthSecretName(agent)
+	if !endpointAuthEnabled(agent) {
+		agent.Status.EndpointAuthSecretName = ""
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, secret)
+		if err == nil && metav1.IsControlledBy(secret, agent) {
+			log.FromContext(ctx).Info("Deleting endpoint auth Secret", "Secret.Name", secret.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, secret))
+		}
+		return client.IgnoreNotFound(err)
+	}
+
+	rotation := agent.Annotations[rotateEndpointKeyAnnotation]
+	found := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	if err == nil && found.Annotations[endpointKeyRotationAnnotation] == rotation && len(found.Data[endpointAuthTokenKey]) > 0 {
+		agent.Status.EndpointAuthSecretName = name
+		return nil
+	}
+
+	token, genErr := generateEndpointToken()
+	if genErr != nil {
+		return genErr
+	}
+
+	if errors.IsNotFound(err) {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: agent.Namespace,
+				Labels:    agentLabels(agent),
+				Annotations: map[string]string{
+					endpointKeyRotationAnnotation: rotation,
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{
+				endpointAuthTokenKey: []byte(token),
+			},
+		}
+		if err := controllerutil.SetControllerReference(agent, secret, r.Scheme); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Creating endpoint auth Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+		if err := r.Create(ctx, secret); err != nil {
+			return err
+		}
+	} else {
+		// The token and the rotation marker are written in a single update, and the changed
+		// token checksum rolls the agent pods when the Deployment is reconciled.
+		log.FromContext(ctx).Info("Rotating endpoint auth token", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
+		if found.Annotations == nil {
+			found.Annotations = map[string]string{}
+		}
+		found.Annotations[endpointKeyRotationAnnotation] = rotation
+		found.Data = map[string][]byte{
+			endpointAuthTokenKey: []byte(token),
+		}
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	agent.Status.EndpointAuthSecretName = name
+	return nil
+}
+
+// endpointAuthEnv returns the environment variable carrying the expected bearer token.
+func endpointAuthEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !endpointAuthEnabled(agent) {
+		return nil
+	}
+	return []corev1.EnvVar{
+		{
+			Name: "AGENT_ENDPOINT_TOKEN",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: endpointAuthSecretName(agent)},
+					Key:                  endpointAuthTokenKey,
+				},
+			},
+		},
+	}
+}
+
+// endpointKeyChecksum returns a hash of the endpoint token, or an empty string when
+// endpoint authentication is disabled.
+func (r *AgentReconciler) endpointKeyChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if !endpointAuthEnabled(agent) {
+		return "", nil
+	}
+	token, err := r.secretValue(ctx, agent.Namespace, &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: endpointAuthSecretName(agent)},
+		Key:                  endpointAuthTokenKey,
+	})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// noEndpointsReason is the reason of the ServiceReady and Degraded conditions of an agent
+// whose replicas are ready while its Service has no ready endpoint, such as when the
+// selector of the Service does not match the agent pods.
+const noEndpointsReason = "NoEndpoints"
+
+// countEndpoints counts the ready and not ready endpoints of the given slices.
+func countEndpoints(slices []discoveryv1.EndpointSlice) aiv1.EndpointStatus {
+	var status aiv1.EndpointStatus
+	for _, slice := range slices {
+		for _, endpoint := range slice.Endpoints {
+			// An unknown readiness is to be interpreted as ready
+			if endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready {
+				status.Ready++
+			} else {
+				status.NotReady++
+			}
+		}
+	}
+	return status
+}
+
+// serviceEndpoints counts the endpoints of the Service of the agent.
+func (r *AgentReconciler) serviceEndpoints(ctx context.Context, agent *aiv1.Agent) (aiv1.EndpointStatus, error) {
+	var slices discoveryv1.EndpointSliceList
+	if err := r.List(ctx, &slices, client.InNamespace(agent.Namespace), client.MatchingLabels{discoveryv1.LabelServiceName: serviceName(agent)}); err != nil {
+		return aiv1.EndpointStatus{}, err
+	}
+	return countEndpoints(slices.Items), nil
+}
+
+// replicasReady reports whether all replicas of the Deployment are ready, and there is at
+// least one.
+func replicasReady(deployment *appsv1.Deployment) bool {
+	return deployment.Status.ReadyReplicas == *deployment.Spec.Replicas && deployment.Status.ReadyReplicas > 0
+}
+
+// setServiceReadyCondition sets the ServiceReady condition once every step succeeded, from
+// the endpoints of the Service. Agents scaled to zero need no endpoint.
+func (r *AgentReconciler) setServiceReadyCondition(agent *aiv1.Agent, deployment *appsv1.Deployment) {
+	switch {
+	case agent.Status.Endpoints.Ready > 0 || *deployment.Spec.Replicas == 0:
+		r.setCondition(agent, aiv1.AgentConditionServiceReady, corev1.ConditionTrue, "ServiceReconciled", "The Service routes to the agent pods")
+	case deployment.Status.ReadyReplicas > 0:
+		r.setCondition(agent, aiv1.AgentConditionServiceReady, corev1.ConditionFalse, noEndpointsReason, fmt.Sprintf(
+			"%d replicas are ready but the Service %s has no ready endpoints, check that its selector matches the agent pods",
+			deployment.Status.ReadyReplicas, serviceName(agent)))
+	default:
+		r.setCondition(agent, aiv1.AgentConditionServiceReady, corev1.ConditionFalse, "EndpointsNotReady", "The Service has no ready endpoints until the agent pods are ready")
+	}
+}
+
+// setNoEndpointsDegraded sets the Degraded condition while the ready replicas of the agent
+// are not endpoints of its Service, and resolves it once they are. Degraded set for another
+// reason is kept.
+func (r *AgentReconciler) setNoEndpointsDegraded(agent *aiv1.Agent) {
+	serviceReady := findCondition(agent.Status.Conditions, aiv1.AgentConditionServiceReady)
+	degraded := findCondition(agent.Status.Conditions, aiv1.AgentConditionDegraded)
+	degradedByEndpoints := degraded != nil && degraded.Status == corev1.ConditionTrue && degraded.Reason == noEndpointsReason
+	switch {
+	case serviceReady.Reason == noEndpointsReason:
+		if degraded == nil || degraded.Status != corev1.ConditionTrue || degradedByEndpoints {
+			r.setCondition(agent, aiv1.AgentConditionDegraded, corev1.ConditionTrue, noEndpointsReason, serviceReady.Message)
+		}
+	case degradedByEndpoints:
+		r.setCondition(agent, aiv1.AgentConditionDegraded, corev1.ConditionFalse, "EndpointsReady", "The Service routes to the ready agent pods")
+	}
+}
+
+// agentOfService returns the agent owning the given Service, if any.
+func agentOfService(service *corev1.Service) (string, bool) {
+	owner := metav1.GetControllerOf(service)
+	if owner == nil || owner.Kind != "Agent" || owner.APIVersion != aiv1.GroupVersion.String() {
+		return "", false
+	}
+	return owner.Name, true
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// endpointWaitContainerName is the name of the init container waiting for the model endpoint.
+	endpointWaitContainerName = "wait-for-endpoint"
+
+	defaultEndpointWaitTimeout = 10 * time.Minute
+
+	// modelEndpointRequeue is how often pods held by the init containers are checked, as
+	// their progress does not change the Deployment.
+	modelEndpointRequeue = 15 * time.Second
+)
+
+// endpointWaitEnvNames are the environment variables of the agent container that the init
+// container inherits, so that it reaches the endpoints through the same proxy and trusts
+// the same certificates, which must cover all endpoints of the provider.
+var endpointWaitEnvNames = map[string]bool{
+	"HTTP_PROXY": true, "HTTPS_PROXY": true, "NO_PROXY": true,
+	"http_proxy": true, "https_proxy": true, "no_proxy": true,
+	"SSL_CERT_FILE": true, "SSL_CERT_DIR": true, "REQUESTS_CA_BUNDLE": true,
+}
+
+// waitsForEndpoint reports whether the agent pods wait for the model endpoint to answer:
+// by default for the self-hosted providers, whose servers load the model weights on start,
+// and never without an endpoint to wait for.
+func waitsForEndpoint(agent *aiv1.Agent) bool {
+	if primaryEndpoint(agent) == "" {
+		return false
+	}
+	if agent.Spec.WaitForEndpoint != nil {
+		return *agent.Spec.WaitForEndpoint
+	}
+	return providersRequiringEndpoint[agent.Spec.Provider]
+}
+
+// endpointWaitTimeout returns how long the init container waits for the model endpoint.
+func endpointWaitTimeout(agent *aiv1.Agent) time.Duration {
+	if agent.Spec.WaitForEndpointTimeout != nil && agent.Spec.WaitForEndpointTimeout.Duration > 0 {
+		return agent.Spec.WaitForEndpointTimeout.Duration
+	}
+	return defaultEndpointWaitTimeout
+}
+
+// endpointWaitContainer returns the init container polling the models route of the endpoint
+// with backoff until it answers or the timeout passes, or nil when the agent does not wait
+// for its endpoint.
+func endpointWaitContainer(agent *aiv1.Agent, agentContainer *corev1.Container) *corev1.Container {
+	if !waitsForEndpoint(agent) {
+		return nil
+	}
+	container := modelEndpointInitContainer(agent, agentContainer, endpointWaitContainerName)
+	container.Env = append(container.Env, corev1.EnvVar{
+		Name: "AGENT_ENDPOINT_WAIT_TIMEOUT_SECONDS", Value: strconv.Itoa(int(endpointWaitTimeout(agent).Seconds())),
+	})
+	return container
+}
+
+// modelEndpointInitContainer returns an init container reaching the model endpoint. It runs
+// wait_for_endpoint.py of the agent image, or the ENDPOINT_WAIT_IMAGE of the operator for
+// images without it, with the proxy and TLS settings and the volumes of the agent container.
+func modelEndpointInitContainer(agent *aiv1.Agent, agentContainer *corev1.Container, name string, args ...string) *corev1.Container {
+	// The agent image is pulled as the agent container pulls it
+	image, pullPolicy := agentContainer.Image, agentContainer.ImagePullPolicy
+	if envImage := os.Getenv("ENDPOINT_WAIT_IMAGE"); envImage != "" {
+		image, pullPolicy = envImage, ""
+	}
+	env := []corev1.EnvVar{{Name: "AGENT_ENDPOINT", Value: primaryEndpoint(agent)}}
+	if endpoints := providerEndpointsEnv(agent); endpoints != "" {
+		env = append(env, corev1.EnvVar{Name: "AGENT_ENDPOINTS", Value: endpoints})
+	}
+	for _, e := range agentContainer.Env {
+		if endpointWaitEnvNames[e.Name] {
+			env = append(env, e)
+		}
+	}
+	var volumeMounts []corev1.VolumeMount
+	for _, mount := range agentContainer.VolumeMounts {
+		mount.ReadOnly = true
+		volumeMounts = append(volumeMounts, mount)
+	}
+
+	return &corev1.Container{
+		Name:                     name,
+		Image:                    image,
+		ImagePullPolicy:          pullPolicy,
+		Command:                  append([]string{"python", "wait_for_endpoint.py"}, args...),
+		Env:                      env,
+		VolumeMounts:             volumeMounts,
+		TerminationMessagePolicy: corev1.TerminationMessageFallbackToLogsOnError,
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		},
+	}
+}
+
+// modelEndpointPods lists the agent pods that are not being deleted, when they run init
+// containers reaching the model endpoint.
+func (r *AgentReconciler) modelEndpointPods(ctx context.Context, agent *aiv1.Agent) ([]corev1.Pod, error) {
+	if !waitsForEndpoint(agent) && !preloadsModel(agent) {
+		return nil, nil
+	}
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return nil, err
+	}
+	var current []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil {
+			current = append(current, pod)
+		}
+	}
+	return current, nil
+}
+
+// initContainerStatus returns the status of the named init container of the pod, or nil.
+func initContainerStatus(pod *corev1.Pod, name string) *corev1.ContainerStatus {
+	for i := range pod.Status.InitContainerStatuses {
+		if pod.Status.InitContainerStatuses[i].Name == name {
+			return &pod.Status.InitContainerStatuses[i]
+		}
+	}
+	return nil
+}
+
+// failedInitContainer returns the termination of the last failed run of the init container
+// when it has not succeeded since, or nil.
+func failedInitContainer(status *corev1.ContainerStatus) *corev1.ContainerStateTerminated {
+	if status == nil || status.State.Terminated != nil && status.State.Terminated.ExitCode == 0 {
+		return nil
+	}
+	if terminated := status.State.Terminated; terminated != nil {
+		return terminated
+	}
+	if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.ExitCode != 0 {
+		return terminated
+	}
+	return nil
+}
+
+// endpointWaitMessage describes the agent pods held by the endpoint wait, for the message of
+// a pending agent: a failed wait with its termination message, or the wait in progress. It
+// returns an empty message when no pod waits for the endpoint.
+func endpointWaitMessage(agent *aiv1.Agent, pods []corev1.Pod) string {
+	if !waitsForEndpoint(agent) {
+		return ""
+	}
+	waiting := ""
+	for i := range pods {
+		status := initContainerStatus(&pods[i], endpointWaitContainerName)
+		if terminated := failedInitContainer(status); terminated != nil {
+			return fmt.Sprintf("Model endpoint %s did not answer within %s, pod %s retries (%d restarts): %s",
+				strings.Join(providerEndpointURLs(agent), ", "), endpointWaitTimeout(agent), pods[i].Name, status.RestartCount, strings.TrimSpace(terminated.Message))
+		}
+		if status != nil && status.State.Running != nil && waiting == "" {
+			waiting = fmt.Sprintf("Waiting for the model endpoint %s to answer, for up to %s", strings.Join(providerEndpointURLs(agent), ", "), endpointWaitTimeout(agent))
+		}
+	}
+	return waiting
+}
+
+// modelEndpointRequeueAfter shortens requeue while a pending agent may have pods held by the
+// init containers, to report their progress.
+func modelEndpointRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if (waitsForEndpoint(agent) || preloadsModel(agent)) && agent.Status.Phase == aiv1.AgentPhasePending && requeue > modelEndpointRequeue {
+		return modelEndpointRequeue
+	}
+	return requeue
+}
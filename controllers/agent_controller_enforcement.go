@@ -0,0 +1,249 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// pausedAnnotation set to "true" on the agent stops the operator from writing the agent
+	// Deployment, Service and ConfigMap, so that they can be edited by hand, e.g. to debug.
+	// Removing it reverts the edits.
+	pausedAnnotation = "kubeagentic.ai/paused"
+	// appliedHashAnnotation records the hash of the fields the operator last wrote to the
+	// agent Deployment, Service and ConfigMap. While it matches the desired rendering, any
+	// difference in those fields was made out of band rather than by a change of the agent.
+	appliedHashAnnotation = "kubeagentic.ai/applied-hash"
+	// manualChangeRevertedReason is the reason of the Warning event naming reverted fields.
+	manualChangeRevertedReason = "ManualChangeReverted"
+)
+
+// agentPaused reports whether the operator leaves the resources of the agent as they are.
+func agentPaused(agent *aiv1.Agent) bool {
+	return agent.Annotations[pausedAnnotation] == "true"
+}
+
+// replicasManaged reports whether the operator sets the replicas of the agent Deployment,
+// rather than the HPA or KEDA.
+func replicasManaged(agent *aiv1.Agent) bool {
+	return budgetSuspended(agent) || waitingForDependencies(agent) || restoringFromSnapshot(agent) || (!hpaEnabled(agent) && !eventSourceAutoscaled(agent))
+}
+
+// appliedHash returns the hash of the managed fields of an owned resource.
+func appliedHash(fields interface{}) string {
+	data, _ := json.Marshal(fields)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+// deploymentAppliedHash returns the hash of the fields of the agent Deployment the operator
+// manages: the pod template, and the replicas unless they are autoscaled.
+func deploymentAppliedHash(agent *aiv1.Agent, deployment *appsv1.Deployment) string {
+	fields := struct {
+		Replicas *int32                 `json:"replicas,omitempty"`
+		Template corev1.PodTemplateSpec `json:"template"`
+	}{Template: deployment.Spec.Template}
+	if replicasManaged(agent) {
+		fields.Replicas = deployment.Spec.Replicas
+	}
+	return appliedHash(fields)
+}
+
+// serviceAppliedHash returns the hash of the fields of the agent Service the operator manages.
+func serviceAppliedHash(service *corev1.Service) string {
+	return appliedHash(struct {
+		Ports    []corev1.ServicePort `json:"ports"`
+		Selector map[string]string    `json:"selector"`
+		Type     corev1.ServiceType   `json:"type"`
+	}{service.Spec.Ports, service.Spec.Selector, service.Spec.Type})
+}
+
+// configMapAppliedHash returns the hash of the data of the agent ConfigMap.
+func configMapAppliedHash(configMap *corev1.ConfigMap) string {
+	return appliedHash(configMap.Data)
+}
+
+// setAppliedHash records hash on an owned resource about to be written.
+func setAppliedHash(annotations map[string]string, hash string) map[string]string {
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[appliedHashAnnotation] = hash
+	return annotations
+}
+
+// recordManualChanges records a Warning event naming the fields of an owned resource edited
+// out of band, which the update being made reverts. Edits are only looked for while the
+// resource was last written with the desired rendering; fields set by the API server or
+// other controllers, such as the status, cloud annotations and autoscaled replicas, are not
+// compared.
+func (r *AgentReconciler) recordManualChanges(agent *aiv1.Agent, kind, name, liveHash, desiredHash string, fields []string) {
+	if liveHash != desiredHash || len(fields) == 0 {
+		return
+	}
+	r.recordEvent(agent, corev1.EventTypeWarning, manualChangeRevertedReason,
+		fmt.Sprintf("Reverted out-of-band changes to %s %s: %s", kind, name, strings.Join(fields, ", ")))
+}
+
+// deploymentDrift returns the managed fields of the live agent Deployment that differ from
+// the desired ones. Fields defaulted by the API server are only compared when desired.
+func deploymentDrift(agent *aiv1.Agent, desired, live *appsv1.Deployment) []string {
+	var fields []string
+	if replicasManaged(agent) && !equality.Semantic.DeepEqual(desired.Spec.Replicas, live.Spec.Replicas) {
+		fields = append(fields, "spec.replicas")
+	}
+	fields = append(fields, mapDrift("spec.template.metadata.labels", desired.Spec.Template.Labels, live.Spec.Template.Labels, false)...)
+	fields = append(fields, mapDrift("spec.template.metadata.annotations", desired.Spec.Template.Annotations, live.Spec.Template.Annotations, false)...)
+
+	desiredPod, livePod := desired.Spec.Template.Spec, live.Spec.Template.Spec
+	if desiredPod.ServiceAccountName != livePod.ServiceAccountName {
+		fields = append(fields, "spec.template.spec.serviceAccountName")
+	}
+	if desiredPod.PriorityClassName != "" && desiredPod.PriorityClassName != livePod.PriorityClassName {
+		fields = append(fields, "spec.template.spec.priorityClassName")
+	}
+	if desiredPod.SchedulerName != "" && desiredPod.SchedulerName != livePod.SchedulerName {
+		fields = append(fields, "spec.template.spec.schedulerName")
+	}
+	fields = append(fields, containersDrift("spec.template.spec.initContainers", desiredPod.InitContainers, livePod.InitContainers)...)
+	fields = append(fields, containersDrift("spec.template.spec.containers", desiredPod.Containers, livePod.Containers)...)
+
+	liveVolumes := map[string]bool{}
+	for _, volume := range livePod.Volumes {
+		liveVolumes[volume.Name] = true
+	}
+	for _, volume := range desiredPod.Volumes {
+		if !liveVolumes[volume.Name] {
+			fields = append(fields, fmt.Sprintf("spec.template.spec.volumes[%s]", volume.Name))
+		}
+		delete(liveVolumes, volume.Name)
+	}
+	for _, name := range sortedKeys(liveVolumes) {
+		fields = append(fields, fmt.Sprintf("spec.template.spec.volumes[%s]", name))
+	}
+	return fields
+}
+
+// containersDrift compares the containers of the agent pod template by name.
+func containersDrift(path string, desired, live []corev1.Container) []string {
+	var fields []string
+	liveByName := map[string]corev1.Container{}
+	for _, container := range live {
+		liveByName[container.Name] = container
+	}
+	for _, want := range desired {
+		got, ok := liveByName[want.Name]
+		delete(liveByName, want.Name)
+		prefix := fmt.Sprintf("%s[%s]", path, want.Name)
+		if !ok {
+			fields = append(fields, prefix)
+			continue
+		}
+		if want.Image != got.Image {
+			fields = append(fields, prefix+".image")
+		}
+		if !equality.Semantic.DeepEqual(want.Command, got.Command) {
+			fields = append(fields, prefix+".command")
+		}
+		if !equality.Semantic.DeepEqual(want.Args, got.Args) {
+			fields = append(fields, prefix+".args")
+		}
+		if envDrift(want.Env, got.Env) {
+			fields = append(fields, prefix+".env")
+		}
+		if !equality.Semantic.DeepEqual(want.Resources, got.Resources) {
+			fields = append(fields, prefix+".resources")
+		}
+	}
+	for _, name := range sortedKeys(liveByName) {
+		fields = append(fields, fmt.Sprintf("%s[%s]", path, name))
+	}
+	return fields
+}
+
+// envDrift reports whether the environment of a container differs, ignoring the API
+// version the API server defaults in field references.
+func envDrift(desired, live []corev1.EnvVar) bool {
+	if len(desired) != len(live) {
+		return true
+	}
+	for i := range desired {
+		want, got := desired[i].DeepCopy(), live[i].DeepCopy()
+		for _, env := range []*corev1.EnvVar{want, got} {
+			if env.ValueFrom != nil && env.ValueFrom.FieldRef != nil {
+				env.ValueFrom.FieldRef.APIVersion = ""
+			}
+		}
+		if !equality.Semantic.DeepEqual(want, got) {
+			return true
+		}
+	}
+	return false
+}
+
+// serviceDrift returns the managed fields of the live agent Service that differ from the
+// desired ones. Node ports allocated by the API server are not compared.
+func serviceDrift(desired, live *corev1.Service) []string {
+	var fields []string
+	if desired.Spec.Type != live.Spec.Type {
+		fields = append(fields, "spec.type")
+	}
+	if !equality.Semantic.DeepEqual(desired.Spec.Selector, live.Spec.Selector) {
+		fields = append(fields, "spec.selector")
+	}
+	ports := make([]corev1.ServicePort, len(live.Spec.Ports))
+	for i, port := range live.Spec.Ports {
+		port.NodePort = 0
+		ports[i] = port
+	}
+	if !equality.Semantic.DeepEqual(desired.Spec.Ports, ports) {
+		fields = append(fields, "spec.ports")
+	}
+	return fields
+}
+
+// configMapDrift returns the keys of the live agent ConfigMap that differ from the desired
+// ones.
+func configMapDrift(desired, live *corev1.ConfigMap) []string {
+	return mapDrift("data", desired.Data, live.Data, true)
+}
+
+// mapDrift returns the keys of path whose live value differs from the desired one. Keys only
+// present in the live map are reported when exhaustive; labels and annotations are not, as
+// other controllers add their own.
+func mapDrift(path string, desired, live map[string]string, exhaustive bool) []string {
+	var fields []string
+	for _, key := range sortedKeys(desired) {
+		if value, ok := live[key]; !ok || value != desired[key] {
+			fields = append(fields, fmt.Sprintf("%s[%s]", path, key))
+		}
+	}
+	if exhaustive {
+		for _, key := range sortedKeys(live) {
+			if _, ok := desired[key]; !ok {
+				fields = append(fields, fmt.Sprintf("%s[%s]", path, key))
+			}
+		}
+	}
+	return fields
+}
+
+// sortedKeys returns the keys of a map in order, for stable event messages.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -7,38 +7,135 @@ import (
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentmetrics"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/backoff"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/connectors"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/errorhistory"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/graphstate"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/health"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/imagepolicy"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/provider"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/queuelimit"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/replicalimit"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/slo"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/transcriptsink"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/warmup"
 )
 
 // AgentReconciler reconciles an Agent object with enhanced features
 type AgentReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
+
+	// ImageResolver resolves agent image tags to digests. imagepolicy.DefaultResolver is used when nil.
+	ImageResolver *imagepolicy.Resolver
+
+	// MetricsScraper reads the request metrics of canary pods. A default scraper is used when nil.
+	MetricsScraper *agentmetrics.Scraper
+
+	// WarmupChecker reads the warm-up state of agent pods. A default checker is used when nil.
+	WarmupChecker *warmup.Checker
+
+	// ConnectorChecker reads the state of the chat connectors of agent pods. A default
+	// checker is used when nil.
+	ConnectorChecker *connectors.Checker
+
+	// TranscriptSinkChecker posts the signed test requests to the transcript sinks of the
+	// agents. A default checker is used when nil.
+	TranscriptSinkChecker *transcriptsink.Checker
+
+	// PrometheusURL is the Prometheus the error budgets of the SLOs of the agents are read
+	// from. The SLOCompliant condition is Unknown when empty.
+	PrometheusURL string
+
+	// SLOQuerier queries the error budgets from Prometheus. A default querier is used when nil.
+	SLOQuerier *slo.Querier
+
+	// HealthChecker reads the detailed health of agent runtimes. A default checker is used
+	// when nil.
+	HealthChecker *health.Checker
+
+	// HealthCheckInterval and HealthCheckTimeout apply to the health checks of agents that
+	// do not set their own. DefaultHealthCheckInterval and DefaultHealthCheckTimeout are
+	// used when zero.
+	HealthCheckInterval time.Duration
+	HealthCheckTimeout  time.Duration
+
+	// Recorder records Events on the agents. No Events are recorded when nil.
+	Recorder record.EventRecorder
+
+	// Clock tells the time of daily token quota resets. The real clock is used when nil.
+	Clock clock.PassiveClock
+
+	// ErrorHistory bounds the recent errors kept in the status of the agents.
+	ErrorHistory errorhistory.History
+
+	// OperatorNamespace holds the fleet rollout state. Changes to the operator default image
+	// are applied to all agents at once when empty.
+	OperatorNamespace string
+
+	// usage keeps the recent resource usage of the agent pods for recommendations.
+	usage usageRecorder
+
+	// QueueLimiter delays the agents enqueued too often, such as one referring to a Secret
+	// another controller keeps rewriting, and the retries of failed reconciles. The events
+	// of owned objects are not delayed. The defaults of queuelimit are used when nil.
+	QueueLimiter *queuelimit.ItemLimiter
+	// QueueQPS and QueueBurst size the token bucket shared by the retries of all agents.
+	// queuelimit.DefaultQPS and queuelimit.DefaultQueueBurst are used when zero.
+	QueueQPS   float64
+	QueueBurst int
+	// PriorityWorkers are the concurrent reconciles of each priority class.
+	// DefaultPriorityWorkers are used for the classes it does not set.
+	PriorityWorkers map[PriorityClass]int
+
+	// failures spaces out the reconciles of agents that keep failing.
+	failures backoff.Backoff
 }
 
 // +kubebuilder:rbac:groups=ai.example.com,resources=agents,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=ai.example.com,resources=agents/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=ai.example.com,resources=agents/finalizers,verbs=update
+// +kubebuilder:rbac:groups=ai.example.com,resources=agentpolicies,verbs=get;list;watch
 // +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch;create;update;delete
 // +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=autoscaling,resources=horizontalpodautoscalers,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=networking.k8s.io,resources=ingresses,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=batch,resources=cronjobs,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=create;patch
 
 // Reconcile is the main reconciliation loop with enhanced features
 func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -50,12 +147,19 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	if err := r.Get(ctx, req.NamespacedName, &agent); err != nil {
 		if errors.IsNotFound(err) {
 			logger.Info("Agent resource not found, assuming it's been deleted")
+			r.forgetFailures(req.NamespacedName)
 			return ctrl.Result{}, nil
 		}
 		logger.Error(err, "Failed to get Agent resource")
 		return ctrl.Result{}, err
 	}
 
+	// Nothing can be created in a namespace being deleted, which deletes the agent with it
+	if agent.DeletionTimestamp == nil && r.namespaceTerminating(ctx, agent.Namespace) {
+		logger.Info("Namespace is terminating, skipping reconciliation until the agent is deleted")
+		return ctrl.Result{}, nil
+	}
+
 	// Add finalizer for cleanup
 	if agent.DeletionTimestamp == nil {
 		if !controllerutil.ContainsFinalizer(&agent, "kubeagentic.ai/finalizer") {
@@ -91,70 +195,455 @@ func (r *AgentReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		}
 	}
 
-	// Validate configuration
-	if err := r.validateConfiguration(ctx, &agent); err != nil {
-		logger.Error(err, "Configuration validation failed")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Configuration validation failed: %v", err))
+	// The status the reconcile starts from; it is only written again when it changes
+	previousStatus := agent.Status.DeepCopy()
+
+	// Delete the agent once its time to live has passed
+	if deleted, err := r.reconcileExpiry(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to delete expired agent")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "ExpiryFailed", fmt.Sprintf("Failed to delete expired agent: %v", err))
+	} else if deleted {
+		return ctrl.Result{}, nil
+	}
+
+	// Restore the spec of a kept revision when requested
+	if restored, err := r.rollbackToRevision(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to roll back to revision")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionConfigValid, "RollbackFailed", fmt.Sprintf("Failed to roll back to revision: %v", err))
+	} else if restored {
+		return ctrl.Result{}, nil
+	}
+
+	// Validate the configuration and the API secret independently, so that the condition of
+	// one keeps reporting its problem while the other is fixed
+	configErr := r.validateAgentConfig(ctx, &agent)
+	var secretErr error
+	if agent.Spec.ApiSecretRef != nil {
+		secretErr = r.validateSecretRef(ctx, &agent)
+	} else if provider.RequiresAPISecret(agent.Spec.Provider, primaryEndpoint(&agent)) {
+		secretErr = withReason("SecretRequired", fmt.Errorf("apiSecretRef is required for provider %s without a self-hosted endpoint", agent.Spec.Provider))
+	}
+	if secretErr != nil {
+		secretErr = fmt.Errorf("Secret validation failed: %w", secretErr)
+	}
+
+	// Secrets and ConfigMaps that do not exist yet hold the agent in the Waiting phase rather
+	// than failing it, since they are often applied after the agent
+	missing, err := r.missingPrerequisites(ctx, &agent)
+	if err != nil {
+		logger.Error(err, "Failed to check prerequisites")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "PrerequisiteCheckFailed", fmt.Sprintf("Failed to check prerequisites: %v", err))
+	}
+	if len(missing) > 0 && errors.IsNotFound(configErr) {
+		// The configuration is validated again once the resources it reads exist
+		r.setCondition(&agent, aiv1.AgentConditionConfigValid, corev1.ConditionUnknown, prerequisitesMissingReason, configErr.Error())
+		configErr = nil
+	} else if configErr == nil {
+		r.setCondition(&agent, aiv1.AgentConditionConfigValid, corev1.ConditionTrue, "ConfigurationValid", "The agent configuration is valid")
+	}
+	if secretErr == nil && agent.Spec.ApiSecretRef == nil {
+		r.setCondition(&agent, aiv1.AgentConditionSecretValid, corev1.ConditionTrue, "SecretNotRequired", "The self-hosted endpoint needs no API secret")
+	} else if secretErr == nil {
+		r.setCondition(&agent, aiv1.AgentConditionSecretValid, corev1.ConditionTrue, "SecretFound", "The API secret exists and holds the key")
+	}
+	if configErr != nil {
+		logger.Error(configErr, "Configuration validation failed")
+		if secretErr != nil {
+			r.setFailedCondition(&agent, aiv1.AgentConditionSecretValid, conditionReason(secretErr, "SecretInvalid"), secretErr.Error())
+		}
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionConfigValid, conditionReason(configErr, "InvalidConfiguration"), configErr.Error())
+	}
+	if secretErr != nil && (len(missing) == 0 || !secretMissing(secretErr)) {
+		logger.Error(secretErr, "Secret validation failed")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionSecretValid, conditionReason(secretErr, "SecretInvalid"), secretErr.Error())
+	}
+	if len(missing) > 0 {
+		if secretErr != nil {
+			r.setCondition(&agent, aiv1.AgentConditionSecretValid, corev1.ConditionFalse, conditionReason(secretErr, "SecretInvalid"), secretErr.Error())
+		}
+		return r.updateStatusWaiting(ctx, &agent, previousStatus, missing)
+	}
+	r.setCondition(&agent, aiv1.AgentConditionPrerequisitesReady, corev1.ConditionTrue, "PrerequisitesReady", "All referenced Secrets and ConfigMaps exist")
+
+	// Keep the spec revision history
+	if err := r.reconcileRevisions(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile spec revisions")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "RevisionsFailed", fmt.Sprintf("Failed to reconcile spec revisions: %v", err))
+	}
+
+	// Resolve the peers rendered into the ConfigMap; missing peers only set a condition
+	if err := r.reconcilePeers(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to resolve peers")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "PeerResolutionFailed", fmt.Sprintf("Failed to resolve peers: %v", err))
 	}
 
-	// Validate secret reference
-	if err := r.validateSecretRef(ctx, &agent); err != nil {
-		logger.Error(err, "Secret validation failed")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Secret validation failed: %v", err))
+	// Resolve the agents the pods are placed relative to; missing ones only set a condition
+	if err := r.reconcilePlacement(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to resolve placements")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "PlacementResolutionFailed", fmt.Sprintf("Failed to resolve placements: %v", err))
+	}
+
+	// Select the model of the schedule in effect, rendered into the ConfigMap and the pods
+	if err := r.reconcileModelSchedule(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to evaluate the model schedule")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "ModelScheduleFailed", fmt.Sprintf("Failed to evaluate the model schedule: %v", err))
 	}
 
 	// Reconcile ConfigMap for tools and configuration
 	if err := r.reconcileConfigMap(ctx, &agent); err != nil {
 		logger.Error(err, "Failed to reconcile ConfigMap")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Failed to reconcile ConfigMap: %v", err))
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDeploymentReady, "ConfigMapFailed", fmt.Sprintf("Failed to reconcile ConfigMap: %v", err))
+	}
+
+	// Reconcile the connectors ConfigMap mounted by the connector sidecar
+	if err := r.reconcileConnectors(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile connectors ConfigMap")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDeploymentReady, "ConnectorsConfigFailed", fmt.Sprintf("Failed to reconcile connectors ConfigMap: %v", err))
+	}
+
+	// Reconcile managed Redis for conversation memory
+	if err := r.reconcileManagedRedis(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile managed Redis")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "RedisFailed", fmt.Sprintf("Failed to reconcile managed Redis: %v", err))
+	}
+
+	// Reconcile shared model cache PVC
+	if err := r.reconcileModelCachePVC(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile model cache PVC")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDeploymentReady, "ModelCacheFailed", fmt.Sprintf("Failed to reconcile model cache PVC: %v", err))
+	}
+
+	// Reconcile the agent PVC, its restores and its backups before mounting it
+	if err := r.reconcilePersistence(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile persistence")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDeploymentReady, "PersistenceFailed", fmt.Sprintf("Failed to reconcile persistence: %v", err))
+	}
+
+	// Reconcile endpoint auth token Secret
+	if err := r.reconcileEndpointAuth(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile endpoint auth")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "EndpointAuthFailed", fmt.Sprintf("Failed to reconcile endpoint auth: %v", err))
+	}
+
+	// Reconcile admin token Secret
+	if err := r.reconcileAdminAuth(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile admin auth")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "AdminAuthFailed", fmt.Sprintf("Failed to reconcile admin auth: %v", err))
+	}
+
+	// Copy cross-namespace secrets into the agent namespace
+	if err := r.reconcileSecretCopies(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to sync secrets")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "SecretSyncFailed", fmt.Sprintf("Failed to sync secrets: %v", err))
+	}
+
+	// Pick the operator default image of the fleet rollout
+	if err := r.reconcileFleetRollout(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile fleet rollout")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "FleetRolloutFailed", fmt.Sprintf("Failed to reconcile fleet rollout: %v", err))
+	}
+
+	// Track the spend against the budget before sizing the Deployment
+	if err := r.reconcileBudget(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile budget")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "BudgetFailed", fmt.Sprintf("Failed to reconcile budget: %v", err))
+	}
+
+	// Track the consumption against the daily token quota
+	if err := r.reconcileTokenQuota(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile token quota")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "TokenQuotaFailed", fmt.Sprintf("Failed to reconcile token quota: %v", err))
+	}
+
+	// Count the prompts answered from the response cache
+	if err := r.reconcileCacheUsage(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to count cache usage")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "CacheUsageFailed", fmt.Sprintf("Failed to count cache usage: %v", err))
+	}
+
+	// Count the requests, to tell whether the agent is used
+	if err := r.reconcileActivity(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to count requests")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "ActivityFailed", fmt.Sprintf("Failed to count requests: %v", err))
+	}
+
+	// Promote the agent to the namespace of its annotation; refused promotions only set its status
+	if err := r.reconcilePromotion(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to promote agent")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "PromotionFailed", fmt.Sprintf("Failed to promote agent: %v", err))
+	}
+
+	// Report a model that its provider deprecated or retired
+	applyModelDeprecation(&agent, r.clock().Now())
+
+	// Hold the agent pods until their dependencies are ready
+	if err := r.reconcileDependencies(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to check dependencies")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "DependencyCheckFailed", fmt.Sprintf("Failed to check dependencies: %v", err))
+	}
+
+	// Resolve the agent image digest if pinning is enabled
+	if err := r.reconcileImageDigest(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to resolve image digest")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDeploymentReady, "ImageResolutionFailed", fmt.Sprintf("Failed to resolve image digest: %v", err))
 	}
 
 	// Reconcile Deployment
 	if err := r.reconcileDeployment(ctx, &agent); err != nil {
 		logger.Error(err, "Failed to reconcile Deployment")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Failed to reconcile Deployment: %v", err))
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDeploymentReady, "DeploymentFailed", fmt.Sprintf("Failed to reconcile Deployment: %v", err))
+	}
+
+	// Report replicas above a maximum lowered after they were set
+	if err := r.checkReplicaLimit(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to check replica limit")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "ReplicaLimitCheckFailed", fmt.Sprintf("Failed to check replica limit: %v", err))
+	}
+
+	// Report pods the LimitRanges and ResourceQuotas of the namespace keep from being created
+	if err := r.reconcileNamespaceLimits(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to check namespace limits")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "LimitCheckFailed", fmt.Sprintf("Failed to check namespace limits: %v", err))
+	}
+
+	// Reconcile conversation router before pointing the Service at it
+	if err := r.reconcileRouter(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile conversation router")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionServiceReady, "RouterFailed", fmt.Sprintf("Failed to reconcile conversation router: %v", err))
+	}
+
+	// Reconcile NetworkPolicy; recomputed on every reconcile so endpoint changes apply
+	if err := r.reconcileNetworkPolicy(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile NetworkPolicy")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionServiceReady, "NetworkPolicyFailed", fmt.Sprintf("Failed to reconcile NetworkPolicy: %v", err))
 	}
 
 	// Reconcile Service
 	if err := r.reconcileService(ctx, &agent); err != nil {
 		logger.Error(err, "Failed to reconcile Service")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Failed to reconcile Service: %v", err))
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionServiceReady, "ServiceFailed", fmt.Sprintf("Failed to reconcile Service: %v", err))
+	}
+
+	// Reconcile the Service of the admin port
+	if err := r.reconcileAdminService(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile admin Service")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionServiceReady, "AdminServiceFailed", fmt.Sprintf("Failed to reconcile admin Service: %v", err))
+	}
+
+	// Report the autoscalers clamped to the autoscaling ceiling of the namespace
+	if err := r.reconcileAutoscalingCeiling(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to check autoscaling ceiling")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "AutoscalingCeilingFailed", fmt.Sprintf("Failed to check autoscaling ceiling: %v", err))
 	}
 
 	// Reconcile HPA if enabled
 	if err := r.reconcileHPA(ctx, &agent); err != nil {
 		logger.Error(err, "Failed to reconcile HPA")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Failed to reconcile HPA: %v", err))
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDeploymentReady, "AutoscalerFailed", fmt.Sprintf("Failed to reconcile HPA: %v", err))
+	}
+
+	// Scale agents consuming an event source on the backlog of their queue through KEDA
+	if err := r.reconcileEventSourceScaling(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile event source scaling")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "EventSourceScalingFailed", fmt.Sprintf("Failed to reconcile event source scaling: %v", err))
+	}
+
+	// Reconcile PodDisruptionBudget for node consolidation
+	if err := r.reconcilePodDisruptionBudget(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile PodDisruptionBudget")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDeploymentReady, "DisruptionBudgetFailed", fmt.Sprintf("Failed to reconcile PodDisruptionBudget: %v", err))
 	}
 
 	// Reconcile Ingress if configured
 	if err := r.reconcileIngress(ctx, &agent); err != nil {
 		logger.Error(err, "Failed to reconcile Ingress")
-		return r.updateStatusFailed(ctx, &agent, fmt.Sprintf("Failed to reconcile Ingress: %v", err))
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionServiceReady, "IngressFailed", fmt.Sprintf("Failed to reconcile Ingress: %v", err))
+	}
+
+	// Publish the URLs and endpoint token of the agent to its consumers
+	if err := r.reconcileBinding(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile binding Secret")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "BindingFailed", fmt.Sprintf("Failed to reconcile binding Secret: %v", err))
+	}
+
+	// Reconcile vector store connectivity check if requested
+	if err := r.reconcileVectorStoreCheck(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile vector store check")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "VectorStoreCheckFailed", fmt.Sprintf("Failed to reconcile vector store check: %v", err))
+	}
+
+	// Check the event source of the agent is reachable
+	if err := r.reconcileEventSourceCheck(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile event source check")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "EventSourceCheckFailed", fmt.Sprintf("Failed to reconcile event source check: %v", err))
+	}
+
+	// Check the transcript sink accepts signed requests and the pods deliver to it
+	if err := r.reconcileTranscriptSink(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile transcript sink check")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "TranscriptSinkCheckFailed", fmt.Sprintf("Failed to reconcile transcript sink check: %v", err))
+	}
+
+	// Generate the SLO rules and report the error budgets left
+	if err := r.reconcileSLO(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile SLO")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "SLOFailed", fmt.Sprintf("Failed to reconcile SLO: %v", err))
+	}
+
+	// Reconcile RAG ingestion CronJob if configured
+	if err := r.reconcileIngestion(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile RAG ingestion")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "IngestionFailed", fmt.Sprintf("Failed to reconcile RAG ingestion: %v", err))
+	}
+
+	// Reconcile scheduled conversation export
+	if err := r.reconcileExport(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile conversation export")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "ExportFailed", fmt.Sprintf("Failed to reconcile conversation export: %v", err))
+	}
+
+	// Reconcile the synthetic probe CronJob if enabled
+	if err := r.reconcileSynthetics(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to reconcile synthetic probe")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "SyntheticProbeFailed", fmt.Sprintf("Failed to reconcile synthetic probe: %v", err))
+	}
+
+	// Report the warm-up of the agent pods
+	if err := r.reconcileWarmup(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to check agent warm-up")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "WarmupCheckFailed", fmt.Sprintf("Failed to check agent warm-up: %v", err))
 	}
 
+	// Report the health of the chat connectors; connector problems only set a condition
+	if err := r.reconcileConnectorHealth(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to check connectors")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "ConnectorCheckFailed", fmt.Sprintf("Failed to check connectors: %v", err))
+	}
+
+	// Poll the detailed health of the agent runtime; an unreachable runtime only sets a condition
+	if err := r.reconcileHealthCheck(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to check agent health")
+		return r.updateStatusFailed(ctx, &agent, aiv1.AgentConditionDegraded, "HealthCheckFailed", fmt.Sprintf("Failed to check agent health: %v", err))
+	}
+
+	// Recommend requests and limits from the measured usage; advisory, so errors do not fail the agent
+	if err := r.reconcileRecommendations(ctx, &agent); err != nil {
+		logger.Error(err, "Failed to compute resource recommendations")
+	}
+
+	// Price the token usage with the current pricing catalog
+	setUsageCost(&agent)
+
 	// Update status
-	if err := r.updateAgentStatus(ctx, &agent); err != nil {
+	if err := r.updateAgentStatus(ctx, &agent, previousStatus); err != nil {
 		logger.Error(err, "Failed to update Agent status")
 		return ctrl.Result{}, err
 	}
 
+	r.resetFailureBackoff(&agent)
 	logger.Info("Enhanced reconciliation completed successfully")
-	return ctrl.Result{RequeueAfter: time.Minute * 5}, nil
+	return ctrl.Result{RequeueAfter: r.requeueAfter(&agent)}, nil
+}
+
+// requeueAfter returns the delay of the periodic reconcile of an agent, shortened in turn by
+// each feature that needs a reconcile sooner.
+func (r *AgentReconciler) requeueAfter(agent *aiv1.Agent) time.Duration {
+	requeue := time.Minute * 5
+	for _, shorten := range []func(*aiv1.Agent, time.Duration) time.Duration{
+		rolloutRequeueAfter,
+		experimentRequeueAfter,
+		syntheticsRequeueAfter,
+		warmupRequeueAfter,
+		budgetRequeueAfter,
+		r.tokenQuotaRequeueAfter,
+		modelEndpointRequeueAfter,
+		connectorsRequeueAfter,
+		r.healthCheckRequeueAfter,
+		r.persistenceRequeueAfter,
+		r.expiryRequeueAfter,
+		r.modelScheduleRequeueAfter,
+	} {
+		requeue = shorten(agent, requeue)
+	}
+	return requeue
+}
+
+// validProviders lists the LLM providers supported by the agent runtime.
+var validProviders = []string{"openai", "gemini", "claude", "vllm", "ollama"}
+
+// providersRequiringEndpoint lists the self-hosted providers that have no default API endpoint.
+var providersRequiringEndpoint = map[string]bool{"vllm": true, "ollama": true}
+
+// isValidProvider reports whether the provider is one of validProviders.
+func isValidProvider(provider string) bool {
+	for _, p := range validProviders {
+		if provider == p {
+			return true
+		}
+	}
+	return false
+}
+
+// validateAgentConfig runs the checks of the agent configuration reported by the ConfigValid
+// condition, and returns the first failure with its reason.
+func (r *AgentReconciler) validateAgentConfig(ctx context.Context, agent *aiv1.Agent) error {
+	checks := []struct {
+		name     string
+		reason   string
+		validate func() error
+	}{
+		{"Configuration", "InvalidConfiguration", func() error { return r.validateConfiguration(ctx, agent) }},
+		{"Endpoints", "InvalidEndpoints", func() error { return validateProviderEndpoints(agent) }},
+		{"vLLM", "InvalidVLLMConfig", func() error { return validateVLLMConfig(agent) }},
+		{"Concurrency", "InvalidConcurrencyConfig", func() error { return validateConcurrencyConfig(agent) }},
+		{"Scratch", "InvalidScratchConfig", func() error { return validateScratch(agent) }},
+		{"LangGraph", "InvalidGraph", func() error { return validateGraph(agent) }},
+		{"Tool", "InvalidTools", func() error { return validateTools(agent) }},
+		{"RAG", "InvalidRAGConfig", func() error { return r.validateRAGConfig(ctx, agent) }},
+		{"Memory", "InvalidMemoryConfig", func() error { return r.validateMemoryConfig(ctx, agent) }},
+		{"Caching", "InvalidCachingConfig", func() error { return r.validateCachingConfig(agent) }},
+		{"Image policy", "ImagePolicyViolation", func() error { return r.validateImagePolicy(ctx, agent) }},
+		{"Routing", "InvalidRoutingConfig", func() error { return r.validateRoutingConfig(ctx, agent) }},
+		{"Rate limit", "InvalidRateLimitConfig", func() error { return r.validateInboundRateLimit(agent) }},
+		{"Guardrails", "InvalidGuardrailsConfig", func() error { return r.validateGuardrails(ctx, agent) }},
+		{"Examples", "InvalidExamples", func() error { return r.validateExamples(ctx, agent) }},
+		{"Model schedule", "InvalidModelSchedule", func() error { return validateModelSchedule(agent) }},
+		{"Connectors", "InvalidConnectorConfig", func() error { return r.validateConnectors(agent) }},
+		{"Tool executor", "InvalidToolExecutor", func() error { return r.validateToolExecutor(agent) }},
+		{"Event source", "InvalidEventSourceConfig", func() error { return r.validateEventSource(ctx, agent) }},
+		{"Worker mode", "InvalidWorkerModeConfig", func() error { return r.validateWorkerMode(ctx, agent) }},
+		{"Export", "InvalidExportConfig", func() error { return r.validateExportConfig(ctx, agent) }},
+		{"Transcript sink", "InvalidTranscriptSinkConfig", func() error { return r.validateTranscriptSink(ctx, agent) }},
+		{"SLO", "InvalidSLOConfig", func() error { return validateSLO(agent) }},
+		{"Model cache", "InvalidModelCache", func() error { return r.validateModelCache(ctx, agent) }},
+		{"Persistence", "InvalidPersistenceConfig", func() error { return validatePersistence(agent) }},
+		{"Encryption", "InvalidEncryptionConfig", func() error { return r.validateEncryptionConfig(ctx, agent) }},
+		{"Security profile", "InvalidSecurityProfile", func() error { return r.validateSecurityProfile(agent) }},
+		{"Pod template overrides", "InvalidPodTemplateOverrides", func() error { return r.validatePodTemplateOverrides(agent) }},
+		{"Rollout", "InvalidRolloutConfig", func() error { return r.validateRolloutConfig(agent) }},
+		{"Experiment", "InvalidExperimentConfig", func() error { return r.validateExperimentConfig(agent) }},
+		{"Budget", "InvalidBudgetConfig", func() error { return r.validateBudgetConfig(agent) }},
+		{"Token quota", "InvalidTokenQuotaConfig", func() error { return r.validateTokenQuotaConfig(agent) }},
+		{"Synthetic probe", "InvalidSyntheticsConfig", func() error { return r.validateSyntheticsConfig(agent) }},
+		{"Health check", "InvalidHealthCheckConfig", func() error { return r.validateHealthCheckConfig(agent) }},
+		{"Admin port", "InvalidAdminPort", func() error { return r.validateAdminPort(agent) }},
+		{"Ingress", "InvalidIngressConfig", func() error { return validateIngress(agent) }},
+		{"Redaction", "InvalidRedactionConfig", func() error { return r.validateRedaction(agent) }},
+		{"Placement", "InvalidPlacement", func() error { return r.validatePlacement(agent) }},
+		{"Dependencies", "InvalidDependencies", func() error { return r.validateDependencies(ctx, agent) }},
+	}
+	for _, check := range checks {
+		if err := check.validate(); err != nil {
+			return withReason(check.reason, fmt.Errorf("%s validation failed: %w", check.name, err))
+		}
+	}
+	return nil
 }
 
 // validateConfiguration validates the agent configuration
 func (r *AgentReconciler) validateConfiguration(ctx context.Context, agent *aiv1.Agent) error {
 	// Validate provider
-	validProviders := []string{"openai", "gemini", "claude", "vllm"}
-	valid := false
-	for _, provider := range validProviders {
-		if agent.Spec.Provider == provider {
-			valid = true
-			break
-		}
-	}
-	if !valid {
+	if !isValidProvider(agent.Spec.Provider) {
 		return fmt.Errorf("invalid provider: %s, must be one of %v", agent.Spec.Provider, validProviders)
 	}
 
@@ -169,8 +658,9 @@ func (r *AgentReconciler) validateConfiguration(ctx context.Context, agent *aiv1
 	}
 
 	// Validate replicas
-	if agent.Spec.Replicas != nil && (*agent.Spec.Replicas < 1 || *agent.Spec.Replicas > 10) {
-		return fmt.Errorf("replicas must be between 1 and 10, got %d", *agent.Spec.Replicas)
+	// The configured maximum is checked by checkReplicaLimit, which does not fail the agent
+	if agent.Spec.Replicas != nil && (*agent.Spec.Replicas < 1 || *agent.Spec.Replicas > replicalimit.SanityMax) {
+		return fmt.Errorf("replicas must be between 1 and %d, got %d", replicalimit.SanityMax, *agent.Spec.Replicas)
 	}
 
 	return nil
@@ -178,25 +668,147 @@ func (r *AgentReconciler) validateConfiguration(ctx context.Context, agent *aiv1
 
 // validateSecretRef ensures that the secret referenced by the Agent exists
 func (r *AgentReconciler) validateSecretRef(ctx context.Context, agent *aiv1.Agent) error {
+	if err := r.validateSecretNamespaces(ctx, agent, agent.Spec.ApiSecretRef); err != nil {
+		return withReason("SecretNamespaceNotAllowed", err)
+	}
+
 	secret := &corev1.Secret{}
 	err := r.Get(ctx, types.NamespacedName{
 		Name:      agent.Spec.ApiSecretRef.Name,
-		Namespace: agent.Namespace,
+		Namespace: secretNamespace(agent, agent.Spec.ApiSecretRef),
 	}, secret)
-	if err != nil {
-		return fmt.Errorf("failed to get secret %s: %w", agent.Spec.ApiSecretRef.Name, err)
+	if errors.IsNotFound(err) {
+		return withReason("SecretNotFound", fmt.Errorf("secret %s not found", agent.Spec.ApiSecretRef.Name))
+	} else if err != nil {
+		return withReason("SecretUnavailable", fmt.Errorf("failed to get secret %s: %w", agent.Spec.ApiSecretRef.Name, err))
 	}
 
 	if _, exists := secret.Data[agent.Spec.ApiSecretRef.Key]; !exists {
-		return fmt.Errorf("key %s not found in secret %s", agent.Spec.ApiSecretRef.Key, agent.Spec.ApiSecretRef.Name)
+		return withReason("SecretKeyNotFound", fmt.Errorf("key %s not found in secret %s", agent.Spec.ApiSecretRef.Key, agent.Spec.ApiSecretRef.Name))
 	}
 
 	return nil
 }
 
+// graphEnd is the node name LangGraph edges use to end the workflow.
+const graphEnd = "__end__"
+
+// validateGraph checks that the nodes of a LangGraph workflow are unique, that its entry
+// point, edges and end nodes refer to them, and that their inputs and outputs match the state.
+func validateGraph(agent *aiv1.Agent) error {
+	config := agent.Spec.LanggraphConfig
+	if agent.Spec.Framework != "langgraph" || config == nil {
+		return nil
+	}
+
+	nodes := map[string]bool{}
+	for _, node := range config.Nodes {
+		if node.Name == "" {
+			return fmt.Errorf("node names must not be empty")
+		}
+		if nodes[node.Name] {
+			return fmt.Errorf("duplicate node %q", node.Name)
+		}
+		nodes[node.Name] = true
+	}
+	if !nodes[config.Entrypoint] {
+		return fmt.Errorf("entrypoint %q is not a node", config.Entrypoint)
+	}
+	for _, edge := range config.Edges {
+		if !nodes[edge.From] {
+			return fmt.Errorf("edge from %q: unknown node", edge.From)
+		}
+		if !nodes[edge.To] && edge.To != graphEnd {
+			return fmt.Errorf("edge from %q to %q: unknown node", edge.From, edge.To)
+		}
+	}
+	for _, endpoint := range config.Endpoints {
+		if !nodes[endpoint] {
+			return fmt.Errorf("endpoint %q is not a node", endpoint)
+		}
+	}
+	if errs := graphstate.Validate(field.NewPath("spec", "langgraphConfig"), config); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	return nil
+}
+
+// validateTools checks that tool names are unique and that the tool nodes of a LangGraph
+// workflow refer to tools of the agent.
+func validateTools(agent *aiv1.Agent) error {
+	tools := map[string]bool{}
+	for _, tool := range agent.Spec.Tools {
+		if tool.Name == "" {
+			return fmt.Errorf("tool names must not be empty")
+		}
+		if tools[tool.Name] {
+			return fmt.Errorf("duplicate tool %q", tool.Name)
+		}
+		tools[tool.Name] = true
+	}
+	if agent.Spec.Framework != "langgraph" || agent.Spec.LanggraphConfig == nil {
+		return nil
+	}
+	for _, node := range agent.Spec.LanggraphConfig.Nodes {
+		if node.Type == "tool" && !tools[node.Tool] {
+			return fmt.Errorf("node %q uses tool %q, which is not in spec.tools", node.Name, node.Tool)
+		}
+	}
+	return nil
+}
+
 // reconcileConfigMap creates a ConfigMap for tools and configuration
 func (r *AgentReconciler) reconcileConfigMap(ctx context.Context, agent *aiv1.Agent) error {
 	configMap := r.buildConfigMap(agent)
+	if len(agent.Spec.Peers) > 0 {
+		peers, err := r.peersConfig(ctx, agent)
+		if err != nil {
+			return err
+		}
+		configMap.Data[peersConfigKey] = peers
+	}
+	if inboundRateLimitEnabled(agent) {
+		rules, err := rateLimitConfig(agent)
+		if err != nil {
+			return err
+		}
+		configMap.Data[rateLimitConfigKey] = rules
+	}
+	if guardrailsEnabled(agent) {
+		policy, err := r.guardrailsPolicy(ctx, agent)
+		if err != nil {
+			return err
+		}
+		config, err := guardrailsConfig(policy)
+		if err != nil {
+			return err
+		}
+		configMap.Data[guardrailsConfigKey] = config
+		r.setGuardrailsCondition(agent, &policy)
+	} else {
+		r.setGuardrailsCondition(agent, nil)
+	}
+	if examplesEnabled(agent) {
+		config, err := r.examplesConfig(ctx, agent)
+		if err != nil {
+			return err
+		}
+		configMap.Data[examplesConfigKey] = config
+	}
+	if modelScheduleHotReload(agent) {
+		config, err := modelScheduleConfig(agent)
+		if err != nil {
+			return err
+		}
+		configMap.Data[modelScheduleConfigKey] = config
+	}
+	if toolExecutorEnabled(agent) {
+		config, err := toolExecutorConfig(agent)
+		if err != nil {
+			return err
+		}
+		configMap.Data[toolExecutorConfigKey] = config
+	}
 	if err := controllerutil.SetControllerReference(agent, configMap, r.Scheme); err != nil {
 		return err
 	}
@@ -205,41 +817,60 @@ func (r *AgentReconciler) reconcileConfigMap(ctx context.Context, agent *aiv1.Ag
 	err := r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		log.FromContext(ctx).Info("Creating new ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+		configMap.Annotations = setAppliedHash(configMap.Annotations, configMapAppliedHash(configMap))
 		return r.Create(ctx, configMap)
 	} else if err != nil {
 		return err
 	}
 
+	// Paused agents keep the ConfigMap as edited by hand.
+	if agentPaused(agent) {
+		log.FromContext(ctx).V(1).Info("Agent paused, leaving ConfigMap as is", "ConfigMap.Name", found.Name)
+		return nil
+	}
+
 	log.FromContext(ctx).Info("Updating existing ConfigMap", "ConfigMap.Namespace", found.Namespace, "ConfigMap.Name", found.Name)
+	hash := configMapAppliedHash(configMap)
+	r.recordManualChanges(agent, "ConfigMap", found.Name, found.Annotations[appliedHashAnnotation], hash, configMapDrift(configMap, found))
+	found.Annotations = setAppliedHash(found.Annotations, hash)
 	found.Data = configMap.Data
+	adoptLabels(found, configMap.Labels)
 	return r.Update(ctx, found)
 }
 
 // buildConfigMap creates a ConfigMap with tools and configuration
 func (r *AgentReconciler) buildConfigMap(agent *aiv1.Agent) *corev1.ConfigMap {
-	labels := map[string]string{
-		"app.kubernetes.io/name":     "kubeagentic-agent",
-		"app.kubernetes.io/instance": agent.Name,
-		"kubeagentic.ai/agent":       agent.Name,
-	}
+	labels := agentLabels(agent)
 
 	data := make(map[string]string)
-	
+
 	// Add tools configuration
 	if len(agent.Spec.Tools) > 0 {
 		toolsJSON, _ := json.Marshal(agent.Spec.Tools)
 		data["tools.json"] = string(toolsJSON)
 	}
 
-	// Add LangGraph configuration
-	if agent.Spec.LanggraphConfig != nil {
+	// Add LangGraph configuration, used by the langgraph framework only
+	if agent.Spec.LanggraphConfig != nil && agent.Spec.Framework == "langgraph" {
 		configJSON, _ := json.Marshal(agent.Spec.LanggraphConfig)
 		data["langgraph-config.json"] = string(configJSON)
 	}
 
+	// Add RAG configuration; the connection string stays in its Secret
+	if ragEnabled(agent) {
+		ragJSON, _ := json.Marshal(agent.Spec.RAG)
+		data["rag-config.json"] = string(ragJSON)
+	}
+
+	// Add conversation memory configuration; the connection URL stays in its Secret
+	if agent.Spec.Memory != nil {
+		memoryJSON, _ := json.Marshal(agent.Spec.Memory)
+		data["memory-config.json"] = string(memoryJSON)
+	}
+
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      agent.Name + "-config",
+			Name:      configMapName(agent),
 			Namespace: agent.Namespace,
 			Labels:    labels,
 		},
@@ -259,15 +890,199 @@ func (r *AgentReconciler) cleanupResources(ctx context.Context, agent *aiv1.Agen
 	agent.Status.LastUpdated = &now
 	r.Status().Update(ctx, agent)
 
+	// Remove copied credentials right away
+	if err := r.deleteSecretCopies(ctx, agent, nil); err != nil {
+		return err
+	}
+
+	deleteSyntheticsMetrics(agent)
+	deleteBudgetMetrics(agent)
+	deleteTokenQuotaMetrics(agent)
+	deleteModelDeprecationMetrics(agent)
+	deleteHealthMetrics(agent)
+	r.usage.forget(types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace})
+	r.resetFailureBackoff(agent)
+
 	return nil
 }
 
+// findAgentsForSecret maps a Secret event to the Agents that reference it, in its namespace
+// or across namespaces, so that creating or rotating a secret is picked up without waiting
+// for the periodic requeue.
+func (r *AgentReconciler) findAgentsForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for secret", "secret", secret.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, agent := range agents.Items {
+		if referencesSecret(&agent, secret.GetNamespace(), secret.GetName()) {
+			// The secret may fix the agent, which should not wait for its backoff to retry
+			r.resetFailureBackoff(&agent)
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// referencesSecret reports whether the agent depends on the given Secret.
+func referencesSecret(agent *aiv1.Agent, namespace, name string) bool {
+	if namespace == agent.Namespace {
+		for _, referenced := range referencedSecretNames(agent) {
+			if referenced == name {
+				return true
+			}
+		}
+		return false
+	}
+	for _, ref := range crossNamespaceSecretRefs(agent) {
+		if secretNamespace(agent, ref) == namespace && ref.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// findAgentsForPolicy maps an AgentPolicy to all Agents in its namespace.
+func (r *AgentReconciler) findAgentsForPolicy(ctx context.Context, policy client.Object) []reconcile.Request {
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents, client.InNamespace(policy.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for policy", "policy", policy.GetName())
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(agents.Items))
+	for _, agent := range agents.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+		})
+	}
+	return requests
+}
+
+// referencedSecretNames returns the names of all Secrets in its namespace the agent depends
+// on, including the synced copies of cross-namespace Secrets.
+func referencedSecretNames(agent *aiv1.Agent) []string {
+	var names []string
+	if agent.Spec.ApiSecretRef != nil {
+		names = append(names, localSecretKeySelector(agent, agent.Spec.ApiSecretRef).Name)
+	}
+	if ragEnabled(agent) && agent.Spec.RAG.VectorStore != nil {
+		names = append(names, agent.Spec.RAG.VectorStore.ConnectionSecretRef.Name)
+	}
+	if embeddings := effectiveEmbeddings(agent); embeddings != nil && embeddings.ApiSecretRef != nil {
+		names = append(names, localSecretKeySelector(agent, embeddings.ApiSecretRef).Name)
+	}
+	if ragEnabled(agent) && agent.Spec.RAG.Ingestion != nil {
+		for _, source := range agent.Spec.RAG.Ingestion.Sources {
+			if source.Bucket != nil && source.Bucket.CredentialsSecretRef != nil {
+				names = append(names, source.Bucket.CredentialsSecretRef.Name)
+			}
+		}
+	}
+	if agent.Spec.Export != nil {
+		names = append(names, agent.Spec.Export.CredentialsSecretRef.Name)
+	}
+	if agent.Spec.Memory != nil && agent.Spec.Memory.ConnectionSecretRef != nil {
+		names = append(names, agent.Spec.Memory.ConnectionSecretRef.Name)
+	}
+	if encryption := agent.Spec.Encryption; encryption != nil {
+		names = append(names, encryption.KeySecretRef.Name)
+		if encryption.PreviousKeySecretRef != nil {
+			names = append(names, encryption.PreviousKeySecretRef.Name)
+		}
+	}
+	if endpointAuthEnabled(agent) {
+		names = append(names, endpointAuthSecretName(agent))
+	}
+	if bindingEnabled(agent) {
+		names = append(names, bindingSecretName(agent))
+	}
+	if adminPortEnabled(agent) {
+		names = append(names, adminAuthSecretName(agent))
+	}
+	names = append(names, peerSecretNames(agent)...)
+	names = append(names, connectorSecretNames(agent)...)
+	names = append(names, eventSourceSecretNames(agent)...)
+	names = append(names, jobQueueSecretNames(agent)...)
+	return names
+}
+
 // SetupWithManager sets up the controller with the Manager
 func (r *AgentReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	referenced, err := NewReferencedCache(mgr)
+	if err != nil {
+		return err
+	}
+	// Each priority class has a controller of its own, with its own queue and workers
+	for _, class := range PriorityClasses {
+		if err := r.setupPriorityController(mgr, class, referenced); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setupPriorityController sets up the controller reconciling the agents of class. The
+// objects the agents refer to that the manager cache does not hold are watched through
+// referenced.
+func (r *AgentReconciler) setupPriorityController(mgr ctrl.Manager, class PriorityClass, referenced cache.Cache) error {
+	name := priorityControllerName(class)
+	items := r.QueueLimiter
+	if items == nil {
+		items = &queuelimit.ItemLimiter{}
+	}
+	// The events of the objects the agents refer to are delayed per agent, so that an object
+	// rewritten in a loop does not starve the other agents; spec changes and the events of
+	// owned objects are not delayed. Events for the agents of the other classes are dropped
+	// before they count.
+	events := AgentEvents{Controller: name, Class: class, Limiter: items, Reader: mgr.GetClient()}
+	limited := events.Referenced
+	owned := events.Owned(mgr.GetScheme(), mgr.GetRESTMapper())
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&aiv1.Agent{}).
-		Owns(&appsv1.Deployment{}).
-		Owns(&corev1.Service{}).
-		Owns(&corev1.ConfigMap{}).
+		Named(name).
+		WithOptions(controller.Options{
+			RateLimiter:             queuelimit.NewRateLimiter(items, r.QueueQPS, r.QueueBurst),
+			MaxConcurrentReconciles: r.priorityWorkers(class),
+		}).
+		// Status writes do not change the generation, so they do not trigger a reconcile;
+		// annotations and labels carry requests such as restarts and rollbacks
+		For(&aiv1.Agent{}, builder.WithPredicates(priorityPredicate(class), predicate.Or(
+			predicate.GenerationChangedPredicate{},
+			predicate.AnnotationChangedPredicate{},
+			predicate.LabelChangedPredicate{},
+		))).
+		Watches(&appsv1.Deployment{}, owned).
+		Watches(&corev1.Service{}, owned).
+		Watches(&corev1.ConfigMap{}, owned).
+		Watches(&batchv1.Job{}, owned).
+		Watches(&batchv1.CronJob{}, owned).
+		Watches(&networkingv1.NetworkPolicy{}, owned).
+		Watches(&policyv1.PodDisruptionBudget{}, owned).
+		Watches(&corev1.Secret{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForSecret))).
+		Watches(&aiv1.AgentPolicy{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForPolicy))).
+		Watches(&corev1.ConfigMap{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForFleetRollout))).
+		// The ConfigMaps users create for the agents are not labelled, so their metadata is
+		// watched through a cache of its own
+		WatchesRawSource(source.Kind(referenced, ConfigMapMetadata()), limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForConfigMap))).
+		// Dependencies are re-evaluated when the agents and Services they refer to change
+		Watches(&aiv1.Agent{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForDependencyAgent))).
+		Watches(&discoveryv1.EndpointSlice{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForEndpointSlice))).
+		// peers.json follows the Service names and endpoint auth of the peers
+		Watches(&aiv1.Agent{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForPeerAgent))).
+		// Placements follow the creation and scaling of the agents they refer to
+		Watches(&aiv1.Agent{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForPlacementAgent))).
+		// Promotions follow the Agents they create and the AgentPolicies accepting them
+		Watches(&aiv1.Agent{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForPromotion))).
+		Watches(&aiv1.AgentPolicy{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForPromotion))).
+		// Agents waiting for an Ingress host get it once the agent owning it releases it
+		Watches(&aiv1.Agent{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForIngressHost))).
+		// Pods rejected by the namespace limits are reported until they fit
+		Watches(&corev1.LimitRange{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForNamespaceLimits))).
+		Watches(&corev1.ResourceQuota{}, limited(handler.EnqueueRequestsFromMapFunc(r.findAgentsForNamespaceLimits))).
 		Complete(r)
 }
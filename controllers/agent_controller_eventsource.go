@@ -0,0 +1,501 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+const (
+	defaultEventConcurrency       = 1
+	defaultEventMaxAttempts       = 3
+	defaultEventMinReplicas       = 1
+	defaultEventMaxReplicas       = 10
+	defaultEventTargetBacklog     = 10
+	eventSourceChecksumAnnotation = "kubeagentic.ai/event-source-checksum"
+)
+
+// Keys of the event source credentials Secrets.
+const (
+	kafkaUsernameKey      = "username"
+	kafkaPasswordKey      = "password"
+	awsAccessKeyIDKey     = "AWS_ACCESS_KEY_ID"
+	awsSecretAccessKeyKey = "AWS_SECRET_ACCESS_KEY"
+)
+
+// eventSourceCheckScript checks that the event source answers: a Kafka broker accepts TCP
+// connections, or the SQS queue can be read with the credentials of the agent.
+const eventSourceCheckScript = `import os, socket, sys
+if os.environ["AGENT_EVENT_SOURCE"] == "kafka":
+    errors = []
+    for broker in os.environ["KAFKA_BOOTSTRAP_SERVERS"].split(","):
+        host, _, port = broker.rpartition(":")
+        try:
+            socket.create_connection((host, int(port)), timeout=10).close()
+            print(f"kafka broker {broker} reachable")
+            sys.exit(0)
+        except OSError as e:
+            errors.append(f"{broker}: {e}")
+    print("kafka brokers unreachable: " + "; ".join(errors))
+    sys.exit(1)
+import boto3
+try:
+    boto3.client("sqs", region_name=os.environ["AWS_REGION"]).get_queue_attributes(QueueUrl=os.environ["SQS_QUEUE_URL"], AttributeNames=["QueueArn"])
+except Exception as e:
+    print(f"sqs queue {os.environ['SQS_QUEUE_URL']} unreachable: {e}")
+    sys.exit(1)
+print(f"sqs queue {os.environ['SQS_QUEUE_URL']} reachable")
+`
+
+// eventSourceEnabled reports whether the agent consumes its work from a queue.
+func eventSourceEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.EventSource != nil
+}
+
+// eventSourceAutoscaled reports whether KEDA scales the agent on the backlog of its queue.
+func eventSourceAutoscaled(agent *aiv1.Agent) bool {
+	return eventSourceEnabled(agent) && agent.Spec.EventSource.Autoscaling != nil && agent.Spec.EventSource.Autoscaling.Enabled
+}
+
+// eventSourceCredentials returns the credentials Secret of the event source and the keys
+// it must hold, or nil when the source uses none.
+func eventSourceCredentials(agent *aiv1.Agent) (*corev1.LocalObjectReference, []string) {
+	source := agent.Spec.EventSource
+	switch {
+	case source.Type == "kafka" && source.Kafka != nil && source.Kafka.CredentialsSecretRef != nil:
+		return source.Kafka.CredentialsSecretRef, []string{kafkaUsernameKey, kafkaPasswordKey}
+	case source.Type == "sqs" && source.SQS != nil && source.SQS.CredentialsSecretRef != nil:
+		return source.SQS.CredentialsSecretRef, []string{awsAccessKeyIDKey, awsSecretAccessKeyKey}
+	}
+	return nil, nil
+}
+
+// kafkaConsumerGroup returns the consumer group of the agent replicas.
+func kafkaConsumerGroup(agent *aiv1.Agent) string {
+	if group := agent.Spec.EventSource.Kafka.ConsumerGroup; group != "" {
+		return group
+	}
+	return agent.Namespace + "." + agent.Name
+}
+
+// validateEventSource checks the event source of the agent, its credentials Secret and,
+// when it scales the agent, that KEDA is installed.
+func (r *AgentReconciler) validateEventSource(ctx context.Context, agent *aiv1.Agent) error {
+	if !eventSourceEnabled(agent) {
+		return nil
+	}
+	source := agent.Spec.EventSource
+	switch source.Type {
+	case "kafka":
+		if source.Kafka == nil {
+			return fmt.Errorf("eventSource.kafka is required for kafka event sources")
+		}
+		if len(source.Kafka.Brokers) == 0 {
+			return fmt.Errorf("eventSource.kafka.brokers must not be empty")
+		}
+		for _, broker := range source.Kafka.Brokers {
+			if _, port, err := net.SplitHostPort(broker); err != nil || port == "" {
+				return fmt.Errorf("eventSource.kafka.brokers: %q is not host:port", broker)
+			}
+		}
+		if source.Kafka.Topic == "" {
+			return fmt.Errorf("eventSource.kafka.topic is required")
+		}
+		if source.Kafka.SASLMechanism != "" && source.Kafka.CredentialsSecretRef == nil {
+			return fmt.Errorf("eventSource.kafka.credentialsSecretRef is required with SASL mechanism %s", source.Kafka.SASLMechanism)
+		}
+	case "sqs":
+		if source.SQS == nil {
+			return fmt.Errorf("eventSource.sqs is required for sqs event sources")
+		}
+		if !strings.HasPrefix(source.SQS.QueueURL, "https://") && !strings.HasPrefix(source.SQS.QueueURL, "http://") {
+			return fmt.Errorf("eventSource.sqs.queueURL must be an http(s) URL")
+		}
+		if source.SQS.Region == "" {
+			return fmt.Errorf("eventSource.sqs.region is required")
+		}
+	default:
+		return fmt.Errorf("invalid eventSource.type %q, must be kafka or sqs", source.Type)
+	}
+
+	if ingressEnabled(agent) && !source.AllowIngress {
+		return fmt.Errorf("agents consuming an event source are not exposed through an Ingress unless eventSource.allowIngress is set")
+	}
+
+	if ref, keys := eventSourceCredentials(agent); ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: agent.Namespace}, secret); err != nil {
+			return fmt.Errorf("failed to get event source secret %s: %w", ref.Name, err)
+		}
+		for _, key := range keys {
+			if _, ok := secret.Data[key]; !ok {
+				return fmt.Errorf("key %s not found in event source secret %s", key, ref.Name)
+			}
+		}
+	}
+
+	if scaling := source.Autoscaling; scaling != nil && scaling.Enabled {
+		minReplicas, maxReplicas := eventScalingBounds(agent)
+		if minReplicas > maxReplicas {
+			return fmt.Errorf("eventSource.autoscaling.minReplicas %d exceeds maxReplicas %d", minReplicas, maxReplicas)
+		}
+		if !r.kedaInstalled() {
+			return fmt.Errorf("eventSource.autoscaling requires KEDA, whose ScaledObject CRD is not installed")
+		}
+	}
+	return nil
+}
+
+// eventScalingBounds returns the minimum and maximum replicas KEDA scales the agent to.
+func eventScalingBounds(agent *aiv1.Agent) (int32, int32) {
+	scaling := agent.Spec.EventSource.Autoscaling
+	minReplicas, maxReplicas := int32(defaultEventMinReplicas), int32(defaultEventMaxReplicas)
+	if scaling.MinReplicas != nil {
+		minReplicas = *scaling.MinReplicas
+	}
+	if scaling.MaxReplicas != nil {
+		maxReplicas = *scaling.MaxReplicas
+	}
+	return minReplicas, maxReplicas
+}
+
+// eventSourceEnv returns the environment variables switching the agent runtime into
+// consumer mode, or nil without an event source.
+func eventSourceEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !eventSourceEnabled(agent) {
+		return nil
+	}
+	source := agent.Spec.EventSource
+	concurrency := int32(defaultEventConcurrency)
+	if source.Concurrency != nil {
+		concurrency = *source.Concurrency
+	}
+	maxAttempts := int32(defaultEventMaxAttempts)
+	deadLetterTarget := ""
+	if source.DeadLetter != nil {
+		if source.DeadLetter.MaxAttempts != nil {
+			maxAttempts = *source.DeadLetter.MaxAttempts
+		}
+		deadLetterTarget = source.DeadLetter.Target
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "AGENT_MODE", Value: "consumer"},
+		{Name: "AGENT_EVENT_SOURCE", Value: source.Type},
+		{Name: "AGENT_EVENT_CONCURRENCY", Value: strconv.Itoa(int(concurrency))},
+		{Name: "AGENT_EVENT_MAX_ATTEMPTS", Value: strconv.Itoa(int(maxAttempts))},
+	}
+	if deadLetterTarget != "" {
+		env = append(env, corev1.EnvVar{Name: "AGENT_EVENT_DEAD_LETTER_TARGET", Value: deadLetterTarget})
+	}
+	return append(env, eventSourceConnectionEnv(agent)...)
+}
+
+// eventSourceConnectionEnv returns the environment variables locating the event source and
+// its credentials, shared by the agent container and the connectivity check.
+func eventSourceConnectionEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	source := agent.Spec.EventSource
+	var env []corev1.EnvVar
+	secretEnv := func(name, key string) {
+		ref, _ := eventSourceCredentials(agent)
+		env = append(env, corev1.EnvVar{
+			Name: name,
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: *ref, Key: key},
+			},
+		})
+	}
+	switch {
+	case source.Type == "kafka" && source.Kafka != nil:
+		kafka := source.Kafka
+		env = append(env,
+			corev1.EnvVar{Name: "KAFKA_BOOTSTRAP_SERVERS", Value: strings.Join(kafka.Brokers, ",")},
+			corev1.EnvVar{Name: "KAFKA_TOPIC", Value: kafka.Topic},
+			corev1.EnvVar{Name: "KAFKA_CONSUMER_GROUP", Value: kafkaConsumerGroup(agent)},
+		)
+		if kafka.TLS {
+			env = append(env, corev1.EnvVar{Name: "KAFKA_TLS", Value: "true"})
+		}
+		if kafka.SASLMechanism != "" {
+			env = append(env, corev1.EnvVar{Name: "KAFKA_SASL_MECHANISM", Value: kafka.SASLMechanism})
+		}
+		if kafka.CredentialsSecretRef != nil {
+			secretEnv("KAFKA_SASL_USERNAME", kafkaUsernameKey)
+			secretEnv("KAFKA_SASL_PASSWORD", kafkaPasswordKey)
+		}
+	case source.Type == "sqs" && source.SQS != nil:
+		env = append(env,
+			corev1.EnvVar{Name: "SQS_QUEUE_URL", Value: source.SQS.QueueURL},
+			corev1.EnvVar{Name: "AWS_REGION", Value: source.SQS.Region},
+		)
+		if source.SQS.CredentialsSecretRef != nil {
+			secretEnv("AWS_ACCESS_KEY_ID", awsAccessKeyIDKey)
+			secretEnv("AWS_SECRET_ACCESS_KEY", awsSecretAccessKeyKey)
+		}
+	}
+	return env
+}
+
+// eventSourceSecretNames returns the name of the credentials Secret of the event source.
+func eventSourceSecretNames(agent *aiv1.Agent) []string {
+	if !eventSourceEnabled(agent) {
+		return nil
+	}
+	if ref, _ := eventSourceCredentials(agent); ref != nil {
+		return []string{ref.Name}
+	}
+	return nil
+}
+
+// eventSourceEndpoints returns the Kafka brokers the agent pods connect to, as URLs for
+// their egress rules. SQS is reached over HTTPS, at addresses not stable enough to pin.
+func eventSourceEndpoints(agent *aiv1.Agent) []string {
+	if !eventSourceEnabled(agent) || agent.Spec.EventSource.Type != "kafka" || agent.Spec.EventSource.Kafka == nil {
+		return nil
+	}
+	var endpoints []string
+	for _, broker := range agent.Spec.EventSource.Kafka.Brokers {
+		endpoints = append(endpoints, "tcp://"+broker)
+	}
+	return endpoints
+}
+
+// eventSourceChecksum returns a hash of the event source connection and credentials, to
+// re-run the connectivity check when they change.
+func (r *AgentReconciler) eventSourceChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	hash := sha256.New()
+	data, err := json.Marshal(eventSourceConnectionEnv(agent))
+	if err != nil {
+		return "", err
+	}
+	hash.Write(data)
+	if ref, keys := eventSourceCredentials(agent); ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: agent.Namespace}, secret); err != nil {
+			return "", fmt.Errorf("failed to get event source secret %s: %w", ref.Name, err)
+		}
+		for _, key := range keys {
+			hash.Write([]byte{0})
+			hash.Write(secret.Data[key])
+		}
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// reconcileEventSourceCheck runs the pre-flight connectivity Job of the event source and
+// records its outcome in the EventSourceReachable condition.
+func (r *AgentReconciler) reconcileEventSourceCheck(ctx context.Context, agent *aiv1.Agent) error {
+	name := naming.Child(agent.Name, "event-source-check")
+	found := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if !eventSourceEnabled(agent) {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionEventSourceReachable)
+		if exists {
+			log.FromContext(ctx).Info("Deleting event source check Job", "Job.Name", found.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+		}
+		return nil
+	}
+
+	checksum, err := r.eventSourceChecksum(ctx, agent)
+	if err != nil {
+		return err
+	}
+
+	now := metav1.NewTime(time.Now())
+	condition := aiv1.AgentCondition{
+		Type:               aiv1.AgentConditionEventSourceReachable,
+		Status:             corev1.ConditionUnknown,
+		Reason:             "Checking",
+		Message:            "Event source connectivity check is running",
+		LastTransitionTime: &now,
+	}
+
+	// Job templates are immutable, so a changed connection means a fresh check. The
+	// replacement Job is created once the deletion of the old one triggers a reconcile.
+	if exists && found.Annotations[eventSourceChecksumAnnotation] != checksum {
+		log.FromContext(ctx).Info("Event source connection changed, re-running check", "Job.Name", found.Name)
+		agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, condition)
+		return client.IgnoreNotFound(r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+	}
+
+	if !exists {
+		job := r.buildEventSourceCheckJob(agent, name, checksum)
+		if err := controllerutil.SetControllerReference(agent, job, r.Scheme); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Creating event source check Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+		if err := r.Create(ctx, job); err != nil {
+			return err
+		}
+		found = job
+	}
+
+	if found.Status.Succeeded > 0 {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "Reachable"
+		condition.Message = fmt.Sprintf("The %s event source is reachable", agent.Spec.EventSource.Type)
+	} else if jobFailed(found) {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "Unreachable"
+		condition.Message = "Event source connectivity check failed, see logs of Job " + found.Name
+	}
+	agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, condition)
+	return nil
+}
+
+// buildEventSourceCheckJob creates the pre-flight Job that verifies the event source is reachable.
+func (r *AgentReconciler) buildEventSourceCheckJob(agent *aiv1.Agent, name, checksum string) *batchv1.Job {
+	labels := map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-job",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "event-source-check",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+	env := append([]corev1.EnvVar{{Name: "AGENT_EVENT_SOURCE", Value: agent.Spec.EventSource.Type}}, eventSourceConnectionEnv(agent)...)
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				eventSourceChecksumAnnotation: checksum,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: int32Ptr(2),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "check",
+							Image:   r.getAgentImage(agent),
+							Command: []string{"python", "-c", eventSourceCheckScript},
+							Env:     env,
+						},
+					},
+				},
+			},
+		},
+	}
+
+	securityprofile.Apply(securityProfile(agent), &job.Spec.Template.Spec, agentUID)
+	return job
+}
+
+// reconcileEventSourceScaling keeps the KEDA ScaledObject scaling the agent on the backlog
+// of its queue, with the TriggerAuthentication passing it the credentials of the source,
+// and deletes them once the agent is no longer scaled by KEDA.
+func (r *AgentReconciler) reconcileEventSourceScaling(ctx context.Context, agent *aiv1.Agent) error {
+	name := naming.Child(agent.Name, "event-source")
+	if !eventSourceAutoscaled(agent) {
+		return r.deleteKEDAObjects(ctx, agent.Namespace, name, scaledObjectGVK, triggerAuthenticationGVK)
+	}
+	scaledObject, triggerAuthentication := buildEventSourceScaling(agent, name)
+	maxReplicas, err := r.autoscalingMaxReplicas(ctx, agent, eventSourceAutoscaler)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(scaledObject.Object, int64(maxReplicas), "spec", "maxReplicaCount"); err != nil {
+		return err
+	}
+	return r.reconcileScaledObject(ctx, agent, scaledObject, triggerAuthentication)
+}
+
+// buildEventSourceScaling returns the ScaledObject scaling the agent Deployment on the
+// backlog of its queue, and the TriggerAuthentication of the source credentials, or nil
+// when the source has none.
+func buildEventSourceScaling(agent *aiv1.Agent, name string) (*unstructured.Unstructured, *unstructured.Unstructured) {
+	source := agent.Spec.EventSource
+	minReplicas, maxReplicas := eventScalingBounds(agent)
+	targetBacklog := int32(defaultEventTargetBacklog)
+	if source.Autoscaling.TargetBacklog != nil {
+		targetBacklog = *source.Autoscaling.TargetBacklog
+	}
+	backlog := strconv.Itoa(int(targetBacklog))
+
+	var trigger map[string]interface{}
+	var secretTargets []interface{}
+	secretTarget := func(parameter, key string) {
+		ref, _ := eventSourceCredentials(agent)
+		secretTargets = append(secretTargets, map[string]interface{}{"parameter": parameter, "name": ref.Name, "key": key})
+	}
+	switch source.Type {
+	case "kafka":
+		metadata := map[string]interface{}{
+			"bootstrapServers": strings.Join(source.Kafka.Brokers, ","),
+			"consumerGroup":    kafkaConsumerGroup(agent),
+			"topic":            source.Kafka.Topic,
+			"lagThreshold":     backlog,
+		}
+		if source.Kafka.TLS {
+			metadata["tls"] = "enable"
+		}
+		if source.Kafka.SASLMechanism != "" {
+			metadata["sasl"] = map[string]string{"PLAIN": "plaintext", "SCRAM-SHA-256": "scram_sha256", "SCRAM-SHA-512": "scram_sha512"}[source.Kafka.SASLMechanism]
+		}
+		if source.Kafka.CredentialsSecretRef != nil {
+			secretTarget("username", kafkaUsernameKey)
+			secretTarget("password", kafkaPasswordKey)
+		}
+		trigger = map[string]interface{}{"type": "kafka", "metadata": metadata}
+	case "sqs":
+		metadata := map[string]interface{}{
+			"queueURL":    source.SQS.QueueURL,
+			"queueLength": backlog,
+			"awsRegion":   source.SQS.Region,
+		}
+		if source.SQS.CredentialsSecretRef != nil {
+			secretTarget("awsAccessKeyID", awsAccessKeyIDKey)
+			secretTarget("awsSecretAccessKey", awsSecretAccessKeyKey)
+		} else {
+			// KEDA reads the queue with its own credentials
+			metadata["identityOwner"] = "operator"
+		}
+		trigger = map[string]interface{}{"type": "aws-sqs-queue", "metadata": metadata}
+	}
+
+	var triggerAuthentication *unstructured.Unstructured
+	if len(secretTargets) > 0 {
+		trigger["authenticationRef"] = map[string]interface{}{"name": name}
+		triggerAuthentication = newKEDAObject(triggerAuthenticationGVK, agent, name, map[string]interface{}{"secretTargetRef": secretTargets})
+	}
+
+	scaledObject := newKEDAObject(scaledObjectGVK, agent, name, map[string]interface{}{
+		"scaleTargetRef":  map[string]interface{}{"name": deploymentName(agent)},
+		"minReplicaCount": int64(minReplicas),
+		"maxReplicaCount": int64(maxReplicas),
+		"triggers":        []interface{}{trigger},
+	})
+	return scaledObject, triggerAuthentication
+}
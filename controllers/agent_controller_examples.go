@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/examples"
+)
+
+const (
+	// examplesMountPath is where examples.json is mounted in the agent container.
+	examplesMountPath = "/etc/kubeagentic/examples"
+	// examplesConfigKey is the key of the agent ConfigMap holding the rendered examples.
+	examplesConfigKey = "examples.json"
+	// examplesChecksumAnnotation rolls the agent pods when the examples change.
+	examplesChecksumAnnotation = "kubeagentic.ai/examples-checksum"
+	// examplesConfigMapName is the app.kubernetes.io/name label that gets the example
+	// ConfigMaps of the users cached and watched, so that their edits are applied at once
+	// rather than at the next reconcile of the agent.
+	examplesConfigMapName = "kubeagentic-examples"
+)
+
+// examplesEnabled reports whether the agent is seeded with few-shot examples.
+func examplesEnabled(agent *aiv1.Agent) bool {
+	return len(agent.Spec.Examples) > 0 || agent.Spec.ExamplesRef != nil
+}
+
+// examplesConfigMap returns the name of the ConfigMap holding the examples of the agent, or
+// "" when it has none or lists them inline.
+func examplesConfigMap(agent *aiv1.Agent) string {
+	if agent.Spec.ExamplesRef == nil {
+		return ""
+	}
+	return agent.Spec.ExamplesRef.Name
+}
+
+// validateExamples checks the examples of the agent: inline or referenced but not both, and
+// within the limits of the examples package once their ConfigMap exists.
+func (r *AgentReconciler) validateExamples(ctx context.Context, agent *aiv1.Agent) error {
+	ref := agent.Spec.ExamplesRef
+	if ref == nil {
+		if err := examples.Validate(agent.Spec.Examples, examples.MaxInline); err != nil {
+			return fmt.Errorf("examples: %w", err)
+		}
+		return nil
+	}
+	if len(agent.Spec.Examples) > 0 {
+		return fmt.Errorf("examples and examplesRef cannot be combined")
+	}
+	if ref.Name == "" || ref.Key == "" {
+		return fmt.Errorf("examplesRef requires a name and a key")
+	}
+	if ref.Optional != nil && *ref.Optional {
+		return fmt.Errorf("examplesRef cannot be optional")
+	}
+	_, err := r.agentExamples(ctx, agent)
+	return err
+}
+
+// agentExamples returns the examples of the agent, read from its ConfigMap when referenced.
+func (r *AgentReconciler) agentExamples(ctx context.Context, agent *aiv1.Agent) ([]aiv1.Example, error) {
+	ref := agent.Spec.ExamplesRef
+	if ref == nil {
+		return agent.Spec.Examples, nil
+	}
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: agent.Namespace}, configMap, referenced); err != nil {
+		return nil, fmt.Errorf("failed to get the examples configmap %s: %w", ref.Name, err)
+	}
+	data, ok := configMap.Data[ref.Key]
+	if !ok {
+		return nil, fmt.Errorf("examples configmap %s has no key %s", ref.Name, ref.Key)
+	}
+	parsed, err := examples.Parse([]byte(data))
+	if err != nil {
+		return nil, fmt.Errorf("configmap %s key %s: %w", ref.Name, ref.Key, err)
+	}
+	return parsed, nil
+}
+
+// examplesConfig returns the examples.json of the agent ConfigMap, read by the runtime.
+func (r *AgentReconciler) examplesConfig(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	parsed, err := r.agentExamples(ctx, agent)
+	if err != nil {
+		return "", err
+	}
+	return examples.Render(parsed)
+}
+
+// examplesChecksum returns a hash of the rendered examples, or an empty string without
+// examples.
+func (r *AgentReconciler) examplesChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if !examplesEnabled(agent) {
+		return "", nil
+	}
+	config, err := r.examplesConfig(ctx, agent)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// examplesVolume returns the volume projecting examples.json of the agent ConfigMap, its
+// mount in the agent container and the environment variable locating it, or nil without
+// examples.
+func examplesVolume(agent *aiv1.Agent) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	if !examplesEnabled(agent) {
+		return nil, nil, nil
+	}
+	volume := corev1.Volume{
+		Name: "examples",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName(agent)},
+				Items:                []corev1.KeyToPath{{Key: examplesConfigKey, Path: examplesConfigKey}},
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      "examples",
+		MountPath: examplesMountPath,
+		ReadOnly:  true,
+	}
+	env := []corev1.EnvVar{{Name: "AGENT_EXAMPLES_FILE", Value: examplesMountPath + "/" + examplesConfigKey}}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}, env
+}
@@ -0,0 +1,381 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentmetrics"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	// variantLabel marks the pods of experiment variant B. Variant A pods are the regular
+	// agent pods, which do not carry the label.
+	variantLabel = "kubeagentic.ai/variant"
+	// experimentWinnerAnnotation on the agent names the winning variant of a completed
+	// experiment with the manual winner policy.
+	experimentWinnerAnnotation = "kubeagentic.ai/experiment-winner"
+
+	variantA = "a"
+	variantB = "b"
+
+	successMetricLatency = "latency"
+	successMetricTokens  = "tokens"
+	winnerPolicyAuto     = "auto"
+
+	// defaultTrafficPercent is the share of pods running variant B when not configured.
+	defaultTrafficPercent = 50
+)
+
+// variantBDeploymentName returns the name of the Deployment running experiment variant B.
+func variantBDeploymentName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "variant-b")
+}
+
+// experimentConfigHash returns a hash identifying the experiment configuration.
+func experimentConfigHash(experiment *aiv1.ExperimentConfig) string {
+	data, _ := json.Marshal(experiment)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+// variantSelector returns the selector of the running pods of an experiment variant.
+func variantSelector(agent *aiv1.Agent, variant string) labels.Selector {
+	operator := selection.Equals
+	if variant == variantA {
+		operator = selection.NotIn
+	}
+	requirement, _ := labels.NewRequirement(variantLabel, operator, []string{variantB})
+	return labels.SelectorFromSet(labels.Set{"kubeagentic.ai/agent": agent.Name}).Add(*requirement)
+}
+
+// validateExperimentConfig checks the experiment settings. Experiments split the pods
+// themselves, so they cannot run next to a rollout strategy doing the same.
+func (r *AgentReconciler) validateExperimentConfig(agent *aiv1.Agent) error {
+	experiment := agent.Spec.Experiment
+	if experiment == nil {
+		return nil
+	}
+	if agent.Spec.Rollout != nil {
+		return fmt.Errorf("experiment cannot be combined with rollout; remove spec.rollout while the experiment runs")
+	}
+	if experiment.Duration.Duration <= 0 {
+		return fmt.Errorf("experiment.duration must be positive")
+	}
+	variant := experiment.VariantB
+	if variant.Model == "" && variant.SystemPrompt == "" && variant.Temperature == "" && variant.MaxTokens == nil {
+		return fmt.Errorf("experiment.variantB must override at least one setting")
+	}
+	if variant.Temperature != "" {
+		if _, err := strconv.ParseFloat(variant.Temperature, 64); err != nil {
+			return fmt.Errorf("experiment.variantB.temperature %q is not a number", variant.Temperature)
+		}
+	}
+	return nil
+}
+
+// applyVariantOverrides applies the settings of an experiment variant to the agent container
+// of deployment.
+func applyVariantOverrides(deployment *appsv1.Deployment, variant aiv1.ExperimentVariant) {
+	container := &deployment.Spec.Template.Spec.Containers[0]
+	overrides := map[string]string{}
+	if variant.Model != "" {
+		overrides["AGENT_MODEL"] = variant.Model
+	}
+	if variant.SystemPrompt != "" {
+		overrides["AGENT_SYSTEM_PROMPT"] = variant.SystemPrompt
+	}
+	if variant.Temperature != "" {
+		overrides["AGENT_TEMPERATURE"] = variant.Temperature
+	}
+	if variant.MaxTokens != nil {
+		overrides["AGENT_MAX_TOKENS"] = strconv.Itoa(int(*variant.MaxTokens))
+	}
+
+	for i := range container.Env {
+		if value, ok := overrides[container.Env[i].Name]; ok {
+			container.Env[i].Value = value
+			delete(overrides, container.Env[i].Name)
+		}
+	}
+	for _, name := range []string{"AGENT_TEMPERATURE", "AGENT_MAX_TOKENS"} {
+		if value, ok := overrides[name]; ok {
+			container.Env = append(container.Env, corev1.EnvVar{Name: name, Value: value})
+		}
+	}
+}
+
+// reconcileExperiment splits the desired agent pods between variant A, the agent
+// Deployment, and variant B, a second Deployment with the variant's overrides, and tracks
+// the metrics of both. Once the experiment is concluded, the desired Deployment runs the
+// winning variant with all replicas. The agent spec is never changed.
+func (r *AgentReconciler) reconcileExperiment(ctx context.Context, agent *aiv1.Agent, desired *appsv1.Deployment) error {
+	experiment := agent.Spec.Experiment
+	if experiment == nil {
+		agent.Status.Experiment = nil
+		return r.deleteVariantB(ctx, agent)
+	}
+
+	hash := experimentConfigHash(experiment)
+	status := agent.Status.Experiment
+	if status == nil || status.ConfigHash != hash {
+		log.FromContext(ctx).Info("Starting experiment", "configHash", hash)
+		status = &aiv1.ExperimentStatus{Phase: aiv1.ExperimentPending, ConfigHash: hash}
+		agent.Status.Experiment = status
+	}
+
+	if err := r.analyzeExperiment(ctx, agent); err != nil {
+		return err
+	}
+
+	if status.Phase == aiv1.ExperimentConcluded {
+		if status.Winner == variantB {
+			applyVariantOverrides(desired, experiment.VariantB)
+		}
+		return r.deleteVariantB(ctx, agent)
+	}
+
+	trafficPercent := experiment.TrafficPercent
+	if trafficPercent == 0 {
+		trafficPercent = defaultTrafficPercent
+	}
+	countB, countA := splitReplicas(*desired.Spec.Replicas, trafficPercent)
+	variant := buildVariantBDeployment(agent, desired, countB)
+	desired.Spec.Replicas = &countA
+
+	weightB := percentOf(countB, countA+countB)
+	variantStatus(status, variantA).Weight = 100 - weightB
+	variantStatus(status, variantB).Weight = weightB
+
+	return r.reconcileVariantBDeployment(ctx, agent, variant)
+}
+
+// variantStatus returns the status of the named variant, adding it when missing.
+func variantStatus(status *aiv1.ExperimentStatus, name string) *aiv1.VariantStatus {
+	for i := range status.Variants {
+		if status.Variants[i].Name == name {
+			return &status.Variants[i]
+		}
+	}
+	status.Variants = append(status.Variants, aiv1.VariantStatus{Name: name})
+	return &status.Variants[len(status.Variants)-1]
+}
+
+// analyzeExperiment advances the experiment: it starts once both variants are ready,
+// records the metrics of both variants while running, and picks the winner when the
+// duration has passed.
+func (r *AgentReconciler) analyzeExperiment(ctx context.Context, agent *aiv1.Agent) error {
+	experiment := agent.Spec.Experiment
+	status := agent.Status.Experiment
+
+	switch status.Phase {
+	case aiv1.ExperimentPending:
+		ready, err := r.experimentReady(ctx, agent)
+		if err != nil || !ready {
+			return err
+		}
+		now := metav1.Now()
+		status.StartTime = &now
+		status.Phase = aiv1.ExperimentRunning
+		for _, name := range []string{variantA, variantB} {
+			sample, err := r.scrapeAgentPods(ctx, agent, variantSelector(agent, name))
+			if err != nil {
+				log.FromContext(ctx).Error(err, "Failed to scrape variant metrics at experiment start", "variant", name)
+				sample = agentmetrics.Sample{}
+			}
+			variantStatus(status, name).StartCounters = requestCounters(sample)
+		}
+		log.FromContext(ctx).Info("Experiment started", "duration", experiment.Duration.Duration)
+
+	case aiv1.ExperimentRunning:
+		samples := map[string]agentmetrics.Sample{}
+		for _, name := range []string{variantA, variantB} {
+			sample, err := r.scrapeAgentPods(ctx, agent, variantSelector(agent, name))
+			if err != nil {
+				log.FromContext(ctx).Error(err, "Failed to scrape variant metrics", "variant", name)
+				return nil
+			}
+			vs := variantStatus(status, name)
+			if vs.StartCounters != nil {
+				sample = sample.Sub(counterSample(vs.StartCounters))
+			}
+			vs.Requests = int64(sample.Requests)
+			vs.ErrorPercent = fmt.Sprintf("%.2f%%", sample.ErrorRate()*100)
+			vs.AverageLatency = sample.AverageLatency().Round(time.Millisecond).String()
+			vs.TokensPerRequest = fmt.Sprintf("%.1f", sample.TokensPerRequest())
+			samples[name] = sample
+		}
+
+		if time.Since(status.StartTime.Time) < experiment.Duration.Duration {
+			return nil
+		}
+		now := metav1.Now()
+		status.EndTime = &now
+		status.Phase = aiv1.ExperimentCompleted
+		winner, summary := compareVariants(experiment.SuccessMetric, samples[variantA], samples[variantB])
+		status.Summary = summary
+		log.FromContext(ctx).Info("Experiment completed", "summary", summary)
+		if experiment.WinnerPolicy == winnerPolicyAuto {
+			status.Winner = winner
+			status.Phase = aiv1.ExperimentConcluded
+		} else {
+			status.Summary += fmt.Sprintf("; set the %s annotation to %q or %q to conclude", experimentWinnerAnnotation, variantA, variantB)
+		}
+
+	case aiv1.ExperimentCompleted:
+		winner := agent.Annotations[experimentWinnerAnnotation]
+		if winner != variantA && winner != variantB {
+			return nil
+		}
+		if err := r.clearAnnotation(ctx, agent, experimentWinnerAnnotation); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Experiment concluded", "winner", winner)
+		status.Winner = winner
+		status.Phase = aiv1.ExperimentConcluded
+	}
+	return nil
+}
+
+// experimentReady reports whether the Deployments of both variants are ready.
+func (r *AgentReconciler) experimentReady(ctx context.Context, agent *aiv1.Agent) (bool, error) {
+	for _, name := range []string{agent.Name, variantBDeploymentName(agent)} {
+		deployment := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, deployment)
+		if errors.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		if !deploymentReady(deployment) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// compareVariants picks the variant with the lower success metric and summarizes the
+// comparison. Ties and experiments without traffic keep variant A.
+func compareVariants(metric string, a, b agentmetrics.Sample) (string, string) {
+	if a.Requests == 0 || b.Requests == 0 {
+		return variantA, fmt.Sprintf("Not enough traffic to compare the variants (%d and %d requests)", int64(a.Requests), int64(b.Requests))
+	}
+
+	name, valueA, valueB := "error rate", a.ErrorRate()*100, b.ErrorRate()*100
+	format := func(v float64) string { return fmt.Sprintf("%.2f%%", v) }
+	switch metric {
+	case successMetricLatency:
+		name, valueA, valueB = "average latency", a.AverageLatency().Seconds(), b.AverageLatency().Seconds()
+		format = func(v float64) string {
+			return (time.Duration(v * float64(time.Second))).Round(time.Millisecond).String()
+		}
+	case successMetricTokens:
+		name, valueA, valueB = "tokens per request", a.TokensPerRequest(), b.TokensPerRequest()
+		format = func(v float64) string { return fmt.Sprintf("%.1f", v) }
+	}
+
+	requests := int64(a.Requests + b.Requests)
+	switch {
+	case valueB < valueA:
+		return variantB, fmt.Sprintf("Variant b has the lower %s (%s vs %s) over %d requests", name, format(valueB), format(valueA), requests)
+	case valueA < valueB:
+		return variantA, fmt.Sprintf("Variant a has the lower %s (%s vs %s) over %d requests", name, format(valueA), format(valueB), requests)
+	default:
+		return variantA, fmt.Sprintf("Both variants have the same %s (%s) over %d requests", name, format(valueA), requests)
+	}
+}
+
+// buildVariantBDeployment returns the Deployment running experiment variant B.
+func buildVariantBDeployment(agent *aiv1.Agent, desired *appsv1.Deployment, replicas int32) *appsv1.Deployment {
+	variant := desired.DeepCopy()
+	variant.Name = variantBDeploymentName(agent)
+	variant.ResourceVersion = ""
+	variant.Spec.Replicas = &replicas
+
+	selector := map[string]string{variantLabel: variantB}
+	for k, v := range desired.Spec.Selector.MatchLabels {
+		selector[k] = v
+	}
+	variant.Labels = selector
+	variant.Spec.Selector = &metav1.LabelSelector{MatchLabels: selector}
+
+	templateLabels := map[string]string{variantLabel: variantB}
+	for k, v := range desired.Spec.Template.Labels {
+		templateLabels[k] = v
+	}
+	variant.Spec.Template.Labels = templateLabels
+
+	applyVariantOverrides(variant, agent.Spec.Experiment.VariantB)
+	container := &variant.Spec.Template.Spec.Containers[0]
+	container.Env = append(container.Env, corev1.EnvVar{Name: "AGENT_VARIANT", Value: variantB})
+	return variant
+}
+
+// reconcileVariantBDeployment creates or updates the Deployment of experiment variant B.
+func (r *AgentReconciler) reconcileVariantBDeployment(ctx context.Context, agent *aiv1.Agent, deployment *appsv1.Deployment) error {
+	if err := controllerutil.SetControllerReference(agent, deployment, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new variant Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
+		return r.Create(ctx, deployment)
+	} else if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Updating existing variant Deployment", "Deployment.Namespace", found.Namespace, "Deployment.Name", found.Name)
+	found.Spec = deployment.Spec
+	return r.Update(ctx, found)
+}
+
+// deleteVariantB removes the Deployment of experiment variant B, if any.
+func (r *AgentReconciler) deleteVariantB(ctx context.Context, agent *aiv1.Agent) error {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: variantBDeploymentName(agent), Namespace: agent.Namespace}, deployment)
+	if err == nil {
+		log.FromContext(ctx).Info("Deleting variant Deployment", "Deployment.Name", deployment.Name)
+		return client.IgnoreNotFound(r.Delete(ctx, deployment))
+	}
+	return client.IgnoreNotFound(err)
+}
+
+// experimentRequeueAfter shortens the periodic requeue so that experiments start and end
+// on time.
+func experimentRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	status := agent.Status.Experiment
+	if agent.Spec.Experiment == nil || status == nil {
+		return requeue
+	}
+	switch status.Phase {
+	case aiv1.ExperimentPending:
+		return canaryReadyRequeue
+	case aiv1.ExperimentRunning:
+		if remaining := time.Until(status.StartTime.Add(agent.Spec.Experiment.Duration.Duration)); remaining < requeue {
+			if remaining <= 0 {
+				return canaryReadyRequeue
+			}
+			return remaining
+		}
+	}
+	return requeue
+}
@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/expiry"
+)
+
+// reconcileExpiry deletes the agent once the deadline of its ttl or expireAt has passed, and
+// otherwise reports the remaining time in the Expiring condition. The agent is deleted like
+// any other, so the finalizer cleans up its resources. The warning Event recorded within
+// expiry.WarningLead of the deadline is recorded once, as the reason of the condition kept in
+// the status tells whether it was. It reports whether the agent was deleted.
+func (r *AgentReconciler) reconcileExpiry(ctx context.Context, agent *aiv1.Agent) (bool, error) {
+	deadline, ok := expiry.Deadline(agent, &agent.Spec, agent.CreationTimestamp.Time)
+	if !ok {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionExpiring)
+		return false, nil
+	}
+
+	now := r.clock().Now()
+	if !now.Before(deadline) {
+		log.FromContext(ctx).Info("Deleting expired agent", "deadline", deadline)
+		r.recordEvent(agent, corev1.EventTypeNormal, "Expired", fmt.Sprintf("The agent expired at %s and is deleted", deadline.UTC().Format(time.RFC3339)))
+		if err := r.Delete(ctx, agent, client.Preconditions{UID: &agent.UID}); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	remaining := deadline.Sub(now).Round(time.Minute)
+	message := fmt.Sprintf("The agent is deleted at %s, in %s; raise the %s annotation to extend it", deadline.UTC().Format(time.RFC3339), remaining, expiry.ExtendAnnotation)
+	reason := "TTLActive"
+	if deadline.Sub(now) <= expiry.WarningLead {
+		reason = "ExpiringSoon"
+		if condition := findCondition(agent.Status.Conditions, aiv1.AgentConditionExpiring); condition == nil || condition.Reason != reason {
+			r.recordEvent(agent, corev1.EventTypeWarning, reason, message)
+		}
+	}
+	r.setCondition(agent, aiv1.AgentConditionExpiring, corev1.ConditionTrue, reason, message)
+	return false, nil
+}
+
+// expiryRequeueAfter shortens requeue to the warning before the deadline of the agent, and to
+// the deadline itself, to warn and delete it on time.
+func (r *AgentReconciler) expiryRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	deadline, ok := expiry.Deadline(agent, &agent.Spec, agent.CreationTimestamp.Time)
+	if !ok {
+		return requeue
+	}
+	next := deadline.Sub(r.clock().Now())
+	if untilWarning := next - expiry.WarningLead; untilWarning > 0 {
+		next = untilWarning
+	}
+	if next < requeue {
+		if next < time.Second {
+			return time.Second
+		}
+		return next
+	}
+	return requeue
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+// conversationStoreEnv returns the environment variables pointing a job at the agent's
+// persistent conversation store. Agents using the inmemory backend have nothing to return.
+func conversationStoreEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if memoryBackend(agent) == "inmemory" {
+		return nil
+	}
+	return memoryEnv(agent)
+}
+
+// validateExportConfig validates the conversation export configuration.
+func (r *AgentReconciler) validateExportConfig(ctx context.Context, agent *aiv1.Agent) error {
+	export := agent.Spec.Export
+	if export == nil {
+		return nil
+	}
+
+	if len(conversationStoreEnv(agent)) == 0 {
+		return fmt.Errorf("export requires a redis or postgres memory backend")
+	}
+	if export.Schedule == "" {
+		return fmt.Errorf("export.schedule is required")
+	}
+	if export.Destination.Bucket == "" {
+		return fmt.Errorf("export.destination.bucket is required")
+	}
+	if export.Format != "" && export.Format != "jsonl" && export.Format != "parquet" {
+		return fmt.Errorf("export.format must be 'jsonl' or 'parquet'")
+	}
+	for _, pattern := range append(append([]string{}, export.Include...), export.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid export filter %q: %w", pattern, err)
+		}
+	}
+
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: export.CredentialsSecretRef.Name, Namespace: agent.Namespace}, secret); err != nil {
+		return fmt.Errorf("failed to get export credentials secret %s: %w", export.CredentialsSecretRef.Name, err)
+	}
+
+	return nil
+}
+
+// reconcileExport ensures the conversation export CronJob matches the agent spec and
+// records the outcome of the most recent export run.
+func (r *AgentReconciler) reconcileExport(ctx context.Context, agent *aiv1.Agent) error {
+	name := naming.ChildWithMax(agent.Name, "conversation-export", naming.MaxCronJobLength)
+	if agent.Spec.Export == nil {
+		agent.Status.Export = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionExportSucceeded)
+		cronJob := &batchv1.CronJob{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, cronJob, cacheOnly)
+		if err == nil {
+			log.FromContext(ctx).Info("Deleting export CronJob", "CronJob.Name", cronJob.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, cronJob, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+		}
+		return client.IgnoreNotFound(err)
+	}
+
+	cronJob := r.buildExportCronJob(agent, name)
+	if err := controllerutil.SetControllerReference(agent, cronJob, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &batchv1.CronJob{}
+	err := r.Get(ctx, types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new export CronJob", "CronJob.Namespace", cronJob.Namespace, "CronJob.Name", cronJob.Name)
+		if err := r.Create(ctx, cronJob); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		log.FromContext(ctx).Info("Updating existing export CronJob", "CronJob.Namespace", found.Namespace, "CronJob.Name", found.Name)
+		found.Spec = cronJob.Spec
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	return r.updateExportStatus(ctx, agent)
+}
+
+// buildExportCronJob creates the CronJob that uploads conversation transcripts to object storage.
+func (r *AgentReconciler) buildExportCronJob(agent *aiv1.Agent, name string) *batchv1.CronJob {
+	labels := map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-job",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "conversation-export",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+	export := agent.Spec.Export
+
+	format := "jsonl"
+	if export.Format != "" {
+		format = export.Format
+	}
+	includeJSON, _ := json.Marshal(export.Include)
+	excludeJSON, _ := json.Marshal(export.Exclude)
+
+	env := []corev1.EnvVar{
+		{Name: "EXPORT_AGENT_NAME", Value: agent.Name},
+		{Name: "EXPORT_AGENT_NAMESPACE", Value: agent.Namespace},
+		{Name: "EXPORT_ENDPOINT", Value: export.Destination.Endpoint},
+		{Name: "EXPORT_BUCKET", Value: export.Destination.Bucket},
+		{Name: "EXPORT_PREFIX", Value: export.Destination.Prefix},
+		{Name: "EXPORT_FORMAT", Value: format},
+		{Name: "EXPORT_INCLUDE", Value: string(includeJSON)},
+		{Name: "EXPORT_EXCLUDE", Value: string(excludeJSON)},
+	}
+	env = append(env, conversationStoreEnv(agent)...)
+
+	// The transcripts are redacted before anything is written to the destination
+	env = append(env, exportRedactionEnv(agent)...)
+
+	// The export decrypts records with the same keys as the agent runtime
+	volumes, volumeMounts, encryptionEnv := encryptionVolume(agent)
+	env = append(env, encryptionEnv...)
+
+	historyLimit := int32(3)
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   export.Schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &historyLimit,
+			FailedJobsHistoryLimit:     &historyLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: int32Ptr(2),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Volumes:       volumes,
+							Containers: []corev1.Container{
+								{
+									Name:         "export",
+									Image:        getExportImage(agent),
+									Env:          env,
+									VolumeMounts: volumeMounts,
+									EnvFrom: []corev1.EnvFromSource{
+										{
+											SecretRef: &corev1.SecretEnvSource{
+												LocalObjectReference: export.CredentialsSecretRef,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	securityprofile.Apply(securityProfile(agent), &cronJob.Spec.JobTemplate.Spec.Template.Spec, agentUID)
+	return cronJob
+}
+
+// exportResult is the summary the export container writes to its termination message.
+type exportResult struct {
+	ObjectCount int64 `json:"objectCount"`
+}
+
+// updateExportStatus records the outcome of the most recently finished export job. A failed
+// run sets the ExportSucceeded condition to False and keeps the last successful export time.
+func (r *AgentReconciler) updateExportStatus(ctx context.Context, agent *aiv1.Agent) error {
+	latest, finished, err := r.latestFinishedJob(ctx, agent, "conversation-export")
+	if err != nil || latest == nil {
+		return err
+	}
+
+	now := metav1.NewTime(time.Now())
+	condition := aiv1.AgentCondition{
+		Type:               aiv1.AgentConditionExportSucceeded,
+		LastTransitionTime: &now,
+	}
+
+	if latest.Status.Succeeded == 0 {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "ExportFailed"
+		condition.Message = "Conversation export failed, see logs of Job " + latest.Name
+		agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, condition)
+		return nil
+	}
+
+	status := &aiv1.ExportStatus{LastExportTime: finished}
+	message, err := r.jobTerminationMessage(ctx, latest, "export")
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		var result exportResult
+		if err := json.Unmarshal([]byte(message), &result); err != nil {
+			log.FromContext(ctx).Info("Ignoring malformed export result", "Job.Name", latest.Name, "error", err.Error())
+		} else {
+			status.ObjectCount = result.ObjectCount
+		}
+	}
+	agent.Status.Export = status
+
+	condition.Status = corev1.ConditionTrue
+	condition.Reason = "Exported"
+	condition.Message = fmt.Sprintf("Exported %d objects", status.ObjectCount)
+	agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, condition)
+	return nil
+}
+
+// getExportImage returns the container image used by the export job, following the
+// same precedence as getAgentImage: spec, then operator environment, then a default.
+func getExportImage(agent *aiv1.Agent) string {
+	if agent.Spec.Export.Image != "" {
+		return agent.Spec.Export.Image
+	}
+	if envImage := os.Getenv("EXPORT_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return "kubeagentic/conversation-export:latest"
+}
@@ -2,31 +2,35 @@ package controllers
 
 import (
 	"context"
-	"fmt"
 
 	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
-	"k8s.io/apimachinery/pkg/util/intstr"
-	ctrl "sigs.k8s.io/controller-runtime"
-	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 
 	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/ingresshost"
 )
 
+// hpaEnabled reports whether the agent is autoscaled, which it is unless it runs a single
+// replica or KEDA scales it on the backlog of its event source.
+func hpaEnabled(agent *aiv1.Agent) bool {
+	if eventSourceAutoscaled(agent) {
+		return false
+	}
+	return agent.Spec.Replicas == nil || *agent.Spec.Replicas != 1
+}
+
 // reconcileHPA creates or updates HorizontalPodAutoscaler for the agent
 func (r *AgentReconciler) reconcileHPA(ctx context.Context, agent *aiv1.Agent) error {
 	// Only create HPA if replicas > 1 or if explicitly enabled
-	if agent.Spec.Replicas != nil && *agent.Spec.Replicas == 1 {
+	if !hpaEnabled(agent) {
 		// Check if HPA exists and delete it
 		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
-		err := r.Get(ctx, types.NamespacedName{Name: agent.Name + "-hpa", Namespace: agent.Namespace}, hpa)
+		err := r.Get(ctx, types.NamespacedName{Name: hpaName(agent), Namespace: agent.Namespace}, hpa)
 		if err == nil {
 			log.FromContext(ctx).Info("Deleting HPA for single replica agent", "HPA.Name", hpa.Name)
 			return r.Delete(ctx, hpa)
@@ -35,12 +39,17 @@ func (r *AgentReconciler) reconcileHPA(ctx context.Context, agent *aiv1.Agent) e
 	}
 
 	hpa := r.buildHPA(agent)
+	maxReplicas, err := r.autoscalingMaxReplicas(ctx, agent, hpaAutoscaler)
+	if err != nil {
+		return err
+	}
+	hpa.Spec.MaxReplicas = maxReplicas
 	if err := controllerutil.SetControllerReference(agent, hpa, r.Scheme); err != nil {
 		return err
 	}
 
 	found := &autoscalingv2.HorizontalPodAutoscaler{}
-	err := r.Get(ctx, types.NamespacedName{Name: hpa.Name, Namespace: hpa.Namespace}, found)
+	err = r.Get(ctx, types.NamespacedName{Name: hpa.Name, Namespace: hpa.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		log.FromContext(ctx).Info("Creating new HPA", "HPA.Namespace", hpa.Namespace, "HPA.Name", hpa.Name)
 		return r.Create(ctx, hpa)
@@ -55,22 +64,13 @@ func (r *AgentReconciler) reconcileHPA(ctx context.Context, agent *aiv1.Agent) e
 
 // buildHPA creates a HorizontalPodAutoscaler for the agent
 func (r *AgentReconciler) buildHPA(agent *aiv1.Agent) *autoscalingv2.HorizontalPodAutoscaler {
-	labels := map[string]string{
-		"app.kubernetes.io/name":     "kubeagentic-agent",
-		"app.kubernetes.io/instance": agent.Name,
-		"kubeagentic.ai/agent":       agent.Name,
-	}
+	labels := agentLabels(agent)
 
-	minReplicas := int32(1)
-	maxReplicas := int32(10)
-	if agent.Spec.Replicas != nil {
-		minReplicas = *agent.Spec.Replicas
-		maxReplicas = *agent.Spec.Replicas * 3 // Scale up to 3x
-	}
+	minReplicas, maxReplicas := hpaScalingBounds(agent)
 
 	return &autoscalingv2.HorizontalPodAutoscaler{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      agent.Name + "-hpa",
+			Name:      hpaName(agent),
 			Namespace: agent.Namespace,
 			Labels:    labels,
 		},
@@ -78,11 +78,11 @@ func (r *AgentReconciler) buildHPA(agent *aiv1.Agent) *autoscalingv2.HorizontalP
 			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 				APIVersion: "apps/v1",
 				Kind:       "Deployment",
-				Name:       agent.Name,
+				Name:       deploymentName(agent),
 			},
 			MinReplicas: &minReplicas,
 			MaxReplicas: maxReplicas,
-			Metrics: []autoscalingv2.MetricSpec{
+			Metrics: append([]autoscalingv2.MetricSpec{
 				{
 					Type: autoscalingv2.ResourceMetricSourceType,
 					Resource: &autoscalingv2.ResourceMetricSource{
@@ -103,18 +103,25 @@ func (r *AgentReconciler) buildHPA(agent *aiv1.Agent) *autoscalingv2.HorizontalP
 						},
 					},
 				},
-			},
+			}, inFlightMetrics(agent)...),
 		},
 	}
 }
 
+// ingressEnabled reports whether the agent is exposed through an Ingress, which it is with
+// the LoadBalancer service type or spec.ingress.
+func ingressEnabled(agent *aiv1.Agent) bool {
+	return ingresshost.Enabled(&agent.Spec)
+}
+
 // reconcileIngress creates or updates Ingress for the agent
 func (r *AgentReconciler) reconcileIngress(ctx context.Context, agent *aiv1.Agent) error {
 	// Only create Ingress if service type is LoadBalancer or if explicitly configured
-	if agent.Spec.ServiceType != "LoadBalancer" {
+	if !ingressEnabled(agent) {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionIngressConflict)
 		// Check if Ingress exists and delete it
 		ingress := &networkingv1.Ingress{}
-		err := r.Get(ctx, types.NamespacedName{Name: agent.Name + "-ingress", Namespace: agent.Namespace}, ingress)
+		err := r.Get(ctx, types.NamespacedName{Name: ingressName(agent), Namespace: agent.Namespace}, ingress)
 		if err == nil {
 			log.FromContext(ctx).Info("Deleting Ingress for non-LoadBalancer service", "Ingress.Name", ingress.Name)
 			return r.Delete(ctx, ingress)
@@ -122,13 +129,19 @@ func (r *AgentReconciler) reconcileIngress(ctx context.Context, agent *aiv1.Agen
 		return nil
 	}
 
+	// Leave the host and paths claimed by an earlier agent to it
+	conflicting, err := r.checkIngressConflict(ctx, agent)
+	if err != nil || conflicting {
+		return err
+	}
+
 	ingress := r.buildIngress(agent)
 	if err := controllerutil.SetControllerReference(agent, ingress, r.Scheme); err != nil {
 		return err
 	}
 
 	found := &networkingv1.Ingress{}
-	err := r.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, found)
+	err = r.Get(ctx, types.NamespacedName{Name: ingress.Name, Namespace: ingress.Namespace}, found)
 	if err != nil && errors.IsNotFound(err) {
 		log.FromContext(ctx).Info("Creating new Ingress", "Ingress.Namespace", ingress.Namespace, "Ingress.Name", ingress.Name)
 		return r.Create(ctx, ingress)
@@ -143,18 +156,14 @@ func (r *AgentReconciler) reconcileIngress(ctx context.Context, agent *aiv1.Agen
 
 // buildIngress creates an Ingress for the agent
 func (r *AgentReconciler) buildIngress(agent *aiv1.Agent) *networkingv1.Ingress {
-	labels := map[string]string{
-		"app.kubernetes.io/name":     "kubeagentic-agent",
-		"app.kubernetes.io/instance": agent.Name,
-		"kubeagentic.ai/agent":       agent.Name,
-	}
+	labels := agentLabels(agent)
 
-	hostname := fmt.Sprintf("%s.%s.local", agent.Name, agent.Namespace)
+	hostname := ingressHost(agent)
 	pathType := networkingv1.PathTypePrefix
 
 	return &networkingv1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      agent.Name + "-ingress",
+			Name:      ingressName(agent),
 			Namespace: agent.Namespace,
 			Labels:    labels,
 			Annotations: map[string]string{
@@ -170,11 +179,11 @@ func (r *AgentReconciler) buildIngress(agent *aiv1.Agent) *networkingv1.Ingress
 						HTTP: &networkingv1.HTTPIngressRuleValue{
 							Paths: []networkingv1.HTTPIngressPath{
 								{
-									Path:     "/",
+									Path:     ingressPath(agent),
 									PathType: &pathType,
 									Backend: networkingv1.IngressBackend{
 										Service: &networkingv1.IngressServiceBackend{
-											Name: agent.Name + "-service",
+											Name: serviceName(agent),
 											Port: networkingv1.ServiceBackendPort{
 												Number: 80,
 											},
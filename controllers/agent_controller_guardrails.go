@@ -0,0 +1,244 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/guardrails"
+)
+
+const (
+	// guardrailsMountPath is where the policy is mounted in the guardrails container.
+	guardrailsMountPath = "/etc/kubeagentic/guardrails"
+	// guardrailsConfigKey is the key of the agent ConfigMap holding the rendered policy.
+	guardrailsConfigKey = "guardrails.json"
+	// guardrailsChecksumAnnotation rolls the agent pods when the policy changes.
+	guardrailsChecksumAnnotation = "kubeagentic.ai/guardrails-checksum"
+	// guardrailsPolicyName is the app.kubernetes.io/name label that gets the policy ConfigMaps
+	// of the users cached and watched, so that their edits are applied at once rather than at
+	// the next reconcile of the agent.
+	guardrailsPolicyName = "kubeagentic-guardrails-policy"
+)
+
+// guardrailsEnabled reports whether the agent pods run the guardrails proxy.
+func guardrailsEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Guardrails != nil && agent.Spec.Guardrails.Enabled
+}
+
+// guardrailsPort returns the port of the guardrails proxy in the agent pods.
+func guardrailsPort(agent *aiv1.Agent) int32 {
+	if agent.Spec.Guardrails != nil && agent.Spec.Guardrails.Port != nil {
+		return *agent.Spec.Guardrails.Port
+	}
+	return guardrails.DefaultPort
+}
+
+// guardrailsFailMode returns the fail mode of the guardrails proxy, closed when unset.
+func guardrailsFailMode(agent *aiv1.Agent) string {
+	if agent.Spec.Guardrails == nil || agent.Spec.Guardrails.FailMode == "" {
+		return guardrails.FailModeClosed
+	}
+	return agent.Spec.Guardrails.FailMode
+}
+
+// guardedPort returns the port of the agent pods behind the rate limit proxy: the guardrails
+// proxy when the agent has one, the agent container otherwise.
+func guardedPort(agent *aiv1.Agent) int32 {
+	if guardrailsEnabled(agent) {
+		return guardrailsPort(agent)
+	}
+	return 8080
+}
+
+// guardrailsPolicyConfigMap returns the name of the policy ConfigMap of the agent, or "" without
+// guardrails.
+func guardrailsPolicyConfigMap(agent *aiv1.Agent) string {
+	if !guardrailsEnabled(agent) || agent.Spec.Guardrails.PolicyRef == nil {
+		return ""
+	}
+	return agent.Spec.Guardrails.PolicyRef.Name
+}
+
+// validateGuardrails checks the guardrails of the agent: a policy reference, a port free of
+// the other containers of the pods, and the policy itself once its ConfigMap exists.
+func (r *AgentReconciler) validateGuardrails(ctx context.Context, agent *aiv1.Agent) error {
+	if !guardrailsEnabled(agent) {
+		return nil
+	}
+	config := agent.Spec.Guardrails
+	if config.PolicyRef == nil || config.PolicyRef.Name == "" || config.PolicyRef.Key == "" {
+		return fmt.Errorf("guardrails.policyRef with a name and a key is required when guardrails are enabled")
+	}
+	if config.PolicyRef.Optional != nil && *config.PolicyRef.Optional {
+		return fmt.Errorf("guardrails.policyRef cannot be optional")
+	}
+	if mode := guardrailsFailMode(agent); mode != guardrails.FailModeClosed && mode != guardrails.FailModeOpen {
+		return fmt.Errorf("guardrails.failMode must be closed or open, not %q", mode)
+	}
+	port := guardrailsPort(agent)
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("guardrails.port must be between 1 and 65535, got %d", port)
+	}
+	used := map[int32]string{8080: "the chat port"}
+	if agent.Spec.Metrics != nil && agent.Spec.Metrics.Port != nil {
+		used[*agent.Spec.Metrics.Port] = "the metrics port"
+	}
+	if adminPortEnabled(agent) {
+		used[agent.Spec.AdminPort.Port] = "the admin port"
+	}
+	if inboundRateLimitEnabled(agent) {
+		used[rateLimitProxyPort] = "the rate limit proxy"
+	}
+	if connectorsEnabled(agent) {
+		used[connectorStatusPort] = "the connector sidecar"
+	}
+	if toolExecutorEnabled(agent) {
+		used[toolExecutorPort] = "the tool executor sidecar"
+	}
+	if owner, ok := used[port]; ok {
+		return fmt.Errorf("guardrails.port %d is already used by %s", port, owner)
+	}
+	_, err := r.guardrailsPolicy(ctx, agent)
+	return err
+}
+
+// guardrailsPolicy reads and checks the policy of the agent from its ConfigMap.
+func (r *AgentReconciler) guardrailsPolicy(ctx context.Context, agent *aiv1.Agent) (guardrails.Policy, error) {
+	ref := agent.Spec.Guardrails.PolicyRef
+	configMap := &corev1.ConfigMap{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: agent.Namespace}, configMap, referenced); err != nil {
+		return guardrails.Policy{}, fmt.Errorf("failed to get the guardrails policy configmap %s: %w", ref.Name, err)
+	}
+	data, ok := configMap.Data[ref.Key]
+	if !ok {
+		return guardrails.Policy{}, fmt.Errorf("guardrails policy configmap %s has no key %s", ref.Name, ref.Key)
+	}
+	policy, err := guardrails.Parse([]byte(data))
+	if err != nil {
+		return guardrails.Policy{}, fmt.Errorf("configmap %s key %s: %w", ref.Name, ref.Key, err)
+	}
+	return policy.Normalized(), nil
+}
+
+// guardrailsConfig returns the guardrails.json of the agent ConfigMap, read by the proxy.
+func guardrailsConfig(policy guardrails.Policy) (string, error) {
+	data, err := json.Marshal(policy)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// guardrailsChecksum returns a hash of the rendered policy, or an empty string without
+// guardrails.
+func (r *AgentReconciler) guardrailsChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if !guardrailsEnabled(agent) {
+		return "", nil
+	}
+	policy, err := r.guardrailsPolicy(ctx, agent)
+	if err != nil {
+		return "", err
+	}
+	config, err := guardrailsConfig(policy)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// setGuardrailsCondition reports the policy the guardrails proxy enforces on the
+// GuardrailsActive condition, which is removed without guardrails. An invalid edit of the
+// policy fails validation before the rendered policy is replaced, so the condition keeps
+// describing the last valid policy the pods enforce.
+func (r *AgentReconciler) setGuardrailsCondition(agent *aiv1.Agent, policy *guardrails.Policy) {
+	if policy == nil {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionGuardrailsActive)
+		return
+	}
+	block, flag := policy.Counts()
+	r.setCondition(agent, aiv1.AgentConditionGuardrailsActive, corev1.ConditionTrue, "PolicyEnforced",
+		fmt.Sprintf("The guardrails proxy enforces %d blocking and %d flagging rules of configmap %s, failing %s",
+			block, flag, agent.Spec.Guardrails.PolicyRef.Name, guardrailsFailMode(agent)))
+}
+
+// getGuardrailsImage returns the image of the guardrails engine: the agent spec, the operator
+// environment, then the operator image, which ships the default engine.
+func getGuardrailsImage(agent *aiv1.Agent) string {
+	if agent.Spec.Guardrails.Image != "" {
+		return agent.Spec.Guardrails.Image
+	}
+	if envImage := os.Getenv("GUARDRAILS_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return "kubeagentic/operator:latest"
+}
+
+// guardrailsProxy returns the sidecar checking the prompts and responses in front of the
+// agent container and the volume of its policy, or nil without guardrails. Its readiness
+// probe fails while a closed proxy has no valid policy, which keeps the pod out of the
+// Service endpoints; an open proxy stays ready and passes the requests unchecked.
+func guardrailsProxy(agent *aiv1.Agent) (*corev1.Container, []corev1.Volume) {
+	if !guardrailsEnabled(agent) {
+		return nil, nil
+	}
+	port := guardrailsPort(agent)
+	container := &corev1.Container{
+		Name:  "guardrails",
+		Image: getGuardrailsImage(agent),
+		Args: []string{
+			"--policy", guardrailsMountPath + "/" + guardrailsConfigKey,
+			"--addr", fmt.Sprintf(":%d", port),
+			"--upstream", "http://127.0.0.1:8080",
+			"--fail-mode", guardrailsFailMode(agent),
+		},
+		Ports: []corev1.ContainerPort{
+			{Name: "guardrails", ContainerPort: port, Protocol: corev1.ProtocolTCP},
+		},
+		// Label the moderation metrics with the identity of the agent
+		Env: identityEnv(agent),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+				corev1.ResourceCPU:    resource.MustParse("25m"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+			},
+		},
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt(int(port))},
+			},
+			PeriodSeconds: 5,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "guardrails", MountPath: guardrailsMountPath, ReadOnly: true},
+		},
+	}
+	// Other engines are run with their own entrypoint
+	if agent.Spec.Guardrails.Image == "" {
+		container.Command = []string{"/guardrails-proxy"}
+	}
+	volume := corev1.Volume{
+		Name: "guardrails",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName(agent)},
+				Items:                []corev1.KeyToPath{{Key: guardrailsConfigKey, Path: guardrailsConfigKey}},
+			},
+		},
+	}
+	return container, []corev1.Volume{volume}
+}
@@ -0,0 +1,210 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/health"
+)
+
+const (
+	// DefaultHealthCheckInterval is the time between two health checks of an agent that
+	// does not set its own.
+	DefaultHealthCheckInterval = time.Minute
+	// DefaultHealthCheckTimeout bounds the health checks of an agent that does not set its own.
+	DefaultHealthCheckTimeout = 5 * time.Second
+)
+
+// componentHealthy is the health of the components of the agent runtimes, exposed on the
+// operator metrics endpoint.
+var componentHealthy = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "kubeagentic_agent_component_healthy",
+	Help: "Whether the component of the agent runtime passed its most recent health check.",
+}, []string{"namespace", "agent", "component"})
+
+func init() {
+	metrics.Registry.MustRegister(componentHealthy)
+}
+
+// healthCheckEnabled reports whether the detailed health of the agent runtime is polled.
+func healthCheckEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.HealthCheck != nil && agent.Spec.HealthCheck.Enabled
+}
+
+// healthCheckInterval returns the time between two health checks of the agent.
+func (r *AgentReconciler) healthCheckInterval(agent *aiv1.Agent) time.Duration {
+	if agent.Spec.HealthCheck.Interval != nil {
+		return agent.Spec.HealthCheck.Interval.Duration
+	}
+	if r.HealthCheckInterval > 0 {
+		return r.HealthCheckInterval
+	}
+	return DefaultHealthCheckInterval
+}
+
+// healthCheckTimeout returns the bound on a health check of the agent.
+func (r *AgentReconciler) healthCheckTimeout(agent *aiv1.Agent) time.Duration {
+	if agent.Spec.HealthCheck.Timeout != nil {
+		return agent.Spec.HealthCheck.Timeout.Duration
+	}
+	if r.HealthCheckTimeout > 0 {
+		return r.HealthCheckTimeout
+	}
+	return DefaultHealthCheckTimeout
+}
+
+// validateHealthCheckConfig checks that the interval and timeout of the health check are
+// positive.
+func (r *AgentReconciler) validateHealthCheckConfig(agent *aiv1.Agent) error {
+	if !healthCheckEnabled(agent) {
+		return nil
+	}
+	if interval := agent.Spec.HealthCheck.Interval; interval != nil && interval.Duration <= 0 {
+		return fmt.Errorf("healthCheck.interval must be positive")
+	}
+	if timeout := agent.Spec.HealthCheck.Timeout; timeout != nil && timeout.Duration <= 0 {
+		return fmt.Errorf("healthCheck.timeout must be positive")
+	}
+	return nil
+}
+
+// healthCheckDue returns the time left until the next health check of the agent, zero or
+// less when it is due.
+func (r *AgentReconciler) healthCheckDue(agent *aiv1.Agent) time.Duration {
+	if agent.Status.Health == nil || agent.Status.Health.LastCheckTime == nil {
+		return 0
+	}
+	return agent.Status.Health.LastCheckTime.Add(r.healthCheckInterval(agent)).Sub(r.clock().Now())
+}
+
+// reconcileHealthCheck polls the detailed health of the agent runtime through its Service,
+// or on its admin port when it has one, once per interval, and reports the failing
+// components in the AgentHealthy condition and the health of the provider endpoints in
+// status.
+// The runtime being unreachable, rejecting the endpoint token or predating the endpoint
+// leaves the condition Unknown: the health of its components is not known then.
+func (r *AgentReconciler) reconcileHealthCheck(ctx context.Context, agent *aiv1.Agent) error {
+	if !healthCheckEnabled(agent) {
+		agent.Status.Health = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionAgentHealthy)
+		deleteHealthMetrics(agent)
+		return nil
+	}
+	if agent.Status.Endpoints.Ready == 0 {
+		r.setCondition(agent, aiv1.AgentConditionAgentHealthy, corev1.ConditionUnknown, "AwaitingEndpoints",
+			"The Service of the agent has no ready endpoint to check")
+		return nil
+	}
+	if r.healthCheckDue(agent) > 0 {
+		return nil
+	}
+
+	// Agents with an admin port serve their health there, with their admin token
+	baseURL := fmt.Sprintf("http://%s.%s.svc", serviceName(agent), agent.Namespace)
+	if adminPortEnabled(agent) {
+		url, err := r.adminURL(ctx, agent)
+		if err != nil {
+			return err
+		}
+		if url == "" {
+			r.setCondition(agent, aiv1.AgentConditionAgentHealthy, corev1.ConditionUnknown, "AwaitingEndpoints",
+				"The agent has no ready pod to check on its admin port")
+			return nil
+		}
+		baseURL = url
+	}
+	token, err := r.pollToken(ctx, agent)
+	if err != nil {
+		return err
+	}
+	checker := r.HealthChecker
+	if checker == nil {
+		checker = &health.Checker{}
+	}
+	report, err := checker.Check(ctx, baseURL, token, r.healthCheckTimeout(agent))
+
+	now := metav1.NewTime(r.clock().Now())
+	status := &aiv1.HealthStatus{LastCheckTime: &now}
+	agent.Status.Health = status
+	switch {
+	case errors.Is(err, health.ErrNotSupported):
+		status.Message = err.Error()
+		deleteHealthMetrics(agent)
+		r.setCondition(agent, aiv1.AgentConditionAgentHealthy, corev1.ConditionUnknown, "HealthCheckNotSupported",
+			fmt.Sprintf("The agent image does not serve %s, update it to report the health of its components", health.Path))
+		return nil
+	case errors.Is(err, health.ErrUnauthorized):
+		status.Message = err.Error()
+		r.setCondition(agent, aiv1.AgentConditionAgentHealthy, corev1.ConditionUnknown, "HealthCheckUnauthorized", err.Error())
+		return nil
+	case err != nil:
+		log.FromContext(ctx).V(1).Info("Failed to check agent health", "error", err.Error())
+		status.Message = err.Error()
+		r.setCondition(agent, aiv1.AgentConditionAgentHealthy, corev1.ConditionUnknown, "AgentUnreachable",
+			fmt.Sprintf("Failed to reach the agent runtime: %v", err))
+		return nil
+	}
+
+	status.Endpoints = providerEndpointHealth(agent, report)
+	names := make([]string, 0, len(report.Components))
+	for name := range report.Components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	deleteHealthMetrics(agent)
+	var failing []string
+	for _, name := range names {
+		component := report.Components[name]
+		healthy := component.Status == health.StateHealthy
+		status.Components = append(status.Components, aiv1.ComponentHealth{Name: name, Healthy: healthy, Message: component.Message})
+		value := 0.0
+		if healthy {
+			value = 1
+		} else if component.Message != "" {
+			failing = append(failing, fmt.Sprintf("%s: %s", name, component.Message))
+		} else {
+			failing = append(failing, name)
+		}
+		componentHealthy.WithLabelValues(agent.Namespace, agent.Name, name).Set(value)
+	}
+
+	if len(failing) > 0 {
+		r.setCondition(agent, aiv1.AgentConditionAgentHealthy, corev1.ConditionFalse, "ComponentsUnhealthy",
+			fmt.Sprintf("Unhealthy components: %s", strings.Join(failing, "; ")))
+		return nil
+	}
+	r.setCondition(agent, aiv1.AgentConditionAgentHealthy, corev1.ConditionTrue, "ComponentsHealthy",
+		fmt.Sprintf("All %d components of the agent runtime are healthy", len(names)))
+	return nil
+}
+
+// healthCheckRequeueAfter shortens requeue to the next health check of the agent.
+func (r *AgentReconciler) healthCheckRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if !healthCheckEnabled(agent) {
+		return requeue
+	}
+	next := r.healthCheckDue(agent)
+	if next <= 0 {
+		next = r.healthCheckInterval(agent)
+	}
+	if next < requeue {
+		return next
+	}
+	return requeue
+}
+
+// deleteHealthMetrics removes the component health of the agent.
+func deleteHealthMetrics(agent *aiv1.Agent) {
+	componentHealthy.DeletePartialMatch(prometheus.Labels{"namespace": agent.Namespace, "agent": agent.Name})
+}
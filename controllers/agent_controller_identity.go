@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// identityEnv returns the environment variables identifying the agent pod, with which the
+// runtime tags its logs, metrics and provider requests. They are read from the pod through
+// the Downward API, except for the UID of the Agent, which the pod does not carry. Their
+// names are reserved: the operator always sets them.
+func identityEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		fieldRefEnv("AGENT_NAME", "metadata.labels['kubeagentic.ai/agent']"),
+		fieldRefEnv("AGENT_NAMESPACE", "metadata.namespace"),
+		{Name: "AGENT_UID", Value: string(agent.UID)},
+		fieldRefEnv("POD_NAME", "metadata.name"),
+		fieldRefEnv("POD_IP", "status.podIP"),
+		fieldRefEnv("NODE_NAME", "spec.nodeName"),
+	}
+}
+
+// fieldRefEnv returns an environment variable set to the field of the pod at fieldPath.
+func fieldRefEnv(name, fieldPath string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{APIVersion: "v1", FieldPath: fieldPath},
+		},
+	}
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/imagepolicy"
+)
+
+// specImage is a user-provided image of an agent and the path of its field.
+type specImage struct {
+	path  string
+	image string
+}
+
+// specImages returns the user-provided images of the agent in the order of their fields.
+func specImages(agent *aiv1.Agent) []specImage {
+	var images []specImage
+	if agent.Spec.Image != "" {
+		images = append(images, specImage{"spec.image", agent.Spec.Image})
+	}
+	if agent.Spec.RAG != nil && agent.Spec.RAG.Ingestion != nil && agent.Spec.RAG.Ingestion.Image != "" {
+		images = append(images, specImage{"spec.rag.ingestion.image", agent.Spec.RAG.Ingestion.Image})
+	}
+	if agent.Spec.Export != nil && agent.Spec.Export.Image != "" {
+		images = append(images, specImage{"spec.export.image", agent.Spec.Export.Image})
+	}
+	if agent.Spec.Synthetics != nil && agent.Spec.Synthetics.Image != "" {
+		images = append(images, specImage{"spec.synthetics.image", agent.Spec.Synthetics.Image})
+	}
+	if agent.Spec.ToolExecutor != nil && agent.Spec.ToolExecutor.Image != "" {
+		images = append(images, specImage{"spec.toolExecutor.image", agent.Spec.ToolExecutor.Image})
+	}
+	return images
+}
+
+// validateImagePolicy re-checks the registry allowlists of the operator and of the
+// AgentPolicies in the agent's namespace, in case the admission webhook was bypassed. The
+// policies are checked in the order of their names, so that the error of an agent breaking
+// several is the same on every reconcile.
+func (r *AgentReconciler) validateImagePolicy(ctx context.Context, agent *aiv1.Agent) error {
+	var policies aiv1.AgentPolicyList
+	if err := r.List(ctx, &policies, client.InNamespace(agent.Namespace)); err != nil {
+		return err
+	}
+	sort.Slice(policies.Items, func(i, j int) bool { return policies.Items[i].Name < policies.Items[j].Name })
+
+	allowlists := [][]string{imagepolicy.AllowedRegistriesFromEnv()}
+	for _, policy := range policies.Items {
+		allowlists = append(allowlists, policy.Spec.AllowedRegistries)
+	}
+
+	for _, image := range specImages(agent) {
+		for _, allowed := range allowlists {
+			ok, err := imagepolicy.RegistryAllowed(image.image, allowed)
+			if err != nil {
+				return fmt.Errorf("invalid %s: %w", image.path, err)
+			}
+			if !ok {
+				return fmt.Errorf("%s %q is not from an allowed registry, allowed registries are %v", image.path, image.image, allowed)
+			}
+		}
+	}
+	return nil
+}
+
+// digestResolutionEnabled reports whether agent images are pinned to digests, either
+// operator-wide through RESOLVE_IMAGE_DIGESTS or by an AgentPolicy of the namespace.
+func (r *AgentReconciler) digestResolutionEnabled(ctx context.Context, agent *aiv1.Agent) (bool, error) {
+	if os.Getenv("RESOLVE_IMAGE_DIGESTS") == "true" {
+		return true, nil
+	}
+
+	var policies aiv1.AgentPolicyList
+	if err := r.List(ctx, &policies, client.InNamespace(agent.Namespace)); err != nil {
+		return false, err
+	}
+	for _, policy := range policies.Items {
+		if policy.Spec.ResolveDigests {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// reconcileImageDigest records the digest the agent image resolves to. The digest is kept
+// in status and only resolved again when the configured image changes, so a moved tag does
+// not roll the agent pods behind the user's back.
+func (r *AgentReconciler) reconcileImageDigest(ctx context.Context, agent *aiv1.Agent) error {
+	enabled, err := r.digestResolutionEnabled(ctx, agent)
+	if err != nil {
+		return err
+	}
+	if !enabled {
+		agent.Status.ResolvedImage = ""
+		agent.Status.ResolvedFrom = ""
+		return nil
+	}
+
+	image := r.getAgentImage(agent)
+	if agent.Status.ResolvedFrom == image && agent.Status.ResolvedImage != "" {
+		return nil
+	}
+
+	resolver := r.ImageResolver
+	if resolver == nil {
+		resolver = imagepolicy.DefaultResolver
+	}
+	resolved, err := resolver.Resolve(ctx, image)
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Pinned agent image to digest", "image", image, "resolved", resolved)
+	agent.Status.ResolvedImage = resolved
+	agent.Status.ResolvedFrom = image
+	return nil
+}
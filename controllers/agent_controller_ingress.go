@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/ingresshost"
+)
+
+// validateIngress checks the host and path of spec.ingress.
+func validateIngress(agent *aiv1.Agent) error {
+	if errs := ingresshost.Validate(&agent.Spec); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	return nil
+}
+
+// checkIngressConflict reports whether an agent created earlier claims the host and an
+// overlapping path of the Ingress of the agent. The admission webhook rejects such agents,
+// but not those created while it was bypassed or before the other agent was. A conflicting
+// agent gets the IngressConflict condition and loses its Ingress, so that it does not shadow
+// the agent owning the host; it gets it back once the conflict is resolved.
+func (r *AgentReconciler) checkIngressConflict(ctx context.Context, agent *aiv1.Agent) (bool, error) {
+	route, _ := ingresshost.For(agent.Name, agent.Namespace, &agent.Spec)
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents); err != nil {
+		return false, fmt.Errorf("failed to list agents: %w", err)
+	}
+	var owner *aiv1.Agent
+	for _, other := range ingresshost.Conflicts(agent.Name, agent.Namespace, route, agents.Items) {
+		other := other
+		if createdBefore(&other, agent) && (owner == nil || createdBefore(&other, owner)) {
+			owner = &other
+		}
+	}
+
+	current := findCondition(agent.Status.Conditions, aiv1.AgentConditionIngressConflict)
+	if owner == nil {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionIngressConflict)
+		return false, nil
+	}
+	ownerRoute, _ := ingresshost.For(owner.Name, owner.Namespace, &owner.Spec)
+	message := fmt.Sprintf("%s overlaps %s of agent %s/%s; the Ingress is not created", route, ownerRoute, owner.Namespace, owner.Name)
+	if current == nil || current.Message != message {
+		r.recordEvent(agent, corev1.EventTypeWarning, "IngressConflict", message)
+	}
+	r.setCondition(agent, aiv1.AgentConditionIngressConflict, corev1.ConditionTrue, "HostClaimed", message)
+
+	ingress := &networkingv1.Ingress{}
+	err := r.Get(ctx, types.NamespacedName{Name: ingressName(agent), Namespace: agent.Namespace}, ingress)
+	if errors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return true, err
+	}
+	log.FromContext(ctx).Info("Deleting conflicting Ingress", "Ingress.Name", ingress.Name, "owner", owner.Namespace+"/"+owner.Name)
+	return true, client.IgnoreNotFound(r.Delete(ctx, ingress))
+}
+
+// createdBefore reports whether agent a was created before agent b, by namespace and name
+// when they were created in the same second, so that exactly one of two conflicting agents
+// owns their host.
+func createdBefore(a, b *aiv1.Agent) bool {
+	if !a.CreationTimestamp.Equal(&b.CreationTimestamp) {
+		return a.CreationTimestamp.Before(&b.CreationTimestamp)
+	}
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	return a.Name < b.Name
+}
+
+// findAgentsForIngressHost maps an Agent event to the other agents whose Ingress overlaps
+// its own, so that an agent waiting for a host gets its Ingress once the agent owning the
+// host is deleted.
+func (r *AgentReconciler) findAgentsForIngressHost(ctx context.Context, obj client.Object) []reconcile.Request {
+	agent, ok := obj.(*aiv1.Agent)
+	if !ok {
+		return nil
+	}
+	route, ok := ingresshost.For(agent.Name, agent.Namespace, &agent.Spec)
+	if !ok {
+		return nil
+	}
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for ingress host", "host", route.Host)
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, other := range ingresshost.Conflicts(agent.Name, agent.Namespace, route, agents.Items) {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: other.Name, Namespace: other.Namespace},
+		})
+	}
+	return requests
+}
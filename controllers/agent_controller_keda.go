@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// +kubebuilder:rbac:groups=keda.sh,resources=scaledobjects;triggerauthentications,verbs=get;list;watch;create;update;patch;delete
+
+// KEDA resources scaling agents on the backlog of their queues. They are handled as
+// unstructured objects, so the operator does not depend on KEDA.
+var (
+	scaledObjectGVK          = schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}
+	triggerAuthenticationGVK = schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "TriggerAuthentication"}
+)
+
+// kedaInstalled reports whether the KEDA ScaledObject CRD is installed in the cluster.
+func (r *AgentReconciler) kedaInstalled() bool {
+	mappings, err := r.RESTMapper().RESTMappings(scaledObjectGVK.GroupKind())
+	return err == nil && len(mappings) > 0
+}
+
+// newKEDAObject returns a KEDA object of the agent with the given spec.
+func newKEDAObject(gvk schema.GroupVersionKind, agent *aiv1.Agent, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(name)
+	obj.SetNamespace(agent.Namespace)
+	obj.SetLabels(agentLabels(agent))
+	return obj
+}
+
+// reconcileScaledObject creates or updates the ScaledObject and its TriggerAuthentication,
+// which shares its name and is deleted when nil.
+func (r *AgentReconciler) reconcileScaledObject(ctx context.Context, agent *aiv1.Agent, scaledObject, triggerAuthentication *unstructured.Unstructured) error {
+	objects := []*unstructured.Unstructured{scaledObject}
+	if triggerAuthentication != nil {
+		objects = append([]*unstructured.Unstructured{triggerAuthentication}, objects...)
+	} else if err := r.deleteKEDAObjects(ctx, agent.Namespace, scaledObject.GetName(), triggerAuthenticationGVK); err != nil {
+		return err
+	}
+
+	for _, obj := range objects {
+		if err := controllerutil.SetControllerReference(agent, obj, r.Scheme); err != nil {
+			return err
+		}
+		found := &unstructured.Unstructured{}
+		found.SetGroupVersionKind(obj.GroupVersionKind())
+		err := r.Get(ctx, types.NamespacedName{Name: obj.GetName(), Namespace: obj.GetNamespace()}, found)
+		if err != nil && errors.IsNotFound(err) {
+			log.FromContext(ctx).Info("Creating KEDA resource", "kind", obj.GetKind(), "name", obj.GetName())
+			if err := r.Create(ctx, obj); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		found.Object["spec"] = obj.Object["spec"]
+		found.SetLabels(obj.GetLabels())
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteKEDAObjects deletes the KEDA objects of the given kinds and name. Clusters without
+// KEDA have nothing to delete.
+func (r *AgentReconciler) deleteKEDAObjects(ctx context.Context, namespace, name string, gvks ...schema.GroupVersionKind) error {
+	for _, gvk := range gvks {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetName(name)
+		obj.SetNamespace(namespace)
+		if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return err
+		}
+	}
+	return nil
+}
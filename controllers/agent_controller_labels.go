@@ -0,0 +1,78 @@
+package controllers
+
+import (
+	"context"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// agentLabels returns the labels of the agent pods and the resources serving them. They also
+// select the agent pods, so every controller must use them rather than its own set.
+func agentLabels(agent *aiv1.Agent) map[string]string {
+	return agentNameLabels(agent.Name)
+}
+
+// agentNameLabels returns the agentLabels of the agent named name, for the agents only
+// referred to by name, such as the agents other agents are placed next to.
+func agentNameLabels(name string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":     "kubeagentic-agent",
+		"app.kubernetes.io/instance": name,
+		"kubeagentic.ai/agent":       name,
+	}
+}
+
+// migrateSelector keeps the selector of found when it differs from the one of desired, as a
+// Deployment created by an operator build labelling its pods otherwise, such as with
+// app: <name>, cannot change its immutable selector. The pod template carries both label
+// sets, so that the pods are selected by either once rolled. It reports whether the selector
+// was kept.
+func migrateSelector(desired, found *appsv1.Deployment) bool {
+	if found.Spec.Selector == nil || equality.Semantic.DeepEqual(found.Spec.Selector, desired.Spec.Selector) {
+		return false
+	}
+
+	// The template labels are copied, as buildDeployment shares them with the selector.
+	labels := map[string]string{}
+	for k, v := range desired.Spec.Template.Labels {
+		labels[k] = v
+	}
+	for k, v := range found.Spec.Selector.MatchLabels {
+		labels[k] = v
+	}
+	desired.Spec.Template.Labels = labels
+	desired.Spec.Selector = found.Spec.Selector.DeepCopy()
+	return true
+}
+
+// servingSelector returns the labels selecting the agent pods that receive traffic. For a
+// Deployment whose selector was kept by migrateSelector, they follow that selector, as its
+// pods not yet rolled only carry the old labels.
+func (r *AgentReconciler) servingSelector(ctx context.Context, agent *aiv1.Agent) (map[string]string, error) {
+	selector := agentSelector(agent)
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: deploymentName(agent), Namespace: agent.Namespace}, deployment); err != nil {
+		return selector, client.IgnoreNotFound(err)
+	}
+	canonical := agentLabels(agent)
+	if deployment.Spec.Selector == nil || equality.Semantic.DeepEqual(deployment.Spec.Selector.MatchLabels, canonical) {
+		return selector, nil
+	}
+
+	// Keep the labels added to the canonical ones, such as the active blue/green revision.
+	migrated := map[string]string{}
+	for k, v := range deployment.Spec.Selector.MatchLabels {
+		migrated[k] = v
+	}
+	for k, v := range selector {
+		if _, ok := canonical[k]; !ok {
+			migrated[k] = v
+		}
+	}
+	return migrated, nil
+}
@@ -0,0 +1,266 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// +kubebuilder:rbac:groups=core,resources=limitranges;resourcequotas,verbs=get;list;watch
+
+const (
+	// limitRangeExceededReason is the reason of the Degraded condition set while the agent
+	// pods violate a LimitRange of the namespace, which rejects them.
+	limitRangeExceededReason = "LimitRangeExceeded"
+	// resourceQuotaExceededReason is the reason of the Degraded condition set while a
+	// ResourceQuota of the namespace leaves no room for an agent pod.
+	resourceQuotaExceededReason = "ResourceQuotaExceeded"
+)
+
+// reconcileNamespaceLimits checks the resources of the agent pods against the LimitRanges
+// and ResourceQuotas of the namespace. Pods they reject are never created, which would
+// otherwise only show in the events of the ReplicaSet; the problem is reported on the
+// Degraded condition instead, and resolved once the pods fit.
+func (r *AgentReconciler) reconcileNamespaceLimits(ctx context.Context, agent *aiv1.Agent) error {
+	var limitRanges corev1.LimitRangeList
+	if err := r.List(ctx, &limitRanges, client.InNamespace(agent.Namespace)); err != nil {
+		return err
+	}
+	var quotas corev1.ResourceQuotaList
+	if err := r.List(ctx, &quotas, client.InNamespace(agent.Namespace)); err != nil {
+		return err
+	}
+
+	pod := r.buildDeployment(agent).Spec.Template.Spec
+	var problems []string
+	for _, limitRange := range limitRanges.Items {
+		problems = append(problems, limitRangeViolations(&limitRange, &pod)...)
+	}
+	if len(problems) > 0 {
+		r.setFailedCondition(agent, aiv1.AgentConditionDegraded, limitRangeExceededReason, strings.Join(problems, "; "))
+		return nil
+	}
+	r.resolveDegradedCondition(agent, limitRangeExceededReason, "LimitRangeSatisfied", "The agent pods satisfy the LimitRanges of the namespace")
+
+	// The remaining quota only matters while pods of the agent are missing
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deploymentName(agent), Namespace: agent.Namespace}, deployment)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	creatingPods := errors.IsNotFound(err) || deployment.Spec.Replicas == nil || deployment.Status.Replicas < *deployment.Spec.Replicas
+	for _, quota := range quotas.Items {
+		problems = append(problems, resourceQuotaViolations(&quota, &pod, creatingPods)...)
+	}
+	if len(problems) > 0 {
+		r.setFailedCondition(agent, aiv1.AgentConditionDegraded, resourceQuotaExceededReason, strings.Join(problems, "; "))
+		return nil
+	}
+	r.resolveDegradedCondition(agent, resourceQuotaExceededReason, "ResourceQuotaAvailable", "The ResourceQuotas of the namespace leave room for the agent pods")
+	return nil
+}
+
+// defaultedResources returns the resources of container with the defaults of a Container
+// item of a LimitRange, as the LimitRanger admission plugin sets them. Requests default to
+// the limits.
+func defaultedResources(container corev1.Container, item corev1.LimitRangeItem) corev1.ResourceRequirements {
+	resources := *container.Resources.DeepCopy()
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	for name, quantity := range item.Default {
+		if _, ok := resources.Limits[name]; !ok {
+			resources.Limits[name] = quantity
+		}
+	}
+	for name, quantity := range item.DefaultRequest {
+		if _, ok := resources.Requests[name]; !ok {
+			resources.Requests[name] = quantity
+		}
+	}
+	for name, quantity := range resources.Limits {
+		if _, ok := resources.Requests[name]; !ok {
+			resources.Requests[name] = quantity
+		}
+	}
+	return resources
+}
+
+// limitRangeViolations returns the resources of the pod outside of the bounds of limitRange.
+func limitRangeViolations(limitRange *corev1.LimitRange, pod *corev1.PodSpec) []string {
+	var problems []string
+	containers := append(append([]corev1.Container{}, pod.InitContainers...), pod.Containers...)
+	for _, item := range limitRange.Spec.Limits {
+		switch item.Type {
+		case corev1.LimitTypeContainer:
+			for _, container := range containers {
+				resources := defaultedResources(container, item)
+				problems = append(problems, boundViolations(limitRange.Name, fmt.Sprintf(" of container %s", container.Name), item, resources.Requests, resources.Limits)...)
+			}
+		case corev1.LimitTypePod:
+			requests, limits := corev1.ResourceList{}, corev1.ResourceList{}
+			for _, container := range pod.Containers {
+				addResources(requests, container.Resources.Requests)
+				addResources(limits, container.Resources.Limits)
+			}
+			problems = append(problems, boundViolations(limitRange.Name, " of the agent pod", item, requests, limits)...)
+		}
+	}
+	return problems
+}
+
+// boundViolations compares requests and limits with the min, max and maximum limit to
+// request ratio of item, e.g. "memory limit 512Mi of container agent exceeds LimitRange
+// tight max 256Mi".
+func boundViolations(limitRange, subject string, item corev1.LimitRangeItem, requests, limits corev1.ResourceList) []string {
+	var problems []string
+	for _, name := range sortedResourceNames(item.Max) {
+		max := item.Max[name]
+		limit, ok := limits[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s%s has no limit, which LimitRange %s requires with max %s", name, subject, limitRange, max.String()))
+		} else if limit.Cmp(max) > 0 {
+			problems = append(problems, fmt.Sprintf("%s limit %s%s exceeds LimitRange %s max %s", name, limit.String(), subject, limitRange, max.String()))
+		}
+	}
+	for _, name := range sortedResourceNames(item.Min) {
+		min := item.Min[name]
+		request, ok := requests[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s%s has no request, which LimitRange %s requires with min %s", name, subject, limitRange, min.String()))
+		} else if request.Cmp(min) < 0 {
+			problems = append(problems, fmt.Sprintf("%s request %s%s is below LimitRange %s min %s", name, request.String(), subject, limitRange, min.String()))
+		}
+	}
+	for _, name := range sortedResourceNames(item.MaxLimitRequestRatio) {
+		ratio := item.MaxLimitRequestRatio[name]
+		request, hasRequest := requests[name]
+		limit, hasLimit := limits[name]
+		if !hasRequest || !hasLimit || request.IsZero() {
+			continue
+		}
+		if float64(limit.MilliValue())/float64(request.MilliValue()) > ratio.AsApproximateFloat64() {
+			problems = append(problems, fmt.Sprintf("%s limit %s%s is more than %s times its request %s, the LimitRange %s maximum ratio", name, limit.String(), subject, ratio.String(), request.String(), limitRange))
+		}
+	}
+	return problems
+}
+
+// sortedResourceNames returns the resource names of list in order, for stable messages.
+func sortedResourceNames(list corev1.ResourceList) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+// addResources adds the quantities of list to total.
+func addResources(total, list corev1.ResourceList) {
+	for name, quantity := range list {
+		sum := total[name]
+		sum.Add(quantity)
+		total[name] = sum
+	}
+}
+
+// podQuotaUsage returns what an agent pod counts against the quota of resource, and whether
+// the agent pods count against it. Init containers run one at a time before the others.
+func podQuotaUsage(name corev1.ResourceName, pod *corev1.PodSpec) (resource.Quantity, bool) {
+	if name == corev1.ResourcePods {
+		return *resource.NewQuantity(1, resource.DecimalSI), true
+	}
+	limits := strings.HasPrefix(string(name), "limits.")
+	compute := corev1.ResourceName(strings.TrimPrefix(strings.TrimPrefix(string(name), "limits."), "requests."))
+	if compute != corev1.ResourceCPU && compute != corev1.ResourceMemory && compute != corev1.ResourceEphemeralStorage {
+		return resource.Quantity{}, false
+	}
+	of := func(container corev1.Container) resource.Quantity {
+		if limits {
+			return container.Resources.Limits[compute]
+		}
+		return container.Resources.Requests[compute]
+	}
+	var usage resource.Quantity
+	for _, container := range pod.Containers {
+		usage.Add(of(container))
+	}
+	for _, container := range pod.InitContainers {
+		if quantity := of(container); quantity.Cmp(usage) > 0 {
+			usage = quantity
+		}
+	}
+	return usage, true
+}
+
+// resourceQuotaViolations returns the resources of an agent pod exceeding the hard limits
+// of quota, or, while pods are being created, what it has left. Quotas restricted to scopes
+// are not checked, as the agent pods may be out of their scope.
+func resourceQuotaViolations(quota *corev1.ResourceQuota, pod *corev1.PodSpec, creatingPods bool) []string {
+	if len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+		return nil
+	}
+	hard := quota.Status.Hard
+	if hard == nil {
+		hard = quota.Spec.Hard
+	}
+	var problems []string
+	for _, name := range sortedResourceNames(hard) {
+		usage, counted := podQuotaUsage(name, pod)
+		if !counted {
+			continue
+		}
+		limit := hard[name]
+		if usage.Cmp(limit) > 0 {
+			problems = append(problems, fmt.Sprintf("%s %s of an agent pod exceeds ResourceQuota %s hard limit %s", name, usage.String(), quota.Name, limit.String()))
+			continue
+		}
+		if !creatingPods {
+			continue
+		}
+		left := limit.DeepCopy()
+		left.Sub(quota.Status.Used[name])
+		if usage.Cmp(left) > 0 {
+			if left.Sign() < 0 {
+				left = resource.Quantity{}
+			}
+			used := quota.Status.Used[name]
+			problems = append(problems, fmt.Sprintf("%s %s of an agent pod exceeds the %s left of ResourceQuota %s, which has used %s of %s",
+				name, usage.String(), left.String(), quota.Name, used.String(), limit.String()))
+		}
+	}
+	return problems
+}
+
+// findAgentsForNamespaceLimits maps a LimitRange or ResourceQuota to the agents of its
+// namespace, so that they are checked again when it changes.
+func (r *AgentReconciler) findAgentsForNamespaceLimits(ctx context.Context, obj client.Object) []reconcile.Request {
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for namespace limits", "name", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(agents.Items))
+	for _, agent := range agents.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+		})
+	}
+	return requests
+}
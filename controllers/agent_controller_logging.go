@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// logLevelAnnotation overrides spec.logLevel without changing the spec, so that debugging an
+// agent managed by GitOps shows no diff. Removing it restores the level of the spec.
+const logLevelAnnotation = "kubeagentic.ai/log-level"
+
+// logLevels are the log levels of the agent runtime.
+var logLevels = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+
+// agentLogLevel returns the log level of the agent runtime: the one of the annotation, when
+// valid, then the one of the spec, then info.
+func agentLogLevel(agent *aiv1.Agent) string {
+	if level := agent.Annotations[logLevelAnnotation]; logLevels[level] {
+		return level
+	}
+	if agent.Spec.LogLevel != "" {
+		return agent.Spec.LogLevel
+	}
+	return "info"
+}
+
+// loggingEnv returns the environment variables configuring the logs of the agent runtime.
+func loggingEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	return []corev1.EnvVar{
+		{Name: "AGENT_LOG_LEVEL", Value: agentLogLevel(agent)},
+		{Name: "AGENT_DEBUG", Value: strconv.FormatBool(agent.Spec.Debug)},
+	}
+}
@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+// memoryBackend returns the configured conversation memory backend, defaulting to inmemory.
+func memoryBackend(agent *aiv1.Agent) string {
+	if agent.Spec.Memory == nil || agent.Spec.Memory.Backend == "" {
+		return "inmemory"
+	}
+	return agent.Spec.Memory.Backend
+}
+
+// managedRedisRequired reports whether the operator must deploy a Redis instance for the
+// agent, for its conversation memory, its job queue or its response cache.
+func managedRedisRequired(agent *aiv1.Agent) bool {
+	return (memoryBackend(agent) == "redis" && agent.Spec.Memory.ConnectionSecretRef == nil) || managedJobQueue(agent) || managedCacheRedis(agent)
+}
+
+// managedRedisName returns the name of the Deployment and Service of the managed Redis.
+func managedRedisName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "redis")
+}
+
+// validateMemoryConfig validates the conversation memory configuration.
+func (r *AgentReconciler) validateMemoryConfig(ctx context.Context, agent *aiv1.Agent) error {
+	memory := agent.Spec.Memory
+	if memory == nil {
+		return nil
+	}
+
+	switch memoryBackend(agent) {
+	case "inmemory":
+		if memory.ConnectionSecretRef != nil {
+			return fmt.Errorf("memory.connectionSecretRef is not supported by the inmemory backend")
+		}
+	case "redis":
+	case "postgres":
+		if memory.ConnectionSecretRef == nil {
+			return fmt.Errorf("memory.connectionSecretRef is required for the postgres backend")
+		}
+	default:
+		return fmt.Errorf("invalid memory backend: %s, must be one of [inmemory redis postgres]", memory.Backend)
+	}
+
+	if memory.MaxHistoryMessages < 0 || memory.SummarizationThreshold < 0 {
+		return fmt.Errorf("memory.maxHistoryMessages and memory.summarizationThreshold must be positive")
+	}
+	if memory.MaxHistoryMessages > 0 && memory.SummarizationThreshold > memory.MaxHistoryMessages {
+		return fmt.Errorf("memory.summarizationThreshold (%d) must not exceed memory.maxHistoryMessages (%d)",
+			memory.SummarizationThreshold, memory.MaxHistoryMessages)
+	}
+
+	if ref := memory.ConnectionSecretRef; ref != nil {
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: agent.Namespace}, secret); err != nil {
+			return fmt.Errorf("failed to get memory connection secret %s: %w", ref.Name, err)
+		}
+		if _, exists := secret.Data[ref.Key]; !exists {
+			return fmt.Errorf("key %s not found in secret %s", ref.Key, ref.Name)
+		}
+	}
+
+	return nil
+}
+
+// memoryEnv returns the AGENT_MEMORY_* environment variables configuring the runtime's
+// conversation memory.
+func memoryEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if agent.Spec.Memory == nil {
+		return nil
+	}
+	memory := agent.Spec.Memory
+
+	env := []corev1.EnvVar{
+		{Name: "AGENT_MEMORY_BACKEND", Value: memoryBackend(agent)},
+	}
+	if memory.ConnectionSecretRef != nil {
+		env = append(env, corev1.EnvVar{
+			Name: "AGENT_MEMORY_URL",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: memory.ConnectionSecretRef,
+			},
+		})
+	} else if memoryBackend(agent) == "redis" {
+		env = append(env, corev1.EnvVar{
+			Name:  "AGENT_MEMORY_URL",
+			Value: fmt.Sprintf("redis://%s.%s.svc:6379/0", managedRedisName(agent), agent.Namespace),
+		})
+	}
+	if memory.MaxHistoryMessages > 0 {
+		env = append(env, corev1.EnvVar{Name: "AGENT_MEMORY_MAX_HISTORY_MESSAGES", Value: fmt.Sprintf("%d", memory.MaxHistoryMessages)})
+	}
+	if memory.SummarizationThreshold > 0 {
+		env = append(env, corev1.EnvVar{Name: "AGENT_MEMORY_SUMMARIZATION_THRESHOLD", Value: fmt.Sprintf("%d", memory.SummarizationThreshold)})
+	}
+	return env
+}
+
+// reconcileManagedRedis deploys a single Redis instance for the agent when one is required,
+// and removes it once it no longer is.
+func (r *AgentReconciler) reconcileManagedRedis(ctx context.Context, agent *aiv1.Agent) error {
+	name := managedRedisName(agent)
+	if !managedRedisRequired(agent) {
+		for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.Service{}} {
+			err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, obj, cacheOnly)
+			if err == nil {
+				log.FromContext(ctx).Info("Deleting managed Redis", "Name", name)
+				err = r.Delete(ctx, obj)
+			}
+			if client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	deployment := r.buildManagedRedisDeployment(agent, name)
+	if err := controllerutil.SetControllerReference(agent, deployment, r.Scheme); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating managed Redis Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	service := r.buildManagedRedisService(agent, name)
+	if err := controllerutil.SetControllerReference(agent, service, r.Scheme); err != nil {
+		return err
+	}
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating managed Redis Service", "Service.Namespace", service.Namespace, "Service.Name", service.Name)
+		return r.Create(ctx, service)
+	}
+	return err
+}
+
+// managedRedisLabels returns the labels of the managed Redis resources.
+func managedRedisLabels(agent *aiv1.Agent) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-redis",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "redis",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+}
+
+// buildManagedRedisDeployment creates the Deployment running the agent's managed Redis.
+func (r *AgentReconciler) buildManagedRedisDeployment(agent *aiv1.Agent, name string) *appsv1.Deployment {
+	labels := managedRedisLabels(agent)
+
+	image := "redis:7-alpine"
+	if envImage := os.Getenv("REDIS_IMAGE"); envImage != "" {
+		image = envImage
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: int32Ptr(1),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "redis",
+							Image: image,
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: 6379, Protocol: corev1.ProtocolTCP},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("256Mi"),
+									corev1.ResourceCPU:    resource.MustParse("200m"),
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									TCPSocket: &corev1.TCPSocketAction{
+										Port: intstr.FromInt(6379),
+									},
+								},
+								PeriodSeconds: 5,
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	securityprofile.Apply(securityProfile(agent), &deployment.Spec.Template.Spec, redisUID, "/data")
+	return deployment
+}
+
+// buildManagedRedisService creates the Service exposing the agent's managed Redis.
+func (r *AgentReconciler) buildManagedRedisService(agent *aiv1.Agent, name string) *corev1.Service {
+	labels := managedRedisLabels(agent)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			Selector: labels,
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "redis",
+					Port:       6379,
+					TargetPort: intstr.FromInt(6379),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,58 @@
+package controllers
+
+import (
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// Annotations of annotation-based Prometheus discovery, set on the pod template.
+	prometheusScrapeAnnotation = "prometheus.io/scrape"
+	prometheusPortAnnotation   = "prometheus.io/port"
+	prometheusPathAnnotation   = "prometheus.io/path"
+
+	defaultMetricsPort = 8080
+	defaultMetricsPath = "/metrics"
+)
+
+// serviceMonitorKind is the kind of the Prometheus Operator resource scraping Services.
+var serviceMonitorKind = schema.GroupKind{Group: "monitoring.coreos.com", Kind: "ServiceMonitor"}
+
+// scrapeViaAnnotations reports whether Prometheus discovers the agent pods by their
+// annotations: when the agent asks for it, or when the cluster has no ServiceMonitor CRD.
+func (r *AgentReconciler) scrapeViaAnnotations(agent *aiv1.Agent) bool {
+	metrics := agent.Spec.Metrics
+	if metrics == nil || !metrics.Enabled {
+		return false
+	}
+	if metrics.ScrapeVia == "annotations" {
+		return true
+	}
+	mappings, err := r.RESTMapper().RESTMappings(serviceMonitorKind)
+	return err != nil || len(mappings) == 0
+}
+
+// prometheusAnnotations returns the pod annotations of annotation-based Prometheus
+// discovery, derived from the metrics configuration of the agent.
+func prometheusAnnotations(agent *aiv1.Agent) map[string]string {
+	// The rate limit and guardrails proxies serve the agent metrics followed by their own
+	port := int32(defaultMetricsPort)
+	if inboundRateLimitEnabled(agent) || guardrailsEnabled(agent) {
+		port = agentServingPort(agent)
+	}
+	if agent.Spec.Metrics.Port != nil {
+		port = *agent.Spec.Metrics.Port
+	}
+	path := defaultMetricsPath
+	if agent.Spec.Metrics.Path != "" {
+		path = agent.Spec.Metrics.Path
+	}
+	return map[string]string{
+		prometheusScrapeAnnotation: "true",
+		prometheusPortAnnotation:   strconv.Itoa(int(port)),
+		prometheusPathAnnotation:   path,
+	}
+}
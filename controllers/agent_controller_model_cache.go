@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// modelCacheMountPath is where the shared model cache is mounted in the agent container.
+const modelCacheMountPath = "/var/cache/models"
+
+// modelCacheSettings is the operator-level configuration of the shared model cache,
+// read from the operator's environment.
+type modelCacheSettings struct {
+	// PVCName is the name of the shared claim created in each agent namespace.
+	PVCName string
+	// HostPath, when set, is used instead of a PersistentVolumeClaim.
+	HostPath string
+	// StorageClass is the storage class of the managed claim. Empty uses the cluster default.
+	StorageClass string
+	// Size is the requested capacity of the managed claim.
+	Size string
+	// AccessMode is the access mode of the managed claim.
+	AccessMode corev1.PersistentVolumeAccessMode
+}
+
+// getModelCacheSettings returns the operator-level model cache configuration.
+func getModelCacheSettings() modelCacheSettings {
+	settings := modelCacheSettings{
+		PVCName:      "kubeagentic-model-cache",
+		HostPath:     os.Getenv("MODEL_CACHE_HOST_PATH"),
+		StorageClass: os.Getenv("MODEL_CACHE_STORAGE_CLASS"),
+		Size:         "100Gi",
+		AccessMode:   corev1.ReadWriteMany,
+	}
+	if name := os.Getenv("MODEL_CACHE_PVC_NAME"); name != "" {
+		settings.PVCName = name
+	}
+	if size := os.Getenv("MODEL_CACHE_SIZE"); size != "" {
+		settings.Size = size
+	}
+	if mode := os.Getenv("MODEL_CACHE_ACCESS_MODE"); mode != "" {
+		settings.AccessMode = corev1.PersistentVolumeAccessMode(mode)
+	}
+	return settings
+}
+
+// modelCacheEnabled reports whether the agent opted into the shared model cache.
+func modelCacheEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.ModelCache != nil && agent.Spec.ModelCache.Enabled
+}
+
+var invalidSubPathChars = regexp.MustCompile(`[^a-zA-Z0-9._-]+`)
+
+// modelCacheSubPath returns the cache directory used by the agent.
+func modelCacheSubPath(agent *aiv1.Agent) string {
+	if agent.Spec.ModelCache.SubPath != "" {
+		return agent.Spec.ModelCache.SubPath
+	}
+	return strings.Trim(invalidSubPathChars.ReplaceAllString(agent.Spec.Model, "-"), "-")
+}
+
+// validateModelCache refuses to share a single-writer cache between several replicas.
+func (r *AgentReconciler) validateModelCache(ctx context.Context, agent *aiv1.Agent) error {
+	if !modelCacheEnabled(agent) {
+		return nil
+	}
+
+	subPath := agent.Spec.ModelCache.SubPath
+	if strings.HasPrefix(subPath, "/") || strings.Contains(subPath, "..") {
+		return fmt.Errorf("model cache subPath %q must be a relative path without '..'", subPath)
+	}
+
+	settings := getModelCacheSettings()
+	if agent.Spec.ModelCache.PVCName == "" && settings.HostPath != "" {
+		return nil
+	}
+
+	replicas := int32(1)
+	if agent.Spec.Replicas != nil {
+		replicas = *agent.Spec.Replicas
+	}
+
+	accessModes := []corev1.PersistentVolumeAccessMode{settings.AccessMode}
+	if agent.Spec.ModelCache.PVCName != "" {
+		pvc := &corev1.PersistentVolumeClaim{}
+		err := r.Get(ctx, types.NamespacedName{Name: agent.Spec.ModelCache.PVCName, Namespace: agent.Namespace}, pvc)
+		if err != nil {
+			return fmt.Errorf("failed to get model cache PVC %s: %w", agent.Spec.ModelCache.PVCName, err)
+		}
+		accessModes = pvc.Spec.AccessModes
+	}
+
+	if replicas > 1 && !hasSharedAccessMode(accessModes) {
+		return fmt.Errorf("model cache with ReadWriteOnce storage cannot be shared by %d replicas, use ReadWriteMany storage or a single replica", replicas)
+	}
+
+	return nil
+}
+
+// hasSharedAccessMode reports whether the access modes allow mounting from several nodes.
+func hasSharedAccessMode(modes []corev1.PersistentVolumeAccessMode) bool {
+	for _, mode := range modes {
+		if mode == corev1.ReadWriteMany || mode == corev1.ReadOnlyMany {
+			return true
+		}
+	}
+	return false
+}
+
+// reconcileModelCachePVC creates the operator-managed cache claim in the agent's namespace.
+// The claim is shared by all agents in the namespace and is therefore not owned by any of them.
+func (r *AgentReconciler) reconcileModelCachePVC(ctx context.Context, agent *aiv1.Agent) error {
+	if !modelCacheEnabled(agent) || agent.Spec.ModelCache.PVCName != "" {
+		return nil
+	}
+
+	settings := getModelCacheSettings()
+	if settings.HostPath != "" {
+		return nil
+	}
+
+	found := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: settings.PVCName, Namespace: agent.Namespace}, found)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	size, err := resource.ParseQuantity(settings.Size)
+	if err != nil {
+		return fmt.Errorf("invalid MODEL_CACHE_SIZE %q: %w", settings.Size, err)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      settings.PVCName,
+			Namespace: agent.Namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/name":       "kubeagentic-model-cache",
+				"app.kubernetes.io/managed-by": "kubeagentic",
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{settings.AccessMode},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	if settings.StorageClass != "" {
+		pvc.Spec.StorageClassName = &settings.StorageClass
+	}
+
+	log.FromContext(ctx).Info("Creating shared model cache PVC", "PVC.Namespace", pvc.Namespace, "PVC.Name", pvc.Name)
+	return r.Create(ctx, pvc)
+}
+
+// modelCacheVolume returns the pod volume, container mount and environment that expose
+// the shared model cache to the agent container.
+func modelCacheVolume(agent *aiv1.Agent) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	if !modelCacheEnabled(agent) {
+		return nil, nil, nil
+	}
+
+	settings := getModelCacheSettings()
+	volume := corev1.Volume{Name: "model-cache"}
+	switch {
+	case agent.Spec.ModelCache.PVCName != "":
+		volume.VolumeSource.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: agent.Spec.ModelCache.PVCName,
+		}
+	case settings.HostPath != "":
+		hostPathType := corev1.HostPathDirectoryOrCreate
+		volume.VolumeSource.HostPath = &corev1.HostPathVolumeSource{
+			Path: settings.HostPath,
+			Type: &hostPathType,
+		}
+	default:
+		volume.VolumeSource.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{
+			ClaimName: settings.PVCName,
+		}
+	}
+
+	mount := corev1.VolumeMount{
+		Name:      "model-cache",
+		MountPath: modelCacheMountPath,
+		SubPath:   modelCacheSubPath(agent),
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "HF_HOME", Value: modelCacheMountPath},
+		{Name: "VLLM_CACHE_ROOT", Value: modelCacheMountPath + "/vllm"},
+	}
+
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}, env
+}
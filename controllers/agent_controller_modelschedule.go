@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/modelschedule"
+)
+
+const (
+	// modelScheduleMountPath is where model.json is mounted in the agent container.
+	modelScheduleMountPath = "/etc/kubeagentic/model"
+	// modelScheduleConfigKey is the key of the agent ConfigMap holding the model in effect.
+	modelScheduleConfigKey = "model.json"
+	// modelSwitchedReason is the reason of the Event recorded when the schedule switches the
+	// model.
+	modelSwitchedReason = "ModelSwitched"
+)
+
+// scheduledModel is the model.json of the agent ConfigMap, read by runtimes reloading the
+// model in effect.
+type scheduledModel struct {
+	Model       string `json:"model"`
+	Temperature string `json:"temperature,omitempty"`
+	MaxTokens   *int32 `json:"maxTokens,omitempty"`
+}
+
+// modelScheduleEnabled reports whether the agent switches its model on a schedule.
+func modelScheduleEnabled(agent *aiv1.Agent) bool {
+	return len(agent.Spec.ModelSchedule) > 0
+}
+
+// modelScheduleHotReload reports whether the agent runtime reloads the model in effect from
+// the agent ConfigMap, which the direct runtime of the operator images does. Agents with an
+// image of their own or the langgraph framework are rolled at the window boundaries instead.
+func modelScheduleHotReload(agent *aiv1.Agent) bool {
+	return modelScheduleEnabled(agent) && agent.Spec.Image == "" && agent.Spec.Framework != "langgraph"
+}
+
+// modelScheduleWindows parses the windows of spec.modelSchedule.
+func modelScheduleWindows(agent *aiv1.Agent) ([]*modelschedule.Window, error) {
+	windows := make([]*modelschedule.Window, len(agent.Spec.ModelSchedule))
+	for i, entry := range agent.Spec.ModelSchedule {
+		window, err := modelschedule.ParseWindow(entry.Window.Start, entry.Window.Duration.Duration, entry.Window.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("modelSchedule[%d].window: %w", i, err)
+		}
+		windows[i] = window
+	}
+	return windows, nil
+}
+
+// validateModelSchedule checks the windows and the parameters of the model schedule. Rollouts
+// and experiments run pods with another model than the stable ones, so they cannot be
+// combined with a schedule switching it.
+func validateModelSchedule(agent *aiv1.Agent) error {
+	if !modelScheduleEnabled(agent) {
+		return nil
+	}
+	if agent.Spec.Rollout != nil {
+		return fmt.Errorf("modelSchedule cannot be combined with rollout")
+	}
+	if agent.Spec.Experiment != nil {
+		return fmt.Errorf("modelSchedule cannot be combined with experiment")
+	}
+	if _, err := modelScheduleWindows(agent); err != nil {
+		return err
+	}
+	for i, entry := range agent.Spec.ModelSchedule {
+		if entry.Model == "" {
+			return fmt.Errorf("modelSchedule[%d].model is required", i)
+		}
+		if params := entry.ModelParameters; params != nil && params.Temperature != "" {
+			if _, err := strconv.ParseFloat(params.Temperature, 64); err != nil {
+				return fmt.Errorf("modelSchedule[%d].modelParameters.temperature %q is not a number", i, params.Temperature)
+			}
+		}
+	}
+	return nil
+}
+
+// activeScheduleEntry returns the entry of spec.modelSchedule in effect, or nil while the
+// agent serves with spec.model.
+func activeScheduleEntry(agent *aiv1.Agent) *aiv1.ModelScheduleEntry {
+	status := agent.Status.ModelSchedule
+	if !modelScheduleEnabled(agent) || status == nil || status.ActiveEntry == nil || int(*status.ActiveEntry) >= len(agent.Spec.ModelSchedule) {
+		return nil
+	}
+	return &agent.Spec.ModelSchedule[*status.ActiveEntry]
+}
+
+// reconcileModelSchedule selects the entry of spec.modelSchedule in effect and reports it,
+// with the time of the next switch, in status.modelSchedule. An Event is recorded when the
+// model changes.
+func (r *AgentReconciler) reconcileModelSchedule(ctx context.Context, agent *aiv1.Agent) error {
+	if !modelScheduleEnabled(agent) {
+		agent.Status.ModelSchedule = nil
+		return nil
+	}
+	windows, err := modelScheduleWindows(agent)
+	if err != nil {
+		return err
+	}
+
+	now := r.clock().Now()
+	status := &aiv1.ModelScheduleStatus{ActiveModel: agent.Spec.Model}
+	if active := modelschedule.Active(windows, now); active >= 0 {
+		entry := int32(active)
+		status.ActiveEntry = &entry
+		status.ActiveModel = agent.Spec.ModelSchedule[active].Model
+	}
+	if next, ok := modelschedule.NextSwitch(windows, now); ok {
+		nextSwitch := metav1.NewTime(next)
+		status.NextSwitchTime = &nextSwitch
+	}
+	if previous := agent.Status.ModelSchedule; previous != nil && previous.ActiveModel != status.ActiveModel {
+		r.recordEvent(agent, corev1.EventTypeNormal, modelSwitchedReason, fmt.Sprintf("Switched the model from %s to %s", previous.ActiveModel, status.ActiveModel))
+	}
+	agent.Status.ModelSchedule = status
+	return nil
+}
+
+// modelScheduleConfig returns the model.json of the agent ConfigMap, naming the model in
+// effect and its parameters.
+func modelScheduleConfig(agent *aiv1.Agent) (string, error) {
+	config := scheduledModel{Model: agent.Spec.Model}
+	if entry := activeScheduleEntry(agent); entry != nil {
+		config.Model = entry.Model
+		if params := entry.ModelParameters; params != nil {
+			config.Temperature, config.MaxTokens = params.Temperature, params.MaxTokens
+		}
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// servedModel returns the model set in the environment of the agent container: the model in
+// effect for agents rolled at the window boundaries, spec.model otherwise.
+func servedModel(agent *aiv1.Agent) string {
+	if entry := activeScheduleEntry(agent); entry != nil && !modelScheduleHotReload(agent) {
+		return entry.Model
+	}
+	return agent.Spec.Model
+}
+
+// modelScheduleVolume returns the parameters of the model in effect for agents rolled at
+// the window boundaries, so that a switch changes the pod template. Runtimes reloading the
+// model get the volume projecting model.json of the agent ConfigMap, its mount and the
+// environment variable locating it instead, which keep the pod template unchanged.
+func modelScheduleVolume(agent *aiv1.Agent) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	if !modelScheduleEnabled(agent) {
+		return nil, nil, nil
+	}
+	if !modelScheduleHotReload(agent) {
+		var env []corev1.EnvVar
+		if entry := activeScheduleEntry(agent); entry != nil && entry.ModelParameters != nil {
+			if entry.ModelParameters.Temperature != "" {
+				env = append(env, corev1.EnvVar{Name: "AGENT_TEMPERATURE", Value: entry.ModelParameters.Temperature})
+			}
+			if entry.ModelParameters.MaxTokens != nil {
+				env = append(env, corev1.EnvVar{Name: "AGENT_MAX_TOKENS", Value: strconv.Itoa(int(*entry.ModelParameters.MaxTokens))})
+			}
+		}
+		return nil, nil, env
+	}
+
+	volume := corev1.Volume{
+		Name: "model-schedule",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName(agent)},
+				Items:                []corev1.KeyToPath{{Key: modelScheduleConfigKey, Path: modelScheduleConfigKey}},
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      "model-schedule",
+		MountPath: modelScheduleMountPath,
+		ReadOnly:  true,
+	}
+	env := []corev1.EnvVar{{Name: "AGENT_MODEL_FILE", Value: modelScheduleMountPath + "/" + modelScheduleConfigKey}}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}, env
+}
+
+// modelScheduleRequeueAfter shortens requeue to reach the next switch of the model schedule
+// on time.
+func (r *AgentReconciler) modelScheduleRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if !modelScheduleEnabled(agent) || agent.Status.ModelSchedule == nil || agent.Status.ModelSchedule.NextSwitchTime == nil {
+		return requeue
+	}
+	if untilSwitch := agent.Status.ModelSchedule.NextSwitchTime.Sub(r.clock().Now()); untilSwitch < requeue {
+		if untilSwitch < time.Second {
+			return time.Second
+		}
+		return untilSwitch
+	}
+	return requeue
+}
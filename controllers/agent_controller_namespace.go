@@ -0,0 +1,27 @@
+package controllers
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// namespaceTerminating reports whether the namespace of the agent is being deleted. The API
+// server refuses to create anything in it, and the namespace controller deletes the agent
+// shortly, so reconciling it would only fail until then. A namespace that cannot be read
+// is assumed to be active.
+func (r *AgentReconciler) namespaceTerminating(ctx context.Context, namespace string) bool {
+	ns := &corev1.Namespace{}
+	if err := r.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		if !errors.IsNotFound(err) {
+			log.FromContext(ctx).V(1).Info("Failed to get namespace, assuming it is active", "namespace", namespace, "error", err.Error())
+		}
+		return false
+	}
+	return ns.DeletionTimestamp != nil || ns.Status.Phase == corev1.NamespaceTerminating
+}
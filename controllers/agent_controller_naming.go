@@ -0,0 +1,151 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/ingresshost"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+// agentResourceNames returns the names of the resources of the agent, honoring
+// spec.nameOverrides.
+func agentResourceNames(agent *aiv1.Agent) aiv1.ResourceNames {
+	return naming.Names(agent.Name, agent.Spec.NameOverrides)
+}
+
+// deploymentName returns the name of the Deployment running the agent pods.
+func deploymentName(agent *aiv1.Agent) string {
+	return agentResourceNames(agent).Deployment
+}
+
+// serviceName returns the name of the Service exposing the agent.
+func serviceName(agent *aiv1.Agent) string {
+	return agentResourceNames(agent).Service
+}
+
+// configMapName returns the name of the ConfigMap holding the tools and configuration of
+// the agent.
+func configMapName(agent *aiv1.Agent) string {
+	return agentResourceNames(agent).ConfigMap
+}
+
+// hpaName returns the name of the HorizontalPodAutoscaler of the agent.
+func hpaName(agent *aiv1.Agent) string {
+	return agentResourceNames(agent).HorizontalPodAutoscaler
+}
+
+// ingressName returns the name of the Ingress of the agent.
+func ingressName(agent *aiv1.Agent) string {
+	return agentResourceNames(agent).Ingress
+}
+
+// ingressHost returns the host the Ingress of the agent serves: spec.ingress.host, or a host
+// whose first label is the agent name limited like any DNS label.
+func ingressHost(agent *aiv1.Agent) string {
+	route, _ := ingresshost.For(agent.Name, agent.Namespace, &agent.Spec)
+	return route.Host
+}
+
+// ingressPath returns the path prefix the Ingress of the agent serves, / unless
+// spec.ingress.path is set.
+func ingressPath(agent *aiv1.Agent) string {
+	route, _ := ingresshost.For(agent.Name, agent.Namespace, &agent.Spec)
+	return route.Path
+}
+
+// monitoringConfigMapName returns the name of the ConfigMap holding the scrape
+// configuration of the agent.
+func monitoringConfigMapName(agent *aiv1.Agent) string {
+	return agentResourceNames(agent).MonitoringConfigMap
+}
+
+// grafanaDashboardName returns the name of the ConfigMap holding the Grafana dashboard of
+// the agent.
+func grafanaDashboardName(agent *aiv1.Agent) string {
+	return agentResourceNames(agent).GrafanaDashboard
+}
+
+// resourceNames returns the names of the main resources in use for the agent, so that
+// users can find them when they were shortened or overridden.
+func resourceNames(agent *aiv1.Agent) *aiv1.ResourceNames {
+	names := agentResourceNames(agent)
+	if !hpaEnabled(agent) {
+		names.HorizontalPodAutoscaler = ""
+	}
+	if ingressEnabled(agent) {
+		names.IngressHost = ingressHost(agent)
+	} else {
+		names.Ingress = ""
+	}
+	return &names
+}
+
+// renamedResource is a resource of the agent whose name changed from name to current.
+type renamedResource struct {
+	name, current string
+	obj           client.Object
+}
+
+// sweepRenamedResources deletes the resources of the agent left behind by a changed name
+// override, found by their names in the status. The previous Deployment is only deleted
+// once the one replacing it is ready, as the Service sends requests to the pods of both
+// meanwhile; it reports whether the previous Deployment still runs.
+func (r *AgentReconciler) sweepRenamedResources(ctx context.Context, agent *aiv1.Agent) (bool, error) {
+	previous := agent.Status.ResourceNames
+	if previous == nil {
+		return false, nil
+	}
+	current := resourceNames(agent)
+
+	renamed := []renamedResource{
+		{previous.Service, current.Service, &corev1.Service{}},
+		{previous.ConfigMap, current.ConfigMap, &corev1.ConfigMap{}},
+		{previous.HorizontalPodAutoscaler, current.HorizontalPodAutoscaler, &autoscalingv2.HorizontalPodAutoscaler{}},
+		{previous.Ingress, current.Ingress, &networkingv1.Ingress{}},
+	}
+	previousDeploymentRuns := false
+	if previous.Deployment != "" && previous.Deployment != current.Deployment {
+		deployment := &appsv1.Deployment{}
+		if err := r.Get(ctx, types.NamespacedName{Name: current.Deployment, Namespace: agent.Namespace}, deployment); client.IgnoreNotFound(err) != nil {
+			return false, err
+		} else if err == nil && deploymentReady(deployment) {
+			renamed = append(renamed, renamedResource{previous.Deployment, current.Deployment, &appsv1.Deployment{}})
+		} else {
+			previousDeploymentRuns = true
+		}
+	}
+
+	for _, resource := range renamed {
+		// Resources that are disabled rather than renamed are deleted by their own step.
+		if resource.name == "" || resource.current == "" || resource.name == resource.current {
+			continue
+		}
+		if err := r.Get(ctx, types.NamespacedName{Name: resource.name, Namespace: agent.Namespace}, resource.obj); err != nil {
+			if errors.IsNotFound(err) {
+				continue
+			}
+			return previousDeploymentRuns, err
+		}
+		// Only delete what the agent owns, never an object that happens to have the old name.
+		if !metav1.IsControlledBy(resource.obj, agent) {
+			continue
+		}
+		log.FromContext(ctx).Info("Deleting renamed resource", "kind", fmt.Sprintf("%T", resource.obj), "name", resource.name, "newName", resource.current)
+		if err := r.Delete(ctx, resource.obj); client.IgnoreNotFound(err) != nil {
+			return previousDeploymentRuns, err
+		}
+	}
+	return previousDeploymentRuns, nil
+}
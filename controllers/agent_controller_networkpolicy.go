@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+// lookupHost resolves endpoint hostnames to addresses for egress CIDRs. It is a variable so
+// that tests can replace it.
+var lookupHost = net.DefaultResolver.LookupHost
+
+// defaultSchemePorts are the ports assumed for endpoint URLs without an explicit port.
+var defaultSchemePorts = map[string]int32{
+	"http":       80,
+	"https":      443,
+	"grpc":       443,
+	"postgres":   5432,
+	"postgresql": 5432,
+	"redis":      6379,
+	"rediss":     6379,
+}
+
+// networkPolicyEnabled reports whether the agent pods are restricted by a NetworkPolicy.
+func networkPolicyEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.NetworkPolicy != nil && agent.Spec.NetworkPolicy.Enabled
+}
+
+// getIngressControllerNamespace returns the namespace of the ingress controller allowed to
+// reach the agents, from the INGRESS_CONTROLLER_NAMESPACE environment variable.
+func getIngressControllerNamespace() string {
+	if namespace := os.Getenv("INGRESS_CONTROLLER_NAMESPACE"); namespace != "" {
+		return namespace
+	}
+	return "ingress-nginx"
+}
+
+// reconcileNetworkPolicy creates, updates or deletes the NetworkPolicy of the agent pods.
+func (r *AgentReconciler) reconcileNetworkPolicy(ctx context.Context, agent *aiv1.Agent) error {
+	name := naming.Child(agent.Name, "network-policy")
+	if !networkPolicyEnabled(agent) {
+		policy := &networkingv1.NetworkPolicy{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, policy, cacheOnly)
+		if err == nil {
+			log.FromContext(ctx).Info("Deleting NetworkPolicy", "NetworkPolicy.Name", policy.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, policy))
+		}
+		return client.IgnoreNotFound(err)
+	}
+
+	policy, err := r.buildNetworkPolicy(ctx, agent, name)
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(agent, policy, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &networkingv1.NetworkPolicy{}
+	err = r.Get(ctx, types.NamespacedName{Name: policy.Name, Namespace: policy.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new NetworkPolicy", "NetworkPolicy.Namespace", policy.Namespace, "NetworkPolicy.Name", policy.Name)
+		return r.Create(ctx, policy)
+	} else if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Updating existing NetworkPolicy", "NetworkPolicy.Namespace", found.Namespace, "NetworkPolicy.Name", found.Name)
+	found.Spec = policy.Spec
+	return r.Update(ctx, found)
+}
+
+// buildNetworkPolicy creates the NetworkPolicy for the agent pods. Ingress is limited to the
+// agent namespace and the ingress controller, and to the operator namespace on the admin
+// port; egress to DNS and the endpoints the agent uses.
+func (r *AgentReconciler) buildNetworkPolicy(ctx context.Context, agent *aiv1.Agent, name string) (*networkingv1.NetworkPolicy, error) {
+	labels := agentLabels(agent)
+	tcp := corev1.ProtocolTCP
+	udp := corev1.ProtocolUDP
+	// Clients reach the agent through its rate limit and guardrails proxies when it has them
+	agentPort := intstr.FromInt(int(agentServingPort(agent)))
+	dnsPort := intstr.FromInt(53)
+
+	ingressFrom := []networkingv1.NetworkPolicyPeer{
+		{PodSelector: &metav1.LabelSelector{}},
+		{NamespaceSelector: namespaceSelector(getIngressControllerNamespace())},
+	}
+	ingressFrom = append(ingressFrom, agent.Spec.NetworkPolicy.ExtraIngressFrom...)
+
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{
+			To: []networkingv1.NetworkPolicyPeer{
+				{
+					NamespaceSelector: &metav1.LabelSelector{},
+					PodSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{"k8s-app": "kube-dns"},
+					},
+				},
+			},
+			Ports: []networkingv1.NetworkPolicyPort{
+				{Protocol: &udp, Port: &dnsPort},
+				{Protocol: &tcp, Port: &dnsPort},
+			},
+		},
+	}
+
+	// Hosted providers are reached over HTTPS; their addresses are not stable enough to pin.
+	endpoints := providerEndpointURLs(agent)
+	if len(endpoints) == 0 {
+		egress = appendEgressRule(egress, egressRuleForPort(443, nil))
+	}
+	if embeddings := effectiveEmbeddings(agent); embeddings != nil {
+		if embeddings.Endpoint != "" {
+			endpoints = append(endpoints, embeddings.Endpoint)
+		} else {
+			egress = appendEgressRule(egress, egressRuleForPort(443, nil))
+		}
+	}
+
+	// The semantic cache embeds prompts with its own embeddings model.
+	if cachingEnabled(agent) && cacheKeyStrategy(agent) == "semantic" {
+		if embeddings := cacheEmbeddings(agent); embeddings != nil && embeddings.Endpoint != "" {
+			endpoints = append(endpoints, embeddings.Endpoint)
+		} else if embeddings != nil {
+			egress = appendEgressRule(egress, egressRuleForPort(443, nil))
+		}
+	}
+
+	// Chat platforms are reached over HTTPS and WebSockets by the connector sidecar.
+	if connectorsEnabled(agent) {
+		egress = appendEgressRule(egress, egressRuleForPort(443, nil))
+	}
+
+	// The transcript sink is pinned like the endpoints.
+	if transcriptSinkEnabled(agent) {
+		endpoints = append(endpoints, agent.Spec.TranscriptSink.URL)
+	}
+
+	// Kafka brokers are pinned; SQS is reached over HTTPS.
+	endpoints = append(endpoints, eventSourceEndpoints(agent)...)
+	if eventSourceEnabled(agent) && agent.Spec.EventSource.Type == "sqs" {
+		egress = appendEgressRule(egress, egressRuleForPort(443, nil))
+	}
+
+	// An external job queue is only known through its Secret.
+	if workerModeEnabled(agent) && agent.Spec.WorkerMode.QueueSecretRef != nil {
+		address, err := r.secretValue(ctx, agent.Namespace, &corev1.SecretKeySelector{LocalObjectReference: *agent.Spec.WorkerMode.QueueSecretRef, Key: jobQueueAddressKey})
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, "tcp://"+address)
+	}
+
+	// Vector store and memory backends are only known through their connection secrets.
+	if ragEnabled(agent) && agent.Spec.RAG.VectorStore != nil {
+		endpoint, err := r.secretValue(ctx, agent.Namespace, &agent.Spec.RAG.VectorStore.ConnectionSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	if agent.Spec.Memory != nil && agent.Spec.Memory.ConnectionSecretRef != nil {
+		endpoint, err := r.secretValue(ctx, agent.Namespace, agent.Spec.Memory.ConnectionSecretRef)
+		if err != nil {
+			return nil, err
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	if managedRedisRequired(agent) {
+		egress = appendEgressRule(egress, egressRuleForPort(6379, []networkingv1.NetworkPolicyPeer{
+			{PodSelector: &metav1.LabelSelector{MatchLabels: managedRedisLabels(agent)}},
+		}))
+	}
+
+	for _, endpoint := range endpoints {
+		rule, err := egressRuleForURL(ctx, agent.Namespace, endpoint)
+		if err != nil {
+			log.FromContext(ctx).Info("Skipping egress rule for unparsable endpoint, use extraEgressTo instead", "error", err.Error())
+			continue
+		}
+		egress = appendEgressRule(egress, rule)
+	}
+
+	// The workers get the policy of the agent pods they are built from.
+	podSelector := metav1.LabelSelector{MatchLabels: labels}
+	if workerModeEnabled(agent) {
+		podSelector = metav1.LabelSelector{
+			MatchLabels: map[string]string{"kubeagentic.ai/agent": agent.Name},
+			MatchExpressions: []metav1.LabelSelectorRequirement{{
+				Key:      "app.kubernetes.io/name",
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   []string{labels["app.kubernetes.io/name"], workerLabels(agent)["app.kubernetes.io/name"]},
+			}},
+		}
+	}
+
+	if len(agent.Spec.NetworkPolicy.ExtraEgressTo) > 0 {
+		egress = append(egress, networkingv1.NetworkPolicyEgressRule{To: agent.Spec.NetworkPolicy.ExtraEgressTo})
+	}
+
+	return &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: podSelector,
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress: append([]networkingv1.NetworkPolicyIngressRule{
+				{
+					From:  ingressFrom,
+					Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &agentPort}},
+				},
+			}, r.adminIngressRule(agent)...),
+			Egress: egress,
+		},
+	}, nil
+}
+
+// egressRuleForURL returns the egress rule allowing connections to the endpoint. In-cluster
+// service names map to their namespace; other hosts are pinned to their addresses when they
+// can be resolved, and otherwise only restricted by port.
+func egressRuleForURL(ctx context.Context, namespace, rawURL string) (networkingv1.NetworkPolicyEgressRule, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return networkingv1.NetworkPolicyEgressRule{}, fmt.Errorf("endpoint is not a URL with a host")
+	}
+
+	port, ok := defaultSchemePorts[u.Scheme]
+	if u.Port() != "" {
+		p, err := strconv.ParseInt(u.Port(), 10, 32)
+		if err != nil {
+			return networkingv1.NetworkPolicyEgressRule{}, fmt.Errorf("invalid port %q", u.Port())
+		}
+		port, ok = int32(p), true
+	}
+	if !ok {
+		return networkingv1.NetworkPolicyEgressRule{}, fmt.Errorf("no default port for scheme %q", u.Scheme)
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		return egressRuleForPort(port, []networkingv1.NetworkPolicyPeer{ipBlockPeer(ip)}), nil
+	}
+
+	// Service DNS names: <service> or <service>.<namespace>.svc[.cluster.local]
+	parts := strings.Split(host, ".")
+	if len(parts) == 1 || strings.HasSuffix(host, ".svc") || strings.HasSuffix(host, ".svc.cluster.local") {
+		serviceNamespace := namespace
+		if len(parts) > 1 {
+			serviceNamespace = parts[1]
+		}
+		return egressRuleForPort(port, []networkingv1.NetworkPolicyPeer{
+			{NamespaceSelector: namespaceSelector(serviceNamespace)},
+		}), nil
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	addresses, err := lookupHost(lookupCtx, host)
+	if err != nil {
+		return egressRuleForPort(port, nil), nil
+	}
+	var peers []networkingv1.NetworkPolicyPeer
+	for _, address := range addresses {
+		if ip := net.ParseIP(address); ip != nil {
+			peers = append(peers, ipBlockPeer(ip))
+		}
+	}
+	return egressRuleForPort(port, peers), nil
+}
+
+// egressRuleForPort returns an egress rule for the TCP port, to the peers or anywhere if none are given.
+func egressRuleForPort(port int32, peers []networkingv1.NetworkPolicyPeer) networkingv1.NetworkPolicyEgressRule {
+	tcp := corev1.ProtocolTCP
+	p := intstr.FromInt(int(port))
+	return networkingv1.NetworkPolicyEgressRule{
+		To:    peers,
+		Ports: []networkingv1.NetworkPolicyPort{{Protocol: &tcp, Port: &p}},
+	}
+}
+
+// appendEgressRule appends the rule unless an identical one is already present.
+func appendEgressRule(rules []networkingv1.NetworkPolicyEgressRule, rule networkingv1.NetworkPolicyEgressRule) []networkingv1.NetworkPolicyEgressRule {
+	for _, existing := range rules {
+		if equality.Semantic.DeepEqual(existing, rule) {
+			return rules
+		}
+	}
+	return append(rules, rule)
+}
+
+// ipBlockPeer returns a peer matching exactly the address.
+func ipBlockPeer(ip net.IP) networkingv1.NetworkPolicyPeer {
+	cidr := ip.String() + "/32"
+	if ip.To4() == nil {
+		cidr = ip.String() + "/128"
+	}
+	return networkingv1.NetworkPolicyPeer{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}
+}
+
+// namespaceSelector selects the namespace by its well-known name label.
+func namespaceSelector(namespace string) *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{"kubernetes.io/metadata.name": namespace},
+	}
+}
+
+// secretValue returns the value of the referenced secret key.
+func (r *AgentReconciler) secretValue(ctx context.Context, namespace string, ref *corev1.SecretKeySelector) (string, error) {
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: namespace}, secret); err != nil {
+		return "", fmt.Errorf("failed to get secret %s: %w", ref.Name, err)
+	}
+	value, exists := secret.Data[ref.Key]
+	if !exists {
+		return "", fmt.Errorf("key %s not found in secret %s", ref.Key, ref.Name)
+	}
+	return string(value), nil
+}
@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// peersMountPath is where peers.json and the peer tokens are mounted in the agent container.
+	peersMountPath = "/etc/kubeagentic/peers"
+	// peersChecksumAnnotation on the pod template rolls the agent pods when a peer moves or
+	// rotates its endpoint token.
+	peersChecksumAnnotation = "kubeagentic.ai/peers-checksum"
+	// peersConfigKey is the key of the agent ConfigMap holding the peers.
+	peersConfigKey = "peers.json"
+)
+
+// peersFileMode makes the mounted peer tokens readable by the file owner only.
+var peersFileMode int32 = 0400
+
+// peerConfig is an entry of peers.json.
+type peerConfig struct {
+	Alias string `json:"alias"`
+	Agent string `json:"agent"`
+	URL   string `json:"url"`
+	// TokenFile holds the bearer token of peers with endpoint auth.
+	TokenFile string `json:"tokenFile,omitempty"`
+}
+
+// peerAlias returns the name of the peer for the agent.
+func peerAlias(peer aiv1.AgentPeer) string {
+	if peer.Alias != "" {
+		return peer.Alias
+	}
+	return peer.AgentRef
+}
+
+// resolvePeers returns the peers of the agent that exist, with their URL and token file,
+// and the names of the peers that do not.
+func (r *AgentReconciler) resolvePeers(ctx context.Context, agent *aiv1.Agent) ([]peerConfig, []*aiv1.Agent, []string, error) {
+	var peers []peerConfig
+	var peerAgents []*aiv1.Agent
+	var missing []string
+	for _, peer := range agent.Spec.Peers {
+		peerAgent := &aiv1.Agent{}
+		err := r.Get(ctx, types.NamespacedName{Name: peer.AgentRef, Namespace: agent.Namespace}, peerAgent)
+		if errors.IsNotFound(err) || err == nil && peerAgent.DeletionTimestamp != nil {
+			missing = append(missing, peer.AgentRef)
+			continue
+		} else if err != nil {
+			return nil, nil, nil, err
+		}
+		config := peerConfig{
+			Alias: peerAlias(peer),
+			Agent: peer.AgentRef,
+			URL:   fmt.Sprintf("http://%s.%s.svc.cluster.local", serviceName(peerAgent), agent.Namespace),
+		}
+		if endpointAuthEnabled(peerAgent) {
+			config.TokenFile = fmt.Sprintf("%s/%s/token", peersMountPath, config.Alias)
+		}
+		peers = append(peers, config)
+		peerAgents = append(peerAgents, peerAgent)
+	}
+	return peers, peerAgents, missing, nil
+}
+
+// peersConfig returns the peers.json of the agent, listing the peers that exist.
+func (r *AgentReconciler) peersConfig(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	peers, _, _, err := r.resolvePeers(ctx, agent)
+	if err != nil {
+		return "", err
+	}
+	if peers == nil {
+		peers = []peerConfig{}
+	}
+	data, err := json.Marshal(peers)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// reconcilePeers reports whether the peers of the agent exist in the PeersResolved
+// condition. Missing peers are left out of peers.json until they are created, so they do
+// not fail the reconcile.
+func (r *AgentReconciler) reconcilePeers(ctx context.Context, agent *aiv1.Agent) error {
+	if len(agent.Spec.Peers) == 0 {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionPeersResolved)
+		return nil
+	}
+	_, _, missing, err := r.resolvePeers(ctx, agent)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		message := fmt.Sprintf("Peer agents not found in namespace %s: %s", agent.Namespace, strings.Join(missing, ", "))
+		log.FromContext(ctx).Info("Agent has dangling peers", "peers", missing)
+		r.setCondition(agent, aiv1.AgentConditionPeersResolved, corev1.ConditionFalse, "PeerNotFound", message)
+		return nil
+	}
+	r.setCondition(agent, aiv1.AgentConditionPeersResolved, corev1.ConditionTrue, "PeersResolved",
+		fmt.Sprintf("All %d peers of the agent exist", len(agent.Spec.Peers)))
+	return nil
+}
+
+// peersChecksum returns a hash of peers.json and of the endpoint tokens of the peers, or an
+// empty string without peers. A token Secret not created yet by the peer is hashed as
+// empty, and changes the checksum once it is.
+func (r *AgentReconciler) peersChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if len(agent.Spec.Peers) == 0 {
+		return "", nil
+	}
+	peers, peerAgents, _, err := r.resolvePeers(ctx, agent)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	data, err := json.Marshal(peers)
+	if err != nil {
+		return "", err
+	}
+	hash.Write(data)
+	for _, peerAgent := range peerAgents {
+		if !endpointAuthEnabled(peerAgent) {
+			continue
+		}
+		secret := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: endpointAuthSecretName(peerAgent), Namespace: agent.Namespace}, secret)
+		if err != nil && !errors.IsNotFound(err) {
+			return "", err
+		}
+		hash.Write([]byte{0})
+		hash.Write(secret.Data[endpointAuthTokenKey])
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// peersVolume returns the pod volume, container mount and environment that expose
+// peers.json of the agent ConfigMap and the endpoint tokens of the peers. Tokens are
+// projected optionally, as only peers with endpoint auth have a token Secret.
+func peersVolume(agent *aiv1.Agent) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	if len(agent.Spec.Peers) == 0 {
+		return nil, nil, nil
+	}
+
+	optional := true
+	sources := []corev1.VolumeProjection{
+		{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName(agent)},
+				Items:                []corev1.KeyToPath{{Key: peersConfigKey, Path: peersConfigKey}},
+			},
+		},
+	}
+	for _, peer := range agent.Spec.Peers {
+		sources = append(sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: agentEndpointAuthSecretName(peer.AgentRef)},
+				Items:                []corev1.KeyToPath{{Key: endpointAuthTokenKey, Path: peerAlias(peer) + "/token"}},
+				Optional:             &optional,
+			},
+		})
+	}
+
+	volume := corev1.Volume{
+		Name: "peers",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources:     sources,
+				DefaultMode: &peersFileMode,
+			},
+		},
+	}
+	mount := corev1.VolumeMount{
+		Name:      "peers",
+		MountPath: peersMountPath,
+		ReadOnly:  true,
+	}
+	env := []corev1.EnvVar{{Name: "AGENT_PEERS_FILE", Value: peersMountPath + "/" + peersConfigKey}}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}, env
+}
+
+// peerSecretNames returns the names of the endpoint token Secrets of the peers of the agent.
+func peerSecretNames(agent *aiv1.Agent) []string {
+	var names []string
+	for _, peer := range agent.Spec.Peers {
+		names = append(names, agentEndpointAuthSecretName(peer.AgentRef))
+	}
+	return names
+}
+
+// findAgentsForPeerAgent maps a change of an agent, such as its Service name or endpoint
+// auth, to the agents it is a peer of.
+func (r *AgentReconciler) findAgentsForPeerAgent(ctx context.Context, obj client.Object) []reconcile.Request {
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for peers")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, agent := range agents.Items {
+		for _, peer := range agent.Spec.Peers {
+			if peer.AgentRef == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,421 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/cronschedule"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+// +kubebuilder:rbac:groups=snapshot.storage.k8s.io,resources=volumesnapshots,verbs=get;list;watch;create;delete
+
+const (
+	// persistenceMountPath is where the claim of the agent is mounted in the agent container.
+	persistenceMountPath = "/var/lib/kubeagentic"
+	// defaultPersistenceSize is the requested storage of the claim without spec.persistence.size.
+	defaultPersistenceSize = "1Gi"
+	// defaultBackupRetention is the number of snapshots kept without a retention.
+	defaultBackupRetention = 7
+	// restoreInProgressReason is the reason of the SnapshotRestored condition while the agent
+	// is scaled to zero for a restore.
+	restoreInProgressReason = "RestoreInProgress"
+	// snapshotsUnavailableReason is the reason of the backup and restore conditions in
+	// clusters without the VolumeSnapshot CRDs.
+	snapshotsUnavailableReason = "VolumeSnapshotsUnavailable"
+	// restoreRequeue is how often a restore checks whether the agent pods are gone.
+	restoreRequeue = 10 * time.Second
+	// backupTimeAnnotation records when the operator took a snapshot, which orders the
+	// snapshots for pruning.
+	backupTimeAnnotation = "kubeagentic.ai/backup-time"
+)
+
+// volumeSnapshotGVK is the kind of the snapshots of the agent claims. Snapshots are handled as
+// unstructured objects, so the operator does not depend on the external snapshotter.
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+
+// persistenceEnabled reports whether the agent has a claim of its own.
+func persistenceEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Persistence != nil
+}
+
+// persistenceClaimName returns the claim mounted into the agent pods: the one restored
+// last, or the claim named after the agent.
+func persistenceClaimName(agent *aiv1.Agent) string {
+	if agent.Status.Persistence != nil && agent.Status.Persistence.ClaimName != "" {
+		return agent.Status.Persistence.ClaimName
+	}
+	return naming.Child(agent.Name, "data")
+}
+
+// persistenceSize returns the requested storage of the claim.
+func persistenceSize(agent *aiv1.Agent) string {
+	if agent.Spec.Persistence.Size != "" {
+		return agent.Spec.Persistence.Size
+	}
+	return defaultPersistenceSize
+}
+
+// backupRetention returns the number of snapshots kept.
+func backupRetention(agent *aiv1.Agent) int {
+	if agent.Spec.Persistence.Backup.Retention > 0 {
+		return int(agent.Spec.Persistence.Backup.Retention)
+	}
+	return defaultBackupRetention
+}
+
+// validatePersistence validates the claim and backup configuration. The claim is mounted
+// read-write by a single pod, so the agent must run exactly one.
+func validatePersistence(agent *aiv1.Agent) error {
+	persistence := agent.Spec.Persistence
+	if persistence == nil {
+		return nil
+	}
+
+	if _, err := resource.ParseQuantity(persistenceSize(agent)); err != nil {
+		return fmt.Errorf("invalid persistence.size %q: %w", persistence.Size, err)
+	}
+	if hpaEnabled(agent) || eventSourceAutoscaled(agent) {
+		return fmt.Errorf("persistence requires replicas: 1 without autoscaling, as the claim is mounted by a single pod")
+	}
+	if workerModeEnabled(agent) {
+		return fmt.Errorf("persistence is not supported in worker mode, as the claim is mounted by a single pod")
+	}
+	if persistence.Backup != nil {
+		if _, err := cronschedule.Parse(persistence.Backup.Schedule); err != nil {
+			return fmt.Errorf("invalid persistence.backup.schedule %q: %w", persistence.Backup.Schedule, err)
+		}
+	}
+	return nil
+}
+
+// volumeSnapshotsInstalled reports whether the VolumeSnapshot CRD is installed in the cluster.
+func (r *AgentReconciler) volumeSnapshotsInstalled() bool {
+	mappings, err := r.RESTMapper().RESTMappings(volumeSnapshotGVK.GroupKind())
+	return err == nil && len(mappings) > 0
+}
+
+// restoringFromSnapshot reports whether the agent pods are held while a snapshot is restored.
+func restoringFromSnapshot(agent *aiv1.Agent) bool {
+	condition := findCondition(agent.Status.Conditions, aiv1.AgentConditionSnapshotRestored)
+	return condition != nil && condition.Status == corev1.ConditionFalse && condition.Reason == restoreInProgressReason
+}
+
+// reconcilePersistence ensures the claim of the agent exists, restores it from
+// spec.persistence.restoreFromSnapshot and takes the scheduled snapshots of it.
+func (r *AgentReconciler) reconcilePersistence(ctx context.Context, agent *aiv1.Agent) error {
+	if !persistenceEnabled(agent) {
+		agent.Status.Persistence = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionBackupSucceeded)
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionSnapshotRestored)
+		return nil
+	}
+	if agent.Status.Persistence == nil {
+		agent.Status.Persistence = &aiv1.PersistenceStatus{ClaimName: persistenceClaimName(agent)}
+	}
+
+	if err := r.reconcileRestore(ctx, agent); err != nil {
+		return err
+	}
+	// The claim is created from the snapshot once the pods are gone
+	if restoringFromSnapshot(agent) {
+		return nil
+	}
+	if err := r.reconcileClaim(ctx, agent, persistenceClaimName(agent), ""); err != nil {
+		return err
+	}
+	return r.reconcileBackups(ctx, agent)
+}
+
+// reconcileClaim creates the claim of the agent, from snapshot when set. Existing claims are
+// kept as they are.
+func (r *AgentReconciler) reconcileClaim(ctx context.Context, agent *aiv1.Agent, name, snapshot string) error {
+	found := &corev1.PersistentVolumeClaim{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, found)
+	if err == nil || !errors.IsNotFound(err) {
+		return err
+	}
+
+	size, err := resource.ParseQuantity(persistenceSize(agent))
+	if err != nil {
+		return err
+	}
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    agentLabels(agent),
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: size,
+				},
+			},
+		},
+	}
+	if agent.Spec.Persistence.StorageClassName != "" {
+		pvc.Spec.StorageClassName = &agent.Spec.Persistence.StorageClassName
+	}
+	if snapshot != "" {
+		apiGroup := volumeSnapshotGVK.Group
+		pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{
+			APIGroup: &apiGroup,
+			Kind:     volumeSnapshotGVK.Kind,
+			Name:     snapshot,
+		}
+	}
+	if err := controllerutil.SetControllerReference(agent, pvc, r.Scheme); err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Creating agent PVC", "PVC.Namespace", pvc.Namespace, "PVC.Name", pvc.Name, "snapshot", snapshot)
+	return r.Create(ctx, pvc)
+}
+
+// reconcileRestore restores spec.persistence.restoreFromSnapshot once into a new claim. The
+// agent is scaled to zero first, as the claim in use cannot be swapped under running pods;
+// once they are gone, the claim is created from the snapshot, replaces the previous one,
+// which is deleted, and the agent is scaled back up.
+func (r *AgentReconciler) reconcileRestore(ctx context.Context, agent *aiv1.Agent) error {
+	snapshot := agent.Spec.Persistence.RestoreFromSnapshot
+	status := agent.Status.Persistence
+	if snapshot == "" {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionSnapshotRestored)
+		return nil
+	}
+	if snapshot == status.RestoredFrom {
+		return nil
+	}
+
+	if !r.volumeSnapshotsInstalled() {
+		r.setCondition(agent, aiv1.AgentConditionSnapshotRestored, corev1.ConditionFalse, snapshotsUnavailableReason,
+			"The VolumeSnapshot CRDs of snapshot.storage.k8s.io/v1 are not installed, the snapshot cannot be restored")
+		return nil
+	}
+	source := &unstructured.Unstructured{}
+	source.SetGroupVersionKind(volumeSnapshotGVK)
+	if err := r.Get(ctx, types.NamespacedName{Name: snapshot, Namespace: agent.Namespace}, source); errors.IsNotFound(err) {
+		r.setCondition(agent, aiv1.AgentConditionSnapshotRestored, corev1.ConditionFalse, "SnapshotNotFound",
+			fmt.Sprintf("VolumeSnapshot %s not found", snapshot))
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	r.setCondition(agent, aiv1.AgentConditionSnapshotRestored, corev1.ConditionFalse, restoreInProgressReason,
+		fmt.Sprintf("Scaling the agent down to restore VolumeSnapshot %s", snapshot))
+	stopped, err := r.agentPodsStopped(ctx, agent)
+	if err != nil || !stopped {
+		return err
+	}
+
+	claim := naming.Child(agent.Name, "data-"+snapshot)
+	if err := r.reconcileClaim(ctx, agent, claim, snapshot); err != nil {
+		return err
+	}
+	previous := status.ClaimName
+	status.ClaimName = claim
+	status.RestoredFrom = snapshot
+	if previous != "" && previous != claim {
+		log.FromContext(ctx).Info("Deleting the PVC replaced by the restore", "PVC.Name", previous, "snapshot", snapshot)
+		pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: previous, Namespace: agent.Namespace}}
+		if err := r.Delete(ctx, pvc); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	r.setCondition(agent, aiv1.AgentConditionSnapshotRestored, corev1.ConditionTrue, "Restored",
+		fmt.Sprintf("Restored VolumeSnapshot %s into PVC %s", snapshot, claim))
+	return nil
+}
+
+// agentPodsStopped reports whether the Deployment of the agent is scaled to zero and its
+// pods are gone, or does not exist.
+func (r *AgentReconciler) agentPodsStopped(ctx context.Context, agent *aiv1.Agent) (bool, error) {
+	deployment := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: deploymentName(agent), Namespace: agent.Namespace}, deployment)
+	if errors.IsNotFound(err) {
+		return true, nil
+	} else if err != nil {
+		return false, err
+	}
+	scaledDown := deployment.Spec.Replicas != nil && *deployment.Spec.Replicas == 0
+	return scaledDown && deployment.Status.Replicas == 0, nil
+}
+
+// lastBackupTime returns when the most recent snapshot was taken, or when the agent was
+// created before the first one.
+func lastBackupTime(agent *aiv1.Agent) time.Time {
+	if agent.Status.Persistence != nil && agent.Status.Persistence.LastBackupTime != nil {
+		return agent.Status.Persistence.LastBackupTime.Time
+	}
+	return agent.CreationTimestamp.Time
+}
+
+// nextBackupTime returns when the next snapshot is due, or the zero time without backups.
+func nextBackupTime(agent *aiv1.Agent) time.Time {
+	if !persistenceEnabled(agent) || agent.Spec.Persistence.Backup == nil {
+		return time.Time{}
+	}
+	schedule, err := cronschedule.Parse(agent.Spec.Persistence.Backup.Schedule)
+	if err != nil {
+		return time.Time{}
+	}
+	return schedule.Next(lastBackupTime(agent).UTC())
+}
+
+// reconcileBackups takes the snapshot of the claim due on the backup schedule, deletes the
+// snapshots beyond the retention and reports them. Clusters without the VolumeSnapshot CRDs
+// only get the BackupSucceeded condition explaining why no snapshot is taken.
+func (r *AgentReconciler) reconcileBackups(ctx context.Context, agent *aiv1.Agent) error {
+	status := agent.Status.Persistence
+	if agent.Spec.Persistence.Backup == nil {
+		status.Snapshots = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionBackupSucceeded)
+		return nil
+	}
+	if !r.volumeSnapshotsInstalled() {
+		r.setCondition(agent, aiv1.AgentConditionBackupSucceeded, corev1.ConditionFalse, snapshotsUnavailableReason,
+			"The VolumeSnapshot CRDs of snapshot.storage.k8s.io/v1 are not installed, no backup is taken")
+		return nil
+	}
+
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(volumeSnapshotGVK.GroupVersion().WithKind(volumeSnapshotGVK.Kind + "List"))
+	if err := r.List(ctx, list, client.InNamespace(agent.Namespace), client.MatchingLabels(backupLabels(agent))); err != nil {
+		return err
+	}
+	snapshots := list.Items
+
+	now := r.clock().Now()
+	if next := nextBackupTime(agent); !next.IsZero() && !next.After(now) {
+		snapshot := r.buildVolumeSnapshot(agent, now)
+		log.FromContext(ctx).Info("Creating VolumeSnapshot", "VolumeSnapshot.Name", snapshot.GetName(), "PVC.Name", persistenceClaimName(agent))
+		if err := r.Create(ctx, snapshot); err != nil {
+			return err
+		}
+		snapshots = append(snapshots, *snapshot)
+		backupTime := metav1.NewTime(now)
+		status.LastBackupTime = &backupTime
+	}
+
+	// Keep the newest snapshots, in the order they were taken
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		return snapshots[i].GetAnnotations()[backupTimeAnnotation] > snapshots[j].GetAnnotations()[backupTimeAnnotation]
+	})
+	if retention := backupRetention(agent); len(snapshots) > retention {
+		for i := range snapshots[retention:] {
+			expired := &snapshots[retention+i]
+			log.FromContext(ctx).Info("Deleting expired VolumeSnapshot", "VolumeSnapshot.Name", expired.GetName())
+			if err := r.Delete(ctx, expired); client.IgnoreNotFound(err) != nil {
+				return err
+			}
+		}
+		snapshots = snapshots[:retention]
+	}
+
+	status.Snapshots = nil
+	for _, snapshot := range snapshots {
+		status.Snapshots = append(status.Snapshots, snapshot.GetName())
+	}
+	if len(snapshots) == 0 {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionBackupSucceeded)
+		return nil
+	}
+	latest := snapshots[0]
+	if message, found, _ := unstructured.NestedString(latest.Object, "status", "error", "message"); found {
+		r.setCondition(agent, aiv1.AgentConditionBackupSucceeded, corev1.ConditionFalse, "SnapshotFailed",
+			fmt.Sprintf("VolumeSnapshot %s failed: %s", latest.GetName(), message))
+		return nil
+	}
+	r.setCondition(agent, aiv1.AgentConditionBackupSucceeded, corev1.ConditionTrue, "BackedUp",
+		fmt.Sprintf("VolumeSnapshot %s of PVC %s taken", latest.GetName(), persistenceClaimName(agent)))
+	return nil
+}
+
+// backupLabels returns the labels of the snapshots the operator takes of the agent claim.
+// Snapshots are not owned by the agent, so that they outlive it and can be restored into
+// an agent created again.
+func backupLabels(agent *aiv1.Agent) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-backup",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "conversation-backup",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+}
+
+// buildVolumeSnapshot returns the snapshot of the agent claim taken at now.
+func (r *AgentReconciler) buildVolumeSnapshot(agent *aiv1.Agent, now time.Time) *unstructured.Unstructured {
+	spec := map[string]interface{}{
+		"source": map[string]interface{}{
+			"persistentVolumeClaimName": persistenceClaimName(agent),
+		},
+	}
+	if class := agent.Spec.Persistence.Backup.VolumeSnapshotClassName; class != "" {
+		spec["volumeSnapshotClassName"] = class
+	}
+
+	snapshot := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	snapshot.SetGroupVersionKind(volumeSnapshotGVK)
+	snapshot.SetName(naming.Child(agent.Name, "backup-"+now.UTC().Format("20060102-150405")))
+	snapshot.SetNamespace(agent.Namespace)
+	snapshot.SetLabels(backupLabels(agent))
+	snapshot.SetAnnotations(map[string]string{backupTimeAnnotation: now.UTC().Format(time.RFC3339)})
+	return snapshot
+}
+
+// persistenceVolume returns the pod volume, container mount and environment that expose the
+// claim of the agent to the agent container.
+func persistenceVolume(agent *aiv1.Agent) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	if !persistenceEnabled(agent) {
+		return nil, nil, nil
+	}
+
+	volume := corev1.Volume{
+		Name: "agent-data",
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: persistenceClaimName(agent),
+			},
+		},
+	}
+	mount := corev1.VolumeMount{Name: "agent-data", MountPath: persistenceMountPath}
+	env := corev1.EnvVar{Name: "AGENT_DATA_DIR", Value: persistenceMountPath}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}, []corev1.EnvVar{env}
+}
+
+// persistenceRequeueAfter shortens requeue while a restore waits for the agent pods to stop,
+// and to when the next snapshot is due.
+func (r *AgentReconciler) persistenceRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if restoringFromSnapshot(agent) && requeue > restoreRequeue {
+		return restoreRequeue
+	}
+	next := nextBackupTime(agent)
+	if next.IsZero() {
+		return requeue
+	}
+	if untilNext := next.Sub(r.clock().Now()); untilNext < requeue {
+		if untilNext < time.Second {
+			return time.Second
+		}
+		return untilNext
+	}
+	return requeue
+}
@@ -0,0 +1,189 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// defaultPlacementTopologyKey places the pods on the same node, or on other nodes.
+	defaultPlacementTopologyKey = "kubernetes.io/hostname"
+	// placementWeight is the weight of the preferred placements, the highest the scheduler
+	// takes, so that they win over its default spreading.
+	placementWeight int32 = 100
+)
+
+// placementTerm returns the affinity term selecting the serving pods of the agent of
+// placement in its topology domain. Workers and jobs of that agent are not selected.
+func placementTerm(placement aiv1.AgentPlacement) corev1.PodAffinityTerm {
+	topologyKey := placement.TopologyKey
+	if topologyKey == "" {
+		topologyKey = defaultPlacementTopologyKey
+	}
+	return corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: agentNameLabels(placement.AgentRef)},
+		TopologyKey:   topologyKey,
+	}
+}
+
+// placementAffinity returns spec.affinity with the pod affinity and anti-affinity of
+// spec.colocateWith and spec.spreadFrom added, or nil without either. The terms of agents
+// that do not exist are kept, so that the pods do not roll when those agents are created;
+// the PlacementResolved condition reports them.
+func placementAffinity(agent *aiv1.Agent) *corev1.Affinity {
+	if agent.Spec.Affinity == nil && len(agent.Spec.ColocateWith) == 0 && len(agent.Spec.SpreadFrom) == 0 {
+		return nil
+	}
+	affinity := &corev1.Affinity{}
+	if agent.Spec.Affinity != nil {
+		affinity = agent.Spec.Affinity.DeepCopy()
+	}
+	if len(agent.Spec.ColocateWith) > 0 {
+		if affinity.PodAffinity == nil {
+			affinity.PodAffinity = &corev1.PodAffinity{}
+		}
+		for _, placement := range agent.Spec.ColocateWith {
+			term := placementTerm(placement)
+			if placement.Mode == aiv1.PlacementRequired {
+				affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+					affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+				continue
+			}
+			affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+				affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+				corev1.WeightedPodAffinityTerm{Weight: placementWeight, PodAffinityTerm: term})
+		}
+	}
+	if len(agent.Spec.SpreadFrom) > 0 {
+		if affinity.PodAntiAffinity == nil {
+			affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		for _, placement := range agent.Spec.SpreadFrom {
+			term := placementTerm(placement)
+			if placement.Mode == aiv1.PlacementRequired {
+				affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+					affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+				continue
+			}
+			affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+				affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+				corev1.WeightedPodAffinityTerm{Weight: placementWeight, PodAffinityTerm: term})
+		}
+	}
+	return affinity
+}
+
+// validatePlacement rejects placements relative to the agent itself, and agents that the
+// pods must be both next to and away from.
+func (r *AgentReconciler) validatePlacement(agent *aiv1.Agent) error {
+	colocated := map[string]bool{}
+	for _, placement := range agent.Spec.ColocateWith {
+		if err := validatePlacementRef(agent, "colocateWith", placement); err != nil {
+			return err
+		}
+		if colocated[placement.AgentRef] {
+			return fmt.Errorf("colocateWith lists agent %s more than once", placement.AgentRef)
+		}
+		colocated[placement.AgentRef] = true
+	}
+	spread := map[string]bool{}
+	for _, placement := range agent.Spec.SpreadFrom {
+		if err := validatePlacementRef(agent, "spreadFrom", placement); err != nil {
+			return err
+		}
+		if spread[placement.AgentRef] {
+			return fmt.Errorf("spreadFrom lists agent %s more than once", placement.AgentRef)
+		}
+		spread[placement.AgentRef] = true
+		if colocated[placement.AgentRef] {
+			return fmt.Errorf("agent %s cannot be in both colocateWith and spreadFrom", placement.AgentRef)
+		}
+	}
+	return nil
+}
+
+// validatePlacementRef checks a placement of the list named field.
+func validatePlacementRef(agent *aiv1.Agent, field string, placement aiv1.AgentPlacement) error {
+	if placement.AgentRef == agent.Name {
+		return fmt.Errorf("%s cannot refer to the agent itself", field)
+	}
+	if placement.TopologyKey != "" {
+		if errs := validation.IsQualifiedName(placement.TopologyKey); len(errs) > 0 {
+			return fmt.Errorf("invalid %s topologyKey %q: %s", field, placement.TopologyKey, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// reconcilePlacement reports whether the agents of colocateWith and spreadFrom exist in the
+// PlacementResolved condition, and warns when an agent that the pods must run next to has
+// no replicas, as they cannot be scheduled then.
+func (r *AgentReconciler) reconcilePlacement(ctx context.Context, agent *aiv1.Agent) error {
+	placements := append(append([]aiv1.AgentPlacement{}, agent.Spec.ColocateWith...), agent.Spec.SpreadFrom...)
+	if len(placements) == 0 {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionPlacementResolved)
+		return nil
+	}
+
+	var missing []string
+	for i, placement := range placements {
+		other := &aiv1.Agent{}
+		err := r.Get(ctx, types.NamespacedName{Name: placement.AgentRef, Namespace: agent.Namespace}, other)
+		if errors.IsNotFound(err) || err == nil && other.DeletionTimestamp != nil {
+			missing = append(missing, placement.AgentRef)
+			continue
+		} else if err != nil {
+			return err
+		}
+		colocated := i < len(agent.Spec.ColocateWith)
+		if colocated && other.Spec.Replicas != nil && *other.Spec.Replicas == 0 {
+			r.recordEvent(agent, corev1.EventTypeWarning, "ColocationTargetScaledToZero",
+				fmt.Sprintf("Agent %s has no replicas to colocate the pods with", placement.AgentRef))
+		}
+	}
+
+	if len(missing) > 0 {
+		message := fmt.Sprintf("Agents of colocateWith or spreadFrom not found in namespace %s: %s", agent.Namespace, strings.Join(missing, ", "))
+		log.FromContext(ctx).Info("Agent has dangling placements", "agents", missing)
+		r.setCondition(agent, aiv1.AgentConditionPlacementResolved, corev1.ConditionFalse, "AgentNotFound", message)
+		return nil
+	}
+	r.setCondition(agent, aiv1.AgentConditionPlacementResolved, corev1.ConditionTrue, "PlacementResolved",
+		fmt.Sprintf("All %d agents the pods are placed relative to exist", len(placements)))
+	return nil
+}
+
+// findAgentsForPlacementAgent maps a change of an agent, such as its creation or scaling,
+// to the agents placed relative to it.
+func (r *AgentReconciler) findAgentsForPlacementAgent(ctx context.Context, obj client.Object) []reconcile.Request {
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for placements")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, agent := range agents.Items {
+		for _, placement := range append(append([]aiv1.AgentPlacement{}, agent.Spec.ColocateWith...), agent.Spec.SpreadFrom...) {
+			if placement.AgentRef == obj.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,35 @@
+package controllers
+
+import (
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// podLabels returns the labels of a pod template of the agent: spec.podLabels, overridden by
+// the operator labels given. Only the operator labels select the pods, so the labels set by
+// users cannot break the selection of the Deployment or the Service.
+func podLabels(agent *aiv1.Agent, operatorLabels map[string]string) map[string]string {
+	labels := make(map[string]string, len(agent.Spec.PodLabels)+len(operatorLabels))
+	for k, v := range agent.Spec.PodLabels {
+		labels[k] = v
+	}
+	for k, v := range operatorLabels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// podAnnotations returns spec.podAnnotations merged with the operator annotations given,
+// which win on conflict. It returns nil when both are empty.
+func podAnnotations(agent *aiv1.Agent, operatorAnnotations map[string]string) map[string]string {
+	if len(agent.Spec.PodAnnotations) == 0 {
+		return operatorAnnotations
+	}
+	annotations := make(map[string]string, len(agent.Spec.PodAnnotations)+len(operatorAnnotations))
+	for k, v := range agent.Spec.PodAnnotations {
+		annotations[k] = v
+	}
+	for k, v := range operatorAnnotations {
+		annotations[k] = v
+	}
+	return annotations
+}
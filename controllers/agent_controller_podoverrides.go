@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// applyPodTemplateOverrides applies the strategic merge patch of spec.podTemplateOverrides to
+// the rendered pod template. The patched template must keep the agent container first and
+// named agent, its API key and the labels the Deployment and Service select the pods by.
+func applyPodTemplateOverrides(agent *aiv1.Agent, template *corev1.PodTemplateSpec) (*corev1.PodTemplateSpec, error) {
+	overrides := agent.Spec.PodTemplateOverrides
+	if overrides == nil || len(overrides.Raw) == 0 {
+		return template, nil
+	}
+	original, err := json.Marshal(template)
+	if err != nil {
+		return nil, err
+	}
+	data, err := strategicpatch.StrategicMergePatch(original, overrides.Raw, corev1.PodTemplateSpec{})
+	if err != nil {
+		return nil, fmt.Errorf("podTemplateOverrides is not a valid strategic merge patch: %w", err)
+	}
+	// Reject misspelled fields, which would otherwise be dropped silently
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	patched := &corev1.PodTemplateSpec{}
+	if err := decoder.Decode(patched); err != nil {
+		return nil, fmt.Errorf("podTemplateOverrides does not patch a pod template: %w", err)
+	}
+
+	for key, value := range template.Labels {
+		if patched.Labels[key] != value {
+			return nil, fmt.Errorf("podTemplateOverrides must not change the pod label %s", key)
+		}
+	}
+	if len(patched.Spec.Containers) == 0 || patched.Spec.Containers[0].Name != template.Spec.Containers[0].Name {
+		return nil, fmt.Errorf("podTemplateOverrides must not rename, remove or reorder the %s container", template.Spec.Containers[0].Name)
+	}
+	if !equality.Semantic.DeepEqual(apiKeyEnv(template.Spec.Containers[0]), apiKeyEnv(patched.Spec.Containers[0])) {
+		return nil, fmt.Errorf("podTemplateOverrides must not change AGENT_API_KEY")
+	}
+	return patched, nil
+}
+
+// apiKeyEnv returns the AGENT_API_KEY environment variable of container, or nil.
+func apiKeyEnv(container corev1.Container) *corev1.EnvVar {
+	for i := range container.Env {
+		if container.Env[i].Name == "AGENT_API_KEY" {
+			return &container.Env[i]
+		}
+	}
+	return nil
+}
+
+// validatePodTemplateOverrides checks that the overrides apply to the pod template rendered
+// without them.
+func (r *AgentReconciler) validatePodTemplateOverrides(agent *aiv1.Agent) error {
+	if agent.Spec.PodTemplateOverrides == nil {
+		return nil
+	}
+	rendered := agent.DeepCopy()
+	rendered.Spec.PodTemplateOverrides = nil
+	_, err := applyPodTemplateOverrides(agent, &r.buildDeployment(rendered).Spec.Template)
+	return err
+}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// preloadContainerName is the name of the init container preloading the model.
+	preloadContainerName = "preload-model"
+
+	defaultPreloadMaxTokens = 16
+	defaultPreloadRetries   = 3
+	defaultPreloadTimeout   = 10 * time.Minute
+)
+
+// preloadsModel reports whether the agent pods preload the model before the agent container
+// starts. Hosted providers keep their models loaded, so only the self-hosted providers with
+// an endpoint preload.
+func preloadsModel(agent *aiv1.Agent) bool {
+	return agent.Spec.Preload != nil && agent.Spec.Preload.Enabled &&
+		primaryEndpoint(agent) != "" && providersRequiringEndpoint[agent.Spec.Provider]
+}
+
+// preloadTimeout returns how long the init container preloads the model.
+func preloadTimeout(agent *aiv1.Agent) time.Duration {
+	if agent.Spec.Preload.Timeout != nil && agent.Spec.Preload.Timeout.Duration > 0 {
+		return agent.Spec.Preload.Timeout.Duration
+	}
+	return defaultPreloadTimeout
+}
+
+// preloadContainer returns the init container sending a generation request to the model
+// endpoint, retried with backoff, or nil when the agent does not preload its model. It runs
+// after the endpoint wait, and sends the API key of the agent to servers requiring one.
+func preloadContainer(agent *aiv1.Agent, agentContainer *corev1.Container) *corev1.Container {
+	if !preloadsModel(agent) {
+		return nil
+	}
+	config := agent.Spec.Preload
+	maxTokens := int32(defaultPreloadMaxTokens)
+	if config.MaxTokens != nil {
+		maxTokens = *config.MaxTokens
+	}
+	retries := int32(defaultPreloadRetries)
+	if config.Retries != nil {
+		retries = *config.Retries
+	}
+
+	container := modelEndpointInitContainer(agent, agentContainer, preloadContainerName, "--preload")
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "AGENT_MODEL", Value: agent.Spec.Model},
+		corev1.EnvVar{Name: "AGENT_PRELOAD_MAX_TOKENS", Value: strconv.Itoa(int(maxTokens))},
+		corev1.EnvVar{Name: "AGENT_PRELOAD_RETRIES", Value: strconv.Itoa(int(retries))},
+		corev1.EnvVar{Name: "AGENT_PRELOAD_TIMEOUT_SECONDS", Value: strconv.Itoa(int(preloadTimeout(agent).Seconds()))},
+	)
+	if config.Prompt != "" {
+		container.Env = append(container.Env, corev1.EnvVar{Name: "AGENT_PRELOAD_PROMPT", Value: config.Prompt})
+	}
+	for _, e := range agentContainer.Env {
+		if e.Name == "AGENT_API_KEY" {
+			container.Env = append(container.Env, e)
+		}
+	}
+	return container
+}
+
+// preloadProgress orders the preload phases from the furthest behind.
+var preloadProgress = map[aiv1.PreloadPhase]int{
+	aiv1.PreloadPhaseFailed:  0,
+	aiv1.PreloadPhaseLoading: 1,
+	aiv1.PreloadPhaseWarming: 2,
+	aiv1.PreloadPhaseReady:   3,
+}
+
+// preloadStatus reports the preload of the model by the agent pods, from the state of their
+// init containers: the phase of the pod furthest behind, or Failed with the termination
+// message when the preload of a pod failed. It returns nil when the agent does not preload
+// its model or has no pods.
+func preloadStatus(agent *aiv1.Agent, pods []corev1.Pod) *aiv1.PreloadStatus {
+	if !preloadsModel(agent) || len(pods) == 0 {
+		return nil
+	}
+	status := &aiv1.PreloadStatus{Phase: aiv1.PreloadPhaseReady}
+	for i := range pods {
+		pod := &pods[i]
+		container := initContainerStatus(pod, preloadContainerName)
+		phase, message := aiv1.PreloadPhaseLoading, ""
+		if terminated := failedInitContainer(container); terminated != nil {
+			phase = aiv1.PreloadPhaseFailed
+			message = fmt.Sprintf("Pod %s failed to preload model %s (%d restarts): %s",
+				pod.Name, agent.Spec.Model, container.RestartCount, strings.TrimSpace(terminated.Message))
+		} else if container != nil && container.State.Terminated != nil {
+			phase = aiv1.PreloadPhaseReady
+		} else if container != nil && container.State.Running != nil {
+			phase = aiv1.PreloadPhaseWarming
+		}
+		if preloadProgress[phase] < preloadProgress[status.Phase] {
+			status.Phase, status.Message = phase, message
+		}
+	}
+	return status
+}
+
+// preloadMessage describes the preload for the message of a pending agent, or returns an
+// empty message when it neither runs nor failed.
+func preloadMessage(agent *aiv1.Agent) string {
+	if agent.Status.Preload == nil {
+		return ""
+	}
+	switch agent.Status.Preload.Phase {
+	case aiv1.PreloadPhaseWarming:
+		return fmt.Sprintf("Preloading model %s on %s", agent.Spec.Model, strings.Join(providerEndpointURLs(agent), ", "))
+	case aiv1.PreloadPhaseFailed:
+		return agent.Status.Preload.Message
+	}
+	return ""
+}
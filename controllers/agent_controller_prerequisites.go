@@ -0,0 +1,161 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/secretref"
+)
+
+const (
+	// prerequisitesMissingReason is the reason of the PrerequisitesReady condition while
+	// referenced Secrets or ConfigMaps do not exist.
+	prerequisitesMissingReason = "PrerequisitesMissing"
+
+	// prerequisitesRequeue is how often an agent waiting for its prerequisites checks them
+	// again. The Secret and ConfigMap watches pick up most of them as soon as they are created;
+	// this covers those that could not be read.
+	prerequisitesRequeue = 5 * time.Minute
+)
+
+// missingPrerequisites returns the Secrets, and the keys in them, and the ConfigMaps that
+// the agent references but that do not exist yet, one entry per field referencing them.
+// The credentials of connectors are left out: their sidecar starts without them and reports
+// them on the ConnectorsReady condition.
+func (r *AgentReconciler) missingPrerequisites(ctx context.Context, agent *aiv1.Agent) ([]string, error) {
+	var refs []secretref.Ref
+	for _, ref := range secretref.Refs(agent.Namespace, &agent.Spec) {
+		if !strings.HasPrefix(ref.Path.String(), "spec.connectors") {
+			refs = append(refs, ref)
+		}
+	}
+	var missing []string
+	for _, err := range secretref.Missing(ctx, r.Client, refs) {
+		if err.Type == field.ErrorTypeNotFound {
+			missing = append(missing, fmt.Sprintf("%s: %v not found", err.Field, err.BadValue))
+		} else {
+			missing = append(missing, fmt.Sprintf("%s: %s", err.Field, err.Detail))
+		}
+	}
+
+	for i, name := range ingestionConfigMapNames(agent) {
+		if name == "" {
+			continue
+		}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, &corev1.ConfigMap{}, referenced)
+		if errors.IsNotFound(err) {
+			path := field.NewPath("spec", "rag", "ingestion", "sources").Index(i).Child("configMapRef")
+			missing = append(missing, fmt.Sprintf("%s: configmap %s in namespace %s not found", path, name, agent.Namespace))
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+	}
+
+	if name := guardrailsPolicyConfigMap(agent); name != "" {
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, &corev1.ConfigMap{}, referenced)
+		if errors.IsNotFound(err) {
+			path := field.NewPath("spec", "guardrails", "policyRef")
+			missing = append(missing, fmt.Sprintf("%s: configmap %s in namespace %s not found", path, name, agent.Namespace))
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+	}
+
+	if name := examplesConfigMap(agent); name != "" {
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, &corev1.ConfigMap{}, referenced)
+		if errors.IsNotFound(err) {
+			path := field.NewPath("spec", "examplesRef")
+			missing = append(missing, fmt.Sprintf("%s: configmap %s in namespace %s not found", path, name, agent.Namespace))
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get configmap %s: %w", name, err)
+		}
+	}
+	return missing, nil
+}
+
+// ingestionConfigMapNames returns the names of the ConfigMaps ingested by the agent, indexed
+// like its ingestion sources, with an empty name for the sources of other kinds.
+func ingestionConfigMapNames(agent *aiv1.Agent) []string {
+	if !ragEnabled(agent) || agent.Spec.RAG.Ingestion == nil {
+		return nil
+	}
+	names := make([]string, len(agent.Spec.RAG.Ingestion.Sources))
+	for i, source := range agent.Spec.RAG.Ingestion.Sources {
+		if source.ConfigMapRef != nil {
+			names[i] = source.ConfigMapRef.Name
+		}
+	}
+	return names
+}
+
+// secretMissing reports whether the API secret of the agent fails validation because it, or
+// its key, does not exist yet.
+func secretMissing(secretErr error) bool {
+	reason := conditionReason(secretErr, "")
+	return reason == "SecretNotFound" || reason == "SecretKeyNotFound"
+}
+
+// updateStatusWaiting sets the agent to the Waiting phase until the prerequisites in missing
+// are created. Unlike a failure, it records no error and does not back off, and the status is
+// only written when it changes; the Event is recorded when the missing prerequisites change.
+func (r *AgentReconciler) updateStatusWaiting(ctx context.Context, agent *aiv1.Agent, previous *aiv1.AgentStatus, missing []string) (ctrl.Result, error) {
+	message := "Waiting for " + strings.Join(missing, "; ")
+	if condition := findCondition(previous.Conditions, aiv1.AgentConditionPrerequisitesReady); condition == nil || condition.Message != message {
+		r.recordEvent(agent, corev1.EventTypeWarning, prerequisitesMissingReason, message)
+	}
+	log.FromContext(ctx).Info("Waiting for prerequisites", "missing", missing)
+
+	agent.Status.Phase = aiv1.AgentPhaseWaiting
+	agent.Status.Message = message
+	r.setCondition(agent, aiv1.AgentConditionPrerequisitesReady, corev1.ConditionFalse, prerequisitesMissingReason, message)
+	r.setReadyCondition(agent)
+	requeue := ctrl.Result{RequeueAfter: r.expiryRequeueAfter(agent, prerequisitesRequeue)}
+
+	if !statusChanged(previous, &agent.Status) {
+		return requeue, nil
+	}
+	now := metav1.NewTime(time.Now())
+	agent.Status.LastUpdated = &now
+	if err := r.Status().Update(ctx, agent); err != nil {
+		return ctrl.Result{}, err
+	}
+	return requeue, nil
+}
+
+// findAgentsForConfigMap maps a ConfigMap event to the Agents in its namespace ingesting it or
+// reading their guardrails policy or their examples from it, so that an agent waiting for the
+// ConfigMap starts once it is created, and an edit of the policy or the examples rolls the
+// agent pods.
+func (r *AgentReconciler) findAgentsForConfigMap(ctx context.Context, configMap client.Object) []reconcile.Request {
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents, client.InNamespace(configMap.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for configmap", "configmap", configMap.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, agent := range agents.Items {
+		for _, name := range append(ingestionConfigMapNames(&agent), guardrailsPolicyConfigMap(&agent), examplesConfigMap(&agent)) {
+			if name == configMap.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// PriorityLabel orders the reconciles of the agents. It holds high, normal or low, or a
+// number: positive numbers are high, negative ones low and zero normal. Agents without a
+// valid priority are normal.
+const PriorityLabel = "kubeagentic.ai/priority"
+
+// PriorityClass is the class of the workqueue reconciling an agent.
+type PriorityClass string
+
+const (
+	PriorityHigh   PriorityClass = "high"
+	PriorityNormal PriorityClass = "normal"
+	PriorityLow    PriorityClass = "low"
+)
+
+// PriorityClasses are the classes in the order their controllers are set up.
+var PriorityClasses = []PriorityClass{PriorityHigh, PriorityNormal, PriorityLow}
+
+// DefaultPriorityWorkers are the concurrent reconciles of each class when the reconciler
+// sets none. The high-priority agents get workers of their own, so that the playground
+// agents of a namespace do not delay them after a restart or during a mass event.
+var DefaultPriorityWorkers = map[PriorityClass]int{PriorityHigh: 2, PriorityNormal: 1, PriorityLow: 1}
+
+// AgentPriority returns the priority class of an agent with labels.
+func AgentPriority(labels map[string]string) PriorityClass {
+	value := labels[PriorityLabel]
+	switch PriorityClass(value) {
+	case PriorityHigh, PriorityNormal, PriorityLow:
+		return PriorityClass(value)
+	}
+	n, err := strconv.Atoi(value)
+	switch {
+	case err != nil || n == 0:
+		return PriorityNormal
+	case n > 0:
+		return PriorityHigh
+	default:
+		return PriorityLow
+	}
+}
+
+// priorityControllerName names the controller of class, and its workqueue in the
+// workqueue_* metrics; the normal class keeps the name of the single controller the
+// operator ran before priorities.
+func priorityControllerName(class PriorityClass) string {
+	if class == PriorityNormal {
+		return agentControllerName
+	}
+	return agentControllerName + "-" + string(class)
+}
+
+// priorityWorkers returns the concurrent reconciles of class.
+func (r *AgentReconciler) priorityWorkers(class PriorityClass) int {
+	if workers := r.PriorityWorkers[class]; workers > 0 {
+		return workers
+	}
+	return DefaultPriorityWorkers[class]
+}
+
+// priorityPredicate keeps the events of the agents of class.
+func priorityPredicate(class PriorityClass) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return AgentPriority(object.GetLabels()) == class
+	})
+}
+
+// PriorityEvents returns an event handler enqueuing the requests of h for the agents of
+// class only, read with reader. Requests for agents that are gone go to the normal class,
+// which cleans up after them.
+func PriorityEvents(class PriorityClass, h handler.EventHandler, reader client.Reader) handler.EventHandler {
+	return &priorityEvents{class: class, handler: h, reader: reader}
+}
+
+type priorityEvents struct {
+	class   PriorityClass
+	handler handler.EventHandler
+	reader  client.Reader
+}
+
+func (e *priorityEvents) queue(ctx context.Context, q workqueue.RateLimitingInterface) workqueue.RateLimitingInterface {
+	return &priorityQueue{RateLimitingInterface: q, ctx: ctx, events: e}
+}
+
+func (e *priorityEvents) Create(ctx context.Context, evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.handler.Create(ctx, evt, e.queue(ctx, q))
+}
+
+func (e *priorityEvents) Update(ctx context.Context, evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.handler.Update(ctx, evt, e.queue(ctx, q))
+}
+
+func (e *priorityEvents) Delete(ctx context.Context, evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.handler.Delete(ctx, evt, e.queue(ctx, q))
+}
+
+func (e *priorityEvents) Generic(ctx context.Context, evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.handler.Generic(ctx, evt, e.queue(ctx, q))
+}
+
+// owns reports whether the agent of item is reconciled by the class of the events.
+func (e *priorityEvents) owns(ctx context.Context, item interface{}) bool {
+	request, ok := item.(reconcile.Request)
+	if !ok {
+		return true
+	}
+	agent := &aiv1.Agent{}
+	if err := e.reader.Get(ctx, request.NamespacedName, agent); err != nil {
+		return e.class == PriorityNormal
+	}
+	return AgentPriority(agent.Labels) == e.class
+}
+
+// priorityQueue drops the requests added by an event handler for the agents of the other
+// classes.
+type priorityQueue struct {
+	workqueue.RateLimitingInterface
+	ctx    context.Context
+	events *priorityEvents
+}
+
+func (q *priorityQueue) Add(item interface{}) {
+	if q.events.owns(q.ctx, item) {
+		q.RateLimitingInterface.Add(item)
+	}
+}
+
+func (q *priorityQueue) AddAfter(item interface{}, delay time.Duration) {
+	if q.events.owns(q.ctx, item) {
+		q.RateLimitingInterface.AddAfter(item, delay)
+	}
+}
+
+func (q *priorityQueue) AddRateLimited(item interface{}) {
+	if q.events.owns(q.ctx, item) {
+		q.RateLimitingInterface.AddRateLimited(item)
+	}
+}
@@ -0,0 +1,147 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/promotion"
+)
+
+// reconcilePromotion keeps the Agent of the same name in the namespace of the
+// kubeagentic.ai/promote-to annotation promoted from the agent, with the spec transformed by
+// the AgentPolicy of that namespace accepting the agent's namespace, and reports the outcome
+// in status.promotion. Promotions that cannot be made only set that status: the annotation
+// may name a missing namespace or one that does not accept the agent, and an Agent of the
+// same name that was not promoted from the agent is never overwritten. Removing the
+// annotation stops the promotion and keeps the promoted Agent.
+func (r *AgentReconciler) reconcilePromotion(ctx context.Context, agent *aiv1.Agent) error {
+	target, ok := agent.Annotations[promotion.Annotation]
+	if !ok {
+		agent.Status.Promotion = nil
+		return nil
+	}
+	previous := agent.Status.Promotion
+	status := &aiv1.PromotionStatus{TargetNamespace: target}
+	if previous != nil && previous.TargetNamespace == target {
+		status.PromotedGeneration, status.LastPromotionTime = previous.PromotedGeneration, previous.LastPromotionTime
+	}
+	fail := func(message string) error {
+		if previous == nil || previous.Result != "Failed" || previous.Message != message {
+			r.recordEvent(agent, corev1.EventTypeWarning, "PromotionFailed", message)
+		}
+		status.Result, status.Message = "Failed", message
+		agent.Status.Promotion = status
+		return nil
+	}
+
+	if errs := promotion.Validate(agent.Namespace, agent.Annotations); len(errs) > 0 {
+		return fail(errs.ToAggregate().Error())
+	}
+	namespace := &corev1.Namespace{}
+	if err := r.Get(ctx, client.ObjectKey{Name: target}, namespace); err != nil {
+		if errors.IsNotFound(err) {
+			return fail(fmt.Sprintf("Namespace %s does not exist", target))
+		}
+		return err
+	}
+	var policies aiv1.AgentPolicyList
+	if err := r.List(ctx, &policies, client.InNamespace(target)); err != nil {
+		return err
+	}
+	rules, policyName := promotion.Rules(agent.Namespace, target, policies.Items)
+	if rules == nil {
+		return fail(fmt.Sprintf("No AgentPolicy of namespace %s accepts agents promoted from namespace %s", target, agent.Namespace))
+	}
+	spec := promotion.Transform(&agent.Spec, rules)
+	hash := promotion.Hash(spec)
+
+	promoted := &aiv1.Agent{}
+	err := r.Get(ctx, client.ObjectKey{Namespace: target, Name: agent.Name}, promoted)
+	switch {
+	case errors.IsNotFound(err):
+		promoted = &aiv1.Agent{ObjectMeta: metav1.ObjectMeta{Namespace: target, Name: agent.Name}}
+		setPromotionMetadata(promoted, agent, hash)
+		promoted.Spec = *spec
+		err = r.Create(ctx, promoted)
+	case err != nil:
+		return err
+	case promoted.Labels[promotion.SourceNamespaceLabel] != agent.Namespace || promoted.Labels[promotion.SourceNameLabel] != agent.Name:
+		return fail(fmt.Sprintf("Agent %s/%s exists and was not promoted from this agent", target, agent.Name))
+	case promoted.Annotations[promotion.HashAnnotation] != hash:
+		setPromotionMetadata(promoted, agent, hash)
+		promoted.Spec = *spec
+		err = r.Update(ctx, promoted)
+	default:
+		status.Result = "Promoted"
+		status.Message = fmt.Sprintf("Agent %s/%s holds generation %d, transformed by AgentPolicy %s", target, agent.Name, status.PromotedGeneration, policyName)
+		agent.Status.Promotion = status
+		return nil
+	}
+	if errors.IsInvalid(err) || errors.IsForbidden(err) {
+		// The admission webhook of the target namespace rejects the promoted spec
+		return fail(fmt.Sprintf("Agent %s/%s was rejected: %v", target, agent.Name, err))
+	}
+	if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Promoted agent", "target", target, "generation", agent.Generation)
+	now := metav1.NewTime(r.clock().Now())
+	status.Result, status.PromotedGeneration, status.LastPromotionTime = "Promoted", agent.Generation, &now
+	status.Message = fmt.Sprintf("Agent %s/%s holds generation %d, transformed by AgentPolicy %s", target, agent.Name, agent.Generation, policyName)
+	agent.Status.Promotion = status
+	r.recordEvent(agent, corev1.EventTypeNormal, "Promoted", status.Message)
+	return nil
+}
+
+// setPromotionMetadata labels the promoted Agent with its source agent and records the
+// promoted generation and the hash of the promoted spec.
+func setPromotionMetadata(promoted, source *aiv1.Agent, hash string) {
+	if promoted.Labels == nil {
+		promoted.Labels = map[string]string{}
+	}
+	promoted.Labels[promotion.SourceNamespaceLabel] = source.Namespace
+	promoted.Labels[promotion.SourceNameLabel] = source.Name
+	if promoted.Annotations == nil {
+		promoted.Annotations = map[string]string{}
+	}
+	promoted.Annotations[promotion.GenerationAnnotation] = fmt.Sprint(source.Generation)
+	promoted.Annotations[promotion.HashAnnotation] = hash
+}
+
+// findAgentsForPromotion maps the changes of promoted Agents, and of the AgentPolicies
+// accepting promotions, to the agents promoted to their namespace, to report the
+// promotions they allow or restore the Agents they overwrite or delete.
+func (r *AgentReconciler) findAgentsForPromotion(ctx context.Context, obj client.Object) []reconcile.Request {
+	if agent, ok := obj.(*aiv1.Agent); ok {
+		namespace, name := agent.Labels[promotion.SourceNamespaceLabel], agent.Labels[promotion.SourceNameLabel]
+		if namespace == "" || name == "" {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: namespace, Name: name}}}
+	}
+
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for promotion")
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, agent := range agents.Items {
+		if agent.Annotations[promotion.Annotation] == obj.GetNamespace() {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+			})
+		}
+	}
+	return requests
+}
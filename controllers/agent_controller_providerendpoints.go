@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/health"
+)
+
+// providerEndpoint is an endpoint of the provider as rendered into AGENT_ENDPOINTS, with
+// the defaults applied.
+type providerEndpoint struct {
+	URL      string `json:"url"`
+	Priority int32  `json:"priority"`
+	Weight   int32  `json:"weight"`
+}
+
+// sortedProviderEndpoints returns the endpoints of the agent ordered by priority, keeping
+// the order of spec.endpoints within a priority.
+func sortedProviderEndpoints(agent *aiv1.Agent) []providerEndpoint {
+	endpoints := make([]providerEndpoint, 0, len(agent.Spec.Endpoints))
+	for _, endpoint := range agent.Spec.Endpoints {
+		rendered := providerEndpoint{URL: endpoint.URL, Weight: 1}
+		if endpoint.Priority != nil {
+			rendered.Priority = *endpoint.Priority
+		}
+		if endpoint.Weight != nil {
+			rendered.Weight = *endpoint.Weight
+		}
+		endpoints = append(endpoints, rendered)
+	}
+	sort.SliceStable(endpoints, func(i, j int) bool { return endpoints[i].Priority < endpoints[j].Priority })
+	return endpoints
+}
+
+// providerEndpointURLs returns the URLs of all endpoints of the provider: those of
+// spec.endpoints by priority, or spec.endpoint, or none for hosted providers.
+func providerEndpointURLs(agent *aiv1.Agent) []string {
+	if len(agent.Spec.Endpoints) == 0 {
+		if agent.Spec.Endpoint == "" {
+			return nil
+		}
+		return []string{agent.Spec.Endpoint}
+	}
+	var urls []string
+	for _, endpoint := range sortedProviderEndpoints(agent) {
+		urls = append(urls, endpoint.URL)
+	}
+	return urls
+}
+
+// primaryEndpoint returns the endpoint the runtime tries first, or an empty string for
+// hosted providers.
+func primaryEndpoint(agent *aiv1.Agent) string {
+	if urls := providerEndpointURLs(agent); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
+
+// providerEndpointsEnv renders the endpoints of the agent into the AGENT_ENDPOINTS value
+// the runtime fails over with, or returns an empty string for agents with one endpoint.
+func providerEndpointsEnv(agent *aiv1.Agent) string {
+	if len(agent.Spec.Endpoints) == 0 {
+		return ""
+	}
+	data, _ := json.Marshal(sortedProviderEndpoints(agent))
+	return string(data)
+}
+
+// validateProviderEndpoints checks that spec.endpoints is not mixed with spec.endpoint,
+// and holds unique http or https URLs with valid priorities and weights.
+func validateProviderEndpoints(agent *aiv1.Agent) error {
+	if len(agent.Spec.Endpoints) == 0 {
+		return nil
+	}
+	if agent.Spec.Endpoint != "" {
+		return fmt.Errorf("endpoint and endpoints are mutually exclusive")
+	}
+	seen := map[string]bool{}
+	for i, endpoint := range agent.Spec.Endpoints {
+		parsed, err := url.Parse(endpoint.URL)
+		if err != nil {
+			return fmt.Errorf("endpoints[%d].url: %v", i, err)
+		}
+		if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("endpoints[%d].url %q must be an absolute http or https URL", i, endpoint.URL)
+		}
+		key := strings.TrimSuffix(endpoint.URL, "/")
+		if seen[key] {
+			return fmt.Errorf("endpoints[%d].url %q is listed more than once", i, endpoint.URL)
+		}
+		seen[key] = true
+		if endpoint.Priority != nil && *endpoint.Priority < 0 {
+			return fmt.Errorf("endpoints[%d].priority must not be negative", i)
+		}
+		if endpoint.Weight != nil && *endpoint.Weight < 1 {
+			return fmt.Errorf("endpoints[%d].weight must be at least 1", i)
+		}
+	}
+	return nil
+}
+
+// providerEndpointHealth returns the health of the endpoints of the agent reported by the
+// runtime, in the order of spec.endpoints. Endpoints missing from the report, such as
+// those of runtimes predating failover, are left out.
+func providerEndpointHealth(agent *aiv1.Agent, report health.Report) []aiv1.ProviderEndpointHealth {
+	if len(agent.Spec.Endpoints) == 0 {
+		return nil
+	}
+	reported := map[string]health.Endpoint{}
+	for _, endpoint := range report.Endpoints {
+		reported[strings.TrimSuffix(endpoint.URL, "/")] = endpoint
+	}
+	var endpoints []aiv1.ProviderEndpointHealth
+	for _, endpoint := range agent.Spec.Endpoints {
+		checked, ok := reported[strings.TrimSuffix(endpoint.URL, "/")]
+		if !ok {
+			continue
+		}
+		endpoints = append(endpoints, aiv1.ProviderEndpointHealth{
+			URL:     endpoint.URL,
+			Healthy: checked.Status == health.StateHealthy,
+			Active:  checked.Active,
+			Message: checked.Message,
+		})
+	}
+	return endpoints
+}
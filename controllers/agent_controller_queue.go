@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// agentControllerName names the Agent controller of the normal priority class, and its
+// workqueue in the workqueue_* metrics. The other classes append their name to it.
+const agentControllerName = "agent"
+
+var reconcileThrottled = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "kubeagentic_reconcile_throttled_total",
+	Help: "Events delayed because their object was enqueued more often than its burst, by controller.",
+}, []string{"controller"})
+
+func init() {
+	metrics.Registry.MustRegister(reconcileThrottled)
+}
+
+// AgentEvents builds the event handlers of the Agent controller of a priority class.
+type AgentEvents struct {
+	// Controller names the controller in kubeagentic_reconcile_throttled_total.
+	Controller string
+	// Class is the priority class of the agents enqueued; events for the others are dropped.
+	Class PriorityClass
+	// Limiter delays the agents enqueued too often by the objects they refer to.
+	Limiter workqueue.RateLimiter
+	// Reader reads the priority class of the agents.
+	Reader client.Reader
+}
+
+// Owned returns the event handler enqueuing the agents controlling the objects of the
+// events, such as their Deployments and Services. These events are not delayed: they carry
+// the progress of rollouts and the out-of-band edits that are reverted at once. The queue
+// holds each agent once, so an owned object rewritten in a loop does not starve the others.
+func (e AgentEvents) Owned(scheme *runtime.Scheme, mapper meta.RESTMapper) handler.EventHandler {
+	return PriorityEvents(e.Class, handler.EnqueueRequestForOwner(scheme, mapper, &aiv1.Agent{}, handler.OnlyControllerOwner()), e.Reader)
+}
+
+// Referenced returns h, enqueuing the agents referring to the objects of its events, such
+// as Secrets and AgentPolicies, delayed per agent by Limiter.
+func (e AgentEvents) Referenced(h handler.EventHandler) handler.EventHandler {
+	return RateLimitedEvents(e.Controller, PriorityEvents(e.Class, h, e.Reader), e.Limiter)
+}
+
+// RateLimitedEvents returns an event handler enqueuing the requests of h after the delay
+// of limiter, so that the events of an object rewritten in a loop do not starve the
+// other objects of the queue. Requests are delayed by limiter alone; the overall token
+// bucket of the queue only applies to retries.
+func RateLimitedEvents(controller string, h handler.EventHandler, limiter workqueue.RateLimiter) handler.EventHandler {
+	return &rateLimitedEvents{controller: controller, handler: h, limiter: limiter}
+}
+
+type rateLimitedEvents struct {
+	controller string
+	handler    handler.EventHandler
+	limiter    workqueue.RateLimiter
+}
+
+func (e *rateLimitedEvents) queue(q workqueue.RateLimitingInterface) workqueue.RateLimitingInterface {
+	return &rateLimitedQueue{RateLimitingInterface: q, events: e}
+}
+
+func (e *rateLimitedEvents) Create(ctx context.Context, evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	e.handler.Create(ctx, evt, e.queue(q))
+}
+
+func (e *rateLimitedEvents) Update(ctx context.Context, evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	e.handler.Update(ctx, evt, e.queue(q))
+}
+
+func (e *rateLimitedEvents) Delete(ctx context.Context, evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	e.handler.Delete(ctx, evt, e.queue(q))
+}
+
+func (e *rateLimitedEvents) Generic(ctx context.Context, evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	e.handler.Generic(ctx, evt, e.queue(q))
+}
+
+// rateLimitedQueue delays the requests added by an event handler. The delaying queue keeps
+// the earliest time an item is added at, so a delayed request does not postpone one that
+// is already due.
+type rateLimitedQueue struct {
+	workqueue.RateLimitingInterface
+	events *rateLimitedEvents
+}
+
+func (q *rateLimitedQueue) Add(item interface{}) {
+	delay := q.events.limiter.When(item)
+	if delay <= 0 {
+		q.RateLimitingInterface.Add(item)
+		return
+	}
+	reconcileThrottled.WithLabelValues(q.events.controller).Inc()
+	q.RateLimitingInterface.AddAfter(item, delay)
+}
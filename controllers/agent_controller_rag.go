@@ -0,0 +1,632 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/provider"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+// vectorStoreChecksumAnnotation records a hash of the vector store connection secret on the
+// pod template so that rotating the secret rolls the agent pods.
+const vectorStoreChecksumAnnotation = "kubeagentic.ai/vector-store-checksum"
+
+// vectorStoreCheckScript is run by the pre-flight Job to verify the vector store accepts TCP connections.
+const vectorStoreCheckScript = `import os, socket, sys, urllib.parse
+ports = {"pgvector": 5432, "qdrant": 6333, "weaviate": 8080}
+url = urllib.parse.urlparse(os.environ["VECTOR_STORE_URL"])
+port = url.port or ports.get(os.environ["VECTOR_STORE_TYPE"], 80)
+try:
+    socket.create_connection((url.hostname, port), timeout=10).close()
+except OSError as e:
+    print(f"vector store {url.hostname}:{port} unreachable: {e}")
+    sys.exit(1)
+print(f"vector store {url.hostname}:{port} reachable")
+`
+
+// ragEnabled reports whether retrieval-augmented generation is turned on for the agent.
+func ragEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.RAG != nil && agent.Spec.RAG.Enabled
+}
+
+// validateRAGConfig ensures the vector store configuration is complete and its secret is available.
+func (r *AgentReconciler) validateRAGConfig(ctx context.Context, agent *aiv1.Agent) error {
+	if !ragEnabled(agent) {
+		return nil
+	}
+
+	vs := agent.Spec.RAG.VectorStore
+	if vs == nil {
+		return fmt.Errorf("rag.vectorStore is required when rag is enabled")
+	}
+	if vs.Dimensions <= 0 {
+		return fmt.Errorf("rag.vectorStore.dimensions must be positive, got %d", vs.Dimensions)
+	}
+
+	secret := &corev1.Secret{}
+	err := r.Get(ctx, types.NamespacedName{
+		Name:      vs.ConnectionSecretRef.Name,
+		Namespace: agent.Namespace,
+	}, secret)
+	if err != nil {
+		return fmt.Errorf("failed to get vector store secret %s: %w", vs.ConnectionSecretRef.Name, err)
+	}
+
+	if _, exists := secret.Data[vs.ConnectionSecretRef.Key]; !exists {
+		return fmt.Errorf("key %s not found in vector store secret %s", vs.ConnectionSecretRef.Key, vs.ConnectionSecretRef.Name)
+	}
+
+	if err := r.validateEmbeddingsConfig(ctx, agent); err != nil {
+		return err
+	}
+
+	return r.validateIngestionConfig(ctx, agent)
+}
+
+// effectiveEmbeddings returns the embeddings configuration with omitted fields defaulted
+// to the primary model connection, or nil when no embeddings model is configured.
+func effectiveEmbeddings(agent *aiv1.Agent) *aiv1.EmbeddingsConfig {
+	if !ragEnabled(agent) || agent.Spec.RAG.Embeddings == nil {
+		return nil
+	}
+
+	embeddings := agent.Spec.RAG.Embeddings.DeepCopy()
+	if embeddings.Provider == "" {
+		embeddings.Provider = agent.Spec.Provider
+		if embeddings.Endpoint == "" {
+			embeddings.Endpoint = primaryEndpoint(agent)
+		}
+	}
+	if embeddings.ApiSecretRef == nil && agent.Spec.ApiSecretRef != nil {
+		embeddings.ApiSecretRef = agent.Spec.ApiSecretRef.DeepCopy()
+	}
+	return embeddings
+}
+
+// validateEmbeddingsConfig applies the primary provider and secret rules to the embeddings model.
+func (r *AgentReconciler) validateEmbeddingsConfig(ctx context.Context, agent *aiv1.Agent) error {
+	embeddings := effectiveEmbeddings(agent)
+	if embeddings == nil {
+		return nil
+	}
+
+	if !isValidProvider(embeddings.Provider) {
+		return fmt.Errorf("invalid embeddings provider: %s, must be one of %v", embeddings.Provider, validProviders)
+	}
+	if embeddings.Model == "" {
+		return fmt.Errorf("rag.embeddings.model is required")
+	}
+	if providersRequiringEndpoint[embeddings.Provider] && embeddings.Endpoint == "" {
+		return fmt.Errorf("rag.embeddings.endpoint is required for provider %s", embeddings.Provider)
+	}
+
+	ref := embeddings.ApiSecretRef
+	if ref == nil {
+		if provider.RequiresAPISecret(embeddings.Provider, embeddings.Endpoint) {
+			return fmt.Errorf("rag.embeddings.apiSecretRef is required for provider %s without a self-hosted endpoint", embeddings.Provider)
+		}
+		return nil
+	}
+	if err := r.validateSecretNamespaces(ctx, agent, ref); err != nil {
+		return err
+	}
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: secretNamespace(agent, ref)}, secret); err != nil {
+		return fmt.Errorf("failed to get embeddings secret %s: %w", ref.Name, err)
+	}
+	if _, exists := secret.Data[ref.Key]; !exists {
+		return fmt.Errorf("key %s not found in embeddings secret %s", ref.Key, ref.Name)
+	}
+
+	return nil
+}
+
+// validateIngestionConfig ensures every ingestion source is well formed and its credentials exist.
+func (r *AgentReconciler) validateIngestionConfig(ctx context.Context, agent *aiv1.Agent) error {
+	ingestion := agent.Spec.RAG.Ingestion
+	if ingestion == nil {
+		return nil
+	}
+
+	if ingestion.Schedule == "" {
+		return fmt.Errorf("rag.ingestion.schedule is required")
+	}
+	if len(ingestion.Sources) == 0 {
+		return fmt.Errorf("rag.ingestion.sources must not be empty")
+	}
+
+	for i, source := range ingestion.Sources {
+		set := 0
+		if source.URL != "" {
+			set++
+			if !strings.HasPrefix(source.URL, "http://") && !strings.HasPrefix(source.URL, "https://") {
+				return fmt.Errorf("rag.ingestion.sources[%d].url must be an http or https URL", i)
+			}
+		}
+		if source.ConfigMapRef != nil {
+			set++
+		}
+		if source.Bucket != nil {
+			set++
+			if creds := source.Bucket.CredentialsSecretRef; creds != nil {
+				secret := &corev1.Secret{}
+				if err := r.Get(ctx, types.NamespacedName{Name: creds.Name, Namespace: agent.Namespace}, secret); err != nil {
+					return fmt.Errorf("failed to get bucket credentials secret %s: %w", creds.Name, err)
+				}
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("rag.ingestion.sources[%d] must set exactly one of url, configMapRef or bucket", i)
+		}
+	}
+
+	return nil
+}
+
+// ragEnv returns the environment variables describing the vector store connection and
+// the embeddings model.
+func ragEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !ragEnabled(agent) || agent.Spec.RAG.VectorStore == nil {
+		return nil
+	}
+
+	vs := agent.Spec.RAG.VectorStore
+	env := []corev1.EnvVar{
+		{Name: "AGENT_RAG_ENABLED", Value: "true"},
+		{Name: "AGENT_VECTOR_STORE_TYPE", Value: vs.Type},
+		{Name: "AGENT_VECTOR_STORE_COLLECTION", Value: vs.Collection},
+		{Name: "AGENT_VECTOR_STORE_DIMENSIONS", Value: fmt.Sprintf("%d", vs.Dimensions)},
+		{
+			Name: "AGENT_VECTOR_STORE_URL",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: &vs.ConnectionSecretRef,
+			},
+		},
+	}
+
+	if embeddings := effectiveEmbeddings(agent); embeddings != nil {
+		env = append(env,
+			corev1.EnvVar{Name: "EMBEDDINGS_PROVIDER", Value: embeddings.Provider},
+			corev1.EnvVar{Name: "EMBEDDINGS_MODEL", Value: embeddings.Model},
+		)
+		if embeddings.ApiSecretRef != nil {
+			env = append(env, corev1.EnvVar{
+				Name: "EMBEDDINGS_API_KEY",
+				ValueFrom: &corev1.EnvVarSource{
+					SecretKeyRef: localSecretKeySelector(agent, embeddings.ApiSecretRef),
+				},
+			})
+		}
+		if embeddings.Endpoint != "" {
+			env = append(env, corev1.EnvVar{Name: "EMBEDDINGS_ENDPOINT", Value: embeddings.Endpoint})
+		}
+	}
+
+	return env
+}
+
+// vectorStoreChecksum returns a hash of the vector store connection string, or an empty
+// string when RAG is not enabled.
+func (r *AgentReconciler) vectorStoreChecksum(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if !ragEnabled(agent) || agent.Spec.RAG.VectorStore == nil {
+		return "", nil
+	}
+
+	ref := agent.Spec.RAG.VectorStore.ConnectionSecretRef
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: agent.Namespace}, secret); err != nil {
+		return "", fmt.Errorf("failed to get vector store secret %s: %w", ref.Name, err)
+	}
+
+	sum := sha256.Sum256(secret.Data[ref.Key])
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// reconcileVectorStoreCheck runs the pre-flight connectivity Job and records its outcome
+// in the VectorStoreReachable condition.
+func (r *AgentReconciler) reconcileVectorStoreCheck(ctx context.Context, agent *aiv1.Agent) error {
+	name := naming.Child(agent.Name, "vector-store-check")
+	found := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, found)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	exists := err == nil
+
+	if !ragEnabled(agent) || agent.Spec.RAG.VectorStore == nil || !agent.Spec.RAG.VectorStore.VerifyConnectivity {
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionVectorStoreReachable)
+		if exists {
+			log.FromContext(ctx).Info("Deleting vector store check Job", "Job.Name", found.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+		}
+		return nil
+	}
+
+	checksum, err := r.vectorStoreChecksum(ctx, agent)
+	if err != nil {
+		return err
+	}
+
+	now := metav1.NewTime(time.Now())
+	condition := aiv1.AgentCondition{
+		Type:               aiv1.AgentConditionVectorStoreReachable,
+		Status:             corev1.ConditionUnknown,
+		Reason:             "Checking",
+		Message:            "Vector store connectivity check is running",
+		LastTransitionTime: &now,
+	}
+
+	// Job templates are immutable, so a changed connection string means a fresh check.
+	// The replacement Job is created once the deletion of the old one triggers a reconcile.
+	if exists && found.Annotations[vectorStoreChecksumAnnotation] != checksum {
+		log.FromContext(ctx).Info("Vector store connection changed, re-running check", "Job.Name", found.Name)
+		agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, condition)
+		return client.IgnoreNotFound(r.Delete(ctx, found, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+	}
+
+	if !exists {
+		job := r.buildVectorStoreCheckJob(agent, name, checksum)
+		if err := controllerutil.SetControllerReference(agent, job, r.Scheme); err != nil {
+			return err
+		}
+		log.FromContext(ctx).Info("Creating vector store check Job", "Job.Namespace", job.Namespace, "Job.Name", job.Name)
+		if err := r.Create(ctx, job); err != nil {
+			return err
+		}
+		found = job
+	}
+
+	if found.Status.Succeeded > 0 {
+		condition.Status = corev1.ConditionTrue
+		condition.Reason = "Reachable"
+		condition.Message = "Vector store connection verified"
+	} else if jobFailed(found) {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "Unreachable"
+		condition.Message = "Vector store connectivity check failed, see logs of Job " + found.Name
+	}
+	agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, condition)
+
+	return nil
+}
+
+// buildVectorStoreCheckJob creates the pre-flight Job that verifies the vector store is reachable.
+func (r *AgentReconciler) buildVectorStoreCheckJob(agent *aiv1.Agent, name, checksum string) *batchv1.Job {
+	labels := map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-job",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "vector-store-check",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+	vs := agent.Spec.RAG.VectorStore
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+			Annotations: map[string]string{
+				vectorStoreChecksumAnnotation: checksum,
+			},
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: int32Ptr(2),
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					Containers: []corev1.Container{
+						{
+							Name:    "check",
+							Image:   r.getAgentImage(agent),
+							Command: []string{"python", "-c", vectorStoreCheckScript},
+							Env: []corev1.EnvVar{
+								{Name: "VECTOR_STORE_TYPE", Value: vs.Type},
+								{
+									Name: "VECTOR_STORE_URL",
+									ValueFrom: &corev1.EnvVarSource{
+										SecretKeyRef: &vs.ConnectionSecretRef,
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	securityprofile.Apply(securityProfile(agent), &job.Spec.Template.Spec, agentUID)
+	return job
+}
+
+// reconcileIngestion manages the CronJob that keeps the vector store populated and
+// records the outcome of its most recent run in the Agent status.
+func (r *AgentReconciler) reconcileIngestion(ctx context.Context, agent *aiv1.Agent) error {
+	name := naming.ChildWithMax(agent.Name, "rag-ingestion", naming.MaxCronJobLength)
+	if !ragEnabled(agent) || agent.Spec.RAG.VectorStore == nil || agent.Spec.RAG.Ingestion == nil {
+		agent.Status.Ingestion = nil
+		cronJob := &batchv1.CronJob{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, cronJob, cacheOnly)
+		if err == nil {
+			log.FromContext(ctx).Info("Deleting ingestion CronJob", "CronJob.Name", cronJob.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, cronJob, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+		}
+		return client.IgnoreNotFound(err)
+	}
+
+	cronJob := r.buildIngestionCronJob(agent, name)
+	if err := controllerutil.SetControllerReference(agent, cronJob, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &batchv1.CronJob{}
+	err := r.Get(ctx, types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new ingestion CronJob", "CronJob.Namespace", cronJob.Namespace, "CronJob.Name", cronJob.Name)
+		if err := r.Create(ctx, cronJob); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		// Updating the job template in place means changed sources apply on the next run.
+		log.FromContext(ctx).Info("Updating existing ingestion CronJob", "CronJob.Namespace", found.Namespace, "CronJob.Name", found.Name)
+		found.Spec = cronJob.Spec
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	return r.updateIngestionStatus(ctx, agent)
+}
+
+// buildIngestionCronJob creates the CronJob that runs document ingestion for the agent.
+func (r *AgentReconciler) buildIngestionCronJob(agent *aiv1.Agent, name string) *batchv1.CronJob {
+	labels := map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-job",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "rag-ingestion",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+	ingestion := agent.Spec.RAG.Ingestion
+
+	historyLimit := int32(3)
+	if ingestion.HistoryLimit != nil {
+		historyLimit = *ingestion.HistoryLimit
+	}
+	chunkSize := int32(1000)
+	if ingestion.ChunkSize > 0 {
+		chunkSize = ingestion.ChunkSize
+	}
+
+	sourcesJSON, _ := json.Marshal(ingestion.Sources)
+	env := []corev1.EnvVar{
+		{Name: "AGENT_PROVIDER", Value: agent.Spec.Provider},
+		{Name: "INGESTION_SOURCES", Value: string(sourcesJSON)},
+		{Name: "INGESTION_CHUNK_SIZE", Value: fmt.Sprintf("%d", chunkSize)},
+		{Name: "INGESTION_CHUNK_OVERLAP", Value: fmt.Sprintf("%d", ingestion.ChunkOverlap)},
+	}
+	if agent.Spec.ApiSecretRef != nil {
+		env = append(env, corev1.EnvVar{
+			Name: "AGENT_API_KEY",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: localSecretKeySelector(agent, agent.Spec.ApiSecretRef),
+			},
+		})
+	}
+	if endpoint := primaryEndpoint(agent); endpoint != "" {
+		env = append(env, corev1.EnvVar{Name: "AGENT_ENDPOINT", Value: endpoint})
+	}
+	env = append(env, ragEnv(agent)...)
+
+	// Bucket credentials are exposed with a per-source prefix so that several buckets can coexist.
+	var envFrom []corev1.EnvFromSource
+	var volumes []corev1.Volume
+	var mounts []corev1.VolumeMount
+	for i, source := range ingestion.Sources {
+		if source.Bucket != nil && source.Bucket.CredentialsSecretRef != nil {
+			envFrom = append(envFrom, corev1.EnvFromSource{
+				Prefix: fmt.Sprintf("BUCKET_%d_", i),
+				SecretRef: &corev1.SecretEnvSource{
+					LocalObjectReference: *source.Bucket.CredentialsSecretRef,
+				},
+			})
+		}
+		if source.ConfigMapRef != nil {
+			volumeName := fmt.Sprintf("source-%d", i)
+			volumes = append(volumes, corev1.Volume{
+				Name: volumeName,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: *source.ConfigMapRef,
+					},
+				},
+			})
+			mounts = append(mounts, corev1.VolumeMount{
+				Name:      volumeName,
+				MountPath: "/sources/configmaps/" + source.ConfigMapRef.Name,
+				ReadOnly:  true,
+			})
+		}
+	}
+
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   ingestion.Schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &historyLimit,
+			FailedJobsHistoryLimit:     &historyLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					BackoffLimit: int32Ptr(2),
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Volumes:       volumes,
+							Containers: []corev1.Container{
+								{
+									Name:         "ingestion",
+									Image:        getIngestionImage(agent),
+									Env:          env,
+									EnvFrom:      envFrom,
+									VolumeMounts: mounts,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	securityprofile.Apply(securityProfile(agent), &cronJob.Spec.JobTemplate.Spec.Template.Spec, agentUID)
+	return cronJob
+}
+
+// ingestionResult is the summary the ingestion container writes to its termination message.
+type ingestionResult struct {
+	DocumentsProcessed int64 `json:"documentsProcessed"`
+	Failures           int64 `json:"failures"`
+}
+
+// updateIngestionStatus records the outcome of the most recently finished ingestion job.
+func (r *AgentReconciler) updateIngestionStatus(ctx context.Context, agent *aiv1.Agent) error {
+	latest, finished, err := r.latestFinishedJob(ctx, agent, "rag-ingestion")
+	if err != nil || latest == nil {
+		return err
+	}
+
+	status := &aiv1.IngestionStatus{
+		LastRunTime:      finished,
+		LastRunSucceeded: latest.Status.Succeeded > 0,
+	}
+
+	// The ingestion container reports its counters through the termination message.
+	message, err := r.jobTerminationMessage(ctx, latest, "ingestion")
+	if err != nil {
+		return err
+	}
+	if message != "" {
+		var result ingestionResult
+		if err := json.Unmarshal([]byte(message), &result); err != nil {
+			log.FromContext(ctx).Info("Ignoring malformed ingestion result", "Job.Name", latest.Name, "error", err.Error())
+		} else {
+			status.DocumentsProcessed = result.DocumentsProcessed
+			status.Failures = result.Failures
+		}
+	}
+
+	agent.Status.Ingestion = status
+	return nil
+}
+
+// latestFinishedJob returns the agent's most recently completed or failed Job of the given
+// component, together with the time it finished. It returns nil when no Job has finished yet.
+func (r *AgentReconciler) latestFinishedJob(ctx context.Context, agent *aiv1.Agent, component string) (*batchv1.Job, *metav1.Time, error) {
+	var jobs batchv1.JobList
+	if err := r.List(ctx, &jobs, client.InNamespace(agent.Namespace), client.MatchingLabels{
+		"kubeagentic.ai/agent":        agent.Name,
+		"app.kubernetes.io/component": component,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	var latest *batchv1.Job
+	var latestTime *metav1.Time
+	for i := range jobs.Items {
+		job := &jobs.Items[i]
+		finished := job.Status.CompletionTime
+		if finished == nil && jobFailed(job) {
+			for j := range job.Status.Conditions {
+				if job.Status.Conditions[j].Type == batchv1.JobFailed {
+					finished = &job.Status.Conditions[j].LastTransitionTime
+				}
+			}
+		}
+		if finished != nil && (latestTime == nil || finished.After(latestTime.Time)) {
+			latest, latestTime = job, finished
+		}
+	}
+	return latest, latestTime, nil
+}
+
+// jobTerminationMessage returns the termination message written by the named container of the Job's pods.
+func (r *AgentReconciler) jobTerminationMessage(ctx context.Context, job *batchv1.Job, container string) (string, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(job.Namespace), client.MatchingLabels{"job-name": job.Name}); err != nil {
+		return "", err
+	}
+	message := ""
+	for _, pod := range pods.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name == container && cs.State.Terminated != nil && cs.State.Terminated.Message != "" {
+				message = cs.State.Terminated.Message
+			}
+		}
+	}
+	return message, nil
+}
+
+// getIngestionImage returns the container image used by the ingestion job, following the
+// same precedence as getAgentImage: spec, then operator environment, then a default.
+func getIngestionImage(agent *aiv1.Agent) string {
+	if agent.Spec.RAG.Ingestion.Image != "" {
+		return agent.Spec.RAG.Ingestion.Image
+	}
+	if envImage := os.Getenv("INGESTION_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return "kubeagentic/rag-ingestion:latest"
+}
+
+// jobFailed reports whether the Job has reached the Failed condition.
+func jobFailed(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == batchv1.JobFailed && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// removeCondition drops the condition of the given type from the list, if present.
+func removeCondition(conditions []aiv1.AgentCondition, conditionType aiv1.AgentConditionType) []aiv1.AgentCondition {
+	for i, condition := range conditions {
+		if condition.Type == conditionType {
+			return append(conditions[:i], conditions[i+1:]...)
+		}
+	}
+	return conditions
+}
@@ -0,0 +1,164 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/ratelimit"
+)
+
+const (
+	// rateLimitProxyPort is the port of the rate limit proxy in the agent pods.
+	rateLimitProxyPort int32 = 8081
+	// rateLimitMountPath is where the rate limit rules are mounted in the proxy container.
+	rateLimitMountPath = "/etc/kubeagentic/ratelimit"
+	// rateLimitConfigKey is the key of the agent ConfigMap holding the rate limit rules.
+	rateLimitConfigKey = "ratelimit.json"
+	// maxRateLimitRules bounds the rules of an agent, each evaluated on every request.
+	maxRateLimitRules = 10
+)
+
+// inboundRateLimitEnabled reports whether the agent pods run the rate limit proxy.
+func inboundRateLimitEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.InboundRateLimit != nil && len(agent.Spec.InboundRateLimit.Rules) > 0
+}
+
+// agentServingPort returns the port of the agent pods serving the clients: the rate limit
+// proxy when the agent has one, then the guardrails proxy, the agent container otherwise.
+func agentServingPort(agent *aiv1.Agent) int32 {
+	if inboundRateLimitEnabled(agent) {
+		return rateLimitProxyPort
+	}
+	return guardedPort(agent)
+}
+
+// validateInboundRateLimit checks the rate limit rules of the agent.
+func (r *AgentReconciler) validateInboundRateLimit(agent *aiv1.Agent) error {
+	if agent.Spec.InboundRateLimit == nil {
+		return nil
+	}
+	rules := agent.Spec.InboundRateLimit.Rules
+	if len(rules) == 0 {
+		return fmt.Errorf("inboundRateLimit.rules must not be empty")
+	}
+	if len(rules) > maxRateLimitRules {
+		return fmt.Errorf("inboundRateLimit has %d rules, at most %d are allowed", len(rules), maxRateLimitRules)
+	}
+	names := map[string]bool{}
+	for _, rule := range rules {
+		if rule.Name == "" {
+			return fmt.Errorf("rate limit rule names must not be empty")
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("duplicate rate limit rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if rule.Header != "" && !headerNamePattern.MatchString(rule.Header) {
+			return fmt.Errorf("rate limit rule %q: invalid header %q", rule.Name, rule.Header)
+		}
+		if rule.Requests < 1 {
+			return fmt.Errorf("rate limit rule %q: requests must be at least 1", rule.Name)
+		}
+		if rule.Window.Duration <= 0 {
+			return fmt.Errorf("rate limit rule %q: the window must be positive", rule.Name)
+		}
+		if rule.Burst != nil && *rule.Burst < 1 {
+			return fmt.Errorf("rate limit rule %q: burst must be at least 1", rule.Name)
+		}
+	}
+	return nil
+}
+
+// rateLimitConfig returns the ratelimit.json of the agent ConfigMap, read by the proxy.
+func rateLimitConfig(agent *aiv1.Agent) (string, error) {
+	config := ratelimit.Config{Rules: []ratelimit.Rule{}}
+	for _, rule := range agent.Spec.InboundRateLimit.Rules {
+		burst := rule.Requests
+		if rule.Burst != nil {
+			burst = *rule.Burst
+		}
+		config.Rules = append(config.Rules, ratelimit.Rule{
+			Name:     rule.Name,
+			Header:   rule.Header,
+			Requests: int(rule.Requests),
+			Window:   rule.Window.Duration.String(),
+			Burst:    int(burst),
+		})
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// getRateLimitProxyImage returns the image of the rate limit proxy: the operator environment,
+// then the operator image, which ships the proxy.
+func getRateLimitProxyImage() string {
+	if envImage := os.Getenv("RATE_LIMIT_PROXY_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return "kubeagentic/operator:latest"
+}
+
+// rateLimitProxy returns the sidecar enforcing the rate limits in front of the agent
+// container, and of its guardrails proxy, and the volume of its rules, or nil without rate
+// limits. Limited requests are rejected before the guardrails check them. The rules are mounted
+// from the agent ConfigMap, whose updates the proxy reloads without restarting, so rule
+// changes do not roll the pods nor drop connections.
+func rateLimitProxy(agent *aiv1.Agent) (*corev1.Container, []corev1.Volume) {
+	if !inboundRateLimitEnabled(agent) {
+		return nil, nil
+	}
+	container := &corev1.Container{
+		Name:    "ratelimit-proxy",
+		Image:   getRateLimitProxyImage(),
+		Command: []string{"/ratelimit-proxy"},
+		Args: []string{
+			"--config", rateLimitMountPath + "/" + rateLimitConfigKey,
+			"--addr", fmt.Sprintf(":%d", rateLimitProxyPort),
+			"--upstream", fmt.Sprintf("http://127.0.0.1:%d", guardedPort(agent)),
+		},
+		Ports: []corev1.ContainerPort{
+			{Name: "ratelimit", ContainerPort: rateLimitProxyPort, Protocol: corev1.ProtocolTCP},
+		},
+		// Label the rate limit metrics with the identity of the agent
+		Env: identityEnv(agent),
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+				corev1.ResourceCPU:    resource.MustParse("25m"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("128Mi"),
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+			},
+		},
+		// The readiness of the agent, checked through the proxy, covers both containers
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/ready", Port: intstr.FromInt(int(rateLimitProxyPort))},
+			},
+			PeriodSeconds: 5,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "ratelimit", MountPath: rateLimitMountPath, ReadOnly: true},
+		},
+	}
+	volume := corev1.Volume{
+		Name: "ratelimit",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: configMapName(agent)},
+				Items:                []corev1.KeyToPath{{Key: rateLimitConfigKey, Path: rateLimitConfigKey}},
+			},
+		},
+	}
+	return container, []corev1.Volume{volume}
+}
@@ -0,0 +1,51 @@
+package controllers
+
+import (
+	"encoding/json"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/redaction"
+)
+
+// validateRedaction checks the rules of spec.redaction, which the runtime and the export
+// job would otherwise fail to compile, or compile into patterns that backtrack forever.
+func (r *AgentReconciler) validateRedaction(agent *aiv1.Agent) error {
+	if errs := redaction.Validate(field.NewPath("spec", "redaction"), agent.Spec.Redaction); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	return nil
+}
+
+// redactionRules returns the rules of the agent applied to target, or nil.
+func redactionRules(agent *aiv1.Agent, target aiv1.RedactionTarget) []redaction.Rule {
+	config := redaction.Render(agent.Spec.Redaction)
+	if !config.Applies(target) {
+		return nil
+	}
+	return config.Rules
+}
+
+// redactionEnvVar returns the variable named name holding the rules of the agent applied to
+// target, or nil when none are.
+func redactionEnvVar(agent *aiv1.Agent, name string, target aiv1.RedactionTarget) []corev1.EnvVar {
+	rules := redactionRules(agent, target)
+	if len(rules) == 0 {
+		return nil
+	}
+	data, _ := json.Marshal(redaction.Config{Rules: rules, ApplyTo: []string{string(target)}})
+	return []corev1.EnvVar{{Name: name, Value: string(data)}}
+}
+
+// redactionEnv returns the environment variables redacting the logs of the agent runtime.
+func redactionEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	return redactionEnvVar(agent, "AGENT_REDACTION", aiv1.RedactionTargetLogs)
+}
+
+// exportRedactionEnv returns the environment variables redacting the transcripts uploaded
+// by the export job before they are written.
+func exportRedactionEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	return redactionEnvVar(agent, "EXPORT_REDACTION", aiv1.RedactionTargetExports)
+}
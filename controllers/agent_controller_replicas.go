@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/replicalimit"
+)
+
+// replicasAboveMaximumReason is the reason of the Degraded condition set while spec.replicas
+// exceeds the maximum of the operator or of an AgentPolicy.
+const replicasAboveMaximumReason = "ReplicasAboveMaximum"
+
+// checkReplicaLimit compares spec.replicas with the maximum of the operator and of the
+// AgentPolicies of the namespace. The admission webhook rejects raising the replicas above
+// it; agents created before the maximum was lowered keep running their replicas and are only
+// reported on the Degraded condition.
+func (r *AgentReconciler) checkReplicaLimit(ctx context.Context, agent *aiv1.Agent) error {
+	var policies aiv1.AgentPolicyList
+	if err := r.List(ctx, &policies, client.InNamespace(agent.Namespace)); err != nil {
+		return err
+	}
+	max, source := replicalimit.Max(agent.Namespace, policies.Items)
+	if agent.Spec.Replicas != nil && *agent.Spec.Replicas > max {
+		r.setFailedCondition(agent, aiv1.AgentConditionDegraded, replicasAboveMaximumReason,
+			fmt.Sprintf("replicas %d exceed the maximum %d of %s; lower spec.replicas, the agent cannot be scaled up further", *agent.Spec.Replicas, max, source))
+		return nil
+	}
+	r.resolveDegradedCondition(agent, replicasAboveMaximumReason, "ReplicasWithinMaximum", fmt.Sprintf("The replicas are within the maximum %d of %s", max, source))
+	return nil
+}
@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"fmt"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// restartedAtAnnotation set on the agent, usually to the current time, restarts the agent
+// pods. Its value is copied to the pod template, so each new value rolls the pods once
+// through the operator's own rollout.
+const restartedAtAnnotation = "kubeagentic.ai/restartedAt"
+
+// podTemplateAnnotations returns the annotations of the agent pod template, including
+// spec.podAnnotations.
+func podTemplateAnnotations(agent *aiv1.Agent) map[string]string {
+	annotations := disruptionAnnotations(agent)
+	if restartedAt := agent.Annotations[restartedAtAnnotation]; restartedAt != "" {
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[restartedAtAnnotation] = restartedAt
+	}
+	return podAnnotations(agent, annotations)
+}
+
+// recordRestart records an Event when the desired pods carry a new restart request.
+func (r *AgentReconciler) recordRestart(agent *aiv1.Agent, desired, found *appsv1.Deployment) {
+	requested := desired.Spec.Template.Annotations[restartedAtAnnotation]
+	if requested == "" || requested == found.Spec.Template.Annotations[restartedAtAnnotation] {
+		return
+	}
+	r.recordEvent(agent, corev1.EventTypeNormal, "RestartRequested", fmt.Sprintf("Restarting the agent pods for the restart requested at %s", requested))
+}
@@ -0,0 +1,258 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// rollbackToAnnotation set to a revision number or hash on the agent restores the spec
+	// kept in that revision. The operator removes it once the spec is restored.
+	rollbackToAnnotation = "kubeagentic.ai/rollback-to"
+	// specHashLabel records the hash of the Agent spec kept in a ControllerRevision.
+	specHashLabel = "kubeagentic.ai/spec-hash"
+	// changeSummaryAnnotation describes how a revision differs from the preceding one.
+	changeSummaryAnnotation = "kubeagentic.ai/change-summary"
+	// revisionTimeAnnotation records when a revision was last made current.
+	revisionTimeAnnotation = "kubeagentic.ai/revision-time"
+
+	defaultRevisionHistoryLimit = 10
+)
+
+// revisionHistoryLimit returns the number of spec revisions kept for the agent.
+func revisionHistoryLimit(agent *aiv1.Agent) int {
+	if agent.Spec.RevisionHistoryLimit != nil {
+		return int(*agent.Spec.RevisionHistoryLimit)
+	}
+	return defaultRevisionHistoryLimit
+}
+
+// specRevisionData returns the serialized spec of the agent and its hash.
+func specRevisionData(agent *aiv1.Agent) ([]byte, string, error) {
+	data, err := json.Marshal(agent.Spec)
+	if err != nil {
+		return nil, "", err
+	}
+	sum := sha256.Sum256(data)
+	return data, hex.EncodeToString(sum[:])[:10], nil
+}
+
+// specChanges names the top-level spec fields that differ between two serialized specs.
+func specChanges(from, to []byte) []string {
+	var before, after map[string]json.RawMessage
+	if err := json.Unmarshal(from, &before); err != nil {
+		return nil
+	}
+	if err := json.Unmarshal(to, &after); err != nil {
+		return nil
+	}
+	var fields []string
+	for field, value := range after {
+		if !bytes.Equal(before[field], value) {
+			fields = append(fields, field)
+		}
+	}
+	for field := range before {
+		if _, ok := after[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// removeRevision returns the revisions without the one named name.
+func removeRevision(revisions []appsv1.ControllerRevision, name string) []appsv1.ControllerRevision {
+	kept := make([]appsv1.ControllerRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		if revision.Name != name {
+			kept = append(kept, revision)
+		}
+	}
+	return kept
+}
+
+// listSpecRevisions returns the ControllerRevisions kept for the agent, newest first.
+func (r *AgentReconciler) listSpecRevisions(ctx context.Context, agent *aiv1.Agent) ([]appsv1.ControllerRevision, error) {
+	var list appsv1.ControllerRevisionList
+	if err := r.List(ctx, &list, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return nil, err
+	}
+	revisions := make([]appsv1.ControllerRevision, 0, len(list.Items))
+	for _, revision := range list.Items {
+		if metav1.IsControlledBy(&revision, agent) {
+			revisions = append(revisions, revision)
+		}
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision > revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// reconcileRevisions keeps the agent spec as a ControllerRevision whenever it changes,
+// prunes the revisions beyond the history limit and lists them in the status. A spec
+// equal to an older revision, for example after a rollback, makes that revision current
+// again instead of adding one.
+func (r *AgentReconciler) reconcileRevisions(ctx context.Context, agent *aiv1.Agent) error {
+	data, hash, err := specRevisionData(agent)
+	if err != nil {
+		return err
+	}
+	revisions, err := r.listSpecRevisions(ctx, agent)
+	if err != nil {
+		return err
+	}
+
+	if len(revisions) == 0 || revisions[0].Labels[specHashLabel] != hash {
+		next := int64(1)
+		summary := "Initial revision"
+		if len(revisions) > 0 {
+			next = revisions[0].Revision + 1
+			summary = "Changed " + strings.Join(specChanges(revisions[0].Data.Raw, data), ", ")
+		}
+
+		var existing *appsv1.ControllerRevision
+		for i := range revisions {
+			if revisions[i].Labels[specHashLabel] == hash {
+				existing = &revisions[i]
+				break
+			}
+		}
+
+		now := time.Now().UTC().Format(time.RFC3339)
+		if existing != nil {
+			log.FromContext(ctx).Info("Restoring Agent spec revision", "revision", existing.Revision, "newRevision", next)
+			summary = fmt.Sprintf("Restored revision %d: %s", existing.Revision, strings.ToLower(summary[:1])+summary[1:])
+			existing.Revision = next
+			if existing.Annotations == nil {
+				existing.Annotations = map[string]string{}
+			}
+			existing.Annotations[changeSummaryAnnotation] = summary
+			existing.Annotations[revisionTimeAnnotation] = now
+			if err := r.Update(ctx, existing); err != nil {
+				return err
+			}
+			current := *existing
+			revisions = append([]appsv1.ControllerRevision{current}, removeRevision(revisions, current.Name)...)
+		} else {
+			revision := &appsv1.ControllerRevision{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      fmt.Sprintf("%s-%s", agent.Name, hash),
+					Namespace: agent.Namespace,
+					Labels: map[string]string{
+						"kubeagentic.ai/agent": agent.Name,
+						specHashLabel:          hash,
+					},
+					Annotations: map[string]string{
+						changeSummaryAnnotation: summary,
+						revisionTimeAnnotation:  now,
+					},
+				},
+				Data:     runtime.RawExtension{Raw: data},
+				Revision: next,
+			}
+			if err := controllerutil.SetControllerReference(agent, revision, r.Scheme); err != nil {
+				return err
+			}
+			log.FromContext(ctx).Info("Creating Agent spec revision", "ControllerRevision.Name", revision.Name, "revision", next)
+			// The cache may not list a revision created by the previous reconciliation yet.
+			if err := r.Create(ctx, revision); err != nil && !errors.IsAlreadyExists(err) {
+				return err
+			}
+			revisions = append([]appsv1.ControllerRevision{*revision}, revisions...)
+		}
+	}
+
+	limit := revisionHistoryLimit(agent)
+	for i := limit; i < len(revisions); i++ {
+		log.FromContext(ctx).Info("Pruning Agent spec revision", "ControllerRevision.Name", revisions[i].Name, "revision", revisions[i].Revision)
+		if err := r.Delete(ctx, &revisions[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	if len(revisions) > limit {
+		revisions = revisions[:limit]
+	}
+
+	agent.Status.Revisions = make([]aiv1.AgentRevision, 0, len(revisions))
+	for _, revision := range revisions {
+		created := revision.CreationTimestamp
+		if t, err := time.Parse(time.RFC3339, revision.Annotations[revisionTimeAnnotation]); err == nil {
+			created = metav1.NewTime(t)
+		}
+		agent.Status.Revisions = append(agent.Status.Revisions, aiv1.AgentRevision{
+			Revision:      revision.Revision,
+			Hash:          revision.Labels[specHashLabel],
+			Time:          created,
+			ChangeSummary: revision.Annotations[changeSummaryAnnotation],
+		})
+	}
+	return nil
+}
+
+// rollbackToRevision restores the spec kept in the revision named by the rollback-to
+// annotation. The restored spec is admitted like any other update, so a revision the
+// webhook now rejects is reported in an Event instead. It returns true once the spec
+// was restored; the update triggers the next reconciliation.
+func (r *AgentReconciler) rollbackToRevision(ctx context.Context, agent *aiv1.Agent) (bool, error) {
+	target, ok := agent.Annotations[rollbackToAnnotation]
+	if !ok {
+		return false, nil
+	}
+	revisions, err := r.listSpecRevisions(ctx, agent)
+	if err != nil {
+		return false, err
+	}
+
+	var found *appsv1.ControllerRevision
+	number, numberErr := strconv.ParseInt(target, 10, 64)
+	for i := range revisions {
+		if (numberErr == nil && revisions[i].Revision == number) || revisions[i].Labels[specHashLabel] == target {
+			found = &revisions[i]
+			break
+		}
+	}
+	if found == nil {
+		r.recordEvent(agent, corev1.EventTypeWarning, "RollbackFailed", fmt.Sprintf("Revision %q is not kept", target))
+		return false, r.clearAnnotation(ctx, agent, rollbackToAnnotation)
+	}
+
+	var spec aiv1.AgentSpec
+	if err := json.Unmarshal(found.Data.Raw, &spec); err != nil {
+		return false, fmt.Errorf("failed to read revision %d: %w", found.Revision, err)
+	}
+	updated := agent.DeepCopy()
+	updated.Spec = spec
+	delete(updated.Annotations, rollbackToAnnotation)
+	if err := r.Update(ctx, updated); err != nil {
+		if !errors.IsInvalid(err) && !errors.IsForbidden(err) {
+			return false, err
+		}
+		r.recordEvent(agent, corev1.EventTypeWarning, "RollbackFailed", fmt.Sprintf("Revision %d was rejected: %v", found.Revision, err))
+		return false, r.clearAnnotation(ctx, agent, rollbackToAnnotation)
+	}
+
+	log.FromContext(ctx).Info("Restored Agent spec revision", "revision", found.Revision)
+	r.recordEvent(agent, corev1.EventTypeNormal, "RolledBack", fmt.Sprintf("Restored the spec of revision %d", found.Revision))
+	return true, nil
+}
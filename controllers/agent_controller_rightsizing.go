@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/rightsizing"
+)
+
+const (
+	// applyRecommendationsAnnotation copies status.recommendations into the agent container
+	// when set to "true".
+	applyRecommendationsAnnotation = "kubeagentic.ai/apply-recommendations"
+
+	// usageWindow is the time covered by the usage samples of an agent.
+	usageWindow = 24 * time.Hour
+	// usageSampleInterval is the minimum time between two samples, close to the resolution
+	// of metrics-server.
+	usageSampleInterval = time.Minute
+	// usageExceedsFactor is how many times its requests the usage must reach to set the
+	// UsageExceedsRequests condition.
+	usageExceedsFactor = 2.0
+)
+
+// podMetricsListGVK is read as unstructured objects, so the operator does not depend on the
+// metrics API types; unstructured reads bypass the cache, which cannot watch PodMetrics.
+var podMetricsListGVK = schema.GroupVersionKind{Group: "metrics.k8s.io", Version: "v1beta1", Kind: "PodMetricsList"}
+
+// usageRecorder keeps the recent usage samples of the agent containers in memory. The
+// samples are lost when the operator restarts, which delays recommendations by MinSamples
+// sample intervals.
+type usageRecorder struct {
+	mu      sync.Mutex
+	windows map[types.NamespacedName]*rightsizing.Window
+	// unavailable is set while the metrics API is not served, to log it once.
+	unavailable bool
+}
+
+func (u *usageRecorder) window(key types.NamespacedName) *rightsizing.Window {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.windows == nil {
+		u.windows = map[types.NamespacedName]*rightsizing.Window{}
+	}
+	if u.windows[key] == nil {
+		u.windows[key] = &rightsizing.Window{Length: usageWindow}
+	}
+	return u.windows[key]
+}
+
+func (u *usageRecorder) forget(key types.NamespacedName) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.windows, key)
+}
+
+// setUnavailable records whether the metrics API is served and reports whether that changed.
+func (u *usageRecorder) setUnavailable(unavailable bool) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	changed := u.unavailable != unavailable
+	u.unavailable = unavailable
+	return changed
+}
+
+// +kubebuilder:rbac:groups=metrics.k8s.io,resources=pods,verbs=get;list
+
+// applyRecommendations returns resources with the recommended CPU and memory requests and
+// limits when the agent carries the apply annotation.
+func applyRecommendations(agent *aiv1.Agent, resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	recommendations := agent.Status.Recommendations
+	if agent.Annotations[applyRecommendationsAnnotation] != "true" || recommendations == nil {
+		return resources
+	}
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+	for name, quantity := range recommendations.Requests {
+		resources.Requests[name] = quantity
+	}
+	for name, quantity := range recommendations.Limits {
+		resources.Limits[name] = quantity
+	}
+	return resources
+}
+
+// reconcileRecommendations samples the usage of the agent containers from the metrics API
+// and publishes the recommended requests and limits. Recommendations only change when a
+// value moves by more than 10%, so that applied recommendations do not roll the pods on
+// every fluctuation. Clusters without metrics-server are skipped.
+func (r *AgentReconciler) reconcileRecommendations(ctx context.Context, agent *aiv1.Agent) error {
+	key := types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace}
+	window := r.usage.window(key)
+	now := r.clock().Now()
+	if now.Sub(window.Last()) >= usageSampleInterval {
+		samples, err := r.sampleUsage(ctx, agent, now)
+		if isMetricsUnavailable(err) {
+			if r.usage.setUnavailable(true) {
+				log.FromContext(ctx).Info("Metrics API not available, skipping resource recommendations", "error", err.Error())
+			}
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if r.usage.setUnavailable(false) {
+			log.FromContext(ctx).Info("Metrics API available, sampling agent resource usage")
+		}
+		window.Add(samples...)
+	}
+
+	samples := window.Samples()
+	recommendation, ok := rightsizing.Recommend(samples)
+	if !ok {
+		return nil
+	}
+	current := agent.Status.Recommendations
+	if current == nil || recommendationDiffers(current, recommendation) {
+		current = &aiv1.ResourceRecommendations{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    *resource.NewMilliQuantity(recommendation.CPURequestMilli, resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(recommendation.MemoryRequestBytes, resource.BinarySI),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    *resource.NewMilliQuantity(recommendation.CPULimitMilli, resource.DecimalSI),
+				corev1.ResourceMemory: *resource.NewQuantity(recommendation.MemoryLimitBytes, resource.BinarySI),
+			},
+			LastUpdated: metav1.NewTime(now),
+		}
+	}
+	current.Samples = int32(len(samples))
+	current.WindowStart = metav1.NewTime(samples[0].Time)
+	agent.Status.Recommendations = current
+
+	r.setUsageCondition(agent, rightsizing.Summarize(samples))
+	return nil
+}
+
+// recommendationDiffers reports whether a value of recommendation moved significantly from
+// the published recommendations.
+func recommendationDiffers(current *aiv1.ResourceRecommendations, recommendation rightsizing.Recommendation) bool {
+	return rightsizing.Differs(current.Requests.Cpu().MilliValue(), recommendation.CPURequestMilli) ||
+		rightsizing.Differs(current.Requests.Memory().Value(), recommendation.MemoryRequestBytes) ||
+		rightsizing.Differs(current.Limits.Cpu().MilliValue(), recommendation.CPULimitMilli) ||
+		rightsizing.Differs(current.Limits.Memory().Value(), recommendation.MemoryLimitBytes)
+}
+
+// setUsageCondition sets the UsageExceedsRequests condition when the 95th percentile of the
+// usage is far above the requests of the agent container.
+func (r *AgentReconciler) setUsageCondition(agent *aiv1.Agent, usage rightsizing.Usage) {
+	requests := agentResources(agent).Requests
+	cpuRequest, memoryRequest := requests.Cpu().MilliValue(), requests.Memory().Value()
+
+	condition := aiv1.AgentCondition{
+		Type:    aiv1.AgentConditionUsageExceedsRequests,
+		Status:  corev1.ConditionFalse,
+		Reason:  "UsageWithinRequests",
+		Message: "The usage of the agent pods is within their requests",
+	}
+	switch {
+	case rightsizing.Exceeds(usage.MemoryP95Bytes, memoryRequest, usageExceedsFactor):
+		condition.Status, condition.Reason = corev1.ConditionTrue, "MemoryUsageExceedsRequests"
+		condition.Message = fmt.Sprintf("The 95th percentile of the memory usage, %s, is more than %g times the %s request; see status.recommendations",
+			resource.NewQuantity(usage.MemoryP95Bytes, resource.BinarySI), usageExceedsFactor, requests.Memory())
+	case rightsizing.Exceeds(usage.CPUP95Milli, cpuRequest, usageExceedsFactor):
+		condition.Status, condition.Reason = corev1.ConditionTrue, "CPUUsageExceedsRequests"
+		condition.Message = fmt.Sprintf("The 95th percentile of the CPU usage, %s, is more than %g times the %s request; see status.recommendations",
+			resource.NewMilliQuantity(usage.CPUP95Milli, resource.DecimalSI), usageExceedsFactor, requests.Cpu())
+	}
+	now := metav1.Now()
+	condition.LastTransitionTime = &now
+	agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, condition)
+}
+
+// sampleUsage returns the usage of the agent container of each agent pod.
+func (r *AgentReconciler) sampleUsage(ctx context.Context, agent *aiv1.Agent, now time.Time) ([]rightsizing.Sample, error) {
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(podMetricsListGVK)
+	if err := r.List(ctx, list, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return nil, err
+	}
+
+	var samples []rightsizing.Sample
+	for _, item := range list.Items {
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+		for _, c := range containers {
+			container, ok := c.(map[string]interface{})
+			if !ok || container["name"] != "agent" {
+				continue
+			}
+			cpu, _, _ := unstructured.NestedString(container, "usage", "cpu")
+			memory, _, _ := unstructured.NestedString(container, "usage", "memory")
+			cpuQuantity, err := resource.ParseQuantity(cpu)
+			if err != nil {
+				continue
+			}
+			memoryQuantity, err := resource.ParseQuantity(memory)
+			if err != nil {
+				continue
+			}
+			samples = append(samples, rightsizing.Sample{Time: now, CPUMilli: cpuQuantity.MilliValue(), MemoryBytes: memoryQuantity.Value()})
+		}
+	}
+	return samples, nil
+}
+
+// isMetricsUnavailable reports whether err means that the metrics API is not served.
+func isMetricsUnavailable(err error) bool {
+	return err != nil && (meta.IsNoMatchError(err) || apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err))
+}
@@ -0,0 +1,535 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentmetrics"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	// templateHashAnnotation records the hash of the desired pod template on the agent
+	// Deployments, so that changes can be detected without comparing defaulted fields.
+	templateHashAnnotation = "kubeagentic.ai/template-hash"
+	// trackLabel distinguishes the canary pods from the stable pods. Both carry the agent
+	// labels selected by the agent Service.
+	trackLabel = "kubeagentic.ai/track"
+	// canaryTrack is the trackLabel value of the canary pods.
+	canaryTrack = "canary"
+	// promoteAnnotation set to "true" on the agent promotes the current canary or preview.
+	// The operator removes it once the change is promoted.
+	promoteAnnotation = "kubeagentic.ai/promote"
+	// abortAnnotation set to "true" on the agent aborts the current canary or preview. The
+	// operator removes it once the change is aborted.
+	abortAnnotation = "kubeagentic.ai/abort"
+
+	// rolloutStrategyBlueGreen selects the blue/green rollout strategy.
+	rolloutStrategyBlueGreen = "blueGreen"
+
+	// canaryReadyRequeue is how often a rollout waiting for canary pods is checked.
+	canaryReadyRequeue = 15 * time.Second
+)
+
+// canaryEnabled reports whether changes to the agent pods are rolled out through a canary.
+func canaryEnabled(agent *aiv1.Agent) bool {
+	rollout := agent.Spec.Rollout
+	return rollout != nil && rollout.Strategy != rolloutStrategyBlueGreen && rollout.Canary != nil && len(rollout.Canary.Steps) > 0
+}
+
+// blueGreenEnabled reports whether changes to the agent pods are rolled out blue/green.
+func blueGreenEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Rollout != nil && agent.Spec.Rollout.Strategy == rolloutStrategyBlueGreen
+}
+
+// validateRolloutConfig checks that the configured rollout strategy has its settings.
+func (r *AgentReconciler) validateRolloutConfig(agent *aiv1.Agent) error {
+	rollout := agent.Spec.Rollout
+	if rollout == nil {
+		return nil
+	}
+	if rollout.Strategy == rolloutStrategyBlueGreen {
+		if rollout.Canary != nil {
+			return fmt.Errorf("rollout.canary cannot be combined with the blueGreen strategy")
+		}
+		if rollout.AutoRollback != nil && rollout.AutoRollback.Enabled {
+			return fmt.Errorf("rollout.autoRollback cannot be combined with the blueGreen strategy; abort the promoted revision within the scale-down delay instead")
+		}
+		return nil
+	}
+	if rollout.BlueGreen != nil {
+		return fmt.Errorf("rollout.blueGreen requires the blueGreen strategy")
+	}
+	// Without a strategy, automatic rollback monitors changes rolled out in place.
+	if rollout.Strategy == "" && rollout.Canary == nil && rollout.AutoRollback != nil {
+		return nil
+	}
+	if rollout.Canary == nil || len(rollout.Canary.Steps) == 0 {
+		return fmt.Errorf("rollout.canary.steps is required for the canary strategy")
+	}
+	return nil
+}
+
+// annotationSet reports whether the boolean annotation key is set to "true" on the agent.
+func annotationSet(agent *aiv1.Agent, key string) bool {
+	return agent.Annotations[key] == "true"
+}
+
+// canaryDeploymentName returns the name of the agent's canary Deployment.
+func canaryDeploymentName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "canary")
+}
+
+// podTemplateHash returns a short hash identifying a pod template.
+func podTemplateHash(template *corev1.PodTemplateSpec) string {
+	data, _ := json.Marshal(template)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+// splitReplicas splits the agent replicas between a new variant, such as a canary, receiving
+// weight percent of the pods and the stable Deployment. The new variant always runs at least
+// one pod, and the stable Deployment keeps at least one pod until the weight reaches 100.
+func splitReplicas(total, weight int32) (variant, stable int32) {
+	variant = int32(math.Ceil(float64(total) * float64(weight) / 100))
+	if variant < 1 {
+		variant = 1
+	}
+	if variant > total {
+		variant = total
+	}
+	stable = total - variant
+	if stable < 1 && weight < 100 {
+		stable = 1
+	}
+	return variant, stable
+}
+
+// percentOf returns part as a percentage of total, rounded down.
+func percentOf(part, total int32) int32 {
+	if total == 0 {
+		return 0
+	}
+	return part * 100 / total
+}
+
+// reconcileRollout reconciles the stable Deployment found against the desired Deployment.
+// When the pod template changed and a rollout strategy is configured, the change runs in a
+// canary or preview Deployment and the stable pods keep their template until it is
+// promoted. It returns false when the stable Deployment should be updated as usual.
+func (r *AgentReconciler) reconcileRollout(ctx context.Context, agent *aiv1.Agent, desired, found *appsv1.Deployment) (bool, error) {
+	if blueGreenEnabled(agent) {
+		if err := r.deleteCanary(ctx, agent); err != nil {
+			return true, err
+		}
+		return r.reconcileBlueGreen(ctx, agent, desired, found)
+	}
+	if err := r.deletePreview(ctx, agent); err != nil {
+		return true, err
+	}
+	return r.reconcileCanary(ctx, agent, desired, found)
+}
+
+// reconcileCanary runs changes to the pod template through the canary steps.
+func (r *AgentReconciler) reconcileCanary(ctx context.Context, agent *aiv1.Agent, desired, found *appsv1.Deployment) (bool, error) {
+	revision := desired.Annotations[templateHashAnnotation]
+	stableRevision := found.Annotations[templateHashAnnotation]
+
+	// Deployments created before the template hash was recorded are updated in place once.
+	if !canaryEnabled(agent) || stableRevision == "" || stableRevision == revision {
+		if !canaryEnabled(agent) {
+			agent.Status.Rollout = nil
+		} else if rollout := agent.Status.Rollout; rollout != nil {
+			rollout.StableRevision = revision
+			if rollout.Phase != aiv1.RolloutPromoted {
+				rollout.Phase = aiv1.RolloutPromoted
+				rollout.Message = "Stable pods run the desired revision"
+			}
+			rollout.CanaryWeight, rollout.StableWeight = 0, 100
+			rollout.StepStartTime, rollout.StepStartCounters = nil, nil
+		}
+		return false, r.deleteCanary(ctx, agent)
+	}
+
+	rollout := agent.Status.Rollout
+	if rollout == nil || rollout.CanaryRevision != revision {
+		log.FromContext(ctx).Info("Starting canary rollout", "revision", revision, "stableRevision", stableRevision)
+		rollout = &aiv1.RolloutStatus{
+			Phase:          aiv1.RolloutProgressing,
+			StableRevision: stableRevision,
+			CanaryRevision: revision,
+		}
+		agent.Status.Rollout = rollout
+	}
+
+	if annotationSet(agent, abortAnnotation) {
+		if err := r.clearAnnotation(ctx, agent, abortAnnotation); err != nil {
+			return true, err
+		}
+		if rollout.Phase != aiv1.RolloutAborted {
+			return true, r.abortCanary(ctx, agent, found, "Aborted manually")
+		}
+	}
+	if annotationSet(agent, promoteAnnotation) {
+		if err := r.clearAnnotation(ctx, agent, promoteAnnotation); err != nil {
+			return true, err
+		}
+		return true, r.promoteCanary(ctx, agent, desired, found, "Promoted manually")
+	}
+
+	if rollout.Phase == aiv1.RolloutAborted {
+		// Keep the stable pods serving all traffic until the spec changes again.
+		return true, r.abortCanary(ctx, agent, found, rollout.Message)
+	}
+
+	steps := agent.Spec.Rollout.Canary.Steps
+	if int(rollout.Step) >= len(steps) {
+		return true, r.promoteCanary(ctx, agent, desired, found, "All canary steps passed")
+	}
+	step := steps[rollout.Step]
+
+	total := *desired.Spec.Replicas
+	canaryCount, stableCount := splitReplicas(total, step.Weight)
+	canary, err := r.reconcileCanaryDeployment(ctx, agent, desired, canaryCount)
+	if err != nil {
+		return true, err
+	}
+	if *found.Spec.Replicas != stableCount {
+		log.FromContext(ctx).Info("Scaling stable Deployment for canary step", "step", rollout.Step, "replicas", stableCount)
+		found.Spec.Replicas = &stableCount
+		if err := r.Update(ctx, found); err != nil {
+			return true, err
+		}
+	}
+	rollout.CanaryWeight = percentOf(canaryCount, canaryCount+stableCount)
+	rollout.StableWeight = 100 - rollout.CanaryWeight
+
+	if canary.Status.ReadyReplicas < canaryCount || canary.Status.UpdatedReplicas < canaryCount {
+		rollout.Phase = aiv1.RolloutProgressing
+		rollout.Message = fmt.Sprintf("Waiting for canary pods of step %d (%d/%d ready)", rollout.Step, canary.Status.ReadyReplicas, canaryCount)
+		rollout.StepStartTime, rollout.StepStartCounters = nil, nil
+		return true, nil
+	}
+
+	if rollout.StepStartTime == nil {
+		now := metav1.Now()
+		rollout.StepStartTime = &now
+		rollout.StepStartCounters = nil
+		if sample, err := r.scrapeCanary(ctx, agent); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to scrape canary metrics at step start")
+		} else {
+			rollout.StepStartCounters = requestCounters(sample)
+		}
+	}
+
+	if step.Pause == nil {
+		rollout.Phase = aiv1.RolloutPaused
+		rollout.Message = fmt.Sprintf("Step %d at weight %d%% waits for promotion", rollout.Step, step.Weight)
+		return true, nil
+	}
+
+	rollout.Phase = aiv1.RolloutProgressing
+	if remaining := time.Until(rollout.StepStartTime.Add(step.Pause.Duration)); remaining > 0 {
+		rollout.Message = fmt.Sprintf("Running step %d at weight %d%%", rollout.Step, step.Weight)
+		return true, nil
+	}
+
+	passed, conclusive, err := r.evaluateCanaryGates(ctx, agent)
+	if err != nil {
+		log.FromContext(ctx).Error(err, "Failed to evaluate canary gates")
+		rollout.Message = fmt.Sprintf("Gate evaluation of step %d failed: %v", rollout.Step, err)
+		return true, nil
+	}
+	if !conclusive {
+		rollout.Message = fmt.Sprintf("Step %d waits for %d requests before evaluating the gates", rollout.Step, agent.Spec.Rollout.Canary.Gates.MinRequests)
+		return true, nil
+	}
+	if !passed {
+		return true, r.abortCanary(ctx, agent, found, fmt.Sprintf("Step %d failed the canary gates", rollout.Step))
+	}
+
+	log.FromContext(ctx).Info("Canary step passed", "step", rollout.Step, "weight", step.Weight)
+	rollout.Step++
+	rollout.StepStartTime, rollout.StepStartCounters = nil, nil
+	if int(rollout.Step) >= len(steps) {
+		return true, r.promoteCanary(ctx, agent, desired, found, "All canary steps passed")
+	}
+	rollout.Message = fmt.Sprintf("Advancing to step %d", rollout.Step)
+	return true, nil
+}
+
+// buildCanaryDeployment returns the canary Deployment running the desired pod template.
+func buildCanaryDeployment(agent *aiv1.Agent, desired *appsv1.Deployment, replicas int32) *appsv1.Deployment {
+	canary := desired.DeepCopy()
+	canary.Name = canaryDeploymentName(agent)
+	canary.ResourceVersion = ""
+	canary.Spec.Replicas = &replicas
+
+	selector := map[string]string{trackLabel: canaryTrack}
+	for k, v := range desired.Spec.Selector.MatchLabels {
+		selector[k] = v
+	}
+	canary.Labels = selector
+	canary.Spec.Selector = &metav1.LabelSelector{MatchLabels: selector}
+
+	templateLabels := map[string]string{trackLabel: canaryTrack}
+	for k, v := range desired.Spec.Template.Labels {
+		templateLabels[k] = v
+	}
+	canary.Spec.Template.Labels = templateLabels
+	return canary
+}
+
+// reconcileCanaryDeployment creates or updates the canary Deployment and returns it.
+func (r *AgentReconciler) reconcileCanaryDeployment(ctx context.Context, agent *aiv1.Agent, desired *appsv1.Deployment, replicas int32) (*appsv1.Deployment, error) {
+	canary := buildCanaryDeployment(agent, desired, replicas)
+	if err := controllerutil.SetControllerReference(agent, canary, r.Scheme); err != nil {
+		return nil, err
+	}
+
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: canary.Name, Namespace: canary.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new canary Deployment", "Deployment.Namespace", canary.Namespace, "Deployment.Name", canary.Name)
+		return canary, r.Create(ctx, canary)
+	} else if err != nil {
+		return nil, err
+	}
+
+	if found.Annotations[templateHashAnnotation] == canary.Annotations[templateHashAnnotation] && *found.Spec.Replicas == replicas {
+		return found, nil
+	}
+	log.FromContext(ctx).Info("Updating existing canary Deployment", "Deployment.Namespace", found.Namespace, "Deployment.Name", found.Name)
+	found.Annotations = canary.Annotations
+	found.Spec = canary.Spec
+	return found, r.Update(ctx, found)
+}
+
+// deleteCanary removes the canary Deployment, if any.
+func (r *AgentReconciler) deleteCanary(ctx context.Context, agent *aiv1.Agent) error {
+	canary := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: canaryDeploymentName(agent), Namespace: agent.Namespace}, canary, cacheOnly)
+	if err == nil {
+		log.FromContext(ctx).Info("Deleting canary Deployment", "Deployment.Name", canary.Name)
+		return client.IgnoreNotFound(r.Delete(ctx, canary))
+	}
+	return client.IgnoreNotFound(err)
+}
+
+// promoteCanary rolls the desired pod template out to the stable Deployment and removes
+// the canary.
+func (r *AgentReconciler) promoteCanary(ctx context.Context, agent *aiv1.Agent, desired, found *appsv1.Deployment, message string) error {
+	rollout := agent.Status.Rollout
+	log.FromContext(ctx).Info("Promoting canary", "revision", rollout.CanaryRevision, "reason", message)
+	found.Annotations = desired.Annotations
+	found.Spec = desired.Spec
+	if err := r.Update(ctx, found); err != nil {
+		return err
+	}
+	rollout.Phase = aiv1.RolloutPromoted
+	rollout.StableRevision = rollout.CanaryRevision
+	rollout.CanaryWeight, rollout.StableWeight = 0, 100
+	rollout.StepStartTime, rollout.StepStartCounters = nil, nil
+	rollout.Message = message
+	return r.deleteCanary(ctx, agent)
+}
+
+// abortCanary removes the canary and scales the stable Deployment back to all replicas.
+// The stable pod template is left untouched, so the aborted revision is not retried until
+// the spec changes.
+func (r *AgentReconciler) abortCanary(ctx context.Context, agent *aiv1.Agent, found *appsv1.Deployment, message string) error {
+	rollout := agent.Status.Rollout
+	if rollout.Phase != aiv1.RolloutAborted {
+		log.FromContext(ctx).Info("Aborting canary", "revision", rollout.CanaryRevision, "reason", message)
+	}
+	rollout.Phase = aiv1.RolloutAborted
+	rollout.CanaryWeight, rollout.StableWeight = 0, 100
+	rollout.StepStartTime, rollout.StepStartCounters = nil, nil
+	rollout.Message = message
+	if err := r.deleteCanary(ctx, agent); err != nil {
+		return err
+	}
+
+	replicas := int32(1)
+	if agent.Spec.Replicas != nil {
+		replicas = *agent.Spec.Replicas
+	}
+	if *found.Spec.Replicas == replicas {
+		return nil
+	}
+	found.Spec.Replicas = &replicas
+	return r.Update(ctx, found)
+}
+
+// clearAnnotation removes an annotation from the agent. The agent keeps its in-memory
+// status, which is written at the end of the reconciliation.
+func (r *AgentReconciler) clearAnnotation(ctx context.Context, agent *aiv1.Agent, key string) error {
+	updated := agent.DeepCopy()
+	delete(updated.Annotations, key)
+	if err := r.Update(ctx, updated); err != nil {
+		return err
+	}
+	agent.Annotations = updated.Annotations
+	agent.ResourceVersion = updated.ResourceVersion
+	return nil
+}
+
+// scrapeCanary returns the summed request counters of the running canary pods.
+func (r *AgentReconciler) scrapeCanary(ctx context.Context, agent *aiv1.Agent) (agentmetrics.Sample, error) {
+	return r.scrapeAgentPods(ctx, agent, labels.SelectorFromSet(labels.Set{
+		"kubeagentic.ai/agent": agent.Name,
+		trackLabel:             canaryTrack,
+	}))
+}
+
+// metricsScraper returns the scraper reading the metrics of the agent pods.
+func (r *AgentReconciler) metricsScraper() *agentmetrics.Scraper {
+	if r.MetricsScraper != nil {
+		return r.MetricsScraper
+	}
+	return &agentmetrics.Scraper{}
+}
+
+// scrapeAgentPods returns the summed request counters of the running pods matching selector.
+func (r *AgentReconciler) scrapeAgentPods(ctx context.Context, agent *aiv1.Agent, selector labels.Selector) (agentmetrics.Sample, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabelsSelector{Selector: selector}); err != nil {
+		return agentmetrics.Sample{}, err
+	}
+
+	token, err := r.endpointToken(ctx, agent)
+	if err != nil {
+		return agentmetrics.Sample{}, err
+	}
+
+	scraper := r.metricsScraper()
+	var total agentmetrics.Sample
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		sample, err := scraper.Scrape(ctx, fmt.Sprintf("http://%s:8080", pod.Status.PodIP), token)
+		if err != nil {
+			return agentmetrics.Sample{}, fmt.Errorf("failed to scrape pod %s: %w", pod.Name, err)
+		}
+		total = total.Add(sample)
+	}
+	return total, nil
+}
+
+// evaluateCanaryGates compares the requests the canary pods handled during the current step
+// with the configured gates and records the results in the rollout status. The evaluation
+// is inconclusive while fewer than gates.minRequests requests were handled.
+func (r *AgentReconciler) evaluateCanaryGates(ctx context.Context, agent *aiv1.Agent) (passed, conclusive bool, err error) {
+	rollout := agent.Status.Rollout
+	gates := agent.Spec.Rollout.Canary.Gates
+	if gates == nil || (gates.MaxErrorPercent == nil && gates.MaxAverageLatency == nil) {
+		rollout.Gates = nil
+		return true, true, nil
+	}
+
+	current, err := r.scrapeCanary(ctx, agent)
+	if err != nil {
+		return false, false, err
+	}
+	delta := current
+	if start := rollout.StepStartCounters; start != nil {
+		delta = current.Sub(counterSample(start))
+	}
+	if delta.Requests < float64(gates.MinRequests) {
+		return false, false, nil
+	}
+
+	passed = true
+	rollout.Gates = nil
+	if gates.MaxErrorPercent != nil {
+		errorPercent := delta.ErrorRate() * 100
+		ok := errorPercent <= float64(*gates.MaxErrorPercent)
+		passed = passed && ok
+		rollout.Gates = append(rollout.Gates, aiv1.GateResult{
+			Name:      "errorRate",
+			Value:     fmt.Sprintf("%.2f%%", errorPercent),
+			Threshold: fmt.Sprintf("%d%%", *gates.MaxErrorPercent),
+			Passed:    ok,
+		})
+	}
+	if gates.MaxAverageLatency != nil {
+		latency := delta.AverageLatency()
+		ok := latency <= gates.MaxAverageLatency.Duration
+		passed = passed && ok
+		rollout.Gates = append(rollout.Gates, aiv1.GateResult{
+			Name:      "averageLatency",
+			Value:     latency.Round(time.Millisecond).String(),
+			Threshold: gates.MaxAverageLatency.Duration.String(),
+			Passed:    ok,
+		})
+	}
+	return passed, true, nil
+}
+
+// requestCounters converts a scraped sample to the counters kept in the rollout status.
+func requestCounters(sample agentmetrics.Sample) *aiv1.RequestCounters {
+	return &aiv1.RequestCounters{
+		Requests:            int64(sample.Requests),
+		Errors:              int64(sample.Errors),
+		LatencyMilliseconds: int64(sample.LatencySum * 1000),
+		Tokens:              int64(sample.Tokens),
+	}
+}
+
+// counterSample converts counters kept in the status back to a sample. Every request has an
+// observed duration, so the latency count equals the requests.
+func counterSample(counters *aiv1.RequestCounters) agentmetrics.Sample {
+	return agentmetrics.Sample{
+		Requests:     float64(counters.Requests),
+		Errors:       float64(counters.Errors),
+		LatencySum:   float64(counters.LatencyMilliseconds) / 1000,
+		LatencyCount: float64(counters.Requests),
+		Tokens:       float64(counters.Tokens),
+	}
+}
+
+// rolloutRequeueAfter shortens the periodic requeue so that canary steps advance and
+// blue/green scale-downs happen on time.
+func rolloutRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	requeue = autoRollbackRequeueAfter(agent, requeue)
+	if blueGreenEnabled(agent) {
+		return blueGreenRequeueAfter(agent, requeue)
+	}
+	rollout := agent.Status.Rollout
+	if !canaryEnabled(agent) || rollout == nil || rollout.Phase != aiv1.RolloutProgressing {
+		return requeue
+	}
+	if rollout.StepStartTime == nil {
+		return canaryReadyRequeue
+	}
+	steps := agent.Spec.Rollout.Canary.Steps
+	if int(rollout.Step) >= len(steps) || steps[rollout.Step].Pause == nil {
+		return requeue
+	}
+	remaining := time.Until(rollout.StepStartTime.Add(steps[rollout.Step].Pause.Duration))
+	if remaining <= 0 {
+		// The step is waiting for enough requests or for a successful scrape.
+		return canaryReadyRequeue
+	}
+	if remaining < requeue {
+		return remaining
+	}
+	return requeue
+}
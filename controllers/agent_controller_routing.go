@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+// defaultConversationHeader is the request header hashed when none is configured.
+const defaultConversationHeader = "X-Conversation-Id"
+
+// routerConfigChecksumAnnotation records a hash of the router configuration on the router
+// pod template so that configuration changes roll the router pods.
+const routerConfigChecksumAnnotation = "kubeagentic.ai/router-config-checksum"
+
+var headerNamePattern = regexp.MustCompile(`^[A-Za-z0-9-]+$`)
+
+// routerConfigTemplate is the Envoy bootstrap of the conversation router. The agent pods are
+// discovered through the headless Service and balanced with Maglev, a consistent hash that
+// only remaps the conversations of the added or removed replicas on scale events. The pods
+// are reached on the port of their rate limit proxy when they have one.
+const routerConfigTemplate = `admin:
+  address:
+    socket_address: { address: 0.0.0.0, port_value: 9901 }
+static_resources:
+  listeners:
+  - name: agent
+    address:
+      socket_address: { address: 0.0.0.0, port_value: 8080 }
+    filter_chains:
+    - filters:
+      - name: envoy.filters.network.http_connection_manager
+        typed_config:
+          "@type": type.googleapis.com/envoy.extensions.filters.network.http_connection_manager.v3.HttpConnectionManager
+          stat_prefix: agent
+          route_config:
+            virtual_hosts:
+            - name: agent
+              domains: ["*"]
+              routes:
+              - match: { prefix: "/" }
+                route:
+                  cluster: agent
+                  timeout: 0s
+                  hash_policy:
+                  - header:
+                      header_name: %s
+          http_filters:
+          - name: envoy.filters.http.router
+            typed_config:
+              "@type": type.googleapis.com/envoy.extensions.filters.http.router.v3.Router
+  clusters:
+  - name: agent
+    type: STRICT_DNS
+    dns_refresh_rate: 5s
+    lb_policy: MAGLEV
+    load_assignment:
+      cluster_name: agent
+      endpoints:
+      - lb_endpoints:
+        - endpoint:
+            address:
+              socket_address: { address: %s, port_value: %d }
+`
+
+// conversationAffinityEnabled reports whether the agent is served through the conversation router.
+func conversationAffinityEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Routing != nil && agent.Spec.Routing.ConversationAffinity != nil &&
+		agent.Spec.Routing.ConversationAffinity.Enabled
+}
+
+// conversationHeader returns the request header identifying the conversation.
+func conversationHeader(agent *aiv1.Agent) string {
+	if header := agent.Spec.Routing.ConversationAffinity.Header; header != "" {
+		return header
+	}
+	return defaultConversationHeader
+}
+
+// routerName returns the name shared by the router Deployment and ConfigMap.
+func routerName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "router")
+}
+
+// headlessServiceName returns the name of the Service resolving to the individual agent pods.
+func headlessServiceName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "headless")
+}
+
+// routerLabels returns the labels of the router pods. They must not match the agent
+// Deployment selector, so that the agent Service can target the router instead.
+func routerLabels(agent *aiv1.Agent) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-router",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "router",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+}
+
+// validateRoutingConfig validates the conversation affinity configuration.
+func (r *AgentReconciler) validateRoutingConfig(ctx context.Context, agent *aiv1.Agent) error {
+	if !conversationAffinityEnabled(agent) {
+		return nil
+	}
+
+	if backend := memoryBackend(agent); backend != "inmemory" {
+		return fmt.Errorf("routing.conversationAffinity is unnecessary with the shared %s memory backend", backend)
+	}
+	if !headerNamePattern.MatchString(conversationHeader(agent)) {
+		return fmt.Errorf("invalid routing.conversationAffinity.header: %s", conversationHeader(agent))
+	}
+	return nil
+}
+
+// buildRouterConfig renders the Envoy configuration hashing the conversation header.
+func buildRouterConfig(agent *aiv1.Agent) string {
+	address := fmt.Sprintf("%s.%s.svc.cluster.local", headlessServiceName(agent), agent.Namespace)
+	return fmt.Sprintf(routerConfigTemplate, conversationHeader(agent), address, agentServingPort(agent))
+}
+
+// reconcileRouter deploys the conversation router and the headless Service it balances over,
+// or removes them when conversation affinity is disabled.
+func (r *AgentReconciler) reconcileRouter(ctx context.Context, agent *aiv1.Agent) error {
+	if !conversationAffinityEnabled(agent) {
+		for _, obj := range []client.Object{
+			&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: routerName(agent), Namespace: agent.Namespace}},
+			&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: routerName(agent), Namespace: agent.Namespace}},
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: headlessServiceName(agent), Namespace: agent.Namespace}},
+		} {
+			if err := client.IgnoreNotFound(r.Delete(ctx, obj)); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	config := buildRouterConfig(agent)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      routerName(agent),
+			Namespace: agent.Namespace,
+			Labels:    routerLabels(agent),
+		},
+		Data: map[string]string{
+			"envoy.yaml": config,
+		},
+	}
+	if err := controllerutil.SetControllerReference(agent, configMap, r.Scheme); err != nil {
+		return err
+	}
+	foundConfigMap := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, foundConfigMap)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new router ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+		if err := r.Create(ctx, configMap); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		foundConfigMap.Data = configMap.Data
+		if err := r.Update(ctx, foundConfigMap); err != nil {
+			return err
+		}
+	}
+
+	headless := r.buildHeadlessService(agent)
+	if err := controllerutil.SetControllerReference(agent, headless, r.Scheme); err != nil {
+		return err
+	}
+	if headless.Spec.Selector, err = r.servingSelector(ctx, agent); err != nil {
+		return err
+	}
+	foundService := &corev1.Service{}
+	err = r.Get(ctx, types.NamespacedName{Name: headless.Name, Namespace: headless.Namespace}, foundService)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new headless Service", "Service.Namespace", headless.Namespace, "Service.Name", headless.Name)
+		if err := r.Create(ctx, headless); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else if !reflect.DeepEqual(foundService.Spec.Selector, headless.Spec.Selector) {
+		// Blue/green promotions switch the revision selected by the headless Service.
+		log.FromContext(ctx).Info("Updating existing headless Service", "Service.Namespace", foundService.Namespace, "Service.Name", foundService.Name)
+		foundService.Spec.Selector = headless.Spec.Selector
+		if err := r.Update(ctx, foundService); err != nil {
+			return err
+		}
+	}
+
+	sum := sha256.Sum256([]byte(config))
+	deployment := r.buildRouterDeployment(agent, hex.EncodeToString(sum[:]))
+	if err := controllerutil.SetControllerReference(agent, deployment, r.Scheme); err != nil {
+		return err
+	}
+	foundDeployment := &appsv1.Deployment{}
+	err = r.Get(ctx, types.NamespacedName{Name: deployment.Name, Namespace: deployment.Namespace}, foundDeployment)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new router Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
+		return r.Create(ctx, deployment)
+	} else if err != nil {
+		return err
+	}
+
+	log.FromContext(ctx).Info("Updating existing router Deployment", "Deployment.Namespace", foundDeployment.Namespace, "Deployment.Name", foundDeployment.Name)
+	foundDeployment.Spec = deployment.Spec
+	return r.Update(ctx, foundDeployment)
+}
+
+// buildHeadlessService creates the headless Service publishing the addresses of the ready agent pods.
+func (r *AgentReconciler) buildHeadlessService(agent *aiv1.Agent) *corev1.Service {
+	labels := agentLabels(agent)
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      headlessServiceName(agent),
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  agentSelector(agent),
+			Ports: []corev1.ServicePort{
+				{
+					Port:       8080,
+					TargetPort: intstr.FromInt(int(agentServingPort(agent))),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// buildRouterDeployment creates the Deployment running the conversation router.
+func (r *AgentReconciler) buildRouterDeployment(agent *aiv1.Agent, checksum string) *appsv1.Deployment {
+	labels := routerLabels(agent)
+
+	replicas := int32(2)
+	if agent.Spec.Routing.ConversationAffinity.RouterReplicas != nil {
+		replicas = *agent.Spec.Routing.ConversationAffinity.RouterReplicas
+	}
+
+	image := "envoyproxy/envoy:v1.29-latest"
+	if envImage := os.Getenv("ROUTER_IMAGE"); envImage != "" {
+		image = envImage
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      routerName(agent),
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+					Annotations: map[string]string{
+						routerConfigChecksumAnnotation: checksum,
+					},
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:  "router",
+							Image: image,
+							Args:  []string{"--config-path", "/etc/envoy/envoy.yaml"},
+							Ports: []corev1.ContainerPort{
+								{ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("256Mi"),
+									corev1.ResourceCPU:    resource.MustParse("500m"),
+								},
+							},
+							ReadinessProbe: &corev1.Probe{
+								ProbeHandler: corev1.ProbeHandler{
+									HTTPGet: &corev1.HTTPGetAction{
+										Path: "/ready",
+										Port: intstr.FromInt(9901),
+									},
+								},
+								PeriodSeconds: 5,
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "config", MountPath: "/etc/envoy", ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{Name: routerName(agent)},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	securityprofile.Apply(securityProfile(agent), &deployment.Spec.Template.Spec, routerUID)
+	return deployment
+}
@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/scratch"
+)
+
+// Default ephemeral storage of the agent container, which holds its logs and writable layer.
+// The limit gets the evicted pod to be the one filling the disk of the node, rather than
+// the other pods the kubelet evicts under disk pressure.
+var (
+	defaultEphemeralStorageRequest = resource.MustParse("256Mi")
+	defaultEphemeralStorageLimit   = resource.MustParse("1Gi")
+)
+
+// validateScratch checks the size and medium of spec.scratch.
+func validateScratch(agent *aiv1.Agent) error {
+	if errs := scratch.Validate(&agent.Spec); len(errs) > 0 {
+		return errs.ToAggregate()
+	}
+	return nil
+}
+
+// scratchVolume returns the bounded emptyDir of spec.scratch, its mount in the agent
+// container and AGENT_TMP_DIR locating it for the runtime.
+func scratchVolume(agent *aiv1.Agent) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	if agent.Spec.Scratch == nil {
+		return nil, nil, nil
+	}
+	sizeLimit := agent.Spec.Scratch.SizeLimit.DeepCopy()
+	emptyDir := &corev1.EmptyDirVolumeSource{SizeLimit: &sizeLimit}
+	if scratch.Medium(&agent.Spec) == scratch.MediumMemory {
+		emptyDir.Medium = corev1.StorageMediumMemory
+	}
+	volume := corev1.Volume{Name: scratch.VolumeName, VolumeSource: corev1.VolumeSource{EmptyDir: emptyDir}}
+	mount := corev1.VolumeMount{Name: scratch.VolumeName, MountPath: scratch.MountPath}
+	env := []corev1.EnvVar{{Name: "AGENT_TMP_DIR", Value: scratch.MountPath}}
+	return []corev1.Volume{volume}, []corev1.VolumeMount{mount}, env
+}
+
+// withEphemeralStorage sets the ephemeral-storage request and limit of resources that the
+// user or size preset left unset. The pod-level limit covers the emptyDirs on disk, so a
+// Disk scratch volume raises the default limit by its size.
+func withEphemeralStorage(agent *aiv1.Agent, resources corev1.ResourceRequirements) corev1.ResourceRequirements {
+	resources = *resources.DeepCopy()
+	if resources.Requests == nil {
+		resources.Requests = corev1.ResourceList{}
+	}
+	if resources.Limits == nil {
+		resources.Limits = corev1.ResourceList{}
+	}
+
+	limit, hasLimit := resources.Limits[corev1.ResourceEphemeralStorage]
+	if !hasLimit {
+		limit = defaultEphemeralStorageLimit.DeepCopy()
+		if agent.Spec.Scratch != nil && scratch.Medium(&agent.Spec) == scratch.MediumDisk {
+			limit.Add(agent.Spec.Scratch.SizeLimit)
+		}
+		if request, ok := resources.Requests[corev1.ResourceEphemeralStorage]; ok && request.Cmp(limit) > 0 {
+			limit = request.DeepCopy()
+		}
+		resources.Limits[corev1.ResourceEphemeralStorage] = limit
+	}
+	if _, ok := resources.Requests[corev1.ResourceEphemeralStorage]; !ok {
+		request := defaultEphemeralStorageRequest.DeepCopy()
+		if request.Cmp(limit) > 0 {
+			request = limit.DeepCopy()
+		}
+		resources.Requests[corev1.ResourceEphemeralStorage] = request
+	}
+	return resources
+}
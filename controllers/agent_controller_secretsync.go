@@ -0,0 +1,177 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/secretref"
+)
+
+const (
+	// syncedSecretComponent labels the copies of cross-namespace Secrets.
+	syncedSecretComponent = "synced-secret"
+	// syncedFromAnnotation records the source of a synced Secret as "<namespace>/<name>".
+	syncedFromAnnotation = "kubeagentic.ai/synced-from"
+)
+
+// secretNamespace returns the namespace of a referenced Secret.
+func secretNamespace(agent *aiv1.Agent, ref *aiv1.SecretKeyReference) string {
+	if ref.Namespace != "" {
+		return ref.Namespace
+	}
+	return agent.Namespace
+}
+
+// isCrossNamespace reports whether ref points outside the agent's namespace.
+func isCrossNamespace(agent *aiv1.Agent, ref *aiv1.SecretKeyReference) bool {
+	return secretNamespace(agent, ref) != agent.Namespace
+}
+
+// crossNamespaceSecretRefs returns the cross-namespace references of the agent keyed by
+// the name of their copy in the agent's namespace.
+func crossNamespaceSecretRefs(agent *aiv1.Agent) map[string]*aiv1.SecretKeyReference {
+	refs := map[string]*aiv1.SecretKeyReference{}
+	if agent.Spec.ApiSecretRef != nil && isCrossNamespace(agent, agent.Spec.ApiSecretRef) {
+		refs[naming.Child(agent.Name, "api-key")] = agent.Spec.ApiSecretRef
+	}
+	if embeddings := effectiveEmbeddings(agent); embeddings != nil && embeddings.ApiSecretRef != nil && isCrossNamespace(agent, embeddings.ApiSecretRef) {
+		if agent.Spec.ApiSecretRef == nil || !sameSecretKey(agent, embeddings.ApiSecretRef, agent.Spec.ApiSecretRef) {
+			refs[naming.Child(agent.Name, "embeddings-api-key")] = embeddings.ApiSecretRef
+		}
+	}
+	return refs
+}
+
+// sameSecretKey reports whether two references select the same Secret key.
+func sameSecretKey(agent *aiv1.Agent, a, b *aiv1.SecretKeyReference) bool {
+	return secretNamespace(agent, a) == secretNamespace(agent, b) && a.Name == b.Name && a.Key == b.Key
+}
+
+// localSecretKeySelector returns the selector the agent's pods use for ref: the Secret
+// itself in the agent's namespace, or its synced copy for a cross-namespace reference.
+func localSecretKeySelector(agent *aiv1.Agent, ref *aiv1.SecretKeyReference) *corev1.SecretKeySelector {
+	selector := ref.SecretKeySelector.DeepCopy()
+	if !isCrossNamespace(agent, ref) {
+		return selector
+	}
+	for name, synced := range crossNamespaceSecretRefs(agent) {
+		if sameSecretKey(agent, synced, ref) {
+			selector.Name = name
+		}
+	}
+	return selector
+}
+
+// validateSecretNamespaces checks that every cross-namespace reference is allowed for the
+// agent's namespace by the source namespace, in case the admission webhook was bypassed.
+func (r *AgentReconciler) validateSecretNamespaces(ctx context.Context, agent *aiv1.Agent, refs ...*aiv1.SecretKeyReference) error {
+	for _, ref := range refs {
+		if !isCrossNamespace(agent, ref) {
+			continue
+		}
+		source := secretNamespace(agent, ref)
+		var policies aiv1.AgentPolicyList
+		if err := r.List(ctx, &policies, client.InNamespace(source)); err != nil {
+			return err
+		}
+		if !secretref.NamespaceAllowed(agent.Namespace, source, policies.Items) {
+			return fmt.Errorf("secret %s/%s: namespace %s is not allowed as a secret source for namespace %s", source, ref.Name, source, agent.Namespace)
+		}
+	}
+	return nil
+}
+
+// reconcileSecretCopies copies the keys of cross-namespace Secrets into the agent's
+// namespace, as pods cannot reference Secrets of other namespaces. Copies are updated when
+// the source rotates and deleted when no longer referenced.
+func (r *AgentReconciler) reconcileSecretCopies(ctx context.Context, agent *aiv1.Agent) error {
+	desired := crossNamespaceSecretRefs(agent)
+	for name, ref := range desired {
+		source := &corev1.Secret{}
+		sourceNamespace := secretNamespace(agent, ref)
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: sourceNamespace}, source); err != nil {
+			return fmt.Errorf("failed to get secret %s/%s: %w", sourceNamespace, ref.Name, err)
+		}
+		value, exists := source.Data[ref.Key]
+		if !exists {
+			return fmt.Errorf("key %s not found in secret %s/%s", ref.Key, sourceNamespace, ref.Name)
+		}
+
+		labels := agentLabels(agent)
+		labels["app.kubernetes.io/component"] = syncedSecretComponent
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: agent.Namespace,
+				Labels:    labels,
+				Annotations: map[string]string{
+					syncedFromAnnotation: sourceNamespace + "/" + ref.Name,
+				},
+			},
+			Type: corev1.SecretTypeOpaque,
+			Data: map[string][]byte{ref.Key: value},
+		}
+		if err := controllerutil.SetControllerReference(agent, secret, r.Scheme); err != nil {
+			return err
+		}
+
+		found := &corev1.Secret{}
+		err := r.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: secret.Namespace}, found)
+		if err != nil && errors.IsNotFound(err) {
+			log.FromContext(ctx).Info("Creating synced Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name, "Source", secret.Annotations[syncedFromAnnotation])
+			if err := r.Create(ctx, secret); err != nil {
+				return err
+			}
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(found, agent) {
+			return fmt.Errorf("secret %s already exists and is not managed by agent %s", found.Name, agent.Name)
+		}
+
+		log.FromContext(ctx).Info("Updating synced Secret", "Secret.Namespace", found.Namespace, "Secret.Name", found.Name)
+		found.Labels = secret.Labels
+		found.Annotations = secret.Annotations
+		found.Data = secret.Data
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	return r.deleteSecretCopies(ctx, agent, desired)
+}
+
+// deleteSecretCopies removes the synced Secrets of the agent that are not in keep. It is
+// also called with no Secrets to keep when the agent is deleted, so that copied credentials
+// are removed right away instead of being left to garbage collection.
+func (r *AgentReconciler) deleteSecretCopies(ctx context.Context, agent *aiv1.Agent, keep map[string]*aiv1.SecretKeyReference) error {
+	var copies corev1.SecretList
+	if err := r.List(ctx, &copies, client.InNamespace(agent.Namespace), client.MatchingLabels{
+		"kubeagentic.ai/agent":        agent.Name,
+		"app.kubernetes.io/component": syncedSecretComponent,
+	}); err != nil {
+		return err
+	}
+	for i := range copies.Items {
+		stale := &copies.Items[i]
+		if _, ok := keep[stale.Name]; ok || !metav1.IsControlledBy(stale, agent) {
+			continue
+		}
+		log.FromContext(ctx).Info("Deleting synced Secret", "Secret.Namespace", stale.Namespace, "Secret.Name", stale.Name)
+		if err := r.Delete(ctx, stale); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
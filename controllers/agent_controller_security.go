@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+// User IDs the generated pods run as under the restricted security profile, matching the
+// non-root users of their images.
+const (
+	// agentUID is the user of the agent image, also used by the job images.
+	agentUID int64 = 1001
+	// redisUID is the redis user of the official Redis image.
+	redisUID int64 = 999
+	// routerUID is the envoy user of the official Envoy image.
+	routerUID int64 = 101
+)
+
+// securityProfile returns the Pod Security Standards level of the agent's pods.
+func securityProfile(agent *aiv1.Agent) string {
+	return securityprofile.Resolve(agent.Spec.SecurityProfile)
+}
+
+// validateSecurityProfile checks that the rendered agent pods comply with the security
+// profile, which operator settings such as a hostPath model cache can break.
+func (r *AgentReconciler) validateSecurityProfile(agent *aiv1.Agent) error {
+	profile := securityProfile(agent)
+	if !securityprofile.Valid(profile) {
+		return fmt.Errorf("invalid security profile: %s, must be one of [baseline restricted]", profile)
+	}
+	if violations := securityprofile.Violations(profile, &r.buildDeployment(agent).Spec.Template.Spec); len(violations) > 0 {
+		return fmt.Errorf("agent pods violate the %s security profile: %s", profile, strings.Join(violations, "; "))
+	}
+	return nil
+}
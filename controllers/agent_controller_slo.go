@@ -0,0 +1,251 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/slo"
+)
+
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=prometheusrules,verbs=get;list;watch;create;update;patch;delete
+
+// prometheusRuleGVK is the kind of the Prometheus Operator holding the SLO rules. It is
+// handled as an unstructured object, so the operator does not depend on the Prometheus
+// Operator.
+var prometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+
+// sloEvaluationInterval is the time between two queries of the error budgets of an agent.
+const sloEvaluationInterval = 5 * time.Minute
+
+// sloEnabled reports whether the agent defines SLOs.
+func sloEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.SLO != nil
+}
+
+// sloRuleName returns the name of the PrometheusRule holding the SLO rules of the agent.
+func sloRuleName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "slo")
+}
+
+// sloObjectives returns the objectives of spec.slo.
+func sloObjectives(agent *aiv1.Agent) slo.Objectives {
+	config := agent.Spec.SLO
+	objectives := slo.Objectives{
+		Agent:     agent.Name,
+		Namespace: agent.Namespace,
+		Target:    config.AvailabilityTarget,
+	}
+	if config.LatencyTargetMs != nil {
+		objectives.LatencyTarget = time.Duration(*config.LatencyTargetMs) * time.Millisecond
+	}
+	if config.Window != nil {
+		objectives.Window = config.Window.Duration
+	}
+	return objectives
+}
+
+// validateSLO checks the targets and the window of spec.slo.
+func validateSLO(agent *aiv1.Agent) error {
+	if !sloEnabled(agent) {
+		return nil
+	}
+	if _, err := slo.ParseTarget(agent.Spec.SLO.AvailabilityTarget); err != nil {
+		return fmt.Errorf("slo.availabilityTarget: %w", err)
+	}
+	if latency := agent.Spec.SLO.LatencyTargetMs; latency != nil && (*latency < 1 || *latency > 600000) {
+		return fmt.Errorf("slo.latencyTargetMs must be between 1 and 600000, got %d", *latency)
+	}
+	if window := agent.Spec.SLO.Window; window != nil {
+		if err := slo.ValidateWindow(window.Duration); err != nil {
+			return fmt.Errorf("slo.window: %w", err)
+		}
+	}
+	return nil
+}
+
+// sloEnv returns the environment variable adding the bucket of the latency target to the
+// duration histogram of the runtime, or nil without a latency SLO.
+func sloEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !sloEnabled(agent) || agent.Spec.SLO.LatencyTargetMs == nil {
+		return nil
+	}
+	return []corev1.EnvVar{{Name: "AGENT_LATENCY_BUCKET_SECONDS", Value: slo.LatencyBucket(sloObjectives(agent).LatencyTarget)}}
+}
+
+// prometheusRulesInstalled reports whether the PrometheusRule CRD is installed in the cluster.
+func (r *AgentReconciler) prometheusRulesInstalled() bool {
+	mappings, err := r.RESTMapper().RESTMappings(prometheusRuleGVK.GroupKind())
+	return err == nil && len(mappings) > 0
+}
+
+// buildSLOPrometheusRule returns the PrometheusRule holding the recording rules and the
+// burn-rate alerts of the SLOs of the agent.
+func buildSLOPrometheusRule(agent *aiv1.Agent) (*unstructured.Unstructured, error) {
+	groups, err := slo.Groups(sloObjectives(agent))
+	if err != nil {
+		return nil, err
+	}
+	// Round-tripped through JSON, as unstructured objects only hold JSON values
+	data, err := json.Marshal(groups)
+	if err != nil {
+		return nil, err
+	}
+	var spec []interface{}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, err
+	}
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": map[string]interface{}{"groups": spec}}}
+	obj.SetGroupVersionKind(prometheusRuleGVK)
+	obj.SetName(sloRuleName(agent))
+	obj.SetNamespace(agent.Namespace)
+	obj.SetLabels(agentLabels(agent))
+	return obj, nil
+}
+
+// reconcileSLO creates or updates the PrometheusRule of the SLOs of the agent, deleting it
+// when spec.slo is removed, and reports on the SLOCompliant condition whether the error
+// budgets are left.
+func (r *AgentReconciler) reconcileSLO(ctx context.Context, agent *aiv1.Agent) error {
+	if !sloEnabled(agent) {
+		agent.Status.SLO = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionSLOCompliant)
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(prometheusRuleGVK)
+		obj.SetName(sloRuleName(agent))
+		obj.SetNamespace(agent.Namespace)
+		if err := r.Delete(ctx, obj); err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) {
+			return err
+		}
+		return nil
+	}
+
+	if !r.prometheusRulesInstalled() {
+		agent.Status.SLO = nil
+		r.setCondition(agent, aiv1.AgentConditionSLOCompliant, corev1.ConditionUnknown, "RulesNotInstalled",
+			"The PrometheusRule CRD of the Prometheus Operator is not installed, so the SLO rules are not generated")
+		return nil
+	}
+	if err := r.reconcileSLOPrometheusRule(ctx, agent); err != nil {
+		return err
+	}
+	r.evaluateSLO(ctx, agent)
+	return nil
+}
+
+// reconcileSLOPrometheusRule creates or updates the PrometheusRule of the SLOs of the agent.
+func (r *AgentReconciler) reconcileSLOPrometheusRule(ctx context.Context, agent *aiv1.Agent) error {
+	rule, err := buildSLOPrometheusRule(agent)
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(agent, rule, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &unstructured.Unstructured{}
+	found.SetGroupVersionKind(prometheusRuleGVK)
+	err = r.Get(ctx, types.NamespacedName{Name: rule.GetName(), Namespace: rule.GetNamespace()}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating PrometheusRule", "PrometheusRule.Name", rule.GetName())
+		return r.Create(ctx, rule)
+	} else if err != nil {
+		return err
+	}
+	found.Object["spec"] = rule.Object["spec"]
+	found.SetLabels(rule.GetLabels())
+	return r.Update(ctx, found)
+}
+
+// evaluateSLO queries the error budgets of the agent from the Prometheus of the operator
+// every sloEvaluationInterval and sets the SLOCompliant condition. The condition is Unknown
+// while Prometheus is not configured, cannot be queried or has no data.
+func (r *AgentReconciler) evaluateSLO(ctx context.Context, agent *aiv1.Agent) {
+	if r.PrometheusURL == "" {
+		agent.Status.SLO = nil
+		r.setCondition(agent, aiv1.AgentConditionSLOCompliant, corev1.ConditionUnknown, "PrometheusNotConfigured",
+			"The operator has no Prometheus URL to read the error budgets from, see --prometheus-url")
+		return
+	}
+
+	now := r.clock().Now()
+	status := agent.Status.SLO
+	if status != nil && status.LastEvaluationTime != nil && now.Sub(status.LastEvaluationTime.Time) < sloEvaluationInterval &&
+		findCondition(agent.Status.Conditions, aiv1.AgentConditionSLOCompliant) != nil {
+		return
+	}
+
+	querier := r.SLOQuerier
+	if querier == nil {
+		querier = &slo.Querier{}
+	}
+	lastEvaluation := metav1.NewTime(now)
+	agent.Status.SLO = &aiv1.SLOStatus{LastEvaluationTime: &lastEvaluation}
+	budgets, err := querier.Budgets(ctx, r.PrometheusURL, agent.Namespace, agent.Name)
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("Failed to query the error budgets", "error", err.Error())
+		r.setCondition(agent, aiv1.AgentConditionSLOCompliant, corev1.ConditionUnknown, "PrometheusUnreachable",
+			fmt.Sprintf("Failed to query the error budgets: %v", err))
+		return
+	}
+	if agent.Spec.SLO.LatencyTargetMs == nil {
+		budgets.Latency = nil
+	}
+
+	var exhausted []string
+	if budgets.Availability != nil {
+		agent.Status.SLO.AvailabilityBudgetRemaining = formatBudget(*budgets.Availability)
+		if *budgets.Availability <= 0 {
+			exhausted = append(exhausted, "availability")
+		}
+	}
+	if budgets.Latency != nil {
+		agent.Status.SLO.LatencyBudgetRemaining = formatBudget(*budgets.Latency)
+		if *budgets.Latency <= 0 {
+			exhausted = append(exhausted, "latency")
+		}
+	}
+	window := slo.Duration(sloWindow(agent))
+	switch {
+	case len(exhausted) > 0:
+		r.setCondition(agent, aiv1.AgentConditionSLOCompliant, corev1.ConditionFalse, "ErrorBudgetExhausted",
+			fmt.Sprintf("The error budget of the %s SLO is exhausted over %s", strings.Join(exhausted, " and "), window))
+	case budgets.Availability == nil:
+		r.setCondition(agent, aiv1.AgentConditionSLOCompliant, corev1.ConditionUnknown, "NoData",
+			"Prometheus has no error budget for the agent yet, which serves no requests or is not scraped")
+	default:
+		message := fmt.Sprintf("%s%% of the availability error budget is left over %s", agent.Status.SLO.AvailabilityBudgetRemaining, window)
+		if budgets.Latency != nil {
+			message += fmt.Sprintf(", %s%% of the latency error budget", agent.Status.SLO.LatencyBudgetRemaining)
+		}
+		r.setCondition(agent, aiv1.AgentConditionSLOCompliant, corev1.ConditionTrue, "WithinBudget", message)
+	}
+}
+
+// sloWindow returns the window of the SLOs of the agent.
+func sloWindow(agent *aiv1.Agent) time.Duration {
+	if window := agent.Spec.SLO.Window; window != nil {
+		return window.Duration
+	}
+	return slo.DefaultWindow
+}
+
+// formatBudget formats a fraction of an error budget as a percentage with 2 decimals.
+func formatBudget(fraction float64) string {
+	return strconv.FormatFloat(fraction*100, 'f', 2, 64)
+}
@@ -0,0 +1,340 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+const (
+	defaultSyntheticsInterval         = 5 * time.Minute
+	defaultSyntheticsTimeout          = 30 * time.Second
+	defaultSyntheticsFailureThreshold = 3
+	defaultSyntheticsPrompt           = "Reply with OK."
+
+	// syntheticProbeFailingReason is the reason of the Degraded condition set when the
+	// synthetic probe failed failureThreshold times in a row.
+	syntheticProbeFailingReason = "SyntheticProbeFailing"
+)
+
+// Synthetic probe results, exposed on the operator metrics endpoint.
+var (
+	syntheticProbeSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_synthetic_probe_success",
+		Help: "Whether the most recent synthetic probe of the agent succeeded.",
+	}, []string{"namespace", "agent"})
+	syntheticProbeLatency = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_synthetic_probe_latency_seconds",
+		Help: "Time the agent took to answer the most recent successful synthetic probe.",
+	}, []string{"namespace", "agent"})
+	syntheticProbeConsecutiveFailures = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_synthetic_probe_consecutive_failures",
+		Help: "Number of failed synthetic probes of the agent since the most recent success.",
+	}, []string{"namespace", "agent"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(syntheticProbeSuccess, syntheticProbeLatency, syntheticProbeConsecutiveFailures)
+}
+
+// syntheticsEnabled reports whether the agent is probed on a schedule.
+func syntheticsEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Synthetics != nil && agent.Spec.Synthetics.Enabled
+}
+
+// syntheticsInterval returns the time between two synthetic probes of the agent.
+func syntheticsInterval(agent *aiv1.Agent) time.Duration {
+	if agent.Spec.Synthetics.Interval != nil {
+		return agent.Spec.Synthetics.Interval.Duration
+	}
+	return defaultSyntheticsInterval
+}
+
+// syntheticsSchedule returns the cron schedule running a probe every interval. Cron only
+// repeats evenly within an hour or a day, so interval is a whole number of minutes below
+// an hour, or a whole number of hours up to a day.
+func syntheticsSchedule(interval time.Duration) (string, error) {
+	switch {
+	case interval < time.Minute || interval > 24*time.Hour:
+		return "", fmt.Errorf("synthetics.interval must be between 1m and 24h, got %s", interval)
+	case interval == 24*time.Hour:
+		return "0 0 * * *", nil
+	case interval%time.Hour == 0:
+		return fmt.Sprintf("0 */%d * * *", int(interval.Hours())), nil
+	case interval < time.Hour && interval%time.Minute == 0:
+		return fmt.Sprintf("*/%d * * * *", int(interval.Minutes())), nil
+	}
+	return "", fmt.Errorf("synthetics.interval must be a whole number of minutes below 1h or of hours, got %s", interval)
+}
+
+// validateSyntheticsConfig validates the synthetic probe configuration.
+func (r *AgentReconciler) validateSyntheticsConfig(agent *aiv1.Agent) error {
+	if !syntheticsEnabled(agent) {
+		return nil
+	}
+	if _, err := syntheticsSchedule(syntheticsInterval(agent)); err != nil {
+		return err
+	}
+	if timeout := agent.Spec.Synthetics.Timeout; timeout != nil && timeout.Duration <= 0 {
+		return fmt.Errorf("synthetics.timeout must be positive")
+	}
+	return nil
+}
+
+// reconcileSynthetics ensures the synthetic probe CronJob matches the agent spec and records
+// the outcome of the most recent probe.
+func (r *AgentReconciler) reconcileSynthetics(ctx context.Context, agent *aiv1.Agent) error {
+	name := naming.ChildWithMax(agent.Name, "synthetic-probe", naming.MaxCronJobLength)
+	if !syntheticsEnabled(agent) {
+		agent.Status.Synthetics = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionSyntheticProbeHealthy)
+		r.resolveDegradedCondition(agent, syntheticProbeFailingReason, "SyntheticProbeDisabled", "The synthetic probe is disabled")
+		deleteSyntheticsMetrics(agent)
+		cronJob := &batchv1.CronJob{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, cronJob, cacheOnly)
+		if err == nil {
+			log.FromContext(ctx).Info("Deleting synthetic probe CronJob", "CronJob.Name", cronJob.Name)
+			return client.IgnoreNotFound(r.Delete(ctx, cronJob, client.PropagationPolicy(metav1.DeletePropagationBackground)))
+		}
+		return client.IgnoreNotFound(err)
+	}
+
+	cronJob, err := r.buildSyntheticsCronJob(agent, name)
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(agent, cronJob, r.Scheme); err != nil {
+		return err
+	}
+
+	found := &batchv1.CronJob{}
+	err = r.Get(ctx, types.NamespacedName{Name: cronJob.Name, Namespace: cronJob.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating new synthetic probe CronJob", "CronJob.Namespace", cronJob.Namespace, "CronJob.Name", cronJob.Name)
+		if err := r.Create(ctx, cronJob); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		log.FromContext(ctx).Info("Updating existing synthetic probe CronJob", "CronJob.Namespace", found.Namespace, "CronJob.Name", found.Name)
+		found.Spec = cronJob.Spec
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	return r.updateSyntheticsStatus(ctx, agent)
+}
+
+// buildSyntheticsCronJob creates the CronJob that sends the probe prompt to the agent Service.
+func (r *AgentReconciler) buildSyntheticsCronJob(agent *aiv1.Agent, name string) (*batchv1.CronJob, error) {
+	labels := map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-job",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "synthetic-probe",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+	synthetics := agent.Spec.Synthetics
+
+	schedule, err := syntheticsSchedule(syntheticsInterval(agent))
+	if err != nil {
+		return nil, err
+	}
+	prompt := defaultSyntheticsPrompt
+	if synthetics.Prompt != "" {
+		prompt = synthetics.Prompt
+	}
+	timeout := defaultSyntheticsTimeout
+	if synthetics.Timeout != nil {
+		timeout = synthetics.Timeout.Duration
+	}
+
+	env := []corev1.EnvVar{
+		{Name: "SYNTHETICS_URL", Value: fmt.Sprintf("http://%s.%s.svc/chat", serviceName(agent), agent.Namespace)},
+		{Name: "SYNTHETICS_PROMPT", Value: prompt},
+		{Name: "SYNTHETICS_EXPECTED_SUBSTRING", Value: synthetics.ExpectedSubstring},
+		{Name: "SYNTHETICS_TIMEOUT_SECONDS", Value: fmt.Sprintf("%d", int64(timeout.Seconds()))},
+	}
+	// The probe authenticates like any other client of the agent endpoint
+	env = append(env, endpointAuthEnv(agent)...)
+
+	historyLimit := int32(3)
+	// Leave the probe time to report a timeout before the Job is stopped
+	deadline := int64(timeout.Seconds()) + 60
+	cronJob := &batchv1.CronJob{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.CronJobSpec{
+			Schedule:                   schedule,
+			ConcurrencyPolicy:          batchv1.ForbidConcurrent,
+			SuccessfulJobsHistoryLimit: &historyLimit,
+			FailedJobsHistoryLimit:     &historyLimit,
+			JobTemplate: batchv1.JobTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: batchv1.JobSpec{
+					// A failed probe is a result, retrying it would hide it
+					BackoffLimit:          int32Ptr(0),
+					ActiveDeadlineSeconds: &deadline,
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{
+							Labels: labels,
+						},
+						Spec: corev1.PodSpec{
+							RestartPolicy: corev1.RestartPolicyNever,
+							Containers: []corev1.Container{
+								{
+									Name:  "probe",
+									Image: getSyntheticsImage(agent),
+									Env:   env,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	securityprofile.Apply(securityProfile(agent), &cronJob.Spec.JobTemplate.Spec.Template.Spec, agentUID)
+	return cronJob, nil
+}
+
+// probeResult is the summary the probe container writes to its termination message.
+type probeResult struct {
+	LatencyMs int64  `json:"latencyMs"`
+	Error     string `json:"error,omitempty"`
+}
+
+// updateSyntheticsStatus records the outcome of the most recently finished probe job. The
+// probe job fails when the agent does not answer in time or the answer lacks the expected
+// substring; failureThreshold failures in a row set the Degraded condition.
+func (r *AgentReconciler) updateSyntheticsStatus(ctx context.Context, agent *aiv1.Agent) error {
+	latest, finished, err := r.latestFinishedJob(ctx, agent, "synthetic-probe")
+	if err != nil || latest == nil {
+		return err
+	}
+
+	status := agent.Status.Synthetics
+	if status == nil {
+		status = &aiv1.SyntheticsStatus{}
+	}
+	if status.LastProbeTime == nil || finished.After(status.LastProbeTime.Time) {
+		var result probeResult
+		message, err := r.jobTerminationMessage(ctx, latest, "probe")
+		if err != nil {
+			return err
+		}
+		if message != "" {
+			if err := json.Unmarshal([]byte(message), &result); err != nil {
+				log.FromContext(ctx).Info("Ignoring malformed probe result", "Job.Name", latest.Name, "error", err.Error())
+			}
+		}
+
+		status.LastProbeTime = finished
+		if latest.Status.Succeeded > 0 {
+			status.LastSuccess = finished
+			status.LastLatencyMs = result.LatencyMs
+			status.ConsecutiveFailures = 0
+			status.Message = ""
+		} else {
+			status.ConsecutiveFailures++
+			status.Message = result.Error
+			if status.Message == "" {
+				status.Message = "Synthetic probe failed, see logs of Job " + latest.Name
+			}
+		}
+	}
+	agent.Status.Synthetics = status
+
+	now := metav1.NewTime(time.Now())
+	condition := aiv1.AgentCondition{
+		Type:               aiv1.AgentConditionSyntheticProbeHealthy,
+		Status:             corev1.ConditionTrue,
+		Reason:             "ProbeSucceeded",
+		Message:            fmt.Sprintf("The agent answered the synthetic probe in %dms", status.LastLatencyMs),
+		LastTransitionTime: &now,
+	}
+	if status.ConsecutiveFailures > 0 {
+		condition.Status = corev1.ConditionFalse
+		condition.Reason = "ProbeFailed"
+		condition.Message = fmt.Sprintf("%d consecutive synthetic probes failed: %s", status.ConsecutiveFailures, status.Message)
+	}
+	agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, condition)
+
+	threshold := int32(defaultSyntheticsFailureThreshold)
+	if agent.Spec.Synthetics.FailureThreshold != nil {
+		threshold = *agent.Spec.Synthetics.FailureThreshold
+	}
+	if status.ConsecutiveFailures >= threshold {
+		r.setFailedCondition(agent, aiv1.AgentConditionDegraded, syntheticProbeFailingReason, condition.Message)
+	} else {
+		r.resolveDegradedCondition(agent, syntheticProbeFailingReason, "SyntheticProbeRecovered", "The synthetic probe failed fewer times in a row than the failure threshold")
+	}
+
+	setSyntheticsMetrics(agent, status)
+	return nil
+}
+
+// setSyntheticsMetrics exports the synthetic probe results of the agent.
+func setSyntheticsMetrics(agent *aiv1.Agent, status *aiv1.SyntheticsStatus) {
+	success := 1.0
+	if status.ConsecutiveFailures > 0 {
+		success = 0
+	}
+	syntheticProbeSuccess.WithLabelValues(agent.Namespace, agent.Name).Set(success)
+	syntheticProbeLatency.WithLabelValues(agent.Namespace, agent.Name).Set(float64(status.LastLatencyMs) / 1000)
+	syntheticProbeConsecutiveFailures.WithLabelValues(agent.Namespace, agent.Name).Set(float64(status.ConsecutiveFailures))
+}
+
+// deleteSyntheticsMetrics removes the synthetic probe results of the agent.
+func deleteSyntheticsMetrics(agent *aiv1.Agent) {
+	syntheticProbeSuccess.DeleteLabelValues(agent.Namespace, agent.Name)
+	syntheticProbeLatency.DeleteLabelValues(agent.Namespace, agent.Name)
+	syntheticProbeConsecutiveFailures.DeleteLabelValues(agent.Namespace, agent.Name)
+}
+
+// syntheticsRequeueAfter shortens requeue to the probe interval, to record each probe.
+func syntheticsRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if !syntheticsEnabled(agent) {
+		return requeue
+	}
+	if interval := syntheticsInterval(agent); interval < requeue {
+		return interval
+	}
+	return requeue
+}
+
+// getSyntheticsImage returns the container image used by the probe job, following the
+// same precedence as getAgentImage: spec, then operator environment, then a default.
+func getSyntheticsImage(agent *aiv1.Agent) string {
+	if agent.Spec.Synthetics.Image != "" {
+		return agent.Spec.Synthetics.Image
+	}
+	if envImage := os.Getenv("SYNTHETICS_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return "kubeagentic/synthetic-probe:latest"
+}
@@ -0,0 +1,216 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+	// Embeds the time zone database, so reset time zones resolve without one in the image
+	_ "time/tzdata"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/tokenquota"
+)
+
+var (
+	tokenQuotaLimit = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_token_quota_limit",
+		Help: "Tokens the agent may consume per day.",
+	}, []string{"namespace", "agent"})
+	tokenQuotaUsed = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_token_quota_used",
+		Help: "Tokens consumed by the agent in the current quota day.",
+	}, []string{"namespace", "agent"})
+	tokenQuotaRemaining = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_token_quota_remaining",
+		Help: "Tokens left to the agent until its daily quota is exhausted.",
+	}, []string{"namespace", "agent"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(tokenQuotaLimit, tokenQuotaUsed, tokenQuotaRemaining)
+}
+
+// clock returns the clock of the reconciler, the real clock when none is set.
+func (r *AgentReconciler) clock() clock.PassiveClock {
+	if r.Clock != nil {
+		return r.Clock
+	}
+	return clock.RealClock{}
+}
+
+// tokenQuotaLocation returns the time zone whose midnight resets the quota of the agent.
+func tokenQuotaLocation(agent *aiv1.Agent) (*time.Location, error) {
+	if agent.Spec.TokenQuota.ResetTimezone == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(agent.Spec.TokenQuota.ResetTimezone)
+}
+
+// tokenQuotaTracker returns the tracker of the daily quota of the agent.
+func (r *AgentReconciler) tokenQuotaTracker(agent *aiv1.Agent) (*tokenquota.Tracker, error) {
+	location, err := tokenQuotaLocation(agent)
+	if err != nil {
+		return nil, err
+	}
+	return &tokenquota.Tracker{Limit: agent.Spec.TokenQuota.DailyLimit, Location: location, Clock: r.clock()}, nil
+}
+
+// tokenQuotaExhausted reports whether the agent has used up its daily token quota.
+func tokenQuotaExhausted(agent *aiv1.Agent) bool {
+	return agent.Spec.TokenQuota != nil && agent.Status.TokenQuota != nil && agent.Status.TokenQuota.Remaining == 0
+}
+
+// validateTokenQuotaConfig validates the token quota configuration.
+func (r *AgentReconciler) validateTokenQuotaConfig(agent *aiv1.Agent) error {
+	if agent.Spec.TokenQuota == nil {
+		return nil
+	}
+	if _, err := tokenQuotaLocation(agent); err != nil {
+		return fmt.Errorf("tokenQuota.resetTimezone %q is not a known time zone", agent.Spec.TokenQuota.ResetTimezone)
+	}
+	return nil
+}
+
+// tokenQuotaEnv returns the environment variables with which the agent runtime enforces the
+// quota. Each pod rejects or throttles requests once it consumed the daily limit itself, or
+// until the reset once the operator found the quota of the whole agent exhausted.
+func tokenQuotaEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	quota := agent.Spec.TokenQuota
+	if quota == nil {
+		return nil
+	}
+	action := quota.Action
+	if action == "" {
+		action = "reject"
+	}
+	timezone := quota.ResetTimezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	throttle := int32(defaultThrottleRequestsPerMinute)
+	if quota.ThrottleRequestsPerMinute != nil {
+		throttle = *quota.ThrottleRequestsPerMinute
+	}
+	env := []corev1.EnvVar{
+		{Name: "AGENT_TOKEN_QUOTA_DAILY_LIMIT", Value: strconv.FormatInt(quota.DailyLimit, 10)},
+		{Name: "AGENT_TOKEN_QUOTA_ACTION", Value: action},
+		{Name: "AGENT_TOKEN_QUOTA_TIMEZONE", Value: timezone},
+		{Name: "AGENT_TOKEN_QUOTA_THROTTLE_PER_MINUTE", Value: fmt.Sprintf("%d", throttle)},
+	}
+	if tokenQuotaExhausted(agent) {
+		env = append(env, corev1.EnvVar{Name: "AGENT_TOKEN_QUOTA_EXHAUSTED_UNTIL", Value: agent.Status.TokenQuota.ResetTime.UTC().Format(time.RFC3339)})
+	}
+	return env
+}
+
+// reconcileTokenQuota adds the tokens consumed since the last update to the consumption of
+// the current quota day and sets the QuotaExhausted condition. The pod counters seen last
+// are kept in the status; the first update only records them.
+func (r *AgentReconciler) reconcileTokenQuota(ctx context.Context, agent *aiv1.Agent) error {
+	previous := agent.Status.TokenQuota
+	if agent.Spec.TokenQuota == nil {
+		agent.Status.TokenQuota = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionQuotaExhausted)
+		deleteTokenQuotaMetrics(agent)
+		return nil
+	}
+
+	tracker, err := r.tokenQuotaTracker(agent)
+	if err != nil {
+		return err
+	}
+	var lastCounters []aiv1.PodTokenCounters
+	var last *tokenquota.Usage
+	if previous != nil {
+		lastCounters = previous.PodCounters
+		last = &tokenquota.Usage{DayStart: previous.DayStart.Time, Used: previous.Used, Counters: podTokenTotals(previous.PodCounters)}
+	}
+	counters, err := r.scrapeTokenCounters(ctx, agent, lastCounters)
+	if err != nil {
+		return err
+	}
+	usage := tracker.Observe(last, podTokenTotals(counters))
+	_, reset := tracker.Day()
+	status := &aiv1.TokenQuotaStatus{
+		DayStart:    metav1.NewTime(usage.DayStart),
+		ResetTime:   metav1.NewTime(reset),
+		Used:        usage.Used,
+		Remaining:   tracker.Remaining(usage),
+		PodCounters: counters,
+	}
+	wasExhausted := tokenQuotaExhausted(agent)
+	agent.Status.TokenQuota = status
+	exhausted := tokenQuotaExhausted(agent)
+
+	now := metav1.NewTime(r.clock().Now())
+	if exhausted {
+		if !wasExhausted {
+			log.FromContext(ctx).Info("Token quota exhausted", "used", status.Used, "dailyLimit", agent.Spec.TokenQuota.DailyLimit)
+			r.recordEvent(agent, corev1.EventTypeWarning, "QuotaExhausted", fmt.Sprintf("Consumed %d of %d tokens today, requests are limited until %s", status.Used, agent.Spec.TokenQuota.DailyLimit, reset.Format(time.RFC3339)))
+		}
+		agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, aiv1.AgentCondition{
+			Type:               aiv1.AgentConditionQuotaExhausted,
+			Status:             corev1.ConditionTrue,
+			Reason:             "DailyLimitReached",
+			Message:            fmt.Sprintf("Consumed %d of %d tokens, the quota resets at %s", status.Used, agent.Spec.TokenQuota.DailyLimit, reset.Format(time.RFC3339)),
+			LastTransitionTime: &now,
+		})
+	} else {
+		if wasExhausted {
+			log.FromContext(ctx).Info("Token quota available again", "used", status.Used, "dailyLimit", agent.Spec.TokenQuota.DailyLimit)
+			r.recordEvent(agent, corev1.EventTypeNormal, "QuotaReset", fmt.Sprintf("%d of %d tokens left today", status.Remaining, agent.Spec.TokenQuota.DailyLimit))
+		}
+		agent.Status.Conditions = r.updateCondition(agent.Status.Conditions, aiv1.AgentCondition{
+			Type:               aiv1.AgentConditionQuotaExhausted,
+			Status:             corev1.ConditionFalse,
+			Reason:             "QuotaAvailable",
+			Message:            fmt.Sprintf("%d of %d tokens left until %s", status.Remaining, agent.Spec.TokenQuota.DailyLimit, reset.Format(time.RFC3339)),
+			LastTransitionTime: &now,
+		})
+	}
+
+	tokenQuotaLimit.WithLabelValues(agent.Namespace, agent.Name).Set(float64(agent.Spec.TokenQuota.DailyLimit))
+	tokenQuotaUsed.WithLabelValues(agent.Namespace, agent.Name).Set(float64(status.Used))
+	tokenQuotaRemaining.WithLabelValues(agent.Namespace, agent.Name).Set(float64(status.Remaining))
+	return nil
+}
+
+// podTokenTotals returns the prompt and completion tokens of each pod.
+func podTokenTotals(counters []aiv1.PodTokenCounters) tokenquota.Counters {
+	totals := tokenquota.Counters{}
+	for _, c := range counters {
+		totals[c.Pod] = c.PromptTokens + c.CompletionTokens
+	}
+	return totals
+}
+
+// deleteTokenQuotaMetrics removes the token quota metrics of the agent.
+func deleteTokenQuotaMetrics(agent *aiv1.Agent) {
+	labels := prometheus.Labels{"namespace": agent.Namespace, "agent": agent.Name}
+	tokenQuotaLimit.DeletePartialMatch(labels)
+	tokenQuotaUsed.DeletePartialMatch(labels)
+	tokenQuotaRemaining.DeletePartialMatch(labels)
+}
+
+// tokenQuotaRequeueAfter shortens requeue to the reset of the quota, to clear the
+// QuotaExhausted condition at midnight.
+func (r *AgentReconciler) tokenQuotaRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if agent.Spec.TokenQuota == nil || agent.Status.TokenQuota == nil {
+		return requeue
+	}
+	if untilReset := agent.Status.TokenQuota.ResetTime.Sub(r.clock().Now()); untilReset < requeue {
+		if untilReset < time.Second {
+			return time.Second
+		}
+		return untilReset
+	}
+	return requeue
+}
@@ -0,0 +1,284 @@
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/toolexec"
+)
+
+const (
+	// toolExecutorContainerName is the name of the executor sidecar in the agent pods.
+	toolExecutorContainerName = "tool-executor"
+	// toolExecutorPort is the loopback port of the execution API in the agent pods.
+	toolExecutorPort int32 = 8091
+	// toolExecutorConfigKey is the key of the agent ConfigMap holding the executor
+	// configuration.
+	toolExecutorConfigKey = "tool-executor.json"
+	// toolExecutorMountPath is where the configuration is mounted in the sidecar.
+	toolExecutorMountPath = "/etc/kubeagentic/tool-executor"
+	// toolExecutorBinPath is where the install init container copies the executor for the
+	// sidecar, whose image is the user's.
+	toolExecutorBinPath = "/kubeagentic/bin"
+	// toolExecutorHome is the writable home directory of the sidecar, for commands such as
+	// git that keep their configuration there.
+	toolExecutorHome = "/home/executor"
+	// toolWorkspacePath is where the workspace shared by the agent container and the
+	// sidecar is mounted in both.
+	toolWorkspacePath = "/workspace"
+	// toolExecutorChecksumAnnotation on the pod template rolls the agent pods when the
+	// executor configuration changes, as the sidecar reads it once at startup.
+	toolExecutorChecksumAnnotation = "kubeagentic.ai/tool-executor-checksum"
+)
+
+// toolExecutorEnabled reports whether the agent pods run the tool executor sidecar.
+func toolExecutorEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.ToolExecutor != nil
+}
+
+// validateToolExecutor checks the tool executor of the agent. Privileged sidecars are
+// rejected, as the commands they run are chosen by the model.
+func (r *AgentReconciler) validateToolExecutor(agent *aiv1.Agent) error {
+	executor := agent.Spec.ToolExecutor
+	if executor == nil {
+		return nil
+	}
+	if executor.Image == "" {
+		return fmt.Errorf("toolExecutor.image is required")
+	}
+	if len(executor.AllowedCommands) == 0 {
+		return fmt.Errorf("toolExecutor.allowedCommands must allow at least one command")
+	}
+	seen := map[string]bool{}
+	for _, command := range executor.AllowedCommands {
+		if command == "" || strings.TrimSpace(command) != command {
+			return fmt.Errorf("toolExecutor.allowedCommands: invalid command %q", command)
+		}
+		if strings.Contains(command, "/") && !filepath.IsAbs(command) {
+			return fmt.Errorf("toolExecutor.allowedCommands: command %q must be a name or an absolute path", command)
+		}
+		if seen[command] {
+			return fmt.Errorf("toolExecutor.allowedCommands: duplicate command %q", command)
+		}
+		seen[command] = true
+	}
+	if executor.Timeout != nil && executor.Timeout.Duration <= 0 {
+		return fmt.Errorf("toolExecutor.timeout must be positive")
+	}
+	if sc := executor.SecurityContext; sc != nil {
+		if sc.Privileged != nil && *sc.Privileged {
+			return fmt.Errorf("toolExecutor must not be privileged")
+		}
+		if sc.AllowPrivilegeEscalation != nil && *sc.AllowPrivilegeEscalation {
+			return fmt.Errorf("toolExecutor must not allow privilege escalation")
+		}
+		if sc.Capabilities != nil && len(sc.Capabilities.Add) > 0 {
+			return fmt.Errorf("toolExecutor must not add capabilities")
+		}
+		if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+			return fmt.Errorf("toolExecutor must not run as user 0")
+		}
+	}
+	return nil
+}
+
+// toolExecutorConfig returns the tool-executor.json of the agent ConfigMap, read by the
+// sidecar. The allowlist the sidecar enforces is the one rendered here.
+func toolExecutorConfig(agent *aiv1.Agent) (string, error) {
+	config := toolexec.Config{
+		AllowedCommands: append([]string{}, agent.Spec.ToolExecutor.AllowedCommands...),
+		Workspace:       toolWorkspacePath,
+		Timeout:         toolexec.DefaultTimeout.String(),
+		Redaction:       redactionRules(agent, aiv1.RedactionTargetToolAudit),
+	}
+	if timeout := agent.Spec.ToolExecutor.Timeout; timeout != nil {
+		config.Timeout = timeout.Duration.String()
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// toolExecutorChecksum returns a hash of the executor configuration, or an empty string
+// without an executor.
+func toolExecutorChecksum(agent *aiv1.Agent) (string, error) {
+	if !toolExecutorEnabled(agent) {
+		return "", nil
+	}
+	config, err := toolExecutorConfig(agent)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(config))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// getToolExecutorInstallImage returns the image installing the executor into the sidecar:
+// the operator environment, then the operator image, which ships the executor.
+func getToolExecutorInstallImage() string {
+	if envImage := os.Getenv("TOOL_EXECUTOR_INSTALL_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return "kubeagentic/operator:latest"
+}
+
+// toolWorkspaceVolume returns the workspace volume shared with the executor sidecar, with
+// its mount and the environment locating the execution API in the agent container, or
+// nothing without an executor.
+func toolWorkspaceVolume(agent *aiv1.Agent) ([]corev1.Volume, []corev1.VolumeMount, []corev1.EnvVar) {
+	if !toolExecutorEnabled(agent) {
+		return nil, nil, nil
+	}
+	volumes := []corev1.Volume{{
+		Name:         "workspace",
+		VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+	}}
+	mounts := []corev1.VolumeMount{{Name: "workspace", MountPath: toolWorkspacePath}}
+	env := []corev1.EnvVar{
+		{Name: "AGENT_TOOL_EXECUTOR_URL", Value: fmt.Sprintf("http://127.0.0.1:%d", toolExecutorPort)},
+		{Name: "AGENT_TOOL_EXECUTOR_COMMANDS", Value: strings.Join(agent.Spec.ToolExecutor.AllowedCommands, ",")},
+		{Name: "AGENT_WORKSPACE", Value: toolWorkspacePath},
+	}
+	return volumes, mounts, env
+}
+
+// toolExecutorSecurityContext returns the security context of the executor containers:
+// non-root, no capabilities, no privilege escalation and a read-only root filesystem,
+// whatever the security profile of the agent. The overrides may change the user and the
+// filesystem, but not grant privileges, which validation rejects.
+func toolExecutorSecurityContext(overrides *corev1.SecurityContext) *corev1.SecurityContext {
+	runAsNonRoot := true
+	runAsUser := agentUID
+	allowPrivilegeEscalation := false
+	privileged := false
+	readOnlyRootFilesystem := true
+	sc := &corev1.SecurityContext{
+		RunAsNonRoot:             &runAsNonRoot,
+		RunAsUser:                &runAsUser,
+		Privileged:               &privileged,
+		AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+		ReadOnlyRootFilesystem:   &readOnlyRootFilesystem,
+		Capabilities:             &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}},
+		SeccompProfile:           &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+	}
+	if overrides == nil {
+		return sc
+	}
+	if overrides.RunAsUser != nil {
+		sc.RunAsUser = overrides.RunAsUser
+	}
+	if overrides.RunAsGroup != nil {
+		sc.RunAsGroup = overrides.RunAsGroup
+	}
+	if overrides.ReadOnlyRootFilesystem != nil {
+		sc.ReadOnlyRootFilesystem = overrides.ReadOnlyRootFilesystem
+	}
+	if overrides.SeccompProfile != nil {
+		sc.SeccompProfile = overrides.SeccompProfile
+	}
+	if overrides.SELinuxOptions != nil {
+		sc.SELinuxOptions = overrides.SELinuxOptions
+	}
+	return sc
+}
+
+// toolExecutorSidecar returns the sidecar running the commands of the tools, the init
+// container installing the execution API into it and their volumes, or nil without an
+// executor. The sidecar runs the image of the user, which provides the commands, and binds
+// the execution API to the loopback interface, so that only the containers of the pod can
+// reach it.
+func toolExecutorSidecar(agent *aiv1.Agent) (*corev1.Container, *corev1.Container, []corev1.Volume) {
+	if !toolExecutorEnabled(agent) {
+		return nil, nil, nil
+	}
+	executor := agent.Spec.ToolExecutor
+
+	install := &corev1.Container{
+		Name:    "tool-executor-install",
+		Image:   getToolExecutorInstallImage(),
+		Command: []string{"/tool-executor"},
+		Args:    []string{"--install", toolExecutorBinPath},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("16Mi"),
+				corev1.ResourceCPU:    resource.MustParse("10m"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+				corev1.ResourceCPU:    resource.MustParse("100m"),
+			},
+		},
+		SecurityContext: toolExecutorSecurityContext(nil),
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "tool-executor-bin", MountPath: toolExecutorBinPath},
+		},
+	}
+
+	resources := corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("64Mi"),
+			corev1.ResourceCPU:    resource.MustParse("50m"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceMemory: resource.MustParse("512Mi"),
+			corev1.ResourceCPU:    resource.MustParse("500m"),
+		},
+	}
+	if executor.Resources != nil {
+		resources = *executor.Resources
+	}
+
+	sidecar := &corev1.Container{
+		Name:    toolExecutorContainerName,
+		Image:   executor.Image,
+		Command: []string{toolExecutorBinPath + "/tool-executor"},
+		Args: []string{
+			"--config", toolExecutorMountPath + "/" + toolExecutorConfigKey,
+			"--addr", fmt.Sprintf("127.0.0.1:%d", toolExecutorPort),
+		},
+		Env: []corev1.EnvVar{
+			{Name: "HOME", Value: toolExecutorHome},
+		},
+		Resources:       resources,
+		SecurityContext: toolExecutorSecurityContext(executor.SecurityContext),
+		ReadinessProbe: &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				// The kubelet cannot reach the loopback interface of the pod
+				Exec: &corev1.ExecAction{Command: []string{toolExecutorBinPath + "/tool-executor", "--check", fmt.Sprintf("127.0.0.1:%d", toolExecutorPort)}},
+			},
+			PeriodSeconds: 10,
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{Name: "workspace", MountPath: toolWorkspacePath},
+			{Name: "tool-executor-bin", MountPath: toolExecutorBinPath, ReadOnly: true},
+			{Name: "tool-executor-config", MountPath: toolExecutorMountPath, ReadOnly: true},
+			{Name: "tool-executor-home", MountPath: toolExecutorHome},
+		},
+	}
+
+	volumes := []corev1.Volume{
+		{Name: "tool-executor-bin", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+		{
+			Name: "tool-executor-config",
+			VolumeSource: corev1.VolumeSource{
+				ConfigMap: &corev1.ConfigMapVolumeSource{
+					LocalObjectReference: corev1.LocalObjectReference{Name: configMapName(agent)},
+					Items:                []corev1.KeyToPath{{Key: toolExecutorConfigKey, Path: toolExecutorConfigKey}},
+				},
+			},
+		},
+		{Name: "tool-executor-home", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}},
+	}
+	return sidecar, install, volumes
+}
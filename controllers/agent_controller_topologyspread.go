@@ -0,0 +1,25 @@
+package controllers
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// topologySpreadConstraints returns the spec.topologySpreadConstraints of the pod template.
+// Constraints without a label selector spread the serving pods of the agent, as selected by
+// placementTerm.
+func topologySpreadConstraints(agent *aiv1.Agent) []corev1.TopologySpreadConstraint {
+	if len(agent.Spec.TopologySpreadConstraints) == 0 {
+		return nil
+	}
+	constraints := make([]corev1.TopologySpreadConstraint, len(agent.Spec.TopologySpreadConstraints))
+	for i, constraint := range agent.Spec.TopologySpreadConstraints {
+		constraint.DeepCopyInto(&constraints[i])
+		if constraints[i].LabelSelector == nil {
+			constraints[i].LabelSelector = &metav1.LabelSelector{MatchLabels: agentLabels(agent)}
+		}
+	}
+	return constraints
+}
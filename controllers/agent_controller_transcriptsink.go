@@ -0,0 +1,222 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/transcriptsink"
+)
+
+const (
+	// transcriptSinkChecksumAnnotation rolls the agent pods when the shared secret of the
+	// transcript sink is rotated, which the runtime reads from its environment.
+	transcriptSinkChecksumAnnotation = "kubeagentic.ai/transcript-sink-checksum"
+
+	// transcriptSinkTestInterval is the time between two test requests to an unchanged sink.
+	transcriptSinkTestInterval = 5 * time.Minute
+)
+
+// transcriptSinkEnabled reports whether the agent pushes its transcripts to a sink.
+func transcriptSinkEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.TranscriptSink != nil
+}
+
+// transcriptSinkFailureThreshold returns the number of consecutive batches a pod fails to
+// deliver before the sink is reported failing.
+func transcriptSinkFailureThreshold(agent *aiv1.Agent) int32 {
+	if threshold := agent.Spec.TranscriptSink.FailureThreshold; threshold != nil {
+		return *threshold
+	}
+	return transcriptsink.DefaultFailureThreshold
+}
+
+// validateTranscriptSink checks the URL, the shared secret and the batches of the
+// transcript sink.
+func (r *AgentReconciler) validateTranscriptSink(ctx context.Context, agent *aiv1.Agent) error {
+	if !transcriptSinkEnabled(agent) {
+		return nil
+	}
+	sink := agent.Spec.TranscriptSink
+	if err := transcriptsink.ValidateURL(sink.URL); err != nil {
+		return fmt.Errorf("transcriptSink.url: %w", err)
+	}
+	if sink.AuthSecretRef.Name == "" || sink.AuthSecretRef.Key == "" {
+		return fmt.Errorf("transcriptSink.authSecretRef requires both name and key")
+	}
+	if batch := sink.Batch; batch != nil {
+		if batch.MaxSize != nil && (*batch.MaxSize < 1 || *batch.MaxSize > transcriptsink.MaxBatchSize) {
+			return fmt.Errorf("transcriptSink.batch.maxSize must be between 1 and %d, got %d", transcriptsink.MaxBatchSize, *batch.MaxSize)
+		}
+		if batch.MaxWait != nil && batch.MaxWait.Duration <= 0 {
+			return fmt.Errorf("transcriptSink.batch.maxWait must be positive")
+		}
+	}
+	if threshold := sink.FailureThreshold; threshold != nil && *threshold < 1 {
+		return fmt.Errorf("transcriptSink.failureThreshold must be at least 1, got %d", *threshold)
+	}
+	secret, err := r.secretValue(ctx, agent.Namespace, &sink.AuthSecretRef)
+	if err != nil {
+		return err
+	}
+	if secret == "" {
+		return fmt.Errorf("the shared secret of the transcript sink in secret %s is empty", sink.AuthSecretRef.Name)
+	}
+	return nil
+}
+
+// transcriptSinkConfig returns the configuration of the sink rendered for the runtime.
+func transcriptSinkConfig(agent *aiv1.Agent) transcriptsink.Config {
+	sink := agent.Spec.TranscriptSink
+	config := transcriptsink.Config{
+		URL:             sink.URL,
+		BatchSize:       transcriptsink.DefaultBatchSize,
+		MaxWaitSeconds:  transcriptsink.DefaultMaxWait.Seconds(),
+		IncludeMetadata: sink.IncludeMetadata,
+	}
+	if batch := sink.Batch; batch != nil {
+		if batch.MaxSize != nil {
+			config.BatchSize = *batch.MaxSize
+		}
+		if batch.MaxWait != nil {
+			config.MaxWaitSeconds = batch.MaxWait.Duration.Seconds()
+		}
+	}
+	return config
+}
+
+// transcriptSinkEnv returns the environment variables pushing the transcripts of the
+// runtime to the sink, with the shared secret signing them and the redaction rules applied
+// to them, or nil without a sink.
+func transcriptSinkEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !transcriptSinkEnabled(agent) {
+		return nil
+	}
+	data, _ := json.Marshal(transcriptSinkConfig(agent))
+	env := []corev1.EnvVar{
+		{Name: "AGENT_TRANSCRIPT_SINK", Value: string(data)},
+		{
+			Name: "AGENT_TRANSCRIPT_SINK_SECRET",
+			ValueFrom: &corev1.EnvVarSource{
+				SecretKeyRef: agent.Spec.TranscriptSink.AuthSecretRef.DeepCopy(),
+			},
+		},
+	}
+	return append(env, redactionEnvVar(agent, "AGENT_TRANSCRIPT_REDACTION", aiv1.RedactionTargetTranscripts)...)
+}
+
+// transcriptSinkSecret returns the shared secret of the sink and a hash of it and of the
+// URL, or an empty secret and checksum without a sink.
+func (r *AgentReconciler) transcriptSinkSecret(ctx context.Context, agent *aiv1.Agent) ([]byte, string, error) {
+	if !transcriptSinkEnabled(agent) {
+		return nil, "", nil
+	}
+	secret, err := r.secretValue(ctx, agent.Namespace, &agent.Spec.TranscriptSink.AuthSecretRef)
+	if err != nil {
+		return nil, "", err
+	}
+	hash := sha256.New()
+	hash.Write([]byte(agent.Spec.TranscriptSink.URL))
+	hash.Write([]byte{0})
+	hash.Write([]byte(secret))
+	return []byte(secret), hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// reconcileTranscriptSink posts a signed test request to the sink of the agent when it is
+// configured, when its URL or shared secret changes and every transcriptSinkTestInterval,
+// and reports on the TranscriptSinkReachable condition whether the sink accepted it. The
+// condition is False while a pod of the agent fails to deliver failureThreshold batches in
+// a row, read from the metrics of the running pods.
+func (r *AgentReconciler) reconcileTranscriptSink(ctx context.Context, agent *aiv1.Agent) error {
+	if !transcriptSinkEnabled(agent) {
+		agent.Status.TranscriptSink = nil
+		agent.Status.Conditions = removeCondition(agent.Status.Conditions, aiv1.AgentConditionTranscriptSinkReachable)
+		return nil
+	}
+	secret, checksum, err := r.transcriptSinkSecret(ctx, agent)
+	if err != nil {
+		return err
+	}
+
+	now := r.clock().Now()
+	status := agent.Status.TranscriptSink
+	if status == nil || status.Checksum != checksum || status.LastTestTime == nil || now.Sub(status.LastTestTime.Time) >= transcriptSinkTestInterval {
+		checker := r.TranscriptSinkChecker
+		if checker == nil {
+			checker = &transcriptsink.Checker{}
+		}
+		lastTest := metav1.NewTime(now)
+		status = &aiv1.TranscriptSinkStatus{LastTestTime: &lastTest, Checksum: checksum}
+		if err := checker.Test(ctx, agent.Spec.TranscriptSink.URL, secret, agent.Name, agent.Namespace, now); err != nil {
+			log.FromContext(ctx).V(1).Info("Transcript sink test failed", "error", err.Error())
+			status.Message = err.Error()
+		}
+		agent.Status.TranscriptSink = status
+	}
+	if status.Message != "" {
+		r.setCondition(agent, aiv1.AgentConditionTranscriptSinkReachable, corev1.ConditionFalse, "TestFailed",
+			fmt.Sprintf("The signed test request to %s failed: %s", agent.Spec.TranscriptSink.URL, status.Message))
+		return nil
+	}
+
+	failing, err := r.transcriptSinkFailingPods(ctx, agent)
+	if err != nil {
+		return err
+	}
+	if len(failing) > 0 {
+		r.setCondition(agent, aiv1.AgentConditionTranscriptSinkReachable, corev1.ConditionFalse, "DeliveryFailing",
+			fmt.Sprintf("Pods %s failed to deliver %d or more batches of transcripts in a row, see the kubeagentic_transcripts_failed_total metric",
+				strings.Join(failing, ", "), transcriptSinkFailureThreshold(agent)))
+		return nil
+	}
+	r.setCondition(agent, aiv1.AgentConditionTranscriptSinkReachable, corev1.ConditionTrue, "Reachable",
+		fmt.Sprintf("The sink accepted the signed test request of %s", status.LastTestTime.UTC().Format(time.RFC3339)))
+	return nil
+}
+
+// transcriptSinkFailingPods returns the sorted names of the running agent pods that failed
+// to deliver failureThreshold batches of transcripts in a row. Pods that cannot be scraped
+// are left out.
+func (r *AgentReconciler) transcriptSinkFailingPods(ctx context.Context, agent *aiv1.Agent) ([]string, error) {
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return nil, err
+	}
+	if len(pods.Items) == 0 {
+		return nil, nil
+	}
+	token, err := r.endpointToken(ctx, agent)
+	if err != nil {
+		return nil, err
+	}
+
+	scraper := r.metricsScraper()
+	threshold := float64(transcriptSinkFailureThreshold(agent))
+	var failing []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+		sample, err := scraper.Scrape(ctx, fmt.Sprintf("http://%s:8080", pod.Status.PodIP), token)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Failed to scrape pod transcript sink failures", "Pod.Name", pod.Name, "error", err.Error())
+			continue
+		}
+		if sample.TranscriptSinkFailures >= threshold {
+			failing = append(failing, pod.Name)
+		}
+	}
+	sort.Strings(failing)
+	return failing, nil
+}
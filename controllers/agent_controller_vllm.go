@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// Bounds of the vLLM micro-batching settings, matching the CRD.
+const (
+	maxVLLMBatchSize           = 256
+	maxVLLMWaitMs              = 1000
+	maxVLLMConcurrentSequences = 1024
+)
+
+// validateVLLMConfig checks that the vLLM tuning is only set for the vllm provider, within
+// its bounds.
+func validateVLLMConfig(agent *aiv1.Agent) error {
+	config := agent.Spec.VLLM
+	if config == nil {
+		return nil
+	}
+	if agent.Spec.Provider != "vllm" {
+		return fmt.Errorf("vllm tuning is only supported by the vllm provider, not %s", agent.Spec.Provider)
+	}
+	if config.MaxBatchSize != nil && (*config.MaxBatchSize < 1 || *config.MaxBatchSize > maxVLLMBatchSize) {
+		return fmt.Errorf("vllm.maxBatchSize must be between 1 and %d", maxVLLMBatchSize)
+	}
+	if config.MaxWaitMs != nil && (*config.MaxWaitMs < 0 || *config.MaxWaitMs > maxVLLMWaitMs) {
+		return fmt.Errorf("vllm.maxWaitMs must be between 0 and %d", maxVLLMWaitMs)
+	}
+	if config.MaxConcurrentSequences != nil && (*config.MaxConcurrentSequences < 1 || *config.MaxConcurrentSequences > maxVLLMConcurrentSequences) {
+		return fmt.Errorf("vllm.maxConcurrentSequences must be between 1 and %d", maxVLLMConcurrentSequences)
+	}
+	return nil
+}
+
+// vllmEnv returns the environment variables configuring the micro-batching of the runtime.
+// Changed values change the pod template, which rolls the agent pods.
+func vllmEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	config := agent.Spec.VLLM
+	if config == nil || agent.Spec.Provider != "vllm" {
+		return nil
+	}
+	var env []corev1.EnvVar
+	if config.MaxBatchSize != nil {
+		env = append(env, corev1.EnvVar{Name: "AGENT_VLLM_MAX_BATCH_SIZE", Value: strconv.Itoa(int(*config.MaxBatchSize))})
+	}
+	if config.MaxWaitMs != nil {
+		env = append(env, corev1.EnvVar{Name: "AGENT_VLLM_MAX_WAIT_MS", Value: strconv.Itoa(int(*config.MaxWaitMs))})
+	}
+	if config.MaxConcurrentSequences != nil {
+		env = append(env, corev1.EnvVar{Name: "AGENT_VLLM_MAX_CONCURRENT_SEQUENCES", Value: strconv.Itoa(int(*config.MaxConcurrentSequences))})
+	}
+	return env
+}
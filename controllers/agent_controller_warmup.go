@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/warmup"
+)
+
+const (
+	defaultWarmupCount   = 3
+	defaultWarmupTimeout = 2 * time.Minute
+
+	// warmupFailedReason is the reason of the Degraded condition set when a pod failed to warm up.
+	warmupFailedReason = "WarmupFailed"
+
+	// warmupRequeue is how often pods still warming up are checked.
+	warmupRequeue = 15 * time.Second
+)
+
+// warmupEnabled reports whether new agent pods send warm-up requests before reporting ready.
+func warmupEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.Warmup != nil && agent.Spec.Warmup.Enabled
+}
+
+// warmupEnv returns the environment variables configuring the warm-up of the agent runtime.
+func warmupEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !warmupEnabled(agent) {
+		return nil
+	}
+	config := agent.Spec.Warmup
+	count := int32(defaultWarmupCount)
+	if config.Count != nil {
+		count = *config.Count
+	}
+	timeout := defaultWarmupTimeout
+	if config.Timeout != nil {
+		timeout = config.Timeout.Duration
+	}
+	env := []corev1.EnvVar{
+		{Name: "AGENT_WARMUP_COUNT", Value: fmt.Sprintf("%d", count)},
+		{Name: "AGENT_WARMUP_TIMEOUT_SECONDS", Value: fmt.Sprintf("%d", int64(timeout.Seconds()))},
+	}
+	if len(config.Prompts) > 0 {
+		prompts, err := json.Marshal(config.Prompts)
+		if err == nil {
+			env = append(env, corev1.EnvVar{Name: "AGENT_WARMUP_PROMPTS", Value: string(prompts)})
+		}
+	}
+	return env
+}
+
+// reconcileWarmup reports the running agent pods still warming up and those whose warm-up
+// failed. A failed warm-up does not keep the pod unready, so it sets the Degraded
+// condition with the warm-up error instead, until no running pod reports a failure.
+func (r *AgentReconciler) reconcileWarmup(ctx context.Context, agent *aiv1.Agent) error {
+	if !warmupEnabled(agent) {
+		agent.Status.Warmup = nil
+		r.clearWarmupCondition(agent)
+		return nil
+	}
+
+	var pods corev1.PodList
+	if err := r.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return err
+	}
+	token, err := r.pollToken(ctx, agent)
+	if err != nil {
+		return err
+	}
+
+	checker := r.WarmupChecker
+	if checker == nil {
+		checker = &warmup.Checker{}
+	}
+	status := &aiv1.WarmupStatus{}
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" || pod.DeletionTimestamp != nil {
+			continue
+		}
+		state, err := checker.Check(ctx, fmt.Sprintf("http://%s:%d", pod.Status.PodIP, probePort(agent)), token)
+		if err != nil {
+			// The pod may not serve requests yet, or run an image without warm-up.
+			log.FromContext(ctx).V(1).Info("Failed to check pod warm-up", "Pod.Name", pod.Name, "error", err.Error())
+			continue
+		}
+		switch state.State {
+		case warmup.StateWarming:
+			status.WarmingPods++
+		case warmup.StateFailed:
+			status.FailedPods++
+			status.Message = fmt.Sprintf("Pod %s failed to warm up: %s", pod.Name, state.Error)
+		}
+	}
+	agent.Status.Warmup = status
+
+	if status.FailedPods == 0 {
+		r.clearWarmupCondition(agent)
+		return nil
+	}
+	r.setFailedCondition(agent, aiv1.AgentConditionDegraded, warmupFailedReason, status.Message)
+	return nil
+}
+
+// clearWarmupCondition resolves the Degraded condition set by a failed warm-up.
+func (r *AgentReconciler) clearWarmupCondition(agent *aiv1.Agent) {
+	r.resolveDegradedCondition(agent, warmupFailedReason, "WarmupSucceeded", "No running pod reports a failed warm-up")
+}
+
+// warmingPods returns the number of agent pods still warming up.
+func warmingPods(agent *aiv1.Agent) int32 {
+	if agent.Status.Warmup == nil {
+		return 0
+	}
+	return agent.Status.Warmup.WarmingPods
+}
+
+// warmupRequeueAfter shortens requeue while pods warm up, to report their outcome.
+func warmupRequeueAfter(agent *aiv1.Agent, requeue time.Duration) time.Duration {
+	if warmingPods(agent) > 0 && requeue > warmupRequeue {
+		return warmupRequeue
+	}
+	return requeue
+}
@@ -0,0 +1,372 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+const (
+	defaultWorkerReplicas          = 1
+	defaultMaxJobDuration          = 30 * time.Minute
+	defaultWorkerMaxReplicas       = 10
+	defaultWorkerTargetQueueLength = 5
+	// managedJobQueueDB keeps the jobs apart from the conversations in the managed Redis.
+	managedJobQueueDB = 1
+)
+
+// Keys of the Secret of an external job queue.
+const (
+	jobQueueAddressKey  = "address"
+	jobQueuePasswordKey = "password"
+)
+
+// workerModeEnabled reports whether the agent runs its jobs in a worker Deployment.
+func workerModeEnabled(agent *aiv1.Agent) bool {
+	return agent.Spec.WorkerMode != nil && agent.Spec.WorkerMode.Enabled
+}
+
+// workersAutoscaled reports whether KEDA scales the workers on the length of the job queue.
+func workersAutoscaled(agent *aiv1.Agent) bool {
+	return workerModeEnabled(agent) && agent.Spec.WorkerMode.Autoscaling != nil && agent.Spec.WorkerMode.Autoscaling.Enabled
+}
+
+// jobQueueBackend returns the Redis holding the job queue, defaulting to managed-redis.
+func jobQueueBackend(agent *aiv1.Agent) string {
+	if agent.Spec.WorkerMode.Queue == "" {
+		return "managed-redis"
+	}
+	return agent.Spec.WorkerMode.Queue
+}
+
+// managedJobQueue reports whether the job queue lives in the managed Redis of the agent.
+func managedJobQueue(agent *aiv1.Agent) bool {
+	return workerModeEnabled(agent) && jobQueueBackend(agent) == "managed-redis"
+}
+
+// workerName returns the name of the worker Deployment and its ScaledObject.
+func workerName(agent *aiv1.Agent) string {
+	return naming.Child(agent.Name, "worker")
+}
+
+// workerLabels returns the labels of the worker pods. They differ from agentLabels, so the
+// agent Service and Deployment do not select the workers.
+func workerLabels(agent *aiv1.Agent) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":      "kubeagentic-worker",
+		"app.kubernetes.io/instance":  agent.Name,
+		"app.kubernetes.io/component": "worker",
+		"kubeagentic.ai/agent":        agent.Name,
+	}
+}
+
+// jobQueueName returns the Redis list of the queued jobs, unique per agent as an external
+// Redis may hold the queues of several agents.
+func jobQueueName(agent *aiv1.Agent) string {
+	return fmt.Sprintf("kubeagentic:%s:%s:jobs", agent.Namespace, agent.Name)
+}
+
+// managedJobQueueAddress returns the address of the managed Redis holding the job queue.
+func managedJobQueueAddress(agent *aiv1.Agent) string {
+	return fmt.Sprintf("%s.%s.svc:6379", managedRedisName(agent), agent.Namespace)
+}
+
+// validateWorkerMode checks the worker mode of the agent against the rest of its spec, the
+// Secret of an external queue and, when it scales the workers, that KEDA is installed.
+func (r *AgentReconciler) validateWorkerMode(ctx context.Context, agent *aiv1.Agent) error {
+	if !workerModeEnabled(agent) {
+		return nil
+	}
+	workerMode := agent.Spec.WorkerMode
+
+	if eventSourceEnabled(agent) {
+		return fmt.Errorf("workerMode cannot be combined with eventSource, whose agent pods consume their work themselves")
+	}
+	if agent.Spec.Replicas != nil && *agent.Spec.Replicas == 0 {
+		return fmt.Errorf("spec.replicas applies to the agent Deployment enqueueing the jobs and must not be 0 in worker mode")
+	}
+	if workerMode.MaxJobDuration != nil && workerMode.MaxJobDuration.Duration <= 0 {
+		return fmt.Errorf("workerMode.maxJobDuration must be positive")
+	}
+
+	switch jobQueueBackend(agent) {
+	case "managed-redis":
+		if workerMode.QueueSecretRef != nil {
+			return fmt.Errorf("workerMode.queueSecretRef is only supported by the external queue")
+		}
+	case "external":
+		if workerMode.QueueSecretRef == nil {
+			return fmt.Errorf("workerMode.queueSecretRef is required for the external queue")
+		}
+		address, err := r.secretValue(ctx, agent.Namespace, &corev1.SecretKeySelector{LocalObjectReference: *workerMode.QueueSecretRef, Key: jobQueueAddressKey})
+		if err != nil {
+			return fmt.Errorf("invalid job queue secret: %w", err)
+		}
+		if _, port, err := net.SplitHostPort(address); err != nil || port == "" {
+			return fmt.Errorf("the address of job queue secret %s must be host:port", workerMode.QueueSecretRef.Name)
+		}
+	default:
+		return fmt.Errorf("invalid workerMode.queue %q, must be managed-redis or external", workerMode.Queue)
+	}
+
+	if workersAutoscaled(agent) {
+		minReplicas, maxReplicas := workerScalingBounds(agent)
+		if minReplicas > maxReplicas {
+			return fmt.Errorf("workerMode.autoscaling.minReplicas %d exceeds maxReplicas %d", minReplicas, maxReplicas)
+		}
+		if !r.kedaInstalled() {
+			return fmt.Errorf("workerMode.autoscaling requires KEDA, whose ScaledObject CRD is not installed")
+		}
+	}
+	return nil
+}
+
+// workerScalingBounds returns the minimum and maximum workers KEDA scales to.
+func workerScalingBounds(agent *aiv1.Agent) (int32, int32) {
+	scaling := agent.Spec.WorkerMode.Autoscaling
+	minReplicas, maxReplicas := int32(0), int32(defaultWorkerMaxReplicas)
+	if scaling.MinReplicas != nil {
+		minReplicas = *scaling.MinReplicas
+	}
+	if scaling.MaxReplicas != nil {
+		maxReplicas = *scaling.MaxReplicas
+	}
+	return minReplicas, maxReplicas
+}
+
+// jobQueueEnv returns the environment variables locating the job queue, set on the agent
+// pods enqueueing the jobs and on the workers, or nil outside of worker mode.
+func jobQueueEnv(agent *aiv1.Agent) []corev1.EnvVar {
+	if !workerModeEnabled(agent) {
+		return nil
+	}
+	env := []corev1.EnvVar{{Name: "AGENT_QUEUE_NAME", Value: jobQueueName(agent)}}
+	if managedJobQueue(agent) {
+		return append(env,
+			corev1.EnvVar{Name: "AGENT_QUEUE_ADDRESS", Value: managedJobQueueAddress(agent)},
+			corev1.EnvVar{Name: "AGENT_QUEUE_DB", Value: strconv.Itoa(managedJobQueueDB)},
+		)
+	}
+	ref := *agent.Spec.WorkerMode.QueueSecretRef
+	optional := true
+	return append(env,
+		corev1.EnvVar{Name: "AGENT_QUEUE_ADDRESS", ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: ref, Key: jobQueueAddressKey},
+		}},
+		corev1.EnvVar{Name: "AGENT_QUEUE_PASSWORD", ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{LocalObjectReference: ref, Key: jobQueuePasswordKey, Optional: &optional},
+		}},
+	)
+}
+
+// jobQueueSecretNames returns the name of the Secret of an external job queue.
+func jobQueueSecretNames(agent *aiv1.Agent) []string {
+	if !workerModeEnabled(agent) || agent.Spec.WorkerMode.QueueSecretRef == nil {
+		return nil
+	}
+	return []string{agent.Spec.WorkerMode.QueueSecretRef.Name}
+}
+
+// workerReplicas returns the desired number of workers.
+func workerReplicas(agent *aiv1.Agent) int32 {
+	if agent.Spec.WorkerMode.WorkerReplicas != nil {
+		return *agent.Spec.WorkerMode.WorkerReplicas
+	}
+	return defaultWorkerReplicas
+}
+
+// buildWorkerDeployment returns the worker Deployment, running the agent container of the
+// agent Deployment in worker mode. The sidecars serving requests are left out; the tool
+// executor stays, as jobs run tools as well.
+func buildWorkerDeployment(agent *aiv1.Agent, api *appsv1.Deployment) *appsv1.Deployment {
+	labels := workerLabels(agent)
+	template := api.Spec.Template.DeepCopy()
+	template.Labels = podLabels(agent, labels)
+
+	container := template.Spec.Containers[0]
+	maxJobDuration := defaultMaxJobDuration
+	if agent.Spec.WorkerMode.MaxJobDuration != nil {
+		maxJobDuration = agent.Spec.WorkerMode.MaxJobDuration.Duration
+	}
+	container.Env = append(container.Env,
+		corev1.EnvVar{Name: "AGENT_MODE", Value: "worker"},
+		corev1.EnvVar{Name: "AGENT_MAX_JOB_DURATION", Value: strconv.Itoa(int(maxJobDuration.Seconds()))},
+	)
+	containers := []corev1.Container{container}
+	for _, sidecar := range template.Spec.Containers[1:] {
+		if sidecar.Name == toolExecutorContainerName {
+			containers = append(containers, sidecar)
+		}
+	}
+	template.Spec.Containers = containers
+
+	// Keep the volumes still mounted without the sidecars.
+	mounted := map[string]bool{}
+	for _, c := range append(template.Spec.InitContainers, containers...) {
+		for _, mount := range c.VolumeMounts {
+			mounted[mount.Name] = true
+		}
+	}
+	var volumes []corev1.Volume
+	for _, volume := range template.Spec.Volumes {
+		if mounted[volume.Name] {
+			volumes = append(volumes, volume)
+		}
+	}
+	template.Spec.Volumes = volumes
+
+	replicas := workerReplicas(agent)
+	if budgetSuspended(agent) || waitingForDependencies(agent) {
+		replicas = 0
+	}
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workerName(agent),
+			Namespace: agent.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: *template,
+		},
+	}
+}
+
+// reconcileWorkers keeps the worker Deployment in line with api, the desired agent
+// Deployment, along with the ScaledObject scaling it, and deletes them outside of worker
+// mode.
+func (r *AgentReconciler) reconcileWorkers(ctx context.Context, agent *aiv1.Agent, api *appsv1.Deployment) error {
+	name := workerName(agent)
+	if !workerModeEnabled(agent) {
+		deployment := &appsv1.Deployment{}
+		err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, deployment, cacheOnly)
+		if err == nil {
+			log.FromContext(ctx).Info("Deleting worker Deployment", "Deployment.Name", name)
+			err = r.Delete(ctx, deployment)
+		}
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+		return r.deleteKEDAObjects(ctx, agent.Namespace, name, scaledObjectGVK, triggerAuthenticationGVK)
+	}
+
+	deployment := buildWorkerDeployment(agent, api)
+	if err := controllerutil.SetControllerReference(agent, deployment, r.Scheme); err != nil {
+		return err
+	}
+	found := &appsv1.Deployment{}
+	err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, found)
+	if err != nil && errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating worker Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
+		if err := r.Create(ctx, deployment); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	} else {
+		// KEDA owns the replicas of autoscaled workers.
+		if workersAutoscaled(agent) && !budgetSuspended(agent) && !waitingForDependencies(agent) && found.Spec.Replicas != nil {
+			deployment.Spec.Replicas = found.Spec.Replicas
+		}
+		found.Spec = deployment.Spec
+		if err := r.Update(ctx, found); err != nil {
+			return err
+		}
+	}
+
+	if !workersAutoscaled(agent) {
+		return r.deleteKEDAObjects(ctx, agent.Namespace, name, scaledObjectGVK, triggerAuthenticationGVK)
+	}
+	scaledObject, triggerAuthentication, err := r.buildWorkerScaling(ctx, agent, name)
+	if err != nil {
+		return err
+	}
+	maxReplicas, err := r.autoscalingMaxReplicas(ctx, agent, workerAutoscaler)
+	if err != nil {
+		return err
+	}
+	if err := unstructured.SetNestedField(scaledObject.Object, int64(maxReplicas), "spec", "maxReplicaCount"); err != nil {
+		return err
+	}
+	return r.reconcileScaledObject(ctx, agent, scaledObject, triggerAuthentication)
+}
+
+// buildWorkerScaling returns the ScaledObject scaling the workers on the length of the job
+// queue, and the TriggerAuthentication passing it the address of an external queue.
+func (r *AgentReconciler) buildWorkerScaling(ctx context.Context, agent *aiv1.Agent, name string) (*unstructured.Unstructured, *unstructured.Unstructured, error) {
+	minReplicas, maxReplicas := workerScalingBounds(agent)
+	targetLength := int32(defaultWorkerTargetQueueLength)
+	if length := agent.Spec.WorkerMode.Autoscaling.TargetQueueLength; length != nil {
+		targetLength = *length
+	}
+	metadata := map[string]interface{}{
+		"listName":   jobQueueName(agent),
+		"listLength": strconv.Itoa(int(targetLength)),
+	}
+	trigger := map[string]interface{}{"type": "redis", "metadata": metadata}
+
+	var triggerAuthentication *unstructured.Unstructured
+	if managedJobQueue(agent) {
+		metadata["address"] = managedJobQueueAddress(agent)
+		metadata["databaseIndex"] = strconv.Itoa(managedJobQueueDB)
+	} else {
+		ref := agent.Spec.WorkerMode.QueueSecretRef
+		secret := &corev1.Secret{}
+		if err := r.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: agent.Namespace}, secret); err != nil {
+			return nil, nil, fmt.Errorf("failed to get job queue secret %s: %w", ref.Name, err)
+		}
+		targets := []interface{}{
+			map[string]interface{}{"parameter": "address", "name": ref.Name, "key": jobQueueAddressKey},
+		}
+		if _, ok := secret.Data[jobQueuePasswordKey]; ok {
+			targets = append(targets, map[string]interface{}{"parameter": "password", "name": ref.Name, "key": jobQueuePasswordKey})
+		}
+		trigger["authenticationRef"] = map[string]interface{}{"name": name}
+		triggerAuthentication = newKEDAObject(triggerAuthenticationGVK, agent, name, map[string]interface{}{"secretTargetRef": targets})
+	}
+
+	scaledObject := newKEDAObject(scaledObjectGVK, agent, name, map[string]interface{}{
+		"scaleTargetRef":  map[string]interface{}{"name": name},
+		"minReplicaCount": int64(minReplicas),
+		"maxReplicaCount": int64(maxReplicas),
+		"triggers":        []interface{}{trigger},
+	})
+	return scaledObject, triggerAuthentication, nil
+}
+
+// workerModeStatus returns the replicas of the agent and worker Deployments, or nil
+// outside of worker mode.
+func (r *AgentReconciler) workerModeStatus(ctx context.Context, agent *aiv1.Agent) (*aiv1.WorkerModeStatus, error) {
+	if !workerModeEnabled(agent) {
+		return nil, nil
+	}
+	status := &aiv1.WorkerModeStatus{APIReplicas: agent.Status.ReplicaStatus}
+	deployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: workerName(agent), Namespace: agent.Namespace}, deployment); err != nil {
+		return status, client.IgnoreNotFound(err)
+	}
+	if deployment.Spec.Replicas != nil {
+		status.WorkerReplicas.Desired = *deployment.Spec.Replicas
+	}
+	status.WorkerReplicas.Ready = deployment.Status.ReadyReplicas
+	status.WorkerReplicas.Available = deployment.Status.AvailableReplicas
+	return status, nil
+}
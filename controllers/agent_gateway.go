@@ -0,0 +1,386 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/gateway"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/registry"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+const (
+	// gatewayMountPath is where the gateway configuration and the agent tokens are mounted.
+	gatewayMountPath = "/etc/kubeagentic/gateway"
+	// gatewayConfigKey is the key of the gateway ConfigMap holding the models.
+	gatewayConfigKey = "models.json"
+	// gatewayUID is the user of the operator image, which ships the gateway.
+	gatewayUID int64 = 65532
+)
+
+// AgentGatewayReconciler deploys the OpenAI-compatible gateway of each AgentGateway. The
+// models the gateway routes are the running agents of the discovery registry of its
+// namespace, so the gateway configuration follows the agents as they come and go.
+type AgentGatewayReconciler struct {
+	client.Client
+	Scheme *runtime.Scheme
+}
+
+// +kubebuilder:rbac:groups=ai.example.com,resources=agentgateways,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ai.example.com,resources=agentgateways/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=ai.example.com,resources=agents,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments,verbs=get;list;watch;create;update;delete
+// +kubebuilder:rbac:groups=core,resources=services;configmaps,verbs=get;list;watch;create;update;delete
+
+// Reconcile renders the models of the gateway and deploys it.
+func (r *AgentGatewayReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	gw := &aiv1.AgentGateway{}
+	if err := r.Get(ctx, req.NamespacedName, gw); err != nil {
+		// Deleted gateways are garbage collected with their resources
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	models, tokenAgents, err := r.gatewayModels(ctx, gw)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	data, err := json.MarshalIndent(gateway.Config{Models: models}, "", "  ")
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	name := gatewayName(gw)
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: gw.Namespace, Labels: gatewayLabels(gw)},
+		Data:       map[string]string{gatewayConfigKey: string(data)},
+	}
+	if err := r.apply(ctx, gw, configMap, func(found client.Object) bool {
+		foundConfigMap := found.(*corev1.ConfigMap)
+		if equality.Semantic.DeepEqual(foundConfigMap.Data, configMap.Data) {
+			return false
+		}
+		foundConfigMap.Data = configMap.Data
+		return true
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile the gateway ConfigMap: %w", err)
+	}
+
+	deployment := buildGatewayDeployment(gw, tokenAgents)
+	if err := r.apply(ctx, gw, deployment, func(found client.Object) bool {
+		foundDeployment := found.(*appsv1.Deployment)
+		if foundDeployment.Annotations[templateHashAnnotation] == deployment.Annotations[templateHashAnnotation] &&
+			equality.Semantic.DeepEqual(foundDeployment.Spec.Replicas, deployment.Spec.Replicas) {
+			return false
+		}
+		if foundDeployment.Annotations == nil {
+			foundDeployment.Annotations = map[string]string{}
+		}
+		foundDeployment.Annotations[templateHashAnnotation] = deployment.Annotations[templateHashAnnotation]
+		foundDeployment.Spec = deployment.Spec
+		return true
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile the gateway Deployment: %w", err)
+	}
+
+	service := buildGatewayService(gw)
+	if err := r.apply(ctx, gw, service, func(found client.Object) bool {
+		foundService := found.(*corev1.Service)
+		if foundService.Spec.Type == service.Spec.Type && equality.Semantic.DeepDerivative(service.Spec.Ports, foundService.Spec.Ports) {
+			return false
+		}
+		foundService.Spec.Type = service.Spec.Type
+		foundService.Spec.Ports = service.Spec.Ports
+		return true
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to reconcile the gateway Service: %w", err)
+	}
+
+	status := aiv1.AgentGatewayStatus{
+		URL:                fmt.Sprintf("http://%s.%s.svc.cluster.local/v1", name, gw.Namespace),
+		ObservedGeneration: gw.Generation,
+	}
+	for _, model := range models {
+		status.Models = append(status.Models, model.Name)
+	}
+	foundDeployment := &appsv1.Deployment{}
+	if err := r.Get(ctx, types.NamespacedName{Name: name, Namespace: gw.Namespace}, foundDeployment); err == nil {
+		status.ReadyReplicas = foundDeployment.Status.ReadyReplicas
+	} else if !errors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+	if !equality.Semantic.DeepEqual(gw.Status, status) {
+		gw.Status = status
+		if err := r.Status().Update(ctx, gw); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// gatewayModels returns the models the gateway routes, sorted by name, and the agents whose
+// endpoint token the gateway mounts.
+func (r *AgentGatewayReconciler) gatewayModels(ctx context.Context, gw *aiv1.AgentGateway) ([]gateway.Model, []string, error) {
+	entries, err := registry.Read(ctx, r, gw.Namespace, registry.ConfigMapName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read the agent registry: %w", err)
+	}
+	exposed := map[string]bool{}
+	for _, name := range gw.Spec.Agents {
+		exposed[name] = true
+	}
+
+	var models []gateway.Model
+	var tokenAgents []string
+	for _, entry := range entries {
+		if entry.Namespace != gw.Namespace || len(exposed) > 0 && !exposed[entry.Name] {
+			continue
+		}
+		agent := &aiv1.Agent{}
+		if err := r.Get(ctx, types.NamespacedName{Name: entry.Name, Namespace: gw.Namespace}, agent); errors.IsNotFound(err) {
+			// The registry lists the agent until it catches up with its deletion
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		model := gateway.Model{Name: entry.Name, URL: entry.URL}
+		if endpointAuthEnabled(agent) {
+			model.TokenFile = fmt.Sprintf("%s/%s/token", gatewayMountPath, entry.Name)
+			tokenAgents = append(tokenAgents, entry.Name)
+		}
+		models = append(models, model)
+	}
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	sort.Strings(tokenAgents)
+	return models, tokenAgents, nil
+}
+
+// apply creates obj, owned by the gateway, or updates the existing object when update
+// changes it.
+func (r *AgentGatewayReconciler) apply(ctx context.Context, gw *aiv1.AgentGateway, obj client.Object, update func(found client.Object) bool) error {
+	if err := controllerutil.SetControllerReference(gw, obj, r.Scheme); err != nil {
+		return err
+	}
+	found := obj.DeepCopyObject().(client.Object)
+	err := r.Get(ctx, client.ObjectKeyFromObject(obj), found)
+	if errors.IsNotFound(err) {
+		log.FromContext(ctx).Info("Creating gateway resource", "Kind", fmt.Sprintf("%T", obj), "Name", obj.GetName())
+		return r.Create(ctx, obj)
+	} else if err != nil {
+		return err
+	}
+	if !update(found) {
+		return nil
+	}
+	log.FromContext(ctx).Info("Updating gateway resource", "Kind", fmt.Sprintf("%T", obj), "Name", obj.GetName())
+	return r.Update(ctx, found)
+}
+
+// gatewayName returns the name of the Deployment, Service and ConfigMap of the gateway.
+func gatewayName(gw *aiv1.AgentGateway) string {
+	return naming.Child(gw.Name, "gateway")
+}
+
+// gatewayLabels returns the labels of the gateway resources.
+func gatewayLabels(gw *aiv1.AgentGateway) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       "kubeagentic-gateway",
+		"app.kubernetes.io/instance":   gw.Name,
+		"app.kubernetes.io/component":  "gateway",
+		"app.kubernetes.io/managed-by": "kubeagentic",
+	}
+}
+
+// getGatewayImage returns the gateway image: the spec, then the operator environment, then
+// the operator image, which ships the gateway.
+func getGatewayImage(gw *aiv1.AgentGateway) string {
+	if gw.Spec.Image != "" {
+		return gw.Spec.Image
+	}
+	if envImage := os.Getenv("GATEWAY_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return "kubeagentic/operator:latest"
+}
+
+// buildGatewayDeployment creates the Deployment running the gateway. The configuration is
+// projected with the endpoint tokens of the agents requiring one; the gateway reloads the
+// configuration when it changes, so only agents gaining or losing a token roll the pods.
+func buildGatewayDeployment(gw *aiv1.AgentGateway, tokenAgents []string) *appsv1.Deployment {
+	labels := gatewayLabels(gw)
+	replicas := int32(1)
+	if gw.Spec.Replicas != nil {
+		replicas = *gw.Spec.Replicas
+	}
+
+	sources := []corev1.VolumeProjection{
+		{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: gatewayName(gw)},
+				Items:                []corev1.KeyToPath{{Key: gatewayConfigKey, Path: gatewayConfigKey}},
+			},
+		},
+	}
+	optional := true
+	for _, agent := range tokenAgents {
+		sources = append(sources, corev1.VolumeProjection{
+			Secret: &corev1.SecretProjection{
+				LocalObjectReference: corev1.LocalObjectReference{Name: agentEndpointAuthSecretName(agent)},
+				Items:                []corev1.KeyToPath{{Key: endpointAuthTokenKey, Path: agent + "/token"}},
+				Optional:             &optional,
+			},
+		})
+	}
+
+	probe := func(period int32) *corev1.Probe {
+		return &corev1.Probe{
+			ProbeHandler: corev1.ProbeHandler{
+				HTTPGet: &corev1.HTTPGetAction{Path: "/healthz", Port: intstr.FromInt(8080)},
+			},
+			PeriodSeconds: period,
+		}
+	}
+
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName(gw),
+			Namespace: gw.Namespace,
+			Labels:    labels,
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: labels,
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: labels,
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    "gateway",
+							Image:   getGatewayImage(gw),
+							Command: []string{"/gateway"},
+							Args:    []string{"--config", gatewayMountPath + "/" + gatewayConfigKey},
+							Ports: []corev1.ContainerPort{
+								{Name: "http", ContainerPort: 8080, Protocol: corev1.ProtocolTCP},
+							},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("32Mi"),
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceMemory: resource.MustParse("128Mi"),
+									corev1.ResourceCPU:    resource.MustParse("500m"),
+								},
+							},
+							ReadinessProbe: probe(5),
+							LivenessProbe:  probe(20),
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "config", MountPath: gatewayMountPath, ReadOnly: true},
+							},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "config",
+							VolumeSource: corev1.VolumeSource{
+								Projected: &corev1.ProjectedVolumeSource{
+									Sources:     sources,
+									DefaultMode: &peersFileMode,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	securityprofile.Apply(securityprofile.Default(), &deployment.Spec.Template.Spec, gatewayUID)
+	// The hash of the template detects changes, as the API server defaults the stored one
+	deployment.Annotations = map[string]string{templateHashAnnotation: podTemplateHash(&deployment.Spec.Template)}
+	return deployment
+}
+
+// buildGatewayService creates the Service exposing the gateway on port 80.
+func buildGatewayService(gw *aiv1.AgentGateway) *corev1.Service {
+	serviceType := gw.Spec.ServiceType
+	if serviceType == "" {
+		serviceType = corev1.ServiceTypeClusterIP
+	}
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gatewayName(gw),
+			Namespace: gw.Namespace,
+			Labels:    gatewayLabels(gw),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: gatewayLabels(gw),
+			Ports: []corev1.ServicePort{
+				{
+					Name:       "http",
+					Port:       80,
+					TargetPort: intstr.FromInt(8080),
+					Protocol:   corev1.ProtocolTCP,
+				},
+			},
+		},
+	}
+}
+
+// gatewaysInNamespace maps a change of the registry or of an agent to the gateways of its
+// namespace.
+func (r *AgentGatewayReconciler) gatewaysInNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	var gateways aiv1.AgentGatewayList
+	if err := r.List(ctx, &gateways, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agent gateways")
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(gateways.Items))
+	for _, gw := range gateways.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: gw.Name, Namespace: gw.Namespace}})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *AgentGatewayReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isRegistry := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == registry.ConfigMapName
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&aiv1.AgentGateway{}).
+		Owns(&appsv1.Deployment{}).
+		Owns(&corev1.Service{}).
+		Owns(&corev1.ConfigMap{}).
+		// The registry lists the running agents, and the agents enable their endpoint auth
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.gatewaysInNamespace), builder.WithPredicates(isRegistry)).
+		Watches(&aiv1.Agent{}, handler.EnqueueRequestsFromMapFunc(r.gatewaysInNamespace), builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Complete(r)
+}
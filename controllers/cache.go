@@ -0,0 +1,269 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/client-go/rest"
+	toolscache "k8s.io/client-go/tools/cache"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// managedNames are the app.kubernetes.io/name labels of the objects the operator creates.
+var managedNames = []string{
+	"kubeagentic-agent",
+	"kubeagentic-worker",
+	"kubeagentic-router",
+	"kubeagentic-redis",
+	"kubeagentic-gateway",
+	"kubeagentic-job",
+	"kubeagentic-model-cache",
+	registryName,
+	fleetDashboardName,
+	guardrailsPolicyName,
+	examplesConfigMapName,
+}
+
+// registryName is the app.kubernetes.io/name label of the agent registry ConfigMaps.
+const registryName = "kubeagentic-registry"
+
+// strippedAnnotations are the annotations removed from cached objects, as they can be as
+// large as the object itself and the operator never reads them.
+var strippedAnnotations = []string{
+	"kubectl.kubernetes.io/last-applied-configuration",
+}
+
+// ManagedSelector selects the objects created by the operator.
+func ManagedSelector() labels.Selector {
+	requirement, err := labels.NewRequirement("app.kubernetes.io/name", selection.In, managedNames)
+	if err != nil {
+		panic(err)
+	}
+	return labels.NewSelector().Add(*requirement)
+}
+
+// labelFilteredObjects returns the kinds owned by the reconcilers, which are only cached
+// when selected by ManagedSelector.
+func labelFilteredObjects() []client.Object {
+	return []client.Object{
+		&appsv1.Deployment{},
+		&corev1.Service{},
+		&corev1.ConfigMap{},
+		&batchv1.Job{},
+		&batchv1.CronJob{},
+		&networkingv1.NetworkPolicy{},
+		&policyv1.PodDisruptionBudget{},
+	}
+}
+
+// labelFiltered reports whether only the labelled objects of the kind of obj are cached.
+func labelFiltered(obj client.Object) bool {
+	switch obj.(type) {
+	case *appsv1.Deployment, *corev1.Service, *corev1.ConfigMap, *batchv1.Job, *batchv1.CronJob,
+		*networkingv1.NetworkPolicy, *policyv1.PodDisruptionBudget:
+		return true
+	}
+	return false
+}
+
+// CacheOptions returns the options of the manager cache. The Owns watches of the reconcilers
+// would otherwise cache every Deployment, Service and ConfigMap of the cluster, so only the
+// objects labelled by the operator are cached for the owned kinds. The ConfigMaps of the
+// operator namespace, such as the pricing catalog, are cached whatever their labels.
+func CacheOptions(operatorNamespace string) cache.Options {
+	selector := ManagedSelector()
+	byObject := map[client.Object]cache.ByObject{}
+	for _, obj := range labelFilteredObjects() {
+		// The options are keyed by pointer, so the ConfigMaps must not have a second entry
+		if _, ok := obj.(*corev1.ConfigMap); !ok {
+			byObject[obj] = cache.ByObject{Label: selector}
+		}
+	}
+	byObject[&corev1.ConfigMap{}] = cache.ByObject{
+		Label: selector,
+		Namespaces: map[string]cache.Config{
+			cache.AllNamespaces: {},
+			operatorNamespace:   {LabelSelector: labels.Everything()},
+		},
+	}
+	return cache.Options{
+		ByObject:         byObject,
+		DefaultTransform: StripCachedObject,
+	}
+}
+
+// NewReferencedCache returns a cache started with mgr, for the metadata of the objects users
+// create for the agents, such as the ConfigMaps they ingest. These are not labelled, so the
+// manager cache drops them for the label-filtered kinds; their metadata is enough to map
+// their events to the agents, which read them through the API server fallback of NewClient.
+func NewReferencedCache(mgr ctrl.Manager) (cache.Cache, error) {
+	referenced, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:           mgr.GetScheme(),
+		Mapper:           mgr.GetRESTMapper(),
+		DefaultTransform: StripCachedObject,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return referenced, mgr.Add(referenced)
+}
+
+// ConfigMapMetadata returns the object the metadata of ConfigMaps is watched through.
+func ConfigMapMetadata() *metav1.PartialObjectMetadata {
+	configMap := &metav1.PartialObjectMetadata{}
+	configMap.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	return configMap
+}
+
+// StripCachedObject removes the managed fields and the strippedAnnotations from objects
+// before they are cached. Updates of a cached object leave its managed fields as they are,
+// but drop the stripped annotations; the annotations of the Agents, AgentPolicies and
+// AgentGateways, which users apply, are kept.
+func StripCachedObject(in interface{}) (interface{}, error) {
+	obj, err := meta.Accessor(in)
+	if err != nil {
+		// Tombstones of deleted objects are passed as is
+		return in, nil
+	}
+	obj.SetManagedFields(nil)
+	switch in.(type) {
+	case *aiv1.Agent, *aiv1.AgentPolicy, *aiv1.AgentGateway:
+		return in, nil
+	}
+	if annotations := obj.GetAnnotations(); annotations != nil {
+		for _, key := range strippedAnnotations {
+			delete(annotations, key)
+		}
+		obj.SetAnnotations(annotations)
+	}
+	return in, nil
+}
+
+var _ toolscache.TransformFunc = StripCachedObject
+
+// NewClient creates the manager client. It reads from the cache, and reads the objects of
+// the label-filtered kinds missing from the cache from the API server once, so that the
+// children created unlabelled by earlier operator builds are found and adopted.
+func NewClient(config *rest.Config, options client.Options) (client.Client, error) {
+	cached, err := client.New(config, options)
+	if err != nil {
+		return nil, err
+	}
+	options.Cache = nil
+	apiReader, err := client.New(config, options)
+	if err != nil {
+		return nil, err
+	}
+	return NewCacheFallbackClient(cached, apiReader), nil
+}
+
+// NewCacheFallbackClient returns a client reading the objects of the label-filtered kinds
+// missing from the cache of cached with apiReader.
+func NewCacheFallbackClient(cached client.Client, apiReader client.Reader) client.Client {
+	return &cacheFallbackClient{Client: cached, apiReader: apiReader, absent: map[fallbackKey]bool{}}
+}
+
+type cacheFallbackClient struct {
+	client.Client
+	apiReader client.Reader
+
+	mu sync.Mutex
+	// absent holds the objects the API server did not have either when read after a cache
+	// miss. The operator creates its children labelled, so they are cached from then on.
+	absent map[fallbackKey]bool
+}
+
+type fallbackKey struct {
+	kind string
+	key  client.ObjectKey
+}
+
+// getMode is a client.GetOption selecting how a Get of a label-filtered kind treats a
+// cache miss.
+type getMode int
+
+const (
+	// cacheOnly reads the optional children of an agent, such as a NetworkPolicy read to be
+	// deleted: a cache miss means the child does not exist.
+	cacheOnly getMode = iota + 1
+	// referenced reads the objects the agents reference, such as the ConfigMap of their
+	// examples. Users create them unlabelled, so they are never cached and are read from the
+	// API server on every cache miss.
+	referenced
+)
+
+// ApplyToGet implements client.GetOption; the mode is only read by cacheFallbackClient.
+func (getMode) ApplyToGet(*client.GetOptions) {}
+
+// Get reads obj from the API server when the cache does not hold it and its kind is label
+// filtered, unless the API server did not have it either on an earlier miss or the Get is
+// cacheOnly. Lists are not completed, as the children are listed by their labels.
+func (c *cacheFallbackClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	err := c.Client.Get(ctx, key, obj, opts...)
+	if !apierrors.IsNotFound(err) || !labelFiltered(obj) {
+		return err
+	}
+	mode := getModeOf(opts)
+	if mode == cacheOnly {
+		return err
+	}
+	if mode == referenced {
+		return c.apiReader.Get(ctx, key, obj, opts...)
+	}
+
+	fallback := fallbackKey{kind: fmt.Sprintf("%T", obj), key: key}
+	c.mu.Lock()
+	absent := c.absent[fallback]
+	c.mu.Unlock()
+	if absent {
+		return err
+	}
+	err = c.apiReader.Get(ctx, key, obj, opts...)
+	if apierrors.IsNotFound(err) {
+		c.mu.Lock()
+		c.absent[fallback] = true
+		c.mu.Unlock()
+	}
+	return err
+}
+
+// getModeOf returns the getMode of opts, or 0 when none is set.
+func getModeOf(opts []client.GetOption) getMode {
+	for _, opt := range opts {
+		if mode, ok := opt.(getMode); ok {
+			return mode
+		}
+	}
+	return 0
+}
+
+// adoptLabels adds the desired labels missing from found, so that a child created unlabelled
+// by an earlier operator build is cached once updated.
+func adoptLabels(found client.Object, desired map[string]string) {
+	foundLabels := found.GetLabels()
+	for k, v := range desired {
+		if _, ok := foundLabels[k]; ok {
+			continue
+		}
+		if foundLabels == nil {
+			foundLabels = map[string]string{}
+		}
+		foundLabels[k] = v
+	}
+	found.SetLabels(foundLabels)
+}
@@ -0,0 +1,430 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/clock"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentmetrics"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/costreport"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+)
+
+const (
+	// DefaultCostReportConfigMap is the ConfigMap in the operator namespace holding the cost
+	// report of the current period and of the previous one.
+	DefaultCostReportConfigMap = "kubeagentic-cost-report"
+
+	// DefaultCostReportTeamLabel is the agent or namespace label naming the team of an agent.
+	DefaultCostReportTeamLabel = "kubeagentic.ai/team"
+
+	costReportJSONKey         = "report.json"
+	costReportTextKey         = "report.txt"
+	costReportPreviousJSONKey = "previous-report.json"
+	costReportPreviousTextKey = "previous-report.txt"
+	costReportStateKey        = "state.json"
+)
+
+var (
+	costReportNamespaceSpend = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_cost_report_namespace_spend",
+		Help: "Spend of the agents of a namespace in the current cost report period.",
+	}, []string{"namespace", "currency"})
+	costReportTeamSpend = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_cost_report_team_spend",
+		Help: "Spend of the agents of a team in the current cost report period.",
+	}, []string{"team", "currency"})
+	costReportPartialAgents = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "kubeagentic_cost_report_partial_agents",
+		Help: "Agents whose metrics could not be read completely for the current cost report.",
+	})
+)
+
+func init() {
+	metrics.Registry.MustRegister(costReportNamespaceSpend, costReportTeamSpend, costReportPartialAgents)
+}
+
+// costReportState is the consumption of the agents in the current period, kept in the
+// report ConfigMap between two runs.
+type costReportState struct {
+	PeriodStart time.Time                   `json:"periodStart"`
+	Agents      map[string]*costReportAgent `json:"agents"`
+	// Undelivered is set while the report of the previous period was not posted to the webhook.
+	Undelivered bool `json:"undelivered,omitempty"`
+}
+
+// costReportAgent is the consumption of an agent and the pod counters seen last.
+type costReportAgent struct {
+	Usage costreport.AgentUsage   `json:"usage"`
+	Pods  []aiv1.PodTokenCounters `json:"pods,omitempty"`
+	// Observed is set once the pod counters were recorded, from which tokens are counted.
+	Observed bool `json:"observed,omitempty"`
+}
+
+// CostReporter periodically reports the tokens consumed by all agents and their cost,
+// grouped by namespace and team, in a ConfigMap of the operator namespace. The report of
+// each completed period is kept and optionally posted to a webhook. Tokens are counted
+// from when the reporter first sees an agent.
+type CostReporter struct {
+	client.Client
+
+	// Namespace is the operator namespace holding the report ConfigMap.
+	Namespace string
+	// ConfigMapName is the name of the report ConfigMap. DefaultCostReportConfigMap is used
+	// when empty.
+	ConfigMapName string
+	// Period is the report period: daily, weekly or monthly. Defaults to weekly.
+	Period string
+	// Interval is how often the report is updated. Defaults to 1h.
+	Interval time.Duration
+	// TeamLabel names the team of an agent, read from the agent and then from its
+	// namespace. DefaultCostReportTeamLabel is used when empty.
+	TeamLabel string
+	// WebhookURL receives the JSON report of each completed period when set.
+	WebhookURL string
+
+	// HTTPClient posts reports to the webhook. http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// Scraper reads the token counters of the agent pods. A default scraper is used when nil.
+	Scraper *agentmetrics.Scraper
+	// Clock tells the report period. The real clock is used when nil.
+	Clock clock.PassiveClock
+}
+
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// Start updates the report periodically until the context is done.
+func (c *CostReporter) Start(ctx context.Context) error {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := c.Sync(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to update cost report")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection runs the reporter on the leader only.
+func (c *CostReporter) NeedLeaderElection() bool {
+	return true
+}
+
+func (c *CostReporter) configMapName() string {
+	if c.ConfigMapName != "" {
+		return c.ConfigMapName
+	}
+	return DefaultCostReportConfigMap
+}
+
+func (c *CostReporter) period() string {
+	if c.Period != "" {
+		return c.Period
+	}
+	return "weekly"
+}
+
+func (c *CostReporter) teamLabel() string {
+	if c.TeamLabel != "" {
+		return c.TeamLabel
+	}
+	return DefaultCostReportTeamLabel
+}
+
+func (c *CostReporter) now() time.Time {
+	if c.Clock != nil {
+		return c.Clock.Now()
+	}
+	return time.Now()
+}
+
+// Sync adds the tokens consumed since the last run to the report of the current period.
+// When the period has ended, its report is kept as the previous report and a new period
+// starts.
+func (c *CostReporter) Sync(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithValues("costReport", c.configMapName())
+	now := c.now()
+	start, end := budgetPeriodBounds(c.period(), now)
+
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: c.configMapName(), Namespace: c.Namespace}, cm)
+	exists := err == nil
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: c.configMapName(), Namespace: c.Namespace}}
+	} else if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+
+	state := &costReportState{}
+	if data := cm.Data[costReportStateKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), state); err != nil {
+			logger.Error(err, "Discarding unreadable cost report state")
+			state = &costReportState{}
+		}
+	}
+	if state.Agents == nil {
+		state.Agents = map[string]*costReportAgent{}
+	}
+
+	if !state.PeriodStart.IsZero() && !state.PeriodStart.Equal(start) {
+		// Close the previous period; its pod counters are the baseline of the new one
+		previousStart, previousEnd := budgetPeriodBounds(c.period(), state.PeriodStart)
+		previous := c.report(state, previousStart, previousEnd, now)
+		if err := writeCostReport(cm, previous, costReportPreviousJSONKey, costReportPreviousTextKey); err != nil {
+			return err
+		}
+		logger.Info("Cost report period completed", "periodStart", previousStart, "spend", previous.Total.Cost)
+		for _, agent := range state.Agents {
+			agent.Usage = costreport.AgentUsage{Namespace: agent.Usage.Namespace, Name: agent.Usage.Name}
+		}
+		state.Undelivered = c.WebhookURL != ""
+	}
+	state.PeriodStart = start
+
+	if err := c.collect(ctx, state); err != nil {
+		return err
+	}
+
+	report := c.report(state, start, end, now)
+	if err := writeCostReport(cm, report, costReportJSONKey, costReportTextKey); err != nil {
+		return err
+	}
+
+	if state.Undelivered {
+		if err := c.deliver(ctx, []byte(cm.Data[costReportPreviousJSONKey])); err != nil {
+			logger.Error(err, "Failed to post cost report, retrying at the next update", "url", c.WebhookURL)
+		} else {
+			logger.Info("Posted cost report", "url", c.WebhookURL)
+			state.Undelivered = false
+		}
+	}
+
+	stateJSON, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	cm.Data[costReportStateKey] = string(stateJSON)
+
+	costReportNamespaceSpend.Reset()
+	costReportTeamSpend.Reset()
+	for _, group := range report.Namespaces {
+		costReportNamespaceSpend.WithLabelValues(group.Name, report.Currency).Set(group.Cost)
+	}
+	for _, group := range report.Teams {
+		costReportTeamSpend.WithLabelValues(group.Name, report.Currency).Set(group.Cost)
+	}
+	costReportPartialAgents.Set(float64(report.Total.PartialAgents))
+
+	if !exists {
+		return c.Create(ctx, cm)
+	}
+	return c.Update(ctx, cm)
+}
+
+// report returns the report of the consumption in state.
+func (c *CostReporter) report(state *costReportState, start, end, now time.Time) *costreport.Report {
+	agents := make([]costreport.AgentUsage, 0, len(state.Agents))
+	for _, agent := range state.Agents {
+		agents = append(agents, agent.Usage)
+	}
+	return costreport.New(c.period(), start, end, now, pricing.Currency, c.teamLabel(), agents)
+}
+
+// collect adds the tokens each agent consumed since the last run to its usage. Agents seen
+// for the first time only have their counters recorded; deleted agents keep the usage they
+// had until they were deleted.
+func (c *CostReporter) collect(ctx context.Context, state *costReportState) error {
+	var agents aiv1.AgentList
+	if err := c.List(ctx, &agents); err != nil {
+		return err
+	}
+	var namespaces corev1.NamespaceList
+	if err := c.List(ctx, &namespaces); err != nil {
+		return err
+	}
+	namespaceTeams := map[string]string{}
+	for _, ns := range namespaces.Items {
+		namespaceTeams[ns.Name] = ns.Labels[c.teamLabel()]
+	}
+
+	seen := map[string]bool{}
+	for i := range agents.Items {
+		agent := &agents.Items[i]
+		key := fleetKey(agent)
+		seen[key] = true
+
+		entry := state.Agents[key]
+		if entry == nil {
+			entry = &costReportAgent{Usage: costreport.AgentUsage{Namespace: agent.Namespace, Name: agent.Name}}
+			state.Agents[key] = entry
+		}
+		counters, unreachable, total, err := c.scrape(ctx, agent, entry.Pods)
+		usage := &entry.Usage
+		usage.Provider, usage.Model = agent.Spec.Provider, agent.Spec.Model
+		usage.Team = agent.Labels[c.teamLabel()]
+		if usage.Team == "" {
+			usage.Team = namespaceTeams[agent.Namespace]
+		}
+		usage.Partial, usage.PartialReason = false, ""
+		switch {
+		case err != nil:
+			usage.Partial, usage.PartialReason = true, err.Error()
+			continue
+		case unreachable > 0:
+			usage.Partial, usage.PartialReason = true, fmt.Sprintf("metrics of %d of %d running pods unreachable", unreachable, total)
+		}
+
+		if entry.Observed {
+			last := map[string]aiv1.PodTokenCounters{}
+			for _, pod := range entry.Pods {
+				last[pod.Pod] = pod
+			}
+			var prompt, completion int64
+			for _, pod := range counters {
+				previous, ok := last[pod.Pod]
+				if !ok || pod.PromptTokens < previous.PromptTokens || pod.CompletionTokens < previous.CompletionTokens {
+					// A new or restarted pod is charged for all its tokens
+					previous = aiv1.PodTokenCounters{}
+				}
+				prompt += pod.PromptTokens - previous.PromptTokens
+				completion += pod.CompletionTokens - previous.CompletionTokens
+			}
+			usage.PromptTokens += prompt
+			usage.CompletionTokens += completion
+			cost, err := pricing.CostFor(agent.Spec.Provider, agent.Spec.Model, prompt, completion)
+			if errors.Is(err, pricing.ErrUnknownModel) {
+				usage.UnpricedTokens += prompt + completion
+			} else if err != nil {
+				return err
+			}
+			usage.Cost += cost
+		}
+		entry.Pods = counters
+		entry.Observed = true
+	}
+
+	// Deleted agents stay in the report of the period with what they consumed until then
+	for key, entry := range state.Agents {
+		if seen[key] {
+			continue
+		}
+		if entry.Usage.PromptTokens+entry.Usage.CompletionTokens == 0 {
+			delete(state.Agents, key)
+			continue
+		}
+		entry.Pods = nil
+		entry.Usage.Partial, entry.Usage.PartialReason = false, ""
+	}
+	return nil
+}
+
+// scrape returns the token counters of the running pods of agent, with the number of pods
+// whose metrics could not be read and the number of running pods. Unreachable pods keep
+// their last counters, to be charged at the next run.
+func (c *CostReporter) scrape(ctx context.Context, agent *aiv1.Agent, last []aiv1.PodTokenCounters) ([]aiv1.PodTokenCounters, int, int, error) {
+	var pods corev1.PodList
+	if err := c.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels{"kubeagentic.ai/agent": agent.Name}); err != nil {
+		return nil, 0, 0, err
+	}
+	// The agent reconciler reads the endpoint token of protected agents
+	token, err := (&AgentReconciler{Client: c.Client}).endpointToken(ctx, agent)
+	if err != nil {
+		return last, 0, 0, err
+	}
+
+	lastByPod := map[string]aiv1.PodTokenCounters{}
+	for _, counters := range last {
+		lastByPod[counters.Pod] = counters
+	}
+	scraper := c.Scraper
+	if scraper == nil {
+		scraper = &agentmetrics.Scraper{}
+	}
+	var counters []aiv1.PodTokenCounters
+	unreachable, total := 0, 0
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			if previous, ok := lastByPod[pod.Name]; ok {
+				counters = append(counters, previous)
+			}
+			continue
+		}
+		total++
+		sample, err := scraper.Scrape(ctx, fmt.Sprintf("http://%s:8080", pod.Status.PodIP), token)
+		if err != nil {
+			log.FromContext(ctx).V(1).Info("Failed to scrape pod token counters", "Pod.Name", pod.Name, "error", err.Error())
+			unreachable++
+			if previous, ok := lastByPod[pod.Name]; ok {
+				counters = append(counters, previous)
+			}
+			continue
+		}
+		counters = append(counters, aiv1.PodTokenCounters{
+			Pod:              pod.Name,
+			PromptTokens:     int64(sample.PromptTokens),
+			CompletionTokens: int64(sample.CompletionTokens),
+		})
+	}
+	return counters, unreachable, total, nil
+}
+
+// deliver posts a JSON report to the webhook.
+func (c *CostReporter) deliver(ctx context.Context, report []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.WebhookURL, bytes.NewReader(report))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	return nil
+}
+
+// writeCostReport stores the JSON and text renderings of report under the given keys.
+func writeCostReport(cm *corev1.ConfigMap, report *costreport.Report, jsonKey, textKey string) error {
+	data, err := report.JSON()
+	if err != nil {
+		return err
+	}
+	cm.Data[jsonKey] = string(data)
+	cm.Data[textKey] = report.Text()
+	return nil
+}
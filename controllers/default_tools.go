@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/defaulttools"
+)
+
+const (
+	// DefaultToolsConfigMap is the ConfigMap in the operator namespace listing the tools
+	// injected into every agent.
+	DefaultToolsConfigMap = "kubeagentic-default-tools"
+
+	defaultToolsKey = "tools.yaml"
+)
+
+var defaultToolsParseFailures = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeagentic_default_tools_parse_failures_total",
+	Help: "Default tools ConfigMaps rejected because they could not be parsed.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(defaultToolsParseFailures)
+}
+
+// DefaultToolsReconciler loads the tools that the defaulting webhook injects into the
+// agents from the DefaultToolsConfigMap whenever it changes. Without the ConfigMap no tools
+// are injected. A ConfigMap that cannot be parsed is logged and counted, and the tools
+// loaded last stay in use.
+type DefaultToolsReconciler struct {
+	client.Client
+
+	// Namespace is the operator namespace holding the DefaultToolsConfigMap.
+	Namespace string
+}
+
+// Reconcile loads the default tools.
+func (r *DefaultToolsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, req.NamespacedName, cm)
+	if errors.IsNotFound(err) {
+		logger.Info("Default tools ConfigMap not found, injecting no default tools")
+		defaulttools.Set(nil)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	tools, err := defaulttools.Parse([]byte(cm.Data[defaultToolsKey]))
+	if err != nil {
+		// Retrying does not help until the ConfigMap is fixed, which triggers a new load
+		defaultToolsParseFailures.Inc()
+		logger.Error(err, "Ignoring the default tools ConfigMap, the previous default tools stay in use",
+			"ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name, "key", defaultToolsKey)
+		return ctrl.Result{}, nil
+	}
+	defaulttools.Set(tools)
+	logger.Info("Loaded default tools", "tools", len(tools))
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *DefaultToolsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isDefaultTools := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == DefaultToolsConfigMap && obj.GetNamespace() == r.Namespace
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("defaulttools").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(isDefaultTools)).
+		Complete(r)
+}
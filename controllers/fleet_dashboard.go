@@ -0,0 +1,144 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/fleetdashboard"
+)
+
+const (
+	// DefaultFleetDashboardConfigMap is the ConfigMap holding the fleet dashboard.
+	DefaultFleetDashboardConfigMap = "kubeagentic-fleet-dashboard"
+
+	// DefaultFleetDashboardFolder is the Grafana folder of the fleet dashboard.
+	DefaultFleetDashboardFolder = "KubeAgentic"
+
+	// FleetDashboardFolderAnnotation names the Grafana folder of the dashboard for the
+	// dashboard sidecar of the Grafana chart, configured with folderAnnotation.
+	FleetDashboardFolderAnnotation = "grafana_folder"
+
+	fleetDashboardName = "kubeagentic-dashboard"
+)
+
+// FleetDashboard keeps the Grafana dashboard of the agent fleet in a ConfigMap labelled with
+// grafana_dashboard, which the dashboard sidecar of Grafana loads. The dashboard charts the
+// metrics of the operator and is rewritten when it differs from the one this operator build
+// renders, so upgrades bring the new panels.
+type FleetDashboard struct {
+	client.Client
+
+	// Namespace holds the dashboard ConfigMap.
+	Namespace string
+	// ConfigMapName is the name of the dashboard ConfigMap. DefaultFleetDashboardConfigMap
+	// is used when empty.
+	ConfigMapName string
+	// Folder is the Grafana folder of the dashboard. DefaultFleetDashboardFolder is used
+	// when empty.
+	Folder string
+	// TopN is how many agents the top spender tables list.
+	TopN int
+	// Interval is how often the ConfigMap is checked. Defaults to 10m.
+	Interval time.Duration
+}
+
+// Start keeps the dashboard up to date until the context is done.
+func (d *FleetDashboard) Start(ctx context.Context) error {
+	interval := d.Interval
+	if interval <= 0 {
+		interval = 10 * time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := d.Sync(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to update fleet dashboard")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection runs the dashboard on the leader only.
+func (d *FleetDashboard) NeedLeaderElection() bool {
+	return true
+}
+
+func (d *FleetDashboard) configMapName() string {
+	if d.ConfigMapName != "" {
+		return d.ConfigMapName
+	}
+	return DefaultFleetDashboardConfigMap
+}
+
+func (d *FleetDashboard) folder() string {
+	if d.Folder != "" {
+		return d.Folder
+	}
+	return DefaultFleetDashboardFolder
+}
+
+// Sync creates the dashboard ConfigMap, or updates it when its dashboard, labels or folder
+// changed.
+func (d *FleetDashboard) Sync(ctx context.Context) error {
+	dashboard, err := fleetdashboard.Render(fleetdashboard.Options{TopN: d.TopN})
+	if err != nil {
+		return err
+	}
+	labels := map[string]string{
+		"app.kubernetes.io/name":       fleetDashboardName,
+		"app.kubernetes.io/managed-by": "kubeagentic",
+		"grafana_dashboard":            "1",
+	}
+	data := map[string]string{fleetdashboard.Key: string(dashboard)}
+
+	cm := &corev1.ConfigMap{}
+	err = d.Get(ctx, types.NamespacedName{Name: d.configMapName(), Namespace: d.Namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        d.configMapName(),
+				Namespace:   d.Namespace,
+				Labels:      labels,
+				Annotations: map[string]string{FleetDashboardFolderAnnotation: d.folder()},
+			},
+			Data: data,
+		}
+		log.FromContext(ctx).Info("Creating fleet dashboard", "namespace", d.Namespace, "configmap", cm.Name)
+		return d.Create(ctx, cm)
+	} else if err != nil {
+		return err
+	}
+
+	changed := !equality.Semantic.DeepEqual(cm.Data, data) || cm.Annotations[FleetDashboardFolderAnnotation] != d.folder()
+	if cm.Labels == nil {
+		cm.Labels = map[string]string{}
+	}
+	for key, value := range labels {
+		if cm.Labels[key] != value {
+			cm.Labels[key] = value
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	if cm.Annotations == nil {
+		cm.Annotations = map[string]string{}
+	}
+	cm.Annotations[FleetDashboardFolderAnnotation] = d.folder()
+	cm.Data = data
+	log.FromContext(ctx).Info("Updating fleet dashboard", "namespace", d.Namespace, "configmap", cm.Name)
+	return d.Update(ctx, cm)
+}
@@ -0,0 +1,403 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// FleetRolloutConfigMap is the ConfigMap in the operator namespace holding the state of
+	// the fleet rollout. Setting its "paused" key to "true" halts the rollout.
+	FleetRolloutConfigMap = "kubeagentic-fleet-rollout"
+
+	fleetTargetImageKey   = "targetImage"
+	fleetPreviousImageKey = "previousImage"
+	fleetUpdatedKey       = "updated"
+	fleetPausedKey        = "paused"
+	fleetLastBatchKey     = "lastBatchTime"
+	fleetMessageKey       = "message"
+
+	// DefaultFleetPriorityLabel orders the namespaces of a fleet rollout: namespaces with a
+	// lower value are updated first, namespaces without the label last.
+	DefaultFleetPriorityLabel = "kubeagentic.ai/rollout-priority"
+)
+
+// defaultAgentImage returns the operator-wide default agent image, the image of the direct
+// framework.
+func defaultAgentImage() string {
+	if envImage := os.Getenv("AGENT_IMAGE"); envImage != "" {
+		return envImage
+	}
+	return "kubeagentic/agent:latest"
+}
+
+// frameworkImage returns the operator default image of the agents of the framework. The
+// LangGraph runtime is much heavier than the direct one, so it may have its own image, set
+// with the AGENT_IMAGE_LANGGRAPH environment variable; other frameworks run the default
+// agent image.
+func frameworkImage(framework string) string {
+	if framework == "langgraph" {
+		if envImage := os.Getenv("AGENT_IMAGE_LANGGRAPH"); envImage != "" {
+			return envImage
+		}
+	}
+	return defaultAgentImage()
+}
+
+// followsFleetRollout reports whether the agent runs the default agent image, whose changes
+// are rolled out to the fleet in batches. Agents with spec.image or a framework image of
+// their own are not affected.
+func followsFleetRollout(agent *aiv1.Agent) bool {
+	return agent.Spec.Image == "" && frameworkImage(agent.Spec.Framework) == defaultAgentImage()
+}
+
+// fleetState is the state of the fleet rollout kept in the FleetRolloutConfigMap.
+type fleetState struct {
+	targetImage   string
+	previousImage string
+	updated       map[string]bool
+	paused        bool
+	lastBatch     time.Time
+	message       string
+}
+
+// parseFleetState reads the fleet rollout state from its ConfigMap.
+func parseFleetState(cm *corev1.ConfigMap) *fleetState {
+	state := &fleetState{
+		targetImage:   cm.Data[fleetTargetImageKey],
+		previousImage: cm.Data[fleetPreviousImageKey],
+		updated:       map[string]bool{},
+		paused:        cm.Data[fleetPausedKey] == "true",
+		message:       cm.Data[fleetMessageKey],
+	}
+	for _, key := range strings.Fields(cm.Data[fleetUpdatedKey]) {
+		state.updated[key] = true
+	}
+	if t, err := time.Parse(time.RFC3339, cm.Data[fleetLastBatchKey]); err == nil {
+		state.lastBatch = t
+	}
+	return state
+}
+
+// write stores the fleet rollout state in its ConfigMap.
+func (s *fleetState) write(cm *corev1.ConfigMap) {
+	keys := make([]string, 0, len(s.updated))
+	for key := range s.updated {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[fleetTargetImageKey] = s.targetImage
+	cm.Data[fleetPreviousImageKey] = s.previousImage
+	cm.Data[fleetUpdatedKey] = strings.Join(keys, "\n")
+	cm.Data[fleetPausedKey] = strconv.FormatBool(s.paused)
+	cm.Data[fleetMessageKey] = s.message
+	if s.lastBatch.IsZero() {
+		delete(cm.Data, fleetLastBatchKey)
+	} else {
+		cm.Data[fleetLastBatchKey] = s.lastBatch.UTC().Format(time.RFC3339)
+	}
+}
+
+// rolling reports whether agents are still being moved to the target image.
+func (s *fleetState) rolling() bool {
+	return s.previousImage != "" && s.previousImage != s.targetImage
+}
+
+// fleetKey identifies an agent in the fleet rollout state.
+func fleetKey(agent *aiv1.Agent) string {
+	return agent.Namespace + "/" + agent.Name
+}
+
+// FleetRolloutCoordinator rolls a changed operator default agent image out to the agents
+// in batches. Agents with spec.image are not affected. Before releasing a batch, it pauses
+// the rollout when too many of the already updated agents are failing.
+type FleetRolloutCoordinator struct {
+	client.Client
+
+	// Namespace is the operator namespace holding the FleetRolloutConfigMap.
+	Namespace string
+	// BatchSize is the number of agents updated at once.
+	BatchSize int
+	// Interval is the time between two batches.
+	Interval time.Duration
+	// MaxFailurePercent is the percentage of failing updated agents that pauses the rollout.
+	MaxFailurePercent int
+	// PriorityLabel is the namespace label ordering the rollout. DefaultFleetPriorityLabel
+	// is used when empty.
+	PriorityLabel string
+	// SyncPeriod is how often the rollout is advanced. Defaults to 30s.
+	SyncPeriod time.Duration
+}
+
+// +kubebuilder:rbac:groups=core,resources=namespaces,verbs=get;list;watch
+
+// Start advances the fleet rollout periodically until the context is done.
+func (c *FleetRolloutCoordinator) Start(ctx context.Context) error {
+	period := c.SyncPeriod
+	if period <= 0 {
+		period = 30 * time.Second
+	}
+	ticker := time.NewTicker(period)
+	defer ticker.Stop()
+	for {
+		if err := c.Sync(ctx); err != nil {
+			log.FromContext(ctx).Error(err, "Failed to advance fleet rollout")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// NeedLeaderElection runs the coordinator on the leader only.
+func (c *FleetRolloutCoordinator) NeedLeaderElection() bool {
+	return true
+}
+
+// Sync starts a rollout when the operator default image changed and releases the next
+// batch of agents once the interval has passed.
+func (c *FleetRolloutCoordinator) Sync(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithValues("fleetRollout", FleetRolloutConfigMap)
+	image := defaultAgentImage()
+
+	cm := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: FleetRolloutConfigMap, Namespace: c.Namespace}, cm)
+	if errors.IsNotFound(err) {
+		// The agents run the current default image; only later changes are coordinated.
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: FleetRolloutConfigMap, Namespace: c.Namespace}}
+		state := &fleetState{targetImage: image, updated: map[string]bool{}, message: fmt.Sprintf("Agents run %s", image)}
+		state.write(cm)
+		logger.Info("Creating fleet rollout state", "image", image)
+		return c.Create(ctx, cm)
+	} else if err != nil {
+		return err
+	}
+
+	state := parseFleetState(cm)
+	if state.targetImage != image {
+		logger.Info("Starting fleet rollout", "image", image, "previousImage", state.targetImage)
+		// Agents keep the image they run until their batch of the new rollout is released.
+		state.previousImage = state.targetImage
+		state.targetImage = image
+		state.updated = map[string]bool{}
+		state.lastBatch = time.Time{}
+		state.message = fmt.Sprintf("Rolling out %s", image)
+	}
+	if !state.rolling() {
+		return c.save(ctx, cm, state)
+	}
+
+	var agents aiv1.AgentList
+	if err := c.List(ctx, &agents); err != nil {
+		return err
+	}
+	var pending, updated []aiv1.Agent
+	for _, agent := range agents.Items {
+		if !followsFleetRollout(&agent) || agent.DeletionTimestamp != nil {
+			continue
+		}
+		if state.updated[fleetKey(&agent)] {
+			updated = append(updated, agent)
+		} else {
+			pending = append(pending, agent)
+		}
+	}
+
+	if len(pending) == 0 {
+		logger.Info("Fleet rollout completed", "image", image, "agents", len(updated))
+		state.previousImage = ""
+		state.updated = map[string]bool{}
+		state.lastBatch = time.Time{}
+		state.message = fmt.Sprintf("Agents run %s", image)
+		return c.save(ctx, cm, state)
+	}
+	if state.paused {
+		if !strings.HasPrefix(state.message, "Paused") {
+			state.message = fmt.Sprintf("Paused with %d of %d agents updated to %s", len(updated), len(updated)+len(pending), image)
+		}
+		return c.save(ctx, cm, state)
+	}
+	if !state.lastBatch.IsZero() && time.Since(state.lastBatch) < c.Interval {
+		return c.save(ctx, cm, state)
+	}
+
+	failing := 0
+	for _, agent := range updated {
+		if agent.Status.Phase != aiv1.AgentPhaseRunning {
+			failing++
+		}
+	}
+	if len(updated) > 0 && failing*100 > c.MaxFailurePercent*len(updated) {
+		logger.Info("Pausing fleet rollout", "failing", failing, "updated", len(updated))
+		state.paused = true
+		state.message = fmt.Sprintf("Paused: %d of %d updated agents are not running; set paused to false to resume", failing, len(updated))
+		return c.save(ctx, cm, state)
+	}
+
+	if err := c.sortByPriority(ctx, pending); err != nil {
+		return err
+	}
+	batch := c.BatchSize
+	if batch < 1 {
+		batch = 1
+	}
+	if batch > len(pending) {
+		batch = len(pending)
+	}
+	for i := range pending[:batch] {
+		state.updated[fleetKey(&pending[i])] = true
+	}
+	state.lastBatch = time.Now()
+	state.message = fmt.Sprintf("Updated %d of %d agents to %s", len(updated)+batch, len(updated)+len(pending), image)
+	logger.Info("Releasing fleet rollout batch", "agents", batch, "remaining", len(pending)-batch)
+	return c.save(ctx, cm, state)
+}
+
+// save writes the state to the ConfigMap when it changed.
+func (c *FleetRolloutCoordinator) save(ctx context.Context, cm *corev1.ConfigMap, state *fleetState) error {
+	before := make(map[string]string, len(cm.Data))
+	for key, value := range cm.Data {
+		before[key] = value
+	}
+	state.write(cm)
+	if len(before) == len(cm.Data) {
+		changed := false
+		for key, value := range cm.Data {
+			if before[key] != value {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return nil
+		}
+	}
+	return c.Update(ctx, cm)
+}
+
+// sortByPriority orders agents by the priority label of their namespace, then by
+// namespace and name.
+func (c *FleetRolloutCoordinator) sortByPriority(ctx context.Context, agents []aiv1.Agent) error {
+	label := c.PriorityLabel
+	if label == "" {
+		label = DefaultFleetPriorityLabel
+	}
+	var namespaces corev1.NamespaceList
+	if err := c.List(ctx, &namespaces); err != nil {
+		return err
+	}
+	priorities := map[string]int{}
+	for _, ns := range namespaces.Items {
+		priority, err := strconv.Atoi(ns.Labels[label])
+		if err != nil {
+			priority = math.MaxInt32
+		}
+		priorities[ns.Name] = priority
+	}
+	priority := func(namespace string) int {
+		if p, ok := priorities[namespace]; ok {
+			return p
+		}
+		return math.MaxInt32
+	}
+
+	sort.SliceStable(agents, func(i, j int) bool {
+		pi, pj := priority(agents[i].Namespace), priority(agents[j].Namespace)
+		if pi != pj {
+			return pi < pj
+		}
+		if agents[i].Namespace != agents[j].Namespace {
+			return agents[i].Namespace < agents[j].Namespace
+		}
+		return agents[i].Name < agents[j].Name
+	})
+	return nil
+}
+
+// reconcileFleetRollout records which operator default image the agent runs. While a
+// fleet rollout is in progress, agents keep the previous default image until their batch
+// is released; agents created during the rollout start with the new one.
+func (r *AgentReconciler) reconcileFleetRollout(ctx context.Context, agent *aiv1.Agent) error {
+	if !followsFleetRollout(agent) || r.OperatorNamespace == "" {
+		agent.Status.FleetRollout = nil
+		return nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, types.NamespacedName{Name: FleetRolloutConfigMap, Namespace: r.OperatorNamespace}, cm)
+	if errors.IsNotFound(err) {
+		agent.Status.FleetRollout = nil
+		return nil
+	} else if err != nil {
+		return err
+	}
+	state := parseFleetState(cm)
+
+	status := &aiv1.FleetRolloutStatus{State: aiv1.FleetRolloutUpdated, Image: state.targetImage}
+	if state.rolling() && !state.updated[fleetKey(agent)] {
+		current := agent.Status.FleetRollout
+		if current == nil || current.Image != state.targetImage {
+			deployment := &appsv1.Deployment{}
+			err := r.Get(ctx, types.NamespacedName{Name: deploymentName(agent), Namespace: agent.Namespace}, deployment)
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+			if err == nil {
+				image := state.previousImage
+				if current != nil && current.Image != "" {
+					image = current.Image
+				}
+				status = &aiv1.FleetRolloutStatus{State: aiv1.FleetRolloutPending, Image: image}
+			}
+		}
+	}
+	agent.Status.FleetRollout = status
+	return nil
+}
+
+// findAgentsForFleetRollout maps changes of the fleet rollout state to the agents running
+// the operator default image.
+func (r *AgentReconciler) findAgentsForFleetRollout(ctx context.Context, cm client.Object) []reconcile.Request {
+	if cm.GetName() != FleetRolloutConfigMap || cm.GetNamespace() != r.OperatorNamespace {
+		return nil
+	}
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list agents for fleet rollout")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(agents.Items))
+	for _, agent := range agents.Items {
+		if !followsFleetRollout(&agent) {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: agent.Name, Namespace: agent.Namespace},
+		})
+	}
+	return requests
+}
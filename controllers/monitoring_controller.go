@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -14,10 +15,27 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
 )
 
+var (
+	fleetAgents = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_agents",
+		Help: "Agents by phase and provider.",
+	}, []string{"phase", "provider"})
+	fleetTokenPrice = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_agent_token_price",
+		Help: "Price of a million tokens of each type of the model of an agent, in " + pricing.Currency + ".",
+	}, []string{"namespace", "agent", "type"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(fleetAgents, fleetTokenPrice)
+}
+
 // MonitoringReconciler handles monitoring and observability for agents
 type MonitoringReconciler struct {
 	client.Client
@@ -40,6 +58,8 @@ func (r *MonitoringReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		return ctrl.Result{}, err
 	}
 
+	updateFleetMetrics(agents.Items)
+
 	// Create or update monitoring resources for each agent
 	for _, agent := range agents.Items {
 		if err := r.setupMonitoringForAgent(ctx, &agent); err != nil {
@@ -52,6 +72,26 @@ func (r *MonitoringReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 	return ctrl.Result{RequeueAfter: time.Minute * 10}, nil
 }
 
+// updateFleetMetrics sets the gauges of the fleet dashboard: the agents by phase and
+// provider, and the token prices of their models, from which the dashboard prices the
+// tokens the agents report. Agents of models without a known price have no price gauge.
+func updateFleetMetrics(agents []aiv1.Agent) {
+	fleetAgents.Reset()
+	fleetTokenPrice.Reset()
+	for _, agent := range agents {
+		phase := agent.Status.Phase
+		if phase == "" {
+			// The agent was not reconciled yet
+			phase = aiv1.AgentPhasePending
+		}
+		fleetAgents.WithLabelValues(string(phase), agent.Spec.Provider).Inc()
+		if price, err := pricing.PriceFor(agent.Spec.Provider, agent.Spec.Model); err == nil {
+			fleetTokenPrice.WithLabelValues(agent.Namespace, agent.Name, "prompt").Set(price.Prompt)
+			fleetTokenPrice.WithLabelValues(agent.Namespace, agent.Name, "completion").Set(price.Completion)
+		}
+	}
+}
+
 // setupMonitoringForAgent sets up monitoring resources for a specific agent
 func (r *MonitoringReconciler) setupMonitoringForAgent(ctx context.Context, agent *aiv1.Agent) error {
 	logger := log.FromContext(ctx).WithValues("agent", agent.Name)
@@ -77,7 +117,7 @@ func (r *MonitoringReconciler) createServiceMonitor(ctx context.Context, agent *
 	// For now, we'll create a ConfigMap with monitoring configuration
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      agent.Name + "-monitoring",
+			Name:      monitoringConfigMapName(agent),
 			Namespace: agent.Namespace,
 			Labels: map[string]string{
 				"app.kubernetes.io/name":     "kubeagentic-agent",
@@ -94,10 +134,10 @@ global:
 scrape_configs:
   - job_name: 'kubeagentic-agent-%s'
     static_configs:
-      - targets: ['%s-service:80']
+      - targets: ['%s:80']
     metrics_path: '/metrics'
     scrape_interval: 30s
-`, agent.Name, agent.Name),
+%s`, agent.Name, serviceName(agent), scrapeAuthConfig(agent)),
 		},
 	}
 
@@ -119,8 +159,22 @@ scrape_configs:
 	return r.Update(ctx, found)
 }
 
+// scrapeAuthConfig returns the scrape job settings that authenticate Prometheus against an
+// agent protected by a generated endpoint key. Prometheus Operator mounts the Secret when
+// it is listed in the Prometheus resource's spec.secrets.
+func scrapeAuthConfig(agent *aiv1.Agent) string {
+	if !endpointAuthEnabled(agent) {
+		return ""
+	}
+	return fmt.Sprintf(`    authorization:
+      type: Bearer
+      credentials_file: /etc/prometheus/secrets/%s/%s
+`, endpointAuthSecretName(agent), endpointAuthTokenKey)
+}
+
 // createGrafanaDashboard creates a Grafana dashboard ConfigMap
 func (r *MonitoringReconciler) createGrafanaDashboard(ctx context.Context, agent *aiv1.Agent) error {
+	selector := metricsSelector(agent)
 	dashboard := fmt.Sprintf(`{
   "dashboard": {
     "id": null,
@@ -134,8 +188,8 @@ func (r *MonitoringReconciler) createGrafanaDashboard(ctx context.Context, agent
         "type": "graph",
         "targets": [
           {
-            "expr": "rate(kubeagentic_requests_total{agent=\"%s\"}[5m])",
-            "legendFormat": "Requests/sec"
+            "expr": "sum by (pod) (rate(kubeagentic_requests_total{%s}[5m]))",
+            "legendFormat": "{{pod}}"
           }
         ],
         "yAxes": [
@@ -150,7 +204,7 @@ func (r *MonitoringReconciler) createGrafanaDashboard(ctx context.Context, agent
         "type": "graph",
         "targets": [
           {
-            "expr": "histogram_quantile(0.95, rate(kubeagentic_response_duration_seconds_bucket{agent=\"%s\"}[5m]))",
+            "expr": "histogram_quantile(0.95, sum by (le) (rate(kubeagentic_response_duration_seconds_bucket{%s}[5m])))",
             "legendFormat": "95th percentile"
           }
         ],
@@ -166,8 +220,8 @@ func (r *MonitoringReconciler) createGrafanaDashboard(ctx context.Context, agent
         "type": "graph",
         "targets": [
           {
-            "expr": "rate(kubeagentic_errors_total{agent=\"%s\"}[5m])",
-            "legendFormat": "Errors/sec"
+            "expr": "sum by (pod) (rate(kubeagentic_errors_total{%s}[5m]))",
+            "legendFormat": "{{pod}}"
           }
         ],
         "yAxes": [
@@ -175,7 +229,7 @@ func (r *MonitoringReconciler) createGrafanaDashboard(ctx context.Context, agent
             "label": "Errors/sec"
           }
         ]
-      }
+      }%s
     ],
     "time": {
       "from": "now-1h",
@@ -183,11 +237,11 @@ func (r *MonitoringReconciler) createGrafanaDashboard(ctx context.Context, agent
     },
     "refresh": "30s"
   }
-}`, agent.Name, agent.Name, agent.Name, agent.Name)
+}`, agent.Name, selector, selector, selector, costPanel(agent)+cachePanel(agent)+guardrailsPanel(agent))
 
 	configMap := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      agent.Name + "-grafana-dashboard",
+			Name:      grafanaDashboardName(agent),
 			Namespace: agent.Namespace,
 			Labels: map[string]string{
 				"app.kubernetes.io/name":     "kubeagentic-agent",
@@ -219,9 +273,105 @@ func (r *MonitoringReconciler) createGrafanaDashboard(ctx context.Context, agent
 	return r.Update(ctx, found)
 }
 
+// metricsSelector returns the label matchers, escaped for the dashboard JSON, selecting the
+// metrics of the agent. The runtime labels them with the agent name and namespace and the
+// pod name it is given through the Downward API, so that agents of the same name in other
+// namespaces are told apart and the panels break down by pod.
+func metricsSelector(agent *aiv1.Agent) string {
+	return fmt.Sprintf(`agent=\"%s\",namespace=\"%s\"`, agent.Name, agent.Namespace)
+}
+
+// costPanel returns the dashboard panel charting the hourly cost of the agent, priced with
+// the current pricing catalog, or nothing when its model has no known price.
+func costPanel(agent *aiv1.Agent) string {
+	price, err := pricing.PriceFor(agent.Spec.Provider, agent.Spec.Model)
+	if err != nil {
+		return ""
+	}
+	selector := metricsSelector(agent)
+	return fmt.Sprintf(`,
+      {
+        "id": 4,
+        "title": "Cost",
+        "type": "graph",
+        "targets": [
+          {
+            "expr": "(sum(increase(kubeagentic_tokens_total{%s,type=\"prompt\"}[1h])) * %g + sum(increase(kubeagentic_tokens_total{%s,type=\"completion\"}[1h])) * %g) / 1e6",
+            "legendFormat": "%s/hour"
+          }
+        ],
+        "yAxes": [
+          {
+            "label": "%s"
+          }
+        ]
+      }`, selector, price.Prompt, selector, price.Completion, pricing.Currency, pricing.Currency)
+}
+
+// cachePanel returns the dashboard panel charting the prompts answered from the response
+// cache and sent to the provider, or nothing when the agent has no cache.
+func cachePanel(agent *aiv1.Agent) string {
+	if !cachingEnabled(agent) {
+		return ""
+	}
+	selector := metricsSelector(agent)
+	return fmt.Sprintf(`,
+      {
+        "id": 5,
+        "title": "Response Cache",
+        "type": "graph",
+        "targets": [
+          {
+            "expr": "sum(rate(kubeagentic_cache_requests_total{%s,result=\"hit\"}[5m]))",
+            "legendFormat": "hits"
+          },
+          {
+            "expr": "sum(rate(kubeagentic_cache_requests_total{%s,result=\"miss\"}[5m]))",
+            "legendFormat": "misses"
+          }
+        ],
+        "yAxes": [
+          {
+            "label": "Prompts/sec"
+          }
+        ]
+      }`, selector, selector)
+}
+
+// guardrailsPanel returns the dashboard panel charting the prompts and responses blocked and
+// flagged by each guardrails rule, or nothing when the agent has no guardrails.
+func guardrailsPanel(agent *aiv1.Agent) string {
+	if !guardrailsEnabled(agent) {
+		return ""
+	}
+	selector := metricsSelector(agent)
+	return fmt.Sprintf(`,
+      {
+        "id": 6,
+        "title": "Guardrails",
+        "type": "graph",
+        "targets": [
+          {
+            "expr": "sum by (rule, target) (rate(kubeagentic_guardrails_blocked_total{%s}[5m]))",
+            "legendFormat": "blocked {{rule}} ({{target}})"
+          },
+          {
+            "expr": "sum by (rule, target) (rate(kubeagentic_guardrails_flagged_total{%s}[5m]))",
+            "legendFormat": "flagged {{rule}} ({{target}})"
+          }
+        ],
+        "yAxes": [
+          {
+            "label": "Requests/sec"
+          }
+        ]
+      }`, selector, selector)
+}
+
 // SetupWithManager sets up the controller with the Manager
 func (r *MonitoringReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
+		Named("monitoring").
 		For(&aiv1.Agent{}).
 		Owns(&corev1.ConfigMap{}).
 		Complete(r)
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+)
+
+const (
+	// PricingConfigMap is the ConfigMap in the operator namespace overriding the prices of
+	// the embedded pricing catalog.
+	PricingConfigMap = "kubeagentic-pricing"
+
+	pricingCatalogKey = "catalog.yaml"
+)
+
+var pricingCatalogParseFailures = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeagentic_pricing_catalog_parse_failures_total",
+	Help: "Pricing ConfigMaps rejected because they could not be parsed.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(pricingCatalogParseFailures)
+}
+
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ai.example.com,resources=agents,verbs=get;list;watch
+// +kubebuilder:rbac:groups=ai.example.com,resources=agents/status,verbs=get;update;patch
+
+// PricingCatalogReconciler loads the pricing catalog from the PricingConfigMap whenever it
+// changes. Models of the ConfigMap are merged over the embedded catalog; without the
+// ConfigMap the embedded catalog is used. A ConfigMap that cannot be parsed is logged and
+// counted, and the catalog loaded last stays in use. Once loaded, the ModelDeprecated
+// condition of the existing agents is updated from the deprecations of the catalog.
+type PricingCatalogReconciler struct {
+	client.Client
+
+	// Namespace is the operator namespace holding the PricingConfigMap.
+	Namespace string
+}
+
+// Reconcile loads the pricing catalog.
+func (r *PricingCatalogReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, req.NamespacedName, cm)
+	if errors.IsNotFound(err) {
+		logger.Info("Pricing ConfigMap not found, using the embedded pricing catalog")
+		pricing.Set(nil)
+		return ctrl.Result{}, r.updateModelDeprecations(ctx)
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	overrides, err := pricing.Parse([]byte(cm.Data[pricingCatalogKey]))
+	if err != nil {
+		// Retrying does not help until the ConfigMap is fixed, which triggers a new load
+		pricingCatalogParseFailures.Inc()
+		logger.Error(err, "Ignoring the pricing ConfigMap, the previous pricing catalog stays in use",
+			"ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name, "key", pricingCatalogKey)
+		return ctrl.Result{}, nil
+	}
+	catalog := pricing.Merge(overrides)
+	pricing.Set(catalog)
+	logger.Info("Loaded pricing catalog", "models", len(catalog.Models), "deprecations", len(catalog.Deprecations), "overrides", len(overrides.Models))
+	return ctrl.Result{}, r.updateModelDeprecations(ctx)
+}
+
+// updateModelDeprecations updates the ModelDeprecated condition of all agents from the
+// current catalog, so that agents learn of deprecations without waiting for their next
+// reconcile.
+func (r *PricingCatalogReconciler) updateModelDeprecations(ctx context.Context) error {
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents); err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range agents.Items {
+		agent := &agents.Items[i]
+		if !applyModelDeprecation(agent, now) {
+			continue
+		}
+		if err := r.Status().Update(ctx, agent); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *PricingCatalogReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isCatalog := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == PricingConfigMap && obj.GetNamespace() == r.Namespace
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("pricingcatalog").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(isCatalog)).
+		Complete(r)
+}
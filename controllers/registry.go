@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/registry"
+)
+
+// RegistryReconciler maintains the agent discovery registry: the registry.ConfigMapName
+// ConfigMap of each namespace lists its running agents, and with ClusterNamespace set, the
+// registry.ClusterConfigMapName ConfigMap of that namespace lists the running agents of
+// all namespaces. The registry is rewritten when an agent starts or stops running, changes
+// a listed field or is deleted, and when the ConfigMap is edited, as the operator is its
+// only writer.
+type RegistryReconciler struct {
+	client.Client
+
+	// ClusterNamespace holds the cluster registry. The cluster registry is disabled when empty.
+	ClusterNamespace string
+}
+
+// +kubebuilder:rbac:groups=ai.example.com,resources=agents,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=configmaps,verbs=get;list;watch;create;update
+
+// Reconcile writes the registry ConfigMap of the request.
+func (r *RegistryReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var opts []client.ListOption
+	if !r.isClusterRegistry(req.NamespacedName) {
+		opts = append(opts, client.InNamespace(req.Namespace))
+	}
+	var agents aiv1.AgentList
+	if err := r.List(ctx, &agents, opts...); err != nil {
+		return ctrl.Result{}, err
+	}
+	var entries []registry.Entry
+	for i := range agents.Items {
+		if entry, ok := registryEntry(&agents.Items[i]); ok {
+			entries = append(entries, entry)
+		}
+	}
+	data, err := registry.Marshal(entries)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	cm := &corev1.ConfigMap{}
+	err = r.Get(ctx, req.NamespacedName, cm)
+	switch {
+	case apierrors.IsNotFound(err):
+		// Namespaces without running agents get no registry until one runs
+		if len(entries) == 0 {
+			return ctrl.Result{}, nil
+		}
+		cm = &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      req.Name,
+				Namespace: req.Namespace,
+				Labels:    registryLabels(),
+			},
+			Data: map[string]string{registry.DataKey: data},
+		}
+		err = r.Create(ctx, cm)
+	case err != nil:
+		return ctrl.Result{}, err
+	case cm.Data[registry.DataKey] == data && len(cm.Data) == 1 && cm.Labels["app.kubernetes.io/name"] == registryName:
+		return ctrl.Result{}, nil
+	default:
+		// Updates carry the resource version read, so a concurrent write fails and the
+		// registry is rebuilt from the agents again
+		cm.Data = map[string]string{registry.DataKey: data}
+		adoptLabels(cm, registryLabels())
+		err = r.Update(ctx, cm)
+	}
+	if apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err) {
+		log.FromContext(ctx).V(1).Info("Agent registry changed concurrently, retrying", "ConfigMap", req.NamespacedName)
+		return ctrl.Result{Requeue: true}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to write the agent registry: %w", err)
+	}
+	log.FromContext(ctx).Info("Updated agent registry", "ConfigMap", req.NamespacedName, "agents", len(entries))
+	return ctrl.Result{}, nil
+}
+
+// registryLabels returns the labels of the registry ConfigMaps, which select them into the
+// operator cache.
+func registryLabels() map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/name":       registryName,
+		"app.kubernetes.io/managed-by": "kubeagentic",
+	}
+}
+
+// registryEntry returns the registry entry of an agent, and false when the agent is not
+// listed because it does not run.
+func registryEntry(agent *aiv1.Agent) (registry.Entry, bool) {
+	if agent.Status.Phase != aiv1.AgentPhaseRunning || agent.DeletionTimestamp != nil {
+		return registry.Entry{}, false
+	}
+	entry := registry.Entry{
+		Name:        agent.Name,
+		Namespace:   agent.Namespace,
+		URL:         fmt.Sprintf("http://%s.%s.svc.cluster.local", serviceName(agent), agent.Namespace),
+		Provider:    agent.Spec.Provider,
+		Model:       agent.Spec.Model,
+		Description: registry.Describe(agent.Spec.SystemPrompt),
+	}
+	for _, tool := range agent.Spec.Tools {
+		entry.Tools = append(entry.Tools, tool.Name)
+	}
+	return entry, true
+}
+
+func (r *RegistryReconciler) isClusterRegistry(key types.NamespacedName) bool {
+	return r.ClusterNamespace != "" && key.Namespace == r.ClusterNamespace && key.Name == registry.ClusterConfigMapName
+}
+
+// registriesOfAgent returns the registries listing the agent.
+func (r *RegistryReconciler) registriesOfAgent(ctx context.Context, obj client.Object) []reconcile.Request {
+	requests := []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: obj.GetNamespace(), Name: registry.ConfigMapName}}}
+	if r.ClusterNamespace != "" {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Namespace: r.ClusterNamespace, Name: registry.ClusterConfigMapName}})
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *RegistryReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isRegistry := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == registry.ConfigMapName || r.isClusterRegistry(client.ObjectKeyFromObject(obj))
+	})
+	// Agent updates only change the registry when its entry changes
+	entryChanged := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldAgent, okOld := e.ObjectOld.(*aiv1.Agent)
+			newAgent, okNew := e.ObjectNew.(*aiv1.Agent)
+			if !okOld || !okNew {
+				return true
+			}
+			oldEntry, oldListed := registryEntry(oldAgent)
+			newEntry, newListed := registryEntry(newAgent)
+			if oldListed != newListed {
+				return true
+			}
+			oldData, _ := registry.Marshal([]registry.Entry{oldEntry})
+			newData, _ := registry.Marshal([]registry.Entry{newEntry})
+			return oldData != newData
+		},
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("registry").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(isRegistry)).
+		Watches(&aiv1.Agent{}, handler.EnqueueRequestsFromMapFunc(r.registriesOfAgent), builder.WithPredicates(entryChanged)).
+		Complete(r)
+}
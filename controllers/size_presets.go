@@ -0,0 +1,83 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/sizing"
+)
+
+const (
+	// SizePresetsConfigMap is the ConfigMap in the operator namespace overriding the
+	// embedded resource presets of spec.size.
+	SizePresetsConfigMap = "kubeagentic-size-presets"
+
+	sizePresetsKey = "presets.yaml"
+)
+
+var sizePresetsParseFailures = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "kubeagentic_size_presets_parse_failures_total",
+	Help: "Size preset ConfigMaps rejected because they could not be parsed.",
+})
+
+func init() {
+	metrics.Registry.MustRegister(sizePresetsParseFailures)
+}
+
+// SizePresetsReconciler loads the resource presets of spec.size from the
+// SizePresetsConfigMap whenever it changes. Sizes of the ConfigMap replace the embedded
+// ones; without the ConfigMap the embedded presets are used. A ConfigMap that cannot be
+// parsed is logged and counted, and the presets loaded last stay in use.
+type SizePresetsReconciler struct {
+	client.Client
+
+	// Namespace is the operator namespace holding the SizePresetsConfigMap.
+	Namespace string
+}
+
+// Reconcile loads the size presets.
+func (r *SizePresetsReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	cm := &corev1.ConfigMap{}
+	err := r.Get(ctx, req.NamespacedName, cm)
+	if errors.IsNotFound(err) {
+		logger.Info("Size presets ConfigMap not found, using the embedded size presets")
+		sizing.Set(nil)
+		return ctrl.Result{}, nil
+	} else if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	overrides, err := sizing.Parse([]byte(cm.Data[sizePresetsKey]))
+	if err != nil {
+		// Retrying does not help until the ConfigMap is fixed, which triggers a new load
+		sizePresetsParseFailures.Inc()
+		logger.Error(err, "Ignoring the size presets ConfigMap, the previous size presets stay in use",
+			"ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name, "key", sizePresetsKey)
+		return ctrl.Result{}, nil
+	}
+	sizing.Set(sizing.Merge(overrides))
+	logger.Info("Loaded size presets", "hostedOverrides", len(overrides.Hosted), "selfHostedOverrides", len(overrides.SelfHosted))
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SizePresetsReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	isPresets := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		return obj.GetName() == SizePresetsConfigMap && obj.GetNamespace() == r.Namespace
+	})
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("sizepresets").
+		For(&corev1.ConfigMap{}, builder.WithPredicates(isPresets)).
+		Complete(r)
+}
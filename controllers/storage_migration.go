@@ -0,0 +1,325 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+const (
+	// StorageMigrationConfigMap is the ConfigMap in the operator namespace holding the
+	// progress of the storage version migrations, one key per CRD.
+	StorageMigrationConfigMap = "kubeagentic-storage-migration"
+
+	// defaultStorageMigrationPageSize is the number of objects migrated between two saves
+	// of the progress.
+	defaultStorageMigrationPageSize = 100
+	// defaultStorageMigrationRetry is the time between two attempts of a failed migration.
+	defaultStorageMigrationRetry = time.Minute
+)
+
+// DefaultMigratedCRDs are the CRDs of the operator, whose objects are migrated.
+var DefaultMigratedCRDs = []string{
+	"agents.ai.example.com",
+	"agentpolicies.ai.example.com",
+	"agentgateways.ai.example.com",
+}
+
+var (
+	storageMigrationObjects = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "kubeagentic_storage_migration_objects_total",
+		Help: "Objects rewritten in the storage version of their CRD, by result.",
+	}, []string{"crd", "result"})
+	storageMigrationRetiredVersions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kubeagentic_storage_migration_retired_versions",
+		Help: "Versions in the storedVersions of the CRD other than its storage version; 0 once migrated.",
+	}, []string{"crd"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(storageMigrationObjects, storageMigrationRetiredVersions)
+}
+
+// StorageVersionMigrator migrates the objects of the operator CRDs to their storage
+// version, so that the versions they were stored in before can be removed from the CRDs.
+//
+// When the status.storedVersions of a CRD lists versions besides its storage version, the
+// migrator rewrites every object of the CRD with a no-op update, which the API server
+// stores in the storage version, and then sets storedVersions to the storage version. The
+// objects are rewritten in the order they are listed, and the last one rewritten is saved
+// in the StorageMigrationConfigMap after each page, so that a restarted operator resumes
+// the migration. Updates carry the resourceVersion that was listed: an object updated by
+// the reconciliation in the meantime has been stored in the storage version already, and
+// its conflict counts as migrated.
+type StorageVersionMigrator struct {
+	client.Client
+	// Reader lists the objects and reads the CRDs, bypassing the cache so that objects are
+	// listed in storage order. The client is used when nil.
+	Reader client.Reader
+
+	// Namespace is the operator namespace holding the StorageMigrationConfigMap.
+	Namespace string
+	// CRDs are the names of the migrated CRDs. DefaultMigratedCRDs are migrated when empty.
+	CRDs []string
+	// PageSize is the number of objects listed at once. Defaults to 100.
+	PageSize int64
+	// RetryInterval is the time between two attempts of a failed migration. Defaults to 1m.
+	RetryInterval time.Duration
+}
+
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions,verbs=get
+// +kubebuilder:rbac:groups=apiextensions.k8s.io,resources=customresourcedefinitions/status,verbs=update
+// +kubebuilder:rbac:groups=ai.example.com,resources=agentpolicies;agentgateways,verbs=update
+
+// Start migrates the CRDs, retrying until the migration succeeds or the context is done.
+func (m *StorageVersionMigrator) Start(ctx context.Context) error {
+	retry := m.RetryInterval
+	if retry <= 0 {
+		retry = defaultStorageMigrationRetry
+	}
+	for {
+		err := m.Migrate(ctx)
+		if err == nil {
+			return nil
+		}
+		log.FromContext(ctx).Error(err, "Failed to migrate storage versions, retrying", "retryInterval", retry)
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(retry):
+		}
+	}
+}
+
+// NeedLeaderElection runs the migration on the leader only.
+func (m *StorageVersionMigrator) NeedLeaderElection() bool {
+	return true
+}
+
+// Migrate migrates the objects of each CRD whose storedVersions lists retired versions.
+func (m *StorageVersionMigrator) Migrate(ctx context.Context) error {
+	crds := m.CRDs
+	if len(crds) == 0 {
+		crds = DefaultMigratedCRDs
+	}
+	var errs []error
+	for _, name := range crds {
+		if err := m.migrateCRD(ctx, name); err != nil {
+			errs = append(errs, fmt.Errorf("crd %s: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// storageMigrationProgress is the progress of the migration of a CRD.
+type storageMigrationProgress struct {
+	// StorageVersion is the version the objects are migrated to. The progress of a
+	// migration to another version is discarded.
+	StorageVersion string `json:"storageVersion"`
+	// LastMigrated is the key, namespace/name, of the last object rewritten in list order.
+	LastMigrated string `json:"lastMigrated,omitempty"`
+	Migrated     int    `json:"migrated"`
+	Failed       int    `json:"failed"`
+}
+
+// storageVersion returns the storage version of crd.
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+	return ""
+}
+
+// retiredStoredVersions returns the stored versions of crd other than storage.
+func retiredStoredVersions(crd *apiextensionsv1.CustomResourceDefinition, storage string) []string {
+	var retired []string
+	for _, version := range crd.Status.StoredVersions {
+		if version != storage {
+			retired = append(retired, version)
+		}
+	}
+	return retired
+}
+
+// storageMigrationKey returns the key of obj in the order objects are listed.
+func storageMigrationKey(obj client.Object) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+func (m *StorageVersionMigrator) reader() client.Reader {
+	if m.Reader != nil {
+		return m.Reader
+	}
+	return m.Client
+}
+
+// migrateCRD rewrites the objects of the CRD stored in retired versions, then drops those
+// versions from its storedVersions.
+func (m *StorageVersionMigrator) migrateCRD(ctx context.Context, name string) error {
+	logger := log.FromContext(ctx).WithValues("crd", name)
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := m.reader().Get(ctx, types.NamespacedName{Name: name}, crd); apierrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	storage := storageVersion(crd)
+	retired := retiredStoredVersions(crd, storage)
+	storageMigrationRetiredVersions.WithLabelValues(name).Set(float64(len(retired)))
+	if storage == "" || len(retired) == 0 {
+		return nil
+	}
+
+	progress, err := m.loadProgress(ctx, name)
+	if err != nil {
+		return err
+	}
+	if progress.StorageVersion != storage {
+		progress = storageMigrationProgress{StorageVersion: storage}
+	}
+	logger.Info("Migrating objects to the storage version", "storageVersion", storage, "retiredVersions", retired,
+		"resumeAfter", progress.LastMigrated, "migrated", progress.Migrated)
+
+	pageSize := m.PageSize
+	if pageSize <= 0 {
+		pageSize = defaultStorageMigrationPageSize
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(schema.GroupVersionKind{Group: crd.Spec.Group, Version: storage, Kind: crd.Spec.Names.ListKind})
+	failed := 0
+	continueToken := ""
+	for {
+		if err := m.reader().List(ctx, list, client.Limit(pageSize), client.Continue(continueToken)); err != nil {
+			return err
+		}
+		for i := range list.Items {
+			obj := &list.Items[i]
+			key := storageMigrationKey(obj)
+			if progress.LastMigrated != "" && key <= progress.LastMigrated {
+				continue
+			}
+			if err := m.rewrite(ctx, obj); err != nil {
+				logger.Error(err, "Failed to migrate object", "object", key)
+				storageMigrationObjects.WithLabelValues(name, "failed").Inc()
+				progress.Failed++
+				failed++
+				continue
+			}
+			storageMigrationObjects.WithLabelValues(name, "migrated").Inc()
+			progress.Migrated++
+			// Objects after a failure are rewritten again on resume, so that the failed
+			// one is retried.
+			if failed == 0 {
+				progress.LastMigrated = key
+			}
+		}
+		if err := m.saveProgress(ctx, name, &progress); err != nil {
+			return err
+		}
+		logger.Info("Migrated a page of objects", "migrated", progress.Migrated, "failed", progress.Failed)
+		continueToken = list.GetContinue()
+		if continueToken == "" {
+			break
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d objects failed to migrate to %s", failed, storage)
+	}
+
+	// The update conflicts when the CRD changed meanwhile, e.g. its storage version, and
+	// the migration is retried.
+	crd.Status.StoredVersions = []string{storage}
+	if err := m.Status().Update(ctx, crd); err != nil {
+		return fmt.Errorf("failed to update the stored versions: %w", err)
+	}
+	storageMigrationRetiredVersions.WithLabelValues(name).Set(0)
+	logger.Info("Migrated objects to the storage version", "storageVersion", storage, "removedVersions", retired, "migrated", progress.Migrated)
+	return m.saveProgress(ctx, name, nil)
+}
+
+// rewrite stores obj in the storage version with a no-op update. Objects updated or deleted
+// since they were listed need no rewrite.
+func (m *StorageVersionMigrator) rewrite(ctx context.Context, obj *unstructured.Unstructured) error {
+	err := m.Update(ctx, obj)
+	if apierrors.IsConflict(err) || apierrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// loadProgress returns the saved progress of the migration of the CRD.
+func (m *StorageVersionMigrator) loadProgress(ctx context.Context, name string) (storageMigrationProgress, error) {
+	var progress storageMigrationProgress
+	cm := &corev1.ConfigMap{}
+	err := m.reader().Get(ctx, types.NamespacedName{Name: StorageMigrationConfigMap, Namespace: m.Namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		return progress, nil
+	} else if err != nil {
+		return progress, err
+	}
+	if data, ok := cm.Data[name]; ok {
+		if err := json.Unmarshal([]byte(data), &progress); err != nil {
+			// Start over rather than never migrating
+			log.FromContext(ctx).Error(err, "Discarding invalid storage migration progress", "crd", name)
+			return storageMigrationProgress{}, nil
+		}
+	}
+	return progress, nil
+}
+
+// saveProgress saves the progress of the migration of the CRD, or removes it when nil.
+func (m *StorageVersionMigrator) saveProgress(ctx context.Context, name string, progress *storageMigrationProgress) error {
+	cm := &corev1.ConfigMap{}
+	err := m.reader().Get(ctx, types.NamespacedName{Name: StorageMigrationConfigMap, Namespace: m.Namespace}, cm)
+	if apierrors.IsNotFound(err) {
+		if progress == nil {
+			return nil
+		}
+		cm = &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{
+			Name:      StorageMigrationConfigMap,
+			Namespace: m.Namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "kubeagentic"},
+		}}
+	} else if err != nil {
+		return err
+	}
+
+	if progress == nil {
+		if _, ok := cm.Data[name]; !ok {
+			return nil
+		}
+		delete(cm.Data, name)
+		return m.Update(ctx, cm)
+	}
+	data, err := json.Marshal(progress)
+	if err != nil {
+		return err
+	}
+	if cm.Data == nil {
+		cm.Data = map[string]string{}
+	}
+	cm.Data[name] = string(data)
+	if cm.ResourceVersion == "" {
+		return m.Create(ctx, cm)
+	}
+	return m.Update(ctx, cm)
+}
@@ -78,7 +78,7 @@ echo "  → Creating namespace..."
 kubectl apply -f deploy/namespace.yaml
 
 echo "  → Installing CRD..."
-kubectl apply -f crd/agent-crd.yaml
+kubectl apply -f crd/agent-crd.yaml -f crd/agentpolicy-crd.yaml
 
 echo "  → Setting up RBAC..."
 kubectl apply -f deploy/rbac.yaml
@@ -1,23 +1,35 @@
 package main
 
 import (
+	"context"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	"sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
 	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
 	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/errorhistory"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/fleetdashboard"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/metricsauth"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/queuelimit"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/webhookcert"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -29,6 +41,7 @@ var (
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(aiv1.AddToScheme(scheme))
+	utilruntime.Must(apiextensionsv1.AddToScheme(scheme))
 	// +kubebuilder:scaffold:scheme
 }
 
@@ -36,11 +49,118 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var webhookPort int
+	var webhookCertDir string
+	var manageWebhookCerts bool
+	var webhookServiceName string
+	var webhookCertSecret string
+	var secureMetrics bool
+	var metricsCertDir string
+	var fleetBatchSize int
+	var fleetInterval time.Duration
+	var fleetMaxFailurePercent int
+	var fleetPriorityLabel string
+	var costReportEnabled bool
+	var costReportPeriod string
+	var costReportInterval time.Duration
+	var costReportTeamLabel string
+	var costReportConfigMap string
+	var costReportWebhookURL string
+	var fleetDashboardEnabled bool
+	var fleetDashboardNamespace string
+	var fleetDashboardFolder string
+	var fleetDashboardTopN int
+	var errorHistorySize int
+	var errorHistoryTTL time.Duration
+	var clusterRegistry bool
+	var healthCheckInterval time.Duration
+	var healthCheckTimeout time.Duration
+	var prometheusURL string
+	var migrateStorageVersions bool
+	var queueLimiter queuelimit.ItemLimiter
+	var queueQPS float64
+	var queueBurst int
+	priorityWorkers := map[controllers.PriorityClass]*int{}
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port the webhook server binds to.")
+	flag.StringVar(&webhookCertDir, "webhook-cert-dir", "/tmp/k8s-webhook-server/serving-certs",
+		"The directory the webhook server reads its serving certificate from.")
+	flag.BoolVar(&manageWebhookCerts, "manage-webhook-certs", true,
+		"Generate and rotate the webhook serving certificate. "+
+			"Disable when certificates are provided by cert-manager or mounted by hand.")
+	flag.StringVar(&webhookServiceName, "webhook-service-name", "kubeagentic-webhook-service",
+		"The Service the webhook serving certificate is issued for.")
+	flag.StringVar(&webhookCertSecret, "webhook-cert-secret", "kubeagentic-webhook-certs",
+		"The Secret holding the generated webhook certificates.")
+	flag.BoolVar(&secureMetrics, "metrics-secure", true,
+		"Serve metrics over HTTPS and only to clients whose bearer token is authorized to get /metrics.")
+	flag.StringVar(&metricsCertDir, "metrics-cert-dir", "",
+		"The directory the metrics server reads its serving certificate from. "+
+			"Defaults to the webhook certificate directory when webhook certificates are managed, "+
+			"otherwise a self-signed certificate is used.")
+	flag.IntVar(&fleetBatchSize, "fleet-rollout-batch-size", 5,
+		"The number of agents updated at once when the default agent image changes.")
+	flag.DurationVar(&fleetInterval, "fleet-rollout-interval", 5*time.Minute,
+		"The time between two batches of a fleet rollout.")
+	flag.IntVar(&fleetMaxFailurePercent, "fleet-rollout-max-failure-percent", 20,
+		"The percentage of updated agents not running that pauses a fleet rollout.")
+	flag.StringVar(&fleetPriorityLabel, "fleet-rollout-priority-label", controllers.DefaultFleetPriorityLabel,
+		"The namespace label ordering a fleet rollout; lower values are updated first.")
+	flag.BoolVar(&costReportEnabled, "cost-report-enabled", false,
+		"Report the token usage and cost of all agents in a ConfigMap of the operator namespace.")
+	flag.StringVar(&costReportPeriod, "cost-report-period", "weekly",
+		"The period of the cost report: daily, weekly or monthly.")
+	flag.DurationVar(&costReportInterval, "cost-report-interval", time.Hour,
+		"How often the cost report is updated.")
+	flag.StringVar(&costReportTeamLabel, "cost-report-team-label", controllers.DefaultCostReportTeamLabel,
+		"The agent or namespace label grouping agents by team in the cost report.")
+	flag.StringVar(&costReportConfigMap, "cost-report-configmap", controllers.DefaultCostReportConfigMap,
+		"The ConfigMap of the operator namespace the cost report is written to.")
+	flag.StringVar(&costReportWebhookURL, "cost-report-webhook-url", "",
+		"A URL the JSON report of each completed period is posted to.")
+	flag.BoolVar(&fleetDashboardEnabled, "fleet-dashboard", false,
+		"Keep the Grafana dashboard of the agent fleet in a ConfigMap labelled grafana_dashboard.")
+	flag.StringVar(&fleetDashboardNamespace, "fleet-dashboard-namespace", "",
+		"The namespace of the fleet dashboard ConfigMap, watched by the Grafana dashboard sidecar. Defaults to the operator namespace.")
+	flag.StringVar(&fleetDashboardFolder, "fleet-dashboard-folder", controllers.DefaultFleetDashboardFolder,
+		"The Grafana folder of the fleet dashboard, set in the grafana_folder annotation of its ConfigMap.")
+	flag.IntVar(&fleetDashboardTopN, "fleet-dashboard-top-n", fleetdashboard.DefaultTopN,
+		"How many agents the top spender tables of the fleet dashboard list.")
+	flag.IntVar(&errorHistorySize, "error-history-size", errorhistory.DefaultSize,
+		"The number of recent errors kept in the status of each agent.")
+	flag.DurationVar(&errorHistoryTTL, "error-history-ttl", errorhistory.DefaultTTL,
+		"How long an error is kept in the status of an agent after it last occurred.")
+	flag.BoolVar(&clusterRegistry, "cluster-registry", false,
+		"List the running agents of all namespaces in the kubeagentic-cluster-registry ConfigMap of the operator namespace.")
+	flag.DurationVar(&healthCheckInterval, "health-check-interval", controllers.DefaultHealthCheckInterval,
+		"The time between two health checks of agents with spec.healthCheck that do not set their own interval.")
+	flag.DurationVar(&healthCheckTimeout, "health-check-timeout", controllers.DefaultHealthCheckTimeout,
+		"The bound on the health checks of agents with spec.healthCheck that do not set their own timeout.")
+	flag.StringVar(&prometheusURL, "prometheus-url", "",
+		"The URL of the Prometheus the error budgets of agents with spec.slo are read from, such as http://prometheus-operated.monitoring:9090.")
+	flag.BoolVar(&migrateStorageVersions, "migrate-storage-versions", true,
+		"Rewrite the objects of the operator CRDs stored in retired versions on startup, "+
+			"and drop those versions from the storedVersions of the CRDs.")
+	flag.IntVar(&queueLimiter.Burst, "agent-queue-item-burst", queuelimit.DefaultBurst,
+		"The number of times an agent is enqueued per --agent-queue-item-window before its reconciles are delayed.")
+	flag.DurationVar(&queueLimiter.Window, "agent-queue-item-window", queuelimit.DefaultWindow,
+		"The period over which the times an agent is enqueued are counted.")
+	flag.DurationVar(&queueLimiter.BaseDelay, "agent-queue-base-delay", queuelimit.DefaultBaseDelay,
+		"The delay of the first reconcile of an agent over its burst, doubled for each further one.")
+	flag.DurationVar(&queueLimiter.MaxDelay, "agent-queue-max-delay", queuelimit.DefaultMaxDelay,
+		"The longest delay of the reconcile of an agent enqueued over its burst.")
+	flag.Float64Var(&queueQPS, "agent-queue-qps", queuelimit.DefaultQPS,
+		"The rate of the token bucket shared by the retries of all agents.")
+	flag.IntVar(&queueBurst, "agent-queue-burst", queuelimit.DefaultQueueBurst,
+		"The size of the token bucket shared by the retries of all agents.")
+	for _, class := range controllers.PriorityClasses {
+		priorityWorkers[class] = flag.Int(fmt.Sprintf("agent-%s-priority-workers", class), controllers.DefaultPriorityWorkers[class],
+			fmt.Sprintf("The concurrent reconciles of the agents labelled %s=%s.", controllers.PriorityLabel, class))
+	}
 
 	opts := zap.Options{
 		Development: true,
@@ -50,12 +170,50 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	var certManager *webhookcert.Manager
+	if manageWebhookCerts {
+		// The manager's client is not usable before the manager starts, so the
+		// certificates are bootstrapped with a direct client.
+		certClient, err := client.New(ctrl.GetConfigOrDie(), client.Options{Scheme: scheme})
+		if err != nil {
+			setupLog.Error(err, "unable to create webhook certificate client")
+			os.Exit(1)
+		}
+		certManager = webhookcert.New(certClient, webhookcert.Options{
+			SecretName:         webhookCertSecret,
+			Namespace:          operatorNamespace(),
+			ServiceName:        webhookServiceName,
+			ExtraServiceNames:  []string{"kubeagentic-operator-metrics-service"},
+			CertDir:            webhookCertDir,
+			ValidatingWebhooks: []string{"kubeagentic-validating-webhook-configuration"},
+			MutatingWebhooks:   []string{"kubeagentic-mutating-webhook-configuration"},
+		})
+		if err := certManager.Bootstrap(context.Background()); err != nil {
+			setupLog.Error(err, "unable to bootstrap webhook certificates")
+			os.Exit(1)
+		}
+	}
+
+	metricsOptions := server.Options{BindAddress: metricsAddr}
+	if secureMetrics {
+		metricsOptions.SecureServing = true
+		metricsOptions.FilterProvider = metricsauth.WithAuthenticationAndAuthorization
+		metricsOptions.CertDir = metricsCertDir
+		if metricsCertDir == "" && certManager != nil {
+			metricsOptions.CertDir = webhookCertDir
+		}
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme:                 scheme,
-		Metrics:                server.Options{BindAddress: metricsAddr},
+		Metrics:                metricsOptions,
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "d1b7e6c2.ai.example.com",
+		WebhookServer:          webhook.NewServer(webhook.Options{Port: webhookPort, CertDir: webhookCertDir}),
+		// Only the objects labelled by the operator are cached for the kinds it owns
+		Cache:     controllers.CacheOptions(operatorNamespace()),
+		NewClient: controllers.NewClient,
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -73,15 +231,166 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Setup the Agent controllers, one per priority class
+	workers := map[controllers.PriorityClass]int{}
+	for class, n := range priorityWorkers {
+		workers[class] = *n
+	}
 	if err = (&controllers.AgentReconciler{
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            mgr.GetEventRecorderFor("agent-controller"),
+		OperatorNamespace:   operatorNamespace(),
+		ErrorHistory:        errorhistory.History{Size: errorHistorySize, TTL: errorHistoryTTL},
+		HealthCheckInterval: healthCheckInterval,
+		HealthCheckTimeout:  healthCheckTimeout,
+		PrometheusURL:       prometheusURL,
+		QueueLimiter:        &queueLimiter,
+		QueueQPS:            queueQPS,
+		QueueBurst:          queueBurst,
+		PriorityWorkers:     workers,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Agent")
+		os.Exit(1)
+	}
+
+	// Load the pricing catalog used for usage costs and budgets
+	if err = (&controllers.PricingCatalogReconciler{
+		Client:    mgr.GetClient(),
+		Namespace: operatorNamespace(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "PricingCatalog")
+		os.Exit(1)
+	}
+
+	// Load the resource presets of spec.size
+	if err = (&controllers.SizePresetsReconciler{
+		Client:    mgr.GetClient(),
+		Namespace: operatorNamespace(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "SizePresets")
+		os.Exit(1)
+	}
+
+	// Load the tools injected into every agent by the defaulting webhook
+	if err = (&controllers.DefaultToolsReconciler{
+		Client:    mgr.GetClient(),
+		Namespace: operatorNamespace(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "DefaultTools")
+		os.Exit(1)
+	}
+
+	// List the running agents in the discovery registry
+	registryReconciler := &controllers.RegistryReconciler{Client: mgr.GetClient()}
+	if clusterRegistry {
+		registryReconciler.ClusterNamespace = operatorNamespace()
+	}
+	if err = registryReconciler.SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Registry")
+		os.Exit(1)
+	}
+
+	// Serve the agents of the registry through the OpenAI-compatible gateways
+	if err = (&controllers.AgentGatewayReconciler{
 		Client: mgr.GetClient(),
 		Scheme: mgr.GetScheme(),
 	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Agent")
+		setupLog.Error(err, "unable to create controller", "controller", "AgentGateway")
+		os.Exit(1)
+	}
+
+	// Roll changes of the default agent image out in batches
+	if err := mgr.Add(&controllers.FleetRolloutCoordinator{
+		Client:            mgr.GetClient(),
+		Namespace:         operatorNamespace(),
+		BatchSize:         fleetBatchSize,
+		Interval:          fleetInterval,
+		MaxFailurePercent: fleetMaxFailurePercent,
+		PriorityLabel:     fleetPriorityLabel,
+	}); err != nil {
+		setupLog.Error(err, "unable to set up fleet rollout coordinator")
+		os.Exit(1)
+	}
+
+	// Migrate the objects stored in retired versions of the CRDs
+	if migrateStorageVersions {
+		if err := mgr.Add(&controllers.StorageVersionMigrator{
+			Client:    mgr.GetClient(),
+			Reader:    mgr.GetAPIReader(),
+			Namespace: operatorNamespace(),
+		}); err != nil {
+			setupLog.Error(err, "unable to set up storage version migration")
+			os.Exit(1)
+		}
+	}
+
+	// Report the usage and cost of the agents
+	if costReportEnabled {
+		switch costReportPeriod {
+		case "daily", "weekly", "monthly":
+		default:
+			setupLog.Error(fmt.Errorf("unknown period %q", costReportPeriod), "invalid --cost-report-period")
+			os.Exit(1)
+		}
+		if err := mgr.Add(&controllers.CostReporter{
+			Client:        mgr.GetClient(),
+			Namespace:     operatorNamespace(),
+			ConfigMapName: costReportConfigMap,
+			Period:        costReportPeriod,
+			Interval:      costReportInterval,
+			TeamLabel:     costReportTeamLabel,
+			WebhookURL:    costReportWebhookURL,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up cost reporter")
+			os.Exit(1)
+		}
+	}
+
+	if fleetDashboardEnabled {
+		namespace := fleetDashboardNamespace
+		if namespace == "" {
+			namespace = operatorNamespace()
+		}
+		if err := mgr.Add(&controllers.FleetDashboard{
+			Client:    mgr.GetClient(),
+			Namespace: namespace,
+			Folder:    fleetDashboardFolder,
+			TopN:      fleetDashboardTopN,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up fleet dashboard")
+			os.Exit(1)
+		}
+	}
+
+	// Setup the Monitoring controller
+	if err = (&controllers.MonitoringReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "Monitoring")
+		os.Exit(1)
+	}
+
+	// Default and validate the Agents at admission
+	if err = (&webhookv1.AgentWebhook{}).SetupWebhookWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create webhook", "webhook", "Agent")
 		os.Exit(1)
 	}
+
 	// +kubebuilder:scaffold:builder
 
+	if certManager != nil {
+		if err := mgr.Add(certManager.Rotator()); err != nil {
+			setupLog.Error(err, "unable to set up webhook certificate rotation")
+			os.Exit(1)
+		}
+		if err := mgr.Add(certManager.Syncer()); err != nil {
+			setupLog.Error(err, "unable to set up webhook certificate sync")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
@@ -97,3 +406,12 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// operatorNamespace returns the namespace the operator runs in, as exposed through the
+// downward API in POD_NAMESPACE.
+func operatorNamespace() string {
+	if ns := os.Getenv("POD_NAMESPACE"); ns != "" {
+		return ns
+	}
+	return "kubeagentic-system"
+}
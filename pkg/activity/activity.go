@@ -0,0 +1,96 @@
+// Package activity sums the chat requests of an agent from the request counters of its pods.
+//
+// The counters of the runtime are cumulative per pod and start from zero again when a pod
+// restarts, so the operator keeps the counters seen last for each pod and counts their
+// increase. The requests of the last hour are kept in buckets of BucketWidth, to report the
+// requests and the error ratio of a sliding hour without a metrics backend.
+package activity
+
+import (
+	"strconv"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// BucketWidth is the interval counted by each bucket.
+	BucketWidth = 5 * time.Minute
+	// Window is how far back RequestsLastHour and ErrorRatio look.
+	Window = time.Hour
+)
+
+// Update returns the activity of an agent after scraping the counters of its pods at now.
+// Pods listed in unscraped exist but could not be scraped, e.g. because they are not
+// running or their image does not expose the metrics; they keep their last counters, so
+// that their requests are counted once they can be scraped again. Pods that are gone are
+// forgotten. The first update only records the counters of the pods: their requests are
+// added to TotalRequests but, as they may be old, not to the last hour.
+func Update(previous *aiv1.ActivityStatus, scraped []aiv1.PodRequestCounters, unscraped []string, now time.Time) *aiv1.ActivityStatus {
+	status := &aiv1.ActivityStatus{}
+	last := map[string]aiv1.PodRequestCounters{}
+	if previous != nil {
+		status = previous.DeepCopy()
+		for _, c := range previous.PodCounters {
+			last[c.Pod] = c
+		}
+	}
+
+	var requests, errors int64
+	var counters []aiv1.PodRequestCounters
+	for _, current := range scraped {
+		before := last[current.Pod]
+		if current.Requests < before.Requests || current.Errors < before.Errors {
+			// A restarted pod counts from zero again
+			before = aiv1.PodRequestCounters{}
+		}
+		requests += current.Requests - before.Requests
+		errors += current.Errors - before.Errors
+		counters = append(counters, current)
+	}
+	for _, pod := range unscraped {
+		if c, ok := last[pod]; ok {
+			counters = append(counters, c)
+		}
+	}
+	status.PodCounters = counters
+	status.TotalRequests += requests
+
+	if previous != nil && requests > 0 {
+		start := metav1.NewTime(now.Truncate(BucketWidth))
+		if n := len(status.Buckets); n > 0 && status.Buckets[n-1].Start.Equal(&start) {
+			status.Buckets[n-1].Requests += requests
+			status.Buckets[n-1].Errors += errors
+		} else {
+			status.Buckets = append(status.Buckets, aiv1.RequestBucket{Start: start, Requests: requests, Errors: errors})
+		}
+		lastRequest := metav1.NewTime(now)
+		status.LastRequestTime = &lastRequest
+	}
+	status.Buckets = Prune(status.Buckets, now)
+
+	status.RequestsLastHour, status.ErrorRatio = 0, ""
+	var windowErrors int64
+	for _, bucket := range status.Buckets {
+		status.RequestsLastHour += bucket.Requests
+		windowErrors += bucket.Errors
+	}
+	if status.RequestsLastHour > 0 {
+		status.ErrorRatio = strconv.FormatFloat(float64(windowErrors)/float64(status.RequestsLastHour), 'f', 4, 64)
+	}
+	return status
+}
+
+// Prune returns the buckets that started within the Window before now.
+func Prune(buckets []aiv1.RequestBucket, now time.Time) []aiv1.RequestBucket {
+	cutoff := now.Add(-Window)
+	var kept []aiv1.RequestBucket
+	for _, bucket := range buckets {
+		if bucket.Start.Time.After(cutoff) {
+			kept = append(kept, bucket)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,323 @@
+// Package agentctl implements the commands of agentctl, the CLI for the deployed agents.
+//
+// list and status read the Agents through the API server. chat connects to the Service of
+// an agent, through a port-forward to one of its ready pods, and streams the answer of the
+// OpenAI-compatible endpoint of the agent. The bearer token generated for agents with
+// spec.endpointAuth is read from its Secret and sent with the prompt.
+package agentctl
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+// endpointAuthTokenKey is the key of the token in the endpoint auth Secret of an agent.
+const endpointAuthTokenKey = "token"
+
+// Connector connects to the Service of an agent. It returns the base URL of the agent API
+// and a function closing the connection.
+type Connector interface {
+	Connect(ctx context.Context, agent *aiv1.Agent) (string, func(), error)
+}
+
+// Commands runs the agentctl commands.
+type Commands struct {
+	// Client reads the Agents and the endpoint auth Secrets.
+	Client client.Client
+	// Namespace is the namespace of the agents.
+	Namespace string
+	// Out receives the output of the commands.
+	Out io.Writer
+	// Connector connects chat to the agents.
+	Connector Connector
+	// HTTPClient sends the prompts. http.DefaultClient is used when nil.
+	HTTPClient *http.Client
+	// Now tells the time, for the ages of agents and conditions. time.Now is used when nil.
+	Now func() time.Time
+}
+
+// List prints the agents of the namespace, or of all namespaces with allNamespaces.
+func (c *Commands) List(ctx context.Context, allNamespaces bool) error {
+	var agents aiv1.AgentList
+	var opts []client.ListOption
+	if !allNamespaces {
+		opts = append(opts, client.InNamespace(c.Namespace))
+	}
+	if err := c.Client.List(ctx, &agents, opts...); err != nil {
+		return fmt.Errorf("failed to list agents: %w", err)
+	}
+	if len(agents.Items) == 0 {
+		if allNamespaces {
+			fmt.Fprintln(c.Out, "No agents found.")
+		} else {
+			fmt.Fprintf(c.Out, "No agents found in namespace %s.\n", c.Namespace)
+		}
+		return nil
+	}
+	sort.Slice(agents.Items, func(i, j int) bool {
+		a, b := agents.Items[i], agents.Items[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		return a.Name < b.Name
+	})
+
+	w := tabwriter.NewWriter(c.Out, 0, 8, 3, ' ', 0)
+	if allNamespaces {
+		fmt.Fprint(w, "NAMESPACE\t")
+	}
+	fmt.Fprintln(w, "NAME\tPHASE\tPROVIDER\tMODEL\tENDPOINT\tAGE")
+	for i := range agents.Items {
+		agent := &agents.Items[i]
+		if allNamespaces {
+			fmt.Fprintf(w, "%s\t", agent.Namespace)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", agent.Name, orNone(string(agent.Status.Phase)), agent.Spec.Provider,
+			agent.Spec.Model, Endpoint(agent), c.age(agent.CreationTimestamp))
+	}
+	return w.Flush()
+}
+
+// Status prints the phase, conditions and usage of an agent.
+func (c *Commands) Status(ctx context.Context, name string) error {
+	agent, err := c.agent(ctx, name)
+	if err != nil {
+		return err
+	}
+	status := agent.Status
+	w := tabwriter.NewWriter(c.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", agent.Name)
+	fmt.Fprintf(w, "Namespace:\t%s\n", agent.Namespace)
+	fmt.Fprintf(w, "Provider:\t%s\n", agent.Spec.Provider)
+	fmt.Fprintf(w, "Model:\t%s\n", agent.Spec.Model)
+	fmt.Fprintf(w, "Endpoint:\t%s\n", Endpoint(agent))
+	fmt.Fprintf(w, "Phase:\t%s\n", orNone(string(status.Phase)))
+	if status.Message != "" {
+		fmt.Fprintf(w, "Message:\t%s\n", status.Message)
+	}
+	fmt.Fprintf(w, "Replicas:\t%d desired, %d ready, %d available\n",
+		status.ReplicaStatus.Desired, status.ReplicaStatus.Ready, status.ReplicaStatus.Available)
+	if status.ObservedGeneration != agent.Generation {
+		fmt.Fprintf(w, "Observed generation:\t%d of %d\n", status.ObservedGeneration, agent.Generation)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.Out, "Conditions:")
+	if len(status.Conditions) == 0 {
+		fmt.Fprintln(c.Out, "  <none>")
+	} else {
+		w = tabwriter.NewWriter(c.Out, 0, 8, 2, ' ', 0)
+		fmt.Fprintln(w, "  TYPE\tSTATUS\tREASON\tAGE\tMESSAGE")
+		for _, condition := range status.Conditions {
+			age := "<unknown>"
+			if condition.LastTransitionTime != nil {
+				age = c.age(*condition.LastTransitionTime)
+			}
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", condition.Type, condition.Status, orNone(condition.Reason), age, condition.Message)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(c.Out, "Usage:")
+	usage := status.Usage
+	if usage == nil {
+		fmt.Fprintln(c.Out, "  <none>")
+		return nil
+	}
+	w = tabwriter.NewWriter(c.Out, 0, 8, 2, ' ', 0)
+	fmt.Fprintf(w, "  Prompt tokens:\t%d\n", usage.PromptTokens)
+	fmt.Fprintf(w, "  Completion tokens:\t%d\n", usage.CompletionTokens)
+	if usage.EmbeddingTokens > 0 {
+		fmt.Fprintf(w, "  Embedding tokens:\t%d\n", usage.EmbeddingTokens)
+	}
+	if usage.EstimatedCost != "" {
+		fmt.Fprintf(w, "  Estimated cost:\t%s %s\n", usage.EstimatedCost, usage.Currency)
+	}
+	if usage.CacheHits+usage.CacheMisses > 0 {
+		fmt.Fprintf(w, "  Cache hits:\t%d of %d\n", usage.CacheHits, usage.CacheHits+usage.CacheMisses)
+	}
+	return w.Flush()
+}
+
+// ChatOptions are the options of a prompt sent with Chat.
+type ChatOptions struct {
+	// Conversation continues the conversation of that ID. The agent starts a new one when
+	// empty.
+	Conversation string
+}
+
+// Chat sends prompt to an agent and streams its answer.
+func (c *Commands) Chat(ctx context.Context, name, prompt string, opts ChatOptions) error {
+	agent, err := c.agent(ctx, name)
+	if err != nil {
+		return err
+	}
+	token, err := c.endpointToken(ctx, agent)
+	if err != nil {
+		return err
+	}
+	baseURL, stop, err := c.Connector.Connect(ctx, agent)
+	if err != nil {
+		return fmt.Errorf("failed to connect to agent %s: %w", name, err)
+	}
+	defer stop()
+
+	request := map[string]interface{}{
+		"model":    agent.Name,
+		"messages": []map[string]string{{"role": "user", "content": prompt}},
+		"stream":   true,
+	}
+	if opts.Conversation != "" {
+		request["user"] = opts.Conversation
+	}
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(baseURL, "/")+"/v1/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "text/event-stream")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send the prompt: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("agent %s answered %s: %s", name, resp.Status, strings.TrimSpace(string(detail)))
+	}
+	return streamAnswer(resp.Body, c.Out)
+}
+
+// completionChunk is a chunk of a streamed chat completion.
+type completionChunk struct {
+	Choices []struct {
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+}
+
+// streamAnswer writes the content of the server-sent chat completion chunks of r to out as
+// they arrive.
+func streamAnswer(r io.Reader, out io.Writer) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data:")
+		if !ok {
+			continue
+		}
+		data = strings.TrimSpace(data)
+		if data == "[DONE]" {
+			break
+		}
+		var chunk completionChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse the answer: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			if _, err := io.WriteString(out, choice.Delta.Content); err != nil {
+				return err
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read the answer: %w", err)
+	}
+	_, err := fmt.Fprintln(out)
+	return err
+}
+
+// endpointToken returns the bearer token of the agent, or "" when its endpoint does not
+// require one.
+func (c *Commands) endpointToken(ctx context.Context, agent *aiv1.Agent) (string, error) {
+	if agent.Spec.EndpointAuth == nil || !agent.Spec.EndpointAuth.GenerateKey {
+		return "", nil
+	}
+	name := agent.Status.EndpointAuthSecretName
+	if name == "" {
+		name = naming.Child(agent.Name, "endpoint-auth")
+	}
+	secret := &corev1.Secret{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: agent.Namespace}, secret); err != nil {
+		return "", fmt.Errorf("failed to read the endpoint token of agent %s: %w", agent.Name, err)
+	}
+	token := string(secret.Data[endpointAuthTokenKey])
+	if token == "" {
+		return "", fmt.Errorf("secret %s has no endpoint token yet", name)
+	}
+	return token, nil
+}
+
+func (c *Commands) agent(ctx context.Context, name string) (*aiv1.Agent, error) {
+	agent := &aiv1.Agent{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: c.Namespace}, agent); err != nil {
+		return nil, fmt.Errorf("failed to get agent %s/%s: %w", c.Namespace, name, err)
+	}
+	return agent, nil
+}
+
+func (c *Commands) age(t metav1.Time) string {
+	if t.IsZero() {
+		return "<unknown>"
+	}
+	now := time.Now
+	if c.Now != nil {
+		now = c.Now
+	}
+	return duration.HumanDuration(now().Sub(t.Time))
+}
+
+// Endpoint returns the URL agents of the cluster reach the agent at, or its ingress URL.
+func Endpoint(agent *aiv1.Agent) string {
+	names := agent.Status.ResourceNames
+	if names == nil {
+		generated := naming.Names(agent.Name, agent.Spec.NameOverrides)
+		names = &generated
+	}
+	if names.IngressHost != "" {
+		return "http://" + names.IngressHost
+	}
+	return fmt.Sprintf("http://%s.%s.svc", names.Service, agent.Namespace)
+}
+
+func orNone(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
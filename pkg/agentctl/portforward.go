@@ -0,0 +1,131 @@
+package agentctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+// PortForwarder connects to the Service of an agent like kubectl port-forward: it forwards
+// a local port to the target port of the Service on one of the ready pods it selects.
+type PortForwarder struct {
+	Config *rest.Config
+	Client client.Client
+	// ErrOut receives the errors of the forwarded connections. They are discarded when nil.
+	ErrOut io.Writer
+}
+
+// Connect forwards a random local port to the Service of agent.
+func (f *PortForwarder) Connect(ctx context.Context, agent *aiv1.Agent) (string, func(), error) {
+	serviceName := naming.Names(agent.Name, agent.Spec.NameOverrides).Service
+	if agent.Status.ResourceNames != nil && agent.Status.ResourceNames.Service != "" {
+		serviceName = agent.Status.ResourceNames.Service
+	}
+	service := &corev1.Service{}
+	if err := f.Client.Get(ctx, types.NamespacedName{Name: serviceName, Namespace: agent.Namespace}, service); err != nil {
+		return "", nil, fmt.Errorf("failed to get service %s: %w", serviceName, err)
+	}
+	if len(service.Spec.Ports) == 0 || len(service.Spec.Selector) == 0 {
+		return "", nil, fmt.Errorf("service %s selects no pods", serviceName)
+	}
+
+	var pods corev1.PodList
+	if err := f.Client.List(ctx, &pods, client.InNamespace(agent.Namespace), client.MatchingLabels(service.Spec.Selector)); err != nil {
+		return "", nil, fmt.Errorf("failed to list the pods of service %s: %w", serviceName, err)
+	}
+	pod := readyPod(pods.Items)
+	if pod == nil {
+		return "", nil, fmt.Errorf("service %s has no ready pod", serviceName)
+	}
+	port, err := podPort(pod, service.Spec.Ports[0].TargetPort)
+	if err != nil {
+		return "", nil, err
+	}
+
+	clientset, err := kubernetes.NewForConfig(f.Config)
+	if err != nil {
+		return "", nil, err
+	}
+	transport, upgrader, err := spdy.RoundTripperFor(f.Config)
+	if err != nil {
+		return "", nil, err
+	}
+	url := clientset.CoreV1().RESTClient().Post().
+		Resource("pods").Namespace(pod.Namespace).Name(pod.Name).SubResource("portforward").URL()
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, url)
+
+	errOut := f.ErrOut
+	if errOut == nil {
+		errOut = io.Discard
+	}
+	stopCh, readyCh := make(chan struct{}), make(chan struct{})
+	forwarder, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, []string{fmt.Sprintf("0:%d", port)}, stopCh, readyCh, io.Discard, errOut)
+	if err != nil {
+		return "", nil, err
+	}
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- forwarder.ForwardPorts()
+	}()
+	stop := func() { close(stopCh) }
+
+	select {
+	case <-readyCh:
+	case err := <-errCh:
+		return "", nil, fmt.Errorf("failed to forward to pod %s: %w", pod.Name, err)
+	case <-ctx.Done():
+		stop()
+		return "", nil, ctx.Err()
+	}
+	ports, err := forwarder.GetPorts()
+	if err != nil || len(ports) == 0 {
+		stop()
+		return "", nil, fmt.Errorf("failed to forward to pod %s: %v", pod.Name, err)
+	}
+	return "http://127.0.0.1:" + strconv.Itoa(int(ports[0].Local)), stop, nil
+}
+
+// readyPod returns a running pod whose containers are ready, or nil.
+func readyPod(pods []corev1.Pod) *corev1.Pod {
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+		for _, condition := range pod.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				return pod
+			}
+		}
+	}
+	return nil
+}
+
+// podPort returns the container port of pod a Service target port refers to.
+func podPort(pod *corev1.Pod, target intstr.IntOrString) (int32, error) {
+	if target.Type == intstr.Int {
+		return target.IntVal, nil
+	}
+	for _, container := range pod.Spec.Containers {
+		for _, port := range container.Ports {
+			if port.Name == target.StrVal {
+				return port.ContainerPort, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("pod %s has no port named %s", pod.Name, target.StrVal)
+}
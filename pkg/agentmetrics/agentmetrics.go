@@ -0,0 +1,229 @@
+// Package agentmetrics scrapes the Prometheus metrics exposed by the agent runtime.
+//
+// The operator reads the request, error and latency counters of individual agent pods to
+// evaluate rollout gates. Counters are cumulative per pod, so callers compare two samples
+// taken over a window with Sub.
+package agentmetrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+)
+
+// Metric names exposed by the agent runtime.
+const (
+	RequestsMetric         = "kubeagentic_requests_total"
+	ErrorsMetric           = "kubeagentic_errors_total"
+	ResponseDurationMetric = "kubeagentic_response_duration_seconds"
+	TokensMetric           = "kubeagentic_tokens_total"
+	CacheRequestsMetric    = "kubeagentic_cache_requests_total"
+	CacheSavedTokensMetric = "kubeagentic_cache_saved_tokens_total"
+	// TranscriptSinkFailuresMetric is a gauge of the batches of transcripts the pod failed to
+	// deliver to its sink in a row.
+	TranscriptSinkFailuresMetric = "kubeagentic_transcript_sink_consecutive_failures"
+)
+
+// Sample holds the cumulative request counters of one or more agent pods.
+type Sample struct {
+	// Requests is the number of chat requests handled.
+	Requests float64
+	// Errors is the number of chat requests that failed.
+	Errors float64
+	// LatencySum is the total duration of the chat requests in seconds.
+	LatencySum float64
+	// LatencyCount is the number of observed chat request durations.
+	LatencyCount float64
+	// Tokens is the number of prompt and completion tokens consumed.
+	Tokens float64
+	// PromptTokens is the part of Tokens sent as prompts.
+	PromptTokens float64
+	// CompletionTokens is the part of Tokens produced as completions.
+	CompletionTokens float64
+	// CacheHits is the number of prompts answered from the response cache.
+	CacheHits float64
+	// CacheMisses is the number of prompts the response cache sent to the provider.
+	CacheMisses float64
+	// CacheSavedPromptTokens is the number of prompt tokens the cache hits did not consume.
+	CacheSavedPromptTokens float64
+	// CacheSavedCompletionTokens is the number of completion tokens the cache hits did not
+	// consume.
+	CacheSavedCompletionTokens float64
+	// TranscriptSinkFailures is the number of batches of transcripts failed in a row. It is a
+	// gauge: Add keeps the highest of both samples and Sub the latest.
+	TranscriptSinkFailures float64
+}
+
+// Add returns the sum of two samples, e.g. of two pods.
+func (s Sample) Add(o Sample) Sample {
+	return Sample{
+		Requests:         s.Requests + o.Requests,
+		Errors:           s.Errors + o.Errors,
+		LatencySum:       s.LatencySum + o.LatencySum,
+		LatencyCount:     s.LatencyCount + o.LatencyCount,
+		Tokens:           s.Tokens + o.Tokens,
+		PromptTokens:     s.PromptTokens + o.PromptTokens,
+		CompletionTokens: s.CompletionTokens + o.CompletionTokens,
+
+		CacheHits:                  s.CacheHits + o.CacheHits,
+		CacheMisses:                s.CacheMisses + o.CacheMisses,
+		CacheSavedPromptTokens:     s.CacheSavedPromptTokens + o.CacheSavedPromptTokens,
+		CacheSavedCompletionTokens: s.CacheSavedCompletionTokens + o.CacheSavedCompletionTokens,
+		TranscriptSinkFailures:     math.Max(s.TranscriptSinkFailures, o.TranscriptSinkFailures),
+	}
+}
+
+// Sub returns the increase from an earlier sample o to s. A counter lower than before means
+// the pod restarted, in which case the current value is the increase since the restart.
+func (s Sample) Sub(o Sample) Sample {
+	if s.Requests < o.Requests || s.Errors < o.Errors || s.LatencyCount < o.LatencyCount || s.Tokens < o.Tokens {
+		return s
+	}
+	return Sample{
+		Requests:         s.Requests - o.Requests,
+		Errors:           s.Errors - o.Errors,
+		LatencySum:       s.LatencySum - o.LatencySum,
+		LatencyCount:     s.LatencyCount - o.LatencyCount,
+		Tokens:           s.Tokens - o.Tokens,
+		PromptTokens:     s.PromptTokens - o.PromptTokens,
+		CompletionTokens: s.CompletionTokens - o.CompletionTokens,
+
+		CacheHits:                  s.CacheHits - o.CacheHits,
+		CacheMisses:                s.CacheMisses - o.CacheMisses,
+		CacheSavedPromptTokens:     s.CacheSavedPromptTokens - o.CacheSavedPromptTokens,
+		CacheSavedCompletionTokens: s.CacheSavedCompletionTokens - o.CacheSavedCompletionTokens,
+		TranscriptSinkFailures:     s.TranscriptSinkFailures,
+	}
+}
+
+// ErrorRate returns the fraction of failed requests, or 0 without requests.
+func (s Sample) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Errors / s.Requests
+}
+
+// AverageLatency returns the mean request duration, or 0 without requests.
+func (s Sample) AverageLatency() time.Duration {
+	if s.LatencyCount == 0 {
+		return 0
+	}
+	return time.Duration(s.LatencySum / s.LatencyCount * float64(time.Second))
+}
+
+// TokensPerRequest returns the mean number of tokens per request, or 0 without requests.
+func (s Sample) TokensPerRequest() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return s.Tokens / s.Requests
+}
+
+// Parse reads a sample from the Prometheus text exposition format, summing the series of
+// all label values.
+func Parse(r io.Reader) (Sample, error) {
+	var parser expfmt.TextParser
+	families, err := parser.TextToMetricFamilies(r)
+	if err != nil {
+		return Sample{}, err
+	}
+
+	var sample Sample
+	for _, metric := range families[RequestsMetric].GetMetric() {
+		sample.Requests += metric.GetCounter().GetValue()
+	}
+	for _, metric := range families[ErrorsMetric].GetMetric() {
+		sample.Errors += metric.GetCounter().GetValue()
+	}
+	for _, metric := range families[ResponseDurationMetric].GetMetric() {
+		sample.LatencySum += metric.GetHistogram().GetSampleSum()
+		sample.LatencyCount += float64(metric.GetHistogram().GetSampleCount())
+	}
+	for _, metric := range families[TokensMetric].GetMetric() {
+		value := metric.GetCounter().GetValue()
+		sample.Tokens += value
+		for _, label := range metric.GetLabel() {
+			if label.GetName() != "type" {
+				continue
+			}
+			switch label.GetValue() {
+			case "prompt":
+				sample.PromptTokens += value
+			case "completion":
+				sample.CompletionTokens += value
+			}
+		}
+	}
+	for _, metric := range families[CacheRequestsMetric].GetMetric() {
+		switch labelValue(metric.GetLabel(), "result") {
+		case "hit":
+			sample.CacheHits += metric.GetCounter().GetValue()
+		case "miss":
+			sample.CacheMisses += metric.GetCounter().GetValue()
+		}
+	}
+	for _, metric := range families[CacheSavedTokensMetric].GetMetric() {
+		switch labelValue(metric.GetLabel(), "type") {
+		case "prompt":
+			sample.CacheSavedPromptTokens += metric.GetCounter().GetValue()
+		case "completion":
+			sample.CacheSavedCompletionTokens += metric.GetCounter().GetValue()
+		}
+	}
+	for _, metric := range families[TranscriptSinkFailuresMetric].GetMetric() {
+		sample.TranscriptSinkFailures = math.Max(sample.TranscriptSinkFailures, metric.GetGauge().GetValue())
+	}
+	return sample, nil
+}
+
+// labelValue returns the value of the label name, or an empty string.
+func labelValue(labels []*dto.LabelPair, name string) string {
+	for _, label := range labels {
+		if label.GetName() == name {
+			return label.GetValue()
+		}
+	}
+	return ""
+}
+
+// Scraper fetches samples from the /metrics endpoint of agent pods.
+type Scraper struct {
+	// Client is the HTTP client used for scraping. http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+// Scrape fetches the sample of the agent runtime at baseURL, sending token as a bearer
+// token when it is set.
+func (s *Scraper) Scrape(ctx context.Context, baseURL, token string) (Sample, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/metrics", nil)
+	if err != nil {
+		return Sample{}, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Sample{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Sample{}, fmt.Errorf("scraping %s/metrics: unexpected status %s", baseURL, resp.Status)
+	}
+	return Parse(resp.Body)
+}
@@ -0,0 +1,109 @@
+// Package backoff computes exponentially growing requeue delays for objects that keep
+// failing to reconcile.
+//
+// The first failure is retried after Base, and each consecutive failure doubles the delay
+// up to Max. A random jitter of up to Jitter times the delay spreads the retries of
+// objects that failed together. Failures are forgotten on Reset, and when the object
+// fails at another generation, as a changed spec deserves a prompt retry.
+package backoff
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultBase is the delay after the first failure when Base is not set.
+	DefaultBase = 2 * time.Minute
+	// DefaultMax caps the delay when Max is not set.
+	DefaultMax = 30 * time.Minute
+	// DefaultJitter is the jitter fraction when Jitter is not set.
+	DefaultJitter = 0.1
+)
+
+// Backoff tracks the consecutive failures of objects. The zero value uses the defaults and
+// is safe for concurrent use.
+type Backoff struct {
+	// Base is the delay after the first failure.
+	Base time.Duration
+	// Max caps the delay, jitter included.
+	Max time.Duration
+	// Jitter is the fraction of the delay added at most at random; negative disables it.
+	Jitter float64
+	// Rand returns a number in [0, 1) scaling the jitter. rand.Float64 is used when nil.
+	Rand func() float64
+
+	mu       sync.Mutex
+	failures map[string]failure
+}
+
+type failure struct {
+	generation int64
+	count      int
+}
+
+// Next records a failure of the object identified by key at generation, and returns the
+// delay before retrying it.
+func (b *Backoff) Next(key string, generation int64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.failures == nil {
+		b.failures = map[string]failure{}
+	}
+	entry := b.failures[key]
+	if entry.generation != generation {
+		entry = failure{generation: generation}
+	}
+	entry.count++
+	b.failures[key] = entry
+	return b.delay(entry.count)
+}
+
+// Failures returns the number of consecutive failures recorded for key.
+func (b *Backoff) Failures(key string) int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures[key].count
+}
+
+// Reset forgets the failures of key, so that its next failure is retried after Base.
+func (b *Backoff) Reset(key string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.failures, key)
+}
+
+// delay returns the delay after count consecutive failures.
+func (b *Backoff) delay(count int) time.Duration {
+	base, max := b.Base, b.Max
+	if base <= 0 {
+		base = DefaultBase
+	}
+	if max <= 0 {
+		max = DefaultMax
+	}
+
+	delay := base
+	for i := 1; i < count && delay < max; i++ {
+		delay *= 2
+	}
+	if jitter := b.jitter(); jitter > 0 {
+		random := rand.Float64
+		if b.Rand != nil {
+			random = b.Rand
+		}
+		delay += time.Duration(jitter * random() * float64(delay))
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+func (b *Backoff) jitter() float64 {
+	if b.Jitter == 0 {
+		return DefaultJitter
+	}
+	return b.Jitter
+}
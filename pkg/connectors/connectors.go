@@ -0,0 +1,100 @@
+// Package connectors defines the configuration of the chat connector sidecar and reads the
+// state it reports.
+//
+// Agents with connectors run a sidecar bridging Slack and Discord to the agent. Each
+// connector is configured by a JSON file, and the sidecar reports whether each connector is
+// connected to its platform on /status, which the operator reads to set the
+// ConnectorsReady condition of the agent.
+package connectors
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// States of a connector reported by the sidecar.
+const (
+	// StateConnecting means the connector has not connected to its platform yet.
+	StateConnecting = "connecting"
+	// StateConnected means the connector receives the messages of its platform.
+	StateConnected = "connected"
+	// StateFailed means the platform rejected the connector, such as for an expired token.
+	// The sidecar keeps retrying with backoff.
+	StateFailed = "failed"
+)
+
+// Config is the configuration file of a connector.
+type Config struct {
+	// Name identifies the connector.
+	Name string `json:"name"`
+	// Type is the chat platform, slack or discord.
+	Type string `json:"type"`
+	// Channels restricts the connector to these channel IDs, all channels when empty.
+	Channels []string `json:"channels"`
+	// Mention answers the messages mentioning the bot.
+	Mention bool `json:"mention"`
+	// DirectMessages answers the direct messages sent to the bot.
+	DirectMessages bool `json:"directMessages"`
+	// Prefix answers the messages starting with it, when set.
+	Prefix string `json:"prefix,omitempty"`
+	// BotTokenFile holds the bot token.
+	BotTokenFile string `json:"botTokenFile"`
+	// AppTokenFile holds the app-level token of the Slack Socket Mode connection.
+	AppTokenFile string `json:"appTokenFile,omitempty"`
+}
+
+// ConnectorStatus is the state of a connector of an agent pod.
+type ConnectorStatus struct {
+	// Name identifies the connector.
+	Name string `json:"name"`
+	// Type is the chat platform.
+	Type string `json:"type"`
+	// State is one of the states above.
+	State string `json:"state"`
+	// Error describes why the connector failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Status is the state of the connectors of an agent pod.
+type Status struct {
+	Connectors []ConnectorStatus `json:"connectors"`
+}
+
+// Checker fetches the state of the connectors from the /status endpoint of the sidecar.
+type Checker struct {
+	// Client is the HTTP client used for the requests. http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+// Check fetches the state of the connectors of the sidecar at baseURL.
+func (c *Checker) Check(ctx context.Context, baseURL string) (Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/status", nil)
+	if err != nil {
+		return Status{}, err
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Status{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Status{}, fmt.Errorf("checking %s/status: unexpected status %s", baseURL, resp.Status)
+	}
+
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return Status{}, fmt.Errorf("checking %s/status: %w", baseURL, err)
+	}
+	return status, nil
+}
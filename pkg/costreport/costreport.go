@@ -0,0 +1,226 @@
+// Package costreport renders the periodic report of the tokens consumed by the agents and
+// what they cost, grouped by namespace and team.
+//
+// Agents whose metrics could not be read completely are flagged as partial, and tokens of
+// models without a known price are reported as unpriced, so that the totals are never
+// silently understated.
+package costreport
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// AgentUsage is the consumption of an agent during the report period.
+type AgentUsage struct {
+	Namespace        string  `json:"namespace"`
+	Name             string  `json:"name"`
+	Team             string  `json:"team,omitempty"`
+	Provider         string  `json:"provider"`
+	Model            string  `json:"model"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	Cost             float64 `json:"cost"`
+	// UnpricedTokens are the tokens of models without a known price, not included in Cost.
+	UnpricedTokens int64 `json:"unpricedTokens,omitempty"`
+	// Partial is set when the metrics of some pods of the agent could not be read.
+	Partial       bool   `json:"partial,omitempty"`
+	PartialReason string `json:"partialReason,omitempty"`
+}
+
+// Group sums the consumption of the agents of a namespace or team.
+type Group struct {
+	Name             string  `json:"name"`
+	Agents           int     `json:"agents"`
+	PromptTokens     int64   `json:"promptTokens"`
+	CompletionTokens int64   `json:"completionTokens"`
+	Cost             float64 `json:"cost"`
+	UnpricedTokens   int64   `json:"unpricedTokens,omitempty"`
+	PartialAgents    int     `json:"partialAgents,omitempty"`
+}
+
+// add adds the consumption of agent to the group.
+func (g *Group) add(agent AgentUsage) {
+	g.Agents++
+	g.PromptTokens += agent.PromptTokens
+	g.CompletionTokens += agent.CompletionTokens
+	g.Cost += agent.Cost
+	g.UnpricedTokens += agent.UnpricedTokens
+	if agent.Partial {
+		g.PartialAgents++
+	}
+}
+
+// Report is the consumption of all agents during a period.
+type Report struct {
+	Period      string    `json:"period"`
+	PeriodStart time.Time `json:"periodStart"`
+	PeriodEnd   time.Time `json:"periodEnd"`
+	GeneratedAt time.Time `json:"generatedAt"`
+	// Complete is set once the period has ended.
+	Complete bool   `json:"complete"`
+	Currency string `json:"currency"`
+	// TeamLabel is the label naming the team of an agent.
+	TeamLabel  string       `json:"teamLabel"`
+	Total      Group        `json:"total"`
+	Namespaces []Group      `json:"namespaces"`
+	Teams      []Group      `json:"teams"`
+	Agents     []AgentUsage `json:"agents"`
+	// Partial is set when the report misses data of some agents.
+	Partial bool `json:"partial"`
+}
+
+// Unassigned is the team of agents without the team label.
+const Unassigned = "(unassigned)"
+
+// New returns the report of agents, sorted and summed by namespace and team.
+func New(period string, start, end, generatedAt time.Time, currency, teamLabel string, agents []AgentUsage) *Report {
+	report := &Report{
+		Period:      period,
+		PeriodStart: start.UTC(),
+		PeriodEnd:   end.UTC(),
+		GeneratedAt: generatedAt.UTC(),
+		Complete:    !generatedAt.Before(end),
+		Currency:    currency,
+		TeamLabel:   teamLabel,
+		Total:       Group{Name: "total"},
+		Namespaces:  []Group{},
+		Teams:       []Group{},
+		Agents:      make([]AgentUsage, 0, len(agents)),
+	}
+	for _, agent := range agents {
+		agent.Cost = roundCost(agent.Cost)
+		report.Agents = append(report.Agents, agent)
+	}
+	sort.Slice(report.Agents, func(i, j int) bool {
+		if report.Agents[i].Namespace != report.Agents[j].Namespace {
+			return report.Agents[i].Namespace < report.Agents[j].Namespace
+		}
+		return report.Agents[i].Name < report.Agents[j].Name
+	})
+
+	namespaces := map[string]*Group{}
+	teams := map[string]*Group{}
+	for _, agent := range report.Agents {
+		team := agent.Team
+		if team == "" {
+			team = Unassigned
+		}
+		if namespaces[agent.Namespace] == nil {
+			namespaces[agent.Namespace] = &Group{Name: agent.Namespace}
+		}
+		if teams[team] == nil {
+			teams[team] = &Group{Name: team}
+		}
+		namespaces[agent.Namespace].add(agent)
+		teams[team].add(agent)
+		report.Total.add(agent)
+		report.Partial = report.Partial || agent.Partial
+	}
+	report.Total.Cost = roundCost(report.Total.Cost)
+	report.Namespaces = sortedGroups(namespaces)
+	report.Teams = sortedGroups(teams)
+	return report
+}
+
+func sortedGroups(groups map[string]*Group) []Group {
+	sorted := make([]Group, 0, len(groups))
+	for _, group := range groups {
+		group.Cost = roundCost(group.Cost)
+		sorted = append(sorted, *group)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
+
+// JSON renders the report as indented JSON.
+func (r *Report) JSON() ([]byte, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// Text renders the report as human-readable tables.
+func (r *Report) Text() string {
+	var buf bytes.Buffer
+	state := "in progress"
+	if r.Complete {
+		state = "complete"
+	}
+	fmt.Fprintf(&buf, "KubeAgentic cost report (%s, %s)\n", r.Period, state)
+	fmt.Fprintf(&buf, "Period:    %s to %s\n", r.PeriodStart.Format(time.RFC3339), r.PeriodEnd.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Generated: %s\n", r.GeneratedAt.Format(time.RFC3339))
+	fmt.Fprintf(&buf, "Total:     %s %s for %d agents, %d prompt and %d completion tokens\n",
+		formatCost(r.Total.Cost), r.Currency, r.Total.Agents, r.Total.PromptTokens, r.Total.CompletionTokens)
+
+	fmt.Fprintf(&buf, "\nBy namespace\n")
+	r.writeGroups(&buf, "NAMESPACE", r.Namespaces)
+	fmt.Fprintf(&buf, "\nBy team (label %s)\n", r.TeamLabel)
+	r.writeGroups(&buf, "TEAM", r.Teams)
+
+	fmt.Fprintf(&buf, "\nBy agent\n")
+	w := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAMESPACE\tAGENT\tTEAM\tMODEL\tPROMPT TOKENS\tCOMPLETION TOKENS\tCOST (%s)\tNOTES\n", r.Currency)
+	for _, agent := range r.Agents {
+		team := agent.Team
+		if team == "" {
+			team = Unassigned
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s/%s\t%d\t%d\t%s", agent.Namespace, agent.Name, team, agent.Provider, agent.Model,
+			agent.PromptTokens, agent.CompletionTokens, formatCost(agent.Cost))
+		// Rows without notes end at the cost, without trailing padding
+		if notes := notes(agent); notes != "" {
+			fmt.Fprintf(w, "\t%s", notes)
+		}
+		fmt.Fprintln(w)
+	}
+	w.Flush()
+
+	if r.Partial || r.Total.UnpricedTokens > 0 {
+		fmt.Fprintf(&buf, "\nWarnings\n")
+		if r.Partial {
+			fmt.Fprintf(&buf, "- Agents with partial data: %d; their consumption may be understated\n", r.Total.PartialAgents)
+		}
+		if r.Total.UnpricedTokens > 0 {
+			fmt.Fprintf(&buf, "- Tokens of models without a known price: %d; they are not included in the cost\n", r.Total.UnpricedTokens)
+		}
+	}
+	return buf.String()
+}
+
+func (r *Report) writeGroups(buf *bytes.Buffer, title string, groups []Group) {
+	w := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "%s\tAGENTS\tPROMPT TOKENS\tCOMPLETION TOKENS\tCOST (%s)\tPARTIAL AGENTS\n", title, r.Currency)
+	for _, group := range groups {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%s\t%d\n", group.Name, group.Agents, group.PromptTokens, group.CompletionTokens, formatCost(group.Cost), group.PartialAgents)
+	}
+	w.Flush()
+}
+
+func notes(agent AgentUsage) string {
+	var notes []string
+	if agent.Partial {
+		notes = append(notes, "partial: "+agent.PartialReason)
+	}
+	if agent.UnpricedTokens > 0 {
+		notes = append(notes, fmt.Sprintf("%d unpriced tokens", agent.UnpricedTokens))
+	}
+	return strings.Join(notes, "; ")
+}
+
+// roundCost rounds sums of costs to a hundredth of a cent, dropping floating-point noise.
+func roundCost(cost float64) float64 {
+	return math.Round(cost*1e4) / 1e4
+}
+
+func formatCost(cost float64) string {
+	return fmt.Sprintf("%.2f", cost)
+}
@@ -0,0 +1,34 @@
+// Package defaultmodel resolves the model of Agents that only name their provider.
+//
+// Hosted providers have built-in defaults, small and inexpensive models that suit a first
+// agent. The operator replaces them, or adds defaults for self-hosted providers, with
+// DEFAULT_MODEL_<PROVIDER> environment variables such as DEFAULT_MODEL_OPENAI.
+package defaultmodel
+
+import (
+	"os"
+	"strings"
+)
+
+// Annotation records on an Agent the model that the defaulting webhook chose, so that it
+// is obvious the model was defaulted rather than chosen.
+const Annotation = "kubeagentic.ai/defaulted-model"
+
+var builtins = map[string]string{
+	"openai": "gpt-4o-mini",
+	"claude": "claude-3-5-haiku-20241022",
+	"gemini": "gemini-1.5-flash",
+}
+
+// For returns the default model of provider, or an empty string when it has none.
+func For(provider string) string {
+	if model := os.Getenv(EnvVar(provider)); model != "" {
+		return model
+	}
+	return builtins[provider]
+}
+
+// EnvVar returns the environment variable overriding the default model of provider.
+func EnvVar(provider string) string {
+	return "DEFAULT_MODEL_" + strings.ToUpper(provider)
+}
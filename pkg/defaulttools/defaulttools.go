@@ -0,0 +1,129 @@
+// Package defaulttools injects the tools of the organization into every Agent.
+//
+// The platform team lists the tools in a ConfigMap of the operator namespace, loaded with
+// Set whenever it changes, and the defaulting webhook appends them to spec.tools with
+// Inject. The names of the injected tools are recorded in the Annotation of the agent, so
+// that users can tell them apart from their own, and the webhook keeps managing them on
+// every update: it refreshes them, and removes those dropped from the defaults. Agents
+// annotated with SkipAnnotation get no default tools.
+package defaulttools
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// Annotation lists the names of the tools injected into an Agent, comma-separated.
+	Annotation = "kubeagentic.ai/default-tools"
+	// SkipAnnotation set to "true" on an Agent opts it out of the default tools.
+	SkipAnnotation = "kubeagentic.ai/skip-default-tools"
+)
+
+// Config is the YAML format of the default tools ConfigMap.
+type Config struct {
+	Tools []aiv1.Tool `json:"tools"`
+}
+
+var (
+	mu      sync.RWMutex
+	current []aiv1.Tool
+)
+
+// Parse reads default tools in the format of Config. Tools need a name and a description,
+// and their names must be unique.
+func Parse(data []byte) ([]aiv1.Tool, error) {
+	config := &Config{}
+	if err := yaml.UnmarshalStrict(data, config); err != nil {
+		return nil, fmt.Errorf("invalid default tools: %w", err)
+	}
+	if len(config.Tools) > aiv1.MaxTools {
+		return nil, fmt.Errorf("invalid default tools: %d tools, at most %d are allowed", len(config.Tools), aiv1.MaxTools)
+	}
+	names := map[string]bool{}
+	for i, tool := range config.Tools {
+		if tool.Name == "" || tool.Description == "" {
+			return nil, fmt.Errorf("invalid default tools: tool %d needs a name and a description", i)
+		}
+		if strings.Contains(tool.Name, ",") {
+			return nil, fmt.Errorf("invalid default tools: tool name %q contains a comma", tool.Name)
+		}
+		if names[tool.Name] {
+			return nil, fmt.Errorf("invalid default tools: tool %q is listed twice", tool.Name)
+		}
+		names[tool.Name] = true
+	}
+	return config.Tools, nil
+}
+
+// Set makes tools the ones injected by Inject. Nil tools inject none.
+func Set(tools []aiv1.Tool) {
+	mu.Lock()
+	defer mu.Unlock()
+	current = tools
+}
+
+// Current returns the tools injected by Inject.
+func Current() []aiv1.Tool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// Inject brings the default tools of the agent with object and spec up to date: the tools
+// injected before are removed, and the current defaults are appended, except for those
+// whose name the agent already uses for a tool of its own and those over aiv1.MaxTools.
+// Injecting twice changes nothing.
+func Inject(object metav1.Object, spec *aiv1.AgentSpec) {
+	annotations := object.GetAnnotations()
+	injected := map[string]bool{}
+	if names := annotations[Annotation]; names != "" {
+		for _, name := range strings.Split(names, ",") {
+			injected[name] = true
+		}
+	}
+
+	// The tools of the agent, without those injected before
+	var tools []aiv1.Tool
+	own := map[string]bool{}
+	for _, tool := range spec.Tools {
+		if !injected[tool.Name] {
+			tools = append(tools, tool)
+			own[tool.Name] = true
+		}
+	}
+
+	var names []string
+	if annotations[SkipAnnotation] != "true" {
+		for _, tool := range Current() {
+			if own[tool.Name] || len(tools) >= aiv1.MaxTools {
+				continue
+			}
+			tools = append(tools, *tool.DeepCopy())
+			names = append(names, tool.Name)
+		}
+	}
+
+	if !equality.Semantic.DeepEqual(tools, spec.Tools) {
+		spec.Tools = tools
+	}
+	if len(names) == 0 {
+		if _, ok := annotations[Annotation]; ok {
+			delete(annotations, Annotation)
+			object.SetAnnotations(annotations)
+		}
+		return
+	}
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[Annotation] = strings.Join(names, ",")
+	object.SetAnnotations(annotations)
+}
@@ -0,0 +1,76 @@
+// Package dependency detects cycles in the dependencies between agents.
+//
+// Agents wait for the agents listed in their spec.dependsOn before starting, so a cycle
+// would keep every agent on it waiting forever. The graph is walked lazily, so that callers
+// can read the dependencies of each agent from a cache or an API server.
+package dependency
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Key identifies an agent.
+type Key struct {
+	Namespace string
+	Name      string
+}
+
+func (k Key) String() string {
+	return k.Namespace + "/" + k.Name
+}
+
+// FindCycle returns a cycle of dependencies through start, from start back to start, or nil
+// when there is none. next returns the agents that an agent depends on; agents that do
+// not exist have no dependencies. Cycles that do not pass through start are not reported.
+func FindCycle(start Key, next func(Key) ([]Key, error)) ([]Key, error) {
+	visited := map[Key]bool{}
+	var path []Key
+	var visit func(Key) (bool, error)
+	visit = func(key Key) (bool, error) {
+		path = append(path, key)
+		dependencies, err := next(key)
+		if err != nil {
+			return false, err
+		}
+		for _, dependency := range dependencies {
+			if dependency == start {
+				path = append(path, dependency)
+				return true, nil
+			}
+			if visited[dependency] {
+				continue
+			}
+			visited[dependency] = true
+			if found, err := visit(dependency); found || err != nil {
+				return found, err
+			}
+		}
+		path = path[:len(path)-1]
+		return false, nil
+	}
+
+	found, err := visit(start)
+	if err != nil || !found {
+		return nil, err
+	}
+	return path, nil
+}
+
+// Describe returns the cycle as a chain of agents, such as a/b -> a/c -> a/b.
+func Describe(cycle []Key) string {
+	names := make([]string, len(cycle))
+	for i, key := range cycle {
+		names[i] = key.String()
+	}
+	return strings.Join(names, " -> ")
+}
+
+// CycleError reports a cycle of dependencies.
+type CycleError struct {
+	Cycle []Key
+}
+
+func (e *CycleError) Error() string {
+	return fmt.Sprintf("circular dependency: %s", Describe(e.Cycle))
+}
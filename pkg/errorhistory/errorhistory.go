@@ -0,0 +1,78 @@
+// Package errorhistory keeps a bounded history of the recent errors of an agent.
+//
+// The history is ordered from the oldest to the newest error. An error identical to the
+// newest one, with the same reason and message, increments its count instead of adding an
+// entry, so that a flapping agent keeps the sequence of its distinct errors. Entries older
+// than the TTL are dropped.
+package errorhistory
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// DefaultSize is the number of entries kept when History.Size is not set.
+	DefaultSize = 5
+	// DefaultTTL is how long entries are kept when History.TTL is not set.
+	DefaultTTL = 24 * time.Hour
+)
+
+// History bounds the recent errors of an agent.
+type History struct {
+	// Size is the maximum number of entries. DefaultSize is used when not positive.
+	Size int
+	// TTL is how long an entry is kept after the error last occurred. DefaultTTL is used
+	// when not positive.
+	TTL time.Duration
+}
+
+// Record adds an error that occurred at now to entries and returns the new entries.
+func (h History) Record(entries []aiv1.AgentError, now time.Time, reason, message string) []aiv1.AgentError {
+	entries = h.Prune(entries, now)
+	if last := len(entries) - 1; last >= 0 && entries[last].Reason == reason && entries[last].Message == message {
+		entries[last].Count++
+		entries[last].Time = metav1.NewTime(now)
+		return entries
+	}
+
+	entries = append(entries, aiv1.AgentError{
+		Time:    metav1.NewTime(now),
+		Reason:  reason,
+		Message: message,
+		Count:   1,
+	})
+	if size := h.size(); len(entries) > size {
+		entries = entries[len(entries)-size:]
+	}
+	return entries
+}
+
+// Prune returns a copy of entries without the ones that expired at now.
+func (h History) Prune(entries []aiv1.AgentError, now time.Time) []aiv1.AgentError {
+	cutoff := now.Add(-h.ttl())
+	var kept []aiv1.AgentError
+	for _, entry := range entries {
+		if entry.Time.Time.After(cutoff) {
+			kept = append(kept, entry)
+		}
+	}
+	return kept
+}
+
+func (h History) size() int {
+	if h.Size > 0 {
+		return h.Size
+	}
+	return DefaultSize
+}
+
+func (h History) ttl() time.Duration {
+	if h.TTL > 0 {
+		return h.TTL
+	}
+	return DefaultTTL
+}
@@ -0,0 +1,99 @@
+// Package examples validates and renders the few-shot examples of an agent.
+//
+// Users list example exchanges in spec.examples, or in a ConfigMap referenced by
+// spec.examplesRef for larger sets. The operator validates them and renders them into
+// examples.json of the agent ConfigMap, which the runtime reads from AGENT_EXAMPLES_FILE and
+// sends to the model between the system prompt and the conversation.
+package examples
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sigs.k8s.io/yaml"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// MaxInline bounds the examples of spec.examples.
+	MaxInline = 50
+	// MaxReferenced bounds the examples of the ConfigMap of spec.examplesRef.
+	MaxReferenced = 500
+	// MaxMessageLength bounds the characters of the user message and of the answer of an
+	// example.
+	MaxMessageLength = 8000
+	// MaxSize bounds the bytes of the rendered examples.json, which shares the 1MiB of the
+	// agent ConfigMap with the rest of its configuration.
+	MaxSize = 512 * 1024
+
+	// CharsPerToken is the characters of a token in the estimates, a common average for
+	// English text.
+	CharsPerToken = 4
+	// messageOverheadTokens are the tokens a message costs besides its text, such as its role.
+	messageOverheadTokens = 4
+	// WarningShare is the share of the context window of the model the system prompt and the
+	// examples may take before the webhook warns, the rest being left to the conversation
+	// and the answer.
+	WarningShare = 0.5
+)
+
+// Parse reads a JSON or YAML list of examples, such as the value of the ConfigMap of
+// spec.examplesRef, and validates it.
+func Parse(data []byte) ([]aiv1.Example, error) {
+	var examples []aiv1.Example
+	if err := yaml.UnmarshalStrict(data, &examples); err != nil {
+		return nil, fmt.Errorf("invalid examples: %w", err)
+	}
+	if err := Validate(examples, MaxReferenced); err != nil {
+		return nil, err
+	}
+	return examples, nil
+}
+
+// Validate checks that there are at most max examples, that each has a user message and an
+// answer within MaxMessageLength, and that they render within MaxSize.
+func Validate(examples []aiv1.Example, max int) error {
+	if len(examples) > max {
+		return fmt.Errorf("%d examples, at most %d are allowed", len(examples), max)
+	}
+	for i, example := range examples {
+		if example.User == "" || example.Assistant == "" {
+			return fmt.Errorf("example %d: user and assistant are required", i)
+		}
+		if len(example.User) > MaxMessageLength || len(example.Assistant) > MaxMessageLength {
+			return fmt.Errorf("example %d: user and assistant must be at most %d characters", i, MaxMessageLength)
+		}
+	}
+	data, err := Render(examples)
+	if err != nil {
+		return err
+	}
+	if len(data) > MaxSize {
+		return fmt.Errorf("examples take %d bytes, at most %d are allowed", len(data), MaxSize)
+	}
+	return nil
+}
+
+// Render returns examples.json of the agent ConfigMap.
+func Render(examples []aiv1.Example) (string, error) {
+	if examples == nil {
+		examples = []aiv1.Example{}
+	}
+	data, err := json.Marshal(examples)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// EstimateTokens estimates the prompt tokens of the system prompt and of the examples, sent
+// with every request.
+func EstimateTokens(systemPrompt string, examples []aiv1.Example) int64 {
+	chars := int64(len(systemPrompt))
+	messages := int64(1 + 2*len(examples))
+	for _, example := range examples {
+		chars += int64(len(example.User) + len(example.Assistant))
+	}
+	return (chars+CharsPerToken-1)/CharsPerToken + messages*messageOverheadTokens
+}
@@ -0,0 +1,98 @@
+// Package expiry decides when Agents with a time to live are deleted.
+//
+// The deadline of an agent is derived from its creation time, spec.ttl, spec.expireAt and the
+// kubeagentic.ai/extend-ttl annotation only, so that it holds across restarts of the operator
+// without any state kept in memory.
+package expiry
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// ExtendAnnotation holds a duration, such as "2h", added to the deadline of the agent.
+	// Raising it extends the agent again; it is not reset once applied.
+	ExtendAnnotation = "kubeagentic.ai/extend-ttl"
+	// WarningLead is how long before its deadline a warning Event is recorded on the agent.
+	WarningLead = 10 * time.Minute
+)
+
+// Extension returns the duration of the ExtendAnnotation of an agent, or 0 when it is not set.
+func Extension(object metav1.Object) (time.Duration, error) {
+	value, ok := object.GetAnnotations()[ExtendAnnotation]
+	if !ok {
+		return 0, nil
+	}
+	extension, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, fmt.Errorf("annotation %s: %w", ExtendAnnotation, err)
+	}
+	if extension < 0 {
+		return 0, fmt.Errorf("annotation %s must not be negative", ExtendAnnotation)
+	}
+	return extension, nil
+}
+
+// Deadline returns when an agent with spec, created at created, expires: the earlier of
+// created plus spec.ttl and spec.expireAt, pushed back by the ExtendAnnotation of object. It
+// returns false for agents without ttl or expireAt. An invalid annotation, which the webhook
+// rejects, is ignored.
+func Deadline(object metav1.Object, spec *aiv1.AgentSpec, created time.Time) (time.Time, bool) {
+	var deadline time.Time
+	if spec.TTL != nil {
+		deadline = created.Add(spec.TTL.Duration)
+	}
+	if spec.ExpireAt != nil && (deadline.IsZero() || spec.ExpireAt.Time.Before(deadline)) {
+		deadline = spec.ExpireAt.Time
+	}
+	if deadline.IsZero() {
+		return time.Time{}, false
+	}
+	extension, _ := Extension(object)
+	return deadline.Add(extension), true
+}
+
+// MaxFromEnv returns the operator-wide maximum lifetime of agents configured in MAX_AGENT_TTL,
+// or 0, without a maximum, when it is not set or not a positive duration.
+func MaxFromEnv() time.Duration {
+	max, err := time.ParseDuration(os.Getenv("MAX_AGENT_TTL"))
+	if err != nil || max <= 0 {
+		return 0
+	}
+	return max
+}
+
+// Max returns the maximum lifetime of the Agents of namespace with a time to live, the lowest
+// of the operator maximum and the maxTTL of the AgentPolicies of the namespace, and what sets
+// it. It returns 0 when nothing caps it.
+func Max(namespace string, policies []aiv1.AgentPolicy) (time.Duration, string) {
+	max, source := MaxFromEnv(), "the operator"
+	for _, policy := range policies {
+		if policy.Namespace != namespace || policy.Spec.MaxTTL == nil || policy.Spec.MaxTTL.Duration <= 0 {
+			continue
+		}
+		if max == 0 || policy.Spec.MaxTTL.Duration < max {
+			max, source = policy.Spec.MaxTTL.Duration, fmt.Sprintf("AgentPolicy %s", policy.Name)
+		}
+	}
+	return max, source
+}
+
+// Validate checks spec.ttl and the ExtendAnnotation of object.
+func Validate(object metav1.Object, spec *aiv1.AgentSpec) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec.TTL != nil && spec.TTL.Duration <= 0 {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("spec").Child("ttl"), spec.TTL.Duration.String(), "must be positive"))
+	}
+	if _, err := Extension(object); err != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata").Child("annotations").Key(ExtendAnnotation), object.GetAnnotations()[ExtendAnnotation], err.Error()))
+	}
+	return allErrs
+}
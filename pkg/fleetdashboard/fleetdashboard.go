@@ -0,0 +1,146 @@
+// Package fleetdashboard renders the Grafana dashboard of the whole agent fleet, charted from
+// the metrics of the operator: the agents by phase and provider, the errors, durations and
+// queue depth of the reconcilers, the admission webhook rejections, and the agents spending
+// the most tokens and money.
+//
+// The dashboard is rendered from the template embedded in this package. Panels are laid out
+// in Go and numbered in order, and the dashboard has a fixed UID, so that the same options
+// always render the same JSON and Grafana keeps its links and annotations across updates.
+package fleetdashboard
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"text/template"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+)
+
+const (
+	// UID is the Grafana UID of the fleet dashboard.
+	UID = "kubeagentic-fleet"
+
+	// DefaultTopN is how many agents the top spender tables list by default.
+	DefaultTopN = 10
+
+	// Key is the key of the dashboard JSON in its ConfigMap.
+	Key = "kubeagentic-fleet.json"
+
+	// panelWidth and panelHeight size the panels on the 24 columns wide Grafana grid, two
+	// panels per row.
+	panelWidth  = 12
+	panelHeight = 8
+)
+
+//go:embed dashboard.json.tmpl
+var dashboardTemplate string
+
+var tmpl = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"json": func(value interface{}) (string, error) {
+		data, err := json.Marshal(value)
+		return string(data), err
+	},
+}).Parse(dashboardTemplate))
+
+// Options configures the rendered dashboard.
+type Options struct {
+	// TopN is how many agents the top spender tables list. DefaultTopN is used when zero.
+	TopN int
+}
+
+// Panel is a panel of the dashboard.
+type Panel struct {
+	ID    int
+	Title string
+	// Type is the Grafana panel type: timeseries or table.
+	Type    string
+	Unit    string
+	Targets []Target
+	X, Y    int
+	W, H    int
+}
+
+// Target is a query of a panel.
+type Target struct {
+	RefID  string
+	Expr   string
+	Legend string
+	// Instant queries the value at the end of the dashboard range, for the tables.
+	Instant bool
+}
+
+// Panels returns the panels of the dashboard, numbered and laid out in order.
+func Panels(options Options) []Panel {
+	topN := options.TopN
+	if topN <= 0 {
+		topN = DefaultTopN
+	}
+	// The spend of an agent is its tokens of each type priced with the gauge the operator
+	// exports from the pricing catalog, per million tokens.
+	tokens := `sum by (namespace, agent, type) (increase(kubeagentic_tokens_total[$__range]))`
+	cost := fmt.Sprintf(`sum by (namespace, agent) (%s * on (namespace, agent, type) group_left kubeagentic_agent_token_price) / 1e6`, tokens)
+
+	panels := []Panel{
+		timeseries("Agents by Phase", "short",
+			query(`sum by (phase) (kubeagentic_agents)`, "{{phase}}")),
+		timeseries("Agents by Provider", "short",
+			query(`sum by (provider) (kubeagentic_agents)`, "{{provider}}")),
+		timeseries("Reconcile Error Rate", "reqps",
+			query(`sum by (controller) (rate(controller_runtime_reconcile_errors_total[5m]))`, "{{controller}}")),
+		timeseries("Reconcile Duration", "s",
+			query(`histogram_quantile(0.5, sum by (le) (rate(controller_runtime_reconcile_time_seconds_bucket[5m])))`, "p50"),
+			query(`histogram_quantile(0.95, sum by (le) (rate(controller_runtime_reconcile_time_seconds_bucket[5m])))`, "p95"),
+			query(`histogram_quantile(0.99, sum by (le) (rate(controller_runtime_reconcile_time_seconds_bucket[5m])))`, "p99")),
+		timeseries("Webhook Rejections", "short",
+			query(`sum by (operation) (increase(kubeagentic_webhook_rejections_total[5m]))`, "{{operation}}")),
+		timeseries("Queue Depth", "short",
+			query(`sum by (name) (workqueue_depth)`, "{{name}}")),
+		table(fmt.Sprintf("Top %d Agents by Tokens", topN), "short",
+			query(fmt.Sprintf(`topk(%d, sum by (namespace, agent) (increase(kubeagentic_tokens_total[$__range])))`, topN), "")),
+		table(fmt.Sprintf("Top %d Agents by Cost", topN), "currency"+pricing.Currency,
+			query(fmt.Sprintf(`topk(%d, %s)`, topN, cost), "")),
+	}
+	for i := range panels {
+		panels[i].ID = i + 1
+		panels[i].X, panels[i].Y = (i%2)*panelWidth, (i/2)*panelHeight
+		panels[i].W, panels[i].H = panelWidth, panelHeight
+		for j := range panels[i].Targets {
+			panels[i].Targets[j].RefID = string(rune('A' + j))
+		}
+	}
+	return panels
+}
+
+// Render returns the indented JSON of the dashboard.
+func Render(options Options) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct {
+		UID    string
+		Panels []Panel
+	}{UID, Panels(options)}); err != nil {
+		return nil, fmt.Errorf("failed to render the fleet dashboard: %w", err)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return nil, fmt.Errorf("the fleet dashboard template renders invalid JSON: %w", err)
+	}
+	indented.WriteByte('\n')
+	return indented.Bytes(), nil
+}
+
+func timeseries(title, unit string, targets ...Target) Panel {
+	return Panel{Title: title, Type: "timeseries", Unit: unit, Targets: targets}
+}
+
+func table(title, unit string, targets ...Target) Panel {
+	for i := range targets {
+		targets[i].Instant = true
+	}
+	return Panel{Title: title, Type: "table", Unit: unit, Targets: targets}
+}
+
+func query(expr, legend string) Target {
+	return Target{Expr: expr, Legend: legend}
+}
@@ -0,0 +1,214 @@
+// Package gateway serves the OpenAI chat completions API in front of the agents of a
+// namespace, which clients select by passing the name of an agent as the model.
+//
+// The operator renders the models the gateway routes into a JSON file, mounted with the
+// endpoint tokens of the agents requiring one. Requests are forwarded to the
+// /v1/chat/completions route of the agent Service with the token of the agent, and
+// streamed responses are passed through as they arrive.
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxRequestBytes bounds the chat completion requests read to find their model.
+const maxRequestBytes = 10 << 20
+
+// Model routes a model name to an agent.
+type Model struct {
+	// Name is the model name clients pass, the name of the agent.
+	Name string `json:"name"`
+	// URL is the base URL of the agent Service.
+	URL string `json:"url"`
+	// TokenFile holds the endpoint token of the agent, for agents with endpoint auth.
+	TokenFile string `json:"tokenFile,omitempty"`
+}
+
+// Config is the routing configuration of the gateway.
+type Config struct {
+	Models []Model `json:"models"`
+}
+
+// ParseConfig returns the configuration in data.
+func ParseConfig(data []byte) (Config, error) {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("invalid gateway configuration: %w", err)
+	}
+	for _, model := range config.Models {
+		if model.Name == "" || model.URL == "" {
+			return Config{}, fmt.Errorf("invalid gateway configuration: model %q needs a name and a URL", model.Name)
+		}
+	}
+	return config, nil
+}
+
+// Gateway is the HTTP handler of the gateway. The zero value routes no model.
+type Gateway struct {
+	// Transport forwards requests to the agents. http.DefaultTransport is used when nil.
+	Transport http.RoundTripper
+	// ReadFile reads the token files. os.ReadFile is used when nil.
+	ReadFile func(name string) ([]byte, error)
+
+	mu     sync.RWMutex
+	models map[string]Model
+}
+
+// SetConfig replaces the models routed by the gateway.
+func (g *Gateway) SetConfig(config Config) {
+	models := make(map[string]Model, len(config.Models))
+	for _, model := range config.Models {
+		models[model.Name] = model
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.models = models
+}
+
+func (g *Gateway) model(name string) (Model, bool) {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	model, ok := g.models[name]
+	return model, ok
+}
+
+// ServeHTTP serves /v1/models and /v1/chat/completions.
+func (g *Gateway) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch req.URL.Path {
+	case "/v1/models":
+		if req.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "", "Use GET to list the models")
+			return
+		}
+		g.listModels(w)
+	case "/v1/chat/completions":
+		if req.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, "invalid_request_error", "", "Use POST to create a chat completion")
+			return
+		}
+		g.chatCompletions(w, req)
+	case "/healthz":
+		w.WriteHeader(http.StatusOK)
+	default:
+		writeError(w, http.StatusNotFound, "invalid_request_error", "", fmt.Sprintf("Unknown route %s", req.URL.Path))
+	}
+}
+
+// listModels lists the routed agents as OpenAI models, sorted by name.
+func (g *Gateway) listModels(w http.ResponseWriter) {
+	g.mu.RLock()
+	names := make([]string, 0, len(g.models))
+	for name := range g.models {
+		names = append(names, name)
+	}
+	g.mu.RUnlock()
+	sort.Strings(names)
+
+	type modelObject struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	}
+	data := make([]modelObject, 0, len(names))
+	for _, name := range names {
+		data = append(data, modelObject{ID: name, Object: "model", OwnedBy: "kubeagentic"})
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"object": "list", "data": data})
+}
+
+// chatCompletions forwards a chat completion to the agent named by its model.
+func (g *Gateway) chatCompletions(w http.ResponseWriter, req *http.Request) {
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxRequestBytes+1))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", "Failed to read the request body")
+		return
+	}
+	if len(body) > maxRequestBytes {
+		writeError(w, http.StatusRequestEntityTooLarge, "invalid_request_error", "", "The request body is too large")
+		return
+	}
+	var request struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(body, &request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", "The request body is not valid JSON")
+		return
+	}
+	if request.Model == "" {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "", "The model field names the agent to send the request to")
+		return
+	}
+	model, ok := g.model(request.Model)
+	if !ok {
+		writeError(w, http.StatusNotFound, "invalid_request_error", "model_not_found",
+			fmt.Sprintf("The model `%s` does not exist or is not running", request.Model))
+		return
+	}
+	target, err := url.Parse(strings.TrimSuffix(model.URL, "/"))
+	if err != nil {
+		writeError(w, http.StatusBadGateway, "api_error", "", fmt.Sprintf("The URL of model `%s` is invalid", request.Model))
+		return
+	}
+	token := ""
+	if model.TokenFile != "" {
+		readFile := g.ReadFile
+		if readFile == nil {
+			readFile = os.ReadFile
+		}
+		data, err := readFile(model.TokenFile)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, "api_error", "", fmt.Sprintf("The endpoint token of model `%s` is not available yet", request.Model))
+			return
+		}
+		token = strings.TrimSpace(string(data))
+	}
+
+	proxy := &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Scheme = target.Scheme
+			r.Out.URL.Host = target.Host
+			r.Out.URL.Path = target.Path + "/v1/chat/completions"
+			r.Out.Host = target.Host
+			// The agent authenticates the gateway, not the client
+			r.Out.Header.Del("Authorization")
+			if token != "" {
+				r.Out.Header.Set("Authorization", "Bearer "+token)
+			}
+			r.Out.Body = io.NopCloser(bytes.NewReader(body))
+			r.Out.ContentLength = int64(len(body))
+		},
+		Transport: g.Transport,
+		// Streamed chunks are passed on as soon as they arrive
+		FlushInterval: -1,
+		ErrorHandler: func(w http.ResponseWriter, _ *http.Request, err error) {
+			writeError(w, http.StatusBadGateway, "api_error", "", fmt.Sprintf("Model `%s` is not reachable: %v", request.Model, err))
+		},
+	}
+	proxy.ServeHTTP(w, req)
+}
+
+// writeError writes an error in the format of the OpenAI API.
+func writeError(w http.ResponseWriter, status int, errorType, code, message string) {
+	body := map[string]interface{}{"message": message, "type": errorType}
+	if code != "" {
+		body["code"] = code
+	}
+	writeJSON(w, status, map[string]interface{}{"error": body})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,196 @@
+// Package graphstate checks the state of LangGraph workflows against their nodes.
+//
+// The state of a workflow, spec.langgraphConfig.state, is a JSON Schema object whose
+// properties are the fields of the state. The runtime reads the inputs of each node from the
+// state and writes its outputs back, so a misspelled field only fails deep inside a run.
+// Validate rejects inputs that are neither fields of the state nor outputs of another node,
+// and outputs of LLM nodes, which write the text of the answer, into fields declared with a
+// type that holds no text.
+package graphstate
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// UserInput is the field the runtime sets to the message of the user before every run.
+const UserInput = "user_input"
+
+// Types are the types of JSON Schema.
+var Types = []string{"array", "boolean", "integer", "null", "number", "object", "string"}
+
+// Schema is the state of a workflow.
+type Schema struct {
+	// Fields maps the name of each field to its types; fields without types hold any value.
+	Fields map[string][]string
+}
+
+// Holds reports whether the field name is declared and accepts values of type typ.
+func (s Schema) Holds(name, typ string) bool {
+	types, ok := s.Fields[name]
+	if !ok {
+		return false
+	}
+	if len(types) == 0 {
+		return true
+	}
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
+
+// ParseState reads the state schema raw, found at path. A nil raw declares no fields.
+func ParseState(path *field.Path, raw *runtime.RawExtension) (Schema, field.ErrorList) {
+	schema := Schema{Fields: map[string][]string{}}
+	if raw == nil || len(raw.Raw) == 0 {
+		return schema, nil
+	}
+
+	var state map[string]interface{}
+	if err := json.Unmarshal(raw.Raw, &state); err != nil {
+		return schema, field.ErrorList{field.Invalid(path, string(raw.Raw), "must be a JSON Schema object")}
+	}
+	var allErrs field.ErrorList
+	if state["type"] != "object" {
+		allErrs = append(allErrs, field.Invalid(path.Child("type"), state["type"], `must be "object"`))
+	}
+	properties, ok := state["properties"].(map[string]interface{})
+	if !ok || len(properties) == 0 {
+		allErrs = append(allErrs, field.Required(path.Child("properties"), "the state needs named properties, one for each field"))
+		return schema, allErrs
+	}
+
+	// Sorted, so that the errors come in a stable order
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		propertyPath := path.Child("properties").Key(name)
+		if name == "" {
+			allErrs = append(allErrs, field.Invalid(propertyPath, name, "field names must not be empty"))
+			continue
+		}
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			allErrs = append(allErrs, field.Invalid(propertyPath, properties[name], "must be a JSON Schema object"))
+			continue
+		}
+		types, err := parseTypes(property["type"])
+		if err != nil {
+			allErrs = append(allErrs, field.Invalid(propertyPath.Child("type"), property["type"], err.Error()))
+			continue
+		}
+		schema.Fields[name] = types
+	}
+	return schema, allErrs
+}
+
+// parseTypes reads the type of a JSON Schema, a type or a list of types.
+func parseTypes(value interface{}) ([]string, error) {
+	var types []string
+	switch value := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		types = []string{value}
+	case []interface{}:
+		for _, item := range value {
+			typ, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("must be a type or a list of types")
+			}
+			types = append(types, typ)
+		}
+	default:
+		return nil, fmt.Errorf("must be a type or a list of types")
+	}
+	for _, typ := range types {
+		if !isType(typ) {
+			return nil, fmt.Errorf("unsupported type %q, must be one of %v", typ, Types)
+		}
+	}
+	return types, nil
+}
+
+func isType(typ string) bool {
+	for _, t := range Types {
+		if typ == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate returns the errors of the state and of the node inputs and outputs of config,
+// found at path.
+func Validate(path *field.Path, config *aiv1.LanggraphConfig) field.ErrorList {
+	if config == nil {
+		return nil
+	}
+	schema, allErrs := ParseState(path.Child("state"), config.State)
+	if len(allErrs) > 0 {
+		// The references cannot be checked against a state that is not understood
+		return allErrs
+	}
+	if types, ok := schema.Fields[UserInput]; ok && !schema.Holds(UserInput, "string") {
+		allErrs = append(allErrs, field.Invalid(path.Child("state").Child("properties").Key(UserInput).Child("type"),
+			strings.Join(types, ","), "the runtime sets user_input to the message of the user, which is a string"))
+	}
+
+	writers := map[string][]string{}
+	for _, node := range config.Nodes {
+		for _, output := range node.Outputs {
+			writers[output] = append(writers[output], node.Name)
+		}
+	}
+
+	for i, node := range config.Nodes {
+		nodePath := path.Child("nodes").Index(i)
+		for j, input := range node.Inputs {
+			if input == UserInput || declared(schema, input) || writtenByOther(writers[input], node.Name) {
+				continue
+			}
+			allErrs = append(allErrs, field.Invalid(nodePath.Child("inputs").Index(j), input,
+				"is neither a field of the state nor an output of another node"))
+		}
+		for j, output := range node.Outputs {
+			outputPath := nodePath.Child("outputs").Index(j)
+			if output == "" {
+				allErrs = append(allErrs, field.Required(outputPath, "output names must not be empty"))
+				continue
+			}
+			if node.Type == "llm" && declared(schema, output) && !schema.Holds(output, "string") {
+				allErrs = append(allErrs, field.Invalid(outputPath, output, fmt.Sprintf(
+					"LLM nodes write the text of the answer, but the state declares %s as %s", output, strings.Join(schema.Fields[output], " or "))))
+			}
+		}
+	}
+	return allErrs
+}
+
+func declared(schema Schema, name string) bool {
+	_, ok := schema.Fields[name]
+	return ok
+}
+
+// writtenByOther reports whether any of writers is not the node named self.
+func writtenByOther(writers []string, self string) bool {
+	for _, writer := range writers {
+		if writer != self {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,227 @@
+// Package guardrails filters the prompts sent to an agent and the responses it returns.
+//
+// Users describe the rules in a policy ConfigMap referenced by spec.guardrails. The operator
+// validates the policy and renders it into the agent ConfigMap, read by the guardrails proxy
+// placed in front of the agent container. Each rule matches a regular expression against the
+// text of the prompt, of the response or of both, and either blocks the request or lets it
+// through flagged, counted in the moderation metrics of the proxy.
+package guardrails
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// DefaultPort is the port of the guardrails proxy in the agent pods.
+	DefaultPort int32 = 8082
+	// MaxRules bounds the rules of a policy, each matched against every prompt and response.
+	MaxRules = 100
+
+	// TargetPrompt matches a rule against the requests of the clients.
+	TargetPrompt = "prompt"
+	// TargetResponse matches a rule against the responses of the agent.
+	TargetResponse = "response"
+	// TargetBoth matches a rule against the requests and the responses.
+	TargetBoth = "both"
+
+	// ActionBlock rejects the requests matching the rule.
+	ActionBlock = "block"
+	// ActionFlag lets the requests matching the rule through, counted and marked with the
+	// FlaggedHeader of the response.
+	ActionFlag = "flag"
+
+	// FailModeClosed rejects the requests while the proxy has no valid policy, and reports
+	// the pod unready so that the Service stops sending it requests.
+	FailModeClosed = "closed"
+	// FailModeOpen lets the requests through unchecked while the proxy has no valid policy.
+	FailModeOpen = "open"
+
+	// FlaggedHeader lists the flagging rules matched by the request or its response.
+	FlaggedHeader = "X-KubeAgentic-Guardrails-Flagged"
+)
+
+// namePattern is the format of the rule names, which label the moderation metrics.
+var namePattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`)
+
+// Rule matches a pattern against the prompts or the responses of the agent.
+type Rule struct {
+	Name string `json:"name"`
+	// Target is prompt, response or both. Defaults to both.
+	Target string `json:"target,omitempty"`
+	// Pattern is a regular expression in the RE2 syntax, such as
+	// (?i)ignore (all )?previous instructions.
+	Pattern string `json:"pattern"`
+	// Action is block or flag. Defaults to block.
+	Action string `json:"action,omitempty"`
+}
+
+// Policy is the set of rules of an agent.
+type Policy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Parse reads a policy in YAML or JSON and checks its rules. Unknown fields are rejected.
+func Parse(data []byte) (Policy, error) {
+	var policy Policy
+	if err := yaml.UnmarshalStrict(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("invalid guardrails policy: %w", err)
+	}
+	if err := policy.Validate(); err != nil {
+		return Policy{}, err
+	}
+	return policy, nil
+}
+
+// Validate checks that the policy has between 1 and MaxRules uniquely named rules with
+// known targets and actions and compiling patterns.
+func (p Policy) Validate() error {
+	if len(p.Rules) == 0 {
+		return fmt.Errorf("invalid guardrails policy: no rules")
+	}
+	if len(p.Rules) > MaxRules {
+		return fmt.Errorf("invalid guardrails policy: %d rules, at most %d are allowed", len(p.Rules), MaxRules)
+	}
+	names := map[string]bool{}
+	for _, rule := range p.Rules {
+		if !namePattern.MatchString(rule.Name) {
+			return fmt.Errorf("invalid guardrails rule name %q: must consist of lower case alphanumeric characters or '-'", rule.Name)
+		}
+		if names[rule.Name] {
+			return fmt.Errorf("invalid guardrails policy: duplicate rule %q", rule.Name)
+		}
+		names[rule.Name] = true
+		if target := rule.target(); target != TargetPrompt && target != TargetResponse && target != TargetBoth {
+			return fmt.Errorf("invalid guardrails rule %q: target must be prompt, response or both, not %q", rule.Name, target)
+		}
+		if action := rule.action(); action != ActionBlock && action != ActionFlag {
+			return fmt.Errorf("invalid guardrails rule %q: action must be block or flag, not %q", rule.Name, action)
+		}
+		if rule.Pattern == "" {
+			return fmt.Errorf("invalid guardrails rule %q: the pattern must not be empty", rule.Name)
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return fmt.Errorf("invalid guardrails rule %q: %w", rule.Name, err)
+		}
+	}
+	return nil
+}
+
+// Normalized returns the policy with the defaults of the rules set.
+func (p Policy) Normalized() Policy {
+	normalized := Policy{Rules: make([]Rule, 0, len(p.Rules))}
+	for _, rule := range p.Rules {
+		rule.Target, rule.Action = rule.target(), rule.action()
+		normalized.Rules = append(normalized.Rules, rule)
+	}
+	return normalized
+}
+
+// Counts returns the number of blocking and flagging rules of the policy.
+func (p Policy) Counts() (block, flag int) {
+	for _, rule := range p.Rules {
+		if rule.action() == ActionFlag {
+			flag++
+		} else {
+			block++
+		}
+	}
+	return block, flag
+}
+
+func (rule Rule) target() string {
+	if rule.Target == "" {
+		return TargetBoth
+	}
+	return rule.Target
+}
+
+func (rule Rule) action() string {
+	if rule.Action == "" {
+		return ActionBlock
+	}
+	return rule.Action
+}
+
+// appliesTo reports whether the rule is matched against the target, prompt or response.
+func (rule Rule) appliesTo(target string) bool {
+	return rule.target() == TargetBoth || rule.target() == target
+}
+
+// Verdict is the outcome of checking a text.
+type Verdict struct {
+	// Blocked is the first blocking rule the text matched, nil when it is allowed.
+	Blocked *Rule
+	// Flagged are the flagging rules the text matched.
+	Flagged []Rule
+}
+
+type compiledRule struct {
+	rule    Rule
+	pattern *regexp.Regexp
+}
+
+// Engine checks texts against a policy. The zero value has no policy and reports it with
+// Loaded, so that the proxy applies its fail mode.
+type Engine struct {
+	mu    sync.RWMutex
+	rules []compiledRule
+	ready bool
+}
+
+// SetPolicy replaces the policy of the engine.
+func (e *Engine) SetPolicy(policy Policy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	rules := make([]compiledRule, 0, len(policy.Rules))
+	for _, rule := range policy.Rules {
+		rules = append(rules, compiledRule{rule: rule, pattern: regexp.MustCompile(rule.Pattern)})
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.rules, e.ready = rules, true
+	return nil
+}
+
+// Loaded reports whether the engine has a policy.
+func (e *Engine) Loaded() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.ready
+}
+
+// HasTarget reports whether a rule of the policy is matched against the target.
+func (e *Engine) HasTarget(target string) bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	for _, rule := range e.rules {
+		if rule.rule.appliesTo(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check matches the rules of the target, prompt or response, against text.
+func (e *Engine) Check(target, text string) Verdict {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	var verdict Verdict
+	for i := range e.rules {
+		rule := &e.rules[i]
+		if !rule.rule.appliesTo(target) || !rule.pattern.MatchString(text) {
+			continue
+		}
+		if rule.rule.action() == ActionFlag {
+			verdict.Flagged = append(verdict.Flagged, rule.rule)
+		} else if verdict.Blocked == nil {
+			blocked := rule.rule
+			verdict.Blocked = &blocked
+		}
+	}
+	return verdict
+}
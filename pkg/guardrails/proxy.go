@@ -0,0 +1,417 @@
+package guardrails
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultMaxBodyBytes bounds the prompts and responses the proxy checks. Larger ones are
+// handled as when the proxy has no policy, according to its fail mode.
+const DefaultMaxBodyBytes = 1 << 20
+
+// exemptPaths are the routes of the agent runtime served without checks: the probes, and
+// the metrics, which the proxy completes with its own.
+var exemptPaths = map[string]bool{
+	"/health":  true,
+	"/ready":   true,
+	"/metrics": true,
+}
+
+// Proxy checks the requests to the agent container and their responses against the policy
+// of its engine. Blocked requests are rejected with 403 Forbidden.
+type Proxy struct {
+	// Upstream is the URL of the agent container.
+	Upstream *url.URL
+	// Transport forwards requests to the agent. http.DefaultTransport is used when nil.
+	Transport http.RoundTripper
+	// Engine applies the policy.
+	Engine Engine
+	// FailMode is closed or open. Defaults to closed.
+	FailMode string
+	// MaxBodyBytes bounds the checked prompts and responses. Defaults to DefaultMaxBodyBytes.
+	MaxBodyBytes int64
+	// Labels are added to the metrics of the proxy, such as the identity of the agent.
+	Labels map[string]string
+
+	mu        sync.Mutex
+	checked   map[string]uint64
+	blocked   map[[2]string]uint64
+	flagged   map[[2]string]uint64
+	unchecked uint64
+}
+
+// ServeHTTP checks the request, forwards it when it is allowed and checks the response.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	switch {
+	case req.URL.Path == "/metrics":
+		p.serveMetrics(w, req)
+		return
+	case req.URL.Path == "/ready" && p.failClosed() && !p.Engine.Loaded():
+		// Take the pod out of the Service endpoints until the policy loads
+		p.reject(w, http.StatusServiceUnavailable, "The guardrails policy is not loaded")
+		return
+	case exemptPaths[req.URL.Path]:
+		p.forward().ServeHTTP(w, req)
+		return
+	}
+
+	if !p.Engine.Loaded() {
+		p.failOpen(w, req, http.StatusServiceUnavailable, "The guardrails policy is not loaded")
+		return
+	}
+	body, err := p.readBody(req.Body)
+	if err == errTooLarge {
+		// Forward the read part of the body followed by the rest
+		req.Body = readCloser{io.MultiReader(bytes.NewReader(body), req.Body), req.Body}
+		p.failOpen(w, req, http.StatusRequestEntityTooLarge, "The request is too large for the guardrails to check")
+		return
+	} else if err != nil {
+		p.reject(w, http.StatusBadRequest, fmt.Sprintf("Failed to read the request: %v", err))
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.ContentLength = int64(len(body))
+
+	var flagged []Rule
+	if len(body) > 0 {
+		verdict := p.Engine.Check(TargetPrompt, Text(body, req.Header.Get("Content-Type")))
+		p.record(TargetPrompt, verdict)
+		if verdict.Blocked != nil {
+			p.reject(w, http.StatusForbidden, fmt.Sprintf("The request was blocked by the guardrails rule %s", verdict.Blocked.Name))
+			return
+		}
+		flagged = verdict.Flagged
+	}
+	p.reverseProxy(flagged).ServeHTTP(w, req)
+}
+
+// reverseProxy forwards the requests to the agent. The responses are checked when a rule
+// targets them, and carry the flagging rules matched by the request and the response.
+func (p *Proxy) reverseProxy(flagged []Rule) *httputil.ReverseProxy {
+	proxy := p.forward()
+	if flagged == nil && !p.Engine.HasTarget(TargetResponse) {
+		return proxy
+	}
+	proxy.ModifyResponse = func(resp *http.Response) error {
+		if p.Engine.HasTarget(TargetResponse) && checkable(resp.Header.Get("Content-Type")) {
+			body, err := p.readBody(resp.Body)
+			if err == errTooLarge {
+				if p.failClosed() {
+					resp.Body.Close()
+					return errUncheckedResponse
+				}
+				p.countUnchecked()
+				resp.Body = readCloser{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+				return p.setFlagged(resp, flagged)
+			}
+			resp.Body.Close()
+			if err != nil {
+				return err
+			}
+			verdict := p.Engine.Check(TargetResponse, Text(body, resp.Header.Get("Content-Type")))
+			p.record(TargetResponse, verdict)
+			if verdict.Blocked != nil {
+				body, _ = json.Marshal(map[string]string{
+					"detail": fmt.Sprintf("The response was blocked by the guardrails rule %s", verdict.Blocked.Name),
+				})
+				resp.StatusCode, resp.Status = http.StatusForbidden, http.StatusText(http.StatusForbidden)
+				resp.Header.Set("Content-Type", "application/json")
+			}
+			flagged = append(flagged, verdict.Flagged...)
+			resp.Body = io.NopCloser(bytes.NewReader(body))
+			resp.ContentLength = int64(len(body))
+			resp.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+		return p.setFlagged(resp, flagged)
+	}
+	proxy.ErrorHandler = func(w http.ResponseWriter, req *http.Request, err error) {
+		if err == errUncheckedResponse {
+			p.reject(w, http.StatusBadGateway, "The response is too large for the guardrails to check")
+			return
+		}
+		p.reject(w, http.StatusBadGateway, fmt.Sprintf("The agent is unavailable: %v", err))
+	}
+	return proxy
+}
+
+// forward returns the reverse proxy passing the requests and responses on unchecked.
+func (p *Proxy) forward() *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(p.Upstream)
+	proxy.Transport = p.Transport
+	// Streamed chat responses are passed on as soon as they arrive when they are not checked
+	proxy.FlushInterval = -1
+	return proxy
+}
+
+// setFlagged lists the flagging rules matched by the request and the response in the
+// FlaggedHeader of the response.
+func (p *Proxy) setFlagged(resp *http.Response, flagged []Rule) error {
+	if len(flagged) == 0 {
+		return nil
+	}
+	names := make([]string, 0, len(flagged))
+	for _, rule := range flagged {
+		names = append(names, rule.Name)
+	}
+	resp.Header.Set(FlaggedHeader, strings.Join(names, ","))
+	return nil
+}
+
+// readCloser reads the checked part of a body followed by its rest, and closes the body.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+var (
+	errTooLarge          = fmt.Errorf("body too large")
+	errUncheckedResponse = fmt.Errorf("response too large to check")
+)
+
+// readBody reads the body up to the maximum checked size. Larger bodies return errTooLarge
+// with the part read.
+func (p *Proxy) readBody(body io.Reader) ([]byte, error) {
+	if body == nil {
+		return nil, nil
+	}
+	limit := p.MaxBodyBytes
+	if limit <= 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return data, errTooLarge
+	}
+	return data, nil
+}
+
+// failOpen forwards the request unchecked with the open fail mode, and rejects it with the
+// status otherwise.
+func (p *Proxy) failOpen(w http.ResponseWriter, req *http.Request, status int, detail string) {
+	if p.failClosed() {
+		p.reject(w, status, detail)
+		return
+	}
+	p.countUnchecked()
+	p.forward().ServeHTTP(w, req)
+}
+
+func (p *Proxy) failClosed() bool {
+	return p.FailMode != FailModeOpen
+}
+
+func (p *Proxy) reject(w http.ResponseWriter, status int, detail string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"detail": detail})
+}
+
+// checkable reports whether the responses of the content type hold text the rules match.
+func checkable(contentType string) bool {
+	return contentType == "" || strings.HasPrefix(contentType, "text/") || strings.Contains(contentType, "json")
+}
+
+// Text returns the text the rules are matched against: the strings of a JSON document, of
+// the JSON events of an event stream, or the body itself, one per line.
+func Text(body []byte, contentType string) string {
+	var lines []string
+	if strings.HasPrefix(contentType, "text/event-stream") {
+		scanner := bufio.NewScanner(bytes.NewReader(body))
+		scanner.Buffer(make([]byte, 0, 64*1024), len(body)+1)
+		for scanner.Scan() {
+			if data, ok := strings.CutPrefix(scanner.Text(), "data:"); ok {
+				lines = appendText(lines, []byte(strings.TrimSpace(data)))
+			}
+		}
+		return strings.Join(lines, "\n")
+	}
+	return strings.Join(appendText(lines, body), "\n")
+}
+
+func appendText(lines []string, data []byte) []string {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return append(lines, string(data))
+	}
+	return appendStrings(lines, document)
+}
+
+// appendStrings appends the strings of a JSON value, the values of objects in key order.
+func appendStrings(lines []string, value interface{}) []string {
+	switch value := value.(type) {
+	case string:
+		return append(lines, value)
+	case []interface{}:
+		for _, item := range value {
+			lines = appendStrings(lines, item)
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(value))
+		for key := range value {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			lines = appendStrings(lines, value[key])
+		}
+	}
+	return lines
+}
+
+func (p *Proxy) record(target string, verdict Verdict) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.checked == nil {
+		p.checked = map[string]uint64{}
+		p.blocked = map[[2]string]uint64{}
+		p.flagged = map[[2]string]uint64{}
+	}
+	p.checked[target]++
+	if verdict.Blocked != nil {
+		p.blocked[[2]string{verdict.Blocked.Name, target}]++
+	}
+	for _, rule := range verdict.Flagged {
+		p.flagged[[2]string{rule.Name, target}]++
+	}
+}
+
+func (p *Proxy) countUnchecked() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.unchecked++
+}
+
+// serveMetrics serves the metrics of the agent runtime followed by those of the proxy, so
+// that scraping the agent Service collects both.
+func (p *Proxy) serveMetrics(w http.ResponseWriter, req *http.Request) {
+	upstream := *p.Upstream
+	upstream.Path = strings.TrimSuffix(upstream.Path, "/") + req.URL.Path
+	upstream.RawQuery = req.URL.RawQuery
+	out, err := http.NewRequestWithContext(req.Context(), http.MethodGet, upstream.String(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The agent may require its endpoint token to serve metrics
+	out.Header.Set("Authorization", req.Header.Get("Authorization"))
+	transport := p.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("agent metrics unavailable: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = io.Copy(w, resp.Body)
+	_, _ = io.WriteString(w, p.Metrics())
+}
+
+// Metrics returns the moderation metrics of the proxy in the Prometheus text format.
+func (p *Proxy) Metrics() string {
+	p.mu.Lock()
+	checked := copyCounts(p.checked)
+	blocked := copyCounts(p.blocked)
+	flagged := copyCounts(p.flagged)
+	unchecked := p.unchecked
+	p.mu.Unlock()
+
+	loaded := 0
+	if p.Engine.Loaded() {
+		loaded = 1
+	}
+	var b strings.Builder
+	b.WriteString("# HELP kubeagentic_guardrails_policy_loaded Whether the guardrails proxy has a valid policy.\n")
+	b.WriteString("# TYPE kubeagentic_guardrails_policy_loaded gauge\n")
+	fmt.Fprintf(&b, "kubeagentic_guardrails_policy_loaded%s %d\n", p.labels(nil), loaded)
+
+	b.WriteString("# HELP kubeagentic_guardrails_checked_total Prompts and responses checked by the guardrails.\n")
+	b.WriteString("# TYPE kubeagentic_guardrails_checked_total counter\n")
+	for _, target := range []string{TargetPrompt, TargetResponse} {
+		fmt.Fprintf(&b, "kubeagentic_guardrails_checked_total%s %d\n", p.labels(map[string]string{"target": target}), checked[target])
+	}
+
+	b.WriteString("# HELP kubeagentic_guardrails_blocked_total Prompts and responses blocked by a guardrails rule.\n")
+	b.WriteString("# TYPE kubeagentic_guardrails_blocked_total counter\n")
+	for _, key := range sortedKeys(blocked) {
+		fmt.Fprintf(&b, "kubeagentic_guardrails_blocked_total%s %d\n", p.labels(map[string]string{"rule": key[0], "target": key[1]}), blocked[key])
+	}
+
+	b.WriteString("# HELP kubeagentic_guardrails_flagged_total Prompts and responses flagged by a guardrails rule.\n")
+	b.WriteString("# TYPE kubeagentic_guardrails_flagged_total counter\n")
+	for _, key := range sortedKeys(flagged) {
+		fmt.Fprintf(&b, "kubeagentic_guardrails_flagged_total%s %d\n", p.labels(map[string]string{"rule": key[0], "target": key[1]}), flagged[key])
+	}
+
+	b.WriteString("# HELP kubeagentic_guardrails_unchecked_total Requests and responses passed unchecked by the open fail mode.\n")
+	b.WriteString("# TYPE kubeagentic_guardrails_unchecked_total counter\n")
+	fmt.Fprintf(&b, "kubeagentic_guardrails_unchecked_total%s %d\n", p.labels(nil), unchecked)
+	return b.String()
+}
+
+// labels renders the labels of the proxy and extra as a Prometheus label set.
+func (p *Proxy) labels(extra map[string]string) string {
+	all := map[string]string{}
+	for name, value := range p.Labels {
+		all[name] = value
+	}
+	for name, value := range extra {
+		all[name] = value
+	}
+	if len(all) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, all[name]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func copyCounts[K comparable](counts map[K]uint64) map[K]uint64 {
+	copied := make(map[K]uint64, len(counts))
+	for key, count := range counts {
+		copied[key] = count
+	}
+	return copied
+}
+
+// sortedKeys returns the rule and target pairs of the counts, sorted.
+func sortedKeys(counts map[[2]string]uint64) [][2]string {
+	keys := make([][2]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	return keys
+}
@@ -0,0 +1,112 @@
+// Package health reads the detailed health reported by the agent runtime.
+//
+// Pod readiness only proves that the agent serves HTTP. The runtime also reports on
+// /health/detailed whether it can reach the components it depends on, such as its LLM
+// provider, its memory backend and its tools, which the operator reads to set the
+// AgentHealthy condition of the agent.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// States of the runtime and of its components.
+const (
+	// StateHealthy means the component is reachable and works.
+	StateHealthy = "healthy"
+	// StateUnhealthy means the component failed its check.
+	StateUnhealthy = "unhealthy"
+)
+
+// Path is the endpoint of the runtime reporting its detailed health.
+const Path = "/health/detailed"
+
+var (
+	// ErrNotSupported is returned for runtimes predating the detailed health endpoint.
+	ErrNotSupported = errors.New("the agent runtime does not report its detailed health")
+	// ErrUnauthorized is returned when the runtime rejects the endpoint token.
+	ErrUnauthorized = errors.New("the agent runtime rejected the endpoint token")
+)
+
+// Component is the health of a component of the runtime.
+type Component struct {
+	// Status is one of the states above.
+	Status string `json:"status"`
+	// Message describes the component, or why it failed.
+	Message string `json:"message,omitempty"`
+}
+
+// Endpoint is the health of an endpoint of the provider, for runtimes failing over between
+// several.
+type Endpoint struct {
+	// URL of the endpoint.
+	URL string `json:"url"`
+	// Status is one of the states above.
+	Status string `json:"status"`
+	// Active reports whether the runtime currently sends requests to the endpoint.
+	Active bool `json:"active"`
+	// Message describes why the endpoint failed its check.
+	Message string `json:"message,omitempty"`
+}
+
+// Report is the detailed health of the runtime.
+type Report struct {
+	// Status is healthy when all components are.
+	Status string `json:"status"`
+	// Components are keyed by name, such as provider, memory and tools.
+	Components map[string]Component `json:"components"`
+	// Endpoints are the endpoints of the provider checked by the runtime, when it has
+	// several. The provider component is healthy while one of them is.
+	Endpoints []Endpoint `json:"endpoints,omitempty"`
+}
+
+// Checker fetches the detailed health of the agent runtime.
+type Checker struct {
+	// Client is the HTTP client used for the requests. http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+// Check fetches the detailed health of the agent runtime at baseURL within timeout, sending
+// token as a bearer token when it is set. An unhealthy runtime answers 503 with its report.
+func (c *Checker) Check(ctx context.Context, baseURL, token string, timeout time.Duration) (Report, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+Path, nil)
+	if err != nil {
+		return Report{}, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Report{}, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusServiceUnavailable:
+	case http.StatusNotFound:
+		return Report{}, ErrNotSupported
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return Report{}, ErrUnauthorized
+	default:
+		return Report{}, fmt.Errorf("checking %s%s: unexpected status %s", baseURL, Path, resp.Status)
+	}
+
+	var report Report
+	if err := json.NewDecoder(resp.Body).Decode(&report); err != nil {
+		return Report{}, fmt.Errorf("checking %s%s: %w", baseURL, Path, err)
+	}
+	return report, nil
+}
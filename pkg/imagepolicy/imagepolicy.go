@@ -0,0 +1,280 @@
+// Package imagepolicy implements the registry allowlist and tag-to-digest resolution
+// applied to agent images.
+package imagepolicy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Reference is a parsed container image reference.
+type Reference struct {
+	// Registry is the registry host, including the port if any, e.g. "registry.example.com:5000".
+	Registry string
+	// Repository is the repository path within the registry, e.g. "library/nginx".
+	Repository string
+	// Tag is the image tag; empty when the reference only has a digest.
+	Tag string
+	// Digest is the manifest digest, e.g. "sha256:...".
+	Digest string
+}
+
+// Name returns the registry and repository of the reference.
+func (r Reference) Name() string {
+	return r.Registry + "/" + r.Repository
+}
+
+// String returns the normalized reference, preferring the digest over the tag.
+func (r Reference) String() string {
+	if r.Digest != "" {
+		return r.Name() + "@" + r.Digest
+	}
+	return r.Name() + ":" + r.Tag
+}
+
+// ParseReference parses an image reference, applying the Docker Hub defaults for the
+// registry, the "library/" namespace and the "latest" tag.
+func ParseReference(image string) (Reference, error) {
+	if image == "" {
+		return Reference{}, fmt.Errorf("empty image reference")
+	}
+
+	var ref Reference
+	name := image
+	if i := strings.Index(name, "@"); i >= 0 {
+		ref.Digest = name[i+1:]
+		name = name[:i]
+		if !strings.Contains(ref.Digest, ":") {
+			return Reference{}, fmt.Errorf("invalid digest in image %q", image)
+		}
+	}
+	// A colon after the last slash separates the tag; earlier colons belong to a registry port.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		ref.Tag = name[i+1:]
+		name = name[:i]
+	}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+
+	parts := strings.SplitN(name, "/", 2)
+	if len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		ref.Registry, ref.Repository = parts[0], parts[1]
+	} else {
+		ref.Registry, ref.Repository = "docker.io", name
+		if !strings.Contains(name, "/") {
+			ref.Repository = "library/" + name
+		}
+	}
+	if ref.Repository == "" {
+		return Reference{}, fmt.Errorf("missing repository in image %q", image)
+	}
+	return ref, nil
+}
+
+// AllowedRegistriesFromEnv returns the operator-wide registry allowlist from the
+// comma-separated ALLOWED_IMAGE_REGISTRIES environment variable.
+func AllowedRegistriesFromEnv() []string {
+	var allowed []string
+	for _, registry := range strings.Split(os.Getenv("ALLOWED_IMAGE_REGISTRIES"), ",") {
+		if registry = strings.TrimSpace(registry); registry != "" {
+			allowed = append(allowed, registry)
+		}
+	}
+	return allowed
+}
+
+// RegistryAllowed reports whether the image comes from one of the allowed registry
+// prefixes. Prefixes match whole path segments, so "registry.example.com" allows neither
+// "registry.example.com:5000/app" nor "registry.example.com.evil/app". An empty allowlist
+// allows every image.
+func RegistryAllowed(image string, allowed []string) (bool, error) {
+	if len(allowed) == 0 {
+		return true, nil
+	}
+	ref, err := ParseReference(image)
+	if err != nil {
+		return false, err
+	}
+	name := ref.Name()
+	for _, prefix := range allowed {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if name == prefix || strings.HasPrefix(name, prefix+"/") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// manifestMediaTypes are the manifest formats accepted when resolving a digest. Listing
+// the index types first returns the multi-arch digest rather than a platform-specific one.
+var manifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// Resolver resolves image tags to digests using the registry HTTP API with anonymous
+// access. Results are cached for the lifetime of the Resolver.
+type Resolver struct {
+	// Client performs the registry requests. http.DefaultClient is used when nil.
+	Client *http.Client
+
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// DefaultResolver is the Resolver used by the operator.
+var DefaultResolver = &Resolver{}
+
+// Resolve returns the image pinned to the digest its tag currently points to. Images that
+// already carry a digest are returned unchanged.
+func (r *Resolver) Resolve(ctx context.Context, image string) (string, error) {
+	ref, err := ParseReference(image)
+	if err != nil {
+		return "", err
+	}
+	if ref.Digest != "" {
+		return image, nil
+	}
+
+	key := ref.String()
+	r.mu.Lock()
+	if digest, ok := r.cache[key]; ok {
+		r.mu.Unlock()
+		return ref.Name() + "@" + digest, nil
+	}
+	r.mu.Unlock()
+
+	digest, err := r.fetchDigest(ctx, ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve digest of %s: %w", image, err)
+	}
+
+	r.mu.Lock()
+	if r.cache == nil {
+		r.cache = make(map[string]string)
+	}
+	r.cache[key] = digest
+	r.mu.Unlock()
+
+	return ref.Name() + "@" + digest, nil
+}
+
+// fetchDigest asks the registry for the digest of the tagged manifest, obtaining an
+// anonymous bearer token first if the registry requires one.
+func (r *Resolver) fetchDigest(ctx context.Context, ref Reference) (string, error) {
+	host := ref.Registry
+	if host == "docker.io" {
+		host = "registry-1.docker.io"
+	}
+	manifestURL := fmt.Sprintf("https://%s/v2/%s/manifests/%s", host, ref.Repository, ref.Tag)
+
+	resp, err := r.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		token, err := r.fetchToken(ctx, resp.Header.Get("WWW-Authenticate"))
+		if err != nil {
+			return "", err
+		}
+		if resp, err = r.headManifest(ctx, manifestURL, token); err != nil {
+			return "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry returned %s", resp.Status)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("registry did not return a digest")
+	}
+	return digest, nil
+}
+
+func (r *Resolver) headManifest(ctx context.Context, manifestURL, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestMediaTypes, ", "))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// fetchToken requests an anonymous token from the realm of a Bearer challenge.
+func (r *Resolver) fetchToken(ctx context.Context, challenge string) (string, error) {
+	if !strings.HasPrefix(challenge, "Bearer ") {
+		return "", fmt.Errorf("unsupported registry authentication %q", challenge)
+	}
+
+	params := map[string]string{}
+	for _, part := range strings.Split(strings.TrimPrefix(challenge, "Bearer "), ",") {
+		if key, value, ok := strings.Cut(strings.TrimSpace(part), "="); ok {
+			params[key] = strings.Trim(value, `"`)
+		}
+	}
+	if params["realm"] == "" {
+		return "", fmt.Errorf("registry challenge has no realm")
+	}
+
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", err
+	}
+	query := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token endpoint returned %s", resp.Status)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+func (r *Resolver) client() *http.Client {
+	if r.Client != nil {
+		return r.Client
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,111 @@
+// Package ingresshost tells the host and path each Agent claims through its Ingress, and finds
+// the Agents whose claims overlap.
+//
+// Ingress controllers merge the rules of all Ingresses of a host, so two agents serving the
+// same path on a host shadow each other, whichever Ingress was written last. Paths are
+// prefixes matched on whole path elements: /support overlaps / and /support/v2, but not
+// /sales or /supporters.
+package ingresshost
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+// Index is the field index of Agents by the host of their Ingress, to find the Agents that
+// may claim the same paths.
+const Index = "kubeagentic.ai/ingress-host"
+
+// Route is the host and path prefix an agent is served at.
+type Route struct {
+	Host string
+	Path string
+}
+
+func (r Route) String() string {
+	return r.Host + r.Path
+}
+
+// Overlaps reports whether the two routes share the host and a path, so that one of the
+// agents would shadow the other.
+func (r Route) Overlaps(other Route) bool {
+	if r.Host != other.Host {
+		return false
+	}
+	return within(r.Path, other.Path) || within(other.Path, r.Path)
+}
+
+// within reports whether path is prefix or a path below it.
+func within(path, prefix string) bool {
+	return prefix == "/" || path == prefix || strings.HasPrefix(path, prefix+"/")
+}
+
+// Enabled reports whether an agent is exposed through an Ingress, which it is with the
+// LoadBalancer service type or an ingress host.
+func Enabled(spec *aiv1.AgentSpec) bool {
+	return spec.ServiceType == corev1.ServiceTypeLoadBalancer || spec.Ingress != nil
+}
+
+// For returns the route of the agent of the given name and namespace, and whether it is
+// exposed through an Ingress. Hosts are lowercased and paths lose their trailing slash.
+func For(name, namespace string, spec *aiv1.AgentSpec) (Route, bool) {
+	if !Enabled(spec) {
+		return Route{}, false
+	}
+	route := Route{Host: fmt.Sprintf("%s.%s.local", naming.Child(name, ""), namespace), Path: "/"}
+	if spec.Ingress != nil {
+		route.Host = strings.ToLower(spec.Ingress.Host)
+		if path := strings.TrimRight(spec.Ingress.Path, "/"); path != "" {
+			route.Path = path
+		}
+	}
+	return route, true
+}
+
+// IndexValues returns the values of Index for an agent: the host of its Ingress, if any.
+func IndexValues(name, namespace string, spec *aiv1.AgentSpec) []string {
+	route, ok := For(name, namespace, spec)
+	if !ok {
+		return nil
+	}
+	return []string{route.Host}
+}
+
+// Conflicts returns the agents other than the one of the given name and namespace whose
+// route overlaps route.
+func Conflicts(name, namespace string, route Route, agents []aiv1.Agent) []aiv1.Agent {
+	var conflicts []aiv1.Agent
+	for _, other := range agents {
+		if other.Name == name && other.Namespace == namespace {
+			continue
+		}
+		if otherRoute, ok := For(other.Name, other.Namespace, &other.Spec); ok && route.Overlaps(otherRoute) {
+			conflicts = append(conflicts, other)
+		}
+	}
+	return conflicts
+}
+
+// Validate checks that spec.ingress sets a DNS subdomain without wildcard as host and an
+// absolute path.
+func Validate(spec *aiv1.AgentSpec) field.ErrorList {
+	if spec.Ingress == nil {
+		return nil
+	}
+	ingressPath := field.NewPath("spec").Child("ingress")
+	var allErrs field.ErrorList
+	for _, msg := range validation.IsDNS1123Subdomain(spec.Ingress.Host) {
+		allErrs = append(allErrs, field.Invalid(ingressPath.Child("host"), spec.Ingress.Host, msg))
+	}
+	if path := spec.Ingress.Path; path != "" && (!strings.HasPrefix(path, "/") || strings.ContainsAny(path, " \t\n?#")) {
+		allErrs = append(allErrs, field.Invalid(ingressPath.Child("path"), path, "must be an absolute path without query or fragment"))
+	}
+	return allErrs
+}
@@ -0,0 +1,174 @@
+// Package metricsauth protects the operator metrics endpoint with delegated
+// authentication and authorization, in the manner of kube-rbac-proxy.
+//
+// Bearer tokens are authenticated with a TokenReview and the request is authorized with
+// a SubjectAccessReview for the non-resource URL, so scrapers need a ClusterRole granting
+// "get" on "/metrics".
+package metricsauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	authenticationclient "k8s.io/client-go/kubernetes/typed/authentication/v1"
+	authorizationclient "k8s.io/client-go/kubernetes/typed/authorization/v1"
+	"k8s.io/client-go/rest"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+)
+
+const (
+	// allowCacheTTL and denyCacheTTL bound how long a review result is reused.
+	allowCacheTTL = time.Minute
+	denyCacheTTL  = 10 * time.Second
+	// reviewTimeout bounds a single TokenReview or SubjectAccessReview call.
+	reviewTimeout = 10 * time.Second
+)
+
+// +kubebuilder:rbac:groups=authentication.k8s.io,resources=tokenreviews,verbs=create
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+
+// WithAuthenticationAndAuthorization is a metrics server FilterProvider that rejects
+// requests without a bearer token with 401 and requests whose user may not "get" the
+// requested path with 403.
+func WithAuthenticationAndAuthorization(config *rest.Config, httpClient *http.Client) (metricsserver.Filter, error) {
+	authn, err := authenticationclient.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	authz, err := authorizationclient.NewForConfigAndClient(config, httpClient)
+	if err != nil {
+		return nil, err
+	}
+	reviewer := &reviewer{
+		tokenReviews:         authn.TokenReviews(),
+		subjectAccessReviews: authz.SubjectAccessReviews(),
+		cache:                map[[sha256.Size]byte]decision{},
+	}
+	return func(log logr.Logger, handler http.Handler) (http.Handler, error) {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			token, ok := bearerToken(req)
+			if !ok {
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+			status, err := reviewer.review(req.Context(), token, strings.ToLower(req.Method), req.URL.Path)
+			if err != nil {
+				log.Error(err, "Failed to review metrics request")
+				http.Error(w, "Authorization failed", http.StatusInternalServerError)
+				return
+			}
+			if status != http.StatusOK {
+				log.V(4).Info("Rejected metrics request", "status", status, "path", req.URL.Path)
+				http.Error(w, http.StatusText(status), status)
+				return
+			}
+			handler.ServeHTTP(w, req)
+		}), nil
+	}, nil
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(req *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(req.Header.Get("Authorization"), " ")
+	token = strings.TrimSpace(token)
+	if !ok || !strings.EqualFold(scheme, "bearer") || token == "" {
+		return "", false
+	}
+	return token, true
+}
+
+// decision is a cached review result.
+type decision struct {
+	status  int
+	expires time.Time
+}
+
+// reviewer authenticates and authorizes tokens against the API server, caching results
+// so that every scrape does not cost two API calls.
+type reviewer struct {
+	tokenReviews         authenticationclient.TokenReviewInterface
+	subjectAccessReviews authorizationclient.SubjectAccessReviewInterface
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]decision
+}
+
+// review returns http.StatusOK, http.StatusUnauthorized or http.StatusForbidden.
+func (r *reviewer) review(ctx context.Context, token, verb, path string) (int, error) {
+	key := sha256.Sum256([]byte(verb + " " + path + " " + token))
+	now := time.Now()
+
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.status, nil
+	}
+
+	status, err := r.reviewUncached(ctx, token, verb, path)
+	if err != nil {
+		return 0, err
+	}
+
+	ttl := denyCacheTTL
+	if status == http.StatusOK {
+		ttl = allowCacheTTL
+	}
+	r.mu.Lock()
+	for k, d := range r.cache {
+		if now.After(d.expires) {
+			delete(r.cache, k)
+		}
+	}
+	r.cache[key] = decision{status: status, expires: now.Add(ttl)}
+	r.mu.Unlock()
+	return status, nil
+}
+
+func (r *reviewer) reviewUncached(ctx context.Context, token, verb, path string) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, reviewTimeout)
+	defer cancel()
+
+	tokenReview, err := r.tokenReviews.Create(ctx, &authenticationv1.TokenReview{
+		Spec: authenticationv1.TokenReviewSpec{Token: token},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return 0, err
+	}
+	if !tokenReview.Status.Authenticated {
+		return http.StatusUnauthorized, nil
+	}
+
+	user := tokenReview.Status.User
+	extra := map[string]authorizationv1.ExtraValue{}
+	for k, v := range user.Extra {
+		extra[k] = authorizationv1.ExtraValue(v)
+	}
+	accessReview, err := r.subjectAccessReviews.Create(ctx, &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   user.Username,
+			UID:    user.UID,
+			Groups: user.Groups,
+			Extra:  extra,
+			NonResourceAttributes: &authorizationv1.NonResourceAttributes{
+				Path: path,
+				Verb: verb,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return 0, err
+	}
+	if !accessReview.Status.Allowed {
+		return http.StatusForbidden, nil
+	}
+	return http.StatusOK, nil
+}
@@ -0,0 +1,114 @@
+// Package modelschedule selects the model of an agent from recurring time windows.
+//
+// A window opens at the times of a cron schedule, in a time zone, and stays open for a
+// duration. An agent serves with the model of the open window of its schedule, or with
+// spec.model while none is open. When windows overlap, the most specific one, the shortest,
+// wins, and the first listed of equally long windows.
+package modelschedule
+
+import (
+	"fmt"
+	"time"
+	// Embeds the time zone database, so window time zones resolve without one in the image
+	_ "time/tzdata"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/cronschedule"
+)
+
+const (
+	// MaxDuration bounds how long a window stays open, so that weekly windows such as
+	// weekends fit.
+	MaxDuration = 7 * 24 * time.Hour
+	// maxSteps bounds the window boundaries NextSwitch looks at before giving up.
+	maxSteps = 1000
+)
+
+// Window is a recurring time window.
+type Window struct {
+	schedule *cronschedule.Schedule
+	duration time.Duration
+	location *time.Location
+}
+
+// ParseWindow parses the cron schedule opening a window, such as "0 20 * * 1-5", its
+// duration and its IANA time zone, UTC when empty.
+func ParseWindow(start string, duration time.Duration, timezone string) (*Window, error) {
+	schedule, err := cronschedule.Parse(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", start, err)
+	}
+	if duration < time.Minute || duration > MaxDuration {
+		return nil, fmt.Errorf("duration %s must be between 1m and %s", duration, MaxDuration)
+	}
+	location := time.UTC
+	if timezone != "" {
+		if location, err = time.LoadLocation(timezone); err != nil {
+			return nil, fmt.Errorf("time zone %q is not a known time zone", timezone)
+		}
+	}
+	window := &Window{schedule: schedule, duration: duration, location: location}
+	if window.schedule.Next(time.Now().In(location)).IsZero() {
+		return nil, fmt.Errorf("schedule %q never opens the window", start)
+	}
+	return window, nil
+}
+
+// Open reports whether the window is open at now.
+func (w *Window) Open(now time.Time) bool {
+	opened := w.schedule.Next(now.In(w.location).Add(-w.duration))
+	return !opened.IsZero() && !opened.After(now)
+}
+
+// nextBoundary returns the first time after now at which the window opens or closes, or
+// the zero time when it does neither.
+func (w *Window) nextBoundary(now time.Time) time.Time {
+	opened := w.schedule.Next(now.In(w.location).Add(-w.duration))
+	if opened.IsZero() || opened.After(now) {
+		return opened
+	}
+	// An open window closes unless the schedule opens it again before
+	closes := opened.Add(w.duration)
+	for step := 0; step < maxSteps; step++ {
+		reopened := w.schedule.Next(opened)
+		if reopened.IsZero() || reopened.After(closes) {
+			return closes
+		}
+		opened, closes = reopened, reopened.Add(w.duration)
+	}
+	return time.Time{}
+}
+
+// Active returns the index of the window of windows in effect at now, or -1 when none is
+// open.
+func Active(windows []*Window, now time.Time) int {
+	active := -1
+	for i, window := range windows {
+		if window.Open(now) && (active < 0 || window.duration < windows[active].duration) {
+			active = i
+		}
+	}
+	return active
+}
+
+// NextSwitch returns the first time after now at which another window, or none, is in
+// effect, or false when that never happens.
+func NextSwitch(windows []*Window, now time.Time) (time.Time, bool) {
+	active := Active(windows, now)
+	t := now
+	for step := 0; step < maxSteps; step++ {
+		var boundary time.Time
+		for _, window := range windows {
+			if next := window.nextBoundary(t); !next.IsZero() && (boundary.IsZero() || next.Before(boundary)) {
+				boundary = next
+			}
+		}
+		if boundary.IsZero() {
+			return time.Time{}, false
+		}
+		if Active(windows, boundary) != active {
+			return boundary, true
+		}
+		t = boundary
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,109 @@
+// Package naming derives the names of the resources created for an agent from its name.
+//
+// Most derived names must be DNS labels of at most 63 characters, as Service names, label
+// values and host names are. A name that would be longer is truncated and completed with a
+// short hash of the full name, so that it stays the same across reconciles and differs for
+// agents whose names only differ past the truncation. The suffix is kept, so that the kind
+// of the resource remains recognizable.
+package naming
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// MaxLength is the length of a DNS label, the limit of most derived names.
+	MaxLength = validation.DNS1123LabelMaxLength
+	// MaxCronJobLength is the length of a CronJob name, which leaves room for the suffix of
+	// the Jobs it creates.
+	MaxCronJobLength = 52
+
+	hashLength = 8
+)
+
+// Child returns the name "<base>-<suffix>", or base when suffix is empty, shortened to
+// MaxLength.
+func Child(base, suffix string) string {
+	return ChildWithMax(base, suffix, MaxLength)
+}
+
+// ChildWithMax returns the name "<base>-<suffix>", or base when suffix is empty. A name
+// longer than max becomes "<truncated base>-<hash>-<suffix>".
+func ChildWithMax(base, suffix string, max int) string {
+	name := base
+	if suffix != "" {
+		name = base + "-" + suffix
+	}
+	if len(name) <= max {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:hashLength]
+	tail := "-" + hash
+	if suffix != "" {
+		tail += "-" + suffix
+	}
+	if len(tail) >= max {
+		// The suffix leaves no room for the base; the hash alone identifies the name.
+		return hash
+	}
+	// A truncated base ending with a separator would double it before the hash.
+	return strings.TrimRight(base[:max-len(tail)], "-.") + tail
+}
+
+// Names returns the names of the resources of the agent named name: the overrides, when
+// set, and otherwise the names derived from the agent name. IngressHost is left to the
+// caller, as it depends on the namespace.
+func Names(name string, overrides *aiv1.NameOverrides) aiv1.ResourceNames {
+	names := aiv1.ResourceNames{
+		Deployment:              name,
+		Service:                 Child(name, "service"),
+		ConfigMap:               Child(name, "config"),
+		HorizontalPodAutoscaler: Child(name, "hpa"),
+		Ingress:                 Child(name, "ingress"),
+		MonitoringConfigMap:     Child(name, "monitoring"),
+		GrafanaDashboard:        Child(name, "grafana-dashboard"),
+	}
+	if overrides == nil {
+		return names
+	}
+	for _, override := range []struct {
+		name  *string
+		value string
+	}{
+		{&names.Deployment, overrides.Deployment},
+		{&names.Service, overrides.Service},
+		{&names.ConfigMap, overrides.ConfigMap},
+		{&names.HorizontalPodAutoscaler, overrides.HPA},
+		{&names.Ingress, overrides.Ingress},
+	} {
+		if override.value != "" {
+			*override.name = override.value
+		}
+	}
+	return names
+}
+
+// ResourceNameIndex is the field index of Agents by the names of their resources, with
+// "<kind>/<name>" values, to find the Agents whose resources would share a name.
+const ResourceNameIndex = "kubeagentic.ai/resource-name"
+
+// IndexValues returns the values of ResourceNameIndex for the resource names of an agent.
+func IndexValues(names aiv1.ResourceNames) []string {
+	return []string{
+		"Deployment/" + names.Deployment,
+		"Service/" + names.Service,
+		"ConfigMap/" + names.ConfigMap,
+		"ConfigMap/" + names.MonitoringConfigMap,
+		"ConfigMap/" + names.GrafanaDashboard,
+		"HorizontalPodAutoscaler/" + names.HorizontalPodAutoscaler,
+		"Ingress/" + names.Ingress,
+	}
+}
@@ -0,0 +1,290 @@
+// Package pricing computes the cost of the tokens consumed by agents.
+//
+// Prices are per million tokens in Currency and keyed by provider and model. Self-hosted
+// providers have no per-token price, so their tokens cost nothing. Models without a price
+// return ErrUnknownModel, which callers must tell apart from a zero cost.
+//
+// The catalog also lists the deprecated models of the providers with their retirement dates,
+// so that agents can be moved off a model before the provider retires it.
+//
+// The prices come from a Catalog. The operator starts with the catalog embedded in this
+// package and replaces it with Set when the pricing ConfigMap changes.
+package pricing
+
+import (
+	_ "embed"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Currency is the currency of the prices.
+const Currency = "USD"
+
+// ErrUnknownModel is returned for models without a price.
+var ErrUnknownModel = errors.New("no price known for model")
+
+// Price is the cost of one million tokens.
+type Price struct {
+	// Prompt is the cost of one million prompt tokens.
+	Prompt float64 `json:"prompt"`
+	// Completion is the cost of one million completion tokens.
+	Completion float64 `json:"completion"`
+}
+
+// DateFormat is the format of the dates of a Deprecation.
+const DateFormat = "2006-01-02"
+
+// Deprecation announces the retirement of a model by its provider.
+type Deprecation struct {
+	// Deprecated is the date from which the model is deprecated. Empty means already.
+	Deprecated string `json:"deprecated,omitempty"`
+	// Retires is the date on which the provider stops serving the model.
+	Retires string `json:"retires"`
+	// Replacement is the model of the same provider suggested instead.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// Retired reports whether the model is retired at now.
+func (d Deprecation) Retired(now time.Time) bool {
+	retires, _ := time.Parse(DateFormat, d.Retires)
+	return !now.Before(retires)
+}
+
+// Message describes the deprecation of the model of provider at now.
+func (d Deprecation) Message(provider, model string, now time.Time) string {
+	message := fmt.Sprintf("model %s/%s is deprecated and retires on %s", provider, model, d.Retires)
+	if d.Retired(now) {
+		message = fmt.Sprintf("model %s/%s was retired on %s", provider, model, d.Retires)
+	}
+	if d.Replacement != "" {
+		message += fmt.Sprintf(", switch to %s", d.Replacement)
+	}
+	return message
+}
+
+// Catalog holds the prices and deprecations of the models, keyed by provider/model.
+type Catalog struct {
+	// SelfHostedProviders lists the providers serving models on the cluster, without
+	// per-token prices.
+	SelfHostedProviders []string `json:"selfHostedProviders,omitempty"`
+	// Models maps provider/model to its price.
+	Models map[string]Price `json:"models,omitempty"`
+	// Deprecations maps provider/model to its deprecation.
+	Deprecations map[string]Deprecation `json:"deprecations,omitempty"`
+	// ContextWindows maps provider/model to the tokens of its context window.
+	ContextWindows map[string]int64 `json:"contextWindows,omitempty"`
+}
+
+//go:embed catalog.yaml
+var defaultCatalogYAML []byte
+
+var (
+	defaultCatalog = mustParse(defaultCatalogYAML)
+
+	mu      sync.RWMutex
+	current = defaultCatalog
+)
+
+// Parse reads a catalog in the YAML format of catalog.yaml. Unknown fields, keys not of
+// the form provider/model, negative prices and deprecations without valid dates are
+// rejected.
+func Parse(data []byte) (*Catalog, error) {
+	catalog := &Catalog{}
+	if err := yaml.UnmarshalStrict(data, catalog); err != nil {
+		return nil, fmt.Errorf("invalid pricing catalog: %w", err)
+	}
+
+	keys := make([]string, 0, len(catalog.Models))
+	for key := range catalog.Models {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		provider, model, ok := strings.Cut(key, "/")
+		if !ok || provider == "" || model == "" {
+			return nil, fmt.Errorf("invalid pricing catalog: model %q is not of the form provider/model", key)
+		}
+		if price := catalog.Models[key]; price.Prompt < 0 || price.Completion < 0 {
+			return nil, fmt.Errorf("invalid pricing catalog: model %q has a negative price", key)
+		}
+	}
+	keys = keys[:0]
+	for key := range catalog.ContextWindows {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		provider, model, ok := strings.Cut(key, "/")
+		if !ok || provider == "" || model == "" {
+			return nil, fmt.Errorf("invalid pricing catalog: context window of model %q is not of the form provider/model", key)
+		}
+		if catalog.ContextWindows[key] <= 0 {
+			return nil, fmt.Errorf("invalid pricing catalog: context window of model %q must be positive", key)
+		}
+	}
+	for _, provider := range catalog.SelfHostedProviders {
+		if provider == "" {
+			return nil, errors.New("invalid pricing catalog: empty self-hosted provider")
+		}
+	}
+
+	keys = keys[:0]
+	for key := range catalog.Deprecations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		provider, model, ok := strings.Cut(key, "/")
+		if !ok || provider == "" || model == "" {
+			return nil, fmt.Errorf("invalid pricing catalog: deprecated model %q is not of the form provider/model", key)
+		}
+		deprecation := catalog.Deprecations[key]
+		retires, err := time.Parse(DateFormat, deprecation.Retires)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pricing catalog: retirement date of model %q must be of the form YYYY-MM-DD", key)
+		}
+		if deprecation.Deprecated != "" {
+			deprecated, err := time.Parse(DateFormat, deprecation.Deprecated)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pricing catalog: deprecation date of model %q must be of the form YYYY-MM-DD", key)
+			}
+			if deprecated.After(retires) {
+				return nil, fmt.Errorf("invalid pricing catalog: model %q is deprecated after its retirement", key)
+			}
+		}
+	}
+	return catalog, nil
+}
+
+func mustParse(data []byte) *Catalog {
+	catalog, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return catalog
+}
+
+// Default returns the catalog embedded in the operator.
+func Default() *Catalog {
+	return defaultCatalog
+}
+
+// Merge returns the default catalog with the models, deprecations, context windows and
+// self-hosted providers of overrides added, overrides taking precedence.
+func Merge(overrides *Catalog) *Catalog {
+	merged := &Catalog{Models: map[string]Price{}, Deprecations: map[string]Deprecation{}, ContextWindows: map[string]int64{}}
+	for key, price := range defaultCatalog.Models {
+		merged.Models[key] = price
+	}
+	for key, deprecation := range defaultCatalog.Deprecations {
+		merged.Deprecations[key] = deprecation
+	}
+	for key, tokens := range defaultCatalog.ContextWindows {
+		merged.ContextWindows[key] = tokens
+	}
+	merged.SelfHostedProviders = append(merged.SelfHostedProviders, defaultCatalog.SelfHostedProviders...)
+	if overrides == nil {
+		return merged
+	}
+	for key, price := range overrides.Models {
+		merged.Models[key] = price
+	}
+	for key, deprecation := range overrides.Deprecations {
+		merged.Deprecations[key] = deprecation
+	}
+	for key, tokens := range overrides.ContextWindows {
+		merged.ContextWindows[key] = tokens
+	}
+	merged.SelfHostedProviders = append(merged.SelfHostedProviders, overrides.SelfHostedProviders...)
+	return merged
+}
+
+// Set makes catalog the one used by PriceFor and CostFor. A nil catalog restores the
+// default.
+func Set(catalog *Catalog) {
+	if catalog == nil {
+		catalog = defaultCatalog
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = catalog
+}
+
+// Current returns the catalog used by PriceFor and CostFor.
+func Current() *Catalog {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// PriceFor returns the price of the model of provider.
+func (c *Catalog) PriceFor(provider, model string) (Price, error) {
+	for _, p := range c.SelfHostedProviders {
+		if p == provider {
+			return Price{}, nil
+		}
+	}
+	price, ok := c.Models[provider+"/"+model]
+	if !ok {
+		return Price{}, fmt.Errorf("%w %s/%s", ErrUnknownModel, provider, model)
+	}
+	return price, nil
+}
+
+// CostFor returns the cost of the tokens consumed by the model of provider.
+func (c *Catalog) CostFor(provider, model string, promptTokens, completionTokens int64) (float64, error) {
+	price, err := c.PriceFor(provider, model)
+	if err != nil {
+		return 0, err
+	}
+	return (float64(promptTokens)*price.Prompt + float64(completionTokens)*price.Completion) / 1e6, nil
+}
+
+// DeprecationFor returns the deprecation of the model of provider, when it is deprecated at
+// now.
+func (c *Catalog) DeprecationFor(provider, model string, now time.Time) (Deprecation, bool) {
+	deprecation, ok := c.Deprecations[provider+"/"+model]
+	if !ok {
+		return Deprecation{}, false
+	}
+	if deprecated, err := time.Parse(DateFormat, deprecation.Deprecated); err == nil && now.Before(deprecated) {
+		return Deprecation{}, false
+	}
+	return deprecation, true
+}
+
+// ContextWindowFor returns the tokens of the context window of the model of provider, when
+// the catalog knows it.
+func (c *Catalog) ContextWindowFor(provider, model string) (int64, bool) {
+	tokens, ok := c.ContextWindows[provider+"/"+model]
+	return tokens, ok
+}
+
+// PriceFor returns the price of the model of provider in the current catalog.
+func PriceFor(provider, model string) (Price, error) {
+	return Current().PriceFor(provider, model)
+}
+
+// CostFor returns the cost of the tokens consumed by the model of provider, priced with
+// the current catalog.
+func CostFor(provider, model string, promptTokens, completionTokens int64) (float64, error) {
+	return Current().CostFor(provider, model, promptTokens, completionTokens)
+}
+
+// DeprecationFor returns the deprecation of the model of provider in the current catalog,
+// when it is deprecated at now.
+func DeprecationFor(provider, model string, now time.Time) (Deprecation, bool) {
+	return Current().DeprecationFor(provider, model, now)
+}
+
+// ContextWindowFor returns the tokens of the context window of the model of provider in the
+// current catalog, when it knows it.
+func ContextWindowFor(provider, model string) (int64, bool) {
+	return Current().ContextWindowFor(provider, model)
+}
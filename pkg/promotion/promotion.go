@@ -0,0 +1,95 @@
+// Package promotion copies the configuration of an Agent to another namespace, such as from
+// staging to production.
+//
+// An Agent annotated with kubeagentic.ai/promote-to is kept promoted to the Agent of the same
+// name in the target namespace while the annotation is set. The target namespace accepts it
+// with the promotion rules of one of its AgentPolicies, which also transform the spec for its
+// environment. The promoted Agent is labeled with its source for traceability.
+package promotion
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/secretref"
+)
+
+const (
+	// Annotation names the namespace an Agent is promoted to.
+	Annotation = "kubeagentic.ai/promote-to"
+	// SourceNamespaceLabel labels a promoted Agent with the namespace it is promoted from.
+	SourceNamespaceLabel = "kubeagentic.ai/promoted-from-namespace"
+	// SourceNameLabel labels a promoted Agent with the name of the Agent it is promoted from.
+	SourceNameLabel = "kubeagentic.ai/promoted-from"
+	// GenerationAnnotation holds the generation of the source Agent promoted last.
+	GenerationAnnotation = "kubeagentic.ai/promoted-generation"
+	// HashAnnotation holds the hash of the transformed spec promoted last, so that the
+	// promoted Agent is only updated when the source or the rules change, and not when its
+	// spec is defaulted.
+	HashAnnotation = "kubeagentic.ai/promoted-hash"
+)
+
+// Rules returns the promotion rules of the first AgentPolicy, by name, of the target
+// namespace accepting Agents promoted from source, or nil when none does.
+func Rules(source, target string, policies []aiv1.AgentPolicy) (*aiv1.PromotionPolicy, string) {
+	sorted := append([]aiv1.AgentPolicy(nil), policies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	for _, policy := range sorted {
+		if policy.Namespace != target || policy.Spec.Promotion == nil {
+			continue
+		}
+		for _, allowed := range policy.Spec.Promotion.AllowedSources {
+			if allowed == source {
+				return policy.Spec.Promotion, policy.Name
+			}
+		}
+	}
+	return nil, ""
+}
+
+// Transform returns the spec of a promoted Agent: a copy of spec with the debug logs and the
+// time to live of the source cleared, the Secrets renamed and the replicas overridden by rules.
+func Transform(spec *aiv1.AgentSpec, rules *aiv1.PromotionPolicy) *aiv1.AgentSpec {
+	promoted := spec.DeepCopy()
+	promoted.Debug = false
+	promoted.TTL = nil
+	promoted.ExpireAt = nil
+	if len(rules.SecretNames) > 0 {
+		secretref.Rename(promoted, rules.SecretNames)
+	}
+	if rules.Replicas != nil {
+		replicas := *rules.Replicas
+		promoted.Replicas = &replicas
+	}
+	return promoted
+}
+
+// Hash returns the hash of a promoted spec recorded in HashAnnotation.
+func Hash(spec *aiv1.AgentSpec) string {
+	data, _ := json.Marshal(spec)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:10]
+}
+
+// Validate checks the Annotation of an Agent of namespace.
+func Validate(namespace string, annotations map[string]string) field.ErrorList {
+	target, ok := annotations[Annotation]
+	if !ok {
+		return nil
+	}
+	path := field.NewPath("metadata").Child("annotations").Key(Annotation)
+	if errs := validation.IsDNS1123Label(target); len(errs) > 0 {
+		return field.ErrorList{field.Invalid(path, target, fmt.Sprintf("must be a namespace name: %v", errs))}
+	}
+	if target == namespace {
+		return field.ErrorList{field.Invalid(path, target, "must name another namespace than the agent's")}
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+// Package provider describes how the operator reaches the models of each provider, for
+// the reconciler and the admission webhook alike.
+package provider
+
+// allowingSecretless lists the providers whose self-hosted or OpenAI-compatible endpoints
+// may be reached without an API secret.
+var allowingSecretless = map[string]bool{"openai": true, "vllm": true, "ollama": true}
+
+// RequiresAPISecret reports whether a model of the provider served at endpoint needs an API
+// secret: always for hosted providers, and unless a custom endpoint is set for the others.
+func RequiresAPISecret(provider, endpoint string) bool {
+	return !allowingSecretless[provider] || endpoint == ""
+}
@@ -0,0 +1,143 @@
+// Package queuelimit limits how often a reconciler workqueue hands out each object.
+//
+// An object enqueued more than Burst times within a Window, such as an agent referring to a
+// Secret another controller keeps rewriting, is delayed exponentially: the first enqueue over the
+// burst waits BaseDelay, and each further one doubles the delay up to MaxDelay. The count
+// starts over with each window, so that an object that calms down is reconciled promptly
+// again. Failures count like any other enqueue, and are not forgotten on success.
+package queuelimit
+
+import (
+	"sync"
+	"time"
+
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/utils/clock"
+)
+
+const (
+	// DefaultBaseDelay is the delay of the first enqueue over the burst.
+	DefaultBaseDelay = 5 * time.Millisecond
+	// DefaultMaxDelay caps the delay of an object.
+	DefaultMaxDelay = 1000 * time.Second
+	// DefaultBurst is the number of enqueues of an object per window that are not delayed.
+	DefaultBurst = 20
+	// DefaultWindow is the period over which the enqueues of an object are counted.
+	DefaultWindow = time.Minute
+	// DefaultQPS is the rate of the overall token bucket.
+	DefaultQPS = 10
+	// DefaultQueueBurst is the size of the overall token bucket.
+	DefaultQueueBurst = 100
+)
+
+// ItemLimiter delays the objects enqueued more than Burst times within a Window. The zero
+// value uses the defaults and is safe for concurrent use.
+type ItemLimiter struct {
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	Burst     int
+	Window    time.Duration
+	// Clock tells the time. The real clock is used when nil.
+	Clock clock.PassiveClock
+
+	mu    sync.Mutex
+	items map[interface{}]*window
+}
+
+type window struct {
+	start time.Time
+	count int
+}
+
+// When records an enqueue of item and returns how long it waits.
+func (l *ItemLimiter) When(item interface{}) time.Duration {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.items == nil {
+		l.items = map[interface{}]*window{}
+	}
+	w, ok := l.items[item]
+	if !ok || now.Sub(w.start) >= l.window() {
+		w = &window{start: now}
+		l.items[item] = w
+	}
+	w.count++
+	return l.delay(w.count - l.burst())
+}
+
+// Forget keeps the count of item, as an object that reconciles successfully in a hot loop
+// must still be delayed. Counts expire with their window.
+func (l *ItemLimiter) Forget(item interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w, ok := l.items[item]; ok && l.now().Sub(w.start) >= l.window() {
+		delete(l.items, item)
+	}
+}
+
+// NumRequeues returns the number of enqueues of item in its current window.
+func (l *ItemLimiter) NumRequeues(item interface{}) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if w, ok := l.items[item]; ok {
+		return w.count
+	}
+	return 0
+}
+
+// delay returns the delay of the enqueue excess times over the burst.
+func (l *ItemLimiter) delay(excess int) time.Duration {
+	if excess <= 0 {
+		return 0
+	}
+	base, max := l.BaseDelay, l.MaxDelay
+	if base <= 0 {
+		base = DefaultBaseDelay
+	}
+	if max <= 0 {
+		max = DefaultMaxDelay
+	}
+	delay := base
+	for i := 1; i < excess && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+func (l *ItemLimiter) burst() int {
+	if l.Burst <= 0 {
+		return DefaultBurst
+	}
+	return l.Burst
+}
+
+func (l *ItemLimiter) window() time.Duration {
+	if l.Window <= 0 {
+		return DefaultWindow
+	}
+	return l.Window
+}
+
+func (l *ItemLimiter) now() time.Time {
+	if l.Clock == nil {
+		return time.Now()
+	}
+	return l.Clock.Now()
+}
+
+// NewRateLimiter returns the rate limiter of a workqueue: the larger delay of items, and of
+// an overall token bucket refilled at qps up to burst. Defaults apply to values of zero.
+func NewRateLimiter(items *ItemLimiter, qps float64, burst int) workqueue.RateLimiter {
+	if qps <= 0 {
+		qps = DefaultQPS
+	}
+	if burst <= 0 {
+		burst = DefaultQueueBurst
+	}
+	return workqueue.NewMaxOfRateLimiter(items, &workqueue.BucketRateLimiter{Limiter: rate.NewLimiter(rate.Limit(qps), burst)})
+}
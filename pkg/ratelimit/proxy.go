@@ -0,0 +1,207 @@
+package ratelimit
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// exemptPaths are the routes of the agent runtime served without limits: the probes, and
+// the metrics, which the proxy completes with its own.
+var exemptPaths = map[string]bool{
+	"/health":  true,
+	"/ready":   true,
+	"/metrics": true,
+}
+
+// Proxy forwards the allowed requests to the agent container and rejects the others with
+// 429 Too Many Requests.
+type Proxy struct {
+	// Upstream is the URL of the agent container.
+	Upstream *url.URL
+	// Transport forwards requests to the agent. http.DefaultTransport is used when nil.
+	Transport http.RoundTripper
+	// Limiter applies the rules.
+	Limiter Limiter
+	// Labels are added to the metrics of the proxy, such as the identity of the agent.
+	Labels map[string]string
+
+	mu      sync.Mutex
+	allowed uint64
+	limited map[string]uint64
+}
+
+// ServeHTTP applies the rules to the request and forwards it when it is allowed.
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.URL.Path == "/metrics" {
+		p.serveMetrics(w, req)
+		return
+	}
+	if !exemptPaths[req.URL.Path] {
+		decision := p.Limiter.Allow(func(header string) string {
+			if header != "" {
+				return req.Header.Get(header)
+			}
+			return clientIP(req)
+		})
+		p.record(decision)
+		if !decision.Allowed {
+			seconds := int(math.Ceil(decision.RetryAfter.Seconds()))
+			if seconds < 1 {
+				seconds = 1
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(seconds))
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusTooManyRequests)
+			_ = json.NewEncoder(w).Encode(map[string]string{
+				"detail": fmt.Sprintf("Rate limit exceeded: rule %s allows %d requests per %s", decision.Rule.Name, decision.Rule.Requests, decision.Rule.Window),
+			})
+			return
+		}
+	}
+	p.reverseProxy().ServeHTTP(w, req)
+}
+
+func (p *Proxy) reverseProxy() *httputil.ReverseProxy {
+	proxy := httputil.NewSingleHostReverseProxy(p.Upstream)
+	proxy.Transport = p.Transport
+	// Streamed chat responses are passed on as soon as they arrive
+	proxy.FlushInterval = -1
+	return proxy
+}
+
+// clientIP returns the IP address of the client of the request.
+func clientIP(req *http.Request) string {
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+func (p *Proxy) record(decision Decision) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if decision.Allowed {
+		p.allowed++
+		return
+	}
+	if p.limited == nil {
+		p.limited = map[string]uint64{}
+	}
+	p.limited[decision.Rule.Name]++
+}
+
+// serveMetrics serves the metrics of the agent runtime followed by those of the proxy, so
+// that scraping the agent Service collects both.
+func (p *Proxy) serveMetrics(w http.ResponseWriter, req *http.Request) {
+	upstream := *p.Upstream
+	upstream.Path = strings.TrimSuffix(upstream.Path, "/") + req.URL.Path
+	upstream.RawQuery = req.URL.RawQuery
+	out, err := http.NewRequestWithContext(req.Context(), http.MethodGet, upstream.String(), nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// The agent may require its endpoint token to serve metrics
+	out.Header.Set("Authorization", req.Header.Get("Authorization"))
+	transport := p.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(out)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("agent metrics unavailable: %v", err), http.StatusBadGateway)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		w.WriteHeader(resp.StatusCode)
+		_, _ = io.Copy(w, resp.Body)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = io.Copy(w, resp.Body)
+	_, _ = io.WriteString(w, p.Metrics())
+}
+
+// Metrics returns the metrics of the proxy in the Prometheus text format.
+func (p *Proxy) Metrics() string {
+	p.mu.Lock()
+	allowed := p.allowed
+	limited := make(map[string]uint64, len(p.limited))
+	for rule, count := range p.limited {
+		limited[rule] = count
+	}
+	p.mu.Unlock()
+	clients := p.Limiter.Clients()
+
+	var b strings.Builder
+	b.WriteString("# HELP kubeagentic_ratelimit_allowed_total Requests allowed by the inbound rate limits.\n")
+	b.WriteString("# TYPE kubeagentic_ratelimit_allowed_total counter\n")
+	fmt.Fprintf(&b, "kubeagentic_ratelimit_allowed_total%s %d\n", p.labels(nil), allowed)
+
+	b.WriteString("# HELP kubeagentic_ratelimit_limited_total Requests rejected by an inbound rate limit rule.\n")
+	b.WriteString("# TYPE kubeagentic_ratelimit_limited_total counter\n")
+	for _, rule := range sortedKeys(clients, limited) {
+		fmt.Fprintf(&b, "kubeagentic_ratelimit_limited_total%s %d\n", p.labels(map[string]string{"rule": rule}), limited[rule])
+	}
+
+	b.WriteString("# HELP kubeagentic_ratelimit_clients Clients tracked by an inbound rate limit rule.\n")
+	b.WriteString("# TYPE kubeagentic_ratelimit_clients gauge\n")
+	for _, rule := range sortedKeys(clients, nil) {
+		fmt.Fprintf(&b, "kubeagentic_ratelimit_clients%s %d\n", p.labels(map[string]string{"rule": rule}), clients[rule])
+	}
+	return b.String()
+}
+
+// labels renders the labels of the proxy and extra as a Prometheus label set.
+func (p *Proxy) labels(extra map[string]string) string {
+	all := map[string]string{}
+	for name, value := range p.Labels {
+		all[name] = value
+	}
+	for name, value := range extra {
+		all[name] = value
+	}
+	if len(all) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(all))
+	for name := range all {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%s=%q", name, all[name]))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// sortedKeys returns the keys of the rules of the current configuration, and of the
+// removed rules that limited requests, sorted.
+func sortedKeys(clients map[string]int, limited map[string]uint64) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for key := range clients {
+		seen[key] = true
+		keys = append(keys, key)
+	}
+	for key := range limited {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
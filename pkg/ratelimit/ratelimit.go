@@ -0,0 +1,198 @@
+// Package ratelimit limits the requests each client sends to an agent.
+//
+// The operator renders the rules of spec.inboundRateLimit into a JSON file, read by the
+// proxy sidecar placed in front of the agent container. Each rule keeps a token bucket per
+// client, identified by a request header or by the client IP address, refilled with the
+// requests of the rule per window up to its burst. A request is allowed when every rule
+// has a token for its client, and consumes one token of each.
+package ratelimit
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often the buckets that refilled completely are forgotten, which
+// bounds the memory of the limiter by the clients active within the window of the rules.
+const sweepInterval = time.Minute
+
+// Rule limits the requests of each client.
+type Rule struct {
+	Name string `json:"name"`
+	// Header identifies the client. Clients are identified by their IP address when empty.
+	Header   string `json:"header,omitempty"`
+	Requests int    `json:"requests"`
+	// Window is a duration such as "1m0s".
+	Window string `json:"window"`
+	Burst  int    `json:"burst"`
+}
+
+// Config is the configuration of the proxy.
+type Config struct {
+	Rules []Rule `json:"rules"`
+}
+
+// ParseConfig returns the configuration in data.
+func ParseConfig(data []byte) (Config, error) {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("invalid rate limit configuration: %w", err)
+	}
+	for _, rule := range config.Rules {
+		if err := rule.validate(); err != nil {
+			return Config{}, fmt.Errorf("invalid rate limit rule %q: %w", rule.Name, err)
+		}
+	}
+	return config, nil
+}
+
+func (rule Rule) validate() error {
+	window, err := time.ParseDuration(rule.Window)
+	if err != nil {
+		return err
+	}
+	if window <= 0 {
+		return fmt.Errorf("the window must be positive")
+	}
+	if rule.Requests < 1 || rule.Burst < 1 {
+		return fmt.Errorf("the requests and the burst must be positive")
+	}
+	return nil
+}
+
+// bucket holds the tokens of a client at a time.
+type bucket struct {
+	tokens float64
+	at     time.Time
+}
+
+// ruleLimiter holds the buckets of the clients of a rule.
+type ruleLimiter struct {
+	rule    Rule
+	perSec  float64
+	burst   float64
+	buckets map[[sha256.Size]byte]*bucket
+}
+
+// tokens returns the tokens of the client at now.
+func (l *ruleLimiter) tokens(key [sha256.Size]byte, now time.Time) float64 {
+	b, ok := l.buckets[key]
+	if !ok {
+		return l.burst
+	}
+	return math.Min(l.burst, b.tokens+now.Sub(b.at).Seconds()*l.perSec)
+}
+
+// Decision is the outcome of a request.
+type Decision struct {
+	Allowed bool
+	// Rule is the exceeded rule of a rejected request.
+	Rule Rule
+	// RetryAfter is when the exceeded rule has a token for the client again.
+	RetryAfter time.Duration
+}
+
+// Limiter applies the rules to the requests. The zero value allows every request.
+type Limiter struct {
+	// Now returns the current time. time.Now is used when nil.
+	Now func() time.Time
+
+	mu        sync.Mutex
+	rules     []*ruleLimiter
+	lastSweep time.Time
+}
+
+func (l *Limiter) now() time.Time {
+	if l.Now != nil {
+		return l.Now()
+	}
+	return time.Now()
+}
+
+// SetConfig replaces the rules of the limiter. The clients of the rules left unchanged keep
+// their buckets, so that reloading the configuration does not reset their limits.
+func (l *Limiter) SetConfig(config Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	previous := map[Rule]*ruleLimiter{}
+	for _, rule := range l.rules {
+		previous[rule.rule] = rule
+	}
+	rules := make([]*ruleLimiter, 0, len(config.Rules))
+	for _, rule := range config.Rules {
+		if existing, ok := previous[rule]; ok {
+			rules = append(rules, existing)
+			continue
+		}
+		window, _ := time.ParseDuration(rule.Window)
+		rules = append(rules, &ruleLimiter{
+			rule:    rule,
+			perSec:  float64(rule.Requests) / window.Seconds(),
+			burst:   float64(rule.Burst),
+			buckets: map[[sha256.Size]byte]*bucket{},
+		})
+	}
+	l.rules = rules
+}
+
+// Allow decides whether a request is allowed, and consumes a token of each rule for its
+// client when it is. clientKey returns the identity of the client for a rule header, or
+// for the client IP address when the header is empty.
+func (l *Limiter) Allow(clientKey func(header string) string) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweep(now)
+
+	keys := make([][sha256.Size]byte, len(l.rules))
+	decision := Decision{Allowed: true}
+	for i, rule := range l.rules {
+		// Identities such as bearer tokens are only kept hashed
+		keys[i] = sha256.Sum256([]byte(rule.rule.Header + "\x00" + clientKey(rule.rule.Header)))
+		tokens := rule.tokens(keys[i], now)
+		if tokens >= 1 {
+			continue
+		}
+		retryAfter := time.Duration((1 - tokens) / rule.perSec * float64(time.Second))
+		if decision.Allowed || retryAfter > decision.RetryAfter {
+			decision = Decision{Rule: rule.rule, RetryAfter: retryAfter}
+		}
+	}
+	if !decision.Allowed {
+		return decision
+	}
+	for i, rule := range l.rules {
+		rule.buckets[keys[i]] = &bucket{tokens: rule.tokens(keys[i], now) - 1, at: now}
+	}
+	return decision
+}
+
+// sweep forgets the buckets that refilled completely, which behave as new clients.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for _, rule := range l.rules {
+		for key := range rule.buckets {
+			if rule.tokens(key, now) >= rule.burst {
+				delete(rule.buckets, key)
+			}
+		}
+	}
+}
+
+// Clients returns the number of clients tracked by each rule, by rule name.
+func (l *Limiter) Clients() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	clients := make(map[string]int, len(l.rules))
+	for _, rule := range l.rules {
+		clients[rule.rule.Name] = len(rule.buckets)
+	}
+	return clients
+}
@@ -0,0 +1,208 @@
+// Package redaction removes personal data from the text the agents and the operator write.
+//
+// The operator renders spec.redaction into a list of rules, the builtin ones first, and
+// hands it to the agent runtime for its logs, to the tool executor for its audit records and
+// to the conversation export job. The runtime and the export job apply the rules with the
+// backtracking regular expression engine of Python, so the patterns are restricted to the
+// syntax both engines share and rejected when they nest unbounded repetitions, which may
+// backtrack catastrophically on a crafted input.
+package redaction
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// MaxRules bounds the custom rules of an agent.
+	MaxRules = 20
+	// MaxPatternLength bounds the length of the pattern of a custom rule.
+	MaxPatternLength = 256
+	// DefaultReplacement replaces the matches of the custom rules that set no replacement.
+	DefaultReplacement = "[REDACTED]"
+)
+
+// Rule replaces the matches of Pattern with Replacement, taken literally.
+type Rule struct {
+	Name        string `json:"name"`
+	Pattern     string `json:"pattern"`
+	Replacement string `json:"replacement"`
+}
+
+// Config is the redaction rendered into the configuration of an agent.
+type Config struct {
+	Rules   []Rule   `json:"rules"`
+	ApplyTo []string `json:"applyTo"`
+}
+
+// Applies reports whether the configuration redacts target.
+func (c Config) Applies(target aiv1.RedactionTarget) bool {
+	for _, applied := range c.ApplyTo {
+		if applied == string(target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Builtin rules, matching the common US and international formats.
+var (
+	EmailRule = Rule{Name: "email", Pattern: `[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`, Replacement: "[REDACTED_EMAIL]"}
+	PhoneRule = Rule{Name: "phone", Pattern: `(?:\+?\d{1,3}[ .-]?)?\(?\d{3}\)?[ .-]?\d{3}[ .-]?\d{4}\b`, Replacement: "[REDACTED_PHONE]"}
+	SSNRule   = Rule{Name: "ssn", Pattern: `\b\d{3}-\d{2}-\d{4}\b`, Replacement: "[REDACTED_SSN]"}
+)
+
+// targets are what is redacted when applyTo is not set.
+var targets = []aiv1.RedactionTarget{aiv1.RedactionTargetLogs, aiv1.RedactionTargetToolAudit, aiv1.RedactionTargetExports, aiv1.RedactionTargetTranscripts}
+
+// Render returns the rules of spec, the builtin ones first and the custom ones in order. It
+// returns an empty configuration when spec is nil or enables no rule.
+func Render(spec *aiv1.RedactionConfig) Config {
+	if spec == nil {
+		return Config{}
+	}
+	var rules []Rule
+	if spec.Builtin.Email {
+		rules = append(rules, EmailRule)
+	}
+	if spec.Builtin.Phone {
+		rules = append(rules, PhoneRule)
+	}
+	if spec.Builtin.SSN {
+		rules = append(rules, SSNRule)
+	}
+	for _, rule := range spec.Rules {
+		replacement := rule.Replacement
+		if replacement == "" {
+			replacement = DefaultReplacement
+		}
+		rules = append(rules, Rule{Name: rule.Name, Pattern: rule.Pattern, Replacement: replacement})
+	}
+	if len(rules) == 0 {
+		return Config{}
+	}
+
+	applyTo := spec.ApplyTo
+	if len(applyTo) == 0 {
+		applyTo = targets
+	}
+	config := Config{Rules: rules}
+	for _, target := range applyTo {
+		config.ApplyTo = append(config.ApplyTo, string(target))
+	}
+	return config
+}
+
+// Validate returns the errors of spec, found at path.
+func Validate(path *field.Path, spec *aiv1.RedactionConfig) field.ErrorList {
+	var allErrs field.ErrorList
+	if spec == nil {
+		return allErrs
+	}
+
+	rulesPath := path.Child("rules")
+	if len(spec.Rules) > MaxRules {
+		allErrs = append(allErrs, field.TooMany(rulesPath, len(spec.Rules), MaxRules))
+	}
+	names := map[string]bool{}
+	for i, rule := range spec.Rules {
+		rulePath := rulesPath.Index(i)
+		switch {
+		case rule.Name == "":
+			allErrs = append(allErrs, field.Required(rulePath.Child("name"), "a redaction rule needs a name"))
+		case names[rule.Name]:
+			allErrs = append(allErrs, field.Duplicate(rulePath.Child("name"), rule.Name))
+		}
+		names[rule.Name] = true
+		if err := CheckPattern(rule.Pattern); err != nil {
+			allErrs = append(allErrs, field.Invalid(rulePath.Child("pattern"), rule.Pattern, err.Error()))
+		}
+	}
+
+	for i, target := range spec.ApplyTo {
+		switch target {
+		case aiv1.RedactionTargetLogs, aiv1.RedactionTargetToolAudit, aiv1.RedactionTargetExports, aiv1.RedactionTargetTranscripts:
+		default:
+			allErrs = append(allErrs, field.NotSupported(path.Child("applyTo").Index(i), target, []string{
+				string(aiv1.RedactionTargetLogs), string(aiv1.RedactionTargetToolAudit), string(aiv1.RedactionTargetExports), string(aiv1.RedactionTargetTranscripts)}))
+		}
+	}
+	return allErrs
+}
+
+// CheckPattern returns why pattern cannot be used by a rule, or nil.
+func CheckPattern(pattern string) error {
+	if pattern == "" {
+		return fmt.Errorf("the pattern must not be empty")
+	}
+	if len(pattern) > MaxPatternLength {
+		return fmt.Errorf("the pattern must be at most %d characters", MaxPatternLength)
+	}
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("the pattern does not compile: %v", err)
+	}
+	// Python has no Unicode classes, and would take \p literally
+	if strings.Contains(pattern, `\p`) || strings.Contains(pattern, `\P`) {
+		return fmt.Errorf("Unicode classes such as \\pL are not supported")
+	}
+	if nestedRepetition(re, false) {
+		return fmt.Errorf("the pattern nests unbounded repetitions, which may backtrack catastrophically")
+	}
+	return nil
+}
+
+// nestedRepetition reports whether re holds an unbounded repetition, within another one
+// when repeated is set.
+func nestedRepetition(re *syntax.Regexp, repeated bool) bool {
+	unbounded := re.Op == syntax.OpStar || re.Op == syntax.OpPlus || (re.Op == syntax.OpRepeat && re.Max == -1)
+	if unbounded && repeated {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if nestedRepetition(sub, repeated || unbounded) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redactor applies rules to text.
+type Redactor struct {
+	rules []compiledRule
+}
+
+type compiledRule struct {
+	re          *regexp.Regexp
+	replacement string
+}
+
+// New returns a redactor applying rules in order.
+func New(rules []Rule) (*Redactor, error) {
+	redactor := &Redactor{}
+	for _, rule := range rules {
+		re, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction rule %q: %w", rule.Name, err)
+		}
+		redactor.rules = append(redactor.rules, compiledRule{re: re, replacement: rule.Replacement})
+	}
+	return redactor, nil
+}
+
+// Redact returns s with the matches of every rule replaced. A nil redactor returns s.
+func (r *Redactor) Redact(s string) string {
+	if r == nil {
+		return s
+	}
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllLiteralString(s, rule.replacement)
+	}
+	return s
+}
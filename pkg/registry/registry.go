@@ -0,0 +1,109 @@
+// Package registry defines the agent discovery registry.
+//
+// The operator lists the running agents of each namespace, with the URL they are reached
+// at, in the ConfigMapName ConfigMap of the namespace, and optionally the running agents of
+// all namespaces in the ClusterConfigMapName ConfigMap of the operator namespace. Workloads
+// discover the agents by reading the ConfigMap, or mounting it, instead of watching the
+// Agent resources. The operator is the only writer of the registry ConfigMaps.
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// ConfigMapName is the ConfigMap listing the running agents of its namespace.
+	ConfigMapName = "kubeagentic-registry"
+
+	// ClusterConfigMapName is the ConfigMap of the operator namespace listing the running
+	// agents of all namespaces, when the cluster registry is enabled.
+	ClusterConfigMapName = "kubeagentic-cluster-registry"
+
+	// DataKey is the key of the registry ConfigMaps holding the JSON list of entries.
+	DataKey = "agents.json"
+
+	// maxDescriptionLength bounds the description of an entry, in characters.
+	maxDescriptionLength = 120
+)
+
+// Entry describes a running agent.
+type Entry struct {
+	Name        string   `json:"name"`
+	Namespace   string   `json:"namespace"`
+	URL         string   `json:"url"`
+	Provider    string   `json:"provider"`
+	Model       string   `json:"model"`
+	Tools       []string `json:"tools,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// Describe returns the description of an agent: the first non-empty line of its system
+// prompt, shortened to maxDescriptionLength characters.
+func Describe(systemPrompt string) string {
+	for _, line := range strings.Split(systemPrompt, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if utf8.RuneCountInString(line) > maxDescriptionLength {
+			line = string([]rune(line)[:maxDescriptionLength-3]) + "..."
+		}
+		return line
+	}
+	return ""
+}
+
+// Marshal returns the registry data of the entries, sorted by namespace and name so that
+// the data only changes with the entries.
+func Marshal(entries []Entry) (string, error) {
+	sorted := append([]Entry{}, entries...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Namespace != sorted[j].Namespace {
+			return sorted[i].Namespace < sorted[j].Namespace
+		}
+		return sorted[i].Name < sorted[j].Name
+	})
+	if sorted == nil {
+		sorted = []Entry{}
+	}
+	data, err := json.MarshalIndent(sorted, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// Parse returns the entries of registry data.
+func Parse(data string) ([]Entry, error) {
+	var entries []Entry
+	if err := json.Unmarshal([]byte(data), &entries); err != nil {
+		return nil, fmt.Errorf("invalid agent registry: %w", err)
+	}
+	return entries, nil
+}
+
+// Read returns the entries of the registry ConfigMap name in namespace. A missing
+// ConfigMap is an empty registry.
+func Read(ctx context.Context, c client.Reader, namespace, name string) ([]Entry, error) {
+	cm := &corev1.ConfigMap{}
+	if err := c.Get(ctx, types.NamespacedName{Namespace: namespace, Name: name}, cm); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if cm.Data[DataKey] == "" {
+		return nil, nil
+	}
+	return Parse(cm.Data[DataKey])
+}
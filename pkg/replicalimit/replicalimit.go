@@ -0,0 +1,136 @@
+// Package replicalimit decides how many replicas the Agents of a namespace may run, and how
+// far their autoscalers may scale them.
+package replicalimit
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// SanityMax is the maximum of spec.replicas in the CRD schema. It only catches typos;
+	// the operator configuration and AgentPolicies set the actual maximum.
+	SanityMax = 1000
+	// DefaultMax is the maximum of spec.replicas when MAX_AGENT_REPLICAS is not set.
+	DefaultMax = 10
+)
+
+// MaxFromEnv returns the operator-wide maximum of spec.replicas configured in
+// MAX_AGENT_REPLICAS, or DefaultMax when it is not set or not a positive number. Values
+// above SanityMax are capped to it.
+func MaxFromEnv() int32 {
+	value, err := strconv.Atoi(os.Getenv("MAX_AGENT_REPLICAS"))
+	if err != nil || value < 1 {
+		return DefaultMax
+	}
+	if value > SanityMax {
+		return SanityMax
+	}
+	return int32(value)
+}
+
+// Max returns the maximum of spec.replicas for the Agents of namespace, the lowest of the
+// operator maximum and the maxReplicas of the AgentPolicies of the namespace, and what sets
+// it, e.g. "the operator" or "AgentPolicy caps".
+func Max(namespace string, policies []aiv1.AgentPolicy) (int32, string) {
+	max, source := MaxFromEnv(), "the operator"
+	for _, policy := range policies {
+		if policy.Namespace != namespace || policy.Spec.MaxReplicas == nil {
+			continue
+		}
+		if *policy.Spec.MaxReplicas < max {
+			max, source = *policy.Spec.MaxReplicas, fmt.Sprintf("AgentPolicy %s", policy.Name)
+		}
+	}
+	return max, source
+}
+
+// Ceiling bounds how far autoscalers may scale the Agents of a namespace: the maximum
+// replicas, and the total CPU and memory requested by the pods at that maximum.
+type Ceiling struct {
+	// MaxReplicas is the highest maximum of an autoscaler, or 0 without a ceiling.
+	MaxReplicas int32
+	// MaxRequests holds the cpu and memory the pods may request together.
+	MaxRequests corev1.ResourceList
+	// Sources tell what sets each ceiling, keyed by "replicas" or the resource name.
+	Sources map[string]string
+}
+
+// CeilingFromEnv returns the operator-wide ceiling configured in MAX_AUTOSCALING_REPLICAS,
+// MAX_AUTOSCALING_CPU and MAX_AUTOSCALING_MEMORY. Unset or invalid variables set no ceiling.
+func CeilingFromEnv() Ceiling {
+	ceiling := Ceiling{MaxRequests: corev1.ResourceList{}, Sources: map[string]string{}}
+	if value, err := strconv.Atoi(os.Getenv("MAX_AUTOSCALING_REPLICAS")); err == nil && value > 0 {
+		ceiling.MaxReplicas, ceiling.Sources["replicas"] = int32(value), "the operator"
+	}
+	for name, variable := range map[corev1.ResourceName]string{corev1.ResourceCPU: "MAX_AUTOSCALING_CPU", corev1.ResourceMemory: "MAX_AUTOSCALING_MEMORY"} {
+		if quantity, err := resource.ParseQuantity(os.Getenv(variable)); err == nil && quantity.Sign() > 0 {
+			ceiling.MaxRequests[name], ceiling.Sources[string(name)] = quantity, "the operator"
+		}
+	}
+	return ceiling
+}
+
+// CeilingFor returns the autoscaling ceiling of the Agents of namespace, the tightest of the
+// operator ceiling and of the autoscalingCeiling of the AgentPolicies of the namespace.
+func CeilingFor(namespace string, policies []aiv1.AgentPolicy) Ceiling {
+	ceiling := CeilingFromEnv()
+	for _, policy := range policies {
+		policyCeiling := policy.Spec.AutoscalingCeiling
+		if policy.Namespace != namespace || policyCeiling == nil {
+			continue
+		}
+		source := fmt.Sprintf("AgentPolicy %s", policy.Name)
+		if max := policyCeiling.MaxReplicas; max != nil && (ceiling.MaxReplicas == 0 || *max < ceiling.MaxReplicas) {
+			ceiling.MaxReplicas, ceiling.Sources["replicas"] = *max, source
+		}
+		for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+			quantity, ok := policyCeiling.MaxRequests[name]
+			if !ok {
+				continue
+			}
+			if current, ok := ceiling.MaxRequests[name]; !ok || quantity.Cmp(current) < 0 {
+				ceiling.MaxRequests[name], ceiling.Sources[string(name)] = quantity, source
+			}
+		}
+	}
+	return ceiling
+}
+
+// Clamp lowers the maximum replicas of an autoscaler of pods requesting podRequests each to
+// the ceiling, but not below minReplicas, and returns the ceilings it exceeds, e.g.
+// "maxReplicas 30 exceeds the ceiling 20 of AgentPolicy caps".
+func (c Ceiling) Clamp(minReplicas, maxReplicas int32, podRequests corev1.ResourceList) (int32, []string) {
+	clamped := maxReplicas
+	var exceeded []string
+	if c.MaxReplicas > 0 && maxReplicas > c.MaxReplicas {
+		clamped = c.MaxReplicas
+		exceeded = append(exceeded, fmt.Sprintf("maxReplicas %d exceeds the ceiling %d of %s", maxReplicas, c.MaxReplicas, c.Sources["replicas"]))
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		limit, ok := c.MaxRequests[name]
+		request := podRequests[name]
+		if !ok || request.IsZero() {
+			continue
+		}
+		total := resource.NewMilliQuantity(request.MilliValue()*int64(maxReplicas), request.Format)
+		if total.Cmp(limit) <= 0 {
+			continue
+		}
+		if fitting := int32(limit.MilliValue() / request.MilliValue()); fitting < clamped {
+			clamped = fitting
+		}
+		exceeded = append(exceeded, fmt.Sprintf("%s requested at %d replicas, %s at %s per pod, exceeds the ceiling %s of %s",
+			name, maxReplicas, total.String(), request.String(), limit.String(), c.Sources[string(name)]))
+	}
+	if clamped < minReplicas {
+		clamped = minReplicas
+	}
+	return clamped, exceeded
+}
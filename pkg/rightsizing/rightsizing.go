@@ -0,0 +1,162 @@
+// Package rightsizing recommends CPU and memory requests and limits for agent containers
+// from a rolling window of their measured usage.
+//
+// Requests cover the 95th percentile of the usage plus RequestHeadroom, and limits the peak
+// usage plus LimitHeadroom. CPU is rounded up to 10 millicores and memory to 8Mi.
+package rightsizing
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// RequestHeadroom is added to the 95th percentile of the usage for requests.
+	RequestHeadroom = 0.15
+	// LimitHeadroom is added to the peak usage for limits.
+	LimitHeadroom = 0.30
+	// MinSamples is the number of samples needed for a recommendation.
+	MinSamples = 12
+
+	minCPUMilli    = 10
+	minMemoryBytes = 32 << 20
+	cpuStepMilli   = 10
+	memoryStep     = 8 << 20
+)
+
+// Sample is the usage of a container at a point in time.
+type Sample struct {
+	Time        time.Time
+	CPUMilli    int64
+	MemoryBytes int64
+}
+
+// Window keeps the samples of the last Length.
+type Window struct {
+	// Length is the time covered by the window.
+	Length  time.Duration
+	samples []Sample
+}
+
+// Add adds samples taken at the same time and drops the ones older than the window.
+func (w *Window) Add(samples ...Sample) {
+	w.samples = append(w.samples, samples...)
+	if len(w.samples) == 0 {
+		return
+	}
+	cutoff := w.samples[len(w.samples)-1].Time.Add(-w.Length)
+	kept := w.samples[:0]
+	for _, s := range w.samples {
+		if s.Time.After(cutoff) {
+			kept = append(kept, s)
+		}
+	}
+	w.samples = kept
+}
+
+// Samples returns the samples in the window, oldest first.
+func (w *Window) Samples() []Sample {
+	return w.samples
+}
+
+// Last returns the time of the newest sample, or the zero time when the window is empty.
+func (w *Window) Last() time.Time {
+	if len(w.samples) == 0 {
+		return time.Time{}
+	}
+	return w.samples[len(w.samples)-1].Time
+}
+
+// Recommendation is the recommended requests and limits of a container.
+type Recommendation struct {
+	CPURequestMilli    int64
+	MemoryRequestBytes int64
+	CPULimitMilli      int64
+	MemoryLimitBytes   int64
+}
+
+// Usage is a summary of the samples.
+type Usage struct {
+	CPUP95Milli    int64
+	MemoryP95Bytes int64
+	CPUMaxMilli    int64
+	MemoryMaxBytes int64
+}
+
+// Summarize returns the 95th percentile and the peak of the samples.
+func Summarize(samples []Sample) Usage {
+	cpu := make([]int64, 0, len(samples))
+	memory := make([]int64, 0, len(samples))
+	for _, s := range samples {
+		cpu = append(cpu, s.CPUMilli)
+		memory = append(memory, s.MemoryBytes)
+	}
+	return Usage{
+		CPUP95Milli:    Percentile(cpu, 95),
+		MemoryP95Bytes: Percentile(memory, 95),
+		CPUMaxMilli:    Percentile(cpu, 100),
+		MemoryMaxBytes: Percentile(memory, 100),
+	}
+}
+
+// Recommend returns the recommendation for samples, or false with fewer than MinSamples.
+func Recommend(samples []Sample) (Recommendation, bool) {
+	if len(samples) < MinSamples {
+		return Recommendation{}, false
+	}
+	usage := Summarize(samples)
+	r := Recommendation{
+		CPURequestMilli:    roundUp(withHeadroom(usage.CPUP95Milli, RequestHeadroom), cpuStepMilli, minCPUMilli),
+		MemoryRequestBytes: roundUp(withHeadroom(usage.MemoryP95Bytes, RequestHeadroom), memoryStep, minMemoryBytes),
+		CPULimitMilli:      roundUp(withHeadroom(usage.CPUMaxMilli, LimitHeadroom), cpuStepMilli, minCPUMilli),
+		MemoryLimitBytes:   roundUp(withHeadroom(usage.MemoryMaxBytes, LimitHeadroom), memoryStep, minMemoryBytes),
+	}
+	if r.CPULimitMilli < r.CPURequestMilli {
+		r.CPULimitMilli = r.CPURequestMilli
+	}
+	if r.MemoryLimitBytes < r.MemoryRequestBytes {
+		r.MemoryLimitBytes = r.MemoryRequestBytes
+	}
+	return r, true
+}
+
+// Percentile returns the nearest-rank p-th percentile of values, or 0 without values.
+func Percentile(values []int64, p float64) int64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sorted := append([]int64(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Exceeds reports whether usage is more than factor times request. Without a request,
+// usage is never considered excessive.
+func Exceeds(usage, request int64, factor float64) bool {
+	return request > 0 && float64(usage) > factor*float64(request)
+}
+
+// Differs reports whether a value moved by more than 10% from the current one, so that
+// small fluctuations of the usage do not change applied recommendations.
+func Differs(current, value int64) bool {
+	if current == 0 {
+		return value != 0
+	}
+	return math.Abs(float64(value-current)) > 0.1*float64(current)
+}
+
+func withHeadroom(value int64, headroom float64) int64 {
+	return int64(math.Ceil(float64(value) * (1 + headroom)))
+}
+
+func roundUp(value, step, min int64) int64 {
+	if value < min {
+		value = min
+	}
+	return (value + step - 1) / step * step
+}
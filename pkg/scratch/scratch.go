@@ -0,0 +1,70 @@
+// Package scratch describes the scratch volume of the agent container, a bounded emptyDir
+// holding the temporary files of the runtime, and the operator-wide cap on its size.
+//
+// Without it the runtime writes its temporary files to the writable layer of the container,
+// which the restricted security profile makes read-only, and which is only bounded by the
+// ephemeral-storage limit of the container.
+package scratch
+
+import (
+	"fmt"
+	"os"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+const (
+	// VolumeName is the name of the scratch volume in the agent pods.
+	VolumeName = "scratch"
+	// MountPath is where the scratch volume is mounted in the agent container, exported to
+	// the runtime as AGENT_TMP_DIR.
+	MountPath = "/var/lib/agent/scratch"
+
+	// MediumDisk backs the scratch volume with the disk of the node.
+	MediumDisk = "Disk"
+	// MediumMemory backs the scratch volume with a tmpfs.
+	MediumMemory = "Memory"
+)
+
+// DefaultMax is the maximum of spec.scratch.sizeLimit when MAX_SCRATCH_SIZE is not set.
+var DefaultMax = resource.MustParse("10Gi")
+
+// MaxFromEnv returns the operator-wide maximum of spec.scratch.sizeLimit configured in
+// MAX_SCRATCH_SIZE, or DefaultMax when it is not set or not a positive quantity.
+func MaxFromEnv() resource.Quantity {
+	max, err := resource.ParseQuantity(os.Getenv("MAX_SCRATCH_SIZE"))
+	if err != nil || max.Sign() <= 0 {
+		return DefaultMax.DeepCopy()
+	}
+	return max
+}
+
+// Medium returns the medium of the scratch volume of spec, Disk when unset.
+func Medium(spec *aiv1.AgentSpec) string {
+	if spec.Scratch == nil || spec.Scratch.Medium == "" {
+		return MediumDisk
+	}
+	return spec.Scratch.Medium
+}
+
+// Validate checks that spec.scratch sets a positive size within MaxFromEnv and a known medium.
+func Validate(spec *aiv1.AgentSpec) field.ErrorList {
+	if spec.Scratch == nil {
+		return nil
+	}
+	scratchPath := field.NewPath("spec").Child("scratch")
+	var allErrs field.ErrorList
+	size, max := spec.Scratch.SizeLimit, MaxFromEnv()
+	if size.Sign() <= 0 {
+		allErrs = append(allErrs, field.Invalid(scratchPath.Child("sizeLimit"), size.String(), "must be positive"))
+	} else if size.Cmp(max) > 0 {
+		allErrs = append(allErrs, field.Invalid(scratchPath.Child("sizeLimit"), size.String(), fmt.Sprintf("must not exceed %s, the maximum set by the operator", max.String())))
+	}
+	if medium := Medium(spec); medium != MediumDisk && medium != MediumMemory {
+		allErrs = append(allErrs, field.NotSupported(scratchPath.Child("medium"), medium, []string{MediumDisk, MediumMemory}))
+	}
+	return allErrs
+}
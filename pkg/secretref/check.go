@@ -0,0 +1,182 @@
+package secretref
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// LookupTimeout bounds the time Missing spends reading the referenced Secrets, so that
+// admission does not wait on a slow API server or a cache that is still syncing.
+const LookupTimeout = 2 * time.Second
+
+// Strict reports whether agents referencing missing Secrets are rejected at admission, as
+// configured with STRICT_SECRET_REFERENCES=true. They are only warned about by default,
+// since GitOps tools may apply an Agent before its Secrets.
+func Strict() bool {
+	return os.Getenv("STRICT_SECRET_REFERENCES") == "true"
+}
+
+// Ref is a Secret referenced by the spec of an Agent.
+type Ref struct {
+	// Path is the field of the reference.
+	Path      *field.Path
+	Namespace string
+	Name      string
+	// Key must be in the Secret when set.
+	Key string
+}
+
+// Refs returns the Secrets referenced by the spec of an Agent of namespace, in the order of
+// its fields.
+func Refs(namespace string, agentSpec *aiv1.AgentSpec) []Ref {
+	var refs []Ref
+	visit(agentSpec, func(path *field.Path, secretNamespace string, ref *corev1.SecretKeySelector) {
+		if ref.Name == "" {
+			return
+		}
+		if secretNamespace == "" {
+			secretNamespace = namespace
+		}
+		refs = append(refs, Ref{Path: path, Namespace: secretNamespace, Name: ref.Name, Key: ref.Key})
+	}, func(path *field.Path, ref *corev1.LocalObjectReference) {
+		if ref.Name == "" {
+			return
+		}
+		refs = append(refs, Ref{Path: path, Namespace: namespace, Name: ref.Name})
+	})
+	return refs
+}
+
+// Rename renames the Secrets referenced by agentSpec that are keys of names to their value,
+// such as the Secrets of another environment when the agent is promoted to it.
+func Rename(agentSpec *aiv1.AgentSpec, names map[string]string) {
+	visit(agentSpec, func(_ *field.Path, _ string, ref *corev1.SecretKeySelector) {
+		if name, ok := names[ref.Name]; ok {
+			ref.Name = name
+		}
+	}, func(_ *field.Path, ref *corev1.LocalObjectReference) {
+		if name, ok := names[ref.Name]; ok {
+			ref.Name = name
+		}
+	})
+}
+
+// visit calls onKeyRef for the Secret key references of agentSpec, with the namespace they
+// name if any, and onNamedRef for its references of whole Secrets, in the order of its fields.
+func visit(agentSpec *aiv1.AgentSpec, onKeyRef func(path *field.Path, secretNamespace string, ref *corev1.SecretKeySelector), onNamedRef func(path *field.Path, ref *corev1.LocalObjectReference)) {
+	spec := field.NewPath("spec")
+	keyRef := func(path *field.Path, secretNamespace string, ref *corev1.SecretKeySelector) {
+		if ref != nil {
+			onKeyRef(path, secretNamespace, ref)
+		}
+	}
+	namedRef := func(path *field.Path, ref *corev1.LocalObjectReference) {
+		if ref != nil {
+			onNamedRef(path, ref)
+		}
+	}
+
+	if ref := agentSpec.ApiSecretRef; ref != nil {
+		keyRef(spec.Child("apiSecretRef"), ref.Namespace, &ref.SecretKeySelector)
+	}
+	if rag := agentSpec.RAG; rag != nil {
+		if rag.Embeddings != nil && rag.Embeddings.ApiSecretRef != nil {
+			ref := rag.Embeddings.ApiSecretRef
+			keyRef(spec.Child("rag", "embeddings", "apiSecretRef"), ref.Namespace, &ref.SecretKeySelector)
+		}
+		if rag.VectorStore != nil {
+			keyRef(spec.Child("rag", "vectorStore", "connectionSecretRef"), "", &rag.VectorStore.ConnectionSecretRef)
+		}
+		if rag.Ingestion != nil {
+			for i, source := range rag.Ingestion.Sources {
+				if source.Bucket != nil {
+					namedRef(spec.Child("rag", "ingestion", "sources").Index(i).Child("bucket", "credentialsSecretRef"), source.Bucket.CredentialsSecretRef)
+				}
+			}
+		}
+	}
+	if caching := agentSpec.Caching; caching != nil && caching.Embeddings != nil && caching.Embeddings.ApiSecretRef != nil {
+		ref := caching.Embeddings.ApiSecretRef
+		keyRef(spec.Child("caching", "embeddings", "apiSecretRef"), ref.Namespace, &ref.SecretKeySelector)
+	}
+	if memory := agentSpec.Memory; memory != nil {
+		keyRef(spec.Child("memory", "connectionSecretRef"), "", memory.ConnectionSecretRef)
+	}
+	if encryption := agentSpec.Encryption; encryption != nil {
+		keyRef(spec.Child("encryption", "keySecretRef"), "", &encryption.KeySecretRef)
+		keyRef(spec.Child("encryption", "previousKeySecretRef"), "", encryption.PreviousKeySecretRef)
+	}
+	if export := agentSpec.Export; export != nil {
+		namedRef(spec.Child("export", "credentialsSecretRef"), &export.CredentialsSecretRef)
+	}
+	if sink := agentSpec.TranscriptSink; sink != nil {
+		keyRef(spec.Child("transcriptSink", "authSecretRef"), "", &sink.AuthSecretRef)
+	}
+	for i := range agentSpec.Connectors {
+		namedRef(spec.Child("connectors").Index(i).Child("credentialsSecretRef"), &agentSpec.Connectors[i].CredentialsSecretRef)
+	}
+	if source := agentSpec.EventSource; source != nil {
+		if source.Kafka != nil {
+			namedRef(spec.Child("eventSource", "kafka", "credentialsSecretRef"), source.Kafka.CredentialsSecretRef)
+		}
+		if source.SQS != nil {
+			namedRef(spec.Child("eventSource", "sqs", "credentialsSecretRef"), source.SQS.CredentialsSecretRef)
+		}
+	}
+	if worker := agentSpec.WorkerMode; worker != nil {
+		namedRef(spec.Child("workerMode", "queueSecretRef"), worker.QueueSecretRef)
+	}
+}
+
+// Missing returns an error for each of refs whose Secret, or key, does not exist. It fails
+// open: Secrets that cannot be read within LookupTimeout, e.g. because the API server is
+// slow or access is denied, are logged and not reported.
+func Missing(ctx context.Context, reader client.Reader, refs []Ref) field.ErrorList {
+	if len(refs) == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, LookupTimeout)
+	defer cancel()
+
+	var allErrs field.ErrorList
+	secrets := map[client.ObjectKey]*corev1.Secret{}
+	for _, ref := range refs {
+		key := client.ObjectKey{Namespace: ref.Namespace, Name: ref.Name}
+		secret, read := secrets[key]
+		if !read {
+			secret = &corev1.Secret{}
+			if err := reader.Get(ctx, key, secret); apierrors.IsNotFound(err) {
+				secret = nil
+			} else if err != nil {
+				logf.FromContext(ctx).Error(err, "Failed to check a referenced Secret, assuming it exists", "secret", key, "field", ref.Path.String())
+				continue
+			}
+			secrets[key] = secret
+		}
+		switch {
+		case secret == nil:
+			allErrs = append(allErrs, field.NotFound(ref.Path, fmt.Sprintf("secret %s in namespace %s", ref.Name, ref.Namespace)))
+		case ref.Key != "" && !hasKey(secret, ref.Key):
+			allErrs = append(allErrs, field.Invalid(ref.Path.Child("key"), ref.Key, fmt.Sprintf("secret %s in namespace %s has no key %s", ref.Name, ref.Namespace, ref.Key)))
+		}
+	}
+	return allErrs
+}
+
+func hasKey(secret *corev1.Secret, key string) bool {
+	if _, ok := secret.Data[key]; ok {
+		return true
+	}
+	_, ok := secret.StringData[key]
+	return ok
+}
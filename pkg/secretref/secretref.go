@@ -0,0 +1,56 @@
+// Package secretref decides from which namespaces the Agents of a namespace may
+// reference Secrets, and checks that the Secrets they reference exist.
+package secretref
+
+import (
+	"os"
+	"strings"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+)
+
+// AllowedNamespacesFromEnv returns the operator-wide grants configured in
+// ALLOWED_SECRET_NAMESPACES as a map from consuming namespace to source namespaces.
+// The variable holds comma-separated "<consumer>:<source>" entries, e.g.
+// "team-a:llm-credentials,*:shared-credentials", where the consumer "*" matches
+// every namespace.
+func AllowedNamespacesFromEnv() map[string][]string {
+	grants := map[string][]string{}
+	for _, entry := range strings.Split(os.Getenv("ALLOWED_SECRET_NAMESPACES"), ",") {
+		consumer, source, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		consumer, source = strings.TrimSpace(consumer), strings.TrimSpace(source)
+		if !ok || consumer == "" || source == "" {
+			continue
+		}
+		grants[consumer] = append(grants[consumer], source)
+	}
+	return grants
+}
+
+// NamespaceAllowed reports whether Agents in consumer may reference Secrets in source.
+// The agent's own namespace is always allowed; other namespaces need a grant from the
+// operator configuration or from one of the AgentPolicies of the source namespace, as
+// read from policies. Policies of other namespaces, such as the consuming one, whose
+// writers could grant themselves any Secret, are ignored.
+func NamespaceAllowed(consumer, source string, policies []aiv1.AgentPolicy) bool {
+	if source == "" || source == consumer {
+		return true
+	}
+	grants := AllowedNamespacesFromEnv()
+	for _, allowed := range append(grants[consumer], grants["*"]...) {
+		if allowed == source {
+			return true
+		}
+	}
+	for _, policy := range policies {
+		if policy.Namespace != source {
+			continue
+		}
+		for _, allowed := range policy.Spec.SecretConsumerNamespaces {
+			if allowed == consumer {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,202 @@
+// Package securityprofile renders and checks pod specs for the levels of the Pod Security
+// Standards.
+//
+// Apply hardens the pods generated by the operator so that they pass the restricted level,
+// and Violations reports the settings of a pod spec that a level forbids, so that operator
+// or user configuration cannot silently break the chosen profile.
+package securityprofile
+
+import (
+	"fmt"
+	"os"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Baseline prevents known privilege escalations.
+	Baseline = "baseline"
+	// Restricted additionally enforces the current pod hardening best practices.
+	Restricted = "restricted"
+)
+
+// Default returns the operator-wide profile from the DEFAULT_SECURITY_PROFILE environment
+// variable. An empty profile renders pods without any hardening.
+func Default() string {
+	return os.Getenv("DEFAULT_SECURITY_PROFILE")
+}
+
+// Resolve returns profile, or the operator default when profile is empty.
+func Resolve(profile string) string {
+	if profile != "" {
+		return profile
+	}
+	return Default()
+}
+
+// Valid reports whether profile is empty or a known profile.
+func Valid(profile string) bool {
+	return profile == "" || profile == Baseline || profile == Restricted
+}
+
+// Apply hardens spec for the restricted profile: the pod runs as the non-root uid with the
+// RuntimeDefault seccomp profile, and every container drops all capabilities, cannot
+// escalate privileges and has a read-only root filesystem. Each path in writablePaths, and
+// /tmp, is backed by an emptyDir in every container. Other profiles leave spec unchanged, as
+// the generated pods already satisfy the baseline level.
+func Apply(profile string, spec *corev1.PodSpec, uid int64, writablePaths ...string) {
+	if profile != Restricted {
+		return
+	}
+
+	runAsNonRoot := true
+	if spec.SecurityContext == nil {
+		spec.SecurityContext = &corev1.PodSecurityContext{}
+	}
+	spec.SecurityContext.RunAsNonRoot = &runAsNonRoot
+	spec.SecurityContext.RunAsUser = &uid
+	spec.SecurityContext.SeccompProfile = &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+
+	paths := append([]string{"/tmp"}, writablePaths...)
+	for i, path := range paths {
+		name := fmt.Sprintf("writable-%d", i)
+		if i == 0 {
+			name = "tmp"
+		}
+		spec.Volumes = append(spec.Volumes, corev1.Volume{
+			Name:         name,
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		})
+		for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+			for j := range containers {
+				containers[j].VolumeMounts = append(containers[j].VolumeMounts, corev1.VolumeMount{Name: name, MountPath: path})
+			}
+		}
+	}
+
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for j := range containers {
+			hardenContainer(&containers[j])
+		}
+	}
+}
+
+// hardenContainer sets the restricted container security context.
+func hardenContainer(container *corev1.Container) {
+	allowPrivilegeEscalation := false
+	readOnlyRootFilesystem := true
+	if container.SecurityContext == nil {
+		container.SecurityContext = &corev1.SecurityContext{}
+	}
+	container.SecurityContext.AllowPrivilegeEscalation = &allowPrivilegeEscalation
+	container.SecurityContext.ReadOnlyRootFilesystem = &readOnlyRootFilesystem
+	container.SecurityContext.Capabilities = &corev1.Capabilities{Drop: []corev1.Capability{"ALL"}}
+}
+
+// Violations returns the settings of spec that profile forbids. Empty profiles allow
+// everything.
+func Violations(profile string, spec *corev1.PodSpec) []string {
+	if profile != Baseline && profile != Restricted {
+		return nil
+	}
+
+	var violations []string
+	if spec.HostNetwork || spec.HostPID || spec.HostIPC {
+		violations = append(violations, "host namespaces must not be shared")
+	}
+	for _, volume := range spec.Volumes {
+		if volume.HostPath != nil {
+			violations = append(violations, fmt.Sprintf("volume %s must not use a hostPath", volume.Name))
+		}
+		if profile == Restricted && !restrictedVolume(volume) {
+			violations = append(violations, fmt.Sprintf("volume %s uses a volume type not allowed by the restricted profile", volume.Name))
+		}
+	}
+
+	podRunAsNonRoot := spec.SecurityContext != nil && spec.SecurityContext.RunAsNonRoot != nil && *spec.SecurityContext.RunAsNonRoot
+	podSeccomp := spec.SecurityContext != nil && spec.SecurityContext.SeccompProfile != nil
+	if profile == Restricted && spec.SecurityContext != nil && spec.SecurityContext.RunAsUser != nil && *spec.SecurityContext.RunAsUser == 0 {
+		violations = append(violations, "pod must not run as user 0")
+	}
+
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for _, container := range containers {
+			violations = append(violations, containerViolations(profile, container, podRunAsNonRoot, podSeccomp)...)
+		}
+	}
+	return violations
+}
+
+// containerViolations returns the settings of container that profile forbids.
+func containerViolations(profile string, container corev1.Container, podRunAsNonRoot, podSeccomp bool) []string {
+	var violations []string
+	sc := container.SecurityContext
+	if sc == nil {
+		sc = &corev1.SecurityContext{}
+	}
+
+	if sc.Privileged != nil && *sc.Privileged {
+		violations = append(violations, fmt.Sprintf("container %s must not be privileged", container.Name))
+	}
+	for _, port := range container.Ports {
+		if port.HostPort != 0 {
+			violations = append(violations, fmt.Sprintf("container %s must not use host ports", container.Name))
+			break
+		}
+	}
+	if sc.Capabilities != nil {
+		for _, capability := range sc.Capabilities.Add {
+			if (profile == Restricted && capability != "NET_BIND_SERVICE") || !baselineCapabilities[capability] {
+				violations = append(violations, fmt.Sprintf("container %s must not add capability %s", container.Name, capability))
+			}
+		}
+	}
+	if profile != Restricted {
+		return violations
+	}
+
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		violations = append(violations, fmt.Sprintf("container %s must set allowPrivilegeEscalation to false", container.Name))
+	}
+	if !dropsAll(sc.Capabilities) {
+		violations = append(violations, fmt.Sprintf("container %s must drop all capabilities", container.Name))
+	}
+	if (sc.RunAsNonRoot != nil && !*sc.RunAsNonRoot) || (sc.RunAsNonRoot == nil && !podRunAsNonRoot) {
+		violations = append(violations, fmt.Sprintf("container %s must run as non-root", container.Name))
+	}
+	if sc.RunAsUser != nil && *sc.RunAsUser == 0 {
+		violations = append(violations, fmt.Sprintf("container %s must not run as user 0", container.Name))
+	}
+	if sc.SeccompProfile == nil && !podSeccomp {
+		violations = append(violations, fmt.Sprintf("container %s must set a seccomp profile", container.Name))
+	}
+	return violations
+}
+
+// baselineCapabilities are the capabilities containers may add under the baseline profile.
+var baselineCapabilities = map[corev1.Capability]bool{
+	"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true,
+	"KILL": true, "MKNOD": true, "NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true,
+	"SETPCAP": true, "SETUID": true, "SYS_CHROOT": true,
+}
+
+// dropsAll reports whether capabilities drop ALL.
+func dropsAll(capabilities *corev1.Capabilities) bool {
+	if capabilities == nil {
+		return false
+	}
+	for _, capability := range capabilities.Drop {
+		if capability == "ALL" {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictedVolume reports whether volume uses a type allowed by the restricted profile.
+func restrictedVolume(volume corev1.Volume) bool {
+	source := volume.VolumeSource
+	return source.ConfigMap != nil || source.CSI != nil || source.DownwardAPI != nil ||
+		source.EmptyDir != nil || source.Ephemeral != nil || source.PersistentVolumeClaim != nil ||
+		source.Projected != nil || source.Secret != nil
+}
@@ -0,0 +1,176 @@
+// Package sizing defines the resource presets of the agent container selected by
+// spec.size.
+//
+// Presets come in two classes: agents of self-hosted providers, which run next to their
+// model servers, and agents of hosted providers. The operator starts with the presets
+// embedded in this package and replaces them with Set when the presets ConfigMap changes.
+package sizing
+
+import (
+	_ "embed"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"sigs.k8s.io/yaml"
+)
+
+// Sizes are the values of spec.size, from the smallest to the largest.
+var Sizes = []string{"small", "medium", "large", "xlarge"}
+
+// Presets holds the resource requirements of each size.
+type Presets struct {
+	// SelfHostedProviders lists the providers whose agents get the SelfHosted presets.
+	SelfHostedProviders []string `json:"selfHostedProviders,omitempty"`
+	// Hosted are the presets of agents of hosted providers, keyed by size.
+	Hosted map[string]corev1.ResourceRequirements `json:"hosted,omitempty"`
+	// SelfHosted are the presets of agents of self-hosted providers, keyed by size.
+	SelfHosted map[string]corev1.ResourceRequirements `json:"selfHosted,omitempty"`
+}
+
+//go:embed presets.yaml
+var defaultPresetsYAML []byte
+
+var (
+	defaultPresets = mustParse(defaultPresetsYAML)
+
+	mu      sync.RWMutex
+	current = defaultPresets
+)
+
+// Parse reads presets in the YAML format of presets.yaml. Unknown fields and sizes are
+// rejected, as are presets whose requests exceed their limits.
+func Parse(data []byte) (*Presets, error) {
+	presets := &Presets{}
+	if err := yaml.UnmarshalStrict(data, presets); err != nil {
+		return nil, fmt.Errorf("invalid size presets: %w", err)
+	}
+	for class, sizes := range map[string]map[string]corev1.ResourceRequirements{"hosted": presets.Hosted, "selfHosted": presets.SelfHosted} {
+		for size, resources := range sizes {
+			if !validSize(size) {
+				return nil, fmt.Errorf("invalid size presets: unknown size %q of %s, must be one of %v", size, class, Sizes)
+			}
+			for name, limit := range resources.Limits {
+				if request, ok := resources.Requests[name]; ok && request.Cmp(limit) > 0 {
+					return nil, fmt.Errorf("invalid size presets: %s request of %s size %s exceeds its limit", name, class, size)
+				}
+			}
+		}
+	}
+	return presets, nil
+}
+
+func mustParse(data []byte) *Presets {
+	presets, err := Parse(data)
+	if err != nil {
+		panic(err)
+	}
+	return presets
+}
+
+func validSize(size string) bool {
+	for _, s := range Sizes {
+		if size == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Default returns the presets embedded in the operator.
+func Default() *Presets {
+	return defaultPresets
+}
+
+// Merge returns the default presets with the sizes of overrides replacing theirs.
+func Merge(overrides *Presets) *Presets {
+	merged := &Presets{
+		SelfHostedProviders: defaultPresets.SelfHostedProviders,
+		Hosted:              map[string]corev1.ResourceRequirements{},
+		SelfHosted:          map[string]corev1.ResourceRequirements{},
+	}
+	for size, resources := range defaultPresets.Hosted {
+		merged.Hosted[size] = resources
+	}
+	for size, resources := range defaultPresets.SelfHosted {
+		merged.SelfHosted[size] = resources
+	}
+	if overrides == nil {
+		return merged
+	}
+	if len(overrides.SelfHostedProviders) > 0 {
+		merged.SelfHostedProviders = overrides.SelfHostedProviders
+	}
+	for size, resources := range overrides.Hosted {
+		merged.Hosted[size] = resources
+	}
+	for size, resources := range overrides.SelfHosted {
+		merged.SelfHosted[size] = resources
+	}
+	return merged
+}
+
+// Set makes presets the ones used by For and IsPreset. Nil presets restore the default.
+func Set(presets *Presets) {
+	if presets == nil {
+		presets = defaultPresets
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	current = presets
+}
+
+// Current returns the presets used by For and IsPreset.
+func Current() *Presets {
+	mu.RLock()
+	defer mu.RUnlock()
+	return current
+}
+
+// For returns the resource requirements of size for an agent of provider.
+func (p *Presets) For(provider, size string) (corev1.ResourceRequirements, bool) {
+	presets := p.Hosted
+	for _, selfHosted := range p.SelfHostedProviders {
+		if provider == selfHosted {
+			presets = p.SelfHosted
+			break
+		}
+	}
+	resources, ok := presets[size]
+	if !ok {
+		return corev1.ResourceRequirements{}, false
+	}
+	return *resources.DeepCopy(), true
+}
+
+// IsPreset reports whether resources are those of a preset, of any size and provider.
+func (p *Presets) IsPreset(resources *corev1.ResourceRequirements) bool {
+	for _, presets := range []map[string]corev1.ResourceRequirements{p.Hosted, p.SelfHosted} {
+		for _, preset := range presets {
+			if equality.Semantic.DeepEqual(preset, *resources) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// For returns the resource requirements of size for an agent of provider with the
+// current presets.
+func For(provider, size string) (corev1.ResourceRequirements, bool) {
+	return Current().For(provider, size)
+}
+
+// IsPreset reports whether resources are those of a preset of the current or the default
+// presets, that is whether they were set from spec.size rather than by the user.
+func IsPreset(resources *corev1.ResourceRequirements) bool {
+	return Current().IsPreset(resources) || defaultPresets.IsPreset(resources)
+}
+
+// Conflicts reports whether resources were set by the user together with size, that is
+// whether they are set and differ from the preset of size for provider.
+func Conflicts(provider, size string, resources *corev1.ResourceRequirements) bool {
+	preset, ok := For(provider, size)
+	return ok && resources != nil && !equality.Semantic.DeepEqual(preset, *resources)
+}
@@ -0,0 +1,342 @@
+// Package slo generates the Prometheus rules of the service level objectives of an agent,
+// and reads the error budgets they record.
+//
+// An agent with spec.slo has an availability SLO, the percentage of its chat requests that
+// succeed over the SLO window, and optionally a latency SLO, the percentage of its chat
+// requests answered within the latency target. For each, recording rules compute the ratio
+// of bad requests over the windows of the alerts and the error budget left over the SLO
+// window, and multiwindow, multi-burn-rate alerts fire when the budget is consumed too fast:
+// the fast alerts page when 2% of the budget is consumed in an hour or 5% in 6 hours, the
+// slow ones open a ticket when 10% is consumed in a day or in 3 days.
+package slo
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultWindow is the SLO window of the agents that do not set it.
+	DefaultWindow = 30 * 24 * time.Hour
+	// MinWindow and MaxWindow bound the SLO window, which must cover the longest alert
+	// window of 3 days.
+	MinWindow = 7 * 24 * time.Hour
+	MaxWindow = 90 * 24 * time.Hour
+
+	// AvailabilityBudgetMetric and LatencyBudgetMetric record the fraction of the error
+	// budgets left over the SLO window, negative once exhausted.
+	AvailabilityBudgetMetric = "kubeagentic:slo_availability_budget_remaining:ratio"
+	LatencyBudgetMetric      = "kubeagentic:slo_latency_budget_remaining:ratio"
+
+	requestsMetric = "kubeagentic_requests_total"
+	errorsMetric   = "kubeagentic_errors_total"
+	durationMetric = "kubeagentic_response_duration_seconds"
+)
+
+// Objectives are the service level objectives of an agent.
+type Objectives struct {
+	Agent     string
+	Namespace string
+	// Target is the percentage of good requests, such as "99.9".
+	Target string
+	// LatencyTarget is the duration within which requests are good; no latency SLO when 0.
+	LatencyTarget time.Duration
+	// Window is the SLO window; DefaultWindow when 0.
+	Window time.Duration
+}
+
+// Rule is a recording or alerting rule of a PrometheusRule.
+type Rule struct {
+	Record      string            `json:"record,omitempty"`
+	Alert       string            `json:"alert,omitempty"`
+	Expr        string            `json:"expr"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// Group is a rule group of a PrometheusRule.
+type Group struct {
+	Name  string `json:"name"`
+	Rules []Rule `json:"rules"`
+}
+
+// burnAlert fires when the budget fraction is consumed within the long window, and still
+// is within the short window, so that the alert resets soon after the burn stops.
+type burnAlert struct {
+	long, short time.Duration
+	budget      *big.Rat
+}
+
+var (
+	fastBurn = []burnAlert{
+		{long: time.Hour, short: 5 * time.Minute, budget: big.NewRat(2, 100)},
+		{long: 6 * time.Hour, short: 30 * time.Minute, budget: big.NewRat(5, 100)},
+	}
+	slowBurn = []burnAlert{
+		{long: 24 * time.Hour, short: 2 * time.Hour, budget: big.NewRat(10, 100)},
+		{long: 72 * time.Hour, short: 6 * time.Hour, budget: big.NewRat(10, 100)},
+	}
+)
+
+// ParseTarget returns the error budget of a target percentage, the fraction of requests
+// that may be bad, such as 1/1000 for "99.9".
+func ParseTarget(target string) (*big.Rat, error) {
+	percent, ok := new(big.Rat).SetString(target)
+	if !ok || strings.ContainsAny(target, "/eE") {
+		return nil, fmt.Errorf("invalid target %q, expected a percentage such as 99.9", target)
+	}
+	if percent.Sign() <= 0 || percent.Cmp(big.NewRat(100, 1)) >= 0 {
+		return nil, fmt.Errorf("target %s must be above 0 and below 100", target)
+	}
+	budget := new(big.Rat).Sub(big.NewRat(100, 1), percent)
+	return budget.Quo(budget, big.NewRat(100, 1)), nil
+}
+
+// ValidateWindow checks that window is between MinWindow and MaxWindow.
+func ValidateWindow(window time.Duration) error {
+	if window < MinWindow || window > MaxWindow {
+		return fmt.Errorf("window %s must be between %s and %s", window, MinWindow, MaxWindow)
+	}
+	return nil
+}
+
+// LatencyBucket returns the le label of the latency target, as the agent runtime formats the
+// bucket it adds to its duration histogram for it.
+func LatencyBucket(target time.Duration) string {
+	seconds := strconv.FormatFloat(target.Seconds(), 'f', -1, 64)
+	if !strings.Contains(seconds, ".") {
+		seconds += ".0"
+	}
+	return seconds
+}
+
+// Duration formats d as a Prometheus duration, such as 5m or 30d.
+func Duration(d time.Duration) string {
+	switch {
+	case d%(24*time.Hour) == 0:
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return fmt.Sprintf("%ds", d/time.Second)
+	}
+}
+
+// objective is an SLO of an agent, with the expression of its ratio of bad requests.
+type objective struct {
+	name        string
+	title       string
+	description string
+	badRatio    func(window string) string
+}
+
+// Groups returns the recording rules and the burn-rate alerts of the objectives.
+func Groups(o Objectives) ([]Group, error) {
+	budget, err := ParseTarget(o.Target)
+	if err != nil {
+		return nil, err
+	}
+	window := o.Window
+	if window == 0 {
+		window = DefaultWindow
+	}
+	if err := ValidateWindow(window); err != nil {
+		return nil, err
+	}
+
+	selector := fmt.Sprintf(`agent=%q,namespace=%q`, o.Agent, o.Namespace)
+	sum := func(metric, extra, rangeFunc, window string) string {
+		return fmt.Sprintf("sum by (namespace, agent) (%s(%s{%s%s}[%s]))", rangeFunc, metric, selector, extra, window)
+	}
+	objectives := []objective{{
+		name:        "availability",
+		title:       "Availability",
+		description: fmt.Sprintf("%s%% of the chat requests succeed", o.Target),
+		badRatio: func(window string) string {
+			return sum(errorsMetric, "", "rate", window) + " / " + sum(requestsMetric, "", "rate", window)
+		},
+	}}
+	if o.LatencyTarget > 0 {
+		le := fmt.Sprintf(`,le=%q`, LatencyBucket(o.LatencyTarget))
+		objectives = append(objectives, objective{
+			name:        "latency",
+			title:       "Latency",
+			description: fmt.Sprintf("%s%% of the chat requests are answered within %s", o.Target, o.LatencyTarget),
+			badRatio: func(window string) string {
+				return "1 - " + sum(durationMetric+"_bucket", le, "rate", window) + " / " + sum(durationMetric+"_count", "", "rate", window)
+			},
+		})
+	}
+
+	budgetValue := formatRat(budget)
+	windows := []time.Duration{}
+	for _, alert := range append(append([]burnAlert{}, fastBurn...), slowBurn...) {
+		windows = appendWindow(windows, alert.short)
+		windows = appendWindow(windows, alert.long)
+	}
+
+	recording := Group{Name: "kubeagentic-slo-recording"}
+	alerting := Group{Name: "kubeagentic-slo-alerts"}
+	for _, obj := range objectives {
+		ratio := func(window time.Duration) string {
+			return fmt.Sprintf("kubeagentic:slo_%s_errors:ratio_rate%s", obj.name, Duration(window))
+		}
+		for _, w := range windows {
+			recording.Rules = append(recording.Rules, Rule{Record: ratio(w), Expr: obj.badRatio(Duration(w))})
+		}
+		budgetMetric := AvailabilityBudgetMetric
+		if obj.name == "latency" {
+			budgetMetric = LatencyBudgetMetric
+		}
+		recording.Rules = append(recording.Rules, Rule{
+			Record: budgetMetric,
+			Expr:   fmt.Sprintf("1 - (%s) / %s", strings.ReplaceAll(obj.badRatio(Duration(window)), "rate(", "increase("), budgetValue),
+		})
+
+		for _, speed := range []struct {
+			name     string
+			severity string
+			alerts   []burnAlert
+		}{{"Fast", "critical", fastBurn}, {"Slow", "warning", slowBurn}} {
+			var conditions, rates []string
+			for _, alert := range speed.alerts {
+				factor := burnFactor(alert, window)
+				threshold := fmt.Sprintf("(%s * %s)", factor, budgetValue)
+				conditions = append(conditions, fmt.Sprintf("(%s{%s} > %s and %s{%s} > %s)",
+					ratio(alert.long), selector, threshold, ratio(alert.short), selector, threshold))
+				consumed := new(big.Rat).Mul(alert.budget, big.NewRat(100, 1))
+				rates = append(rates, fmt.Sprintf("%s%% of the budget within %s", formatRat(consumed), Duration(alert.long)))
+			}
+			alerting.Rules = append(alerting.Rules, Rule{
+				Alert: fmt.Sprintf("KubeAgentic%sBudgetBurn%s", obj.title, speed.name),
+				Expr:  strings.Join(conditions, " or "),
+				Labels: map[string]string{
+					"severity": speed.severity,
+					"slo":      obj.name,
+				},
+				Annotations: map[string]string{
+					"summary": fmt.Sprintf("Agent %s/%s burns the error budget of its %s SLO %s", o.Namespace, o.Agent, obj.name, strings.ToLower(speed.name)),
+					"description": fmt.Sprintf("The SLO of agent %s/%s is that %s over %s. Its bad requests consume %s.",
+						o.Namespace, o.Agent, obj.description, Duration(window), strings.Join(rates, ", or ")),
+				},
+			})
+		}
+	}
+	return []Group{recording, alerting}, nil
+}
+
+// burnFactor returns how many times faster than sustainable over window the budget of the
+// alert is consumed within its long window.
+func burnFactor(alert burnAlert, window time.Duration) string {
+	factor := new(big.Rat).Mul(alert.budget, big.NewRat(int64(window/time.Minute), int64(alert.long/time.Minute)))
+	return formatRat(factor)
+}
+
+// formatRat formats r as a decimal number, rounded to 6 decimals.
+func formatRat(r *big.Rat) string {
+	value := strings.TrimRight(r.FloatString(6), "0")
+	return strings.TrimSuffix(value, ".")
+}
+
+func appendWindow(windows []time.Duration, window time.Duration) []time.Duration {
+	for i, w := range windows {
+		if w == window {
+			return windows
+		}
+		if w > window {
+			return append(windows[:i], append([]time.Duration{window}, windows[i:]...)...)
+		}
+	}
+	return append(windows, window)
+}
+
+// Budgets are the fractions of the error budgets left over the SLO window, nil when Prometheus
+// has no data for them yet.
+type Budgets struct {
+	Availability *float64
+	Latency      *float64
+}
+
+// Querier reads the error budgets recorded by the rules from the Prometheus HTTP API.
+type Querier struct {
+	// Client is the HTTP client used for the requests. http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+// queryResponse is the response of the instant queries of the Prometheus HTTP API.
+type queryResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error"`
+	Data   struct {
+		ResultType string `json:"resultType"`
+		Result     []struct {
+			Value [2]any `json:"value"`
+		} `json:"result"`
+	} `json:"data"`
+}
+
+// Budgets queries the error budgets of an agent from the Prometheus at prometheusURL.
+func (q *Querier) Budgets(ctx context.Context, prometheusURL, namespace, agent string) (Budgets, error) {
+	var budgets Budgets
+	var err error
+	if budgets.Availability, err = q.query(ctx, prometheusURL, AvailabilityBudgetMetric, namespace, agent); err != nil {
+		return Budgets{}, err
+	}
+	if budgets.Latency, err = q.query(ctx, prometheusURL, LatencyBudgetMetric, namespace, agent); err != nil {
+		return Budgets{}, err
+	}
+	return budgets, nil
+}
+
+func (q *Querier) query(ctx context.Context, prometheusURL, metric, namespace, agent string) (*float64, error) {
+	endpoint := strings.TrimSuffix(prometheusURL, "/") + "/api/v1/query"
+	query := fmt.Sprintf(`%s{agent=%q,namespace=%q}`, metric, agent, namespace)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	client := q.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result queryResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("querying %s: unexpected response %s: %w", endpoint, resp.Status, err)
+	}
+	if resp.StatusCode != http.StatusOK || result.Status != "success" {
+		return nil, fmt.Errorf("querying %s: %s %s", endpoint, resp.Status, result.Error)
+	}
+	if result.Data.ResultType != "vector" || len(result.Data.Result) == 0 {
+		return nil, nil
+	}
+	raw, ok := result.Data.Result[0].Value[1].(string)
+	if !ok {
+		return nil, fmt.Errorf("querying %s: unexpected value %v", endpoint, result.Data.Result[0].Value[1])
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("querying %s: %w", endpoint, err)
+	}
+	// The ratios are NaN while the agent served no requests over the window
+	if math.IsNaN(value) {
+		return nil, nil
+	}
+	return &value, nil
+}
@@ -0,0 +1,81 @@
+// Package tokenquota tracks the tokens an agent consumes per day against its quota.
+//
+// The agent pods expose cumulative token counters. The tracker charges each pod for the
+// increase of its counters since the previous observation, and a pod that is new or whose
+// counters went down, because it restarted, for all its tokens. The consumption is reset
+// at midnight in the time zone of the quota.
+package tokenquota
+
+import (
+	"time"
+
+	"k8s.io/utils/clock"
+)
+
+// Counters are the cumulative token counters of the agent pods, keyed by pod name.
+type Counters map[string]int64
+
+// Usage is the consumption of a quota day.
+type Usage struct {
+	// DayStart is the midnight starting the quota day.
+	DayStart time.Time
+	// Used is the number of tokens consumed since DayStart.
+	Used int64
+	// Counters are the pod counters seen last.
+	Counters Counters
+}
+
+// Tracker computes the consumption of an agent against its daily limit.
+type Tracker struct {
+	// Limit is the number of tokens allowed per day.
+	Limit int64
+	// Location is the time zone whose midnight resets the quota.
+	Location *time.Location
+	// Clock tells the current time.
+	Clock clock.PassiveClock
+}
+
+// Day returns the start and end of the current quota day.
+func (t *Tracker) Day() (time.Time, time.Time) {
+	now := t.Clock.Now().In(t.Location)
+	start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, t.Location)
+	return start, start.AddDate(0, 0, 1)
+}
+
+// Observe adds the tokens consumed since last to the usage of the current day. Without a
+// previous usage, the counters are only recorded, so tokens are counted from the first
+// observation.
+func (t *Tracker) Observe(last *Usage, counters Counters) *Usage {
+	start, _ := t.Day()
+	usage := &Usage{DayStart: start, Counters: counters}
+	if last == nil {
+		return usage
+	}
+	if last.DayStart.Equal(start) {
+		usage.Used = last.Used
+	}
+	for pod, tokens := range counters {
+		seen, ok := last.Counters[pod]
+		if !ok || tokens < seen {
+			seen = 0
+		}
+		usage.Used += tokens - seen
+	}
+	return usage
+}
+
+// Remaining returns the number of tokens left in the day of usage.
+func (t *Tracker) Remaining(usage *Usage) int64 {
+	if start, _ := t.Day(); usage == nil || !usage.DayStart.Equal(start) {
+		return t.Limit
+	}
+	if usage.Used >= t.Limit {
+		return 0
+	}
+	return t.Limit - usage.Used
+}
+
+// Exhausted reports whether usage has reached the limit of the current day.
+func (t *Tracker) Exhausted(usage *Usage) bool {
+	return t.Remaining(usage) == 0
+}
@@ -0,0 +1,275 @@
+// Package toolexec runs the commands of the agent tools in the executor sidecar.
+//
+// The operator renders spec.toolExecutor into a JSON file, read by the executor sidecar of
+// the agent pods. The executor serves an execution API on the loopback interface only, so
+// that it is reachable from the agent container of the pod and nothing else, and runs the
+// requested command without a shell in the workspace shared with the agent container.
+// Commands that are not in the allowlist of the configuration are rejected. Every command
+// that runs leaves an audit record, from which the redaction rules of the configuration
+// remove personal data.
+package toolexec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/redaction"
+)
+
+const (
+	// DefaultTimeout bounds the commands when the configuration sets no timeout.
+	DefaultTimeout = time.Minute
+	// maxOutputBytes bounds the output of a command kept for the response, per stream.
+	maxOutputBytes = 1 << 20
+	// maxRequestBytes bounds the body of an execution request.
+	maxRequestBytes = 1 << 20
+)
+
+// ErrNotAllowed is returned for commands that are not in the allowlist.
+var ErrNotAllowed = errors.New("command not allowed")
+
+// Config is the configuration of the executor.
+type Config struct {
+	// AllowedCommands are the commands that may run, by name or absolute path.
+	AllowedCommands []string `json:"allowedCommands"`
+	// Workspace is the directory commands run in.
+	Workspace string `json:"workspace"`
+	// Timeout is a duration such as "1m0s".
+	Timeout string `json:"timeout"`
+	// Redaction are the rules applied to the audit records.
+	Redaction []redaction.Rule `json:"redaction,omitempty"`
+}
+
+// ParseConfig returns the configuration in data.
+func ParseConfig(data []byte) (Config, error) {
+	var config Config
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("invalid tool executor configuration: %w", err)
+	}
+	if len(config.AllowedCommands) == 0 {
+		return Config{}, fmt.Errorf("invalid tool executor configuration: no allowed commands")
+	}
+	if config.Workspace == "" || !filepath.IsAbs(config.Workspace) {
+		return Config{}, fmt.Errorf("invalid tool executor configuration: the workspace must be an absolute path")
+	}
+	if config.Timeout != "" {
+		timeout, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return Config{}, fmt.Errorf("invalid tool executor configuration: %w", err)
+		}
+		if timeout <= 0 {
+			return Config{}, fmt.Errorf("invalid tool executor configuration: the timeout must be positive")
+		}
+	}
+	if _, err := redaction.New(config.Redaction); err != nil {
+		return Config{}, fmt.Errorf("invalid tool executor configuration: %w", err)
+	}
+	return config, nil
+}
+
+// Request is a command the runtime asks to run.
+type Request struct {
+	Command string   `json:"command"`
+	Args    []string `json:"args,omitempty"`
+	// Dir is the directory the command runs in, relative to the workspace.
+	Dir string `json:"dir,omitempty"`
+	// Stdin is written to the standard input of the command.
+	Stdin string `json:"stdin,omitempty"`
+}
+
+// Result is the outcome of a command.
+type Result struct {
+	ExitCode int    `json:"exitCode"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+	// TimedOut reports that the command was killed at the timeout.
+	TimedOut bool `json:"timedOut,omitempty"`
+	// Truncated reports that the output exceeded the size kept for the response.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// AuditRecord is written for every command that runs, or is rejected.
+type AuditRecord struct {
+	Time     time.Time `json:"time"`
+	Command  string    `json:"command"`
+	Args     []string  `json:"args,omitempty"`
+	Dir      string    `json:"dir,omitempty"`
+	ExitCode int       `json:"exitCode"`
+	TimedOut bool      `json:"timedOut,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Executor runs the allowed commands of its configuration. It serves POST /execute, taking
+// a Request and returning a Result, and GET /healthz.
+type Executor struct {
+	config   Config
+	allowed  map[string]bool
+	timeout  time.Duration
+	redactor *redaction.Redactor
+	audit    io.Writer
+}
+
+// NewExecutor returns an executor for config, which must have been parsed by ParseConfig.
+// It writes its audit records to audit, one JSON object per line, unless audit is nil.
+func NewExecutor(config Config, audit io.Writer) *Executor {
+	executor := &Executor{config: config, allowed: map[string]bool{}, timeout: DefaultTimeout, audit: audit}
+	// ParseConfig checked the rules
+	executor.redactor, _ = redaction.New(config.Redaction)
+	for _, command := range config.AllowedCommands {
+		executor.allowed[command] = true
+	}
+	if timeout, err := time.ParseDuration(config.Timeout); err == nil && timeout > 0 {
+		executor.timeout = timeout
+	}
+	return executor
+}
+
+// Allowed reports whether command may run. Commands are matched exactly, so that an
+// allowed name does not allow a binary of the same name elsewhere.
+func (e *Executor) Allowed(command string) bool {
+	return e.allowed[command]
+}
+
+// workDir returns the directory of the request, which must stay within the workspace.
+func (e *Executor) workDir(dir string) (string, error) {
+	if dir == "" {
+		return e.config.Workspace, nil
+	}
+	if filepath.IsAbs(dir) {
+		return "", fmt.Errorf("dir must be relative to the workspace")
+	}
+	path := filepath.Join(e.config.Workspace, dir)
+	if path != e.config.Workspace && !strings.HasPrefix(path, e.config.Workspace+string(filepath.Separator)) {
+		return "", fmt.Errorf("dir must stay within the workspace")
+	}
+	return path, nil
+}
+
+// Run runs the command of request and audits it. A command that runs and fails returns a
+// Result with its exit code and no error.
+func (e *Executor) Run(ctx context.Context, request Request) (Result, error) {
+	result, err := e.run(ctx, request)
+	e.record(request, result, err)
+	return result, err
+}
+
+// record writes the audit record of request, redacted.
+func (e *Executor) record(request Request, result Result, err error) {
+	if e.audit == nil {
+		return
+	}
+	record := AuditRecord{
+		Time:     time.Now().UTC(),
+		Command:  e.redactor.Redact(request.Command),
+		Dir:      e.redactor.Redact(request.Dir),
+		ExitCode: result.ExitCode,
+		TimedOut: result.TimedOut,
+	}
+	for _, arg := range request.Args {
+		record.Args = append(record.Args, e.redactor.Redact(arg))
+	}
+	if err != nil {
+		record.Error = e.redactor.Redact(err.Error())
+	}
+	data, _ := json.Marshal(record)
+	_, _ = e.audit.Write(append(data, '\n'))
+}
+
+func (e *Executor) run(ctx context.Context, request Request) (Result, error) {
+	if !e.Allowed(request.Command) {
+		return Result{}, fmt.Errorf("%w: %s", ErrNotAllowed, request.Command)
+	}
+	dir, err := e.workDir(request.Dir)
+	if err != nil {
+		return Result{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, request.Command, request.Args...)
+	cmd.Dir = dir
+	cmd.Stdin = strings.NewReader(request.Stdin)
+	stdout := &limitedBuffer{limit: maxOutputBytes}
+	stderr := &limitedBuffer{limit: maxOutputBytes}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+
+	err = cmd.Run()
+	result := Result{
+		Stdout:    stdout.String(),
+		Stderr:    stderr.String(),
+		Truncated: stdout.truncated || stderr.truncated,
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		result.ExitCode = -1
+		result.TimedOut = true
+	case errors.As(err, &exitErr):
+		result.ExitCode = exitErr.ExitCode()
+	case err != nil:
+		return Result{}, err
+	}
+	return result, nil
+}
+
+// ServeHTTP serves the execution API.
+func (e *Executor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/healthz":
+		w.WriteHeader(http.StatusOK)
+		return
+	case "/execute":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var request Request
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRequestBytes)).Decode(&request); err != nil {
+		http.Error(w, fmt.Sprintf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	result, err := e.Run(r.Context(), request)
+	if errors.Is(err, ErrNotAllowed) {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(result)
+}
+
+// limitedBuffer keeps the first limit bytes written to it and discards the rest.
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); len(p) > room {
+		b.truncated = true
+		if room > 0 {
+			b.Buffer.Write(p[:room])
+		}
+		return len(p), nil
+	}
+	return b.Buffer.Write(p)
+}
@@ -0,0 +1,163 @@
+// Package transcriptsink pushes the transcripts of an agent to an HTTPS endpoint.
+//
+// Agents with spec.transcriptSink post every completed exchange of their conversations, in
+// batches, to the URL of the sink. Each request is signed with a secret shared with the
+// sink, in the SignatureHeader, so that the sink can reject requests it did not get from
+// the agent. The operator posts a signed test request when the sink is configured and
+// periodically afterwards, and reports its outcome on the TranscriptSinkReachable condition.
+package transcriptsink
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader signs the requests to the sink: t=<unix time>,v1=<signature>, the
+	// signature being the hex HMAC-SHA256 of "<unix time>.<body>" with the shared secret.
+	SignatureHeader = "X-KubeAgentic-Signature"
+	// EventHeader tells the test requests of the operator from the transcripts of the agent.
+	EventHeader = "X-KubeAgentic-Event"
+
+	// EventTest is the event of the test requests, which carry no transcripts.
+	EventTest = "test"
+	// EventTranscripts is the event of the batches of transcripts.
+	EventTranscripts = "transcripts"
+
+	// DefaultBatchSize is the number of transcripts posted at once when the agent does not
+	// set it.
+	DefaultBatchSize int32 = 20
+	// MaxBatchSize bounds the transcripts posted at once.
+	MaxBatchSize int32 = 1000
+	// DefaultMaxWait is how long a transcript waits for its batch to fill when the agent
+	// does not set it.
+	DefaultMaxWait = 10 * time.Second
+	// DefaultFailureThreshold is the number of consecutive batches a pod fails to deliver
+	// before the sink is reported failing, when the agent does not set it.
+	DefaultFailureThreshold int32 = 3
+
+	// timeout bounds the test requests.
+	timeout = 10 * time.Second
+)
+
+// Config is the configuration of the sink rendered for the agent runtime. The shared
+// secret is passed apart, from its Secret.
+type Config struct {
+	URL             string  `json:"url"`
+	BatchSize       int32   `json:"batchSize"`
+	MaxWaitSeconds  float64 `json:"maxWaitSeconds"`
+	IncludeMetadata bool    `json:"includeMetadata"`
+}
+
+// ValidateURL checks that raw is an HTTPS URL with a host and without credentials, which
+// belong in the shared secret.
+func ValidateURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid URL: %w", err)
+	}
+	if u.Scheme != "https" {
+		return fmt.Errorf("the URL must use https, not %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return fmt.Errorf("the URL must have a host")
+	}
+	if u.User != nil {
+		return fmt.Errorf("the URL must not contain credentials")
+	}
+	return nil
+}
+
+// Sign returns the value of the SignatureHeader of body sent at timestamp.
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unix + "."))
+	mac.Write(body)
+	return "t=" + unix + ",v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks the SignatureHeader of body received at now, rejecting signatures older
+// than tolerance so that recorded requests cannot be replayed.
+func Verify(secret []byte, header string, body []byte, now time.Time, tolerance time.Duration) error {
+	var unix, signature string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			signature = value
+		}
+	}
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || signature == "" {
+		return fmt.Errorf("malformed signature %q", header)
+	}
+	timestamp := time.Unix(seconds, 0)
+	if age := now.Sub(timestamp); age > tolerance || age < -tolerance {
+		return fmt.Errorf("signature timestamp %s is outside the tolerance of %s", timestamp.UTC().Format(time.RFC3339), tolerance)
+	}
+	if !hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte("t="+unix+",v1="+signature)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// Payload is the body of the requests to the sink.
+type Payload struct {
+	Type        string            `json:"type"`
+	Agent       string            `json:"agent"`
+	Namespace   string            `json:"namespace"`
+	SentAt      time.Time         `json:"sentAt"`
+	Transcripts []json.RawMessage `json:"transcripts"`
+}
+
+// Checker posts signed test requests to sinks.
+type Checker struct {
+	// Client is the HTTP client used for the requests. http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+// Test posts a signed test request, without transcripts, on behalf of the agent to the
+// sink at rawURL. Any status other than 2xx fails the test.
+func (c *Checker) Test(ctx context.Context, rawURL string, secret []byte, agent, namespace string, now time.Time) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	body, err := json.Marshal(Payload{Type: EventTest, Agent: agent, Namespace: namespace, SentAt: now.UTC(), Transcripts: []json.RawMessage{}})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, EventTest)
+	req.Header.Set(SignatureHeader, Sign(secret, now, body))
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("the sink answered %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+// Package warmup reads the warm-up state reported by the agent runtime.
+//
+// Agent pods with warm-up enabled send a number of chat requests after starting and only
+// report ready once they are done. The runtime exposes the outcome on /warmup, which the
+// operator reads to report pods still warming up and warm-up failures.
+package warmup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// States reported by the agent runtime.
+const (
+	// StateWarming means the pod is still sending warm-up requests.
+	StateWarming = "warming"
+	// StateWarm means all warm-up requests succeeded.
+	StateWarm = "warm"
+	// StateFailed means a warm-up request failed or the warm-up timed out. The pod reports
+	// ready anyway.
+	StateFailed = "failed"
+	// StateDisabled means the pod runs without warm-up.
+	StateDisabled = "disabled"
+)
+
+// Status is the warm-up state of an agent pod.
+type Status struct {
+	// State is one of the states above.
+	State string `json:"state"`
+	// Completed is the number of warm-up requests that succeeded.
+	Completed int `json:"completed"`
+	// Error describes why the warm-up failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Checker fetches the warm-up state from the /warmup endpoint of agent pods.
+type Checker struct {
+	// Client is the HTTP client used for the requests. http.DefaultClient is used when nil.
+	Client *http.Client
+}
+
+// Check fetches the warm-up state of the agent runtime at baseURL, sending token as a bearer
+// token when it is set.
+func (c *Checker) Check(ctx context.Context, baseURL, token string) (Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/warmup", nil)
+	if err != nil {
+		return Status{}, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := c.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Status{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return Status{}, fmt.Errorf("checking %s/warmup: unexpected status %s", baseURL, resp.Status)
+	}
+
+	var status Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return Status{}, fmt.Errorf("checking %s/warmup: %w", baseURL, err)
+	}
+	return status, nil
+}
@@ -0,0 +1,514 @@
+// Package webhookcert generates and rotates the serving certificate of the operator's
+// admission webhooks, so that they can be installed without cert-manager.
+//
+// The CA and serving certificate are kept in a Secret shared by all operator replicas.
+// Every replica writes the serving certificate from the Secret into the webhook server's
+// certificate directory, where it is reloaded on change. Only the leader renews the
+// certificates and patches the CA bundle into the webhook configurations.
+package webhookcert
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+)
+
+const (
+	// CACertKey is the Secret key holding the PEM bundle of trusted CA certificates,
+	// the active CA first.
+	CACertKey = "ca.crt"
+	// CAKeyKey is the Secret key holding the private key of the active CA.
+	CAKeyKey = "ca.key"
+)
+
+// Options configures certificate management.
+type Options struct {
+	// SecretName and Namespace locate the Secret holding the certificates.
+	SecretName string
+	Namespace  string
+	// ServiceName is the webhook Service the serving certificate is issued for.
+	ServiceName string
+	// ExtraServiceNames are further Services in Namespace the serving certificate is
+	// valid for, such as the metrics Service.
+	ExtraServiceNames []string
+	// CertDir is the certificate directory of the webhook server.
+	CertDir string
+	// ValidatingWebhooks and MutatingWebhooks name the webhook configurations whose
+	// caBundle is kept in sync.
+	ValidatingWebhooks []string
+	MutatingWebhooks   []string
+	// CAValidity and CertValidity are the lifetimes of newly generated certificates.
+	CAValidity   time.Duration
+	CertValidity time.Duration
+	// RotateBefore renews a certificate when it expires within this duration.
+	RotateBefore time.Duration
+	// CheckInterval is how often certificates are checked for renewal.
+	CheckInterval time.Duration
+}
+
+// Manager bootstraps and rotates the webhook certificates.
+//
+// +kubebuilder:rbac:groups=admissionregistration.k8s.io,resources=validatingwebhookconfigurations;mutatingwebhookconfigurations,verbs=get;list;watch;update;patch
+type Manager struct {
+	Client  client.Client
+	Options Options
+	// Now returns the current time; tests replace it to simulate expiry.
+	Now func() time.Time
+}
+
+// New returns a Manager, filling unset validity and interval options with defaults.
+func New(c client.Client, opts Options) *Manager {
+	if opts.CAValidity == 0 {
+		opts.CAValidity = 10 * 365 * 24 * time.Hour
+	}
+	if opts.CertValidity == 0 {
+		opts.CertValidity = 365 * 24 * time.Hour
+	}
+	if opts.RotateBefore == 0 {
+		opts.RotateBefore = 30 * 24 * time.Hour
+	}
+	if opts.CheckInterval == 0 {
+		opts.CheckInterval = time.Hour
+	}
+	return &Manager{Client: c, Options: opts, Now: time.Now}
+}
+
+// Bootstrap creates the certificate Secret if it does not exist yet and writes the serving
+// certificate into the certificate directory. It runs before the webhook server starts
+// and never renews existing certificates, which is left to the leader.
+func (m *Manager) Bootstrap(ctx context.Context) error {
+	secret, err := m.ensureSecret(ctx, false)
+	if err != nil {
+		return err
+	}
+	return m.writeCertDir(secret)
+}
+
+// Rotate renews certificates that are about to expire, patches the CA bundle into the
+// webhook configurations and writes the serving certificate into the certificate directory.
+func (m *Manager) Rotate(ctx context.Context) error {
+	secret, err := m.ensureSecret(ctx, true)
+	if err != nil {
+		return err
+	}
+	if err := m.patchCABundle(ctx, secret.Data[CACertKey]); err != nil {
+		return err
+	}
+	return m.writeCertDir(secret)
+}
+
+// Sync writes the serving certificate from the Secret into the certificate directory.
+func (m *Manager) Sync(ctx context.Context) error {
+	secret := &corev1.Secret{}
+	if err := m.Client.Get(ctx, m.secretKey(), secret); err != nil {
+		return err
+	}
+	return m.writeCertDir(secret)
+}
+
+// Rotator returns a leader-elected runnable that calls Rotate periodically.
+func (m *Manager) Rotator() manager.Runnable {
+	return &periodic{interval: m.Options.CheckInterval, leaderOnly: true, fn: m.Rotate, name: "rotate"}
+}
+
+// Syncer returns a runnable that calls Sync periodically on every replica, so that
+// followers pick up certificates renewed by the leader.
+func (m *Manager) Syncer() manager.Runnable {
+	interval := m.Options.CheckInterval / 4
+	if interval < time.Minute {
+		interval = time.Minute
+	}
+	return &periodic{interval: interval, fn: m.Sync, name: "sync"}
+}
+
+// periodic runs fn immediately and then on every tick until the context is cancelled.
+type periodic struct {
+	interval   time.Duration
+	leaderOnly bool
+	fn         func(context.Context) error
+	name       string
+}
+
+func (p *periodic) Start(ctx context.Context) error {
+	logger := log.FromContext(ctx).WithName("webhook-cert")
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		if err := p.fn(ctx); err != nil {
+			logger.Error(err, "Failed to "+p.name+" webhook certificates")
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (p *periodic) NeedLeaderElection() bool {
+	return p.leaderOnly
+}
+
+func (m *Manager) secretKey() types.NamespacedName {
+	return types.NamespacedName{Name: m.Options.SecretName, Namespace: m.Options.Namespace}
+}
+
+// ensureSecret returns the certificate Secret, creating it when missing and renewing
+// expiring certificates when renew is set.
+func (m *Manager) ensureSecret(ctx context.Context, renew bool) (*corev1.Secret, error) {
+	secret := &corev1.Secret{}
+	err := m.Client.Get(ctx, m.secretKey(), secret)
+	if errors.IsNotFound(err) {
+		data, _, err := m.renewData(nil)
+		if err != nil {
+			return nil, err
+		}
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      m.Options.SecretName,
+				Namespace: m.Options.Namespace,
+				Labels: map[string]string{
+					"app.kubernetes.io/name":      "kubeagentic",
+					"app.kubernetes.io/component": "webhook-cert",
+				},
+			},
+			Type: corev1.SecretTypeTLS,
+			Data: data,
+		}
+		log.FromContext(ctx).Info("Creating webhook certificate Secret", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+		err = m.Client.Create(ctx, secret)
+		if errors.IsAlreadyExists(err) {
+			// Another replica bootstrapped concurrently; use its certificates.
+			secret = &corev1.Secret{}
+			err = m.Client.Get(ctx, m.secretKey(), secret)
+		}
+		return secret, err
+	} else if err != nil {
+		return nil, err
+	}
+
+	if !renew {
+		return secret, nil
+	}
+	data, changed, err := m.renewData(secret.Data)
+	if err != nil || !changed {
+		return secret, err
+	}
+	log.FromContext(ctx).Info("Renewing webhook certificates", "Secret.Namespace", secret.Namespace, "Secret.Name", secret.Name)
+	secret.Data = data
+	return secret, m.Client.Update(ctx, secret)
+}
+
+// renewData returns the Secret data with expiring, invalid or missing certificates
+// replaced, and whether anything changed.
+func (m *Manager) renewData(data map[string][]byte) (map[string][]byte, bool, error) {
+	now := m.Now()
+	deadline := now.Add(m.Options.RotateBefore)
+
+	bundle, _ := parseCertificates(data[CACertKey])
+	caKey, keyErr := parsePrivateKey(data[CAKeyKey])
+	caRenewed := len(bundle) == 0 || keyErr != nil || bundle[0].NotAfter.Before(deadline)
+
+	caCertPEM, caKeyPEM := data[CACertKey], data[CAKeyKey]
+	if caRenewed {
+		var err error
+		var caCert *x509.Certificate
+		caCert, caKey, err = generateCA(now, m.Options.CAValidity)
+		if err != nil {
+			return nil, false, err
+		}
+		// Keep trusting the previous CA until it expires, so that replicas still serving
+		// the old certificate are accepted while they pick up the new one.
+		bundle = append([]*x509.Certificate{caCert}, bundle...)
+		if caKeyPEM, err = encodePrivateKey(caKey); err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Drop expired CAs from the bundle.
+	var trusted []*x509.Certificate
+	for _, cert := range bundle {
+		if cert.NotAfter.After(now) {
+			trusted = append(trusted, cert)
+		}
+	}
+	changed := caRenewed
+	if encoded := encodeCertificates(trusted); !bytes.Equal(encoded, caCertPEM) {
+		caCertPEM = encoded
+		changed = true
+	}
+
+	servingCert, servingKey := data[corev1.TLSCertKey], data[corev1.TLSPrivateKeyKey]
+	if caRenewed || m.needsServingCert(servingCert, servingKey, trusted[0], deadline) {
+		var err error
+		servingCert, servingKey, err = generateServingCert(trusted[0], caKey, m.dnsNames(), now, m.Options.CertValidity)
+		if err != nil {
+			return nil, false, err
+		}
+		changed = true
+	}
+	if !changed {
+		return data, false, nil
+	}
+
+	return map[string][]byte{
+		CACertKey:               caCertPEM,
+		CAKeyKey:                caKeyPEM,
+		corev1.TLSCertKey:       servingCert,
+		corev1.TLSPrivateKeyKey: servingKey,
+	}, true, nil
+}
+
+// needsServingCert reports whether the serving certificate is missing, not signed by the
+// active CA, issued for other names or expiring before the deadline.
+func (m *Manager) needsServingCert(certPEM, keyPEM []byte, ca *x509.Certificate, deadline time.Time) bool {
+	certs, err := parseCertificates(certPEM)
+	if err != nil || len(certs) == 0 {
+		return true
+	}
+	if _, err := parsePrivateKey(keyPEM); err != nil {
+		return true
+	}
+	cert := certs[0]
+	if cert.NotAfter.Before(deadline) || cert.CheckSignatureFrom(ca) != nil {
+		return true
+	}
+	for _, name := range m.dnsNames() {
+		if cert.VerifyHostname(name) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// dnsNames returns the names the Services are reachable under, the webhook Service first.
+func (m *Manager) dnsNames() []string {
+	var names []string
+	ns := m.Options.Namespace
+	for _, svc := range append([]string{m.Options.ServiceName}, m.Options.ExtraServiceNames...) {
+		names = append(names,
+			svc,
+			svc+"."+ns,
+			svc+"."+ns+".svc",
+			svc+"."+ns+".svc.cluster.local",
+		)
+	}
+	return names
+}
+
+// patchCABundle sets the CA bundle on every webhook of the configured webhook
+// configurations. Configurations that do not exist are skipped.
+func (m *Manager) patchCABundle(ctx context.Context, caBundle []byte) error {
+	logger := log.FromContext(ctx)
+	for _, name := range m.Options.ValidatingWebhooks {
+		config := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		if err := m.Client.Get(ctx, types.NamespacedName{Name: name}, config); err != nil {
+			if errors.IsNotFound(err) {
+				logger.Info("ValidatingWebhookConfiguration not found, skipping caBundle", "Name", name)
+				continue
+			}
+			return err
+		}
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				config.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if changed {
+			logger.Info("Updating caBundle", "ValidatingWebhookConfiguration", name)
+			if err := m.Client.Update(ctx, config); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range m.Options.MutatingWebhooks {
+		config := &admissionregistrationv1.MutatingWebhookConfiguration{}
+		if err := m.Client.Get(ctx, types.NamespacedName{Name: name}, config); err != nil {
+			if errors.IsNotFound(err) {
+				logger.Info("MutatingWebhookConfiguration not found, skipping caBundle", "Name", name)
+				continue
+			}
+			return err
+		}
+		changed := false
+		for i := range config.Webhooks {
+			if !bytes.Equal(config.Webhooks[i].ClientConfig.CABundle, caBundle) {
+				config.Webhooks[i].ClientConfig.CABundle = caBundle
+				changed = true
+			}
+		}
+		if changed {
+			logger.Info("Updating caBundle", "MutatingWebhookConfiguration", name)
+			if err := m.Client.Update(ctx, config); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeCertDir writes the serving certificate and key into the certificate directory.
+// Files are replaced atomically and only when their content changed, as every write
+// makes the webhook server reload the certificate.
+func (m *Manager) writeCertDir(secret *corev1.Secret) error {
+	if err := os.MkdirAll(m.Options.CertDir, 0o700); err != nil {
+		return err
+	}
+	for _, key := range []string{corev1.TLSPrivateKeyKey, corev1.TLSCertKey} {
+		content := secret.Data[key]
+		if len(content) == 0 {
+			return fmt.Errorf("secret %s/%s has no %s", secret.Namespace, secret.Name, key)
+		}
+		path := filepath.Join(m.Options.CertDir, key)
+		if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, content) {
+			continue
+		}
+		tmp, err := os.CreateTemp(m.Options.CertDir, "."+key)
+		if err != nil {
+			return err
+		}
+		if _, err := tmp.Write(content); err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+			return err
+		}
+		if err := tmp.Close(); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+		if err := os.Rename(tmp.Name(), path); err != nil {
+			os.Remove(tmp.Name())
+			return err
+		}
+	}
+	return nil
+}
+
+// generateCA returns a new self-signed CA certificate and key.
+func generateCA(now time.Time, validity time.Duration) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "kubeagentic-webhook-ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(validity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// generateServingCert returns a PEM-encoded serving certificate for dnsNames signed by the CA.
+func generateServingCert(ca *x509.Certificate, caKey *ecdsa.PrivateKey, dnsNames []string, now time.Time, validity time.Duration) ([]byte, []byte, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	serial, err := serialNumber()
+	if err != nil {
+		return nil, nil, err
+	}
+	notAfter := now.Add(validity)
+	if notAfter.After(ca.NotAfter) {
+		notAfter = ca.NotAfter
+	}
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: dnsNames[2]},
+		DNSNames:     dnsNames,
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := encodePrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), keyPEM, nil
+}
+
+func serialNumber() (*big.Int, error) {
+	return rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+}
+
+func encodeCertificates(certs []*x509.Certificate) []byte {
+	var buf bytes.Buffer
+	for _, cert := range certs {
+		pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	return buf.Bytes()
+}
+
+func parseCertificates(data []byte) ([]*x509.Certificate, error) {
+	var certs []*x509.Certificate
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs, nil
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+}
+
+func encodePrivateKey(key *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), nil
+}
+
+func parsePrivateKey(data []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM private key")
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
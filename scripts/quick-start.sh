@@ -40,7 +40,7 @@ echo "  → Creating the 'kubeagentic-system' namespace..."
 kubectl apply -f deploy/namespace.yaml
 
 echo "  → Installing the Agent Custom Resource Definition (CRD)..."
-kubectl apply -f crd/agent-crd.yaml
+kubectl apply -f crd/agent-crd.yaml -f crd/agentpolicy-crd.yaml
 
 echo "  → Setting up Role-Based Access Control (RBAC)..."
 kubectl apply -f deploy/rbac.yaml
@@ -0,0 +1,161 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/activity"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentmetrics"
+)
+
+var _ = Describe("Agent Activity", func() {
+	start := time.Date(2024, 3, 14, 10, 0, 0, 0, time.UTC)
+
+	pod := func(name string, requests, errors int64) aiv1.PodRequestCounters {
+		return aiv1.PodRequestCounters{Pod: name, Requests: requests, Errors: errors}
+	}
+
+	Context("When aggregating the pod counters", func() {
+		It("Should only record the counters at the first update", func() {
+			status := activity.Update(nil, []aiv1.PodRequestCounters{pod("a", 100, 5), pod("b", 50, 0)}, nil, start)
+
+			Expect(status.TotalRequests).Should(Equal(int64(150)))
+			Expect(status.RequestsLastHour).Should(BeZero())
+			Expect(status.LastRequestTime).Should(BeNil())
+			Expect(status.ErrorRatio).Should(BeEmpty())
+			Expect(status.PodCounters).Should(HaveLen(2))
+		})
+
+		It("Should sum the increase of the pods across replicas", func() {
+			status := activity.Update(nil, []aiv1.PodRequestCounters{pod("a", 100, 5), pod("b", 50, 0)}, nil, start)
+			now := start.Add(2 * time.Minute)
+			status = activity.Update(status, []aiv1.PodRequestCounters{pod("a", 130, 6), pod("b", 60, 1), pod("c", 10, 0)}, nil, now)
+
+			// 30 from a, 10 from b and the 10 of the new pod c
+			Expect(status.TotalRequests).Should(Equal(int64(200)))
+			Expect(status.RequestsLastHour).Should(Equal(int64(50)))
+			Expect(status.ErrorRatio).Should(Equal("0.0400"))
+			Expect(status.LastRequestTime.Time).Should(Equal(now))
+		})
+
+		It("Should count a restarted pod from zero", func() {
+			status := activity.Update(nil, []aiv1.PodRequestCounters{pod("a", 100, 10)}, nil, start)
+			status = activity.Update(status, []aiv1.PodRequestCounters{pod("a", 7, 1)}, nil, start.Add(time.Minute))
+
+			Expect(status.TotalRequests).Should(Equal(int64(107)))
+			Expect(status.RequestsLastHour).Should(Equal(int64(7)))
+			Expect(status.PodCounters).Should(Equal([]aiv1.PodRequestCounters{pod("a", 7, 1)}))
+
+			By("Counting the increase after the restart")
+			status = activity.Update(status, []aiv1.PodRequestCounters{pod("a", 12, 1)}, nil, start.Add(2*time.Minute))
+			Expect(status.TotalRequests).Should(Equal(int64(112)))
+			Expect(status.RequestsLastHour).Should(Equal(int64(12)))
+			Expect(status.Buckets).Should(HaveLen(1))
+		})
+
+		It("Should keep the counters of pods that cannot be scraped", func() {
+			status := activity.Update(nil, []aiv1.PodRequestCounters{pod("a", 100, 0), pod("b", 40, 0)}, nil, start)
+			status = activity.Update(status, []aiv1.PodRequestCounters{pod("a", 110, 0)}, []string{"b", "old-image"}, start.Add(time.Minute))
+
+			Expect(status.TotalRequests).Should(Equal(int64(150)))
+			Expect(status.PodCounters).Should(ConsistOf(pod("a", 110, 0), pod("b", 40, 0)))
+
+			By("Counting the requests of the pod once it is scraped again")
+			status = activity.Update(status, []aiv1.PodRequestCounters{pod("a", 110, 0), pod("b", 45, 0)}, nil, start.Add(2*time.Minute))
+			Expect(status.TotalRequests).Should(Equal(int64(155)))
+		})
+
+		It("Should forget the requests older than an hour", func() {
+			status := activity.Update(nil, []aiv1.PodRequestCounters{pod("a", 0, 0)}, nil, start)
+			status = activity.Update(status, []aiv1.PodRequestCounters{pod("a", 20, 2)}, nil, start.Add(time.Minute))
+			status = activity.Update(status, []aiv1.PodRequestCounters{pod("a", 30, 2)}, nil, start.Add(40*time.Minute))
+			Expect(status.RequestsLastHour).Should(Equal(int64(30)))
+			Expect(status.Buckets).Should(HaveLen(2))
+
+			status = activity.Update(status, []aiv1.PodRequestCounters{pod("a", 30, 2)}, nil, start.Add(110*time.Minute))
+			Expect(status.TotalRequests).Should(Equal(int64(30)))
+			Expect(status.RequestsLastHour).Should(BeZero())
+			Expect(status.ErrorRatio).Should(BeEmpty())
+			Expect(status.Buckets).Should(BeEmpty())
+			Expect(status.LastRequestTime.Time).Should(Equal(start.Add(40 * time.Minute)))
+		})
+	})
+
+	Context("When reconciling an agent", func() {
+		It("Should report the requests of the running pods", func() {
+			ctx := context.Background()
+			scheme := newScheme()
+			fakeClient := newFakeClientBuilder(scheme).
+				WithObjects(
+					&aiv1.Agent{
+						ObjectMeta: metav1.ObjectMeta{Name: "helpdesk", Namespace: "default"},
+						Spec: aiv1.AgentSpec{
+							Provider:     "openai",
+							Model:        "gpt-4o",
+							SystemPrompt: "You are a helpful AI assistant.",
+							ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+						},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+						Data:       map[string][]byte{"api-key": []byte("sk-test")},
+					},
+					&corev1.Pod{
+						ObjectMeta: metav1.ObjectMeta{Name: "helpdesk-abc", Namespace: "default", Labels: map[string]string{"kubeagentic.ai/agent": "helpdesk"}},
+						Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.9"},
+					},
+				).
+				Build()
+
+			requests := 40
+			metricsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				fmt.Fprintf(w, "# TYPE %s counter\n", agentmetrics.RequestsMetric)
+				fmt.Fprintf(w, "%s{agent=\"helpdesk\",variant=\"\"} %d\n", agentmetrics.RequestsMetric, requests)
+				fmt.Fprintf(w, "# TYPE %s counter\n", agentmetrics.ErrorsMetric)
+				fmt.Fprintf(w, "%s{agent=\"helpdesk\",variant=\"\"} 2\n", agentmetrics.ErrorsMetric)
+			}))
+			DeferCleanup(metricsServer.Close)
+			server, err := url.Parse(metricsServer.URL)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			clock := clocktesting.NewFakePassiveClock(start)
+			reconciler := &controllers.AgentReconciler{
+				Client:         fakeClient,
+				Scheme:         scheme,
+				Clock:          clock,
+				MetricsScraper: &agentmetrics.Scraper{Client: &http.Client{Transport: redirectTransport{server: server}}},
+			}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "helpdesk", Namespace: "default"}}
+			reconcile := func() *aiv1.Agent {
+				return reconcileAgent(ctx, reconciler, request)
+			}
+
+			agent := reconcile()
+			Expect(agent.Status.Activity).ShouldNot(BeNil())
+			Expect(agent.Status.Activity.TotalRequests).Should(Equal(int64(40)))
+			Expect(agent.Status.Activity.LastRequestTime).Should(BeNil())
+
+			By("Reporting the requests since the last poll")
+			requests = 60
+			clock.SetTime(start.Add(5 * time.Minute))
+			agent = reconcile()
+			Expect(agent.Status.Activity.TotalRequests).Should(Equal(int64(60)))
+			Expect(agent.Status.Activity.RequestsLastHour).Should(Equal(int64(20)))
+			Expect(agent.Status.Activity.LastRequestTime.Time).Should(BeTemporally("==", start.Add(5*time.Minute)))
+		})
+	})
+})
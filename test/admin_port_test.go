@@ -0,0 +1,263 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/health"
+)
+
+var _ = Describe("Agent Admin Port", func() {
+	var (
+		ctx         context.Context
+		fakeClient  client.Client
+		reconciler  *controllers.AgentReconciler
+		request     ctrl.Request
+		adminScheme *runtime.Scheme
+	)
+
+	newAgent := func(adminPort *aiv1.AdminPortConfig) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "ops", Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "vllm",
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.default.svc:8000/v1",
+				AdminPort:    adminPort,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		adminScheme = newScheme()
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "ops", Namespace: "default"}}
+	})
+
+	newReconciler := func(objects ...client.Object) {
+		fakeClient = newFakeClientBuilder(adminScheme).
+			WithObjects(objects...).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: adminScheme, OperatorNamespace: "kubeagentic-system"}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	updateAgent := func(mutate func(agent *aiv1.Agent)) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		mutate(agent)
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	agentContainer := func() corev1.Container {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ops", Namespace: "default"}, deployment)).Should(Succeed())
+		return deployment.Spec.Template.Spec.Containers[0]
+	}
+
+	envValue := func(container corev1.Container, name string) *corev1.EnvVar {
+		for i := range container.Env {
+			if container.Env[i].Name == name {
+				return &container.Env[i]
+			}
+		}
+		return nil
+	}
+
+	It("Should serve the probes on the admin port with a token of its own", func() {
+		newReconciler(newAgent(&aiv1.AdminPortConfig{Port: 9000}))
+		agent := reconcile()
+		Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+		Expect(agent.Status.AdminAuthSecretName).Should(Equal("ops-admin-auth"))
+
+		container := agentContainer()
+		Expect(container.Ports).Should(ContainElement(corev1.ContainerPort{Name: "admin", ContainerPort: 9000, Protocol: corev1.ProtocolTCP}))
+		Expect(container.LivenessProbe.HTTPGet.Port).Should(Equal(intstr.FromInt(9000)))
+		Expect(container.ReadinessProbe.HTTPGet.Port).Should(Equal(intstr.FromInt(9000)))
+		Expect(envValue(container, "AGENT_ADMIN_PORT").Value).Should(Equal("9000"))
+		Expect(envValue(container, "AGENT_ADMIN_TOKEN").ValueFrom.SecretKeyRef.Name).Should(Equal("ops-admin-auth"))
+
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ops-admin-auth", Namespace: "default"}, secret)).Should(Succeed())
+		Expect(secret.Data["token"]).ShouldNot(BeEmpty())
+
+		By("Keeping the admin port out of the agent Service")
+		service := &corev1.Service{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ops-service", Namespace: "default"}, service)).Should(Succeed())
+		Expect(service.Spec.Ports).Should(HaveLen(1))
+		Expect(service.Spec.Ports[0].TargetPort).Should(Equal(intstr.FromInt(8080)))
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "ops-admin", Namespace: "default"}, &corev1.Service{}))).Should(BeTrue())
+
+		By("Removing the admin port")
+		updateAgent(func(agent *aiv1.Agent) { agent.Spec.AdminPort = nil })
+		agent = reconcile()
+		Expect(agent.Status.AdminAuthSecretName).Should(BeEmpty())
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "ops-admin-auth", Namespace: "default"}, &corev1.Secret{}))).Should(BeTrue())
+		container = agentContainer()
+		Expect(container.Ports).Should(HaveLen(1))
+		Expect(container.LivenessProbe.HTTPGet.Port).Should(Equal(intstr.FromInt(8080)))
+		Expect(envValue(container, "AGENT_ADMIN_PORT")).Should(BeNil())
+	})
+
+	It("Should expose the admin port to the operator namespace only", func() {
+		agent := newAgent(&aiv1.AdminPortConfig{Port: 9000, Expose: true})
+		agent.Spec.NetworkPolicy = &aiv1.NetworkPolicyConfig{Enabled: true}
+		newReconciler(agent)
+		reconcile()
+
+		service := &corev1.Service{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ops-admin", Namespace: "default"}, service)).Should(Succeed())
+		Expect(service.Spec.Type).Should(Equal(corev1.ServiceTypeClusterIP))
+		Expect(service.Spec.Ports).Should(HaveLen(1))
+		Expect(service.Spec.Ports[0].Port).Should(Equal(int32(9000)))
+		Expect(service.Spec.Ports[0].TargetPort).Should(Equal(intstr.FromInt(9000)))
+		Expect(metav1.IsControlledBy(service, reconcile())).Should(BeTrue())
+
+		policy := &networkingv1.NetworkPolicy{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ops-network-policy", Namespace: "default"}, policy)).Should(Succeed())
+		Expect(policy.Spec.Ingress).Should(HaveLen(2))
+		Expect(*policy.Spec.Ingress[0].Ports[0].Port).Should(Equal(intstr.FromInt(8080)))
+		admin := policy.Spec.Ingress[1]
+		Expect(*admin.Ports[0].Port).Should(Equal(intstr.FromInt(9000)))
+		Expect(admin.From).Should(HaveLen(1))
+		Expect(admin.From[0].NamespaceSelector.MatchLabels).Should(Equal(map[string]string{"kubernetes.io/metadata.name": "kubeagentic-system"}))
+
+		By("Deleting the admin Service once it is no longer exposed")
+		updateAgent(func(agent *aiv1.Agent) { agent.Spec.AdminPort.Expose = false })
+		reconcile()
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "ops-admin", Namespace: "default"}, &corev1.Service{}))).Should(BeTrue())
+	})
+
+	It("Should reject admin ports used by the pods", func() {
+		for _, tc := range []struct {
+			mutate  func(agent *aiv1.Agent)
+			message string
+		}{
+			{func(agent *aiv1.Agent) { agent.Spec.AdminPort.Port = 8080 }, "adminPort.port 8080 is already used by the chat port"},
+			{func(agent *aiv1.Agent) {
+				port := int32(9000)
+				agent.Spec.Metrics = &aiv1.MetricsConfig{Enabled: true, Port: &port}
+			}, "adminPort.port 9000 is already used by the metrics port"},
+			{func(agent *aiv1.Agent) {
+				agent.Spec.AdminPort.Port = 8081
+				agent.Spec.InboundRateLimit = &aiv1.InboundRateLimitConfig{Rules: []aiv1.RateLimitRule{{Name: "default", Requests: 60, Window: metav1.Duration{Duration: time.Minute}}}}
+			}, "adminPort.port 8081 is already used by the rate limit proxy"},
+		} {
+			agent := newAgent(&aiv1.AdminPortConfig{Port: 9000})
+			tc.mutate(agent)
+			newReconciler(agent)
+			agent = reconcile()
+			Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+			Expect(agent.Status.Message).Should(ContainSubstring(tc.message))
+			var configValid aiv1.AgentCondition
+			for _, condition := range agent.Status.Conditions {
+				if condition.Type == aiv1.AgentConditionConfigValid {
+					configValid = condition
+				}
+			}
+			Expect(configValid.Reason).Should(Equal("InvalidAdminPort"))
+			Expect(apierrors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "ops", Namespace: "default"}, &appsv1.Deployment{}))).Should(BeTrue())
+		}
+	})
+
+	Context("Polling the health", func() {
+		var hosts []string
+
+		newHealthReconciler := func(expose bool) {
+			ready := true
+			agent := newAgent(&aiv1.AdminPortConfig{Port: 9000, Expose: expose})
+			agent.Spec.EndpointAuth = &aiv1.EndpointAuthConfig{GenerateKey: true}
+			agent.Spec.HealthCheck = &aiv1.HealthCheckConfig{Enabled: true}
+			newReconciler(agent,
+				&discoveryv1.EndpointSlice{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "ops-service-abcde",
+						Namespace: "default",
+						Labels:    map[string]string{discoveryv1.LabelServiceName: "ops-service"},
+					},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.5"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}}},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "ops-pod", Namespace: "default", Labels: map[string]string{
+						"app.kubernetes.io/name":     "kubeagentic-agent",
+						"app.kubernetes.io/instance": "ops",
+						"kubeagentic.ai/agent":       "ops",
+					}},
+					Status: corev1.PodStatus{
+						Phase:      corev1.PodRunning,
+						PodIP:      "10.0.0.5",
+						Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+					},
+				},
+			)
+
+			hosts = nil
+			runtimeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				hosts = append(hosts, r.Host)
+				secret := &corev1.Secret{}
+				Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ops-admin-auth", Namespace: "default"}, secret)).Should(Succeed())
+				if r.Header.Get("Authorization") != "Bearer "+string(secret.Data["token"]) {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				Expect(json.NewEncoder(w).Encode(health.Report{Status: health.StateHealthy, Components: map[string]health.Component{
+					"provider": {Status: health.StateHealthy, Message: "vllm is reachable"},
+				}})).Should(Succeed())
+			}))
+			DeferCleanup(runtimeServer.Close)
+			server, err := url.Parse(runtimeServer.URL)
+			Expect(err).ShouldNot(HaveOccurred())
+			reconciler.HealthChecker = &health.Checker{Client: &http.Client{Transport: redirectTransport{server: server}}}
+		}
+
+		agentHealthy := func(agent *aiv1.Agent) aiv1.AgentCondition {
+			for _, condition := range agent.Status.Conditions {
+				if condition.Type == aiv1.AgentConditionAgentHealthy {
+					return condition
+				}
+			}
+			return aiv1.AgentCondition{}
+		}
+
+		It("Should poll the admin Service with the admin token", func() {
+			newHealthReconciler(true)
+			reconcile()
+			agent := reconcile()
+			Expect(hosts).Should(Equal([]string{"ops-admin.default.svc:9000"}))
+			Expect(agentHealthy(agent).Reason).Should(Equal("ComponentsHealthy"))
+		})
+
+		It("Should poll a ready pod when the admin port is not exposed", func() {
+			newHealthReconciler(false)
+			reconcile()
+			agent := reconcile()
+			Expect(hosts).Should(Equal([]string{"10.0.0.5:9000"}))
+			Expect(agentHealthy(agent).Reason).Should(Equal("ComponentsHealthy"))
+		})
+	})
+})
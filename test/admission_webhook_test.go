@@ -0,0 +1,139 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+)
+
+var _ = Describe("Admission Webhooks", func() {
+	admittedAgent := func(provider string) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     provider,
+				SystemPrompt: "You are a helpful AI assistant.",
+				ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+			},
+		}
+	}
+
+	Context("Defaulting and validating the Agents", func() {
+		It("Should default the empty fields", func() {
+			agent := admittedAgent("openai")
+			Expect((&webhookv1.AgentWebhook{}).Default(context.Background(), agent)).Should(Succeed())
+			Expect(agent.Spec.Framework).Should(Equal("direct"))
+			Expect(agent.Spec.Model).ShouldNot(BeEmpty())
+			Expect(agent.Spec.Replicas).Should(HaveValue(Equal(int32(1))))
+			Expect(agent.Spec.ServiceType).Should(Equal(corev1.ServiceTypeClusterIP))
+			Expect(agent.Spec.Resources).ShouldNot(BeNil())
+		})
+
+		It("Should reject unknown providers and accept the known ones", func() {
+			validator := &webhookv1.AgentWebhook{}
+			agent := admittedAgent("openai")
+			Expect(validator.Default(context.Background(), agent)).Should(Succeed())
+			_, err := validator.ValidateCreate(context.Background(), agent)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			updated := agent.DeepCopy()
+			updated.Spec.Provider = "acme"
+			_, err = validator.ValidateUpdate(context.Background(), agent, updated)
+			Expect(err).Should(MatchError(ContainSubstring("spec.provider")))
+		})
+
+		It("Should refuse objects other than Agents", func() {
+			_, err := (&webhookv1.AgentWebhook{}).ValidateCreate(context.Background(), &corev1.ConfigMap{})
+			Expect(err).Should(MatchError(ContainSubstring("expected an Agent")))
+		})
+	})
+
+	It("Should default and validate the Agents in the API server", func() {
+		if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+			Skip("the webhooks are called by envtest, which needs KUBEBUILDER_ASSETS")
+		}
+		testEnv := &envtest.Environment{
+			CRDDirectoryPaths:     []string{filepath.Join("..", "crd")},
+			ErrorIfCRDPathMissing: true,
+			// The webhook configurations of the install manifest, pointed at the local server
+			WebhookInstallOptions: envtest.WebhookInstallOptions{
+				Paths: []string{filepath.Join("..", "deploy", "operator-enhanced.yaml")},
+			},
+		}
+		cfg, err := testEnv.Start()
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(testEnv.Stop)
+		Expect(testEnv.WebhookInstallOptions.MutatingWebhooks).Should(HaveLen(1))
+		Expect(testEnv.WebhookInstallOptions.ValidatingWebhooks).Should(HaveLen(1))
+
+		webhookScheme := newScheme()
+		options := &testEnv.WebhookInstallOptions
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme:  webhookScheme,
+			Metrics: metricsserver.Options{BindAddress: "0"},
+			WebhookServer: webhook.NewServer(webhook.Options{
+				Host:    options.LocalServingHost,
+				Port:    options.LocalServingPort,
+				CertDir: options.LocalServingCertDir,
+			}),
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect((&webhookv1.AgentWebhook{}).SetupWebhookWithManager(mgr)).Should(Succeed())
+		// Registered after the environment, so that the manager stops before the API server
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		DeferCleanup(func() {
+			cancel()
+			<-stopped
+		})
+		go func() {
+			defer GinkgoRecover()
+			defer close(stopped)
+			Expect(mgr.Start(ctx)).Should(Succeed())
+		}()
+
+		apiClient, err := client.New(cfg, client.Options{Scheme: webhookScheme})
+		Expect(err).ShouldNot(HaveOccurred())
+		Eventually(func() error {
+			return mgr.GetWebhookServer().StartedChecker()(nil)
+		}, 10*time.Second, 100*time.Millisecond).Should(Succeed(), "webhook server at %s:%d", options.LocalServingHost, options.LocalServingPort)
+
+		By("Applying the defaults, the model required by the CRD included")
+		agent := admittedAgent("openai")
+		Expect(apiClient.Create(ctx, agent)).Should(Succeed())
+		stored := &aiv1.Agent{}
+		Expect(apiClient.Get(ctx, client.ObjectKeyFromObject(agent), stored)).Should(Succeed())
+		Expect(stored.Spec.Model).ShouldNot(BeEmpty())
+		Expect(stored.Spec.Framework).Should(Equal("direct"))
+		Expect(stored.Spec.Replicas).Should(HaveValue(Equal(int32(1))))
+		Expect(stored.Spec.Resources).ShouldNot(BeNil())
+
+		By("Refusing a hosted provider without an API secret, which only the webhook checks")
+		invalid := admittedAgent("claude")
+		invalid.Name = "invalid"
+		invalid.Spec.ApiSecretRef = nil
+		err = apiClient.Create(ctx, invalid)
+		Expect(err).Should(HaveOccurred())
+		Expect(err.Error()).Should(ContainSubstring(`admission webhook "vagent.kb.io" denied the request`))
+		Expect(err.Error()).Should(ContainSubstring("spec.apiSecretRef"))
+
+		By("Refusing an unknown provider")
+		invalid = admittedAgent("acme")
+		invalid.Name = "unknown"
+		Expect(apiClient.Create(ctx, invalid)).ShouldNot(Succeed())
+	})
+})
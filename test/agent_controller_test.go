@@ -6,8 +6,18 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	psaapi "k8s.io/pod-security-admission/api"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
 )
@@ -20,6 +30,15 @@ var _ = Describe("Agent Controller", func() {
 		interval       = time.Millisecond * 250
 	)
 
+	BeforeEach(func() {
+		requireEnvtest()
+		// The agents wait for the API key they refer to before they are reconciled
+		Expect(client.IgnoreAlreadyExists(k8sClient.Create(context.Background(), &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: AgentNamespace},
+			StringData: map[string]string{"api-key": "sk-test"},
+		}))).Should(Succeed())
+	})
+
 	Context("When creating an Agent", func() {
 		It("Should create a Deployment", func() {
 			By("Creating a new Agent")
@@ -33,11 +52,13 @@ var _ = Describe("Agent Controller", func() {
 					Provider: "openai",
 					Model:    "gpt-4",
 					SystemPrompt: "You are a helpful AI assistant.",
-					ApiSecretRef: corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "test-secret",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
 						},
-						Key: "api-key",
 					},
 					Framework: "direct",
 					Replicas:  int32Ptr(1),
@@ -103,11 +124,13 @@ var _ = Describe("Agent Controller", func() {
 					Provider: "openai",
 					Model:    "gpt-4",
 					SystemPrompt: "You are a helpful AI assistant.",
-					ApiSecretRef: corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "test-secret",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
 						},
-						Key: "api-key",
 					},
 					Framework: "direct",
 					Replicas:  int32Ptr(3),
@@ -138,11 +161,13 @@ var _ = Describe("Agent Controller", func() {
 					Provider: "openai",
 					Model:    "gpt-4",
 					SystemPrompt: "You are a helpful AI assistant.",
-					ApiSecretRef: corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "test-secret",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
 						},
-						Key: "api-key",
 					},
 					Framework:   "direct",
 					Replicas:    int32Ptr(1),
@@ -163,44 +188,1984 @@ var _ = Describe("Agent Controller", func() {
 		})
 	})
 
-	Context("When updating an Agent", func() {
-		It("Should update the Deployment when spec changes", func() {
-			By("Creating an Agent")
+	Context("When enabling the model cache", func() {
+		It("Should mount the shared cache into the agent pods", func() {
+			By("Creating an Agent with the model cache enabled")
 			ctx := context.Background()
 			agent := &aiv1.Agent{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      AgentName + "-update",
+					Name:      AgentName + "-cache",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "vllm",
+					Model:    "meta-llama/Llama-3-8B",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint: "http://vllm:8000/v1",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Replicas: int32Ptr(1),
+					ModelCache: &aiv1.ModelCacheConfig{
+						Enabled: true,
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking that the shared cache PVC is created")
+			pvcLookupKey := types.NamespacedName{Name: "kubeagentic-model-cache", Namespace: AgentNamespace}
+			createdPVC := &corev1.PersistentVolumeClaim{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, pvcLookupKey, createdPVC)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+			Expect(createdPVC.Spec.AccessModes).Should(ContainElement(corev1.ReadWriteMany))
+
+			By("Checking that the Deployment mounts the cache")
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-cache", Namespace: AgentNamespace}
+			createdDeployment := &appsv1.Deployment{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			podSpec := createdDeployment.Spec.Template.Spec
+			Expect(podSpec.Volumes).Should(HaveLen(1))
+			Expect(podSpec.Volumes[0].PersistentVolumeClaim).ShouldNot(BeNil())
+			Expect(podSpec.Volumes[0].PersistentVolumeClaim.ClaimName).Should(Equal("kubeagentic-model-cache"))
+
+			container := podSpec.Containers[0]
+			Expect(container.VolumeMounts).Should(ContainElement(corev1.VolumeMount{
+				Name:      "model-cache",
+				MountPath: "/var/cache/models",
+				SubPath:   "meta-llama-Llama-3-8B",
+			}))
+			Expect(container.Env).Should(ContainElement(corev1.EnvVar{Name: "HF_HOME", Value: "/var/cache/models"}))
+			Expect(container.Env).Should(ContainElement(corev1.EnvVar{Name: "VLLM_CACHE_ROOT", Value: "/var/cache/models/vllm"}))
+		})
+
+		It("Should reject ReadWriteOnce storage with multiple replicas", func() {
+			By("Creating a ReadWriteOnce PVC")
+			ctx := context.Background()
+			pvc := &corev1.PersistentVolumeClaim{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "rwo-model-cache",
+					Namespace: AgentNamespace,
+				},
+				Spec: corev1.PersistentVolumeClaimSpec{
+					AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+					Resources: corev1.ResourceRequirements{
+						Requests: corev1.ResourceList{
+							corev1.ResourceStorage: resource.MustParse("10Gi"),
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, pvc)).Should(Succeed())
+
+			By("Creating an Agent with two replicas using the PVC")
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-cache-rwo",
 					Namespace: AgentNamespace,
 				},
 				Spec: aiv1.AgentSpec{
 					Provider: "openai",
 					Model:    "gpt-4",
 					SystemPrompt: "You are a helpful AI assistant.",
-					ApiSecretRef: corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "test-secret",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
 						},
-						Key: "api-key",
 					},
-					Framework: "direct",
-					Replicas:  int32Ptr(1),
+					Replicas: int32Ptr(2),
+					ModelCache: &aiv1.ModelCacheConfig{
+						Enabled: true,
+						PVCName: "rwo-model-cache",
+					},
 				},
 			}
 
 			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
 
-			By("Updating the Agent spec")
-			agentLookupKey := types.NamespacedName{Name: AgentName + "-update", Namespace: AgentNamespace}
+			By("Checking that the Agent fails with a ReadWriteOnce message")
+			agentLookupKey := types.NamespacedName{Name: AgentName + "-cache-rwo", Namespace: AgentNamespace}
 			createdAgent := &aiv1.Agent{}
 
-			Eventually(func() bool {
+			Eventually(func() string {
 				err := k8sClient.Get(ctx, agentLookupKey, createdAgent)
+				if err != nil {
+					return ""
+				}
+				return string(createdAgent.Status.Phase)
+			}, timeout, interval).Should(Equal(string(aiv1.AgentPhaseFailed)))
+			Expect(createdAgent.Status.Message).Should(ContainSubstring("ReadWriteOnce"))
+
+			By("Checking that no Deployment is created")
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-cache-rwo", Namespace: AgentNamespace}
+			Consistently(func() bool {
+				err := k8sClient.Get(ctx, deploymentLookupKey, &appsv1.Deployment{})
+				return err != nil
+			}, time.Second*2, interval).Should(BeTrue())
+		})
+	})
+
+	Context("When enabling conversation affinity", func() {
+		It("Should render a router hashing the conversation header", func() {
+			By("Creating an Agent with conversation affinity on a custom header")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-affinity",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Replicas: int32Ptr(3),
+					Routing: &aiv1.RoutingConfig{
+						ConversationAffinity: &aiv1.ConversationAffinityConfig{
+							Enabled: true,
+							Header:  "X-Session-Id",
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking that the router configuration hashes the header")
+			configLookupKey := types.NamespacedName{Name: AgentName + "-affinity-router", Namespace: AgentNamespace}
+			createdConfigMap := &corev1.ConfigMap{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, configLookupKey, createdConfigMap)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			envoyConfig := createdConfigMap.Data["envoy.yaml"]
+			Expect(envoyConfig).Should(ContainSubstring("header_name: X-Session-Id"))
+			Expect(envoyConfig).Should(ContainSubstring("lb_policy: MAGLEV"))
+			Expect(envoyConfig).Should(ContainSubstring(AgentName + "-affinity-headless." + AgentNamespace + ".svc.cluster.local"))
+
+			By("Checking that the agent Service targets the router")
+			serviceLookupKey := types.NamespacedName{Name: AgentName + "-affinity-service", Namespace: AgentNamespace}
+			createdService := &corev1.Service{}
+
+			Eventually(func() string {
+				err := k8sClient.Get(ctx, serviceLookupKey, createdService)
+				if err != nil {
+					return ""
+				}
+				return createdService.Spec.Selector["app.kubernetes.io/name"]
+			}, timeout, interval).Should(Equal("kubeagentic-router"))
+
+			By("Checking that the headless Service targets the agent pods")
+			headlessLookupKey := types.NamespacedName{Name: AgentName + "-affinity-headless", Namespace: AgentNamespace}
+			headlessService := &corev1.Service{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, headlessLookupKey, headlessService)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+			Expect(headlessService.Spec.ClusterIP).Should(Equal(corev1.ClusterIPNone))
+			Expect(headlessService.Spec.Selector["app.kubernetes.io/name"]).Should(Equal("kubeagentic-agent"))
+		})
+	})
+
+	Context("When enabling the NetworkPolicy", func() {
+		It("Should allow HTTPS egress for a hosted provider", func() {
+			By("Creating an Agent without a custom endpoint")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-netpol",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Replicas: int32Ptr(1),
+					NetworkPolicy: &aiv1.NetworkPolicyConfig{
+						Enabled: true,
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking the rendered rules")
+			policyLookupKey := types.NamespacedName{Name: AgentName + "-netpol-network-policy", Namespace: AgentNamespace}
+			createdPolicy := &networkingv1.NetworkPolicy{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, policyLookupKey, createdPolicy)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(createdPolicy.Spec.PolicyTypes).Should(ConsistOf(networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress))
+			Expect(createdPolicy.Spec.Ingress).Should(HaveLen(1))
+			Expect(createdPolicy.Spec.Ingress[0].Ports[0].Port.IntValue()).Should(Equal(8080))
+
+			Expect(createdPolicy.Spec.Egress).Should(HaveLen(2))
+			Expect(createdPolicy.Spec.Egress[0].Ports[0].Port.IntValue()).Should(Equal(53))
+			Expect(createdPolicy.Spec.Egress[1].To).Should(BeEmpty())
+			Expect(createdPolicy.Spec.Egress[1].Ports[0].Port.IntValue()).Should(Equal(443))
+		})
+
+		It("Should restrict egress to a custom endpoint", func() {
+			By("Creating an Agent with a custom endpoint")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-netpol-endpoint",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "vllm",
+					Model:    "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint: "http://10.0.0.5:8000/v1",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Replicas: int32Ptr(1),
+					NetworkPolicy: &aiv1.NetworkPolicyConfig{
+						Enabled: true,
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking that egress is pinned to the endpoint address and port")
+			policyLookupKey := types.NamespacedName{Name: AgentName + "-netpol-endpoint-network-policy", Namespace: AgentNamespace}
+			createdPolicy := &networkingv1.NetworkPolicy{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, policyLookupKey, createdPolicy)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(createdPolicy.Spec.Egress).Should(HaveLen(2))
+			endpointRule := createdPolicy.Spec.Egress[1]
+			Expect(endpointRule.To).Should(HaveLen(1))
+			Expect(endpointRule.To[0].IPBlock).ShouldNot(BeNil())
+			Expect(endpointRule.To[0].IPBlock.CIDR).Should(Equal("10.0.0.5/32"))
+			Expect(endpointRule.Ports[0].Port.IntValue()).Should(Equal(8000))
+
+			By("Disabling the NetworkPolicy")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: AgentName + "-netpol-endpoint", Namespace: AgentNamespace}, agent)).Should(Succeed())
+			agent.Spec.NetworkPolicy.Enabled = false
+			Expect(k8sClient.Update(ctx, agent)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, policyLookupKey, &networkingv1.NetworkPolicy{})
+				return err != nil
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
+
+	Context("When generating an endpoint key", func() {
+		It("Should rotate the token and roll the pods on request", func() {
+			By("Creating an Agent with a generated endpoint key")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-endpoint-auth",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					EndpointAuth: &aiv1.EndpointAuthConfig{
+						GenerateKey: true,
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking that the token Secret is generated")
+			secretLookupKey := types.NamespacedName{Name: AgentName + "-endpoint-auth-endpoint-auth", Namespace: AgentNamespace}
+			createdSecret := &corev1.Secret{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, secretLookupKey, createdSecret)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+			token := string(createdSecret.Data["token"])
+			Expect(token).ShouldNot(BeEmpty())
+
+			By("Checking that the agent container expects the token")
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-endpoint-auth", Namespace: AgentNamespace}
+			createdDeployment := &appsv1.Deployment{}
+
+			Eventually(func() string {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				if err != nil {
+					return ""
+				}
+				return createdDeployment.Spec.Template.Annotations["kubeagentic.ai/endpoint-key-checksum"]
+			}, timeout, interval).ShouldNot(BeEmpty())
+			checksum := createdDeployment.Spec.Template.Annotations["kubeagentic.ai/endpoint-key-checksum"]
+
+			var tokenEnv *corev1.EnvVar
+			for i, env := range createdDeployment.Spec.Template.Spec.Containers[0].Env {
+				if env.Name == "AGENT_ENDPOINT_TOKEN" {
+					tokenEnv = &createdDeployment.Spec.Template.Spec.Containers[0].Env[i]
+				}
+			}
+			Expect(tokenEnv).ShouldNot(BeNil())
+			Expect(tokenEnv.ValueFrom.SecretKeyRef.Name).Should(Equal(AgentName + "-endpoint-auth-endpoint-auth"))
+
+			By("Requesting a rotation")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, agent)).Should(Succeed())
+			agent.Annotations = map[string]string{"kubeagentic.ai/rotate-endpoint-key": "1"}
+			Expect(k8sClient.Update(ctx, agent)).Should(Succeed())
+
+			Eventually(func() string {
+				err := k8sClient.Get(ctx, secretLookupKey, createdSecret)
+				if err != nil {
+					return ""
+				}
+				return string(createdSecret.Data["token"])
+			}, timeout, interval).ShouldNot(Equal(token))
+
+			Eventually(func() string {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				if err != nil {
+					return ""
+				}
+				return createdDeployment.Spec.Template.Annotations["kubeagentic.ai/endpoint-key-checksum"]
+			}, timeout, interval).ShouldNot(Equal(checksum))
+		})
+	})
+
+	Context("When automounting the ServiceAccount token", func() {
+		It("Should not mount the token by default", func() {
+			By("Creating an Agent without opting in")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-automount",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-automount", Namespace: AgentNamespace}
+			createdDeployment := &appsv1.Deployment{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
 				return err == nil
 			}, timeout, interval).Should(BeTrue())
+			Expect(createdDeployment.Spec.Template.Spec.AutomountServiceAccountToken).ShouldNot(BeNil())
+			Expect(*createdDeployment.Spec.Template.Spec.AutomountServiceAccountToken).Should(BeFalse())
+		})
+
+		It("Should keep the token of an existing Deployment until the spec changes", func() {
+			By("Creating a Deployment as an earlier operator version would")
+			ctx := context.Background()
+			labels := map[string]string{
+				"app.kubernetes.io/name":     "kubeagentic-agent",
+				"app.kubernetes.io/instance": AgentName + "-legacy",
+				"kubeagentic.ai/agent":       AgentName + "-legacy",
+			}
+			legacy := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-legacy",
+					Namespace: AgentNamespace,
+					Labels:    labels,
+				},
+				Spec: appsv1.DeploymentSpec{
+					Replicas: int32Ptr(1),
+					Selector: &metav1.LabelSelector{MatchLabels: labels},
+					Template: corev1.PodTemplateSpec{
+						ObjectMeta: metav1.ObjectMeta{Labels: labels},
+						Spec: corev1.PodSpec{
+							Containers: []corev1.Container{{Name: "agent", Image: "kubeagentic/agent:latest"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, legacy)).Should(Succeed())
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-legacy",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Replicas: int32Ptr(1),
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking that the first reconcile keeps the token mounted")
+			agentLookupKey := types.NamespacedName{Name: AgentName + "-legacy", Namespace: AgentNamespace}
+			createdAgent := &aiv1.Agent{}
+
+			Eventually(func() int64 {
+				err := k8sClient.Get(ctx, agentLookupKey, createdAgent)
+				if err != nil {
+					return 0
+				}
+				return createdAgent.Status.ObservedGeneration
+			}, timeout, interval).Should(Equal(agent.Generation))
 
+			deployment := &appsv1.Deployment{}
+			Expect(k8sClient.Get(ctx, agentLookupKey, deployment)).Should(Succeed())
+			Expect(deployment.Spec.Template.Spec.AutomountServiceAccountToken).Should(BeNil())
+
+			By("Changing the Agent spec")
 			createdAgent.Spec.Replicas = int32Ptr(2)
 			Expect(k8sClient.Update(ctx, createdAgent)).Should(Succeed())
 
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, agentLookupKey, deployment)
+				if err != nil {
+					return false
+				}
+				automount := deployment.Spec.Template.Spec.AutomountServiceAccountToken
+				return automount != nil && !*automount
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
+
+	Context("When referencing a secret from another namespace", func() {
+		It("Should sync a copy of the allowed secret and delete it with the agent", func() {
+			By("Creating a credentials namespace granting access through an AgentPolicy")
+			ctx := context.Background()
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{Name: "llm-credentials"},
+			})).Should(Succeed())
+			source := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: "llm-credentials"},
+				Data:       map[string][]byte{"api-key": []byte("sk-original")},
+			}
+			Expect(k8sClient.Create(ctx, source)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &aiv1.AgentPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "shared-credentials", Namespace: "llm-credentials"},
+				Spec: aiv1.AgentPolicySpec{
+					SecretConsumerNamespaces: []string{AgentNamespace},
+				},
+			})).Should(Succeed())
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-shared",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "openai",
+							},
+							Key: "api-key",
+						},
+						Namespace: "llm-credentials",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking that the key is copied into the agent namespace")
+			copyLookupKey := types.NamespacedName{Name: AgentName + "-shared-api-key", Namespace: AgentNamespace}
+			synced := &corev1.Secret{}
+
+			Eventually(func() string {
+				err := k8sClient.Get(ctx, copyLookupKey, synced)
+				if err != nil {
+					return ""
+				}
+				return string(synced.Data["api-key"])
+			}, timeout, interval).Should(Equal("sk-original"))
+
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-shared", Namespace: AgentNamespace}
+			createdDeployment := &appsv1.Deployment{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+			for _, env := range createdDeployment.Spec.Template.Spec.Containers[0].Env {
+				if env.Name == "AGENT_API_KEY" {
+					Expect(env.ValueFrom.SecretKeyRef.Name).Should(Equal(AgentName + "-shared-api-key"))
+				}
+			}
+
+			By("Rotating the source secret")
+			source.Data["api-key"] = []byte("sk-rotated")
+			Expect(k8sClient.Update(ctx, source)).Should(Succeed())
+
+			Eventually(func() string {
+				err := k8sClient.Get(ctx, copyLookupKey, synced)
+				if err != nil {
+					return ""
+				}
+				return string(synced.Data["api-key"])
+			}, timeout, interval).Should(Equal("sk-rotated"))
+
+			By("Deleting the agent")
+			Expect(k8sClient.Delete(ctx, agent)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, copyLookupKey, synced)
+				return err != nil
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("Should fail an agent whose namespace is not granted", func() {
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-denied",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "openai",
+							},
+							Key: "api-key",
+						},
+						Namespace: "kube-system",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			agentLookupKey := types.NamespacedName{Name: AgentName + "-denied", Namespace: AgentNamespace}
+			createdAgent := &aiv1.Agent{}
+
+			Eventually(func() aiv1.AgentPhase {
+				err := k8sClient.Get(ctx, agentLookupKey, createdAgent)
+				if err != nil {
+					return ""
+				}
+				return createdAgent.Status.Phase
+			}, timeout, interval).Should(Equal(aiv1.AgentPhaseFailed))
+			Expect(createdAgent.Status.Message).Should(ContainSubstring("namespace kube-system is not allowed"))
+		})
+	})
+
+	Context("When protecting agents from node consolidation", func() {
+		It("Should annotate the pods and remove the annotations when disabled", func() {
+			By("Creating an Agent with doNotDisrupt")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-disruption",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Disruption: &aiv1.DisruptionConfig{
+						DoNotDisrupt: true,
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking the eviction annotations on the pod template")
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-disruption", Namespace: AgentNamespace}
+			createdDeployment := &appsv1.Deployment{}
+
+			Eventually(func() map[string]string {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				if err != nil {
+					return nil
+				}
+				return createdDeployment.Spec.Template.Annotations
+			}, timeout, interval).Should(And(
+				HaveKeyWithValue("cluster-autoscaler.kubernetes.io/safe-to-evict", "false"),
+				HaveKeyWithValue("karpenter.sh/do-not-disrupt", "true"),
+			))
+
+			By("Checking the PodDisruptionBudget")
+			pdbLookupKey := types.NamespacedName{Name: AgentName + "-disruption-pdb", Namespace: AgentNamespace}
+			createdPDB := &policyv1.PodDisruptionBudget{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, pdbLookupKey, createdPDB)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+			Expect(createdPDB.Spec.MaxUnavailable.IntValue()).Should(Equal(0))
+
+			By("Switching to one eviction at a time")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, agent)).Should(Succeed())
+			agent.Spec.Disruption = &aiv1.DisruptionConfig{ConsolidationPolicy: "OneAtATime"}
+			Expect(k8sClient.Update(ctx, agent)).Should(Succeed())
+
+			Eventually(func() map[string]string {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				if err != nil {
+					return map[string]string{"error": err.Error()}
+				}
+				return createdDeployment.Spec.Template.Annotations
+			}, timeout, interval).ShouldNot(Or(
+				HaveKey("cluster-autoscaler.kubernetes.io/safe-to-evict"),
+				HaveKey("karpenter.sh/do-not-disrupt"),
+				HaveKey("error"),
+			))
+
+			Eventually(func() int {
+				err := k8sClient.Get(ctx, pdbLookupKey, createdPDB)
+				if err != nil {
+					return -1
+				}
+				return createdPDB.Spec.MaxUnavailable.IntValue()
+			}, timeout, interval).Should(Equal(1))
+
+			By("Removing the disruption settings")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, agent)).Should(Succeed())
+			agent.Spec.Disruption = nil
+			Expect(k8sClient.Update(ctx, agent)).Should(Succeed())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, pdbLookupKey, createdPDB)
+				return err != nil
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("Should not annotate the pods by default", func() {
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-disruptable",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-disruptable", Namespace: AgentNamespace}
+			createdDeployment := &appsv1.Deployment{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+			Expect(createdDeployment.Spec.Template.Annotations).ShouldNot(HaveKey("cluster-autoscaler.kubernetes.io/safe-to-evict"))
+			Expect(createdDeployment.Spec.Template.Annotations).ShouldNot(HaveKey("karpenter.sh/do-not-disrupt"))
+		})
+	})
+
+	Context("When encrypting persisted conversations", func() {
+		It("Should mount the keys and roll the pods when a key changes", func() {
+			By("Creating the encryption key Secret")
+			ctx := context.Background()
+			keys := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-encryption-keys", Namespace: AgentNamespace},
+				Data: map[string][]byte{
+					"current":  []byte("0123456789abcdef0123456789abcdef"),
+					"previous": []byte("fedcba9876543210fedcba9876543210"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, keys)).Should(Succeed())
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-encryption",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Encryption: &aiv1.EncryptionConfig{
+						KeySecretRef: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "test-encryption-keys"},
+							Key:                  "current",
+						},
+						PreviousKeySecretRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "test-encryption-keys"},
+							Key:                  "previous",
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Checking the key volume and environment")
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-encryption", Namespace: AgentNamespace}
+			createdDeployment := &appsv1.Deployment{}
+
+			Eventually(func() string {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				if err != nil {
+					return ""
+				}
+				return createdDeployment.Spec.Template.Annotations["kubeagentic.ai/encryption-key-checksum"]
+			}, timeout, interval).ShouldNot(BeEmpty())
+			checksum := createdDeployment.Spec.Template.Annotations["kubeagentic.ai/encryption-key-checksum"]
+
+			var keyVolume *corev1.Volume
+			for i, volume := range createdDeployment.Spec.Template.Spec.Volumes {
+				if volume.Name == "encryption-keys" {
+					keyVolume = &createdDeployment.Spec.Template.Spec.Volumes[i]
+				}
+			}
+			Expect(keyVolume).ShouldNot(BeNil())
+			Expect(*keyVolume.Projected.DefaultMode).Should(Equal(int32(0400)))
+			Expect(keyVolume.Projected.Sources).Should(HaveLen(2))
+
+			container := createdDeployment.Spec.Template.Spec.Containers[0]
+			Expect(container.Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_ENCRYPTION_KEY_FILE", Value: "/etc/kubeagentic/encryption/key"}))
+			Expect(container.Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_ENCRYPTION_PREVIOUS_KEY_FILE", Value: "/etc/kubeagentic/encryption/previous-key"}))
+
+			By("Changing the previous key")
+			keys.Data["previous"] = []byte("00000000000000000000000000000000")
+			Expect(k8sClient.Update(ctx, keys)).Should(Succeed())
+
+			Eventually(func() string {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				if err != nil {
+					return ""
+				}
+				return createdDeployment.Spec.Template.Annotations["kubeagentic.ai/encryption-key-checksum"]
+			}, timeout, interval).ShouldNot(Equal(checksum))
+		})
+
+		It("Should reject a key that is too short", func() {
+			ctx := context.Background()
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-short-encryption-key", Namespace: AgentNamespace},
+				Data:       map[string][]byte{"key": []byte("too-short")},
+			})).Should(Succeed())
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-short-key",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Encryption: &aiv1.EncryptionConfig{
+						KeySecretRef: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "test-short-encryption-key"},
+							Key:                  "key",
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			agentLookupKey := types.NamespacedName{Name: AgentName + "-short-key", Namespace: AgentNamespace}
+			createdAgent := &aiv1.Agent{}
+
+			Eventually(func() aiv1.AgentPhase {
+				err := k8sClient.Get(ctx, agentLookupKey, createdAgent)
+				if err != nil {
+					return ""
+				}
+				return createdAgent.Status.Phase
+			}, timeout, interval).Should(Equal(aiv1.AgentPhaseFailed))
+			Expect(createdAgent.Status.Message).Should(ContainSubstring("must be at least 32 bytes"))
+		})
+	})
+
+	Context("When using the restricted security profile", func() {
+		It("Should render pods passing the restricted Pod Security Standard", func() {
+			By("Creating a restricted Agent")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-restricted",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					SecurityProfile: "restricted",
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Evaluating the pod template with the Pod Security Admission checks")
+			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-restricted", Namespace: AgentNamespace}
+			createdDeployment := &appsv1.Deployment{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, deploymentLookupKey, createdDeployment)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			result := evaluatePodSecurity(psaapi.LevelRestricted, &createdDeployment.Spec.Template.Spec)
+			Expect(result.Allowed).Should(BeTrue(), result.ForbiddenDetail())
+			Expect(*createdDeployment.Spec.Template.Spec.Containers[0].SecurityContext.ReadOnlyRootFilesystem).Should(BeTrue())
+		})
+	})
+
+	Context("When rolling out a change through a canary", func() {
+		It("Should run the change in a canary Deployment until it is promoted", func() {
+			By("Creating an Agent with a canary rollout")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-canary",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Replicas: int32Ptr(4),
+					Rollout: &aiv1.RolloutConfig{
+						Canary: &aiv1.CanaryStrategy{
+							Steps: []aiv1.CanaryStep{{Weight: 25}},
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			stableLookupKey := types.NamespacedName{Name: AgentName + "-canary", Namespace: AgentNamespace}
+			stable := &appsv1.Deployment{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, stableLookupKey, stable)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			By("Changing the model")
+			createdAgent := &aiv1.Agent{}
+			Expect(k8sClient.Get(ctx, stableLookupKey, createdAgent)).Should(Succeed())
+			createdAgent.Spec.Model = "gpt-4o"
+			Expect(k8sClient.Update(ctx, createdAgent)).Should(Succeed())
+
+			canaryLookupKey := types.NamespacedName{Name: AgentName + "-canary-canary", Namespace: AgentNamespace}
+			canary := &appsv1.Deployment{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, canaryLookupKey, canary)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(canary.Spec.Template.Labels).Should(HaveKeyWithValue("kubeagentic.ai/track", "canary"))
+			Expect(canary.Spec.Template.Labels).Should(HaveKeyWithValue("kubeagentic.ai/agent", AgentName+"-canary"))
+			Expect(*canary.Spec.Replicas).Should(Equal(int32(1)))
+			Expect(canary.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-4o"}))
+
+			Eventually(func() int32 {
+				if err := k8sClient.Get(ctx, stableLookupKey, stable); err != nil {
+					return 0
+				}
+				return *stable.Spec.Replicas
+			}, timeout, interval).Should(Equal(int32(3)))
+			Expect(stable.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-4"}))
+
+			Eventually(func() aiv1.RolloutPhase {
+				if err := k8sClient.Get(ctx, stableLookupKey, createdAgent); err != nil || createdAgent.Status.Rollout == nil {
+					return ""
+				}
+				return createdAgent.Status.Rollout.Phase
+			}, timeout, interval).Should(Equal(aiv1.RolloutProgressing))
+			Expect(createdAgent.Status.Rollout.CanaryWeight).Should(Equal(int32(25)))
+
+			By("Promoting the canary with the rollout annotation")
+			if createdAgent.Annotations == nil {
+				createdAgent.Annotations = map[string]string{}
+			}
+			createdAgent.Annotations["kubeagentic.ai/promote"] = "true"
+			Expect(k8sClient.Update(ctx, createdAgent)).Should(Succeed())
+
+			Eventually(func() bool {
+				if err := k8sClient.Get(ctx, stableLookupKey, stable); err != nil {
+					return false
+				}
+				for _, env := range stable.Spec.Template.Spec.Containers[0].Env {
+					if env.Name == "AGENT_MODEL" {
+						return env.Value == "gpt-4o" && *stable.Spec.Replicas == 4
+					}
+				}
+				return false
+			}, timeout, interval).Should(BeTrue())
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, canaryLookupKey, canary)
+				return err != nil
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(k8sClient.Get(ctx, stableLookupKey, createdAgent)).Should(Succeed())
+			Expect(createdAgent.Annotations).ShouldNot(HaveKey("kubeagentic.ai/promote"))
+			Expect(createdAgent.Status.Rollout.Phase).Should(Equal(aiv1.RolloutPromoted))
+		})
+	})
+
+	Context("When rolling out a change blue/green", func() {
+		It("Should switch the Service to a ready preview and back on abort", func() {
+			By("Creating an Agent with the blueGreen strategy")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-bluegreen",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Replicas: int32Ptr(2),
+					Rollout: &aiv1.RolloutConfig{
+						Strategy: "blueGreen",
+						BlueGreen: &aiv1.BlueGreenStrategy{
+							ScaleDownDelay: &metav1.Duration{Duration: 10 * time.Minute},
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			// No pods run in the test environment, so readiness is reported by hand.
+			markReady := func(key types.NamespacedName) {
+				Eventually(func() error {
+					deployment := &appsv1.Deployment{}
+					if err := k8sClient.Get(ctx, key, deployment); err != nil {
+						return err
+					}
+					replicas := *deployment.Spec.Replicas
+					deployment.Status.ObservedGeneration = deployment.Generation
+					deployment.Status.Replicas = replicas
+					deployment.Status.UpdatedReplicas = replicas
+					deployment.Status.ReadyReplicas = replicas
+					deployment.Status.AvailableReplicas = replicas
+					return k8sClient.Status().Update(ctx, deployment)
+				}, timeout, interval).Should(Succeed())
+			}
+			serviceRevision := func() string {
+				service := &corev1.Service{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: AgentName + "-bluegreen-service", Namespace: AgentNamespace}, service); err != nil {
+					return ""
+				}
+				return service.Spec.Selector["kubeagentic.ai/revision"]
+			}
+
+			stableLookupKey := types.NamespacedName{Name: AgentName + "-bluegreen", Namespace: AgentNamespace}
+			stable := &appsv1.Deployment{}
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, stableLookupKey, stable); err != nil {
+					return ""
+				}
+				return stable.Spec.Template.Labels["kubeagentic.ai/revision"]
+			}, timeout, interval).ShouldNot(BeEmpty())
+			blueRevision := stable.Annotations["kubeagentic.ai/template-hash"]
+			Expect(stable.Spec.Template.Labels["kubeagentic.ai/revision"]).Should(Equal(blueRevision))
+
+			By("Selecting the ready stable revision")
+			markReady(stableLookupKey)
+			Eventually(serviceRevision, timeout, interval).Should(Equal(blueRevision))
+
+			By("Changing the model")
+			createdAgent := &aiv1.Agent{}
+			Expect(k8sClient.Get(ctx, stableLookupKey, createdAgent)).Should(Succeed())
+			createdAgent.Spec.Model = "gpt-4o"
+			Expect(k8sClient.Update(ctx, createdAgent)).Should(Succeed())
+
+			previewLookupKey := types.NamespacedName{Name: AgentName + "-bluegreen-preview", Namespace: AgentNamespace}
+			preview := &appsv1.Deployment{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, previewLookupKey, preview)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			greenRevision := preview.Annotations["kubeagentic.ai/template-hash"]
+			Expect(greenRevision).ShouldNot(Equal(blueRevision))
+			Expect(*preview.Spec.Replicas).Should(Equal(int32(2)))
+			Expect(preview.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-4o"}))
+
+			previewService := &corev1.Service{}
+			Expect(k8sClient.Get(ctx, previewLookupKey, previewService)).Should(Succeed())
+			Expect(previewService.Spec.Selector).Should(HaveKeyWithValue("kubeagentic.ai/revision", greenRevision))
+
+			Expect(k8sClient.Get(ctx, stableLookupKey, stable)).Should(Succeed())
+			Expect(stable.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-4"}))
+			Expect(serviceRevision()).Should(Equal(blueRevision))
+
+			By("Waiting for promotion once the preview is ready")
+			markReady(previewLookupKey)
+			Eventually(func() aiv1.RolloutPhase {
+				if err := k8sClient.Get(ctx, stableLookupKey, createdAgent); err != nil || createdAgent.Status.Rollout == nil {
+					return ""
+				}
+				return createdAgent.Status.Rollout.Phase
+			}, timeout, interval).Should(Equal(aiv1.RolloutPaused))
+			Expect(createdAgent.Status.Rollout.ActiveRevision).Should(Equal(blueRevision))
+			Expect(createdAgent.Status.Rollout.PreviewRevision).Should(Equal(greenRevision))
+
+			By("Promoting the preview")
+			createdAgent.Annotations = map[string]string{"kubeagentic.ai/promote": "true"}
+			Expect(k8sClient.Update(ctx, createdAgent)).Should(Succeed())
+			Eventually(serviceRevision, timeout, interval).Should(Equal(greenRevision))
+
+			By("Aborting within the scale-down delay")
+			Expect(k8sClient.Get(ctx, stableLookupKey, createdAgent)).Should(Succeed())
+			Expect(createdAgent.Status.Rollout.ActiveRevision).Should(Equal(greenRevision))
+			createdAgent.Annotations = map[string]string{"kubeagentic.ai/abort": "true"}
+			Expect(k8sClient.Update(ctx, createdAgent)).Should(Succeed())
+			Eventually(serviceRevision, timeout, interval).Should(Equal(blueRevision))
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, previewLookupKey, preview)
+				return err != nil
+			}, timeout, interval).Should(BeTrue())
+			Expect(k8sClient.Get(ctx, stableLookupKey, createdAgent)).Should(Succeed())
+			Expect(createdAgent.Status.Rollout.Phase).Should(Equal(aiv1.RolloutAborted))
+		})
+	})
+
+	Context("When a change fails to roll out", func() {
+		It("Should restore the previous pod template and leave the spec untouched", func() {
+			By("Creating an Agent with automatic rollback")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-rollback",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Rollout: &aiv1.RolloutConfig{
+						AutoRollback: &aiv1.AutoRollbackConfig{
+							Enabled: true,
+							Window:  &metav1.Duration{Duration: time.Hour},
+						},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			lookupKey := types.NamespacedName{Name: AgentName + "-rollback", Namespace: AgentNamespace}
+			agentModel := func() string {
+				deployment := &appsv1.Deployment{}
+				if err := k8sClient.Get(ctx, lookupKey, deployment); err != nil {
+					return ""
+				}
+				for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+					if env.Name == "AGENT_MODEL" {
+						return env.Value
+					}
+				}
+				return ""
+			}
+			Eventually(agentModel, timeout, interval).Should(Equal("gpt-4"))
+
+			By("Changing the model")
+			createdAgent := &aiv1.Agent{}
+			Expect(k8sClient.Get(ctx, lookupKey, createdAgent)).Should(Succeed())
+			createdAgent.Spec.Model = "gpt-4o"
+			Expect(k8sClient.Update(ctx, createdAgent)).Should(Succeed())
+			Eventually(agentModel, timeout, interval).Should(Equal("gpt-4o"))
+			Eventually(func() bool {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil || createdAgent.Status.AutoRollback == nil {
+					return false
+				}
+				return createdAgent.Status.AutoRollback.WatchStartTime != nil
+			}, timeout, interval).Should(BeTrue())
+
+			By("Failing the rollout")
+			// No pods run in the test environment, so the failure is reported by hand.
+			Eventually(func() error {
+				deployment := &appsv1.Deployment{}
+				if err := k8sClient.Get(ctx, lookupKey, deployment); err != nil {
+					return err
+				}
+				deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+					Type:    appsv1.DeploymentProgressing,
+					Status:  corev1.ConditionFalse,
+					Reason:  "ProgressDeadlineExceeded",
+					Message: "ReplicaSet has timed out progressing.",
+				}}
+				return k8sClient.Status().Update(ctx, deployment)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(agentModel, timeout, interval).Should(Equal("gpt-4"))
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+					return ""
+				}
+				for _, condition := range createdAgent.Status.Conditions {
+					if condition.Type == aiv1.AgentConditionDegraded && condition.Status == corev1.ConditionTrue {
+						return condition.Reason
+					}
+				}
+				return ""
+			}, timeout, interval).Should(Equal("AutoRolledBack"))
+			Expect(createdAgent.Spec.Model).Should(Equal("gpt-4o"))
+			Expect(createdAgent.Status.AutoRollback.RolledBackRevision).ShouldNot(BeEmpty())
+
+			By("Keeping the restored template across reconciles")
+			Consistently(agentModel, time.Second*2, interval).Should(Equal("gpt-4"))
+		})
+	})
+
+	Context("When running an A/B experiment", func() {
+		It("Should split the agent pods between the two variants", func() {
+			By("Creating an Agent with an experiment")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-experiment",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4o",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Replicas: int32Ptr(2),
+					Experiment: &aiv1.ExperimentConfig{
+						VariantB: aiv1.ExperimentVariant{
+							Model: "gpt-4o-mini",
+						},
+						TrafficPercent: 50,
+						Duration:       metav1.Duration{Duration: time.Hour},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			variantLookupKey := types.NamespacedName{Name: AgentName + "-experiment-variant-b", Namespace: AgentNamespace}
+			variant := &appsv1.Deployment{}
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, variantLookupKey, variant)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			Expect(*variant.Spec.Replicas).Should(Equal(int32(1)))
+			Expect(variant.Spec.Template.Labels).Should(HaveKeyWithValue("kubeagentic.ai/variant", "b"))
+			Expect(variant.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-4o-mini"}))
+			Expect(variant.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_VARIANT", Value: "b"}))
+
+			stable := &appsv1.Deployment{}
+			Eventually(func() int32 {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: AgentName + "-experiment", Namespace: AgentNamespace}, stable); err != nil {
+					return 0
+				}
+				return *stable.Spec.Replicas
+			}, timeout, interval).Should(Equal(int32(1)))
+			Expect(stable.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-4o"}))
+		})
+
+		It("Should reject an experiment combined with a rollout", func() {
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-experiment-rollout",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4o",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Rollout: &aiv1.RolloutConfig{Strategy: "blueGreen"},
+					Experiment: &aiv1.ExperimentConfig{
+						VariantB: aiv1.ExperimentVariant{
+							Model: "gpt-4o-mini",
+						},
+						TrafficPercent: 50,
+						Duration:       metav1.Duration{Duration: time.Hour},
+					},
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			createdAgent := &aiv1.Agent{}
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, createdAgent); err != nil {
+					return ""
+				}
+				return createdAgent.Status.Message
+			}, timeout, interval).Should(ContainSubstring("cannot be combined"))
+			Expect(createdAgent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		})
+	})
+
+	Context("When keeping spec revisions", func() {
+		It("Should capture, prune and roll back revisions", func() {
+			By("Creating an Agent with a history limit of two")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-revisions",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					RevisionHistoryLimit: int32Ptr(2),
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			lookupKey := types.NamespacedName{Name: AgentName + "-revisions", Namespace: AgentNamespace}
+			createdAgent := &aiv1.Agent{}
+			revisionNumbers := func() []int64 {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+					return nil
+				}
+				var numbers []int64
+				for _, revision := range createdAgent.Status.Revisions {
+					numbers = append(numbers, revision.Revision)
+				}
+				return numbers
+			}
+			Eventually(revisionNumbers, timeout, interval).Should(Equal([]int64{1}))
+			firstHash := createdAgent.Status.Revisions[0].Hash
+
+			revision := &appsv1.ControllerRevision{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: AgentName + "-revisions-" + firstHash, Namespace: AgentNamespace}, revision)).Should(Succeed())
+			Expect(metav1.IsControlledBy(revision, createdAgent)).Should(BeTrue())
+
+			changeModel := func(model string) {
+				Eventually(func() error {
+					if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+						return err
+					}
+					createdAgent.Spec.Model = model
+					return k8sClient.Update(ctx, createdAgent)
+				}, timeout, interval).Should(Succeed())
+			}
+
+			By("Capturing a changed spec")
+			changeModel("gpt-4o")
+			Eventually(revisionNumbers, timeout, interval).Should(Equal([]int64{2, 1}))
+			Expect(createdAgent.Status.Revisions[0].ChangeSummary).Should(Equal("Changed model"))
+
+			By("Pruning revisions beyond the limit")
+			changeModel("gpt-4o-mini")
+			Eventually(revisionNumbers, timeout, interval).Should(Equal([]int64{3, 2}))
+			Eventually(func() int {
+				revisions := &appsv1.ControllerRevisionList{}
+				if err := k8sClient.List(ctx, revisions, client.InNamespace(AgentNamespace), client.MatchingLabels{"kubeagentic.ai/agent": AgentName + "-revisions"}); err != nil {
+					return -1
+				}
+				return len(revisions.Items)
+			}, timeout, interval).Should(Equal(2))
+
+			By("Rolling back to revision 2")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+					return err
+				}
+				createdAgent.Annotations = map[string]string{"kubeagentic.ai/rollback-to": "2"}
+				return k8sClient.Update(ctx, createdAgent)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+					return ""
+				}
+				return createdAgent.Spec.Model
+			}, timeout, interval).Should(Equal("gpt-4o"))
+			Expect(createdAgent.Annotations).ShouldNot(HaveKey("kubeagentic.ai/rollback-to"))
+			Eventually(revisionNumbers, timeout, interval).Should(Equal([]int64{4, 3}))
+			Expect(createdAgent.Status.Revisions[0].ChangeSummary).Should(HavePrefix("Restored revision 2"))
+		})
+	})
+
+	Context("When warming up agent pods", func() {
+		It("Should configure the warm-up of the agent container", func() {
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-warmup",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Warmup: &aiv1.WarmupConfig{
+						Enabled: true,
+						Prompts: []string{"What are your opening hours?"},
+						Timeout: &metav1.Duration{Duration: time.Minute},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, deployment)
+			}, timeout, interval).Should(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Env).Should(ContainElements(
+				corev1.EnvVar{Name: "AGENT_WARMUP_COUNT", Value: "3"},
+				corev1.EnvVar{Name: "AGENT_WARMUP_TIMEOUT_SECONDS", Value: "60"},
+				corev1.EnvVar{Name: "AGENT_WARMUP_PROMPTS", Value: `["What are your opening hours?"]`},
+			))
+
+			By("Reporting no pods warming up without running pods")
+			createdAgent := &aiv1.Agent{}
+			Eventually(func() *aiv1.WarmupStatus {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, createdAgent); err != nil {
+					return nil
+				}
+				return createdAgent.Status.Warmup
+			}, timeout, interval).Should(Equal(&aiv1.WarmupStatus{}))
+		})
+	})
+
+	Context("When probing an agent with synthetic prompts", func() {
+		It("Should schedule the probe and record failed probes", func() {
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-synthetics",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					EndpointAuth: &aiv1.EndpointAuthConfig{GenerateKey: true},
+					Synthetics: &aiv1.SyntheticsConfig{
+						Enabled:           true,
+						Interval:          &metav1.Duration{Duration: 10 * time.Minute},
+						Prompt:            "What is the capital of France?",
+						ExpectedSubstring: "Paris",
+						FailureThreshold:  int32Ptr(1),
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			cronJob := &batchv1.CronJob{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name + "-synthetic-probe", Namespace: AgentNamespace}, cronJob)
+			}, timeout, interval).Should(Succeed())
+			Expect(cronJob.Spec.Schedule).Should(Equal("*/10 * * * *"))
+			container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+			Expect(container.Env).Should(ContainElements(
+				corev1.EnvVar{Name: "SYNTHETICS_URL", Value: "http://" + agent.Name + "-service." + AgentNamespace + ".svc/chat"},
+				corev1.EnvVar{Name: "SYNTHETICS_EXPECTED_SUBSTRING", Value: "Paris"},
+			))
+			Expect(container.Env).Should(ContainElement(HaveField("Name", "AGENT_ENDPOINT_TOKEN")))
+
+			By("Recording a failed probe")
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      agent.Name + "-synthetic-probe-1",
+					Namespace: AgentNamespace,
+					Labels:    cronJob.Spec.JobTemplate.Labels,
+				},
+				Spec: cronJob.Spec.JobTemplate.Spec,
+			}
+			Expect(k8sClient.Create(ctx, job)).Should(Succeed())
+			job.Status.Conditions = []batchv1.JobCondition{{
+				Type:               batchv1.JobFailed,
+				Status:             corev1.ConditionTrue,
+				LastTransitionTime: metav1.Now(),
+			}}
+			Expect(k8sClient.Status().Update(ctx, job)).Should(Succeed())
+
+			// Trigger a reconciliation instead of waiting for the probe interval
+			lookupKey := types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}
+			createdAgent := &aiv1.Agent{}
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+					return err
+				}
+				createdAgent.Annotations = map[string]string{"test/probe": "1"}
+				return k8sClient.Update(ctx, createdAgent)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() int32 {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil || createdAgent.Status.Synthetics == nil {
+					return 0
+				}
+				return createdAgent.Status.Synthetics.ConsecutiveFailures
+			}, timeout, interval).Should(Equal(int32(1)))
+			Expect(createdAgent.Status.Conditions).Should(ContainElement(And(
+				HaveField("Type", aiv1.AgentConditionSyntheticProbeHealthy),
+				HaveField("Status", corev1.ConditionFalse),
+			)))
+			Expect(createdAgent.Status.Conditions).Should(ContainElement(And(
+				HaveField("Type", aiv1.AgentConditionDegraded),
+				HaveField("Reason", "SyntheticProbeFailing"),
+			)))
+		})
+	})
+
+	Context("When capping the agent spend", func() {
+		It("Should track the spend of the current budget period", func() {
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-budget",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Budget: &aiv1.BudgetConfig{
+						Amount: "100",
+						Period: "daily",
+						Action: "suspend",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			lookupKey := types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}
+			createdAgent := &aiv1.Agent{}
+			Eventually(func() *aiv1.BudgetStatus {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+					return nil
+				}
+				return createdAgent.Status.Budget
+			}, timeout, interval).ShouldNot(BeNil())
+			Expect(createdAgent.Status.Budget.Spend).Should(Equal("0.0000"))
+			Expect(createdAgent.Status.Budget.Currency).Should(Equal("USD"))
+			Expect(createdAgent.Status.Budget.ActionTaken).Should(BeEmpty())
+			Expect(createdAgent.Status.Budget.ResetTime.Time.Sub(createdAgent.Status.Budget.PeriodStart.Time)).Should(Equal(24 * time.Hour))
+			Expect(createdAgent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseBudgetExceeded))
+		})
+
+		It("Should reject a budget in another currency", func() {
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-budget-eur",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Budget: &aiv1.BudgetConfig{Amount: "100", Currency: "EUR"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			lookupKey := types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}
+			createdAgent := &aiv1.Agent{}
+			Eventually(func() aiv1.AgentPhase {
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+					return ""
+				}
+				return createdAgent.Status.Phase
+			}, timeout, interval).Should(Equal(aiv1.AgentPhaseFailed))
+		})
+	})
+
+	Context("When reporting readiness conditions", func() {
+		ctx := context.Background()
+
+		conditionOf := func(name string, conditionType aiv1.AgentConditionType) func() aiv1.AgentCondition {
+			return func() aiv1.AgentCondition {
+				agent := &aiv1.Agent{}
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: AgentNamespace}, agent); err != nil {
+					return aiv1.AgentCondition{}
+				}
+				for _, condition := range agent.Status.Conditions {
+					if condition.Type == conditionType {
+						return condition
+					}
+				}
+				return aiv1.AgentCondition{}
+			}
+		}
+
+		graphAgent := func(name, secretName string) *aiv1.Agent {
+			return &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Framework:    "langgraph",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: secretName,
+							},
+							Key: "api-key",
+						},
+					},
+					LanggraphConfig: &aiv1.LanggraphConfig{
+						GraphType: "sequential",
+						Nodes: []aiv1.WorkflowNode{
+							{Name: "analyze", Type: "llm", Prompt: "Analyze: {user_input}"},
+							{Name: "respond", Type: "llm", Prompt: "Respond: {analysis}"},
+						},
+						Edges: []aiv1.WorkflowEdge{
+							{From: "analyze", To: "summarize"},
+						},
+						Entrypoint: "analyze",
+					},
+				},
+			}
+		}
+
+		It("Should report a missing secret until it is created", func() {
+			agent := graphAgent(AgentName+"-missing-secret", "conditions-secret")
+			agent.Spec.LanggraphConfig.Edges[0].To = "respond"
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionSecretValid), timeout, interval).Should(And(
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", "SecretNotFound"),
+			))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionConfigValid)()).Should(HaveField("Status", corev1.ConditionTrue))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionReady)()).Should(And(
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", "SecretNotFound"),
+			))
+
+			By("Creating the secret")
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "conditions-secret", Namespace: AgentNamespace},
+				Data:       map[string][]byte{"api-key": []byte("sk-test")},
+			})).Should(Succeed())
+
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionSecretValid), timeout, interval).Should(And(
+				HaveField("Status", corev1.ConditionTrue),
+				HaveField("Reason", "SecretFound"),
+			))
+			// No pods run in the test environment, so neither the Deployment nor the Service is
+			// ready, and the Deployment keeps the agent from being Ready.
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionServiceReady), timeout, interval).Should(And(
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", "EndpointsNotReady"),
+			))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionDeploymentReady)()).Should(HaveField("Status", corev1.ConditionFalse))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionReady)()).Should(And(
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", "ReplicasNotReady"),
+			))
+		})
+
+		It("Should report an invalid graph until it is fixed", func() {
+			Expect(k8sClient.Create(ctx, &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "graph-secret", Namespace: AgentNamespace},
+				Data:       map[string][]byte{"api-key": []byte("sk-test")},
+			})).Should(Succeed())
+			agent := graphAgent(AgentName+"-invalid-graph", "graph-secret")
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionConfigValid), timeout, interval).Should(And(
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", "InvalidGraph"),
+				HaveField("Message", ContainSubstring(`"summarize"`)),
+			))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionSecretValid)()).Should(HaveField("Status", corev1.ConditionTrue))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionReady)()).Should(HaveField("Reason", "InvalidGraph"))
+
+			By("Fixing the edge")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, agent); err != nil {
+					return err
+				}
+				agent.Spec.LanggraphConfig.Edges[0].To = "respond"
+				return k8sClient.Update(ctx, agent)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionConfigValid), timeout, interval).Should(And(
+				HaveField("Status", corev1.ConditionTrue),
+				HaveField("Reason", "ConfigurationValid"),
+			))
+		})
+
+		It("Should keep reporting a missing secret while the graph is fixed", func() {
+			agent := graphAgent(AgentName+"-both-invalid", "absent-secret")
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionConfigValid), timeout, interval).Should(HaveField("Reason", "InvalidGraph"))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionSecretValid)()).Should(HaveField("Reason", "SecretNotFound"))
+
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, agent); err != nil {
+					return err
+				}
+				agent.Spec.LanggraphConfig.Edges[0].To = "respond"
+				return k8sClient.Update(ctx, agent)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionConfigValid), timeout, interval).Should(HaveField("Status", corev1.ConditionTrue))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionSecretValid)()).Should(And(
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", "SecretNotFound"),
+			))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionReady)()).Should(HaveField("Reason", "SecretNotFound"))
+		})
+
+		It("Should not require a secret for a self-hosted endpoint", func() {
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-secretless",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "meta-llama/Llama-2-7b-chat-hf",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.models.svc:8000/v1",
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionSecretValid), timeout, interval).Should(And(
+				HaveField("Status", corev1.ConditionTrue),
+				HaveField("Reason", "SecretNotRequired"),
+			))
+
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}, deployment)
+			}, timeout, interval).Should(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Env).ShouldNot(ContainElement(HaveField("Name", "AGENT_API_KEY")))
+		})
+
+		It("Should require a secret for a hosted provider", func() {
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-hosted-secretless",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "claude",
+					Model:        "claude-3-sonnet",
+					SystemPrompt: "You are a helpful AI assistant.",
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			Eventually(conditionOf(agent.Name, aiv1.AgentConditionSecretValid), timeout, interval).Should(And(
+				HaveField("Status", corev1.ConditionFalse),
+				HaveField("Reason", "SecretRequired"),
+			))
+			Expect(conditionOf(agent.Name, aiv1.AgentConditionReady)()).Should(HaveField("Reason", "SecretRequired"))
+		})
+	})
+
+	Context("When restarting an Agent", func() {
+		It("Should roll the pods once for each restart request", func() {
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-restart",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			lookupKey := types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}
+			deploymentKey := types.NamespacedName{Name: agent.Name, Namespace: AgentNamespace}
+			deployment := &appsv1.Deployment{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, deploymentKey, deployment)
+			}, timeout, interval).Should(Succeed())
+			Expect(deployment.Spec.Template.Annotations).ShouldNot(HaveKey("kubeagentic.ai/restartedAt"))
+
+			By("Requesting a restart")
+			Eventually(func() error {
+				createdAgent := &aiv1.Agent{}
+				if err := k8sClient.Get(ctx, lookupKey, createdAgent); err != nil {
+					return err
+				}
+				createdAgent.Annotations = map[string]string{"kubeagentic.ai/restartedAt": "2024-01-01T00:00:00Z"}
+				return k8sClient.Update(ctx, createdAgent)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, deploymentKey, deployment); err != nil {
+					return ""
+				}
+				return deployment.Spec.Template.Annotations["kubeagentic.ai/restartedAt"]
+			}, timeout, interval).Should(Equal("2024-01-01T00:00:00Z"))
+
+			By("Not rolling the pods again on later reconciliations")
+			generation := deployment.Generation
+			Consistently(func() int64 {
+				if err := k8sClient.Get(ctx, deploymentKey, deployment); err != nil {
+					return -1
+				}
+				return deployment.Generation
+			}, time.Second*2, interval).Should(Equal(generation))
+		})
+	})
+
+	Context("When updating an Agent", func() {
+		It("Should update the Deployment when spec changes", func() {
+			By("Creating an Agent")
+			ctx := context.Background()
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      AgentName + "-update",
+					Namespace: AgentNamespace,
+				},
+				Spec: aiv1.AgentSpec{
+					Provider: "openai",
+					Model:    "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
+						},
+					},
+					Framework: "direct",
+					Replicas:  int32Ptr(1),
+				},
+			}
+
+			Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+
+			By("Updating the Agent spec")
+			agentLookupKey := types.NamespacedName{Name: AgentName + "-update", Namespace: AgentNamespace}
+			createdAgent := &aiv1.Agent{}
+
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, agentLookupKey, createdAgent)
+				return err == nil
+			}, timeout, interval).Should(BeTrue())
+
+			// The controller updates the agent as well, adding its finalizer
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, agentLookupKey, createdAgent); err != nil {
+					return err
+				}
+				createdAgent.Spec.Replicas = int32Ptr(2)
+				return k8sClient.Update(ctx, createdAgent)
+			}, timeout, interval).Should(Succeed())
+
 			By("Checking that the Deployment is updated")
 			deploymentLookupKey := types.NamespacedName{Name: AgentName + "-update", Namespace: AgentNamespace}
 			updatedDeployment := &appsv1.Deployment{}
@@ -228,11 +2193,13 @@ var _ = Describe("Agent Controller", func() {
 					Provider: "openai",
 					Model:    "gpt-4",
 					SystemPrompt: "You are a helpful AI assistant.",
-					ApiSecretRef: corev1.SecretKeySelector{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: "test-secret",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{
+								Name: "test-secret",
+							},
+							Key: "api-key",
 						},
-						Key: "api-key",
 					},
 					Framework: "direct",
 					Replicas:  int32Ptr(1),
@@ -0,0 +1,251 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentctl"
+)
+
+// stubConnector connects agentctl chat to a fake agent server instead of port-forwarding.
+type stubConnector struct {
+	url       string
+	connected []string
+	stopped   int
+}
+
+func (s *stubConnector) Connect(ctx context.Context, agent *aiv1.Agent) (string, func(), error) {
+	s.connected = append(s.connected, agent.Namespace+"/"+agent.Name)
+	return s.url, func() { s.stopped++ }, nil
+}
+
+var _ = Describe("agentctl", func() {
+	var (
+		ctx      context.Context
+		out      *bytes.Buffer
+		now      time.Time
+		scheme   *runtime.Scheme
+		commands *agentctl.Commands
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		out = &bytes.Buffer{}
+		now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+		scheme = newScheme()
+	})
+
+	newAgent := func(namespace, name string) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: metav1.NewTime(now.Add(-3 * time.Hour))},
+			Spec:       aiv1.AgentSpec{Provider: "openai", Model: "gpt-4"},
+		}
+	}
+
+	withClient := func(objects ...client.Object) {
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+		commands = &agentctl.Commands{Client: fakeClient, Namespace: "team-a", Out: out, Now: func() time.Time { return now }}
+	}
+
+	Context("list", func() {
+		It("Should show the phase, provider and endpoint of the agents of the namespace", func() {
+			ready := newAgent("team-a", "support-bot")
+			ready.Status.Phase = aiv1.AgentPhaseRunning
+			exposed := newAgent("team-a", "public-bot")
+			exposed.Spec.Provider = "claude"
+			exposed.Status.ResourceNames = &aiv1.ResourceNames{Service: "public-bot-service", IngressHost: "bot.example.com"}
+			withClient(ready, exposed, newAgent("team-b", "other-bot"))
+
+			Expect(commands.List(ctx, false)).Should(Succeed())
+			lines := bytes.Split(bytes.TrimSpace(out.Bytes()), []byte("\n"))
+			Expect(lines).Should(HaveLen(3))
+			Expect(string(lines[0])).Should(MatchRegexp(`^NAME\s+PHASE\s+PROVIDER\s+MODEL\s+ENDPOINT\s+AGE$`))
+			Expect(string(lines[1])).Should(MatchRegexp(`^public-bot\s+<none>\s+claude\s+gpt-4\s+http://bot.example.com\s+3h$`))
+			Expect(string(lines[2])).Should(MatchRegexp(`^support-bot\s+Running\s+openai\s+gpt-4\s+http://support-bot-service.team-a.svc\s+3h$`))
+		})
+
+		It("Should show the agents of all namespaces", func() {
+			withClient(newAgent("team-a", "support-bot"), newAgent("team-b", "other-bot"))
+
+			Expect(commands.List(ctx, true)).Should(Succeed())
+			Expect(out.String()).Should(MatchRegexp(`(?m)^NAMESPACE\s+NAME\s+PHASE`))
+			Expect(out.String()).Should(MatchRegexp(`(?m)^team-a\s+support-bot\s`))
+			Expect(out.String()).Should(MatchRegexp(`(?m)^team-b\s+other-bot\s`))
+		})
+
+		It("Should say when the namespace has no agents", func() {
+			withClient(newAgent("team-b", "other-bot"))
+
+			Expect(commands.List(ctx, false)).Should(Succeed())
+			Expect(out.String()).Should(Equal("No agents found in namespace team-a.\n"))
+		})
+	})
+
+	Context("status", func() {
+		It("Should print the conditions and usage of the agent", func() {
+			agent := newAgent("team-a", "support-bot")
+			agent.Status.Phase = aiv1.AgentPhaseRunning
+			agent.Status.ReplicaStatus = aiv1.ReplicaStatus{Desired: 2, Ready: 2, Available: 2}
+			transition := metav1.NewTime(now.Add(-10 * time.Minute))
+			agent.Status.Conditions = []aiv1.AgentCondition{
+				{Type: aiv1.AgentConditionReady, Status: corev1.ConditionTrue, Reason: "DeploymentReady", Message: "All replicas are ready", LastTransitionTime: &transition},
+			}
+			agent.Status.Usage = &aiv1.UsageStatus{PromptTokens: 1200, CompletionTokens: 300, EstimatedCost: "0.0450", Currency: "USD"}
+			withClient(agent)
+
+			Expect(commands.Status(ctx, "support-bot")).Should(Succeed())
+			Expect(out.String()).Should(MatchRegexp(`(?m)^Phase:\s+Running$`))
+			Expect(out.String()).Should(MatchRegexp(`(?m)^Replicas:\s+2 desired, 2 ready, 2 available$`))
+			Expect(out.String()).Should(MatchRegexp(`(?m)^  Ready\s+True\s+DeploymentReady\s+10m\s+All replicas are ready$`))
+			Expect(out.String()).Should(MatchRegexp(`(?m)^  Prompt tokens:\s+1200$`))
+			Expect(out.String()).Should(MatchRegexp(`(?m)^  Completion tokens:\s+300$`))
+			Expect(out.String()).Should(MatchRegexp(`(?m)^  Estimated cost:\s+0.0450 USD$`))
+		})
+
+		It("Should fail for an unknown agent", func() {
+			withClient()
+
+			Expect(commands.Status(ctx, "missing-bot")).Should(MatchError(ContainSubstring("failed to get agent team-a/missing-bot")))
+		})
+	})
+
+	Context("chat", func() {
+		var (
+			connector *stubConnector
+			requests  []map[string]interface{}
+			tokens    []string
+		)
+
+		BeforeEach(func() {
+			requests, tokens = nil, nil
+			// A fake agent answering in server-sent chunks, as the agent runtime does
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				Expect(r.Method).Should(Equal(http.MethodPost))
+				Expect(r.URL.Path).Should(Equal("/v1/chat/completions"))
+				tokens = append(tokens, r.Header.Get("Authorization"))
+				if r.Header.Get("Authorization") == "Bearer wrong" {
+					http.Error(w, `{"error":"invalid token"}`, http.StatusUnauthorized)
+					return
+				}
+				var request map[string]interface{}
+				Expect(json.NewDecoder(r.Body).Decode(&request)).Should(Succeed())
+				requests = append(requests, request)
+
+				w.Header().Set("Content-Type", "text/event-stream")
+				for _, content := range []string{"Hello", ", how can", " I help?"} {
+					chunk := map[string]interface{}{"choices": []map[string]interface{}{{"delta": map[string]string{"content": content}}}}
+					data, _ := json.Marshal(chunk)
+					fmt.Fprintf(w, "data: %s\n\n", data)
+					w.(http.Flusher).Flush()
+				}
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			}))
+			DeferCleanup(server.Close)
+			connector = &stubConnector{url: server.URL}
+		})
+
+		It("Should stream the answer of the agent", func() {
+			withClient(newAgent("team-a", "support-bot"))
+			commands.Connector = connector
+
+			Expect(commands.Chat(ctx, "support-bot", "Hi", agentctl.ChatOptions{Conversation: "c-42"})).Should(Succeed())
+			Expect(out.String()).Should(Equal("Hello, how can I help?\n"))
+			Expect(connector.connected).Should(Equal([]string{"team-a/support-bot"}))
+			Expect(connector.stopped).Should(Equal(1))
+
+			Expect(tokens).Should(Equal([]string{""}))
+			Expect(requests).Should(HaveLen(1))
+			Expect(requests[0]).Should(HaveKeyWithValue("stream", true))
+			Expect(requests[0]).Should(HaveKeyWithValue("user", "c-42"))
+			Expect(requests[0]["messages"]).Should(Equal([]interface{}{map[string]interface{}{"role": "user", "content": "Hi"}}))
+		})
+
+		It("Should send the generated endpoint token", func() {
+			agent := newAgent("team-a", "support-bot")
+			agent.Spec.EndpointAuth = &aiv1.EndpointAuthConfig{GenerateKey: true}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "support-bot-endpoint-auth", Namespace: "team-a"},
+				Data:       map[string][]byte{"token": []byte("s3cr3t")},
+			}
+			withClient(agent, secret)
+			commands.Connector = connector
+
+			Expect(commands.Chat(ctx, "support-bot", "Hi", agentctl.ChatOptions{})).Should(Succeed())
+			Expect(tokens).Should(Equal([]string{"Bearer s3cr3t"}))
+			Expect(requests[0]).ShouldNot(HaveKey("user"))
+		})
+
+		It("Should report the error answered by the agent", func() {
+			agent := newAgent("team-a", "support-bot")
+			agent.Spec.EndpointAuth = &aiv1.EndpointAuthConfig{GenerateKey: true}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "support-bot-endpoint-auth", Namespace: "team-a"},
+				Data:       map[string][]byte{"token": []byte("wrong")},
+			}
+			withClient(agent, secret)
+			commands.Connector = connector
+
+			Expect(commands.Chat(ctx, "support-bot", "Hi", agentctl.ChatOptions{})).Should(MatchError(ContainSubstring("401 Unauthorized")))
+			Expect(connector.stopped).Should(Equal(1))
+		})
+
+		It("Should not connect before the endpoint token is generated", func() {
+			agent := newAgent("team-a", "support-bot")
+			agent.Spec.EndpointAuth = &aiv1.EndpointAuthConfig{GenerateKey: true}
+			withClient(agent)
+			commands.Connector = connector
+
+			Expect(commands.Chat(ctx, "support-bot", "Hi", agentctl.ChatOptions{})).Should(MatchError(ContainSubstring("failed to read the endpoint token")))
+			Expect(connector.connected).Should(BeEmpty())
+		})
+	})
+
+	It("Should list the agents stored by the API server", func() {
+		if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+			Skip("the API server of the commands is envtest, which needs KUBEBUILDER_ASSETS")
+		}
+		testEnv := &envtest.Environment{
+			CRDDirectoryPaths:     []string{filepath.Join("..", "crd")},
+			ErrorIfCRDPathMissing: true,
+		}
+		cfg, err := testEnv.Start()
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(testEnv.Stop)
+
+		apiClient, err := client.New(cfg, client.Options{Scheme: scheme})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(apiClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-a"}})).Should(Succeed())
+		agent := &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "support-bot", Namespace: "team-a"},
+			Spec:       aiv1.AgentSpec{Provider: "openai", Model: "gpt-4", SystemPrompt: "You are helpful.", ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai"}, Key: "api-key"}}},
+		}
+		Expect(apiClient.Create(ctx, agent)).Should(Succeed())
+		agent.Status.Phase = aiv1.AgentPhaseRunning
+		Expect(apiClient.Status().Update(ctx, agent)).Should(Succeed())
+
+		commands = &agentctl.Commands{Client: apiClient, Namespace: "team-a", Out: out}
+		Expect(commands.List(ctx, false)).Should(Succeed())
+		Expect(out.String()).Should(MatchRegexp(`(?m)^support-bot\s+Running\s+openai\s+gpt-4\s+http://support-bot-service.team-a.svc\s`))
+
+		out.Reset()
+		Expect(commands.Status(ctx, "support-bot")).Should(Succeed())
+		Expect(out.String()).Should(MatchRegexp(`(?m)^Phase:\s+Running$`))
+	})
+})
@@ -0,0 +1,84 @@
+package test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentmetrics"
+)
+
+var _ = Describe("Agent Metrics", func() {
+	const exposition = `# HELP kubeagentic_requests_total Chat requests handled by the agent
+# TYPE kubeagentic_requests_total counter
+kubeagentic_requests_total{agent="support"} 40.0
+# HELP kubeagentic_errors_total Chat requests that failed
+# TYPE kubeagentic_errors_total counter
+kubeagentic_errors_total{agent="support"} 2.0
+# HELP kubeagentic_response_duration_seconds Duration of chat requests
+# TYPE kubeagentic_response_duration_seconds histogram
+kubeagentic_response_duration_seconds_bucket{agent="support",le="1.0"} 10.0
+kubeagentic_response_duration_seconds_bucket{agent="support",le="+Inf"} 40.0
+kubeagentic_response_duration_seconds_sum{agent="support"} 80.0
+kubeagentic_response_duration_seconds_count{agent="support"} 40.0
+`
+
+	Context("When parsing the agent runtime metrics", func() {
+		It("Should read the request, error and latency counters", func() {
+			sample, err := agentmetrics.Parse(strings.NewReader(exposition))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sample.Requests).Should(Equal(40.0))
+			Expect(sample.Errors).Should(Equal(2.0))
+			Expect(sample.ErrorRate()).Should(Equal(0.05))
+			Expect(sample.AverageLatency()).Should(Equal(2 * time.Second))
+		})
+
+		It("Should read the prompt and completion token counters", func() {
+			sample, err := agentmetrics.Parse(strings.NewReader(exposition + `# TYPE kubeagentic_tokens_total counter
+kubeagentic_tokens_total{agent="support",type="prompt"} 1200.0
+kubeagentic_tokens_total{agent="support",type="completion"} 300.0
+`))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sample.PromptTokens).Should(Equal(1200.0))
+			Expect(sample.CompletionTokens).Should(Equal(300.0))
+		})
+
+		It("Should read the response cache counters", func() {
+			sample, err := agentmetrics.Parse(strings.NewReader(exposition + `# TYPE kubeagentic_cache_requests_total counter
+kubeagentic_cache_requests_total{agent="support",result="hit"} 25.0
+kubeagentic_cache_requests_total{agent="support",result="miss"} 15.0
+# TYPE kubeagentic_cache_saved_tokens_total counter
+kubeagentic_cache_saved_tokens_total{agent="support",type="prompt"} 5000.0
+kubeagentic_cache_saved_tokens_total{agent="support",type="completion"} 2000.0
+`))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sample.CacheHits).Should(Equal(25.0))
+			Expect(sample.CacheMisses).Should(Equal(15.0))
+			Expect(sample.CacheSavedPromptTokens).Should(Equal(5000.0))
+			Expect(sample.CacheSavedCompletionTokens).Should(Equal(2000.0))
+		})
+
+		It("Should return an empty sample without agent metrics", func() {
+			sample, err := agentmetrics.Parse(strings.NewReader("# TYPE up gauge\nup 1\n"))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(sample).Should(Equal(agentmetrics.Sample{}))
+			Expect(sample.ErrorRate()).Should(Equal(0.0))
+		})
+	})
+
+	Context("When comparing samples", func() {
+		It("Should return the increase over the window", func() {
+			start := agentmetrics.Sample{Requests: 10, Errors: 1, LatencySum: 5, LatencyCount: 10}
+			end := agentmetrics.Sample{Requests: 30, Errors: 3, LatencySum: 25, LatencyCount: 30}
+			Expect(end.Sub(start)).Should(Equal(agentmetrics.Sample{Requests: 20, Errors: 2, LatencySum: 20, LatencyCount: 20}))
+		})
+
+		It("Should treat lower counters as a restart", func() {
+			start := agentmetrics.Sample{Requests: 100, Errors: 4, LatencySum: 50, LatencyCount: 100}
+			end := agentmetrics.Sample{Requests: 5, Errors: 1, LatencySum: 2, LatencyCount: 5}
+			Expect(end.Sub(start)).Should(Equal(end))
+		})
+	})
+})
@@ -0,0 +1,185 @@
+package test
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/replicalimit"
+)
+
+var _ = Describe("Agent Autoscaling Ceiling", func() {
+	setEnv := func(name, value string) {
+		previous, had := os.LookupEnv(name)
+		DeferCleanup(func() {
+			if had {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+		if value == "" {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, value)
+		}
+	}
+
+	ceilingPolicy := func(name string, maxReplicas int32, maxRequests corev1.ResourceList) *aiv1.AgentPolicy {
+		return &aiv1.AgentPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aiv1.AgentPolicySpec{AutoscalingCeiling: &aiv1.AutoscalingCeiling{
+				MaxReplicas: &maxReplicas,
+				MaxRequests: maxRequests,
+			}},
+		}
+	}
+
+	podRequests := corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m"), corev1.ResourceMemory: resource.MustParse("256Mi")}
+
+	BeforeEach(func() {
+		for _, name := range []string{"MAX_AUTOSCALING_REPLICAS", "MAX_AUTOSCALING_CPU", "MAX_AUTOSCALING_MEMORY"} {
+			setEnv(name, "")
+		}
+	})
+
+	It("Should reject the maximums the admission webhook checks when they exceed a ceiling", func() {
+		setEnv("MAX_AUTOSCALING_REPLICAS", "40")
+		setEnv("MAX_AUTOSCALING_MEMORY", "4Gi")
+
+		ceiling := replicalimit.CeilingFor("default", nil)
+		maxReplicas, exceeded := ceiling.Clamp(2, 12, podRequests)
+		Expect(exceeded).Should(BeEmpty())
+		Expect(maxReplicas).Should(Equal(int32(12)))
+
+		maxReplicas, exceeded = ceiling.Clamp(2, 50, podRequests)
+		Expect(maxReplicas).Should(Equal(int32(16)))
+		Expect(exceeded).Should(ConsistOf(
+			"maxReplicas 50 exceeds the ceiling 40 of the operator",
+			"memory requested at 50 replicas, 12800Mi at 256Mi per pod, exceeds the ceiling 4Gi of the operator",
+		))
+
+		By("Taking the tightest ceiling of the AgentPolicies of the namespace")
+		ceiling = replicalimit.CeilingFor("default", []aiv1.AgentPolicy{
+			*ceilingPolicy("caps", 8, corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")}),
+		})
+		maxReplicas, exceeded = ceiling.Clamp(2, 30, podRequests)
+		Expect(maxReplicas).Should(Equal(int32(8)))
+		Expect(exceeded).Should(ConsistOf(
+			"maxReplicas 30 exceeds the ceiling 8 of AgentPolicy caps",
+			"cpu requested at 30 replicas, 3 at 100m per pod, exceeds the ceiling 2 of AgentPolicy caps",
+			"memory requested at 30 replicas, 7680Mi at 256Mi per pod, exceeds the ceiling 4Gi of the operator",
+		))
+
+		By("Never clamping below the minimum replicas")
+		maxReplicas, _ = ceiling.Clamp(10, 30, podRequests)
+		Expect(maxReplicas).Should(Equal(int32(10)))
+	})
+
+	Context("When a ceiling is lowered after the agent was created", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			recorder   *record.FakeRecorder
+			request    ctrl.Request
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			ceilingScheme := newScheme()
+
+			replicas := int32(4)
+			fakeClient = newFakeClientBuilder(ceilingScheme).
+				WithObjects(&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "scaled", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "vllm",
+						Model:        "llama-3-8b",
+						SystemPrompt: "You are a helpful AI assistant.",
+						Endpoint:     "http://vllm.default.svc:8000/v1",
+						Replicas:     &replicas,
+					},
+				}).
+				Build()
+			recorder = record.NewFakeRecorder(20)
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: ceilingScheme, Recorder: recorder}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "scaled", Namespace: "default"}}
+		})
+
+		reconcile := func() *aiv1.Agent {
+			return reconcileAgent(ctx, reconciler, request)
+		}
+
+		hpaMaxReplicas := func() int32 {
+			hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "scaled-hpa", Namespace: "default"}, hpa)).Should(Succeed())
+			return hpa.Spec.MaxReplicas
+		}
+
+		policyClamped := func(agent *aiv1.Agent) *aiv1.AgentCondition {
+			for i := range agent.Status.Conditions {
+				if agent.Status.Conditions[i].Type == aiv1.AgentConditionPolicyClamped {
+					return &agent.Status.Conditions[i]
+				}
+			}
+			return nil
+		}
+
+		drainEvents := func() []string {
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			return events
+		}
+
+		It("Should clamp the HPA, report PolicyClamped and record the clamping once", func() {
+			agent := reconcile()
+			Expect(hpaMaxReplicas()).Should(Equal(int32(12)))
+			Expect(policyClamped(agent)).Should(BeNil())
+			drainEvents()
+
+			policy := ceilingPolicy("caps", 6, nil)
+			Expect(fakeClient.Create(ctx, policy)).Should(Succeed())
+			agent = reconcile()
+			Expect(hpaMaxReplicas()).Should(Equal(int32(6)))
+			condition := policyClamped(agent)
+			Expect(condition).ShouldNot(BeNil())
+			Expect(condition.Status).Should(Equal(corev1.ConditionTrue))
+			Expect(condition.Reason).Should(Equal("MaxReplicasClamped"))
+			Expect(condition.Message).Should(Equal("HPA maxReplicas clamped from 12 to 6: maxReplicas 12 exceeds the ceiling 6 of AgentPolicy caps"))
+			Expect(drainEvents()).Should(ContainElement(
+				"Warning AutoscalingClamped HPA maxReplicas clamped from 12 to 6: maxReplicas 12 exceeds the ceiling 6 of AgentPolicy caps"))
+
+			By("Recording no new event while the clamping stays the same")
+			reconcile()
+			Expect(drainEvents()).ShouldNot(ContainElement(ContainSubstring("AutoscalingClamped")))
+
+			By("Clamping on the total memory requested at the maximum")
+			Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(policy), policy)).Should(Succeed())
+			policy.Spec.AutoscalingCeiling.MaxRequests = corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1280Mi")}
+			Expect(fakeClient.Update(ctx, policy)).Should(Succeed())
+			reconcile()
+			Expect(hpaMaxReplicas()).Should(Equal(int32(5)))
+
+			By("Clearing the condition once the policy is removed")
+			Expect(fakeClient.Delete(ctx, policy)).Should(Succeed())
+			agent = reconcile()
+			Expect(hpaMaxReplicas()).Should(Equal(int32(12)))
+			Expect(policyClamped(agent)).Should(BeNil())
+		})
+	})
+})
@@ -0,0 +1,176 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/backoff"
+)
+
+var _ = Describe("Failure Backoff", func() {
+	var failures *backoff.Backoff
+
+	BeforeEach(func() {
+		failures = &backoff.Backoff{Rand: func() float64 { return 0 }}
+	})
+
+	Context("When an agent keeps failing", func() {
+		It("Should double the delay up to the cap", func() {
+			var delays []time.Duration
+			for i := 0; i < 7; i++ {
+				delays = append(delays, failures.Next("agent-uid", 1))
+			}
+
+			Expect(delays).Should(Equal([]time.Duration{
+				2 * time.Minute,
+				4 * time.Minute,
+				8 * time.Minute,
+				16 * time.Minute,
+				30 * time.Minute,
+				30 * time.Minute,
+				30 * time.Minute,
+			}))
+			Expect(failures.Failures("agent-uid")).Should(Equal(7))
+		})
+
+		It("Should add jitter without exceeding the cap", func() {
+			failures.Rand = func() float64 { return 0.5 }
+
+			Expect(failures.Next("agent-uid", 1)).Should(Equal(2*time.Minute + 6*time.Second))
+			for i := 0; i < 5; i++ {
+				failures.Next("agent-uid", 1)
+			}
+			Expect(failures.Next("agent-uid", 1)).Should(Equal(30 * time.Minute))
+		})
+
+		It("Should track agents independently", func() {
+			failures.Next("broken-agent", 1)
+			failures.Next("broken-agent", 1)
+
+			Expect(failures.Next("other-agent", 1)).Should(Equal(2 * time.Minute))
+			Expect(failures.Next("broken-agent", 1)).Should(Equal(8 * time.Minute))
+		})
+	})
+
+	Context("When an agent recovers", func() {
+		It("Should start over from the base delay after a reset", func() {
+			failures.Next("agent-uid", 1)
+			failures.Next("agent-uid", 1)
+			failures.Reset("agent-uid")
+
+			Expect(failures.Failures("agent-uid")).Should(BeZero())
+			Expect(failures.Next("agent-uid", 1)).Should(Equal(2 * time.Minute))
+		})
+
+		It("Should start over when the spec changes", func() {
+			failures.Next("agent-uid", 1)
+			failures.Next("agent-uid", 1)
+
+			Expect(failures.Next("agent-uid", 2)).Should(Equal(2 * time.Minute))
+			Expect(failures.Failures("agent-uid")).Should(Equal(1))
+		})
+	})
+
+	It("Should use the configured base and cap", func() {
+		failures.Base = 10 * time.Second
+		failures.Max = 25 * time.Second
+
+		Expect(failures.Next("agent-uid", 1)).Should(Equal(10 * time.Second))
+		Expect(failures.Next("agent-uid", 1)).Should(Equal(20 * time.Second))
+		Expect(failures.Next("agent-uid", 1)).Should(Equal(25 * time.Second))
+	})
+})
+
+var _ = Describe("Failure Backoff Of Deleted Agents", func() {
+	// A rollout cannot be combined with an experiment, so the agent fails on every reconcile
+	brokenAgent := func() *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "openai",
+				Model:        "gpt-4o",
+				SystemPrompt: "You are a helpful AI assistant.",
+				ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+				Rollout:      &aiv1.RolloutConfig{Strategy: "blueGreen"},
+				Experiment: &aiv1.ExperimentConfig{
+					VariantB:       aiv1.ExperimentVariant{Model: "gpt-4o-mini"},
+					TrafficPercent: 50,
+					Duration:       metav1.Duration{Duration: time.Hour},
+				},
+			},
+		}
+	}
+
+	backoffMetric := func() (float64, bool) {
+		families, err := metrics.Registry.Gather()
+		Expect(err).ShouldNot(HaveOccurred())
+		for _, family := range families {
+			if family.GetName() != "kubeagentic_agent_failure_backoff_seconds" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels["namespace"] == "default" && labels["agent"] == "broken" {
+					return metric.GetGauge().GetValue(), true
+				}
+			}
+		}
+		return 0, false
+	}
+
+	It("Should forget the failures of an agent deleted without its finalizer", func() {
+		ctx := context.Background()
+		scheme := newScheme()
+		fakeClient := newFakeClientBuilder(scheme).
+			WithObjects(&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+				Data:       map[string][]byte{"api-key": []byte("sk-test")},
+			}, brokenAgent()).
+			Build()
+		reconciler := &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme, Recorder: record.NewFakeRecorder(100)}
+		request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "broken", Namespace: "default"}}
+
+		var result ctrl.Result
+		for i := 0; i < 3; i++ {
+			var err error
+			result, err = reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+		}
+		Expect(result.RequeueAfter).Should(BeNumerically(">=", 8*time.Minute))
+		_, found := backoffMetric()
+		Expect(found).Should(BeTrue())
+
+		By("Deleting the agent without running its finalizer")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Finalizers = nil
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		Expect(fakeClient.Delete(ctx, agent)).Should(Succeed())
+
+		result, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).Should(Equal(ctrl.Result{}))
+		_, found = backoffMetric()
+		Expect(found).Should(BeFalse())
+
+		By("Recreating an agent under the same name")
+		Expect(fakeClient.Create(ctx, brokenAgent())).Should(Succeed())
+		result, err = reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.RequeueAfter).Should(BeNumerically("<", 4*time.Minute))
+	})
+})
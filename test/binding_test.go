@@ -0,0 +1,172 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Binding Secret", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+		bindingKey types.NamespacedName
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		bindingScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(bindingScheme).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "billing", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+				},
+			}).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: bindingScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "billing", Namespace: "default"}}
+		bindingKey = types.NamespacedName{Name: "billing-binding", Namespace: "default"}
+	})
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	updateAgent := func(mutate func(agent *aiv1.Agent)) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		mutate(agent)
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	binding := func() map[string]string {
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, bindingKey, secret)).Should(Succeed())
+		Expect(secret.Type).Should(Equal(corev1.SecretType("servicebinding.io/agent")))
+		Expect(metav1.GetControllerOf(secret)).ShouldNot(BeNil())
+		Expect(metav1.GetControllerOf(secret).Name).Should(Equal("billing"))
+		data := map[string]string{}
+		for key, value := range secret.Data {
+			data[key] = string(value)
+		}
+		return data
+	}
+
+	endpointToken := func() string {
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "billing-endpoint-auth", Namespace: "default"}, secret)).Should(Succeed())
+		return string(secret.Data["token"])
+	}
+
+	It("Should publish the in-cluster URL of the agent", func() {
+		agent := reconcile()
+		Expect(agent.Status.Binding).ShouldNot(BeNil())
+		Expect(agent.Status.Binding.SecretName).Should(Equal("billing-binding"))
+		Expect(binding()).Should(Equal(map[string]string{
+			"type":     "agent",
+			"provider": "kubeagentic",
+			"host":     "billing-service.default.svc.cluster.local",
+			"port":     "80",
+			"uri":      "http://billing-service.default.svc.cluster.local",
+		}))
+	})
+
+	It("Should follow the URLs of the agent", func() {
+		reconcile()
+
+		By("Renaming the Service")
+		updateAgent(func(agent *aiv1.Agent) {
+			agent.Spec.NameOverrides = &aiv1.NameOverrides{Service: "billing-api"}
+		})
+		reconcile()
+		Expect(binding()).Should(HaveKeyWithValue("uri", "http://billing-api.default.svc.cluster.local"))
+		Expect(binding()).Should(HaveKeyWithValue("host", "billing-api.default.svc.cluster.local"))
+		Expect(binding()).ShouldNot(HaveKey("external-uri"))
+
+		By("Exposing the agent through an Ingress")
+		updateAgent(func(agent *aiv1.Agent) {
+			agent.Spec.ServiceType = corev1.ServiceTypeLoadBalancer
+		})
+		reconcile()
+		Expect(binding()).Should(HaveKeyWithValue("external-uri", "http://billing.default.local"))
+	})
+
+	It("Should carry the endpoint token through its rotations", func() {
+		updateAgent(func(agent *aiv1.Agent) {
+			agent.Spec.EndpointAuth = &aiv1.EndpointAuthConfig{GenerateKey: true}
+		})
+		reconcile()
+		first := endpointToken()
+		Expect(first).ShouldNot(BeEmpty())
+		Expect(binding()).Should(HaveKeyWithValue("token", first))
+
+		updateAgent(func(agent *aiv1.Agent) {
+			agent.Annotations = map[string]string{"kubeagentic.ai/rotate-endpoint-key": "1"}
+		})
+		reconcile()
+		second := endpointToken()
+		Expect(second).ShouldNot(Equal(first))
+		Expect(binding()).Should(HaveKeyWithValue("token", second))
+
+		By("Dropping the token with the endpoint auth")
+		updateAgent(func(agent *aiv1.Agent) {
+			agent.Spec.EndpointAuth = nil
+		})
+		reconcile()
+		Expect(binding()).ShouldNot(HaveKey("token"))
+	})
+
+	It("Should restore edits of the binding", func() {
+		reconcile()
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, bindingKey, secret)).Should(Succeed())
+		secret.Data["uri"] = []byte("http://elsewhere")
+		Expect(fakeClient.Update(ctx, secret)).Should(Succeed())
+
+		reconcile()
+		Expect(binding()).Should(HaveKeyWithValue("uri", "http://billing-service.default.svc.cluster.local"))
+	})
+
+	It("Should delete the binding when it is disabled", func() {
+		reconcile()
+		disabled := false
+		updateAgent(func(agent *aiv1.Agent) {
+			agent.Spec.Binding = &aiv1.BindingConfig{Enabled: &disabled}
+		})
+
+		agent := reconcile()
+		Expect(agent.Status.Binding).Should(BeNil())
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, bindingKey, &corev1.Secret{}))).Should(BeTrue())
+	})
+
+	It("Should not take over a Secret of the same name", func() {
+		Expect(fakeClient.Create(ctx, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "billing-binding", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("hunter2")},
+		})).Should(Succeed())
+
+		agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		Expect(agent.Status.Message).Should(ContainSubstring("secret billing-binding already exists and is not owned by the agent"))
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, bindingKey, secret)).Should(Succeed())
+		Expect(secret.Data).Should(Equal(map[string][]byte{"password": []byte("hunter2")}))
+	})
+})
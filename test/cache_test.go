@@ -0,0 +1,279 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Operator Cache", func() {
+	const lastApplied = "kubectl.kubernetes.io/last-applied-configuration"
+
+	var ctx context.Context
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should strip the managed fields and the applied configuration from cached objects", func() {
+		deployment := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name:          "legacy",
+			Annotations:   map[string]string{lastApplied: `{"kind":"Deployment"}`, "kubeagentic.ai/template-hash": "abc"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply}},
+		}}
+		out, err := controllers.StripCachedObject(deployment)
+		Expect(err).ShouldNot(HaveOccurred())
+		stripped := out.(*appsv1.Deployment)
+		Expect(stripped.ManagedFields).Should(BeEmpty())
+		Expect(stripped.Annotations).Should(Equal(map[string]string{"kubeagentic.ai/template-hash": "abc"}))
+
+		By("Keeping the applied configuration of the agents users apply")
+		agent := &aiv1.Agent{ObjectMeta: metav1.ObjectMeta{
+			Name:          "applied",
+			Annotations:   map[string]string{lastApplied: `{"kind":"Agent"}`},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl", Operation: metav1.ManagedFieldsOperationApply}},
+		}}
+		out, err = controllers.StripCachedObject(agent)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(out.(*aiv1.Agent).ManagedFields).Should(BeEmpty())
+		Expect(out.(*aiv1.Agent).Annotations).Should(HaveKey(lastApplied))
+	})
+
+	Context("When the cache only holds labelled objects", func() {
+		var (
+			apiClient  client.Client
+			apiReads   map[string]int
+			fallback   client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+		)
+
+		BeforeEach(func() {
+			cacheScheme := newScheme()
+
+			legacy := map[string]string{"app": "legacy-bot"}
+			apiClient = newFakeClientBuilder(cacheScheme).
+				WithObjects(
+					&aiv1.Agent{
+						ObjectMeta: metav1.ObjectMeta{Name: "legacy-bot", Namespace: "default"},
+						Spec: aiv1.AgentSpec{
+							Provider:     "vllm",
+							Model:        "llama-3-8b",
+							SystemPrompt: "You are a helpful AI assistant.",
+							Endpoint:     "http://vllm.default.svc:8000/v1",
+						},
+					},
+					// Created by an operator build that did not label its resources
+					&appsv1.Deployment{
+						ObjectMeta: metav1.ObjectMeta{Name: "legacy-bot", Namespace: "default"},
+						Spec: appsv1.DeploymentSpec{
+							Selector: &metav1.LabelSelector{MatchLabels: legacy},
+							Template: corev1.PodTemplateSpec{
+								ObjectMeta: metav1.ObjectMeta{Labels: legacy},
+								Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "agent", Image: "kubeagentic/agent:old"}}},
+							},
+						},
+					},
+					&corev1.Service{
+						ObjectMeta: metav1.ObjectMeta{Name: "legacy-bot-service", Namespace: "default"},
+						Spec:       corev1.ServiceSpec{Selector: legacy, Ports: []corev1.ServicePort{{Port: 80}}},
+					},
+				).
+				Build()
+
+			// The cache misses the objects of the label-filtered kinds the selector does not match
+			selector := controllers.ManagedSelector()
+			cached := interceptor.NewClient(apiClient.(client.WithWatch), interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					if err := c.Get(ctx, key, obj, opts...); err != nil {
+						return err
+					}
+					switch obj.(type) {
+					case *appsv1.Deployment, *corev1.Service, *corev1.ConfigMap:
+						if !selector.Matches(labels.Set(obj.GetLabels())) {
+							return apierrors.NewNotFound(schema.GroupResource{}, key.Name)
+						}
+					}
+					return nil
+				},
+			})
+
+			// The reads of the API server after a cache miss, by kind and name
+			apiReads = map[string]int{}
+			apiReader := interceptor.NewClient(apiClient.(client.WithWatch), interceptor.Funcs{
+				Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+					apiReads[fmt.Sprintf("%T %s", obj, key.Name)]++
+					return c.Get(ctx, key, obj, opts...)
+				},
+			})
+
+			fallback = controllers.NewCacheFallbackClient(cached, apiReader)
+			reconciler = &controllers.AgentReconciler{Client: fallback, Scheme: cacheScheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy-bot", Namespace: "default"}}
+		})
+
+		It("Should find the unlabelled children of an agent and label them", func() {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(apiClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			Expect(controllers.ManagedSelector().Matches(labels.Set(deployment.Labels))).Should(BeTrue())
+			By("Keeping the immutable selector of the adopted Deployment")
+			Expect(deployment.Spec.Selector.MatchLabels).Should(Equal(map[string]string{"app": "legacy-bot"}))
+
+			service := &corev1.Service{}
+			Expect(apiClient.Get(ctx, types.NamespacedName{Name: "legacy-bot-service", Namespace: "default"}, service)).Should(Succeed())
+			Expect(service.Labels).Should(HaveKeyWithValue("app.kubernetes.io/name", "kubeagentic-agent"))
+		})
+
+		It("Should read a child missing from the API server once and the optional children never", func() {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(apiReads).Should(HaveKeyWithValue("*v1.Deployment legacy-bot", 1))
+			Expect(apiReads).ShouldNot(HaveKey(HavePrefix("*v1.NetworkPolicy")))
+			Expect(apiReads).ShouldNot(HaveKey(HavePrefix("*v1.PodDisruptionBudget")))
+			Expect(apiReads).ShouldNot(HaveKey(HavePrefix("*v1.CronJob")))
+
+			By("Reading the children from the cache once adopted or created")
+			firstReads := map[string]int{}
+			for key, reads := range apiReads {
+				firstReads[key] = reads
+			}
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(apiReads).Should(Equal(firstReads))
+
+			By("Treating an object the API server does not have either as absent from then on")
+			key := types.NamespacedName{Name: "never-created", Namespace: "default"}
+			for i := 0; i < 3; i++ {
+				Expect(apierrors.IsNotFound(fallback.Get(ctx, key, &corev1.ConfigMap{}))).Should(BeTrue())
+			}
+			Expect(apiReads).Should(HaveKeyWithValue("*v1.ConfigMap never-created", 1))
+		})
+	})
+
+	It("Should not cache the unlabelled objects of the owned kinds", func() {
+		if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+			Skip("the cache runs against envtest, which needs KUBEBUILDER_ASSETS")
+		}
+		testEnv := &envtest.Environment{}
+		cfg, err := testEnv.Start()
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(testEnv.Stop)
+
+		cacheScheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(cacheScheme)).Should(Succeed())
+		apiClient, err := client.New(cfg, client.Options{Scheme: cacheScheme})
+		Expect(err).ShouldNot(HaveOccurred())
+		for _, namespace := range []string{"kubeagentic-system", "team-a"} {
+			Expect(apiClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).Should(Succeed())
+		}
+		configMaps := []*corev1.ConfigMap{
+			{ObjectMeta: metav1.ObjectMeta{Name: "agent-config", Namespace: "team-a", Labels: map[string]string{"app.kubernetes.io/name": "kubeagentic-agent"},
+				Annotations: map[string]string{lastApplied: "{}"}}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "unrelated", Namespace: "team-a"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: controllers.PricingConfigMap, Namespace: "kubeagentic-system"}},
+		}
+		for _, configMap := range configMaps {
+			Expect(apiClient.Create(ctx, configMap)).Should(Succeed())
+		}
+
+		options := controllers.CacheOptions("kubeagentic-system")
+		options.Scheme = cacheScheme
+		operatorCache, err := cache.New(cfg, options)
+		Expect(err).ShouldNot(HaveOccurred())
+		cacheCtx, cancel := context.WithCancel(ctx)
+		DeferCleanup(cancel)
+		go func() {
+			defer GinkgoRecover()
+			Expect(operatorCache.Start(cacheCtx)).Should(Succeed())
+		}()
+		Expect(operatorCache.WaitForCacheSync(cacheCtx)).Should(BeTrue())
+
+		names := func() []string {
+			var list corev1.ConfigMapList
+			Expect(operatorCache.List(ctx, &list)).Should(Succeed())
+			var names []string
+			for _, configMap := range list.Items {
+				if configMap.Name != "kube-root-ca.crt" {
+					names = append(names, configMap.Name)
+				}
+			}
+			return names
+		}
+		Eventually(names, 10*time.Second).Should(ConsistOf("agent-config", controllers.PricingConfigMap))
+		Consistently(names, time.Second).ShouldNot(ContainElement("unrelated"))
+
+		cached := &corev1.ConfigMap{}
+		Expect(operatorCache.Get(ctx, types.NamespacedName{Name: "agent-config", Namespace: "team-a"}, cached)).Should(Succeed())
+		Expect(cached.ManagedFields).Should(BeEmpty())
+		Expect(cached.Annotations).ShouldNot(HaveKey(lastApplied))
+
+		By("Reading the unlabelled objects from the API server")
+		cachedClient, err := client.New(cfg, client.Options{Scheme: cacheScheme, Cache: &client.CacheOptions{Reader: operatorCache}})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(cachedClient.Get(ctx, types.NamespacedName{Name: "unrelated", Namespace: "team-a"}, &corev1.ConfigMap{})).ShouldNot(Succeed())
+		fallback := controllers.NewCacheFallbackClient(cachedClient, apiClient)
+		Expect(fallback.Get(ctx, types.NamespacedName{Name: "unrelated", Namespace: "team-a"}, &corev1.ConfigMap{})).Should(Succeed())
+	})
+
+	It("Should reconcile the agents waiting for an unlabelled ConfigMap once it is created", func() {
+		requireEnvtest()
+		agent := &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "few-shot", Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "vllm",
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.default.svc:8000/v1",
+				ExamplesRef: &corev1.ConfigMapKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "few-shot-examples"},
+					Key:                  "examples.yaml",
+				},
+			},
+		}
+		Expect(k8sClient.Create(ctx, agent)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, agent)
+
+		phase := func() aiv1.AgentPhase {
+			current := &aiv1.Agent{}
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(agent), current)).Should(Succeed())
+			return current.Status.Phase
+		}
+		Eventually(phase, 10*time.Second).Should(Equal(aiv1.AgentPhaseWaiting))
+
+		By("Creating the ConfigMap unlabelled through the cached client")
+		examples := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "few-shot-examples", Namespace: "default"},
+			Data:       map[string]string{"examples.yaml": "- input: Hello\n  output: Hi, how can I help?\n"},
+		}
+		Expect(managerClient.Create(ctx, examples)).Should(Succeed())
+		DeferCleanup(k8sClient.Delete, ctx, examples)
+		Expect(controllers.ManagedSelector().Matches(labels.Set(examples.Labels))).Should(BeFalse())
+
+		// The agent is requeued after minutes otherwise
+		Eventually(phase, 10*time.Second).ShouldNot(Equal(aiv1.AgentPhaseWaiting))
+	})
+})
@@ -0,0 +1,141 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Request Concurrency", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newReconciler := func(concurrency *aiv1.ConcurrencyConfig) {
+		scheme := newScheme()
+
+		replicas := int32(2)
+		fakeClient = newFakeClientBuilder(scheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "openai",
+						Model:        "gpt-4o",
+						SystemPrompt: "You are a helpful AI assistant.",
+						ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"},
+							Key:                  "api-key",
+						}},
+						Replicas:    &replicas,
+						Concurrency: concurrency,
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				},
+			).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	container := func() corev1.Container {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		return deployment.Spec.Template.Spec.Containers[0]
+	}
+
+	agentEnv := func() map[string]string {
+		env := map[string]string{}
+		for _, e := range container().Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+
+	hpaMetrics := func() []autoscalingv2.MetricSpec {
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-hpa", Namespace: "default"}, hpa)).Should(Succeed())
+		return hpa.Spec.Metrics
+	}
+
+	configValid := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionConfigValid {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should leave the runtime unbounded without spec.concurrency", func() {
+		newReconciler(nil)
+		reconcile()
+		Expect(agentEnv()).ShouldNot(HaveKey("AGENT_MAX_CONCURRENT_REQUESTS"))
+		Expect(container().ReadinessProbe.HTTPGet.Path).Should(Equal("/ready"))
+		Expect(hpaMetrics()).Should(HaveLen(2))
+	})
+
+	It("Should pass the bounds to the runtime with the default reject status", func() {
+		newReconciler(&aiv1.ConcurrencyConfig{MaxConcurrentRequests: 8, QueueLength: 16})
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionTrue))
+		env := agentEnv()
+		Expect(env).Should(HaveKeyWithValue("AGENT_MAX_CONCURRENT_REQUESTS", "8"))
+		Expect(env).Should(HaveKeyWithValue("AGENT_REQUEST_QUEUE_LENGTH", "16"))
+		Expect(env).Should(HaveKeyWithValue("AGENT_REJECT_STATUS_CODE", "503"))
+		Expect(container().ReadinessProbe.HTTPGet.Path).Should(Equal("/ready"))
+	})
+
+	It("Should probe readiness with backpressure and scale on the requests in flight", func() {
+		utilization := int32(75)
+		newReconciler(&aiv1.ConcurrencyConfig{
+			MaxConcurrentRequests: 8,
+			RejectStatusCode:      429,
+			BackpressureReadiness: true,
+			TargetUtilization:     &utilization,
+		})
+		reconcile()
+		Expect(agentEnv()).Should(HaveKeyWithValue("AGENT_REJECT_STATUS_CODE", "429"))
+		Expect(container().ReadinessProbe.HTTPGet.Path).Should(Equal("/ready?backpressure=true"))
+
+		metrics := hpaMetrics()
+		Expect(metrics).Should(HaveLen(3))
+		Expect(metrics[2].Type).Should(Equal(autoscalingv2.PodsMetricSourceType))
+		Expect(metrics[2].Pods.Metric.Name).Should(Equal(controllers.InFlightRequestsMetric))
+		Expect(metrics[2].Pods.Target.Type).Should(Equal(autoscalingv2.AverageValueMetricType))
+		Expect(metrics[2].Pods.Target.AverageValue.String()).Should(Equal("6"))
+	})
+
+	It("Should reject agents handling no request at once", func() {
+		newReconciler(&aiv1.ConcurrencyConfig{MaxConcurrentRequests: 0})
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid(agent).Reason).Should(Equal("InvalidConcurrencyConfig"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("maxConcurrentRequests must be at least 1"))
+	})
+})
@@ -0,0 +1,318 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/connectors"
+)
+
+// redirectTransport sends all requests to the server, whatever their host.
+type redirectTransport struct {
+	server *url.URL
+}
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.server.Scheme
+	req.URL.Host = t.server.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+var _ = Describe("Agent Connectors", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+		status     connectors.Status
+		sidecar    *httptest.Server
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		connectorsScheme := newScheme()
+
+		mention := false
+		fakeClient = newFakeClientBuilder(connectorsScheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "helpdesk", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "vllm",
+						Model:        "llama-3-8b",
+						SystemPrompt: "You are a helpful AI assistant.",
+						Endpoint:     "http://vllm.default.svc:8000/v1",
+						Connectors: []aiv1.AgentConnector{
+							{
+								Name:                 "support",
+								Type:                 "slack",
+								CredentialsSecretRef: corev1.LocalObjectReference{Name: "support-slack"},
+								Channels:             []string{"C0123456789"},
+							},
+							{
+								Name:                 "community",
+								Type:                 "discord",
+								CredentialsSecretRef: corev1.LocalObjectReference{Name: "community-discord"},
+								Trigger:              &aiv1.ConnectorTrigger{Mention: &mention, Prefix: "!ask"},
+							},
+						},
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "support-slack", Namespace: "default"},
+					Data:       map[string][]byte{"bot-token": []byte("xoxb-1"), "app-token": []byte("xapp-1")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "community-discord", Namespace: "default"},
+					Data:       map[string][]byte{"bot-token": []byte("discord-1")},
+				},
+				&corev1.Pod{
+					ObjectMeta: metav1.ObjectMeta{Name: "helpdesk-abc", Namespace: "default", Labels: map[string]string{"kubeagentic.ai/agent": "helpdesk"}},
+					Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.5"},
+				},
+			).
+			Build()
+
+		status = connectors.Status{Connectors: []connectors.ConnectorStatus{
+			{Name: "support", Type: "slack", State: connectors.StateConnected},
+			{Name: "community", Type: "discord", State: connectors.StateConnected},
+		}}
+		sidecar = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).Should(Equal("/status"))
+			Expect(r.Host).Should(Equal("10.0.0.5:8090"))
+			Expect(json.NewEncoder(w).Encode(status)).Should(Succeed())
+		}))
+		DeferCleanup(sidecar.Close)
+		server, err := url.Parse(sidecar.URL)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		reconciler = &controllers.AgentReconciler{
+			Client:           fakeClient,
+			Scheme:           connectorsScheme,
+			ConnectorChecker: &connectors.Checker{Client: &http.Client{Transport: redirectTransport{server: server}}},
+		}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "helpdesk", Namespace: "default"}}
+	})
+
+	reconcile := func() (*appsv1.Deployment, *aiv1.Agent) {
+		deployment := reconcileDeployment(ctx, reconciler, request)
+		current := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, current)).Should(Succeed())
+		return deployment, current
+	}
+
+	connectorConfigs := func() map[string]connectors.Config {
+		configMap := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "helpdesk-connectors", Namespace: "default"}, configMap)).Should(Succeed())
+		configs := map[string]connectors.Config{}
+		for key, data := range configMap.Data {
+			var config connectors.Config
+			Expect(json.Unmarshal([]byte(data), &config)).Should(Succeed())
+			configs[key] = config
+		}
+		return configs
+	}
+
+	findContainer := func(deployment *appsv1.Deployment, name string) *corev1.Container {
+		for i := range deployment.Spec.Template.Spec.Containers {
+			if deployment.Spec.Template.Spec.Containers[i].Name == name {
+				return &deployment.Spec.Template.Spec.Containers[i]
+			}
+		}
+		return nil
+	}
+
+	connectorsVolume := func(deployment *appsv1.Deployment) *corev1.Volume {
+		for i := range deployment.Spec.Template.Spec.Volumes {
+			if deployment.Spec.Template.Spec.Volumes[i].Name == "connectors" {
+				return &deployment.Spec.Template.Spec.Volumes[i]
+			}
+		}
+		return nil
+	}
+
+	connectorsReady := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionConnectorsReady {
+				return condition
+			}
+		}
+		Fail("condition ConnectorsReady not set")
+		return aiv1.AgentCondition{}
+	}
+
+	updateConnectors := func(update func(connectors []aiv1.AgentConnector) []aiv1.AgentConnector) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.Connectors = update(agent.Spec.Connectors)
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	It("Should render the configuration of each connector", func() {
+		deployment, _ := reconcile()
+		Expect(connectorConfigs()).Should(Equal(map[string]connectors.Config{
+			"support.json": {
+				Name:           "support",
+				Type:           "slack",
+				Channels:       []string{"C0123456789"},
+				Mention:        true,
+				DirectMessages: true,
+				BotTokenFile:   "/etc/kubeagentic/connectors/support/bot-token",
+				AppTokenFile:   "/etc/kubeagentic/connectors/support/app-token",
+			},
+			"community.json": {
+				Name:           "community",
+				Type:           "discord",
+				Channels:       []string{},
+				Mention:        false,
+				DirectMessages: true,
+				Prefix:         "!ask",
+				BotTokenFile:   "/etc/kubeagentic/connectors/community/bot-token",
+			},
+		}))
+
+		container := findContainer(deployment, "connectors")
+		Expect(container).ShouldNot(BeNil())
+		Expect(container.Command).Should(Equal([]string{"python", "connector.py"}))
+		Expect(container.ReadinessProbe).Should(BeNil())
+		Expect(container.Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_URL", Value: "http://127.0.0.1:8080"}))
+		Expect(container.VolumeMounts).Should(ContainElement(HaveField("MountPath", "/etc/kubeagentic/connectors")))
+
+		volume := connectorsVolume(deployment)
+		Expect(volume).ShouldNot(BeNil())
+		Expect(volume.Projected.Sources).Should(HaveLen(4))
+		Expect(volume.Projected.Sources[0].ConfigMap.Items).Should(Equal([]corev1.KeyToPath{{Key: "support.json", Path: "support/config.json"}}))
+		Expect(volume.Projected.Sources[1].Secret.Name).Should(Equal("support-slack"))
+		Expect(volume.Projected.Sources[1].Secret.Items).Should(Equal([]corev1.KeyToPath{
+			{Key: "bot-token", Path: "support/bot-token"},
+			{Key: "app-token", Path: "support/app-token"},
+		}))
+		Expect(volume.Projected.Sources[3].Secret.Items).Should(Equal([]corev1.KeyToPath{{Key: "bot-token", Path: "community/bot-token"}}))
+		Expect(deployment.Spec.Template.Annotations["kubeagentic.ai/connectors-checksum"]).ShouldNot(BeEmpty())
+	})
+
+	It("Should roll the pods when connector credentials are rotated", func() {
+		deployment, _ := reconcile()
+		checksum := deployment.Spec.Template.Annotations["kubeagentic.ai/connectors-checksum"]
+
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "community-discord", Namespace: "default"}, secret)).Should(Succeed())
+		secret.Data["bot-token"] = []byte("discord-2")
+		Expect(fakeClient.Update(ctx, secret)).Should(Succeed())
+
+		deployment, _ = reconcile()
+		Expect(deployment.Spec.Template.Annotations["kubeagentic.ai/connectors-checksum"]).ShouldNot(Equal(checksum))
+	})
+
+	It("Should report connected connectors", func() {
+		_, current := reconcile()
+		condition := connectorsReady(current)
+		Expect(condition.Status).Should(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).Should(Equal("ConnectorsConnected"))
+	})
+
+	It("Should report a failed connector without failing the agent", func() {
+		status.Connectors[0].State = connectors.StateFailed
+		status.Connectors[0].Error = "invalid_auth"
+
+		_, current := reconcile()
+		condition := connectorsReady(current)
+		Expect(condition.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).Should(Equal("ConnectorFailed"))
+		Expect(condition.Message).Should(Equal("connector support: invalid_auth"))
+		Expect(current.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+	})
+
+	It("Should report connectors still connecting", func() {
+		status.Connectors = status.Connectors[:1]
+
+		_, current := reconcile()
+		condition := connectorsReady(current)
+		Expect(condition.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).Should(Equal("ConnectorsConnecting"))
+		Expect(condition.Message).Should(ContainSubstring("community"))
+	})
+
+	Context("When the credentials are invalid", func() {
+		It("Should report a missing key without failing the agent", func() {
+			secret := &corev1.Secret{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-slack", Namespace: "default"}, secret)).Should(Succeed())
+			delete(secret.Data, "app-token")
+			Expect(fakeClient.Update(ctx, secret)).Should(Succeed())
+
+			deployment, current := reconcile()
+			condition := connectorsReady(current)
+			Expect(condition.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(condition.Reason).Should(Equal("ConnectorSecretInvalid"))
+			Expect(condition.Message).Should(Equal("connector support: secret support-slack has no app-token"))
+			Expect(current.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+			Expect(findContainer(deployment, "connectors")).ShouldNot(BeNil())
+		})
+
+		It("Should report a missing Secret", func() {
+			Expect(fakeClient.Delete(ctx, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "community-discord", Namespace: "default"}})).Should(Succeed())
+
+			_, current := reconcile()
+			condition := connectorsReady(current)
+			Expect(condition.Reason).Should(Equal("ConnectorSecretInvalid"))
+			Expect(condition.Message).Should(Equal("connector community: secret community-discord not found"))
+			Expect(current.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+		})
+	})
+
+	It("Should reject connectors sharing a name", func() {
+		updateConnectors(func(list []aiv1.AgentConnector) []aiv1.AgentConnector {
+			list[1].Name = "support"
+			return list
+		})
+
+		_, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		current := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, current)).Should(Succeed())
+		Expect(current.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		Expect(current.Status.Message).Should(ContainSubstring(`duplicate connector "support"`))
+	})
+
+	It("Should clean up the configuration of removed connectors", func() {
+		reconcile()
+		updateConnectors(func(list []aiv1.AgentConnector) []aiv1.AgentConnector {
+			return list[:1]
+		})
+
+		deployment, _ := reconcile()
+		Expect(connectorConfigs()).Should(HaveLen(1))
+		Expect(connectorConfigs()).Should(HaveKey("support.json"))
+		Expect(connectorsVolume(deployment).Projected.Sources).Should(HaveLen(2))
+
+		updateConnectors(func([]aiv1.AgentConnector) []aiv1.AgentConnector {
+			return nil
+		})
+
+		deployment, current := reconcile()
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "helpdesk-connectors", Namespace: "default"}, &corev1.ConfigMap{})
+		Expect(errors.IsNotFound(err)).Should(BeTrue())
+		Expect(findContainer(deployment, "connectors")).Should(BeNil())
+		Expect(connectorsVolume(deployment)).Should(BeNil())
+		Expect(deployment.Spec.Template.Annotations).ShouldNot(HaveKey("kubeagentic.ai/connectors-checksum"))
+		for _, condition := range current.Status.Conditions {
+			Expect(condition.Type).ShouldNot(Equal(aiv1.AgentConditionConnectorsReady))
+		}
+	})
+})
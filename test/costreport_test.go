@@ -0,0 +1,74 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/costreport"
+)
+
+func expectGolden(name string, actual []byte) {
+	expectGoldenIn("costreport", name, actual)
+}
+
+// Set UPDATE_GOLDEN=1 to rewrite the golden files after an intended rendering change.
+func expectGoldenIn(dir, name string, actual []byte) {
+	path := filepath.Join("testdata", dir, name)
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		Expect(os.WriteFile(path, actual, 0o644)).Should(Succeed())
+	}
+	expected, err := os.ReadFile(path)
+	Expect(err).ShouldNot(HaveOccurred())
+	Expect(string(actual)).Should(Equal(string(expected)))
+}
+
+var _ = Describe("Cost Report", func() {
+	start := time.Date(2024, 3, 11, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 0, 7)
+	agents := []costreport.AgentUsage{
+		{Namespace: "support", Name: "triage", Team: "customer-care", Provider: "openai", Model: "gpt-4o",
+			PromptTokens: 1200000, CompletionTokens: 300000, Cost: 6},
+		{Namespace: "research", Name: "summarizer", Team: "data", Provider: "claude", Model: "claude-3-5-sonnet-20241022",
+			PromptTokens: 500000, CompletionTokens: 100000, Cost: 3,
+			Partial: true, PartialReason: "metrics of 1 of 3 running pods unreachable"},
+		{Namespace: "support", Name: "faq", Provider: "vllm", Model: "llama-3-8b",
+			PromptTokens: 800000, CompletionTokens: 200000},
+		{Namespace: "research", Name: "experimental", Team: "data", Provider: "openai", Model: "gpt-next",
+			PromptTokens: 1000, CompletionTokens: 500, UnpricedTokens: 1500},
+	}
+
+	Context("When rendering a report", func() {
+		It("Should group the agents by namespace and team", func() {
+			report := costreport.New("weekly", start, end, start.Add(36*time.Hour), "USD", "kubeagentic.ai/team", agents)
+			Expect(report.Complete).Should(BeFalse())
+			Expect(report.Partial).Should(BeTrue())
+			Expect(report.Total.Cost).Should(Equal(9.0))
+			Expect(report.Teams).Should(HaveLen(3))
+			Expect(report.Teams[0].Name).Should(Equal(costreport.Unassigned))
+		})
+
+		It("Should match the golden JSON report", func() {
+			report := costreport.New("weekly", start, end, end, "USD", "kubeagentic.ai/team", agents)
+			data, err := report.JSON()
+			Expect(err).ShouldNot(HaveOccurred())
+			expectGolden("weekly.json", data)
+		})
+
+		It("Should match the golden text report", func() {
+			report := costreport.New("weekly", start, end, end, "USD", "kubeagentic.ai/team", agents)
+			expectGolden("weekly.txt", []byte(report.Text()))
+		})
+
+		It("Should render a report without agents", func() {
+			report := costreport.New("daily", start, start.AddDate(0, 0, 1), start.Add(time.Hour), "USD", "kubeagentic.ai/team", nil)
+			data, err := report.JSON()
+			Expect(err).ShouldNot(HaveOccurred())
+			expectGolden("empty.json", data)
+			expectGolden("empty.txt", []byte(report.Text()))
+		})
+	})
+})
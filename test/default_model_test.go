@@ -0,0 +1,55 @@
+package test
+
+import (
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/defaultmodel"
+)
+
+var _ = Describe("Default Models", func() {
+	setEnv := func(name, value string) {
+		previous, had := os.LookupEnv(name)
+		DeferCleanup(func() {
+			if had {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+		if value == "" {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, value)
+		}
+	}
+
+	BeforeEach(func() {
+		for _, provider := range []string{"openai", "claude", "gemini", "vllm", "ollama"} {
+			setEnv(defaultmodel.EnvVar(provider), "")
+		}
+	})
+
+	It("Should default the model of each hosted provider", func() {
+		Expect(defaultmodel.For("openai")).Should(Equal("gpt-4o-mini"))
+		Expect(defaultmodel.For("claude")).Should(Equal("claude-3-5-haiku-20241022"))
+		Expect(defaultmodel.For("gemini")).Should(Equal("gemini-1.5-flash"))
+	})
+
+	It("Should not default the model of self-hosted providers", func() {
+		Expect(defaultmodel.For("vllm")).Should(BeEmpty())
+		Expect(defaultmodel.For("ollama")).Should(BeEmpty())
+	})
+
+	It("Should prefer the operator configuration over the built-in defaults", func() {
+		Expect(defaultmodel.EnvVar("openai")).Should(Equal("DEFAULT_MODEL_OPENAI"))
+		setEnv("DEFAULT_MODEL_OPENAI", "gpt-4o")
+		setEnv("DEFAULT_MODEL_VLLM", "llama-3-8b")
+
+		Expect(defaultmodel.For("openai")).Should(Equal("gpt-4o"))
+		Expect(defaultmodel.For("vllm")).Should(Equal("llama-3-8b"))
+		Expect(defaultmodel.For("claude")).Should(Equal("claude-3-5-haiku-20241022"))
+	})
+})
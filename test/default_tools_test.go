@@ -0,0 +1,162 @@
+package test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/defaulttools"
+)
+
+var _ = Describe("Default Tools", func() {
+	ticketLookup := aiv1.Tool{Name: "ticket-lookup", Description: "Look up a support ticket by its ID"}
+	runbookSearch := aiv1.Tool{Name: "runbook-search", Description: "Search the operations runbooks"}
+	calculator := aiv1.Tool{Name: "calculator", Description: "Perform mathematical calculations"}
+
+	var agent *aiv1.Agent
+
+	BeforeEach(func() {
+		defaulttools.Set([]aiv1.Tool{ticketLookup, runbookSearch})
+		agent = &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-agent", Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "openai",
+				Model:        "gpt-4o",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Tools:        []aiv1.Tool{calculator},
+			},
+		}
+	})
+
+	AfterEach(func() {
+		defaulttools.Set(nil)
+	})
+
+	Context("When injecting the tools", func() {
+		It("Should append the default tools and annotate their names", func() {
+			defaulttools.Inject(agent, &agent.Spec)
+
+			Expect(agent.Spec.Tools).Should(Equal([]aiv1.Tool{calculator, ticketLookup, runbookSearch}))
+			Expect(agent.Annotations).Should(HaveKeyWithValue(defaulttools.Annotation, "ticket-lookup,runbook-search"))
+
+			By("Changing nothing when injecting again")
+			defaulttools.Inject(agent, &agent.Spec)
+			Expect(agent.Spec.Tools).Should(Equal([]aiv1.Tool{calculator, ticketLookup, runbookSearch}))
+			Expect(agent.Annotations).Should(HaveKeyWithValue(defaulttools.Annotation, "ticket-lookup,runbook-search"))
+		})
+
+		It("Should keep the tool of the agent over a default tool of the same name", func() {
+			ownLookup := aiv1.Tool{Name: "ticket-lookup", Description: "Look up a ticket of our own tracker"}
+			agent.Spec.Tools = append(agent.Spec.Tools, ownLookup)
+
+			defaulttools.Inject(agent, &agent.Spec)
+
+			Expect(agent.Spec.Tools).Should(Equal([]aiv1.Tool{calculator, ownLookup, runbookSearch}))
+			Expect(agent.Annotations).Should(HaveKeyWithValue(defaulttools.Annotation, "runbook-search"))
+		})
+
+		It("Should inject no tools into agents opting out", func() {
+			agent.Annotations = map[string]string{defaulttools.SkipAnnotation: "true"}
+
+			defaulttools.Inject(agent, &agent.Spec)
+
+			Expect(agent.Spec.Tools).Should(Equal([]aiv1.Tool{calculator}))
+			Expect(agent.Annotations).ShouldNot(HaveKey(defaulttools.Annotation))
+
+			By("Removing the tools injected before the agent opted out")
+			agent.Annotations = nil
+			defaulttools.Inject(agent, &agent.Spec)
+			Expect(agent.Spec.Tools).Should(HaveLen(3))
+			agent.Annotations[defaulttools.SkipAnnotation] = "true"
+			defaulttools.Inject(agent, &agent.Spec)
+			Expect(agent.Spec.Tools).Should(Equal([]aiv1.Tool{calculator}))
+			Expect(agent.Annotations).ShouldNot(HaveKey(defaulttools.Annotation))
+		})
+
+		It("Should follow changes of the default tools", func() {
+			defaulttools.Inject(agent, &agent.Spec)
+
+			updatedLookup := aiv1.Tool{Name: "ticket-lookup", Description: "Look up a support or incident ticket by its ID"}
+			defaulttools.Set([]aiv1.Tool{updatedLookup})
+			defaulttools.Inject(agent, &agent.Spec)
+
+			Expect(agent.Spec.Tools).Should(Equal([]aiv1.Tool{calculator, updatedLookup}))
+			Expect(agent.Annotations).Should(HaveKeyWithValue(defaulttools.Annotation, "ticket-lookup"))
+		})
+
+		It("Should leave out the default tools over the tool limit", func() {
+			agent.Spec.Tools = nil
+			for i := 0; i < aiv1.MaxTools-1; i++ {
+				agent.Spec.Tools = append(agent.Spec.Tools, aiv1.Tool{Name: fmt.Sprintf("tool-%d", i), Description: "A tool"})
+			}
+
+			defaulttools.Inject(agent, &agent.Spec)
+
+			Expect(agent.Spec.Tools).Should(HaveLen(aiv1.MaxTools))
+			Expect(agent.Spec.Tools[aiv1.MaxTools-1]).Should(Equal(ticketLookup))
+			Expect(agent.Annotations).Should(HaveKeyWithValue(defaulttools.Annotation, "ticket-lookup"))
+		})
+	})
+
+	Context("When parsing the tools", func() {
+		It("Should reject tools without a name or description and duplicate names", func() {
+			for _, config := range []string{
+				"tools:\n- name: ticket-lookup",
+				"tools:\n- description: Look up a ticket",
+				"tools:\n- {name: a, description: A}\n- {name: a, description: B}",
+				"tools:\n- {name: \"a,b\", description: A}",
+				"tool:\n- {name: a, description: A}",
+			} {
+				_, err := defaulttools.Parse([]byte(config))
+				Expect(err).Should(HaveOccurred(), config)
+			}
+		})
+	})
+
+	Context("When loading the ConfigMap", func() {
+		It("Should load the tools and keep them when the ConfigMap is broken", func() {
+			ctx := context.Background()
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: controllers.DefaultToolsConfigMap, Namespace: "kubeagentic-system"},
+				Data: map[string]string{"tools.yaml": `
+tools:
+- name: ticket-lookup
+  description: Look up a support ticket by its ID
+`},
+			}
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(configMap).Build()
+			reconciler := &controllers.DefaultToolsReconciler{Client: fakeClient, Namespace: "kubeagentic-system"}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: controllers.DefaultToolsConfigMap, Namespace: "kubeagentic-system"}}
+
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(defaulttools.Current()).Should(Equal([]aiv1.Tool{ticketLookup}))
+
+			By("Keeping the tools loaded last")
+			configMap.Data["tools.yaml"] = "tools: [not a tool"
+			Expect(fakeClient.Update(ctx, configMap)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(defaulttools.Current()).Should(Equal([]aiv1.Tool{ticketLookup}))
+
+			By("Injecting no tools without the ConfigMap")
+			Expect(fakeClient.Delete(ctx, configMap)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(defaulttools.Current()).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/dependency"
+)
+
+var _ = Describe("Agent Dependencies", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+	)
+
+	newAgent := func(name string, dependsOn ...aiv1.AgentDependency) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "vllm",
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.default.svc:8000/v1",
+				DependsOn:    dependsOn,
+			},
+		}
+	}
+
+	setup := func(objects ...client.Object) {
+		ctx = context.Background()
+		dependencyScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(dependencyScheme).
+			WithObjects(objects...).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: dependencyScheme}
+	}
+
+	reconcile := func(name string) *aiv1.Agent {
+		request := ctrl.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: "default"}}
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	replicas := func(name string) int32 {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, deployment)).Should(Succeed())
+		return *deployment.Spec.Replicas
+	}
+
+	condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == conditionType {
+				return condition
+			}
+		}
+		Fail("condition " + string(conditionType) + " not set")
+		return aiv1.AgentCondition{}
+	}
+
+	setRetrieverReady := func(status corev1.ConditionStatus) {
+		retriever := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "retriever", Namespace: "default"}, retriever)).Should(Succeed())
+		now := metav1.Now()
+		retriever.Status.Conditions = []aiv1.AgentCondition{{Type: aiv1.AgentConditionReady, Status: status, Reason: "Test", Message: "set by the test", LastTransitionTime: &now}}
+		Expect(fakeClient.Status().Update(ctx, retriever)).Should(Succeed())
+	}
+
+	It("Should hold the agent until its dependencies are ready and keep it running afterwards", func() {
+		minReady := int32(2)
+		setup(
+			newAgent("retriever"),
+			newAgent("responder",
+				aiv1.AgentDependency{Agent: "retriever"},
+				aiv1.AgentDependency{Service: "vector-store", MinReadyEndpoints: &minReady},
+			),
+			&corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: "vector-store", Namespace: "default"}},
+		)
+
+		By("Waiting for the agent and the Service")
+		responder := reconcile("responder")
+		Expect(replicas("responder")).Should(BeZero())
+		Expect(responder.Status.Phase).Should(Equal(aiv1.AgentPhaseWaitingForDependencies))
+		Expect(condition(responder, aiv1.AgentConditionDependenciesReady).Reason).Should(Equal("WaitingForDependencies"))
+		Expect(responder.Status.Dependencies).Should(HaveLen(2))
+		Expect(responder.Status.Dependencies[0]).Should(Equal(aiv1.DependencyStatus{
+			Kind: "Agent", Name: "retriever", Namespace: "default", Message: "Agent readiness not reported yet",
+		}))
+		Expect(responder.Status.Dependencies[1]).Should(Equal(aiv1.DependencyStatus{
+			Kind: "Service", Name: "vector-store", Namespace: "default", Message: "0 of 2 ready endpoints",
+		}))
+
+		By("Still waiting while only some dependencies are ready")
+		setRetrieverReady(corev1.ConditionTrue)
+		ready, notReady := true, false
+		Expect(fakeClient.Create(ctx, &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "vector-store-abc12",
+				Namespace: "default",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "vector-store"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints: []discoveryv1.Endpoint{
+				{Addresses: []string{"10.0.0.1"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}},
+				{Addresses: []string{"10.0.0.2"}, Conditions: discoveryv1.EndpointConditions{Ready: &notReady}},
+			},
+		})).Should(Succeed())
+		responder = reconcile("responder")
+		Expect(replicas("responder")).Should(BeZero())
+		Expect(responder.Status.Dependencies[0].Ready).Should(BeTrue())
+		Expect(responder.Status.Dependencies[1].Message).Should(Equal("1 of 2 ready endpoints"))
+
+		By("Releasing the agent once all dependencies are ready")
+		slice := &discoveryv1.EndpointSlice{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "vector-store-abc12", Namespace: "default"}, slice)).Should(Succeed())
+		slice.Endpoints[1].Conditions.Ready = &ready
+		Expect(fakeClient.Update(ctx, slice)).Should(Succeed())
+		responder = reconcile("responder")
+		Expect(replicas("responder")).Should(Equal(int32(1)))
+		Expect(responder.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseWaitingForDependencies))
+		Expect(condition(responder, aiv1.AgentConditionDependenciesReady).Status).Should(Equal(corev1.ConditionTrue))
+
+		By("Reporting a dependency that becomes unready without stopping the agent")
+		setRetrieverReady(corev1.ConditionFalse)
+		responder = reconcile("responder")
+		Expect(replicas("responder")).Should(Equal(int32(1)))
+		Expect(condition(responder, aiv1.AgentConditionDependenciesReady).Reason).Should(Equal("DependenciesNotReady"))
+	})
+
+	It("Should reject circular dependencies", func() {
+		setup(
+			newAgent("planner", aiv1.AgentDependency{Agent: "executor"}),
+			newAgent("executor", aiv1.AgentDependency{Agent: "critic"}),
+			newAgent("critic", aiv1.AgentDependency{Agent: "planner"}),
+		)
+
+		planner := reconcile("planner")
+		configValid := condition(planner, aiv1.AgentConditionConfigValid)
+		Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid.Reason).Should(Equal("InvalidDependencies"))
+		Expect(configValid.Message).Should(ContainSubstring("circular dependency: default/planner -> default/executor -> default/critic -> default/planner"))
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "planner", Namespace: "default"}, &appsv1.Deployment{})).ShouldNot(Succeed())
+	})
+
+	It("Should find cycles through the agent only", func() {
+		graph := map[string][]string{"a": {"b"}, "b": {"c", "d"}, "c": {"b"}, "d": {"a"}, "e": {"b"}, "self": {"self"}}
+		next := func(key dependency.Key) ([]dependency.Key, error) {
+			var keys []dependency.Key
+			for _, name := range graph[key.Name] {
+				keys = append(keys, dependency.Key{Namespace: "default", Name: name})
+			}
+			return keys, nil
+		}
+
+		cycle, err := dependency.FindCycle(dependency.Key{Namespace: "default", Name: "a"}, next)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(dependency.Describe(cycle)).Should(Equal("default/a -> default/b -> default/d -> default/a"))
+
+		cycle, err = dependency.FindCycle(dependency.Key{Namespace: "default", Name: "self"}, next)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(dependency.Describe(cycle)).Should(Equal("default/self -> default/self"))
+
+		By("Leaving the cycles that the agent only leads into to their own agents")
+		cycle, err = dependency.FindCycle(dependency.Key{Namespace: "default", Name: "e"}, next)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(cycle).Should(BeNil())
+	})
+})
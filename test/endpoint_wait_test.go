@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Model Endpoint Wait", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newReconciler := func(spec aiv1.AgentSpec, objects ...client.Object) {
+		waitScheme := newScheme()
+
+		objects = append(objects, &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "waiting-agent", Namespace: "default"},
+			Spec:       spec,
+		})
+		fakeClient = newFakeClientBuilder(waitScheme).
+			WithObjects(objects...).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: waitScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "waiting-agent", Namespace: "default"}}
+	}
+
+	initContainers := func() []corev1.Container {
+		deployment := reconcileDeployment(ctx, reconciler, request)
+		return deployment.Spec.Template.Spec.InitContainers
+	}
+
+	vllmSpec := func() aiv1.AgentSpec {
+		return aiv1.AgentSpec{
+			Provider:     "vllm",
+			Model:        "llama-3-8b",
+			SystemPrompt: "You are a helpful AI assistant.",
+			Endpoint:     "http://vllm.default.svc:8000/v1",
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		previous, had := os.LookupEnv("ENDPOINT_WAIT_IMAGE")
+		DeferCleanup(func() {
+			if had {
+				os.Setenv("ENDPOINT_WAIT_IMAGE", previous)
+			}
+		})
+		os.Unsetenv("ENDPOINT_WAIT_IMAGE")
+	})
+
+	It("Should wait for the endpoint of a self-hosted provider", func() {
+		spec := vllmSpec()
+		spec.WaitForEndpointTimeout = &metav1.Duration{Duration: 20 * time.Minute}
+		newReconciler(spec)
+
+		containers := initContainers()
+		Expect(containers).Should(HaveLen(1))
+		wait := containers[0]
+		Expect(wait.Name).Should(Equal("wait-for-endpoint"))
+		Expect(wait.Command).Should(Equal([]string{"python", "wait_for_endpoint.py"}))
+		Expect(wait.TerminationMessagePolicy).Should(Equal(corev1.TerminationMessageFallbackToLogsOnError))
+		env := map[string]string{}
+		for _, e := range wait.Env {
+			env[e.Name] = e.Value
+		}
+		Expect(env).Should(HaveKeyWithValue("AGENT_ENDPOINT", "http://vllm.default.svc:8000/v1"))
+		Expect(env).Should(HaveKeyWithValue("AGENT_ENDPOINT_WAIT_TIMEOUT_SECONDS", "1200"))
+	})
+
+	It("Should run the image of the operator when set", func() {
+		os.Setenv("ENDPOINT_WAIT_IMAGE", "kubeagentic/agent:v1")
+		DeferCleanup(os.Unsetenv, "ENDPOINT_WAIT_IMAGE")
+		newReconciler(vllmSpec())
+		Expect(initContainers()[0].Image).Should(Equal("kubeagentic/agent:v1"))
+	})
+
+	It("Should not wait for hosted providers unless requested", func() {
+		spec := vllmSpec()
+		spec.Provider = "openai"
+		spec.Model = "gpt-4o"
+		spec.ApiSecretRef = &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"},
+			Key:                  "api-key",
+		}}
+		newReconciler(spec, &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+			Data:       map[string][]byte{"api-key": []byte("sk-test")},
+		})
+		Expect(initContainers()).Should(BeEmpty())
+
+		By("Requesting the wait")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		wait := true
+		agent.Spec.WaitForEndpoint = &wait
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		Expect(initContainers()).Should(HaveLen(1))
+	})
+
+	It("Should not wait when disabled", func() {
+		spec := vllmSpec()
+		wait := false
+		spec.WaitForEndpoint = &wait
+		newReconciler(spec)
+		Expect(initContainers()).Should(BeEmpty())
+	})
+
+	It("Should report a failed wait in the agent message", func() {
+		newReconciler(vllmSpec(), &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "waiting-agent-7d9f-abcde", Namespace: "default", Labels: map[string]string{"kubeagentic.ai/agent": "waiting-agent"}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				InitContainerStatuses: []corev1.ContainerStatus{{
+					Name:         "wait-for-endpoint",
+					RestartCount: 2,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+					LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+						ExitCode: 1,
+						Message:  "last error from http://vllm.default.svc:8000/v1/models: Connection refused",
+					}},
+				}},
+			},
+		})
+		initContainers()
+
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhasePending))
+		Expect(agent.Status.Message).Should(And(
+			ContainSubstring("did not answer within 10m0s"),
+			ContainSubstring("Connection refused"),
+		))
+	})
+})
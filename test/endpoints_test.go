@@ -0,0 +1,143 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Endpoint Readiness", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		endpointScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(endpointScheme).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "router", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+				},
+			}).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: endpointScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "router", Namespace: "default"}}
+	})
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) aiv1.AgentCondition {
+		for _, c := range agent.Status.Conditions {
+			if c.Type == conditionType {
+				return c
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	markReplicasReady := func() {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		deployment.Status.Replicas = 1
+		deployment.Status.ReadyReplicas = 1
+		deployment.Status.AvailableReplicas = 1
+		Expect(fakeClient.Status().Update(ctx, deployment)).Should(Succeed())
+	}
+
+	createEndpoints := func(name string, ready ...bool) {
+		slice := &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "router-service"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+		}
+		for i := range ready {
+			slice.Endpoints = append(slice.Endpoints, discoveryv1.Endpoint{
+				Addresses:  []string{"10.0.0.1"},
+				Conditions: discoveryv1.EndpointConditions{Ready: &ready[i]},
+			})
+		}
+		Expect(fakeClient.Create(ctx, slice)).Should(Succeed())
+	}
+
+	It("Should wait for the pods to become endpoints while the replicas are not ready", func() {
+		agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhasePending))
+		Expect(condition(agent, aiv1.AgentConditionServiceReady).Reason).Should(Equal("EndpointsNotReady"))
+		Expect(condition(agent, aiv1.AgentConditionReady).Reason).Should(Equal("ReplicasNotReady"))
+		Expect(condition(agent, aiv1.AgentConditionDegraded).Reason).ShouldNot(Equal("NoEndpoints"))
+	})
+
+	It("Should not report ready replicas without endpoints as Running", func() {
+		reconcile()
+		markReplicasReady()
+
+		agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhasePending))
+		Expect(agent.Status.Message).Should(ContainSubstring("router-service"))
+		Expect(condition(agent, aiv1.AgentConditionDeploymentReady).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(condition(agent, aiv1.AgentConditionServiceReady).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(condition(agent, aiv1.AgentConditionServiceReady).Reason).Should(Equal("NoEndpoints"))
+		Expect(condition(agent, aiv1.AgentConditionReady).Reason).Should(Equal("NoEndpoints"))
+		Expect(condition(agent, aiv1.AgentConditionDegraded).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(condition(agent, aiv1.AgentConditionDegraded).Reason).Should(Equal("NoEndpoints"))
+
+		By("Counting the endpoints that are not ready yet")
+		createEndpoints("router-service-abcde", false)
+		agent = reconcile()
+		Expect(agent.Status.Endpoints).Should(Equal(aiv1.EndpointStatus{Ready: 0, NotReady: 1}))
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhasePending))
+		Expect(condition(agent, aiv1.AgentConditionReady).Reason).Should(Equal("NoEndpoints"))
+
+		By("Becoming Running once an endpoint is ready")
+		createEndpoints("router-service-fghij", true)
+		agent = reconcile()
+		Expect(agent.Status.Endpoints).Should(Equal(aiv1.EndpointStatus{Ready: 1, NotReady: 1}))
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseRunning))
+		Expect(condition(agent, aiv1.AgentConditionServiceReady).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(condition(agent, aiv1.AgentConditionReady).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(condition(agent, aiv1.AgentConditionDegraded).Status).Should(Equal(corev1.ConditionFalse))
+	})
+
+	It("Should report a Service whose selector does not match the agent pods", func() {
+		reconcile()
+		markReplicasReady()
+
+		By("Changing the selector of the Service, leaving it without endpoints")
+		service := &corev1.Service{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "router-service", Namespace: "default"}, service)).Should(Succeed())
+		service.Spec.Selector = map[string]string{"app": "router"}
+		Expect(fakeClient.Update(ctx, service)).Should(Succeed())
+		createEndpoints("router-service-abcde")
+
+		agent := reconcile()
+		Expect(agent.Status.Endpoints).Should(Equal(aiv1.EndpointStatus{}))
+		Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseRunning))
+		Expect(condition(agent, aiv1.AgentConditionDegraded).Reason).Should(Equal("NoEndpoints"))
+		Expect(condition(agent, aiv1.AgentConditionDegraded).Message).Should(ContainSubstring("selector"))
+	})
+})
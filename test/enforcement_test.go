@@ -0,0 +1,171 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Enforcement", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		recorder   *record.FakeRecorder
+		request    ctrl.Request
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		enforcementScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(enforcementScheme).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "enforced", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+				},
+			}).
+			Build()
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: enforcementScheme, Recorder: recorder}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "enforced", Namespace: "default"}}
+	})
+
+	reconcile := func() {
+		_, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+	}
+
+	deployment := func() *appsv1.Deployment {
+		found := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, found)).Should(Succeed())
+		return found
+	}
+
+	drainEvents := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		return events
+	}
+
+	It("Should revert an edited container image within one reconcile", func() {
+		reconcile()
+		reconcile()
+		image := deployment().Spec.Template.Spec.Containers[0].Image
+		drainEvents()
+
+		edited := deployment()
+		edited.Spec.Template.Spec.Containers[0].Image = "busybox:latest"
+		Expect(fakeClient.Update(ctx, edited)).Should(Succeed())
+
+		// The edit of the owned Deployment triggers the reconcile
+		reconcile()
+		Expect(deployment().Spec.Template.Spec.Containers[0].Image).Should(Equal(image))
+		Expect(drainEvents()).Should(ContainElement(
+			"Warning ManualChangeReverted Reverted out-of-band changes to Deployment enforced: spec.template.spec.containers[agent].image"))
+
+		By("Reverting nothing once the Deployment is restored")
+		reconcile()
+		Expect(drainEvents()).ShouldNot(ContainElement(ContainSubstring("ManualChangeReverted")))
+	})
+
+	It("Should leave the replicas set by the HPA and the annotations of other controllers", func() {
+		reconcile()
+		reconcile()
+		drainEvents()
+
+		scaled := deployment()
+		replicas := int32(4)
+		scaled.Spec.Replicas = &replicas
+		scaled.Annotations["deployment.kubernetes.io/revision"] = "3"
+		Expect(fakeClient.Update(ctx, scaled)).Should(Succeed())
+
+		reconcile()
+		Expect(*deployment().Spec.Replicas).Should(Equal(int32(4)))
+		Expect(deployment().Annotations).Should(HaveKeyWithValue("deployment.kubernetes.io/revision", "3"))
+		Expect(drainEvents()).ShouldNot(ContainElement(ContainSubstring("ManualChangeReverted")))
+	})
+
+	It("Should raise the replicas set by the HPA to a raised minimum", func() {
+		reconcile()
+		Expect(*deployment().Spec.Replicas).Should(Equal(int32(1)))
+
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.Replicas = int32Ptr(3)
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile()
+		Expect(*deployment().Spec.Replicas).Should(Equal(int32(3)))
+
+		scaled := deployment()
+		scaled.Spec.Replicas = int32Ptr(5)
+		Expect(fakeClient.Update(ctx, scaled)).Should(Succeed())
+		reconcile()
+		Expect(*deployment().Spec.Replicas).Should(Equal(int32(5)))
+	})
+
+	It("Should revert edits of the Service and ConfigMap, unless the agent is paused", func() {
+		reconcile()
+		reconcile()
+		drainEvents()
+
+		service := &corev1.Service{}
+		serviceKey := types.NamespacedName{Name: "enforced-service", Namespace: "default"}
+		Expect(fakeClient.Get(ctx, serviceKey, service)).Should(Succeed())
+		service.Spec.Type = corev1.ServiceTypeNodePort
+		Expect(fakeClient.Update(ctx, service)).Should(Succeed())
+		configMap := &corev1.ConfigMap{}
+		configMapKey := types.NamespacedName{Name: "enforced-config", Namespace: "default"}
+		Expect(fakeClient.Get(ctx, configMapKey, configMap)).Should(Succeed())
+		if configMap.Data == nil {
+			configMap.Data = map[string]string{}
+		}
+		configMap.Data["debug.json"] = "{}"
+		Expect(fakeClient.Update(ctx, configMap)).Should(Succeed())
+
+		reconcile()
+		Expect(fakeClient.Get(ctx, serviceKey, service)).Should(Succeed())
+		Expect(service.Spec.Type).Should(Equal(corev1.ServiceTypeClusterIP))
+		Expect(fakeClient.Get(ctx, configMapKey, configMap)).Should(Succeed())
+		Expect(configMap.Data).ShouldNot(HaveKey("debug.json"))
+		Expect(drainEvents()).Should(ContainElements(
+			"Warning ManualChangeReverted Reverted out-of-band changes to Service enforced-service: spec.type",
+			"Warning ManualChangeReverted Reverted out-of-band changes to ConfigMap enforced-config: data[debug.json]",
+		))
+
+		By("Keeping the edits of a paused agent")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Annotations = map[string]string{"kubeagentic.ai/paused": "true"}
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		edited := deployment()
+		edited.Spec.Template.Spec.Containers[0].Image = "busybox:latest"
+		Expect(fakeClient.Update(ctx, edited)).Should(Succeed())
+		reconcile()
+		Expect(deployment().Spec.Template.Spec.Containers[0].Image).Should(Equal("busybox:latest"))
+
+		By("Reverting them once resumed")
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		delete(agent.Annotations, "kubeagentic.ai/paused")
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile()
+		Expect(deployment().Spec.Template.Spec.Containers[0].Image).ShouldNot(Equal("busybox:latest"))
+	})
+})
@@ -0,0 +1,99 @@
+package test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/errorhistory"
+)
+
+var _ = Describe("Error History", func() {
+	var (
+		now     time.Time
+		history errorhistory.History
+	)
+
+	BeforeEach(func() {
+		now = time.Date(2024, 3, 14, 12, 0, 0, 0, time.UTC)
+		history = errorhistory.History{Size: 3, TTL: time.Hour}
+	})
+
+	reasons := func(entries []aiv1.AgentError) []string {
+		var result []string
+		for _, entry := range entries {
+			result = append(result, entry.Reason)
+		}
+		return result
+	}
+
+	Context("When recording errors", func() {
+		It("Should count consecutive identical errors in one entry", func() {
+			entries := history.Record(nil, now, "SecretNotFound", "secret openai not found")
+			entries = history.Record(entries, now.Add(time.Minute), "SecretNotFound", "secret openai not found")
+			entries = history.Record(entries, now.Add(2*time.Minute), "SecretNotFound", "secret openai not found")
+
+			Expect(entries).Should(HaveLen(1))
+			Expect(entries[0].Count).Should(Equal(int32(3)))
+			Expect(entries[0].Time.Time).Should(Equal(now.Add(2 * time.Minute)))
+		})
+
+		It("Should keep the sequence of a flapping agent, oldest first", func() {
+			entries := history.Record(nil, now, "SecretNotFound", "secret openai not found")
+			entries = history.Record(entries, now.Add(time.Minute), "InvalidGraph", "unknown node")
+			entries = history.Record(entries, now.Add(2*time.Minute), "SecretNotFound", "secret openai not found")
+
+			Expect(reasons(entries)).Should(Equal([]string{"SecretNotFound", "InvalidGraph", "SecretNotFound"}))
+			for _, entry := range entries {
+				Expect(entry.Count).Should(Equal(int32(1)))
+			}
+		})
+
+		It("Should not merge errors with the same reason but another message", func() {
+			entries := history.Record(nil, now, "DeploymentFailed", "quota exceeded")
+			entries = history.Record(entries, now.Add(time.Minute), "DeploymentFailed", "conflict")
+
+			Expect(entries).Should(HaveLen(2))
+		})
+
+		It("Should drop the oldest entries beyond the size", func() {
+			var entries []aiv1.AgentError
+			for i, reason := range []string{"First", "Second", "Third", "Fourth", "Fifth"} {
+				entries = history.Record(entries, now.Add(time.Duration(i)*time.Minute), reason, "failed")
+			}
+
+			Expect(reasons(entries)).Should(Equal([]string{"Third", "Fourth", "Fifth"}))
+		})
+
+		It("Should keep five entries by default", func() {
+			var entries []aiv1.AgentError
+			for i := 0; i < 8; i++ {
+				entries = errorhistory.History{}.Record(entries, now.Add(time.Duration(i)*time.Minute), "Failed", time.Duration(i).String())
+			}
+
+			Expect(entries).Should(HaveLen(errorhistory.DefaultSize))
+			Expect(entries[0].Message).Should(Equal(time.Duration(3).String()))
+		})
+	})
+
+	Context("When errors age", func() {
+		It("Should drop entries older than the TTL", func() {
+			entries := history.Record(nil, now, "First", "failed")
+			entries = history.Record(entries, now.Add(30*time.Minute), "Second", "failed")
+
+			Expect(reasons(history.Prune(entries, now.Add(45*time.Minute)))).Should(Equal([]string{"First", "Second"}))
+			Expect(reasons(history.Prune(entries, now.Add(time.Hour)))).Should(Equal([]string{"Second"}))
+			Expect(history.Prune(entries, now.Add(2*time.Hour))).Should(BeEmpty())
+		})
+
+		It("Should start a new entry when a repeated error expired", func() {
+			entries := history.Record(nil, now, "SecretNotFound", "secret openai not found")
+			entries = history.Record(entries, now.Add(2*time.Hour), "SecretNotFound", "secret openai not found")
+
+			Expect(entries).Should(HaveLen(1))
+			Expect(entries[0].Count).Should(Equal(int32(1)))
+		})
+	})
+})
@@ -0,0 +1,333 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Event Sources", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		request    ctrl.Request
+	)
+
+	scaledObjectGVK := schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}
+	triggerAuthenticationGVK := schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "TriggerAuthentication"}
+
+	newReconciler := func(kedaCRDs bool, spec aiv1.AgentSpec) *controllers.AgentReconciler {
+		eventScheme := newScheme()
+
+		mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "keda.sh", Version: "v1alpha1"}})
+		if kedaCRDs {
+			mapper.Add(scaledObjectGVK, meta.RESTScopeNamespace)
+			mapper.Add(triggerAuthenticationGVK, meta.RESTScopeNamespace)
+		}
+		for gvk := range eventScheme.AllKnownTypes() {
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+		}
+
+		spec.Provider = "vllm"
+		spec.Model = "llama-3-8b"
+		spec.SystemPrompt = "You are a helpful AI assistant."
+		spec.Endpoint = "http://vllm.default.svc:8000/v1"
+		fakeClient = newFakeClientBuilder(eventScheme).
+			WithRESTMapper(mapper).
+			WithStatusSubresource(&batchv1.Job{}).
+			WithObjects(
+				&aiv1.Agent{ObjectMeta: metav1.ObjectMeta{Name: "ticket-triage", Namespace: "default"}, Spec: spec},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "triage-kafka", Namespace: "default"},
+					Data:       map[string][]byte{"username": []byte("triage"), "password": []byte("s3cret")},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "triage-aws", Namespace: "default"},
+					Data:       map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("AKIA"), "AWS_SECRET_ACCESS_KEY": []byte("secret")},
+				},
+			).
+			Build()
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "ticket-triage", Namespace: "default"}}
+		return &controllers.AgentReconciler{Client: fakeClient, Scheme: eventScheme}
+	}
+
+	kafkaSource := func() *aiv1.EventSourceConfig {
+		concurrency := int32(4)
+		return &aiv1.EventSourceConfig{
+			Type: "kafka",
+			Kafka: &aiv1.KafkaEventSource{
+				Brokers:              []string{"10.0.0.9:9092", "10.0.0.10:9092"},
+				Topic:                "tickets",
+				SASLMechanism:        "SCRAM-SHA-512",
+				TLS:                  true,
+				CredentialsSecretRef: &corev1.LocalObjectReference{Name: "triage-kafka"},
+			},
+			Concurrency: &concurrency,
+			DeadLetter:  &aiv1.EventDeadLetterConfig{Target: "tickets-dlq"},
+		}
+	}
+
+	sqsSource := func() *aiv1.EventSourceConfig {
+		return &aiv1.EventSourceConfig{
+			Type: "sqs",
+			SQS: &aiv1.SQSEventSource{
+				QueueURL:             "https://sqs.eu-west-1.amazonaws.com/123456789012/tickets",
+				Region:               "eu-west-1",
+				CredentialsSecretRef: &corev1.LocalObjectReference{Name: "triage-aws"},
+			},
+		}
+	}
+
+	reconcile := func(reconciler *controllers.AgentReconciler) *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	agentEnv := func() map[string]corev1.EnvVar {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		env := map[string]corev1.EnvVar{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e
+		}
+		return env
+	}
+
+	condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) *aiv1.AgentCondition {
+		for i := range agent.Status.Conditions {
+			if agent.Status.Conditions[i].Type == conditionType {
+				return &agent.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should render the Kafka consumer configuration", func() {
+		reconcile(newReconciler(false, aiv1.AgentSpec{EventSource: kafkaSource()}))
+
+		env := agentEnv()
+		Expect(env["AGENT_MODE"].Value).Should(Equal("consumer"))
+		Expect(env["AGENT_EVENT_SOURCE"].Value).Should(Equal("kafka"))
+		Expect(env["AGENT_EVENT_CONCURRENCY"].Value).Should(Equal("4"))
+		Expect(env["AGENT_EVENT_MAX_ATTEMPTS"].Value).Should(Equal("3"))
+		Expect(env["AGENT_EVENT_DEAD_LETTER_TARGET"].Value).Should(Equal("tickets-dlq"))
+		Expect(env["KAFKA_BOOTSTRAP_SERVERS"].Value).Should(Equal("10.0.0.9:9092,10.0.0.10:9092"))
+		Expect(env["KAFKA_TOPIC"].Value).Should(Equal("tickets"))
+		Expect(env["KAFKA_CONSUMER_GROUP"].Value).Should(Equal("default.ticket-triage"))
+		Expect(env["KAFKA_TLS"].Value).Should(Equal("true"))
+		Expect(env["KAFKA_SASL_MECHANISM"].Value).Should(Equal("SCRAM-SHA-512"))
+		Expect(env["KAFKA_SASL_USERNAME"].ValueFrom.SecretKeyRef.Name).Should(Equal("triage-kafka"))
+		Expect(env["KAFKA_SASL_USERNAME"].ValueFrom.SecretKeyRef.Key).Should(Equal("username"))
+		Expect(env["KAFKA_SASL_PASSWORD"].ValueFrom.SecretKeyRef.Key).Should(Equal("password"))
+		Expect(env).ShouldNot(HaveKey("SQS_QUEUE_URL"))
+	})
+
+	It("Should render the SQS consumer configuration", func() {
+		reconcile(newReconciler(false, aiv1.AgentSpec{EventSource: sqsSource()}))
+
+		env := agentEnv()
+		Expect(env["AGENT_MODE"].Value).Should(Equal("consumer"))
+		Expect(env["AGENT_EVENT_SOURCE"].Value).Should(Equal("sqs"))
+		Expect(env["AGENT_EVENT_CONCURRENCY"].Value).Should(Equal("1"))
+		Expect(env).ShouldNot(HaveKey("AGENT_EVENT_DEAD_LETTER_TARGET"))
+		Expect(env["SQS_QUEUE_URL"].Value).Should(Equal("https://sqs.eu-west-1.amazonaws.com/123456789012/tickets"))
+		Expect(env["AWS_REGION"].Value).Should(Equal("eu-west-1"))
+		Expect(env["AWS_ACCESS_KEY_ID"].ValueFrom.SecretKeyRef.Name).Should(Equal("triage-aws"))
+		Expect(env["AWS_SECRET_ACCESS_KEY"].ValueFrom.SecretKeyRef.Key).Should(Equal("AWS_SECRET_ACCESS_KEY"))
+		Expect(env).ShouldNot(HaveKey("KAFKA_TOPIC"))
+	})
+
+	It("Should report the pre-flight connectivity check in EventSourceReachable", func() {
+		reconciler := newReconciler(false, aiv1.AgentSpec{EventSource: kafkaSource()})
+		agent := reconcile(reconciler)
+		Expect(condition(agent, aiv1.AgentConditionEventSourceReachable).Status).Should(Equal(corev1.ConditionUnknown))
+
+		job := &batchv1.Job{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-event-source-check", Namespace: "default"}, job)).Should(Succeed())
+		Expect(job.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "KAFKA_BOOTSTRAP_SERVERS", Value: "10.0.0.9:9092,10.0.0.10:9092"}))
+
+		By("Failing the check")
+		job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+		Expect(fakeClient.Status().Update(ctx, job)).Should(Succeed())
+		reached := condition(reconcile(reconciler), aiv1.AgentConditionEventSourceReachable)
+		Expect(reached.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(reached.Reason).Should(Equal("Unreachable"))
+
+		By("Changing the brokers, which re-runs the check")
+		agent = &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.EventSource.Kafka.Brokers = []string{"10.0.0.11:9092"}
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile(reconciler)
+		reconcile(reconciler)
+		job = &batchv1.Job{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-event-source-check", Namespace: "default"}, job)).Should(Succeed())
+		Expect(job.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "KAFKA_BOOTSTRAP_SERVERS", Value: "10.0.0.11:9092"}))
+
+		By("Passing the check")
+		job.Status.Succeeded = 1
+		Expect(fakeClient.Status().Update(ctx, job)).Should(Succeed())
+		reached = condition(reconcile(reconciler), aiv1.AgentConditionEventSourceReachable)
+		Expect(reached.Status).Should(Equal(corev1.ConditionTrue))
+		Expect(reached.Reason).Should(Equal("Reachable"))
+	})
+
+	It("Should allow egress to the brokers in the NetworkPolicy", func() {
+		reconcile(newReconciler(false, aiv1.AgentSpec{
+			EventSource:   kafkaSource(),
+			NetworkPolicy: &aiv1.NetworkPolicyConfig{Enabled: true},
+		}))
+
+		policy := &networkingv1.NetworkPolicy{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-network-policy", Namespace: "default"}, policy)).Should(Succeed())
+		var cidrs []string
+		for _, rule := range policy.Spec.Egress {
+			for _, peer := range rule.To {
+				if peer.IPBlock != nil && rule.Ports[0].Port.IntValue() == 9092 {
+					cidrs = append(cidrs, peer.IPBlock.CIDR)
+				}
+			}
+		}
+		Expect(cidrs).Should(ConsistOf("10.0.0.9/32", "10.0.0.10/32"))
+	})
+
+	It("Should allow HTTPS egress for SQS", func() {
+		reconcile(newReconciler(false, aiv1.AgentSpec{
+			EventSource:   sqsSource(),
+			NetworkPolicy: &aiv1.NetworkPolicyConfig{Enabled: true},
+		}))
+
+		policy := &networkingv1.NetworkPolicy{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-network-policy", Namespace: "default"}, policy)).Should(Succeed())
+		var anywhere []int
+		for _, rule := range policy.Spec.Egress {
+			if len(rule.To) == 0 {
+				anywhere = append(anywhere, rule.Ports[0].Port.IntValue())
+			}
+		}
+		Expect(anywhere).Should(ContainElement(443))
+	})
+
+	It("Should scale on the backlog through KEDA instead of an HPA", func() {
+		source := kafkaSource()
+		minReplicas := int32(0)
+		source.Autoscaling = &aiv1.EventSourceAutoscaling{Enabled: true, MinReplicas: &minReplicas}
+		reconciler := newReconciler(true, aiv1.AgentSpec{EventSource: source})
+		reconcile(reconciler)
+
+		scaledObject := &unstructured.Unstructured{}
+		scaledObject.SetGroupVersionKind(scaledObjectGVK)
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-event-source", Namespace: "default"}, scaledObject)).Should(Succeed())
+		Expect(scaledObject.Object["spec"]).Should(And(
+			HaveKeyWithValue("scaleTargetRef", map[string]interface{}{"name": "ticket-triage"}),
+			HaveKeyWithValue("minReplicaCount", BeEquivalentTo(0)),
+			HaveKeyWithValue("maxReplicaCount", BeEquivalentTo(10)),
+		))
+		triggers, _, _ := unstructured.NestedSlice(scaledObject.Object, "spec", "triggers")
+		Expect(triggers).Should(HaveLen(1))
+		trigger := triggers[0].(map[string]interface{})
+		Expect(trigger["type"]).Should(Equal("kafka"))
+		Expect(trigger["metadata"]).Should(And(
+			HaveKeyWithValue("bootstrapServers", "10.0.0.9:9092,10.0.0.10:9092"),
+			HaveKeyWithValue("consumerGroup", "default.ticket-triage"),
+			HaveKeyWithValue("topic", "tickets"),
+			HaveKeyWithValue("lagThreshold", "10"),
+			HaveKeyWithValue("sasl", "scram_sha512"),
+			HaveKeyWithValue("tls", "enable"),
+		))
+
+		triggerAuthentication := &unstructured.Unstructured{}
+		triggerAuthentication.SetGroupVersionKind(triggerAuthenticationGVK)
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-event-source", Namespace: "default"}, triggerAuthentication)).Should(Succeed())
+		targets, _, _ := unstructured.NestedSlice(triggerAuthentication.Object, "spec", "secretTargetRef")
+		Expect(targets).Should(ContainElement(map[string]interface{}{"parameter": "password", "name": "triage-kafka", "key": "password"}))
+
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-hpa", Namespace: "default"}, hpa)
+		Expect(errors.IsNotFound(err)).Should(BeTrue())
+
+		By("Keeping the replicas set by KEDA")
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		replicas := int32(7)
+		deployment.Spec.Replicas = &replicas
+		Expect(fakeClient.Update(ctx, deployment)).Should(Succeed())
+		reconcile(reconciler)
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		Expect(*deployment.Spec.Replicas).Should(Equal(int32(7)))
+
+		By("Removing the event source")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.EventSource = nil
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile(reconciler)
+		err = fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-event-source", Namespace: "default"}, scaledObject)
+		Expect(errors.IsNotFound(err)).Should(BeTrue())
+	})
+
+	It("Should render the SQS scaler with its credentials", func() {
+		source := sqsSource()
+		source.Autoscaling = &aiv1.EventSourceAutoscaling{Enabled: true}
+		reconcile(newReconciler(true, aiv1.AgentSpec{EventSource: source}))
+
+		scaledObject := &unstructured.Unstructured{}
+		scaledObject.SetGroupVersionKind(scaledObjectGVK)
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "ticket-triage-event-source", Namespace: "default"}, scaledObject)).Should(Succeed())
+		triggers, _, _ := unstructured.NestedSlice(scaledObject.Object, "spec", "triggers")
+		trigger := triggers[0].(map[string]interface{})
+		Expect(trigger["type"]).Should(Equal("aws-sqs-queue"))
+		Expect(trigger["metadata"]).Should(And(
+			HaveKeyWithValue("queueURL", "https://sqs.eu-west-1.amazonaws.com/123456789012/tickets"),
+			HaveKeyWithValue("queueLength", "10"),
+			HaveKeyWithValue("awsRegion", "eu-west-1"),
+			Not(HaveKey("identityOwner")),
+		))
+		Expect(trigger["authenticationRef"]).Should(Equal(map[string]interface{}{"name": "ticket-triage-event-source"}))
+	})
+
+	It("Should require KEDA to scale on the backlog", func() {
+		source := sqsSource()
+		source.Autoscaling = &aiv1.EventSourceAutoscaling{Enabled: true}
+		agent := reconcile(newReconciler(false, aiv1.AgentSpec{EventSource: source}))
+
+		configValid := condition(agent, aiv1.AgentConditionConfigValid)
+		Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid.Reason).Should(Equal("InvalidEventSourceConfig"))
+		Expect(configValid.Message).Should(ContainSubstring("KEDA"))
+	})
+
+	It("Should keep event-driven agents off the Ingress unless allowed", func() {
+		reconciler := newReconciler(false, aiv1.AgentSpec{EventSource: sqsSource(), ServiceType: "LoadBalancer"})
+		configValid := condition(reconcile(reconciler), aiv1.AgentConditionConfigValid)
+		Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid.Reason).Should(Equal("InvalidEventSourceConfig"))
+
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.EventSource.AllowIngress = true
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		configValid = condition(reconcile(reconciler), aiv1.AgentConditionConfigValid)
+		Expect(configValid.Status).Should(Equal(corev1.ConditionTrue))
+	})
+})
@@ -0,0 +1,256 @@
+package test
+
+import (
+	"context"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/examples"
+)
+
+const referencedExamples = `- user: Where is my order?
+  assistant: Could you share the order number?
+- user: "It is #1234."
+  assistant: "Order #1234 ships tomorrow."
+`
+
+var _ = Describe("Few-shot Examples", func() {
+	Context("Parsing and rendering the examples", func() {
+		It("Should render the examples for the runtime", func() {
+			parsed, err := examples.Parse([]byte(referencedExamples))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(parsed).Should(HaveLen(2))
+			rendered, err := examples.Render(parsed)
+			Expect(err).ShouldNot(HaveOccurred())
+			expectGoldenIn("examples", "examples.json", []byte(rendered))
+
+			fromJSON, err := examples.Parse([]byte(rendered))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(fromJSON).Should(Equal(parsed))
+
+			rendered, err = examples.Render(nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(rendered).Should(Equal("[]"))
+		})
+
+		It("Should reject invalid examples", func() {
+			_, err := examples.Parse([]byte("- user: hi\n  answer: hello\n"))
+			Expect(err).Should(MatchError(ContainSubstring(`unknown field "answer"`)))
+			_, err = examples.Parse([]byte("- user: hi\n"))
+			Expect(err).Should(MatchError(ContainSubstring("example 0: user and assistant are required")))
+
+			long := []aiv1.Example{{User: "hi", Assistant: strings.Repeat("a", examples.MaxMessageLength+1)}}
+			Expect(examples.Validate(long, examples.MaxInline)).Should(MatchError(ContainSubstring("at most 8000 characters")))
+			many := make([]aiv1.Example, examples.MaxInline+1)
+			for i := range many {
+				many[i] = aiv1.Example{User: "hi", Assistant: "hello"}
+			}
+			Expect(examples.Validate(many, examples.MaxInline)).Should(MatchError("51 examples, at most 50 are allowed"))
+			Expect(examples.Validate(many, examples.MaxReferenced)).Should(Succeed())
+		})
+
+		It("Should estimate the tokens of the system prompt and the examples", func() {
+			Expect(examples.EstimateTokens("", nil)).Should(Equal(int64(4)))
+			seeded := []aiv1.Example{{User: strings.Repeat("u", 40), Assistant: strings.Repeat("a", 80)}}
+			Expect(examples.EstimateTokens(strings.Repeat("s", 39), seeded)).Should(Equal(int64(40 + 3*4)))
+		})
+	})
+
+	Context("Reconciling the examples", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+		)
+
+		newReconciler := func(mutate func(*aiv1.AgentSpec), objects ...client.Object) {
+			scheme := newScheme()
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+				},
+			}
+			mutate(&agent.Spec)
+			fakeClient = newFakeClientBuilder(scheme).
+				WithObjects(append(objects, agent)...).
+				Build()
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+		}
+
+		examplesRef := func(spec *aiv1.AgentSpec) {
+			spec.ExamplesRef = &corev1.ConfigMapKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "support-examples"},
+				Key:                  "examples.yaml",
+			}
+		}
+
+		examplesConfigMap := func(data string) *corev1.ConfigMap {
+			return &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "support-examples", Namespace: "default"},
+				Data:       map[string]string{"examples.yaml": data},
+			}
+		}
+
+		reconcile := func() *aiv1.Agent {
+			return reconcileAgent(ctx, reconciler, request)
+		}
+
+		get := func(name string, obj client.Object) {
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, obj)).Should(Succeed())
+		}
+
+		condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) *aiv1.AgentCondition {
+			for i := range agent.Status.Conditions {
+				if agent.Status.Conditions[i].Type == conditionType {
+					return &agent.Status.Conditions[i]
+				}
+			}
+			return nil
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+		})
+
+		It("Should render the inline examples and mount them into the agent container", func() {
+			newReconciler(func(spec *aiv1.AgentSpec) {
+				spec.Examples = []aiv1.Example{{User: "Where is my order?", Assistant: "Could you share the order number?"}}
+			})
+			reconcile()
+
+			configMap := &corev1.ConfigMap{}
+			get("support-config", configMap)
+			Expect(configMap.Data["examples.json"]).Should(MatchJSON(`[{"user": "Where is my order?", "assistant": "Could you share the order number?"}]`))
+
+			deployment := &appsv1.Deployment{}
+			get("support", deployment)
+			agentContainer := deployment.Spec.Template.Spec.Containers[0]
+			Expect(agentContainer.Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_EXAMPLES_FILE", Value: "/etc/kubeagentic/examples/examples.json"}))
+			Expect(agentContainer.VolumeMounts).Should(ContainElement(corev1.VolumeMount{Name: "examples", MountPath: "/etc/kubeagentic/examples", ReadOnly: true}))
+			Expect(deployment.Spec.Template.Annotations).Should(HaveKey("kubeagentic.ai/examples-checksum"))
+
+			By("Removing the examples")
+			agent := &aiv1.Agent{}
+			get("support", agent)
+			agent.Spec.Examples = nil
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			reconcile()
+			get("support-config", configMap)
+			Expect(configMap.Data).ShouldNot(HaveKey("examples.json"))
+			get("support", deployment)
+			Expect(deployment.Spec.Template.Annotations).ShouldNot(HaveKey("kubeagentic.ai/examples-checksum"))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Env).ShouldNot(ContainElement(HaveField("Name", "AGENT_EXAMPLES_FILE")))
+		})
+
+		It("Should read the referenced examples and roll the pods when they change", func() {
+			newReconciler(examplesRef)
+			agent := reconcile()
+			Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseWaiting))
+			Expect(agent.Status.Message).Should(ContainSubstring("spec.examplesRef: configmap support-examples in namespace default not found"))
+
+			Expect(fakeClient.Create(ctx, examplesConfigMap(referencedExamples))).Should(Succeed())
+			reconcile()
+			configMap := &corev1.ConfigMap{}
+			get("support-config", configMap)
+			expectGoldenIn("examples", "examples.json", []byte(configMap.Data["examples.json"]))
+			deployment := &appsv1.Deployment{}
+			get("support", deployment)
+			checksum := deployment.Spec.Template.Annotations["kubeagentic.ai/examples-checksum"]
+			Expect(checksum).ShouldNot(BeEmpty())
+
+			By("Editing the examples, which rolls the pods")
+			examplesMap := &corev1.ConfigMap{}
+			get("support-examples", examplesMap)
+			examplesMap.Data["examples.yaml"] = strings.Replace(referencedExamples, "ships tomorrow", "shipped today", 1)
+			Expect(fakeClient.Update(ctx, examplesMap)).Should(Succeed())
+			reconcile()
+			get("support", deployment)
+			Expect(deployment.Spec.Template.Annotations["kubeagentic.ai/examples-checksum"]).ShouldNot(Equal(checksum))
+			get("support-config", configMap)
+			Expect(configMap.Data["examples.json"]).Should(ContainSubstring("shipped today"))
+
+			By("Breaking the examples, which keeps the last valid ones")
+			get("support-examples", examplesMap)
+			examplesMap.Data["examples.yaml"] = "- user: hi\n"
+			Expect(fakeClient.Update(ctx, examplesMap)).Should(Succeed())
+			agent = reconcile()
+			configValid := condition(agent, aiv1.AgentConditionConfigValid)
+			Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(configValid.Reason).Should(Equal("InvalidExamples"))
+			Expect(configValid.Message).Should(ContainSubstring("configmap support-examples key examples.yaml: example 0: user and assistant are required"))
+			get("support-config", configMap)
+			Expect(configMap.Data["examples.json"]).Should(ContainSubstring("shipped today"))
+		})
+
+		It("Should reject inline examples combined with a reference", func() {
+			newReconciler(func(spec *aiv1.AgentSpec) {
+				examplesRef(spec)
+				spec.Examples = []aiv1.Example{{User: "hi", Assistant: "hello"}}
+			}, examplesConfigMap(referencedExamples))
+			agent := reconcile()
+			configValid := condition(agent, aiv1.AgentConditionConfigValid)
+			Expect(configValid.Reason).Should(Equal("InvalidExamples"))
+			Expect(configValid.Message).Should(ContainSubstring("examples and examplesRef cannot be combined"))
+		})
+	})
+
+	Context("Admitting agents with examples", func() {
+		admit := func(model string, seeded []aiv1.Example) ([]string, error) {
+			validator := &webhookv1.AgentWebhook{}
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        model,
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+					Examples:     seeded,
+				},
+			}
+			Expect(validator.Default(context.Background(), agent)).Should(Succeed())
+			warnings, err := validator.ValidateCreate(context.Background(), agent)
+			return warnings, err
+		}
+
+		long := func(n int) []aiv1.Example {
+			seeded := make([]aiv1.Example, n)
+			for i := range seeded {
+				seeded[i] = aiv1.Example{User: strings.Repeat("u", 4000), Assistant: strings.Repeat("a", 4000)}
+			}
+			return seeded
+		}
+
+		It("Should warn when the examples take much of the context window of the model", func() {
+			warnings, err := admit("gpt-4", long(3))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(warnings).Should(ContainElement(ContainSubstring("spec.systemPrompt and the 3 examples take about 6036 tokens of every request, more than 50% of the 8192 tokens of the context window of openai/gpt-4")))
+
+			warnings, err = admit("gpt-4o", long(3))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(warnings).ShouldNot(ContainElement(ContainSubstring("context window")))
+		})
+
+		It("Should reject too many examples", func() {
+			_, err := admit("gpt-4o", long(51))
+			Expect(err).Should(MatchError(ContainSubstring("spec.examples: Invalid value: 51: 51 examples, at most 50 are allowed")))
+		})
+	})
+})
@@ -0,0 +1,188 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Conversation Export", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+	cronJobKey := types.NamespacedName{Name: "archivist-conversation-export", Namespace: "default"}
+
+	newExport := func() *aiv1.ExportConfig {
+		return &aiv1.ExportConfig{
+			Schedule: "0 2 * * *",
+			Destination: aiv1.ExportDestination{
+				Endpoint: "https://s3.example.com",
+				Bucket:   "transcripts",
+				Prefix:   "support/",
+			},
+			CredentialsSecretRef: corev1.LocalObjectReference{Name: "export-credentials"},
+			Exclude:              []string{"test-*"},
+		}
+	}
+
+	newReconciler := func(memory *aiv1.MemoryConfig, export *aiv1.ExportConfig) {
+		scheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(scheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "archivist", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "vllm",
+						Model:        "llama-3-8b",
+						SystemPrompt: "You are a helpful AI assistant.",
+						Endpoint:     "http://vllm.default.svc:8000/v1",
+						Memory:       memory,
+						Export:       export,
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "export-credentials", Namespace: "default"},
+					Data:       map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("key"), "AWS_SECRET_ACCESS_KEY": []byte("secret")},
+				},
+			).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "archivist", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) *aiv1.AgentCondition {
+		for i := range agent.Status.Conditions {
+			if agent.Status.Conditions[i].Type == conditionType {
+				return &agent.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	// finishedExport creates an export Job that finished at the given time, with the pod
+	// reporting the uploaded objects through the termination message.
+	finishedExport := func(name string, finished time.Time, succeeded bool, message string) {
+		completed := metav1.NewTime(finished)
+		job := &batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "default",
+				Labels: map[string]string{
+					"kubeagentic.ai/agent":        "archivist",
+					"app.kubernetes.io/component": "conversation-export",
+				},
+			},
+		}
+		Expect(fakeClient.Create(ctx, job)).Should(Succeed())
+		if succeeded {
+			job.Status.Succeeded = 1
+			job.Status.CompletionTime = &completed
+		} else {
+			job.Status.Failed = 1
+			job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: completed}}
+		}
+		Expect(fakeClient.Status().Update(ctx, job)).Should(Succeed())
+		Expect(fakeClient.Create(ctx, &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name + "-pod", Namespace: "default", Labels: map[string]string{"job-name": name}},
+			Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "export", Image: "kubeagentic/conversation-export:latest"}}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+				Name:  "export",
+				State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: message}},
+			}}},
+		})).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should upload the conversations of the memory backend from a CronJob owned by the agent", func() {
+		newReconciler(&aiv1.MemoryConfig{Backend: "redis"}, newExport())
+		reconcile()
+
+		cronJob := &batchv1.CronJob{}
+		Expect(fakeClient.Get(ctx, cronJobKey, cronJob)).Should(Succeed())
+		Expect(cronJob.OwnerReferences).Should(HaveLen(1))
+		Expect(cronJob.OwnerReferences[0].Name).Should(Equal("archivist"))
+		Expect(cronJob.Spec.Schedule).Should(Equal("0 2 * * *"))
+		Expect(cronJob.Spec.ConcurrencyPolicy).Should(Equal(batchv1.ForbidConcurrent))
+
+		container := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0]
+		Expect(container.Image).Should(Equal("kubeagentic/conversation-export:latest"))
+		Expect(container.Env).Should(ContainElements(
+			corev1.EnvVar{Name: "EXPORT_ENDPOINT", Value: "https://s3.example.com"},
+			corev1.EnvVar{Name: "EXPORT_BUCKET", Value: "transcripts"},
+			corev1.EnvVar{Name: "EXPORT_PREFIX", Value: "support/"},
+			corev1.EnvVar{Name: "EXPORT_FORMAT", Value: "jsonl"},
+			corev1.EnvVar{Name: "EXPORT_INCLUDE", Value: "null"},
+			corev1.EnvVar{Name: "EXPORT_EXCLUDE", Value: `["test-*"]`},
+			corev1.EnvVar{Name: "AGENT_MEMORY_BACKEND", Value: "redis"},
+			corev1.EnvVar{Name: "AGENT_MEMORY_URL", Value: "redis://archivist-redis.default.svc:6379/0"},
+		))
+		Expect(container.EnvFrom).Should(ConsistOf(HaveField("SecretRef.Name", "export-credentials")))
+	})
+
+	It("Should record the last export and set a condition when an export fails", func() {
+		newReconciler(&aiv1.MemoryConfig{Backend: "redis"}, newExport())
+		agent := reconcile()
+		Expect(agent.Status.Export).Should(BeNil())
+		Expect(condition(agent, aiv1.AgentConditionExportSucceeded)).Should(BeNil())
+
+		start := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+		finishedExport("archivist-conversation-export-1", start, true, `{"objectCount":12}`)
+		agent = reconcile()
+		Expect(agent.Status.Export).ShouldNot(BeNil())
+		Expect(agent.Status.Export.LastExportTime.Time).Should(BeTemporally("==", start))
+		Expect(agent.Status.Export.ObjectCount).Should(Equal(int64(12)))
+		Expect(condition(agent, aiv1.AgentConditionExportSucceeded).Status).Should(Equal(corev1.ConditionTrue))
+
+		By("Keeping the last successful export after a failed run")
+		finishedExport("archivist-conversation-export-2", start.Add(24*time.Hour), false, "")
+		agent = reconcile()
+		exported := condition(agent, aiv1.AgentConditionExportSucceeded)
+		Expect(exported.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(exported.Reason).Should(Equal("ExportFailed"))
+		Expect(exported.Message).Should(ContainSubstring("archivist-conversation-export-2"))
+		Expect(agent.Status.Export.LastExportTime.Time).Should(BeTemporally("==", start))
+
+		By("Removing the CronJob, the status and the condition with the export")
+		agent = &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.Export = nil
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(agent.Status.Export).Should(BeNil())
+		Expect(condition(agent, aiv1.AgentConditionExportSucceeded)).Should(BeNil())
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, cronJobKey, &batchv1.CronJob{}))).Should(BeTrue())
+	})
+
+	It("Should require a persistent memory backend", func() {
+		newReconciler(nil, newExport())
+		agent := reconcile()
+		configValid := condition(agent, aiv1.AgentConditionConfigValid)
+		Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid.Reason).Should(Equal("InvalidExportConfig"))
+		Expect(configValid.Message).Should(ContainSubstring("export requires a redis or postgres memory backend"))
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, cronJobKey, &batchv1.CronJob{}))).Should(BeTrue())
+	})
+})
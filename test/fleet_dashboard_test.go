@@ -0,0 +1,123 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/fleetdashboard"
+)
+
+var _ = Describe("Fleet Dashboard", func() {
+	type target struct {
+		RefID string `json:"refId"`
+		Expr  string `json:"expr"`
+	}
+	type panel struct {
+		ID      int      `json:"id"`
+		Title   string   `json:"title"`
+		Type    string   `json:"type"`
+		Targets []target `json:"targets"`
+		GridPos struct {
+			X, Y, W, H int
+		} `json:"gridPos"`
+	}
+	type dashboard struct {
+		UID    string  `json:"uid"`
+		Panels []panel `json:"panels"`
+	}
+
+	parse := func(data []byte) dashboard {
+		var parsed dashboard
+		Expect(json.Unmarshal(data, &parsed)).Should(Succeed())
+		return parsed
+	}
+
+	Context("When rendering the dashboard", func() {
+		It("Should match the golden dashboard", func() {
+			data, err := fleetdashboard.Render(fleetdashboard.Options{})
+			Expect(err).ShouldNot(HaveOccurred())
+			expectGoldenIn("fleetdashboard", "dashboard.json", data)
+		})
+
+		It("Should number the panels and lay them out without overlaps", func() {
+			data, err := fleetdashboard.Render(fleetdashboard.Options{})
+			Expect(err).ShouldNot(HaveOccurred())
+			parsed := parse(data)
+			Expect(parsed.UID).Should(Equal(fleetdashboard.UID))
+			Expect(parsed.Panels).ShouldNot(BeEmpty())
+
+			positions := map[[2]int]bool{}
+			for i, panel := range parsed.Panels {
+				Expect(panel.ID).Should(Equal(i + 1))
+				Expect(panel.Targets).ShouldNot(BeEmpty(), panel.Title)
+				for _, target := range panel.Targets {
+					Expect(target.Expr).ShouldNot(BeEmpty(), panel.Title)
+				}
+				position := [2]int{panel.GridPos.X, panel.GridPos.Y}
+				Expect(positions).ShouldNot(HaveKey(position), panel.Title)
+				positions[position] = true
+			}
+		})
+
+		It("Should render the same dashboard every time", func() {
+			first, err := fleetdashboard.Render(fleetdashboard.Options{TopN: 5})
+			Expect(err).ShouldNot(HaveOccurred())
+			second, err := fleetdashboard.Render(fleetdashboard.Options{TopN: 5})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(second).Should(Equal(first))
+		})
+
+		It("Should list the configured number of top agents", func() {
+			data, err := fleetdashboard.Render(fleetdashboard.Options{TopN: 5})
+			Expect(err).ShouldNot(HaveOccurred())
+			var titles []string
+			for _, panel := range parse(data).Panels {
+				if panel.Type == "table" {
+					titles = append(titles, panel.Title)
+					Expect(panel.Targets[0].Expr).Should(HavePrefix("topk(5, "))
+				}
+			}
+			Expect(titles).Should(ConsistOf("Top 5 Agents by Tokens", "Top 5 Agents by Cost"))
+		})
+	})
+
+	Context("When syncing the dashboard ConfigMap", func() {
+		It("Should create it labelled for Grafana and restore edits", func() {
+			ctx := context.Background()
+			scheme := runtime.NewScheme()
+			Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+			fakeClient := fake.NewClientBuilder().WithScheme(scheme).Build()
+			fleet := &controllers.FleetDashboard{Client: fakeClient, Namespace: "monitoring", Folder: "AI Platform"}
+			key := types.NamespacedName{Name: controllers.DefaultFleetDashboardConfigMap, Namespace: "monitoring"}
+
+			Expect(fleet.Sync(ctx)).Should(Succeed())
+			cm := &corev1.ConfigMap{}
+			Expect(fakeClient.Get(ctx, key, cm)).Should(Succeed())
+			Expect(cm.Labels).Should(HaveKeyWithValue("grafana_dashboard", "1"))
+			Expect(cm.Annotations).Should(HaveKeyWithValue(controllers.FleetDashboardFolderAnnotation, "AI Platform"))
+			Expect(parse([]byte(cm.Data[fleetdashboard.Key])).UID).Should(Equal(fleetdashboard.UID))
+
+			By("Rewriting a changed dashboard")
+			cm.Data[fleetdashboard.Key] = "{}"
+			Expect(fakeClient.Update(ctx, cm)).Should(Succeed())
+			Expect(fleet.Sync(ctx)).Should(Succeed())
+			Expect(fakeClient.Get(ctx, key, cm)).Should(Succeed())
+			Expect(parse([]byte(cm.Data[fleetdashboard.Key])).Panels).ShouldNot(BeEmpty())
+
+			By("Leaving an up to date dashboard unchanged")
+			version := cm.ResourceVersion
+			Expect(fleet.Sync(ctx)).Should(Succeed())
+			Expect(fakeClient.Get(ctx, key, cm)).Should(Succeed())
+			Expect(cm.ResourceVersion).Should(Equal(version))
+		})
+	})
+})
@@ -0,0 +1,126 @@
+package test
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Fleet Rollout", func() {
+	const (
+		operatorNamespace = "default"
+		timeout           = time.Second * 10
+		interval          = time.Millisecond * 250
+	)
+
+	BeforeEach(requireEnvtest)
+
+	Context("When the default agent image changes", func() {
+		It("Should update agents in priority order, skip pinned images and pause", func() {
+			ctx := context.Background()
+			previousImage, hadImage := os.LookupEnv("AGENT_IMAGE")
+			DeferCleanup(func() {
+				if hadImage {
+					os.Setenv("AGENT_IMAGE", previousImage)
+				} else {
+					os.Unsetenv("AGENT_IMAGE")
+				}
+			})
+
+			By("Creating a prioritized namespace and agents")
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "fleet-first",
+					Labels: map[string]string{controllers.DefaultFleetPriorityLabel: "0"},
+				},
+			})).Should(Succeed())
+			newAgent := func(name, namespace, image string) *aiv1.Agent {
+				return &aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+					Spec: aiv1.AgentSpec{
+						Provider:     "openai",
+						Model:        "gpt-4",
+						SystemPrompt: "You are a helpful AI assistant.",
+						Image:        image,
+						ApiSecretRef: &aiv1.SecretKeyReference{
+							SecretKeySelector: corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "test-secret"},
+								Key:                  "api-key",
+							},
+						},
+					},
+				}
+			}
+			Expect(k8sClient.Create(ctx, newAgent("fleet-agent", "fleet-first", ""))).Should(Succeed())
+			Expect(k8sClient.Create(ctx, newAgent("fleet-pinned", "fleet-first", "registry.example.com/agent:pinned"))).Should(Succeed())
+			// An agent of a namespace without priority is left for a later batch, whatever the
+			// agents of the other specs
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "fleet-later"}})).Should(Succeed())
+			Expect(k8sClient.Create(ctx, newAgent("fleet-agent", "fleet-later", ""))).Should(Succeed())
+
+			coordinator := &controllers.FleetRolloutCoordinator{
+				Client:            k8sClient,
+				Namespace:         operatorNamespace,
+				BatchSize:         1,
+				MaxFailurePercent: 100,
+			}
+			stateKey := types.NamespacedName{Name: controllers.FleetRolloutConfigMap, Namespace: operatorNamespace}
+			state := func() map[string]string {
+				cm := &corev1.ConfigMap{}
+				Expect(k8sClient.Get(ctx, stateKey, cm)).Should(Succeed())
+				return cm.Data
+			}
+			DeferCleanup(func() {
+				Expect(k8sClient.Delete(ctx, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: stateKey.Name, Namespace: stateKey.Namespace}})).Should(Succeed())
+			})
+
+			By("Recording the current default image")
+			os.Setenv("AGENT_IMAGE", "kubeagentic/agent:v1")
+			Expect(coordinator.Sync(ctx)).Should(Succeed())
+			Expect(state()).Should(HaveKeyWithValue("targetImage", "kubeagentic/agent:v1"))
+			Expect(state()).Should(HaveKeyWithValue("previousImage", ""))
+
+			By("Releasing the first batch from the prioritized namespace")
+			os.Setenv("AGENT_IMAGE", "kubeagentic/agent:v2")
+			Expect(coordinator.Sync(ctx)).Should(Succeed())
+			Expect(strings.Fields(state()["updated"])).Should(Equal([]string{"fleet-first/fleet-agent"}))
+			Expect(state()).Should(HaveKeyWithValue("targetImage", "kubeagentic/agent:v2"))
+			Expect(state()).Should(HaveKeyWithValue("previousImage", "kubeagentic/agent:v1"))
+
+			By("Halting the rollout with the pause switch")
+			setPaused := func(paused string) {
+				cm := &corev1.ConfigMap{}
+				Expect(k8sClient.Get(ctx, stateKey, cm)).Should(Succeed())
+				cm.Data["paused"] = paused
+				Expect(k8sClient.Update(ctx, cm)).Should(Succeed())
+			}
+			setPaused("true")
+			Eventually(func() string {
+				Expect(coordinator.Sync(ctx)).Should(Succeed())
+				return state()["message"]
+			}, timeout, interval).Should(HavePrefix("Paused"))
+			Expect(strings.Fields(state()["updated"])).Should(Equal([]string{"fleet-first/fleet-agent"}))
+
+			By("Pausing when the updated agents are failing")
+			// No pods run in the test environment, so the updated agent is not running.
+			setPaused("false")
+			coordinator.MaxFailurePercent = 0
+			Eventually(func() string {
+				Expect(coordinator.Sync(ctx)).Should(Succeed())
+				return state()["paused"]
+			}, timeout, interval).Should(Equal("true"))
+			Expect(state()["message"]).Should(ContainSubstring("not running"))
+			Expect(strings.Fields(state()["updated"])).ShouldNot(ContainElement("fleet-first/fleet-pinned"))
+		})
+	})
+})
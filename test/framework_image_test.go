@@ -0,0 +1,110 @@
+package test
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Per-Framework Agent Images", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	setEnv := func(name, value string) {
+		previous, had := os.LookupEnv(name)
+		DeferCleanup(func() {
+			if had {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+		os.Setenv(name, value)
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		setEnv("AGENT_IMAGE", "kubeagentic/agent:v1")
+		setEnv("AGENT_IMAGE_LANGGRAPH", "kubeagentic/agent-langgraph:v1")
+
+		imageScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(imageScheme).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "framework-agent", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					Framework:    "direct",
+					LanggraphConfig: &aiv1.LanggraphConfig{
+						GraphType:  "sequential",
+						Nodes:      []aiv1.WorkflowNode{{Name: "answer", Type: "llm", Prompt: "Answer the question."}},
+						Edges:      []aiv1.WorkflowEdge{{From: "answer", To: "__end__"}},
+						Entrypoint: "answer",
+					},
+				},
+			}).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: imageScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "framework-agent", Namespace: "default"}}
+	})
+
+	reconcile := func() (*appsv1.Deployment, *corev1.ConfigMap) {
+		deployment := reconcileDeployment(ctx, reconciler, request)
+		configMap := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "framework-agent-config", Namespace: "default"}, configMap)).Should(Succeed())
+		return deployment, configMap
+	}
+
+	update := func(mutate func(*aiv1.Agent)) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		mutate(agent)
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	It("Should run the default image of each framework", func() {
+		deployment, configMap := reconcile()
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).Should(Equal("kubeagentic/agent:v1"))
+		Expect(configMap.Data).ShouldNot(HaveKey("langgraph-config.json"))
+
+		By("Switching to the langgraph framework")
+		update(func(agent *aiv1.Agent) { agent.Spec.Framework = "langgraph" })
+		deployment, configMap = reconcile()
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).Should(Equal("kubeagentic/agent-langgraph:v1"))
+		Expect(configMap.Data).Should(HaveKey("langgraph-config.json"))
+	})
+
+	It("Should run the default agent image for langgraph without a LangGraph image", func() {
+		os.Unsetenv("AGENT_IMAGE_LANGGRAPH")
+		update(func(agent *aiv1.Agent) { agent.Spec.Framework = "langgraph" })
+		deployment, _ := reconcile()
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).Should(Equal("kubeagentic/agent:v1"))
+	})
+
+	It("Should prefer spec.image over the framework image", func() {
+		update(func(agent *aiv1.Agent) {
+			agent.Spec.Framework = "langgraph"
+			agent.Spec.Image = "registry.example.com/custom-agent:1.0"
+		})
+		deployment, _ := reconcile()
+		Expect(deployment.Spec.Template.Spec.Containers[0].Image).Should(Equal("registry.example.com/custom-agent:1.0"))
+	})
+})
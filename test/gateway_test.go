@@ -0,0 +1,309 @@
+package test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/gateway"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/registry"
+)
+
+var _ = Describe("OpenAI-Compatible Gateway", func() {
+	Context("Serving the OpenAI API", func() {
+		var (
+			gw       *gateway.Gateway
+			server   *httptest.Server
+			received chan *http.Request
+		)
+
+		// agentServer answers chat completions with the name of the agent and records the
+		// requests it receives
+		agentServer := func(name string) *httptest.Server {
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				body, _ := io.ReadAll(req.Body)
+				req.Body = io.NopCloser(strings.NewReader(string(body)))
+				received <- req
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"object":"chat.completion","model":%q,"choices":[{"message":{"role":"assistant","content":"from %s"}}]}`, name, name)
+			}))
+			DeferCleanup(backend.Close)
+			return backend
+		}
+
+		post := func(body string) *http.Response {
+			resp, err := http.Post(server.URL+"/v1/chat/completions", "application/json", strings.NewReader(body))
+			Expect(err).ShouldNot(HaveOccurred())
+			DeferCleanup(resp.Body.Close)
+			return resp
+		}
+
+		BeforeEach(func() {
+			received = make(chan *http.Request, 10)
+			gw = &gateway.Gateway{
+				ReadFile: func(name string) ([]byte, error) {
+					if name == "/etc/kubeagentic/gateway/invoices/token" {
+						return []byte("invoices-token\n"), nil
+					}
+					return nil, fmt.Errorf("open %s: no such file or directory", name)
+				},
+			}
+			server = httptest.NewServer(gw)
+			DeferCleanup(server.Close)
+		})
+
+		It("Should route each model to its agent with the token of the agent", func() {
+			gw.SetConfig(gateway.Config{Models: []gateway.Model{
+				{Name: "invoices", URL: agentServer("invoices").URL, TokenFile: "/etc/kubeagentic/gateway/invoices/token"},
+				{Name: "refunds", URL: agentServer("refunds").URL},
+			}})
+
+			resp := post(`{"model": "invoices", "messages": [{"role": "user", "content": "Hi"}]}`)
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			body, _ := io.ReadAll(resp.Body)
+			Expect(string(body)).Should(ContainSubstring("from invoices"))
+			var req *http.Request
+			Eventually(received).Should(Receive(&req))
+			Expect(req.URL.Path).Should(Equal("/v1/chat/completions"))
+			Expect(req.Header.Get("Authorization")).Should(Equal("Bearer invoices-token"))
+			forwarded, _ := io.ReadAll(req.Body)
+			Expect(string(forwarded)).Should(ContainSubstring(`"content": "Hi"`))
+
+			By("Replacing the credentials of the client for agents without endpoint auth")
+			req2, _ := http.NewRequest(http.MethodPost, server.URL+"/v1/chat/completions", strings.NewReader(`{"model": "refunds", "messages": []}`))
+			req2.Header.Set("Authorization", "Bearer sk-client")
+			resp, err := http.DefaultClient.Do(req2)
+			Expect(err).ShouldNot(HaveOccurred())
+			defer resp.Body.Close()
+			body, _ = io.ReadAll(resp.Body)
+			Expect(string(body)).Should(ContainSubstring("from refunds"))
+			Eventually(received).Should(Receive(&req))
+			Expect(req.Header.Get("Authorization")).Should(BeEmpty())
+		})
+
+		It("Should list the models sorted by name", func() {
+			gw.SetConfig(gateway.Config{Models: []gateway.Model{
+				{Name: "refunds", URL: "http://refunds-service.billing.svc.cluster.local"},
+				{Name: "invoices", URL: "http://invoices-service.billing.svc.cluster.local"},
+			}})
+
+			resp, err := http.Get(server.URL + "/v1/models")
+			Expect(err).ShouldNot(HaveOccurred())
+			defer resp.Body.Close()
+			var list struct {
+				Object string `json:"object"`
+				Data   []struct {
+					ID     string `json:"id"`
+					Object string `json:"object"`
+				} `json:"data"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&list)).Should(Succeed())
+			Expect(list.Object).Should(Equal("list"))
+			Expect(list.Data).Should(HaveLen(2))
+			Expect(list.Data[0].ID).Should(Equal("invoices"))
+			Expect(list.Data[1].ID).Should(Equal("refunds"))
+			Expect(list.Data[0].Object).Should(Equal("model"))
+		})
+
+		It("Should reject unknown and missing models", func() {
+			gw.SetConfig(gateway.Config{Models: []gateway.Model{{Name: "invoices", URL: agentServer("invoices").URL}}})
+
+			resp := post(`{"model": "gpt-4o", "messages": []}`)
+			Expect(resp.StatusCode).Should(Equal(http.StatusNotFound))
+			var body struct {
+				Error struct {
+					Message string `json:"message"`
+					Type    string `json:"type"`
+					Code    string `json:"code"`
+				} `json:"error"`
+			}
+			Expect(json.NewDecoder(resp.Body).Decode(&body)).Should(Succeed())
+			Expect(body.Error.Code).Should(Equal("model_not_found"))
+			Expect(body.Error.Message).Should(ContainSubstring("gpt-4o"))
+
+			Expect(post(`{"messages": []}`).StatusCode).Should(Equal(http.StatusBadRequest))
+			Expect(post(`not json`).StatusCode).Should(Equal(http.StatusBadRequest))
+			Consistently(received).ShouldNot(Receive())
+
+			By("Dropping the models of removed agents")
+			gw.SetConfig(gateway.Config{})
+			Expect(post(`{"model": "invoices", "messages": []}`).StatusCode).Should(Equal(http.StatusNotFound))
+		})
+
+		It("Should pass streamed chunks through as they arrive", func() {
+			release := make(chan struct{})
+			backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				w.Header().Set("Content-Type", "text/event-stream")
+				fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"Hello\"}}]}\n\n")
+				w.(http.Flusher).Flush()
+				<-release
+				fmt.Fprint(w, "data: [DONE]\n\n")
+			}))
+			DeferCleanup(backend.Close)
+			DeferCleanup(func() {
+				select {
+				case <-release:
+				default:
+					close(release)
+				}
+			})
+			gw.SetConfig(gateway.Config{Models: []gateway.Model{{Name: "invoices", URL: backend.URL}}})
+
+			resp := post(`{"model": "invoices", "stream": true, "messages": []}`)
+			Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+			Expect(resp.Header.Get("Content-Type")).Should(Equal("text/event-stream"))
+			reader := bufio.NewReader(resp.Body)
+
+			// The first chunk arrives while the agent is still generating
+			line, err := reader.ReadString('\n')
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(line).Should(ContainSubstring("Hello"))
+
+			close(release)
+			rest, err := io.ReadAll(reader)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(string(rest)).Should(ContainSubstring("data: [DONE]"))
+		})
+	})
+
+	Context("Reconciling an AgentGateway", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentGatewayReconciler
+			request    ctrl.Request
+		)
+
+		agent := func(name string, endpointAuth bool) *aiv1.Agent {
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "billing"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.billing.svc:8000/v1",
+				},
+			}
+			if endpointAuth {
+				agent.Spec.EndpointAuth = &aiv1.EndpointAuthConfig{GenerateKey: true}
+			}
+			return agent
+		}
+
+		setRegistry := func(names ...string) {
+			var entries []registry.Entry
+			for _, name := range names {
+				entries = append(entries, registry.Entry{
+					Name:      name,
+					Namespace: "billing",
+					URL:       fmt.Sprintf("http://%s-service.billing.svc.cluster.local", name),
+				})
+			}
+			data, err := registry.Marshal(entries)
+			Expect(err).ShouldNot(HaveOccurred())
+			cm := &corev1.ConfigMap{}
+			err = fakeClient.Get(ctx, types.NamespacedName{Name: registry.ConfigMapName, Namespace: "billing"}, cm)
+			if err != nil {
+				cm = &corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: registry.ConfigMapName, Namespace: "billing"},
+					Data:       map[string]string{registry.DataKey: data},
+				}
+				Expect(fakeClient.Create(ctx, cm)).Should(Succeed())
+				return
+			}
+			cm.Data = map[string]string{registry.DataKey: data}
+			Expect(fakeClient.Update(ctx, cm)).Should(Succeed())
+		}
+
+		reconcile := func() (gateway.Config, *appsv1.Deployment, *aiv1.AgentGateway) {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			cm := &corev1.ConfigMap{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "agents-gateway", Namespace: "billing"}, cm)).Should(Succeed())
+			config, err := gateway.ParseConfig([]byte(cm.Data["models.json"]))
+			Expect(err).ShouldNot(HaveOccurred())
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "agents-gateway", Namespace: "billing"}, deployment)).Should(Succeed())
+			gw := &aiv1.AgentGateway{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, gw)).Should(Succeed())
+			return config, deployment, gw
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			gatewayScheme := newScheme()
+
+			fakeClient = fake.NewClientBuilder().
+				WithScheme(gatewayScheme).
+				WithStatusSubresource(&aiv1.AgentGateway{}, &appsv1.Deployment{}).
+				WithObjects(
+					&aiv1.AgentGateway{ObjectMeta: metav1.ObjectMeta{Name: "agents", Namespace: "billing"}},
+					agent("invoices", true),
+					agent("refunds", false),
+				).
+				Build()
+			reconciler = &controllers.AgentGatewayReconciler{Client: fakeClient, Scheme: gatewayScheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "agents", Namespace: "billing"}}
+		})
+
+		It("Should route the running agents of the registry as they come and go", func() {
+			config, _, gw := reconcile()
+			Expect(config.Models).Should(BeEmpty())
+			Expect(gw.Status.URL).Should(Equal("http://agents-gateway.billing.svc.cluster.local/v1"))
+
+			setRegistry("refunds", "invoices")
+			config, deployment, gw := reconcile()
+			Expect(config.Models).Should(Equal([]gateway.Model{
+				{Name: "invoices", URL: "http://invoices-service.billing.svc.cluster.local", TokenFile: "/etc/kubeagentic/gateway/invoices/token"},
+				{Name: "refunds", URL: "http://refunds-service.billing.svc.cluster.local"},
+			}))
+			Expect(gw.Status.Models).Should(Equal([]string{"invoices", "refunds"}))
+
+			By("Mounting the endpoint token of the agents with endpoint auth")
+			sources := deployment.Spec.Template.Spec.Volumes[0].Projected.Sources
+			Expect(sources).Should(HaveLen(2))
+			Expect(sources[1].Secret.Name).Should(Equal("invoices-endpoint-auth"))
+			Expect(sources[1].Secret.Items[0].Path).Should(Equal("invoices/token"))
+			Expect(deployment.Spec.Template.Spec.Containers[0].Command).Should(Equal([]string{"/gateway"}))
+
+			setRegistry("refunds")
+			config, deployment, _ = reconcile()
+			Expect(config.Models).Should(HaveLen(1))
+			Expect(config.Models[0].Name).Should(Equal("refunds"))
+			Expect(deployment.Spec.Template.Spec.Volumes[0].Projected.Sources).Should(HaveLen(1))
+
+			service := &corev1.Service{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "agents-gateway", Namespace: "billing"}, service)).Should(Succeed())
+			Expect(service.Spec.Ports[0].Port).Should(Equal(int32(80)))
+		})
+
+		It("Should only route the agents listed in the spec", func() {
+			gw := &aiv1.AgentGateway{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, gw)).Should(Succeed())
+			gw.Spec.Agents = []string{"refunds"}
+			Expect(fakeClient.Update(ctx, gw)).Should(Succeed())
+
+			setRegistry("invoices", "refunds")
+			config, _, _ := reconcile()
+			Expect(config.Models).Should(HaveLen(1))
+			Expect(config.Models[0].Name).Should(Equal("refunds"))
+		})
+	})
+})
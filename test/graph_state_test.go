@@ -0,0 +1,74 @@
+package test
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/graphstate"
+)
+
+var _ = Describe("LangGraph State", func() {
+	validate := func(name string) []string {
+		data, err := os.ReadFile(filepath.Join("testdata", "graphstate", name))
+		Expect(err).ShouldNot(HaveOccurred())
+		config := &aiv1.LanggraphConfig{}
+		Expect(yaml.UnmarshalStrict(data, config)).Should(Succeed())
+
+		var fields []string
+		for _, err := range graphstate.Validate(field.NewPath("spec").Child("langgraphConfig"), config) {
+			fields = append(fields, err.Field)
+		}
+		return fields
+	}
+
+	It("Should accept inputs of state fields and of outputs of other nodes", func() {
+		Expect(validate("valid.yaml")).Should(BeEmpty())
+	})
+
+	It("Should reject inputs of unknown fields", func() {
+		Expect(validate("unknown-field.yaml")).Should(Equal([]string{
+			"spec.langgraphConfig.nodes[0].inputs[0]",
+			"spec.langgraphConfig.nodes[1].inputs[1]",
+			"spec.langgraphConfig.nodes[2].inputs[0]",
+		}))
+	})
+
+	It("Should reject LLM outputs into fields that hold no text", func() {
+		Expect(validate("type-conflict.yaml")).Should(Equal([]string{
+			"spec.langgraphConfig.state.properties[user_input].type",
+			"spec.langgraphConfig.nodes[0].outputs[0]",
+		}))
+	})
+
+	It("Should reject states that are not JSON Schema objects", func() {
+		Expect(validate("invalid-state.yaml")).Should(Equal([]string{
+			"spec.langgraphConfig.state.type",
+			"spec.langgraphConfig.state.properties[notes]",
+			"spec.langgraphConfig.state.properties[order_id].type",
+		}))
+
+		_, errs := graphstate.ParseState(field.NewPath("state"), &runtime.RawExtension{Raw: []byte(`{"type": "object"}`)})
+		Expect(errs).Should(HaveLen(1))
+		Expect(errs[0].Field).Should(Equal("state.properties"))
+	})
+
+	It("Should only allow the inputs of user_input and outputs of other nodes without a state", func() {
+		config := &aiv1.LanggraphConfig{
+			Nodes: []aiv1.WorkflowNode{
+				{Name: "classify", Type: "llm", Inputs: []string{"user_input"}, Outputs: []string{"issue_type"}},
+				{Name: "respond", Type: "llm", Inputs: []string{"issue_type", "order_id"}},
+			},
+		}
+		errs := graphstate.Validate(field.NewPath("spec").Child("langgraphConfig"), config)
+		Expect(errs).Should(HaveLen(1))
+		Expect(errs[0].Field).Should(Equal("spec.langgraphConfig.nodes[1].inputs[1]"))
+		Expect(errs[0].BadValue).Should(Equal("order_id"))
+	})
+})
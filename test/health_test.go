@@ -0,0 +1,211 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/health"
+)
+
+var _ = Describe("Agent Health Check", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+		clock      *clocktesting.FakePassiveClock
+		report     health.Report
+		statusCode int
+		checks     int
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		healthScheme := newScheme()
+
+		ready := true
+		fakeClient = newFakeClientBuilder(healthScheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "scout", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "vllm",
+						Model:        "llama-3-8b",
+						SystemPrompt: "You are a helpful AI assistant.",
+						Endpoint:     "http://vllm.default.svc:8000/v1",
+						EndpointAuth: &aiv1.EndpointAuthConfig{GenerateKey: true},
+						HealthCheck:  &aiv1.HealthCheckConfig{Enabled: true, Interval: &metav1.Duration{Duration: 2 * time.Minute}},
+					},
+				},
+				&discoveryv1.EndpointSlice{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "scout-service-abcde",
+						Namespace: "default",
+						Labels:    map[string]string{discoveryv1.LabelServiceName: "scout-service"},
+					},
+					AddressType: discoveryv1.AddressTypeIPv4,
+					Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.5"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}}},
+				},
+			).
+			Build()
+
+		report = health.Report{Status: health.StateHealthy, Components: map[string]health.Component{
+			"provider": {Status: health.StateHealthy, Message: "vllm is reachable"},
+			"memory":   {Status: health.StateHealthy, Message: "inmemory backend"},
+			"tools":    {Status: health.StateHealthy, Message: "0 tools configured"},
+		}}
+		statusCode = http.StatusOK
+		checks = 0
+		runtimeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			checks++
+			Expect(r.Host).Should(Equal("scout-service.default.svc"))
+			if r.URL.Path != health.Path {
+				http.NotFound(w, r)
+				return
+			}
+			secret := &corev1.Secret{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "scout-endpoint-auth", Namespace: "default"}, secret)).Should(Succeed())
+			if r.Header.Get("Authorization") != "Bearer "+string(secret.Data["token"]) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(statusCode)
+			Expect(json.NewEncoder(w).Encode(report)).Should(Succeed())
+		}))
+		DeferCleanup(runtimeServer.Close)
+		server, err := url.Parse(runtimeServer.URL)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		clock = clocktesting.NewFakePassiveClock(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+		reconciler = &controllers.AgentReconciler{
+			Client:        fakeClient,
+			Scheme:        healthScheme,
+			Clock:         clock,
+			HealthChecker: &health.Checker{Client: &http.Client{Transport: redirectTransport{server: server}}},
+		}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "scout", Namespace: "default"}}
+	})
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	agentHealthy := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionAgentHealthy {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	componentMetric := func(component string) float64 {
+		families, err := metrics.Registry.Gather()
+		Expect(err).ShouldNot(HaveOccurred())
+		for _, family := range families {
+			if family.GetName() != "kubeagentic_agent_component_healthy" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				labels := map[string]string{}
+				for _, label := range metric.GetLabel() {
+					labels[label.GetName()] = label.GetValue()
+				}
+				if labels["agent"] == "scout" && labels["component"] == component {
+					return metric.GetGauge().GetValue()
+				}
+			}
+		}
+		return -1
+	}
+
+	It("Should wait for a ready endpoint before checking", func() {
+		agent := reconcile()
+		Expect(checks).Should(BeZero())
+		Expect(agentHealthy(agent).Status).Should(Equal(corev1.ConditionUnknown))
+		Expect(agentHealthy(agent).Reason).Should(Equal("AwaitingEndpoints"))
+	})
+
+	It("Should report the health of the components with the generated key", func() {
+		reconcile()
+		agent := reconcile()
+		Expect(checks).Should(Equal(1))
+		Expect(agentHealthy(agent).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(agentHealthy(agent).Reason).Should(Equal("ComponentsHealthy"))
+		Expect(agent.Status.Health.Components).Should(Equal([]aiv1.ComponentHealth{
+			{Name: "memory", Healthy: true, Message: "inmemory backend"},
+			{Name: "provider", Healthy: true, Message: "vllm is reachable"},
+			{Name: "tools", Healthy: true, Message: "0 tools configured"},
+		}))
+		Expect(componentMetric("provider")).Should(Equal(1.0))
+
+		By("Waiting for the interval before checking again")
+		report.Components["provider"] = health.Component{Status: health.StateUnhealthy, Message: "connection refused"}
+		report.Status = health.StateUnhealthy
+		statusCode = http.StatusServiceUnavailable
+		clock.SetTime(clock.Now().Add(time.Minute))
+		Expect(agentHealthy(reconcile()).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(checks).Should(Equal(1))
+
+		By("Listing the failing components")
+		clock.SetTime(clock.Now().Add(time.Minute))
+		agent = reconcile()
+		Expect(checks).Should(Equal(2))
+		Expect(agentHealthy(agent).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(agentHealthy(agent).Reason).Should(Equal("ComponentsUnhealthy"))
+		Expect(agentHealthy(agent).Message).Should(Equal("Unhealthy components: provider: connection refused"))
+		Expect(componentMetric("provider")).Should(Equal(0.0))
+		Expect(componentMetric("memory")).Should(Equal(1.0))
+
+		By("Not failing the agent on unhealthy components")
+		Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+
+		By("Removing the condition and metrics once disabled")
+		agent.Spec.HealthCheck.Enabled = false
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(agentHealthy(agent).Type).Should(BeEmpty())
+		Expect(agent.Status.Health).Should(BeNil())
+		Expect(componentMetric("provider")).Should(Equal(-1.0))
+	})
+
+	It("Should tolerate unreachable runtimes", func() {
+		reconciler.HealthChecker.Client.Transport = redirectTransport{server: &url.URL{Scheme: "http", Host: "127.0.0.1:1"}}
+		reconcile()
+		agent := reconcile()
+		Expect(agentHealthy(agent).Status).Should(Equal(corev1.ConditionUnknown))
+		Expect(agentHealthy(agent).Reason).Should(Equal("AgentUnreachable"))
+		Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+	})
+
+	It("Should tell older runtimes apart", func() {
+		report = health.Report{}
+		old := httptest.NewServer(http.NotFoundHandler())
+		DeferCleanup(old.Close)
+		server, err := url.Parse(old.URL)
+		Expect(err).ShouldNot(HaveOccurred())
+		reconciler.HealthChecker.Client.Transport = redirectTransport{server: server}
+
+		reconcile()
+		agent := reconcile()
+		Expect(agentHealthy(agent).Status).Should(Equal(corev1.ConditionUnknown))
+		Expect(agentHealthy(agent).Reason).Should(Equal("HealthCheckNotSupported"))
+	})
+})
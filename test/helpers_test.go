@@ -0,0 +1,52 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+// newScheme returns a scheme with the built-in types and the types of the operator.
+func newScheme() *runtime.Scheme {
+	scheme := runtime.NewScheme()
+	Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+	Expect(aiv1.AddToScheme(scheme)).Should(Succeed())
+	return scheme
+}
+
+// newFakeClientBuilder returns the builder of a fake client for scheme. The status of the
+// agents and their Deployments is a subresource, as on a cluster, so that the reconciler
+// must write it through the status client.
+func newFakeClientBuilder(scheme *runtime.Scheme) *fake.ClientBuilder {
+	return fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&aiv1.Agent{}, &appsv1.Deployment{})
+}
+
+// reconcileAgent reconciles the agent of request, which must succeed, and returns the agent
+// as the reconcile left it.
+func reconcileAgent(ctx context.Context, reconciler *controllers.AgentReconciler, request ctrl.Request) *aiv1.Agent {
+	_, err := reconciler.Reconcile(ctx, request)
+	Expect(err).ShouldNot(HaveOccurred())
+	agent := &aiv1.Agent{}
+	Expect(reconciler.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+	return agent
+}
+
+// reconcileDeployment reconciles the agent of request, which must succeed, and returns the
+// Deployment of the agent.
+func reconcileDeployment(ctx context.Context, reconciler *controllers.AgentReconciler, request ctrl.Request) *appsv1.Deployment {
+	_, err := reconciler.Reconcile(ctx, request)
+	Expect(err).ShouldNot(HaveOccurred())
+	deployment := &appsv1.Deployment{}
+	Expect(reconciler.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+	return deployment
+}
@@ -0,0 +1,62 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Identity Environment", func() {
+	It("Should inject the identity of the pod through the Downward API", func() {
+		ctx := context.Background()
+		identityScheme := newScheme()
+
+		fakeClient := newFakeClientBuilder(identityScheme).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "local-agent", Namespace: "default", UID: "0c5f7a1e-agent-uid"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+				},
+			}).
+			Build()
+
+		reconciler := &controllers.AgentReconciler{Client: fakeClient, Scheme: identityScheme}
+		request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "local-agent", Namespace: "default"}}
+		_, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		env := map[string]corev1.EnvVar{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e
+		}
+
+		for name, fieldPath := range map[string]string{
+			"AGENT_NAME":      "metadata.labels['kubeagentic.ai/agent']",
+			"AGENT_NAMESPACE": "metadata.namespace",
+			"POD_NAME":        "metadata.name",
+			"POD_IP":          "status.podIP",
+			"NODE_NAME":       "spec.nodeName",
+		} {
+			Expect(env).Should(HaveKey(name))
+			Expect(env[name].ValueFrom).ShouldNot(BeNil(), name)
+			Expect(env[name].ValueFrom.FieldRef).ShouldNot(BeNil(), name)
+			Expect(env[name].ValueFrom.FieldRef.FieldPath).Should(Equal(fieldPath), name)
+		}
+		Expect(env["AGENT_UID"].Value).Should(Equal("0c5f7a1e-agent-uid"))
+		Expect(deployment.Spec.Template.Labels).Should(HaveKeyWithValue("kubeagentic.ai/agent", "local-agent"))
+	})
+})
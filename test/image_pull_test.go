@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Image Pull", func() {
+	Context("Reconciling the pull settings", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			scheme := newScheme()
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:         "vllm",
+					Model:            "llama-3-8b",
+					SystemPrompt:     "You are a helpful AI assistant.",
+					Endpoint:         "http://vllm.default.svc:8000/v1",
+					Image:            "registry.example.com/agents/support:1.4.0",
+					ImagePullPolicy:  corev1.PullIfNotPresent,
+					ImagePullSecrets: []corev1.LocalObjectReference{{Name: "registry-credentials"}},
+				},
+			}
+			fakeClient = newFakeClientBuilder(scheme).
+				WithObjects(agent).
+				Build()
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+		})
+
+		reconcile := func() *appsv1.Deployment {
+			return reconcileDeployment(ctx, reconciler, request)
+		}
+
+		It("Should set the pull secrets and policy of the agent pods and update them on change", func() {
+			deployment := reconcile()
+			podSpec := deployment.Spec.Template.Spec
+			Expect(podSpec.ImagePullSecrets).Should(Equal([]corev1.LocalObjectReference{{Name: "registry-credentials"}}))
+			Expect(podSpec.Containers[0].ImagePullPolicy).Should(Equal(corev1.PullIfNotPresent))
+			// The endpoint wait runs the agent image, pulled the same way
+			Expect(podSpec.InitContainers).Should(ContainElement(And(
+				HaveField("Image", "registry.example.com/agents/support:1.4.0"),
+				HaveField("ImagePullPolicy", corev1.PullIfNotPresent),
+			)))
+
+			By("Rotating to another pull Secret and pull policy")
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Spec.ImagePullSecrets = []corev1.LocalObjectReference{{Name: "registry-credentials-2024"}}
+			agent.Spec.ImagePullPolicy = corev1.PullAlways
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			deployment = reconcile()
+			podSpec = deployment.Spec.Template.Spec
+			Expect(podSpec.ImagePullSecrets).Should(Equal([]corev1.LocalObjectReference{{Name: "registry-credentials-2024"}}))
+			Expect(podSpec.Containers[0].ImagePullPolicy).Should(Equal(corev1.PullAlways))
+
+			By("Removing them")
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Spec.ImagePullSecrets = nil
+			agent.Spec.ImagePullPolicy = ""
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			deployment = reconcile()
+			Expect(deployment.Spec.Template.Spec.ImagePullSecrets).Should(BeEmpty())
+			Expect(deployment.Spec.Template.Spec.Containers[0].ImagePullPolicy).Should(BeEmpty())
+		})
+	})
+
+	Context("Admitting agents with pull settings", func() {
+		admit := func(policy corev1.PullPolicy, secrets ...string) error {
+			validator := &webhookv1.AgentWebhook{}
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:        "openai",
+					Model:           "gpt-4o",
+					SystemPrompt:    "You are a helpful AI assistant.",
+					ApiSecretRef:    &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+					ImagePullPolicy: policy,
+				},
+			}
+			for _, secret := range secrets {
+				agent.Spec.ImagePullSecrets = append(agent.Spec.ImagePullSecrets, corev1.LocalObjectReference{Name: secret})
+			}
+			Expect(validator.Default(context.Background(), agent)).Should(Succeed())
+			_, err := validator.ValidateCreate(context.Background(), agent)
+			return err
+		}
+
+		It("Should admit supported pull policies and named pull Secrets", func() {
+			Expect(admit("")).Should(Succeed())
+			Expect(admit(corev1.PullNever, "registry-credentials", "mirror-credentials")).Should(Succeed())
+		})
+
+		It("Should reject unsupported pull policies and invalid pull Secrets", func() {
+			err := admit("Sometimes")
+			Expect(err).Should(MatchError(ContainSubstring(`spec.imagePullPolicy: Unsupported value: "Sometimes"`)))
+
+			err = admit(corev1.PullAlways, "registry-credentials", "", "Registry_Credentials", "registry-credentials")
+			Expect(err).Should(MatchError(ContainSubstring("spec.imagePullSecrets[1].name: Required value")))
+			Expect(err).Should(MatchError(ContainSubstring(`spec.imagePullSecrets[2].name: Invalid value: "Registry_Credentials"`)))
+			Expect(err).Should(MatchError(ContainSubstring(`spec.imagePullSecrets[3].name: Duplicate value: "registry-credentials"`)))
+		})
+	})
+})
@@ -0,0 +1,174 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/imagepolicy"
+)
+
+var _ = Describe("Image Policy", func() {
+	Context("When matching registries", func() {
+		It("Should match whole registry and path segments", func() {
+			allowed := []string{"registry.example.com:5000", "ghcr.io/example"}
+
+			for image, expected := range map[string]bool{
+				"registry.example.com:5000/agent:v1":                                     true,
+				"registry.example.com:5000/team/agent@sha256:" + strings.Repeat("a", 64): true,
+				"registry.example.com/agent:v1":                                          false,
+				"registry.example.com:50001/agent:v1":                                    false,
+				"ghcr.io/example/agent:v1":                                               true,
+				"ghcr.io/example-evil/agent:v1":                                          false,
+				"ghcr.io/other/agent:v1":                                                 false,
+				"kubeagentic/agent:latest":                                               false,
+			} {
+				ok, err := imagepolicy.RegistryAllowed(image, allowed)
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(ok).Should(Equal(expected), image)
+			}
+		})
+
+		It("Should normalize Docker Hub images", func() {
+			ok, err := imagepolicy.RegistryAllowed("nginx:1.25", []string{"docker.io/library"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).Should(BeTrue())
+
+			ok, err = imagepolicy.RegistryAllowed("kubeagentic/agent", []string{"docker.io/kubeagentic"})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).Should(BeTrue())
+		})
+
+		It("Should allow everything with an empty allowlist", func() {
+			ok, err := imagepolicy.RegistryAllowed("docker.io/whatever/agent:latest", nil)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(ok).Should(BeTrue())
+		})
+	})
+
+	Context("When resolving digests", func() {
+		const digest = "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+		var (
+			server   *httptest.Server
+			requests int32
+		)
+
+		BeforeEach(func() {
+			atomic.StoreInt32(&requests, 0)
+			server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				switch {
+				case req.URL.Path == "/token":
+					w.Write([]byte(`{"token": "anonymous"}`))
+				case req.Header.Get("Authorization") != "Bearer anonymous":
+					w.Header().Set("WWW-Authenticate", `Bearer realm="https://`+req.Host+`/token",service="registry",scope="repository:team/agent:pull"`)
+					w.WriteHeader(http.StatusUnauthorized)
+				case req.Method == http.MethodHead && req.URL.Path == "/v2/team/agent/manifests/v1":
+					atomic.AddInt32(&requests, 1)
+					w.Header().Set("Docker-Content-Digest", digest)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+		})
+
+		AfterEach(func() {
+			server.Close()
+		})
+
+		It("Should pin the tag to its digest and cache the result", func() {
+			ctx := context.Background()
+			resolver := &imagepolicy.Resolver{Client: server.Client()}
+			registry := strings.TrimPrefix(server.URL, "https://")
+
+			resolved, err := resolver.Resolve(ctx, registry+"/team/agent:v1")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resolved).Should(Equal(registry + "/team/agent@" + digest))
+
+			resolved, err = resolver.Resolve(ctx, registry+"/team/agent:v1")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resolved).Should(Equal(registry + "/team/agent@" + digest))
+			Expect(atomic.LoadInt32(&requests)).Should(Equal(int32(1)))
+		})
+
+		It("Should leave digest references untouched", func() {
+			resolver := &imagepolicy.Resolver{Client: server.Client()}
+			registry := strings.TrimPrefix(server.URL, "https://")
+
+			image := registry + "/team/agent@" + digest
+			resolved, err := resolver.Resolve(context.Background(), image)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(resolved).Should(Equal(image))
+			Expect(atomic.LoadInt32(&requests)).Should(Equal(int32(0)))
+		})
+
+		It("Should fail for unknown tags", func() {
+			resolver := &imagepolicy.Resolver{Client: server.Client()}
+			registry := strings.TrimPrefix(server.URL, "https://")
+
+			_, err := resolver.Resolve(context.Background(), registry+"/team/agent:missing")
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+
+	Context("When admitting the images of an agent", func() {
+		It("Should report the images in the order of their fields and the policies in the order of their names", func() {
+			policy := func(name, registry string) *aiv1.AgentPolicy {
+				return &aiv1.AgentPolicy{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+					Spec:       aiv1.AgentPolicySpec{AllowedRegistries: []string{registry}},
+				}
+			}
+			readerClient := newFakeClientBuilder(newScheme()).
+				WithObjects(policy("b-registries", "b.example.com"), policy("a-registries", "a.example.com")).
+				Build()
+			// List the policies in reverse, as the cache may list them in any order
+			validator := &webhookv1.AgentWebhook{Reader: interceptor.NewClient(readerClient, interceptor.Funcs{
+				List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+					if err := c.List(ctx, list, opts...); err != nil {
+						return err
+					}
+					if policies, ok := list.(*aiv1.AgentPolicyList); ok {
+						slices.Reverse(policies.Items)
+					}
+					return nil
+				},
+			})}
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "ollama",
+					Model:        "llama3",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://ollama:11434",
+					Image:        "docker.io/team/agent:v1",
+					Export:       &aiv1.ExportConfig{Image: "ghcr.io/team/export:v1"},
+				},
+			}
+
+			_, err := validator.ValidateCreate(context.Background(), agent)
+			Expect(err).Should(HaveOccurred())
+			message := err.Error()
+			Expect(strings.Index(message, `spec.image: Forbidden: image "docker.io/team/agent:v1" is not from a registry allowed by the AgentPolicy a-registries`)).Should(BeNumerically("<",
+				strings.Index(message, `spec.image: Forbidden: image "docker.io/team/agent:v1" is not from a registry allowed by the AgentPolicy b-registries`)))
+			Expect(strings.Index(message, "AgentPolicy b-registries")).Should(BeNumerically("<", strings.Index(message, `spec.export.image: Forbidden`)))
+
+			By("Rejecting the agent with the same message on every admission")
+			for i := 0; i < 5; i++ {
+				_, again := validator.ValidateCreate(context.Background(), agent)
+				Expect(again).Should(MatchError(message))
+			}
+		})
+	})
+})
@@ -0,0 +1,271 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/ratelimit"
+)
+
+var _ = Describe("Inbound Rate Limiting", func() {
+	Context("Reconciling the proxy sidecar", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+		)
+
+		burst := int32(10)
+		rules := []aiv1.RateLimitRule{
+			{Name: "per-key", Header: "X-API-Key", Requests: 60, Window: metav1.Duration{Duration: time.Minute}, Burst: &burst},
+			{Name: "per-ip", Requests: 1000, Window: metav1.Duration{Duration: time.Hour}},
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			rateLimitScheme := newScheme()
+
+			fakeClient = newFakeClientBuilder(rateLimitScheme).
+				WithObjects(&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "limited-agent", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:         "vllm",
+						Model:            "llama-3-8b",
+						SystemPrompt:     "You are a helpful AI assistant.",
+						Endpoint:         "http://vllm.default.svc:8000/v1",
+						InboundRateLimit: &aiv1.InboundRateLimitConfig{Rules: rules},
+					},
+				}).
+				Build()
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: rateLimitScheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "limited-agent", Namespace: "default"}}
+		})
+
+		reconcile := func() *aiv1.Agent {
+			return reconcileAgent(ctx, reconciler, request)
+		}
+
+		update := func(mutate func(*aiv1.Agent)) {
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			mutate(agent)
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		}
+
+		condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) aiv1.AgentCondition {
+			for _, condition := range agent.Status.Conditions {
+				if condition.Type == conditionType {
+					return condition
+				}
+			}
+			Fail("condition " + string(conditionType) + " not set")
+			return aiv1.AgentCondition{}
+		}
+
+		get := func(name string, obj client.Object) {
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, obj)).Should(Succeed())
+		}
+
+		It("Should render the rules and put the proxy in front of the agent", func() {
+			reconcile()
+
+			configMap := &corev1.ConfigMap{}
+			get("limited-agent-config", configMap)
+			config, err := ratelimit.ParseConfig([]byte(configMap.Data["ratelimit.json"]))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(config.Rules).Should(Equal([]ratelimit.Rule{
+				{Name: "per-key", Header: "X-API-Key", Requests: 60, Window: "1m0s", Burst: 10},
+				{Name: "per-ip", Requests: 1000, Window: "1h0m0s", Burst: 1000},
+			}))
+
+			deployment := &appsv1.Deployment{}
+			get("limited-agent", deployment)
+			containers := deployment.Spec.Template.Spec.Containers
+			Expect(containers).Should(HaveLen(2))
+			Expect(containers[0].Name).Should(Equal("agent"))
+			Expect(containers[1].Name).Should(Equal("ratelimit-proxy"))
+			Expect(containers[1].Ports[0].ContainerPort).Should(Equal(int32(8081)))
+			Expect(containers[1].Args).Should(ContainElement("http://127.0.0.1:8080"))
+
+			service := &corev1.Service{}
+			get("limited-agent-service", service)
+			Expect(service.Spec.Ports[0].Port).Should(Equal(int32(80)))
+			Expect(service.Spec.Ports[0].TargetPort.IntValue()).Should(Equal(8081))
+
+			By("Editing a rule, which the proxy reloads without rolling the pods")
+			update(func(agent *aiv1.Agent) { agent.Spec.InboundRateLimit.Rules[0].Requests = 120 })
+			reconcile()
+			get("limited-agent-config", configMap)
+			Expect(configMap.Data["ratelimit.json"]).Should(ContainSubstring(`"requests":120`))
+			rolled := &appsv1.Deployment{}
+			get("limited-agent", rolled)
+			Expect(rolled.Spec.Template).Should(Equal(deployment.Spec.Template))
+
+			By("Removing the rate limits")
+			update(func(agent *aiv1.Agent) { agent.Spec.InboundRateLimit = nil })
+			reconcile()
+			get("limited-agent", deployment)
+			Expect(deployment.Spec.Template.Spec.Containers).Should(HaveLen(1))
+			get("limited-agent-service", service)
+			Expect(service.Spec.Ports[0].TargetPort.IntValue()).Should(Equal(8080))
+			get("limited-agent-config", configMap)
+			Expect(configMap.Data).ShouldNot(HaveKey("ratelimit.json"))
+		})
+
+		It("Should send the routed requests through the proxy", func() {
+			update(func(agent *aiv1.Agent) {
+				agent.Spec.Routing = &aiv1.RoutingConfig{ConversationAffinity: &aiv1.ConversationAffinityConfig{Enabled: true}}
+			})
+			reconcile()
+
+			service := &corev1.Service{}
+			get("limited-agent-service", service)
+			Expect(service.Spec.Ports[0].TargetPort.IntValue()).Should(Equal(8080))
+			headless := &corev1.Service{}
+			get("limited-agent-headless", headless)
+			Expect(headless.Spec.Ports[0].TargetPort.IntValue()).Should(Equal(8081))
+			router := &corev1.ConfigMap{}
+			get("limited-agent-router", router)
+			Expect(router.Data["envoy.yaml"]).Should(ContainSubstring("limited-agent-headless.default.svc.cluster.local, port_value: 8081"))
+		})
+
+		It("Should reject too many rules and empty windows", func() {
+			update(func(agent *aiv1.Agent) {
+				agent.Spec.InboundRateLimit.Rules[1].Window = metav1.Duration{}
+			})
+			agent := reconcile()
+			configValid := condition(agent, aiv1.AgentConditionConfigValid)
+			Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(configValid.Reason).Should(Equal("InvalidRateLimitConfig"))
+			Expect(configValid.Message).Should(ContainSubstring(`rule "per-ip": the window must be positive`))
+
+			update(func(agent *aiv1.Agent) {
+				agent.Spec.InboundRateLimit.Rules = nil
+				for i := 0; i < 11; i++ {
+					agent.Spec.InboundRateLimit.Rules = append(agent.Spec.InboundRateLimit.Rules, aiv1.RateLimitRule{
+						Name: fmt.Sprintf("rule-%d", i), Requests: 1, Window: metav1.Duration{Duration: time.Minute},
+					})
+				}
+			})
+			agent = reconcile()
+			configValid = condition(agent, aiv1.AgentConditionConfigValid)
+			Expect(configValid.Reason).Should(Equal("InvalidRateLimitConfig"))
+			Expect(configValid.Message).Should(ContainSubstring("at most 10 are allowed"))
+		})
+	})
+
+	Context("Enforcing the limits", func() {
+		var (
+			now      time.Time
+			proxy    *ratelimit.Proxy
+			server   *httptest.Server
+			upstream int
+		)
+
+		BeforeEach(func() {
+			now = time.Date(2026, 10, 17, 10, 0, 0, 0, time.UTC)
+			upstream = 0
+			agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+				if req.URL.Path == "/metrics" {
+					fmt.Fprint(w, "kubeagentic_requests_total 3\n")
+					return
+				}
+				upstream++
+				fmt.Fprint(w, `{"response": "ok"}`)
+			}))
+			DeferCleanup(agent.Close)
+			agentURL, err := url.Parse(agent.URL)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			proxy = &ratelimit.Proxy{Upstream: agentURL, Labels: map[string]string{"agent": "limited-agent"}}
+			proxy.Limiter.Now = func() time.Time { return now }
+			proxy.Limiter.SetConfig(ratelimit.Config{Rules: []ratelimit.Rule{
+				{Name: "per-key", Header: "X-API-Key", Requests: 2, Window: "1m0s", Burst: 2},
+			}})
+			server = httptest.NewServer(proxy)
+			DeferCleanup(server.Close)
+		})
+
+		send := func(key string) *http.Response {
+			req, err := http.NewRequest(http.MethodPost, server.URL+"/chat", strings.NewReader(`{"message": "Hi"}`))
+			Expect(err).ShouldNot(HaveOccurred())
+			req.Header.Set("X-API-Key", key)
+			resp, err := http.DefaultClient.Do(req)
+			Expect(err).ShouldNot(HaveOccurred())
+			DeferCleanup(resp.Body.Close)
+			return resp
+		}
+
+		It("Should limit each client separately and refill over the window", func() {
+			Expect(send("alice").StatusCode).Should(Equal(http.StatusOK))
+			Expect(send("alice").StatusCode).Should(Equal(http.StatusOK))
+			limited := send("alice")
+			Expect(limited.StatusCode).Should(Equal(http.StatusTooManyRequests))
+			Expect(limited.Header.Get("Retry-After")).Should(Equal("30"))
+			body, _ := io.ReadAll(limited.Body)
+			Expect(string(body)).Should(ContainSubstring("rule per-key allows 2 requests per 1m0s"))
+
+			By("Allowing the other clients")
+			Expect(send("bob").StatusCode).Should(Equal(http.StatusOK))
+			Expect(upstream).Should(Equal(3))
+
+			By("Refilling a token every 30 seconds")
+			now = now.Add(30 * time.Second)
+			Expect(send("alice").StatusCode).Should(Equal(http.StatusOK))
+			Expect(send("alice").StatusCode).Should(Equal(http.StatusTooManyRequests))
+		})
+
+		It("Should keep the buckets of unchanged rules on reload", func() {
+			send("alice")
+			send("alice")
+			proxy.Limiter.SetConfig(ratelimit.Config{Rules: []ratelimit.Rule{
+				{Name: "per-key", Header: "X-API-Key", Requests: 2, Window: "1m0s", Burst: 2},
+				{Name: "per-ip", Requests: 100, Window: "1h0m0s", Burst: 100},
+			}})
+			Expect(send("alice").StatusCode).Should(Equal(http.StatusTooManyRequests))
+
+			proxy.Limiter.SetConfig(ratelimit.Config{Rules: []ratelimit.Rule{
+				{Name: "per-key", Header: "X-API-Key", Requests: 10, Window: "1m0s", Burst: 10},
+			}})
+			Expect(send("alice").StatusCode).Should(Equal(http.StatusOK))
+		})
+
+		It("Should append the rate limit metrics to those of the agent", func() {
+			send("alice")
+			send("alice")
+			send("alice")
+
+			resp, err := http.Get(server.URL + "/metrics")
+			Expect(err).ShouldNot(HaveOccurred())
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			Expect(string(body)).Should(ContainSubstring("kubeagentic_requests_total 3\n"))
+			Expect(string(body)).Should(ContainSubstring(`kubeagentic_ratelimit_allowed_total{agent="limited-agent"} 2`))
+			Expect(string(body)).Should(ContainSubstring(`kubeagentic_ratelimit_limited_total{agent="limited-agent",rule="per-key"} 1`))
+			Expect(string(body)).Should(ContainSubstring(`kubeagentic_ratelimit_clients{agent="limited-agent",rule="per-key"} 1`))
+		})
+
+		It("Should reject invalid configurations", func() {
+			_, err := ratelimit.ParseConfig([]byte(`{"rules": [{"name": "empty", "requests": 1, "window": "0s", "burst": 1}]}`))
+			Expect(err).Should(MatchError(ContainSubstring("the window must be positive")))
+		})
+	})
+})
@@ -0,0 +1,190 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/ingresshost"
+)
+
+var _ = Describe("Ingress Conflicts", func() {
+	created := time.Date(2024, 3, 14, 10, 0, 0, 0, time.UTC)
+
+	exposedAgent := func(name, namespace, host, path string, age time.Duration) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, CreationTimestamp: metav1.NewTime(created.Add(-age))},
+			Spec: aiv1.AgentSpec{
+				Provider:     "openai",
+				Model:        "gpt-4o",
+				SystemPrompt: "You are a helpful AI assistant.",
+				ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+				Ingress:      &aiv1.IngressConfig{Host: host, Path: path},
+			},
+		}
+	}
+
+	route := func(host, path string) ingresshost.Route {
+		agent := exposedAgent("agent", "default", host, path, 0)
+		route, ok := ingresshost.For(agent.Name, agent.Namespace, &agent.Spec)
+		Expect(ok).Should(BeTrue())
+		return route
+	}
+
+	Context("When comparing routes", func() {
+		It("Should allow different paths on the same host", func() {
+			Expect(route("ai.company.com", "/support").Overlaps(route("ai.company.com", "/sales"))).Should(BeFalse())
+			Expect(route("ai.company.com", "/support").Overlaps(route("ai.company.com", "/supporters"))).Should(BeFalse())
+			Expect(route("ai.company.com", "/support").Overlaps(route("chat.company.com", "/support"))).Should(BeFalse())
+		})
+
+		It("Should detect overlapping paths on the same host", func() {
+			Expect(route("ai.company.com", "/support").Overlaps(route("AI.company.com", "/support/"))).Should(BeTrue())
+			Expect(route("ai.company.com", "/support").Overlaps(route("ai.company.com", "/support/v2"))).Should(BeTrue())
+			Expect(route("ai.company.com", "").Overlaps(route("ai.company.com", "/sales"))).Should(BeTrue())
+		})
+
+		It("Should not expose agents without an Ingress", func() {
+			agent := exposedAgent("agent", "default", "ai.company.com", "/", 0)
+			agent.Spec.Ingress = nil
+			Expect(ingresshost.IndexValues(agent.Name, agent.Namespace, &agent.Spec)).Should(BeEmpty())
+
+			agent.Spec.ServiceType = corev1.ServiceTypeLoadBalancer
+			Expect(ingresshost.IndexValues(agent.Name, agent.Namespace, &agent.Spec)).Should(Equal([]string{"agent.default.local"}))
+		})
+
+		It("Should reject invalid hosts and paths", func() {
+			agent := exposedAgent("agent", "default", "*.company.com", "support", 0)
+			errs := ingresshost.Validate(&agent.Spec)
+			Expect(errs).Should(HaveLen(2))
+			Expect(errs[0].Field).Should(Equal("spec.ingress.host"))
+			Expect(errs[1].Field).Should(Equal("spec.ingress.path"))
+		})
+	})
+
+	Context("When reconciling agents claiming the same host", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			recorder   *record.FakeRecorder
+		)
+
+		setup := func(agents ...client.Object) {
+			ctx = context.Background()
+			scheme := newScheme()
+			objects := append([]client.Object{}, agents...)
+			for _, namespace := range []string{"default", "sales"} {
+				objects = append(objects, &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: namespace},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				})
+			}
+			fakeClient = newFakeClientBuilder(scheme).
+				WithObjects(objects...).
+				Build()
+			recorder = record.NewFakeRecorder(100)
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme, Recorder: recorder}
+		}
+
+		reconcile := func(name, namespace string) *aiv1.Agent {
+			key := types.NamespacedName{Name: name, Namespace: namespace}
+			_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: key})
+			Expect(err).ShouldNot(HaveOccurred())
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, key, agent)).Should(Succeed())
+			return agent
+		}
+
+		ingress := func(name, namespace string) (*networkingv1.Ingress, error) {
+			ingress := &networkingv1.Ingress{}
+			err := fakeClient.Get(ctx, types.NamespacedName{Name: name + "-ingress", Namespace: namespace}, ingress)
+			return ingress, err
+		}
+
+		conflict := func(agent *aiv1.Agent) *aiv1.AgentCondition {
+			for i := range agent.Status.Conditions {
+				if agent.Status.Conditions[i].Type == aiv1.AgentConditionIngressConflict {
+					return &agent.Status.Conditions[i]
+				}
+			}
+			return nil
+		}
+
+		It("Should leave the host to the agent of the same namespace created first", func() {
+			setup(
+				exposedAgent("support", "default", "ai.company.com", "/support", time.Hour),
+				exposedAgent("support-v2", "default", "ai.company.com", "/support", 0),
+			)
+
+			owner := reconcile("support", "default")
+			Expect(conflict(owner)).Should(BeNil())
+			created, err := ingress("support", "default")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(created.Spec.Rules[0].Host).Should(Equal("ai.company.com"))
+			Expect(created.Spec.Rules[0].HTTP.Paths[0].Path).Should(Equal("/support"))
+
+			agent := reconcile("support-v2", "default")
+			Expect(conflict(agent)).ShouldNot(BeNil())
+			Expect(conflict(agent).Reason).Should(Equal("HostClaimed"))
+			Expect(conflict(agent).Message).Should(Equal("ai.company.com/support overlaps ai.company.com/support of agent default/support; the Ingress is not created"))
+			Expect(recorder.Events).Should(Receive(HavePrefix("Warning IngressConflict")))
+			_, err = ingress("support-v2", "default")
+			Expect(errors.IsNotFound(err)).Should(BeTrue())
+		})
+
+		It("Should detect conflicts across namespaces and give the Ingress back once resolved", func() {
+			setup(
+				exposedAgent("assistant", "default", "ai.company.com", "/", time.Hour),
+				exposedAgent("sales", "sales", "ai.company.com", "/sales", 0),
+			)
+
+			agent := reconcile("sales", "sales")
+			Expect(conflict(agent)).ShouldNot(BeNil())
+			Expect(conflict(agent).Message).Should(ContainSubstring("of agent default/assistant"))
+
+			By("Moving the owner to a path of its own")
+			owner := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "assistant", Namespace: "default"}, owner)).Should(Succeed())
+			owner.Spec.Ingress.Path = "/assistant"
+			Expect(fakeClient.Update(ctx, owner)).Should(Succeed())
+
+			agent = reconcile("sales", "sales")
+			Expect(conflict(agent)).Should(BeNil())
+			_, err := ingress("sales", "sales")
+			Expect(err).ShouldNot(HaveOccurred())
+		})
+
+		It("Should delete the Ingress of an agent whose path starts overlapping", func() {
+			setup(
+				exposedAgent("support", "default", "ai.company.com", "/support", time.Hour),
+				exposedAgent("sales", "sales", "ai.company.com", "/sales", 0),
+			)
+			reconcile("sales", "sales")
+			_, err := ingress("sales", "sales")
+			Expect(err).ShouldNot(HaveOccurred())
+
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "sales", Namespace: "sales"}, agent)).Should(Succeed())
+			agent.Spec.Ingress.Path = "/support/sales"
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+
+			agent = reconcile("sales", "sales")
+			Expect(conflict(agent)).ShouldNot(BeNil())
+			_, err = ingress("sales", "sales")
+			Expect(errors.IsNotFound(err)).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,166 @@
+package test
+
+import (
+	"context"
+	"fmt"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Label Migration", func() {
+	const agentName = "legacy-agent"
+
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	legacyLabels := map[string]string{"app": agentName}
+	canonicalLabels := map[string]string{
+		"app.kubernetes.io/name":     "kubeagentic-agent",
+		"app.kubernetes.io/instance": agentName,
+		"kubeagentic.ai/agent":       agentName,
+	}
+
+	newClient := func(objects ...client.Object) client.Client {
+		migrationScheme := newScheme()
+
+		objects = append(objects,
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "legacy-secret", Namespace: "default"},
+				Data:       map[string][]byte{"api-key": []byte("sk-test")},
+			},
+			&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: agentName, Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "openai",
+					Model:        "gpt-4",
+					SystemPrompt: "You are a helpful AI assistant.",
+					ApiSecretRef: &aiv1.SecretKeyReference{
+						SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "legacy-secret"},
+							Key:                  "api-key",
+						},
+					},
+				},
+			},
+		)
+		return newFakeClientBuilder(migrationScheme).
+			WithObjects(objects...).
+			// The fake client does not enforce the immutable Deployment selector like the API server.
+			WithInterceptorFuncs(interceptor.Funcs{
+				Update: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.UpdateOption) error {
+					if deployment, ok := obj.(*appsv1.Deployment); ok {
+						existing := &appsv1.Deployment{}
+						if err := c.Get(ctx, client.ObjectKeyFromObject(deployment), existing); err != nil {
+							return err
+						}
+						if !equality.Semantic.DeepEqual(existing.Spec.Selector, deployment.Spec.Selector) {
+							return fmt.Errorf("Deployment.apps %q is invalid: spec.selector: field is immutable", deployment.Name)
+						}
+					}
+					return c.Update(ctx, obj, opts...)
+				},
+			}).
+			Build()
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: agentName, Namespace: "default"}}
+	})
+
+	Context("When upgrading an agent whose Deployment selects its pods by the app label", func() {
+		BeforeEach(func() {
+			replicas := int32(1)
+			fakeClient = newClient(
+				&appsv1.Deployment{
+					ObjectMeta: metav1.ObjectMeta{Name: agentName, Namespace: "default", Labels: legacyLabels},
+					Spec: appsv1.DeploymentSpec{
+						Replicas: &replicas,
+						Selector: &metav1.LabelSelector{MatchLabels: legacyLabels},
+						Template: corev1.PodTemplateSpec{
+							ObjectMeta: metav1.ObjectMeta{Labels: legacyLabels},
+							Spec: corev1.PodSpec{
+								Containers: []corev1.Container{{Name: "agent", Image: "kubeagentic/agent:old"}},
+							},
+						},
+					},
+				},
+				&corev1.Service{
+					ObjectMeta: metav1.ObjectMeta{Name: agentName + "-service", Namespace: "default"},
+					Spec: corev1.ServiceSpec{
+						Selector: legacyLabels,
+						Ports:    []corev1.ServicePort{{Port: 80}},
+					},
+				},
+			)
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: fakeClient.Scheme()}
+		})
+
+		It("Should keep the selector and label the pods with both label sets", func() {
+			for i := 0; i < 2; i++ {
+				_, err := reconciler.Reconcile(ctx, request)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			Expect(deployment.Spec.Selector.MatchLabels).Should(Equal(legacyLabels))
+			for k, v := range legacyLabels {
+				Expect(deployment.Spec.Template.Labels).Should(HaveKeyWithValue(k, v))
+			}
+			for k, v := range canonicalLabels {
+				Expect(deployment.Spec.Template.Labels).Should(HaveKeyWithValue(k, v))
+			}
+			Expect(deployment.Spec.Template.Spec.Containers[0].Image).ShouldNot(Equal("kubeagentic/agent:old"))
+
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+		})
+
+		It("Should keep the Service selecting the pods of the Deployment", func() {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			service := &corev1.Service{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: agentName + "-service", Namespace: "default"}, service)).Should(Succeed())
+			Expect(service.Spec.Selector).Should(Equal(legacyLabels))
+		})
+	})
+
+	Context("When creating a new agent", func() {
+		BeforeEach(func() {
+			fakeClient = newClient()
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: fakeClient.Scheme()}
+		})
+
+		It("Should select the pods by the canonical labels", func() {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			Expect(deployment.Spec.Selector.MatchLabels).Should(Equal(canonicalLabels))
+
+			service := &corev1.Service{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: agentName + "-service", Namespace: "default"}, service)).Should(Succeed())
+			Expect(service.Spec.Selector).Should(Equal(canonicalLabels))
+		})
+	})
+})
@@ -0,0 +1,83 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Log Level", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		logScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(logScheme).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "debugged-agent", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					LogLevel:     "warn",
+				},
+			}).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: logScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "debugged-agent", Namespace: "default"}}
+	})
+
+	envValue := func(name string) string {
+		deployment := reconcileDeployment(ctx, reconciler, request)
+		for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+			if env.Name == name {
+				return env.Value
+			}
+		}
+		return ""
+	}
+
+	setAnnotation := func(value string) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		if value == "" {
+			delete(agent.Annotations, "kubeagentic.ai/log-level")
+		} else {
+			agent.Annotations = map[string]string{"kubeagentic.ai/log-level": value}
+		}
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	It("Should pass the log level of the spec to the runtime", func() {
+		Expect(envValue("AGENT_LOG_LEVEL")).Should(Equal("warn"))
+		Expect(envValue("AGENT_DEBUG")).Should(Equal("false"))
+	})
+
+	It("Should prefer the annotation over the spec until it is removed", func() {
+		setAnnotation("debug")
+		Expect(envValue("AGENT_LOG_LEVEL")).Should(Equal("debug"))
+
+		setAnnotation("")
+		Expect(envValue("AGENT_LOG_LEVEL")).Should(Equal("warn"))
+	})
+
+	It("Should ignore an invalid annotation", func() {
+		setAnnotation("verbose")
+		Expect(envValue("AGENT_LOG_LEVEL")).Should(Equal("warn"))
+	})
+})
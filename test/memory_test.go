@@ -0,0 +1,151 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Conversation Memory", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+	redisKey := types.NamespacedName{Name: "concierge-redis", Namespace: "default"}
+
+	newReconciler := func(memory *aiv1.MemoryConfig) {
+		scheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(scheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "concierge", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "vllm",
+						Model:        "llama-3-8b",
+						SystemPrompt: "You are a helpful AI assistant.",
+						Endpoint:     "http://vllm.default.svc:8000/v1",
+						Memory:       memory,
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "memory-store", Namespace: "default"},
+					Data:       map[string][]byte{"url": []byte("postgres://postgres.default.svc:5432/memory")},
+				},
+			).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "concierge", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	agentEnv := func() map[string]corev1.EnvVar {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		env := map[string]corev1.EnvVar{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e
+		}
+		return env
+	}
+
+	configValid := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionConfigValid {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should render an external backend into the environment and the config ConfigMap", func() {
+		newReconciler(&aiv1.MemoryConfig{
+			Backend: "postgres",
+			ConnectionSecretRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "memory-store"},
+				Key:                  "url",
+			},
+			MaxHistoryMessages:     50,
+			SummarizationThreshold: 40,
+		})
+		Expect(configValid(reconcile()).Status).Should(Equal(corev1.ConditionTrue))
+
+		env := agentEnv()
+		Expect(env["AGENT_MEMORY_BACKEND"].Value).Should(Equal("postgres"))
+		Expect(env["AGENT_MEMORY_URL"].ValueFrom.SecretKeyRef.Name).Should(Equal("memory-store"))
+		Expect(env["AGENT_MEMORY_MAX_HISTORY_MESSAGES"].Value).Should(Equal("50"))
+		Expect(env["AGENT_MEMORY_SUMMARIZATION_THRESHOLD"].Value).Should(Equal("40"))
+
+		configMap := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "concierge-config", Namespace: "default"}, configMap)).Should(Succeed())
+		Expect(configMap.Data["memory-config.json"]).Should(ContainSubstring(`"backend":"postgres"`))
+		Expect(configMap.Data["memory-config.json"]).ShouldNot(ContainSubstring("postgres.default.svc"))
+
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, redisKey, &appsv1.Deployment{}))).Should(BeTrue())
+	})
+
+	It("Should deploy a managed Redis selected apart from the agent pods", func() {
+		newReconciler(&aiv1.MemoryConfig{Backend: "redis"})
+		reconcile()
+
+		Expect(agentEnv()["AGENT_MEMORY_URL"].Value).Should(Equal("redis://concierge-redis.default.svc:6379/0"))
+
+		redis := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, redisKey, redis)).Should(Succeed())
+		Expect(redis.OwnerReferences).Should(HaveLen(1))
+		redisService := &corev1.Service{}
+		Expect(fakeClient.Get(ctx, redisKey, redisService)).Should(Succeed())
+		Expect(redisService.Spec.Ports).Should(ConsistOf(HaveField("Port", int32(6379))))
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		service := &corev1.Service{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "concierge-service", Namespace: "default"}, service)).Should(Succeed())
+
+		redisPods := labels.Set(redis.Spec.Template.Labels)
+		agentPods := labels.Set(deployment.Spec.Template.Labels)
+		Expect(labels.SelectorFromSet(redisService.Spec.Selector).Matches(redisPods)).Should(BeTrue())
+		Expect(labels.SelectorFromSet(redisService.Spec.Selector).Matches(agentPods)).Should(BeFalse())
+		Expect(labels.SelectorFromSet(service.Spec.Selector).Matches(agentPods)).Should(BeTrue())
+		Expect(labels.SelectorFromSet(service.Spec.Selector).Matches(redisPods)).Should(BeFalse())
+
+		By("Removing the managed Redis once the agent keeps its memory in process")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.Memory.Backend = "inmemory"
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile()
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, redisKey, &appsv1.Deployment{}))).Should(BeTrue())
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, redisKey, &corev1.Service{}))).Should(BeTrue())
+		Expect(agentEnv()).ShouldNot(HaveKey("AGENT_MEMORY_URL"))
+	})
+
+	It("Should reject a postgres backend without a connection secret", func() {
+		newReconciler(&aiv1.MemoryConfig{Backend: "postgres"})
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid(agent).Reason).Should(Equal("InvalidMemoryConfig"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("memory.connectionSecretRef is required for the postgres backend"))
+	})
+})
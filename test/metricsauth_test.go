@@ -0,0 +1,94 @@
+package test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/go-logr/logr"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/metricsauth"
+)
+
+var _ = Describe("Metrics Authorization", func() {
+	const (
+		scraperToken  = "scraper-token"
+		readOnlyToken = "read-only-token"
+	)
+
+	var (
+		apiServer     *httptest.Server
+		metricsServer *httptest.Server
+	)
+
+	BeforeEach(func() {
+		// A stand-in API server that knows two users, of which only the scraper may get /metrics.
+		apiServer = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			switch r.URL.Path {
+			case "/apis/authentication.k8s.io/v1/tokenreviews":
+				review := &authenticationv1.TokenReview{}
+				Expect(json.NewDecoder(r.Body).Decode(review)).Should(Succeed())
+				switch review.Spec.Token {
+				case scraperToken:
+					review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "system:serviceaccount:monitoring:prometheus"}}
+				case readOnlyToken:
+					review.Status = authenticationv1.TokenReviewStatus{Authenticated: true, User: authenticationv1.UserInfo{Username: "system:serviceaccount:default:reader"}}
+				}
+				json.NewEncoder(w).Encode(review)
+			case "/apis/authorization.k8s.io/v1/subjectaccessreviews":
+				review := &authorizationv1.SubjectAccessReview{}
+				Expect(json.NewDecoder(r.Body).Decode(review)).Should(Succeed())
+				review.Status.Allowed = review.Spec.User == "system:serviceaccount:monitoring:prometheus" &&
+					review.Spec.NonResourceAttributes != nil &&
+					review.Spec.NonResourceAttributes.Path == "/metrics" &&
+					review.Spec.NonResourceAttributes.Verb == "get"
+				json.NewEncoder(w).Encode(review)
+			default:
+				http.NotFound(w, r)
+			}
+		}))
+		DeferCleanup(apiServer.Close)
+
+		filter, err := metricsauth.WithAuthenticationAndAuthorization(&rest.Config{Host: apiServer.URL}, apiServer.Client())
+		Expect(err).ShouldNot(HaveOccurred())
+		handler, err := filter(logr.Discard(), http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "controller_runtime_reconcile_total 1\n")
+		}))
+		Expect(err).ShouldNot(HaveOccurred())
+		metricsServer = httptest.NewTLSServer(handler)
+		DeferCleanup(metricsServer.Close)
+	})
+
+	scrape := func(token string) int {
+		req, err := http.NewRequest(http.MethodGet, metricsServer.URL+"/metrics", nil)
+		Expect(err).ShouldNot(HaveOccurred())
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := metricsServer.Client().Do(req)
+		Expect(err).ShouldNot(HaveOccurred())
+		defer resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	It("Should reject an unauthenticated scrape", func() {
+		Expect(scrape("")).Should(Equal(http.StatusUnauthorized))
+		Expect(scrape("unknown-token")).Should(Equal(http.StatusUnauthorized))
+	})
+
+	It("Should reject a scrape by a user without access to /metrics", func() {
+		Expect(scrape(readOnlyToken)).Should(Equal(http.StatusForbidden))
+	})
+
+	It("Should serve an authorized scrape", func() {
+		Expect(scrape(scraperToken)).Should(Equal(http.StatusOK))
+	})
+})
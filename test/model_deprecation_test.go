@@ -0,0 +1,131 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+)
+
+var _ = Describe("Model Deprecations", func() {
+	AfterEach(func() {
+		pricing.Set(nil)
+	})
+
+	Context("When reading the catalog", func() {
+		It("Should describe deprecated and retired models in the admission warning", func() {
+			deprecation, ok := pricing.DeprecationFor("claude", "claude-3-opus-20240229", time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))
+			Expect(ok).Should(BeTrue())
+			Expect(deprecation.Message("claude", "claude-3-opus-20240229", time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC))).Should(Equal(
+				"model claude/claude-3-opus-20240229 is deprecated and retires on 2026-01-05, switch to claude-opus-4-1-20250805"))
+			Expect(deprecation.Message("claude", "claude-3-opus-20240229", time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC))).Should(Equal(
+				"model claude/claude-3-opus-20240229 was retired on 2026-01-05, switch to claude-opus-4-1-20250805"))
+
+			By("Not warning before the deprecation date or for current models")
+			_, ok = pricing.DeprecationFor("claude", "claude-3-opus-20240229", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+			Expect(ok).Should(BeFalse())
+			_, ok = pricing.DeprecationFor("openai", "gpt-4o", time.Now())
+			Expect(ok).Should(BeFalse())
+		})
+
+		It("Should reject deprecations without valid dates", func() {
+			for _, catalog := range []string{
+				"deprecations:\n  openai/gpt-4: {replacement: gpt-4o}",
+				"deprecations:\n  openai/gpt-4: {retires: 30/06/2026}",
+				"deprecations:\n  gpt-4: {retires: \"2026-06-30\"}",
+				"deprecations:\n  openai/gpt-4: {deprecated: \"2026-07-01\", retires: \"2026-06-30\"}",
+			} {
+				_, err := pricing.Parse([]byte(catalog))
+				Expect(err).Should(HaveOccurred(), catalog)
+			}
+		})
+	})
+
+	Context("When the catalog deprecates the model of an agent", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			scheme     *runtime.Scheme
+			request    ctrl.Request
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			scheme = newScheme()
+
+			fakeClient = newFakeClientBuilder(scheme).
+				WithObjects(
+					&corev1.ConfigMap{
+						ObjectMeta: metav1.ObjectMeta{Name: controllers.PricingConfigMap, Namespace: "kubeagentic-system"},
+						Data: map[string]string{"catalog.yaml": `
+deprecations:
+  openai/gpt-4:
+    deprecated: "2024-01-01"
+    retires: "2999-01-01"
+    replacement: gpt-4o
+`},
+					},
+					&aiv1.Agent{
+						ObjectMeta: metav1.ObjectMeta{Name: "legacy-agent", Namespace: "default"},
+						Spec: aiv1.AgentSpec{
+							Provider:     "openai",
+							Model:        "gpt-4",
+							SystemPrompt: "You are a helpful AI assistant.",
+							Endpoint:     "http://gateway.default.svc:8080/v1",
+						},
+					},
+				).
+				Build()
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "legacy-agent", Namespace: "default"}}
+		})
+
+		condition := func() *aiv1.AgentCondition {
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			for i := range agent.Status.Conditions {
+				if agent.Status.Conditions[i].Type == aiv1.AgentConditionModelDeprecated {
+					return &agent.Status.Conditions[i]
+				}
+			}
+			return nil
+		}
+
+		It("Should set the condition when the catalog is loaded and clear it once the model changes", func() {
+			catalogReconciler := &controllers.PricingCatalogReconciler{Client: fakeClient, Namespace: "kubeagentic-system"}
+			_, err := catalogReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Name: controllers.PricingConfigMap, Namespace: "kubeagentic-system"}})
+			Expect(err).ShouldNot(HaveOccurred())
+
+			deprecated := condition()
+			Expect(deprecated).ShouldNot(BeNil())
+			Expect(deprecated.Status).Should(Equal(corev1.ConditionTrue))
+			Expect(deprecated.Reason).Should(Equal("ModelDeprecated"))
+			Expect(deprecated.Message).Should(Equal("model openai/gpt-4 is deprecated and retires on 2999-01-01, switch to gpt-4o"))
+
+			By("Keeping the condition through reconciles of the agent")
+			agentReconciler := &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+			_, err = agentReconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(condition()).ShouldNot(BeNil())
+
+			By("Switching to the replacement")
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Spec.Model = "gpt-4o"
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			_, err = agentReconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(condition()).Should(BeNil())
+		})
+	})
+})
@@ -0,0 +1,135 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Model Preload", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newReconciler := func(provider string, pods ...client.Object) {
+		preloadScheme := newScheme()
+
+		retries := int32(5)
+		objects := append(pods, &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "preloaded-agent", Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     provider,
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.default.svc:8000/v1",
+				Preload:      &aiv1.PreloadConfig{Enabled: true, Prompt: "Hi", Retries: &retries},
+			},
+		})
+		fakeClient = newFakeClientBuilder(preloadScheme).
+			WithObjects(objects...).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: preloadScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "preloaded-agent", Namespace: "default"}}
+	}
+
+	reconcile := func() (*appsv1.Deployment, *aiv1.Agent) {
+		deployment := reconcileDeployment(ctx, reconciler, request)
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		return deployment, agent
+	}
+
+	pod := func(name string, preload corev1.ContainerStatus) *corev1.Pod {
+		preload.Name = "preload-model"
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"kubeagentic.ai/agent": "preloaded-agent"}},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodPending,
+				InitContainerStatuses: []corev1.ContainerStatus{
+					{Name: "wait-for-endpoint", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}},
+					preload,
+				},
+			},
+		}
+	}
+	done := corev1.ContainerStatus{State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 0}}}
+	running := corev1.ContainerStatus{State: corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should preload the model after the endpoint answers", func() {
+		newReconciler("vllm")
+		deployment, _ := reconcile()
+
+		containers := deployment.Spec.Template.Spec.InitContainers
+		Expect(containers).Should(HaveLen(2))
+		Expect(containers[0].Name).Should(Equal("wait-for-endpoint"))
+		Expect(containers[1].Name).Should(Equal("preload-model"))
+		Expect(containers[1].Command).Should(Equal([]string{"python", "wait_for_endpoint.py", "--preload"}))
+		env := map[string]string{}
+		for _, e := range containers[1].Env {
+			env[e.Name] = e.Value
+		}
+		Expect(env).Should(And(
+			HaveKeyWithValue("AGENT_ENDPOINT", "http://vllm.default.svc:8000/v1"),
+			HaveKeyWithValue("AGENT_MODEL", "llama-3-8b"),
+			HaveKeyWithValue("AGENT_PRELOAD_PROMPT", "Hi"),
+			HaveKeyWithValue("AGENT_PRELOAD_MAX_TOKENS", "16"),
+			HaveKeyWithValue("AGENT_PRELOAD_RETRIES", "5"),
+			HaveKeyWithValue("AGENT_PRELOAD_TIMEOUT_SECONDS", "600"),
+		))
+	})
+
+	It("Should skip the preload for hosted providers", func() {
+		newReconciler("openai")
+		deployment, agent := reconcile()
+		Expect(deployment.Spec.Template.Spec.InitContainers).Should(BeEmpty())
+		Expect(agent.Status.Preload).Should(BeNil())
+	})
+
+	It("Should report the pod furthest behind", func() {
+		newReconciler("vllm", pod("preloaded-agent-a", done), pod("preloaded-agent-b", running))
+		_, agent := reconcile()
+		Expect(agent.Status.Preload).ShouldNot(BeNil())
+		Expect(agent.Status.Preload.Phase).Should(Equal(aiv1.PreloadPhaseWarming))
+		Expect(agent.Status.Message).Should(ContainSubstring("Preloading model llama-3-8b"))
+	})
+
+	It("Should report ready once every pod preloaded the model", func() {
+		newReconciler("vllm", pod("preloaded-agent-a", done))
+		_, agent := reconcile()
+		Expect(agent.Status.Preload).ShouldNot(BeNil())
+		Expect(agent.Status.Preload.Phase).Should(Equal(aiv1.PreloadPhaseReady))
+	})
+
+	It("Should report a failed preload", func() {
+		newReconciler("vllm", pod("preloaded-agent-a", done), pod("preloaded-agent-b", corev1.ContainerStatus{
+			RestartCount: 1,
+			State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+			LastTerminationState: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+				ExitCode: 1,
+				Message:  "last error from http://vllm.default.svc:8000/v1/chat/completions: HTTP 500",
+			}},
+		}))
+		_, agent := reconcile()
+		Expect(agent.Status.Preload).ShouldNot(BeNil())
+		Expect(agent.Status.Preload.Phase).Should(Equal(aiv1.PreloadPhaseFailed))
+		Expect(agent.Status.Preload.Message).Should(ContainSubstring("preloaded-agent-b failed to preload model llama-3-8b"))
+		Expect(agent.Status.Message).Should(Equal(agent.Status.Preload.Message))
+	})
+})
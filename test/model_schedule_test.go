@@ -0,0 +1,303 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/modelschedule"
+)
+
+var _ = Describe("Model Schedule", func() {
+	paris, err := time.LoadLocation("Europe/Paris")
+	if err != nil {
+		panic(err)
+	}
+	// Wednesday, March 13th 2024, in Paris
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, paris)
+	}
+
+	// Nights from 8pm to 8am, and a shorter late evening window from 10pm to midnight
+	schedule := func() []aiv1.ModelScheduleEntry {
+		return []aiv1.ModelScheduleEntry{
+			{
+				Window: aiv1.ScheduleWindow{Start: "0 20 * * *", Duration: metav1.Duration{Duration: 12 * time.Hour}, Timezone: "Europe/Paris"},
+				Model:  "gpt-4o-mini",
+			},
+			{
+				Window:          aiv1.ScheduleWindow{Start: "0 22 * * *", Duration: metav1.Duration{Duration: 2 * time.Hour}, Timezone: "Europe/Paris"},
+				Model:           "gpt-3.5-turbo",
+				ModelParameters: &aiv1.ModelParameters{Temperature: "0.2", MaxTokens: int32Ptr(500)},
+			},
+		}
+	}
+
+	Context("Evaluating the windows", func() {
+		parse := func(entries []aiv1.ModelScheduleEntry) []*modelschedule.Window {
+			windows := make([]*modelschedule.Window, len(entries))
+			for i, entry := range entries {
+				window, err := modelschedule.ParseWindow(entry.Window.Start, entry.Window.Duration.Duration, entry.Window.Timezone)
+				Expect(err).ShouldNot(HaveOccurred())
+				windows[i] = window
+			}
+			return windows
+		}
+
+		It("Should switch at the window boundaries, the shortest overlapping window winning", func() {
+			windows := parse(schedule())
+			for _, step := range []struct {
+				now        time.Time
+				active     int
+				nextSwitch time.Time
+			}{
+				{at(13, 19, 59), -1, at(13, 20, 0)},
+				{at(13, 20, 0), 0, at(13, 22, 0)},
+				{at(13, 21, 59), 0, at(13, 22, 0)},
+				{at(13, 22, 0), 1, at(14, 0, 0)},
+				{at(13, 23, 59), 1, at(14, 0, 0)},
+				{at(14, 0, 0), 0, at(14, 8, 0)},
+				{at(14, 8, 0), -1, at(14, 20, 0)},
+			} {
+				Expect(modelschedule.Active(windows, step.now)).Should(Equal(step.active), "active at %s", step.now)
+				nextSwitch, ok := modelschedule.NextSwitch(windows, step.now)
+				Expect(ok).Should(BeTrue())
+				Expect(nextSwitch).Should(BeTemporally("==", step.nextSwitch), "next switch at %s", step.now)
+			}
+		})
+
+		It("Should prefer the first listed of equally long windows", func() {
+			entries := schedule()
+			entries[1].Window.Duration = entries[0].Window.Duration
+			windows := parse(entries)
+			Expect(modelschedule.Active(windows, at(13, 23, 0))).Should(Equal(0))
+			Expect(modelschedule.Active(windows[1:], at(13, 23, 0))).Should(Equal(0))
+		})
+
+		It("Should keep the duration of windows spanning a daylight saving time change", func() {
+			windows := parse(schedule()[:1])
+			// Clocks go forward from 2am to 3am on March 31st
+			nextSwitch, ok := modelschedule.NextSwitch(windows, at(30, 21, 0))
+			Expect(ok).Should(BeTrue())
+			Expect(nextSwitch).Should(BeTemporally("==", at(31, 9, 0)))
+		})
+
+		It("Should reject invalid windows", func() {
+			_, err := modelschedule.ParseWindow("0 20 * *", time.Hour, "")
+			Expect(err).Should(MatchError(`invalid schedule "0 20 * *": expected 5 fields, found 4`))
+			_, err = modelschedule.ParseWindow("0 25 * * *", time.Hour, "")
+			Expect(err).Should(MatchError(ContainSubstring(`hour: value "25" is not between 0 and 23`)))
+			_, err = modelschedule.ParseWindow("0 20 * * *", 8*24*time.Hour, "")
+			Expect(err).Should(MatchError("duration 192h0m0s must be between 1m and 168h0m0s"))
+			_, err = modelschedule.ParseWindow("0 20 * * *", time.Hour, "Mars/Olympus")
+			Expect(err).Should(MatchError(`time zone "Mars/Olympus" is not a known time zone`))
+			_, err = modelschedule.ParseWindow("0 20 30 feb *", time.Hour, "")
+			Expect(err).Should(MatchError(`schedule "0 20 30 feb *" never opens the window`))
+		})
+	})
+
+	Context("Reconciling the model schedule", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			clock      *clocktesting.FakePassiveClock
+			recorder   *record.FakeRecorder
+			request    ctrl.Request
+		)
+
+		newReconciler := func(mutate func(*aiv1.AgentSpec)) {
+			scheme := newScheme()
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:      "vllm",
+					Model:         "gpt-4o",
+					SystemPrompt:  "You are a helpful AI assistant.",
+					Endpoint:      "http://vllm.default.svc:8000/v1",
+					ModelSchedule: schedule(),
+				},
+			}
+			mutate(&agent.Spec)
+			fakeClient = newFakeClientBuilder(scheme).
+				WithObjects(agent).
+				Build()
+			clock = clocktesting.NewFakePassiveClock(at(13, 12, 0))
+			recorder = record.NewFakeRecorder(100)
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme, Clock: clock, Recorder: recorder}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+		}
+
+		reconcile := func() (*aiv1.Agent, ctrl.Result) {
+			result, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			return agent, result
+		}
+
+		get := func(name string, obj client.Object) {
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, obj)).Should(Succeed())
+		}
+
+		agentEnv := func() []corev1.EnvVar {
+			deployment := &appsv1.Deployment{}
+			get("support", deployment)
+			return deployment.Spec.Template.Spec.Containers[0].Env
+		}
+
+		drainEvents := func() []string {
+			var events []string
+			for len(recorder.Events) > 0 {
+				events = append(events, <-recorder.Events)
+			}
+			return events
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+		})
+
+		It("Should hot reload the scheduled model of the runtime without rolling the pods", func() {
+			newReconciler(func(*aiv1.AgentSpec) {})
+			clock.SetTime(at(13, 19, 59).Add(50 * time.Second))
+			agent, result := reconcile()
+			Expect(agent.Status.ModelSchedule).ShouldNot(BeNil())
+			Expect(agent.Status.ModelSchedule.ActiveModel).Should(Equal("gpt-4o"))
+			Expect(agent.Status.ModelSchedule.ActiveEntry).Should(BeNil())
+			Expect(agent.Status.ModelSchedule.NextSwitchTime.Time).Should(BeTemporally("==", at(13, 20, 0)))
+			Expect(result.RequeueAfter).Should(Equal(10 * time.Second))
+
+			configMap := &corev1.ConfigMap{}
+			get("support-config", configMap)
+			Expect(configMap.Data["model.json"]).Should(MatchJSON(`{"model": "gpt-4o"}`))
+			env := agentEnv()
+			Expect(env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-4o"}))
+			Expect(env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL_FILE", Value: "/etc/kubeagentic/model/model.json"}))
+
+			By("Opening the late evening window, which overrides the night window")
+			clock.SetTime(at(13, 22, 0))
+			agent, _ = reconcile()
+			Expect(agent.Status.ModelSchedule.ActiveModel).Should(Equal("gpt-3.5-turbo"))
+			Expect(*agent.Status.ModelSchedule.ActiveEntry).Should(Equal(int32(1)))
+			Expect(agent.Status.ModelSchedule.NextSwitchTime.Time).Should(BeTemporally("==", at(14, 0, 0)))
+			Expect(drainEvents()).Should(ContainElement("Normal ModelSwitched Switched the model from gpt-4o to gpt-3.5-turbo"))
+
+			get("support-config", configMap)
+			expectGoldenIn("modelschedule", "model.json", []byte(configMap.Data["model.json"]))
+			Expect(agentEnv()).Should(Equal(env))
+
+			By("Closing the late evening window, which falls back to the night window")
+			clock.SetTime(at(14, 0, 0))
+			agent, _ = reconcile()
+			Expect(agent.Status.ModelSchedule.ActiveModel).Should(Equal("gpt-4o-mini"))
+			Expect(drainEvents()).Should(ContainElement("Normal ModelSwitched Switched the model from gpt-3.5-turbo to gpt-4o-mini"))
+			get("support-config", configMap)
+			Expect(configMap.Data["model.json"]).Should(MatchJSON(`{"model": "gpt-4o-mini"}`))
+
+			By("Removing the schedule")
+			agent.Spec.ModelSchedule = nil
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			agent, _ = reconcile()
+			Expect(agent.Status.ModelSchedule).Should(BeNil())
+			get("support-config", configMap)
+			Expect(configMap.Data).ShouldNot(HaveKey("model.json"))
+			Expect(agentEnv()).ShouldNot(ContainElement(HaveField("Name", "AGENT_MODEL_FILE")))
+		})
+
+		It("Should roll the pods of agents with an image of their own at the window boundaries", func() {
+			newReconciler(func(spec *aiv1.AgentSpec) {
+				spec.Image = "registry.example.com/support-agent:1.0.0"
+			})
+			clock.SetTime(at(13, 22, 30))
+			agent, _ := reconcile()
+			Expect(agent.Status.ModelSchedule.ActiveModel).Should(Equal("gpt-3.5-turbo"))
+			env := agentEnv()
+			Expect(env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-3.5-turbo"}))
+			Expect(env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_TEMPERATURE", Value: "0.2"}))
+			Expect(env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MAX_TOKENS", Value: "500"}))
+			Expect(env).ShouldNot(ContainElement(HaveField("Name", "AGENT_MODEL_FILE")))
+
+			clock.SetTime(at(14, 8, 0))
+			reconcile()
+			env = agentEnv()
+			Expect(env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_MODEL", Value: "gpt-4o"}))
+			Expect(env).ShouldNot(ContainElement(HaveField("Name", "AGENT_TEMPERATURE")))
+		})
+
+		It("Should reject a schedule combined with a rollout", func() {
+			newReconciler(func(spec *aiv1.AgentSpec) {
+				spec.Rollout = &aiv1.RolloutConfig{Canary: &aiv1.CanaryStrategy{Steps: []aiv1.CanaryStep{{Weight: 25}}}}
+			})
+			agent, _ := reconcile()
+			var configValid *aiv1.AgentCondition
+			for i := range agent.Status.Conditions {
+				if agent.Status.Conditions[i].Type == aiv1.AgentConditionConfigValid {
+					configValid = &agent.Status.Conditions[i]
+				}
+			}
+			Expect(configValid).ShouldNot(BeNil())
+			Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(configValid.Reason).Should(Equal("InvalidModelSchedule"))
+			Expect(configValid.Message).Should(ContainSubstring("modelSchedule cannot be combined with rollout"))
+		})
+	})
+
+	Context("Admitting agents with a model schedule", func() {
+		admit := func(mutate func(*aiv1.AgentSpec)) error {
+			validator := &webhookv1.AgentWebhook{}
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:      "openai",
+					Model:         "gpt-4o",
+					SystemPrompt:  "You are a helpful AI assistant.",
+					ApiSecretRef:  &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+					ModelSchedule: schedule(),
+				},
+			}
+			mutate(&agent.Spec)
+			Expect(validator.Default(context.Background(), agent)).Should(Succeed())
+			_, err := validator.ValidateCreate(context.Background(), agent)
+			return err
+		}
+
+		It("Should admit models of the pricing catalog", func() {
+			Expect(admit(func(*aiv1.AgentSpec) {})).Should(Succeed())
+		})
+
+		It("Should reject models missing from the pricing catalog of the provider", func() {
+			err := admit(func(spec *aiv1.AgentSpec) {
+				spec.ModelSchedule[1].Model = "claude-3-haiku"
+			})
+			Expect(err).Should(MatchError(ContainSubstring(`spec.modelSchedule[1].model: Invalid value: "claude-3-haiku": not in the pricing catalog of provider openai`)))
+		})
+
+		It("Should reject invalid windows and a schedule combined with an experiment", func() {
+			err := admit(func(spec *aiv1.AgentSpec) {
+				spec.ModelSchedule[0].Window.Timezone = "Mars/Olympus"
+				spec.Experiment = &aiv1.ExperimentConfig{
+					VariantB:       aiv1.ExperimentVariant{Model: "gpt-4o-mini"},
+					TrafficPercent: 50,
+					Duration:       metav1.Duration{Duration: time.Hour},
+				}
+			})
+			Expect(err).Should(MatchError(ContainSubstring(`spec.modelSchedule[0].window: Invalid value: "0 20 * * *": time zone "Mars/Olympus" is not a known time zone`)))
+			Expect(err).Should(MatchError(ContainSubstring("spec.modelSchedule: Forbidden: cannot be combined with spec.experiment")))
+		})
+	})
+})
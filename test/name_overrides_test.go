@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Name Overrides", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		overridesScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(overridesScheme).
+			WithObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "support-secret", Namespace: "default"},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				},
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "support-bot", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:      "openai",
+						Model:         "gpt-4",
+						SystemPrompt:  "You are a helpful AI assistant.",
+						NameOverrides: &aiv1.NameOverrides{Service: "support-bot"},
+						ApiSecretRef: &aiv1.SecretKeyReference{
+							SecretKeySelector: corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "support-secret"},
+								Key:                  "api-key",
+							},
+						},
+					},
+				},
+			).
+			Build()
+
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: overridesScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support-bot", Namespace: "default"}}
+	})
+
+	exists := func(name string, obj client.Object) bool {
+		err := fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, obj)
+		if errors.IsNotFound(err) {
+			return false
+		}
+		Expect(err).ShouldNot(HaveOccurred())
+		return true
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	setOverrides := func(overrides *aiv1.NameOverrides) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.NameOverrides = overrides
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	Context("When the Service name is overridden", func() {
+		It("Should create the Service under the historical name", func() {
+			agent := reconcile()
+
+			Expect(exists("support-bot", &corev1.Service{})).Should(BeTrue())
+			Expect(exists("support-bot-service", &corev1.Service{})).Should(BeFalse())
+			Expect(agent.Status.ResourceNames).ShouldNot(BeNil())
+			Expect(agent.Status.ResourceNames.Service).Should(Equal("support-bot"))
+			Expect(agent.Status.ResourceNames.Deployment).Should(Equal("support-bot"))
+		})
+	})
+
+	Context("When an override changes", func() {
+		It("Should replace the Service and delete the previous one", func() {
+			reconcile()
+			setOverrides(&aiv1.NameOverrides{Service: "support"})
+			agent := reconcile()
+
+			Expect(exists("support", &corev1.Service{})).Should(BeTrue())
+			Expect(exists("support-bot", &corev1.Service{})).Should(BeFalse())
+			Expect(agent.Status.ResourceNames.Service).Should(Equal("support"))
+		})
+
+		It("Should keep the previous Deployment until the new one is ready", func() {
+			reconcile()
+			setOverrides(&aiv1.NameOverrides{Service: "support-bot", Deployment: "support-bot-v2"})
+			agent := reconcile()
+
+			Expect(exists("support-bot-v2", &appsv1.Deployment{})).Should(BeTrue())
+			Expect(exists("support-bot", &appsv1.Deployment{})).Should(BeTrue())
+			Expect(agent.Status.ResourceNames.Deployment).Should(Equal("support-bot"))
+
+			By("Marking the new Deployment ready")
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-bot-v2", Namespace: "default"}, deployment)).Should(Succeed())
+			deployment.Status.ObservedGeneration = deployment.Generation
+			deployment.Status.Replicas = 1
+			deployment.Status.UpdatedReplicas = 1
+			deployment.Status.ReadyReplicas = 1
+			deployment.Status.AvailableReplicas = 1
+			Expect(fakeClient.Status().Update(ctx, deployment)).Should(Succeed())
+
+			agent = reconcile()
+			Expect(exists("support-bot", &appsv1.Deployment{})).Should(BeFalse())
+			Expect(agent.Status.ResourceNames.Deployment).Should(Equal("support-bot-v2"))
+		})
+	})
+})
@@ -0,0 +1,133 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Namespace Limits", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newReconciler := func(objects ...client.Object) {
+		limitsScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(limitsScheme).
+			WithStatusSubresource(&corev1.ResourceQuota{}).
+			WithObjects(append(objects, &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "limited", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+				},
+			})...).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: limitsScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "limited", Namespace: "default"}}
+	}
+
+	degraded := func() aiv1.AgentCondition {
+		agent := reconcileAgent(ctx, reconciler, request)
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionDegraded {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should report containers above the max of a LimitRange until it is raised", func() {
+		limitRange := &corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "tight", Namespace: "default"},
+			Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+				Type: corev1.LimitTypeContainer,
+				Max:  corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("256Mi")},
+			}}},
+		}
+		newReconciler(limitRange)
+
+		condition := degraded()
+		Expect(condition.Status).Should(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).Should(Equal("LimitRangeExceeded"))
+		Expect(condition.Message).Should(ContainSubstring("memory limit 512Mi of container agent exceeds LimitRange tight max 256Mi"))
+
+		By("Resolving the condition once the LimitRange allows the pods")
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(limitRange), limitRange)).Should(Succeed())
+		limitRange.Spec.Limits[0].Max[corev1.ResourceMemory] = resource.MustParse("1Gi")
+		Expect(fakeClient.Update(ctx, limitRange)).Should(Succeed())
+		condition = degraded()
+		Expect(condition.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).Should(Equal("LimitRangeSatisfied"))
+	})
+
+	It("Should report requests below the min of a LimitRange", func() {
+		newReconciler(&corev1.LimitRange{
+			ObjectMeta: metav1.ObjectMeta{Name: "floor", Namespace: "default"},
+			Spec: corev1.LimitRangeSpec{Limits: []corev1.LimitRangeItem{{
+				Type: corev1.LimitTypeContainer,
+				Min:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+			}}},
+		})
+
+		condition := degraded()
+		Expect(condition.Reason).Should(Equal("LimitRangeExceeded"))
+		Expect(condition.Message).Should(ContainSubstring("cpu request 100m of container agent is below LimitRange floor min 500m"))
+	})
+
+	It("Should report a ResourceQuota without room for another pod", func() {
+		quota := &corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+			Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("1Gi")}},
+			Status: corev1.ResourceQuotaStatus{
+				Hard: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("1Gi")},
+				Used: corev1.ResourceList{corev1.ResourceLimitsMemory: resource.MustParse("768Mi")},
+			},
+		}
+		newReconciler(quota)
+
+		condition := degraded()
+		Expect(condition.Status).Should(Equal(corev1.ConditionTrue))
+		Expect(condition.Reason).Should(Equal("ResourceQuotaExceeded"))
+		Expect(condition.Message).Should(ContainSubstring("limits.memory 512Mi of an agent pod exceeds the 256Mi left of ResourceQuota team, which has used 768Mi of 1Gi"))
+
+		By("Resolving the condition once the quota is freed")
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(quota), quota)).Should(Succeed())
+		quota.Status.Used[corev1.ResourceLimitsMemory] = resource.MustParse("256Mi")
+		Expect(fakeClient.Status().Update(ctx, quota)).Should(Succeed())
+		condition = degraded()
+		Expect(condition.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).Should(Equal("ResourceQuotaAvailable"))
+	})
+
+	It("Should report an agent pod larger than the whole ResourceQuota", func() {
+		newReconciler(&corev1.ResourceQuota{
+			ObjectMeta: metav1.ObjectMeta{Name: "small", Namespace: "default"},
+			Spec:       corev1.ResourceQuotaSpec{Hard: corev1.ResourceList{corev1.ResourceRequestsCPU: resource.MustParse("50m")}},
+		})
+
+		condition := degraded()
+		Expect(condition.Reason).Should(Equal("ResourceQuotaExceeded"))
+		Expect(condition.Message).Should(ContainSubstring("requests.cpu 100m of an agent pod exceeds ResourceQuota small hard limit 50m"))
+	})
+})
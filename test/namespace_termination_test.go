@@ -0,0 +1,163 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-logr/logr"
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+// errorCountingSink counts the error-level entries logged through it.
+type errorCountingSink struct {
+	mu       *sync.Mutex
+	messages *[]string
+}
+
+func (s errorCountingSink) Init(logr.RuntimeInfo)                  {}
+func (s errorCountingSink) Enabled(int) bool                       { return true }
+func (s errorCountingSink) Info(int, string, ...interface{})       {}
+func (s errorCountingSink) WithValues(...interface{}) logr.LogSink { return s }
+func (s errorCountingSink) WithName(string) logr.LogSink           { return s }
+func (s errorCountingSink) Error(err error, msg string, _ ...interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*s.messages = append(*s.messages, fmt.Sprintf("%s: %v", msg, err))
+}
+
+// terminatingError is the error of the API server creating an object in a terminating namespace.
+func terminatingError(obj client.Object) error {
+	err := apierrors.NewForbidden(schema.GroupResource{Resource: "objects"}, obj.GetName(),
+		fmt.Errorf("unable to create new content in namespace %s because it is being terminated", obj.GetNamespace()))
+	err.ErrStatus.Details.Causes = []metav1.StatusCause{{
+		Type:    corev1.NamespaceTerminatingCause,
+		Message: fmt.Sprintf("namespace %s is currently being deleted", obj.GetNamespace()),
+		Field:   "metadata.namespace",
+	}}
+	return err
+}
+
+var _ = Describe("Agents in Terminating Namespaces", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+		namespace  *corev1.Namespace
+		creates    int
+		errorLogs  []string
+		// terminateOnCreate starts terminating the namespace at the next create
+		terminateOnCreate bool
+	)
+
+	BeforeEach(func() {
+		creates, errorLogs, terminateOnCreate = 0, nil, false
+		ctx = log.IntoContext(context.Background(), logr.New(errorCountingSink{mu: &sync.Mutex{}, messages: &errorLogs}))
+		namespace = &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{Name: "team-a"},
+			Status:     corev1.NamespaceStatus{Phase: corev1.NamespaceActive},
+		}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "doomed", Namespace: "team-a"}}
+	})
+
+	// newReconciler builds a reconciler whose creates fail once the namespace is terminating,
+	// as they do on the API server.
+	newReconciler := func(agent *aiv1.Agent) {
+		terminationScheme := newScheme()
+
+		base := newFakeClientBuilder(terminationScheme).
+			WithObjects(namespace, agent).
+			Build()
+		fakeClient = interceptor.NewClient(base, interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				current := &corev1.Namespace{}
+				Expect(c.Get(ctx, client.ObjectKeyFromObject(namespace), current)).Should(Succeed())
+				if terminateOnCreate {
+					terminateOnCreate = false
+					current.Status.Phase = corev1.NamespaceTerminating
+					Expect(c.Status().Update(ctx, current)).Should(Succeed())
+				}
+				if current.Status.Phase == corev1.NamespaceTerminating {
+					creates++
+					return terminatingError(obj)
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		})
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: terminationScheme}
+	}
+
+	newAgent := func() *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "doomed", Namespace: "team-a", Finalizers: []string{"kubeagentic.ai/finalizer"}},
+			Spec: aiv1.AgentSpec{
+				Provider:     "vllm",
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.models.svc:8000/v1",
+			},
+		}
+	}
+
+	terminate := func() {
+		current := &corev1.Namespace{}
+		Expect(fakeClient.Get(ctx, client.ObjectKeyFromObject(namespace), current)).Should(Succeed())
+		current.Status.Phase = corev1.NamespaceTerminating
+		Expect(fakeClient.Status().Update(ctx, current)).Should(Succeed())
+	}
+
+	It("Should not try to create anything and let the agent be deleted", func() {
+		newReconciler(newAgent())
+		terminate()
+
+		for i := 0; i < 3; i++ {
+			result, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(result).Should(Equal(ctrl.Result{}))
+		}
+		Expect(creates).Should(BeZero())
+		Expect(errorLogs).Should(BeEmpty())
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		Expect(agent.Status.Phase).Should(BeEmpty())
+
+		By("Removing the finalizer once the namespace controller deletes the agent")
+		Expect(fakeClient.Delete(ctx, agent)).Should(Succeed())
+		_, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, request.NamespacedName, &aiv1.Agent{}))).Should(BeTrue())
+		Expect(errorLogs).Should(BeEmpty())
+	})
+
+	It("Should not fail an agent whose namespace starts terminating during the reconcile", func() {
+		newReconciler(newAgent())
+		terminateOnCreate = true
+
+		result, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result).Should(Equal(ctrl.Result{}))
+		Expect(creates).Should(Equal(1))
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhasePending))
+
+		By("Skipping the next reconciles")
+		_, err = reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(creates).Should(Equal(1))
+	})
+})
@@ -0,0 +1,57 @@
+package test
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+)
+
+var _ = Describe("Child Resource Naming", func() {
+	longName := strings.Repeat("customer-support-agent-", 3)[:62]
+
+	Context("When the derived name fits", func() {
+		It("Should append the suffix to the agent name", func() {
+			Expect(naming.Child("support", "service")).Should(Equal("support-service"))
+			Expect(naming.Child("support", "")).Should(Equal("support"))
+			Expect(naming.ChildWithMax("support", "rag-ingestion", naming.MaxCronJobLength)).Should(Equal("support-rag-ingestion"))
+		})
+	})
+
+	Context("When the derived name is too long", func() {
+		It("Should shorten it to a valid DNS label keeping the suffix", func() {
+			for _, suffix := range []string{"service", "grafana-dashboard", "config", ""} {
+				name := naming.Child(longName, suffix)
+				Expect(len(name)).Should(BeNumerically("<=", naming.MaxLength))
+				Expect(validation.IsDNS1123Label(name)).Should(BeEmpty())
+				if suffix != "" {
+					Expect(name).Should(HaveSuffix("-" + suffix))
+				}
+			}
+
+			name := naming.ChildWithMax(longName, "conversation-export", naming.MaxCronJobLength)
+			Expect(len(name)).Should(BeNumerically("<=", naming.MaxCronJobLength))
+			Expect(name).Should(HaveSuffix("-conversation-export"))
+		})
+
+		It("Should derive the same name from the same input", func() {
+			Expect(naming.Child(longName, "service")).Should(Equal(naming.Child(longName, "service")))
+		})
+
+		It("Should derive distinct names for names differing past the truncation", func() {
+			first := naming.Child(longName[:61]+"a", "grafana-dashboard")
+			second := naming.Child(longName[:61]+"b", "grafana-dashboard")
+			Expect(first).ShouldNot(Equal(second))
+			Expect(first[:30]).Should(Equal(second[:30]))
+		})
+
+		It("Should not end the truncated name with a separator", func() {
+			name := naming.Child(strings.Repeat("a", 45)+"-"+strings.Repeat("b", 30), "service")
+			Expect(name).ShouldNot(ContainSubstring("--"))
+			Expect(validation.IsDNS1123Label(name)).Should(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,163 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Node Scheduling", func() {
+	gpuToleration := corev1.Toleration{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Effect: corev1.TaintEffectNoSchedule}
+	gpuAffinity := &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.PreferredSchedulingTerm{{
+				Weight: 50,
+				Preference: corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{
+					Key: "nvidia.com/gpu.product", Operator: corev1.NodeSelectorOpIn, Values: []string{"NVIDIA-A100-SXM4-80GB"},
+				}}},
+			}},
+		},
+	}
+
+	Context("Reconciling the scheduling constraints", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			scheme := newScheme()
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					NodeSelector: map[string]string{"nvidia.com/gpu.present": "true"},
+					Tolerations:  []corev1.Toleration{gpuToleration},
+					Affinity:     gpuAffinity,
+				},
+			}
+			fakeClient = newFakeClientBuilder(scheme).
+				WithObjects(agent).
+				Build()
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "llama", Namespace: "default"}}
+		})
+
+		reconcile := func() corev1.PodSpec {
+			deployment := reconcileDeployment(ctx, reconciler, request)
+			return deployment.Spec.Template.Spec
+		}
+
+		update := func(mutate func(*aiv1.AgentSpec)) {
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			mutate(&agent.Spec)
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		}
+
+		It("Should set the constraints on the pod template and keep them across reconciles", func() {
+			podSpec := reconcile()
+			Expect(podSpec.NodeSelector).Should(Equal(map[string]string{"nvidia.com/gpu.present": "true"}))
+			Expect(podSpec.Tolerations).Should(Equal([]corev1.Toleration{gpuToleration}))
+			Expect(podSpec.Affinity).Should(Equal(gpuAffinity))
+
+			By("Reconciling again")
+			Expect(reconcile()).Should(Equal(podSpec))
+
+			By("Tolerating another taint, which updates the Deployment")
+			spotToleration := corev1.Toleration{Key: "cloud.google.com/gke-spot", Operator: corev1.TolerationOpEqual, Value: "true", Effect: corev1.TaintEffectNoSchedule}
+			update(func(spec *aiv1.AgentSpec) {
+				spec.Tolerations = append(spec.Tolerations, spotToleration)
+			})
+			podSpec = reconcile()
+			Expect(podSpec.Tolerations).Should(Equal([]corev1.Toleration{gpuToleration, spotToleration}))
+			Expect(podSpec.NodeSelector).Should(HaveKey("nvidia.com/gpu.present"))
+
+			By("Removing them")
+			update(func(spec *aiv1.AgentSpec) {
+				spec.NodeSelector, spec.Tolerations, spec.Affinity = nil, nil, nil
+			})
+			podSpec = reconcile()
+			Expect(podSpec.NodeSelector).Should(BeEmpty())
+			Expect(podSpec.Tolerations).Should(BeEmpty())
+			Expect(podSpec.Affinity).Should(BeNil())
+		})
+
+		It("Should add the placement terms to the affinity", func() {
+			update(func(spec *aiv1.AgentSpec) {
+				spec.SpreadFrom = []aiv1.AgentPlacement{{AgentRef: "batch"}}
+			})
+			affinity := reconcile().Affinity
+			Expect(affinity.NodeAffinity).Should(Equal(gpuAffinity.NodeAffinity))
+			Expect(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).Should(HaveLen(1))
+			Expect(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.LabelSelector.MatchLabels).
+				Should(HaveKeyWithValue("kubeagentic.ai/agent", "batch"))
+
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			Expect(agent.Spec.Affinity.PodAntiAffinity).Should(BeNil())
+		})
+	})
+
+	Context("Admitting agents with scheduling constraints", func() {
+		admit := func(mutate func(*aiv1.AgentSpec)) error {
+			validator := &webhookv1.AgentWebhook{}
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+				},
+			}
+			mutate(&agent.Spec)
+			Expect(validator.Default(context.Background(), agent)).Should(Succeed())
+			_, err := validator.ValidateCreate(context.Background(), agent)
+			return err
+		}
+
+		It("Should admit valid constraints", func() {
+			Expect(admit(func(spec *aiv1.AgentSpec) {
+				spec.NodeSelector = map[string]string{"nvidia.com/gpu.present": "true"}
+				spec.Tolerations = []corev1.Toleration{gpuToleration, {Operator: corev1.TolerationOpExists}}
+				spec.Affinity = gpuAffinity
+			})).Should(Succeed())
+		})
+
+		It("Should reject node selector labels and tolerations the API server would refuse", func() {
+			seconds := int64(300)
+			err := admit(func(spec *aiv1.AgentSpec) {
+				spec.NodeSelector = map[string]string{"gpu": "a100 80gb"}
+				spec.Tolerations = []corev1.Toleration{
+					{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists, Value: "true"},
+					{Operator: corev1.TolerationOpEqual, Value: "true"},
+					{Key: "nvidia.com/gpu", Operator: "In"},
+					{Key: "nvidia.com/gpu", Effect: corev1.TaintEffectNoSchedule, TolerationSeconds: &seconds},
+				}
+			})
+			Expect(err).Should(MatchError(ContainSubstring(`spec.nodeSelector: Invalid value: "a100 80gb"`)))
+			Expect(err).Should(MatchError(ContainSubstring(`spec.tolerations[0].value: Invalid value: "true": must be empty when operator is Exists`)))
+			Expect(err).Should(MatchError(ContainSubstring(`spec.tolerations[1].operator: Invalid value: "Equal": must be Exists when key is empty`)))
+			Expect(err).Should(MatchError(ContainSubstring(`spec.tolerations[2].operator: Unsupported value: "In"`)))
+			Expect(err).Should(MatchError(ContainSubstring("spec.tolerations[3].tolerationSeconds: Invalid value: 300: only applies to the NoExecute effect")))
+		})
+	})
+})
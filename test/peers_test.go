@@ -0,0 +1,136 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Peers", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	agent := func(name string, peers ...aiv1.AgentPeer) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "vllm",
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.default.svc:8000/v1",
+				Peers:        peers,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		peersScheme := newScheme()
+
+		billing := agent("billing-agent-v2")
+		billing.Spec.EndpointAuth = &aiv1.EndpointAuthConfig{GenerateKey: true}
+		fakeClient = newFakeClientBuilder(peersScheme).
+			WithObjects(
+				agent("coordinator", aiv1.AgentPeer{AgentRef: "retriever"}, aiv1.AgentPeer{AgentRef: "billing-agent-v2", Alias: "billing"}),
+				agent("retriever"),
+				billing,
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "billing-agent-v2-endpoint-auth", Namespace: "default"},
+					Data:       map[string][]byte{"token": []byte("first-token")},
+				},
+			).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: peersScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "coordinator", Namespace: "default"}}
+	})
+
+	reconcile := func() (*appsv1.Deployment, []map[string]string, *aiv1.Agent) {
+		deployment := reconcileDeployment(ctx, reconciler, request)
+		configMap := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "coordinator-config", Namespace: "default"}, configMap)).Should(Succeed())
+		var peers []map[string]string
+		Expect(json.Unmarshal([]byte(configMap.Data["peers.json"]), &peers)).Should(Succeed())
+		current := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, current)).Should(Succeed())
+		return deployment, peers, current
+	}
+
+	peersResolved := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionPeersResolved {
+				return condition
+			}
+		}
+		Fail("condition PeersResolved not set")
+		return aiv1.AgentCondition{}
+	}
+
+	It("Should render the peers and mount their tokens", func() {
+		deployment, peers, current := reconcile()
+		Expect(peers).Should(Equal([]map[string]string{
+			{"alias": "retriever", "agent": "retriever", "url": "http://retriever-service.default.svc.cluster.local"},
+			{"alias": "billing", "agent": "billing-agent-v2", "url": "http://billing-agent-v2-service.default.svc.cluster.local", "tokenFile": "/etc/kubeagentic/peers/billing/token"},
+		}))
+
+		podSpec := deployment.Spec.Template.Spec
+		Expect(podSpec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_PEERS_FILE", Value: "/etc/kubeagentic/peers/peers.json"}))
+		Expect(podSpec.Containers[0].VolumeMounts).Should(ContainElement(HaveField("MountPath", "/etc/kubeagentic/peers")))
+		var volume *corev1.Volume
+		for i := range podSpec.Volumes {
+			if podSpec.Volumes[i].Name == "peers" {
+				volume = &podSpec.Volumes[i]
+			}
+		}
+		Expect(volume).ShouldNot(BeNil())
+		Expect(volume.Projected.Sources).Should(HaveLen(3))
+		Expect(volume.Projected.Sources[0].ConfigMap.Name).Should(Equal("coordinator-config"))
+		Expect(volume.Projected.Sources[2].Secret.Name).Should(Equal("billing-agent-v2-endpoint-auth"))
+		Expect(volume.Projected.Sources[2].Secret.Items[0].Path).Should(Equal("billing/token"))
+
+		Expect(peersResolved(current).Status).Should(Equal(corev1.ConditionTrue))
+	})
+
+	It("Should roll the pods when a peer rotates its token", func() {
+		deployment, _, _ := reconcile()
+		checksum := deployment.Spec.Template.Annotations["kubeagentic.ai/peers-checksum"]
+		Expect(checksum).ShouldNot(BeEmpty())
+
+		secret := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "billing-agent-v2-endpoint-auth", Namespace: "default"}, secret)).Should(Succeed())
+		secret.Data["token"] = []byte("second-token")
+		Expect(fakeClient.Update(ctx, secret)).Should(Succeed())
+
+		deployment, _, _ = reconcile()
+		Expect(deployment.Spec.Template.Annotations["kubeagentic.ai/peers-checksum"]).ShouldNot(Equal(checksum))
+	})
+
+	It("Should report dangling peers without failing", func() {
+		retriever := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "retriever", Namespace: "default"}, retriever)).Should(Succeed())
+		Expect(fakeClient.Delete(ctx, retriever)).Should(Succeed())
+
+		_, peers, current := reconcile()
+		Expect(peers).Should(HaveLen(1))
+		Expect(peers[0]["alias"]).Should(Equal("billing"))
+		condition := peersResolved(current)
+		Expect(condition.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(condition.Reason).Should(Equal("PeerNotFound"))
+		Expect(condition.Message).Should(ContainSubstring("retriever"))
+		Expect(current.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+	})
+})
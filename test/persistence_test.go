@@ -0,0 +1,297 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Persistence", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		clock      *clocktesting.FakePassiveClock
+		request    ctrl.Request
+	)
+
+	snapshotGVK := schema.GroupVersionKind{Group: "snapshot.storage.k8s.io", Version: "v1", Kind: "VolumeSnapshot"}
+	claimKey := types.NamespacedName{Name: "archivist-data", Namespace: "default"}
+	created := time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC)
+
+	newReconciler := func(snapshotCRDs bool, replicas *int32, persistence *aiv1.PersistenceConfig) {
+		scheme := newScheme()
+
+		mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{snapshotGVK.GroupVersion()})
+		if snapshotCRDs {
+			mapper.Add(snapshotGVK, meta.RESTScopeNamespace)
+		}
+		for gvk := range scheme.AllKnownTypes() {
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+		}
+
+		fakeClient = newFakeClientBuilder(scheme).
+			WithRESTMapper(mapper).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "archivist", Namespace: "default", CreationTimestamp: metav1.NewTime(created)},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					Replicas:     replicas,
+					Persistence:  persistence,
+				},
+			}).
+			Build()
+		clock = clocktesting.NewFakePassiveClock(created)
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme, Clock: clock}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "archivist", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	single := func() *int32 {
+		replicas := int32(1)
+		return &replicas
+	}
+
+	condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) *aiv1.AgentCondition {
+		for i := range agent.Status.Conditions {
+			if agent.Status.Conditions[i].Type == conditionType {
+				return &agent.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	snapshot := func(name string) (*unstructured.Unstructured, error) {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(snapshotGVK)
+		return obj, fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, obj)
+	}
+
+	// setDeploymentReplicas reports the given number of pods in the status of the agent
+	// Deployment, as the Deployment controller would.
+	setDeploymentReplicas := func(replicas int32) {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		deployment.Status.Replicas = replicas
+		Expect(fakeClient.Status().Update(ctx, deployment)).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should mount a claim of its own into the single pod of the agent", func() {
+		newReconciler(true, single(), &aiv1.PersistenceConfig{Size: "5Gi", StorageClassName: "fast"})
+		agent := reconcile()
+		Expect(agent.Status.Persistence).ShouldNot(BeNil())
+		Expect(agent.Status.Persistence.ClaimName).Should(Equal("archivist-data"))
+
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(fakeClient.Get(ctx, claimKey, claim)).Should(Succeed())
+		Expect(claim.OwnerReferences).Should(ConsistOf(HaveField("Name", "archivist")))
+		Expect(claim.Spec.AccessModes).Should(ConsistOf(corev1.ReadWriteOnce))
+		Expect(claim.Spec.Resources.Requests.Storage().String()).Should(Equal("5Gi"))
+		Expect(*claim.Spec.StorageClassName).Should(Equal("fast"))
+		Expect(claim.Spec.DataSource).Should(BeNil())
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		Expect(deployment.Spec.Strategy.Type).Should(Equal(appsv1.RecreateDeploymentStrategyType))
+		Expect(deployment.Spec.Template.Spec.Volumes).Should(ContainElement(And(
+			HaveField("Name", "agent-data"),
+			HaveField("PersistentVolumeClaim.ClaimName", "archivist-data"),
+		)))
+		container := deployment.Spec.Template.Spec.Containers[0]
+		Expect(container.VolumeMounts).Should(ContainElement(corev1.VolumeMount{Name: "agent-data", MountPath: "/var/lib/kubeagentic"}))
+		Expect(container.Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_DATA_DIR", Value: "/var/lib/kubeagentic"}))
+	})
+
+	It("Should refuse a claim shared by several pods", func() {
+		newReconciler(true, nil, &aiv1.PersistenceConfig{})
+		agent := reconcile()
+		configValid := condition(agent, aiv1.AgentConditionConfigValid)
+		Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid.Reason).Should(Equal("InvalidPersistenceConfig"))
+		Expect(configValid.Message).Should(ContainSubstring("persistence requires replicas: 1"))
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, claimKey, &corev1.PersistentVolumeClaim{}))).Should(BeTrue())
+	})
+
+	It("Should take a snapshot when the schedule comes due and prune the snapshots beyond the retention", func() {
+		newReconciler(true, single(), &aiv1.PersistenceConfig{
+			Backup: &aiv1.BackupConfig{Schedule: "0 2 * * *", VolumeSnapshotClassName: "csi-snapclass", Retention: 2},
+		})
+
+		By("Waiting for the first time of the schedule after the agent was created")
+		clock.SetTime(created.Add(30 * time.Minute))
+		agent := reconcile()
+		Expect(agent.Status.Persistence.Snapshots).Should(BeEmpty())
+		Expect(agent.Status.Persistence.LastBackupTime).Should(BeNil())
+		Expect(condition(agent, aiv1.AgentConditionBackupSucceeded)).Should(BeNil())
+
+		By("Snapshotting the claim when the schedule comes due")
+		first := time.Date(2026, 3, 1, 2, 0, 0, 0, time.UTC)
+		clock.SetTime(first)
+		agent = reconcile()
+		Expect(agent.Status.Persistence.Snapshots).Should(Equal([]string{"archivist-backup-20260301-020000"}))
+		Expect(agent.Status.Persistence.LastBackupTime.Time).Should(BeTemporally("==", first))
+		backedUp := condition(agent, aiv1.AgentConditionBackupSucceeded)
+		Expect(backedUp.Status).Should(Equal(corev1.ConditionTrue))
+		Expect(backedUp.Reason).Should(Equal("BackedUp"))
+
+		taken, err := snapshot("archivist-backup-20260301-020000")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(taken.GetOwnerReferences()).Should(BeEmpty())
+		Expect(taken.GetLabels()).Should(HaveKeyWithValue("kubeagentic.ai/agent", "archivist"))
+		Expect(taken.Object["spec"]).Should(Equal(map[string]interface{}{
+			"source":                  map[string]interface{}{"persistentVolumeClaimName": "archivist-data"},
+			"volumeSnapshotClassName": "csi-snapclass",
+		}))
+
+		By("Taking no other snapshot before the next time of the schedule")
+		clock.SetTime(first.Add(time.Hour))
+		agent = reconcile()
+		Expect(agent.Status.Persistence.Snapshots).Should(HaveLen(1))
+
+		By("Deleting the oldest snapshot beyond the retention")
+		clock.SetTime(first.Add(24 * time.Hour))
+		reconcile()
+		clock.SetTime(first.Add(48 * time.Hour))
+		agent = reconcile()
+		Expect(agent.Status.Persistence.Snapshots).Should(Equal([]string{
+			"archivist-backup-20260303-020000",
+			"archivist-backup-20260302-020000",
+		}))
+		_, err = snapshot("archivist-backup-20260301-020000")
+		Expect(apierrors.IsNotFound(err)).Should(BeTrue())
+
+		By("Reporting a snapshot the CSI driver failed to take")
+		failed, err := snapshot("archivist-backup-20260303-020000")
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(unstructured.SetNestedField(failed.Object, "snapshot quota exceeded", "status", "error", "message")).Should(Succeed())
+		Expect(fakeClient.Update(ctx, failed)).Should(Succeed())
+		agent = reconcile()
+		backedUp = condition(agent, aiv1.AgentConditionBackupSucceeded)
+		Expect(backedUp.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(backedUp.Reason).Should(Equal("SnapshotFailed"))
+		Expect(backedUp.Message).Should(ContainSubstring("snapshot quota exceeded"))
+	})
+
+	It("Should scale the agent down, restore the snapshot into a new claim and scale it back up", func() {
+		newReconciler(true, single(), &aiv1.PersistenceConfig{})
+		reconcile()
+		setDeploymentReplicas(1)
+
+		nightly := &unstructured.Unstructured{}
+		nightly.SetGroupVersionKind(snapshotGVK)
+		nightly.SetName("nightly")
+		nightly.SetNamespace("default")
+		Expect(fakeClient.Create(ctx, nightly)).Should(Succeed())
+
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.Persistence.RestoreFromSnapshot = "nightly"
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+
+		By("Scaling the agent down before touching the claim")
+		agent = reconcile()
+		restored := condition(agent, aiv1.AgentConditionSnapshotRestored)
+		Expect(restored.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(restored.Reason).Should(Equal("RestoreInProgress"))
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhasePending))
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		Expect(*deployment.Spec.Replicas).Should(BeEquivalentTo(0))
+
+		By("Waiting for the agent pods to be gone")
+		result, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(result.RequeueAfter).Should(Equal(10 * time.Second))
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "archivist-data-nightly", Namespace: "default"}, &corev1.PersistentVolumeClaim{}))).Should(BeTrue())
+
+		By("Swapping in the claim restored from the snapshot and scaling the agent back up")
+		setDeploymentReplicas(0)
+		agent = reconcile()
+		Expect(agent.Status.Persistence.ClaimName).Should(Equal("archivist-data-nightly"))
+		Expect(agent.Status.Persistence.RestoredFrom).Should(Equal("nightly"))
+		restored = condition(agent, aiv1.AgentConditionSnapshotRestored)
+		Expect(restored.Status).Should(Equal(corev1.ConditionTrue))
+		Expect(restored.Reason).Should(Equal("Restored"))
+
+		claim := &corev1.PersistentVolumeClaim{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "archivist-data-nightly", Namespace: "default"}, claim)).Should(Succeed())
+		Expect(claim.Spec.DataSource).ShouldNot(BeNil())
+		Expect(*claim.Spec.DataSource.APIGroup).Should(Equal("snapshot.storage.k8s.io"))
+		Expect(claim.Spec.DataSource.Kind).Should(Equal("VolumeSnapshot"))
+		Expect(claim.Spec.DataSource.Name).Should(Equal("nightly"))
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, claimKey, &corev1.PersistentVolumeClaim{}))).Should(BeTrue())
+
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		Expect(*deployment.Spec.Replicas).Should(BeEquivalentTo(1))
+		Expect(deployment.Spec.Template.Spec.Volumes).Should(ContainElement(HaveField("PersistentVolumeClaim.ClaimName", "archivist-data-nightly")))
+
+		By("Restoring the snapshot only once")
+		setDeploymentReplicas(1)
+		agent = reconcile()
+		Expect(condition(agent, aiv1.AgentConditionSnapshotRestored).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		Expect(*deployment.Spec.Replicas).Should(BeEquivalentTo(1))
+	})
+
+	It("Should keep the agent running when the snapshot to restore does not exist", func() {
+		newReconciler(true, single(), &aiv1.PersistenceConfig{RestoreFromSnapshot: "missing"})
+		agent := reconcile()
+		restored := condition(agent, aiv1.AgentConditionSnapshotRestored)
+		Expect(restored.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(restored.Reason).Should(Equal("SnapshotNotFound"))
+		Expect(agent.Status.Persistence.ClaimName).Should(Equal("archivist-data"))
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		Expect(*deployment.Spec.Replicas).Should(BeEquivalentTo(1))
+	})
+
+	It("Should degrade with a condition in clusters without the VolumeSnapshot CRDs", func() {
+		newReconciler(false, single(), &aiv1.PersistenceConfig{
+			Backup:              &aiv1.BackupConfig{Schedule: "0 2 * * *"},
+			RestoreFromSnapshot: "nightly",
+		})
+		clock.SetTime(time.Date(2026, 3, 2, 2, 0, 0, 0, time.UTC))
+		agent := reconcile()
+
+		for _, conditionType := range []aiv1.AgentConditionType{aiv1.AgentConditionBackupSucceeded, aiv1.AgentConditionSnapshotRestored} {
+			unavailable := condition(agent, conditionType)
+			Expect(unavailable).ShouldNot(BeNil())
+			Expect(unavailable.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(unavailable.Reason).Should(Equal("VolumeSnapshotsUnavailable"))
+			Expect(unavailable.Message).Should(ContainSubstring("snapshot.storage.k8s.io/v1"))
+		}
+		Expect(agent.Status.Persistence.Snapshots).Should(BeEmpty())
+		Expect(fakeClient.Get(ctx, claimKey, &corev1.PersistentVolumeClaim{})).Should(Succeed())
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		Expect(*deployment.Spec.Replicas).Should(BeEquivalentTo(1))
+	})
+})
@@ -0,0 +1,178 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Placement", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		recorder   *record.FakeRecorder
+		request    ctrl.Request
+	)
+
+	placementAgent := func(name string, replicas int32) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "vllm",
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.default.svc:8000/v1",
+				Replicas:     &replicas,
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		placementScheme := newScheme()
+
+		responder := placementAgent("responder", 2)
+		responder.Spec.ColocateWith = []aiv1.AgentPlacement{
+			{AgentRef: "retriever", Mode: aiv1.PlacementRequired},
+			{AgentRef: "reranker", TopologyKey: "topology.kubernetes.io/zone", Mode: aiv1.PlacementPreferred},
+		}
+		responder.Spec.SpreadFrom = []aiv1.AgentPlacement{{AgentRef: "batch", TopologyKey: "topology.kubernetes.io/zone"}}
+		fakeClient = newFakeClientBuilder(placementScheme).
+			WithObjects(responder, placementAgent("retriever", 1), placementAgent("reranker", 1), placementAgent("batch", 1)).
+			Build()
+		recorder = record.NewFakeRecorder(10)
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: placementScheme, Recorder: recorder}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "responder", Namespace: "default"}}
+	})
+
+	reconcile := func() (*appsv1.Deployment, *aiv1.Agent) {
+		agent := reconcileAgent(ctx, reconciler, request)
+		deployment := &appsv1.Deployment{}
+		if agent.Status.Phase != aiv1.AgentPhaseFailed {
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		}
+		return deployment, agent
+	}
+
+	update := func(name string, mutate func(*aiv1.Agent)) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, agent)).Should(Succeed())
+		mutate(agent)
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	condition := func(agent *aiv1.Agent) *aiv1.AgentCondition {
+		for i := range agent.Status.Conditions {
+			if agent.Status.Conditions[i].Type == aiv1.AgentConditionPlacementResolved {
+				return &agent.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	selector := func(agent string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{
+			"app.kubernetes.io/name":     "kubeagentic-agent",
+			"app.kubernetes.io/instance": agent,
+			"kubeagentic.ai/agent":       agent,
+		}}
+	}
+
+	It("Should translate the placements into pod affinity terms against the pods of the agents", func() {
+		deployment, agent := reconcile()
+		affinity := deployment.Spec.Template.Spec.Affinity
+		Expect(affinity).ShouldNot(BeNil())
+		Expect(affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution).Should(Equal([]corev1.PodAffinityTerm{
+			{LabelSelector: selector("retriever"), TopologyKey: "kubernetes.io/hostname"},
+		}))
+		Expect(affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution).Should(Equal([]corev1.WeightedPodAffinityTerm{
+			{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{LabelSelector: selector("reranker"), TopologyKey: "topology.kubernetes.io/zone"}},
+		}))
+
+		By("Spreading the pods from the other agent, preferably by default")
+		Expect(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).Should(BeEmpty())
+		Expect(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).Should(Equal([]corev1.WeightedPodAffinityTerm{
+			{Weight: 100, PodAffinityTerm: corev1.PodAffinityTerm{LabelSelector: selector("batch"), TopologyKey: "topology.kubernetes.io/zone"}},
+		}))
+
+		Expect(condition(agent)).ShouldNot(BeNil())
+		Expect(condition(agent).Status).Should(Equal(corev1.ConditionTrue))
+	})
+
+	It("Should roll the Deployment when the placements change", func() {
+		deployment, _ := reconcile()
+		hash := deployment.Annotations["kubeagentic.ai/template-hash"]
+		Expect(hash).ShouldNot(BeEmpty())
+
+		update("responder", func(agent *aiv1.Agent) {
+			agent.Spec.ColocateWith = agent.Spec.ColocateWith[:1]
+			agent.Spec.SpreadFrom = nil
+		})
+		deployment, agent := reconcile()
+		Expect(deployment.Annotations["kubeagentic.ai/template-hash"]).ShouldNot(Equal(hash))
+		Expect(deployment.Spec.Template.Spec.Affinity.PodAffinity.PreferredDuringSchedulingIgnoredDuringExecution).Should(BeEmpty())
+		Expect(deployment.Spec.Template.Spec.Affinity.PodAntiAffinity).Should(BeNil())
+
+		update("responder", func(agent *aiv1.Agent) {
+			agent.Spec.ColocateWith = nil
+		})
+		deployment, agent = reconcile()
+		Expect(deployment.Spec.Template.Spec.Affinity).Should(BeNil())
+		Expect(condition(agent)).Should(BeNil())
+	})
+
+	It("Should report dangling references in a condition rather than fail", func() {
+		Expect(fakeClient.Delete(ctx, placementAgent("reranker", 1))).Should(Succeed())
+		deployment, agent := reconcile()
+		Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+		Expect(condition(agent).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(condition(agent).Reason).Should(Equal("AgentNotFound"))
+		Expect(condition(agent).Message).Should(ContainSubstring("reranker"))
+		hash := deployment.Annotations["kubeagentic.ai/template-hash"]
+
+		By("Resolving the reference once the agent is created, without rolling the pods")
+		Expect(fakeClient.Create(ctx, placementAgent("reranker", 1))).Should(Succeed())
+		deployment, agent = reconcile()
+		Expect(condition(agent).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(deployment.Annotations["kubeagentic.ai/template-hash"]).Should(Equal(hash))
+	})
+
+	It("Should warn when an agent to colocate with has no replicas", func() {
+		update("retriever", func(agent *aiv1.Agent) {
+			zero := int32(0)
+			agent.Spec.Replicas = &zero
+		})
+		reconcile()
+		Expect(recorder.Events).Should(Receive(ContainSubstring("ColocationTargetScaledToZero Agent retriever has no replicas")))
+	})
+
+	It("Should reject an agent to be both next to and away from", func() {
+		update("responder", func(agent *aiv1.Agent) {
+			agent.Spec.SpreadFrom = []aiv1.AgentPlacement{{AgentRef: "retriever"}}
+		})
+		_, agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		Expect(agent.Status.Message).Should(ContainSubstring("agent retriever cannot be in both colocateWith and spreadFrom"))
+	})
+
+	It("Should reject placements relative to the agent itself", func() {
+		update("responder", func(agent *aiv1.Agent) {
+			agent.Spec.SpreadFrom = []aiv1.AgentPlacement{{AgentRef: "responder"}}
+		})
+		_, agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		Expect(agent.Status.Message).Should(ContainSubstring("spreadFrom cannot refer to the agent itself"))
+	})
+})
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Pod Metadata", func() {
+	operatorLabels := map[string]string{
+		"app.kubernetes.io/name":     "kubeagentic-agent",
+		"app.kubernetes.io/instance": "support",
+		"kubeagentic.ai/agent":       "support",
+	}
+
+	Context("Reconciling the pod labels and annotations", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			scheme := newScheme()
+
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					PodLabels: map[string]string{
+						"cost-center":            "support",
+						"app.kubernetes.io/name": "support-bot",
+					},
+					PodAnnotations: map[string]string{"sidecar.istio.io/inject": "true"},
+				},
+			}
+			fakeClient = newFakeClientBuilder(scheme).
+				WithObjects(agent).
+				Build()
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+		})
+
+		reconcile := func() *appsv1.Deployment {
+			return reconcileDeployment(ctx, reconciler, request)
+		}
+
+		It("Should add them to the pod template and select the pods by the operator labels only", func() {
+			deployment := reconcile()
+			Expect(deployment.Spec.Template.Labels).Should(HaveKeyWithValue("cost-center", "support"))
+			Expect(deployment.Spec.Template.Labels).Should(HaveKeyWithValue("app.kubernetes.io/name", "kubeagentic-agent"))
+			Expect(deployment.Spec.Template.Annotations).Should(HaveKeyWithValue("sidecar.istio.io/inject", "true"))
+			Expect(deployment.Spec.Selector.MatchLabels).Should(Equal(operatorLabels))
+			Expect(deployment.Labels).Should(Equal(operatorLabels))
+
+			service := &corev1.Service{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-service", Namespace: "default"}, service)).Should(Succeed())
+			Expect(service.Spec.Selector).Should(Equal(operatorLabels))
+
+			By("Changing the annotations, which rolls the pods")
+			hash := deployment.Annotations["kubeagentic.ai/template-hash"]
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Spec.PodAnnotations = map[string]string{"vault.hashicorp.com/agent-inject": "true"}
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			deployment = reconcile()
+			Expect(deployment.Spec.Template.Annotations).Should(HaveKeyWithValue("vault.hashicorp.com/agent-inject", "true"))
+			Expect(deployment.Spec.Template.Annotations).ShouldNot(HaveKey("sidecar.istio.io/inject"))
+			Expect(deployment.Annotations["kubeagentic.ai/template-hash"]).ShouldNot(Equal(hash))
+			Expect(deployment.Spec.Selector.MatchLabels).Should(Equal(operatorLabels))
+		})
+	})
+
+	Context("Admitting agents with pod labels and annotations", func() {
+		admit := func(mutate func(*aiv1.AgentSpec)) ([]string, error) {
+			validator := &webhookv1.AgentWebhook{}
+			agent := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+				},
+			}
+			mutate(&agent.Spec)
+			Expect(validator.Default(context.Background(), agent)).Should(Succeed())
+			return validator.ValidateCreate(context.Background(), agent)
+		}
+
+		It("Should warn about the keys the operator sets", func() {
+			warnings, err := admit(func(spec *aiv1.AgentSpec) {
+				spec.PodLabels = map[string]string{"cost-center": "support", "kubeagentic.ai/agent": "other"}
+				spec.PodAnnotations = map[string]string{"sidecar.istio.io/inject": "true"}
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(warnings).Should(ContainElement(ContainSubstring("spec.podLabels[kubeagentic.ai/agent] is set by the operator")))
+		})
+
+		It("Should reject labels and annotations the API server would refuse", func() {
+			_, err := admit(func(spec *aiv1.AgentSpec) {
+				spec.PodLabels = map[string]string{"cost-center": "customer support"}
+				spec.PodAnnotations = map[string]string{"sidecar istio": "true"}
+			})
+			Expect(err).Should(MatchError(ContainSubstring(`spec.podLabels: Invalid value: "customer support"`)))
+			Expect(err).Should(MatchError(ContainSubstring(`spec.podAnnotations: Invalid value: "sidecar istio"`)))
+		})
+	})
+})
@@ -0,0 +1,150 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Pod Template Overrides", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newReconciler := func(overrides string) {
+		overridesScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(overridesScheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "patched", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "openai",
+						Model:        "gpt-4o",
+						SystemPrompt: "You are a helpful AI assistant.",
+						ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"},
+							Key:                  "api-key",
+						}},
+						PodTemplateOverrides: &runtime.RawExtension{Raw: []byte(overrides)},
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				},
+			).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: overridesScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "patched", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	deployment := func() *appsv1.Deployment {
+		found := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, found)).Should(Succeed())
+		return found
+	}
+
+	configValid := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionConfigValid {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should add a volume and its mount, and roll the pods when the overrides change", func() {
+		newReconciler(`{"spec": {
+			"volumes": [{"name": "scratch", "emptyDir": {"medium": "Memory"}}],
+			"containers": [{"name": "agent", "volumeMounts": [{"name": "scratch", "mountPath": "/scratch"}]}]
+		}}`)
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionTrue))
+
+		template := deployment().Spec.Template
+		Expect(template.Spec.Volumes).Should(ContainElement(HaveField("Name", "scratch")))
+		agentContainer := template.Spec.Containers[0]
+		Expect(agentContainer.Name).Should(Equal("agent"))
+		Expect(agentContainer.VolumeMounts).Should(ContainElement(corev1.VolumeMount{Name: "scratch", MountPath: "/scratch"}))
+		Expect(agentContainer.Env).Should(ContainElement(HaveField("Name", "AGENT_API_KEY")))
+		hash := deployment().Annotations["kubeagentic.ai/template-hash"]
+
+		By("Rendering the changed overrides into the template hash")
+		agent.Spec.PodTemplateOverrides = &runtime.RawExtension{Raw: []byte(`{"spec": {"volumes": [{"name": "scratch", "emptyDir": {}}]}}`)}
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile()
+		Expect(deployment().Annotations["kubeagentic.ai/template-hash"]).ShouldNot(Equal(hash))
+		Expect(deployment().Spec.Template.Spec.Containers[0].VolumeMounts).ShouldNot(ContainElement(HaveField("Name", "scratch")))
+	})
+
+	It("Should reject overrides of the agent container name, its API key and the pod labels", func() {
+		newReconciler(`{"spec": {"containers": [{"name": "agent", "$patch": "delete"}]}}`)
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid(agent).Reason).Should(Equal("InvalidPodTemplateOverrides"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("must not rename, remove or reorder the agent container"))
+
+		By("Rejecting a changed API key")
+		agent.Spec.PodTemplateOverrides = &runtime.RawExtension{Raw: []byte(`{"spec": {"containers": [{"name": "agent", "env": [{"name": "AGENT_API_KEY", "value": "sk-other"}]}]}}`)}
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(configValid(agent).Reason).Should(Equal("InvalidPodTemplateOverrides"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("must not change AGENT_API_KEY"))
+
+		By("Rejecting a changed pod label")
+		agent.Spec.PodTemplateOverrides = &runtime.RawExtension{Raw: []byte(`{"metadata": {"labels": {"kubeagentic.ai/agent": "other"}}}`)}
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(configValid(agent).Reason).Should(Equal("InvalidPodTemplateOverrides"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("must not change the pod label kubeagentic.ai/agent"))
+
+		By("Rejecting misspelled fields")
+		agent.Spec.PodTemplateOverrides = &runtime.RawExtension{Raw: []byte(`{"spec": {"nodeSelectr": {"disk": "ssd"}}}`)}
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(configValid(agent).Reason).Should(Equal("InvalidPodTemplateOverrides"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("nodeSelectr"))
+	})
+
+	It("Should apply over the defaults, keeping the fields it does not set", func() {
+		newReconciler(`{"spec": {
+			"securityContext": {"sysctls": [{"name": "net.ipv4.tcp_keepalive_time", "value": "60"}]},
+			"containers": [{"name": "agent", "resources": {"limits": {"memory": "1Gi"}}}]
+		}}`)
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionTrue))
+
+		template := deployment().Spec.Template
+		resources := template.Spec.Containers[0].Resources
+		Expect(resources.Limits.Memory().Equal(resource.MustParse("1Gi"))).Should(BeTrue())
+		Expect(resources.Limits.Cpu().Equal(resource.MustParse("200m"))).Should(BeTrue())
+		Expect(resources.Requests.Memory().Equal(resource.MustParse("256Mi"))).Should(BeTrue())
+		Expect(template.Spec.SecurityContext).ShouldNot(BeNil())
+		Expect(template.Spec.SecurityContext.Sysctls).Should(ConsistOf(corev1.Sysctl{Name: "net.ipv4.tcp_keepalive_time", Value: "60"}))
+		Expect(template.Spec.Containers[0].ReadinessProbe).ShouldNot(BeNil())
+	})
+})
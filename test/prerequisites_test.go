@@ -0,0 +1,179 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Prerequisites", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		recorder   *record.FakeRecorder
+		request    ctrl.Request
+	)
+
+	apiKeys := func() *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+			Data:       map[string][]byte{"api-key": []byte("sk-test")},
+		}
+	}
+	redisCredentials := func() *corev1.Secret {
+		return &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "redis-credentials", Namespace: "default"},
+			Data:       map[string][]byte{"url": []byte("redis://redis:6379")},
+		}
+	}
+
+	setup := func(mutate func(spec *aiv1.AgentSpec), objects ...client.Object) {
+		ctx = context.Background()
+		scheme := newScheme()
+		spec := aiv1.AgentSpec{
+			Provider:     "openai",
+			Model:        "gpt-4o",
+			SystemPrompt: "You are a helpful AI assistant.",
+			ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+			Memory: &aiv1.MemoryConfig{
+				Backend:             "redis",
+				ConnectionSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "redis-credentials"}, Key: "url"},
+			},
+		}
+		if mutate != nil {
+			mutate(&spec)
+		}
+		objects = append(objects, &aiv1.Agent{ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"}, Spec: spec})
+		fakeClient = newFakeClientBuilder(scheme).
+			WithObjects(objects...).
+			Build()
+		recorder = record.NewFakeRecorder(100)
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme, Recorder: recorder}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+	}
+
+	reconcile := func() (ctrl.Result, *aiv1.Agent) {
+		result, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		return result, agent
+	}
+
+	condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == conditionType {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	It("Should wait for the missing Secrets and start once they are created", func() {
+		setup(nil)
+
+		result, agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseWaiting))
+		prerequisites := condition(agent, aiv1.AgentConditionPrerequisitesReady)
+		Expect(prerequisites.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(prerequisites.Reason).Should(Equal("PrerequisitesMissing"))
+		Expect(prerequisites.Message).Should(Equal("Waiting for spec.apiSecretRef: secret openai-secret in namespace default not found; " +
+			"spec.memory.connectionSecretRef: secret redis-credentials in namespace default not found"))
+		Expect(agent.Status.Message).Should(Equal(prerequisites.Message))
+		Expect(result.RequeueAfter).ShouldNot(BeZero())
+		Expect(recorder.Events).Should(Receive(HavePrefix("Warning PrerequisitesMissing")))
+
+		By("Reporting the conditions of the missing Secrets without recording errors")
+		Expect(condition(agent, aiv1.AgentConditionSecretValid).Reason).Should(Equal("SecretNotFound"))
+		Expect(condition(agent, aiv1.AgentConditionConfigValid).Status).Should(Equal(corev1.ConditionUnknown))
+		Expect(condition(agent, aiv1.AgentConditionReady).Reason).Should(Equal("SecretNotFound"))
+		Expect(agent.Status.RecentErrors).Should(BeEmpty())
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, request.NamespacedName, &appsv1.Deployment{}))).Should(BeTrue())
+
+		By("Waiting for the Secrets still missing")
+		Expect(fakeClient.Create(ctx, apiKeys())).Should(Succeed())
+		_, agent = reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseWaiting))
+		Expect(condition(agent, aiv1.AgentConditionPrerequisitesReady).Message).Should(Equal("Waiting for spec.memory.connectionSecretRef: secret redis-credentials in namespace default not found"))
+		Expect(condition(agent, aiv1.AgentConditionSecretValid).Status).Should(Equal(corev1.ConditionTrue))
+
+		By("Starting once all of them exist")
+		Expect(fakeClient.Create(ctx, redisCredentials())).Should(Succeed())
+		_, agent = reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhasePending))
+		Expect(condition(agent, aiv1.AgentConditionPrerequisitesReady).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(condition(agent, aiv1.AgentConditionConfigValid).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(fakeClient.Get(ctx, request.NamespacedName, &appsv1.Deployment{})).Should(Succeed())
+	})
+
+	It("Should wait for a missing key of the API secret", func() {
+		keys := apiKeys()
+		keys.Data = map[string][]byte{"apikey": []byte("sk-test")}
+		setup(nil, keys, redisCredentials())
+
+		_, agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseWaiting))
+		Expect(condition(agent, aiv1.AgentConditionSecretValid).Reason).Should(Equal("SecretKeyNotFound"))
+		Expect(condition(agent, aiv1.AgentConditionPrerequisitesReady).Message).Should(ContainSubstring("secret openai-secret in namespace default has no key api-key"))
+	})
+
+	It("Should wait for the ConfigMaps of the ingestion sources", func() {
+		setup(func(spec *aiv1.AgentSpec) {
+			spec.Memory = nil
+			spec.RAG = &aiv1.RAGConfig{
+				Enabled: true,
+				VectorStore: &aiv1.VectorStoreConfig{
+					Type:                "pgvector",
+					Collection:          "faq",
+					Dimensions:          1536,
+					ConnectionSecretRef: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "redis-credentials"}, Key: "url"},
+				},
+				Ingestion: &aiv1.RAGIngestionConfig{
+					Schedule: "0 * * * *",
+					Sources: []aiv1.IngestionSource{
+						{URL: "https://docs.example.com/faq"},
+						{ConfigMapRef: &corev1.LocalObjectReference{Name: "support-docs"}},
+					},
+				},
+			}
+		}, apiKeys(), redisCredentials())
+
+		_, agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseWaiting))
+		Expect(condition(agent, aiv1.AgentConditionPrerequisitesReady).Message).Should(Equal("Waiting for spec.rag.ingestion.sources[1].configMapRef: configmap support-docs in namespace default not found"))
+	})
+
+	It("Should not wait for the credentials of connectors", func() {
+		setup(func(spec *aiv1.AgentSpec) {
+			spec.Connectors = []aiv1.AgentConnector{{Name: "support", Type: "slack", CredentialsSecretRef: corev1.LocalObjectReference{Name: "support-slack"}}}
+		}, apiKeys(), redisCredentials())
+
+		_, agent := reconcile()
+		Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseWaiting))
+		Expect(condition(agent, aiv1.AgentConditionPrerequisitesReady).Status).Should(Equal(corev1.ConditionTrue))
+	})
+
+	It("Should keep failing agents with an invalid configuration", func() {
+		setup(func(spec *aiv1.AgentSpec) {
+			spec.Memory.Backend = "mongodb"
+		})
+
+		_, agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		Expect(condition(agent, aiv1.AgentConditionConfigValid).Reason).Should(Equal("InvalidMemoryConfig"))
+		Expect(condition(agent, aiv1.AgentConditionSecretValid).Reason).Should(Equal("SecretNotFound"))
+	})
+})
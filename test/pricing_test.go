@@ -0,0 +1,75 @@
+package test
+
+import (
+	"errors"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/pricing"
+)
+
+var _ = Describe("Model Pricing", func() {
+	Context("When pricing tokens", func() {
+		It("Should charge prompt and completion tokens at their own rates", func() {
+			price, err := pricing.PriceFor("openai", "gpt-4o")
+			Expect(err).ShouldNot(HaveOccurred())
+			cost, err := pricing.CostFor("openai", "gpt-4o", 2_000_000, 1_000_000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cost).Should(BeNumerically("~", 2*price.Prompt+price.Completion, 1e-9))
+		})
+
+		It("Should not charge self-hosted models", func() {
+			cost, err := pricing.CostFor("vllm", "meta-llama/Llama-3-8B", 1_000_000, 1_000_000)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(cost).Should(BeZero())
+		})
+
+		It("Should report models missing from the catalog", func() {
+			_, err := pricing.CostFor("openai", "gpt-unknown", 10, 10)
+			Expect(errors.Is(err, pricing.ErrUnknownModel)).Should(BeTrue())
+		})
+	})
+
+	Context("When loading a pricing catalog", func() {
+		AfterEach(func() {
+			pricing.Set(nil)
+		})
+
+		It("Should merge the catalog over the embedded prices", func() {
+			overrides, err := pricing.Parse([]byte(`
+selfHostedProviders: [my-gateway]
+models:
+  openai/gpt-4o:
+    prompt: 2
+    completion: 8
+  claude/claude-sonnet-4-20250514:
+    prompt: 3
+    completion: 15
+`))
+			Expect(err).ShouldNot(HaveOccurred())
+			pricing.Set(pricing.Merge(overrides))
+
+			Expect(pricing.PriceFor("openai", "gpt-4o")).Should(Equal(pricing.Price{Prompt: 2, Completion: 8}))
+			Expect(pricing.PriceFor("claude", "claude-sonnet-4-20250514")).Should(Equal(pricing.Price{Prompt: 3, Completion: 15}))
+			Expect(pricing.PriceFor("openai", "gpt-4")).Should(Equal(pricing.Price{Prompt: 30, Completion: 60}))
+			Expect(pricing.CostFor("my-gateway", "any", 1000, 1000)).Should(BeZero())
+
+			pricing.Set(nil)
+			Expect(pricing.PriceFor("openai", "gpt-4o")).Should(Equal(pricing.Price{Prompt: 2.5, Completion: 10}))
+		})
+
+		It("Should reject malformed catalogs", func() {
+			for _, catalog := range []string{
+				"models: [openai/gpt-4o]",
+				"model:\n  openai/gpt-4o: {prompt: 1}",
+				"models:\n  gpt-4o: {prompt: 1, completion: 2}",
+				"models:\n  openai/gpt-4o: {prompt: -1, completion: 2}",
+				"models:\n  openai/gpt-4o: {prompt: 1, completions: 2}",
+			} {
+				_, err := pricing.Parse([]byte(catalog))
+				Expect(err).Should(HaveOccurred(), catalog)
+			}
+		})
+	})
+})
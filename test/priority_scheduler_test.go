@@ -0,0 +1,85 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Priority and Scheduler", func() {
+	newAgent := func() *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "llama", Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:          "vllm",
+				Model:             "llama-3-8b",
+				SystemPrompt:      "You are a helpful AI assistant.",
+				Endpoint:          "http://vllm.default.svc:8000/v1",
+				PriorityClassName: "production-agents",
+				SchedulerName:     "gpu-binpack-scheduler",
+			},
+		}
+	}
+
+	Context("Reconciling the priority and scheduler", func() {
+		It("Should set them on the pod template and update the Deployment on change", func() {
+			ctx := context.Background()
+			scheme := newScheme()
+
+			fakeClient := newFakeClientBuilder(scheme).
+				WithObjects(newAgent()).
+				Build()
+			reconciler := &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "llama", Namespace: "default"}}
+			reconcile := func() *appsv1.Deployment {
+				return reconcileDeployment(ctx, reconciler, request)
+			}
+
+			deployment := reconcile()
+			Expect(deployment.Spec.Template.Spec.PriorityClassName).Should(Equal("production-agents"))
+			Expect(deployment.Spec.Template.Spec.SchedulerName).Should(Equal("gpu-binpack-scheduler"))
+
+			By("Moving the agent to another PriorityClass and the default scheduler")
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Spec.PriorityClassName = "critical-agents"
+			agent.Spec.SchedulerName = ""
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			deployment = reconcile()
+			Expect(deployment.Spec.Template.Spec.PriorityClassName).Should(Equal("critical-agents"))
+			Expect(deployment.Spec.Template.Spec.SchedulerName).Should(BeEmpty())
+		})
+	})
+
+	Context("Admitting agents with a priority and scheduler", func() {
+		admit := func(priorityClassName, schedulerName string) error {
+			agent := newAgent()
+			agent.Spec.PriorityClassName = priorityClassName
+			agent.Spec.SchedulerName = schedulerName
+			validator := &webhookv1.AgentWebhook{}
+			Expect(validator.Default(context.Background(), agent)).Should(Succeed())
+			_, err := validator.ValidateCreate(context.Background(), agent)
+			return err
+		}
+
+		It("Should admit DNS subdomain names", func() {
+			Expect(admit("production-agents", "gpu-binpack-scheduler")).Should(Succeed())
+			Expect(admit("", "")).Should(Succeed())
+		})
+
+		It("Should reject names with invalid DNS characters", func() {
+			err := admit("Production_Agents", "gpu binpack")
+			Expect(err).Should(MatchError(ContainSubstring(`spec.priorityClassName: Invalid value: "Production_Agents"`)))
+			Expect(err).Should(MatchError(ContainSubstring(`spec.schedulerName: Invalid value: "gpu binpack"`)))
+		})
+	})
+})
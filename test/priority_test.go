@@ -0,0 +1,214 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/util/workqueue"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Reconcile Priority", func() {
+	priorityAgent := func(name, priority string) *aiv1.Agent {
+		agent := &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "vllm",
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.default.svc:8000/v1",
+			},
+		}
+		if priority != "" {
+			agent.Labels = map[string]string{controllers.PriorityLabel: priority}
+		}
+		return agent
+	}
+
+	DescribeTable("Should classify the agents by their priority label",
+		func(value string, class controllers.PriorityClass) {
+			labels := map[string]string{}
+			if value != "" {
+				labels[controllers.PriorityLabel] = value
+			}
+			Expect(controllers.AgentPriority(labels)).Should(Equal(class))
+		},
+		Entry("high", "high", controllers.PriorityHigh),
+		Entry("low", "low", controllers.PriorityLow),
+		Entry("a positive number", "100", controllers.PriorityHigh),
+		Entry("a negative number", "-5", controllers.PriorityLow),
+		Entry("zero", "0", controllers.PriorityNormal),
+		Entry("no label", "", controllers.PriorityNormal),
+		Entry("an invalid value", "urgent", controllers.PriorityNormal),
+	)
+
+	It("Should enqueue the events of an agent in the queue of its class only", func() {
+		priorityScheme := newScheme()
+		reader := fake.NewClientBuilder().WithScheme(priorityScheme).WithObjects(
+			priorityAgent("checkout", "high"),
+			priorityAgent("faq", ""),
+			priorityAgent("playground", "-1"),
+		).Build()
+
+		queues := map[controllers.PriorityClass]workqueue.RateLimitingInterface{}
+		for _, class := range controllers.PriorityClasses {
+			queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+			DeferCleanup(queue.ShutDown)
+			queues[class] = queue
+			events := controllers.PriorityEvents(class, &handler.EnqueueRequestForObject{}, reader)
+			// The Services of the agents share their names, as the owned objects would map to them
+			for _, name := range []string{"checkout", "faq", "playground", "deleted"} {
+				service := &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"}}
+				events.Update(context.Background(), event.UpdateEvent{ObjectOld: service, ObjectNew: service}, queue)
+			}
+		}
+
+		queued := func(class controllers.PriorityClass) []string {
+			var names []string
+			for queues[class].Len() > 0 {
+				item, _ := queues[class].Get()
+				names = append(names, item.(reconcile.Request).Name)
+				queues[class].Done(item)
+			}
+			return names
+		}
+		Expect(queued(controllers.PriorityHigh)).Should(Equal([]string{"checkout"}))
+		By("Cleaning up after deleted agents in the normal class")
+		Expect(queued(controllers.PriorityNormal)).Should(Equal([]string{"faq", "deleted"}))
+		Expect(queued(controllers.PriorityLow)).Should(Equal([]string{"playground"}))
+	})
+
+	It("Should write the status of high-priority agents first after a restart", func() {
+		if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+			Skip("the manager runs against envtest, which needs KUBEBUILDER_ASSETS")
+		}
+		testEnv := &envtest.Environment{
+			CRDDirectoryPaths:     []string{filepath.Join("..", "crd")},
+			ErrorIfCRDPathMissing: true,
+		}
+		cfg, err := testEnv.Start()
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(testEnv.Stop)
+		// Registered after the environment, so that the manager stops before the API server
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		DeferCleanup(func() {
+			cancel()
+			<-stopped
+		})
+
+		priorityScheme := newScheme()
+		apiClient, err := client.New(cfg, client.Options{Scheme: priorityScheme})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		// The agents exist before the manager starts, as after an operator restart
+		high := map[string]bool{}
+		for i := 0; i < 30; i++ {
+			priority := "low"
+			if i%10 == 9 {
+				priority = "high"
+			}
+			agent := priorityAgent(fmt.Sprintf("agent-%02d", i), priority)
+			Expect(apiClient.Create(ctx, agent)).Should(Succeed())
+			if priority == "high" {
+				high[agent.Name] = true
+			}
+		}
+
+		mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+			Scheme:  priorityScheme,
+			Metrics: metricsserver.Options{BindAddress: "0"},
+			// The recording client below wraps a client that can watch
+			NewClient: func(config *rest.Config, options client.Options) (client.Client, error) {
+				return client.NewWithWatch(config, options)
+			},
+		})
+		Expect(err).ShouldNot(HaveOccurred())
+		var (
+			mu     sync.Mutex
+			writes []string
+			seen   = map[string]bool{}
+		)
+		record := func(obj client.Object) {
+			mu.Lock()
+			defer mu.Unlock()
+			if _, ok := obj.(*aiv1.Agent); ok && !seen[obj.GetName()] {
+				seen[obj.GetName()] = true
+				writes = append(writes, obj.GetName())
+			}
+		}
+		recording := interceptor.NewClient(mgr.GetClient().(client.WithWatch), interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				record(obj)
+				return c.SubResource(subResource).Update(ctx, obj, opts...)
+			},
+			SubResourcePatch: func(ctx context.Context, c client.Client, subResource string, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+				record(obj)
+				return c.SubResource(subResource).Patch(ctx, obj, patch, opts...)
+			},
+		})
+		Expect((&controllers.AgentReconciler{
+			Client:          recording,
+			Scheme:          priorityScheme,
+			PriorityWorkers: map[controllers.PriorityClass]int{controllers.PriorityHigh: len(high), controllers.PriorityLow: 1},
+		}).SetupWithManager(mgr)).Should(Succeed())
+		go func() {
+			defer GinkgoRecover()
+			defer close(stopped)
+			Expect(mgr.Start(ctx)).Should(Succeed())
+		}()
+
+		Eventually(func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(writes)
+		}, 30*time.Second).Should(BeNumerically(">=", 2*len(high)))
+		mu.Lock()
+		first := append([]string{}, writes[:2*len(high)]...)
+		mu.Unlock()
+		for name := range high {
+			Expect(first).Should(ContainElement(name))
+		}
+
+		By("Reporting the queue latency of each class")
+		families, err := metrics.Registry.Gather()
+		Expect(err).ShouldNot(HaveOccurred())
+		queues := map[string]bool{}
+		for _, family := range families {
+			if family.GetName() != "workqueue_queue_duration_seconds" {
+				continue
+			}
+			for _, metric := range family.GetMetric() {
+				for _, label := range metric.GetLabel() {
+					if label.GetName() == "name" {
+						queues[label.GetValue()] = true
+					}
+				}
+			}
+		}
+		Expect(queues).Should(HaveKey("agent-high"))
+		Expect(queues).Should(HaveKey("agent-low"))
+		Expect(queues).Should(HaveKey("agent"))
+	})
+})
@@ -0,0 +1,229 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/promotion"
+)
+
+var _ = Describe("Agent Promotion", func() {
+	int32Ptr := func(value int32) *int32 { return &value }
+
+	stagingAgent := func() *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "support-agent",
+				Namespace:   "staging",
+				Generation:  3,
+				Annotations: map[string]string{promotion.Annotation: "production"},
+				Labels:      map[string]string{"team": "support"},
+			},
+			Spec: aiv1.AgentSpec{
+				Provider:     "openai",
+				Model:        "gpt-4o",
+				SystemPrompt: "You are a support agent.",
+				ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-staging"}, Key: "api-key"}},
+				Replicas:     int32Ptr(1),
+				Debug:        true,
+				LogLevel:     "info",
+			},
+		}
+	}
+
+	// previewSpec is the spec of a preview agent with a time to live and a memory backend
+	previewSpec := func() *aiv1.AgentSpec {
+		spec := stagingAgent().Spec
+		spec.TTL = &metav1.Duration{Duration: 72 * time.Hour}
+		spec.Memory = &aiv1.MemoryConfig{Backend: "redis", ConnectionSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "redis-staging"}, Key: "url"}}
+		return &spec
+	}
+
+	productionPolicy := func(name string, sources ...string) *aiv1.AgentPolicy {
+		return &aiv1.AgentPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "production"},
+			Spec: aiv1.AgentPolicySpec{Promotion: &aiv1.PromotionPolicy{
+				AllowedSources: sources,
+				SecretNames:    map[string]string{"openai-staging": "openai-production", "redis-staging": "redis-production"},
+				Replicas:       int32Ptr(3),
+			}},
+		}
+	}
+
+	Context("When transforming a promoted spec", func() {
+		It("Should strip the debug logs and the time to live", func() {
+			promoted := promotion.Transform(previewSpec(), &aiv1.PromotionPolicy{AllowedSources: []string{"staging"}})
+
+			Expect(promoted.Debug).Should(BeFalse())
+			Expect(promoted.TTL).Should(BeNil())
+			Expect(promoted.ExpireAt).Should(BeNil())
+			Expect(promoted.LogLevel).Should(Equal("info"))
+			Expect(*promoted.Replicas).Should(Equal(int32(1)))
+		})
+
+		It("Should rename the referenced Secrets and override the replicas", func() {
+			source := previewSpec()
+			promoted := promotion.Transform(source, productionPolicy("promotions", "staging").Spec.Promotion)
+
+			Expect(promoted.ApiSecretRef.Name).Should(Equal("openai-production"))
+			Expect(promoted.ApiSecretRef.Key).Should(Equal("api-key"))
+			Expect(promoted.Memory.ConnectionSecretRef.Name).Should(Equal("redis-production"))
+			Expect(*promoted.Replicas).Should(Equal(int32(3)))
+
+			By("Leaving the source spec unchanged")
+			Expect(source.ApiSecretRef.Name).Should(Equal("openai-staging"))
+			Expect(source.Debug).Should(BeTrue())
+			Expect(source.TTL).ShouldNot(BeNil())
+		})
+
+		It("Should keep the Secrets without a new name", func() {
+			promoted := promotion.Transform(previewSpec(), &aiv1.PromotionPolicy{
+				AllowedSources: []string{"staging"},
+				SecretNames:    map[string]string{"redis-staging": "redis-production"},
+			})
+			Expect(promoted.ApiSecretRef.Name).Should(Equal("openai-staging"))
+			Expect(promoted.Memory.ConnectionSecretRef.Name).Should(Equal("redis-production"))
+		})
+
+		It("Should take the rules of the first policy accepting the source namespace", func() {
+			policies := []aiv1.AgentPolicy{*productionPolicy("b-promotions", "staging"), *productionPolicy("a-promotions", "qa"), *productionPolicy("c-promotions", "staging")}
+			policies[0].Spec.Promotion.Replicas = int32Ptr(5)
+
+			rules, name := promotion.Rules("staging", "production", policies)
+			Expect(name).Should(Equal("b-promotions"))
+			Expect(*rules.Replicas).Should(Equal(int32(5)))
+
+			rules, _ = promotion.Rules("dev", "production", policies)
+			Expect(rules).Should(BeNil())
+		})
+
+		It("Should reject annotations not naming another namespace", func() {
+			Expect(promotion.Validate("staging", map[string]string{promotion.Annotation: "production"})).Should(BeEmpty())
+			Expect(promotion.Validate("staging", map[string]string{promotion.Annotation: "staging"})).Should(HaveLen(1))
+			Expect(promotion.Validate("staging", map[string]string{promotion.Annotation: "Production!"})).Should(HaveLen(1))
+		})
+	})
+
+	Context("When reconciling an annotated agent", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			recorder   *record.FakeRecorder
+			request    ctrl.Request
+		)
+
+		setup := func(objects ...client.Object) {
+			ctx = context.Background()
+			scheme := newScheme()
+			objects = append(objects,
+				stagingAgent(),
+				&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "staging"}},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "openai-staging", Namespace: "staging"},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				},
+			)
+			fakeClient = newFakeClientBuilder(scheme).
+				WithObjects(objects...).
+				Build()
+			recorder = record.NewFakeRecorder(100)
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme, Recorder: recorder}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support-agent", Namespace: "staging"}}
+		}
+
+		reconcileSource := func() *aiv1.Agent {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			source := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, source)).Should(Succeed())
+			return source
+		}
+
+		It("Should create the promoted agent in the target namespace", func() {
+			setup(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "production"}}, productionPolicy("promotions", "staging"))
+
+			source := reconcileSource()
+			Expect(source.Status.Promotion).ShouldNot(BeNil())
+			Expect(source.Status.Promotion.Result).Should(Equal("Promoted"))
+			Expect(source.Status.Promotion.TargetNamespace).Should(Equal("production"))
+			Expect(source.Status.Promotion.LastPromotionTime).ShouldNot(BeNil())
+
+			promoted := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-agent", Namespace: "production"}, promoted)).Should(Succeed())
+			Expect(promoted.Labels).Should(HaveKeyWithValue(promotion.SourceNamespaceLabel, "staging"))
+			Expect(promoted.Labels).Should(HaveKeyWithValue(promotion.SourceNameLabel, "support-agent"))
+			Expect(promoted.Labels).ShouldNot(HaveKey("team"))
+			Expect(promoted.Annotations).ShouldNot(HaveKey(promotion.Annotation))
+			Expect(promoted.Annotations).Should(HaveKeyWithValue(promotion.GenerationAnnotation, "3"))
+			Expect(promoted.Spec.Debug).Should(BeFalse())
+			Expect(promoted.Spec.TTL).Should(BeNil())
+			Expect(promoted.Spec.ApiSecretRef.Name).Should(Equal("openai-production"))
+			Expect(*promoted.Spec.Replicas).Should(Equal(int32(3)))
+
+			By("Following the changes of the source agent")
+			source.Spec.SystemPrompt = "You are a tuned support agent."
+			source.Generation = 4
+			Expect(fakeClient.Update(ctx, source)).Should(Succeed())
+			reconcileSource()
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-agent", Namespace: "production"}, promoted)).Should(Succeed())
+			Expect(promoted.Spec.SystemPrompt).Should(Equal("You are a tuned support agent."))
+		})
+
+		It("Should refuse namespaces without a policy accepting the source", func() {
+			setup(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "production"}}, productionPolicy("promotions", "qa"))
+
+			source := reconcileSource()
+			Expect(source.Status.Promotion.Result).Should(Equal("Failed"))
+			Expect(source.Status.Promotion.Message).Should(ContainSubstring("No AgentPolicy of namespace production accepts agents promoted from namespace staging"))
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-agent", Namespace: "production"}, &aiv1.Agent{})).ShouldNot(Succeed())
+		})
+
+		It("Should report missing target namespaces", func() {
+			setup()
+
+			source := reconcileSource()
+			Expect(source.Status.Promotion.Result).Should(Equal("Failed"))
+			Expect(source.Status.Promotion.Message).Should(Equal("Namespace production does not exist"))
+		})
+
+		It("Should not overwrite an agent that was not promoted from the source", func() {
+			existing := &aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "support-agent", Namespace: "production"},
+				Spec:       aiv1.AgentSpec{Provider: "claude", Model: "claude-3-5-sonnet", SystemPrompt: "Hand-written."},
+			}
+			setup(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "production"}}, productionPolicy("promotions", "staging"), existing)
+
+			source := reconcileSource()
+			Expect(source.Status.Promotion.Result).Should(Equal("Failed"))
+			Expect(source.Status.Promotion.Message).Should(ContainSubstring("was not promoted from this agent"))
+			Expect(recorder.Events).Should(Receive(ContainSubstring("PromotionFailed")))
+
+			kept := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-agent", Namespace: "production"}, kept)).Should(Succeed())
+			Expect(kept.Spec.SystemPrompt).Should(Equal("Hand-written."))
+		})
+
+		It("Should clear the status once the annotation is removed", func() {
+			setup(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "production"}}, productionPolicy("promotions", "staging"))
+			source := reconcileSource()
+
+			delete(source.Annotations, promotion.Annotation)
+			Expect(fakeClient.Update(ctx, source)).Should(Succeed())
+			source = reconcileSource()
+			Expect(source.Status.Promotion).Should(BeNil())
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-agent", Namespace: "production"}, &aiv1.Agent{})).Should(Succeed())
+		})
+	})
+})
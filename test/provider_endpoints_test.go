@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/health"
+)
+
+var _ = Describe("Agent Provider Endpoints", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newReconciler := func(spec aiv1.AgentSpec, objects ...client.Object) {
+		endpointsScheme := newScheme()
+
+		objects = append(objects, &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "failover", Namespace: "default"},
+			Spec:       spec,
+		})
+		fakeClient = newFakeClientBuilder(endpointsScheme).
+			WithObjects(objects...).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: endpointsScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "failover", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	configValid := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionConfigValid {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	envOf := func(container corev1.Container) map[string]string {
+		env := map[string]string{}
+		for _, e := range container.Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+
+	failoverSpec := func() aiv1.AgentSpec {
+		backup, heavy := int32(1), int32(3)
+		return aiv1.AgentSpec{
+			Provider:     "vllm",
+			Model:        "llama-3-8b",
+			SystemPrompt: "You are a helpful AI assistant.",
+			Endpoints: []aiv1.ProviderEndpoint{
+				{URL: "http://10.0.0.12:8001/v1", Priority: &backup},
+				{URL: "http://10.0.0.10:8000/v1", Weight: &heavy},
+				{URL: "http://10.0.0.11:8000/v1"},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should render the endpoints by priority for the runtime and the endpoint wait", func() {
+		spec := failoverSpec()
+		spec.NetworkPolicy = &aiv1.NetworkPolicyConfig{Enabled: true}
+		newReconciler(spec)
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionTrue))
+		Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		env := envOf(deployment.Spec.Template.Spec.Containers[0])
+		Expect(env).Should(HaveKeyWithValue("AGENT_ENDPOINT", "http://10.0.0.10:8000/v1"))
+		Expect(env).Should(HaveKey("AGENT_ENDPOINTS"))
+		var rendered []map[string]interface{}
+		Expect(json.Unmarshal([]byte(env["AGENT_ENDPOINTS"]), &rendered)).Should(Succeed())
+		Expect(rendered).Should(Equal([]map[string]interface{}{
+			{"url": "http://10.0.0.10:8000/v1", "priority": 0.0, "weight": 3.0},
+			{"url": "http://10.0.0.11:8000/v1", "priority": 0.0, "weight": 1.0},
+			{"url": "http://10.0.0.12:8001/v1", "priority": 1.0, "weight": 1.0},
+		}))
+		Expect(env).ShouldNot(HaveKey("AGENT_API_KEY"))
+
+		By("Waiting for any of the endpoints")
+		initContainers := deployment.Spec.Template.Spec.InitContainers
+		Expect(initContainers).Should(HaveLen(1))
+		Expect(envOf(initContainers[0])).Should(HaveKeyWithValue("AGENT_ENDPOINTS", env["AGENT_ENDPOINTS"]))
+
+		By("Allowing egress to every endpoint")
+		policy := &networkingv1.NetworkPolicy{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "failover-network-policy", Namespace: "default"}, policy)).Should(Succeed())
+		var cidrs []string
+		for _, rule := range policy.Spec.Egress {
+			for _, peer := range rule.To {
+				if peer.IPBlock != nil {
+					cidrs = append(cidrs, peer.IPBlock.CIDR)
+				}
+			}
+		}
+		Expect(cidrs).Should(ConsistOf("10.0.0.10/32", "10.0.0.11/32", "10.0.0.12/32"))
+	})
+
+	It("Should reject mixing endpoint with endpoints, and invalid or duplicate URLs", func() {
+		spec := failoverSpec()
+		spec.Endpoint = "http://vllm.default.svc:8000/v1"
+		newReconciler(spec)
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid(agent).Reason).Should(Equal("InvalidEndpoints"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("mutually exclusive"))
+
+		By("Rejecting a URL without a scheme")
+		agent.Spec.Endpoint = ""
+		agent.Spec.Endpoints[1].URL = "10.0.0.10:8000/v1"
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(configValid(agent).Reason).Should(Equal("InvalidEndpoints"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("endpoints[1].url"))
+
+		By("Rejecting the same URL listed twice")
+		agent.Spec.Endpoints[1].URL = "http://10.0.0.11:8000/v1/"
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(configValid(agent).Reason).Should(Equal("InvalidEndpoints"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("more than once"))
+
+		By("Accepting unique URLs")
+		agent.Spec.Endpoints[1].URL = "https://vllm.backup.example.com/v1"
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		Expect(configValid(reconcile()).Status).Should(Equal(corev1.ConditionTrue))
+	})
+
+	It("Should publish the health of each endpoint reported by the runtime", func() {
+		spec := failoverSpec()
+		spec.HealthCheck = &aiv1.HealthCheckConfig{Enabled: true}
+		ready := true
+		newReconciler(spec, &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "failover-service-abcde",
+				Namespace: "default",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "failover-service"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.5"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}}},
+		})
+
+		runtimeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(json.NewEncoder(w).Encode(health.Report{
+				Status: health.StateHealthy,
+				Components: map[string]health.Component{
+					"provider": {Status: health.StateHealthy, Message: "2 of 3 endpoints reachable, active http://10.0.0.11:8000/v1"},
+				},
+				Endpoints: []health.Endpoint{
+					{URL: "http://10.0.0.10:8000/v1", Status: health.StateUnhealthy, Message: "Connection error."},
+					{URL: "http://10.0.0.11:8000/v1", Status: health.StateHealthy, Active: true},
+					{URL: "http://10.0.0.12:8001/v1", Status: health.StateHealthy},
+				},
+			})).Should(Succeed())
+		}))
+		DeferCleanup(runtimeServer.Close)
+		server, err := url.Parse(runtimeServer.URL)
+		Expect(err).ShouldNot(HaveOccurred())
+		reconciler.HealthChecker = &health.Checker{Client: &http.Client{Transport: redirectTransport{server: server}}}
+
+		reconcile()
+		agent := reconcile()
+		Expect(agent.Status.Health).ShouldNot(BeNil())
+		Expect(agent.Status.Health.Endpoints).Should(Equal([]aiv1.ProviderEndpointHealth{
+			{URL: "http://10.0.0.12:8001/v1", Healthy: true},
+			{URL: "http://10.0.0.10:8000/v1", Healthy: false, Message: "Connection error."},
+			{URL: "http://10.0.0.11:8000/v1", Healthy: true, Active: true},
+		}))
+	})
+})
@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	testingclock "k8s.io/utils/clock/testing"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/queuelimit"
+)
+
+var _ = Describe("Agent Workqueue Rate Limiting", func() {
+	It("Should delay an agent enqueued over its burst and reset it with the window", func() {
+		clock := testingclock.NewFakePassiveClock(time.Now())
+		limiter := &queuelimit.ItemLimiter{BaseDelay: time.Second, MaxDelay: 10 * time.Second, Burst: 2, Window: time.Minute, Clock: clock}
+
+		var delays []time.Duration
+		for i := 0; i < 7; i++ {
+			delays = append(delays, limiter.When("default/hot"))
+		}
+		Expect(delays).Should(Equal([]time.Duration{0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}))
+		Expect(limiter.When("default/other")).Should(BeZero())
+
+		By("Keeping the count of an agent reconciled successfully")
+		limiter.Forget("default/hot")
+		Expect(limiter.When("default/hot")).Should(Equal(10 * time.Second))
+
+		clock.SetTime(clock.Now().Add(time.Minute))
+		Expect(limiter.When("default/hot")).Should(BeZero())
+	})
+
+	It("Should keep reconciling the other agents while one is flooded with events", func() {
+		items := &queuelimit.ItemLimiter{}
+		queue := workqueue.NewRateLimitingQueue(queuelimit.NewRateLimiter(items, 0, 0))
+		DeferCleanup(queue.ShutDown)
+		events := controllers.RateLimitedEvents("agent", &handler.EnqueueRequestForObject{}, items)
+
+		var (
+			mu         sync.Mutex
+			reconciles = map[string]int{}
+			reconciled = map[string]time.Time{}
+		)
+		// A single worker, as the Agent controller runs by default
+		go func() {
+			for {
+				item, shutdown := queue.Get()
+				if shutdown {
+					return
+				}
+				time.Sleep(2 * time.Millisecond)
+				mu.Lock()
+				key := item.(reconcile.Request).String()
+				reconciles[key]++
+				reconciled[key] = time.Now()
+				mu.Unlock()
+				queue.Forget(item)
+				queue.Done(item)
+			}
+		}()
+
+		service := func(agent string) *corev1.Service {
+			return &corev1.Service{ObjectMeta: metav1.ObjectMeta{Name: agent, Namespace: "default"}}
+		}
+		update := func(agent string) {
+			events.Update(context.Background(), event.UpdateEvent{ObjectOld: service(agent), ObjectNew: service(agent)}, queue)
+		}
+
+		// Another controller rewrites the Service of the hot agent in a loop
+		ctx, cancel := context.WithCancel(context.Background())
+		DeferCleanup(cancel)
+		go func() {
+			for ctx.Err() == nil {
+				update("hot")
+				time.Sleep(50 * time.Microsecond)
+			}
+		}()
+
+		time.Sleep(200 * time.Millisecond)
+		quiet := types.NamespacedName{Name: "quiet", Namespace: "default"}.String()
+		for i := 0; i < 5; i++ {
+			enqueued := time.Now()
+			update("quiet")
+			Eventually(func() time.Time {
+				mu.Lock()
+				defer mu.Unlock()
+				return reconciled[quiet]
+			}, 100*time.Millisecond, time.Millisecond).Should(BeTemporally(">=", enqueued))
+			time.Sleep(50 * time.Millisecond)
+		}
+		cancel()
+
+		mu.Lock()
+		defer mu.Unlock()
+		Expect(reconciles[quiet]).Should(Equal(5))
+		hot := types.NamespacedName{Name: "hot", Namespace: "default"}.String()
+		Expect(reconciles[hot]).Should(BeNumerically("<=", queuelimit.DefaultBurst+20))
+	})
+
+	It("Should not delay the revert of a drifted Service after a rollout", func() {
+		scheme := newScheme()
+		agent := &aiv1.Agent{ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default", UID: "support-uid"}}
+		fakeClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(agent).Build()
+		mapper := meta.NewDefaultRESTMapper(nil)
+		mapper.Add(aiv1.GroupVersion.WithKind("Agent"), meta.RESTScopeNamespace)
+
+		items := &queuelimit.ItemLimiter{BaseDelay: time.Minute, Burst: 2}
+		queue := workqueue.NewRateLimitingQueue(queuelimit.NewRateLimiter(items, 0, 0))
+		DeferCleanup(queue.ShutDown)
+		events := controllers.AgentEvents{Controller: "agent", Class: controllers.PriorityNormal, Limiter: items, Reader: fakeClient}
+		owned := events.Owned(scheme, mapper)
+
+		ownedBy := func(object client.Object) client.Object {
+			object.SetName("support")
+			object.SetNamespace("default")
+			object.SetOwnerReferences([]metav1.OwnerReference{*metav1.NewControllerRef(agent, aiv1.GroupVersion.WithKind("Agent"))})
+			return object
+		}
+		request := reconcile.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+		reconcileNow := func() {
+			Expect(queue.Len()).Should(Equal(1))
+			item, _ := queue.Get()
+			Expect(item).Should(Equal(request))
+			queue.Forget(item)
+			queue.Done(item)
+		}
+
+		By("Reconciling every status update of the Deployment during a rollout")
+		for i := 0; i < 100; i++ {
+			deployment := ownedBy(&appsv1.Deployment{})
+			owned.Update(context.Background(), event.UpdateEvent{ObjectOld: deployment, ObjectNew: deployment}, queue)
+			reconcileNow()
+		}
+		Expect(items.NumRequeues(request)).Should(BeZero())
+
+		By("Reverting the Service edited out of band at once")
+		service := ownedBy(&corev1.Service{})
+		owned.Update(context.Background(), event.UpdateEvent{ObjectOld: service, ObjectNew: service}, queue)
+		reconcileNow()
+
+		By("Still delaying the agent when a Secret it refers to is rewritten in a loop")
+		referenced := events.Referenced(handler.EnqueueRequestsFromMapFunc(func(context.Context, client.Object) []reconcile.Request {
+			return []reconcile.Request{request}
+		}))
+		secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"}}
+		for i := 0; i < 2; i++ {
+			referenced.Update(context.Background(), event.UpdateEvent{ObjectOld: secret, ObjectNew: secret}, queue)
+			reconcileNow()
+		}
+		referenced.Update(context.Background(), event.UpdateEvent{ObjectOld: secret, ObjectNew: secret}, queue)
+		Expect(queue.Len()).Should(BeZero())
+	})
+})
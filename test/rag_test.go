@@ -0,0 +1,394 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("RAG", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	vectorStore := func() *aiv1.VectorStoreConfig {
+		return &aiv1.VectorStoreConfig{
+			Type: "pgvector",
+			ConnectionSecretRef: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "vector-store"},
+				Key:                  "dsn",
+			},
+			Collection:         "documents",
+			Dimensions:         1536,
+			VerifyConnectivity: true,
+		}
+	}
+
+	newReconciler := func(rag *aiv1.RAGConfig, objects ...client.Object) {
+		scheme := newScheme()
+
+		objects = append(objects,
+			&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "librarian", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					RAG:          rag,
+				},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "vector-store", Namespace: "default"},
+				Data:       map[string][]byte{"dsn": []byte("postgres://pgvector.default.svc:5432/rag")},
+			},
+		)
+		fakeClient = newFakeClientBuilder(scheme).
+			WithObjects(objects...).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "librarian", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	update := func(mutate func(*aiv1.Agent)) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		mutate(agent)
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) *aiv1.AgentCondition {
+		for i := range agent.Status.Conditions {
+			if agent.Status.Conditions[i].Type == conditionType {
+				return &agent.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	Context("Checking the vector store connection", func() {
+		checkKey := types.NamespacedName{Name: "librarian-vector-store-check", Namespace: "default"}
+
+		checkJob := func() *batchv1.Job {
+			job := &batchv1.Job{}
+			Expect(fakeClient.Get(ctx, checkKey, job)).Should(Succeed())
+			return job
+		}
+
+		finishCheck := func(mutate func(*batchv1.Job)) {
+			job := checkJob()
+			mutate(job)
+			Expect(fakeClient.Status().Update(ctx, job)).Should(Succeed())
+		}
+
+		BeforeEach(func() {
+			newReconciler(&aiv1.RAGConfig{Enabled: true, VectorStore: vectorStore()})
+		})
+
+		It("Should run a pre-flight Job owned by the agent", func() {
+			agent := reconcile()
+
+			job := checkJob()
+			Expect(job.OwnerReferences).Should(HaveLen(1))
+			Expect(job.OwnerReferences[0].Name).Should(Equal("librarian"))
+			Expect(job.Labels).Should(HaveKeyWithValue("app.kubernetes.io/component", "vector-store-check"))
+			Expect(job.Spec.Template.Spec.RestartPolicy).Should(Equal(corev1.RestartPolicyNever))
+			container := job.Spec.Template.Spec.Containers[0]
+			Expect(container.Env).Should(ContainElement(corev1.EnvVar{Name: "VECTOR_STORE_TYPE", Value: "pgvector"}))
+			Expect(container.Env).Should(ContainElement(HaveField("ValueFrom.SecretKeyRef.Name", "vector-store")))
+
+			reachable := condition(agent, aiv1.AgentConditionVectorStoreReachable)
+			Expect(reachable).ShouldNot(BeNil())
+			Expect(reachable.Status).Should(Equal(corev1.ConditionUnknown))
+			Expect(reachable.Reason).Should(Equal("Checking"))
+		})
+
+		It("Should report the outcome of the Job in the VectorStoreReachable condition", func() {
+			reconcile()
+			finishCheck(func(job *batchv1.Job) { job.Status.Succeeded = 1 })
+			reachable := condition(reconcile(), aiv1.AgentConditionVectorStoreReachable)
+			Expect(reachable.Status).Should(Equal(corev1.ConditionTrue))
+			Expect(reachable.Reason).Should(Equal("Reachable"))
+
+			By("Reporting a failed check")
+			finishCheck(func(job *batchv1.Job) {
+				job.Status.Succeeded = 0
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue}}
+			})
+			reachable = condition(reconcile(), aiv1.AgentConditionVectorStoreReachable)
+			Expect(reachable.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(reachable.Reason).Should(Equal("Unreachable"))
+			Expect(reachable.Message).Should(ContainSubstring("librarian-vector-store-check"))
+		})
+
+		It("Should re-run the check and roll the pods when the connection secret rotates", func() {
+			reconcile()
+			finishCheck(func(job *batchv1.Job) { job.Status.Succeeded = 1 })
+			reconcile()
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			checksum := deployment.Spec.Template.Annotations["kubeagentic.ai/vector-store-checksum"]
+			Expect(checksum).ShouldNot(BeEmpty())
+
+			secret := &corev1.Secret{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "vector-store", Namespace: "default"}, secret)).Should(Succeed())
+			secret.Data["dsn"] = []byte("postgres://pgvector-replica.default.svc:5432/rag")
+			Expect(fakeClient.Update(ctx, secret)).Should(Succeed())
+
+			agent := reconcile()
+			Expect(apierrors.IsNotFound(fakeClient.Get(ctx, checkKey, &batchv1.Job{}))).Should(BeTrue())
+			Expect(condition(agent, aiv1.AgentConditionVectorStoreReachable).Status).Should(Equal(corev1.ConditionUnknown))
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			Expect(deployment.Spec.Template.Annotations["kubeagentic.ai/vector-store-checksum"]).ShouldNot(Equal(checksum))
+
+			By("Creating the replacement Job on the next reconcile")
+			reconcile()
+			Expect(checkJob().Status.Succeeded).Should(BeZero())
+		})
+
+		It("Should delete the Job and the condition once the check is turned off", func() {
+			reconcile()
+			update(func(agent *aiv1.Agent) { agent.Spec.RAG.VectorStore.VerifyConnectivity = false })
+
+			agent := reconcile()
+			Expect(apierrors.IsNotFound(fakeClient.Get(ctx, checkKey, &batchv1.Job{}))).Should(BeTrue())
+			Expect(condition(agent, aiv1.AgentConditionVectorStoreReachable)).Should(BeNil())
+		})
+	})
+
+	Context("Ingesting documents", func() {
+		cronJobKey := types.NamespacedName{Name: "librarian-rag-ingestion", Namespace: "default"}
+
+		ingestion := func() *aiv1.RAGIngestionConfig {
+			return &aiv1.RAGIngestionConfig{
+				Schedule: "0 */6 * * *",
+				Sources: []aiv1.IngestionSource{
+					{URL: "https://docs.example.com/handbook"},
+					{ConfigMapRef: &corev1.LocalObjectReference{Name: "faq"}},
+					{Bucket: &aiv1.BucketSource{
+						Bucket:               "manuals",
+						CredentialsSecretRef: &corev1.LocalObjectReference{Name: "bucket-credentials"},
+					}},
+				},
+				ChunkOverlap: 100,
+			}
+		}
+
+		cronJob := func() *batchv1.CronJob {
+			cronJob := &batchv1.CronJob{}
+			Expect(fakeClient.Get(ctx, cronJobKey, cronJob)).Should(Succeed())
+			return cronJob
+		}
+
+		// finishedRun creates an ingestion Job that finished at the given time, with the pod
+		// reporting its counters through the termination message.
+		finishedRun := func(name string, finished time.Time, succeeded bool, message string) {
+			completed := metav1.NewTime(finished)
+			job := &batchv1.Job{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      name,
+					Namespace: "default",
+					Labels: map[string]string{
+						"kubeagentic.ai/agent":        "librarian",
+						"app.kubernetes.io/component": "rag-ingestion",
+					},
+				},
+			}
+			Expect(fakeClient.Create(ctx, job)).Should(Succeed())
+			if succeeded {
+				job.Status.Succeeded = 1
+				job.Status.CompletionTime = &completed
+			} else {
+				job.Status.Failed = 1
+				job.Status.Conditions = []batchv1.JobCondition{{Type: batchv1.JobFailed, Status: corev1.ConditionTrue, LastTransitionTime: completed}}
+			}
+			Expect(fakeClient.Status().Update(ctx, job)).Should(Succeed())
+			Expect(fakeClient.Create(ctx, &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: name + "-pod", Namespace: "default", Labels: map[string]string{"job-name": name}},
+				Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "ingestion", Image: "kubeagentic/rag-ingestion:latest"}}},
+				Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+					Name:  "ingestion",
+					State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: message}},
+				}}},
+			})).Should(Succeed())
+		}
+
+		BeforeEach(func() {
+			rag := &aiv1.RAGConfig{Enabled: true, VectorStore: vectorStore(), Ingestion: ingestion()}
+			rag.VectorStore.VerifyConnectivity = false
+			newReconciler(rag,
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "bucket-credentials", Namespace: "default"},
+					Data:       map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("key")},
+				},
+				&corev1.ConfigMap{
+					ObjectMeta: metav1.ObjectMeta{Name: "faq", Namespace: "default"},
+					Data:       map[string]string{"returns.md": "Returns are accepted within 30 days."},
+				},
+			)
+		})
+
+		It("Should run the ingestion on its schedule in a CronJob owned by the agent", func() {
+			reconcile()
+
+			cronJob := cronJob()
+			Expect(cronJob.OwnerReferences).Should(HaveLen(1))
+			Expect(cronJob.OwnerReferences[0].Name).Should(Equal("librarian"))
+			Expect(cronJob.Spec.Schedule).Should(Equal("0 */6 * * *"))
+			Expect(cronJob.Spec.ConcurrencyPolicy).Should(Equal(batchv1.ForbidConcurrent))
+			Expect(*cronJob.Spec.SuccessfulJobsHistoryLimit).Should(Equal(int32(3)))
+			Expect(*cronJob.Spec.FailedJobsHistoryLimit).Should(Equal(int32(3)))
+
+			podSpec := cronJob.Spec.JobTemplate.Spec.Template.Spec
+			container := podSpec.Containers[0]
+			Expect(container.Image).Should(Equal("kubeagentic/rag-ingestion:latest"))
+			Expect(container.Env).Should(ContainElements(
+				corev1.EnvVar{Name: "AGENT_PROVIDER", Value: "vllm"},
+				corev1.EnvVar{Name: "INGESTION_CHUNK_SIZE", Value: "1000"},
+				corev1.EnvVar{Name: "INGESTION_CHUNK_OVERLAP", Value: "100"},
+				corev1.EnvVar{Name: "AGENT_VECTOR_STORE_COLLECTION", Value: "documents"},
+			))
+			Expect(container.EnvFrom).Should(ConsistOf(HaveField("Prefix", "BUCKET_2_")))
+			Expect(podSpec.Volumes).Should(ConsistOf(HaveField("ConfigMap.Name", "faq")))
+			Expect(container.VolumeMounts).Should(ConsistOf(HaveField("MountPath", "/sources/configmaps/faq")))
+		})
+
+		It("Should apply changed sources and history limits to the next run", func() {
+			reconcile()
+			update(func(agent *aiv1.Agent) {
+				agent.Spec.RAG.Ingestion.Sources = []aiv1.IngestionSource{{URL: "https://docs.example.com/changelog"}}
+				agent.Spec.RAG.Ingestion.HistoryLimit = int32Ptr(1)
+			})
+			reconcile()
+
+			cronJob := cronJob()
+			Expect(*cronJob.Spec.SuccessfulJobsHistoryLimit).Should(Equal(int32(1)))
+			env := cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Env
+			Expect(env).Should(ContainElement(And(
+				HaveField("Name", "INGESTION_SOURCES"),
+				HaveField("Value", ContainSubstring("changelog")),
+			)))
+			Expect(env).ShouldNot(ContainElement(HaveField("Value", ContainSubstring("handbook"))))
+		})
+
+		It("Should record the result of the most recent run in the status", func() {
+			Expect(reconcile().Status.Ingestion).Should(BeNil())
+
+			start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+			finishedRun("librarian-rag-ingestion-1", start, true, `{"documentsProcessed":40,"failures":0}`)
+			finishedRun("librarian-rag-ingestion-2", start.Add(6*time.Hour), true, `{"documentsProcessed":42,"failures":3}`)
+			status := reconcile().Status.Ingestion
+			Expect(status).ShouldNot(BeNil())
+			Expect(status.LastRunSucceeded).Should(BeTrue())
+			Expect(status.LastRunTime.Time).Should(BeTemporally("==", start.Add(6*time.Hour)))
+			Expect(status.DocumentsProcessed).Should(Equal(int64(42)))
+			Expect(status.Failures).Should(Equal(int64(3)))
+
+			By("Reporting a failed run")
+			finishedRun("librarian-rag-ingestion-3", start.Add(12*time.Hour), false, "")
+			status = reconcile().Status.Ingestion
+			Expect(status.LastRunSucceeded).Should(BeFalse())
+			Expect(status.LastRunTime.Time).Should(BeTemporally("==", start.Add(12*time.Hour)))
+			Expect(status.DocumentsProcessed).Should(BeZero())
+		})
+
+		It("Should delete the CronJob and its status once ingestion is removed", func() {
+			finishedRun("librarian-rag-ingestion-1", time.Now(), true, `{"documentsProcessed":40}`)
+			Expect(reconcile().Status.Ingestion).ShouldNot(BeNil())
+
+			update(func(agent *aiv1.Agent) { agent.Spec.RAG.Ingestion = nil })
+			Expect(reconcile().Status.Ingestion).Should(BeNil())
+			Expect(apierrors.IsNotFound(fakeClient.Get(ctx, cronJobKey, &batchv1.CronJob{}))).Should(BeTrue())
+		})
+	})
+
+	Context("Configuring the embeddings model", func() {
+		agentEnv := func() map[string]corev1.EnvVar {
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			env := map[string]corev1.EnvVar{}
+			for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+				env[e.Name] = e
+			}
+			return env
+		}
+
+		configValid := func(agent *aiv1.Agent) aiv1.AgentCondition {
+			if condition := condition(agent, aiv1.AgentConditionConfigValid); condition != nil {
+				return *condition
+			}
+			return aiv1.AgentCondition{}
+		}
+
+		embeddingsRAG := func(embeddings *aiv1.EmbeddingsConfig) *aiv1.RAGConfig {
+			rag := &aiv1.RAGConfig{Enabled: true, VectorStore: vectorStore(), Embeddings: embeddings}
+			rag.VectorStore.VerifyConnectivity = false
+			return rag
+		}
+
+		It("Should default the embeddings provider and endpoint to the primary model", func() {
+			newReconciler(embeddingsRAG(&aiv1.EmbeddingsConfig{Model: "bge-small-en"}))
+			Expect(configValid(reconcile()).Status).Should(Equal(corev1.ConditionTrue))
+
+			env := agentEnv()
+			Expect(env["EMBEDDINGS_PROVIDER"].Value).Should(Equal("vllm"))
+			Expect(env["EMBEDDINGS_MODEL"].Value).Should(Equal("bge-small-en"))
+			Expect(env["EMBEDDINGS_ENDPOINT"].Value).Should(Equal("http://vllm.default.svc:8000/v1"))
+			Expect(env).ShouldNot(HaveKey("EMBEDDINGS_API_KEY"))
+		})
+
+		It("Should export a separate provider with its own API key", func() {
+			newReconciler(embeddingsRAG(&aiv1.EmbeddingsConfig{
+				Provider: "openai",
+				Model:    "text-embedding-3-small",
+				ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{
+					LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"},
+					Key:                  "api-key",
+				}},
+			}), &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+				Data:       map[string][]byte{"api-key": []byte("sk-test")},
+			})
+			Expect(configValid(reconcile()).Status).Should(Equal(corev1.ConditionTrue))
+
+			env := agentEnv()
+			Expect(env["EMBEDDINGS_PROVIDER"].Value).Should(Equal("openai"))
+			Expect(env["EMBEDDINGS_API_KEY"].ValueFrom.SecretKeyRef.Name).Should(Equal("openai-secret"))
+			Expect(env["EMBEDDINGS_API_KEY"].ValueFrom.SecretKeyRef.Key).Should(Equal("api-key"))
+			Expect(env).ShouldNot(HaveKey("EMBEDDINGS_ENDPOINT"))
+			Expect(env["AGENT_PROVIDER"].Value).Should(Equal("vllm"))
+		})
+
+		It("Should reject a self-hosted embeddings provider without an endpoint", func() {
+			newReconciler(embeddingsRAG(&aiv1.EmbeddingsConfig{Provider: "ollama", Model: "nomic-embed-text"}))
+			agent := reconcile()
+			Expect(configValid(agent).Status).Should(Equal(corev1.ConditionFalse))
+			Expect(configValid(agent).Reason).Should(Equal("InvalidRAGConfig"))
+			Expect(configValid(agent).Message).Should(ContainSubstring("rag.embeddings.endpoint is required for provider ollama"))
+		})
+	})
+})
@@ -0,0 +1,264 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/redaction"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/toolexec"
+)
+
+var _ = Describe("Agent Redaction", func() {
+	spec := &aiv1.RedactionConfig{
+		Builtin: aiv1.RedactionBuiltins{Email: true, Phone: true, SSN: true},
+		Rules: []aiv1.RedactionRule{
+			{Name: "account-id", Pattern: `ACC-[0-9]{8}`, Replacement: "[ACCOUNT]"},
+			{Name: "ticket", Pattern: `(?i)ticket #[0-9]+`},
+		},
+	}
+
+	renderJSON := func(spec *aiv1.RedactionConfig) []byte {
+		data, err := json.MarshalIndent(redaction.Render(spec), "", "  ")
+		Expect(err).ShouldNot(HaveOccurred())
+		return append(data, '\n')
+	}
+
+	Context("When rendering the rules", func() {
+		It("Should match the golden rules with builtin and custom rules", func() {
+			expectGoldenIn("redaction", "all.json", renderJSON(spec))
+		})
+
+		It("Should match the golden rules restricted to the logs", func() {
+			expectGoldenIn("redaction", "logs-only.json", renderJSON(&aiv1.RedactionConfig{
+				Builtin: aiv1.RedactionBuiltins{Email: true},
+				ApplyTo: []aiv1.RedactionTarget{aiv1.RedactionTargetLogs},
+			}))
+		})
+
+		It("Should render nothing without enabled rules", func() {
+			Expect(redaction.Render(nil)).Should(Equal(redaction.Config{}))
+			Expect(redaction.Render(&aiv1.RedactionConfig{ApplyTo: []aiv1.RedactionTarget{aiv1.RedactionTargetLogs}})).Should(Equal(redaction.Config{}))
+		})
+
+		It("Should redact the matches of the rules literally", func() {
+			redactor, err := redaction.New(redaction.Render(&aiv1.RedactionConfig{
+				Builtin: aiv1.RedactionBuiltins{Email: true, Phone: true, SSN: true},
+				Rules:   []aiv1.RedactionRule{{Name: "account-id", Pattern: `ACC-([0-9]{8})`, Replacement: "$1"}},
+			}).Rules)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(redactor.Redact("Mail jane.doe@example.com or call +1 415-555-0100 about 123-45-6789 and ACC-12345678")).Should(Equal(
+				"Mail [REDACTED_EMAIL] or call [REDACTED_PHONE] about [REDACTED_SSN] and $1"))
+		})
+	})
+
+	Context("When validating the rules", func() {
+		validate := func(rules ...aiv1.RedactionRule) field.ErrorList {
+			return redaction.Validate(field.NewPath("spec", "redaction"), &aiv1.RedactionConfig{Rules: rules})
+		}
+
+		It("Should accept the builtin and custom rules", func() {
+			Expect(redaction.Validate(field.NewPath("spec", "redaction"), spec)).Should(BeEmpty())
+			for _, rule := range []redaction.Rule{redaction.EmailRule, redaction.PhoneRule, redaction.SSNRule} {
+				Expect(redaction.CheckPattern(rule.Pattern)).Should(Succeed())
+			}
+		})
+
+		DescribeTable("Should reject patterns that may backtrack catastrophically",
+			func(pattern string) {
+				errs := validate(aiv1.RedactionRule{Name: "bad", Pattern: pattern})
+				Expect(errs).Should(HaveLen(1))
+				Expect(errs[0].Field).Should(Equal("spec.redaction.rules[0].pattern"))
+				Expect(errs[0].Detail).Should(ContainSubstring("backtrack catastrophically"))
+			},
+			Entry("nested plus", `(a+)+`),
+			Entry("nested star in a non-capturing group", `(?:a*)*b`),
+			Entry("nested repetition in an alternation", `(x|[a-z]+)*@`),
+			Entry("unbounded range of a repetition", `(\w+\s?){2,}$`),
+		)
+
+		It("Should reject patterns that do not compile", func() {
+			errs := validate(aiv1.RedactionRule{Name: "bad", Pattern: `ACC-[0-9`})
+			Expect(errs).Should(HaveLen(1))
+			Expect(errs[0].Detail).Should(ContainSubstring("does not compile"))
+		})
+
+		It("Should reject Unicode classes, unknown to the runtime", func() {
+			errs := validate(aiv1.RedactionRule{Name: "names", Pattern: `\p{Lu}\pL{1,20}`})
+			Expect(errs).Should(HaveLen(1))
+			Expect(errs[0].Detail).Should(ContainSubstring("Unicode classes"))
+		})
+
+		It("Should bound the rules and their patterns", func() {
+			var rules []aiv1.RedactionRule
+			for i := 0; i <= redaction.MaxRules; i++ {
+				rules = append(rules, aiv1.RedactionRule{Name: "rule-" + string(rune('a'+i)), Pattern: "x"})
+			}
+			errs := validate(rules...)
+			Expect(errs).Should(HaveLen(1))
+			Expect(errs[0].Type).Should(Equal(field.ErrorTypeTooMany))
+
+			errs = validate(aiv1.RedactionRule{Name: "long", Pattern: string(bytes.Repeat([]byte("a"), redaction.MaxPatternLength+1))})
+			Expect(errs).Should(HaveLen(1))
+			Expect(errs[0].Detail).Should(ContainSubstring("at most 256 characters"))
+		})
+
+		It("Should reject duplicate names", func() {
+			errs := validate(aiv1.RedactionRule{Name: "id", Pattern: "a"}, aiv1.RedactionRule{Name: "id", Pattern: "b"})
+			Expect(errs).Should(HaveLen(1))
+			Expect(errs[0].Type).Should(Equal(field.ErrorTypeDuplicate))
+		})
+	})
+
+	Context("When reconciling an agent", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+		)
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			redactionScheme := newScheme()
+
+			fakeClient = newFakeClientBuilder(redactionScheme).
+				WithObjects(
+					&aiv1.Agent{
+						ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+						Spec: aiv1.AgentSpec{
+							Provider:     "vllm",
+							Model:        "llama-3-8b",
+							SystemPrompt: "You are a helpful AI assistant.",
+							Endpoint:     "http://vllm.default.svc:8000/v1",
+							Memory: &aiv1.MemoryConfig{
+								Backend: "redis",
+								ConnectionSecretRef: &corev1.SecretKeySelector{
+									LocalObjectReference: corev1.LocalObjectReference{Name: "redis-credentials"},
+									Key:                  "url",
+								},
+							},
+							Export: &aiv1.ExportConfig{
+								Schedule:             "0 * * * *",
+								Destination:          aiv1.ExportDestination{Bucket: "transcripts"},
+								CredentialsSecretRef: corev1.LocalObjectReference{Name: "export-credentials"},
+							},
+							ToolExecutor: &aiv1.ToolExecutorConfig{
+								Image:           "registry.example.com/tools/git-kubectl:1.2",
+								AllowedCommands: []string{"git"},
+								Timeout:         &metav1.Duration{Duration: time.Minute},
+							},
+							Redaction: &aiv1.RedactionConfig{
+								Builtin: aiv1.RedactionBuiltins{Email: true},
+								ApplyTo: []aiv1.RedactionTarget{aiv1.RedactionTargetToolAudit, aiv1.RedactionTargetExports},
+							},
+						},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "redis-credentials", Namespace: "default"},
+						Data:       map[string][]byte{"url": []byte("redis://redis:6379")},
+					},
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "export-credentials", Namespace: "default"},
+						Data:       map[string][]byte{"AWS_ACCESS_KEY_ID": []byte("id")},
+					},
+				).
+				Build()
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: redactionScheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+		})
+
+		reconcile := func() *aiv1.Agent {
+			return reconcileAgent(ctx, reconciler, request)
+		}
+
+		emailOnly := `{"rules":[{"name":"email","pattern":"[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\\.[A-Za-z]{2,}","replacement":"[REDACTED_EMAIL]"}],"applyTo":["exports"]}`
+
+		It("Should redact the exports and tool audit records only where applied", func() {
+			agent := reconcile()
+			Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed), agent.Status.Message)
+
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			for _, env := range deployment.Spec.Template.Spec.Containers[0].Env {
+				Expect(env.Name).ShouldNot(Equal("AGENT_REDACTION"))
+			}
+
+			cronJob := &batchv1.CronJob{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-conversation-export", Namespace: "default"}, cronJob)).Should(Succeed())
+			Expect(cronJob.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(
+				corev1.EnvVar{Name: "EXPORT_REDACTION", Value: emailOnly}))
+
+			configMap := &corev1.ConfigMap{}
+			Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-config", Namespace: "default"}, configMap)).Should(Succeed())
+			config, err := toolexec.ParseConfig([]byte(configMap.Data["tool-executor.json"]))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(config.Redaction).Should(Equal([]redaction.Rule{redaction.EmailRule}))
+		})
+
+		It("Should redact the logs of the runtime when applied", func() {
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Spec.Redaction.ApplyTo = nil
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			reconcile()
+
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{
+				Name:  "AGENT_REDACTION",
+				Value: `{"rules":[{"name":"email","pattern":"[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\\.[A-Za-z]{2,}","replacement":"[REDACTED_EMAIL]"}],"applyTo":["logs"]}`,
+			}))
+		})
+
+		It("Should fail agents with catastrophic patterns", func() {
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Spec.Redaction.Rules = []aiv1.RedactionRule{{Name: "words", Pattern: `(\w+\s?)*$`}}
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+
+			agent = reconcile()
+			Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+			Expect(agent.Status.Message).Should(ContainSubstring("may backtrack catastrophically"))
+		})
+	})
+
+	It("Should audit the executed commands with the personal data redacted", func() {
+		var audit bytes.Buffer
+		executor := toolexec.NewExecutor(toolexec.Config{
+			AllowedCommands: []string{"true"},
+			Workspace:       GinkgoT().TempDir(),
+			Redaction:       []redaction.Rule{redaction.EmailRule},
+		}, &audit)
+
+		_, err := executor.Run(context.Background(), toolexec.Request{Command: "true", Args: []string{"--to", "jane.doe@example.com"}})
+		Expect(err).ShouldNot(HaveOccurred())
+		_, err = executor.Run(context.Background(), toolexec.Request{Command: "mail", Args: []string{"jane.doe@example.com"}})
+		Expect(err).Should(MatchError(toolexec.ErrNotAllowed))
+
+		lines := bytes.Split(bytes.TrimSpace(audit.Bytes()), []byte("\n"))
+		Expect(lines).Should(HaveLen(2))
+		var record toolexec.AuditRecord
+		Expect(json.Unmarshal(lines[0], &record)).Should(Succeed())
+		Expect(record.Command).Should(Equal("true"))
+		Expect(record.Args).Should(Equal([]string{"--to", "[REDACTED_EMAIL]"}))
+		Expect(record.ExitCode).Should(Equal(0))
+		Expect(json.Unmarshal(lines[1], &record)).Should(Succeed())
+		Expect(record.Error).Should(Equal("command not allowed: mail"))
+		Expect(string(audit.Bytes())).ShouldNot(ContainSubstring("jane.doe"))
+	})
+})
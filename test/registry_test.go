@@ -0,0 +1,157 @@
+package test
+
+import (
+	"context"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/registry"
+)
+
+var _ = Describe("Agent Registry", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.RegistryReconciler
+		request    ctrl.Request
+	)
+
+	runningAgent := func(name, namespace string) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec: aiv1.AgentSpec{
+				Provider:     "openai",
+				Model:        "gpt-4o",
+				SystemPrompt: "\nYou answer questions about invoices.\nBe concise.",
+				Tools:        []aiv1.Tool{{Name: "lookup_invoice"}},
+			},
+			Status: aiv1.AgentStatus{Phase: aiv1.AgentPhaseRunning},
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		registryScheme := newScheme()
+
+		pending := runningAgent("starting", "billing")
+		pending.Status.Phase = aiv1.AgentPhasePending
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(registryScheme).
+			WithStatusSubresource(&aiv1.Agent{}).
+			WithObjects(runningAgent("invoices", "billing"), pending, runningAgent("tickets", "support")).
+			Build()
+		reconciler = &controllers.RegistryReconciler{Client: fakeClient, ClusterNamespace: "kubeagentic-system"}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "billing", Name: registry.ConfigMapName}}
+	})
+
+	reconcile := func(req ctrl.Request) []registry.Entry {
+		_, err := reconciler.Reconcile(ctx, req)
+		Expect(err).ShouldNot(HaveOccurred())
+		entries, err := registry.Read(ctx, fakeClient, req.Namespace, req.Name)
+		Expect(err).ShouldNot(HaveOccurred())
+		return entries
+	}
+
+	setPhase := func(name string, phase aiv1.AgentPhase) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Namespace: "billing", Name: name}, agent)).Should(Succeed())
+		agent.Status.Phase = phase
+		Expect(fakeClient.Status().Update(ctx, agent)).Should(Succeed())
+	}
+
+	It("Should list the running agents of the namespace", func() {
+		Expect(reconcile(request)).Should(Equal([]registry.Entry{{
+			Name:        "invoices",
+			Namespace:   "billing",
+			URL:         "http://invoices-service.billing.svc.cluster.local",
+			Provider:    "openai",
+			Model:       "gpt-4o",
+			Tools:       []string{"lookup_invoice"},
+			Description: "You answer questions about invoices.",
+		}}))
+	})
+
+	It("Should add and remove agents as they start and stop running", func() {
+		Expect(reconcile(request)).Should(HaveLen(1))
+
+		By("Starting an agent")
+		setPhase("starting", aiv1.AgentPhaseRunning)
+		Expect(reconcile(request)).Should(HaveLen(2))
+
+		By("Updating the model of an agent")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Namespace: "billing", Name: "starting"}, agent)).Should(Succeed())
+		agent.Spec.Model = "gpt-4o-mini"
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		Expect(reconcile(request)).Should(ContainElement(HaveField("Model", "gpt-4o-mini")))
+
+		By("Failing an agent")
+		setPhase("invoices", aiv1.AgentPhaseFailed)
+		entries := reconcile(request)
+		Expect(entries).Should(HaveLen(1))
+		Expect(entries[0].Name).Should(Equal("starting"))
+
+		By("Deleting the last running agent")
+		Expect(fakeClient.Delete(ctx, agent)).Should(Succeed())
+		Expect(reconcile(request)).Should(BeEmpty())
+		cm := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, cm)).Should(Succeed())
+		Expect(cm.Data).Should(HaveKeyWithValue(registry.DataKey, "[]"))
+	})
+
+	It("Should not create a registry without running agents", func() {
+		_, err := reconciler.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "empty", Name: registry.ConfigMapName}})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Namespace: "empty", Name: registry.ConfigMapName}, &corev1.ConfigMap{})).ShouldNot(Succeed())
+	})
+
+	It("Should list the running agents of all namespaces in the cluster registry", func() {
+		entries := reconcile(ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "kubeagentic-system", Name: registry.ClusterConfigMapName}})
+		Expect(entries).Should(HaveLen(2))
+		Expect(entries[0].Namespace).Should(Equal("billing"))
+		Expect(entries[1].Namespace).Should(Equal("support"))
+	})
+
+	It("Should restore a registry edited by another writer", func() {
+		Expect(reconcile(request)).Should(HaveLen(1))
+		cm := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, cm)).Should(Succeed())
+		cm.Data = map[string]string{registry.DataKey: "[]", "extra": "value"}
+		Expect(fakeClient.Update(ctx, cm)).Should(Succeed())
+
+		Expect(reconcile(request)).Should(HaveLen(1))
+		Expect(fakeClient.Get(ctx, request.NamespacedName, cm)).Should(Succeed())
+		Expect(cm.Data).ShouldNot(HaveKey("extra"))
+	})
+
+	It("Should converge when reconciles of the same registry race", func() {
+		setPhase("starting", aiv1.AgentPhaseRunning)
+		var wg sync.WaitGroup
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func() {
+				defer GinkgoRecover()
+				defer wg.Done()
+				// Losing writers requeue instead of failing or writing stale data
+				_, err := reconciler.Reconcile(ctx, request)
+				Expect(err).ShouldNot(HaveOccurred())
+			}()
+		}
+		wg.Wait()
+
+		entries := reconcile(request)
+		Expect(entries).Should(HaveLen(2))
+		Expect(entries[0].Name).Should(Equal("invoices"))
+		Expect(entries[1].Name).Should(Equal("starting"))
+	})
+})
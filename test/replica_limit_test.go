@@ -0,0 +1,168 @@
+package test
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/replicalimit"
+)
+
+var _ = Describe("Agent Replica Limit", func() {
+	setEnv := func(name, value string) {
+		previous, had := os.LookupEnv(name)
+		DeferCleanup(func() {
+			if had {
+				os.Setenv(name, previous)
+			} else {
+				os.Unsetenv(name)
+			}
+		})
+		if value == "" {
+			os.Unsetenv(name)
+		} else {
+			os.Setenv(name, value)
+		}
+	}
+
+	policy := func(name, namespace string, maxReplicas int32) aiv1.AgentPolicy {
+		return aiv1.AgentPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+			Spec:       aiv1.AgentPolicySpec{MaxReplicas: &maxReplicas},
+		}
+	}
+
+	BeforeEach(func() {
+		setEnv("MAX_AGENT_REPLICAS", "")
+	})
+
+	It("Should default to 10 and cap the operator maximum to the CRD schema maximum", func() {
+		Expect(replicalimit.MaxFromEnv()).Should(Equal(int32(10)))
+
+		setEnv("MAX_AGENT_REPLICAS", "24")
+		Expect(replicalimit.MaxFromEnv()).Should(Equal(int32(24)))
+
+		setEnv("MAX_AGENT_REPLICAS", "5000")
+		Expect(replicalimit.MaxFromEnv()).Should(Equal(int32(replicalimit.SanityMax)))
+
+		setEnv("MAX_AGENT_REPLICAS", "none")
+		Expect(replicalimit.MaxFromEnv()).Should(Equal(int32(replicalimit.DefaultMax)))
+	})
+
+	It("Should let AgentPolicies of the namespace lower the operator maximum, but not raise it", func() {
+		setEnv("MAX_AGENT_REPLICAS", "24")
+
+		max, source := replicalimit.Max("traffic", []aiv1.AgentPolicy{policy("generous", "traffic", 50)})
+		Expect(max).Should(Equal(int32(24)))
+		Expect(source).Should(Equal("the operator"))
+
+		max, source = replicalimit.Max("traffic", []aiv1.AgentPolicy{
+			policy("generous", "traffic", 50),
+			policy("caps", "traffic", 6),
+			policy("elsewhere", "other", 2),
+		})
+		Expect(max).Should(Equal(int32(6)))
+		Expect(source).Should(Equal("AgentPolicy caps"))
+	})
+
+	Context("When reconciling", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+		)
+
+		newReconciler := func(replicas int32, objects ...client.Object) {
+			limitScheme := newScheme()
+
+			fakeClient = newFakeClientBuilder(limitScheme).
+				WithObjects(append(objects, &aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "busy", Namespace: "traffic"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "vllm",
+						Model:        "llama-3-8b",
+						SystemPrompt: "You are a helpful AI assistant.",
+						Endpoint:     "http://vllm.traffic.svc:8000/v1",
+						Replicas:     &replicas,
+					},
+				})...).
+				Build()
+			reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: limitScheme}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "busy", Namespace: "traffic"}}
+		}
+
+		reconcile := func() *aiv1.Agent {
+			return reconcileAgent(ctx, reconciler, request)
+		}
+
+		condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) aiv1.AgentCondition {
+			for _, condition := range agent.Status.Conditions {
+				if condition.Type == conditionType {
+					return condition
+				}
+			}
+			return aiv1.AgentCondition{}
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+		})
+
+		It("Should run 24 replicas when the operator maximum allows them", func() {
+			setEnv("MAX_AGENT_REPLICAS", "24")
+			newReconciler(24)
+
+			agent := reconcile()
+			Expect(condition(agent, aiv1.AgentConditionConfigValid).Status).Should(Equal(corev1.ConditionTrue))
+			Expect(condition(agent, aiv1.AgentConditionDegraded).Reason).ShouldNot(Equal("ReplicasAboveMaximum"))
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			Expect(*deployment.Spec.Replicas).Should(Equal(int32(24)))
+		})
+
+		It("Should keep the replicas of an agent above a lowered maximum and only report it", func() {
+			setEnv("MAX_AGENT_REPLICAS", "24")
+			caps := policy("caps", "traffic", 8)
+			newReconciler(12, &caps)
+
+			agent := reconcile()
+			Expect(condition(agent, aiv1.AgentConditionConfigValid).Status).Should(Equal(corev1.ConditionTrue))
+			degraded := condition(agent, aiv1.AgentConditionDegraded)
+			Expect(degraded.Status).Should(Equal(corev1.ConditionTrue))
+			Expect(degraded.Reason).Should(Equal("ReplicasAboveMaximum"))
+			Expect(degraded.Message).Should(ContainSubstring("replicas 12 exceed the maximum 8 of AgentPolicy caps"))
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			Expect(*deployment.Spec.Replicas).Should(Equal(int32(12)))
+
+			By("Resolving the condition once the replicas are lowered")
+			replicas := int32(8)
+			agent.Spec.Replicas = &replicas
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			agent = reconcile()
+			degraded = condition(agent, aiv1.AgentConditionDegraded)
+			Expect(degraded.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(degraded.Reason).Should(Equal("ReplicasWithinMaximum"))
+		})
+
+		It("Should reject replicas above the CRD schema maximum whatever the operator maximum", func() {
+			setEnv("MAX_AGENT_REPLICAS", "5000")
+			newReconciler(1001)
+
+			configValid := condition(reconcile(), aiv1.AgentConditionConfigValid)
+			Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(configValid.Message).Should(ContainSubstring("replicas must be between 1 and 1000, got 1001"))
+		})
+	})
+})
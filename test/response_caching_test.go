@@ -0,0 +1,199 @@
+package test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/agentmetrics"
+)
+
+var _ = Describe("Agent Response Caching", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newReconciler := func(spec aiv1.AgentSpec, objects ...client.Object) {
+		cachingScheme := newScheme()
+
+		objects = append(objects,
+			&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "faq", Namespace: "default"},
+				Spec:       spec,
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+				Data:       map[string][]byte{"api-key": []byte("sk-test")},
+			},
+		)
+		fakeClient = newFakeClientBuilder(cachingScheme).
+			WithObjects(objects...).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: cachingScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "faq", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	agentEnv := func() map[string]corev1.EnvVar {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		env := map[string]corev1.EnvVar{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e
+		}
+		return env
+	}
+
+	configValid := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionConfigValid {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	cachingSpec := func(caching *aiv1.CachingConfig) aiv1.AgentSpec {
+		return aiv1.AgentSpec{
+			Provider:     "openai",
+			Model:        "gpt-4o",
+			SystemPrompt: "You answer frequently asked questions.",
+			ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"},
+				Key:                  "api-key",
+			}},
+			Caching: caching,
+		}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should configure an exact cache kept in the agent pods", func() {
+		maxEntries := int32(500)
+		newReconciler(cachingSpec(&aiv1.CachingConfig{
+			Enabled:    true,
+			TTL:        &metav1.Duration{Duration: 30 * time.Minute},
+			MaxEntries: &maxEntries,
+		}))
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionTrue))
+		env := agentEnv()
+		Expect(env["AGENT_CACHE_ENABLED"].Value).Should(Equal("true"))
+		Expect(env["AGENT_CACHE_BACKEND"].Value).Should(Equal("memory"))
+		Expect(env["AGENT_CACHE_TTL_SECONDS"].Value).Should(Equal("1800"))
+		Expect(env["AGENT_CACHE_MAX_ENTRIES"].Value).Should(Equal("500"))
+		Expect(env["AGENT_CACHE_KEY_STRATEGY"].Value).Should(Equal("exact"))
+		Expect(env).ShouldNot(HaveKey("AGENT_CACHE_URL"))
+		Expect(env).ShouldNot(HaveKey("AGENT_CACHE_EMBEDDINGS_MODEL"))
+
+		By("Requiring the redis backend for several replicas")
+		replicas := int32(3)
+		agent.Spec.Replicas = &replicas
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid(agent).Reason).Should(Equal("InvalidCachingConfig"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("caching.backend must be redis"))
+	})
+
+	It("Should share a semantic cache through the managed Redis", func() {
+		replicas := int32(2)
+		spec := cachingSpec(&aiv1.CachingConfig{
+			Enabled:             true,
+			Backend:             "redis",
+			KeyStrategy:         "semantic",
+			SimilarityThreshold: "0.9",
+			Embeddings:          &aiv1.EmbeddingsConfig{Model: "text-embedding-3-small"},
+		})
+		spec.Replicas = &replicas
+		newReconciler(spec)
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionTrue))
+
+		redis := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "faq-redis", Namespace: "default"}, redis)).Should(Succeed())
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "faq-redis", Namespace: "default"}, &corev1.Service{})).Should(Succeed())
+
+		env := agentEnv()
+		Expect(env["AGENT_CACHE_BACKEND"].Value).Should(Equal("redis"))
+		Expect(env["AGENT_CACHE_URL"].Value).Should(Equal("redis://faq-redis.default.svc:6379/0"))
+		Expect(env["AGENT_CACHE_KEY_STRATEGY"].Value).Should(Equal("semantic"))
+		Expect(env["AGENT_CACHE_SIMILARITY_THRESHOLD"].Value).Should(Equal("0.9"))
+		Expect(env["AGENT_CACHE_EMBEDDINGS_PROVIDER"].Value).Should(Equal("openai"))
+		Expect(env["AGENT_CACHE_EMBEDDINGS_MODEL"].Value).Should(Equal("text-embedding-3-small"))
+		Expect(env["AGENT_CACHE_EMBEDDINGS_API_KEY"].ValueFrom.SecretKeyRef.Name).Should(Equal("openai-secret"))
+
+		By("Rejecting a provider without an embeddings API")
+		agent.Spec.Caching.Embeddings.Provider = "claude"
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile()
+		Expect(configValid(agent).Reason).Should(Equal("InvalidCachingConfig"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("no OpenAI-compatible embeddings API"))
+
+		By("Removing the managed Redis with the cache")
+		agent.Spec.Caching = nil
+		agent.Spec.Replicas = nil
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile()
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "faq-redis", Namespace: "default"}, &appsv1.Deployment{})).ShouldNot(Succeed())
+		Expect(agentEnv()).ShouldNot(HaveKey("AGENT_CACHE_ENABLED"))
+	})
+
+	It("Should add the hits of the agent pods and the cost they saved to the usage", func() {
+		hits, savedPrompt := 30, 400000
+		metricsServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "# TYPE %s counter\n", agentmetrics.CacheRequestsMetric)
+			fmt.Fprintf(w, "%s{agent=\"faq\",result=\"hit\"} %d\n", agentmetrics.CacheRequestsMetric, hits)
+			fmt.Fprintf(w, "%s{agent=\"faq\",result=\"miss\"} 10\n", agentmetrics.CacheRequestsMetric)
+			fmt.Fprintf(w, "# TYPE %s counter\n", agentmetrics.CacheSavedTokensMetric)
+			fmt.Fprintf(w, "%s{agent=\"faq\",type=\"prompt\"} %d\n", agentmetrics.CacheSavedTokensMetric, savedPrompt)
+			fmt.Fprintf(w, "%s{agent=\"faq\",type=\"completion\"} 100000\n", agentmetrics.CacheSavedTokensMetric)
+		}))
+		DeferCleanup(metricsServer.Close)
+		server, err := url.Parse(metricsServer.URL)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		newReconciler(cachingSpec(&aiv1.CachingConfig{Enabled: true}), &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "faq-abc", Namespace: "default", Labels: map[string]string{"kubeagentic.ai/agent": "faq"}},
+			Status:     corev1.PodStatus{Phase: corev1.PodRunning, PodIP: "10.0.0.8"},
+		})
+		reconciler.MetricsScraper = &agentmetrics.Scraper{Client: &http.Client{Transport: redirectTransport{server: server}}}
+
+		agent := reconcile()
+		Expect(agent.Status.Usage).ShouldNot(BeNil())
+		Expect(agent.Status.Usage.CacheHits).Should(Equal(int64(30)))
+		Expect(agent.Status.Usage.CacheMisses).Should(Equal(int64(10)))
+		Expect(agent.Status.Usage.CacheSavedTokens).Should(Equal(int64(500000)))
+		// 400k prompt tokens at 2.5 and 100k completion tokens at 10 per million
+		Expect(agent.Status.Usage.EstimatedSavings).Should(Equal("2.0000"))
+
+		By("Counting only the increase of the pod counters")
+		hits, savedPrompt = 40, 800000
+		agent = reconcile()
+		Expect(agent.Status.Usage.CacheHits).Should(Equal(int64(40)))
+		Expect(agent.Status.Usage.CacheSavedTokens).Should(Equal(int64(900000)))
+		Expect(agent.Status.Usage.EstimatedSavings).Should(Equal("3.0000"))
+	})
+})
@@ -0,0 +1,131 @@
+package test
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/rightsizing"
+)
+
+var rightsizingStart = time.Date(2024, 3, 14, 0, 0, 0, 0, time.UTC)
+
+// loadUsageSamples reads "minute,cpu millicores,memory bytes" lines of a fixture.
+func loadUsageSamples(name string) []rightsizing.Sample {
+	file, err := os.Open(filepath.Join("testdata", "rightsizing", name))
+	Expect(err).ShouldNot(HaveOccurred())
+	defer file.Close()
+
+	var samples []rightsizing.Sample
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Split(line, ",")
+		Expect(fields).Should(HaveLen(3))
+		minute, err := strconv.Atoi(fields[0])
+		Expect(err).ShouldNot(HaveOccurred())
+		cpu, err := strconv.ParseInt(fields[1], 10, 64)
+		Expect(err).ShouldNot(HaveOccurred())
+		memory, err := strconv.ParseInt(fields[2], 10, 64)
+		Expect(err).ShouldNot(HaveOccurred())
+		samples = append(samples, rightsizing.Sample{
+			Time:        rightsizingStart.Add(time.Duration(minute) * time.Minute),
+			CPUMilli:    cpu,
+			MemoryBytes: memory,
+		})
+	}
+	Expect(scanner.Err()).ShouldNot(HaveOccurred())
+	return samples
+}
+
+var _ = Describe("Resource Right-Sizing", func() {
+	const mebibyte = 1 << 20
+
+	Context("When recommending from measured usage", func() {
+		It("Should add headroom to the 95th percentile and the peak", func() {
+			samples := loadUsageSamples("steady.csv")
+
+			usage := rightsizing.Summarize(samples)
+			Expect(usage.CPUP95Milli).Should(Equal(int64(59)))
+			Expect(usage.CPUMaxMilli).Should(Equal(int64(240)))
+			Expect(usage.MemoryMaxBytes).Should(Equal(int64(300 * mebibyte)))
+
+			recommendation, ok := rightsizing.Recommend(samples)
+			Expect(ok).Should(BeTrue())
+			Expect(recommendation.CPURequestMilli).Should(Equal(int64(70)))
+			Expect(recommendation.CPULimitMilli).Should(Equal(int64(320)))
+			Expect(recommendation.MemoryRequestBytes).Should(Equal(int64(248 * mebibyte)))
+			Expect(recommendation.MemoryLimitBytes).Should(Equal(int64(392 * mebibyte)))
+		})
+
+		It("Should not recommend before enough samples were collected", func() {
+			samples := loadUsageSamples("steady.csv")[:rightsizing.MinSamples-1]
+
+			_, ok := rightsizing.Recommend(samples)
+			Expect(ok).Should(BeFalse())
+		})
+
+		It("Should not recommend below the minimum resources", func() {
+			var samples []rightsizing.Sample
+			for i := 0; i < rightsizing.MinSamples; i++ {
+				samples = append(samples, rightsizing.Sample{
+					Time:        rightsizingStart.Add(time.Duration(i) * time.Minute),
+					CPUMilli:    1,
+					MemoryBytes: mebibyte,
+				})
+			}
+
+			recommendation, ok := rightsizing.Recommend(samples)
+			Expect(ok).Should(BeTrue())
+			Expect(recommendation.CPURequestMilli).Should(Equal(int64(10)))
+			Expect(recommendation.MemoryRequestBytes).Should(Equal(int64(32 * mebibyte)))
+		})
+	})
+
+	Context("When computing percentiles", func() {
+		It("Should use the nearest rank", func() {
+			values := []int64{50, 10, 40, 20, 30}
+			Expect(rightsizing.Percentile(values, 95)).Should(Equal(int64(50)))
+			Expect(rightsizing.Percentile(values, 50)).Should(Equal(int64(30)))
+			Expect(rightsizing.Percentile(values, 0)).Should(Equal(int64(10)))
+			Expect(rightsizing.Percentile(nil, 95)).Should(BeZero())
+		})
+	})
+
+	Context("When keeping a usage window", func() {
+		It("Should drop samples older than the window", func() {
+			window := &rightsizing.Window{Length: 10 * time.Minute}
+			for _, sample := range loadUsageSamples("steady.csv")[:15] {
+				window.Add(sample)
+			}
+
+			Expect(window.Samples()).Should(HaveLen(10))
+			Expect(window.Samples()[0].Time).Should(Equal(rightsizingStart.Add(5 * time.Minute)))
+			Expect(window.Last()).Should(Equal(rightsizingStart.Add(14 * time.Minute)))
+		})
+	})
+
+	Context("When comparing usage and recommendations", func() {
+		It("Should ignore changes within 10%", func() {
+			Expect(rightsizing.Differs(100, 110)).Should(BeFalse())
+			Expect(rightsizing.Differs(100, 90)).Should(BeFalse())
+			Expect(rightsizing.Differs(100, 111)).Should(BeTrue())
+			Expect(rightsizing.Differs(0, 10)).Should(BeTrue())
+		})
+
+		It("Should flag usage above twice the requests", func() {
+			Expect(rightsizing.Exceeds(201, 100, 2)).Should(BeTrue())
+			Expect(rightsizing.Exceeds(200, 100, 2)).Should(BeFalse())
+			Expect(rightsizing.Exceeds(500, 0, 2)).Should(BeFalse())
+		})
+	})
+})
@@ -0,0 +1,116 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Prometheus Scrape Annotations", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		request    ctrl.Request
+	)
+
+	newReconciler := func(serviceMonitorCRD bool, metrics *aiv1.MetricsConfig) *controllers.AgentReconciler {
+		scrapeScheme := newScheme()
+
+		mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "monitoring.coreos.com", Version: "v1"}})
+		if serviceMonitorCRD {
+			mapper.Add(schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}, meta.RESTScopeNamespace)
+		}
+		for gvk := range scrapeScheme.AllKnownTypes() {
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+		}
+
+		fakeClient = newFakeClientBuilder(scrapeScheme).
+			WithRESTMapper(mapper).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "scraped-agent", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					Metrics:      metrics,
+				},
+			}).
+			Build()
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "scraped-agent", Namespace: "default"}}
+		return &controllers.AgentReconciler{Client: fakeClient, Scheme: scrapeScheme}
+	}
+
+	podAnnotations := func(reconciler *controllers.AgentReconciler) map[string]string {
+		deployment := reconcileDeployment(ctx, reconciler, request)
+		return deployment.Spec.Template.Annotations
+	}
+
+	setMetrics := func(metrics *aiv1.MetricsConfig) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.Metrics = metrics
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should render the annotations from the metrics block", func() {
+		port := int32(9090)
+		reconciler := newReconciler(true, &aiv1.MetricsConfig{Enabled: true, Port: &port, Path: "/stats", ScrapeVia: "annotations"})
+
+		Expect(podAnnotations(reconciler)).Should(And(
+			HaveKeyWithValue("prometheus.io/scrape", "true"),
+			HaveKeyWithValue("prometheus.io/port", "9090"),
+			HaveKeyWithValue("prometheus.io/path", "/stats"),
+		))
+	})
+
+	It("Should fall back to the annotations without the ServiceMonitor CRD", func() {
+		Expect(podAnnotations(newReconciler(false, &aiv1.MetricsConfig{Enabled: true}))).Should(And(
+			HaveKeyWithValue("prometheus.io/scrape", "true"),
+			HaveKeyWithValue("prometheus.io/port", "8080"),
+			HaveKeyWithValue("prometheus.io/path", "/metrics"),
+		))
+		Expect(podAnnotations(newReconciler(true, &aiv1.MetricsConfig{Enabled: true}))).ShouldNot(HaveKey("prometheus.io/scrape"))
+	})
+
+	It("Should remove the annotations once disabled and keep the other annotations", func() {
+		reconciler := newReconciler(true, &aiv1.MetricsConfig{Enabled: true, ScrapeVia: "annotations"})
+		Expect(podAnnotations(reconciler)).Should(HaveKey("prometheus.io/scrape"))
+
+		By("Requesting a restart, which annotates the pod template")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Annotations = map[string]string{"kubeagentic.ai/restartedAt": "2026-10-17T10:00:00Z"}
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		Expect(podAnnotations(reconciler)).Should(And(
+			HaveKey("prometheus.io/scrape"),
+			HaveKeyWithValue("kubeagentic.ai/restartedAt", "2026-10-17T10:00:00Z"),
+		))
+
+		By("Disabling the metrics")
+		setMetrics(&aiv1.MetricsConfig{Enabled: false, ScrapeVia: "annotations"})
+		annotations := podAnnotations(reconciler)
+		Expect(annotations).ShouldNot(HaveKey("prometheus.io/scrape"))
+		Expect(annotations).ShouldNot(HaveKey("prometheus.io/port"))
+		Expect(annotations).ShouldNot(HaveKey("prometheus.io/path"))
+		Expect(annotations).Should(HaveKeyWithValue("kubeagentic.ai/restartedAt", "2026-10-17T10:00:00Z"))
+
+		By("Enabling them again")
+		setMetrics(&aiv1.MetricsConfig{Enabled: true, ScrapeVia: "annotations"})
+		Expect(podAnnotations(reconciler)).Should(HaveKeyWithValue("prometheus.io/scrape", "true"))
+	})
+})
@@ -0,0 +1,215 @@
+package test
+
+import (
+	"context"
+	"os"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	psaapi "k8s.io/pod-security-admission/api"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/scratch"
+)
+
+var _ = Describe("Scratch Volume", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newSpec := func() aiv1.AgentSpec {
+		return aiv1.AgentSpec{
+			Provider:     "openai",
+			Model:        "gpt-4o",
+			SystemPrompt: "You are a helpful AI assistant.",
+			ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"},
+				Key:                  "api-key",
+			}},
+		}
+	}
+
+	newReconciler := func(spec aiv1.AgentSpec) {
+		scheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(scheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "researcher", Namespace: "default"},
+					Spec:       spec,
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				},
+			).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "researcher", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	podSpec := func() corev1.PodSpec {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		return deployment.Spec.Template.Spec
+	}
+
+	agentEnv := func(container corev1.Container) map[string]string {
+		env := map[string]string{}
+		for _, e := range container.Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+
+	volume := func(spec corev1.PodSpec, name string) *corev1.Volume {
+		for i := range spec.Volumes {
+			if spec.Volumes[i].Name == name {
+				return &spec.Volumes[i]
+			}
+		}
+		return nil
+	}
+
+	configValid := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionConfigValid {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		previous, had := os.LookupEnv("MAX_SCRATCH_SIZE")
+		DeferCleanup(func() {
+			if had {
+				os.Setenv("MAX_SCRATCH_SIZE", previous)
+			} else {
+				os.Unsetenv("MAX_SCRATCH_SIZE")
+			}
+		})
+		os.Unsetenv("MAX_SCRATCH_SIZE")
+	})
+
+	Context("When rendering the agent container", func() {
+		It("Should bound the ephemeral storage by default", func() {
+			newReconciler(newSpec())
+			reconcile()
+			spec := podSpec()
+			resources := spec.Containers[0].Resources
+			Expect(resources.Requests.StorageEphemeral().String()).Should(Equal("256Mi"))
+			Expect(resources.Limits.StorageEphemeral().String()).Should(Equal("1Gi"))
+			Expect(volume(spec, scratch.VolumeName)).Should(BeNil())
+			Expect(agentEnv(spec.Containers[0])).ShouldNot(HaveKey("AGENT_TMP_DIR"))
+		})
+
+		It("Should keep the ephemeral storage of the user's resources", func() {
+			agentSpec := newSpec()
+			agentSpec.Resources = &corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{corev1.ResourceEphemeralStorage: resource.MustParse("2Gi")},
+				Limits:   corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1Gi")},
+			}
+			newReconciler(agentSpec)
+			reconcile()
+			resources := podSpec().Containers[0].Resources
+			Expect(resources.Requests.StorageEphemeral().String()).Should(Equal("2Gi"))
+			Expect(resources.Limits.StorageEphemeral().String()).Should(Equal("2Gi"))
+			Expect(resources.Limits.Memory().String()).Should(Equal("1Gi"))
+		})
+
+		It("Should mount a disk scratch volume and raise the default limit by its size", func() {
+			agentSpec := newSpec()
+			agentSpec.Scratch = &aiv1.ScratchConfig{SizeLimit: resource.MustParse("2Gi")}
+			newReconciler(agentSpec)
+			agent := reconcile()
+			Expect(configValid(agent).Status).Should(Equal(corev1.ConditionTrue))
+
+			spec := podSpec()
+			scratchVolume := volume(spec, scratch.VolumeName)
+			Expect(scratchVolume).ShouldNot(BeNil())
+			Expect(scratchVolume.EmptyDir.Medium).Should(Equal(corev1.StorageMediumDefault))
+			Expect(scratchVolume.EmptyDir.SizeLimit.String()).Should(Equal("2Gi"))
+			Expect(spec.Containers[0].VolumeMounts).Should(ContainElement(corev1.VolumeMount{Name: scratch.VolumeName, MountPath: scratch.MountPath}))
+			Expect(agentEnv(spec.Containers[0])).Should(HaveKeyWithValue("AGENT_TMP_DIR", scratch.MountPath))
+			Expect(spec.Containers[0].Resources.Limits.StorageEphemeral().String()).Should(Equal("3Gi"))
+		})
+
+		It("Should back a memory scratch volume with a tmpfs outside the ephemeral storage", func() {
+			agentSpec := newSpec()
+			agentSpec.Scratch = &aiv1.ScratchConfig{SizeLimit: resource.MustParse("128Mi"), Medium: scratch.MediumMemory}
+			newReconciler(agentSpec)
+			reconcile()
+			spec := podSpec()
+			Expect(volume(spec, scratch.VolumeName).EmptyDir.Medium).Should(Equal(corev1.StorageMediumMemory))
+			Expect(spec.Containers[0].Resources.Limits.StorageEphemeral().String()).Should(Equal("1Gi"))
+		})
+	})
+
+	Context("When the agent runs with the restricted security profile", func() {
+		It("Should keep the scratch volume writable next to the read-only root filesystem", func() {
+			agentSpec := newSpec()
+			agentSpec.SecurityProfile = "restricted"
+			agentSpec.Scratch = &aiv1.ScratchConfig{SizeLimit: resource.MustParse("1Gi")}
+			newReconciler(agentSpec)
+			reconcile()
+
+			spec := podSpec()
+			container := spec.Containers[0]
+			Expect(*container.SecurityContext.ReadOnlyRootFilesystem).Should(BeTrue())
+			mountPaths := map[string]string{}
+			for _, mount := range container.VolumeMounts {
+				Expect(mountPaths).ShouldNot(HaveKey(mount.MountPath), mount.Name)
+				mountPaths[mount.MountPath] = mount.Name
+			}
+			Expect(mountPaths).Should(HaveKeyWithValue(scratch.MountPath, scratch.VolumeName))
+			Expect(mountPaths).Should(HaveKeyWithValue("/tmp", "tmp"))
+			Expect(agentEnv(container)).Should(HaveKeyWithValue("AGENT_TMP_DIR", scratch.MountPath))
+			Expect(evaluatePodSecurity(psaapi.LevelRestricted, &spec).Allowed).Should(BeTrue())
+		})
+	})
+
+	Context("When validating the scratch volume", func() {
+		It("Should reject sizes above the maximum of the operator", func() {
+			agentSpec := newSpec()
+			agentSpec.Scratch = &aiv1.ScratchConfig{SizeLimit: resource.MustParse("20Gi")}
+			errs := scratch.Validate(&agentSpec)
+			Expect(errs).Should(HaveLen(1))
+			Expect(errs[0].Field).Should(Equal("spec.scratch.sizeLimit"))
+			Expect(errs[0].Detail).Should(ContainSubstring("must not exceed 10Gi"))
+
+			os.Setenv("MAX_SCRATCH_SIZE", "50Gi")
+			Expect(scratch.Validate(&agentSpec)).Should(BeEmpty())
+
+			agentSpec.Scratch.SizeLimit = resource.MustParse("0")
+			Expect(scratch.Validate(&agentSpec)).Should(HaveLen(1))
+		})
+
+		It("Should report oversized scratch volumes on the agent", func() {
+			os.Setenv("MAX_SCRATCH_SIZE", "1Gi")
+			agentSpec := newSpec()
+			agentSpec.Scratch = &aiv1.ScratchConfig{SizeLimit: resource.MustParse("2Gi")}
+			newReconciler(agentSpec)
+			agent := reconcile()
+			Expect(configValid(agent).Status).Should(Equal(corev1.ConditionFalse))
+			Expect(configValid(agent).Reason).Should(Equal("InvalidScratchConfig"))
+			Expect(configValid(agent).Message).Should(ContainSubstring("must not exceed 1Gi"))
+		})
+	})
+})
@@ -0,0 +1,274 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/naming"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/provider"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/secretref"
+)
+
+var _ = Describe("Secret References at Admission", func() {
+	var (
+		ctx        context.Context
+		scheme     *runtime.Scheme
+		agentSpec  *aiv1.AgentSpec
+		openaiKeys *corev1.Secret
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		scheme = runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(scheme)).Should(Succeed())
+		agentSpec = &aiv1.AgentSpec{
+			Provider:     "openai",
+			Model:        "gpt-4",
+			ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+			Connectors: []aiv1.AgentConnector{
+				{Name: "slack", Type: "slack", CredentialsSecretRef: corev1.LocalObjectReference{Name: "slack-bot"}},
+			},
+		}
+		openaiKeys = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "team-a"},
+			Data:       map[string][]byte{"api-key": []byte("sk-test")},
+		}
+	})
+
+	missing := func(reader client.Reader) field.ErrorList {
+		return secretref.Missing(ctx, reader, secretref.Refs("team-a", agentSpec))
+	}
+
+	It("Should list the referenced Secrets with their fields", func() {
+		agentSpec.ApiSecretRef.Namespace = "llm-credentials"
+		agentSpec.Memory = &aiv1.MemoryConfig{ConnectionSecretRef: &corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "redis"}, Key: "url"}}
+
+		refs := secretref.Refs("team-a", agentSpec)
+		Expect(refs).Should(HaveLen(3))
+		Expect(refs[0]).Should(Equal(secretref.Ref{Path: field.NewPath("spec", "apiSecretRef"), Namespace: "llm-credentials", Name: "openai-secret", Key: "api-key"}))
+		Expect(refs[1]).Should(Equal(secretref.Ref{Path: field.NewPath("spec", "memory", "connectionSecretRef"), Namespace: "team-a", Name: "redis", Key: "url"}))
+		Expect(refs[2]).Should(Equal(secretref.Ref{Path: field.NewPath("spec", "connectors").Index(0).Child("credentialsSecretRef"), Namespace: "team-a", Name: "slack-bot"}))
+	})
+
+	It("Should accept an agent whose Secrets exist", func() {
+		slack := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "slack-bot", Namespace: "team-a"}}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(openaiKeys, slack).Build()
+
+		Expect(missing(reader)).Should(BeEmpty())
+	})
+
+	It("Should report the missing Secrets and keys", func() {
+		openaiKeys.Data = map[string][]byte{"apikey": []byte("sk-test")}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(openaiKeys).Build()
+
+		errs := missing(reader)
+		Expect(errs).Should(HaveLen(2))
+		Expect(errs[0].Type).Should(Equal(field.ErrorTypeInvalid))
+		Expect(errs[0].Field).Should(Equal("spec.apiSecretRef.key"))
+		Expect(errs[0].Error()).Should(ContainSubstring("secret openai-secret in namespace team-a has no key api-key"))
+		Expect(errs[1].Type).Should(Equal(field.ErrorTypeNotFound))
+		Expect(errs[1].Field).Should(Equal("spec.connectors[0].credentialsSecretRef"))
+		Expect(errs[1].Error()).Should(ContainSubstring("secret slack-bot in namespace team-a"))
+	})
+
+	It("Should read a Secret referenced twice once", func() {
+		agentSpec.Connectors = nil
+		agentSpec.RAG = &aiv1.RAGConfig{Embeddings: &aiv1.EmbeddingsConfig{ApiSecretRef: agentSpec.ApiSecretRef.DeepCopy()}}
+		gets := 0
+		reader := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme).Build(), interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				gets++
+				return c.Get(ctx, key, obj, opts...)
+			},
+		})
+
+		errs := missing(reader)
+		Expect(errs).Should(HaveLen(2))
+		Expect(errs[1].Field).Should(Equal("spec.rag.embeddings.apiSecretRef"))
+		Expect(gets).Should(Equal(1))
+	})
+
+	It("Should fail open when the Secrets cannot be read", func() {
+		reader := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme).Build(), interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				return errors.New("connection refused")
+			},
+		})
+
+		Expect(missing(reader)).Should(BeEmpty())
+	})
+
+	It("Should give up on a slow lookup after the timeout", func() {
+		reader := interceptor.NewClient(fake.NewClientBuilder().WithScheme(scheme).Build(), interceptor.Funcs{
+			Get: func(ctx context.Context, c client.WithWatch, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		})
+
+		start := time.Now()
+		Expect(missing(reader)).Should(BeEmpty())
+		Expect(time.Since(start)).Should(BeNumerically("<", secretref.LookupTimeout+time.Second))
+	})
+
+	It("Should only be strict when the operator is configured so", func() {
+		DeferCleanup(os.Unsetenv, "STRICT_SECRET_REFERENCES")
+		Expect(secretref.Strict()).Should(BeFalse())
+		Expect(os.Setenv("STRICT_SECRET_REFERENCES", "true")).Should(Succeed())
+		Expect(secretref.Strict()).Should(BeTrue())
+	})
+})
+
+var _ = Describe("Secretless Providers", func() {
+	It("Should only require an API secret for hosted providers or without a custom endpoint", func() {
+		Expect(provider.RequiresAPISecret("claude", "")).Should(BeTrue())
+		Expect(provider.RequiresAPISecret("gemini", "https://proxy.internal")).Should(BeTrue())
+		Expect(provider.RequiresAPISecret("openai", "")).Should(BeTrue())
+		Expect(provider.RequiresAPISecret("ollama", "")).Should(BeTrue())
+		Expect(provider.RequiresAPISecret("openai", "http://litellm:4000")).Should(BeFalse())
+		Expect(provider.RequiresAPISecret("vllm", "http://vllm:8000")).Should(BeFalse())
+		Expect(provider.RequiresAPISecret("ollama", "http://ollama:11434")).Should(BeFalse())
+	})
+})
+
+// admissionRequestKey keys a value of the admission context, to check that the webhook reads
+// the cluster within it.
+type admissionRequestKey struct{}
+
+var _ = Describe("Cross-namespace Secret Grants", func() {
+	grant := func(namespace string, consumers ...string) aiv1.AgentPolicy {
+		return aiv1.AgentPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "shared-credentials", Namespace: namespace},
+			Spec:       aiv1.AgentPolicySpec{SecretConsumerNamespaces: consumers},
+		}
+	}
+
+	condition := func(agent *aiv1.Agent, conditionType aiv1.AgentConditionType) *aiv1.AgentCondition {
+		for i := range agent.Status.Conditions {
+			if agent.Status.Conditions[i].Type == conditionType {
+				return &agent.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	It("Should only take grants from the AgentPolicies of the source namespace", func() {
+		Expect(secretref.NamespaceAllowed("team-a", "team-a", nil)).Should(BeTrue())
+		Expect(secretref.NamespaceAllowed("team-a", "llm-credentials", nil)).Should(BeFalse())
+		Expect(secretref.NamespaceAllowed("team-a", "llm-credentials", []aiv1.AgentPolicy{grant("llm-credentials", "team-a")})).Should(BeTrue())
+		Expect(secretref.NamespaceAllowed("team-b", "llm-credentials", []aiv1.AgentPolicy{grant("llm-credentials", "team-a")})).Should(BeFalse())
+
+		By("Ignoring the policies the consuming namespace grants itself with")
+		Expect(secretref.NamespaceAllowed("team-a", "llm-credentials", []aiv1.AgentPolicy{grant("team-a", "team-a", "llm-credentials")})).Should(BeFalse())
+	})
+
+	It("Should fail an agent whose own namespace grants it the Secrets of another", func() {
+		ctx := context.Background()
+		scheme := newScheme()
+		selfGrant := grant("team-a", "team-a")
+		fakeClient := newFakeClientBuilder(scheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "team-a"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "openai",
+						Model:        "gpt-4",
+						SystemPrompt: "You are a helpful AI assistant.",
+						ApiSecretRef: &aiv1.SecretKeyReference{
+							SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai"}, Key: "api-key"},
+							Namespace:         "llm-credentials",
+						},
+					},
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "openai", Namespace: "llm-credentials"},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				},
+				&selfGrant,
+			).
+			Build()
+		reconciler := &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+		request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "team-a"}}
+
+		agent := reconcileAgent(ctx, reconciler, request)
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		secretValid := condition(agent, aiv1.AgentConditionSecretValid)
+		Expect(secretValid.Status).Should(Equal(corev1.ConditionFalse))
+		Expect(secretValid.Reason).Should(Equal("SecretNamespaceNotAllowed"))
+		Expect(apierrors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "support-api-key", Namespace: "team-a"}, &corev1.Secret{}))).Should(BeTrue())
+
+		By("Granting the namespace from the source namespace")
+		sourceGrant := grant("llm-credentials", "team-a")
+		Expect(fakeClient.Create(ctx, &sourceGrant)).Should(Succeed())
+		agent = reconcileAgent(ctx, reconciler, request)
+		Expect(condition(agent, aiv1.AgentConditionSecretValid).Status).Should(Equal(corev1.ConditionTrue))
+		synced := &corev1.Secret{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "support-api-key", Namespace: "team-a"}, synced)).Should(Succeed())
+		Expect(synced.Data).Should(HaveKeyWithValue("api-key", []byte("sk-test")))
+	})
+
+	It("Should reject a self-granted Secret at admission with the reader of the webhook", func() {
+		selfGrant := grant("team-a", "team-a")
+		readerClient := newFakeClientBuilder(newScheme()).
+			WithObjects(&selfGrant).
+			WithIndex(&aiv1.Agent{}, naming.ResourceNameIndex, func(obj client.Object) []string {
+				agent := obj.(*aiv1.Agent)
+				return naming.IndexValues(naming.Names(agent.Name, agent.Spec.NameOverrides))
+			}).
+			Build()
+		// The contexts the webhook lists with, to check that it reads within the admission request
+		var listContexts []context.Context
+		validator := &webhookv1.AgentWebhook{Reader: interceptor.NewClient(readerClient, interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				listContexts = append(listContexts, ctx)
+				return c.List(ctx, list, opts...)
+			},
+		})}
+		agent := &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "team-a"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "openai",
+				Model:        "gpt-4",
+				SystemPrompt: "You are a helpful AI assistant.",
+				ApiSecretRef: &aiv1.SecretKeyReference{
+					SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai"}, Key: "api-key"},
+					Namespace:         "llm-credentials",
+				},
+			},
+		}
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), admissionRequestKey{}, "admission"))
+		defer cancel()
+
+		_, err := validator.ValidateCreate(ctx, agent)
+		Expect(err).Should(MatchError(ContainSubstring("secretConsumerNamespaces of an AgentPolicy of namespace llm-credentials")))
+		Expect(listContexts).ShouldNot(BeEmpty())
+		for _, listContext := range listContexts {
+			Expect(listContext.Value(admissionRequestKey{})).Should(Equal("admission"))
+		}
+
+		By("Admitting the agent once the source namespace grants it")
+		sourceGrant := grant("llm-credentials", "team-a")
+		Expect(readerClient.Create(ctx, &sourceGrant)).Should(Succeed())
+		_, err = validator.ValidateCreate(ctx, agent)
+		Expect(err).ShouldNot(HaveOccurred())
+	})
+})
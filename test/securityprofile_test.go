@@ -0,0 +1,103 @@
+package test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	psaapi "k8s.io/pod-security-admission/api"
+	"k8s.io/pod-security-admission/policy"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/securityprofile"
+)
+
+// evaluatePodSecurity runs the upstream Pod Security Admission checks of level on spec.
+func evaluatePodSecurity(level psaapi.Level, spec *corev1.PodSpec) policy.AggregateCheckResult {
+	evaluator, err := policy.NewEvaluator(policy.DefaultChecks())
+	Expect(err).ShouldNot(HaveOccurred())
+	results := evaluator.EvaluatePod(psaapi.LevelVersion{Level: level, Version: psaapi.LatestVersion()}, &metav1.ObjectMeta{}, spec)
+	return policy.AggregateCheckResults(results)
+}
+
+var _ = Describe("Security Profile", func() {
+	newPodSpec := func() *corev1.PodSpec {
+		return &corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init", Image: "busybox"}},
+			Containers: []corev1.Container{
+				{Name: "agent", Image: "kubeagentic/agent:latest"},
+				{Name: "sidecar", Image: "envoyproxy/envoy:v1.29-latest"},
+			},
+			Volumes: []corev1.Volume{
+				{Name: "config", VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{}}},
+			},
+		}
+	}
+
+	Context("When applying the restricted profile", func() {
+		It("Should pass the upstream restricted level", func() {
+			spec := newPodSpec()
+			Expect(evaluatePodSecurity(psaapi.LevelRestricted, spec).Allowed).Should(BeFalse())
+
+			securityprofile.Apply(securityprofile.Restricted, spec, 1001, "/data")
+
+			result := evaluatePodSecurity(psaapi.LevelRestricted, spec)
+			Expect(result.Allowed).Should(BeTrue(), result.ForbiddenDetail())
+			Expect(securityprofile.Violations(securityprofile.Restricted, spec)).Should(BeEmpty())
+		})
+
+		It("Should back /tmp and the writable paths with emptyDirs", func() {
+			spec := newPodSpec()
+			securityprofile.Apply(securityprofile.Restricted, spec, 1001, "/data")
+
+			for _, container := range append(spec.InitContainers, spec.Containers...) {
+				Expect(*container.SecurityContext.ReadOnlyRootFilesystem).Should(BeTrue())
+				var paths []string
+				for _, mount := range container.VolumeMounts {
+					paths = append(paths, mount.MountPath)
+				}
+				Expect(paths).Should(ConsistOf("/tmp", "/data"))
+			}
+			Expect(*spec.SecurityContext.RunAsUser).Should(Equal(int64(1001)))
+		})
+
+		It("Should leave pods unchanged for other profiles", func() {
+			for _, profile := range []string{"", securityprofile.Baseline} {
+				spec := newPodSpec()
+				securityprofile.Apply(profile, spec, 1001)
+				Expect(spec).Should(Equal(newPodSpec()))
+			}
+		})
+	})
+
+	Context("When checking pods against a profile", func() {
+		It("Should reject a privileged sidecar under both levels", func() {
+			privileged := true
+			for _, profile := range []string{securityprofile.Baseline, securityprofile.Restricted} {
+				spec := newPodSpec()
+				securityprofile.Apply(profile, spec, 1001)
+				spec.Containers[1].SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+
+				Expect(securityprofile.Violations(profile, spec)).Should(ContainElement("container sidecar must not be privileged"))
+				Expect(evaluatePodSecurity(psaapi.Level(profile), spec).Allowed).Should(BeFalse())
+			}
+		})
+
+		It("Should reject hostPath volumes", func() {
+			spec := newPodSpec()
+			spec.Volumes = append(spec.Volumes, corev1.Volume{
+				Name:         "model-cache",
+				VolumeSource: corev1.VolumeSource{HostPath: &corev1.HostPathVolumeSource{Path: "/var/cache/models"}},
+			})
+
+			Expect(securityprofile.Violations(securityprofile.Baseline, spec)).Should(ContainElement("volume model-cache must not use a hostPath"))
+			Expect(evaluatePodSecurity(psaapi.LevelBaseline, spec).Allowed).Should(BeFalse())
+			Expect(securityprofile.Violations("", spec)).Should(BeEmpty())
+		})
+
+		It("Should agree with the upstream baseline level on unhardened pods", func() {
+			spec := newPodSpec()
+			Expect(securityprofile.Violations(securityprofile.Baseline, spec)).Should(BeEmpty())
+			Expect(evaluatePodSecurity(psaapi.LevelBaseline, spec).Allowed).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,124 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/sizing"
+)
+
+var _ = Describe("Size Presets", func() {
+	resources := func(requestCPU, requestMemory, limitCPU, limitMemory string) corev1.ResourceRequirements {
+		return corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(requestCPU),
+				corev1.ResourceMemory: resource.MustParse(requestMemory),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse(limitCPU),
+				corev1.ResourceMemory: resource.MustParse(limitMemory),
+			},
+		}
+	}
+
+	AfterEach(func() {
+		sizing.Set(nil)
+	})
+
+	It("Should define the embedded presets of each size and provider class", func() {
+		for _, preset := range []struct {
+			provider, size string
+			expected       corev1.ResourceRequirements
+		}{
+			{"openai", "small", resources("100m", "256Mi", "200m", "512Mi")},
+			{"claude", "medium", resources("250m", "512Mi", "500m", "1Gi")},
+			{"gemini", "large", resources("500m", "1Gi", "1", "2Gi")},
+			{"openai", "xlarge", resources("1", "2Gi", "2", "4Gi")},
+			{"vllm", "small", resources("250m", "512Mi", "500m", "1Gi")},
+			{"vllm", "medium", resources("500m", "1Gi", "1", "2Gi")},
+			{"ollama", "large", resources("1", "2Gi", "2", "4Gi")},
+			{"vllm", "xlarge", resources("2", "4Gi", "4", "8Gi")},
+		} {
+			actual, ok := sizing.For(preset.provider, preset.size)
+			Expect(ok).Should(BeTrue(), preset.provider+"/"+preset.size)
+			Expect(equality.Semantic.DeepEqual(actual, preset.expected)).Should(BeTrue(), preset.provider+"/"+preset.size)
+		}
+		_, ok := sizing.For("openai", "huge")
+		Expect(ok).Should(BeFalse())
+	})
+
+	It("Should reject resources set together with a size", func() {
+		preset, _ := sizing.For("openai", "medium")
+		Expect(sizing.Conflicts("openai", "medium", nil)).Should(BeFalse())
+		Expect(sizing.Conflicts("openai", "medium", &preset)).Should(BeFalse())
+
+		explicit := resources("300m", "768Mi", "600m", "1Gi")
+		Expect(sizing.Conflicts("openai", "medium", &explicit)).Should(BeTrue())
+		Expect(sizing.IsPreset(&explicit)).Should(BeFalse())
+
+		By("Treating the resources of another preset as set from the size")
+		Expect(sizing.IsPreset(&preset)).Should(BeTrue())
+		Expect(sizing.Conflicts("vllm", "medium", &preset)).Should(BeTrue())
+	})
+
+	It("Should replace the presets of the operator configuration size by size", func() {
+		overrides, err := sizing.Parse([]byte(`
+hosted:
+  small:
+    requests: {cpu: 50m, memory: 128Mi}
+    limits: {cpu: 100m, memory: 256Mi}
+`))
+		Expect(err).ShouldNot(HaveOccurred())
+		sizing.Set(sizing.Merge(overrides))
+
+		small, _ := sizing.For("openai", "small")
+		Expect(small.Requests.Memory().String()).Should(Equal("128Mi"))
+		large, _ := sizing.For("openai", "large")
+		Expect(large.Requests.Memory().String()).Should(Equal("1Gi"))
+
+		_, err = sizing.Parse([]byte("hosted:\n  huge:\n    requests: {cpu: \"8\"}\n"))
+		Expect(err).Should(HaveOccurred())
+		_, err = sizing.Parse([]byte("hosted:\n  small:\n    requests: {cpu: \"2\"}\n    limits: {cpu: \"1\"}\n"))
+		Expect(err).Should(HaveOccurred())
+	})
+
+	It("Should run the agent container with the preset of its size", func() {
+		ctx := context.Background()
+		sizeScheme := newScheme()
+
+		fakeClient := newFakeClientBuilder(sizeScheme).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "sized-agent", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					Size:         "large",
+				},
+			}).
+			Build()
+
+		reconciler := &controllers.AgentReconciler{Client: fakeClient, Scheme: sizeScheme}
+		request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "sized-agent", Namespace: "default"}}
+		_, err := reconciler.Reconcile(ctx, request)
+		Expect(err).ShouldNot(HaveOccurred())
+
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		container := deployment.Spec.Template.Spec.Containers[0]
+		Expect(container.Resources.Requests.Memory().String()).Should(Equal("2Gi"))
+		Expect(container.Resources.Limits.Cpu().String()).Should(Equal("2"))
+	})
+})
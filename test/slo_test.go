@@ -0,0 +1,327 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/slo"
+)
+
+var _ = Describe("SLO", func() {
+	Context("Generating the rules", func() {
+		It("Should generate the availability rules and alerts", func() {
+			groups, err := slo.Groups(slo.Objectives{Agent: "support", Namespace: "default", Target: "99.9"})
+			Expect(err).ShouldNot(HaveOccurred())
+			data, err := yaml.Marshal(groups)
+			Expect(err).ShouldNot(HaveOccurred())
+			expectGoldenIn("slo", "availability.yaml", data)
+		})
+
+		It("Should generate the latency rules and alerts over a custom window", func() {
+			groups, err := slo.Groups(slo.Objectives{
+				Agent:         "support",
+				Namespace:     "default",
+				Target:        "99.5",
+				LatencyTarget: 2500 * time.Millisecond,
+				Window:        28 * 24 * time.Hour,
+			})
+			Expect(err).ShouldNot(HaveOccurred())
+			data, err := yaml.Marshal(groups)
+			Expect(err).ShouldNot(HaveOccurred())
+			expectGoldenIn("slo", "latency.yaml", data)
+		})
+
+		It("Should reject targets and windows the alerts cannot use", func() {
+			for _, target := range []string{"100", "0", "abc", "1e2", "99/100"} {
+				_, err := slo.Groups(slo.Objectives{Agent: "support", Namespace: "default", Target: target})
+				Expect(err).Should(HaveOccurred(), "target %s", target)
+			}
+			_, err := slo.Groups(slo.Objectives{Agent: "support", Namespace: "default", Target: "99.9", Window: 24 * time.Hour})
+			Expect(err).Should(MatchError(ContainSubstring("must be between")))
+		})
+
+		It("Should name the latency bucket as the runtime does", func() {
+			Expect(slo.LatencyBucket(300 * time.Millisecond)).Should(Equal("0.3"))
+			Expect(slo.LatencyBucket(2 * time.Second)).Should(Equal("2.0"))
+			Expect(slo.LatencyBucket(1250 * time.Millisecond)).Should(Equal("1.25"))
+		})
+	})
+
+	Context("Reconciling the SLOs", func() {
+		var (
+			ctx        context.Context
+			fakeClient client.Client
+			reconciler *controllers.AgentReconciler
+			request    ctrl.Request
+			clock      *clocktesting.FakePassiveClock
+			prometheus *httptest.Server
+			budgets    map[string]string
+			queries    []string
+		)
+		start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+		prometheusRuleGVK := schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+		ruleName := types.NamespacedName{Name: "support-slo", Namespace: "default"}
+
+		newReconciler := func(prometheusRuleCRD bool, config *aiv1.SLOConfig) {
+			scheme := newScheme()
+
+			mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "monitoring.coreos.com", Version: "v1"}})
+			if prometheusRuleCRD {
+				mapper.Add(prometheusRuleGVK, meta.RESTScopeNamespace)
+			}
+			for gvk := range scheme.AllKnownTypes() {
+				mapper.Add(gvk, meta.RESTScopeNamespace)
+			}
+
+			fakeClient = fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithRESTMapper(mapper).
+				WithStatusSubresource(&aiv1.Agent{}, &appsv1.Deployment{}).
+				WithObjects(&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "vllm",
+						Model:        "llama-3-8b",
+						SystemPrompt: "You are a helpful AI assistant.",
+						Endpoint:     "http://vllm.default.svc:8000/v1",
+						SLO:          config,
+					},
+				}).
+				Build()
+			clock = clocktesting.NewFakePassiveClock(start)
+			reconciler = &controllers.AgentReconciler{
+				Client:        fakeClient,
+				Scheme:        scheme,
+				Clock:         clock,
+				PrometheusURL: prometheus.URL,
+				SLOQuerier:    &slo.Querier{Client: prometheus.Client()},
+			}
+			request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+		}
+
+		configured := func() *aiv1.SLOConfig {
+			latency := int32(3000)
+			return &aiv1.SLOConfig{AvailabilityTarget: "99.9", LatencyTargetMs: &latency}
+		}
+
+		reconcile := func() *aiv1.Agent {
+			return reconcileAgent(ctx, reconciler, request)
+		}
+
+		update := func(mutate func(*aiv1.Agent)) {
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			mutate(agent)
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		}
+
+		rule := func() (*unstructured.Unstructured, error) {
+			obj := &unstructured.Unstructured{}
+			obj.SetGroupVersionKind(prometheusRuleGVK)
+			return obj, fakeClient.Get(ctx, ruleName, obj)
+		}
+
+		// expressions returns the expressions of the rules, keyed by the recorded metric or alert
+		expressions := func(obj *unstructured.Unstructured) map[string]string {
+			groups, found, err := unstructured.NestedSlice(obj.Object, "spec", "groups")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(found).Should(BeTrue())
+			exprs := map[string]string{}
+			for _, group := range groups {
+				for _, r := range group.(map[string]interface{})["rules"].([]interface{}) {
+					r := r.(map[string]interface{})
+					name, _ := r["record"].(string)
+					if alert, ok := r["alert"].(string); ok {
+						name = alert
+					}
+					exprs[name] = r["expr"].(string)
+				}
+			}
+			return exprs
+		}
+
+		condition := func(agent *aiv1.Agent) *aiv1.AgentCondition {
+			for i := range agent.Status.Conditions {
+				if agent.Status.Conditions[i].Type == aiv1.AgentConditionSLOCompliant {
+					return &agent.Status.Conditions[i]
+				}
+			}
+			return nil
+		}
+
+		BeforeEach(func() {
+			ctx = context.Background()
+			budgets = map[string]string{
+				slo.AvailabilityBudgetMetric: "0.625",
+				slo.LatencyBudgetMetric:      "0.9",
+			}
+			queries = nil
+			prometheus = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query().Get("query")
+				queries = append(queries, query)
+				result := []interface{}{}
+				for metric, value := range budgets {
+					if strings.HasPrefix(query, metric+"{") {
+						result = append(result, map[string]interface{}{
+							"metric": map[string]string{"agent": "support", "namespace": "default"},
+							"value":  []interface{}{1772366400, value},
+						})
+					}
+				}
+				w.Header().Set("Content-Type", "application/json")
+				Expect(json.NewEncoder(w).Encode(map[string]interface{}{
+					"status": "success",
+					"data":   map[string]interface{}{"resultType": "vector", "result": result},
+				})).Should(Succeed())
+			}))
+			DeferCleanup(prometheus.Close)
+		})
+
+		It("Should generate the rules and report the budgets left", func() {
+			newReconciler(true, configured())
+			agent := reconcile()
+
+			obj, err := rule()
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(obj.GetLabels()).Should(HaveKeyWithValue("kubeagentic.ai/agent", "support"))
+			Expect(obj.GetOwnerReferences()).Should(HaveLen(1))
+			exprs := expressions(obj)
+			Expect(exprs).Should(HaveKey("KubeAgenticAvailabilityBudgetBurnFast"))
+			Expect(exprs).Should(HaveKey("KubeAgenticLatencyBudgetBurnSlow"))
+			Expect(exprs["kubeagentic:slo_latency_errors:ratio_rate5m"]).Should(ContainSubstring(`le="3.0"`))
+			Expect(exprs["KubeAgenticAvailabilityBudgetBurnFast"]).Should(ContainSubstring("> (14.4 * 0.001)"))
+
+			compliant := condition(agent)
+			Expect(compliant).ShouldNot(BeNil())
+			Expect(compliant.Status).Should(Equal(corev1.ConditionTrue))
+			Expect(compliant.Reason).Should(Equal("WithinBudget"))
+			Expect(compliant.Message).Should(Equal("62.50% of the availability error budget is left over 30d, 90.00% of the latency error budget"))
+			Expect(agent.Status.SLO).ShouldNot(BeNil())
+			Expect(agent.Status.SLO.AvailabilityBudgetRemaining).Should(Equal("62.50"))
+			Expect(agent.Status.SLO.LatencyBudgetRemaining).Should(Equal("90.00"))
+			Expect(agent.Status.SLO.LastEvaluationTime.Time).Should(BeTemporally("==", start))
+			Expect(queries).Should(ContainElement(`kubeagentic:slo_availability_budget_remaining:ratio{agent="support",namespace="default"}`))
+
+			By("Adding the bucket of the latency target to the runtime histogram")
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			Expect(deployment.Spec.Template.Spec.Containers[0].Env).Should(ContainElement(corev1.EnvVar{Name: "AGENT_LATENCY_BUCKET_SECONDS", Value: "3.0"}))
+		})
+
+		It("Should update the rules when the targets change and delete them with the block", func() {
+			newReconciler(true, configured())
+			reconcile()
+
+			update(func(agent *aiv1.Agent) {
+				agent.Spec.SLO.AvailabilityTarget = "99"
+				agent.Spec.SLO.LatencyTargetMs = nil
+			})
+			reconcile()
+			obj, err := rule()
+			Expect(err).ShouldNot(HaveOccurred())
+			exprs := expressions(obj)
+			Expect(exprs["KubeAgenticAvailabilityBudgetBurnFast"]).Should(ContainSubstring("> (14.4 * 0.01)"))
+			Expect(exprs).ShouldNot(HaveKey("KubeAgenticLatencyBudgetBurnFast"))
+
+			update(func(agent *aiv1.Agent) { agent.Spec.SLO = nil })
+			agent := reconcile()
+			_, err = rule()
+			Expect(errors.IsNotFound(err)).Should(BeTrue())
+			Expect(condition(agent)).Should(BeNil())
+			Expect(agent.Status.SLO).Should(BeNil())
+		})
+
+		It("Should report an exhausted error budget, and query it again after the interval", func() {
+			budgets[slo.LatencyBudgetMetric] = "-0.2"
+			newReconciler(true, configured())
+			agent := reconcile()
+
+			compliant := condition(agent)
+			Expect(compliant).ShouldNot(BeNil())
+			Expect(compliant.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(compliant.Reason).Should(Equal("ErrorBudgetExhausted"))
+			Expect(compliant.Message).Should(Equal("The error budget of the latency SLO is exhausted over 30d"))
+			Expect(agent.Status.SLO.LatencyBudgetRemaining).Should(Equal("-20.00"))
+			Expect(agent.Status.Phase).ShouldNot(Equal(aiv1.AgentPhaseFailed))
+
+			By("Not querying Prometheus again before the interval")
+			queried := len(queries)
+			budgets[slo.LatencyBudgetMetric] = "0.1"
+			clock.SetTime(start.Add(time.Minute))
+			reconcile()
+			Expect(queries).Should(HaveLen(queried))
+
+			clock.SetTime(start.Add(6 * time.Minute))
+			agent = reconcile()
+			Expect(condition(agent).Status).Should(Equal(corev1.ConditionTrue))
+		})
+
+		It("Should report Unknown without data, Prometheus or rules", func() {
+			budgets = map[string]string{slo.AvailabilityBudgetMetric: "NaN"}
+			newReconciler(true, configured())
+			agent := reconcile()
+			Expect(condition(agent).Status).Should(Equal(corev1.ConditionUnknown))
+			Expect(condition(agent).Reason).Should(Equal("NoData"))
+
+			By("Reporting an unreachable Prometheus")
+			reconciler.PrometheusURL = "http://127.0.0.1:1"
+			reconciler.SLOQuerier = nil
+			clock.SetTime(start.Add(6 * time.Minute))
+			agent = reconcile()
+			Expect(condition(agent).Status).Should(Equal(corev1.ConditionUnknown))
+			Expect(condition(agent).Reason).Should(Equal("PrometheusUnreachable"))
+
+			By("Reporting a missing Prometheus URL")
+			reconciler.PrometheusURL = ""
+			agent = reconcile()
+			Expect(condition(agent).Status).Should(Equal(corev1.ConditionUnknown))
+			Expect(condition(agent).Reason).Should(Equal("PrometheusNotConfigured"))
+
+			By("Reporting a cluster without the PrometheusRule CRD")
+			newReconciler(false, configured())
+			agent = reconcile()
+			Expect(condition(agent).Status).Should(Equal(corev1.ConditionUnknown))
+			Expect(condition(agent).Reason).Should(Equal("RulesNotInstalled"))
+		})
+
+		It("Should reject an invalid window", func() {
+			config := configured()
+			config.Window = &metav1.Duration{Duration: 24 * time.Hour}
+			newReconciler(true, config)
+			agent := reconcile()
+
+			var configValid *aiv1.AgentCondition
+			for i := range agent.Status.Conditions {
+				if agent.Status.Conditions[i].Type == aiv1.AgentConditionConfigValid {
+					configValid = &agent.Status.Conditions[i]
+				}
+			}
+			Expect(configValid).ShouldNot(BeNil())
+			Expect(configValid.Status).Should(Equal(corev1.ConditionFalse))
+			Expect(configValid.Reason).Should(Equal("InvalidSLOConfig"))
+			Expect(configValid.Message).Should(ContainSubstring("slo.window"))
+		})
+	})
+})
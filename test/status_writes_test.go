@@ -0,0 +1,156 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Status Writes", func() {
+	var (
+		ctx          context.Context
+		fakeClient   client.Client
+		reconciler   *controllers.AgentReconciler
+		request      ctrl.Request
+		statusWrites int
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		statusScheme := newScheme()
+
+		statusWrites = 0
+		fakeClient = newFakeClientBuilder(statusScheme).
+			WithObjects(
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "status-secret", Namespace: "default"},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				},
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "status-agent", Namespace: "default"},
+					Spec: aiv1.AgentSpec{
+						Provider:     "openai",
+						Model:        "gpt-4",
+						SystemPrompt: "You are a helpful AI assistant.",
+						ApiSecretRef: &aiv1.SecretKeyReference{
+							SecretKeySelector: corev1.SecretKeySelector{
+								LocalObjectReference: corev1.LocalObjectReference{Name: "status-secret"},
+								Key:                  "api-key",
+							},
+						},
+					},
+				},
+			).
+			WithInterceptorFuncs(interceptor.Funcs{
+				SubResourceUpdate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+					if _, ok := obj.(*aiv1.Agent); ok && subResource == "status" {
+						statusWrites++
+					}
+					return c.SubResource(subResource).Update(ctx, obj, opts...)
+				},
+			}).
+			Build()
+
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: statusScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "status-agent", Namespace: "default"}}
+	})
+
+	Context("When nothing changes between reconciles", func() {
+		It("Should not write the status again", func() {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(statusWrites).Should(BeNumerically(">", 0))
+
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			lastUpdated := agent.Status.LastUpdated.DeepCopy()
+
+			statusWrites = 0
+			for i := 0; i < 3; i++ {
+				_, err := reconciler.Reconcile(ctx, request)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+			Expect(statusWrites).Should(BeZero())
+
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			Expect(agent.Status.LastUpdated.Equal(lastUpdated)).Should(BeTrue())
+		})
+
+		It("Should write the status once the Deployment becomes ready", func() {
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+
+			deployment := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+			deployment.Status.Replicas = 1
+			deployment.Status.ReadyReplicas = 1
+			deployment.Status.AvailableReplicas = 1
+			Expect(fakeClient.Status().Update(ctx, deployment)).Should(Succeed())
+			ready := true
+			Expect(fakeClient.Create(ctx, &discoveryv1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "status-agent-service-abcde",
+					Namespace: "default",
+					Labels:    map[string]string{discoveryv1.LabelServiceName: "status-agent-service"},
+				},
+				AddressType: discoveryv1.AddressTypeIPv4,
+				Endpoints:   []discoveryv1.Endpoint{{Addresses: []string{"10.0.0.5"}, Conditions: discoveryv1.EndpointConditions{Ready: &ready}}},
+			})).Should(Succeed())
+
+			statusWrites = 0
+			for i := 0; i < 3; i++ {
+				_, err := reconciler.Reconcile(ctx, request)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+			Expect(statusWrites).Should(Equal(1))
+
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseRunning))
+		})
+	})
+
+	Context("When the agent keeps failing the same way", func() {
+		It("Should only write the status when the failure changes", func() {
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Spec.Provider = "mistral"
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+
+			_, err := reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(statusWrites).Should(BeNumerically(">", 0))
+
+			statusWrites = 0
+			for i := 0; i < 3; i++ {
+				_, err := reconciler.Reconcile(ctx, request)
+				Expect(err).ShouldNot(HaveOccurred())
+			}
+			Expect(statusWrites).Should(BeZero())
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+			Expect(agent.Status.Message).Should(ContainSubstring("mistral"))
+
+			By("Writing the status once the agent fails differently")
+			agent.Spec.Provider = "cohere"
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			_, err = reconciler.Reconcile(ctx, request)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(statusWrites).Should(Equal(1))
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			Expect(agent.Status.Message).Should(ContainSubstring("cohere"))
+		})
+	})
+})
@@ -0,0 +1,177 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Storage Version Migration", func() {
+	const crdName = "agents.ai.example.com"
+
+	var (
+		ctx       context.Context
+		apiClient client.Client
+	)
+
+	BeforeEach(func() {
+		if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+			Skip("the storage version migration runs against envtest, which needs KUBEBUILDER_ASSETS")
+		}
+		ctx = context.Background()
+
+		// A control plane of its own, as the CRD differs from the one of the other specs
+		testEnv := &envtest.Environment{
+			CRDDirectoryPaths:     []string{filepath.Join("testdata", "storagemigration")},
+			ErrorIfCRDPathMissing: true,
+		}
+		cfg, err := testEnv.Start()
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(testEnv.Stop)
+
+		migrationScheme := runtime.NewScheme()
+		Expect(clientgoscheme.AddToScheme(migrationScheme)).Should(Succeed())
+		Expect(apiextensionsv1.AddToScheme(migrationScheme)).Should(Succeed())
+		apiClient, err = client.New(cfg, client.Options{Scheme: migrationScheme})
+		Expect(err).ShouldNot(HaveOccurred())
+
+		for _, namespace := range []string{"kubeagentic-system", "migration"} {
+			Expect(apiClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: namespace}})).Should(Succeed())
+		}
+	})
+
+	agentAt := func(version, name string) *unstructured.Unstructured {
+		agent := &unstructured.Unstructured{}
+		agent.SetGroupVersionKind(schema.GroupVersionKind{Group: "ai.example.com", Version: version, Kind: "Agent"})
+		agent.SetNamespace("migration")
+		agent.SetName(name)
+		return agent
+	}
+
+	createAgents := func(version string, names ...string) {
+		for _, name := range names {
+			agent := agentAt(version, name)
+			agent.Object["spec"] = map[string]interface{}{"provider": "openai", "model": "gpt-4"}
+			Expect(apiClient.Create(ctx, agent)).Should(Succeed())
+		}
+	}
+
+	resourceVersions := func() map[string]string {
+		list := &unstructured.UnstructuredList{}
+		list.SetGroupVersionKind(schema.GroupVersionKind{Group: "ai.example.com", Version: "v1", Kind: "AgentList"})
+		Expect(apiClient.List(ctx, list, client.InNamespace("migration"))).Should(Succeed())
+		versions := map[string]string{}
+		for _, agent := range list.Items {
+			versions[agent.GetName()] = agent.GetResourceVersion()
+		}
+		return versions
+	}
+
+	crd := func() *apiextensionsv1.CustomResourceDefinition {
+		current := &apiextensionsv1.CustomResourceDefinition{}
+		Expect(apiClient.Get(ctx, types.NamespacedName{Name: crdName}, current)).Should(Succeed())
+		return current
+	}
+
+	// storeInV1 makes v1 the storage version, after which the CRD lists both versions in
+	// its storedVersions.
+	storeInV1 := func() {
+		current := crd()
+		for i := range current.Spec.Versions {
+			current.Spec.Versions[i].Storage = current.Spec.Versions[i].Name == "v1"
+		}
+		Expect(apiClient.Update(ctx, current)).Should(Succeed())
+		Eventually(func() []string { return crd().Status.StoredVersions }).Should(ConsistOf("v1alpha1", "v1"))
+	}
+
+	newMigrator := func() *controllers.StorageVersionMigrator {
+		return &controllers.StorageVersionMigrator{
+			Client:    apiClient,
+			Namespace: "kubeagentic-system",
+			CRDs:      []string{crdName},
+			PageSize:  2,
+		}
+	}
+
+	It("Should rewrite the agents stored in v1alpha1 while they are reconciled and retire v1alpha1", func() {
+		createAgents("v1alpha1", "agent-1", "agent-2", "agent-3", "agent-4", "agent-5")
+		storeInV1()
+		createAgents("v1", "agent-6")
+		before := resourceVersions()
+
+		By("Updating agents concurrently, as the reconciliation does")
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer GinkgoRecover()
+			defer wg.Done()
+			for i := 0; i < 10; i++ {
+				agent := agentAt("v1", fmt.Sprintf("agent-%d", i%6+1))
+				if err := apiClient.Get(ctx, client.ObjectKeyFromObject(agent), agent); err != nil {
+					continue
+				}
+				agent.SetLabels(map[string]string{"reconciled": fmt.Sprint(i)})
+				// Conflicts with the migration are retried by the reconciliation
+				_ = apiClient.Update(ctx, agent)
+			}
+		}()
+		Expect(newMigrator().Migrate(ctx)).Should(Succeed())
+		wg.Wait()
+
+		Expect(crd().Status.StoredVersions).Should(Equal([]string{"v1"}))
+		after := resourceVersions()
+		for name, resourceVersion := range before {
+			Expect(after[name]).ShouldNot(Equal(resourceVersion), "agent %s was not rewritten", name)
+		}
+
+		By("Removing the progress of the completed migration")
+		progress := &corev1.ConfigMap{}
+		Expect(apiClient.Get(ctx, types.NamespacedName{Name: controllers.StorageMigrationConfigMap, Namespace: "kubeagentic-system"}, progress)).Should(Succeed())
+		Expect(progress.Data).ShouldNot(HaveKey(crdName))
+
+		By("Doing nothing once the CRD has no retired versions")
+		rewritten := resourceVersions()
+		Expect(newMigrator().Migrate(ctx)).Should(Succeed())
+		Expect(resourceVersions()).Should(Equal(rewritten))
+	})
+
+	It("Should resume an interrupted migration after the last migrated agent", func() {
+		createAgents("v1alpha1", "agent-1", "agent-2", "agent-3", "agent-4")
+		storeInV1()
+
+		By("Saving the progress of a migration interrupted after agent-2")
+		data, err := json.Marshal(map[string]interface{}{"storageVersion": "v1", "lastMigrated": "migration/agent-2", "migrated": 2})
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(apiClient.Create(ctx, &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: controllers.StorageMigrationConfigMap, Namespace: "kubeagentic-system"},
+			Data:       map[string]string{crdName: string(data)},
+		})).Should(Succeed())
+		before := resourceVersions()
+
+		Expect(newMigrator().Migrate(ctx)).Should(Succeed())
+		after := resourceVersions()
+		Expect(after["agent-1"]).Should(Equal(before["agent-1"]))
+		Expect(after["agent-2"]).Should(Equal(before["agent-2"]))
+		Expect(after["agent-3"]).ShouldNot(Equal(before["agent-3"]))
+		Expect(after["agent-4"]).ShouldNot(Equal(before["agent-4"]))
+		Expect(crd().Status.StoredVersions).Should(Equal([]string{"v1"}))
+	})
+})
@@ -0,0 +1,96 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/client-go/rest"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/envtest"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
+
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+// Most specs run the reconciler against a fake client. The specs using k8sClient need the
+// API server and etcd binaries of envtest, which `make test` installs and points
+// KUBEBUILDER_ASSETS at; without them those specs are skipped.
+var (
+	cfg       *rest.Config
+	k8sClient client.Client
+	// managerClient is the client of the manager, which reads through the operator cache as
+	// the reconcilers do.
+	managerClient client.Client
+	testEnv       *envtest.Environment
+	cancel        context.CancelFunc
+)
+
+func TestAgentOperator(t *testing.T) {
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Agent Operator Suite")
+}
+
+var _ = BeforeSuite(func() {
+	logf.SetLogger(zap.New(zap.WriteTo(GinkgoWriter), zap.UseDevMode(true)))
+	if os.Getenv("KUBEBUILDER_ASSETS") == "" {
+		return
+	}
+
+	By("Bootstrapping the test environment")
+	testEnv = &envtest.Environment{
+		CRDDirectoryPaths:     []string{filepath.Join("..", "crd")},
+		ErrorIfCRDPathMissing: true,
+	}
+	var err error
+	cfg, err = testEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	Expect(cfg).NotTo(BeNil())
+
+	scheme := newScheme()
+	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme})
+	Expect(err).NotTo(HaveOccurred())
+
+	By("Starting the Agent controller")
+	mgr, err := ctrl.NewManager(cfg, ctrl.Options{
+		Scheme:    scheme,
+		Metrics:   metricsserver.Options{BindAddress: "0"},
+		Cache:     controllers.CacheOptions("kubeagentic-system"),
+		NewClient: controllers.NewClient,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	managerClient = mgr.GetClient()
+	Expect((&controllers.AgentReconciler{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+	}).SetupWithManager(mgr)).To(Succeed())
+
+	var ctx context.Context
+	ctx, cancel = context.WithCancel(context.Background())
+	go func() {
+		defer GinkgoRecover()
+		Expect(mgr.Start(ctx)).To(Succeed())
+	}()
+})
+
+var _ = AfterSuite(func() {
+	if testEnv == nil {
+		return
+	}
+	By("Tearing down the test environment")
+	cancel()
+	Expect(testEnv.Stop()).To(Succeed())
+})
+
+// requireEnvtest skips the current spec when the suite runs without the envtest binaries.
+func requireEnvtest() {
+	if k8sClient == nil {
+		Skip("KUBEBUILDER_ASSETS is not set; run make test to install the envtest binaries")
+	}
+}
@@ -0,0 +1,80 @@
+package test
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	clocktesting "k8s.io/utils/clock/testing"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/tokenquota"
+)
+
+var _ = Describe("Token Quota", func() {
+	var (
+		location *time.Location
+		clock    *clocktesting.FakePassiveClock
+		tracker  *tokenquota.Tracker
+	)
+
+	BeforeEach(func() {
+		var err error
+		location, err = time.LoadLocation("America/New_York")
+		Expect(err).ShouldNot(HaveOccurred())
+		clock = clocktesting.NewFakePassiveClock(time.Date(2024, 3, 14, 22, 0, 0, 0, location))
+		tracker = &tokenquota.Tracker{Limit: 1000, Location: location, Clock: clock}
+	})
+
+	Context("When tracking the consumption of a day", func() {
+		It("Should count tokens from the first observation", func() {
+			usage := tracker.Observe(nil, tokenquota.Counters{"agent-0": 5000})
+			Expect(usage.Used).Should(BeZero())
+			Expect(usage.DayStart).Should(Equal(time.Date(2024, 3, 14, 0, 0, 0, 0, location)))
+			Expect(tracker.Remaining(usage)).Should(Equal(int64(1000)))
+		})
+
+		It("Should exhaust the quota once the consumption crosses the limit", func() {
+			usage := tracker.Observe(nil, tokenquota.Counters{"agent-0": 100, "agent-1": 0})
+
+			clock.SetTime(clock.Now().Add(30 * time.Minute))
+			usage = tracker.Observe(usage, tokenquota.Counters{"agent-0": 500, "agent-1": 300})
+			Expect(usage.Used).Should(Equal(int64(700)))
+			Expect(tracker.Exhausted(usage)).Should(BeFalse())
+
+			clock.SetTime(clock.Now().Add(30 * time.Minute))
+			usage = tracker.Observe(usage, tokenquota.Counters{"agent-0": 600, "agent-1": 600})
+			Expect(usage.Used).Should(Equal(int64(1100)))
+			Expect(tracker.Remaining(usage)).Should(BeZero())
+			Expect(tracker.Exhausted(usage)).Should(BeTrue())
+		})
+
+		It("Should charge new and restarted pods for all their tokens", func() {
+			usage := tracker.Observe(nil, tokenquota.Counters{"agent-0": 400})
+			usage = tracker.Observe(usage, tokenquota.Counters{"agent-0": 50, "agent-2": 20})
+			Expect(usage.Used).Should(Equal(int64(70)))
+		})
+	})
+
+	Context("When the day ends", func() {
+		It("Should reset the quota at midnight in the reset time zone", func() {
+			usage := tracker.Observe(nil, tokenquota.Counters{"agent-0": 0})
+			usage = tracker.Observe(usage, tokenquota.Counters{"agent-0": 1500})
+			Expect(tracker.Exhausted(usage)).Should(BeTrue())
+
+			_, reset := tracker.Day()
+			Expect(reset).Should(Equal(time.Date(2024, 3, 15, 0, 0, 0, 0, location)))
+
+			By("Staying exhausted until midnight")
+			clock.SetTime(reset.Add(-time.Second))
+			Expect(tracker.Exhausted(usage)).Should(BeTrue())
+
+			By("Starting the new day with the full quota")
+			clock.SetTime(reset.Add(time.Second))
+			Expect(tracker.Exhausted(usage)).Should(BeFalse())
+			usage = tracker.Observe(usage, tokenquota.Counters{"agent-0": 1600})
+			Expect(usage.DayStart).Should(Equal(reset))
+			Expect(usage.Used).Should(Equal(int64(100)))
+			Expect(tracker.Remaining(usage)).Should(Equal(int64(900)))
+		})
+	})
+})
@@ -0,0 +1,189 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/toolexec"
+)
+
+var _ = Describe("Agent Tool Executor", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		executorScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(executorScheme).
+			WithObjects(&aiv1.Agent{
+				ObjectMeta: metav1.ObjectMeta{Name: "operator-bot", Namespace: "default"},
+				Spec: aiv1.AgentSpec{
+					Provider:     "vllm",
+					Model:        "llama-3-8b",
+					SystemPrompt: "You are a helpful AI assistant.",
+					Endpoint:     "http://vllm.default.svc:8000/v1",
+					ToolExecutor: &aiv1.ToolExecutorConfig{
+						Image:           "registry.example.com/tools/git-kubectl:1.2",
+						AllowedCommands: []string{"git", "kubectl"},
+						Timeout:         &metav1.Duration{Duration: 2 * time.Minute},
+					},
+				},
+			}).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: executorScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "operator-bot", Namespace: "default"}}
+	})
+
+	reconcile := func() (*appsv1.Deployment, *aiv1.Agent) {
+		agent := reconcileAgent(ctx, reconciler, request)
+		deployment := &appsv1.Deployment{}
+		if agent.Status.Phase != aiv1.AgentPhaseFailed {
+			Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		}
+		return deployment, agent
+	}
+
+	update := func(mutate func(*aiv1.ToolExecutorConfig)) {
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		mutate(agent.Spec.ToolExecutor)
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+	}
+
+	container := func(containers []corev1.Container, name string) *corev1.Container {
+		for i := range containers {
+			if containers[i].Name == name {
+				return &containers[i]
+			}
+		}
+		return nil
+	}
+
+	It("Should run the sidecar on localhost with a workspace shared with the agent container", func() {
+		deployment, _ := reconcile()
+		pod := deployment.Spec.Template.Spec
+
+		sidecar := container(pod.Containers, "tool-executor")
+		Expect(sidecar).ShouldNot(BeNil())
+		Expect(sidecar.Image).Should(Equal("registry.example.com/tools/git-kubectl:1.2"))
+		Expect(sidecar.Command).Should(Equal([]string{"/kubeagentic/bin/tool-executor"}))
+		Expect(sidecar.Args).Should(ContainElements("--addr", "127.0.0.1:8091"))
+		Expect(sidecar.Ports).Should(BeEmpty())
+		Expect(sidecar.VolumeMounts).Should(ContainElement(corev1.VolumeMount{Name: "workspace", MountPath: "/workspace"}))
+
+		By("Running the sidecar with a tighter security context than the agent container")
+		sc := sidecar.SecurityContext
+		Expect(sc).ShouldNot(BeNil())
+		Expect(*sc.RunAsNonRoot).Should(BeTrue())
+		Expect(*sc.Privileged).Should(BeFalse())
+		Expect(*sc.AllowPrivilegeEscalation).Should(BeFalse())
+		Expect(*sc.ReadOnlyRootFilesystem).Should(BeTrue())
+		Expect(sc.Capabilities.Drop).Should(Equal([]corev1.Capability{"ALL"}))
+		Expect(sc.SeccompProfile.Type).Should(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+
+		By("Installing the execution API from the operator image")
+		install := container(pod.InitContainers, "tool-executor-install")
+		Expect(install).ShouldNot(BeNil())
+		Expect(install.Args).Should(Equal([]string{"--install", "/kubeagentic/bin"}))
+
+		agent := pod.Containers[0]
+		Expect(agent.VolumeMounts).Should(ContainElement(corev1.VolumeMount{Name: "workspace", MountPath: "/workspace"}))
+		Expect(agent.Env).Should(ContainElements(
+			corev1.EnvVar{Name: "AGENT_TOOL_EXECUTOR_URL", Value: "http://127.0.0.1:8091"},
+			corev1.EnvVar{Name: "AGENT_TOOL_EXECUTOR_COMMANDS", Value: "git,kubectl"},
+			corev1.EnvVar{Name: "AGENT_WORKSPACE", Value: "/workspace"},
+		))
+
+		By("Rendering the allowlist into the agent ConfigMap")
+		configMap := &corev1.ConfigMap{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "operator-bot-config", Namespace: "default"}, configMap)).Should(Succeed())
+		config, err := toolexec.ParseConfig([]byte(configMap.Data["tool-executor.json"]))
+		Expect(err).ShouldNot(HaveOccurred())
+		Expect(config).Should(Equal(toolexec.Config{AllowedCommands: []string{"git", "kubectl"}, Workspace: "/workspace", Timeout: "2m0s"}))
+	})
+
+	It("Should roll the pods when the allowlist changes", func() {
+		deployment, _ := reconcile()
+		checksum := deployment.Spec.Template.Annotations["kubeagentic.ai/tool-executor-checksum"]
+		Expect(checksum).ShouldNot(BeEmpty())
+
+		deployment, _ = reconcile()
+		Expect(deployment.Spec.Template.Annotations["kubeagentic.ai/tool-executor-checksum"]).Should(Equal(checksum))
+
+		update(func(executor *aiv1.ToolExecutorConfig) {
+			executor.AllowedCommands = []string{"git"}
+		})
+		deployment, _ = reconcile()
+		Expect(deployment.Spec.Template.Annotations["kubeagentic.ai/tool-executor-checksum"]).ShouldNot(Equal(checksum))
+	})
+
+	It("Should reject an executor without allowed commands", func() {
+		update(func(executor *aiv1.ToolExecutorConfig) {
+			executor.AllowedCommands = nil
+		})
+		_, agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		Expect(agent.Status.Message).Should(ContainSubstring("toolExecutor.allowedCommands must allow at least one command"))
+	})
+
+	It("Should reject a privileged executor", func() {
+		privileged := true
+		update(func(executor *aiv1.ToolExecutorConfig) {
+			executor.SecurityContext = &corev1.SecurityContext{Privileged: &privileged}
+		})
+		_, agent := reconcile()
+		Expect(agent.Status.Phase).Should(Equal(aiv1.AgentPhaseFailed))
+		Expect(agent.Status.Message).Should(ContainSubstring("toolExecutor must not be privileged"))
+	})
+
+	It("Should only run the allowed commands in the workspace", func() {
+		workspace := GinkgoT().TempDir()
+		executor := toolexec.NewExecutor(toolexec.Config{AllowedCommands: []string{"pwd"}, Workspace: workspace}, nil)
+		server := httptest.NewServer(executor)
+		DeferCleanup(server.Close)
+
+		execute := func(request toolexec.Request) (*http.Response, toolexec.Result) {
+			body, err := json.Marshal(request)
+			Expect(err).ShouldNot(HaveOccurred())
+			resp, err := http.Post(server.URL+"/execute", "application/json", bytes.NewReader(body))
+			Expect(err).ShouldNot(HaveOccurred())
+			defer resp.Body.Close()
+			var result toolexec.Result
+			if resp.StatusCode == http.StatusOK {
+				Expect(json.NewDecoder(resp.Body).Decode(&result)).Should(Succeed())
+			}
+			return resp, result
+		}
+
+		resp, result := execute(toolexec.Request{Command: "pwd"})
+		Expect(resp.StatusCode).Should(Equal(http.StatusOK))
+		Expect(result.ExitCode).Should(Equal(0))
+		Expect(result.Stdout).Should(Equal(workspace + "\n"))
+
+		resp, _ = execute(toolexec.Request{Command: "sh", Args: []string{"-c", "id"}})
+		Expect(resp.StatusCode).Should(Equal(http.StatusForbidden))
+
+		resp, _ = execute(toolexec.Request{Command: "pwd", Dir: "../.."})
+		Expect(resp.StatusCode).Should(Equal(http.StatusBadRequest))
+	})
+})
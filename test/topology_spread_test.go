@@ -0,0 +1,120 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	webhookv1 "github.com/KubeAgentic-Community/kubeagentic/api/webhook/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Topology Spread", func() {
+	hostnameSpread := corev1.TopologySpreadConstraint{
+		MaxSkew:           1,
+		TopologyKey:       "kubernetes.io/hostname",
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+	}
+
+	newAgent := func(replicas int32) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{Name: "support", Namespace: "default"},
+			Spec: aiv1.AgentSpec{
+				Provider:     "vllm",
+				Model:        "llama-3-8b",
+				SystemPrompt: "You are a helpful AI assistant.",
+				Endpoint:     "http://vllm.default.svc:8000/v1",
+				Replicas:     &replicas,
+			},
+		}
+	}
+
+	Context("Defaulting the constraints", func() {
+		defaulted := func(agent *aiv1.Agent) []corev1.TopologySpreadConstraint {
+			Expect((&webhookv1.AgentWebhook{}).Default(context.Background(), agent)).Should(Succeed())
+			return agent.Spec.TopologySpreadConstraints
+		}
+
+		It("Should spread agents of more than one replica across nodes", func() {
+			Expect(defaulted(newAgent(3))).Should(Equal([]corev1.TopologySpreadConstraint{hostnameSpread}))
+			Expect(defaulted(newAgent(1))).Should(BeEmpty())
+		})
+
+		It("Should keep the constraints of the agent", func() {
+			agent := newAgent(3)
+			zoneSpread := corev1.TopologySpreadConstraint{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule}
+			agent.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{zoneSpread}
+			Expect(defaulted(agent)).Should(Equal([]corev1.TopologySpreadConstraint{zoneSpread}))
+		})
+	})
+
+	Context("Reconciling the constraints", func() {
+		It("Should set the constraints on the pod template and keep them while the HPA scales", func() {
+			ctx := context.Background()
+			scheme := newScheme()
+
+			agent := newAgent(3)
+			agent.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{hostnameSpread}
+			fakeClient := newFakeClientBuilder(scheme).
+				WithObjects(agent).
+				Build()
+			reconciler := &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "support", Namespace: "default"}}
+			reconcile := func() *appsv1.Deployment {
+				return reconcileDeployment(ctx, reconciler, request)
+			}
+
+			expected := hostnameSpread
+			expected.LabelSelector = &metav1.LabelSelector{MatchLabels: map[string]string{
+				"app.kubernetes.io/name":     "kubeagentic-agent",
+				"app.kubernetes.io/instance": "support",
+				"kubeagentic.ai/agent":       "support",
+			}}
+			deployment := reconcile()
+			Expect(deployment.Spec.Template.Spec.TopologySpreadConstraints).Should(Equal([]corev1.TopologySpreadConstraint{expected}))
+
+			By("Scaling the Deployment as the HPA does")
+			deployment.Spec.Replicas = int32Ptr(5)
+			Expect(fakeClient.Update(ctx, deployment)).Should(Succeed())
+			deployment = reconcile()
+			Expect(*deployment.Spec.Replicas).Should(Equal(int32(5)))
+			Expect(deployment.Spec.Template.Spec.TopologySpreadConstraints).Should(Equal([]corev1.TopologySpreadConstraint{expected}))
+
+			stored := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, stored)).Should(Succeed())
+			Expect(stored.Spec.TopologySpreadConstraints[0].LabelSelector).Should(BeNil())
+		})
+	})
+
+	Context("Admitting agents with constraints", func() {
+		admit := func(constraints ...corev1.TopologySpreadConstraint) error {
+			agent := newAgent(3)
+			agent.Spec.TopologySpreadConstraints = constraints
+			validator := &webhookv1.AgentWebhook{}
+			Expect(validator.Default(context.Background(), agent)).Should(Succeed())
+			_, err := validator.ValidateCreate(context.Background(), agent)
+			return err
+		}
+
+		It("Should reject a skew below 1, an empty topology key and duplicated constraints", func() {
+			Expect(admit(hostnameSpread)).Should(Succeed())
+
+			err := admit(
+				corev1.TopologySpreadConstraint{MaxSkew: 0, TopologyKey: "kubernetes.io/hostname", WhenUnsatisfiable: corev1.DoNotSchedule},
+				corev1.TopologySpreadConstraint{MaxSkew: 1, WhenUnsatisfiable: corev1.ScheduleAnyway},
+				hostnameSpread,
+				hostnameSpread,
+			)
+			Expect(err).Should(MatchError(ContainSubstring("spec.topologySpreadConstraints[0].maxSkew: Invalid value: 0: must be at least 1")))
+			Expect(err).Should(MatchError(ContainSubstring("spec.topologySpreadConstraints[1].topologyKey: Required value")))
+			Expect(err).Should(MatchError(ContainSubstring(`spec.topologySpreadConstraints[3]: Duplicate value: "{kubernetes.io/hostname, ScheduleAnyway}"`)))
+		})
+	})
+})
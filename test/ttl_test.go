@@ -0,0 +1,198 @@
+package test
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	clocktesting "k8s.io/utils/clock/testing"
+	ctrl "sigs.k8s.io/controller-runtime"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/expiry"
+)
+
+var _ = Describe("Agent TTL", func() {
+	created := time.Date(2024, 3, 14, 10, 0, 0, 0, time.UTC)
+
+	previewAgent := func(ttl time.Duration, annotations map[string]string) *aiv1.Agent {
+		return &aiv1.Agent{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "preview-pr-42",
+				Namespace:         "default",
+				Annotations:       annotations,
+				CreationTimestamp: metav1.NewTime(created),
+			},
+			Spec: aiv1.AgentSpec{
+				Provider:     "openai",
+				Model:        "gpt-4o",
+				SystemPrompt: "You are a helpful AI assistant.",
+				ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"}, Key: "api-key"}},
+				TTL:          &metav1.Duration{Duration: ttl},
+			},
+		}
+	}
+
+	Context("When computing the deadline", func() {
+		It("Should have no deadline without ttl or expireAt", func() {
+			agent := previewAgent(time.Hour, nil)
+			agent.Spec.TTL = nil
+			_, ok := expiry.Deadline(agent, &agent.Spec, created)
+			Expect(ok).Should(BeFalse())
+		})
+
+		It("Should apply the earlier of ttl and expireAt", func() {
+			agent := previewAgent(72*time.Hour, nil)
+			deadline, ok := expiry.Deadline(agent, &agent.Spec, created)
+			Expect(ok).Should(BeTrue())
+			Expect(deadline).Should(Equal(created.Add(72 * time.Hour)))
+
+			expireAt := metav1.NewTime(created.Add(24 * time.Hour))
+			agent.Spec.ExpireAt = &expireAt
+			deadline, _ = expiry.Deadline(agent, &agent.Spec, created)
+			Expect(deadline).Should(Equal(created.Add(24 * time.Hour)))
+		})
+
+		It("Should push the deadline back by the extension", func() {
+			agent := previewAgent(time.Hour, map[string]string{expiry.ExtendAnnotation: "90m"})
+			deadline, _ := expiry.Deadline(agent, &agent.Spec, created)
+			Expect(deadline).Should(Equal(created.Add(150 * time.Minute)))
+		})
+
+		It("Should reject invalid ttls and extensions", func() {
+			agent := previewAgent(0, map[string]string{expiry.ExtendAnnotation: "a day"})
+			errs := expiry.Validate(agent, &agent.Spec)
+			Expect(errs).Should(HaveLen(2))
+			Expect(errs[0].Field).Should(Equal("spec.ttl"))
+			Expect(errs[1].Field).Should(Equal("metadata.annotations[kubeagentic.ai/extend-ttl]"))
+
+			agent = previewAgent(time.Hour, map[string]string{expiry.ExtendAnnotation: "-1h"})
+			Expect(expiry.Validate(agent, &agent.Spec)).Should(HaveLen(1))
+		})
+	})
+
+	Context("When capping the lifetime", func() {
+		BeforeEach(func() {
+			previous, had := os.LookupEnv("MAX_AGENT_TTL")
+			DeferCleanup(func() {
+				if had {
+					os.Setenv("MAX_AGENT_TTL", previous)
+				} else {
+					os.Unsetenv("MAX_AGENT_TTL")
+				}
+			})
+			os.Unsetenv("MAX_AGENT_TTL")
+		})
+
+		policy := func(name, namespace string, maxTTL time.Duration) aiv1.AgentPolicy {
+			return aiv1.AgentPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+				Spec:       aiv1.AgentPolicySpec{MaxTTL: &metav1.Duration{Duration: maxTTL}},
+			}
+		}
+
+		It("Should not cap the lifetime by default", func() {
+			max, _ := expiry.Max("default", nil)
+			Expect(max).Should(BeZero())
+		})
+
+		It("Should apply the lowest maximum of the operator and the policies of the namespace", func() {
+			os.Setenv("MAX_AGENT_TTL", "720h")
+			max, source := expiry.Max("default", nil)
+			Expect(max).Should(Equal(720 * time.Hour))
+			Expect(source).Should(Equal("the operator"))
+
+			max, source = expiry.Max("default", []aiv1.AgentPolicy{
+				policy("previews", "default", 168*time.Hour),
+				policy("other", "staging", time.Hour),
+			})
+			Expect(max).Should(Equal(168 * time.Hour))
+			Expect(source).Should(Equal("AgentPolicy previews"))
+		})
+	})
+
+	Context("When reconciling an agent with a ttl", func() {
+		It("Should warn before the deadline and delete the agent once it expires", func() {
+			ctx := context.Background()
+			scheme := newScheme()
+			fakeClient := newFakeClientBuilder(scheme).
+				WithObjects(
+					previewAgent(time.Hour, nil),
+					&corev1.Secret{
+						ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+						Data:       map[string][]byte{"api-key": []byte("sk-test")},
+					},
+				).
+				Build()
+			clock := clocktesting.NewFakePassiveClock(created.Add(5 * time.Minute))
+			recorder := record.NewFakeRecorder(100)
+			reconciler := &controllers.AgentReconciler{Client: fakeClient, Scheme: scheme, Clock: clock, Recorder: recorder}
+			request := ctrl.Request{NamespacedName: types.NamespacedName{Name: "preview-pr-42", Namespace: "default"}}
+			reconcileAt := func(offset time.Duration) ctrl.Result {
+				clock.SetTime(created.Add(offset))
+				result, err := reconciler.Reconcile(ctx, request)
+				Expect(err).ShouldNot(HaveOccurred())
+				return result
+			}
+			expiring := func() *aiv1.AgentCondition {
+				agent := &aiv1.Agent{}
+				Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+				for i := range agent.Status.Conditions {
+					if agent.Status.Conditions[i].Type == aiv1.AgentConditionExpiring {
+						return &agent.Status.Conditions[i]
+					}
+				}
+				return nil
+			}
+			drainEvents := func() []string {
+				var events []string
+				for len(recorder.Events) > 0 {
+					events = append(events, <-recorder.Events)
+				}
+				return events
+			}
+
+			reconcileAt(5 * time.Minute)
+			Expect(expiring()).ShouldNot(BeNil())
+			Expect(expiring().Reason).Should(Equal("TTLActive"))
+			Expect(expiring().Message).Should(ContainSubstring("in 55m0s"))
+
+			By("Requeueing at the warning")
+			Expect(reconcileAt(48 * time.Minute).RequeueAfter).Should(BeNumerically("<=", 2*time.Minute))
+			Expect(drainEvents()).ShouldNot(ContainElement(HavePrefix("Warning ExpiringSoon")))
+
+			By("Warning once within 10 minutes of the deadline")
+			reconcileAt(52 * time.Minute)
+			Expect(drainEvents()).Should(ContainElement(HavePrefix("Warning ExpiringSoon")))
+			Expect(expiring().Reason).Should(Equal("ExpiringSoon"))
+			reconcileAt(55 * time.Minute)
+			Expect(drainEvents()).ShouldNot(ContainElement(HavePrefix("Warning ExpiringSoon")))
+
+			By("Pushing the deadline back with the annotation")
+			agent := &aiv1.Agent{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			agent.Annotations = map[string]string{expiry.ExtendAnnotation: "30m"}
+			Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+			reconcileAt(61 * time.Minute)
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			Expect(agent.DeletionTimestamp).Should(BeNil())
+			Expect(expiring().Reason).Should(Equal("TTLActive"))
+
+			By("Deleting the agent through its finalizer at the deadline")
+			reconcileAt(90 * time.Minute)
+			Expect(drainEvents()).Should(ContainElement(HavePrefix("Normal Expired")))
+			Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+			Expect(agent.DeletionTimestamp).ShouldNot(BeNil())
+			reconcileAt(90 * time.Minute)
+			Expect(errors.IsNotFound(fakeClient.Get(ctx, request.NamespacedName, agent))).Should(BeTrue())
+		})
+	})
+})
@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("vLLM Batch Tuning", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		reconciler *controllers.AgentReconciler
+		request    ctrl.Request
+	)
+
+	newReconciler := func(spec aiv1.AgentSpec) {
+		tuningScheme := newScheme()
+
+		fakeClient = newFakeClientBuilder(tuningScheme).
+			WithObjects(
+				&aiv1.Agent{
+					ObjectMeta: metav1.ObjectMeta{Name: "batcher", Namespace: "default"},
+					Spec:       spec,
+				},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "openai-secret", Namespace: "default"},
+					Data:       map[string][]byte{"api-key": []byte("sk-test")},
+				},
+			).
+			Build()
+		reconciler = &controllers.AgentReconciler{Client: fakeClient, Scheme: tuningScheme}
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "batcher", Namespace: "default"}}
+	}
+
+	reconcile := func() *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	agentEnv := func() map[string]string {
+		deployment := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, deployment)).Should(Succeed())
+		env := map[string]string{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e.Value
+		}
+		return env
+	}
+
+	configValid := func(agent *aiv1.Agent) aiv1.AgentCondition {
+		for _, condition := range agent.Status.Conditions {
+			if condition.Type == aiv1.AgentConditionConfigValid {
+				return condition
+			}
+		}
+		return aiv1.AgentCondition{}
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should pass the batching settings to the runtime and roll the pods on change", func() {
+		batchSize, wait := int32(16), int32(20)
+		newReconciler(aiv1.AgentSpec{
+			Provider:     "vllm",
+			Model:        "llama-3-8b",
+			SystemPrompt: "You are a helpful AI assistant.",
+			Endpoint:     "http://vllm.default.svc:8000/v1",
+			VLLM:         &aiv1.VLLMConfig{MaxBatchSize: &batchSize, MaxWaitMs: &wait},
+		})
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionTrue))
+		env := agentEnv()
+		Expect(env).Should(HaveKeyWithValue("AGENT_VLLM_MAX_BATCH_SIZE", "16"))
+		Expect(env).Should(HaveKeyWithValue("AGENT_VLLM_MAX_WAIT_MS", "20"))
+		Expect(env).ShouldNot(HaveKey("AGENT_VLLM_MAX_CONCURRENT_SEQUENCES"))
+
+		By("Changing the pod template with the settings")
+		sequences := int32(64)
+		agent.Spec.VLLM.MaxBatchSize = nil
+		agent.Spec.VLLM.MaxConcurrentSequences = &sequences
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile()
+		env = agentEnv()
+		Expect(env).ShouldNot(HaveKey("AGENT_VLLM_MAX_BATCH_SIZE"))
+		Expect(env).Should(HaveKeyWithValue("AGENT_VLLM_MAX_CONCURRENT_SEQUENCES", "64"))
+	})
+
+	It("Should reject the tuning for other providers", func() {
+		batchSize := int32(8)
+		newReconciler(aiv1.AgentSpec{
+			Provider:     "openai",
+			Model:        "gpt-4o",
+			SystemPrompt: "You are a helpful AI assistant.",
+			ApiSecretRef: &aiv1.SecretKeyReference{SecretKeySelector: corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "openai-secret"},
+				Key:                  "api-key",
+			}},
+			VLLM: &aiv1.VLLMConfig{MaxBatchSize: &batchSize},
+		})
+		agent := reconcile()
+		Expect(configValid(agent).Status).Should(Equal(corev1.ConditionFalse))
+		Expect(configValid(agent).Reason).Should(Equal("InvalidVLLMConfig"))
+		Expect(configValid(agent).Message).Should(ContainSubstring("only supported by the vllm provider"))
+	})
+})
@@ -0,0 +1,58 @@
+package test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/warmup"
+)
+
+var _ = Describe("Agent Warm-up", func() {
+	// fakeAgent serves the /warmup endpoint of the agent runtime, expecting token when set.
+	fakeAgent := func(body, token string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/warmup" {
+				http.NotFound(w, r)
+				return
+			}
+			if token != "" && r.Header.Get("Authorization") != "Bearer "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(body))
+		}))
+		DeferCleanup(server.Close)
+		return server
+	}
+
+	Context("When checking the warm-up of an agent pod", func() {
+		It("Should report a pod still warming up", func() {
+			server := fakeAgent(`{"state":"warming","completed":1,"error":null}`, "")
+			status, err := (&warmup.Checker{}).Check(context.Background(), server.URL, "")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(status).Should(Equal(warmup.Status{State: warmup.StateWarming, Completed: 1}))
+		})
+
+		It("Should report the warm-up error", func() {
+			server := fakeAgent(`{"state":"failed","completed":2,"error":"timed out after 120s"}`, "secret")
+			status, err := (&warmup.Checker{}).Check(context.Background(), server.URL, "secret")
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(status.State).Should(Equal(warmup.StateFailed))
+			Expect(status.Error).Should(Equal("timed out after 120s"))
+		})
+
+		It("Should fail on an unexpected response", func() {
+			server := fakeAgent(`{"state":"warm","completed":3}`, "secret")
+			_, err := (&warmup.Checker{}).Check(context.Background(), server.URL, "")
+			Expect(err).Should(MatchError(ContainSubstring("401")))
+
+			_, err = (&warmup.Checker{}).Check(context.Background(), server.URL+"/missing", "")
+			Expect(err).Should(HaveOccurred())
+		})
+	})
+})
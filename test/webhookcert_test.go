@@ -0,0 +1,127 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/KubeAgentic-Community/kubeagentic/pkg/webhookcert"
+)
+
+var _ = Describe("Webhook Certificates", func() {
+	const webhookConfigName = "kubeagentic-validating-webhook-configuration"
+
+	var (
+		ctx         context.Context
+		certClient  client.Client
+		certManager *webhookcert.Manager
+		certDir     string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		certScheme := runtime.NewScheme()
+		Expect(corev1.AddToScheme(certScheme)).Should(Succeed())
+		Expect(admissionregistrationv1.AddToScheme(certScheme)).Should(Succeed())
+
+		certClient = fake.NewClientBuilder().WithScheme(certScheme).WithObjects(
+			&admissionregistrationv1.ValidatingWebhookConfiguration{
+				ObjectMeta: metav1.ObjectMeta{Name: webhookConfigName},
+				Webhooks: []admissionregistrationv1.ValidatingWebhook{
+					{Name: "vagent.kb.io"},
+				},
+			},
+		).Build()
+
+		var err error
+		certDir, err = os.MkdirTemp("", "webhook-certs")
+		Expect(err).ShouldNot(HaveOccurred())
+		DeferCleanup(os.RemoveAll, certDir)
+
+		certManager = webhookcert.New(certClient, webhookcert.Options{
+			SecretName:         "kubeagentic-webhook-certs",
+			Namespace:          "kubeagentic-system",
+			ServiceName:        "kubeagentic-webhook-service",
+			CertDir:            certDir,
+			ValidatingWebhooks: []string{webhookConfigName},
+		})
+	})
+
+	caBundle := func() []byte {
+		config := &admissionregistrationv1.ValidatingWebhookConfiguration{}
+		Expect(certClient.Get(ctx, types.NamespacedName{Name: webhookConfigName}, config)).Should(Succeed())
+		return config.Webhooks[0].ClientConfig.CABundle
+	}
+
+	Context("When bootstrapping from an empty cluster", func() {
+		It("Should generate the Secret, write the certificate and patch the caBundle", func() {
+			Expect(certManager.Bootstrap(ctx)).Should(Succeed())
+
+			secret := &corev1.Secret{}
+			Expect(certClient.Get(ctx, types.NamespacedName{Name: "kubeagentic-webhook-certs", Namespace: "kubeagentic-system"}, secret)).Should(Succeed())
+			Expect(secret.Data).Should(HaveKey(webhookcert.CACertKey))
+			Expect(secret.Data).Should(HaveKey(corev1.TLSCertKey))
+
+			servingCert, err := os.ReadFile(filepath.Join(certDir, corev1.TLSCertKey))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(servingCert).Should(Equal(secret.Data[corev1.TLSCertKey]))
+
+			Expect(certManager.Rotate(ctx)).Should(Succeed())
+			Expect(caBundle()).Should(Equal(secret.Data[webhookcert.CACertKey]))
+
+			By("Reusing the certificates on the next start")
+			Expect(certManager.Bootstrap(ctx)).Should(Succeed())
+			restarted, err := os.ReadFile(filepath.Join(certDir, corev1.TLSCertKey))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(restarted).Should(Equal(servingCert))
+		})
+	})
+
+	Context("When certificates approach expiry", func() {
+		It("Should renew the serving certificate and keep the CA", func() {
+			Expect(certManager.Bootstrap(ctx)).Should(Succeed())
+			Expect(certManager.Rotate(ctx)).Should(Succeed())
+			originalBundle := caBundle()
+			originalCert, err := os.ReadFile(filepath.Join(certDir, corev1.TLSCertKey))
+			Expect(err).ShouldNot(HaveOccurred())
+
+			certManager.Now = func() time.Time { return time.Now().Add(340 * 24 * time.Hour) }
+			Expect(certManager.Rotate(ctx)).Should(Succeed())
+
+			renewedCert, err := os.ReadFile(filepath.Join(certDir, corev1.TLSCertKey))
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(renewedCert).ShouldNot(Equal(originalCert))
+			Expect(caBundle()).Should(Equal(originalBundle))
+		})
+
+		It("Should renew the CA and update the caBundle", func() {
+			Expect(certManager.Bootstrap(ctx)).Should(Succeed())
+			Expect(certManager.Rotate(ctx)).Should(Succeed())
+			originalBundle := caBundle()
+
+			certManager.Now = func() time.Time { return time.Now().Add(3640 * 24 * time.Hour) }
+			Expect(certManager.Rotate(ctx)).Should(Succeed())
+
+			By("Trusting both CAs until the old one expires")
+			renewedBundle := caBundle()
+			Expect(renewedBundle).ShouldNot(Equal(originalBundle))
+			Expect(string(renewedBundle)).Should(HaveSuffix(string(originalBundle)))
+
+			certManager.Now = func() time.Time { return time.Now().Add(3651 * 24 * time.Hour) }
+			Expect(certManager.Rotate(ctx)).Should(Succeed())
+			Expect(string(caBundle())).ShouldNot(ContainSubstring(string(originalBundle)))
+		})
+	})
+})
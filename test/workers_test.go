@@ -0,0 +1,291 @@
+package test
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	aiv1 "github.com/KubeAgentic-Community/kubeagentic/api/v1"
+	"github.com/KubeAgentic-Community/kubeagentic/controllers"
+)
+
+var _ = Describe("Agent Worker Mode", func() {
+	var (
+		ctx        context.Context
+		fakeClient client.Client
+		request    ctrl.Request
+	)
+
+	scaledObjectGVK := schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "ScaledObject"}
+	workerKey := types.NamespacedName{Name: "reports-worker", Namespace: "default"}
+
+	newReconciler := func(kedaCRDs bool, spec aiv1.AgentSpec) *controllers.AgentReconciler {
+		workerScheme := newScheme()
+
+		mapper := meta.NewDefaultRESTMapper([]schema.GroupVersion{{Group: "keda.sh", Version: "v1alpha1"}})
+		if kedaCRDs {
+			mapper.Add(scaledObjectGVK, meta.RESTScopeNamespace)
+			mapper.Add(schema.GroupVersionKind{Group: "keda.sh", Version: "v1alpha1", Kind: "TriggerAuthentication"}, meta.RESTScopeNamespace)
+		}
+		for gvk := range workerScheme.AllKnownTypes() {
+			mapper.Add(gvk, meta.RESTScopeNamespace)
+		}
+
+		spec.Provider = "vllm"
+		spec.Model = "llama-3-8b"
+		spec.SystemPrompt = "You are a helpful AI assistant."
+		spec.Endpoint = "http://vllm.default.svc:8000/v1"
+		fakeClient = fake.NewClientBuilder().
+			WithScheme(workerScheme).
+			WithRESTMapper(mapper).
+			WithStatusSubresource(&aiv1.Agent{}, &appsv1.Deployment{}).
+			WithObjects(
+				&aiv1.Agent{ObjectMeta: metav1.ObjectMeta{Name: "reports", Namespace: "default"}, Spec: spec},
+				&corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: "jobs-redis", Namespace: "default"},
+					Data:       map[string][]byte{"address": []byte("10.0.0.20:6380"), "password": []byte("s3cret")},
+				},
+			).
+			Build()
+		request = ctrl.Request{NamespacedName: types.NamespacedName{Name: "reports", Namespace: "default"}}
+		return &controllers.AgentReconciler{Client: fakeClient, Scheme: workerScheme}
+	}
+
+	reconcile := func(reconciler *controllers.AgentReconciler) *aiv1.Agent {
+		return reconcileAgent(ctx, reconciler, request)
+	}
+
+	containerEnv := func(deployment *appsv1.Deployment) map[string]corev1.EnvVar {
+		env := map[string]corev1.EnvVar{}
+		for _, e := range deployment.Spec.Template.Spec.Containers[0].Env {
+			env[e.Name] = e
+		}
+		return env
+	}
+
+	configValid := func(agent *aiv1.Agent) *aiv1.AgentCondition {
+		for i := range agent.Status.Conditions {
+			if agent.Status.Conditions[i].Type == aiv1.AgentConditionConfigValid {
+				return &agent.Status.Conditions[i]
+			}
+		}
+		return nil
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+	})
+
+	It("Should run the workers next to the agent Deployment on the managed Redis", func() {
+		apiReplicas, workers := int32(3), int32(2)
+		agent := reconcile(newReconciler(false, aiv1.AgentSpec{
+			Replicas: &apiReplicas,
+			WorkerMode: &aiv1.WorkerModeConfig{
+				Enabled:        true,
+				WorkerReplicas: &workers,
+				MaxJobDuration: &metav1.Duration{Duration: time.Hour},
+			},
+		}))
+
+		api := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, api)).Should(Succeed())
+		Expect(*api.Spec.Replicas).Should(Equal(int32(3)))
+		apiEnv := containerEnv(api)
+		Expect(apiEnv).ShouldNot(HaveKey("AGENT_MODE"))
+		Expect(apiEnv["AGENT_QUEUE_ADDRESS"].Value).Should(Equal("reports-redis.default.svc:6379"))
+		Expect(apiEnv["AGENT_QUEUE_DB"].Value).Should(Equal("1"))
+		Expect(apiEnv["AGENT_QUEUE_NAME"].Value).Should(Equal("kubeagentic:default:reports:jobs"))
+
+		worker := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, workerKey, worker)).Should(Succeed())
+		Expect(*worker.Spec.Replicas).Should(Equal(int32(2)))
+		Expect(worker.Spec.Selector.MatchLabels).Should(HaveKeyWithValue("app.kubernetes.io/name", "kubeagentic-worker"))
+		Expect(worker.Spec.Template.Labels).Should(HaveKeyWithValue("app.kubernetes.io/name", "kubeagentic-worker"))
+		Expect(worker.OwnerReferences).Should(HaveLen(1))
+		Expect(worker.Spec.Template.Spec.Containers).Should(HaveLen(1))
+		Expect(worker.Spec.Template.Spec.Containers[0].Image).Should(Equal(api.Spec.Template.Spec.Containers[0].Image))
+		workerEnv := containerEnv(worker)
+		Expect(workerEnv["AGENT_MODE"].Value).Should(Equal("worker"))
+		Expect(workerEnv["AGENT_MAX_JOB_DURATION"].Value).Should(Equal("3600"))
+		Expect(workerEnv["AGENT_QUEUE_ADDRESS"]).Should(Equal(apiEnv["AGENT_QUEUE_ADDRESS"]))
+
+		By("Deploying the managed Redis holding the queue")
+		redis := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "reports-redis", Namespace: "default"}, redis)).Should(Succeed())
+
+		By("Reporting the replicas of both Deployments")
+		Expect(agent.Status.WorkerMode).ShouldNot(BeNil())
+		Expect(agent.Status.WorkerMode.APIReplicas.Desired).Should(Equal(int32(3)))
+		Expect(agent.Status.WorkerMode.WorkerReplicas.Desired).Should(Equal(int32(2)))
+	})
+
+	It("Should keep spec.replicas and the HPA to the agent Deployment", func() {
+		apiReplicas := int32(1)
+		reconciler := newReconciler(false, aiv1.AgentSpec{
+			Replicas:   &apiReplicas,
+			WorkerMode: &aiv1.WorkerModeConfig{Enabled: true},
+		})
+		reconcile(reconciler)
+
+		worker := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, workerKey, worker)).Should(Succeed())
+		Expect(*worker.Spec.Replicas).Should(Equal(int32(1)))
+		hpa := &autoscalingv2.HorizontalPodAutoscaler{}
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "reports-hpa", Namespace: "default"}, hpa))).Should(BeTrue())
+
+		By("Scaling the agent Deployment to several replicas")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		apiReplicas = 4
+		agent.Spec.Replicas = &apiReplicas
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		reconcile(reconciler)
+
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "reports-hpa", Namespace: "default"}, hpa)).Should(Succeed())
+		Expect(hpa.Spec.ScaleTargetRef.Name).Should(Equal("reports"))
+		Expect(fakeClient.Get(ctx, workerKey, worker)).Should(Succeed())
+		Expect(*worker.Spec.Replicas).Should(Equal(int32(1)))
+
+		By("Rejecting an agent Deployment scaled to zero")
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		apiReplicas = 0
+		agent.Spec.Replicas = &apiReplicas
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		condition := configValid(reconcile(reconciler))
+		Expect(condition.Status).Should(Equal(corev1.ConditionFalse))
+	})
+
+	It("Should use an external queue and allow egress to it", func() {
+		reconcile(newReconciler(false, aiv1.AgentSpec{
+			WorkerMode: &aiv1.WorkerModeConfig{
+				Enabled:        true,
+				Queue:          "external",
+				QueueSecretRef: &corev1.LocalObjectReference{Name: "jobs-redis"},
+			},
+			NetworkPolicy: &aiv1.NetworkPolicyConfig{Enabled: true},
+		}))
+
+		worker := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, workerKey, worker)).Should(Succeed())
+		env := containerEnv(worker)
+		Expect(env["AGENT_QUEUE_ADDRESS"].ValueFrom.SecretKeyRef.Name).Should(Equal("jobs-redis"))
+		Expect(env["AGENT_QUEUE_ADDRESS"].ValueFrom.SecretKeyRef.Key).Should(Equal("address"))
+		Expect(env["AGENT_QUEUE_PASSWORD"].ValueFrom.SecretKeyRef.Key).Should(Equal("password"))
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "reports-redis", Namespace: "default"}, &appsv1.Deployment{}))).Should(BeTrue())
+
+		policy := &networkingv1.NetworkPolicy{}
+		Expect(fakeClient.Get(ctx, types.NamespacedName{Name: "reports-network-policy", Namespace: "default"}, policy)).Should(Succeed())
+		Expect(policy.Spec.PodSelector.MatchExpressions).Should(ConsistOf(metav1.LabelSelectorRequirement{
+			Key:      "app.kubernetes.io/name",
+			Operator: metav1.LabelSelectorOpIn,
+			Values:   []string{"kubeagentic-agent", "kubeagentic-worker"},
+		}))
+		var cidrs []string
+		for _, rule := range policy.Spec.Egress {
+			for _, peer := range rule.To {
+				if peer.IPBlock != nil && rule.Ports[0].Port.IntValue() == 6380 {
+					cidrs = append(cidrs, peer.IPBlock.CIDR)
+				}
+			}
+		}
+		Expect(cidrs).Should(ConsistOf("10.0.0.20/32"))
+	})
+
+	It("Should scale the workers on the queue length through KEDA", func() {
+		minReplicas := int32(1)
+		reconciler := newReconciler(true, aiv1.AgentSpec{
+			WorkerMode: &aiv1.WorkerModeConfig{
+				Enabled:     true,
+				Autoscaling: &aiv1.WorkerAutoscaling{Enabled: true, MinReplicas: &minReplicas},
+			},
+		})
+		reconcile(reconciler)
+
+		scaledObject := &unstructured.Unstructured{}
+		scaledObject.SetGroupVersionKind(scaledObjectGVK)
+		Expect(fakeClient.Get(ctx, workerKey, scaledObject)).Should(Succeed())
+		Expect(scaledObject.Object["spec"]).Should(And(
+			HaveKeyWithValue("scaleTargetRef", map[string]interface{}{"name": "reports-worker"}),
+			HaveKeyWithValue("minReplicaCount", BeEquivalentTo(1)),
+			HaveKeyWithValue("maxReplicaCount", BeEquivalentTo(10)),
+		))
+		triggers, _, _ := unstructured.NestedSlice(scaledObject.Object, "spec", "triggers")
+		Expect(triggers).Should(ConsistOf(map[string]interface{}{
+			"type": "redis",
+			"metadata": map[string]interface{}{
+				"address":       "reports-redis.default.svc:6379",
+				"databaseIndex": "1",
+				"listName":      "kubeagentic:default:reports:jobs",
+				"listLength":    "5",
+			},
+		}))
+
+		By("Keeping the workers set by KEDA")
+		worker := &appsv1.Deployment{}
+		Expect(fakeClient.Get(ctx, workerKey, worker)).Should(Succeed())
+		replicas := int32(6)
+		worker.Spec.Replicas = &replicas
+		Expect(fakeClient.Update(ctx, worker)).Should(Succeed())
+		reconcile(reconciler)
+		Expect(fakeClient.Get(ctx, workerKey, worker)).Should(Succeed())
+		Expect(*worker.Spec.Replicas).Should(Equal(int32(6)))
+
+		By("Disabling worker mode")
+		agent := &aiv1.Agent{}
+		Expect(fakeClient.Get(ctx, request.NamespacedName, agent)).Should(Succeed())
+		agent.Spec.WorkerMode.Enabled = false
+		Expect(fakeClient.Update(ctx, agent)).Should(Succeed())
+		agent = reconcile(reconciler)
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, workerKey, &appsv1.Deployment{}))).Should(BeTrue())
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, workerKey, scaledObject))).Should(BeTrue())
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, types.NamespacedName{Name: "reports-redis", Namespace: "default"}, &appsv1.Deployment{}))).Should(BeTrue())
+		Expect(agent.Status.WorkerMode).Should(BeNil())
+		Expect(containerEnv(func() *appsv1.Deployment {
+			api := &appsv1.Deployment{}
+			Expect(fakeClient.Get(ctx, request.NamespacedName, api)).Should(Succeed())
+			return api
+		}())).ShouldNot(HaveKey("AGENT_QUEUE_ADDRESS"))
+	})
+
+	It("Should reject invalid worker configurations", func() {
+		By("Requiring KEDA to autoscale the workers")
+		condition := configValid(reconcile(newReconciler(false, aiv1.AgentSpec{
+			WorkerMode: &aiv1.WorkerModeConfig{Enabled: true, Autoscaling: &aiv1.WorkerAutoscaling{Enabled: true}},
+		})))
+		Expect(condition.Reason).Should(Equal("InvalidWorkerModeConfig"))
+		Expect(condition.Message).Should(ContainSubstring("KEDA"))
+
+		By("Requiring the Secret of an external queue")
+		condition = configValid(reconcile(newReconciler(false, aiv1.AgentSpec{
+			WorkerMode: &aiv1.WorkerModeConfig{Enabled: true, Queue: "external"},
+		})))
+		Expect(condition.Reason).Should(Equal("InvalidWorkerModeConfig"))
+		Expect(condition.Message).Should(ContainSubstring("queueSecretRef"))
+
+		By("Rejecting an event source")
+		condition = configValid(reconcile(newReconciler(false, aiv1.AgentSpec{
+			WorkerMode: &aiv1.WorkerModeConfig{Enabled: true},
+			EventSource: &aiv1.EventSourceConfig{
+				Type:  "kafka",
+				Kafka: &aiv1.KafkaEventSource{Brokers: []string{"10.0.0.9:9092"}, Topic: "reports"},
+			},
+		})))
+		Expect(condition.Reason).Should(Equal("InvalidWorkerModeConfig"))
+		Expect(errors.IsNotFound(fakeClient.Get(ctx, workerKey, &appsv1.Deployment{}))).Should(BeTrue())
+	})
+})